@@ -11,6 +11,7 @@ import (
 
 const dialTimeout = 10 * time.Second
 const fastReqTimeout = 30 * time.Second
+const healthCheckTimeout = 5 * time.Second
 const slowReqTimeout = 5 * time.Minute
 
 type Api struct{}
@@ -30,6 +31,11 @@ func init() {
 	}
 }
 
+// GetApiHost is the server the current auth points at
+func GetApiHost() string {
+	return getApiHost()
+}
+
 func getApiHost() string {
 	if auth.Current == nil {
 		return ""
@@ -44,10 +50,16 @@ type authenticatedTransport struct {
 	underlyingTransport http.RoundTripper
 }
 
-// RoundTrip executes a single HTTP transaction and adds a custom header
+// RoundTrip executes a single HTTP transaction, adding the auth header and recording the upload encodings the server accepts
 func (t *authenticatedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	auth.SetAuthHeader(req)
-	return t.underlyingTransport.RoundTrip(req)
+
+	resp, err := t.underlyingTransport.RoundTrip(req)
+	if err == nil && resp.Header.Get("Accept-Encoding") != "" {
+		recordServerEncodings(resp.Header.Get("Accept-Encoding"))
+	}
+
+	return resp, err
 }
 
 var netDialer = &net.Dialer{
@@ -61,6 +73,14 @@ var unauthenticatedClient = &http.Client{
 	Timeout: fastReqTimeout,
 }
 
+// healthCheckClient fails fast so an unreachable server is noticed before a prompt is written
+var healthCheckClient = &http.Client{
+	Transport: &http.Transport{
+		Dial: netDialer.Dial,
+	},
+	Timeout: healthCheckTimeout,
+}
+
 var authenticatedFastClient = &http.Client{
 	Transport: &authenticatedTransport{
 		underlyingTransport: &http.Transport{
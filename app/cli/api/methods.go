@@ -7,8 +7,11 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"plandex/types"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/plandex/plandex/shared"
 )
@@ -38,6 +41,36 @@ func (a *Api) StartTrial() (*shared.StartTrialResponse, *shared.ApiError) {
 	return &startTrialResponse, nil
 }
 
+func (a *Api) StartGuestTrial(req shared.StartGuestTrialRequest, host string) (*shared.StartTrialResponse, *shared.ApiError) {
+	serverUrl := host + "/accounts/start_guest_trial"
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := unauthenticatedClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		return nil, apiErr
+	}
+
+	var startTrialResponse shared.StartTrialResponse
+	err = json.NewDecoder(resp.Body).Decode(&startTrialResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &startTrialResponse, nil
+}
+
 func (a *Api) CreateProject(req shared.CreateProjectRequest) (*shared.CreateProjectResponse, *shared.ApiError) {
 	serverUrl := getApiHost() + "/projects"
 
@@ -163,12 +196,39 @@ func (a *Api) RenameProject(projectId string, req shared.RenameProjectRequest) *
 	return nil
 }
 func (a *Api) ListPlans(projectIds []string) ([]*shared.Plan, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans?", getApiHost())
-	parts := []string{}
+	return a.FilterPlans(projectIds, shared.ListPlansFilter{})
+}
+
+func (a *Api) FilterPlans(projectIds []string, filter shared.ListPlansFilter) ([]*shared.Plan, *shared.ApiError) {
+	params := url.Values{}
 	for _, projectId := range projectIds {
-		parts = append(parts, fmt.Sprintf("projectId=%s", projectId))
+		params.Add("projectId", projectId)
 	}
-	serverUrl += strings.Join(parts, "&")
+	for _, tag := range filter.Tags {
+		params.Add("tag", tag)
+	}
+	for _, status := range filter.Statuses {
+		params.Add("status", string(status))
+	}
+	if filter.UpdatedAfter != nil {
+		params.Set("updatedAfter", filter.UpdatedAfter.Format(time.RFC3339))
+	}
+	if filter.UpdatedBefore != nil {
+		params.Set("updatedBefore", filter.UpdatedBefore.Format(time.RFC3339))
+	}
+	if filter.AuthorId != "" {
+		params.Set("author", filter.AuthorId)
+	}
+	if filter.Archived {
+		params.Set("archived", "true")
+	}
+	if filter.SortBy != "" {
+		params.Set("sort", string(filter.SortBy))
+	}
+	if filter.Asc {
+		params.Set("asc", "true")
+	}
+	serverUrl := fmt.Sprintf("%s/plans?%s", getApiHost(), params.Encode())
 
 	resp, err := authenticatedFastClient.Get(serverUrl)
 	if err != nil {
@@ -183,7 +243,7 @@ func (a *Api) ListPlans(projectIds []string) ([]*shared.Plan, *shared.ApiError)
 
 		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
 		if didRefresh {
-			return a.ListPlans(projectIds)
+			return a.FilterPlans(projectIds, filter)
 		}
 		return nil, apiErr
 	}
@@ -197,6 +257,44 @@ func (a *Api) ListPlans(projectIds []string) ([]*shared.Plan, *shared.ApiError)
 	return plans, nil
 }
 
+func (a *Api) SearchPlans(query, projectId string, limit int) (*shared.SearchResponse, *shared.ApiError) {
+	params := url.Values{}
+	params.Set("q", query)
+	if projectId != "" {
+		params.Set("projectId", projectId)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	serverUrl := fmt.Sprintf("%s/plans/search?%s", getApiHost(), params.Encode())
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.SearchPlans(query, projectId, limit)
+		}
+		return nil, apiErr
+	}
+
+	var searchResponse shared.SearchResponse
+	err = json.NewDecoder(resp.Body).Decode(&searchResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &searchResponse, nil
+}
+
 func (a *Api) ListArchivedPlans(projectIds []string) ([]*shared.Plan, *shared.ApiError) {
 	serverUrl := fmt.Sprintf("%s/plans/archive?", getApiHost())
 	parts := []string{}
@@ -451,7 +549,7 @@ func (a *Api) TellPlan(planId, branch string, req shared.TellPlanRequest, onStre
 
 	resp, err := client.Do(request)
 	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeServerUnreachable, Msg: fmt.Sprintf("error sending request: %v", err)}
 	}
 
 	if resp.StatusCode >= 400 {
@@ -565,6 +663,76 @@ func (a *Api) RespondMissingFile(planId, branch string, req shared.RespondMissin
 
 }
 
+func (a *Api) RespondContinue(planId, branch string, req shared.RespondContinueRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/respond_continue", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodPost, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+
+		if didRefresh {
+			return a.RespondContinue(planId, branch, req)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) SteerPlan(planId, branch string, req shared.SteerPlanRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/steer", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodPost, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+
+		if didRefresh {
+			return a.SteerPlan(planId, branch, req)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
 func (a *Api) ConnectPlan(planId, branch string, onStream types.OnStreamPlan) *shared.ApiError {
 	serverUrl := fmt.Sprintf("%s/plans/%s/%s/connect", getApiHost(), planId, branch)
 
@@ -623,6 +791,60 @@ func (a *Api) StopPlan(planId, branch string) *shared.ApiError {
 	return nil
 }
 
+func (a *Api) PauseBuild(planId, branch string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/pause_build", getApiHost(), planId, branch)
+
+	req, err := http.NewRequest(http.MethodPatch, serverUrl, nil)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.PauseBuild(planId, branch)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) ResumeBuild(planId, branch string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/resume_build", getApiHost(), planId, branch)
+
+	req, err := http.NewRequest(http.MethodPatch, serverUrl, nil)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.ResumeBuild(planId, branch)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
 func (a *Api) GetCurrentPlanState(planId, branch string) (*shared.CurrentPlanState, *shared.ApiError) {
 	serverUrl := fmt.Sprintf("%s/plans/%s/%s/current_plan", getApiHost(), planId, branch)
 
@@ -786,221 +1008,1923 @@ func (a *Api) RenamePlan(planId string, name string) *shared.ApiError {
 	return nil
 }
 
-func (a *Api) RejectAllChanges(planId, branch string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/reject_all", getApiHost(), planId, branch)
+func (a *Api) AutoRenamePlan(planId, branch string, req shared.AutoRenamePlanRequest) (*shared.AutoRenamePlanResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/auto-rename", getApiHost(), planId, branch)
 
-	req, err := http.NewRequest(http.MethodPatch, serverUrl, nil)
+	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
 	}
 
-	resp, err := authenticatedFastClient.Do(req)
+	resp, err := authenticatedSlowClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
 	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
+
 		apiErr := handleApiError(resp, errorBody)
 
 		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
 		if didRefresh {
-			return a.RejectAllChanges(planId, branch)
+			return a.AutoRenamePlan(planId, branch, req)
 		}
-		return apiErr
+		return nil, apiErr
 	}
 
-	return nil
-}
+	var res shared.AutoRenamePlanResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
 
-func (a *Api) RejectFile(planId, branch, filePath string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/reject_file", getApiHost(), planId, branch)
+	return &res, nil
+}
 
-	reqBytes, err := json.Marshal(shared.RejectFileRequest{FilePath: filePath})
+func (a *Api) UpdatePlanTags(planId string, req shared.UpdatePlanTagsRequest) ([]string, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/tags", getApiHost(), planId)
 
+	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
 	}
 
-	req, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
+	request, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
+
 	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := authenticatedFastClient.Do(req)
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+
 	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
 	}
+
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
+
 		apiErr := handleApiError(resp, errorBody)
+
 		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
 		if didRefresh {
-			a.RejectFile(planId, branch, filePath)
+			return a.UpdatePlanTags(planId, req)
 		}
-		return apiErr
+		return nil, apiErr
 	}
 
-	return nil
-}
-
-func (a *Api) RejectFiles(planId, branch string, paths []string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/reject_files", getApiHost(), planId, branch)
-
-	reqBytes, err := json.Marshal(shared.RejectFilesRequest{Paths: paths})
-
+	var tags []string
+	err = json.NewDecoder(resp.Body).Decode(&tags)
 	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
 	}
 
-	req, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+	return tags, nil
+}
+
+func (a *Api) GetOrgStats(days int, userId string) (*shared.OrgStatsResponse, *shared.ApiError) {
+	params := url.Values{}
+	if days > 0 {
+		params.Set("days", strconv.Itoa(days))
 	}
-	req.Header.Set("Content-Type", "application/json")
+	if userId != "" {
+		params.Set("userId", userId)
+	}
+	serverUrl := fmt.Sprintf("%s/orgs/stats?%s", getApiHost(), params.Encode())
 
-	resp, err := authenticatedFastClient.Do(req)
+	resp, err := authenticatedFastClient.Get(serverUrl)
 	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
+
 		apiErr := handleApiError(resp, errorBody)
+
 		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
 		if didRefresh {
-			a.RejectFiles(planId, branch, paths)
+			return a.GetOrgStats(days, userId)
 		}
-		return apiErr
+		return nil, apiErr
 	}
 
-	return nil
+	var res shared.OrgStatsResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &res, nil
+}
+
+func (a *Api) ListPlanTags() ([]*shared.PlanTagCount, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/tags", getApiHost())
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.ListPlanTags()
+		}
+		return nil, apiErr
+	}
+
+	var counts []*shared.PlanTagCount
+	err = json.NewDecoder(resp.Body).Decode(&counts)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return counts, nil
+}
+
+func (a *Api) ListPlanShares(planId string) ([]*shared.PlanShare, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/shares", getApiHost(), planId)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.ListPlanShares(planId)
+		}
+		return nil, apiErr
+	}
+
+	var shares []*shared.PlanShare
+	err = json.NewDecoder(resp.Body).Decode(&shares)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return shares, nil
+}
+
+func (a *Api) SharePlan(planId string, req shared.SharePlanRequest) (*shared.PlanShare, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/shares", getApiHost(), planId)
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.SharePlan(planId, req)
+		}
+		return nil, apiErr
+	}
+
+	var share shared.PlanShare
+	err = json.NewDecoder(resp.Body).Decode(&share)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &share, nil
+}
+
+func (a *Api) UnsharePlan(planId, userId string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/shares/%s", getApiHost(), planId, userId)
+
+	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.UnsharePlan(planId, userId)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) ListSharedPlans() ([]*shared.Plan, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/shared", getApiHost())
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.ListSharedPlans()
+		}
+		return nil, apiErr
+	}
+
+	var plans []*shared.Plan
+	err = json.NewDecoder(resp.Body).Decode(&plans)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return plans, nil
+}
+
+func (a *Api) RejectAllChanges(planId, branch string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/reject_all", getApiHost(), planId, branch)
+
+	req, err := http.NewRequest(http.MethodPatch, serverUrl, nil)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.RejectAllChanges(planId, branch)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) GetCommitMsg(planId, branch string, req shared.CommitMsgRequest) (*shared.CommitMsgResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/commit_msg", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedSlowClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.GetCommitMsg(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var res shared.CommitMsgResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &res, nil
+}
+
+func (a *Api) CreateImpactReport(planId, branch string, req shared.ImpactReportRequest) (*shared.ImpactReport, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/impact_report", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedSlowClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.CreateImpactReport(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var report shared.ImpactReport
+	err = json.NewDecoder(resp.Body).Decode(&report)
+	if err != nil {
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &report, nil
+}
+
+func (a *Api) GetImpactReport(planId, branch string) (*shared.ImpactReport, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/impact_report", getApiHost(), planId, branch)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.GetImpactReport(planId, branch)
+		}
+		return nil, apiErr
+	}
+
+	var report shared.ImpactReport
+	err = json.NewDecoder(resp.Body).Decode(&report)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &report, nil
+}
+
+func (a *Api) Bench(planId, branch string, req shared.BenchRequest) (*shared.BenchResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/bench", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedSlowClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.Bench(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var res shared.BenchResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &res, nil
+}
+
+func (a *Api) ListBenchResults(limit int) ([]*shared.BenchResult, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/bench_results?limit=%d", getApiHost(), limit)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListBenchResults(limit)
+		}
+		return nil, apiErr
+	}
+
+	var results []*shared.BenchResult
+	err = json.NewDecoder(resp.Body).Decode(&results)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return results, nil
+}
+
+func (a *Api) RecordApplyHook(planId, branch string, req shared.RecordApplyHookRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/apply_hooks", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(req)
+
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.RecordApplyHook(planId, branch, req)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) RejectFile(planId, branch, filePath string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/reject_file", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(shared.RejectFileRequest{FilePath: filePath})
+
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			a.RejectFile(planId, branch, filePath)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) RejectFiles(planId, branch string, paths []string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/reject_files", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(shared.RejectFilesRequest{Paths: paths})
+
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			a.RejectFiles(planId, branch, paths)
+		}
+		return apiErr
+	}
+
+	return nil
 }
 
 func (a *Api) LoadContext(planId, branch string, req shared.LoadContextRequest) (*shared.LoadContextResponse, *shared.ApiError) {
 	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	// use the upload client since we may be uploading relatively large files
+	resp, cleanup, err := doUpload(http.MethodPost, serverUrl, reqBytes)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer cleanup()
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.LoadContext(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var loadContextResponse shared.LoadContextResponse
+	err = json.NewDecoder(resp.Body).Decode(&loadContextResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &loadContextResponse, nil
+}
+
+func (a *Api) UpdateContext(planId, branch string, req shared.UpdateContextRequest) (*shared.UpdateContextResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	// use the upload client since we may be uploading relatively large files
+	resp, cleanup, err := doUpload(http.MethodPut, serverUrl, reqBytes)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer cleanup()
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.UpdateContext(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var updateContextResponse shared.UpdateContextResponse
+	err = json.NewDecoder(resp.Body).Decode(&updateContextResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &updateContextResponse, nil
+}
+
+func (a *Api) DeleteContext(planId, branch string, req shared.DeleteContextRequest) (*shared.DeleteContextResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodDelete, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.DeleteContext(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var deleteContextResponse shared.DeleteContextResponse
+	err = json.NewDecoder(resp.Body).Decode(&deleteContextResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &deleteContextResponse, nil
+}
+
+func (a *Api) ListContext(planId, branch string) ([]*shared.Context, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListContext(planId, branch)
+		}
+		return nil, apiErr
+	}
+
+	var contexts []*shared.Context
+	err = json.NewDecoder(resp.Body).Decode(&contexts)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return contexts, nil
+}
+
+func (a *Api) ListConvo(planId, branch string) ([]*shared.ConvoMessage, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/convo", getApiHost(), planId, branch)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListConvo(planId, branch)
+		}
+		return nil, apiErr
+	}
+
+	var convos []*shared.ConvoMessage
+	err = json.NewDecoder(resp.Body).Decode(&convos)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return convos, nil
+}
+
+func (a *Api) GetPlanStatus(planId, branch string) (string, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/status", getApiHost(), planId, branch)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return "", &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.GetPlanStatus(planId, branch)
+		}
+		return "", apiErr
+	}
+
+	body, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return "", &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error reading response body: %v", err)}
+	}
+
+	return string(body), nil
+}
+
+func (a *Api) GetPlanDiffs(planId, branch string, plain bool) (string, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/diffs", getApiHost(), planId, branch)
+	if plain {
+		serverUrl += "?plain=true"
+	}
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return "", &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.GetPlanDiffs(planId, branch, plain)
+		}
+		return "", apiErr
+	}
+
+	body, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return "", &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error reading response body: %v", err)}
+	}
+
+	return string(body), nil
+}
+
+func (a *Api) GetPlanProvenance(planId, branch string) (*shared.GetPlanProvenanceResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/provenance", getApiHost(), planId, branch)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.GetPlanProvenance(planId, branch)
+		}
+		return nil, apiErr
+	}
+
+	var res shared.GetPlanProvenanceResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &res, nil
+}
+
+func (a *Api) ListLogs(planId, branch string) (*shared.LogResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/logs", getApiHost(), planId, branch)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListLogs(planId, branch)
+		}
+		return nil, apiErr
+	}
+
+	var logs shared.LogResponse
+	err = json.NewDecoder(resp.Body).Decode(&logs)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &logs, nil
+}
+
+func (a *Api) ListBuilds(planId, branch string) ([]*shared.FileBuild, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/builds", getApiHost(), planId, branch)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListBuilds(planId, branch)
+		}
+		return nil, apiErr
+	}
+
+	var builds []*shared.FileBuild
+	err = json.NewDecoder(resp.Body).Decode(&builds)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return builds, nil
+}
+
+func (a *Api) ListBuildCaptures(planId, path string) ([]*shared.BuildCapture, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/build_captures", getApiHost(), planId)
+	if path != "" {
+		serverUrl += "?path=" + url.QueryEscape(path)
+	}
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListBuildCaptures(planId, path)
+		}
+		return nil, apiErr
+	}
+
+	var captures []*shared.BuildCapture
+	err = json.NewDecoder(resp.Body).Decode(&captures)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return captures, nil
+}
+
+func (a *Api) GetBuildCapture(planId, captureId string) (*shared.BuildCapture, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/build_captures/%s", getApiHost(), planId, captureId)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.GetBuildCapture(planId, captureId)
+		}
+		return nil, apiErr
+	}
+
+	var capture shared.BuildCapture
+	err = json.NewDecoder(resp.Body).Decode(&capture)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &capture, nil
+}
+
+func (a *Api) DeleteBuildCaptures(planId string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/build_captures", getApiHost(), planId)
+
+	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.DeleteBuildCaptures(planId)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) RewindPlan(planId, branch string, req shared.RewindPlanRequest) (*shared.RewindPlanResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/rewind", getApiHost(), planId, branch)
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.RewindPlan(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var rewindPlanResponse shared.RewindPlanResponse
+	err = json.NewDecoder(resp.Body).Decode(&rewindPlanResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &rewindPlanResponse, nil
+}
+
+func (a *Api) EditMessage(planId, branch string, req shared.EditMessageRequest) (*shared.EditMessageResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/convo", getApiHost(), planId, branch)
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.EditMessage(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var editMessageResponse shared.EditMessageResponse
+	err = json.NewDecoder(resp.Body).Decode(&editMessageResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &editMessageResponse, nil
+}
+
+func (a *Api) RerunPlan(planId, branch string) (*shared.RerunPlanResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/rerun", getApiHost(), planId, branch)
+
+	request, err := http.NewRequest(http.MethodPatch, serverUrl, nil)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.RerunPlan(planId, branch)
+		}
+		return nil, apiErr
+	}
+
+	var rerunPlanResponse shared.RerunPlanResponse
+	err = json.NewDecoder(resp.Body).Decode(&rerunPlanResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &rerunPlanResponse, nil
+}
+
+func (a *Api) SignIn(req shared.SignInRequest, customHost string) (*shared.SessionResponse, *shared.ApiError) {
+	host := customHost
+	if host == "" {
+		host = cloudApiHost
+	}
+	serverUrl := host + "/accounts/sign_in"
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := unauthenticatedClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		return nil, apiErr
+	}
+
+	var sessionResponse shared.SessionResponse
+	err = json.NewDecoder(resp.Body).Decode(&sessionResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &sessionResponse, nil
+}
+
+func (a *Api) CreateAccount(req shared.CreateAccountRequest, customHost string) (*shared.SessionResponse, *shared.ApiError) {
+	host := customHost
+	if host == "" {
+		host = cloudApiHost
+	}
+	serverUrl := host + "/accounts"
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := unauthenticatedClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		return nil, apiErr
+	}
+
+	var sessionResponse shared.SessionResponse
+	err = json.NewDecoder(resp.Body).Decode(&sessionResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &sessionResponse, nil
+}
+
+func (a *Api) ConvertTrial(req shared.ConvertTrialRequest) (*shared.SessionResponse, *shared.ApiError) {
+	serverUrl := getApiHost() + "/accounts/convert_trial"
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		return nil, apiErr
+	}
+
+	var sessionResponse shared.SessionResponse
+	err = json.NewDecoder(resp.Body).Decode(&sessionResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &sessionResponse, nil
+}
+
+func (a *Api) CreateOrg(req shared.CreateOrgRequest) (*shared.CreateOrgResponse, *shared.ApiError) {
+	serverUrl := getApiHost() + "/orgs"
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.CreateOrg(req)
+		}
+		return nil, apiErr
+	}
+
+	var createOrgResponse shared.CreateOrgResponse
+	err = json.NewDecoder(resp.Body).Decode(&createOrgResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &createOrgResponse, nil
+}
+
+func (a *Api) GetOrgSession() *shared.ApiError {
+	serverUrl := getApiHost() + "/orgs/session"
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) ListOrgs() ([]*shared.Org, *shared.ApiError) {
+	serverUrl := getApiHost() + "/orgs"
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListOrgs()
+		}
+		return nil, apiErr
+	}
+
+	var orgs []*shared.Org
+	err = json.NewDecoder(resp.Body).Decode(&orgs)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return orgs, nil
+}
+
+func (a *Api) DeleteUser(userId string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/orgs/users/%s", getApiHost(), userId)
+	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.DeleteUser(userId)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) ListOrgRoles() ([]*shared.OrgRole, *shared.ApiError) {
+	serverUrl := getApiHost() + "/orgs/roles"
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListOrgRoles()
+		}
+		return nil, apiErr
+	}
+
+	var roles []*shared.OrgRole
+	err = json.NewDecoder(resp.Body).Decode(&roles)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
+	}
+
+	return roles, nil
+}
+
+func (a *Api) InviteUser(req shared.InviteRequest) (*shared.InviteResponse, *shared.ApiError) {
+	serverUrl := getApiHost() + "/invites"
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.InviteUser(req)
+		}
+		return nil, apiErr
+	}
+
+	var res shared.InviteResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &res, nil
+}
+
+func (a *Api) ResendInvite(inviteId string, req shared.ResendInviteRequest) (*shared.InviteResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/invites/%s/resend", getApiHost(), inviteId)
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ResendInvite(inviteId, req)
+		}
+		return nil, apiErr
+	}
+
+	var res shared.InviteResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &res, nil
+}
+
+func (a *Api) AcceptInvite(req shared.AcceptInviteRequest) (*shared.AcceptInviteResponse, *shared.ApiError) {
+	serverUrl := getApiHost() + "/invites/accept"
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.AcceptInvite(req)
+		}
+		return nil, apiErr
+	}
+
+	var res shared.AcceptInviteResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &res, nil
+}
+
+func (a *Api) ListPendingInvites() ([]*shared.Invite, *shared.ApiError) {
+	serverUrl := getApiHost() + "/invites/pending"
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListPendingInvites()
+		}
+		return nil, apiErr
+	}
+
+	var invites []*shared.Invite
+	err = json.NewDecoder(resp.Body).Decode(&invites)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return invites, nil
+}
+
+func (a *Api) ListAcceptedInvites() ([]*shared.Invite, *shared.ApiError) {
+	serverUrl := getApiHost() + "/invites/accepted"
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListAcceptedInvites()
+		}
+		return nil, apiErr
+	}
+
+	var invites []*shared.Invite
+	err = json.NewDecoder(resp.Body).Decode(&invites)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return invites, nil
+}
+
+func (a *Api) ListAllInvites() ([]*shared.Invite, *shared.ApiError) {
+	serverUrl := getApiHost() + "/invites/all"
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListAllInvites()
+		}
+		return nil, apiErr
+	}
+
+	var invites []*shared.Invite
+	err = json.NewDecoder(resp.Body).Decode(&invites)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return invites, nil
+}
+
+func (a *Api) DeleteInvite(inviteId string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/invites/%s", getApiHost(), inviteId)
+	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.DeleteInvite(inviteId)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) CreateEmailVerification(email, customHost, userId string) (*shared.CreateEmailVerificationResponse, *shared.ApiError) {
+	host := customHost
+	if host == "" {
+		host = cloudApiHost
+	}
+	serverUrl := host + "/accounts/email_verifications"
+	req := shared.CreateEmailVerificationRequest{Email: email, UserId: userId}
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := unauthenticatedClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		return nil, handleApiError(resp, errorBody)
+	}
+
+	var verificationResponse shared.CreateEmailVerificationResponse
+	err = json.NewDecoder(resp.Body).Decode(&verificationResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &verificationResponse, nil
+}
+
+func (a *Api) CheckHealth() *shared.ApiError {
+	serverUrl := getApiHost() + "/health"
+
+	resp, err := healthCheckClient.Get(serverUrl)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeServerUnreachable, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		return handleApiError(resp, errorBody)
+	}
+
+	return nil
+}
+
+func (a *Api) SignOut() *shared.ApiError {
+	serverUrl := getApiHost() + "/accounts/sign_out"
+
+	req, err := http.NewRequest(http.MethodPost, serverUrl, nil)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		return handleApiError(resp, errorBody)
+	}
+
+	return nil
+}
+
+func (a *Api) RotateAuthToken() (*shared.RotateAuthTokenResponse, *shared.ApiError) {
+	serverUrl := getApiHost() + "/accounts/rotate_token"
+
+	req, err := http.NewRequest(http.MethodPost, serverUrl, nil)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		return nil, handleApiError(resp, errorBody)
+	}
+
+	var res shared.RotateAuthTokenResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &res, nil
+}
+
+func (a *Api) ListUsers() (*shared.ListUsersResponse, *shared.ApiError) {
+	serverUrl := getApiHost() + "/users"
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListUsers()
+		}
+		return nil, apiErr
+	}
+
+	var r *shared.ListUsersResponse
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return r, nil
+}
+
+func (a *Api) ListBranches(planId string) ([]*shared.Branch, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/branches", getApiHost(), planId)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListBranches(planId)
+		}
+		return nil, apiErr
+	}
+
+	var branches []*shared.Branch
+	err = json.NewDecoder(resp.Body).Decode(&branches)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
+	}
+
+	return branches, nil
+}
+
+func (a *Api) CreateBranch(planId, branch string, req shared.CreateBranchRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/branches", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.CreateBranch(planId, branch, req)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) DeleteBranch(planId, branch string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/branches/%s", getApiHost(), planId, branch)
+
+	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.DeleteBranch(planId, branch)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) GetSettings(planId, branch string) (*shared.PlanSettings, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/settings", getApiHost(), planId, branch)
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.GetSettings(planId, branch)
+		}
+		return nil, apiErr
+	}
+
+	var settings shared.PlanSettings
+	err = json.NewDecoder(resp.Body).Decode(&settings)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
+	}
+
+	return &settings, nil
+}
+
+func (a *Api) UpdateSettings(planId, branch string, req shared.UpdateSettingsRequest) (*shared.UpdateSettingsResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/settings", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
 	}
 
-	// use the slow client since we may be uploading relatively large files
-	resp, err := authenticatedSlowClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	// log.Println("UpdateSettings", string(reqBytes))
+
+	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
+
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.LoadContext(planId, branch, req)
+			return a.UpdateSettings(planId, branch, req)
 		}
 		return nil, apiErr
 	}
 
-	var loadContextResponse shared.LoadContextResponse
-	err = json.NewDecoder(resp.Body).Decode(&loadContextResponse)
+	var updateRes shared.UpdateSettingsResponse
+	err = json.NewDecoder(resp.Body).Decode(&updateRes)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return &loadContextResponse, nil
+	return &updateRes, nil
+
 }
 
-func (a *Api) UpdateContext(planId, branch string, req shared.UpdateContextRequest) (*shared.UpdateContextResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
+func (a *Api) ValidateSettings(planId, branch string, req shared.ValidateSettingsRequest) (*shared.ValidateSettingsResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/settings/validate", getApiHost(), planId, branch)
 
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
 	}
 
-	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ValidateSettings(planId, branch, req)
+		}
+		return nil, apiErr
+	}
 
+	var validateRes shared.ValidateSettingsResponse
+	err = json.NewDecoder(resp.Body).Decode(&validateRes)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	request.Header.Set("Content-Type", "application/json")
+	return &validateRes, nil
+}
 
-	// use the slow client since we may be uploading relatively large files
-	resp, err := authenticatedSlowClient.Do(request)
+func (a *Api) ValidateOrgDefaultSettings(req shared.ValidateSettingsRequest) (*shared.ValidateSettingsResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/default_settings/validate", getApiHost())
+
+	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
+
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.UpdateContext(planId, branch, req)
+			return a.ValidateOrgDefaultSettings(req)
 		}
 		return nil, apiErr
 	}
 
-	var updateContextResponse shared.UpdateContextResponse
-	err = json.NewDecoder(resp.Body).Decode(&updateContextResponse)
+	var validateRes shared.ValidateSettingsResponse
+	err = json.NewDecoder(resp.Body).Decode(&validateRes)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return &updateContextResponse, nil
+	return &validateRes, nil
 }
 
-func (a *Api) DeleteContext(planId, branch string, req shared.DeleteContextRequest) (*shared.DeleteContextResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
+func (a *Api) GetOrgDefaultSettings() (*shared.PlanSettings, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/default_settings", getApiHost())
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
+
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.GetOrgDefaultSettings()
+		}
+		return nil, apiErr
+	}
+
+	var settings shared.PlanSettings
+	err = json.NewDecoder(resp.Body).Decode(&settings)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
+	}
+
+	return &settings, nil
+}
+
+func (a *Api) UpdateOrgDefaultSettings(req shared.UpdateSettingsRequest) (*shared.UpdateSettingsResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/default_settings", getApiHost())
+
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
 	}
 
-	request, err := http.NewRequest(http.MethodDelete, serverUrl, bytes.NewBuffer(reqBytes))
+	// log.Println("UpdateSettings", string(reqBytes))
+
+	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
 	}
 	request.Header.Set("Content-Type", "application/json")
 
 	resp, err := authenticatedFastClient.Do(request)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
+
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.DeleteContext(planId, branch, req)
+			return a.UpdateOrgDefaultSettings(req)
 		}
 		return nil, apiErr
 	}
 
-	var deleteContextResponse shared.DeleteContextResponse
-	err = json.NewDecoder(resp.Body).Decode(&deleteContextResponse)
+	var updateRes shared.UpdateSettingsResponse
+	err = json.NewDecoder(resp.Body).Decode(&updateRes)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return &deleteContextResponse, nil
+	return &updateRes, nil
+
 }
 
-func (a *Api) ListContext(planId, branch string) ([]*shared.Context, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
+func (a *Api) GetOrgRetentionPolicy() (*shared.OrgRetentionPolicy, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/retention_policy", getApiHost())
 
 	resp, err := authenticatedFastClient.Get(serverUrl)
+
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
@@ -1009,26 +2933,60 @@ func (a *Api) ListContext(planId, branch string) ([]*shared.Context, *shared.Api
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.ListContext(planId, branch)
+			return a.GetOrgRetentionPolicy()
 		}
 		return nil, apiErr
 	}
 
-	var contexts []*shared.Context
-	err = json.NewDecoder(resp.Body).Decode(&contexts)
+	var policy shared.OrgRetentionPolicy
+	err = json.NewDecoder(resp.Body).Decode(&policy)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return contexts, nil
+	return &policy, nil
 }
 
-func (a *Api) ListConvo(planId, branch string) ([]*shared.ConvoMessage, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/convo", getApiHost(), planId, branch)
+func (a *Api) UpdateOrgRetentionPolicy(req shared.UpdateOrgRetentionPolicyRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/orgs/retention_policy", getApiHost())
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.UpdateOrgRetentionPolicy(req)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) GetUserDigestSettings() (*shared.GetUserDigestSettingsResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/users/digest_settings", getApiHost())
 
 	resp, err := authenticatedFastClient.Get(serverUrl)
+
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
@@ -1037,26 +2995,60 @@ func (a *Api) ListConvo(planId, branch string) ([]*shared.ConvoMessage, *shared.
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.ListConvo(planId, branch)
+			return a.GetUserDigestSettings()
 		}
 		return nil, apiErr
 	}
 
-	var convos []*shared.ConvoMessage
-	err = json.NewDecoder(resp.Body).Decode(&convos)
+	var res shared.GetUserDigestSettingsResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return convos, nil
+	return &res, nil
 }
 
-func (a *Api) GetPlanStatus(planId, branch string) (string, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/status", getApiHost(), planId, branch)
+func (a *Api) UpdateUserDigestSettings(req shared.UpdateUserDigestSettingsRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/users/digest_settings", getApiHost())
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.UpdateUserDigestSettings(req)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) GetOrgResponseCache() (*shared.GetOrgResponseCacheResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/response_cache", getApiHost())
 
 	resp, err := authenticatedFastClient.Get(serverUrl)
+
 	if err != nil {
-		return "", &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
@@ -1065,26 +3057,64 @@ func (a *Api) GetPlanStatus(planId, branch string) (string, *shared.ApiError) {
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.GetPlanStatus(planId, branch)
+			return a.GetOrgResponseCache()
 		}
-		return "", apiErr
+		return nil, apiErr
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	var res shared.GetOrgResponseCacheResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
+	}
 
+	return &res, nil
+}
+
+func (a *Api) UpdateOrgResponseCachePolicy(req shared.UpdateOrgResponseCachePolicyRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/orgs/response_cache_policy", getApiHost())
+
+	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return "", &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error reading response body: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.UpdateOrgResponseCachePolicy(req)
+		}
+		return apiErr
 	}
 
-	return string(body), nil
+	return nil
 }
 
-func (a *Api) GetPlanDiffs(planId, branch string) (string, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/diffs", getApiHost(), planId, branch)
+func (a *Api) ClearOrgResponseCache() *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/orgs/response_cache", getApiHost())
 
-	resp, err := authenticatedFastClient.Get(serverUrl)
+	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
 	if err != nil {
-		return "", &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
@@ -1093,28 +3123,22 @@ func (a *Api) GetPlanDiffs(planId, branch string) (string, *shared.ApiError) {
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.GetPlanDiffs(planId, branch)
+			return a.ClearOrgResponseCache()
 		}
-		return "", apiErr
-	}
-
-	body, err := io.ReadAll(resp.Body)
-
-	if err != nil {
-		return "", &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error reading response body: %v", err)}
+		return apiErr
 	}
 
-	return string(body), nil
+	return nil
 }
 
-func (a *Api) ListLogs(planId, branch string) (*shared.LogResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/logs", getApiHost(), planId, branch)
+func (a *Api) GetOrgCodeScanPolicy() (*shared.GetOrgCodeScanPolicyResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/code_scan_policy", getApiHost())
 
 	resp, err := authenticatedFastClient.Get(serverUrl)
+
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
-
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
@@ -1122,38 +3146,38 @@ func (a *Api) ListLogs(planId, branch string) (*shared.LogResponse, *shared.ApiE
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.ListLogs(planId, branch)
+			return a.GetOrgCodeScanPolicy()
 		}
 		return nil, apiErr
 	}
 
-	var logs shared.LogResponse
-	err = json.NewDecoder(resp.Body).Decode(&logs)
+	var res shared.GetOrgCodeScanPolicyResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return &logs, nil
+	return &res, nil
 }
 
-func (a *Api) RewindPlan(planId, branch string, req shared.RewindPlanRequest) (*shared.RewindPlanResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/rewind", getApiHost(), planId, branch)
+func (a *Api) UpdateOrgCodeScanPolicy(req shared.UpdateOrgCodeScanPolicyRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/orgs/code_scan_policy", getApiHost())
+
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
 	}
 
-	request, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
+	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
 	}
 	request.Header.Set("Content-Type", "application/json")
 
 	resp, err := authenticatedFastClient.Do(request)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
-
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
@@ -1161,122 +3185,116 @@ func (a *Api) RewindPlan(planId, branch string, req shared.RewindPlanRequest) (*
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.RewindPlan(planId, branch, req)
+			return a.UpdateOrgCodeScanPolicy(req)
 		}
-		return nil, apiErr
-	}
-
-	var rewindPlanResponse shared.RewindPlanResponse
-	err = json.NewDecoder(resp.Body).Decode(&rewindPlanResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return apiErr
 	}
 
-	return &rewindPlanResponse, nil
+	return nil
 }
 
-func (a *Api) SignIn(req shared.SignInRequest, customHost string) (*shared.SessionResponse, *shared.ApiError) {
-	host := customHost
-	if host == "" {
-		host = cloudApiHost
-	}
-	serverUrl := host + "/accounts/sign_in"
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
+func (a *Api) ListOrgContextBundles() ([]*shared.OrgContextBundle, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/context_bundles", getApiHost())
+
+	resp, err := authenticatedFastClient.Get(serverUrl)
 
-	resp, err := unauthenticatedClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
 		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListOrgContextBundles()
+		}
 		return nil, apiErr
 	}
 
-	var sessionResponse shared.SessionResponse
-	err = json.NewDecoder(resp.Body).Decode(&sessionResponse)
+	var bundles []*shared.OrgContextBundle
+	err = json.NewDecoder(resp.Body).Decode(&bundles)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return &sessionResponse, nil
+	return bundles, nil
 }
 
-func (a *Api) CreateAccount(req shared.CreateAccountRequest, customHost string) (*shared.SessionResponse, *shared.ApiError) {
-	host := customHost
-	if host == "" {
-		host = cloudApiHost
-	}
-	serverUrl := host + "/accounts"
+func (a *Api) UpdateOrgContextBundle(req shared.UpdateOrgContextBundleRequest) (*shared.OrgContextBundle, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/context_bundles/%s", getApiHost(), url.PathEscape(req.Bundle.Name))
+
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
 	}
 
-	resp, err := unauthenticatedClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	// use the upload client since bundles can have relatively large files
+	resp, cleanup, err := doUpload(http.MethodPut, serverUrl, reqBytes)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
+	defer cleanup()
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
 		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.UpdateOrgContextBundle(req)
+		}
 		return nil, apiErr
 	}
 
-	var sessionResponse shared.SessionResponse
-	err = json.NewDecoder(resp.Body).Decode(&sessionResponse)
+	var bundle shared.OrgContextBundle
+	err = json.NewDecoder(resp.Body).Decode(&bundle)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return &sessionResponse, nil
+	return &bundle, nil
 }
 
-func (a *Api) ConvertTrial(req shared.ConvertTrialRequest) (*shared.SessionResponse, *shared.ApiError) {
-	serverUrl := getApiHost() + "/accounts/convert_trial"
-	reqBytes, err := json.Marshal(req)
+func (a *Api) DeleteOrgContextBundle(name string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/orgs/context_bundles/%s", getApiHost(), url.PathEscape(name))
+
+	request, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
 	}
 
-	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	resp, err := authenticatedFastClient.Do(request)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
 		apiErr := handleApiError(resp, errorBody)
-		return nil, apiErr
-	}
-
-	var sessionResponse shared.SessionResponse
-	err = json.NewDecoder(resp.Body).Decode(&sessionResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.DeleteOrgContextBundle(name)
+		}
+		return apiErr
 	}
 
-	return &sessionResponse, nil
+	return nil
 }
 
-func (a *Api) CreateOrg(req shared.CreateOrgRequest) (*shared.CreateOrgResponse, *shared.ApiError) {
-	serverUrl := getApiHost() + "/orgs"
+func (a *Api) SyncContextBundles(planId, branch string, req shared.SyncContextBundlesRequest) (*shared.SyncContextBundlesResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context_bundles/sync", getApiHost(), planId, branch)
+
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
 	}
 
-	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	resp, err := authenticatedSlowClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
@@ -1285,42 +3303,56 @@ func (a *Api) CreateOrg(req shared.CreateOrgRequest) (*shared.CreateOrgResponse,
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.CreateOrg(req)
+			return a.SyncContextBundles(planId, branch, req)
 		}
 		return nil, apiErr
 	}
 
-	var createOrgResponse shared.CreateOrgResponse
-	err = json.NewDecoder(resp.Body).Decode(&createOrgResponse)
+	var res shared.SyncContextBundlesResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return &createOrgResponse, nil
+	return &res, nil
 }
 
-func (a *Api) GetOrgSession() *shared.ApiError {
-	serverUrl := getApiHost() + "/orgs/session"
+func (a *Api) ListKnowledgeEntries() ([]*shared.KnowledgeEntry, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/knowledge", getApiHost())
+
 	resp, err := authenticatedFastClient.Get(serverUrl)
+
 	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
 		apiErr := handleApiError(resp, errorBody)
-		return apiErr
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListKnowledgeEntries()
+		}
+		return nil, apiErr
 	}
 
-	return nil
+	var entries []*shared.KnowledgeEntry
+	err = json.NewDecoder(resp.Body).Decode(&entries)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
+	}
+
+	return entries, nil
 }
 
-func (a *Api) ListOrgs() ([]*shared.Org, *shared.ApiError) {
-	serverUrl := getApiHost() + "/orgs"
+func (a *Api) GetKnowledgeEntry(id string) (*shared.KnowledgeEntry, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/knowledge/%s", getApiHost(), url.PathEscape(id))
+
 	resp, err := authenticatedFastClient.Get(serverUrl)
+
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
@@ -1329,31 +3361,34 @@ func (a *Api) ListOrgs() ([]*shared.Org, *shared.ApiError) {
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.ListOrgs()
+			return a.GetKnowledgeEntry(id)
 		}
 		return nil, apiErr
 	}
 
-	var orgs []*shared.Org
-	err = json.NewDecoder(resp.Body).Decode(&orgs)
+	var entry shared.KnowledgeEntry
+	err = json.NewDecoder(resp.Body).Decode(&entry)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return orgs, nil
+	return &entry, nil
 }
 
-func (a *Api) DeleteUser(userId string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/orgs/users/%s", getApiHost(), userId)
-	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
+func (a *Api) CreateKnowledgeEntry(req shared.CreateKnowledgeEntryRequest) (*shared.KnowledgeEntry, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/knowledge", getApiHost())
+
+	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
 	}
 
-	resp, err := authenticatedFastClient.Do(req)
+	// use the upload client since entries can be whole documents
+	resp, cleanup, err := doUpload(http.MethodPost, serverUrl, reqBytes)
 	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
+	defer cleanup()
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
@@ -1361,17 +3396,29 @@ func (a *Api) DeleteUser(userId string) *shared.ApiError {
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.DeleteUser(userId)
+			return a.CreateKnowledgeEntry(req)
 		}
-		return apiErr
+		return nil, apiErr
 	}
 
-	return nil
+	var entry shared.KnowledgeEntry
+	err = json.NewDecoder(resp.Body).Decode(&entry)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
+	}
+
+	return &entry, nil
 }
 
-func (a *Api) ListOrgRoles() ([]*shared.OrgRole, *shared.ApiError) {
-	serverUrl := getApiHost() + "/orgs/roles"
-	resp, err := authenticatedFastClient.Get(serverUrl)
+func (a *Api) SavePlanKnowledge(planId, branch string, req shared.SavePlanKnowledgeRequest) (*shared.KnowledgeEntry, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/knowledge", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
+	}
+
+	resp, err := authenticatedSlowClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
@@ -1379,44 +3426,43 @@ func (a *Api) ListOrgRoles() ([]*shared.OrgRole, *shared.ApiError) {
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
-
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.ListOrgRoles()
+			return a.SavePlanKnowledge(planId, branch, req)
 		}
 		return nil, apiErr
 	}
 
-	var roles []*shared.OrgRole
-	err = json.NewDecoder(resp.Body).Decode(&roles)
+	var entry shared.KnowledgeEntry
+	err = json.NewDecoder(resp.Body).Decode(&entry)
 	if err != nil {
 		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return roles, nil
+	return &entry, nil
 }
 
-func (a *Api) InviteUser(req shared.InviteRequest) *shared.ApiError {
-	serverUrl := getApiHost() + "/invites"
-	reqBytes, err := json.Marshal(req)
+func (a *Api) DeleteKnowledgeEntry(id string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/orgs/knowledge/%s", getApiHost(), url.PathEscape(id))
+
+	request, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
 	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
 	}
 
-	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	resp, err := authenticatedFastClient.Do(request)
 	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
 		apiErr := handleApiError(resp, errorBody)
-
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.InviteUser(req)
+			return a.DeleteKnowledgeEntry(id)
 		}
 		return apiErr
 	}
@@ -1424,38 +3470,18 @@ func (a *Api) InviteUser(req shared.InviteRequest) *shared.ApiError {
 	return nil
 }
 
-func (a *Api) ListPendingInvites() ([]*shared.Invite, *shared.ApiError) {
-	serverUrl := getApiHost() + "/invites/pending"
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListPendingInvites()
-		}
-		return nil, apiErr
-	}
+func (a *Api) SearchKnowledge(req shared.SearchKnowledgeRequest) (*shared.SearchKnowledgeResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/knowledge/search", getApiHost())
 
-	var invites []*shared.Invite
-	err = json.NewDecoder(resp.Body).Decode(&invites)
+	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
 	}
 
-	return invites, nil
-}
-
-func (a *Api) ListAcceptedInvites() ([]*shared.Invite, *shared.ApiError) {
-	serverUrl := getApiHost() + "/invites/accepted"
-	resp, err := authenticatedFastClient.Get(serverUrl)
+	// the first search after entries are added embeds them, which can take a while
+	resp, err := authenticatedSlowClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
@@ -1464,25 +3490,27 @@ func (a *Api) ListAcceptedInvites() ([]*shared.Invite, *shared.ApiError) {
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.ListAcceptedInvites()
+			return a.SearchKnowledge(req)
 		}
 		return nil, apiErr
 	}
 
-	var invites []*shared.Invite
-	err = json.NewDecoder(resp.Body).Decode(&invites)
+	var res shared.SearchKnowledgeResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return invites, nil
+	return &res, nil
 }
 
-func (a *Api) ListAllInvites() ([]*shared.Invite, *shared.ApiError) {
-	serverUrl := getApiHost() + "/invites/all"
+func (a *Api) GetLearnedPreferences() (*shared.GetLearnedPreferencesResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/learned_preferences", getApiHost())
+
 	resp, err := authenticatedFastClient.Get(serverUrl)
+
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
@@ -1491,40 +3519,43 @@ func (a *Api) ListAllInvites() ([]*shared.Invite, *shared.ApiError) {
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.ListAllInvites()
+			return a.GetLearnedPreferences()
 		}
 		return nil, apiErr
 	}
 
-	var invites []*shared.Invite
-	err = json.NewDecoder(resp.Body).Decode(&invites)
+	var res shared.GetLearnedPreferencesResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return invites, nil
+	return &res, nil
 }
 
-func (a *Api) DeleteInvite(inviteId string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/invites/%s", getApiHost(), inviteId)
-	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
+func (a *Api) ClearLearnedPreferences(org bool) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/orgs/learned_preferences", getApiHost())
+	if org {
+		serverUrl += "?org=true"
+	}
+
+	request, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
 	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
 	}
 
-	resp, err := authenticatedFastClient.Do(req)
+	resp, err := authenticatedFastClient.Do(request)
 	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
 		apiErr := handleApiError(resp, errorBody)
-
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.DeleteInvite(inviteId)
+			return a.ClearLearnedPreferences(org)
 		}
 		return apiErr
 	}
@@ -1532,65 +3563,79 @@ func (a *Api) DeleteInvite(inviteId string) *shared.ApiError {
 	return nil
 }
 
-func (a *Api) CreateEmailVerification(email, customHost, userId string) (*shared.CreateEmailVerificationResponse, *shared.ApiError) {
-	host := customHost
-	if host == "" {
-		host = cloudApiHost
-	}
-	serverUrl := host + "/accounts/email_verifications"
-	req := shared.CreateEmailVerificationRequest{Email: email, UserId: userId}
+// Chat sends a chat and calls onChunk with each part of the reply as it streams. It returns once the reply is finished.
+func (a *Api) Chat(req shared.ChatRequest, onChunk func(string)) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/chat", getApiHost())
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
 	}
 
-	resp, err := unauthenticatedClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	request, err := http.NewRequest(http.MethodPost, serverUrl, bytes.NewBuffer(reqBytes))
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedStreamingClient.Do(request)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeServerUnreachable, Msg: fmt.Sprintf("error sending request: %v", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
-		return nil, handleApiError(resp, errorBody)
-	}
-
-	var verificationResponse shared.CreateEmailVerificationResponse
-	err = json.NewDecoder(resp.Body).Decode(&verificationResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.Chat(req, onChunk)
+		}
+		return apiErr
 	}
 
-	return &verificationResponse, nil
+	return readReplyRespStream(resp.Body, onChunk)
 }
 
-func (a *Api) SignOut() *shared.ApiError {
-	serverUrl := getApiHost() + "/accounts/sign_out"
+// AskPlan sends a question about the plan's context and calls onChunk with each part of the answer as it streams. It returns once the answer is finished.
+func (a *Api) AskPlan(planId, branch string, req shared.AskPlanRequest, onChunk func(string)) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/ask", getApiHost(), planId, branch)
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
 
-	req, err := http.NewRequest(http.MethodPost, serverUrl, nil)
+	request, err := http.NewRequest(http.MethodPost, serverUrl, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
 	}
+	request.Header.Set("Content-Type", "application/json")
 
-	resp, err := authenticatedFastClient.Do(req)
+	resp, err := authenticatedStreamingClient.Do(request)
 	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeServerUnreachable, Msg: fmt.Sprintf("error sending request: %v", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
-		return handleApiError(resp, errorBody)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.AskPlan(planId, branch, req, onChunk)
+		}
+		return apiErr
 	}
 
-	return nil
+	return readReplyRespStream(resp.Body, onChunk)
 }
 
-func (a *Api) ListUsers() (*shared.ListUsersResponse, *shared.ApiError) {
-	serverUrl := getApiHost() + "/users"
+func (a *Api) GetOrgTrustPolicy() (*shared.TrustPolicy, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/trust_policy", getApiHost())
+
 	resp, err := authenticatedFastClient.Get(serverUrl)
+
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
@@ -1599,24 +3644,58 @@ func (a *Api) ListUsers() (*shared.ListUsersResponse, *shared.ApiError) {
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.ListUsers()
+			return a.GetOrgTrustPolicy()
 		}
 		return nil, apiErr
 	}
 
-	var r *shared.ListUsersResponse
-	err = json.NewDecoder(resp.Body).Decode(&r)
+	var policy shared.TrustPolicy
+	err = json.NewDecoder(resp.Body).Decode(&policy)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return r, nil
+	return &policy, nil
 }
 
-func (a *Api) ListBranches(planId string) ([]*shared.Branch, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/branches", getApiHost(), planId)
+func (a *Api) UpdateOrgTrustPolicy(req shared.UpdateTrustPolicyRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/orgs/trust_policy", getApiHost())
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.UpdateOrgTrustPolicy(req)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) GetProjectTrustPolicy(projectId string) (*shared.EffectiveTrustPolicy, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/projects/%s/trust_policy", getApiHost(), projectId)
 
 	resp, err := authenticatedFastClient.Get(serverUrl)
+
 	if err != nil {
 		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
@@ -1624,33 +3703,38 @@ func (a *Api) ListBranches(planId string) ([]*shared.Branch, *shared.ApiError) {
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
-
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.ListBranches(planId)
+			return a.GetProjectTrustPolicy(projectId)
 		}
 		return nil, apiErr
 	}
 
-	var branches []*shared.Branch
-	err = json.NewDecoder(resp.Body).Decode(&branches)
+	var policy shared.EffectiveTrustPolicy
+	err = json.NewDecoder(resp.Body).Decode(&policy)
 	if err != nil {
 		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return branches, nil
+	return &policy, nil
 }
 
-func (a *Api) CreateBranch(planId, branch string, req shared.CreateBranchRequest) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/branches", getApiHost(), planId, branch)
+func (a *Api) UpdateProjectTrustPolicy(projectId string, req shared.UpdateTrustPolicyRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/projects/%s/trust_policy", getApiHost(), projectId)
 
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
 		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
 	}
 
-	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
 	if err != nil {
 		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
@@ -1658,11 +3742,10 @@ func (a *Api) CreateBranch(planId, branch string, req shared.CreateBranchRequest
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
-
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.CreateBranch(planId, branch, req)
+			return a.UpdateProjectTrustPolicy(projectId, req)
 		}
 		return apiErr
 	}
@@ -1670,8 +3753,8 @@ func (a *Api) CreateBranch(planId, branch string, req shared.CreateBranchRequest
 	return nil
 }
 
-func (a *Api) DeleteBranch(planId, branch string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/branches/%s", getApiHost(), planId, branch)
+func (a *Api) DeleteProjectTrustPolicy(projectId string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/projects/%s/trust_policy", getApiHost(), projectId)
 
 	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
 	if err != nil {
@@ -1686,11 +3769,10 @@ func (a *Api) DeleteBranch(planId, branch string) *shared.ApiError {
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
-
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.DeleteBranch(planId, branch)
+			return a.DeleteProjectTrustPolicy(projectId)
 		}
 		return apiErr
 	}
@@ -1698,8 +3780,8 @@ func (a *Api) DeleteBranch(planId, branch string) *shared.ApiError {
 	return nil
 }
 
-func (a *Api) GetSettings(planId, branch string) (*shared.PlanSettings, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/settings", getApiHost(), planId, branch)
+func (a *Api) GetOrgTrialPolicy() (*shared.OrgTrialPolicy, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/trial_policy", getApiHost())
 
 	resp, err := authenticatedFastClient.Get(serverUrl)
 
@@ -1713,30 +3795,28 @@ func (a *Api) GetSettings(planId, branch string) (*shared.PlanSettings, *shared.
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.GetSettings(planId, branch)
+			return a.GetOrgTrialPolicy()
 		}
 		return nil, apiErr
 	}
 
-	var settings shared.PlanSettings
-	err = json.NewDecoder(resp.Body).Decode(&settings)
+	var policy shared.OrgTrialPolicy
+	err = json.NewDecoder(resp.Body).Decode(&policy)
 	if err != nil {
 		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return &settings, nil
+	return &policy, nil
 }
 
-func (a *Api) UpdateSettings(planId, branch string, req shared.UpdateSettingsRequest) (*shared.UpdateSettingsResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/settings", getApiHost(), planId, branch)
+func (a *Api) UpdateOrgTrialPolicy(req shared.UpdateOrgTrialPolicyRequest) (*shared.UpdateOrgTrialPolicyResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/trial_policy", getApiHost())
 
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
 		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
 	}
 
-	// log.Println("UpdateSettings", string(reqBytes))
-
 	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
@@ -1751,27 +3831,25 @@ func (a *Api) UpdateSettings(planId, branch string, req shared.UpdateSettingsReq
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
-
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.UpdateSettings(planId, branch, req)
+			return a.UpdateOrgTrialPolicy(req)
 		}
 		return nil, apiErr
 	}
 
-	var updateRes shared.UpdateSettingsResponse
-	err = json.NewDecoder(resp.Body).Decode(&updateRes)
+	var res shared.UpdateOrgTrialPolicyResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
 	if err != nil {
 		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return &updateRes, nil
-
+	return &res, nil
 }
 
-func (a *Api) GetOrgDefaultSettings() (*shared.PlanSettings, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/default_settings", getApiHost())
+func (a *Api) ListOrgCredentials() ([]*shared.OrgCredential, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/orgs/credentials", getApiHost())
 
 	resp, err := authenticatedFastClient.Get(serverUrl)
 
@@ -1785,61 +3863,72 @@ func (a *Api) GetOrgDefaultSettings() (*shared.PlanSettings, *shared.ApiError) {
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.GetOrgDefaultSettings()
+			return a.ListOrgCredentials()
 		}
 		return nil, apiErr
 	}
 
-	var settings shared.PlanSettings
-	err = json.NewDecoder(resp.Body).Decode(&settings)
+	var credentials []*shared.OrgCredential
+	err = json.NewDecoder(resp.Body).Decode(&credentials)
 	if err != nil {
 		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
 	}
 
-	return &settings, nil
+	return credentials, nil
 }
 
-func (a *Api) UpdateOrgDefaultSettings(req shared.UpdateSettingsRequest) (*shared.UpdateSettingsResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/default_settings", getApiHost())
+func (a *Api) SetOrgCredential(req shared.SetOrgCredentialRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/orgs/credentials", getApiHost())
 
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
-	}
-
-	// log.Println("UpdateSettings", string(reqBytes))
-
-	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
 	}
-	request.Header.Set("Content-Type", "application/json")
 
-	resp, err := authenticatedFastClient.Do(request)
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
-
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.UpdateOrgDefaultSettings(req)
+			return a.SetOrgCredential(req)
 		}
-		return nil, apiErr
+		return apiErr
 	}
 
-	var updateRes shared.UpdateSettingsResponse
-	err = json.NewDecoder(resp.Body).Decode(&updateRes)
+	return nil
+}
+
+func (a *Api) DeleteOrgCredential(name string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/orgs/credentials/%s", getApiHost(), name)
+
+	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
 	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
 	}
 
-	return &updateRes, nil
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.DeleteOrgCredential(name)
+		}
+		return apiErr
+	}
 
+	return nil
 }
 
 func (a *Api) CreateCustomModel(model *shared.AvailableModel) *shared.ApiError {
@@ -1869,6 +3958,40 @@ func (a *Api) CreateCustomModel(model *shared.AvailableModel) *shared.ApiError {
 	return nil
 }
 
+func (a *Api) ProbeModelEndpoint(req shared.ProbeModelEndpointRequest) (*shared.ProbeModelEndpointResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/custom_models/probe", getApiHost())
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
+	}
+
+	// each capability check is a model call, which can be slow for a self-hosted model that's still loading
+	resp, err := authenticatedSlowClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ProbeModelEndpoint(req)
+		}
+		return nil, apiErr
+	}
+
+	var res shared.ProbeModelEndpointResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
+	}
+
+	return &res, nil
+}
+
 func (a *Api) ListCustomModels() ([]*shared.AvailableModel, *shared.ApiError) {
 	serverUrl := fmt.Sprintf("%s/custom_models", getApiHost())
 	resp, err := authenticatedFastClient.Get(serverUrl)
@@ -1982,6 +4105,39 @@ func (a *Api) ListModelPacks() ([]*shared.ModelPack, *shared.ApiError) {
 
 }
 
+func (a *Api) UpdateModelPack(set *shared.ModelPack) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/model_sets/%s", getApiHost(), set.Id)
+	body, err := json.Marshal(set)
+	if err != nil {
+		return &shared.ApiError{Msg: "Failed to marshal model pack"}
+	}
+
+	req, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(body))
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.UpdateModelPack(set)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
 func (a *Api) DeleteModelPack(setId string) *shared.ApiError {
 	serverUrl := fmt.Sprintf("%s/model_sets/%s", getApiHost(), setId)
 
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"plandex/types"
@@ -60,3 +61,30 @@ func readUntilSeparator(reader *bufio.Reader, separator string) (string, error)
 		}
 	}
 }
+
+// readReplyRespStream reads a reply that isn't part of a plan's stream, like a chat, until it's finished
+func readReplyRespStream(body io.Reader, onChunk func(string)) *shared.ApiError {
+	reader := bufio.NewReader(body)
+
+	for {
+		s, err := readUntilSeparator(reader, shared.STREAM_MESSAGE_SEPARATOR)
+		if err != nil {
+			return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error reading reply stream: %v", err)}
+		}
+
+		var msg shared.StreamMessage
+		err = json.Unmarshal([]byte(s), &msg)
+		if err != nil {
+			return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error unmarshalling reply stream message: %v", err)}
+		}
+
+		switch msg.Type {
+		case shared.StreamMessageReply:
+			onChunk(msg.ReplyChunk)
+		case shared.StreamMessageError:
+			return msg.Error
+		case shared.StreamMessageFinished:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// request bodies larger than this are compressed and streamed
+const compressMinBytes = 32 * 1024
+
+// an upload is cancelled if no progress is made for this long
+const uploadStallTimeout = 60 * time.Second
+
+// once the body is fully sent, allow this long for the server to respond
+const uploadResponseTimeout = slowReqTimeout
+
+var authenticatedUploadClient = &http.Client{
+	Transport: &authenticatedTransport{
+		underlyingTransport: &http.Transport{
+			Dial: netDialer.Dial,
+		},
+	},
+	// No global timeout -- uploads are cancelled when they stall instead, so large payloads on slow links don't time out
+}
+
+var uploadProgressFn func(sent, total int64)
+
+// SetUploadProgressFn sets a callback that receives progress for large uploads (bytes of the uncompressed payload sent so far). Pass nil to clear it.
+func SetUploadProgressFn(fn func(sent, total int64)) {
+	uploadProgressFn = fn
+}
+
+var (
+	serverEncodingsMu sync.Mutex
+	// the request encodings the server advertised in its last Accept-Encoding response header, or nil if it hasn't sent one
+	serverEncodings map[string]bool
+)
+
+// recordServerEncodings stores the encodings from a response's Accept-Encoding header (RFC 7694)
+func recordServerEncodings(header string) {
+	encodings := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		encoding, _, _ := strings.Cut(part, ";")
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding != "" {
+			encodings[encoding] = true
+		}
+	}
+
+	serverEncodingsMu.Lock()
+	defer serverEncodingsMu.Unlock()
+	serverEncodings = encodings
+}
+
+// uploadEncoding picks the Content-Encoding for large uploads. Until the server has advertised what it accepts, gzip is used since every server that accepts compressed uploads accepts it.
+func uploadEncoding() string {
+	serverEncodingsMu.Lock()
+	defer serverEncodingsMu.Unlock()
+
+	if serverEncodings == nil {
+		return "gzip"
+	}
+
+	for _, encoding := range []string{"zstd", "gzip"} {
+		if serverEncodings[encoding] {
+			return encoding
+		}
+	}
+
+	return ""
+}
+
+type progressReader struct {
+	r          io.Reader
+	sent       int64
+	total      int64
+	onProgress func(sent, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.onProgress(p.sent, p.total)
+	}
+	return n, err
+}
+
+// doUpload sends a potentially large JSON payload with the upload client. If the server rejects the encoding, it's sent once more with one the server advertised. The returned cleanup function must be called once the response has been read.
+func doUpload(method, url string, body []byte) (*http.Response, func(), error) {
+	encoding := uploadEncoding()
+
+	req, cleanup, err := newUploadRequest(method, url, body, encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := authenticatedUploadClient.Do(req)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnsupportedMediaType && len(body) > compressMinBytes && uploadEncoding() != encoding {
+		resp.Body.Close()
+		cleanup()
+
+		req, cleanup, err = newUploadRequest(method, url, body, uploadEncoding())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		resp, err = authenticatedUploadClient.Do(req)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+	}
+
+	return resp, cleanup, nil
+}
+
+// newUploadRequest creates a request for a potentially large JSON payload. Large payloads are compressed with encoding (unless it's empty) and streamed with chunked encoding. The returned cleanup function must be called once the response has been read.
+func newUploadRequest(method, url string, body []byte, encoding string) (*http.Request, func(), error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := time.AfterFunc(uploadStallTimeout, cancel)
+
+	cleanup := func() {
+		timer.Stop()
+		cancel()
+	}
+
+	total := int64(len(body))
+	progressFn := uploadProgressFn
+
+	var reqBody io.Reader
+	compress := total > compressMinBytes && encoding != ""
+
+	source := &progressReader{
+		r:     bytes.NewReader(body),
+		total: total,
+		onProgress: func(sent, total int64) {
+			if sent >= total {
+				timer.Reset(uploadResponseTimeout)
+			} else {
+				timer.Reset(uploadStallTimeout)
+			}
+			if progressFn != nil {
+				progressFn(sent, total)
+			}
+		},
+	}
+
+	if compress {
+		pr, pw := io.Pipe()
+		go func() {
+			var w io.WriteCloser
+			if encoding == "zstd" {
+				zw, err := zstd.NewWriter(pw, zstd.WithEncoderConcurrency(1))
+				if err != nil {
+					pw.CloseWithError(err)
+					return
+				}
+				w = zw
+			} else {
+				w = gzip.NewWriter(pw)
+			}
+
+			_, err := io.Copy(w, source)
+			if err == nil {
+				err = w.Close()
+			}
+			pw.CloseWithError(err)
+		}()
+		reqBody = pr
+	} else {
+		reqBody = source
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if compress {
+		req.Header.Set("Content-Encoding", encoding)
+		// unknown length -- sent with chunked transfer encoding
+		req.ContentLength = -1
+	} else {
+		req.ContentLength = total
+	}
+
+	return req, cleanup, nil
+}
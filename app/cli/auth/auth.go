@@ -14,6 +14,44 @@ func MustResolveAuthWithOrg() {
 }
 
 func MustResolveAuth(requireOrg bool) {
+	mustResolveAuth(requireOrg, true)
+}
+
+// MustResolveDefaultAuth loads the default org from auth.json without switching to the project's bound org, for commands that manage the binding itself
+func MustResolveDefaultAuth() {
+	mustResolveAuth(false, false)
+}
+
+// MaybeResolveAuth loads the current auth, switched to the project's bound org, without prompting to sign in. Current stays nil if no one is signed in.
+func MaybeResolveAuth() error {
+	bytes, err := os.ReadFile(fs.HomeAuthPath)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error reading auth.json: %v", err)
+	}
+
+	var auth types.ClientAuth
+	err = json.Unmarshal(bytes, &auth)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling auth.json: %v", err)
+	}
+
+	_, err = resolveToken(&auth.ClientAccount)
+
+	if err != nil {
+		return fmt.Errorf("error resolving auth token: %v", err)
+	}
+
+	Current = &auth
+	defaultAuth = &auth
+
+	return resolveProjectOrg(true)
+}
+
+func mustResolveAuth(requireOrg, useProjectOrg bool) {
 	if apiClient == nil {
 		term.OutputErrorAndExit("error resolving auth: api client not set")
 	}
@@ -41,7 +79,28 @@ func MustResolveAuth(requireOrg bool) {
 		term.OutputErrorAndExit("error unmarshalling auth.json: %v", err)
 	}
 
+	migrated, err := resolveToken(&auth.ClientAccount)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error resolving auth token: %v", err)
+	}
+
 	Current = &auth
+	defaultAuth = &auth
+
+	if migrated {
+		err = writeCurrentAuth()
+
+		if err != nil {
+			term.OutputErrorAndExit("Error writing auth: %v", err)
+		}
+	}
+
+	err = resolveProjectOrg(useProjectOrg)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error resolving project org: %v", err)
+	}
 
 	if requireOrg && Current.OrgId == "" {
 		term.StartSpinner("")
@@ -80,6 +139,10 @@ func RefreshInvalidToken() error {
 		return fmt.Errorf("error refreshing token: auth not loaded")
 	}
 
+	if !isUsingDefault() {
+		return refreshBoundToken()
+	}
+
 	hasAccount, pin, err := verifyEmail(Current.Email, Current.Host)
 
 	if err != nil {
@@ -89,12 +152,7 @@ func RefreshInvalidToken() error {
 	if hasAccount {
 		return signIn(Current.Email, pin, Current.Host)
 	} else {
-		host := Current.Host
-		if host == "" {
-			host = "Plandex Cloud"
-		}
-
-		term.OutputErrorAndExit("Account %s not found on %s", Current.Email, host)
+		term.OutputErrorAndExit("Account %s not found on %s", Current.Email, hostLabel(Current.Host))
 	}
 
 	return nil
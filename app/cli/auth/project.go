@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/term"
+	"plandex/types"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+// ProjectOrg is the org and server the current project is bound to, if any
+var ProjectOrg *types.ProjectOrgBinding
+
+// defaultAuth is the account and org from auth.json. It's used everywhere a project isn't bound to an org.
+var defaultAuth *types.ClientAuth
+
+// AccountOrg is an org that one of the signed-in accounts belongs to
+type AccountOrg struct {
+	Account *types.ClientAccount
+	Org     *shared.Org
+}
+
+func (o *AccountOrg) Binding() *types.ProjectOrgBinding {
+	return &types.ProjectOrgBinding{
+		IsCloud: o.Account.IsCloud,
+		Host:    o.Account.Host,
+		OrgId:   o.Org.Id,
+		OrgName: o.Org.Name,
+		Email:   o.Account.Email,
+	}
+}
+
+// CurrentBinding is the org commands are running against right now
+func CurrentBinding() *types.ProjectOrgBinding {
+	return bindingFor(Current)
+}
+
+// DefaultBinding is the default org from auth.json
+func DefaultBinding() *types.ProjectOrgBinding {
+	return bindingFor(defaultAuth)
+}
+
+func bindingFor(auth *types.ClientAuth) *types.ProjectOrgBinding {
+	if auth == nil || auth.OrgId == "" {
+		return nil
+	}
+
+	return &types.ProjectOrgBinding{
+		IsCloud: auth.IsCloud,
+		Host:    auth.Host,
+		OrgId:   auth.OrgId,
+		OrgName: auth.OrgName,
+		Email:   auth.Email,
+	}
+}
+
+func loadProjectOrg() (*types.ProjectOrgBinding, error) {
+	if fs.PlandexDir == "" {
+		return nil, nil
+	}
+
+	bytes, err := os.ReadFile(filepath.Join(fs.PlandexDir, "project.json"))
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading project.json: %v", err)
+	}
+
+	var settings types.CurrentProjectSettings
+	err = json.Unmarshal(bytes, &settings)
+
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling project.json: %v", err)
+	}
+
+	return settings.Org, nil
+}
+
+// resolveProjectOrg loads the project's org binding and, if apply is set, switches Current to the bound org using a signed-in account on the bound server. auth.json isn't changed, so other projects keep using the default org.
+func resolveProjectOrg(apply bool) error {
+	binding, err := loadProjectOrg()
+
+	if err != nil {
+		return err
+	}
+
+	ProjectOrg = binding
+
+	if !apply || binding == nil || Current == nil {
+		return nil
+	}
+
+	return UseBinding(binding)
+}
+
+// UseBinding points Current at a bound org for the rest of the command
+func UseBinding(binding *types.ProjectOrgBinding) error {
+	if binding.MatchesAccount(&Current.ClientAccount) {
+		if Current.OrgId == binding.OrgId {
+			return nil
+		}
+
+		account := Current.ClientAccount
+		Current = &types.ClientAuth{
+			ClientAccount: account,
+			OrgId:         binding.OrgId,
+			OrgName:       binding.OrgName,
+		}
+		return nil
+	}
+
+	accounts, err := loadAccounts()
+
+	if err != nil {
+		return fmt.Errorf("error loading accounts: %v", err)
+	}
+
+	for _, account := range accounts {
+		if binding.MatchesAccount(account) {
+			Current = &types.ClientAuth{
+				ClientAccount: *account,
+				OrgId:         binding.OrgId,
+				OrgName:       binding.OrgName,
+			}
+			return nil
+		}
+	}
+
+	account := "an account"
+	if binding.Email != "" {
+		account = binding.Email
+	}
+
+	return fmt.Errorf("this project is bound to org %s on %s, but %s isn't signed in there\nRun %s to sign in, or %s to use your default org", binding.OrgName, binding.HostLabel(), account, color.New(color.Bold, term.ColorHiCyan).Sprint("plandex sign-in"), color.New(color.Bold, term.ColorHiCyan).Sprint("plandex orgs unbind"))
+}
+
+// isUsingDefault checks whether Current is the auth from auth.json, so refreshing its token can go through the normal sign in flow
+func isUsingDefault() bool {
+	return defaultAuth == nil || (Current.UserId == defaultAuth.UserId && Current.OrgId == defaultAuth.OrgId)
+}
+
+// refreshBoundToken signs in again for a bound project's account, keeping the bound org. auth.json is only updated when the account is also the default one.
+func refreshBoundToken() error {
+	hasAccount, pin, err := verifyEmail(Current.Email, Current.Host)
+
+	if err != nil {
+		return fmt.Errorf("error verifying email: %v", err)
+	}
+
+	if !hasAccount {
+		return fmt.Errorf("account %s not found on %s", Current.Email, hostLabel(Current.Host))
+	}
+
+	term.StartSpinner("")
+	res, apiErr := apiClient.SignIn(shared.SignInRequest{
+		Email: Current.Email,
+		Pin:   pin,
+	}, Current.Host)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		return fmt.Errorf("error signing in: %v", apiErr.Msg)
+	}
+
+	Current.Token = res.Token
+	Current.UserName = res.UserName
+
+	err = storeAccount(&Current.ClientAccount)
+
+	if err != nil {
+		return fmt.Errorf("error storing account: %v", err)
+	}
+
+	if defaultAuth != nil && defaultAuth.UserId == Current.UserId {
+		defaultAuth.Token = res.Token
+		defaultAuth.UserName = res.UserName
+
+		bound := Current
+		Current = defaultAuth
+		err = writeCurrentAuth()
+		Current = bound
+
+		if err != nil {
+			return fmt.Errorf("error writing auth: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// ListAccountOrgs lists the orgs of every signed-in account. Accounts whose orgs can't be listed are returned separately, so one expired session doesn't hide the rest.
+func ListAccountOrgs() ([]*AccountOrg, map[string]string, error) {
+	accounts, err := loadAccounts()
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading accounts: %v", err)
+	}
+
+	current := Current
+	defer func() {
+		Current = current
+	}()
+
+	var res []*AccountOrg
+	errsByEmail := map[string]string{}
+
+	for _, account := range accounts {
+		if account.IsTrial {
+			continue
+		}
+
+		Current = &types.ClientAuth{ClientAccount: *account}
+
+		orgs, apiErr := apiClient.ListOrgs()
+
+		if apiErr != nil {
+			errsByEmail[account.Email] = apiErr.Msg
+			continue
+		}
+
+		for _, org := range orgs {
+			res = append(res, &AccountOrg{Account: account, Org: org})
+		}
+	}
+
+	return res, errsByEmail, nil
+}
+
+// SetDefaultOrg makes an org the default in auth.json. A bound project keeps using its own org.
+func SetDefaultOrg(accountOrg *AccountOrg) error {
+	bound := Current
+
+	err := setAuth(&types.ClientAuth{
+		ClientAccount: *accountOrg.Account,
+		OrgId:         accountOrg.Org.Id,
+		OrgName:       accountOrg.Org.Name,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defaultAuth = Current
+
+	if ProjectOrg != nil {
+		Current = bound
+	}
+
+	return nil
+}
+
+func hostLabel(host string) string {
+	if host == "" {
+		return "Plandex Cloud"
+	}
+	return host
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"fmt"
+	"plandex/term"
+	"plandex/types"
+)
+
+// ListAccounts lists the signed-in accounts, with their tokens loaded from secrets storage
+func ListAccounts() ([]*types.ClientAccount, error) {
+	return loadAccounts()
+}
+
+// RotateToken replaces the current account's auth token. The server revokes the old token, and the new one is stored in place of it.
+func RotateToken() error {
+	if Current == nil {
+		return fmt.Errorf("error rotating token: auth not loaded")
+	}
+
+	term.StartSpinner("")
+	res, apiErr := apiClient.RotateAuthToken()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		return fmt.Errorf("error rotating token: %v", apiErr.Msg)
+	}
+
+	Current.Token = res.Token
+
+	err := storeAccount(&Current.ClientAccount)
+
+	if err != nil {
+		return fmt.Errorf("error storing account: %v", err)
+	}
+
+	if defaultAuth != nil && defaultAuth.UserId == Current.UserId {
+		defaultAuth.Token = res.Token
+
+		current := Current
+		Current = defaultAuth
+		err = writeCurrentAuth()
+		Current = current
+
+		if err != nil {
+			return fmt.Errorf("error writing auth: %v", err)
+		}
+	}
+
+	return nil
+}
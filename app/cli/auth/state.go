@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"plandex/fs"
+	"plandex/secrets"
 	"plandex/types"
 )
 
@@ -29,9 +30,51 @@ func loadAccounts() ([]*types.ClientAccount, error) {
 		return nil, fmt.Errorf("error unmarshalling accounts.json: %v", err)
 	}
 
+	migrated := false
+	for _, account := range accounts {
+		didMigrate, err := resolveToken(account)
+
+		if err != nil {
+			return nil, err
+		}
+
+		migrated = migrated || didMigrate
+	}
+
+	if migrated {
+		err = writeAccounts(accounts)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return accounts, nil
 }
 
+// resolveToken loads an account's token from secrets storage. A token still in plaintext from an older version is moved into secrets storage, and true is returned so the file it came from can be rewritten without it.
+func resolveToken(account *types.ClientAccount) (bool, error) {
+	if account.Token != "" {
+		err := secrets.Set(secrets.AuthTokenName(account.UserId), account.Token)
+
+		if err != nil {
+			return false, fmt.Errorf("error storing auth token: %v", err)
+		}
+
+		return true, nil
+	}
+
+	token, err := secrets.Get(secrets.AuthTokenName(account.UserId))
+
+	if err != nil {
+		return false, fmt.Errorf("error loading auth token: %v", err)
+	}
+
+	account.Token = token
+
+	return false, nil
+}
+
 func setAuth(auth *types.ClientAuth) error {
 	err := storeAccount(&auth.ClientAccount)
 
@@ -70,13 +113,33 @@ func storeAccount(toStore *types.ClientAccount) error {
 		accounts = append(accounts, toStore)
 	}
 
-	bytes, err := json.Marshal(accounts)
+	if toStore.Token != "" {
+		err = secrets.Set(secrets.AuthTokenName(toStore.UserId), toStore.Token)
+
+		if err != nil {
+			return fmt.Errorf("error storing auth token: %v", err)
+		}
+	}
+
+	return writeAccounts(accounts)
+}
+
+func writeAccounts(accounts []*types.ClientAccount) error {
+	// tokens are never written to accounts.json
+	var toWrite []types.ClientAccount
+	for _, account := range accounts {
+		a := *account
+		a.Token = ""
+		toWrite = append(toWrite, a)
+	}
+
+	bytes, err := json.Marshal(toWrite)
 
 	if err != nil {
 		return fmt.Errorf("error marshalling accounts: %v", err)
 	}
 
-	err = os.WriteFile(fs.HomeAccountsPath, bytes, os.ModePerm)
+	err = os.WriteFile(fs.HomeAccountsPath, bytes, 0600)
 
 	if err != nil {
 		return fmt.Errorf("error writing accounts: %v", err)
@@ -90,13 +153,25 @@ func writeCurrentAuth() error {
 		return fmt.Errorf("error writing auth: auth not loaded")
 	}
 
-	bytes, err := json.Marshal(Current)
+	if Current.Token != "" {
+		err := secrets.Set(secrets.AuthTokenName(Current.UserId), Current.Token)
+
+		if err != nil {
+			return fmt.Errorf("error storing auth token: %v", err)
+		}
+	}
+
+	// the token is never written to auth.json
+	toWrite := *Current
+	toWrite.Token = ""
+
+	bytes, err := json.Marshal(toWrite)
 
 	if err != nil {
 		return fmt.Errorf("error marshalling auth: %v", err)
 	}
 
-	err = os.WriteFile(fs.HomeAuthPath, bytes, os.ModePerm)
+	err = os.WriteFile(fs.HomeAuthPath, bytes, 0600)
 
 	if err != nil {
 		return fmt.Errorf("error writing auth: %v", err)
@@ -9,52 +9,86 @@ import (
 )
 
 func ConvertTrial() error {
+	// guests joined a self-hosted org through its trial policy, so they sign up on that server and keep the org
+	isGuest := !Current.IsCloud
+	host := Current.Host
+
 	email, err := term.GetRequiredUserStringInput("Your email:")
 
 	if err != nil {
 		return fmt.Errorf("error prompting email: %v", err)
 	}
 
-	hasAccount, pin, err := verifyEmail(email, "")
+	hasAccount, pin, err := verifyEmail(email, host)
 
 	if err != nil {
 		return fmt.Errorf("error verifying email: %v", err)
 	}
 
-	if hasAccount {
-		term.OutputErrorAndExit("Can't convert a trial into an account that already exists")
+	req := shared.ConvertTrialRequest{
+		Email: email,
+		Pin:   pin,
 	}
 
-	name, err := term.GetUserStringInput("Your name:")
+	if hasAccount {
+		if !isGuest {
+			term.OutputErrorAndExit("Can't convert a trial into an account that already exists")
+		}
 
-	if err != nil {
-		return fmt.Errorf("error prompting name: %v", err)
-	}
+		shouldMove, err := term.ConfirmYesNo(fmt.Sprintf("%s already has an account. Move your trial plans to it?", email))
 
-	orgName, err := term.GetRequiredUserStringInput("Org name:")
+		if err != nil {
+			return fmt.Errorf("error prompting to move plans: %v", err)
+		}
 
-	if err != nil {
-		return fmt.Errorf("error prompting org name: %v", err)
-	}
+		if !shouldMove {
+			return nil
+		}
 
-	autoAddDomainUsers, err := promptAutoAddUsersIfValid(email)
+		req.ExistingAccount = true
+	} else {
+		name, err := term.GetUserStringInput("Your name:")
 
-	if err != nil {
-		return fmt.Errorf("error prompting auto add domain users: %v", err)
+		if err != nil {
+			return fmt.Errorf("error prompting name: %v", err)
+		}
+
+		req.UserName = name
+
+		if !isGuest {
+			orgName, err := term.GetRequiredUserStringInput("Org name:")
+
+			if err != nil {
+				return fmt.Errorf("error prompting org name: %v", err)
+			}
+
+			autoAddDomainUsers, err := promptAutoAddUsersIfValid(email)
+
+			if err != nil {
+				return fmt.Errorf("error prompting auto add domain users: %v", err)
+			}
+
+			req.OrgName = orgName
+			req.OrgAutoAddDomainUsers = autoAddDomainUsers
+		}
 	}
 
+	orgId := Current.OrgId
+
 	term.StartSpinner("")
-	res, apiErr := apiClient.ConvertTrial(shared.ConvertTrialRequest{
-		Email:                 email,
-		Pin:                   pin,
-		UserName:              name,
-		OrgName:               orgName,
-		OrgAutoAddDomainUsers: autoAddDomainUsers,
-	})
+	res, apiErr := apiClient.ConvertTrial(req)
 	term.StopSpinner()
 
 	if apiErr != nil {
-		return fmt.Errorf("error converting trial: %v", apiErr)
+		return fmt.Errorf("error converting trial: %v", apiErr.Msg)
+	}
+
+	org := res.Orgs[0]
+	for _, o := range res.Orgs {
+		if o.Id == orgId {
+			org = o
+			break
+		}
 	}
 
 	err = setAuth(&types.ClientAuth{
@@ -63,11 +97,44 @@ func ConvertTrial() error {
 			UserId:   res.UserId,
 			UserName: res.UserName,
 			Token:    res.Token,
-			IsCloud:  true,
+			IsCloud:  !isGuest,
+			Host:     host,
 			IsTrial:  false,
 		},
-		OrgId:   res.Orgs[0].Id,
-		OrgName: res.Orgs[0].Id,
+		OrgId:   org.Id,
+		OrgName: org.Name,
+	})
+
+	if err != nil {
+		return fmt.Errorf("error setting auth: %v", err)
+	}
+
+	return nil
+}
+
+// StartGuestTrial starts a trial in an org on a self-hosted server with the access code from the org's trial policy
+func StartGuestTrial(host, accessCode string) error {
+	term.StartSpinner("🌟 Starting trial...")
+
+	res, apiErr := apiClient.StartGuestTrial(shared.StartGuestTrialRequest{AccessCode: accessCode}, host)
+
+	term.StopSpinner()
+	if apiErr != nil {
+		return fmt.Errorf("error starting trial: %v", apiErr.Msg)
+	}
+
+	err := setAuth(&types.ClientAuth{
+		ClientAccount: types.ClientAccount{
+			Email:    res.Email,
+			UserId:   res.UserId,
+			UserName: res.UserName,
+			Token:    res.Token,
+			IsTrial:  true,
+			IsCloud:  false,
+			Host:     host,
+		},
+		OrgId:   res.OrgId,
+		OrgName: res.OrgName,
 	})
 
 	if err != nil {
@@ -0,0 +1,37 @@
+package changes_tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var summaryTextColor = lipgloss.Color("#bbb")
+
+// renderFileSummary shows the summaries written for each build of the selected file, so the changes are easy to follow before reading them
+func (m changesUIModel) renderFileSummary() string {
+	if m.selectionInfo == nil || m.width == 0 {
+		return ""
+	}
+
+	var summaries []string
+	for _, res := range m.currentPlan.PlanResult.FileResultsByPath[m.selectionInfo.currentPath] {
+		if res.Summary != "" {
+			summaries = append(summaries, res.Summary)
+		}
+	}
+
+	if len(summaries) == 0 {
+		return ""
+	}
+
+	style := lipgloss.NewStyle().
+		Width(m.width).
+		Padding(0, 1).
+		Foreground(summaryTextColor).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		BorderForeground(borderColor)
+
+	return style.Render(strings.Join(summaries, "\n\n"))
+}
@@ -29,30 +29,38 @@ func (m changesUIModel) View() string {
 
 	tabs := m.renderPathTabs()
 
+	summary := m.renderFileSummary()
+
 	sidebar := m.renderSidebar()
 
 	mainView := m.renderMainView()
 
 	layout := lipgloss.JoinHorizontal(lipgloss.Top, sidebar, mainView)
 
-	view := lipgloss.JoinVertical(lipgloss.Left,
-		tabs,
-		layout,
-		help,
-	)
+	views := []string{tabs}
+	if summary != "" {
+		views = append(views, summary)
+	}
+	views = append(views, layout, help)
+
+	view := lipgloss.JoinVertical(lipgloss.Left, views...)
 
 	return view
 }
 
 func (m changesUIModel) getMainViewDims() (int, int) {
 	tabsHeight := lipgloss.Height(m.renderPathTabs())
+	summaryHeight := 0
+	if summary := m.renderFileSummary(); summary != "" {
+		summaryHeight = lipgloss.Height(summary)
+	}
 	helpHeight := lipgloss.Height(m.renderHelp())
 	sidebarWidth := lipgloss.Width(m.renderSidebar())
 	mainViewHeaderHeight := lipgloss.Height(m.renderMainViewHeader())
 	mainViewFooterHeight := lipgloss.Height(m.renderMainViewFooter())
 
 	mainViewWidth := m.width - sidebarWidth
-	mainViewHeight := m.height - (helpHeight + tabsHeight)
+	mainViewHeight := m.height - (helpHeight + tabsHeight + summaryHeight)
 
 	if m.selectedNewFile() || m.selectedFullFile() {
 		mainViewHeight -= mainViewHeaderHeight
@@ -9,9 +9,18 @@ import (
 )
 
 var autoConfirm bool
+var commitProvenance bool
+var allowFlagged bool
+var regenLockfiles bool
+var applyTarget string
 
 func init() {
 	applyCmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "Automatically confirm unless plan is outdated")
+	applyCmd.Flags().BoolVar(&commitProvenance, "provenance", lib.CommitProvenanceByDefault(), "Add a provenance trailer for each file to the commit message")
+	applyCmd.Flags().BoolVar(&allowFlagged, "allow-flagged", false, "Apply changes flagged by code scanning without confirmation")
+	applyCmd.Flags().BoolVar(&regenLockfiles, "regen-lockfiles", lib.RegenerateLockfilesByDefault(), "Regenerate the lockfiles of updated dependency manifests in an isolated directory")
+
+	applyCmd.Flags().StringVar(&applyTarget, "target", lib.DefaultApplyTarget(), "Apply to a checkout on another machine over ssh, like user@host:/srv/app")
 
 	RootCmd.AddCommand(applyCmd)
 }
@@ -31,5 +40,11 @@ func apply(cmd *cobra.Command, args []string) {
 		term.OutputNoCurrentPlanErrorAndExit()
 	}
 
-	lib.MustApplyPlan(lib.CurrentPlanId, lib.CurrentBranch, autoConfirm)
+	lib.MustApplyPlan(lib.CurrentPlanId, lib.CurrentBranch, lib.ApplyFlags{
+		AutoConfirm:         autoConfirm,
+		WithProvenance:      commitProvenance,
+		AllowFlagged:        allowFlagged,
+		RegenerateLockfiles: regenLockfiles,
+		Target:              applyTarget,
+	})
 }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var askPromptFile string
+
+func init() {
+	RootCmd.AddCommand(askCmd)
+
+	askCmd.Flags().StringVarP(&askPromptFile, "file", "f", "", "File containing the question")
+}
+
+var askCmd = &cobra.Command{
+	Use:   "ask [question]",
+	Short: "Ask a question about the plan's context",
+	Long:  "Ask a question about the current plan's context. The answer cites the files and lines it's based on. It isn't added to the plan's conversation, and it never creates pending changes, so you can explore the code without affecting the plan.",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   ask,
+}
+
+func ask(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	var question string
+	if len(args) > 0 {
+		question = args[0]
+	} else if askPromptFile != "" {
+		bytes, err := os.ReadFile(askPromptFile)
+		if err != nil {
+			term.OutputErrorAndExit("Error reading question file: %v", err)
+		}
+		question = string(bytes)
+	} else {
+		question = getEditorPrompt()
+	}
+
+	question = strings.TrimSpace(question)
+	if question == "" {
+		fmt.Println("🤷‍♂️ No question to ask")
+		return
+	}
+
+	// answers cite line numbers, so they should be from the current files
+	lib.MustCheckOutdatedContext(true, nil)
+
+	apiKeys := lib.MustVerifyApiKeysSilent()
+
+	openAIBase := os.Getenv("OPENAI_API_BASE")
+	if openAIBase == "" {
+		openAIBase = os.Getenv("OPENAI_ENDPOINT")
+	}
+
+	term.StartSpinner("")
+	started := false
+	apiErr := api.Client.AskPlan(lib.CurrentPlanId, lib.CurrentBranch, shared.AskPlanRequest{
+		Question:    question,
+		ApiKeys:     apiKeys,
+		OpenAIBase:  openAIBase,
+		OpenAIOrgId: os.Getenv("OPENAI_ORG_ID"),
+	}, func(chunk string) {
+		if !started {
+			term.StopSpinner()
+			started = true
+		}
+		fmt.Print(chunk)
+	})
+	if !started {
+		term.StopSpinner()
+	}
+	fmt.Println()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error asking question: %v", apiErr.Msg)
+	}
+
+	fmt.Println()
+	term.PrintCmds("", "ask", "load", "tell")
+}
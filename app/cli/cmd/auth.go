@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/secrets"
+	"plandex/term"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authStatusCmd)
+	authCmd.AddCommand(authRotateCmd)
+	authCmd.AddCommand(authSetKeyCmd)
+	authCmd.AddCommand(authRmKeyCmd)
+}
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Show where auth tokens and provider keys are stored",
+	Args:  cobra.NoArgs,
+	Run:   authStatus,
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show where auth tokens and provider keys are stored",
+	Args:  cobra.NoArgs,
+	Run:   authStatus,
+}
+
+var authRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Replace the current account's auth token and re-encrypt the secrets file",
+	Args:  cobra.NoArgs,
+	Run:   authRotate,
+}
+
+var authSetKeyCmd = &cobra.Command{
+	Use:   "set-key <ENV_VAR>",
+	Short: "Store a model provider key, used when its environment variable isn't set",
+	Args:  cobra.ExactArgs(1),
+	Run:   authSetKey,
+}
+
+var authRmKeyCmd = &cobra.Command{
+	Use:   "rm-key <ENV_VAR>",
+	Short: "Remove a stored model provider key",
+	Args:  cobra.ExactArgs(1),
+	Run:   authRmKey,
+}
+
+func authStatus(cmd *cobra.Command, args []string) {
+	accounts, err := auth.ListAccounts()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading accounts: %v", err)
+	}
+
+	stored, err := secrets.List()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading secrets: %v", err)
+	}
+
+	usesPassphrase, err := secrets.FileUsesPassphrase()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading secrets file: %v", err)
+	}
+
+	color.New(color.Bold, term.ColorHiCyan).Println("🔐 Secrets Storage")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.Append([]string{"New secrets stored in", secretsBackendLabel(secrets.Backend())})
+	if usesPassphrase {
+		table.Append([]string{"Secrets file key", "derived from PLANDEX_SECRETS_PASSPHRASE"})
+	}
+	table.Render()
+	fmt.Println()
+
+	color.New(color.Bold, term.ColorHiCyan).Println("👤 Accounts")
+
+	if len(accounts) == 0 {
+		fmt.Println("🤷‍♂️ No accounts signed in")
+	} else {
+		table = tablewriter.NewWriter(os.Stdout)
+		table.SetAutoWrapText(false)
+		table.SetHeader([]string{"Email", "Host", "Token"})
+
+		for _, account := range accounts {
+			host := account.Host
+			if host == "" {
+				host = "Plandex Cloud"
+			}
+
+			token := color.New(term.ColorHiRed).Sprint("missing—sign in again")
+			if account.Token != "" {
+				token = secretsBackendLabel(stored[secrets.AuthTokenName(account.UserId)])
+			}
+
+			table.Append([]string{account.Email, host, token})
+		}
+
+		table.Render()
+	}
+	fmt.Println()
+
+	color.New(color.Bold, term.ColorHiCyan).Println("🔑 Provider Keys")
+
+	envVars, err := secrets.ProviderKeyNames()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading provider keys: %v", err)
+	}
+
+	if len(envVars) == 0 {
+		fmt.Println("🤷‍♂️ No provider keys stored—keys are read from environment variables")
+	} else {
+		table = tablewriter.NewWriter(os.Stdout)
+		table.SetAutoWrapText(false)
+		table.SetHeader([]string{"Env Var", "Stored In", "Used"})
+
+		for _, envVar := range envVars {
+			used := "yes"
+			if os.Getenv(envVar) != "" {
+				used = "no—environment variable is set"
+			}
+
+			table.Append([]string{envVar, secretsBackendLabel(stored[secrets.ProviderKeyName(envVar)]), used})
+		}
+
+		table.Render()
+	}
+	fmt.Println()
+
+	term.PrintCmds("", "auth rotate", "auth set-key", "auth rm-key")
+}
+
+func authRotate(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuth(false)
+
+	err := auth.RotateToken()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error rotating auth token: %v", err)
+	}
+
+	fmt.Printf("✅ Rotated auth token for %s—the previous token no longer works\n", color.New(color.Bold, term.ColorHiCyan).Sprint(auth.Current.Email))
+
+	stored, err := secrets.List()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading secrets: %v", err)
+	}
+
+	usesFile := false
+	for _, backend := range stored {
+		if backend == secrets.BackendFile {
+			usesFile = true
+			break
+		}
+	}
+
+	if usesFile {
+		err = secrets.RotateFileKey()
+
+		if err != nil {
+			term.OutputErrorAndExit("Error re-encrypting secrets file: %v", err)
+		}
+
+		fmt.Println("✅ Re-encrypted secrets file with a new key")
+	}
+}
+
+func authSetKey(cmd *cobra.Command, args []string) {
+	envVar := strings.ToUpper(strings.TrimSpace(args[0]))
+
+	key, err := term.GetUserPasswordInput(fmt.Sprintf("%s:", envVar))
+
+	if err != nil {
+		term.OutputErrorAndExit("Error prompting key: %v", err)
+	}
+
+	key = strings.TrimSpace(key)
+
+	if key == "" {
+		term.OutputErrorAndExit("Key can't be empty")
+	}
+
+	err = secrets.Set(secrets.ProviderKeyName(envVar), key)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error storing key: %v", err)
+	}
+
+	fmt.Printf("✅ Stored %s in %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(envVar), secretsBackendLabel(secrets.Backend()))
+
+	if os.Getenv(envVar) != "" {
+		fmt.Printf("%s is also set in the environment, which takes precedence\n", envVar)
+	}
+}
+
+func authRmKey(cmd *cobra.Command, args []string) {
+	envVar := strings.ToUpper(strings.TrimSpace(args[0]))
+
+	stored, err := secrets.Get(secrets.ProviderKeyName(envVar))
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading key: %v", err)
+	}
+
+	if stored == "" {
+		term.OutputErrorAndExit("No stored key for %s", envVar)
+	}
+
+	err = secrets.Delete(secrets.ProviderKeyName(envVar))
+
+	if err != nil {
+		term.OutputErrorAndExit("Error removing key: %v", err)
+	}
+
+	fmt.Printf("✅ Removed stored %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(envVar))
+}
+
+func secretsBackendLabel(backend string) string {
+	switch backend {
+	case secrets.BackendKeychain:
+		if runtime.GOOS == "darwin" {
+			return "macOS Keychain"
+		}
+		return "system keyring"
+	case secrets.BackendFile:
+		return "encrypted file (" + filepath.Join(fs.HomePlandexDir, "secrets.enc") + ")"
+	}
+	return "not stored"
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var autoContinueCmd = &cobra.Command{
+	Use:   "auto-continue [always|ask|never]",
+	Short: "Show or set whether the plan continues to its next step on its own",
+	Long: `Show or set whether the plan continues to its next step on its own after each reply.
+
+	always: continue automatically (the default)
+	ask: ask before each step while connected to the stream
+	never: stop after each reply, like 'plandex tell --stop'
+	`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: shared.AllAutoContinuePolicies,
+	Run:       autoContinue,
+}
+
+func init() {
+	RootCmd.AddCommand(autoContinueCmd)
+}
+
+func autoContinue(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current settings: %v", apiErr.Msg)
+	}
+
+	if len(args) == 0 {
+		fmt.Printf("⏭  Auto-continue: %s\n", settings.GetAutoContinuePolicy())
+		fmt.Println()
+		term.PrintCmds("", "auto-continue")
+		return
+	}
+
+	policy := shared.AutoContinuePolicy(strings.ToLower(args[0]))
+	if !policy.Valid() || policy == "" {
+		term.OutputErrorAndExit("Auto-continue must be one of: %s", strings.Join(shared.AllAutoContinuePolicies, ", "))
+	}
+
+	if settings.GetAutoContinuePolicy() == policy {
+		fmt.Printf("🤷‍♂️ Auto-continue is already %s\n", policy)
+		return
+	}
+
+	settings.AutoContinue = policy
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.UpdateSettings(
+		lib.CurrentPlanId,
+		lib.CurrentBranch,
+		shared.UpdateSettingsRequest{
+			Settings: settings,
+		})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating settings: %v", apiErr.Msg)
+	}
+
+	fmt.Println(res.Msg)
+	fmt.Println()
+	term.PrintCmds("", "tell", "continue")
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/format"
+	"plandex/lib"
+	"plandex/term"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var benchModelPacks []string
+var benchHistoryLimit int
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Compare builder models on the plan's latest changes",
+	Long: `Build the file changes from the plan's latest reply again with each model pack's builder model, and compare how long the builds took, the tokens they used, and how many passed. A build passes when all its changes apply and the result is syntactically valid. Results are stored for your org so you can compare models across benchmarks with 'plandex bench history'.
+
+	plandex bench -m gpt-4o-latest -m anthropic-claude-3.5-sonnet
+	`,
+	Args: cobra.NoArgs,
+	Run:  bench,
+}
+
+var benchHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show your org's benchmark results by model",
+	Args:  cobra.NoArgs,
+	Run:   benchHistory,
+}
+
+func init() {
+	RootCmd.AddCommand(benchCmd)
+	benchCmd.AddCommand(benchHistoryCmd)
+
+	benchCmd.Flags().StringArrayVarP(&benchModelPacks, "model-pack", "m", nil, "Model pack whose builder to benchmark; repeat for each pack (at least 2)")
+	benchHistoryCmd.Flags().IntVarP(&benchHistoryLimit, "limit", "n", 50, "Number of recent results to include")
+}
+
+func bench(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	if len(benchModelPacks) < 2 {
+		term.OutputErrorAndExit("At least 2 model packs are required. Use --model-pack/-m for each one.")
+	}
+
+	modelPacks := resolveModelPacks(benchModelPacks)
+
+	apiKeys := lib.MustVerifyApiKeysForModelPacks(modelPacks)
+
+	openAIBase := os.Getenv("OPENAI_API_BASE")
+	if openAIBase == "" {
+		openAIBase = os.Getenv("OPENAI_ENDPOINT")
+	}
+
+	var names []string
+	for _, modelPack := range modelPacks {
+		names = append(names, modelPack.Name)
+	}
+
+	term.StartSpinner(fmt.Sprintf("⏱️  Benchmarking %d model packs...", len(modelPacks)))
+	res, apiErr := api.Client.Bench(lib.CurrentPlanId, lib.CurrentBranch, shared.BenchRequest{
+		ModelPacks:  names,
+		ApiKeys:     apiKeys,
+		OpenAIBase:  openAIBase,
+		OpenAIOrgId: os.Getenv("OPENAI_ORG_ID"),
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		if apiErr.Msg == shared.NoBenchFilesErr {
+			fmt.Println("🤷‍♂️ The plan doesn't have any file changes to benchmark yet")
+			fmt.Println()
+			term.PrintCmds("", "tell")
+			return
+		}
+		term.OutputErrorAndExit("Error running benchmark: %v", apiErr.Msg)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Model Pack", "Builder", "Passed", "Time", "Time/File", "Tokens In", "Tokens Out"})
+
+	for _, result := range res.Results {
+		if result.Error != "" {
+			table.Append([]string{result.ModelPack, result.BuilderModel, "🚨 " + result.Error, "-", "-", "-", "-"})
+			continue
+		}
+
+		duration := time.Duration(result.DurationMs) * time.Millisecond
+		perFile := duration / time.Duration(max(result.NumFiles, 1))
+
+		table.Append([]string{
+			result.ModelPack,
+			result.BuilderModel,
+			formatBenchPassed(result.NumPassed, result.NumFiles),
+			duration.Round(time.Millisecond * 100).String(),
+			perFile.Round(time.Millisecond * 100).String(),
+			strconv.Itoa(result.PromptTokens),
+			strconv.Itoa(result.CompletionTokens),
+		})
+	}
+
+	table.Render()
+	fmt.Println()
+	term.PrintCmds("", "bench history")
+}
+
+type benchSummary struct {
+	modelPack        string
+	builderModel     string
+	runs             int
+	numFiles         int
+	numPassed        int
+	durationMs       int64
+	promptTokens     int
+	completionTokens int
+	lastRunAt        time.Time
+}
+
+func benchHistory(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	results, apiErr := api.Client.ListBenchResults(benchHistoryLimit)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting benchmark results: %v", apiErr.Msg)
+	}
+
+	summariesByKey := map[string]*benchSummary{}
+	var summaries []*benchSummary
+	for _, result := range results {
+		if result.Error != "" {
+			continue
+		}
+
+		key := result.ModelPack + "|" + result.BuilderModel
+		summary := summariesByKey[key]
+		if summary == nil {
+			summary = &benchSummary{modelPack: result.ModelPack, builderModel: result.BuilderModel}
+			summariesByKey[key] = summary
+			summaries = append(summaries, summary)
+		}
+
+		summary.runs++
+		summary.numFiles += result.NumFiles
+		summary.numPassed += result.NumPassed
+		summary.durationMs += result.DurationMs
+		summary.promptTokens += result.PromptTokens
+		summary.completionTokens += result.CompletionTokens
+		if result.CreatedAt.After(summary.lastRunAt) {
+			summary.lastRunAt = result.CreatedAt
+		}
+	}
+
+	if len(summaries) == 0 {
+		fmt.Println("🤷‍♂️ No benchmark results yet")
+		fmt.Println()
+		term.PrintCmds("", "bench")
+		return
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].numPassed*summaries[j].numFiles > summaries[j].numPassed*summaries[i].numFiles
+	})
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Model Pack", "Builder", "Runs", "Passed", "Time/File", "Tokens/File", "Last Run"})
+
+	for _, summary := range summaries {
+		numFiles := max(summary.numFiles, 1)
+		perFile := time.Duration(summary.durationMs/int64(numFiles)) * time.Millisecond
+
+		table.Append([]string{
+			summary.modelPack,
+			summary.builderModel,
+			strconv.Itoa(summary.runs),
+			formatBenchPassed(summary.numPassed, summary.numFiles),
+			perFile.Round(time.Millisecond * 100).String(),
+			strconv.Itoa((summary.promptTokens + summary.completionTokens) / numFiles),
+			format.Time(summary.lastRunAt),
+		})
+	}
+
+	table.Render()
+}
+
+func formatBenchPassed(numPassed, numFiles int) string {
+	pct := 0
+	if numFiles > 0 {
+		pct = numPassed * 100 / numFiles
+	}
+	return fmt.Sprintf("%d/%d (%d%%)", numPassed, numFiles, pct)
+}
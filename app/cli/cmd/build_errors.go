@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var buildErrorsCmd = &cobra.Command{
+	Use:   "build-errors [abort|skip|pause]",
+	Short: "Show or set what happens when a file fails to build",
+	Long: `Show or set what happens when a file fails to build while other files are still building.
+
+	abort: stop the whole build (the default)
+	skip: skip the failed file and keep building the rest—the failed file stays pending for 'plandex build'
+	pause: pause the build so the problem can be fixed, then build the failed file again with 'plandex resume'
+	`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: shared.AllBuildErrorPolicies,
+	Run:       buildErrors,
+}
+
+func init() {
+	RootCmd.AddCommand(buildErrorsCmd)
+}
+
+func buildErrors(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current settings: %v", apiErr.Msg)
+	}
+
+	if len(args) == 0 {
+		fmt.Printf("🚧 On build error: %s\n", settings.GetBuildErrorPolicy())
+		fmt.Println()
+		term.PrintCmds("", "build-errors")
+		return
+	}
+
+	policy := shared.BuildErrorPolicy(strings.ToLower(args[0]))
+	if !policy.Valid() || policy == "" {
+		term.OutputErrorAndExit("Build error policy must be one of: %s", strings.Join(shared.AllBuildErrorPolicies, ", "))
+	}
+
+	if settings.GetBuildErrorPolicy() == policy {
+		fmt.Printf("🤷‍♂️ Build error policy is already %s\n", policy)
+		return
+	}
+
+	settings.OnBuildError = policy
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.UpdateSettings(
+		lib.CurrentPlanId,
+		lib.CurrentBranch,
+		shared.UpdateSettingsRequest{
+			Settings: settings,
+		})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating settings: %v", apiErr.Msg)
+	}
+
+	fmt.Println(res.Msg)
+	fmt.Println()
+	term.PrintCmds("", "build")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var buildFileLimitCmd = &cobra.Command{
+	Use:   "build-file-limit [tokens|default]",
+	Short: "Show or set the size of the largest file the builder will update",
+	Long: `Show or set the size of the largest file the builder will update, in tokens.
+
+	Files over the limit fail to build with an error that shows their size, instead of failing partway through the model call. What happens next follows 'plandex build-errors'. Split large files into smaller ones, or raise the limit if your builder model has room for them.
+
+	Use 'default' to go back to the default limit of ` + strconv.Itoa(shared.DefaultMaxBuildFileTokens) + ` tokens.
+	`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  buildFileLimit,
+}
+
+func init() {
+	RootCmd.AddCommand(buildFileLimitCmd)
+}
+
+func buildFileLimit(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current settings: %v", apiErr.Msg)
+	}
+
+	if len(args) == 0 {
+		fmt.Printf("📏 Build file limit: %d 🪙\n", settings.GetMaxBuildFileTokens())
+		fmt.Println()
+		term.PrintCmds("", "build-file-limit")
+		return
+	}
+
+	var maxTokens int
+	if strings.ToLower(args[0]) != "default" {
+		var err error
+		maxTokens, err = strconv.Atoi(strings.ReplaceAll(args[0], ",", ""))
+		if err != nil || maxTokens <= 0 {
+			term.OutputErrorAndExit("Build file limit must be a positive number of tokens or 'default'")
+		}
+	}
+
+	if settings.MaxBuildFileTokens == maxTokens {
+		fmt.Printf("🤷‍♂️ Build file limit is already %d\n", settings.GetMaxBuildFileTokens())
+		return
+	}
+
+	settings.MaxBuildFileTokens = maxTokens
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.UpdateSettings(
+		lib.CurrentPlanId,
+		lib.CurrentBranch,
+		shared.UpdateSettingsRequest{
+			Settings: settings,
+		})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating settings: %v", apiErr.Msg)
+	}
+
+	fmt.Println(res.Msg)
+	fmt.Println()
+	term.PrintCmds("", "build")
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var buildStrategyCmd = &cobra.Command{
+	Use:   "build-strategy [standard|speculative]",
+	Short: "Show or set how files are built",
+	Long: `Show or set how files are built.
+
+	standard: build each file with the builder model, then check it with the verifier model (the default)
+	speculative: draft each file with the cheaper draft-builder model—drafts that apply cleanly without adding syntax errors are used as-is, and the rest are built again with the builder model and verified
+	`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: shared.AllBuildStrategies,
+	Run:       buildStrategy,
+}
+
+func init() {
+	RootCmd.AddCommand(buildStrategyCmd)
+}
+
+func buildStrategy(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current settings: %v", apiErr.Msg)
+	}
+
+	if len(args) == 0 {
+		fmt.Printf("🏗️  Build strategy: %s\n", settings.GetBuildStrategy())
+		if settings.GetBuildStrategy() == shared.BuildStrategySpeculative {
+			fmt.Printf("📝 Drafts by %s\n", settings.ModelPack.GetDraftBuilder().BaseModelConfig.ModelName)
+		}
+		fmt.Println()
+		term.PrintCmds("", "build-strategy")
+		return
+	}
+
+	strategy := shared.BuildStrategy(strings.ToLower(args[0]))
+	if !strategy.Valid() || strategy == "" {
+		term.OutputErrorAndExit("Build strategy must be one of: %s", strings.Join(shared.AllBuildStrategies, ", "))
+	}
+
+	if settings.GetBuildStrategy() == strategy {
+		fmt.Printf("🤷‍♂️ Build strategy is already %s\n", strategy)
+		return
+	}
+
+	settings.BuildStrategy = strategy
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.UpdateSettings(
+		lib.CurrentPlanId,
+		lib.CurrentBranch,
+		shared.UpdateSettingsRequest{
+			Settings: settings,
+		})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating settings: %v", apiErr.Msg)
+	}
+
+	fmt.Println(res.Msg)
+	fmt.Println()
+
+	if strategy == shared.BuildStrategySpeculative && settings.ModelPack.DraftBuilder == nil {
+		fmt.Printf("The draft-builder role uses the builder model (%s) until it's set to a cheaper model\n", settings.ModelPack.Builder.BaseModelConfig.ModelName)
+		fmt.Println()
+		term.PrintCmds("", "set-model", "build")
+		return
+	}
+
+	term.PrintCmds("", "build")
+}
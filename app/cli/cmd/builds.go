@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/format"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var buildsCmd = &cobra.Command{
+	Use:   "builds",
+	Short: "List the current branch's file builds",
+	Long:  "List every file build for the current plan and branch: builds waiting in the queue, builds that are running, and builds that have finished, failed, or been stopped, with their retries, tokens, and durations.",
+	Args:  cobra.NoArgs,
+	Run:   listBuilds,
+}
+
+func init() {
+	RootCmd.AddCommand(buildsCmd)
+}
+
+func listBuilds(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	builds, apiErr := api.Client.ListBuilds(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error listing builds: %v", apiErr.Msg)
+	}
+
+	if len(builds) == 0 {
+		fmt.Println("🤷‍♂️ No builds")
+		fmt.Println()
+		term.PrintCmds("", "build")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"File", "Status", "Retries", "Tokens", "Duration", "Started", "Error"})
+
+	for _, build := range builds {
+		path := build.Path
+		if build.IsVerification {
+			path += " (verify)"
+		}
+
+		started := ""
+		duration := ""
+		if build.StartedAt != nil {
+			started = format.Time(*build.StartedAt)
+
+			end := time.Now()
+			if build.FinishedAt != nil {
+				end = *build.FinishedAt
+			}
+			duration = end.Sub(*build.StartedAt).Round(time.Second).String()
+		}
+
+		errStr := ""
+		if build.Error != "" {
+			errStr = color.New(term.ColorHiRed).Sprint(build.Error)
+		}
+
+		table.Append([]string{
+			path,
+			buildStatusLabel(build.Status),
+			strconv.Itoa(build.NumRetries),
+			strconv.Itoa(build.NumTokens),
+			duration,
+			started,
+			errStr,
+		})
+	}
+
+	table.Render()
+	fmt.Println()
+
+	term.PrintCmds("", "ps", "connect", "stop")
+}
+
+func buildStatusLabel(status shared.FileBuildStatus) string {
+	switch status {
+	case shared.FileBuildStatusQueued:
+		return "⏳ Queued"
+	case shared.FileBuildStatusBuilding:
+		return color.New(term.ColorHiCyan).Sprint("🏗️  Building")
+	case shared.FileBuildStatusFinished:
+		return color.New(term.ColorHiGreen).Sprint("✅ Finished")
+	case shared.FileBuildStatusFailed:
+		return color.New(term.ColorHiRed).Sprint("🚨 Failed")
+	case shared.FileBuildStatusStopped:
+		return "🛑 Stopped"
+	}
+	return string(status)
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/format"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var bundleDescription string
+
+func init() {
+	RootCmd.AddCommand(bundlesCmd)
+	bundlesCmd.AddCommand(setBundleCmd)
+	bundlesCmd.AddCommand(rmBundleCmd)
+	bundlesCmd.AddCommand(syncBundlesCmd)
+
+	setBundleCmd.Flags().StringVarP(&bundleDescription, "description", "d", "", "What the bundle is for")
+}
+
+var bundlesCmd = &cobra.Command{
+	Use:   "bundles",
+	Short: "List the org's context bundles",
+	Long:  "List your org's context bundles: style guides, architecture docs, shared type definitions, and other files that are attached to every new plan in the org.",
+	Args:  cobra.NoArgs,
+	Run:   listBundles,
+}
+
+var setBundleCmd = &cobra.Command{
+	Use:   "set <name> <file>...",
+	Short: "Create or update an org context bundle",
+	Long:  "Create a context bundle from the given files, or replace an existing bundle's files and bump its version. New plans get the latest version; existing plans are updated with 'plandex bundles sync'.",
+	Args:  cobra.MinimumNArgs(2),
+	Run:   setBundle,
+}
+
+var rmBundleCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Delete an org context bundle",
+	Args:  cobra.ExactArgs(1),
+	Run:   rmBundle,
+}
+
+var syncBundlesCmd = &cobra.Command{
+	Use:   "sync [name]...",
+	Short: "Update the current plan's context bundles to their latest versions",
+	Long:  "Update the context bundles attached to the current plan and branch to their latest versions, and remove bundles that were deleted. Bundles named as arguments are attached if the plan doesn't have them yet.",
+	Run:   syncBundles,
+}
+
+func listBundles(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	bundles, apiErr := api.Client.ListOrgContextBundles()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting context bundles: %v", apiErr.Msg)
+		return
+	}
+
+	if len(bundles) == 0 {
+		fmt.Println("🤷‍♂️ Your org doesn't have any context bundles")
+		fmt.Println()
+		term.PrintCmds("", "bundles set")
+		return
+	}
+
+	color.New(color.Bold, term.ColorHiCyan).Println("📚 Context Bundles")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Name", "Version", "Files", "Tokens", "Description", "Updated"})
+
+	for _, bundle := range bundles {
+		var names []string
+		numTokens := 0
+		for _, file := range bundle.Files {
+			names = append(names, file.Name)
+			numTokens += file.NumTokens
+		}
+
+		table.Append([]string{
+			color.New(color.Bold, term.ColorHiGreen).Sprint(bundle.Name),
+			"v" + strconv.Itoa(bundle.Version),
+			strings.Join(names, "\n"),
+			strconv.Itoa(numTokens),
+			bundle.Description,
+			format.Time(bundle.UpdatedAt),
+		})
+	}
+
+	table.Render()
+	fmt.Println()
+
+	term.PrintCmds("", "bundles set", "bundles sync")
+}
+
+func setBundle(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	bundle := &shared.OrgContextBundle{
+		Name:        args[0],
+		Description: bundleDescription,
+	}
+
+	for _, path := range args[1:] {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			term.OutputErrorAndExit("Error reading %s: %v", path, err)
+			return
+		}
+		if !utf8.Valid(bytes) {
+			term.OutputErrorAndExit("%s isn't a UTF-8 text file", path)
+			return
+		}
+
+		bundle.Files = append(bundle.Files, &shared.OrgContextBundleFile{
+			Name: bundleFileName(path),
+			Body: string(bytes),
+		})
+	}
+
+	err := bundle.Validate()
+	if err != nil {
+		term.OutputErrorAndExit("Invalid context bundle: %v", err)
+		return
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.UpdateOrgContextBundle(shared.UpdateOrgContextBundleRequest{Bundle: bundle})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating context bundle: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Printf("✅ Saved context bundle %s %s\n", color.New(color.Bold, term.ColorHiGreen).Sprint(res.Name), color.New(color.FgHiWhite).Sprintf("v%d", res.Version))
+	fmt.Println()
+	fmt.Println("New plans in your org will have it in context. Run 'plandex bundles sync' to update existing plans.")
+	fmt.Println()
+
+	term.PrintCmds("", "bundles", "bundles sync")
+}
+
+// bundleFileName names a bundle file by its path from the project root, or by its base name if it's outside the project
+func bundleFileName(path string) string {
+	abs, err := filepath.Abs(path)
+	if err == nil && fs.ProjectRoot != "" {
+		rel, err := filepath.Rel(fs.ProjectRoot, abs)
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(path)
+}
+
+func rmBundle(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	name := args[0]
+
+	term.StartSpinner("")
+	apiErr := api.Client.DeleteOrgContextBundle(name)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error deleting context bundle: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Printf("✅ Deleted context bundle %s\n", color.New(color.Bold, term.ColorHiGreen).Sprint(name))
+	fmt.Println()
+	fmt.Println("Plans that have it in context keep it until they're synced with 'plandex bundles sync'.")
+}
+
+func syncBundles(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.SyncContextBundles(lib.CurrentPlanId, lib.CurrentBranch, shared.SyncContextBundlesRequest{Attach: args})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error syncing context bundles: %v", apiErr.Msg)
+		return
+	}
+
+	if len(res.Synced) == 0 && res.TokensDiff == 0 {
+		fmt.Println("✅ Context bundles are up to date")
+		return
+	}
+
+	fmt.Printf("✅ Synced context bundles | %+d 🪙 | total → %d 🪙\n", res.TokensDiff, res.TotalTokens)
+	for _, name := range res.Synced {
+		fmt.Printf("• %s\n", name)
+	}
+	fmt.Println()
+
+	term.PrintCmds("", "ls", "tell")
+}
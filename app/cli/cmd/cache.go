@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/term"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var cacheEnabled bool
+var cacheScope string
+var cacheTtlHours int
+var cacheOps []string
+
+func init() {
+	RootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(setCacheCmd)
+	cacheCmd.AddCommand(clearCacheCmd)
+
+	setCacheCmd.Flags().BoolVar(&cacheEnabled, "enabled", false, "Turn the response cache on or off (--enabled=false to turn it off)")
+	setCacheCmd.Flags().StringVar(&cacheScope, "scope", "", "Who can use a cached response: 'org' for anyone in the org, or 'user' for only the user whose call was cached")
+	setCacheCmd.Flags().IntVar(&cacheTtlHours, "ttl", 0, fmt.Sprintf("Hours a cached response can be used for (max %d)", shared.MaxResponseCacheTtlHours))
+	setCacheCmd.Flags().StringSliceVar(&cacheOps, "ops", nil, "Comma-separated operations to cache: summaries, plan-names, context-names")
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Show the org's model response cache",
+	Run:   showCache,
+}
+
+var setCacheCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Update the org's response cache policy",
+	Run:   setCache,
+}
+
+var clearCacheCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete the org's cached responses",
+	Run:   clearCache,
+}
+
+func showCache(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.GetOrgResponseCache()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting response cache: %v", apiErr.Msg)
+		return
+	}
+
+	renderResponseCachePolicy(res.Policy, res.Stats)
+
+	term.PrintCmds("", "cache set", "cache clear")
+}
+
+func setCache(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	if !cmd.Flags().Changed("enabled") && !cmd.Flags().Changed("scope") && !cmd.Flags().Changed("ttl") && !cmd.Flags().Changed("ops") {
+		term.OutputErrorAndExit("Set at least one of --enabled, --scope, --ttl, or --ops")
+		return
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.GetOrgResponseCache()
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting response cache: %v", apiErr.Msg)
+		return
+	}
+	policy := res.Policy
+
+	// only the flags that were passed are changed
+	if cmd.Flags().Changed("enabled") {
+		policy.Enabled = cacheEnabled
+	}
+	if cmd.Flags().Changed("scope") {
+		policy.Scope = shared.ResponseCacheScope(cacheScope)
+	}
+	if cmd.Flags().Changed("ttl") {
+		policy.TtlHours = cacheTtlHours
+	}
+	if cmd.Flags().Changed("ops") {
+		policy.Operations = []shared.ResponseCacheOperation{}
+		for _, op := range cacheOps {
+			policy.Operations = append(policy.Operations, shared.ResponseCacheOperation(strings.TrimSpace(op)))
+		}
+	}
+
+	err := policy.Validate()
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Invalid response cache policy: %v", err)
+		return
+	}
+
+	apiErr = api.Client.UpdateOrgResponseCachePolicy(shared.UpdateOrgResponseCachePolicyRequest{Policy: policy})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating response cache policy: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Println("✅ Updated response cache policy")
+	fmt.Println()
+
+	renderResponseCachePolicy(policy, nil)
+}
+
+func clearCache(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	apiErr := api.Client.ClearOrgResponseCache()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error clearing response cache: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Println("✅ Cleared cached responses")
+}
+
+func renderResponseCachePolicy(policy *shared.OrgResponseCachePolicy, stats *shared.ResponseCacheStats) {
+	color.New(color.Bold, term.ColorHiCyan).Println("♻️  Response Cache")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+
+	if !policy.Enabled {
+		table.Append([]string{"Enabled", "no"})
+		table.Render()
+		fmt.Println()
+		return
+	}
+
+	scope := "anyone in the org"
+	if policy.Scope == shared.ResponseCacheScopeUser {
+		scope = "only the user whose call was cached"
+	}
+
+	var ops []string
+	for _, op := range policy.Operations {
+		ops = append(ops, shared.ResponseCacheOperationDescriptions[op])
+	}
+	if len(ops) == 0 {
+		ops = append(ops, "none")
+	}
+
+	table.Append([]string{"Enabled", "yes"})
+	table.Append([]string{"Shared with", scope})
+	table.Append([]string{"Expires after", strconv.Itoa(policy.TtlHours) + " hours"})
+	table.Append([]string{"Cached calls", strings.Join(ops, ", ")})
+	if stats != nil {
+		table.Append([]string{"Cached responses", strconv.Itoa(stats.NumEntries)})
+		table.Append([]string{"Cache hits", strconv.Itoa(stats.NumHits)})
+	}
+	table.Render()
+	fmt.Println()
+}
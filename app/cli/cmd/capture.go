@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var captureDumpPath string
+var captureDumpOut string
+var captureDumpJson bool
+
+var captureCmd = &cobra.Command{
+	Use:   "capture",
+	Short: "Show whether model requests are captured for builds",
+	Long: `Capture mode records the full prompts and raw model responses for each file build in the current plan, so you can see why a build produced unexpected output. Likely secrets are redacted before captures are stored.
+
+	plandex capture on
+	plandex capture ls
+	plandex capture dump --path src/main.go
+	plandex capture off
+	`,
+	Args: cobra.NoArgs,
+	Run:  showCapture,
+}
+
+var captureOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Start capturing model requests and responses for builds",
+	Args:  cobra.NoArgs,
+	Run:   captureOn,
+}
+
+var captureOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Stop capturing model requests and responses for builds",
+	Args:  cobra.NoArgs,
+	Run:   captureOff,
+}
+
+var captureLsCmd = &cobra.Command{
+	Use:   "ls [path]",
+	Short: "List captured build requests for the current plan",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   listCaptures,
+}
+
+var captureDumpCmd = &cobra.Command{
+	Use:   "dump [capture-ids...]",
+	Short: "Output captured build requests and responses",
+	Long:  "Output captured build requests and responses. With no ids, every capture for the current plan is output (or every capture for a file with --path).",
+	Run:   dumpCaptures,
+}
+
+var captureClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete all captures for the current plan",
+	Args:  cobra.NoArgs,
+	Run:   clearCaptures,
+}
+
+func init() {
+	RootCmd.AddCommand(captureCmd)
+	captureCmd.AddCommand(captureOnCmd)
+	captureCmd.AddCommand(captureOffCmd)
+	captureCmd.AddCommand(captureLsCmd)
+	captureCmd.AddCommand(captureDumpCmd)
+	captureCmd.AddCommand(captureClearCmd)
+
+	captureDumpCmd.Flags().StringVarP(&captureDumpPath, "path", "p", "", "Only output captures for this file")
+	captureDumpCmd.Flags().StringVarP(&captureDumpOut, "out", "o", "", "Write to a file instead of stdout")
+	captureDumpCmd.Flags().BoolVar(&captureDumpJson, "json", false, "Output as JSON")
+}
+
+func showCapture(cmd *cobra.Command, args []string) {
+	settings := mustGetCaptureSettings()
+
+	if settings.CaptureModelIO {
+		fmt.Println("🔴 Capture is on. Model requests and responses are recorded for each build.")
+		fmt.Println()
+		term.PrintCmds("", "capture ls", "capture dump", "capture off")
+	} else {
+		fmt.Println("⚪️ Capture is off")
+		fmt.Println()
+		term.PrintCmds("", "capture on", "capture ls")
+	}
+}
+
+func captureOn(cmd *cobra.Command, args []string) {
+	settings := mustGetCaptureSettings()
+
+	if settings.CaptureModelIO {
+		fmt.Println("🤷‍♂️ Capture is already on")
+		return
+	}
+
+	settings.CaptureModelIO = true
+	mustUpdateCaptureSettings(settings)
+
+	fmt.Println("🔴 Capture is on. Model requests and responses will be recorded for each build.")
+	fmt.Println()
+	term.PrintCmds("", "build", "capture ls", "capture off")
+}
+
+func captureOff(cmd *cobra.Command, args []string) {
+	settings := mustGetCaptureSettings()
+
+	if !settings.CaptureModelIO {
+		fmt.Println("🤷‍♂️ Capture is already off")
+		return
+	}
+
+	settings.CaptureModelIO = false
+	mustUpdateCaptureSettings(settings)
+
+	fmt.Println("⚪️ Capture is off. Existing captures are kept until you clear them.")
+	fmt.Println()
+	term.PrintCmds("", "capture ls", "capture clear")
+}
+
+func listCaptures(cmd *cobra.Command, args []string) {
+	mustResolveCapturePlan()
+
+	var path string
+	if len(args) > 0 {
+		path = mustResolveCapturePath(args[0])
+	}
+
+	term.StartSpinner("")
+	captures, apiErr := api.Client.ListBuildCaptures(lib.CurrentPlanId, path)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error listing captures: %v", apiErr.Msg)
+	}
+
+	if len(captures) == 0 {
+		fmt.Println("🤷‍♂️ No captures")
+		fmt.Println()
+		term.PrintCmds("", "capture on")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Id", "Started", "File", "Phase", "Model", "Error"})
+	for _, capture := range captures {
+		errStr := ""
+		if capture.Error != "" {
+			errStr = color.New(term.ColorHiRed).Sprint(capture.Error)
+		}
+		table.Append([]string{
+			capture.Id,
+			capture.StartedAt.Local().Format("Jan 2 15:04:05"),
+			capture.Path,
+			string(capture.Phase),
+			capture.ModelName,
+			errStr,
+		})
+	}
+	table.Render()
+	fmt.Println()
+
+	term.PrintCmds("", "capture dump")
+}
+
+func dumpCaptures(cmd *cobra.Command, args []string) {
+	mustResolveCapturePlan()
+
+	ids := args
+	if len(ids) == 0 {
+		var path string
+		if captureDumpPath != "" {
+			path = mustResolveCapturePath(captureDumpPath)
+		}
+
+		term.StartSpinner("")
+		captures, apiErr := api.Client.ListBuildCaptures(lib.CurrentPlanId, path)
+		term.StopSpinner()
+
+		if apiErr != nil {
+			term.OutputErrorAndExit("Error listing captures: %v", apiErr.Msg)
+		}
+
+		for _, capture := range captures {
+			ids = append(ids, capture.Id)
+		}
+	}
+
+	if len(ids) == 0 {
+		fmt.Fprintln(os.Stderr, "🤷‍♂️ No captures")
+		return
+	}
+
+	var captures []*shared.BuildCapture
+	term.StartSpinner("")
+	for _, id := range ids {
+		capture, apiErr := api.Client.GetBuildCapture(lib.CurrentPlanId, id)
+		if apiErr != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("Error getting capture %s: %v", id, apiErr.Msg)
+		}
+		captures = append(captures, capture)
+	}
+	term.StopSpinner()
+
+	var w io.Writer = os.Stdout
+	if captureDumpOut != "" {
+		f, err := os.Create(captureDumpOut)
+		if err != nil {
+			term.OutputErrorAndExit("Error creating %s: %v", captureDumpOut, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if captureDumpJson {
+		bytes, err := json.MarshalIndent(captures, "", "  ")
+		if err != nil {
+			term.OutputErrorAndExit("Error marshalling captures: %v", err)
+		}
+		fmt.Fprintln(w, string(bytes))
+	} else {
+		for _, capture := range captures {
+			fmt.Fprintf(w, "=== %s | %s | %s | %s | %s ===\n\n", capture.Id, capture.Path, capture.Phase, capture.ModelName, capture.StartedAt.Local().Format("2006-01-02 15:04:05"))
+			fmt.Fprintf(w, "--- request ---\n%s\n\n", capture.Request)
+			fmt.Fprintf(w, "--- response ---\n%s\n\n", capture.Response)
+			if capture.Error != "" {
+				fmt.Fprintf(w, "--- error ---\n%s\n\n", capture.Error)
+			}
+		}
+	}
+
+	if captureDumpOut != "" {
+		fmt.Printf("✅ Wrote %d capture(s) to %s\n", len(captures), captureDumpOut)
+	}
+}
+
+func clearCaptures(cmd *cobra.Command, args []string) {
+	mustResolveCapturePlan()
+
+	term.StartSpinner("")
+	apiErr := api.Client.DeleteBuildCaptures(lib.CurrentPlanId)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error clearing captures: %v", apiErr.Msg)
+	}
+
+	fmt.Println("✅ Cleared captures")
+}
+
+func mustResolveCapturePlan() {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+}
+
+func mustGetCaptureSettings() *shared.PlanSettings {
+	mustResolveCapturePlan()
+
+	term.StartSpinner("")
+	settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current settings: %v", apiErr.Msg)
+	}
+
+	return settings
+}
+
+func mustUpdateCaptureSettings(settings *shared.PlanSettings) {
+	term.StartSpinner("")
+	_, apiErr := api.Client.UpdateSettings(
+		lib.CurrentPlanId,
+		lib.CurrentBranch,
+		shared.UpdateSettingsRequest{
+			Settings: settings,
+		})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating settings: %v", apiErr.Msg)
+	}
+}
+
+// captured paths are relative to the project root
+func mustResolveCapturePath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		term.OutputErrorAndExit("Error resolving path %s: %v", path, err)
+	}
+
+	relPath, err := filepath.Rel(fs.ProjectRoot, absPath)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		term.OutputErrorAndExit("%s is outside the project", path)
+	}
+
+	return filepath.ToSlash(relPath)
+}
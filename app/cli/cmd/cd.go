@@ -15,8 +15,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var cdShared bool
+
 func init() {
 	RootCmd.AddCommand(cdCmd)
+	cdCmd.Flags().BoolVar(&cdShared, "shared", false, "Choose from plans shared with you, from any org")
 }
 
 var cdCmd = &cobra.Command{
@@ -39,7 +42,13 @@ func cd(cmd *cobra.Command, args []string) {
 	var plan *shared.Plan
 
 	term.StartSpinner("")
-	plans, apiErr := api.Client.ListPlans([]string{lib.CurrentProjectId})
+	var plans []*shared.Plan
+	var apiErr *shared.ApiError
+	if cdShared {
+		plans, apiErr = api.Client.ListSharedPlans()
+	} else {
+		plans, apiErr = api.Client.ListPlans([]string{lib.CurrentProjectId})
+	}
 	term.StopSpinner()
 
 	if apiErr != nil {
@@ -104,12 +113,15 @@ func cd(cmd *cobra.Command, args []string) {
 	// reload current plan, which will also handle setting the right branch
 	lib.MustLoadCurrentPlan()
 
-	// fire and forget SetProjectPlan request (we don't care about the response or errors)
-	// this only matters for setting the current plan on a new device (i.e. when the current plan is not set)
-	go api.Client.SetProjectPlan(lib.CurrentProjectId, shared.SetProjectPlanRequest{PlanId: plan.Id})
+	// a plan shared from another project is only set as the current plan locally
+	if plan.ProjectId == lib.CurrentProjectId {
+		// fire and forget SetProjectPlan request (we don't care about the response or errors)
+		// this only matters for setting the current plan on a new device (i.e. when the current plan is not set)
+		go api.Client.SetProjectPlan(lib.CurrentProjectId, shared.SetProjectPlanRequest{PlanId: plan.Id})
 
-	// give the SetProjectPlan request some time to be sent before exiting
-	time.Sleep(50 * time.Millisecond)
+		// give the SetProjectPlan request some time to be sent before exiting
+		time.Sleep(50 * time.Millisecond)
+	}
 
 	fmt.Println("✅ Changed current plan to " + color.New(term.ColorHiGreen, color.Bold).Sprint(plan.Name))
 
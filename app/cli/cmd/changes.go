@@ -2,19 +2,26 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"plandex/api"
 	"plandex/auth"
 	"plandex/changes_tui"
 	"plandex/lib"
 	"plandex/plan_exec"
 	"plandex/term"
+	"strings"
 
+	"github.com/fatih/color"
 	"github.com/plandex/plandex/shared"
 	"github.com/spf13/cobra"
 )
 
+var exportPatchPath string
+
 func init() {
 	RootCmd.AddCommand(changesCmd)
+
+	changesCmd.Flags().StringVar(&exportPatchPath, "export", "", "Export the pending changes to a patch file that can be applied with 'git apply'")
 }
 
 var changesCmd = &cobra.Command{
@@ -28,6 +35,11 @@ func changes(cmd *cobra.Command, args []string) {
 	auth.MustResolveAuthWithOrg()
 	lib.MustResolveProject()
 
+	if exportPatchPath != "" {
+		exportPatch(exportPatchPath)
+		return
+	}
+
 	term.StartSpinner("")
 
 	currentPlanState, apiErr := api.Client.GetCurrentPlanState(lib.CurrentPlanId, lib.CurrentBranch)
@@ -116,3 +128,37 @@ func changes(cmd *cobra.Command, args []string) {
 	}
 
 }
+
+// exportPatch writes the pending changes to a patch file, so they can be shared with someone who doesn't use Plandex and applied with 'git apply'
+func exportPatch(path string) {
+	term.StartSpinner("")
+	patch, apiErr := api.Client.GetPlanDiffs(lib.CurrentPlanId, lib.CurrentBranch, true)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting pending changes: %s", apiErr.Msg)
+	}
+
+	if strings.TrimSpace(patch) == "" {
+		fmt.Println("🤷‍♂️ No pending changes to export")
+		return
+	}
+
+	err := os.WriteFile(path, []byte(patch), 0644)
+	if err != nil {
+		term.OutputErrorAndExit("Error writing patch to %s: %v", path, err)
+	}
+
+	numFiles := strings.Count(patch, "\ndiff --git ")
+	if strings.HasPrefix(patch, "diff --git ") {
+		numFiles++
+	}
+	suffix := ""
+	if numFiles != 1 {
+		suffix = "s"
+	}
+
+	fmt.Printf("✅ Exported changes to %d file%s to %s\n", numFiles, suffix, path)
+	fmt.Println()
+	fmt.Printf("Apply them with %s\n", color.New(color.Bold, term.ColorHiCyan).Sprintf("git apply %s", path))
+}
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var chatPromptFile string
+var chatContextPaths []string
+var chatPlanName string
+var chatPlanLast int
+
+func init() {
+	RootCmd.AddCommand(chatCmd)
+	chatCmd.AddCommand(showChatCmd)
+	chatCmd.AddCommand(clearChatCmd)
+	chatCmd.AddCommand(planChatCmd)
+
+	chatCmd.Flags().StringVarP(&chatPromptFile, "file", "f", "", "File containing prompt")
+	chatCmd.Flags().StringSliceVarP(&chatContextPaths, "context", "c", nil, "Files to add to the chat's context")
+
+	planChatCmd.Flags().StringVarP(&chatPlanName, "name", "n", "", "Name of the new plan")
+	planChatCmd.Flags().IntVar(&chatPlanLast, "last", 0, "Only carry over the last n messages (default all)")
+}
+
+var chatCmd = &cobra.Command{
+	Use:   "chat [prompt]",
+	Short: "Chat about the project without starting a plan",
+	Long:  "Chat about the project with the chat model, without creating a plan or building any changes. Chats are kept locally, one per project, and use the chat role from your default model settings. Add files for the model to see with --context. When you're ready to make changes, turn the chat into a plan with 'plandex chat plan'.",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   doChat,
+}
+
+var showChatCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the project's chat",
+	Args:  cobra.NoArgs,
+	Run:   showChat,
+}
+
+var clearChatCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Clear the project's chat",
+	Args:  cobra.NoArgs,
+	Run:   clearChat,
+}
+
+var planChatCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Turn the chat into a new plan",
+	Long:  "Start a new plan from the project's chat. The chat's messages are loaded into the new plan's context as a note, along with the chat's context files and any project files the messages mention by path. The new plan becomes the current plan, and the chat is cleared.",
+	Args:  cobra.NoArgs,
+	Run:   planFromChat,
+}
+
+func doChat(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	chat := mustLoadChat()
+
+	if len(chatContextPaths) > 0 {
+		err := lib.AddChatContextPaths(chat, chatContextPaths)
+		if err != nil {
+			term.OutputErrorAndExit("Error adding chat context: %v", err)
+		}
+
+		err = lib.WriteChat(chat)
+		if err != nil {
+			term.OutputErrorAndExit("Error writing chat: %v", err)
+		}
+
+		fmt.Printf("📄 Chat context: %s\n", strings.Join(chat.ContextPaths, ", "))
+	}
+
+	var prompt string
+	if len(args) > 0 {
+		prompt = args[0]
+	} else if chatPromptFile != "" {
+		bytes, err := os.ReadFile(chatPromptFile)
+		if err != nil {
+			term.OutputErrorAndExit("Error reading prompt file: %v", err)
+		}
+		prompt = string(bytes)
+	} else if len(chatContextPaths) > 0 {
+		// adding context on its own doesn't need a prompt
+		return
+	} else {
+		prompt = getEditorPrompt()
+	}
+
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		fmt.Println("🤷‍♂️ No prompt to send")
+		return
+	}
+
+	apiKeys := lib.MustVerifyChatApiKeys()
+
+	contextFiles, err := lib.ChatContextFiles(chat)
+	if err != nil {
+		term.OutputErrorAndExit("Error reading chat context: %v", err)
+	}
+
+	chat.Messages = append(chat.Messages, &shared.ChatMessage{
+		Role:      shared.ChatMessageRoleUser,
+		Content:   prompt,
+		CreatedAt: time.Now(),
+	})
+
+	openAIBase := os.Getenv("OPENAI_API_BASE")
+	if openAIBase == "" {
+		openAIBase = os.Getenv("OPENAI_ENDPOINT")
+	}
+
+	var reply strings.Builder
+
+	term.StartSpinner("")
+	started := false
+	apiErr := api.Client.Chat(shared.ChatRequest{
+		Messages:    chat.Messages,
+		Context:     contextFiles,
+		ApiKeys:     apiKeys,
+		OpenAIBase:  openAIBase,
+		OpenAIOrgId: os.Getenv("OPENAI_ORG_ID"),
+	}, func(chunk string) {
+		if !started {
+			term.StopSpinner()
+			started = true
+		}
+		reply.WriteString(chunk)
+		fmt.Print(chunk)
+	})
+	if !started {
+		term.StopSpinner()
+	}
+	fmt.Println()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error sending chat: %v", apiErr.Msg)
+	}
+
+	chat.Messages = append(chat.Messages, &shared.ChatMessage{
+		Role:      shared.ChatMessageRoleAssistant,
+		Content:   reply.String(),
+		CreatedAt: time.Now(),
+	})
+
+	err = lib.WriteChat(chat)
+	if err != nil {
+		term.OutputErrorAndExit("Error writing chat: %v", err)
+	}
+
+	fmt.Println()
+	term.PrintCmds("", "chat", "chat plan", "chat clear")
+}
+
+func showChat(cmd *cobra.Command, args []string) {
+	lib.MustResolveProject()
+
+	chat := mustLoadChat()
+
+	if len(chat.Messages) == 0 && len(chat.ContextPaths) == 0 {
+		fmt.Println("🤷‍♂️ No chat for this project")
+		fmt.Println()
+		term.PrintCmds("", "chat")
+		return
+	}
+
+	if len(chat.ContextPaths) > 0 {
+		fmt.Printf("📄 Chat context: %s\n\n", strings.Join(chat.ContextPaths, ", "))
+	}
+
+	for _, msg := range chat.Messages {
+		label := "🙋 You"
+		if msg.Role == shared.ChatMessageRoleAssistant {
+			label = "🤖 Plandex"
+		}
+		color.New(color.Bold, term.ColorHiCyan).Println(label)
+
+		md, err := term.GetMarkdown(msg.Content)
+		if err != nil {
+			md = msg.Content + "\n"
+		}
+		fmt.Print(md)
+		fmt.Println()
+	}
+
+	term.PrintCmds("", "chat", "chat plan", "chat clear")
+}
+
+func clearChat(cmd *cobra.Command, args []string) {
+	lib.MustResolveProject()
+
+	err := lib.ClearChat()
+	if err != nil {
+		term.OutputErrorAndExit("Error clearing chat: %v", err)
+	}
+
+	fmt.Println("✅ Chat cleared")
+}
+
+func planFromChat(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	chat := mustLoadChat()
+
+	if len(chat.Messages) == 0 {
+		fmt.Println("🤷‍♂️ No chat to start a plan from")
+		fmt.Println()
+		term.PrintCmds("", "chat")
+		return
+	}
+
+	messages := chat.Messages
+	if chatPlanLast > 0 && chatPlanLast < len(messages) {
+		messages = messages[len(messages)-chatPlanLast:]
+	}
+
+	paths, err := lib.ChatReferencedPaths(chat, messages)
+	if err != nil {
+		term.OutputErrorAndExit("Error finding files referenced by the chat: %v", err)
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.CreatePlan(lib.CurrentProjectId, shared.CreatePlanRequest{Name: chatPlanName})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error creating plan: %v", apiErr.Msg)
+	}
+
+	err = lib.WriteCurrentPlan(res.Id)
+	if err != nil {
+		term.OutputErrorAndExit("Error setting current plan: %v", err)
+	}
+
+	lib.MustLoadCurrentPlan()
+
+	name := chatPlanName
+	if name == "" {
+		name = "draft"
+	}
+	fmt.Printf("✅ Started new plan %s from the chat and set it to current plan\n", color.New(color.Bold, term.ColorHiGreen).Sprint(name))
+
+	lib.MustApplyProjectConfigToNewPlan(paths, lib.ChatTranscript(messages), "chat")
+
+	err = lib.ClearChat()
+	if err != nil {
+		term.OutputErrorAndExit("Error clearing chat: %v", err)
+	}
+
+	fmt.Println()
+	term.PrintCmds("", "tell", "ls")
+}
+
+func mustLoadChat() *types.Chat {
+	chat, err := lib.LoadChat()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading chat: %v", err)
+	}
+	return chat
+}
@@ -26,8 +26,12 @@ var checkoutCmd = &cobra.Command{
 	Args:    cobra.MaximumNArgs(1),
 }
 
+var fromMessageNum int
+
 func init() {
 	RootCmd.AddCommand(checkoutCmd)
+
+	checkoutCmd.Flags().IntVarP(&fromMessageNum, "from-message", "m", 0, "Root a new branch at this conversation message number (see 'plandex convo') instead of the current branch's latest state")
 }
 
 func checkout(cmd *cobra.Command, args []string) {
@@ -121,9 +125,13 @@ func checkout(cmd *cobra.Command, args []string) {
 		term.OutputErrorAndExit("Branch not found")
 	}
 
+	if fromMessageNum > 0 && !willCreate {
+		term.OutputErrorAndExit("Branch %s already exists—--from-message only applies when creating a new branch", branchName)
+	}
+
 	if willCreate {
 		term.StartSpinner("")
-		err := api.Client.CreateBranch(lib.CurrentPlanId, lib.CurrentBranch, shared.CreateBranchRequest{Name: branchName})
+		err := api.Client.CreateBranch(lib.CurrentPlanId, lib.CurrentBranch, shared.CreateBranchRequest{Name: branchName, FromMessageNum: fromMessageNum})
 		term.StopSpinner()
 
 		if err != nil {
@@ -143,6 +151,10 @@ func checkout(cmd *cobra.Command, args []string) {
 
 	fmt.Printf("✅ Checked out branch %s\n", color.New(color.Bold, term.ColorHiGreen).Sprint(branchName))
 
+	if willCreate && fromMessageNum > 0 {
+		fmt.Printf("⏪ Rooted at message #%d from %s\n", fromMessageNum, lib.CurrentBranch)
+	}
+
 	fmt.Println()
 	term.PrintCmds("", "load", "tell", "branches", "delete-branch")
 
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var citationsCmd = &cobra.Command{
+	Use:   "citations [msg-num]",
+	Short: "Show the code cited in a reply",
+	Long:  "Show the code cited in a Plandex reply, checking each citation against the project's current files. Defaults to the latest reply; pass a message number from 'plandex convo' to show the citations in another one.",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   citations,
+}
+
+func init() {
+	RootCmd.AddCommand(citationsCmd)
+}
+
+func citations(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	var msgNum int
+	if len(args) > 0 {
+		var err error
+		msgNum, err = strconv.Atoi(args[0])
+		if err != nil {
+			term.OutputErrorAndExit("Invalid message number: %s", args[0])
+		}
+	}
+
+	term.StartSpinner("")
+	conversation, apiErr := api.Client.ListConvo(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error loading conversation: %v", apiErr.Msg)
+	}
+
+	var msg *shared.ConvoMessage
+	for _, m := range conversation {
+		if msgNum > 0 {
+			if m.Num == msgNum {
+				msg = m
+				break
+			}
+		} else if m.Role == "assistant" {
+			msg = m
+		}
+	}
+
+	if msg == nil {
+		if msgNum > 0 {
+			term.OutputErrorAndExit("No message %d in the conversation", msgNum)
+		}
+		fmt.Println("🤷‍♂️ No replies yet")
+		return
+	}
+
+	cited := shared.ParseCitations(msg.Message)
+	if len(cited) == 0 {
+		fmt.Printf("🤷‍♂️ Message %d doesn't cite any code\n", msg.Num)
+		return
+	}
+
+	var numInvalid int
+	var output string
+	for _, c := range cited {
+		lines, err := lib.CitedLines(c)
+		if err != nil {
+			numInvalid++
+			output += color.New(color.Bold, term.ColorHiRed).Sprintf("⚠️  %s", c.Location()) + fmt.Sprintf(" | not found: %v\n\n", err)
+			continue
+		}
+
+		output += color.New(color.Bold, term.ColorHiCyan).Sprintf("📎 %s", c.Location()) + "\n"
+		for i, line := range lines {
+			output += color.New(color.FgHiBlack).Sprintf("%5d │ ", c.StartLine+i) + line + "\n"
+		}
+		output += "\n"
+	}
+
+	summary := fmt.Sprintf("%d citations in message %d", len(cited), msg.Num)
+	if len(cited) == 1 {
+		summary = fmt.Sprintf("1 citation in message %d", msg.Num)
+	}
+	if numInvalid > 0 {
+		summary += color.New(term.ColorHiRed).Sprintf(" | %d don't match the project's files", numInvalid)
+	}
+
+	term.PageOutput(strings.TrimSuffix(output, "\n") + "\n" + term.GetDivisionLine() + summary + "\n")
+
+	fmt.Println()
+	term.PrintCmds("", "convo", "tell")
+}
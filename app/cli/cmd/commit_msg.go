@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var commitMsgOnly bool
+var changelogOnly bool
+
+var commitMsgCmd = &cobra.Command{
+	Use:   "commit-msg",
+	Short: "Write a commit message and changelog entry for pending changes",
+	Args:  cobra.NoArgs,
+	Run:   commitMsg,
+}
+
+func init() {
+	RootCmd.AddCommand(commitMsgCmd)
+
+	commitMsgCmd.Flags().BoolVar(&commitMsgOnly, "commit", false, "Output only the commit message, for use in scripts")
+	commitMsgCmd.Flags().BoolVar(&changelogOnly, "changelog", false, "Output only the changelog entry, for use in scripts")
+}
+
+func commitMsg(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if commitMsgOnly && changelogOnly {
+		term.OutputErrorAndExit("Only one of --commit and --changelog can be used")
+	}
+
+	apiKeys := lib.MustVerifyApiKeysSilent()
+
+	openAIBase := os.Getenv("OPENAI_API_BASE")
+	if openAIBase == "" {
+		openAIBase = os.Getenv("OPENAI_ENDPOINT")
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.GetCommitMsg(lib.CurrentPlanId, lib.CurrentBranch, shared.CommitMsgRequest{
+		ApiKeys:     apiKeys,
+		OpenAIBase:  openAIBase,
+		OpenAIOrgId: os.Getenv("OPENAI_ORG_ID"),
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		if apiErr.Msg == shared.NoPendingChangesErr {
+			fmt.Println("🤷‍♂️ No pending changes")
+			return
+		}
+		term.OutputErrorAndExit("Error writing commit message: %v", apiErr.Msg)
+	}
+
+	if commitMsgOnly {
+		fmt.Println(res.CommitMsg)
+		return
+	}
+
+	if changelogOnly {
+		fmt.Println(res.Changelog)
+		return
+	}
+
+	color.New(color.Bold, term.ColorHiCyan).Println("Commit message")
+	fmt.Println()
+	fmt.Println(res.CommitMsg)
+	fmt.Println()
+
+	color.New(color.Bold, term.ColorHiCyan).Println("Changelog entry")
+	md, err := term.GetMarkdown(res.Changelog)
+	if err != nil {
+		term.OutputErrorAndExit("Error formatting markdown: %v", err)
+	}
+	fmt.Println(md)
+
+	term.PrintCmds("", "apply")
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var compareShowDiffs bool
+
+var compareCmd = &cobra.Command{
+	Use:   "compare [branch...]",
+	Short: "Compare pending changes across plan branches",
+	Long:  "Compare pending changes across plan branches. With no arguments, compares branches forked from the current branch.",
+	Run:   compare,
+}
+
+func init() {
+	RootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().BoolVarP(&compareShowDiffs, "diffs", "d", false, "Show each branch's diffs after the comparison")
+}
+
+func compare(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	branches, apiErr := api.Client.ListBranches(lib.CurrentPlanId)
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting branches: %v", apiErr.Msg)
+	}
+
+	var toCompare []*shared.Branch
+	if len(args) > 0 {
+		byName := map[string]*shared.Branch{}
+		for _, branch := range branches {
+			byName[branch.Name] = branch
+		}
+		for _, name := range args {
+			branch := byName[name]
+			if branch == nil {
+				term.OutputErrorAndExit("Branch %s not found", name)
+			}
+			toCompare = append(toCompare, branch)
+		}
+	} else {
+		var currentBranchId string
+		for _, branch := range branches {
+			if branch.Name == lib.CurrentBranch {
+				currentBranchId = branch.Id
+				break
+			}
+		}
+		for _, branch := range branches {
+			if branch.ParentBranchId != nil && *branch.ParentBranchId == currentBranchId {
+				toCompare = append(toCompare, branch)
+			}
+		}
+	}
+
+	if len(toCompare) == 0 {
+		term.StopSpinner()
+		fmt.Println("🤷‍♂️ No branches to compare")
+		fmt.Println()
+		term.PrintCmds("", "explore", "branches")
+		return
+	}
+
+	comparisons, err := lib.CompareBranches(lib.CurrentPlanId, toCompare)
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error comparing branches: %v", err)
+	}
+
+	renderBranchComparison(comparisons, compareShowDiffs)
+
+	fmt.Println()
+	term.PrintCmds("", "checkout", "diff", "apply")
+}
+
+func renderBranchComparison(comparisons []*lib.BranchComparison, showDiffs bool) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"#", "Branch", "Status", "Models", "Files", "Lines", "Verified", "Tokens"})
+
+	for i, c := range comparisons {
+		status := string(c.Branch.Status)
+		switch c.Branch.Status {
+		case shared.PlanStatusFinished:
+			status = "✅ " + status
+		case shared.PlanStatusError:
+			status = "🚨 " + status
+		case shared.PlanStatusMissingFile, shared.PlanStatusPromptingContinue:
+			status = "⏸️  needs input"
+		}
+
+		files := strconv.Itoa(c.NumFiles)
+		if c.NumFailed > 0 {
+			files += fmt.Sprintf(" (%d ⚠️)", c.NumFailed)
+		}
+
+		verified := "-"
+		if c.NumVerified > 0 {
+			if c.NumVerifyFailed > 0 {
+				verified = fmt.Sprintf("❌ %d/%d failed", c.NumVerifyFailed, c.NumVerified)
+			} else {
+				verified = fmt.Sprintf("✅ %d/%d", c.NumVerified, c.NumVerified)
+			}
+		}
+
+		models := c.PlannerModel
+		if c.BuilderModel != c.PlannerModel {
+			models += " / " + c.BuilderModel
+		}
+		if c.ModelPack != "" {
+			models = c.ModelPack + " (" + models + ")"
+		}
+
+		var name string
+		if c.Branch.Name == lib.CurrentBranch {
+			name = color.New(color.Bold, term.ColorHiGreen).Sprint(c.Branch.Name) + " 👈"
+		} else {
+			name = c.Branch.Name
+		}
+
+		table.Append([]string{
+			strconv.Itoa(i + 1),
+			name,
+			status,
+			models,
+			files,
+			color.New(term.ColorHiGreen).Sprintf("+%d", c.LinesAdded) + " " + color.New(term.ColorHiRed).Sprintf("-%d", c.LinesRemoved),
+			verified,
+			strconv.Itoa(c.Tokens) + " 🪙",
+		})
+	}
+
+	table.Render()
+
+	if !showDiffs {
+		return
+	}
+
+	for _, c := range comparisons {
+		fmt.Println()
+		color.New(color.Bold, term.ColorHiCyan).Printf("Branch %s\n", c.Branch.Name)
+		fmt.Println()
+		if c.Diffs == "" {
+			fmt.Println("🤷‍♂️ No pending changes")
+		} else {
+			fmt.Println(c.Diffs)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/config"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show CLI settings for this machine and the project",
+	Args:  cobra.NoArgs,
+	Run:   showConfig,
+}
+
+var setConfigCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Update a CLI setting",
+	Args:  cobra.ExactArgs(2),
+	Run:   setConfig,
+}
+
+func init() {
+	RootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(setConfigCmd)
+}
+
+func showConfig(cmd *cobra.Command, args []string) {
+	c, err := config.Load()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading config: %v", err)
+	}
+
+	renderConfig(c)
+	renderProjectConfig()
+
+	term.PrintCmds("", "config set")
+}
+
+func setConfig(cmd *cobra.Command, args []string) {
+	setting := config.GetSetting(args[0])
+
+	if setting == nil {
+		var keys []string
+		for _, s := range config.Settings {
+			keys = append(keys, s.Key)
+		}
+		term.OutputErrorAndExit("'%s' isn't a CLI setting. Settings are %s", args[0], strings.Join(keys, ", "))
+	}
+
+	c, err := config.Load()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading config: %v", err)
+	}
+
+	err = setting.Set(c, args[1])
+
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	err = config.Write(c)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error writing config: %v", err)
+	}
+
+	fmt.Printf("✅ Set %s to %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(setting.Key), color.New(color.Bold, term.ColorHiGreen).Sprint(setting.Get(c)))
+}
+
+func renderConfig(c *config.Config) {
+	color.New(color.Bold, term.ColorHiCyan).Println("⚙️  CLI Settings")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Key", "Value", "Options", "Description"})
+
+	for _, s := range config.Settings {
+		table.Append([]string{s.Key, s.Get(c), strings.Join(s.Values, " | "), s.Description})
+	}
+
+	table.Render()
+	fmt.Println()
+}
+
+// renderProjectConfig shows the settings in the project's .plandex/config.yml, if it has one
+func renderProjectConfig() {
+	path := config.ProjectConfigPath()
+	if path == "" {
+		return
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+
+	color.New(color.Bold, term.ColorHiCyan).Println("📁 Project Settings (.plandex/config.yml)")
+
+	c, err := config.LoadProjectConfig()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n\n", err)
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Key", "Value"})
+
+	hooks := []string{}
+	for _, hook := range []shared.ApplyHookType{shared.ApplyHookPre, shared.ApplyHookPost} {
+		if c.Hooks[hook] != "" {
+			hooks = append(hooks, fmt.Sprintf("%s: %s", hook, c.Hooks[hook]))
+		}
+	}
+
+	table.Append([]string{"context", orNone(strings.Join(c.Context, "\n"))})
+	table.Append([]string{"modelPack", orNone(c.ModelPack)})
+	table.Append([]string{"autoApply", string(c.AutoApplyPolicy())})
+	table.Append([]string{"hooks", orNone(strings.Join(hooks, "\n"))})
+	table.Append([]string{"conventions", orNone(c.Conventions)})
+
+	table.Render()
+	fmt.Println()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -42,5 +42,6 @@ func doContinue(cmd *cobra.Command, args []string) {
 		CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
 			return lib.MustCheckOutdatedContext(false, maybeContexts)
 		},
+		OnFinished: lib.MaybeAutoApply,
 	}, "", tellBg, tellStop, tellNoBuild, true)
 }
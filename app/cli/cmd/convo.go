@@ -78,6 +78,7 @@ func convo(cmd *cobra.Command, args []string) {
 	var convo string
 	var totalTokens int
 	var didCut bool
+	var anyStale bool
 	for i, msg := range conversation {
 		if msgRangeStart > 0 && msg.Num < msgRangeStart {
 			didCut = true
@@ -113,10 +114,18 @@ func convo(cmd *cobra.Command, args []string) {
 		header := fmt.Sprintf("#### %d | %s | %s | %d 🪙 ", i+1,
 			author, formattedTs, msg.Tokens)
 
+		if msg.EditedAt != nil {
+			header += "| ✏️  edited "
+		}
+		if msg.Stale {
+			header += "| 🕸️  stale "
+			anyStale = true
+		}
+
 		if plainTextOutput {
 			convo += header + "\n" + msg.Message + "\n\n"
 		} else {
-			md, err := term.GetMarkdown(header + "\n" + msg.Message + "\n\n")
+			md, err := term.GetMarkdown(header + "\n" + lib.RenderCitations(msg.Message) + "\n\n")
 			if err != nil {
 				term.OutputErrorAndExit("Error creating markdown representation: %v", err)
 			}
@@ -152,6 +161,12 @@ func convo(cmd *cobra.Command, args []string) {
 		term.PageOutput(output)
 
 		fmt.Println()
-		term.PrintCmds("", "convo 1", "convo 2-5", "convo --plain", "log")
+		if anyStale {
+			fmt.Println("🕸️  Stale messages came after an edited prompt")
+			fmt.Println()
+			term.PrintCmds("", "rerun", "rewind")
+		} else {
+			term.PrintCmds("", "convo 1", "convo 2-5", "convo --plain", "citations", "edit-prompt", "log")
+		}
 	}
 }
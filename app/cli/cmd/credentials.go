@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(credentialsCmd)
+	credentialsCmd.AddCommand(setCredentialCmd)
+	credentialsCmd.AddCommand(rmCredentialCmd)
+}
+
+var credentialsCmd = &cobra.Command{
+	Use:   "credentials",
+	Short: "List the org's model provider credentials",
+	Run:   listCredentials,
+}
+
+var setCredentialCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Set an org model provider credential, like OPENAI_API_KEY",
+	Args:  cobra.ExactArgs(1),
+	Run:   setCredential,
+}
+
+var rmCredentialCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove an org model provider credential",
+	Args:  cobra.ExactArgs(1),
+	Run:   rmCredential,
+}
+
+func listCredentials(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	credentials, apiErr := api.Client.ListOrgCredentials()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error listing credentials: %v", apiErr.Msg)
+		return
+	}
+
+	if len(credentials) == 0 {
+		fmt.Println("🤷‍♂️ No org credentials set. Models use the API keys in your environment.")
+		fmt.Println()
+		term.PrintCmds("", "credentials set")
+		return
+	}
+
+	color.New(color.Bold, term.ColorHiCyan).Println("🔑 Org Credentials")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Name", "Updated"})
+	for _, credential := range credentials {
+		table.Append([]string{credential.Name, credential.UpdatedAt.Local().Format("Jan 2, 2006 3:04pm")})
+	}
+	table.Render()
+	fmt.Println()
+
+	fmt.Println("Models with a matching API key env var use these credentials instead of keys in your environment.")
+	fmt.Println()
+
+	term.PrintCmds("", "credentials set", "credentials rm")
+}
+
+func setCredential(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	name := strings.ToUpper(args[0])
+
+	err := shared.ValidateOrgCredentialName(name)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+		return
+	}
+
+	value, err := term.GetUserPasswordInput(fmt.Sprintf("Value for %s:", name))
+	if err != nil {
+		term.OutputErrorAndExit("Error reading value: %v", err)
+		return
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		term.OutputErrorAndExit("Value can't be empty")
+		return
+	}
+
+	term.StartSpinner("")
+	apiErr := api.Client.SetOrgCredential(shared.SetOrgCredentialRequest{Name: name, Value: value})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error setting credential: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Printf("✅ Set org credential %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(name))
+}
+
+func rmCredential(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	name := strings.ToUpper(args[0])
+
+	term.StartSpinner("")
+	apiErr := api.Client.DeleteOrgCredential(name)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error removing credential: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Printf("✅ Removed org credential %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(name))
+}
@@ -9,6 +9,7 @@ import (
 	"plandex/lib"
 	"plandex/term"
 	"strconv"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
@@ -78,6 +79,12 @@ func current(cmd *cobra.Command, args []string) {
 	table.Rich(row, style)
 
 	table.Render()
+
+	if len(plan.Tags) > 0 {
+		fmt.Println()
+		fmt.Println("🏷️  " + strings.Join(plan.Tags, ", "))
+	}
+
 	fmt.Println()
 	term.PrintCmds("", "tell", "ls", "plans")
 
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"fmt"
 	"plandex/api"
 	"plandex/auth"
+	"plandex/fs"
 	"plandex/lib"
 	"plandex/term"
 
@@ -13,11 +15,19 @@ var diffsCmd = &cobra.Command{
 	Use:     "diff",
 	Aliases: []string{"diffs"},
 	Short:   "Show diffs for the pending changes in git diff format",
-	Run:     diffs,
+	Long: `Show diffs for the pending changes in git diff format.
+
+	By default, changes are diffed against the files as they were loaded into context. With --head, they're diffed against the project's git HEAD instead, so edits you've made to the files since they were loaded show up as part of the net change that applying the plan would leave you with.
+	`,
+	Run: diffs,
 }
 
+var diffAgainstHead bool
+
 func init() {
 	RootCmd.AddCommand(diffsCmd)
+
+	diffsCmd.Flags().BoolVar(&diffAgainstHead, "head", false, "Diff against the project's git HEAD instead of the loaded context")
 }
 
 func diffs(cmd *cobra.Command, args []string) {
@@ -28,7 +38,12 @@ func diffs(cmd *cobra.Command, args []string) {
 		term.OutputNoCurrentPlanErrorAndExit()
 	}
 
-	diffs, err := api.Client.GetPlanDiffs(lib.CurrentPlanId, lib.CurrentBranch)
+	if diffAgainstHead {
+		diffsAgainstHead()
+		return
+	}
+
+	diffs, err := api.Client.GetPlanDiffs(lib.CurrentPlanId, lib.CurrentBranch, false)
 
 	if err != nil {
 		term.OutputErrorAndExit("Error getting plan diffs: %v", err)
@@ -37,3 +52,31 @@ func diffs(cmd *cobra.Command, args []string) {
 
 	term.PageOutput(diffs)
 }
+
+func diffsAgainstHead() {
+	if fs.ProjectRoot == "" {
+		term.OutputErrorAndExit("Run 'plandex diff --head' from the project's directory")
+	}
+
+	term.StartSpinner("")
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(lib.CurrentPlanId, lib.CurrentBranch)
+
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr.Msg)
+	}
+
+	diffs, err := lib.GetPlanDiffsAgainstHead(currentPlanState.CurrentPlanFiles)
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error getting diffs against HEAD: %v", err)
+	}
+
+	if diffs == "" {
+		fmt.Println("🤷‍♂️ No differences from HEAD")
+		return
+	}
+
+	term.PageOutput(diffs)
+}
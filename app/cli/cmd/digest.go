@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/term"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(digestCmd)
+	digestCmd.AddCommand(setDigestCmd)
+}
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Show how often you get an email digest of plan activity",
+	Run:   showDigest,
+}
+
+var setDigestCmd = &cobra.Command{
+	Use:   "set [daily|weekly|off]",
+	Short: "Set how often you get an email digest of plan activity",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   setDigest,
+}
+
+func showDigest(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuth(false)
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.GetUserDigestSettings()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting digest settings: %v", apiErr.Msg)
+		return
+	}
+
+	renderDigestSettings(res)
+
+	term.PrintCmds("", "digest set")
+}
+
+func setDigest(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuth(false)
+
+	var frequency shared.DigestFrequency
+	if len(args) > 0 {
+		frequency = shared.DigestFrequency(args[0])
+	} else {
+		selected, err := term.SelectFromList("How often do you want a digest?", []string{string(shared.DigestFrequencyDaily), string(shared.DigestFrequencyWeekly), string(shared.DigestFrequencyOff)})
+		if err != nil {
+			term.OutputErrorAndExit("Error selecting digest frequency: %v", err)
+			return
+		}
+		frequency = shared.DigestFrequency(selected)
+	}
+
+	err := frequency.Validate()
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+		return
+	}
+
+	term.StartSpinner("")
+	apiErr := api.Client.UpdateUserDigestSettings(shared.UpdateUserDigestSettingsRequest{Frequency: frequency})
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error updating digest settings: %v", apiErr.Msg)
+		return
+	}
+
+	res, apiErr := api.Client.GetUserDigestSettings()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting digest settings: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Println("✅ Updated digest settings")
+	fmt.Println()
+
+	renderDigestSettings(res)
+}
+
+func renderDigestSettings(res *shared.GetUserDigestSettingsResponse) {
+	settings := res.Settings
+
+	color.New(color.Bold, term.ColorHiCyan).Println("📬 Activity Digest")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.Append([]string{"Frequency", string(settings.Frequency)})
+	if settings.Frequency != shared.DigestFrequencyOff && settings.LastSentAt != nil {
+		table.Append([]string{"Covers activity since", settings.LastSentAt.Local().Format("Jan 2, 2006 3:04pm")})
+	}
+	table.Render()
+	fmt.Println()
+
+	if settings.Frequency != shared.DigestFrequencyOff && !res.CanSendEmail {
+		color.New(term.ColorHiYellow).Println("⚠️  This server isn't set up to send email, so no digests will be sent until it is")
+		fmt.Println()
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var docSyncCmd = &cobra.Command{
+	Use:   "doc-sync [on|off]",
+	Short: "Show or set doc sync, and list the doc updates it wrote",
+	Long: `Show or set doc sync, and list the doc updates it wrote.
+
+	With doc sync on, when a build changes a file's public API—exported Go functions, methods, types, vars, and consts, or exported TypeScript and JavaScript declarations—the markdown docs loaded in context that reference the changed symbols are followed by a build that updates them. Doc updates are pending changes like any other, so they can be reviewed, rejected with 'plandex reject --docs', or applied.
+	`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"on", "off"},
+	Run:       docSync,
+}
+
+func init() {
+	RootCmd.AddCommand(docSyncCmd)
+}
+
+func docSync(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current settings: %v", apiErr.Msg)
+	}
+
+	if len(args) == 0 {
+		showDocSyncs(settings)
+		return
+	}
+
+	var enabled bool
+	switch strings.ToLower(args[0]) {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		term.OutputErrorAndExit("Doc sync must be 'on' or 'off'")
+	}
+
+	if settings.SyncDocs == enabled {
+		fmt.Printf("🤷‍♂️ Doc sync is already %s\n", args[0])
+		return
+	}
+
+	settings.SyncDocs = enabled
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.UpdateSettings(
+		lib.CurrentPlanId,
+		lib.CurrentBranch,
+		shared.UpdateSettingsRequest{
+			Settings: settings,
+		})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating settings: %v", apiErr.Msg)
+	}
+
+	fmt.Println(res.Msg)
+	fmt.Println()
+
+	if enabled {
+		fmt.Println("📝 Docs in context will be updated when the next build changes a public API they reference")
+		fmt.Println()
+		term.PrintCmds("", "load", "build", "doc-sync")
+		return
+	}
+
+	term.PrintCmds("", "doc-sync")
+}
+
+func showDocSyncs(settings *shared.PlanSettings) {
+	if settings.SyncDocs {
+		fmt.Println("📝 Doc sync: on")
+	} else {
+		fmt.Println("📝 Doc sync: off")
+	}
+	fmt.Println()
+
+	term.StartSpinner("")
+	state, apiErr := api.Client.GetCurrentPlanState(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr.Msg)
+	}
+
+	sourcesByPath := state.PendingDocSyncsByPath()
+
+	if len(sourcesByPath) == 0 {
+		fmt.Println("🤷‍♂️ No pending doc sync updates")
+		fmt.Println()
+		term.PrintCmds("", "doc-sync on", "doc-sync off")
+		return
+	}
+
+	var paths []string
+	for path := range sourcesByPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Doc", "For API Changes To"})
+	for _, path := range paths {
+		table.Append([]string{path, sourcesByPath[path]})
+	}
+	table.Render()
+	fmt.Println()
+
+	term.PrintCmds("", "diff", "reject --docs", "apply")
+}
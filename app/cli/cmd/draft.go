@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/format"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
+	"strconv"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var draftFile string
+var draftClipboard bool
+var draftEditor bool
+
+func init() {
+	RootCmd.AddCommand(draftCmd)
+	draftCmd.AddCommand(addDraftCmd)
+	draftCmd.AddCommand(showDraftCmd)
+	draftCmd.AddCommand(rmDraftCmd)
+	draftCmd.AddCommand(clearDraftCmd)
+
+	addDraftCmd.Flags().StringVarP(&draftFile, "file", "f", "", "File containing the message")
+	addDraftCmd.Flags().BoolVarP(&draftClipboard, "clipboard", "c", false, "Add the clipboard's contents as a message")
+	addDraftCmd.Flags().BoolVarP(&draftEditor, "editor", "e", false, "Write the message in $EDITOR from a template with task, constraints, and files sections")
+}
+
+var draftCmd = &cobra.Command{
+	Use:   "draft",
+	Short: "List the messages in the plan's draft prompt",
+	Args:  cobra.NoArgs,
+	Run:   listDraft,
+}
+
+var addDraftCmd = &cobra.Command{
+	Use:   "add [message]",
+	Short: "Add a message to the plan's draft prompt",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   addDraft,
+}
+
+var showDraftCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the full prompt the draft will be sent as",
+	Args:  cobra.NoArgs,
+	Run:   showDraft,
+}
+
+var rmDraftCmd = &cobra.Command{
+	Use:   "rm <number>",
+	Short: "Remove a message from the plan's draft",
+	Args:  cobra.ExactArgs(1),
+	Run:   rmDraft,
+}
+
+var clearDraftCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all messages from the plan's draft",
+	Args:  cobra.NoArgs,
+	Run:   clearDraft,
+}
+
+func listDraft(cmd *cobra.Command, args []string) {
+	messages := mustLoadDraftForPlan()
+
+	if len(messages) == 0 {
+		fmt.Println("🤷‍♂️ No draft for this plan")
+		fmt.Println()
+		term.PrintCmds("", "draft add")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"#", "Added", "Message"})
+
+	for i, message := range messages {
+		table.Append([]string{strconv.Itoa(i + 1), format.Time(message.CreatedAt), promptPreview(message.Body)})
+	}
+
+	table.Render()
+	fmt.Println()
+
+	fmt.Printf("Send the draft as one prompt with %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint("plandex tell --draft"))
+	fmt.Println()
+
+	term.PrintCmds("", "draft add", "draft show", "draft rm", "draft clear")
+}
+
+func addDraft(cmd *cobra.Command, args []string) {
+	mustResolvePlanForDraft()
+
+	var body string
+
+	if len(args) > 0 {
+		body = args[0]
+	} else if draftFile != "" {
+		bytes, err := os.ReadFile(draftFile)
+		if err != nil {
+			term.OutputErrorAndExit("Error reading message file: %v", err)
+		}
+		body = string(bytes)
+	} else if draftClipboard {
+		text, err := clipboard.ReadAll()
+		if err != nil {
+			term.OutputErrorAndExit("Error reading clipboard: %v", err)
+		}
+		body = text
+	} else if draftEditor {
+		body = cleanTemplatePrompt(getEditorPromptWithText(promptTemplate))
+	} else {
+		body = getEditorPrompt()
+	}
+
+	if strings.TrimSpace(body) == "" {
+		fmt.Println("🤷‍♂️ No message to add")
+		return
+	}
+
+	messages, err := lib.AddDraftMessage(body)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error adding draft message: %v", err)
+	}
+
+	fmt.Printf("✅ Added message %d to the draft\n", len(messages))
+	fmt.Println()
+
+	term.PrintCmds("", "draft", "draft show", "tell --draft")
+}
+
+func showDraft(cmd *cobra.Command, args []string) {
+	messages := mustLoadDraftForPlan()
+
+	if len(messages) == 0 {
+		fmt.Println("🤷‍♂️ No draft for this plan")
+		return
+	}
+
+	fmt.Println(lib.DraftPrompt(messages))
+	fmt.Println()
+
+	term.PrintCmds("", "tell --draft", "draft rm")
+}
+
+func rmDraft(cmd *cobra.Command, args []string) {
+	messages := mustLoadDraftForPlan()
+
+	n, err := strconv.Atoi(args[0])
+
+	if err != nil || n < 1 || n > len(messages) {
+		term.OutputErrorAndExit("No draft message #%s", args[0])
+	}
+
+	err = lib.RemoveDraftMessage(messages[n-1].Id)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error removing draft message: %v", err)
+	}
+
+	fmt.Printf("✅ Removed message %d from the draft\n", n)
+}
+
+func clearDraft(cmd *cobra.Command, args []string) {
+	mustResolvePlanForDraft()
+
+	err := lib.ClearDraft()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error clearing draft: %v", err)
+	}
+
+	fmt.Println("✅ Cleared the draft")
+}
+
+func mustResolvePlanForDraft() {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+}
+
+func mustLoadDraftForPlan() []*types.DraftMessage {
+	mustResolvePlanForDraft()
+
+	messages, err := lib.LoadDraft()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading draft: %v", err)
+	}
+
+	return messages
+}
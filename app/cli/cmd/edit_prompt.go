@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var editPromptFile string
+
+var editPromptCmd = &cobra.Command{
+	Use:   "edit-prompt [message-num] [prompt]",
+	Short: "Edit an earlier prompt and mark everything after it stale",
+	Long:  "Edit an earlier prompt in the conversation (use the message numbers from 'plandex convo'). Every later message, and any changes built from their replies, are marked stale. Use 'plandex rerun' to replay the plan from the edited prompt, or 'plandex rewind' to undo the edit.",
+	Args:  cobra.RangeArgs(1, 2),
+	Run:   editPrompt,
+}
+
+func init() {
+	RootCmd.AddCommand(editPromptCmd)
+
+	editPromptCmd.Flags().StringVarP(&editPromptFile, "file", "f", "", "File containing the edited prompt")
+}
+
+func editPrompt(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	num, err := strconv.Atoi(args[0])
+	if err != nil || num < 1 {
+		term.OutputErrorAndExit("Invalid message number: %s", args[0])
+	}
+
+	var prompt string
+
+	if len(args) > 1 {
+		prompt = args[1]
+	} else if editPromptFile != "" {
+		bytes, err := os.ReadFile(editPromptFile)
+		if err != nil {
+			term.OutputErrorAndExit("Error reading prompt file: %v", err)
+		}
+		prompt = string(bytes)
+	} else {
+		term.StartSpinner("")
+		conversation, apiErr := api.Client.ListConvo(lib.CurrentPlanId, lib.CurrentBranch)
+		term.StopSpinner()
+
+		if apiErr != nil {
+			term.OutputErrorAndExit("Error loading conversation: %v", apiErr.Msg)
+		}
+
+		var current string
+		for _, msg := range conversation {
+			if msg.Num == num {
+				current = msg.Message
+				break
+			}
+		}
+
+		prompt = getEditorPromptWithText(current)
+	}
+
+	prompt = strings.TrimSpace(prompt)
+
+	if prompt == "" {
+		fmt.Println("🤷‍♂️ No prompt to save")
+		return
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.EditMessage(lib.CurrentPlanId, lib.CurrentBranch, shared.EditMessageRequest{
+		Num:     num,
+		Message: prompt,
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error editing prompt: %v", apiErr.Msg)
+	}
+
+	fmt.Printf("✏️  Edited message #%d\n", num)
+
+	if res.NumStaleMessages > 0 {
+		fmt.Printf("🕸️  %d later messages", res.NumStaleMessages)
+		if res.NumStaleResults > 0 {
+			fmt.Printf(" and %d file changes", res.NumStaleResults)
+		}
+		fmt.Println(" marked stale")
+	}
+
+	fmt.Println()
+	term.PrintCmds("", "rerun", "convo", "rewind")
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var explorePromptFile string
+var exploreModelPacks []string
+var exploreVariants []string
+var exploreNumBranches int
+var exploreNoBuild bool
+
+var exploreCmd = &cobra.Command{
+	Use:   "explore [prompt]",
+	Short: "Send a prompt on several branches at once and compare the results",
+	Long: `Send a prompt on several new branches forked from the current branch, each with a different model pack or prompt variant, and compare the results when they finish.
+
+When both --model-pack and --variant are set, a branch is created for each combination of the two.`,
+	Args: cobra.RangeArgs(0, 1),
+	Run:  explore,
+}
+
+func init() {
+	RootCmd.AddCommand(exploreCmd)
+
+	exploreCmd.Flags().StringVarP(&explorePromptFile, "file", "f", "", "File containing prompt")
+	exploreCmd.Flags().StringArrayVarP(&exploreModelPacks, "model-pack", "m", nil, "Model pack to use on a branch; repeat for each branch")
+	exploreCmd.Flags().StringArrayVar(&exploreVariants, "variant", nil, "Extra instructions to add to the prompt on a branch; repeat for each branch")
+	exploreCmd.Flags().IntVarP(&exploreNumBranches, "branches", "b", 2, "Number of branches to run when no model packs or variants are given")
+	exploreCmd.Flags().BoolVarP(&exploreNoBuild, "no-build", "n", false, "Don't build files")
+}
+
+func explore(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	var prompt string
+	if len(args) > 0 {
+		prompt = args[0]
+	} else if explorePromptFile != "" {
+		bytes, err := os.ReadFile(explorePromptFile)
+		if err != nil {
+			term.OutputErrorAndExit("Error reading prompt file: %v", err)
+		}
+		prompt = string(bytes)
+	} else {
+		prompt = getEditorPrompt()
+	}
+
+	if prompt == "" {
+		fmt.Println("🤷‍♂️ No prompt to send")
+		return
+	}
+
+	modelPacks := resolveModelPacks(exploreModelPacks)
+
+	variants := getExploreVariants(prompt, modelPacks)
+
+	if len(variants) < 2 {
+		term.OutputErrorAndExit("Explore needs at least 2 branches to compare")
+	}
+
+	if len(variants) > lib.MaxExploreBranches {
+		term.OutputErrorAndExit("Explore can run at most %d branches at once, but %d were requested", lib.MaxExploreBranches, len(variants))
+	}
+
+	apiKeys := lib.MustVerifyApiKeysForModelPacks(modelPacks)
+
+	term.StartSpinner("")
+	contexts, apiErr := api.Client.ListContext(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting context: %v", apiErr.Msg)
+	}
+
+	anyOutdated, didUpdate := lib.MustCheckOutdatedContext(false, contexts)
+	if anyOutdated && !didUpdate {
+		fmt.Println("Prompt not sent")
+		return
+	}
+
+	term.StartSpinner("")
+	branches, apiErr := api.Client.ListBranches(lib.CurrentPlanId)
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting branches: %v", apiErr.Msg)
+	}
+
+	branchNames := lib.NextExploreBranchNames(branches, len(variants))
+	for i, variant := range variants {
+		variant.Branch = branchNames[i]
+	}
+
+	term.UpdateSpinnerMsg(fmt.Sprintf("🌱 Starting %d branches...", len(variants)))
+	err := lib.StartExplore(apiKeys, contexts, variants, exploreNoBuild)
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error starting explore: %v", err)
+	}
+
+	term.StartSpinner(fmt.Sprintf("Exploring %d branches • 0/%d finished", len(variants), len(variants)))
+	finished, err := lib.WaitForExplore(branchNames)
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error waiting for branches: %v", err)
+	}
+
+	comparisons, err := lib.CompareBranches(lib.CurrentPlanId, finished)
+	term.StopSpinner()
+	if err != nil {
+		term.OutputErrorAndExit("Error comparing branches: %v", err)
+	}
+
+	renderBranchComparison(comparisons, false)
+	fmt.Println()
+
+	var opts []string
+	for _, variant := range variants {
+		opts = append(opts, variant.Branch)
+	}
+	stay := fmt.Sprintf("Stay on %s", lib.CurrentBranch)
+	opts = append(opts, stay)
+
+	selected, err := term.SelectFromList("Checkout the best result?", opts)
+	if err != nil {
+		if err.Error() == "interrupt" {
+			return
+		}
+		term.OutputErrorAndExit("Error selecting branch: %v", err)
+	}
+
+	if selected == stay {
+		fmt.Println()
+		term.PrintCmds("", "compare --diffs", "checkout", "delete-branch")
+		return
+	}
+
+	err = lib.WriteCurrentBranch(selected)
+	if err != nil {
+		term.OutputErrorAndExit("Error setting current branch: %v", err)
+	}
+
+	fmt.Printf("✅ Checked out branch %s\n", color.New(color.Bold, term.ColorHiGreen).Sprint(selected))
+	fmt.Println()
+	term.PrintCmds("", "changes", "diff", "apply", "compare")
+}
+
+// resolveModelPacks looks up model packs by name, checking built-in packs first and then the org's custom packs
+func resolveModelPacks(names []string) []*shared.ModelPack {
+	if len(names) == 0 {
+		return nil
+	}
+
+	var customModelPacks []*shared.ModelPack
+	loadedCustom := false
+
+	var res []*shared.ModelPack
+	for _, name := range names {
+		var modelPack *shared.ModelPack
+		for _, mp := range shared.BuiltInModelPacks {
+			if strings.EqualFold(mp.Name, name) {
+				modelPack = mp
+				break
+			}
+		}
+
+		if modelPack == nil {
+			if !loadedCustom {
+				term.StartSpinner("")
+				var apiErr *shared.ApiError
+				customModelPacks, apiErr = api.Client.ListModelPacks()
+				term.StopSpinner()
+				if apiErr != nil {
+					term.OutputErrorAndExit("Error getting custom model packs: %v", apiErr.Msg)
+				}
+				loadedCustom = true
+			}
+
+			for _, mp := range customModelPacks {
+				if strings.EqualFold(mp.Name, name) {
+					modelPack = mp
+					break
+				}
+			}
+		}
+
+		if modelPack == nil {
+			term.OutputErrorAndExit("Model pack %s not found", name)
+		}
+
+		res = append(res, modelPack)
+	}
+
+	return res
+}
+
+// getExploreVariants returns a variant for each combination of model pack and prompt variant, or exploreNumBranches copies of the prompt when neither is set
+func getExploreVariants(prompt string, modelPacks []*shared.ModelPack) []*lib.ExploreVariant {
+	if len(modelPacks) == 0 && len(exploreVariants) == 0 {
+		var res []*lib.ExploreVariant
+		for i := 0; i < exploreNumBranches; i++ {
+			res = append(res, &lib.ExploreVariant{Prompt: prompt})
+		}
+		return res
+	}
+
+	packs := modelPacks
+	if len(packs) == 0 {
+		packs = []*shared.ModelPack{nil}
+	}
+
+	prompts := []string{prompt}
+	if len(exploreVariants) > 0 {
+		prompts = nil
+		for _, variant := range exploreVariants {
+			prompts = append(prompts, prompt+"\n\n"+variant)
+		}
+	}
+
+	var res []*lib.ExploreVariant
+	for _, pack := range packs {
+		for _, p := range prompts {
+			res = append(res, &lib.ExploreVariant{ModelPack: pack, Prompt: p})
+		}
+	}
+	return res
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var impactLast bool
+var impactPlain bool
+
+var impactCmd = &cobra.Command{
+	Use:   "impact",
+	Short: "Write an impact report for pending changes",
+	Args:  cobra.NoArgs,
+	Run:   impact,
+}
+
+func init() {
+	RootCmd.AddCommand(impactCmd)
+
+	impactCmd.Flags().BoolVar(&impactLast, "last", false, "Show the plan's last impact report instead of writing a new one")
+	impactCmd.Flags().BoolVar(&impactPlain, "plain", false, "Output the report as plain markdown, for a PR description")
+}
+
+func impact(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	var report *shared.ImpactReport
+	var apiErr *shared.ApiError
+
+	if impactLast {
+		term.StartSpinner("")
+		report, apiErr = api.Client.GetImpactReport(lib.CurrentPlanId, lib.CurrentBranch)
+		term.StopSpinner()
+
+		if apiErr != nil {
+			if apiErr.Msg == shared.NoImpactReportErr {
+				fmt.Println("🤷‍♂️ No impact report for this plan yet")
+				fmt.Println()
+				term.PrintCmds("", "impact")
+				return
+			}
+			term.OutputErrorAndExit("Error getting impact report: %v", apiErr.Msg)
+		}
+	} else {
+		apiKeys := lib.MustVerifyApiKeysSilent()
+
+		openAIBase := os.Getenv("OPENAI_API_BASE")
+		if openAIBase == "" {
+			openAIBase = os.Getenv("OPENAI_ENDPOINT")
+		}
+
+		term.StartSpinner("")
+		report, apiErr = api.Client.CreateImpactReport(lib.CurrentPlanId, lib.CurrentBranch, shared.ImpactReportRequest{
+			ApiKeys:     apiKeys,
+			OpenAIBase:  openAIBase,
+			OpenAIOrgId: os.Getenv("OPENAI_ORG_ID"),
+		})
+		term.StopSpinner()
+
+		if apiErr != nil {
+			if apiErr.Msg == shared.NoPendingChangesErr {
+				fmt.Println("🤷‍♂️ No pending changes")
+				return
+			}
+			term.OutputErrorAndExit("Error writing impact report: %v", apiErr.Msg)
+		}
+	}
+
+	if impactPlain {
+		fmt.Print(report.ToMarkdown())
+		return
+	}
+
+	md, err := term.GetMarkdown(report.ToMarkdown())
+	if err != nil {
+		term.OutputErrorAndExit("Error formatting markdown: %v", err)
+	}
+	fmt.Println(md)
+
+	term.PrintCmds("", "impact --plain", "apply")
+}
@@ -6,6 +6,7 @@ import (
 	"plandex/auth"
 	"plandex/term"
 
+	"github.com/fatih/color"
 	"github.com/plandex/plandex/shared"
 	"github.com/spf13/cobra"
 )
@@ -17,8 +18,12 @@ var inviteCmd = &cobra.Command{
 	Args:  cobra.MaximumNArgs(3),
 }
 
+var inviteExpiresIn int
+
 func init() {
 	RootCmd.AddCommand(inviteCmd)
+
+	inviteCmd.Flags().IntVar(&inviteExpiresIn, "expires-in", shared.DefaultInviteExpirationDays, "Number of days until the invite expires")
 }
 
 func invite(cmd *cobra.Command, args []string) {
@@ -61,6 +66,10 @@ func invite(cmd *cobra.Command, args []string) {
 	if orgRoleName == "" {
 		var orgRoleNames []string
 		for _, orgRole := range orgRoles {
+			// guests only join with a trial access code
+			if orgRole.Name == "guest" {
+				continue
+			}
 			orgRoleNames = append(orgRoleNames, orgRole.Label)
 		}
 
@@ -85,17 +94,37 @@ func invite(cmd *cobra.Command, args []string) {
 	}
 
 	inviteRequest := shared.InviteRequest{
-		Email:     email,
-		Name:      name,
-		OrgRoleId: orgRoleId,
+		Email:         email,
+		Name:          name,
+		OrgRoleId:     orgRoleId,
+		ExpiresInDays: inviteExpiresIn,
 	}
 
 	term.StartSpinner("")
-	apiErr := api.Client.InviteUser(inviteRequest)
+	res, apiErr := api.Client.InviteUser(inviteRequest)
 	term.StopSpinner()
 	if apiErr != nil {
 		term.OutputErrorAndExit("Failed to invite user: %s", apiErr.Msg)
 	}
 
-	fmt.Println("✅ Invite sent")
+	if res.EmailSent {
+		fmt.Printf("✅ Invite sent to %s\n", email)
+	} else {
+		fmt.Printf("✅ Invite created for %s\n", email)
+	}
+
+	printInviteToken(res)
+}
+
+// printInviteToken shows how to accept an invite with its token. The token can't be shown again, only replaced with 'plandex invites resend'.
+func printInviteToken(res *shared.InviteResponse) {
+	fmt.Printf("Expires %s\n\n", res.ExpiresAt.Local().Format("January 2, 2006"))
+
+	if res.EmailSent {
+		fmt.Println("They can sign in with their email to join, or accept with any account by running:")
+	} else {
+		fmt.Println("Email isn't set up on this server, so share this command with them. They can run it after signing in with any account:")
+	}
+
+	fmt.Println(color.New(color.Bold, term.ColorHiCyan).Sprintf("plandex invites accept %s", res.Token))
 }
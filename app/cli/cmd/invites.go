@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/term"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var resendExpiresIn int
+
+func init() {
+	RootCmd.AddCommand(invitesCmd)
+	invitesCmd.AddCommand(resendInviteCmd)
+	invitesCmd.AddCommand(acceptInviteCmd)
+
+	resendInviteCmd.Flags().IntVar(&resendExpiresIn, "expires-in", shared.DefaultInviteExpirationDays, "Number of days until the resent invite expires")
+}
+
+var invitesCmd = &cobra.Command{
+	Use:   "invites",
+	Short: "List the org's pending invites",
+	Args:  cobra.NoArgs,
+	Run:   listInvites,
+}
+
+var resendInviteCmd = &cobra.Command{
+	Use:   "resend [email]",
+	Short: "Resend a pending invite with a new token and expiration",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   resendInvite,
+}
+
+var acceptInviteCmd = &cobra.Command{
+	Use:   "accept <token>",
+	Short: "Join an org with an invite token",
+	Args:  cobra.ExactArgs(1),
+	Run:   acceptInvite,
+}
+
+func listInvites(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	invites, apiErr := api.Client.ListPendingInvites()
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error listing invites: %v", apiErr.Msg)
+	}
+
+	orgRoles, apiErr := api.Client.ListOrgRoles()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error listing org roles: %v", apiErr.Msg)
+	}
+
+	if len(invites) == 0 {
+		fmt.Println("🤷‍♂️ No pending invites")
+		fmt.Println()
+		term.PrintCmds("", "invite")
+		return
+	}
+
+	roleLabelsById := map[string]string{}
+	for _, role := range orgRoles {
+		roleLabelsById[role.Id] = role.Label
+	}
+
+	now := time.Now()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Email", "Name", "Role", "Invited", "Expires", "Emailed"})
+
+	for _, invite := range invites {
+		expires := "never"
+		if invite.ExpiresAt != nil {
+			expires = invite.ExpiresAt.Local().Format("Jan 2, 2006")
+		}
+		if invite.IsExpired(now) {
+			expires = color.New(term.ColorHiRed).Sprint("expired")
+		}
+
+		emailed := "no"
+		if invite.EmailSentAt != nil {
+			emailed = "yes"
+		}
+
+		table.Append([]string{
+			invite.Email,
+			invite.Name,
+			roleLabelsById[invite.OrgRoleId],
+			invite.CreatedAt.Local().Format("Jan 2, 2006"),
+			expires,
+			emailed,
+		})
+	}
+
+	table.Render()
+	fmt.Println()
+
+	term.PrintCmds("", "invites resend", "revoke", "invite")
+}
+
+func resendInvite(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	invites, apiErr := api.Client.ListPendingInvites()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error listing invites: %v", apiErr.Msg)
+	}
+
+	if len(invites) == 0 {
+		term.OutputErrorAndExit("No pending invites")
+	}
+
+	var invite *shared.Invite
+
+	if len(args) > 0 {
+		for _, i := range invites {
+			if strings.EqualFold(i.Email, args[0]) {
+				invite = i
+				break
+			}
+		}
+
+		if invite == nil {
+			term.OutputErrorAndExit("No pending invite for '%s'", args[0])
+		}
+	} else {
+		var options []string
+		for _, i := range invites {
+			options = append(options, fmt.Sprintf("%s <%s>", i.Name, i.Email))
+		}
+
+		selected, err := term.SelectFromList("Select an invite to resend:", options)
+
+		if err != nil {
+			term.OutputErrorAndExit("Error selecting invite: %v", err)
+		}
+
+		for idx, opt := range options {
+			if opt == selected {
+				invite = invites[idx]
+				break
+			}
+		}
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.ResendInvite(invite.Id, shared.ResendInviteRequest{ExpiresInDays: resendExpiresIn})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error resending invite: %v", apiErr.Msg)
+	}
+
+	if res.EmailSent {
+		fmt.Printf("✅ Invite resent to %s\n", invite.Email)
+	} else {
+		fmt.Printf("✅ Invite renewed for %s\n", invite.Email)
+	}
+	fmt.Println("The previous invite token no longer works.")
+
+	printInviteToken(res)
+}
+
+func acceptInvite(cmd *cobra.Command, args []string) {
+	auth.MustResolveDefaultAuth()
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.AcceptInvite(shared.AcceptInviteRequest{Token: args[0]})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error accepting invite: %v", apiErr.Msg)
+	}
+
+	fmt.Printf("✅ Joined %s as %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(res.OrgName), auth.Current.Email)
+	fmt.Println()
+
+	if auth.Current.OrgId == res.OrgId {
+		return
+	}
+
+	shouldSwitch, err := term.ConfirmYesNo(fmt.Sprintf("Make %s your default org?", res.OrgName))
+
+	if err != nil {
+		term.OutputErrorAndExit("Error prompting to switch org: %v", err)
+	}
+
+	if shouldSwitch {
+		account := auth.Current.ClientAccount
+		err = auth.SetDefaultOrg(&auth.AccountOrg{
+			Account: &account,
+			Org:     &shared.Org{Id: res.OrgId, Name: res.OrgName},
+		})
+
+		if err != nil {
+			term.OutputErrorAndExit("Error switching org: %v", err)
+		}
+
+		fmt.Printf("✅ Default org is now %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(res.OrgName))
+		fmt.Println()
+	}
+
+	term.PrintCmds("", "orgs", "orgs bind")
+}
@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/format"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var knowledgeTitle string
+var knowledgeKind string
+var knowledgeNote string
+
+func init() {
+	RootCmd.AddCommand(knowledgeCmd)
+	knowledgeCmd.AddCommand(addKnowledgeCmd)
+	knowledgeCmd.AddCommand(saveKnowledgeCmd)
+	knowledgeCmd.AddCommand(showKnowledgeCmd)
+	knowledgeCmd.AddCommand(rmKnowledgeCmd)
+	knowledgeCmd.AddCommand(searchKnowledgeCmd)
+
+	addKnowledgeCmd.Flags().StringVarP(&knowledgeTitle, "title", "t", "", "Title of the entry (defaults to the file name)")
+	addKnowledgeCmd.Flags().StringVarP(&knowledgeKind, "kind", "k", string(shared.KnowledgeKindNote), "Kind of entry: note or adr")
+	addKnowledgeCmd.Flags().StringVarP(&knowledgeNote, "note", "n", "", "Text of the entry, instead of a file")
+}
+
+var knowledgeCmd = &cobra.Command{
+	Use:   "knowledge",
+	Short: "List the org's knowledge base",
+	Long:  "List your org's knowledge base: notes, architecture decision records, and summaries of past plans. When you send a prompt, the planner searches the knowledge base and adds the entries that are relevant to its context.",
+	Args:  cobra.NoArgs,
+	Run:   listKnowledge,
+}
+
+var addKnowledgeCmd = &cobra.Command{
+	Use:   "add [file]",
+	Short: "Add a note or ADR to the knowledge base",
+	Long:  "Add a file to the knowledge base, or a short note with --note. Entries are embedded with your OpenAI key so they can be found by meaning, not just by keyword.",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   addKnowledge,
+}
+
+var saveKnowledgeCmd = &cobra.Command{
+	Use:   "save [title]",
+	Short: "Save the current plan's summary to the knowledge base",
+	Long:  "Save the latest summary of the current plan to the knowledge base, so future plans can learn from it. Saving the same plan again replaces its entry. The title defaults to the plan's name.",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   saveKnowledge,
+}
+
+var showKnowledgeCmd = &cobra.Command{
+	Use:   "show <id or number>",
+	Short: "Show a knowledge base entry",
+	Args:  cobra.ExactArgs(1),
+	Run:   showKnowledge,
+}
+
+var rmKnowledgeCmd = &cobra.Command{
+	Use:   "rm <id or number>",
+	Short: "Remove a knowledge base entry",
+	Args:  cobra.ExactArgs(1),
+	Run:   rmKnowledge,
+}
+
+var searchKnowledgeCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search the knowledge base",
+	Long:  "Search the knowledge base the same way the planner does: by meaning when an OpenAI key is available, and by keyword otherwise.",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   searchKnowledge,
+}
+
+func listKnowledge(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	entries, apiErr := api.Client.ListKnowledgeEntries()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting knowledge base: %v", apiErr.Msg)
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("🤷‍♂️ Your org's knowledge base is empty")
+		fmt.Println()
+		term.PrintCmds("", "knowledge add", "knowledge save")
+		return
+	}
+
+	color.New(color.Bold, term.ColorHiCyan).Println("🧠 Knowledge Base")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"#", "Title", "Kind", "Tokens", "Added By", "Updated"})
+
+	for i, entry := range entries {
+		table.Append([]string{
+			strconv.Itoa(i + 1),
+			color.New(color.Bold, term.ColorHiGreen).Sprint(entry.Title),
+			string(entry.Kind),
+			strconv.Itoa(entry.NumTokens),
+			entry.CreatedBy,
+			format.Time(entry.UpdatedAt),
+		})
+	}
+
+	table.Render()
+	fmt.Println()
+
+	term.PrintCmds("", "knowledge show", "knowledge search", "knowledge add")
+}
+
+func addKnowledge(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	kind, err := shared.ParseKnowledgeKind(knowledgeKind)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+		return
+	}
+	if kind == shared.KnowledgeKindPlanSummary {
+		term.OutputErrorAndExit("Plan summaries are saved from a plan with 'plandex knowledge save'")
+		return
+	}
+
+	var body string
+	title := knowledgeTitle
+
+	if len(args) == 1 {
+		if knowledgeNote != "" {
+			term.OutputErrorAndExit("Pass either a file or --note, not both")
+			return
+		}
+
+		bytes, err := os.ReadFile(args[0])
+		if err != nil {
+			term.OutputErrorAndExit("Error reading %s: %v", args[0], err)
+			return
+		}
+		if !utf8.Valid(bytes) {
+			term.OutputErrorAndExit("%s isn't a UTF-8 text file", args[0])
+			return
+		}
+
+		body = string(bytes)
+		if title == "" {
+			title = filepath.Base(args[0])
+		}
+	} else if knowledgeNote != "" {
+		body = knowledgeNote
+	} else {
+		term.OutputErrorAndExit("Pass a file or a --note to add")
+		return
+	}
+
+	if title == "" {
+		term.OutputErrorAndExit("A --title is required for notes")
+		return
+	}
+
+	entry := &shared.KnowledgeEntry{
+		Kind:  kind,
+		Title: title,
+		Body:  body,
+	}
+
+	err = entry.Validate()
+	if err != nil {
+		term.OutputErrorAndExit("Invalid knowledge entry: %v", err)
+		return
+	}
+
+	apiKeys, openAIBase, openAIOrgId := knowledgeEmbeddingCredentials()
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.CreateKnowledgeEntry(shared.CreateKnowledgeEntryRequest{
+		Entry:       entry,
+		ApiKeys:     apiKeys,
+		OpenAIBase:  openAIBase,
+		OpenAIOrgId: openAIOrgId,
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error adding knowledge entry: %v", apiErr.Msg)
+		return
+	}
+
+	printSavedKnowledgeEntry(res)
+}
+
+func saveKnowledge(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	var title string
+	if len(args) == 1 {
+		title = args[0]
+	}
+
+	apiKeys := lib.MustVerifyApiKeysSilent()
+
+	openAIBase := os.Getenv("OPENAI_API_BASE")
+	if openAIBase == "" {
+		openAIBase = os.Getenv("OPENAI_ENDPOINT")
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.SavePlanKnowledge(lib.CurrentPlanId, lib.CurrentBranch, shared.SavePlanKnowledgeRequest{
+		Title:       title,
+		ApiKeys:     apiKeys,
+		OpenAIBase:  openAIBase,
+		OpenAIOrgId: os.Getenv("OPENAI_ORG_ID"),
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error saving plan to knowledge base: %v", apiErr.Msg)
+		return
+	}
+
+	printSavedKnowledgeEntry(res)
+}
+
+func printSavedKnowledgeEntry(entry *shared.KnowledgeEntry) {
+	fmt.Printf("✅ Saved %s to the knowledge base | %d 🪙\n", color.New(color.Bold, term.ColorHiGreen).Sprint(entry.Title), entry.NumTokens)
+	if !entry.HasEmbedding {
+		fmt.Println()
+		fmt.Println("It couldn't be embedded, so until it is, it'll only be found by keyword. Set OPENAI_API_KEY or add an OpenAI credential to your org to search by meaning.")
+	}
+	fmt.Println()
+
+	term.PrintCmds("", "knowledge", "knowledge search")
+}
+
+func showKnowledge(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	id := resolveKnowledgeEntryId(args[0])
+
+	term.StartSpinner("")
+	entry, apiErr := api.Client.GetKnowledgeEntry(id)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting knowledge entry: %v", apiErr.Msg)
+		return
+	}
+
+	color.New(color.Bold, term.ColorHiGreen).Println(entry.Title)
+	fmt.Println(color.New(color.FgHiWhite).Sprintf("%s | %d 🪙 | added by %s | updated %s", entry.Kind, entry.NumTokens, entry.CreatedBy, format.Time(entry.UpdatedAt)))
+	fmt.Println()
+	fmt.Println(strings.TrimSpace(entry.Body))
+}
+
+func rmKnowledge(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	id := resolveKnowledgeEntryId(args[0])
+
+	term.StartSpinner("")
+	apiErr := api.Client.DeleteKnowledgeEntry(id)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error removing knowledge entry: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Println("✅ Removed knowledge entry")
+}
+
+func searchKnowledge(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	query := strings.Join(args, " ")
+	apiKeys, openAIBase, openAIOrgId := knowledgeEmbeddingCredentials()
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.SearchKnowledge(shared.SearchKnowledgeRequest{
+		Query:       query,
+		ApiKeys:     apiKeys,
+		OpenAIBase:  openAIBase,
+		OpenAIOrgId: openAIOrgId,
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error searching knowledge base: %v", apiErr.Msg)
+		return
+	}
+
+	if len(res.Results) == 0 {
+		fmt.Println("🤷‍♂️ No matching knowledge entries")
+		return
+	}
+
+	for _, result := range res.Results {
+		match := fmt.Sprintf("%.2f", result.Score)
+		if result.IsKeywordMatch {
+			match = "keyword"
+		}
+
+		fmt.Printf("%s %s\n", color.New(color.Bold, term.ColorHiGreen).Sprint(result.Entry.Title), color.New(color.FgHiWhite).Sprintf("(%s | %s | %s)", result.Entry.Kind, match, result.Entry.Id))
+		fmt.Println(result.Snippet)
+		fmt.Println()
+	}
+
+	if !res.UsedEmbeddings {
+		fmt.Println("Results were matched by keyword. Set OPENAI_API_KEY or add an OpenAI credential to your org to search by meaning.")
+		fmt.Println()
+	}
+
+	term.PrintCmds("", "knowledge show")
+}
+
+// knowledgeEmbeddingCredentials returns the OpenAI credentials in the environment, if any, for embedding entries and queries outside of a plan. Without them, the server uses the org's OpenAI credential, or falls back to keyword search.
+func knowledgeEmbeddingCredentials() (map[string]string, string, string) {
+	apiKeys := map[string]string{}
+	if key := os.Getenv(shared.OpenAIEnvVar); key != "" {
+		apiKeys[shared.OpenAIEnvVar] = key
+	}
+
+	openAIBase := os.Getenv("OPENAI_API_BASE")
+	if openAIBase == "" {
+		openAIBase = os.Getenv("OPENAI_ENDPOINT")
+	}
+
+	return apiKeys, openAIBase, os.Getenv("OPENAI_ORG_ID")
+}
+
+// resolveKnowledgeEntryId accepts an entry's id, or its number in 'plandex knowledge'
+func resolveKnowledgeEntryId(arg string) string {
+	num, err := strconv.Atoi(arg)
+	if err != nil {
+		return arg
+	}
+
+	term.StartSpinner("")
+	entries, apiErr := api.Client.ListKnowledgeEntries()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting knowledge base: %v", apiErr.Msg)
+	}
+
+	if num < 1 || num > len(entries) {
+		term.OutputErrorAndExit("No knowledge entry #%d", num)
+	}
+
+	return entries[num-1].Id
+}
@@ -6,6 +6,7 @@ import (
 	"plandex/lib"
 	"plandex/term"
 	"plandex/types"
+	"strings"
 
 	"github.com/sashabaranov/go-openai"
 	"github.com/spf13/cobra"
@@ -15,6 +16,7 @@ var (
 	recursive       bool
 	namesOnly       bool
 	note            string
+	contextName     string
 	forceSkipIgnore bool
 	imageDetail     string
 )
@@ -29,6 +31,7 @@ var contextLoadCmd = &cobra.Command{
 
 func init() {
 	contextLoadCmd.Flags().StringVarP(&note, "note", "n", "", "Add a note to the context")
+	contextLoadCmd.Flags().StringVar(&contextName, "name", "", "Name for piped data (or the note if nothing is piped) instead of a generated one")
 	contextLoadCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Search directories recursively")
 	contextLoadCmd.Flags().BoolVar(&namesOnly, "tree", false, "Load directory tree with file names only")
 	contextLoadCmd.Flags().BoolVarP(&forceSkipIgnore, "force", "f", false, "Load files even when ignored by .gitignore or .plandexignore")
@@ -45,13 +48,66 @@ func contextLoad(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	lib.MustLoadContext(args, &types.LoadContextParams{
+	params := &types.LoadContextParams{
 		Note:            note,
+		Name:            strings.TrimSpace(contextName),
 		Recursive:       recursive,
 		NamesOnly:       namesOnly,
 		ForceSkipIgnore: forceSkipIgnore,
 		ImageDetail:     openai.ImageURLDetail(imageDetail),
-	})
+	}
+
+	// when the server can't be reached, the load is queued instead of lost
+	term.StartSpinner("")
+	serverReachable := lib.IsServerReachable()
+	term.StopSpinner()
+
+	var hasQueued bool
+	if serverReachable {
+		queued, err := lib.LoadBranchOfflineQueue()
+
+		if err != nil {
+			term.OutputErrorAndExit("Error loading offline queue: %v", err)
+		}
+
+		hasQueued = len(queued) > 0
+	}
+
+	if !serverReachable || hasQueued {
+		// piped data is read now so it's kept if the load ends up queued
+		pipedData, err := lib.ReadPipedData()
+
+		if err != nil {
+			term.OutputErrorAndExit("Error reading piped data: %v", err)
+		}
+
+		action := &types.QueuedAction{
+			Type:       types.QueuedActionLoad,
+			Resources:  args,
+			LoadParams: params,
+			PipedData:  pipedData,
+		}
+
+		if !serverReachable {
+			lib.MustQueueAction(action)
+			return
+		}
+
+		if maybeSendQueuedFirst(action, nil) {
+			return
+		}
+
+		if pipedData != "" {
+			// the queue wasn't sent, so load the data that was already read from stdin
+			action.Dir = ""
+			lib.MustReplayQueuedLoad(action)
+			fmt.Println()
+			term.PrintCmds("", "ls", "tell")
+			return
+		}
+	}
+
+	lib.MustLoadContext(args, params)
 
 	fmt.Println()
 	term.PrintCmds("", "ls", "tell")
@@ -12,6 +12,7 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
 	"github.com/spf13/cobra"
 )
 
@@ -28,13 +29,24 @@ func listContext(cmd *cobra.Command, args []string) {
 
 	term.StartSpinner("")
 	contexts, err := api.Client.ListContext(lib.CurrentPlanId, lib.CurrentBranch)
-	term.StopSpinner()
 
 	if err != nil {
+		term.StopSpinner()
 		term.OutputErrorAndExit("Error listing context: %v", err)
 	}
 
+	settings, err := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error getting settings: %v", err)
+	}
+
+	// counts are shown for the planner model's tokenizer, since that's what the planner's token limit applies to
+	tokenizer := settings.GetPlannerTokenizer()
+
 	totalTokens := 0
+	numEstimated := 0
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"#", "Name", "Type", "🪙", "Added", "Updated"})
 	table.SetAutoWrapText(false)
@@ -47,7 +59,14 @@ func listContext(cmd *cobra.Command, args []string) {
 	}
 
 	for i, context := range contexts {
-		totalTokens += context.NumTokens
+		numTokens, exact := context.GetNumTokensFor(tokenizer)
+		totalTokens += numTokens
+
+		tokensLabel := strconv.Itoa(numTokens)
+		if !exact {
+			tokensLabel = "~" + tokensLabel
+			numEstimated++
+		}
 
 		t, icon := context.TypeAndIcon()
 
@@ -60,7 +79,7 @@ func listContext(cmd *cobra.Command, args []string) {
 			strconv.Itoa(i + 1),
 			" " + icon + " " + name,
 			t,
-			strconv.Itoa(context.NumTokens), //+ " 🪙",
+			tokensLabel, //+ " 🪙",
 			format.Time(context.CreatedAt),
 			format.Time(context.UpdatedAt),
 		}
@@ -74,10 +93,28 @@ func listContext(cmd *cobra.Command, args []string) {
 
 	tokensTbl := tablewriter.NewWriter(os.Stdout)
 	tokensTbl.SetAutoWrapText(false)
-	tokensTbl.Append([]string{color.New(term.ColorHiCyan, color.Bold).Sprintf("Total tokens →") + color.New(color.Bold).Sprintf(" %d 🪙", totalTokens)})
+	totalLabel := strconv.Itoa(totalTokens)
+	if numEstimated > 0 {
+		totalLabel = "~" + totalLabel
+	}
+	tokensTbl.Append([]string{color.New(term.ColorHiCyan, color.Bold).Sprintf("Total tokens →") + color.New(color.Bold).Sprintf(" %s 🪙", totalLabel)})
 
 	tokensTbl.Render()
 
+	modelPack := settings.ModelPack
+	if modelPack == nil {
+		modelPack = shared.DefaultModelPack
+	}
+	plannerModel := modelPack.Planner.BaseModelConfig.ModelName
+	if tokenizer.IsEstimate() {
+		fmt.Printf("~ Tokens are estimates for the %s tokenizer used by %s, since its vocabulary isn't available\n", shared.TokenizerLabels[tokenizer], plannerModel)
+	} else if numEstimated > 0 {
+		fmt.Printf("Tokens counted with the %s tokenizer used by %s\n", shared.TokenizerLabels[tokenizer], plannerModel)
+		fmt.Printf("~ Estimated for context that hasn't changed since before %s counts were stored\n", shared.TokenizerLabels[tokenizer])
+	} else {
+		fmt.Printf("Tokens counted with the %s tokenizer used by %s\n", shared.TokenizerLabels[tokenizer], plannerModel)
+	}
+
 	fmt.Println()
 	term.PrintCmds("", "load", "rm", "clear")
 
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"plandex/api"
@@ -8,6 +9,7 @@ import (
 	"plandex/lib"
 	"plandex/term"
 	"strconv"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
@@ -16,6 +18,8 @@ import (
 )
 
 var customModelPacksOnly bool
+var modelPackExportOut string
+var modelPackImportName string
 
 var modelPacksCmd = &cobra.Command{
 	Use:   "model-packs",
@@ -37,12 +41,38 @@ var deleteModelPackCmd = &cobra.Command{
 	Run:     deleteModelPack,
 }
 
+var exportModelPackCmd = &cobra.Command{
+	Use:   "export [name]",
+	Short: "Export a model pack as JSON",
+	Long: `Export a built-in or custom model pack as JSON so it can be shared and imported into another org with 'plandex model-packs import'.
+
+	plandex model-packs export my-pack -o my-pack.json
+	`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  exportModelPack,
+}
+
+var importModelPackCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import a model pack from a JSON file",
+	Long: `Import a model pack exported with 'plandex model-packs export' as a custom model pack. If a custom model pack with the same name exists, you'll be asked whether to replace it.
+
+	plandex model-packs import my-pack.json
+	`,
+	Args: cobra.ExactArgs(1),
+	Run:  importModelPack,
+}
+
 func init() {
 	RootCmd.AddCommand(modelPacksCmd)
 	modelPacksCmd.AddCommand(createModelPackCmd)
 	modelPacksCmd.AddCommand(deleteModelPackCmd)
+	modelPacksCmd.AddCommand(exportModelPackCmd)
+	modelPacksCmd.AddCommand(importModelPackCmd)
 
 	modelPacksCmd.Flags().BoolVarP(&customModelPacksOnly, "custom", "c", false, "Only show custom model packs")
+	exportModelPackCmd.Flags().StringVarP(&modelPackExportOut, "out", "o", "", "Write the model pack to a file instead of stdout")
+	importModelPackCmd.Flags().StringVarP(&modelPackImportName, "name", "n", "", "Import the model pack under a different name")
 }
 
 func deleteModelPack(cmd *cobra.Command, args []string) {
@@ -168,7 +198,7 @@ func listModelPacks(cmd *cobra.Command, args []string) {
 	} else if len(customModelPacks) > 0 {
 		term.PrintCmds("", "model-packs --custom", "model-packs create", "model-packs delete")
 	} else {
-		term.PrintCmds("", "model-packs create")
+		term.PrintCmds("", "model-packs create", "model-packs import")
 	}
 
 }
@@ -211,6 +241,16 @@ func createModelPack(cmd *cobra.Command, args []string) {
 	mp.Verifier = &verifier
 	autoFix := getModelRoleConfig(customModels, shared.ModelRoleAutoFix)
 	mp.AutoFix = &autoFix
+	draftBuilder := getModelRoleConfig(customModels, shared.ModelRoleDraftBuilder)
+	mp.DraftBuilder = &draftBuilder
+	chat := getModelRoleConfig(customModels, shared.ModelRoleChat)
+	mp.Chat = &chat
+
+	err = mp.Validate()
+	if err != nil {
+		term.OutputErrorAndExit("Invalid model pack: %v", err)
+		return
+	}
 
 	term.StartSpinner("")
 	apiErr = api.Client.CreateModelPack(mp)
@@ -280,3 +320,152 @@ func getModelForRole(customModels []*shared.AvailableModel, role shared.ModelRol
 	color.New(color.Bold).Printf("Select a model for the %s role 👇\n", role)
 	return lib.SelectModelForRole(customModels, role, false)
 }
+
+func exportModelPack(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	var mp *shared.ModelPack
+
+	if len(args) == 1 {
+		mp = resolveModelPacks(args)[0]
+	} else {
+		term.StartSpinner("")
+		customModelPacks, apiErr := api.Client.ListModelPacks()
+		term.StopSpinner()
+
+		if apiErr != nil {
+			term.OutputErrorAndExit("Error fetching model packs: %v", apiErr.Msg)
+			return
+		}
+
+		var opts []string
+		for _, mp := range shared.BuiltInModelPacks {
+			opts = append(opts, "Built-in | "+mp.Name)
+		}
+		for _, mp := range customModelPacks {
+			opts = append(opts, "Custom | "+mp.Name)
+		}
+
+		selected, err := term.SelectFromList("Select a model pack to export:", opts)
+		if err != nil {
+			term.OutputErrorAndExit("Error selecting model pack: %v", err)
+			return
+		}
+
+		var idx int
+		for i, opt := range opts {
+			if opt == selected {
+				idx = i
+				break
+			}
+		}
+
+		if idx < len(shared.BuiltInModelPacks) {
+			mp = shared.BuiltInModelPacks[idx]
+		} else {
+			mp = customModelPacks[idx-len(shared.BuiltInModelPacks)]
+		}
+	}
+
+	// ids are specific to an org, so they aren't exported
+	exported := *mp
+	exported.Id = ""
+
+	bytes, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		term.OutputErrorAndExit("Error marshalling model pack: %v", err)
+		return
+	}
+
+	if modelPackExportOut == "" {
+		fmt.Println(string(bytes))
+		return
+	}
+
+	err = os.WriteFile(modelPackExportOut, append(bytes, '\n'), 0644)
+	if err != nil {
+		term.OutputErrorAndExit("Error writing %s: %v", modelPackExportOut, err)
+		return
+	}
+
+	fmt.Printf("✅ Exported model pack %s to %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(mp.Name), modelPackExportOut)
+}
+
+func importModelPack(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	bytes, err := os.ReadFile(args[0])
+	if err != nil {
+		term.OutputErrorAndExit("Error reading %s: %v", args[0], err)
+		return
+	}
+
+	var mp shared.ModelPack
+	err = json.Unmarshal(bytes, &mp)
+	if err != nil {
+		term.OutputErrorAndExit("Error parsing model pack JSON: %v", err)
+		return
+	}
+	mp.Id = ""
+
+	if modelPackImportName != "" {
+		mp.Name = modelPackImportName
+	}
+
+	err = mp.Validate()
+	if err != nil {
+		term.OutputErrorAndExit("Invalid model pack: %v", err)
+		return
+	}
+
+	for _, builtIn := range shared.BuiltInModelPacks {
+		if strings.EqualFold(builtIn.Name, mp.Name) {
+			term.OutputErrorAndExit("%s is the name of a built-in model pack. Use --name to import it under a different name.", mp.Name)
+			return
+		}
+	}
+
+	term.StartSpinner("")
+	customModelPacks, apiErr := api.Client.ListModelPacks()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error fetching model packs: %v", apiErr.Msg)
+		return
+	}
+
+	for _, existing := range customModelPacks {
+		if strings.EqualFold(existing.Name, mp.Name) {
+			replace, err := term.ConfirmYesNo(fmt.Sprintf("A custom model pack named %s already exists. Replace it?", existing.Name))
+			if err != nil {
+				term.OutputErrorAndExit("Error confirming: %v", err)
+				return
+			}
+			if !replace {
+				fmt.Println("🤷‍♂️ Model pack not imported. Use --name to import it under a different name.")
+				return
+			}
+			mp.Id = existing.Id
+			break
+		}
+	}
+
+	term.StartSpinner("")
+	if mp.Id == "" {
+		apiErr = api.Client.CreateModelPack(&mp)
+	} else {
+		apiErr = api.Client.UpdateModelPack(&mp)
+	}
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error importing model pack: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Println("✅ Imported model pack", color.New(color.Bold, term.ColorHiCyan).Sprint(mp.Name))
+	fmt.Printf("Use it for the current plan with %s\n", color.New(color.Bold, term.ColorHiCyan).Sprintf("plandex set-model %s", mp.Name))
+	fmt.Println()
+
+	term.PrintCmds("", "model-packs --custom", "set-model default")
+}
@@ -8,6 +8,7 @@ import (
 	"plandex/lib"
 	"plandex/term"
 	"strconv"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
@@ -23,6 +24,7 @@ func init() {
 	modelsCmd.AddCommand(createCustomModelCmd)
 	modelsCmd.AddCommand(deleteCustomModelCmd)
 	modelsCmd.AddCommand(defaultModelsCmd)
+	modelsCmd.AddCommand(resetModelsCmd)
 
 	listAvailableModelsCmd.Flags().BoolVarP(&customModelsOnly, "custom", "c", false, "List custom models only")
 }
@@ -39,6 +41,13 @@ var defaultModelsCmd = &cobra.Command{
 	Run:   defaultModels,
 }
 
+var resetModelsCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Reset current plan model settings to the org-wide defaults",
+	Args:  cobra.NoArgs,
+	Run:   resetModels,
+}
+
 var listAvailableModelsCmd = &cobra.Command{
 	Use:     "available",
 	Aliases: []string{"avail"},
@@ -112,7 +121,7 @@ func createCustomModel(cmd *cobra.Command, args []string) {
 			term.OutputErrorAndExit("Error reading base URL: %v", err)
 			return
 		}
-		model.BaseUrl = baseUrl
+		model.BaseUrl = shared.NormalizeModelBaseUrl(baseUrl)
 	} else {
 		model.BaseUrl = shared.BaseUrlByProvider[model.Provider]
 	}
@@ -131,6 +140,38 @@ func createCustomModel(cmd *cobra.Command, args []string) {
 	}
 	model.ApiKeyEnvVar = apiKeyEnvVar
 
+	// for self-hosted and other custom endpoints, compatibility can be detected instead of answered
+	var probed *shared.ProbeModelEndpointResponse
+	if model.Provider == shared.ModelProviderCustom {
+		shouldProbe, err := term.ConfirmYesNo("Check the endpoint and detect what it supports?")
+		if err != nil {
+			term.OutputErrorAndExit("Error confirming endpoint check: %v", err)
+			return
+		}
+
+		if shouldProbe {
+			req := shared.ProbeModelEndpointRequest{
+				BaseUrl:   model.BaseUrl,
+				ModelName: model.ModelName,
+				ApiKey:    os.Getenv(model.ApiKeyEnvVar),
+			}
+			probed = mustProbeModelEndpoint(req)
+			renderModelProbe(req, probed)
+
+			if !probed.Compatibility.IsOpenAICompatible {
+				addAnyway, err := term.ConfirmYesNo("The endpoint didn't respond to a chat completion. Add the model anyway?")
+				if err != nil {
+					term.OutputErrorAndExit("Error confirming: %v", err)
+					return
+				}
+				if !addAnyway {
+					return
+				}
+				probed = nil
+			}
+		}
+	}
+
 	fmt.Println("Max Tokens is the total maximum context size of the model.")
 
 	maxTokensStr, err := term.GetRequiredUserStringInput("Max Tokens:")
@@ -171,25 +212,34 @@ func createCustomModel(cmd *cobra.Command, args []string) {
 	}
 	model.DefaultReservedOutputTokens = reservedOutputTokens
 
-	model.ModelCompatibility.HasStreaming, err = term.ConfirmYesNo("Is streaming supported?")
-	if err != nil {
-		term.OutputErrorAndExit("Error confirming streaming support: %v", err)
-		return
-	}
-	model.ModelCompatibility.HasJsonResponseMode, err = term.ConfirmYesNo("Is JSON mode supported?")
-	if err != nil {
-		term.OutputErrorAndExit("Error confirming JSON mode support: %v", err)
-		return
-	}
-	model.ModelCompatibility.HasFunctionCalling, err = term.ConfirmYesNo("Is function calling supported?")
-	if err != nil {
-		term.OutputErrorAndExit("Error confirming function calling support: %v", err)
-		return
-	}
-	model.ModelCompatibility.HasStreamingFunctionCalls, err = term.ConfirmYesNo("Are streaming function calls supported?")
-	if err != nil {
-		term.OutputErrorAndExit("Error confirming streaming function calls support: %v", err)
-		return
+	if probed != nil {
+		model.ModelCompatibility = probed.Compatibility
+	} else {
+		model.ModelCompatibility.HasStreaming, err = term.ConfirmYesNo("Is streaming supported?")
+		if err != nil {
+			term.OutputErrorAndExit("Error confirming streaming support: %v", err)
+			return
+		}
+		model.ModelCompatibility.HasJsonResponseMode, err = term.ConfirmYesNo("Is JSON mode supported?")
+		if err != nil {
+			term.OutputErrorAndExit("Error confirming JSON mode support: %v", err)
+			return
+		}
+		model.ModelCompatibility.HasFunctionCalling, err = term.ConfirmYesNo("Is function calling supported?")
+		if err != nil {
+			term.OutputErrorAndExit("Error confirming function calling support: %v", err)
+			return
+		}
+		model.ModelCompatibility.HasStreamingFunctionCalls, err = term.ConfirmYesNo("Are streaming function calls supported?")
+		if err != nil {
+			term.OutputErrorAndExit("Error confirming streaming function calls support: %v", err)
+			return
+		}
+		model.ModelCompatibility.HasStructuredOutputs, err = term.ConfirmYesNo("Are structured outputs (json_schema response format) supported?")
+		if err != nil {
+			term.OutputErrorAndExit("Error confirming structured outputs support: %v", err)
+			return
+		}
 	}
 
 	model.ModelCompatibility.HasImageSupport, err = term.ConfirmYesNo("Is multi-modal image support enabled?")
@@ -229,13 +279,21 @@ func models(cmd *cobra.Command, args []string) {
 	}
 
 	settings, err := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
-	term.StopSpinner()
 
 	if err != nil {
+		term.StopSpinner()
 		term.OutputErrorAndExit("Error getting settings: %v", err)
 		return
 	}
 
+	defaultSettings, err := api.Client.GetOrgDefaultSettings()
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error getting default model settings: %v", err)
+		return
+	}
+
 	title := fmt.Sprintf("%s Model Settings", color.New(color.Bold, term.ColorHiGreen).Sprint(plan.Name))
 
 	table := tablewriter.NewWriter(os.Stdout)
@@ -244,9 +302,79 @@ func models(cmd *cobra.Command, args []string) {
 	table.Render()
 	fmt.Println()
 
-	renderSettings(settings)
+	overridesDefaults := settings.OverridesOrgDefaults(defaultSettings)
+	overriddenRoles := map[shared.ModelRole]bool{}
+	for _, role := range settings.OverriddenRoles(defaultSettings) {
+		overriddenRoles[role] = true
+	}
 
-	term.PrintCmds("", "set-model", "models available", "models default")
+	if overridesDefaults {
+		fmt.Printf("✏️  Branch %s overrides the org-wide default model settings\n", color.New(color.Bold, term.ColorHiCyan).Sprint(lib.CurrentBranch))
+	} else {
+		fmt.Println("✅ Using the org-wide default model settings")
+	}
+	fmt.Println()
+
+	renderSettings(settings, overriddenRoles)
+
+	if overridesDefaults {
+		term.PrintCmds("", "set-model", "models reset", "models available", "models default")
+	} else {
+		term.PrintCmds("", "set-model", "models available", "models default")
+	}
+}
+
+func resetModels(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting settings: %v", apiErr.Msg)
+		return
+	}
+
+	defaultSettings, apiErr := api.Client.GetOrgDefaultSettings()
+
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting default model settings: %v", apiErr.Msg)
+		return
+	}
+
+	if !settings.OverridesOrgDefaults(defaultSettings) {
+		term.StopSpinner()
+		fmt.Println("🤷‍♂️ Already using the org-wide default model settings")
+		return
+	}
+
+	// only model settings are reset; workspaces and other plan settings are kept
+	settings.ModelPack = defaultSettings.ModelPack
+	settings.ModelOverrides = defaultSettings.ModelOverrides
+
+	res, apiErr := api.Client.UpdateSettings(
+		lib.CurrentPlanId,
+		lib.CurrentBranch,
+		shared.UpdateSettingsRequest{
+			Settings: settings,
+		})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating settings: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Println(res.Msg)
+	fmt.Println()
+	term.PrintCmds("", "models", "log")
 }
 
 func defaultModels(cmd *cobra.Command, args []string) {
@@ -268,7 +396,7 @@ func defaultModels(cmd *cobra.Command, args []string) {
 	table.Render()
 	fmt.Println()
 
-	renderSettings(settings)
+	renderSettings(settings, nil)
 
 	term.PrintCmds("", "set-model default", "models available", "models")
 }
@@ -417,7 +545,8 @@ func deleteCustomModel(cmd *cobra.Command, args []string) {
 	term.PrintCmds("", "models available", "models add")
 }
 
-func renderSettings(settings *shared.PlanSettings) {
+// renderSettings shows model settings. Roles in overriddenRoles are marked as overriding the org-wide defaults.
+func renderSettings(settings *shared.PlanSettings, overriddenRoles map[shared.ModelRole]bool) {
 	modelPack := settings.ModelPack
 
 	color.New(color.Bold, term.ColorHiCyan).Println("🎛️  Current Model Pack")
@@ -433,28 +562,64 @@ func renderSettings(settings *shared.PlanSettings) {
 	color.New(color.Bold, term.ColorHiCyan).Println("🤖 Models")
 	table = tablewriter.NewWriter(os.Stdout)
 	table.SetAutoWrapText(false)
-	table.SetHeader([]string{"Role", "Provider", "Model", "Temperature", "Top P"})
+	table.SetHeader([]string{"Role", "Provider", "Model", "Temperature", "Top P", "Other Settings"})
+
+	addModelRow := func(role shared.ModelRole, config shared.ModelRoleConfig) {
+		var other []string
+		if config.MaxResponseTokens != nil {
+			other = append(other, fmt.Sprintf("max-response-tokens %d", *config.MaxResponseTokens))
+		}
+		if config.PresencePenalty != nil {
+			other = append(other, fmt.Sprintf("presence-penalty %.1f", *config.PresencePenalty))
+		}
+		if config.FrequencyPenalty != nil {
+			other = append(other, fmt.Sprintf("frequency-penalty %.1f", *config.FrequencyPenalty))
+		}
+		if config.Seed != nil {
+			other = append(other, fmt.Sprintf("seed %d", *config.Seed))
+		}
+		if config.ReasoningEffort != "" {
+			other = append(other, fmt.Sprintf("reasoning-effort %s", config.ReasoningEffort))
+		}
+
+		temperature := fmt.Sprintf("%.1f", config.Temperature)
+		topP := fmt.Sprintf("%.1f", config.TopP)
+		if config.BaseModelConfig.IsReasoningModel() {
+			temperature = "-"
+			topP = "-"
+		}
+
+		roleLabel := string(role)
+		if overriddenRoles[role] {
+			roleLabel += " ✏️"
+		}
 
-	addModelRow := func(role string, config shared.ModelRoleConfig) {
 		table.Append([]string{
-			role,
+			roleLabel,
 			string(config.BaseModelConfig.Provider),
 			config.BaseModelConfig.ModelName,
-			fmt.Sprintf("%.1f", config.Temperature),
-			fmt.Sprintf("%.1f", config.TopP),
+			temperature,
+			topP,
+			strings.Join(other, ", "),
 		})
 	}
 
-	addModelRow(string(shared.ModelRolePlanner), modelPack.Planner.ModelRoleConfig)
-	addModelRow(string(shared.ModelRolePlanSummary), modelPack.PlanSummary)
-	addModelRow(string(shared.ModelRoleBuilder), modelPack.Builder)
-	addModelRow(string(shared.ModelRoleName), modelPack.Namer)
-	addModelRow(string(shared.ModelRoleCommitMsg), modelPack.CommitMsg)
-	addModelRow(string(shared.ModelRoleExecStatus), modelPack.ExecStatus)
-	addModelRow(string(shared.ModelRoleVerifier), modelPack.GetVerifier())
-	addModelRow(string(shared.ModelRoleAutoFix), modelPack.GetAutoFix())
+	addModelRow(shared.ModelRolePlanner, modelPack.Planner.ModelRoleConfig)
+	addModelRow(shared.ModelRolePlanSummary, modelPack.PlanSummary)
+	addModelRow(shared.ModelRoleBuilder, modelPack.Builder)
+	addModelRow(shared.ModelRoleName, modelPack.Namer)
+	addModelRow(shared.ModelRoleCommitMsg, modelPack.CommitMsg)
+	addModelRow(shared.ModelRoleExecStatus, modelPack.ExecStatus)
+	addModelRow(shared.ModelRoleVerifier, modelPack.GetVerifier())
+	addModelRow(shared.ModelRoleAutoFix, modelPack.GetAutoFix())
+	addModelRow(shared.ModelRoleDraftBuilder, modelPack.GetDraftBuilder())
+	addModelRow(shared.ModelRoleChat, modelPack.GetChat())
 	table.Render()
 
+	if len(overriddenRoles) > 0 {
+		fmt.Println("✏️  overrides the org-wide default for this branch")
+	}
+
 	fmt.Println()
 
 	color.New(color.Bold, term.ColorHiCyan).Println("🧠 Planner Defaults")
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var checkApiKeyEnvVar string
+
+func init() {
+	modelsCmd.AddCommand(checkModelCmd)
+
+	checkModelCmd.Flags().StringVar(&checkApiKeyEnvVar, "key-env", "", "Environment variable with the endpoint's API key, if it needs one")
+}
+
+var checkModelCmd = &cobra.Command{
+	Use:   "check <base-url> <model-name>",
+	Short: "Check what a self-hosted or OpenAI-compatible model endpoint supports",
+	Long: `Check what a self-hosted or OpenAI-compatible model endpoint supports.
+
+The server lists the endpoint's models and makes a tiny request for streaming, JSON mode, function calling, streaming function calls, and structured outputs. Works with vLLM, TGI, and other servers with an OpenAI-compatible API, which is usually served under '/v1' (e.g. http://gpu-1:8000/v1).`,
+	Args: cobra.ExactArgs(2),
+	Run:  checkModel,
+}
+
+func checkModel(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	req := shared.ProbeModelEndpointRequest{
+		BaseUrl:   shared.NormalizeModelBaseUrl(args[0]),
+		ModelName: args[1],
+	}
+
+	if checkApiKeyEnvVar != "" {
+		req.ApiKey = os.Getenv(checkApiKeyEnvVar)
+		if req.ApiKey == "" {
+			term.OutputErrorAndExit("%s isn't set", checkApiKeyEnvVar)
+			return
+		}
+	}
+
+	err := req.Validate()
+	if err != nil {
+		term.OutputErrorAndExit("Invalid endpoint: %v", err)
+		return
+	}
+
+	res := mustProbeModelEndpoint(req)
+
+	renderModelProbe(req, res)
+
+	term.PrintCmds("", "models add")
+}
+
+func mustProbeModelEndpoint(req shared.ProbeModelEndpointRequest) *shared.ProbeModelEndpointResponse {
+	term.StartSpinner("🔎 Checking endpoint...")
+	res, apiErr := api.Client.ProbeModelEndpoint(req)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error checking endpoint: %v", apiErr.Msg)
+		return nil
+	}
+
+	return res
+}
+
+func renderModelProbe(req shared.ProbeModelEndpointRequest, res *shared.ProbeModelEndpointResponse) {
+	color.New(color.Bold, term.ColorHiCyan).Printf("🔎 %s → %s\n", req.BaseUrl, req.ModelName)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Check", "Result"})
+
+	for _, check := range shared.ModelProbeChecks {
+		result := "✅"
+		if !res.Passed(check) {
+			result = "❌"
+			if msg, ok := res.Errors[check]; ok {
+				result += " " + msg
+			} else {
+				result += " skipped"
+			}
+		}
+		table.Append([]string{shared.ModelProbeCheckDescriptions[check], result})
+	}
+
+	table.Render()
+
+	if res.Reachable {
+		fmt.Printf("Responded in %dms\n", res.LatencyMs)
+	}
+
+	if !res.ModelFound && len(res.ServedModels) > 0 {
+		fmt.Printf("Models served by this endpoint: %s\n", strings.Join(res.ServedModels, ", "))
+	}
+
+	fmt.Println()
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"plandex/api"
 	"plandex/auth"
@@ -41,9 +42,15 @@ func new(cmd *cobra.Command, args []string) {
 
 	if apiErr != nil {
 		if apiErr.Type == shared.ApiErrorTypeTrialPlansExceeded {
-			fmt.Fprintf(os.Stderr, "🚨 You've reached the Plandex Cloud anonymous trial limit of %d plans\n", apiErr.TrialPlansExceededError.MaxPlans)
+			upgradeMsg := "Upgrade to an unlimited free account?"
+			if auth.Current.IsCloud {
+				fmt.Fprintf(os.Stderr, "🚨 You've reached the Plandex Cloud anonymous trial limit of %d plans\n", apiErr.TrialPlansExceededError.MaxPlans)
+			} else {
+				fmt.Fprintf(os.Stderr, "🚨 You've reached %s's trial limit of %d plans\n", auth.Current.OrgName, apiErr.TrialPlansExceededError.MaxPlans)
+				upgradeMsg = "Sign up to keep your plans and remove the limit?"
+			}
 
-			res, err := term.ConfirmYesNo("Upgrade to an unlimited free account?")
+			res, err := term.ConfirmYesNo(upgradeMsg)
 
 			if err != nil {
 				term.OutputErrorAndExit("Error prompting upgrade trial: %v", err)
@@ -68,12 +75,20 @@ func new(cmd *cobra.Command, args []string) {
 		term.OutputErrorAndExit("Error setting current plan: %v", err)
 	}
 
+	lib.MustLoadCurrentPlan()
+
 	if name == "" {
 		name = "draft"
 	}
 
 	fmt.Printf("✅ Started new plan %s and set it to current plan\n", color.New(color.Bold, term.ColorHiGreen).Sprint(name))
 
+	if len(res.ContextBundles) > 0 {
+		fmt.Printf("📚 Loaded your org's context bundles: %s\n", strings.Join(res.ContextBundles, ", "))
+	}
+
+	lib.MustApplyProjectConfigToNewPlan(nil, "", "")
+
 	fmt.Println()
 	term.PrintCmds("", "load", "tell", "plans", "current")
 
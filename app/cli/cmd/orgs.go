@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(orgsCmd)
+	orgsCmd.AddCommand(switchOrgCmd)
+	orgsCmd.AddCommand(bindOrgCmd)
+	orgsCmd.AddCommand(unbindOrgCmd)
+}
+
+var orgsCmd = &cobra.Command{
+	Use:   "orgs",
+	Short: "List the orgs of all signed-in accounts",
+	Args:  cobra.NoArgs,
+	Run:   listOrgs,
+}
+
+var switchOrgCmd = &cobra.Command{
+	Use:   "switch [org-name]",
+	Short: "Switch the default org used by projects that aren't bound to one",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   switchOrg,
+}
+
+var bindOrgCmd = &cobra.Command{
+	Use:   "bind [org-name]",
+	Short: "Bind the current project to an org and server",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   bindOrg,
+}
+
+var unbindOrgCmd = &cobra.Command{
+	Use:   "unbind",
+	Short: "Remove the current project's org binding so it uses the default org",
+	Args:  cobra.NoArgs,
+	Run:   unbindOrg,
+}
+
+func listOrgs(cmd *cobra.Command, args []string) {
+	auth.MustResolveDefaultAuth()
+
+	orgs := mustListAccountOrgs()
+
+	defaultBinding := auth.DefaultBinding()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Org", "Server", "Account", ""})
+
+	for _, org := range orgs {
+		binding := org.Binding()
+
+		var labels []string
+		if defaultBinding != nil && sameOrg(binding, defaultBinding) {
+			labels = append(labels, "default")
+		}
+		if auth.ProjectOrg != nil && sameOrg(binding, auth.ProjectOrg) {
+			labels = append(labels, "this project")
+		}
+
+		name := org.Org.Name
+		if len(labels) > 0 {
+			name = color.New(color.Bold, term.ColorHiGreen).Sprint(name)
+		}
+
+		table.Append([]string{name, binding.HostLabel(), org.Account.Email, strings.Join(labels, ", ")})
+	}
+
+	table.Render()
+	fmt.Println()
+
+	if auth.ProjectOrg != nil {
+		fmt.Printf("📌 This project is bound to %s on %s\n\n", color.New(color.Bold, term.ColorHiCyan).Sprint(auth.ProjectOrg.OrgName), auth.ProjectOrg.HostLabel())
+	}
+
+	term.PrintCmds("", "orgs switch", "orgs bind", "sign-in")
+}
+
+func switchOrg(cmd *cobra.Command, args []string) {
+	auth.MustResolveDefaultAuth()
+
+	org := mustSelectAccountOrg(args, "Select a default org:")
+
+	err := auth.SetDefaultOrg(org)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error switching org: %v", err)
+	}
+
+	fmt.Printf("✅ Default org is now %s on %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(org.Org.Name), org.Binding().HostLabel())
+
+	if auth.ProjectOrg != nil {
+		fmt.Printf("📌 This project stays bound to %s—use %s to follow the default org\n", color.New(color.Bold, term.ColorHiCyan).Sprint(auth.ProjectOrg.OrgName), color.New(color.Bold, term.ColorHiCyan).Sprint("plandex orgs unbind"))
+	}
+}
+
+func bindOrg(cmd *cobra.Command, args []string) {
+	auth.MustResolveDefaultAuth()
+	mustResolveProjectDir()
+
+	org := mustSelectAccountOrg(args, "Bind this project to an org:")
+	binding := org.Binding()
+
+	if auth.ProjectOrg != nil && sameOrg(binding, auth.ProjectOrg) {
+		fmt.Printf("🤷‍♂️ This project is already bound to %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(binding.OrgName))
+		return
+	}
+
+	term.StartSpinner("")
+	created, err := lib.BindProjectOrg(binding)
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error binding project: %v", err)
+	}
+
+	fmt.Printf("✅ Bound this project to %s on %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(binding.OrgName), binding.HostLabel())
+	if created {
+		fmt.Println("This is the project's first use of the org, so it starts without plans. Plans in other orgs are kept and come back if you bind to them again.")
+	}
+	fmt.Println()
+
+	term.PrintCmds("", "plans", "new")
+}
+
+func unbindOrg(cmd *cobra.Command, args []string) {
+	auth.MustResolveDefaultAuth()
+	mustResolveProjectDir()
+
+	if auth.ProjectOrg == nil {
+		fmt.Println("🤷‍♂️ This project isn't bound to an org")
+		return
+	}
+
+	term.StartSpinner("")
+	created, err := lib.UnbindProjectOrg()
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error unbinding project: %v", err)
+	}
+
+	defaultBinding := auth.DefaultBinding()
+
+	fmt.Printf("✅ This project now uses your default org, %s on %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(defaultBinding.OrgName), defaultBinding.HostLabel())
+	if created {
+		fmt.Println("This is the project's first use of the org, so it starts without plans.")
+	}
+	fmt.Println()
+
+	term.PrintCmds("", "plans", "orgs")
+}
+
+func mustResolveProjectDir() {
+	if fs.PlandexDir == "" || fs.ProjectRoot == "" {
+		term.OutputErrorAndExit("No project in current directory. Run %s to start one.", color.New(color.Bold, term.ColorHiCyan).Sprint("plandex new"))
+	}
+
+	lib.MustResolveProject()
+}
+
+func mustListAccountOrgs() []*auth.AccountOrg {
+	term.StartSpinner("")
+	orgs, errsByEmail, err := auth.ListAccountOrgs()
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error listing orgs: %v", err)
+	}
+
+	for email, msg := range errsByEmail {
+		fmt.Fprintf(os.Stderr, "⚠️  Couldn't list orgs for %s: %s\n", email, msg)
+	}
+
+	if len(orgs) == 0 {
+		term.OutputErrorAndExit("No orgs found. Run %s to sign in or create an org.", color.New(color.Bold, term.ColorHiCyan).Sprint("plandex sign-in"))
+	}
+
+	return orgs
+}
+
+// mustSelectAccountOrg finds an org by name, or prompts for one if there's no name or the name matches orgs on more than one account
+func mustSelectAccountOrg(args []string, msg string) *auth.AccountOrg {
+	orgs := mustListAccountOrgs()
+
+	candidates := orgs
+	if len(args) > 0 {
+		candidates = nil
+		for _, org := range orgs {
+			if strings.EqualFold(org.Org.Name, args[0]) {
+				candidates = append(candidates, org)
+			}
+		}
+
+		if len(candidates) == 0 {
+			term.OutputErrorAndExit("No org named '%s'. Run %s to see your orgs.", args[0], color.New(color.Bold, term.ColorHiCyan).Sprint("plandex orgs"))
+		}
+
+		if len(candidates) == 1 {
+			return candidates[0]
+		}
+	}
+
+	var options []string
+	for _, org := range candidates {
+		options = append(options, fmt.Sprintf("%s | %s | %s", org.Org.Name, org.Binding().HostLabel(), org.Account.Email))
+	}
+
+	selected, err := term.SelectFromList(msg, options)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error selecting org: %v", err)
+	}
+
+	for i, opt := range options {
+		if opt == selected {
+			return candidates[i]
+		}
+	}
+
+	term.OutputErrorAndExit("Error selecting org: org not found")
+	return nil
+}
+
+func sameOrg(a, b *types.ProjectOrgBinding) bool {
+	return a.Key() == b.Key() && (a.Email == "" || b.Email == "" || a.Email == b.Email)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+
+	"github.com/spf13/cobra"
+)
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause [stream-id-or-plan] [branch]",
+	Short: "Pause an active plan's build",
+	Long:  "Pause an active plan's build. Builds that are already calling the model finish, but no file starts its next build until the build is resumed with 'plandex resume'. Useful for updating context before more tokens are spent.",
+	Args:  cobra.MaximumNArgs(2),
+	Run:   pause,
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume [stream-id-or-plan] [branch]",
+	Short: "Resume a paused plan build",
+	Long:  "Resume a plan build paused with 'plandex pause'. The plan's context is reloaded first, so builds still to come use any context changes made while the build was paused.",
+	Args:  cobra.MaximumNArgs(2),
+	Run:   resume,
+}
+
+func init() {
+	RootCmd.AddCommand(pauseCmd)
+	RootCmd.AddCommand(resumeCmd)
+}
+
+func pause(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	planId, branch, shouldContinue := lib.SelectActiveStream(args)
+
+	if !shouldContinue {
+		return
+	}
+
+	term.StartSpinner("")
+	apiErr := api.Client.PauseBuild(planId, branch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error pausing build: %v", apiErr.Msg)
+	}
+
+	fmt.Println("⏸️  Build paused. Builds in progress will finish, then the rest will wait.")
+
+	fmt.Println()
+	term.PrintCmds("", "builds", "load", "resume", "stop")
+}
+
+func resume(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	planId, branch, shouldContinue := lib.SelectActiveStream(args)
+
+	if !shouldContinue {
+		return
+	}
+
+	term.StartSpinner("")
+	apiErr := api.Client.ResumeBuild(planId, branch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error resuming build: %v", apiErr.Msg)
+	}
+
+	fmt.Println("▶️  Build resumed")
+
+	fmt.Println()
+	term.PrintCmds("", "connect", "builds")
+}
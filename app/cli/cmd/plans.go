@@ -26,10 +26,12 @@ import (
 )
 
 var archivedOnly bool
+var sharedOnly bool
 
 func init() {
 	RootCmd.AddCommand(plansCmd)
 	plansCmd.Flags().BoolVarP(&archivedOnly, "archived", "a", false, "List archived plans")
+	plansCmd.Flags().BoolVar(&sharedOnly, "shared", false, "List plans shared with you, from any org")
 }
 
 // plansCmd represents the list command
@@ -44,14 +46,24 @@ func plans(cmd *cobra.Command, args []string) {
 	auth.MustResolveAuthWithOrg()
 	lib.MaybeResolveProject()
 
+	if sharedOnly {
+		listShared()
+		return
+	}
+
+	filter, usersById, err := getPlansFilter()
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
 	if archivedOnly {
-		listArchived()
+		listArchived(filter)
 	} else {
-		listActive()
+		listActive(filter, usersById)
 	}
 }
 
-func listActive() {
+func listActive(filter shared.ListPlansFilter, usersById map[string]*shared.User) {
 	errCh := make(chan error)
 
 	var parentProjectIdsWithPaths [][2]string
@@ -117,8 +129,21 @@ func listActive() {
 		return
 	}
 
+	filtered := isPlansFiltered()
+
 	term.StartSpinner("")
-	plans, apiErr := api.Client.ListPlans(projectIds)
+	plans, apiErr := api.Client.FilterPlans(projectIds, filter)
+
+	// plan numbers always come from the default listing so they can be used with 'plandex cd' and other commands
+	var numByPlanId map[string]int
+	if apiErr == nil && filtered && lib.CurrentProjectId != "" {
+		var defaultPlans []*shared.Plan
+		defaultPlans, apiErr = api.Client.ListPlans([]string{lib.CurrentProjectId})
+		numByPlanId = map[string]int{}
+		for i, p := range defaultPlans {
+			numByPlanId[p.Id] = i + 1
+		}
+	}
 	term.StopSpinner()
 
 	if apiErr != nil {
@@ -126,12 +151,26 @@ func listActive() {
 	}
 
 	if len(plans) == 0 {
+		if filtered {
+			fmt.Println("🤷‍♂️ No plans match the filters")
+			fmt.Println()
+			term.PrintCmds("", "plans", "tags")
+			return
+		}
 		fmt.Println("🤷‍♂️ No plans")
 		fmt.Println()
 		term.PrintCmds("", "new")
 		return
 	}
 
+	var hasTags bool
+	for _, p := range plans {
+		if len(p.Tags) > 0 {
+			hasTags = true
+			break
+		}
+	}
+
 	plansByProjectId := make(map[string][]*shared.Plan)
 	var currentProjectPlanIds []string
 	for _, p := range plans {
@@ -142,7 +181,7 @@ func listActive() {
 	}
 
 	for projectId, plans := range plansByProjectId {
-		if projectId != lib.CurrentProjectId {
+		if projectId != lib.CurrentProjectId && filter.SortBy == "" {
 			// sort non-current-project plans alphabetically
 			sort.Slice(plans, func(i, j int) bool {
 				return plans[i].Name < plans[j].Name
@@ -184,7 +223,14 @@ func listActive() {
 
 		table := tablewriter.NewWriter(os.Stdout)
 		table.SetAutoWrapText(false)
-		table.SetHeader([]string{"#", "Name", "Updated" /*, "Created" /*"Branches",*/, "Branch", "Context", "Convo"})
+		header := []string{"#", "Name", "Updated" /*, "Created" /*"Branches",*/, "Branch", "Context", "Convo"}
+		if hasTags {
+			header = append(header, "Tags")
+		}
+		if usersById != nil {
+			header = append(header, "Author")
+		}
+		table.SetHeader(header)
 
 		currentProjectPlans := plansByProjectId[lib.CurrentProjectId]
 		if len(parentProjectIdsWithPaths) > 0 || len(childProjectIdsWithPaths) > 0 {
@@ -194,6 +240,13 @@ func listActive() {
 		}
 		for i, p := range currentProjectPlans {
 			num := strconv.Itoa(i + 1)
+			if numByPlanId != nil {
+				if n, ok := numByPlanId[p.Id]; ok {
+					num = strconv.Itoa(n)
+				} else {
+					num = "-"
+				}
+			}
 			if p.Id == lib.CurrentPlanId {
 				num = color.New(color.Bold, term.ColorHiGreen).Sprint(num)
 			}
@@ -204,6 +257,9 @@ func listActive() {
 			} else {
 				name = p.Name
 			}
+			if p.SharedAccess != "" {
+				name += color.New(term.ColorHiYellow).Sprintf(" (shared, %s)", shareAccessLabel(p.SharedAccess))
+			}
 
 			currentBranch := currentBranchesByPlanId[p.Id]
 
@@ -218,6 +274,18 @@ func listActive() {
 				strconv.Itoa(currentBranch.ConvoTokens) + " 🪙",
 			}
 
+			if hasTags {
+				row = append(row, strings.Join(p.Tags, ", "))
+			}
+
+			if usersById != nil {
+				var author string
+				if user := usersById[p.OwnerId]; user != nil {
+					author = user.Name
+				}
+				row = append(row, author)
+			}
+
 			var style []tablewriter.Colors
 			if p.Name == lib.CurrentPlanId {
 				style = []tablewriter.Colors{
@@ -346,15 +414,35 @@ func listActive() {
 	}
 }
 
-func listArchived() {
+func listArchived(filter shared.ListPlansFilter) {
 	var projectIds []string
 
 	if lib.CurrentProjectId != "" {
 		projectIds = append(projectIds, lib.CurrentProjectId)
 	}
 
+	var plans []*shared.Plan
+	var apiErr *shared.ApiError
+
+	// as with active plans, numbers come from the default listing so they can be used with 'plandex unarchive'
+	var numByPlanId map[string]int
+
 	term.StartSpinner("")
-	plans, apiErr := api.Client.ListArchivedPlans(projectIds)
+	if isPlansFiltered() {
+		filter.Archived = true
+		plans, apiErr = api.Client.FilterPlans(projectIds, filter)
+
+		if apiErr == nil {
+			var defaultPlans []*shared.Plan
+			defaultPlans, apiErr = api.Client.ListArchivedPlans(projectIds)
+			numByPlanId = map[string]int{}
+			for i, p := range defaultPlans {
+				numByPlanId[p.Id] = i + 1
+			}
+		}
+	} else {
+		plans, apiErr = api.Client.ListArchivedPlans(projectIds)
+	}
 	term.StopSpinner()
 
 	if apiErr != nil {
@@ -370,10 +458,17 @@ func listArchived() {
 
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetAutoWrapText(false)
-	table.SetHeader([]string{"#", "Name", "Updated"})
+	table.SetHeader([]string{"#", "Name", "Updated", "Tags"})
 
 	for i, p := range plans {
 		num := strconv.Itoa(i + 1)
+		if numByPlanId != nil {
+			if n, ok := numByPlanId[p.Id]; ok {
+				num = strconv.Itoa(n)
+			} else {
+				num = "-"
+			}
+		}
 		if p.Id == lib.CurrentPlanId {
 			num = color.New(color.Bold, term.ColorHiGreen).Sprint(num)
 		}
@@ -382,6 +477,7 @@ func listArchived() {
 			num,
 			p.Name,
 			format.Time(p.UpdatedAt),
+			strings.Join(p.Tags, ", "),
 		}
 
 		var style []tablewriter.Colors
@@ -403,3 +499,45 @@ func listArchived() {
 	fmt.Println()
 	term.PrintCmds("", "unarchive")
 }
+
+func listShared() {
+	term.StartSpinner("")
+	plans, apiErr := api.Client.ListSharedPlans()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting shared plans: %v", apiErr.Msg)
+	}
+
+	if len(plans) == 0 {
+		fmt.Println("🤷‍♂️ No plans are shared with you")
+		fmt.Println()
+		term.PrintCmds("", "plans")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"#", "Name", "Org", "Access", "Updated"})
+
+	for i, p := range plans {
+		num := strconv.Itoa(i + 1)
+		name := p.Name
+		if p.Id == lib.CurrentPlanId {
+			num = color.New(color.Bold, term.ColorHiGreen).Sprint(num)
+			name = color.New(color.Bold, term.ColorHiGreen).Sprint(p.Name) + fmt.Sprint(" 👈")
+		}
+
+		table.Append([]string{
+			num,
+			name,
+			p.OrgName,
+			shareAccessLabel(p.SharedAccess),
+			format.Time(p.UpdatedAt),
+		})
+	}
+	table.Render()
+
+	fmt.Println()
+	term.PrintCmds("", "cd --shared")
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+var plansTags []string
+var plansStatuses []string
+var plansSince string
+var plansUntil string
+var plansAuthor string
+var plansSort string
+var plansAsc bool
+
+func init() {
+	plansCmd.Flags().StringSliceVarP(&plansTags, "tag", "t", nil, "Only list plans with these tags")
+	plansCmd.Flags().StringSliceVarP(&plansStatuses, "status", "s", nil, "Only list plans with a branch in one of these statuses")
+	plansCmd.Flags().StringVar(&plansSince, "since", "", "Only list plans updated since a date (YYYY-MM-DD) or duration ago (e.g. 3d, 2w, 12h)")
+	plansCmd.Flags().StringVar(&plansUntil, "until", "", "Only list plans last updated before a date (YYYY-MM-DD, inclusive) or duration ago")
+	plansCmd.Flags().StringVar(&plansAuthor, "author", "", "List plans by 'me' (default), 'any' org member, or a member's email; other members' plans must be shared with the org")
+	plansCmd.Flags().StringVar(&plansSort, "sort", "", "Sort by 'updated' (default), 'created', or 'name'")
+	plansCmd.Flags().BoolVar(&plansAsc, "asc", false, "Sort in ascending order")
+}
+
+func isPlansFiltered() bool {
+	return len(plansTags) > 0 || len(plansStatuses) > 0 || plansSince != "" || plansUntil != "" || (plansAuthor != "" && plansAuthor != "me") || plansSort != "" || plansAsc
+}
+
+// getPlansFilter builds the filter for 'plandex plans' from its flags. usersById is only loaded when listing plans by other org members.
+func getPlansFilter() (filter shared.ListPlansFilter, usersById map[string]*shared.User, err error) {
+	for _, tag := range plansTags {
+		normalized, err := shared.NormalizePlanTag(tag)
+		if err != nil {
+			return filter, nil, err
+		}
+		filter.Tags = append(filter.Tags, normalized)
+	}
+
+	for _, s := range plansStatuses {
+		status := shared.PlanStatus(s)
+		if !status.Valid() {
+			return filter, nil, fmt.Errorf("invalid status '%s'—valid statuses are: %v", s, shared.AllPlanStatuses)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	now := time.Now()
+
+	if plansSince != "" {
+		since, err := parsePlansDate(plansSince, now, false)
+		if err != nil {
+			return filter, nil, err
+		}
+		filter.UpdatedAfter = &since
+	}
+
+	if plansUntil != "" {
+		until, err := parsePlansDate(plansUntil, now, true)
+		if err != nil {
+			return filter, nil, err
+		}
+		filter.UpdatedBefore = &until
+	}
+
+	if plansSort != "" {
+		filter.SortBy = shared.PlanSortBy(plansSort)
+		if !filter.SortBy.Valid() {
+			return filter, nil, fmt.Errorf("invalid sort '%s'—valid sorts are: %v", plansSort, shared.AllPlanSorts)
+		}
+	}
+	filter.Asc = plansAsc
+
+	if plansAuthor != "" && plansAuthor != "me" {
+		res, apiErr := api.Client.ListUsers()
+		if apiErr != nil {
+			return filter, nil, fmt.Errorf("error listing org members: %v", apiErr.Msg)
+		}
+
+		usersById = map[string]*shared.User{}
+		for _, user := range res.Users {
+			usersById[user.Id] = user
+			if strings.EqualFold(user.Email, plansAuthor) {
+				filter.AuthorId = user.Id
+			}
+		}
+
+		if plansAuthor == shared.PlanAuthorAny {
+			filter.AuthorId = shared.PlanAuthorAny
+		} else if filter.AuthorId == "" {
+			return filter, nil, fmt.Errorf("no org member with email '%s'", plansAuthor)
+		}
+	}
+
+	return filter, usersById, nil
+}
+
+// parsePlansDate parses a YYYY-MM-DD date in local time, or a duration before now like 3d, 2w, or 12h. With endOfDay, a date includes the whole day.
+func parsePlansDate(s string, now time.Time, endOfDay bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	if len(s) > 1 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err == nil && n >= 0 {
+			switch s[len(s)-1] {
+			case 'd':
+				return now.AddDate(0, 0, -n), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), nil
+			}
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date '%s'—use YYYY-MM-DD or a duration like 3d, 2w, or 12h", s)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/plugins"
+	"plandex/term"
+	"plandex/version"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(pluginsCmd)
+}
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List plugins: plandex-<name> executables on your PATH that run as 'plandex <name>'",
+	Args:  cobra.NoArgs,
+	Run:   listPlugins,
+}
+
+func listPlugins(cmd *cobra.Command, args []string) {
+	list := plugins.List()
+
+	if len(list) == 0 {
+		fmt.Println("🤷‍♂️ No plugins on your PATH")
+		fmt.Println()
+		fmt.Printf("Add an executable named %s to your PATH to run it as %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(plugins.Prefix+"<name>"), color.New(color.Bold, term.ColorHiCyan).Sprint("plandex <name>"))
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Command", "Path"})
+
+	for _, plugin := range list {
+		table.Append([]string{"plandex " + plugin.Name, plugin.Path})
+	}
+
+	table.Render()
+}
+
+// maybeRunPlugin runs a plandex-<name> plugin when args start with a name that isn't a built-in command, then exits with the plugin's exit code. It returns without doing anything otherwise.
+func maybeRunPlugin(args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	found, _, err := RootCmd.Find(args)
+	if err == nil && found != RootCmd {
+		return
+	}
+
+	path, err := plugins.Find(args[0])
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+	if path == "" {
+		return
+	}
+
+	code, err := plugins.Run(path, args[1:], pluginContext())
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	os.Exit(code)
+}
+
+func pluginContext() *plugins.Context {
+	ctx := &plugins.Context{
+		Version:     version.Version,
+		Cwd:         fs.Cwd,
+		ProjectRoot: fs.ProjectRoot,
+	}
+
+	lib.MaybeResolveProject()
+	if lib.CurrentProjectId != "" {
+		lib.MustLoadCurrentPlan()
+		ctx.ProjectId = lib.CurrentProjectId
+		ctx.PlanId = lib.CurrentPlanId
+		ctx.Branch = lib.CurrentBranch
+	}
+
+	err := auth.MaybeResolveAuth()
+	if err != nil {
+		term.OutputErrorAndExit("Error resolving auth: %v", err)
+	}
+
+	if auth.Current != nil {
+		ctx.ApiHost = api.GetApiHost()
+		ctx.IsCloud = auth.Current.IsCloud
+		ctx.UserId = auth.Current.UserId
+		ctx.Email = auth.Current.Email
+		ctx.OrgId = auth.Current.OrgId
+		ctx.OrgName = auth.Current.OrgName
+		ctx.Token = auth.Current.Token
+	}
+
+	return ctx
+}
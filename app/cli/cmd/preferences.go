@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/format"
+	"plandex/term"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var clearOrgPreferences bool
+
+func init() {
+	RootCmd.AddCommand(preferencesCmd)
+	preferencesCmd.AddCommand(clearPreferencesCmd)
+
+	clearPreferencesCmd.Flags().BoolVar(&clearOrgPreferences, "org", false, "Clear the org's preferences instead of yours")
+}
+
+var preferencesCmd = &cobra.Command{
+	Use:   "preferences",
+	Short: "Show learned preferences",
+	Long:  "Show the preferences Plandex has learned from changes you and your org rejected, or edited after applying them. They're added to the planner's and builder's prompts so disliked patterns aren't repeated.",
+	Args:  cobra.NoArgs,
+	Run:   showPreferences,
+}
+
+var clearPreferencesCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Forget learned preferences",
+	Long:  "Forget your learned preferences, or your org's with --org, along with the rejections and edits they were learned from. Clearing the org's preferences requires permission to update any plan.",
+	Args:  cobra.NoArgs,
+	Run:   clearPreferences,
+}
+
+func showPreferences(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.GetLearnedPreferences()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting learned preferences: %v", apiErr.Msg)
+		return
+	}
+
+	printLearnedPreferences("👤 Your Preferences", res.User, res.PendingUserEvents)
+	printLearnedPreferences("🏢 Org Preferences", res.Org, res.PendingOrgEvents)
+
+	term.PrintCmds("", "preferences clear")
+}
+
+func printLearnedPreferences(title string, prefs *shared.LearnedPreferences, numPending int) {
+	color.New(color.Bold, term.ColorHiCyan).Println(title)
+
+	if prefs == nil || prefs.Summary == "" {
+		fmt.Println("Nothing learned yet")
+	} else {
+		fmt.Println(prefs.Summary)
+		fmt.Println()
+		fmt.Println(color.New(color.FgHiWhite).Sprintf("Learned from %d rejections and edits | updated %s", prefs.NumEvents, format.Time(prefs.UpdatedAt)))
+	}
+
+	if numPending > 0 {
+		fmt.Println(color.New(color.FgHiWhite).Sprintf("%d more to learn from", numPending))
+	}
+
+	fmt.Println()
+}
+
+func clearPreferences(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	apiErr := api.Client.ClearLearnedPreferences(clearOrgPreferences)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error clearing learned preferences: %v", apiErr.Msg)
+		return
+	}
+
+	if clearOrgPreferences {
+		fmt.Println("✅ Cleared your org's learned preferences")
+	} else {
+		fmt.Println("✅ Cleared your learned preferences")
+	}
+}
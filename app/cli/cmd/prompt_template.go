@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+)
+
+// promptTemplate is opened by 'tell --editor' to help structure longer tasks. Comments and sections left empty are removed before the prompt is sent.
+const promptTemplate = `## Task
+<!-- What should be built or changed? -->
+
+
+## Constraints
+<!-- Requirements, conventions to follow, or things to avoid -->
+
+
+## Files
+<!-- Files to change or look at, one per line. Load any that aren't in context yet with 'plandex load'. -->
+
+`
+
+var templateHeadings = map[string]bool{
+	"## Task":        true,
+	"## Constraints": true,
+	"## Files":       true,
+}
+
+var htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+var extraBlankLinesPattern = regexp.MustCompile(`\n{3,}`)
+
+// cleanTemplatePrompt removes the template's comments and any of its sections left empty
+func cleanTemplatePrompt(prompt string) string {
+	prompt = htmlCommentPattern.ReplaceAllString(prompt, "")
+
+	lines := strings.Split(prompt, "\n")
+
+	var res []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if templateHeadings[strings.TrimSpace(line)] {
+			empty := true
+			for _, next := range lines[i+1:] {
+				if templateHeadings[strings.TrimSpace(next)] {
+					break
+				}
+				if strings.TrimSpace(next) != "" {
+					empty = false
+					break
+				}
+			}
+
+			if empty {
+				continue
+			}
+		}
+
+		res = append(res, line)
+	}
+
+	prompt = strings.Join(res, "\n")
+
+	// collapse the blank lines left behind by removed comments and sections
+	prompt = extraBlankLinesPattern.ReplaceAllString(prompt, "\n\n")
+
+	return strings.TrimSpace(prompt)
+}
+
+// promptPreview is a prompt's first line, shortened to fit in a table
+func promptPreview(prompt string) string {
+	preview := strings.TrimSpace(prompt)
+	if i := strings.Index(preview, "\n"); i >= 0 {
+		preview = preview[:i] + " …"
+	}
+	if runes := []rune(preview); len(runes) > 60 {
+		preview = string(runes[:60]) + "…"
+	}
+	return preview
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/format"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var provenanceJson bool
+
+var provenanceCmd = &cobra.Command{
+	Use:   "provenance",
+	Short: "Show where each file change came from",
+	Long:  "Show the provenance of each file change on the current plan and branch: the model and role that wrote it, the build it came from, and a hash of its content. When the server signs provenance, each signature is checked. Use 'plandex apply --provenance' to add provenance to the commit message.",
+	Args:  cobra.NoArgs,
+	Run:   showProvenance,
+}
+
+func init() {
+	provenanceCmd.Flags().BoolVar(&provenanceJson, "json", false, "Output provenance as JSON")
+
+	RootCmd.AddCommand(provenanceCmd)
+}
+
+func showProvenance(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.GetPlanProvenance(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting provenance: %v", apiErr.Msg)
+	}
+
+	if provenanceJson {
+		bytes, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			term.OutputErrorAndExit("Error marshalling provenance: %v", err)
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if len(res.Entries) == 0 {
+		fmt.Println("🤷‍♂️ No file changes with provenance")
+		fmt.Println()
+		term.PrintCmds("", "changes", "builds")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	header := []string{"File", "Model", "Role", "Build", "Content", "Status", "Created"}
+	if res.Signing {
+		header = append(header, "Signed")
+	}
+	table.SetHeader(header)
+
+	for _, entry := range res.Entries {
+		p := entry.Provenance
+
+		status := "⏳ Pending"
+		if entry.AppliedAt != nil {
+			status = color.New(term.ColorHiGreen).Sprint("✅ Applied")
+		} else if entry.RejectedAt != nil {
+			status = "🚫 Rejected"
+		}
+
+		row := []string{
+			p.Path,
+			p.ModelLabel(),
+			string(p.Role),
+			shortId(p.PlanBuildId),
+			shortHash(p.ContentHash),
+			status,
+			format.Time(p.CreatedAt),
+		}
+
+		if res.Signing {
+			signed := "–"
+			if entry.SignatureValid != nil {
+				if *entry.SignatureValid {
+					signed = "✅"
+				} else {
+					signed = color.New(term.ColorHiRed).Sprint("❌ Invalid")
+				}
+			}
+			row = append(row, signed)
+		}
+
+		table.Append(row)
+	}
+
+	table.Render()
+	fmt.Println()
+
+	term.PrintCmds("", "apply", "builds")
+}
+
+// shortHash shortens a 'sha256:<hex>' hash for display, like git's abbreviated commit hashes
+func shortHash(hash string) string {
+	_, hex, found := strings.Cut(hash, ":")
+	if !found {
+		hex = hash
+	}
+	if len(hex) > 12 {
+		return hex[:12]
+	}
+	return hex
+}
+
+func shortId(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -65,6 +65,8 @@ func ps(cmd *cobra.Command, args []string) {
 			status = "Stopped " + format.Time(finishedAt)
 		case shared.PlanStatusMissingFile:
 			status = "Missing file"
+		case shared.PlanStatusPromptingContinue:
+			status = "Waiting to continue"
 		}
 
 		row := []string{
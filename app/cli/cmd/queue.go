@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/format"
+	"plandex/lib"
+	"plandex/plan_exec"
+	"plandex/term"
+	"plandex/types"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(queueCmd)
+	queueCmd.AddCommand(syncQueueCmd)
+	queueCmd.AddCommand(rmQueueCmd)
+	queueCmd.AddCommand(clearQueueCmd)
+}
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "List tells and context loads queued while the server was unreachable",
+	Args:  cobra.NoArgs,
+	Run:   listQueue,
+}
+
+var syncQueueCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Send the current branch's queued tells and context loads",
+	Args:  cobra.NoArgs,
+	Run:   syncQueue,
+}
+
+var rmQueueCmd = &cobra.Command{
+	Use:   "rm <number>",
+	Short: "Remove a queued action",
+	Args:  cobra.ExactArgs(1),
+	Run:   rmQueue,
+}
+
+var clearQueueCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all queued actions on the current branch",
+	Args:  cobra.NoArgs,
+	Run:   clearQueue,
+}
+
+func listQueue(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	actions := mustLoadBranchQueue()
+
+	if len(actions) == 0 {
+		fmt.Println("🤷‍♂️ Nothing queued on this branch")
+		return
+	}
+
+	term.StartSpinner("")
+	reachable := lib.IsServerReachable()
+	term.StopSpinner()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"#", "Type", "Queued", "Details"})
+
+	for i, action := range actions {
+		table.Append([]string{strconv.Itoa(i + 1), string(action.Type), format.Time(action.CreatedAt), queuedActionDetails(action)})
+	}
+
+	table.Render()
+	fmt.Println()
+
+	if reachable {
+		fmt.Println("🟢 Server is reachable—queued actions can be sent")
+		fmt.Println()
+		term.PrintCmds("", "queue sync", "queue rm", "queue clear")
+	} else {
+		fmt.Println("🔴 Server is still unreachable—queued actions will wait")
+		fmt.Println()
+		term.PrintCmds("", "queue rm", "queue clear")
+	}
+}
+
+func syncQueue(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	actions := mustLoadBranchQueue()
+
+	if len(actions) == 0 {
+		fmt.Println("🤷‍♂️ Nothing queued on this branch")
+		return
+	}
+
+	term.StartSpinner("")
+	reachable := lib.IsServerReachable()
+	term.StopSpinner()
+
+	if !reachable {
+		term.OutputErrorAndExit("Server is still unreachable. %d queued action(s) kept.", len(actions))
+	}
+
+	sendQueuedActions(actions)
+}
+
+func rmQueue(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	actions := mustLoadBranchQueue()
+
+	n, err := strconv.Atoi(args[0])
+
+	if err != nil || n < 1 || n > len(actions) {
+		term.OutputErrorAndExit("No queued action #%s", args[0])
+	}
+
+	err = lib.RemoveQueuedAction(actions[n-1].Id)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error removing queued action: %v", err)
+	}
+
+	fmt.Printf("✅ Removed queued %s #%d\n", actions[n-1].Type, n)
+}
+
+func clearQueue(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	actions := mustLoadBranchQueue()
+
+	if len(actions) == 0 {
+		fmt.Println("🤷‍♂️ Nothing queued on this branch")
+		return
+	}
+
+	for _, action := range actions {
+		err := lib.RemoveQueuedAction(action.Id)
+
+		if err != nil {
+			term.OutputErrorAndExit("Error removing queued action: %v", err)
+		}
+	}
+
+	fmt.Printf("✅ Removed %d queued action(s)\n", len(actions))
+}
+
+// maybeSendQueuedFirst offers to send the branch's queued actions before a new one, so they go out in the order they were written. If the user agrees, the new action is queued behind them, onQueued is called if set, and true is returned.
+func maybeSendQueuedFirst(action *types.QueuedAction, onQueued func()) bool {
+	actions := mustLoadBranchQueue()
+
+	if len(actions) == 0 {
+		return false
+	}
+
+	fmt.Printf("📥 %d action(s) were queued on this branch while the server was unreachable\n", len(actions))
+
+	res, err := term.ConfirmYesNo("Send them first?")
+
+	if err != nil {
+		term.OutputErrorAndExit("Error prompting to send queued actions: %v", err)
+	}
+
+	if !res {
+		return false
+	}
+
+	err = lib.QueueAction(action)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error queueing %s: %v", action.Type, err)
+	}
+
+	if onQueued != nil {
+		onQueued()
+	}
+
+	sendQueuedActions(append(actions, action))
+
+	return true
+}
+
+// sendQueuedActions sends queued actions in order. Context loads are sent one after another. A tell streams the plan's reply, so it's the last action sent in a run, and any later actions stay queued.
+func sendQueuedActions(actions []*types.QueuedAction) {
+	var apiKeys map[string]string
+
+	for i, action := range actions {
+		switch action.Type {
+		case types.QueuedActionLoad:
+			fmt.Printf("📤 Sending queued load %d/%d\n", i+1, len(actions))
+
+			lib.MustReplayQueuedLoad(action)
+
+			err := lib.RemoveQueuedAction(action.Id)
+
+			if err != nil {
+				term.OutputErrorAndExit("Error removing queued action: %v", err)
+			}
+
+			fmt.Println()
+
+		case types.QueuedActionTell:
+			fmt.Printf("📤 Sending queued prompt %d/%d\n", i+1, len(actions))
+
+			remaining := len(actions) - i - 1
+			if remaining > 0 {
+				fmt.Printf("%d more action(s) stay queued until this reply finishes. Then run %s.\n", remaining, color.New(color.Bold, term.ColorHiCyan).Sprint("plandex queue sync"))
+			}
+			fmt.Println()
+
+			if apiKeys == nil {
+				apiKeys = lib.MustVerifyApiKeys()
+			}
+
+			queued := action
+			plan_exec.TellPlan(plan_exec.ExecParams{
+				CurrentPlanId: lib.CurrentPlanId,
+				CurrentBranch: lib.CurrentBranch,
+				ApiKeys:       apiKeys,
+				CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
+					return lib.MustCheckOutdatedContext(false, maybeContexts)
+				},
+				OnFinished: lib.MaybeAutoApply,
+				OnPromptSent: func() {
+					err := lib.RemoveQueuedAction(queued.Id)
+
+					if err != nil {
+						term.OutputErrorAndExit("Error removing queued action: %v", err)
+					}
+				},
+				OnServerUnreachable: func() {
+					term.OutputErrorAndExit("Lost connection to the server. The prompt is still queued.")
+				},
+			}, action.Prompt, action.TellBg, action.TellStop, action.TellNoBuild, false)
+
+			return
+		}
+	}
+
+	fmt.Println("✅ Sent all queued actions")
+}
+
+func mustLoadBranchQueue() []*types.QueuedAction {
+	actions, err := lib.LoadBranchOfflineQueue()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading offline queue: %v", err)
+	}
+
+	return actions
+}
+
+func queuedActionDetails(action *types.QueuedAction) string {
+	if action.Type == types.QueuedActionTell {
+		return promptPreview(action.Prompt)
+	}
+
+	var parts []string
+	parts = append(parts, action.Resources...)
+	if action.LoadParams != nil && action.LoadParams.Note != "" {
+		parts = append(parts, "note")
+	}
+	if action.PipedData != "" {
+		parts = append(parts, "piped data")
+	}
+	return strings.Join(parts, ", ")
+}
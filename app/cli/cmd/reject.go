@@ -13,11 +13,15 @@ import (
 )
 
 var rejectAll bool
+var rejectTests bool
+var rejectDocs bool
 
 func init() {
 	RootCmd.AddCommand(rejectCmd)
 
 	rejectCmd.Flags().BoolVarP(&rejectAll, "all", "a", false, "Reject all pending changes")
+	rejectCmd.Flags().BoolVar(&rejectTests, "tests", false, "Reject pending tests written by test scaffolding")
+	rejectCmd.Flags().BoolVar(&rejectDocs, "docs", false, "Reject pending doc updates written by doc sync")
 }
 
 var rejectCmd = &cobra.Command{
@@ -44,15 +48,39 @@ func reject(cmd *cobra.Command, args []string) {
 		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr)
 	}
 
-	currentFiles := currentPlanState.CurrentPlanFiles.Files
+	currentFiles := currentPlanState.CurrentPlanFiles
+	pendingPaths := currentFiles.PendingPaths()
 
-	if len(currentFiles) == 0 {
+	if len(pendingPaths) == 0 {
 		term.StopSpinner()
 		term.OutputErrorAndExit("No pending changes to reject")
 	}
 
+	if rejectTests {
+		for path := range currentPlanState.PendingTestScaffoldsByPath() {
+			args = append(args, path)
+		}
+		if len(args) == 0 {
+			term.StopSpinner()
+			term.OutputErrorAndExit("No pending scaffolded tests to reject")
+		}
+		sort.Strings(args)
+	}
+
+	if rejectDocs {
+		numArgs := len(args)
+		for path := range currentPlanState.PendingDocSyncsByPath() {
+			args = append(args, path)
+		}
+		if len(args) == numArgs {
+			term.StopSpinner()
+			term.OutputErrorAndExit("No pending doc sync updates to reject")
+		}
+		sort.Strings(args)
+	}
+
 	if rejectAll || len(args) == 0 {
-		numToReject := len(currentFiles)
+		numToReject := len(pendingPaths)
 		suffix := ""
 		if numToReject > 1 {
 			suffix = "s"
@@ -64,16 +92,12 @@ func reject(cmd *cobra.Command, args []string) {
 			// output list of pending files
 			fmt.Println("Files with pending changes:")
 
-			pathsToSort := make([]string, 0, len(currentFiles))
-
-			for path := range currentFiles {
-				pathsToSort = append(pathsToSort, path)
-			}
-
-			sort.Strings(pathsToSort)
-
-			for _, path := range pathsToSort {
-				fmt.Println(" • ", path)
+			for _, path := range pendingPaths {
+				if currentFiles.Removed[path] {
+					fmt.Println(" • ", path, "(removed)")
+				} else {
+					fmt.Println(" • ", path)
+				}
 			}
 			fmt.Println()
 
@@ -104,7 +128,7 @@ func reject(cmd *cobra.Command, args []string) {
 
 	if len(args) > 0 {
 		for _, path := range args {
-			if _, ok := currentFiles[path]; !ok {
+			if _, ok := currentFiles.Files[path]; !ok && !currentFiles.Removed[path] {
 				term.StopSpinner()
 				term.OutputErrorAndExit("File %s not found in plan or has no changes to reject", path)
 			}
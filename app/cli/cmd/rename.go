@@ -2,24 +2,31 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"plandex/api"
 	"plandex/auth"
 	"plandex/lib"
 	"plandex/term"
 
 	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
 	"github.com/spf13/cobra"
 )
 
+var autoRename bool
+
 var renameCmd = &cobra.Command{
 	Use:   "rename [new-name]",
 	Short: "Rename the current plan",
+	Long:  "Rename the current plan. With --auto, a new name is generated from the prompts in the plan's conversation.",
 	Args:  cobra.MaximumNArgs(1),
 	Run:   rename,
 }
 
 func init() {
 	RootCmd.AddCommand(renameCmd)
+
+	renameCmd.Flags().BoolVar(&autoRename, "auto", false, "Generate a new name from the plan's conversation")
 }
 
 func rename(cmd *cobra.Command, args []string) {
@@ -30,6 +37,14 @@ func rename(cmd *cobra.Command, args []string) {
 		term.OutputNoCurrentPlanErrorAndExit()
 	}
 
+	if autoRename {
+		if len(args) > 0 {
+			term.OutputErrorAndExit("Pass either a new name or --auto, not both")
+		}
+		renameAuto()
+		return
+	}
+
 	var newName string
 	if len(args) > 0 {
 		newName = args[0]
@@ -51,8 +66,31 @@ func rename(cmd *cobra.Command, args []string) {
 	term.StopSpinner()
 
 	if err != nil {
-		term.OutputErrorAndExit("Error renaming plan: %v", err)
+		term.OutputErrorAndExit("Error renaming plan: %v", err.Msg)
 	}
 
 	fmt.Printf("✅ Plan renamed to %s\n", color.New(color.Bold, term.ColorHiGreen).Sprint(newName))
 }
+
+func renameAuto() {
+	apiKeys := lib.MustVerifyApiKeysSilent()
+
+	openAIBase := os.Getenv("OPENAI_API_BASE")
+	if openAIBase == "" {
+		openAIBase = os.Getenv("OPENAI_ENDPOINT")
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.AutoRenamePlan(lib.CurrentPlanId, lib.CurrentBranch, shared.AutoRenamePlanRequest{
+		ApiKeys:     apiKeys,
+		OpenAIBase:  openAIBase,
+		OpenAIOrgId: os.Getenv("OPENAI_ORG_ID"),
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error renaming plan: %v", apiErr.Msg)
+	}
+
+	fmt.Printf("✅ Plan renamed to %s\n", color.New(color.Bold, term.ColorHiGreen).Sprint(res.Name))
+}
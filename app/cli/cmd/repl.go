@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/kballard/go-shellquote"
+	"github.com/spf13/cobra"
+	xterm "golang.org/x/term"
+)
+
+const replHistoryLimit = 1000
+
+var replCmd = &cobra.Command{
+	Use:   "repl",
+	Short: "Start an interactive session for the current plan",
+	Long:  "Start an interactive session for the current plan. Type a prompt to send it, or a slash command like /load, /build, /changes, or /apply. Use the up and down arrows for history and tab to complete file paths.",
+	Args:  cobra.NoArgs,
+	Run:   repl,
+}
+
+func init() {
+	RootCmd.AddCommand(replCmd)
+}
+
+// slash commands that map directly to a plandex command, for completion and /help. Any other /command is passed through as well.
+var replSlashCmds = []string{
+	"/load", "/ls", "/rm", "/update", "/clear",
+	"/tell", "/continue", "/build", "/changes", "/diff", "/apply", "/reject",
+	"/log", "/rewind", "/convo", "/summary",
+	"/branches", "/checkout", "/cd", "/plans", "/current",
+	"/ps", "/stop", "/models", "/set-model",
+}
+
+// slash commands handled by the REPL itself
+var replBuiltinCmds = []string{"/editor", "/draft", "/history", "/help", "/exit", "/quit"}
+
+func repl(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !xterm.IsTerminal(fd) {
+		term.OutputErrorAndExit("plandex repl needs an interactive terminal")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		term.OutputErrorAndExit("Error finding plandex executable: %v", err)
+	}
+
+	// Ctrl+C stops the running command, not the session. The REPL exits with Ctrl+C or Ctrl+D at the prompt.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+	go func() {
+		for range sigCh {
+		}
+	}()
+
+	t := xterm.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "")
+	t.AutoCompleteCallback = replComplete(t)
+
+	history := loadReplHistory()
+
+	printReplIntro()
+
+	var planId, planName string
+
+	for {
+		// the plan or branch can change with /cd or /checkout
+		lib.MustLoadCurrentPlan()
+
+		if lib.CurrentPlanId != planId {
+			planId = lib.CurrentPlanId
+			planName = ""
+
+			plan, apiErr := api.Client.GetPlan(planId)
+			if apiErr == nil {
+				planName = plan.Name
+			}
+		}
+
+		label := lib.CurrentBranch
+		if planName != "" {
+			label = planName + "@" + lib.CurrentBranch
+		}
+		t.SetPrompt(color.New(color.Bold, term.ColorHiCyan).Sprint(label) + " › ")
+
+		line, err := readReplLine(fd, t)
+
+		if err != nil {
+			if err == io.EOF {
+				fmt.Println()
+				return
+			}
+			term.OutputErrorAndExit("Error reading input: %v", err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		history = append(history, line)
+		appendReplHistory(line)
+
+		cmdArgs, done := replLineToArgs(line, history)
+
+		if done {
+			return
+		}
+
+		if cmdArgs == nil {
+			continue
+		}
+
+		c := exec.Command(exe, cmdArgs...)
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+
+		err = c.Run()
+
+		if err != nil {
+			if _, ok := err.(*exec.ExitError); !ok {
+				fmt.Fprintf(os.Stderr, "🚨 Error running command: %v\n", err)
+			}
+		}
+
+		fmt.Println()
+	}
+}
+
+// readReplLine reads a line with the terminal in raw mode, so arrows, history, and tab completion work. The terminal is restored before commands run.
+func readReplLine(fd int, t *xterm.Terminal) (string, error) {
+	state, err := xterm.MakeRaw(fd)
+
+	if err != nil {
+		return "", fmt.Errorf("error setting raw mode: %v", err)
+	}
+
+	defer xterm.Restore(fd, state)
+
+	return t.ReadLine()
+}
+
+// replLineToArgs turns a line of input into plandex args. Plain text is sent as a prompt. It returns nil args for input handled by the REPL itself, and done when the session should end.
+func replLineToArgs(line string, history []string) ([]string, bool) {
+	if !strings.HasPrefix(line, "/") {
+		return []string{"tell", line}, false
+	}
+
+	words, err := shellquote.Split(line)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "🚨 Couldn't parse command: %v\n", err)
+		return nil, false
+	}
+
+	name := strings.TrimPrefix(words[0], "/")
+	rest := words[1:]
+
+	switch name {
+	case "exit", "quit":
+		return nil, true
+	case "help":
+		printReplHelp()
+		return nil, false
+	case "history":
+		start := 0
+		if len(history) > 20 {
+			start = len(history) - 20
+		}
+		for i := start; i < len(history); i++ {
+			fmt.Printf("%4d  %s\n", i+1, history[i])
+		}
+		return nil, false
+	case "editor":
+		return append([]string{"tell", "--editor"}, rest...), false
+	case "":
+		printReplHelp()
+		return nil, false
+	}
+
+	return append([]string{name}, rest...), false
+}
+
+func printReplIntro() {
+	fmt.Println(color.New(color.Bold, term.ColorHiCyan).Sprint("⚡️ Plandex REPL"))
+	fmt.Println("Type a prompt to send it, or /help for commands. Ctrl+D to exit.")
+	fmt.Println()
+}
+
+func printReplHelp() {
+	fmt.Println("Type a prompt and press enter to send it. Slash commands run plandex commands in this session:")
+	fmt.Println()
+	fmt.Println("  " + strings.Join(replSlashCmds, " "))
+	fmt.Println()
+	fmt.Println("Other plandex commands work too, e.g. /tag or /share. Session commands:")
+	fmt.Println()
+	fmt.Println("  /editor    write a prompt in your editor from a template")
+	fmt.Println("  /draft     list the plan's draft (/draft add, /draft show, ...)")
+	fmt.Println("  /history   show recent input")
+	fmt.Println("  /exit      end the session (or Ctrl+D)")
+	fmt.Println()
+	fmt.Println("Tab completes slash commands and file paths. Up and down arrows move through this session's input.")
+}
+
+func replHistoryPath() string {
+	return filepath.Join(fs.HomePlandexDir, "repl_history")
+}
+
+func loadReplHistory() []string {
+	f, err := os.Open(replHistoryPath())
+
+	if err != nil {
+		return nil
+	}
+
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if len(lines) > replHistoryLimit {
+		lines = lines[len(lines)-replHistoryLimit:]
+	}
+
+	return lines
+}
+
+func appendReplHistory(line string) {
+	f, err := os.OpenFile(replHistoryPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return
+	}
+
+	defer f.Close()
+
+	// history is one line per entry
+	f.WriteString(strings.ReplaceAll(line, "\n", " ") + "\n")
+}
+
+// replComplete completes slash commands at the start of the line, and file paths relative to the working directory for the word before the cursor
+func replComplete(t *xterm.Terminal) func(line string, pos int, key rune) (string, int, bool) {
+	return func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		before := line[:pos]
+		after := line[pos:]
+
+		wordStart := strings.LastIndexAny(before, " \t") + 1
+		word := before[wordStart:]
+
+		var candidates []string
+
+		if wordStart == 0 && strings.HasPrefix(word, "/") {
+			for _, c := range append(append([]string{}, replSlashCmds...), replBuiltinCmds...) {
+				if strings.HasPrefix(c, word) {
+					candidates = append(candidates, c+" ")
+				}
+			}
+		} else {
+			candidates = completePath(word)
+		}
+
+		if len(candidates) == 0 {
+			return "", 0, false
+		}
+
+		completed := longestCommonPrefix(candidates)
+
+		if len(candidates) > 1 && completed == word {
+			// nothing more to fill in, so show the options
+			var names []string
+			for _, c := range candidates {
+				names = append(names, filepath.Base(strings.TrimSuffix(c, " ")))
+			}
+			// the terminal is in raw mode, so lines need a carriage return
+			fmt.Fprintf(t, "%s\r\n", strings.Join(names, "  "))
+			return "", 0, false
+		}
+
+		newLine := before[:wordStart] + completed + after
+		return newLine, wordStart + len(completed), true
+	}
+}
+
+func completePath(word string) []string {
+	dir, prefix := filepath.Split(word)
+
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+
+	entries, err := os.ReadDir(readDir)
+
+	if err != nil {
+		return nil
+	}
+
+	var res []string
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		// hidden files only when asked for
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+
+		if entry.IsDir() {
+			res = append(res, dir+name+string(filepath.Separator))
+		} else {
+			res = append(res, dir+name+" ")
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+func longestCommonPrefix(strs []string) string {
+	prefix := strs[0]
+	for _, s := range strs[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/plan_exec"
+	"plandex/term"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var rerunCmd = &cobra.Command{
+	Use:   "rerun",
+	Short: "Replay the plan from an edited prompt, one step at a time",
+	Long:  "Replay the plan from a prompt edited with 'plandex edit-prompt'. The first run drops the stale messages after the edit, rejects any pending changes built from them, and continues from the edited prompt. Each later run sends the next prompt that came after the edit, until all of them have been replayed.",
+	Args:  cobra.NoArgs,
+	Run:   rerun,
+}
+
+func init() {
+	RootCmd.AddCommand(rerunCmd)
+
+	rerunCmd.Flags().BoolVarP(&tellStop, "stop", "s", false, "Stop after a single reply")
+	rerunCmd.Flags().BoolVarP(&tellNoBuild, "no-build", "n", false, "Don't build files")
+	rerunCmd.Flags().BoolVar(&tellBg, "bg", false, "Execute autonomously in the background")
+}
+
+func rerun(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	apiKeys := lib.MustVerifyApiKeys()
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.RerunPlan(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error re-running plan: %v", apiErr.Msg)
+	}
+
+	if res.IsUserContinue {
+		fmt.Printf("🔁 Re-running from message #%d\n", res.FromMessageNum)
+	} else {
+		fmt.Printf("🔁 Replaying prompt as message #%d\n", res.FromMessageNum)
+	}
+
+	if res.NumRemaining > 0 {
+		fmt.Printf("%d more prompts to replay after this—run 'plandex rerun' again when it's done\n", res.NumRemaining)
+	}
+	fmt.Println()
+
+	plan_exec.TellPlan(plan_exec.ExecParams{
+		CurrentPlanId: lib.CurrentPlanId,
+		CurrentBranch: lib.CurrentBranch,
+		ApiKeys:       apiKeys,
+		CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
+			return lib.MustCheckOutdatedContext(false, maybeContexts)
+		},
+		OnFinished: lib.MaybeAutoApply,
+	}, res.Prompt, tellBg, tellStop, tellNoBuild, res.IsUserContinue)
+}
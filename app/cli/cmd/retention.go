@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/term"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var retentionArchiveAfter int
+var retentionDeleteAfter int
+var retentionNotifyDays int
+
+func init() {
+	RootCmd.AddCommand(retentionCmd)
+	retentionCmd.AddCommand(setRetentionCmd)
+
+	setRetentionCmd.Flags().IntVar(&retentionArchiveAfter, "archive-after", 0, "Archive plans that haven't been touched for this many days (0 to disable)")
+	setRetentionCmd.Flags().IntVar(&retentionDeleteAfter, "delete-after", 0, "Permanently delete plans that have been archived for this many days (0 to disable)")
+	setRetentionCmd.Flags().IntVar(&retentionNotifyDays, "notify-days", shared.DefaultRetentionNotifyDaysBeforeDelete, "Notify plan owners this many days before their archived plans are deleted")
+}
+
+var retentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Show the org's plan retention policy",
+	Run:   showRetention,
+}
+
+var setRetentionCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Update the org's plan retention policy",
+	Run:   setRetention,
+}
+
+func showRetention(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	policy, apiErr := api.Client.GetOrgRetentionPolicy()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting retention policy: %v", apiErr.Msg)
+		return
+	}
+
+	renderRetentionPolicy(policy)
+
+	term.PrintCmds("", "retention set")
+}
+
+func setRetention(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	if !cmd.Flags().Changed("archive-after") && !cmd.Flags().Changed("delete-after") && !cmd.Flags().Changed("notify-days") {
+		term.OutputErrorAndExit("Set at least one of --archive-after, --delete-after, or --notify-days")
+		return
+	}
+
+	term.StartSpinner("")
+	policy, apiErr := api.Client.GetOrgRetentionPolicy()
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting retention policy: %v", apiErr.Msg)
+		return
+	}
+
+	// only the flags that were passed are changed
+	if cmd.Flags().Changed("archive-after") {
+		policy.ArchiveAfterDays = retentionArchiveAfter
+	}
+	if cmd.Flags().Changed("delete-after") {
+		policy.DeleteArchivedAfterDays = retentionDeleteAfter
+	}
+	if cmd.Flags().Changed("notify-days") {
+		policy.NotifyDaysBeforeDelete = retentionNotifyDays
+	}
+
+	err := policy.Validate()
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Invalid retention policy: %v", err)
+		return
+	}
+
+	apiErr = api.Client.UpdateOrgRetentionPolicy(shared.UpdateOrgRetentionPolicyRequest{Policy: policy})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating retention policy: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Println("✅ Updated retention policy")
+	fmt.Println()
+
+	renderRetentionPolicy(policy)
+}
+
+func renderRetentionPolicy(policy *shared.OrgRetentionPolicy) {
+	days := func(n int) string {
+		if n == 0 {
+			return "never"
+		}
+		return strconv.Itoa(n) + " days"
+	}
+
+	color.New(color.Bold, term.ColorHiCyan).Println("🗄️  Retention Policy")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.Append([]string{"Archive plans untouched for", days(policy.ArchiveAfterDays)})
+	table.Append([]string{"Delete plans archived for", days(policy.DeleteArchivedAfterDays)})
+	if policy.DeleteArchivedAfterDays > 0 {
+		table.Append([]string{"Notify owners before deletion", days(policy.NotifyDaysBeforeDelete)})
+	}
+	table.Render()
+	fmt.Println()
+}
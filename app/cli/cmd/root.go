@@ -25,6 +25,8 @@ var RootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	maybeRunPlugin(os.Args[1:])
+
 	if err := RootCmd.Execute(); err != nil {
 		// term.OutputErrorAndExit("Error executing root command: %v", err)
 		// log.Fatalf("Error executing root command: %v", err)
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var scanEnabled bool
+var scanLicenses []string
+var scanMinLines int
+var scanMatchContext bool
+var scanMatchCorpus bool
+
+func init() {
+	RootCmd.AddCommand(scanCmd)
+	scanCmd.AddCommand(scanPolicyCmd)
+	scanCmd.AddCommand(setScanPolicyCmd)
+
+	setScanPolicyCmd.Flags().BoolVar(&scanEnabled, "enabled", false, "Turn code scanning on or off (--enabled=false to turn it off)")
+	setScanPolicyCmd.Flags().StringSliceVar(&scanLicenses, "licenses", nil, "Comma-separated license names or identifiers to flag in headers, like 'GPL,AGPL'")
+	setScanPolicyCmd.Flags().IntVar(&scanMinLines, "min-lines", 0, fmt.Sprintf("Lines in a verbatim block before it's flagged (min %d)", shared.MinCodeScanVerbatimLines))
+	setScanPolicyCmd.Flags().BoolVar(&scanMatchContext, "match-context", false, "Flag blocks copied from the plan's other context")
+	setScanPolicyCmd.Flags().BoolVar(&scanMatchCorpus, "match-corpus", false, "Flag blocks copied from the server's scan corpus")
+}
+
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Show pending changes flagged by code scanning",
+	Long:  "Show the pending changes on the current plan and branch that your org's code scan flagged: license headers for banned licenses, and large blocks copied verbatim from context or the server's scan corpus. Flagged changes are confirmed before they're applied.",
+	Args:  cobra.NoArgs,
+	Run:   showScanFindings,
+}
+
+var scanPolicyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Show the org's code scan policy",
+	Args:  cobra.NoArgs,
+	Run:   showScanPolicy,
+}
+
+var setScanPolicyCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Update the org's code scan policy",
+	Args:  cobra.NoArgs,
+	Run:   setScanPolicy,
+}
+
+func showScanFindings(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	state, apiErr := api.Client.GetCurrentPlanState(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr.Msg)
+		return
+	}
+
+	findingsByPath := state.PendingScanFindingsByPath()
+
+	if len(findingsByPath) == 0 {
+		fmt.Println("✅ No pending changes are flagged")
+		fmt.Println()
+		term.PrintCmds("", "scan policy", "apply")
+		return
+	}
+
+	lib.PrintScanFindings(findingsByPath)
+
+	term.PrintCmds("", "diff", "apply", "reject")
+}
+
+func showScanPolicy(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.GetOrgCodeScanPolicy()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting code scan policy: %v", apiErr.Msg)
+		return
+	}
+
+	renderCodeScanPolicy(res.Policy, res.NumCorpusFiles)
+
+	term.PrintCmds("", "scan set", "scan")
+}
+
+func setScanPolicy(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	flags := []string{"enabled", "licenses", "min-lines", "match-context", "match-corpus"}
+	anyChanged := false
+	for _, flag := range flags {
+		if cmd.Flags().Changed(flag) {
+			anyChanged = true
+		}
+	}
+	if !anyChanged {
+		term.OutputErrorAndExit("Set at least one of --enabled, --licenses, --min-lines, --match-context, or --match-corpus")
+		return
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.GetOrgCodeScanPolicy()
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting code scan policy: %v", apiErr.Msg)
+		return
+	}
+	policy := res.Policy
+
+	// only the flags that were passed are changed
+	if cmd.Flags().Changed("enabled") {
+		policy.Enabled = scanEnabled
+	}
+	if cmd.Flags().Changed("licenses") {
+		policy.BannedLicenses = []string{}
+		for _, license := range scanLicenses {
+			license = strings.TrimSpace(license)
+			if license != "" {
+				policy.BannedLicenses = append(policy.BannedLicenses, license)
+			}
+		}
+	}
+	if cmd.Flags().Changed("min-lines") {
+		policy.MinVerbatimLines = scanMinLines
+	}
+	if cmd.Flags().Changed("match-context") {
+		policy.MatchContext = scanMatchContext
+	}
+	if cmd.Flags().Changed("match-corpus") {
+		policy.MatchCorpus = scanMatchCorpus
+	}
+
+	err := policy.Validate()
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Invalid code scan policy: %v", err)
+		return
+	}
+
+	apiErr = api.Client.UpdateOrgCodeScanPolicy(shared.UpdateOrgCodeScanPolicyRequest{Policy: policy})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating code scan policy: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Println("✅ Updated code scan policy")
+	fmt.Println()
+
+	renderCodeScanPolicy(policy, res.NumCorpusFiles)
+}
+
+func renderCodeScanPolicy(policy *shared.OrgCodeScanPolicy, numCorpusFiles int) {
+	color.New(color.Bold, term.ColorHiCyan).Println("🔎 Code Scanning")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+
+	if !policy.Enabled {
+		table.Append([]string{"Enabled", "no"})
+		table.Render()
+		fmt.Println()
+		return
+	}
+
+	licenses := strings.Join(policy.BannedLicenses, ", ")
+	if licenses == "" {
+		licenses = "none"
+	}
+
+	var sources []string
+	if policy.MatchContext {
+		sources = append(sources, "context")
+	}
+	if policy.MatchCorpus {
+		corpus := "scan corpus"
+		if numCorpusFiles == 0 {
+			corpus += " (not configured on the server)"
+		} else {
+			corpus += fmt.Sprintf(" (%d files)", numCorpusFiles)
+		}
+		sources = append(sources, corpus)
+	}
+	if len(sources) == 0 {
+		sources = append(sources, "none")
+	}
+
+	table.Append([]string{"Enabled", "yes"})
+	table.Append([]string{"Banned licenses", licenses})
+	table.Append([]string{"Verbatim blocks", strconv.Itoa(policy.MinVerbatimLines) + "+ lines"})
+	table.Append([]string{"Matched against", strings.Join(sources, ", ")})
+	table.Render()
+	fmt.Println()
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/format"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var searchCurrentProject bool
+var searchLimit int
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search plan names, prompts, replies, and changed files",
+	Long:  `Search across every plan you can access in the org—your own plans and plans shared with the org, including archived plans. Matches plan names, prompts, replies, and the paths of files the plans changed, on every branch. Every word in the query has to match, ignoring case; wrap words in double quotes to match them as a phrase.`,
+	Args:  cobra.MinimumNArgs(1),
+	Run:   search,
+}
+
+func init() {
+	RootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().BoolVarP(&searchCurrentProject, "project", "p", false, "Only search plans in the current project")
+	searchCmd.Flags().IntVarP(&searchLimit, "limit", "l", shared.DefaultSearchLimit, fmt.Sprintf("Maximum number of results (up to %d)", shared.MaxSearchLimit))
+}
+
+func search(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	var projectId string
+	if searchCurrentProject {
+		lib.MustResolveProject()
+		projectId = lib.CurrentProjectId
+	}
+
+	query := strings.Join(args, " ")
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.SearchPlans(query, projectId, searchLimit)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error searching plans: %v", apiErr.Msg)
+	}
+
+	if len(res.Results) == 0 {
+		fmt.Printf("🤷‍♂️ No matches in %d plans\n", res.NumPlansSearched)
+		printSearchPartial(res)
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Plan", "Branch", "Match", "", "When"})
+
+	for _, result := range res.Results {
+		table.Append([]string{
+			color.New(color.Bold, term.ColorHiGreen).Sprint(result.PlanName),
+			result.Branch,
+			searchMatchLabel(result),
+			result.Snippet,
+			format.Time(result.CreatedAt),
+		})
+	}
+
+	table.Render()
+	fmt.Println()
+
+	fmt.Printf("%d matches in %d plans searched\n", len(res.Results), res.NumPlansSearched)
+	printSearchPartial(res)
+	fmt.Println()
+
+	term.PrintCmds("", "cd", "convo", "checkout")
+}
+
+func searchMatchLabel(result *shared.SearchResult) string {
+	switch result.Kind {
+	case shared.SearchResultKindPlanName:
+		return "📋 Plan name"
+	case shared.SearchResultKindPrompt:
+		return fmt.Sprintf("💬 Prompt #%d", result.MessageNum)
+	case shared.SearchResultKindResponse:
+		return fmt.Sprintf("🤖 Reply #%d", result.MessageNum)
+	case shared.SearchResultKindFilePath:
+		if result.MessageNum > 0 {
+			return fmt.Sprintf("📄 File (reply #%d)", result.MessageNum)
+		}
+		return "📄 File"
+	}
+	return string(result.Kind)
+}
+
+func printSearchPartial(res *shared.SearchResponse) {
+	if !res.Partial {
+		return
+	}
+	fmt.Println(color.New(term.ColorHiYellow).Sprint("⚠️  Only your most recently updated plans were searched, since there were too many to search at once. Use --project/-p to narrow the search."))
+}
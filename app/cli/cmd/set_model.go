@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/fatih/color"
 	"github.com/plandex/plandex/shared"
 	"github.com/spf13/cobra"
 )
@@ -57,6 +58,21 @@ func modelsSet(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	term.StartSpinner("")
+	validateRes, apiErr := api.Client.ValidateSettings(lib.CurrentPlanId, lib.CurrentBranch, shared.ValidateSettingsRequest{
+		Settings: settings,
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error checking settings: %v", apiErr.Msg)
+		return
+	}
+
+	if !confirmSettingsIssues(validateRes) {
+		return
+	}
+
 	term.StartSpinner("")
 	res, apiErr := api.Client.UpdateSettings(
 		lib.CurrentPlanId,
@@ -73,7 +89,7 @@ func modelsSet(cmd *cobra.Command, args []string) {
 
 	fmt.Println(res.Msg)
 	fmt.Println()
-	term.PrintCmds("", "models", "set-model default", "log")
+	term.PrintCmds("", "models", "models reset", "log")
 }
 
 func defaultModelsSet(cmd *cobra.Command, args []string) {
@@ -94,6 +110,21 @@ func defaultModelsSet(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	term.StartSpinner("")
+	validateRes, apiErr := api.Client.ValidateOrgDefaultSettings(shared.ValidateSettingsRequest{
+		Settings: settings,
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error checking settings: %v", apiErr.Msg)
+		return
+	}
+
+	if !confirmSettingsIssues(validateRes) {
+		return
+	}
+
 	term.StartSpinner("")
 	res, apiErr := api.Client.UpdateOrgDefaultSettings(
 		shared.UpdateSettingsRequest{
@@ -111,6 +142,48 @@ func defaultModelsSet(cmd *cobra.Command, args []string) {
 	term.PrintCmds("", "models", "set-model default", "log")
 }
 
+// confirmSettingsIssues shows the issues found when checking new settings. Settings with errors aren't saved, and settings with warnings are only saved if confirmed. Returns whether to save them.
+func confirmSettingsIssues(res *shared.ValidateSettingsResponse) bool {
+	if len(res.Issues) == 0 {
+		return true
+	}
+
+	for _, issue := range res.Issues {
+		label := color.New(color.Bold, term.ColorHiYellow).Sprint("⚠️  Warning")
+		if issue.Severity == shared.SettingsIssueError {
+			label = color.New(color.Bold, term.ColorHiRed).Sprint("🚨 Error")
+		}
+
+		msg := issue.Msg
+		if issue.Role != "" {
+			msg = fmt.Sprintf("%s | %s", color.New(color.Bold).Sprint(issue.Role), msg)
+		}
+		fmt.Printf("%s %s\n", label, msg)
+
+		if issue.Fix != "" {
+			fmt.Printf("   → %s\n", issue.Fix)
+		}
+	}
+	fmt.Println()
+
+	if !res.Valid {
+		fmt.Println("🤷‍♂️ Settings not saved. Model calls would fail with them.")
+		return false
+	}
+
+	shouldSave, err := term.ConfirmYesNo("Save the settings anyway?")
+
+	if err != nil {
+		term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
+	}
+
+	if !shouldSave {
+		fmt.Println("🤷‍♂️ Settings not saved")
+	}
+
+	return shouldSave
+}
+
 func updateModelSettings(args []string, originalSettings *shared.PlanSettings) *shared.PlanSettings {
 	// Marshal and unmarshal to make a deep copy of the settings
 	jsonBytes, err := json.Marshal(originalSettings)
@@ -132,8 +205,6 @@ func updateModelSettings(args []string, originalSettings *shared.PlanSettings) *
 	var settingCompact string
 	var settingDasherized string
 	var selectedModel *shared.AvailableModel
-	var temperature *float64
-	var topP *float64
 
 	if len(args) > 0 {
 		modelSetOrRoleOrSetting = args[0]
@@ -162,6 +233,23 @@ func updateModelSettings(args []string, originalSettings *shared.PlanSettings) *
 					}
 				}
 			}
+			if role == "" && settingCompact == "" {
+				term.StartSpinner("")
+				customModelPacks, apiErr := api.Client.ListModelPacks()
+				term.StopSpinner()
+
+				if apiErr != nil {
+					term.OutputErrorAndExit("Error getting custom model packs: %v", apiErr.Msg)
+					return nil
+				}
+
+				for _, ms := range customModelPacks {
+					if strings.EqualFold(ms.Name, modelSetOrRoleOrSetting) {
+						modelPack = ms
+						break
+					}
+				}
+			}
 		}
 	}
 
@@ -318,7 +406,15 @@ func updateModelSettings(args []string, originalSettings *shared.PlanSettings) *
 		}
 
 		if role != "" {
-			if !(propertyCompact == "temperature" || propertyCompact == "topp") {
+			var roleProp string
+			for _, prop := range shared.ModelRolePropsDasherized {
+				if propertyCompact == shared.Compact(prop) {
+					roleProp = prop
+					break
+				}
+			}
+
+			if roleProp == "" && propertyCompact != "" {
 				term.StartSpinner("")
 				customModels, apiErr := api.Client.ListCustomModels()
 				term.StopSpinner()
@@ -346,15 +442,19 @@ func updateModelSettings(args []string, originalSettings *shared.PlanSettings) *
 						break
 					}
 				}
+
+				if selectedModel == nil {
+					term.OutputErrorAndExit("%s isn't a model or setting for the %s role", args[1], role)
+					return nil
+				}
 			}
 
-			if selectedModel == nil && propertyCompact == "" {
+			if selectedModel == nil && roleProp == "" {
 			Outer:
 				for {
-					opts := []string{
-						"Select a model",
-						"Set temperature",
-						"Set top-p",
+					opts := []string{"Select a model"}
+					for _, prop := range shared.ModelRolePropsDasherized {
+						opts = append(opts, fmt.Sprintf("Set %s → %s", prop, shared.ModelRolePropDescriptions[prop]))
 					}
 
 					opts = append(opts, lib.GoBack)
@@ -387,53 +487,29 @@ func updateModelSettings(args []string, originalSettings *shared.PlanSettings) *
 						if selectedModel != nil {
 							break Outer
 						}
-					} else if selection == "Set temperature" {
-						propertyCompact = "temperature"
-						break Outer
-					} else if selection == "Set top-p" {
-						propertyCompact = "topp"
-						break Outer
+						continue
 					}
-				}
-			}
 
-			if selectedModel == nil {
-				if propertyCompact != "" {
-					if value == "" {
-						msg := "Set"
-						if propertyCompact == "temperature" {
-							msg += "temperature (-2.0 to 2.0)"
-						} else if propertyCompact == "topp" {
-							msg += "top-p (0.0 to 1.0)"
-						}
-						var err error
-						value, err = term.GetRequiredUserStringInput(msg)
-						if err != nil {
-							if err.Error() == "interrupt" {
-								return nil
-							}
-
-							term.OutputErrorAndExit("Error getting value: %v", err)
-							return nil
+					for i, opt := range opts {
+						if opt == selection {
+							roleProp = shared.ModelRolePropsDasherized[i-1]
+							break Outer
 						}
 					}
+				}
+			}
 
-					switch propertyCompact {
-					case "temperature":
-						f, err := strconv.ParseFloat(value, 32)
-						if err != nil || f < -2.0 || f > 2.0 {
-							fmt.Println("Invalid value for temperature:", value)
-							return nil
-						}
-						temperature = &f
-					case "topp":
-						f, err := strconv.ParseFloat(value, 32)
-						if err != nil || f < 0.0 || f > 1.0 {
-							fmt.Println("Invalid value for top-p:", value)
-							return nil
-						}
-						topP = &f
+			if roleProp != "" && value == "" && len(args) < 3 {
+				msg := fmt.Sprintf("Set %s (%s)", roleProp, shared.ModelRolePropDescriptions[roleProp])
+				var err error
+				value, err = term.GetUserStringInput(msg)
+				if err != nil {
+					if err.Error() == "interrupt" {
+						return nil
 					}
+
+					term.OutputErrorAndExit("Error getting value: %v", err)
+					return nil
 				}
 			}
 
@@ -441,63 +517,32 @@ func updateModelSettings(args []string, originalSettings *shared.PlanSettings) *
 				settings.ModelPack = shared.DefaultModelPack
 			}
 
-			switch role {
-			case shared.ModelRolePlanner:
-				if selectedModel != nil {
-					settings.ModelPack.Planner.BaseModelConfig = selectedModel.BaseModelConfig
+			roleConfig := settings.ModelPack.GetRoleConfig(role)
+
+			if selectedModel != nil {
+				roleConfig.BaseModelConfig = selectedModel.BaseModelConfig
+				if role == shared.ModelRolePlanner {
 					settings.ModelPack.Planner.PlannerModelConfig = shared.PlannerModelConfig{
 						MaxConvoTokens:       selectedModel.DefaultMaxConvoTokens,
 						ReservedOutputTokens: selectedModel.DefaultReservedOutputTokens,
 					}
-				} else if temperature != nil {
-					settings.ModelPack.Planner.Temperature = float32(*temperature)
-				} else if topP != nil {
-					settings.ModelPack.Planner.TopP = float32(*topP)
-				}
-
-			case shared.ModelRolePlanSummary:
-				if selectedModel != nil {
-					settings.ModelPack.PlanSummary.BaseModelConfig = selectedModel.BaseModelConfig
-				} else if temperature != nil {
-					settings.ModelPack.PlanSummary.Temperature = float32(*temperature)
-				} else if topP != nil {
-					settings.ModelPack.PlanSummary.TopP = float32(*topP)
-				}
-
-			case shared.ModelRoleBuilder:
-				if selectedModel != nil {
-					settings.ModelPack.Builder.BaseModelConfig = selectedModel.BaseModelConfig
-				} else if temperature != nil {
-					settings.ModelPack.Builder.Temperature = float32(*temperature)
-				} else if topP != nil {
-					settings.ModelPack.Builder.TopP = float32(*topP)
 				}
 
-			case shared.ModelRoleName:
-				if selectedModel != nil {
-					settings.ModelPack.Namer.BaseModelConfig = selectedModel.BaseModelConfig
-				} else if temperature != nil {
-					settings.ModelPack.Namer.Temperature = float32(*temperature)
-				} else if topP != nil {
-					settings.ModelPack.Namer.TopP = float32(*topP)
+				// settings the new model doesn't support are cleared rather than rejected
+				if selectedModel.BaseModelConfig.IsReasoningModel() {
+					roleConfig.PresencePenalty = nil
+					roleConfig.FrequencyPenalty = nil
+				} else {
+					roleConfig.ReasoningEffort = ""
 				}
-
-			case shared.ModelRoleCommitMsg:
-				if selectedModel != nil {
-					settings.ModelPack.CommitMsg.BaseModelConfig = selectedModel.BaseModelConfig
-				} else if temperature != nil {
-					settings.ModelPack.CommitMsg.Temperature = float32(*temperature)
-				} else if topP != nil {
-					settings.ModelPack.CommitMsg.TopP = float32(*topP)
+				if roleConfig.MaxResponseTokens != nil && *roleConfig.MaxResponseTokens > selectedModel.MaxTokens {
+					roleConfig.MaxResponseTokens = nil
 				}
-
-			case shared.ModelRoleExecStatus:
-				if selectedModel != nil {
-					settings.ModelPack.ExecStatus.BaseModelConfig = selectedModel.BaseModelConfig
-				} else if temperature != nil {
-					settings.ModelPack.ExecStatus.Temperature = float32(*temperature)
-				} else if topP != nil {
-					settings.ModelPack.ExecStatus.TopP = float32(*topP)
+			} else if roleProp != "" {
+				err := roleConfig.SetProp(roleProp, value)
+				if err != nil {
+					term.OutputErrorAndExit("Invalid setting: %v", err)
+					return nil
 				}
 			}
 		}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/format"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var shareWrite bool
+
+var shareCmd = &cobra.Command{
+	Use:   "share <email>",
+	Short: "Share the current plan with a user, including users outside the org",
+	Args:  cobra.ExactArgs(1),
+	Run:   share,
+}
+
+var sharesCmd = &cobra.Command{
+	Use:   "shares",
+	Short: "List the users the current plan is shared with",
+	Args:  cobra.NoArgs,
+	Run:   listShares,
+}
+
+var unshareCmd = &cobra.Command{
+	Use:   "unshare [email]",
+	Short: "Stop sharing the current plan with a user",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   unshare,
+}
+
+func init() {
+	RootCmd.AddCommand(shareCmd)
+	RootCmd.AddCommand(sharesCmd)
+	RootCmd.AddCommand(unshareCmd)
+
+	shareCmd.Flags().BoolVarP(&shareWrite, "write", "w", false, "Let the user change the plan's context, conversation, and pending changes (read-only by default)")
+}
+
+func share(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	access := shared.PlanShareAccessRead
+	if shareWrite {
+		access = shared.PlanShareAccessWrite
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.SharePlan(lib.CurrentPlanId, shared.SharePlanRequest{
+		Email:  args[0],
+		Access: access,
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error sharing plan: %v", apiErr.Msg)
+	}
+
+	fmt.Printf("✅ Shared plan with %s (%s)\n", color.New(color.Bold, term.ColorHiCyan).Sprint(res.UserEmail), shareAccessLabel(res.Access))
+	fmt.Println()
+	term.PrintCmds("", "shares", "unshare")
+}
+
+func listShares(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	shares := mustListPlanShares()
+
+	if len(shares) == 0 {
+		fmt.Println("🤷‍♂️ Plan isn't shared with any users")
+		fmt.Println()
+		term.PrintCmds("", "share")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Email", "Name", "Access", "Shared"})
+
+	for _, s := range shares {
+		table.Append([]string{s.UserEmail, s.UserName, shareAccessLabel(s.Access), format.Time(s.CreatedAt)})
+	}
+
+	table.Render()
+	fmt.Println()
+
+	term.PrintCmds("", "share", "unshare")
+}
+
+func unshare(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	shares := mustListPlanShares()
+
+	if len(shares) == 0 {
+		term.OutputErrorAndExit("Plan isn't shared with any users")
+	}
+
+	var toRemove *shared.PlanShare
+
+	if len(args) > 0 {
+		for _, s := range shares {
+			if strings.EqualFold(s.UserEmail, args[0]) {
+				toRemove = s
+				break
+			}
+		}
+
+		if toRemove == nil {
+			term.OutputErrorAndExit("Plan isn't shared with '%s'", args[0])
+		}
+	} else {
+		var options []string
+		for _, s := range shares {
+			options = append(options, fmt.Sprintf("%s <%s>", s.UserName, s.UserEmail))
+		}
+
+		selected, err := term.SelectFromList("Stop sharing with:", options)
+
+		if err != nil {
+			term.OutputErrorAndExit("Error selecting user: %v", err)
+		}
+
+		for i, opt := range options {
+			if opt == selected {
+				toRemove = shares[i]
+				break
+			}
+		}
+	}
+
+	term.StartSpinner("")
+	apiErr := api.Client.UnsharePlan(lib.CurrentPlanId, toRemove.UserId)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error unsharing plan: %v", apiErr.Msg)
+	}
+
+	fmt.Printf("✅ Stopped sharing plan with %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(toRemove.UserEmail))
+}
+
+func mustListPlanShares() []*shared.PlanShare {
+	term.StartSpinner("")
+	shares, apiErr := api.Client.ListPlanShares(lib.CurrentPlanId)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error listing plan shares: %v", apiErr.Msg)
+	}
+
+	return shares
+}
+
+func shareAccessLabel(access shared.PlanShareAccess) string {
+	if access == shared.PlanShareAccessWrite {
+		return "read/write"
+	}
+	return "read-only"
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/term"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var statsDays int
+var statsUser string
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show builds, build failures, and model token usage across the org",
+	Long:  "Show builds per day, build failure rates and average durations, and model token usage by model and by user. Org members who can manage billing see the whole org; everyone else sees their own activity.",
+	Args:  cobra.NoArgs,
+	Run:   stats,
+}
+
+func init() {
+	RootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().IntVarP(&statsDays, "days", "d", shared.DefaultStatsDays, fmt.Sprintf("Number of days to report on, counting today (up to %d)", shared.MaxStatsDays))
+	statsCmd.Flags().StringVarP(&statsUser, "user", "u", "", "Only report on an org member, by email")
+}
+
+// days with more than this many rows only list the days that had builds
+const maxStatsDayRows = 31
+
+func stats(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	if statsDays < 1 || statsDays > shared.MaxStatsDays {
+		term.OutputErrorAndExit("--days must be between 1 and %d", shared.MaxStatsDays)
+	}
+
+	term.StartSpinner("")
+
+	var userId string
+	if statsUser != "" {
+		res, apiErr := api.Client.ListUsers()
+		if apiErr != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("Error listing org members: %v", apiErr.Msg)
+		}
+
+		for _, user := range res.Users {
+			if strings.EqualFold(user.Email, statsUser) {
+				userId = user.Id
+				break
+			}
+		}
+
+		if userId == "" {
+			term.StopSpinner()
+			term.OutputErrorAndExit("No org member with email '%s'", statsUser)
+		}
+	}
+
+	res, apiErr := api.Client.GetOrgStats(statsDays, userId)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting stats: %v", apiErr.Msg)
+	}
+
+	scope := "org-wide"
+	if statsUser != "" {
+		scope = statsUser
+	} else if !res.IsOrgWide {
+		scope = "your activity"
+	}
+
+	color.New(color.Bold, term.ColorHiCyan).Printf("📊 Last %d days (%s)\n", statsDays, scope)
+	fmt.Println()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Builds", "Finished", "Failed", "Failure Rate", "Avg Duration"})
+
+	avgDuration := ""
+	if res.NumFinishedBuilds > 0 {
+		avgDuration = (time.Duration(res.AvgBuildDurationMs) * time.Millisecond).Round(time.Second).String()
+	}
+
+	table.Append([]string{
+		strconv.Itoa(res.NumBuilds),
+		strconv.Itoa(res.NumFinishedBuilds),
+		strconv.Itoa(res.NumFailedBuilds),
+		fmt.Sprintf("%.1f%%", res.FailureRate*100),
+		avgDuration,
+	})
+	table.Render()
+
+	if res.NumBuilds > 0 {
+		fmt.Println()
+		printBuildsPerDay(res.BuildsPerDay)
+	}
+
+	fmt.Println()
+
+	numEstimated := 0
+	if len(res.UsageByModel) == 0 {
+		fmt.Println("🤷‍♂️ No model usage")
+	} else {
+		table = tablewriter.NewWriter(os.Stdout)
+		table.SetAutoWrapText(false)
+		table.SetHeader([]string{"Role", "Model", "Calls", "Input 🪙", "Output 🪙", "Reasoning 🪙", "Cost"})
+
+		for _, usage := range res.UsageByModel {
+			// models whose tokenizer isn't available are counted by converting cl100k counts
+			estimated := shared.GetTokenizer(usage.ModelName).IsEstimate()
+			if estimated {
+				numEstimated++
+			}
+
+			table.Append([]string{
+				string(usage.Role),
+				fmt.Sprintf("%s/%s", usage.Provider, usage.ModelName),
+				strconv.Itoa(usage.NumCalls),
+				shared.FormatTokens(usage.InputTokens, estimated),
+				shared.FormatTokens(usage.OutputTokens, estimated),
+				strconv.Itoa(usage.ReasoningTokens),
+				shared.FormatCost(usage.Cost),
+			})
+		}
+		table.Render()
+	}
+
+	if res.IsOrgWide && statsUser == "" && len(res.UsageByUser) > 0 {
+		fmt.Println()
+
+		table = tablewriter.NewWriter(os.Stdout)
+		table.SetAutoWrapText(false)
+		table.SetHeader([]string{"User", "Calls", "Input 🪙", "Output 🪙", "Reasoning 🪙", "Cost"})
+
+		for _, usage := range res.UsageByUser {
+			user := usage.UserName
+			if usage.UserEmail != "" {
+				user = fmt.Sprintf("%s <%s>", usage.UserName, usage.UserEmail)
+			} else if usage.UserId == "" {
+				user = "(deleted user)"
+			}
+
+			table.Append([]string{
+				user,
+				strconv.Itoa(usage.NumCalls),
+				strconv.Itoa(usage.InputTokens),
+				strconv.Itoa(usage.OutputTokens),
+				strconv.Itoa(usage.ReasoningTokens),
+				shared.FormatCost(usage.Cost),
+			})
+		}
+		table.Render()
+	}
+
+	fmt.Println()
+	fmt.Println("Token counts are estimates for planner replies and file builds, except for reasoning models, which report their own usage. Reasoning tokens are included in output tokens. Costs are at the prices when each call was made, and leave out models without a known price.")
+	if numEstimated > 0 {
+		fmt.Println("~ Converted from OpenAI cl100k counts, since the model's tokenizer isn't available")
+	}
+	fmt.Println()
+	term.PrintCmds("", "stats --days", "builds")
+}
+
+func printBuildsPerDay(days []*shared.BuildDayStats) {
+	var maxBuilds int
+	for _, day := range days {
+		if day.NumBuilds > maxBuilds {
+			maxBuilds = day.NumBuilds
+		}
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Day", "Builds", "Failed", ""})
+
+	for _, day := range days {
+		if len(days) > maxStatsDayRows && day.NumBuilds == 0 {
+			continue
+		}
+
+		var bar string
+		if maxBuilds > 0 {
+			bar = strings.Repeat("█", (day.NumBuilds*30+maxBuilds-1)/maxBuilds)
+		}
+
+		failed := strconv.Itoa(day.NumFailed)
+		if day.NumFailed > 0 {
+			failed = color.New(term.ColorHiRed).Sprint(failed)
+		}
+
+		table.Append([]string{day.Day, strconv.Itoa(day.NumBuilds), failed, bar})
+	}
+	table.Render()
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var steerCmd = &cobra.Command{
+	Use:   "steer [note] [stream-id-or-plan] [branch]",
+	Short: "Send a short note to an active plan's next reply",
+	Long:  "Send a short note, like \"don't touch the tests\", to an active plan stream without stopping it. The reply that's streaming continues, and the note is added to the planner's next reply and every reply after it until the stream ends.",
+	Args:  cobra.MaximumNArgs(3),
+	Run:   steer,
+}
+
+func init() {
+	RootCmd.AddCommand(steerCmd)
+}
+
+func steer(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	var note string
+	if len(args) > 0 {
+		note = args[0]
+		args = args[1:]
+	} else {
+		var err error
+		note, err = term.GetRequiredUserStringInput("Note:")
+		if err != nil {
+			term.OutputErrorAndExit("Error reading note: %v", err)
+		}
+	}
+
+	note = strings.TrimSpace(note)
+	if len(note) > shared.MaxSteeringNoteChars {
+		term.OutputErrorAndExit("Note is %d characters. Keep it under %d, or stop the plan and send a new prompt.", len(note), shared.MaxSteeringNoteChars)
+	}
+
+	planId, branch, shouldContinue := lib.SelectActiveStream(args)
+
+	if !shouldContinue {
+		return
+	}
+
+	term.StartSpinner("")
+	apiErr := api.Client.SteerPlan(planId, branch, shared.SteerPlanRequest{Note: note})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error sending note: %v", apiErr.Msg)
+	}
+
+	fmt.Println("📝 Note sent. It will be used from the plan's next reply onward.")
+
+	fmt.Println()
+	term.PrintCmds("", "connect", "stop")
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <tag...>",
+	Short: "Add tags to the current plan",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   tag,
+}
+
+var untagCmd = &cobra.Command{
+	Use:   "untag <tag...>",
+	Short: "Remove tags from the current plan",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   untag,
+}
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "List the tags used across plans in the org",
+	Args:  cobra.NoArgs,
+	Run:   tags,
+}
+
+func init() {
+	RootCmd.AddCommand(tagCmd)
+	RootCmd.AddCommand(untagCmd)
+	RootCmd.AddCommand(tagsCmd)
+}
+
+func tag(cmd *cobra.Command, args []string) {
+	updatePlanTags(shared.UpdatePlanTagsRequest{Add: splitTagArgs(args)})
+}
+
+func untag(cmd *cobra.Command, args []string) {
+	updatePlanTags(shared.UpdatePlanTagsRequest{Remove: splitTagArgs(args)})
+}
+
+func updatePlanTags(req shared.UpdatePlanTagsRequest) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	for _, t := range append(req.Add, req.Remove...) {
+		if _, err := shared.NormalizePlanTag(t); err != nil {
+			term.OutputErrorAndExit("Invalid tag: %v", err)
+		}
+	}
+
+	term.StartSpinner("")
+	tags, apiErr := api.Client.UpdatePlanTags(lib.CurrentPlanId, req)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating tags: %v", apiErr.Msg)
+	}
+
+	if len(tags) == 0 {
+		fmt.Println("🏷️  Plan has no tags")
+	} else {
+		fmt.Println("🏷️  Plan tags: " + color.New(color.Bold, term.ColorHiCyan).Sprint(strings.Join(tags, ", ")))
+	}
+
+	fmt.Println()
+	term.PrintCmds("", "tags", "plans --tag")
+}
+
+func tags(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	counts, apiErr := api.Client.ListPlanTags()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error listing tags: %v", apiErr.Msg)
+	}
+
+	if len(counts) == 0 {
+		fmt.Println("🤷‍♂️ No tags")
+		fmt.Println()
+		term.PrintCmds("", "tag")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Tag", "Plans"})
+
+	for _, count := range counts {
+		table.Append([]string{count.Tag, strconv.Itoa(count.NumPlans)})
+	}
+
+	table.Render()
+
+	fmt.Println()
+	term.PrintCmds("", "plans --tag", "tag", "untag")
+}
+
+// splitTagArgs accepts tags as separate args or comma-separated
+func splitTagArgs(args []string) []string {
+	var res []string
+	for _, arg := range args {
+		for _, t := range strings.Split(arg, ",") {
+			if strings.TrimSpace(t) != "" {
+				res = append(res, t)
+			}
+		}
+	}
+	return res
+}
@@ -8,8 +8,10 @@ import (
 	"plandex/lib"
 	"plandex/plan_exec"
 	"plandex/term"
+	"plandex/types"
 	"strings"
 
+	"github.com/fatih/color"
 	"github.com/plandex/plandex/shared"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +24,8 @@ var tellPromptFile string
 var tellBg bool
 var tellStop bool
 var tellNoBuild bool
+var tellEditor bool
+var tellDraft bool
 
 // tellCmd represents the prompt command
 var tellCmd = &cobra.Command{
@@ -40,6 +44,8 @@ func init() {
 	tellCmd.Flags().BoolVarP(&tellStop, "stop", "s", false, "Stop after a single reply")
 	tellCmd.Flags().BoolVarP(&tellNoBuild, "no-build", "n", false, "Don't build files")
 	tellCmd.Flags().BoolVar(&tellBg, "bg", false, "Execute autonomously in the background")
+	tellCmd.Flags().BoolVarP(&tellEditor, "editor", "e", false, "Write the prompt in $EDITOR from a template with task, constraints, and files sections")
+	tellCmd.Flags().BoolVar(&tellDraft, "draft", false, "Send the plan's draft messages as one prompt (with --editor, review them first)")
 }
 
 func doTell(cmd *cobra.Command, args []string) {
@@ -51,11 +57,21 @@ func doTell(cmd *cobra.Command, args []string) {
 		term.OutputNoCurrentPlanErrorAndExit()
 	}
 
-	apiKeys := lib.MustVerifyApiKeys()
+	// when the server can't be reached, the prompt is queued instead of lost
+	term.StartSpinner("")
+	serverReachable := lib.IsServerReachable()
+	term.StopSpinner()
+
+	var apiKeys map[string]string
+	if serverReachable {
+		apiKeys = lib.MustVerifyApiKeys()
+	}
 
 	var prompt string
 
-	if len(args) > 0 {
+	if tellDraft {
+		prompt = mustGetDraftPrompt(args)
+	} else if len(args) > 0 {
 		prompt = args[0]
 	} else if tellPromptFile != "" {
 		bytes, err := os.ReadFile(tellPromptFile)
@@ -63,6 +79,8 @@ func doTell(cmd *cobra.Command, args []string) {
 			term.OutputErrorAndExit("Error reading prompt file: %v", err)
 		}
 		prompt = string(bytes)
+	} else if tellEditor {
+		prompt = cleanTemplatePrompt(getEditorPromptWithText(promptTemplate))
 	} else {
 		prompt = getEditorPrompt()
 	}
@@ -72,6 +90,37 @@ func doTell(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	// a sent or queued draft is cleared, since the prompt now holds it
+	onDraftUsed := func() {
+		if !tellDraft {
+			return
+		}
+
+		err := lib.ClearDraft()
+
+		if err != nil {
+			term.OutputErrorAndExit("Error clearing draft: %v", err)
+		}
+	}
+
+	action := &types.QueuedAction{
+		Type:        types.QueuedActionTell,
+		Prompt:      prompt,
+		TellBg:      tellBg,
+		TellStop:    tellStop,
+		TellNoBuild: tellNoBuild,
+	}
+
+	if !serverReachable {
+		lib.MustQueueAction(action)
+		onDraftUsed()
+		return
+	}
+
+	if maybeSendQueuedFirst(action, onDraftUsed) {
+		return
+	}
+
 	plan_exec.TellPlan(plan_exec.ExecParams{
 		CurrentPlanId: lib.CurrentPlanId,
 		CurrentBranch: lib.CurrentBranch,
@@ -79,9 +128,40 @@ func doTell(cmd *cobra.Command, args []string) {
 		CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
 			return lib.MustCheckOutdatedContext(false, maybeContexts)
 		},
+		OnFinished:   lib.MaybeAutoApply,
+		OnPromptSent: onDraftUsed,
+		OnServerUnreachable: func() {
+			lib.MustQueueAction(action)
+			onDraftUsed()
+		},
 	}, prompt, tellBg, tellStop, tellNoBuild, false)
 }
 
+// mustGetDraftPrompt joins the plan's draft messages, plus a final message from the command line if given. With --editor, the joined prompt is opened for review before it's sent.
+func mustGetDraftPrompt(args []string) string {
+	messages, err := lib.LoadDraft()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading draft: %v", err)
+	}
+
+	if len(messages) == 0 {
+		term.OutputErrorAndExit("No draft for this plan. Add messages with %s.", color.New(color.Bold, term.ColorHiCyan).Sprint("plandex draft add"))
+	}
+
+	prompt := lib.DraftPrompt(messages)
+
+	if len(args) > 0 {
+		prompt += "\n\n" + strings.TrimSpace(args[0])
+	}
+
+	if tellEditor {
+		prompt = cleanTemplatePrompt(getEditorPromptWithText(prompt))
+	}
+
+	return prompt
+}
+
 func prepareEditorCommand(editor string, filename string) *exec.Cmd {
 	switch editor {
 	case "vim":
@@ -98,6 +178,11 @@ func getEditorInstructions(editor string) string {
 }
 
 func getEditorPrompt() string {
+	return getEditorPromptWithText("")
+}
+
+// getEditorPromptWithText opens the editor with text already filled in below the instructions
+func getEditorPromptWithText(text string) string {
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
 		editor = os.Getenv("VISUAL")
@@ -113,7 +198,7 @@ func getEditorPrompt() string {
 
 	instructions := getEditorInstructions(editor)
 	filename := tempFile.Name()
-	err = os.WriteFile(filename, []byte(instructions), 0644)
+	err = os.WriteFile(filename, []byte(instructions+text), 0644)
 	if err != nil {
 		term.OutputErrorAndExit("Failed to write instructions to temporary file: %v", err)
 	}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var testsCmd = &cobra.Command{
+	Use:   "tests [on|off]",
+	Short: "Show or set test scaffolding, and list the tests it wrote",
+	Long: `Show or set test scaffolding, and list the tests it wrote.
+
+	With test scaffolding on, each file a build changes is followed by a build of its tests, at the path the language's conventions use—like 'term_test.go' for 'term.go' or 'format.test.ts' for 'format.ts'. Existing tests are updated, and missing ones are created. Scaffolded tests are pending changes like any other, so they can be reviewed, rejected with 'plandex reject --tests', or applied.
+	`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"on", "off"},
+	Run:       tests,
+}
+
+func init() {
+	RootCmd.AddCommand(testsCmd)
+}
+
+func tests(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current settings: %v", apiErr.Msg)
+	}
+
+	if len(args) == 0 {
+		showTestScaffolds(settings)
+		return
+	}
+
+	var enabled bool
+	switch strings.ToLower(args[0]) {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		term.OutputErrorAndExit("Test scaffolding must be 'on' or 'off'")
+	}
+
+	if settings.ScaffoldTests == enabled {
+		fmt.Printf("🤷‍♂️ Test scaffolding is already %s\n", args[0])
+		return
+	}
+
+	settings.ScaffoldTests = enabled
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.UpdateSettings(
+		lib.CurrentPlanId,
+		lib.CurrentBranch,
+		shared.UpdateSettingsRequest{
+			Settings: settings,
+		})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating settings: %v", apiErr.Msg)
+	}
+
+	fmt.Println(res.Msg)
+	fmt.Println()
+
+	if enabled {
+		fmt.Println("🧪 Files changed by the next build will have their tests built too")
+		fmt.Println()
+		term.PrintCmds("", "build", "tests")
+		return
+	}
+
+	term.PrintCmds("", "tests")
+}
+
+func showTestScaffolds(settings *shared.PlanSettings) {
+	if settings.ScaffoldTests {
+		fmt.Println("🧪 Test scaffolding: on")
+	} else {
+		fmt.Println("🧪 Test scaffolding: off")
+	}
+	fmt.Println()
+
+	term.StartSpinner("")
+	state, apiErr := api.Client.GetCurrentPlanState(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr.Msg)
+	}
+
+	sourcesByPath := state.PendingTestScaffoldsByPath()
+
+	if len(sourcesByPath) == 0 {
+		fmt.Println("🤷‍♂️ No pending scaffolded tests")
+		fmt.Println()
+		term.PrintCmds("", "tests on", "tests off")
+		return
+	}
+
+	var paths []string
+	for path := range sourcesByPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Tests", "For Changes To"})
+	for _, path := range paths {
+		table.Append([]string{path, sourcesByPath[path]})
+	}
+	table.Render()
+	fmt.Println()
+
+	term.PrintCmds("", "diff", "reject --tests", "apply")
+}
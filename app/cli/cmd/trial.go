@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/term"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var trialEnable bool
+var trialDisable bool
+var trialMaxPlans int
+var trialMaxTokens int
+var trialRotateCode bool
+var trialHost string
+
+func init() {
+	RootCmd.AddCommand(trialCmd)
+	trialCmd.AddCommand(setTrialCmd)
+	trialCmd.AddCommand(joinTrialCmd)
+	trialCmd.AddCommand(upgradeTrialCmd)
+
+	setTrialCmd.Flags().BoolVar(&trialEnable, "enable", false, "Let people start trials with the org's access code")
+	setTrialCmd.Flags().BoolVar(&trialDisable, "disable", false, "Stop new trials and block existing trial users until they sign up")
+	setTrialCmd.Flags().IntVar(&trialMaxPlans, "max-plans", shared.DefaultTrialMaxPlans, "Max number of plans per trial user")
+	setTrialCmd.Flags().IntVar(&trialMaxTokens, "max-tokens", shared.DefaultTrialMaxTokens, "Max number of model tokens per trial user")
+	setTrialCmd.Flags().BoolVar(&trialRotateCode, "rotate-code", false, "Replace the access code so the current one stops working")
+
+	joinTrialCmd.Flags().StringVar(&trialHost, "host", "", "Host of the self-hosted Plandex server")
+}
+
+var trialCmd = &cobra.Command{
+	Use:   "trial",
+	Short: "Show the org's trial policy for guests without accounts",
+	Args:  cobra.NoArgs,
+	Run:   showTrial,
+}
+
+var setTrialCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Update the org's trial policy",
+	Args:  cobra.NoArgs,
+	Run:   setTrial,
+}
+
+var joinTrialCmd = &cobra.Command{
+	Use:   "join [access-code]",
+	Short: "Start a trial in an org on a self-hosted server",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   joinTrial,
+}
+
+var upgradeTrialCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Sign up and keep your trial plans",
+	Args:  cobra.NoArgs,
+	Run:   upgradeTrial,
+}
+
+func showTrial(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	policy, apiErr := api.Client.GetOrgTrialPolicy()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting trial policy: %v", apiErr.Msg)
+		return
+	}
+
+	renderTrialPolicy(policy)
+
+	term.PrintCmds("", "trial set")
+}
+
+func setTrial(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	if auth.Current.IsCloud {
+		term.OutputErrorAndExit("Trial policies are only available on self-hosted servers")
+		return
+	}
+
+	if trialEnable && trialDisable {
+		term.OutputErrorAndExit("Pass only one of --enable or --disable")
+		return
+	}
+
+	if !trialEnable && !trialDisable && !cmd.Flags().Changed("max-plans") && !cmd.Flags().Changed("max-tokens") && !trialRotateCode {
+		term.OutputErrorAndExit("Set at least one of --enable, --disable, --max-plans, --max-tokens, or --rotate-code")
+		return
+	}
+
+	term.StartSpinner("")
+	policy, apiErr := api.Client.GetOrgTrialPolicy()
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting trial policy: %v", apiErr.Msg)
+		return
+	}
+
+	// only the flags that were passed are changed
+	if trialEnable {
+		policy.Enabled = true
+	}
+	if trialDisable {
+		policy.Enabled = false
+	}
+	if cmd.Flags().Changed("max-plans") {
+		policy.MaxPlans = trialMaxPlans
+	}
+	if cmd.Flags().Changed("max-tokens") {
+		policy.MaxTokens = trialMaxTokens
+	}
+
+	err := policy.Validate()
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Invalid trial policy: %v", err)
+		return
+	}
+
+	res, apiErr := api.Client.UpdateOrgTrialPolicy(shared.UpdateOrgTrialPolicyRequest{
+		Policy:           policy,
+		RotateAccessCode: trialRotateCode,
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating trial policy: %v", apiErr.Msg)
+		return
+	}
+
+	fmt.Println("✅ Updated trial policy")
+	fmt.Println()
+
+	renderTrialPolicy(res.Policy)
+
+	if res.AccessCode != "" {
+		fmt.Println("🔑 Access code: " + color.New(color.Bold, term.ColorHiGreen).Sprint(res.AccessCode))
+		fmt.Println("It's only shown once. Anyone with the code can start a trial by running:")
+		fmt.Println(color.New(color.Bold, term.ColorHiCyan).Sprintf("plandex trial join %s --host %s", res.AccessCode, auth.Current.Host))
+		fmt.Println()
+	}
+}
+
+func joinTrial(cmd *cobra.Command, args []string) {
+	var err error
+
+	host := trialHost
+	if host == "" {
+		host, err = term.GetRequiredUserStringInput("Host:")
+
+		if err != nil {
+			term.OutputErrorAndExit("Error prompting host: %v", err)
+		}
+	}
+
+	var accessCode string
+	if len(args) > 0 {
+		accessCode = args[0]
+	} else {
+		accessCode, err = term.GetRequiredUserStringInput("Access code:")
+
+		if err != nil {
+			term.OutputErrorAndExit("Error prompting access code: %v", err)
+		}
+	}
+
+	err = auth.StartGuestTrial(strings.TrimSuffix(host, "/"), strings.TrimSpace(accessCode))
+
+	if err != nil {
+		term.OutputErrorAndExit("Error starting trial: %v", err)
+	}
+
+	fmt.Printf("✅ Started a trial in %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(auth.Current.OrgName))
+	fmt.Printf("Your plans are kept when you sign up with %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint("plandex trial upgrade"))
+	fmt.Println()
+
+	term.PrintCmds("", "new", "trial upgrade")
+}
+
+func upgradeTrial(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	if !auth.Current.IsTrial {
+		fmt.Println("🤷‍♂️ You're not using a trial")
+		return
+	}
+
+	err := auth.ConvertTrial()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error upgrading trial: %v", err)
+	}
+
+	if auth.Current.IsTrial {
+		return
+	}
+
+	fmt.Printf("✅ Signed up as %s | Org: %s\n", color.New(color.Bold, term.ColorHiGreen).Sprintf("<%s> %s", auth.Current.UserName, auth.Current.Email), color.New(term.ColorHiCyan).Sprint(auth.Current.OrgName))
+	fmt.Println()
+
+	term.PrintCmds("", "plans", "new")
+}
+
+func renderTrialPolicy(policy *shared.OrgTrialPolicy) {
+	enabled := "no"
+	if policy.Enabled {
+		enabled = "yes"
+	}
+
+	accessCode := "not generated"
+	if policy.HasAccessCode {
+		accessCode = "generated"
+	}
+
+	color.New(color.Bold, term.ColorHiCyan).Println("🧪 Trial Policy")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.Append([]string{"Trials enabled", enabled})
+	table.Append([]string{"Max plans per trial user", strconv.Itoa(policy.MaxPlans)})
+	table.Append([]string{"Max tokens per trial user", strconv.Itoa(policy.MaxTokens)})
+	table.Append([]string{"Access code", accessCode})
+	table.Render()
+	fmt.Println()
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var trustOrg bool
+
+func init() {
+	RootCmd.AddCommand(trustCmd)
+	trustCmd.AddCommand(setTrustCmd)
+	trustCmd.AddCommand(trustDirsCmd)
+	trustCmd.AddCommand(trustCostCmd)
+	trustCmd.AddCommand(resetTrustCmd)
+
+	setTrustCmd.Flags().BoolVar(&trustOrg, "org", false, "Update the org's policy instead of the project's")
+	trustDirsCmd.Flags().BoolVar(&trustOrg, "org", false, "Update the org's policy instead of the project's")
+	trustCostCmd.Flags().BoolVar(&trustOrg, "org", false, "Update the org's policy instead of the project's")
+}
+
+var trustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Show which actions Plandex can take without confirmation in this project",
+	Args:  cobra.NoArgs,
+	Run:   showTrust,
+}
+
+var setTrustCmd = &cobra.Command{
+	Use:   "set <action> <allow|confirm|deny>",
+	Short: "Set whether an action needs confirmation",
+	Args:  cobra.ExactArgs(2),
+	Run:   setTrust,
+}
+
+var trustDirsCmd = &cobra.Command{
+	Use:   "dirs [dirs...]",
+	Short: "Set the dirs where new files can always be created (no dirs to clear)",
+	Run:   setTrustDirs,
+}
+
+var trustCostCmd = &cobra.Command{
+	Use:   "cost <usd>",
+	Short: "Pause builds estimated to cost more than this until they're confirmed (0 to never pause)",
+	Args:  cobra.ExactArgs(1),
+	Run:   setTrustCost,
+}
+
+var resetTrustCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Remove the project's own policy so only the org's applies",
+	Args:  cobra.NoArgs,
+	Run:   resetTrust,
+}
+
+func showTrust(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	term.StartSpinner("")
+	policy := lib.MustGetTrustPolicy()
+	term.StopSpinner()
+
+	renderTrustPolicy(policy)
+
+	term.PrintCmds("", "trust set", "trust dirs", "trust cost", "trust reset")
+}
+
+func setTrust(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	action := shared.TrustAction(strings.ToLower(args[0]))
+	level := shared.TrustLevel(strings.ToLower(args[1]))
+
+	if _, ok := shared.TrustActionDescriptions[action]; !ok {
+		var actions []string
+		for _, a := range shared.TrustActions {
+			actions = append(actions, string(a))
+		}
+		term.OutputErrorAndExit("'%s' isn't an action. Actions are %s", args[0], strings.Join(actions, ", "))
+	}
+
+	err := level.Validate()
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	mustUpdateTrustPolicy(func(policy *shared.TrustPolicy) {
+		policy.SetLevel(action, level)
+	})
+
+	fmt.Printf("✅ Set %s to %s in the %s policy\n", color.New(color.Bold, term.ColorHiCyan).Sprint(action), color.New(color.Bold, term.ColorHiGreen).Sprint(level), trustPolicyScope())
+	fmt.Println()
+
+	term.PrintCmds("", "trust")
+}
+
+func setTrustDirs(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	mustUpdateTrustPolicy(func(policy *shared.TrustPolicy) {
+		policy.CreateFilesDirs = args
+	})
+
+	if len(args) == 0 {
+		fmt.Printf("✅ Cleared dirs for new files in the %s policy\n", trustPolicyScope())
+	} else {
+		fmt.Printf("✅ New files can always be created in %s in the %s policy\n", color.New(color.Bold, term.ColorHiCyan).Sprint(strings.Join(args, ", ")), trustPolicyScope())
+	}
+	fmt.Println()
+
+	term.PrintCmds("", "trust")
+}
+
+func setTrustCost(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	threshold, err := strconv.ParseFloat(strings.TrimPrefix(args[0], "$"), 64)
+	if err != nil || threshold < 0 {
+		term.OutputErrorAndExit("'%s' isn't a valid amount. Use a number of dollars like 0.50, or 0 to never pause builds.", args[0])
+	}
+
+	mustUpdateTrustPolicy(func(policy *shared.TrustPolicy) {
+		policy.BuildCostConfirmAbove = threshold
+	})
+
+	if threshold == 0 {
+		fmt.Printf("✅ Builds won't wait for confirmation based on cost in the %s policy\n", trustPolicyScope())
+	} else {
+		fmt.Printf("✅ Builds estimated to cost more than %s will wait for confirmation in the %s policy\n", color.New(color.Bold, term.ColorHiCyan).Sprint(shared.FormatCost(threshold)), trustPolicyScope())
+	}
+	fmt.Println()
+
+	term.PrintCmds("", "trust")
+}
+
+func resetTrust(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	term.StartSpinner("")
+	apiErr := api.Client.DeleteProjectTrustPolicy(lib.CurrentProjectId)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error resetting trust policy: %v", apiErr.Msg)
+	}
+
+	fmt.Println("✅ Removed the project's trust policy—the org's policy applies")
+}
+
+// mustUpdateTrustPolicy applies an update to the org's policy with --org, or else to the project's. A project without its own policy starts from a copy of the org's, so only the updated setting differs.
+func mustUpdateTrustPolicy(update func(policy *shared.TrustPolicy)) {
+	term.StartSpinner("")
+	current := lib.MustGetTrustPolicy()
+
+	var policy shared.TrustPolicy
+	if trustOrg || current.Project == nil {
+		policy = *current.Org
+	} else {
+		policy = *current.Project
+	}
+	policy.CreateFilesDirs = append([]string{}, policy.CreateFilesDirs...)
+
+	update(&policy)
+	policy.NormalizeDirs()
+
+	err := policy.Validate()
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Invalid trust policy: %v", err)
+	}
+
+	req := shared.UpdateTrustPolicyRequest{Policy: &policy}
+
+	var apiErr *shared.ApiError
+	if trustOrg {
+		apiErr = api.Client.UpdateOrgTrustPolicy(req)
+	} else {
+		apiErr = api.Client.UpdateProjectTrustPolicy(lib.CurrentProjectId, req)
+	}
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating trust policy: %v", apiErr.Msg)
+	}
+}
+
+func trustPolicyScope() string {
+	if trustOrg {
+		return "org"
+	}
+	return "project"
+}
+
+func renderTrustPolicy(policy *shared.EffectiveTrustPolicy) {
+	color.New(color.Bold, term.ColorHiCyan).Println("🛡️  Trust Policy")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Action", "Description", "Org", "Project", "Applies"})
+
+	for _, action := range shared.TrustActions {
+		projectLevel := "-"
+		if policy.Project != nil {
+			projectLevel = string(policy.Project.Level(action))
+		}
+
+		table.Append([]string{
+			string(action),
+			shared.TrustActionDescriptions[action],
+			string(policy.Org.Level(action)),
+			projectLevel,
+			trustLevelLabel(policy.Level(action)),
+		})
+	}
+
+	table.Render()
+	fmt.Println()
+
+	orgDirs := "none"
+	if len(policy.Org.CreateFilesDirs) > 0 {
+		orgDirs = strings.Join(policy.Org.CreateFilesDirs, ", ")
+	}
+	fmt.Println("Dirs where new files are always allowed")
+	fmt.Println("  Org: " + orgDirs)
+
+	if policy.Project != nil {
+		projectDirs := "none"
+		if len(policy.Project.CreateFilesDirs) > 0 {
+			projectDirs = strings.Join(policy.Project.CreateFilesDirs, ", ")
+		}
+		fmt.Println("  Project: " + projectDirs)
+	}
+	fmt.Println()
+
+	fmt.Println("Confirm builds estimated to cost more than")
+	fmt.Println("  Org: " + buildCostThresholdLabel(policy.Org.BuildCostConfirmAbove))
+	if policy.Project != nil {
+		fmt.Println("  Project: " + buildCostThresholdLabel(policy.Project.BuildCostConfirmAbove))
+	}
+	fmt.Println()
+
+	if policy.Project == nil {
+		fmt.Println("This project doesn't have its own policy, so the org's applies")
+	} else {
+		fmt.Println("Where the org and project policies differ, the stricter one applies")
+	}
+	fmt.Println()
+}
+
+func buildCostThresholdLabel(threshold float64) string {
+	if threshold == 0 {
+		return "never confirm"
+	}
+	return shared.FormatCost(threshold)
+}
+
+func trustLevelLabel(level shared.TrustLevel) string {
+	switch level {
+	case shared.TrustLevelAllow:
+		return color.New(term.ColorHiGreen).Sprint(level)
+	case shared.TrustLevelConfirm:
+		return color.New(term.ColorHiYellow).Sprint(level)
+	case shared.TrustLevelDeny:
+		return color.New(term.ColorHiRed).Sprint(level)
+	}
+	return string(level)
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var workspacesCmd = &cobra.Command{
+	Use:     "workspaces",
+	Aliases: []string{"ws"},
+	Short:   "List workspace roots for the current plan",
+	Long: `Workspaces scope a plan to one or more directories in a monorepo. When workspaces are set, context loading skips paths outside them, and file paths in model output are resolved against them.
+
+	plandex workspaces add apps/api apps/web
+	plandex workspaces rm apps/web
+	plandex workspaces clear
+	`,
+	Args: cobra.NoArgs,
+	Run:  listWorkspaces,
+}
+
+var workspacesAddCmd = &cobra.Command{
+	Use:   "add [dirs...]",
+	Short: "Add workspace roots to the current plan",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   addWorkspaces,
+}
+
+var workspacesRmCmd = &cobra.Command{
+	Use:     "rm [dirs...]",
+	Aliases: []string{"remove"},
+	Short:   "Remove workspace roots from the current plan",
+	Args:    cobra.MinimumNArgs(1),
+	Run:     rmWorkspaces,
+}
+
+var workspacesClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all workspace roots from the current plan",
+	Args:  cobra.NoArgs,
+	Run:   clearWorkspaces,
+}
+
+func init() {
+	RootCmd.AddCommand(workspacesCmd)
+	workspacesCmd.AddCommand(workspacesAddCmd)
+	workspacesCmd.AddCommand(workspacesRmCmd)
+	workspacesCmd.AddCommand(workspacesClearCmd)
+}
+
+func listWorkspaces(cmd *cobra.Command, args []string) {
+	settings := mustGetWorkspaceSettings()
+
+	if len(settings.Workspaces) == 0 {
+		fmt.Println("🤷‍♂️ No workspaces set. The plan is scoped to the whole project.")
+		fmt.Println()
+		term.PrintCmds("", "workspaces add")
+		return
+	}
+
+	fmt.Println("📂 Workspaces")
+	for _, root := range settings.Workspaces {
+		fmt.Printf("  • %s\n", root)
+	}
+	fmt.Println()
+	term.PrintCmds("", "workspaces add", "workspaces rm")
+}
+
+func addWorkspaces(cmd *cobra.Command, args []string) {
+	settings := mustGetWorkspaceSettings()
+
+	for _, arg := range args {
+		root := mustResolveWorkspaceRoot(arg)
+		if root == "" {
+			term.OutputErrorAndExit("The project root can't be a workspace. Use 'plandex workspaces clear' to scope the plan to the whole project.")
+		}
+
+		info, err := os.Stat(filepath.Join(fs.ProjectRoot, root))
+		if err != nil || !info.IsDir() {
+			term.OutputErrorAndExit("%s is not a directory", arg)
+		}
+
+		settings.Workspaces = append(settings.Workspaces, root)
+	}
+
+	mustUpdateWorkspaces(settings)
+}
+
+func rmWorkspaces(cmd *cobra.Command, args []string) {
+	settings := mustGetWorkspaceSettings()
+
+	toRemove := map[string]bool{}
+	for _, arg := range args {
+		toRemove[mustResolveWorkspaceRoot(arg)] = true
+	}
+
+	var workspaces []string
+	for _, root := range settings.Workspaces {
+		if !toRemove[root] {
+			workspaces = append(workspaces, root)
+		}
+	}
+
+	if len(workspaces) == len(settings.Workspaces) {
+		fmt.Println("🤷‍♂️ No matching workspaces")
+		return
+	}
+
+	settings.Workspaces = workspaces
+
+	mustUpdateWorkspaces(settings)
+}
+
+func clearWorkspaces(cmd *cobra.Command, args []string) {
+	settings := mustGetWorkspaceSettings()
+
+	if len(settings.Workspaces) == 0 {
+		fmt.Println("🤷‍♂️ No workspaces set")
+		return
+	}
+
+	settings.Workspaces = nil
+
+	mustUpdateWorkspaces(settings)
+}
+
+func mustGetWorkspaceSettings() *shared.PlanSettings {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputNoCurrentPlanErrorAndExit()
+	}
+
+	term.StartSpinner("")
+	settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current settings: %v", apiErr.Msg)
+	}
+
+	return settings
+}
+
+func mustUpdateWorkspaces(settings *shared.PlanSettings) {
+	term.StartSpinner("")
+	res, apiErr := api.Client.UpdateSettings(
+		lib.CurrentPlanId,
+		lib.CurrentBranch,
+		shared.UpdateSettingsRequest{
+			Settings: settings,
+		})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating settings: %v", apiErr.Msg)
+	}
+
+	fmt.Println(res.Msg)
+	fmt.Println()
+	term.PrintCmds("", "workspaces", "load")
+}
+
+// workspace roots are stored relative to the project root
+func mustResolveWorkspaceRoot(dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		term.OutputErrorAndExit("Error resolving path %s: %v", dir, err)
+	}
+
+	relDir, err := filepath.Rel(fs.ProjectRoot, absDir)
+	if err != nil || strings.HasPrefix(relDir, "..") {
+		term.OutputErrorAndExit("%s is outside the project", dir)
+	}
+
+	return shared.NormalizeWorkspaceRoot(filepath.ToSlash(relDir))
+}
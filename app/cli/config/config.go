@@ -0,0 +1,122 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"strings"
+)
+
+// CLI settings are stored locally in config.json in the home plandex dir. They apply to every project and account on this machine.
+
+type NotificationMode string
+
+const (
+	NotificationsOff       NotificationMode = "off"
+	NotificationsUnfocused NotificationMode = "unfocused"
+	NotificationsAlways    NotificationMode = "always"
+)
+
+type Config struct {
+	Notifications NotificationMode `json:"notifications,omitempty"`
+}
+
+func (c *Config) NotificationMode() NotificationMode {
+	if c.Notifications == "" {
+		return NotificationsOff
+	}
+	return c.Notifications
+}
+
+// Setting describes a key for 'plandex config set'
+type Setting struct {
+	Key         string
+	Description string
+	Values      []string
+	get         func(c *Config) string
+	set         func(c *Config, value string)
+}
+
+var Settings = []*Setting{
+	{
+		Key:         "notifications",
+		Description: "Desktop notification when a plan or build finishes or fails",
+		Values:      []string{string(NotificationsOff), string(NotificationsUnfocused), string(NotificationsAlways)},
+		get: func(c *Config) string {
+			return string(c.NotificationMode())
+		},
+		set: func(c *Config, value string) {
+			c.Notifications = NotificationMode(value)
+		},
+	},
+}
+
+func (s *Setting) Get(c *Config) string {
+	return s.get(c)
+}
+
+// Set validates and sets a value
+func (s *Setting) Set(c *Config, value string) error {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	for _, v := range s.Values {
+		if v == value {
+			s.set(c, value)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s must be %s", s.Key, strings.Join(s.Values, " | "))
+}
+
+func GetSetting(key string) *Setting {
+	for _, s := range Settings {
+		if s.Key == key {
+			return s
+		}
+	}
+	return nil
+}
+
+func configPath() string {
+	return filepath.Join(fs.HomePlandexDir, "config.json")
+}
+
+func Load() (*Config, error) {
+	var c Config
+
+	bytes, err := os.ReadFile(configPath())
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &c, nil
+		}
+		return nil, fmt.Errorf("error reading config.json: %v", err)
+	}
+
+	err = json.Unmarshal(bytes, &c)
+
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling config.json: %v", err)
+	}
+
+	return &c, nil
+}
+
+func Write(c *Config) error {
+	bytes, err := json.MarshalIndent(c, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("error marshalling config: %v", err)
+	}
+
+	err = os.WriteFile(configPath(), bytes, 0644)
+
+	if err != nil {
+		return fmt.Errorf("error writing config.json: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"strings"
+	"sync"
+
+	"github.com/plandex/plandex/shared"
+	"gopkg.in/yaml.v3"
+)
+
+// Project settings are stored in config.yml in the project's .plandex dir. Unlike config.json, the file is meant to be committed, so everyone working on the project gets the same defaults.
+
+type AutoApplyPolicy string
+
+const (
+	// changes are only applied with 'plandex apply'
+	AutoApplyOff AutoApplyPolicy = "off"
+	// after a plan finishes with pending changes, ask whether to apply them
+	AutoApplyConfirm AutoApplyPolicy = "confirm"
+	// after a plan finishes with pending changes, apply them without asking
+	AutoApplyAlways AutoApplyPolicy = "always"
+)
+
+var AutoApplyPolicies = []AutoApplyPolicy{AutoApplyOff, AutoApplyConfirm, AutoApplyAlways}
+
+type ProjectConfig struct {
+	// gitignore-style patterns for files loaded into the context of every new plan
+	Context []string `yaml:"context,omitempty"`
+
+	// name of a built-in or custom model pack set on every new plan
+	ModelPack string `yaml:"modelPack,omitempty"`
+
+	AutoApply AutoApplyPolicy `yaml:"autoApply,omitempty"`
+
+	// scripts run for apply hooks, relative to the project root, in place of the scripts in .plandex/hooks
+	Hooks map[shared.ApplyHookType]string `yaml:"hooks,omitempty"`
+
+	// a file with the project's conventions, relative to the project root, sent with every prompt
+	Conventions string `yaml:"conventions,omitempty"`
+}
+
+func (c *ProjectConfig) AutoApplyPolicy() AutoApplyPolicy {
+	if c.AutoApply == "" {
+		return AutoApplyOff
+	}
+	return c.AutoApply
+}
+
+// HookPath returns the absolute path of the script configured for an apply hook, or an empty string if none is configured
+func (c *ProjectConfig) HookPath(hook shared.ApplyHookType) string {
+	if c.Hooks[hook] == "" {
+		return ""
+	}
+	return filepath.Join(fs.ProjectRoot, filepath.FromSlash(c.Hooks[hook]))
+}
+
+// ConventionsPath returns the absolute path of the conventions file, or an empty string if none is configured
+func (c *ProjectConfig) ConventionsPath() string {
+	if c.Conventions == "" {
+		return ""
+	}
+	return filepath.Join(fs.ProjectRoot, filepath.FromSlash(c.Conventions))
+}
+
+func (c *ProjectConfig) validate() error {
+	valid := false
+	for _, p := range AutoApplyPolicies {
+		if c.AutoApplyPolicy() == p {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("autoApply must be %s", joinPolicies())
+	}
+
+	for hook, path := range c.Hooks {
+		if hook != shared.ApplyHookPre && hook != shared.ApplyHookPost {
+			return fmt.Errorf("'%s' isn't an apply hook—hooks are %s and %s", hook, shared.ApplyHookPre, shared.ApplyHookPost)
+		}
+		if err := validateProjectPath(path); err != nil {
+			return fmt.Errorf("%s hook: %v", hook, err)
+		}
+	}
+
+	if c.Conventions != "" {
+		if err := validateProjectPath(c.Conventions); err != nil {
+			return fmt.Errorf("conventions: %v", err)
+		}
+	}
+
+	for _, pattern := range c.Context {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("context patterns can't be empty")
+		}
+	}
+
+	return nil
+}
+
+// validateProjectPath checks that a path in the config is relative and stays inside the project
+func validateProjectPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("path can't be empty")
+	}
+	if filepath.IsAbs(path) {
+		return fmt.Errorf("'%s' must be relative to the project root", path)
+	}
+	clean := filepath.Clean(filepath.FromSlash(path))
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("'%s' is outside the project", path)
+	}
+	return nil
+}
+
+func joinPolicies() string {
+	var res []string
+	for _, p := range AutoApplyPolicies {
+		res = append(res, string(p))
+	}
+	return strings.Join(res, " | ")
+}
+
+// ProjectConfigPath is where the current project's config.yml is, or an empty string outside a project
+func ProjectConfigPath() string {
+	if fs.PlandexDir == "" {
+		return ""
+	}
+	return filepath.Join(fs.PlandexDir, "config.yml")
+}
+
+var projectConfig *ProjectConfig
+var projectConfigErr error
+var projectConfigOnce sync.Once
+
+// LoadProjectConfig returns the current project's settings. A project without a config.yml has an empty config, which keeps the usual behavior. Unknown keys are an error, so typos don't go unnoticed.
+func LoadProjectConfig() (*ProjectConfig, error) {
+	projectConfigOnce.Do(func() {
+		projectConfig, projectConfigErr = loadProjectConfig()
+	})
+	return projectConfig, projectConfigErr
+}
+
+func loadProjectConfig() (*ProjectConfig, error) {
+	var c ProjectConfig
+
+	path := ProjectConfigPath()
+	if path == "" {
+		return &c, nil
+	}
+
+	b, err := os.ReadFile(path)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &c, nil
+		}
+		return nil, fmt.Errorf("error reading config.yml: %v", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	err = dec.Decode(&c)
+
+	// an empty file is an empty config
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error parsing config.yml: %v", err)
+	}
+
+	err = c.validate()
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid config.yml: %v", err)
+	}
+
+	return &c, nil
+}
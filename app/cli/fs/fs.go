@@ -144,14 +144,24 @@ func GetPaths(baseDir, currentDir string) (*ProjectPaths, error) {
 		return nil, err
 	}
 
+	isGitRepo := IsGitRepo(baseDir)
+
+	// outside of a git repo, .gitignore files are still respected so that build output, dependencies, etc. aren't loaded by accident
+	var gitIgnored *nestedGitIgnore
+	if !isGitRepo {
+		gitIgnored, err = getNestedGitIgnore(baseDir, currentDir)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	allPaths := map[string]bool{}
 	activePaths := map[string]bool{}
 
 	allDirs := map[string]bool{}
 	activeDirs := map[string]bool{}
 
-	isGitRepo := IsGitRepo(baseDir)
-
 	errCh := make(chan error)
 	var mu sync.Mutex
 	numRoutines := 0
@@ -309,6 +319,17 @@ func GetPaths(baseDir, currentDir string) (*ProjectPaths, error) {
 				if ignored != nil && ignored.MatchesPath(relPath) {
 					return filepath.SkipDir
 				}
+
+				if gitIgnored != nil {
+					if gitIgnored.MatchesPath(relPath) {
+						return filepath.SkipDir
+					}
+
+					err = gitIgnored.loadDir(relPath)
+					if err != nil {
+						return err
+					}
+				}
 			} else {
 				relPath, err := filepath.Rel(currentDir, path)
 				if err != nil {
@@ -321,6 +342,10 @@ func GetPaths(baseDir, currentDir string) (*ProjectPaths, error) {
 					return nil
 				}
 
+				if gitIgnored != nil && gitIgnored.MatchesPath(relPath) {
+					return nil
+				}
+
 				if !isGitRepo {
 					mu.Lock()
 					defer mu.Unlock()
@@ -376,6 +401,13 @@ func GetPaths(baseDir, currentDir string) (*ProjectPaths, error) {
 		}
 	}
 
+	// paths are sent to the server and matched against the model's output with forward slashes, whatever the OS
+	if filepath.Separator != '/' {
+		activePaths = toSlashKeys(activePaths)
+		allPaths = toSlashKeys(allPaths)
+		ignoredPaths = toSlashKeys(ignoredPaths)
+	}
+
 	return &ProjectPaths{
 		ActivePaths:    activePaths,
 		AllPaths:       allPaths,
@@ -384,6 +416,14 @@ func GetPaths(baseDir, currentDir string) (*ProjectPaths, error) {
 	}, nil
 }
 
+func toSlashKeys[V any](m map[string]V) map[string]V {
+	res := make(map[string]V, len(m))
+	for k, v := range m {
+		res[filepath.ToSlash(k)] = v
+	}
+	return res
+}
+
 func GetPlandexIgnore(dir string) (*ignore.GitIgnore, error) {
 	ignorePath := filepath.Join(dir, ".plandexignore")
 
@@ -402,6 +442,98 @@ func GetPlandexIgnore(dir string) (*ignore.GitIgnore, error) {
 	return nil, nil
 }
 
+func GetGitIgnore(dir string) (*ignore.GitIgnore, error) {
+	ignorePath := filepath.Join(dir, ".gitignore")
+
+	if _, err := os.Stat(ignorePath); err == nil {
+		ignored, err := ignore.CompileIgnoreFile(ignorePath)
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading .gitignore file: %s", err)
+		}
+
+		return ignored, nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error checking for .gitignore file: %s", err)
+	}
+
+	return nil, nil
+}
+
+// nestedGitIgnore matches paths against every .gitignore between currentDir and the path, each relative to its own directory, the way git does
+type nestedGitIgnore struct {
+	currentDir string
+	byDir      map[string]*ignore.GitIgnore
+}
+
+// getNestedGitIgnore loads the .gitignore files from currentDir down to baseDir. Files in directories below baseDir are loaded as the walk reaches them.
+func getNestedGitIgnore(baseDir, currentDir string) (*nestedGitIgnore, error) {
+	g := &nestedGitIgnore{
+		currentDir: currentDir,
+		byDir:      map[string]*ignore.GitIgnore{},
+	}
+
+	relBase, err := filepath.Rel(currentDir, baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := []string{"."}
+	if relBase != "." && relBase != ".." && !strings.HasPrefix(relBase, ".."+string(filepath.Separator)) {
+		for dir := relBase; dir != "."; dir = filepath.Dir(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	for _, dir := range dirs {
+		err = g.loadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+func (g *nestedGitIgnore) loadDir(relDir string) error {
+	if _, ok := g.byDir[relDir]; ok {
+		return nil
+	}
+
+	ignored, err := GetGitIgnore(filepath.Join(g.currentDir, relDir))
+	if err != nil {
+		return err
+	}
+
+	// nil entries are kept too so each directory is only checked once
+	g.byDir[relDir] = ignored
+
+	return nil
+}
+
+func (g *nestedGitIgnore) MatchesPath(relPath string) bool {
+	dir := relPath
+	for dir != "." && dir != string(filepath.Separator) && dir != "" {
+		dir = filepath.Dir(dir)
+
+		ignored := g.byDir[dir]
+		if ignored == nil {
+			continue
+		}
+
+		pathInDir, err := filepath.Rel(dir, relPath)
+		if err != nil {
+			continue
+		}
+
+		if ignored.MatchesPath(pathInDir) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func GetParentProjectIdsWithPaths() ([][2]string, error) {
 	var parentProjectIds [][2]string
 	currentDir := filepath.Dir(Cwd)
@@ -506,6 +638,28 @@ func GetBaseDirForContexts(contexts []*shared.Context) string {
 	return GetBaseDirForFilePaths(paths)
 }
 
+// OsPath returns the absolute path of a file path relative to the current directory, for reading and writing the file. The os package adds Windows' long path prefix to absolute paths, so files nested deeper than the 260 character limit can be loaded and applied.
+func OsPath(path string) string {
+	absPath, err := filepath.Abs(filepath.FromSlash(path))
+	if err != nil {
+		return path
+	}
+	return absPath
+}
+
+// PathInWorkspaces checks a path relative to the current directory against workspace roots, which are relative to the project root
+func PathInWorkspaces(path string, workspaces []string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	relPath, err := filepath.Rel(ProjectRoot, absPath)
+	if err != nil {
+		return false
+	}
+	return shared.PathInWorkspaces(filepath.ToSlash(relPath), workspaces)
+}
+
 func GetBaseDirForFilePaths(paths []string) string {
 	baseDir := ProjectRoot
 	dirsUp := 0
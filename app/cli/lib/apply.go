@@ -1,18 +1,42 @@
 package lib
 
 import (
+	"bytes"
 	"fmt"
 	"os"
-	"path/filepath"
 	"plandex/api"
 	"plandex/fs"
 	"plandex/term"
-	"strings"
 
 	"github.com/plandex/plandex/shared"
 )
 
-func MustApplyPlan(planId, branch string, autoConfirm bool) {
+// CommitProvenanceByDefault is whether commits of applied changes include provenance trailers when the apply command's --provenance flag isn't set
+func CommitProvenanceByDefault() bool {
+	return os.Getenv("PLANDEX_COMMIT_PROVENANCE") != ""
+}
+
+type ApplyFlags struct {
+	AutoConfirm bool
+	// add provenance trailers to the commit message
+	WithProvenance bool
+	// apply changes flagged by code scanning without confirmation
+	AllowFlagged bool
+	// regenerate the lockfiles of updated manifests
+	RegenerateLockfiles bool
+	// where to apply the changes, like a checkout on another machine. Empty means the local project.
+	Target string
+}
+
+func MustApplyPlan(planId, branch string, flags ApplyFlags) {
+	target, err := ParseApplyTarget(flags.Target)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+	if flags.Target == "" && !flags.AutoConfirm {
+		target = mustOfferDevContainer(target)
+	}
+
 	term.StartSpinner("")
 
 	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
@@ -62,33 +86,62 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 		}
 	}
 
-	anyOutdated, didUpdate := MustCheckOutdatedContext(true, nil)
+	// a remote target's files are checked against the context when they're read below
+	if target.IsLocal() {
+		anyOutdated, didUpdate := MustCheckOutdatedContext(true, nil)
 
-	if anyOutdated && !didUpdate {
-		term.StopSpinner()
-		fmt.Println("Apply plan canceled")
-		os.Exit(0)
+		if anyOutdated && !didUpdate {
+			term.StopSpinner()
+			fmt.Println("Apply plan canceled")
+			os.Exit(0)
+		}
 	}
 
 	currentPlanFiles := currentPlanState.CurrentPlanFiles
-	isRepo := fs.ProjectRootIsGitRepo()
+	// changes applied to a remote target aren't committed, since its repo isn't the local one
+	isRepo := target.IsLocal() && fs.ProjectRootIsGitRepo()
 
 	toApply := currentPlanFiles.Files
+	toRemove := currentPlanFiles.Removed
 
-	if len(toApply) == 0 {
+	if len(toApply) == 0 && len(toRemove) == 0 {
 		term.StopSpinner()
 		fmt.Println("🤷‍♂️ No changes to apply")
 		return
 	}
 
-	if !autoConfirm {
+	var pathsToUpdate []string
+	for path := range toApply {
+		pathsToUpdate = append(pathsToUpdate, path)
+	}
+	pathsToApply := currentPlanFiles.PendingPaths()
+
+	targetFiles := mustReadTargetFiles(target, pathsToApply)
+	if !target.IsLocal() {
+		mustCheckTargetConflicts(target, currentPlanState, targetFiles)
+	}
+
+	trustPolicy := MustGetTrustPolicy()
+	mustCheckNewFilesTrust(trustPolicy, pathsToUpdate, targetFiles)
+	mustCheckRemovedFilesTrust(trustPolicy, toRemove)
+
+	encodedFiles := mustEncodeApplyFiles(currentPlanState, toApply)
+
+	mustReviewScanFindings(currentPlanState, flags.AutoConfirm, flags.AllowFlagged)
+
+	if !flags.AutoConfirm {
 		term.StopSpinner()
-		numToApply := len(toApply)
+		numToApply := len(pathsToApply)
 		suffix := ""
 		if numToApply > 1 {
 			suffix = "s"
 		}
-		shouldContinue, err := term.ConfirmYesNo("Apply changes to %d file%s?", numToApply, suffix)
+		var shouldContinue bool
+		if target.IsLocal() {
+			shouldContinue, err = term.ConfirmYesNo("Apply changes to %d file%s?", numToApply, suffix)
+		} else {
+			shouldContinue, err = term.ConfirmYesNo("Apply changes to %d file%s on %s?", numToApply, suffix, target.Name())
+		}
 
 		if err != nil {
 			term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
@@ -110,6 +163,26 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 		term.OutputSimpleError(errMsg, unformattedErrMsg)
 	}
 
+	runCommands := trustPolicy.Level(shared.TrustActionRunCommands)
+
+	preHookRes, err := runApplyHook(target, shared.ApplyHookPre, planId, branch, pathsToApply, runCommands)
+	if err != nil {
+		onErr("failed to run pre-apply hook: %v", err)
+		return
+	}
+	if preHookRes != nil {
+		aborted := preHookRes.exitCode != 0
+		recordApplyHook(shared.ApplyHookPre, planId, branch, preHookRes, aborted)
+		if aborted {
+			onErr("pre-apply hook failed with exit code %d, so the pending changes weren't applied", preHookRes.exitCode)
+			return
+		}
+		term.ResumeSpinner()
+
+		// the hook can change the files, like by stashing uncommitted changes
+		targetFiles = mustReadTargetFiles(target, pathsToApply)
+	}
+
 	apiKeys := MustVerifyApiKeysSilent()
 
 	var commitSummary string
@@ -131,66 +204,49 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 	}
 
 	var updatedFiles []string
-	for path, content := range toApply {
-		// Compute destination path
-		dstPath := filepath.Join(fs.ProjectRoot, path)
-
-		content = strings.ReplaceAll(content, "\\`\\`\\`", "```")
-
-		// Check if the file exists
-		var exists bool
-		_, err := os.Stat(dstPath)
-		if err == nil {
-			exists = true
-		} else {
-			if os.IsNotExist(err) {
-				exists = false
-			} else {
-				onErr("failed to check if %s exists:", dstPath)
-				return
-			}
+	for path, content := range encodedFiles {
+		// Check if the file has changed
+		if existing, exists := targetFiles[path]; exists && bytes.Equal(existing, content) {
+			continue
 		}
 
-		if exists {
-			// read file content
-			bytes, err := os.ReadFile(dstPath)
-
-			if err != nil {
-				onErr("failed to read %s:", dstPath)
-				return
-			}
+		err := target.WriteFile(path, content)
+		if err != nil {
+			onErr("failed to write %s: %v", path, err)
+			return
+		}
 
-			// Check if the file has changed
-			if string(bytes) == content {
-				// log.Println("File is unchanged, skipping")
-				continue
-			} else {
-				updatedFiles = append(updatedFiles, path)
-			}
-		} else {
-			updatedFiles = append(updatedFiles, path)
+		updatedFiles = append(updatedFiles, path)
+	}
 
-			// Create the directory if it doesn't exist
-			err := os.MkdirAll(filepath.Dir(dstPath), 0755)
-			if err != nil {
-				onErr("failed to create directory %s:", filepath.Dir(dstPath))
-				return
-			}
+	for path := range toRemove {
+		if _, exists := targetFiles[path]; !exists {
+			continue
 		}
 
-		// Write the file
-		err = os.WriteFile(dstPath, []byte(content), 0644)
+		// untracked files can't be staged once they're removed, so they're left out of the commit. A remote target's removals are never committed, so they're all counted.
+		tracked := !target.IsLocal() || (isRepo && GitFileIsTracked(fs.ProjectRoot, path))
+
+		err := target.RemoveFile(path)
 		if err != nil {
-			onErr("failed to write %s:", dstPath)
+			onErr("failed to remove %s: %v", path, err)
 			return
 		}
+
+		if tracked {
+			updatedFiles = append(updatedFiles, path)
+		}
 	}
 
 	term.StopSpinner()
 
+	// regenerated lockfiles are committed with their manifests. Regenerating them runs the package manager locally, so it's skipped for a remote target.
+	if target.IsLocal() {
+		updatedFiles = append(updatedFiles, regenerateLockfiles(updatedFiles, flags.RegenerateLockfiles, runCommands)...)
+	}
+
 	if len(updatedFiles) == 0 {
 		fmt.Println("✅ Applied changes, but no files were updated")
-		return
 	} else {
 		if isRepo {
 			fmt.Println("✏️  Plandex can commit these updates with an automatically generated message.")
@@ -206,7 +262,22 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 
 			if confirmed {
 				// Commit the changes
-				msg := currentPlanState.PendingChangesSummaryForApply(commitSummary)
+				// the server writes a conventional commit message for the changes
+				msg := commitSummary
+				if msg == "" {
+					msg = currentPlanState.PendingChangesSummaryForApply(commitSummary)
+				}
+
+				if flags.WithProvenance {
+					latest := currentPlanState.LatestProvenanceByPath()
+					var provenance []*shared.PlanFileResultProvenance
+					for _, path := range updatedFiles {
+						if p, ok := latest[path]; ok {
+							provenance = append(provenance, p)
+						}
+					}
+					msg = shared.WithProvenanceTrailers(msg, provenance)
+				}
 
 				// log.Println("Committing changes with message:")
 				// log.Println(msg)
@@ -224,7 +295,23 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 		if len(updatedFiles) > 1 {
 			suffix = "s"
 		}
-		fmt.Printf("✅ Applied changes, %d file%s updated\n", len(updatedFiles), suffix)
+		if target.IsLocal() {
+			fmt.Printf("✅ Applied changes, %d file%s updated\n", len(updatedFiles), suffix)
+		} else {
+			fmt.Printf("✅ Applied changes to %s, %d file%s updated\n", target.Name(), len(updatedFiles), suffix)
+		}
 	}
 
+	postHookRes, err := runApplyHook(target, shared.ApplyHookPost, planId, branch, pathsToApply, runCommands)
+	if err != nil {
+		term.OutputSimpleError("Failed to run post-apply hook: %v", err)
+		return
+	}
+	if postHookRes != nil {
+		recordApplyHook(shared.ApplyHookPost, planId, branch, postHookRes, false)
+		if postHookRes.exitCode != 0 {
+			fmt.Println()
+			fmt.Fprintf(os.Stderr, "⚠️  post-apply hook failed with exit code %d\n", postHookRes.exitCode)
+		}
+	}
 }
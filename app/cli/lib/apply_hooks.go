@@ -0,0 +1,156 @@
+package lib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+// applyHookResult is the result of running an apply hook script from .plandex/hooks, or nil if the project doesn't have one
+type applyHookResult struct {
+	exitCode int
+	output   string
+}
+
+// runApplyHook runs the project's script for an apply hook, if it has one, the target runs hooks, and the trust policy's run-commands level allows it. Scripts run from the root of the target's checkout with the plan, branch, and paths being applied in the environment, and their output is shown as they run and captured for the plan's history.
+func runApplyHook(target ApplyTarget, hook shared.ApplyHookType, planId, branch string, paths []string, runCommands shared.TrustLevel) (*applyHookResult, error) {
+	hookPath := applyHookPath(hook)
+	if hookPath == "" {
+		return nil, nil
+	}
+
+	info, err := os.Stat(hookPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error checking %s hook: %v", hook, err)
+	}
+
+	if info.IsDir() {
+		return nil, nil
+	}
+
+	if info.Mode()&0111 == 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  Skipping %s hook because %s isn't executable. Run 'chmod +x %s' to enable it.\n", hook, hookPath, hookPath)
+		return nil, nil
+	}
+
+	sortedPaths := append([]string{}, paths...)
+	sort.Strings(sortedPaths)
+
+	cmd, err := target.hookCommand(hookPath, []string{
+		"PLANDEX_HOOK=" + string(hook),
+		"PLANDEX_PLAN_ID=" + planId,
+		"PLANDEX_BRANCH=" + branch,
+		"PLANDEX_APPLY_PATHS=" + strings.Join(sortedPaths, "\n"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error preparing %s hook: %v", hook, err)
+	}
+	if cmd == nil {
+		return nil, nil
+	}
+
+	shouldRun, err := confirmRunCommand(runCommands, fmt.Sprintf("%s hook", hook))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get confirmation user input: %v", err)
+	}
+	if !shouldRun {
+		return nil, nil
+	}
+
+	var output bytes.Buffer
+	out := io.MultiWriter(os.Stderr, &output)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	term.StopSpinner()
+	if where := hookLocation(target); where != "" {
+		fmt.Fprintln(os.Stderr, color.New(color.Bold, term.ColorHiCyan).Sprintf("🪝 Running %s hook in %s", hook, where))
+	} else {
+		fmt.Fprintln(os.Stderr, color.New(color.Bold, term.ColorHiCyan).Sprintf("🪝 Running %s hook", hook))
+	}
+
+	err = cmd.Run()
+
+	res := &applyHookResult{output: output.String()}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("error running %s hook: %v", hook, err)
+		}
+		res.exitCode = exitErr.ExitCode()
+	}
+
+	fmt.Fprintln(os.Stderr)
+
+	return res, nil
+}
+
+// hasApplyHooks is whether the project has a script for any apply hook
+func hasApplyHooks() bool {
+	for _, hook := range []shared.ApplyHookType{shared.ApplyHookPre, shared.ApplyHookPost} {
+		hookPath := applyHookPath(hook)
+		if hookPath == "" {
+			continue
+		}
+		info, err := os.Stat(hookPath)
+		if err == nil && !info.IsDir() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyHookPath is where the project's script for an apply hook is: the path set for it in .plandex/config.yml, or .plandex/hooks/<hook> if none is set. It's empty outside a project.
+func applyHookPath(hook shared.ApplyHookType) string {
+	if fs.PlandexDir == "" {
+		return ""
+	}
+
+	if path := MustLoadProjectConfig().HookPath(hook); path != "" {
+		return path
+	}
+
+	return filepath.Join(fs.PlandexDir, "hooks", string(hook))
+}
+
+// hookLocation describes where a target runs hooks when it isn't the local project, like 'container 3f2a9c'
+func hookLocation(target ApplyTarget) string {
+	switch t := target.(type) {
+	case *devContainerApplyTarget:
+		return t.container.where
+	case *shellApplyTarget:
+		return t.where
+	}
+	return ""
+}
+
+// recordApplyHook adds a hook's result to the plan's history. Failing to record it doesn't stop the apply, so it's only shown as a warning.
+func recordApplyHook(hook shared.ApplyHookType, planId, branch string, res *applyHookResult, abortedApply bool) {
+	apiErr := api.Client.RecordApplyHook(planId, branch, shared.RecordApplyHookRequest{
+		Hook:         hook,
+		ExitCode:     res.exitCode,
+		Output:       res.output,
+		AbortedApply: abortedApply,
+	})
+
+	if apiErr != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Couldn't add %s hook output to the plan's history: %s\n", hook, apiErr.Msg)
+	}
+}
@@ -0,0 +1,377 @@
+package lib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/term"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// ApplyTarget is where an apply writes the plan's changes: the local project, or a checkout of it on another machine. Paths are relative to the project's root.
+type ApplyTarget interface {
+	// Name describes the target in messages
+	Name() string
+	IsLocal() bool
+	// ReadFile returns a file's content, or false if the file doesn't exist
+	ReadFile(path string) ([]byte, bool, error)
+	// WriteFile writes a file, creating its directory if needed
+	WriteFile(path string, content []byte) error
+	// RemoveFile removes a file, and isn't an error if the file doesn't exist
+	RemoveFile(path string) error
+	// hookCommand returns the command that runs an apply hook script with env added to its environment, or nil if hooks don't run on the target
+	hookCommand(hookPath string, env []string) (*exec.Cmd, error)
+}
+
+// DefaultApplyTarget is the target changes are applied to when the apply command's --target flag isn't set
+func DefaultApplyTarget() string {
+	return os.Getenv("PLANDEX_APPLY_TARGET")
+}
+
+// ParseApplyTarget returns the target for a target spec. An empty spec is the local project, and 'devcontainer' is the local project with hooks run in its running dev container. A checkout in a docker container is a url like 'docker://my-container/srv/app'. A remote checkout is either an ssh url like 'ssh://user@host:2222/srv/app' or the scp form 'user@host:/srv/app'.
+func ParseApplyTarget(spec string) (ApplyTarget, error) {
+	if spec == "" {
+		return &localApplyTarget{root: fs.ProjectRoot}, nil
+	}
+
+	if spec == devContainerTargetSpec {
+		devContainer, err := findDevContainer()
+		if err != nil {
+			return nil, err
+		}
+		if devContainer == nil {
+			return nil, fmt.Errorf("no running dev container found for %s. Start it with your editor or the devcontainer CLI first.", fs.ProjectRoot)
+		}
+		return devContainer.target(), nil
+	}
+
+	if strings.HasPrefix(spec, "docker://") {
+		container, dir, _ := strings.Cut(strings.TrimPrefix(spec, "docker://"), "/")
+		if container == "" || dir == "" {
+			return nil, fmt.Errorf("docker target %s needs a container and the path of the checkout, like docker://my-container/srv/app", spec)
+		}
+		// docker would read it as an option
+		if strings.HasPrefix(container, "-") {
+			return nil, fmt.Errorf("invalid docker target %s: the container can't start with '-'", spec)
+		}
+		return newContainerApplyTarget(container, "/"+dir), nil
+	}
+
+	if strings.HasPrefix(spec, "ssh://") {
+		u, err := url.Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh target %s: %v", spec, err)
+		}
+		if u.Hostname() == "" || u.Path == "" || u.Path == "/" {
+			return nil, fmt.Errorf("ssh target %s needs a host and the path of the checkout, like ssh://user@host/srv/app", spec)
+		}
+
+		host := u.Hostname()
+		if u.User != nil {
+			host = u.User.Username() + "@" + host
+		}
+
+		return newSshApplyTarget(host, u.Port(), u.Path)
+	}
+
+	host, dir, ok := strings.Cut(spec, ":")
+	if !ok || host == "" || dir == "" || strings.Contains(host, "/") {
+		return nil, fmt.Errorf("invalid apply target %s: use ssh://user@host/path or user@host:path", spec)
+	}
+
+	return newSshApplyTarget(host, "", dir)
+}
+
+type localApplyTarget struct {
+	root string
+}
+
+func (t *localApplyTarget) Name() string {
+	return "the project"
+}
+
+func (t *localApplyTarget) IsLocal() bool {
+	return true
+}
+
+func (t *localApplyTarget) ReadFile(path string) ([]byte, bool, error) {
+	content, err := os.ReadFile(filepath.Join(t.root, path))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return content, true, nil
+}
+
+func (t *localApplyTarget) WriteFile(path string, content []byte) error {
+	dstPath := filepath.Join(t.root, path)
+
+	err := os.MkdirAll(filepath.Dir(dstPath), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", filepath.Dir(dstPath), err)
+	}
+
+	return os.WriteFile(dstPath, content, 0644)
+}
+
+func (t *localApplyTarget) RemoveFile(path string) error {
+	err := os.Remove(filepath.Join(t.root, path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (t *localApplyTarget) hookCommand(hookPath string, env []string) (*exec.Cmd, error) {
+	cmd := exec.Command(hookPath)
+	cmd.Dir = t.root
+	cmd.Env = append(os.Environ(), env...)
+	return cmd, nil
+}
+
+// shellApplyTarget applies changes to a checkout that's reached by running shell commands, like on another machine over ssh or in a container with docker exec
+type shellApplyTarget struct {
+	// name describes the target in messages, like 'user@host:/srv/app'
+	name string
+	// where describes the machine in errors, like the ssh host or the container
+	where string
+	dir   string
+	// command returns the command that runs a shell command on the target
+	command func(shellCmd string) *exec.Cmd
+	// runsHooks is whether the project's apply hooks run on the target
+	runsHooks bool
+}
+
+// missingFileExitCode is the exit code of a read when the file doesn't exist on the target. ssh itself exits with 255 on connection errors, and docker exec with 125 to 127 when the container can't run the command.
+const missingFileExitCode = 3
+
+// newSshApplyTarget returns a target that uses the system's ssh client, so the user's ssh config, keys, and agent are used. Commands run in batch mode, since a password prompt would be hidden behind the spinner. Hooks aren't run on ssh targets. A host starting with '-' is rejected, since ssh would read it as an option like -oProxyCommand.
+func newSshApplyTarget(host, port, dir string) (*shellApplyTarget, error) {
+	if strings.HasPrefix(host, "-") {
+		return nil, fmt.Errorf("invalid ssh host %s: it can't start with '-'", host)
+	}
+
+	return &shellApplyTarget{
+		name:  host + ":" + dir,
+		where: host,
+		dir:   dir,
+		command: func(shellCmd string) *exec.Cmd {
+			args := []string{"-o", "BatchMode=yes"}
+			if port != "" {
+				args = append(args, "-p", port)
+			}
+			return exec.Command("ssh", append(args, "--", host, shellCmd)...)
+		},
+	}, nil
+}
+
+// newContainerApplyTarget returns a target for a checkout in a running docker container. The project's apply hooks run in the container, so changes are checked in the container's environment.
+func newContainerApplyTarget(container, dir string) *shellApplyTarget {
+	return &shellApplyTarget{
+		name:  "container " + container + ":" + dir,
+		where: "container " + container,
+		dir:   dir,
+		command: func(shellCmd string) *exec.Cmd {
+			return exec.Command("docker", "exec", "-i", container, "sh", "-c", shellCmd)
+		},
+		runsHooks: true,
+	}
+}
+
+func (t *shellApplyTarget) Name() string {
+	return t.name
+}
+
+func (t *shellApplyTarget) IsLocal() bool {
+	return false
+}
+
+func (t *shellApplyTarget) remotePath(p string) string {
+	return path.Join(t.dir, filepath.ToSlash(p))
+}
+
+func (t *shellApplyTarget) run(shellCmd string, stdin []byte) ([]byte, error) {
+	cmd := t.command(shellCmd)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, &remoteCmdError{err: err, stderr: strings.TrimSpace(stderr.String())}
+	}
+
+	return stdout.Bytes(), nil
+}
+
+type remoteCmdError struct {
+	err    error
+	stderr string
+}
+
+func (e *remoteCmdError) Error() string {
+	if e.stderr == "" {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%v: %s", e.err, e.stderr)
+}
+
+func (e *remoteCmdError) Unwrap() error {
+	return e.err
+}
+
+func (t *shellApplyTarget) ReadFile(p string) ([]byte, bool, error) {
+	remotePath := shellQuote(t.remotePath(p))
+
+	content, err := t.run(fmt.Sprintf("if [ -f %s ]; then cat -- %s; else exit %d; fi", remotePath, remotePath, missingFileExitCode), nil)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == missingFileExitCode {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to read %s on %s: %v", p, t.where, err)
+	}
+
+	return content, true, nil
+}
+
+func (t *shellApplyTarget) WriteFile(p string, content []byte) error {
+	remotePath := t.remotePath(p)
+
+	_, err := t.run(fmt.Sprintf("mkdir -p -- %s && cat > %s", shellQuote(path.Dir(remotePath)), shellQuote(remotePath)), content)
+	if err != nil {
+		return fmt.Errorf("failed to write %s on %s: %v", p, t.where, err)
+	}
+
+	return nil
+}
+
+func (t *shellApplyTarget) RemoveFile(p string) error {
+	_, err := t.run("rm -f -- "+shellQuote(t.remotePath(p)), nil)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s on %s: %v", p, t.where, err)
+	}
+
+	return nil
+}
+
+// hookCommand copies the hook script to a temporary file on the target and runs it from the checkout, since the target doesn't necessarily have the project's .plandex directory
+func (t *shellApplyTarget) hookCommand(hookPath string, env []string) (*exec.Cmd, error) {
+	if !t.runsHooks {
+		return nil, nil
+	}
+
+	script, err := os.ReadFile(hookPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", hookPath, err)
+	}
+
+	quotedEnv := make([]string, len(env))
+	for i, v := range env {
+		quotedEnv[i] = shellQuote(v)
+	}
+
+	cmd := t.command(fmt.Sprintf(`cd -- %s && f=$(mktemp) && cat > "$f" && chmod +x "$f" && env %s "$f"; s=$?; rm -f "$f"; exit $s`, shellQuote(t.dir), strings.Join(quotedEnv, " ")))
+	cmd.Stdin = bytes.NewReader(script)
+
+	return cmd, nil
+}
+
+// mustReadTargetFiles reads the target's current content of each path. Files that don't exist are left out.
+func mustReadTargetFiles(target ApplyTarget, paths []string) map[string][]byte {
+	res := map[string][]byte{}
+
+	for _, path := range paths {
+		content, exists, err := target.ReadFile(path)
+		if err != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("%v", err)
+		}
+		if exists {
+			res[path] = content
+		}
+	}
+
+	return res
+}
+
+// mustCheckTargetConflicts checks a remote target's files against the context they were loaded from, like MustCheckOutdatedContext does for the local project. A file conflicts if it was changed or removed on the target since it was loaded, or if a new file already exists there, unless the target already has the plan's content. Conflicts stop the apply.
+func mustCheckTargetConflicts(target ApplyTarget, currentPlanState *shared.CurrentPlanState, targetFiles map[string][]byte) {
+	currentPlanFiles := currentPlanState.CurrentPlanFiles
+
+	var conflicts []string
+
+	for _, path := range currentPlanFiles.PendingPaths() {
+		existing, exists := targetFiles[path]
+		updated, isUpdate := currentPlanFiles.Files[path]
+		context := currentPlanState.ContextsByPath[path]
+
+		var body string
+		if exists {
+			var encoding string
+			if context != nil {
+				encoding = context.Encoding
+			}
+
+			var err error
+			body, err = decodeFileContentAs(existing, encoding)
+			if err != nil {
+				conflicts = append(conflicts, fmt.Sprintf("%s can't be read: %v", path, err))
+				continue
+			}
+		}
+
+		if isUpdate && exists && body == updated {
+			continue
+		}
+
+		if context == nil {
+			if exists {
+				conflicts = append(conflicts, path+" already exists")
+			}
+			continue
+		}
+
+		if !exists {
+			if isUpdate {
+				conflicts = append(conflicts, path+" was removed")
+			}
+		} else if shared.ContextSha(body) != context.Sha {
+			conflicts = append(conflicts, path+" was changed")
+		}
+	}
+
+	if len(conflicts) == 0 {
+		return
+	}
+
+	sort.Strings(conflicts)
+
+	term.StopSpinner()
+	fmt.Printf("🚨 Files on %s have changed since they were loaded into context:\n", target.Name())
+	for _, conflict := range conflicts {
+		fmt.Println("  • " + conflict)
+	}
+	fmt.Println()
+	fmt.Println("Update the context from the target's checkout, or reject the conflicting files, then apply again.")
+	os.Exit(1)
+}
+
+// shellQuote quotes a string for a POSIX shell, since ssh runs its command through the remote user's shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
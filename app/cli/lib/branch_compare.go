@@ -0,0 +1,139 @@
+package lib
+
+import (
+	"fmt"
+	"plandex/api"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// BranchComparison summarizes a branch's pending changes so results from the same prompt on different branches can be compared side by side
+type BranchComparison struct {
+	Branch       *shared.Branch
+	ModelPack    string
+	PlannerModel string
+	BuilderModel string
+
+	NumFiles     int
+	LinesAdded   int
+	LinesRemoved int
+	NumFailed    int
+
+	NumVerified     int
+	NumVerifyFailed int
+
+	// Tokens counts conversation tokens added since the branch was created, which approximates what the branch's results cost
+	Tokens int
+
+	Diffs string
+}
+
+// CompareBranches loads a comparison for each branch concurrently, in the same order as the branches
+func CompareBranches(planId string, branches []*shared.Branch) ([]*BranchComparison, error) {
+	comparisons := make([]*BranchComparison, len(branches))
+	errCh := make(chan error, len(branches))
+
+	for i, branch := range branches {
+		go func(i int, branch *shared.Branch) {
+			comparison, err := getBranchComparison(planId, branch)
+			if err != nil {
+				errCh <- fmt.Errorf("error comparing branch %s: %v", branch.Name, err)
+				return
+			}
+			comparisons[i] = comparison
+			errCh <- nil
+		}(i, branch)
+	}
+
+	for range branches {
+		err := <-errCh
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return comparisons, nil
+}
+
+func getBranchComparison(planId string, branch *shared.Branch) (*BranchComparison, error) {
+	comparison := &BranchComparison{Branch: branch}
+
+	settings, apiErr := api.Client.GetSettings(planId, branch.Name)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting settings: %s", apiErr.Msg)
+	}
+
+	modelPack := settings.ModelPack
+	if modelPack == nil {
+		modelPack = shared.DefaultModelPack
+	}
+	comparison.ModelPack = modelPack.Name
+	comparison.PlannerModel = modelPack.Planner.BaseModelConfig.ModelName
+	comparison.BuilderModel = modelPack.Builder.BaseModelConfig.ModelName
+
+	planState, apiErr := api.Client.GetCurrentPlanState(planId, branch.Name)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting plan state: %s", apiErr.Msg)
+	}
+
+	if planState.PlanResult != nil {
+		for _, results := range planState.PlanResult.FileResultsByPath {
+			anyPending := false
+			for _, result := range results {
+				if !result.IsPending() {
+					continue
+				}
+				anyPending = true
+
+				if result.AnyFailed {
+					comparison.NumFailed++
+				}
+
+				if result.CanVerify && result.RanVerifyAt != nil {
+					comparison.NumVerified++
+					if !result.VerifyPassed {
+						comparison.NumVerifyFailed++
+					}
+				}
+			}
+			if anyPending {
+				comparison.NumFiles++
+			}
+		}
+	}
+
+	diffs, apiErr := api.Client.GetPlanDiffs(planId, branch.Name, false)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting diffs: %s", apiErr.Msg)
+	}
+	comparison.Diffs = diffs
+	comparison.LinesAdded, comparison.LinesRemoved = countDiffLines(diffs)
+
+	convo, apiErr := api.Client.ListConvo(planId, branch.Name)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting conversation: %s", apiErr.Msg)
+	}
+
+	for _, msg := range convo {
+		if msg.CreatedAt.After(branch.CreatedAt) {
+			comparison.Tokens += msg.Tokens
+		}
+	}
+
+	return comparison, nil
+}
+
+func countDiffLines(diffs string) (added, removed int) {
+	for _, line := range strings.Split(diffs, "\n") {
+		if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+			continue
+		}
+		if strings.HasPrefix(line, "+") {
+			added++
+		} else if strings.HasPrefix(line, "-") {
+			removed++
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,209 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/types"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// A project has one chat, kept locally since chats aren't stored on the server. It's cleared when it's turned into a plan.
+
+func chatPath() (string, error) {
+	if CurrentProjectId == "" {
+		return "", fmt.Errorf("no current project")
+	}
+
+	return filepath.Join(fs.HomePlandexDir, CurrentProjectId, "chat.json"), nil
+}
+
+func LoadChat() (*types.Chat, error) {
+	path, err := chatPath()
+
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := os.ReadFile(path)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &types.Chat{}, nil
+		}
+		return nil, fmt.Errorf("error reading chat: %v", err)
+	}
+
+	var chat types.Chat
+	err = json.Unmarshal(bytes, &chat)
+
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling chat: %v", err)
+	}
+
+	return &chat, nil
+}
+
+func WriteChat(chat *types.Chat) error {
+	path, err := chatPath()
+
+	if err != nil {
+		return err
+	}
+
+	if chat == nil || (len(chat.Messages) == 0 && len(chat.ContextPaths) == 0) {
+		err = os.Remove(path)
+
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing chat: %v", err)
+		}
+
+		return nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+
+	if err != nil {
+		return fmt.Errorf("error creating project dir: %v", err)
+	}
+
+	bytes, err := json.Marshal(chat)
+
+	if err != nil {
+		return fmt.Errorf("error marshalling chat: %v", err)
+	}
+
+	err = os.WriteFile(path, bytes, 0600)
+
+	if err != nil {
+		return fmt.Errorf("error writing chat: %v", err)
+	}
+
+	return nil
+}
+
+func ClearChat() error {
+	return WriteChat(nil)
+}
+
+// AddChatContextPaths adds files to the chat's context, relative to the project root. Paths that are already in the context are skipped.
+func AddChatContextPaths(chat *types.Chat, paths []string) error {
+	existing := map[string]bool{}
+	for _, path := range chat.ContextPaths {
+		existing[path] = true
+	}
+
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for %s: %v", path, err)
+		}
+
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", path, err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory—only files can be added to a chat", path)
+		}
+
+		relPath, err := filepath.Rel(fs.ProjectRoot, absPath)
+		if err != nil {
+			return fmt.Errorf("error getting relative path for %s: %v", path, err)
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if existing[relPath] {
+			continue
+		}
+		existing[relPath] = true
+		chat.ContextPaths = append(chat.ContextPaths, relPath)
+	}
+
+	return nil
+}
+
+// ChatContextFiles reads the current bodies of the chat's context files. Files that no longer exist are skipped.
+func ChatContextFiles(chat *types.Chat) ([]*shared.ChatContextFile, error) {
+	var files []*shared.ChatContextFile
+
+	for _, path := range chat.ContextPaths {
+		bytes, err := os.ReadFile(filepath.Join(fs.ProjectRoot, filepath.FromSlash(path)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading %s: %v", path, err)
+		}
+
+		files = append(files, &shared.ChatContextFile{
+			Path: path,
+			Body: string(bytes),
+		})
+	}
+
+	return files, nil
+}
+
+var chatPathCandidatePattern = regexp.MustCompile(`[\w./-]+`)
+
+// ChatReferencedPaths returns the files a chat refers to: its context files, and any files in the project that are mentioned by path in the given messages. Paths are relative to the project root.
+func ChatReferencedPaths(chat *types.Chat, messages []*shared.ChatMessage) ([]string, error) {
+	res := map[string]bool{}
+	for _, path := range chat.ContextPaths {
+		if _, err := os.Stat(filepath.Join(fs.ProjectRoot, filepath.FromSlash(path))); err == nil {
+			res[path] = true
+		}
+	}
+
+	paths, err := fs.GetProjectPaths(fs.ProjectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error getting project paths: %v", err)
+	}
+
+	for _, msg := range messages {
+		for _, candidate := range chatPathCandidatePattern.FindAllString(msg.Content, -1) {
+			candidate = strings.TrimPrefix(strings.TrimRight(candidate, ".:"), "./")
+			if candidate == "" || res[candidate] || !paths.ActivePaths[candidate] {
+				continue
+			}
+
+			info, err := os.Stat(filepath.Join(fs.ProjectRoot, filepath.FromSlash(candidate)))
+			if err != nil || info.IsDir() {
+				continue
+			}
+
+			res[candidate] = true
+		}
+	}
+
+	var sorted []string
+	for path := range res {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	return sorted, nil
+}
+
+// ChatTranscript formats chat messages as a note for a plan's context
+func ChatTranscript(messages []*shared.ChatMessage) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		switch msg.Role {
+		case shared.ChatMessageRoleUser:
+			b.WriteString("**User:**\n")
+		case shared.ChatMessageRoleAssistant:
+			b.WriteString("**Plandex:**\n")
+		}
+		b.WriteString(strings.TrimSpace(msg.Content))
+		b.WriteString("\n\n")
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,86 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"strings"
+	"sync"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// file lines are cached since the stream TUI renders citations again with every chunk of a reply
+var citationFileLines = map[string][]string{}
+var citationFileErrs = map[string]error{}
+var citationMu sync.Mutex
+
+// CitedLines returns the lines a citation refers to in the project's current files. It returns an error if the file doesn't exist or the lines are out of range, which means the citation can't be trusted.
+func CitedLines(c *shared.Citation) ([]string, error) {
+	lines, err := getCitationFileLines(c.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.StartLine < 1 || c.EndLine < c.StartLine {
+		return nil, fmt.Errorf("invalid line range")
+	}
+
+	if c.EndLine > len(lines) {
+		return nil, fmt.Errorf("file has %d lines", len(lines))
+	}
+
+	return lines[c.StartLine-1 : c.EndLine], nil
+}
+
+// RenderCitations replaces the citations in a reply with a compact reference to each one, flagging any that don't match the project's files. 'plandex citations' expands them into snippets.
+func RenderCitations(reply string) string {
+	return shared.ReplaceCitations(reply, func(c *shared.Citation) string {
+		_, err := CitedLines(c)
+		if err != nil {
+			return fmt.Sprintf("`⚠️ %s (not found: %v)`", c.Location(), err)
+		}
+		return fmt.Sprintf("`📎 %s`", c.Location())
+	})
+}
+
+func getCitationFileLines(path string) ([]string, error) {
+	citationMu.Lock()
+	defer citationMu.Unlock()
+
+	if lines, ok := citationFileLines[path]; ok {
+		return lines, nil
+	}
+	if err, ok := citationFileErrs[path]; ok {
+		return nil, err
+	}
+
+	lines, err := readCitationFile(path)
+	if err != nil {
+		citationFileErrs[path] = err
+		return nil, err
+	}
+
+	citationFileLines[path] = lines
+	return lines, nil
+}
+
+func readCitationFile(path string) ([]string, error) {
+	absPath := filepath.Join(fs.ProjectRoot, filepath.FromSlash(path))
+
+	rel, err := filepath.Rel(fs.ProjectRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("outside the project")
+	}
+
+	bytes, err := os.ReadFile(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no such file")
+		}
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimSuffix(string(bytes), "\n"), "\n"), nil
+}
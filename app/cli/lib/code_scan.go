@@ -0,0 +1,81 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"plandex/term"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+)
+
+// PrintScanFindings lists the code scan findings for each file, sorted by path
+func PrintScanFindings(findingsByPath map[string][]*shared.CodeScanFinding) {
+	var paths []string
+	for path := range findingsByPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"File", "Lines", "Finding", "Match"})
+
+	for _, path := range paths {
+		for _, finding := range findingsByPath[path] {
+			table.Append([]string{
+				path,
+				finding.Lines(),
+				shared.CodeScanRuleDescriptions[finding.Rule],
+				finding.Match,
+			})
+		}
+	}
+
+	table.Render()
+	fmt.Println()
+}
+
+// mustReviewScanFindings shows pending changes flagged by the org's code scan before they're applied. Flagged changes are confirmed even when the apply itself is auto-confirmed, unless allowFlagged is set.
+func mustReviewScanFindings(state *shared.CurrentPlanState, autoConfirm, allowFlagged bool) {
+	findingsByPath := state.PendingScanFindingsByPath()
+	if len(findingsByPath) == 0 {
+		return
+	}
+
+	term.StopSpinner()
+
+	suffix := ""
+	if len(findingsByPath) > 1 {
+		suffix = "s"
+	}
+	fmt.Printf("🔎 Code scanning flagged %d file%s for review\n", len(findingsByPath), suffix)
+	fmt.Println()
+	PrintScanFindings(findingsByPath)
+
+	if allowFlagged {
+		term.ResumeSpinner()
+		return
+	}
+
+	if autoConfirm {
+		fmt.Printf("Review the flagged changes, then apply with %s or reject them with %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint("plandex apply --allow-flagged"), color.New(color.Bold, term.ColorHiCyan).Sprint("plandex reject"))
+		fmt.Println()
+		term.PrintCmds("", "diff", "reject")
+		os.Exit(1)
+	}
+
+	confirmed, err := term.ConfirmYesNo("Apply flagged changes anyway?")
+
+	if err != nil {
+		term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
+	}
+
+	if !confirmed {
+		fmt.Println("Apply plan canceled")
+		os.Exit(0)
+	}
+	term.ResumeSpinner()
+}
@@ -0,0 +1,136 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// Context bodies are cached locally by sha after they're sent to the server so later updates can be sent as deltas against the last synced body.
+
+func getContextBodyCachePath(sha string) string {
+	return filepath.Join(fs.CacheDir, "context-bodies", sha)
+}
+
+func cacheContextBody(body string) {
+	if fs.CacheDir == "" {
+		return
+	}
+
+	path := getContextBodyCachePath(shared.ContextSha(body))
+
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return
+	}
+
+	// failing to cache just means the next update sends the full body
+	os.WriteFile(path, []byte(body), 0644)
+}
+
+func getCachedContextBody(sha string) (string, bool) {
+	if fs.CacheDir == "" || sha == "" {
+		return "", false
+	}
+
+	bytes, err := os.ReadFile(getContextBodyCachePath(sha))
+	if err != nil {
+		return "", false
+	}
+
+	// guard against a corrupted cache entry
+	body := string(bytes)
+	if shared.ContextSha(body) != sha {
+		return "", false
+	}
+
+	return body, true
+}
+
+func removeCachedContextBody(sha string) {
+	if fs.CacheDir == "" || sha == "" {
+		return
+	}
+	os.Remove(getContextBodyCachePath(sha))
+}
+
+// updateContextWithDeltas sends updates as deltas where the previous body is cached locally and the delta is meaningfully smaller than the full body, falling back to full bodies if the server's copy doesn't match.
+func updateContextWithDeltas(req shared.UpdateContextRequest, contextsById map[string]*shared.Context) (*shared.UpdateContextResponse, *shared.ApiError) {
+	deltaReq := shared.UpdateContextRequest{}
+	numDeltas := 0
+
+	for id, params := range req {
+		context := contextsById[id]
+
+		if context != nil && context.ContextType != shared.ContextImageType {
+			if base, ok := getCachedContextBody(context.Sha); ok {
+				delta := shared.ComputeContextDelta(base, params.Body)
+				if delta.DeltaSize() < len(params.Body)/2 {
+					deltaReq[id] = &shared.UpdateContextParams{Delta: delta}
+					numDeltas++
+					continue
+				}
+			}
+		}
+
+		deltaReq[id] = params
+	}
+
+	var res *shared.UpdateContextResponse
+	var apiErr *shared.ApiError
+
+	stopProgress := showUploadProgress("🔄 Updating context...")
+	defer stopProgress()
+
+	if numDeltas > 0 {
+		res, apiErr = api.Client.UpdateContext(CurrentPlanId, CurrentBranch, deltaReq)
+		if apiErr != nil && apiErr.Type == shared.ApiErrorTypeContextDeltaMismatch {
+			res, apiErr = api.Client.UpdateContext(CurrentPlanId, CurrentBranch, req)
+		}
+	} else {
+		res, apiErr = api.Client.UpdateContext(CurrentPlanId, CurrentBranch, req)
+	}
+
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if !res.MaxTokensExceeded {
+		for id, params := range req {
+			context := contextsById[id]
+			if context == nil || context.ContextType == shared.ContextImageType {
+				continue
+			}
+			removeCachedContextBody(context.Sha)
+			cacheContextBody(params.Body)
+		}
+	}
+
+	return res, nil
+}
+
+// only show upload progress for payloads large enough to take a noticeable amount of time
+const uploadProgressMinBytes = 1024 * 1024
+
+// showUploadProgress updates the active spinner with upload progress for large payloads. Call the returned function once the upload is done.
+func showUploadProgress(msg string) func() {
+	api.SetUploadProgressFn(func(sent, total int64) {
+		if total < uploadProgressMinBytes {
+			return
+		}
+		if sent >= total {
+			term.UpdateSpinnerMsg(msg)
+			return
+		}
+		term.UpdateSpinnerMsg(fmt.Sprintf("%s %d%% of %.1f MB", msg, sent*100/total, float64(total)/(1024*1024)))
+	})
+
+	return func() {
+		api.SetUploadProgressFn(nil)
+	}
+}
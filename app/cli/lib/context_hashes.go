@@ -0,0 +1,116 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"sync"
+	"time"
+)
+
+// Local per-plan cache of file hashes keyed by path, so checking for outdated context only needs to read and hash files whose size or mod time changed since the last check.
+
+type fileHashEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Sha     string    `json:"sha"`
+}
+
+type fileHashCache struct {
+	path    string
+	entries map[string]*fileHashEntry
+	seen    map[string]bool
+	changed bool
+	mu      sync.Mutex
+}
+
+func loadFileHashCache() *fileHashCache {
+	cache := &fileHashCache{
+		entries: map[string]*fileHashEntry{},
+		seen:    map[string]bool{},
+	}
+
+	if CurrentPlanId == "" || fs.CacheDir == "" {
+		return cache
+	}
+
+	cache.path = filepath.Join(fs.CacheDir, "context-hashes", CurrentPlanId+".json")
+
+	bytes, err := os.ReadFile(cache.path)
+	if err != nil {
+		return cache
+	}
+
+	err = json.Unmarshal(bytes, &cache.entries)
+	if err != nil || cache.entries == nil {
+		// a corrupt cache just means everything gets re-hashed
+		cache.entries = map[string]*fileHashEntry{}
+	}
+
+	return cache
+}
+
+// getSha returns the cached sha for path if the file is unchanged since it was cached
+func (c *fileHashCache) getSha(path string, info os.FileInfo) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seen[path] = true
+
+	entry := c.entries[path]
+	if entry == nil || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		return "", false
+	}
+	return entry.Sha, true
+}
+
+func (c *fileHashCache) setContent(path string, info os.FileInfo, content []byte) string {
+	hash := sha256.Sum256(content)
+	sha := hex.EncodeToString(hash[:])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seen[path] = true
+	c.entries[path] = &fileHashEntry{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Sha:     sha,
+	}
+	c.changed = true
+
+	return sha
+}
+
+// save writes the cache, dropping entries for paths that weren't checked (i.e. no longer in context)
+func (c *fileHashCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.entries {
+		if !c.seen[path] {
+			delete(c.entries, path)
+			c.changed = true
+		}
+	}
+
+	if c.path == "" || !c.changed {
+		return nil
+	}
+
+	bytes, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(c.path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	c.changed = false
+	return os.WriteFile(c.path, bytes, 0644)
+}
@@ -7,6 +7,7 @@ import (
 
 	"io"
 	"os"
+	"path/filepath"
 	"plandex/api"
 	"plandex/fs"
 	"plandex/term"
@@ -34,10 +35,12 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 		onErr(fmt.Errorf("failed to stat stdin: %v", err))
 	}
 
+	isPiped := fileInfo.Mode()&os.ModeNamedPipe != 0
+
 	var apiKeys map[string]string
 	var openAIBase string
 
-	if params.Note != "" || fileInfo.Mode()&os.ModeNamedPipe != 0 {
+	if params.Note != "" || isPiped {
 		apiKeys = MustVerifyApiKeysSilent()
 		openAIBase = os.Getenv("OPENAI_API_BASE")
 		if openAIBase == "" {
@@ -46,8 +49,15 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 	}
 
 	if params.Note != "" {
+		var noteName string
+		if !isPiped {
+			// with piped data, the name labels the piped data instead
+			noteName = params.Name
+		}
+
 		loadContextReq = append(loadContextReq, &shared.LoadContextParams{
 			ContextType: shared.ContextNoteType,
+			Name:        noteName,
 			Body:        params.Note,
 			ApiKeys:     apiKeys,
 			OpenAIBase:  openAIBase,
@@ -55,18 +65,30 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 		})
 	}
 
-	if fileInfo.Mode()&os.ModeNamedPipe != 0 {
+	if isPiped {
 		reader := bufio.NewReader(os.Stdin)
-		pipedData, err := io.ReadAll(reader)
+		pipedBytes, err := io.ReadAll(reader)
 		if err != nil {
 			onErr(fmt.Errorf("failed to read piped data: %v", err))
 		}
 
-		if len(pipedData) > 0 {
+		pipedData, numTrimmed, err := cleanPipedData(string(pipedBytes))
+		if err != nil {
+			onErr(fmt.Errorf("failed to trim piped data: %v", err))
+		}
+
+		if numTrimmed > 0 {
+			term.StopSpinner()
+			fmt.Fprintf(os.Stderr, "✂️  Piped data is over %d 🪙, so the first %d lines were trimmed\n", shared.MaxPipedDataTokens, numTrimmed)
+			term.StartSpinner("📥 Loading context...")
+		}
+
+		if strings.TrimSpace(pipedData) != "" {
 
 			loadContextReq = append(loadContextReq, &shared.LoadContextParams{
 				ContextType: shared.ContextPipedDataType,
-				Body:        string(pipedData),
+				Name:        params.Name,
+				Body:        pipedData,
 				ApiKeys:     apiKeys,
 				OpenAIBase:  openAIBase,
 				OpenAIOrgId: os.Getenv("OPENAI_ORG_ID"),
@@ -83,7 +105,8 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 			if url.IsValidURL(resource) {
 				inputUrls = append(inputUrls, resource)
 			} else {
-				if strings.HasPrefix(resource, "."+string(os.PathSeparator)) {
+				resource = filepath.ToSlash(resource)
+				if strings.HasPrefix(resource, "./") {
 					resource = resource[2:]
 				}
 
@@ -96,6 +119,9 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 
 	errCh := make(chan error)
 	ignoredPaths := make(map[string]string)
+	tooLargePaths := make(map[string]int64)
+	binaryPaths := make(map[string]bool)
+	outsideWorkspacePaths := make(map[string]bool)
 
 	numRoutines := 0
 
@@ -117,6 +143,15 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 	}
 
 	if len(inputFilePaths) > 0 {
+		var workspaces []string
+		if !params.ForceSkipIgnore {
+			settings, apiErr := api.Client.GetSettings(CurrentPlanId, CurrentBranch)
+			if apiErr != nil {
+				onErr(fmt.Errorf("failed to get plan settings: %v", apiErr.Msg))
+			}
+			workspaces = settings.Workspaces
+		}
+
 		baseDir := fs.GetBaseDirForFilePaths(inputFilePaths)
 
 		paths, err := fs.GetProjectPaths(baseDir)
@@ -185,6 +220,16 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 						flattenedPaths = filteredPaths
 					}
 
+					if len(workspaces) > 0 {
+						var filteredPaths []string
+						for _, path := range flattenedPaths {
+							if fs.PathInWorkspaces(path, workspaces) {
+								filteredPaths = append(filteredPaths, path)
+							}
+						}
+						flattenedPaths = filteredPaths
+					}
+
 					body := strings.Join(flattenedPaths, "\n")
 
 					name := inputFilePath
@@ -229,8 +274,24 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 				flattenedPaths = filteredPaths
 			}
 
+			if len(workspaces) > 0 {
+				var filteredPaths []string
+				for _, path := range flattenedPaths {
+					if fs.PathInWorkspaces(path, workspaces) {
+						filteredPaths = append(filteredPaths, path)
+					} else {
+						outsideWorkspacePaths[path] = true
+					}
+				}
+				flattenedPaths = filteredPaths
+			}
+
 			inputFilePaths = flattenedPaths
 
+			if len(flattenedPaths) > shared.MaxContextCount {
+				onErr(fmt.Errorf("%d files matched, which exceeds the limit of %d per load. Use .plandexignore to exclude paths or load a more specific directory", len(flattenedPaths), shared.MaxContextCount))
+			}
+
 			for _, path := range flattenedPaths {
 				var contextType shared.ContextType
 				isImage := shared.IsImageFile(path)
@@ -249,13 +310,41 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 
 				numRoutines++
 				go func(path string) {
+					info, err := os.Stat(fs.OsPath(path))
+					if err != nil {
+						errCh <- fmt.Errorf("failed to stat the file %s: %v", path, err)
+						return
+					}
 
-					fileContent, err := os.ReadFile(path)
+					if info.Size() > shared.MaxContextBodySize {
+						contextMu.Lock()
+						defer contextMu.Unlock()
+						tooLargePaths[path] = info.Size()
+						errCh <- nil
+						return
+					}
+
+					fileContent, err := os.ReadFile(fs.OsPath(path))
 					if err != nil {
 						errCh <- fmt.Errorf("failed to read the file %s: %v", path, err)
 						return
 					}
 
+					// files in legacy encodings are loaded as UTF-8, and changes are converted back when they're applied
+					var body, encoding string
+					if !isImage {
+						var isText bool
+						body, encoding, isText = decodeFileContent(fileContent)
+
+						if !isText {
+							contextMu.Lock()
+							defer contextMu.Unlock()
+							binaryPaths[path] = true
+							errCh <- nil
+							return
+						}
+					}
+
 					contextMu.Lock()
 					defer contextMu.Unlock()
 
@@ -272,8 +361,9 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 						loadContextReq = append(loadContextReq, &shared.LoadContextParams{
 							ContextType: shared.ContextFileType,
 							Name:        path,
-							Body:        string(fileContent),
+							Body:        body,
 							FilePath:    path,
+							Encoding:    encoding,
 						})
 					}
 
@@ -353,6 +443,14 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 			printIgnoredMsg()
 			didOutputReason = true
 		}
+		if len(outsideWorkspacePaths) > 0 {
+			printOutsideWorkspacesMsg(outsideWorkspacePaths)
+			didOutputReason = true
+		}
+		if len(tooLargePaths) > 0 || len(binaryPaths) > 0 {
+			printSkippedFilesMsg(tooLargePaths, binaryPaths)
+			didOutputReason = true
+		}
 
 		if !didOutputReason {
 			fmt.Println()
@@ -383,17 +481,28 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 		os.Exit(0)
 	}
 
+	stopProgress := showUploadProgress("📥 Loading context...")
 	res, apiErr := api.Client.LoadContext(CurrentPlanId, CurrentBranch, loadContextReq)
+	stopProgress()
 
 	if apiErr != nil {
 		onErr(fmt.Errorf("failed to load context: %v", apiErr.Msg))
 	}
 
+	if !res.MaxTokensExceeded {
+		// cache loaded bodies so later updates can be sent as deltas
+		for _, context := range loadContextReq {
+			switch context.ContextType {
+			case shared.ContextFileType, shared.ContextDirectoryTreeType, shared.ContextURLType:
+				cacheContextBody(context.Body)
+			}
+		}
+	}
+
 	term.StopSpinner()
 
 	if res.MaxTokensExceeded {
-		overage := res.TotalTokens - res.MaxTokens
-		term.OutputErrorAndExit("Update would add %d 🪙 and exceed token limit (%d) by %d 🪙\n", res.TokensAdded, res.MaxTokens, overage)
+		term.OutputErrorAndExit("%s\n", tokenLimitExceededMsg(res))
 	}
 
 	if hasConflicts {
@@ -416,6 +525,14 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 	if len(ignoredPaths) > 0 {
 		printIgnoredMsg()
 	}
+
+	if len(outsideWorkspacePaths) > 0 {
+		printOutsideWorkspacesMsg(outsideWorkspacePaths)
+	}
+
+	if len(tooLargePaths) > 0 || len(binaryPaths) > 0 {
+		printSkippedFilesMsg(tooLargePaths, binaryPaths)
+	}
 }
 
 func printAlreadyLoadedMsg(alreadyLoadedByComposite map[string]*shared.Context) {
@@ -436,3 +553,41 @@ func printIgnoredMsg() {
 	fmt.Println()
 	fmt.Println("ℹ️  " + color.New(color.FgWhite).Sprint("Due to .gitignore or .plandexignore, some paths weren't loaded.\nUse --force / -f to load ignored paths."))
 }
+
+func printSkippedFilesMsg(tooLargePaths map[string]int64, binaryPaths map[string]bool) {
+	if len(tooLargePaths) > 0 {
+		fmt.Println()
+		fmt.Printf("🙅‍♂️ Skipped because they exceed the size limit (%d MB):\n", shared.MaxContextBodySize/(1024*1024))
+		for path, size := range tooLargePaths {
+			fmt.Printf("  • 📄 %s (%.1f MB)\n", path, float64(size)/(1024*1024))
+		}
+	}
+
+	if len(binaryPaths) > 0 {
+		fmt.Println()
+		fmt.Println("🙅‍♂️ Skipped because they appear to be binary files:")
+		for path := range binaryPaths {
+			fmt.Printf("  • 📄 %s\n", path)
+		}
+	}
+}
+
+func printOutsideWorkspacesMsg(outsideWorkspacePaths map[string]bool) {
+	fmt.Println()
+	fmt.Println("🙅‍♂️ Skipped because they're outside the plan's workspaces:")
+	for path := range outsideWorkspacePaths {
+		fmt.Printf("  • 📄 %s\n", path)
+	}
+	fmt.Println()
+	fmt.Println("ℹ️  " + color.New(color.FgWhite).Sprint("Use --force / -f to load them anyway, or 'plandex workspaces add' to add a workspace."))
+}
+
+// tokenLimitExceededMsg explains a load or update that would put the plan over the planner's token limit
+func tokenLimitExceededMsg(res *shared.LoadContextResponse) string {
+	overage := res.TotalTokens - res.MaxTokens
+	msg := fmt.Sprintf("Update would add %s 🪙 and exceed token limit (%d) by %s 🪙", shared.FormatTokens(res.TokensAdded, res.TokensEstimated), res.MaxTokens, shared.FormatTokens(overage, res.TokensEstimated))
+	if res.TokensEstimated {
+		msg += "\n~ Estimated for the planner model's tokenizer, since its vocabulary isn't available"
+	}
+	return msg
+}
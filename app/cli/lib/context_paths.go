@@ -50,11 +50,11 @@ func ParseInputPaths(fileOrDirPaths []string, params *types.LoadContextParams) (
 
 					if params.NamesOnly {
 						// add directory name to results
-						resPaths = append(resPaths, path)
+						resPaths = append(resPaths, filepath.ToSlash(path))
 					}
 				} else {
 					// add file path to results
-					resPaths = append(resPaths, path)
+					resPaths = append(resPaths, filepath.ToSlash(path))
 				}
 
 				return nil
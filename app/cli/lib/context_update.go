@@ -207,6 +207,8 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 	var paths *fs.ProjectPaths
 	var hasDirectoryTreeWithIgnoredPaths bool
 
+	hashCache := loadFileHashCache()
+
 	for _, context := range contexts {
 		if context.ContextType == shared.ContextDirectoryTreeType && !context.ForceSkipIgnore {
 			hasDirectoryTreeWithIgnoredPaths = true
@@ -231,30 +233,53 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 			go func(context *shared.Context) {
 				defer wg.Done()
 
-				mu.Lock()
-				defer mu.Unlock()
-
-				if _, err := os.Stat(context.FilePath); os.IsNotExist(err) {
+				info, err := os.Stat(fs.OsPath(context.FilePath))
+				if os.IsNotExist(err) {
+					mu.Lock()
+					defer mu.Unlock()
 					deleteIds[context.Id] = true
 					numFilesRemoved++
 					tokenDiffsById[context.Id] = -context.NumTokens
 					return
 				}
 
-				fileContent, err := os.ReadFile(context.FilePath)
+				if err != nil {
+					mu.Lock()
+					defer mu.Unlock()
+					errs = append(errs, fmt.Errorf("failed to stat the file %s: %v", context.FilePath, err))
+					return
+				}
+
+				// skip reading files that haven't changed since they were last hashed
+				if sha, ok := hashCache.getSha(context.FilePath, info); ok && sha == context.Sha {
+					return
+				}
+
+				fileContent, err := os.ReadFile(fs.OsPath(context.FilePath))
+
+				if err != nil {
+					mu.Lock()
+					defer mu.Unlock()
+					errs = append(errs, fmt.Errorf("failed to read the file %s: %v", context.FilePath, err))
+					return
+				}
 
+				body, err := decodeFileContentAs(fileContent, context.Encoding)
 				if err != nil {
+					mu.Lock()
+					defer mu.Unlock()
 					errs = append(errs, fmt.Errorf("failed to read the file %s: %v", context.FilePath, err))
 					return
 				}
 
-				hash := sha256.Sum256(fileContent)
-				sha := hex.EncodeToString(hash[:])
+				sha := hashCache.setContent(context.FilePath, info, []byte(body))
 
 				if sha != context.Sha {
-					body := string(fileContent)
-
 					numTokens, err := shared.GetNumTokens(body)
+
+					mu.Lock()
+					defer mu.Unlock()
+
 					if err != nil {
 						errs = append(errs, fmt.Errorf("failed to get the number of tokens in the file %s: %v", context.FilePath, err))
 						return
@@ -276,7 +301,7 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 				defer wg.Done()
 
 				// check if the directory tree exists
-				if _, err := os.Stat(context.FilePath); os.IsNotExist(err) {
+				if _, err := os.Stat(fs.OsPath(context.FilePath)); os.IsNotExist(err) {
 					mu.Lock()
 					defer mu.Unlock()
 					deleteIds[context.Id] = true
@@ -377,6 +402,11 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 		return nil, fmt.Errorf("failed to check context outdated: %v", errs)
 	}
 
+	err := hashCache.save()
+	if err != nil {
+		log.Printf("failed to save context hash cache: %v", err)
+	}
+
 	var msg string
 	var hasConflicts bool
 
@@ -387,10 +417,10 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 		}, nil
 	} else if doUpdate {
 		filesToLoad := map[string]string{}
-		for id := range req {
+		for id, params := range req {
 			context := contextsById[id]
 			if context.ContextType == shared.ContextFileType {
-				filesToLoad[context.FilePath] = context.Body
+				filesToLoad[context.FilePath] = params.Body
 			}
 		}
 		for id := range deleteIds {
@@ -407,10 +437,13 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 		}
 
 		if len(req) > 0 {
-			res, apiErr := api.Client.UpdateContext(CurrentPlanId, CurrentBranch, req)
+			res, apiErr := updateContextWithDeltas(req, contextsById)
 			if apiErr != nil {
 				return nil, fmt.Errorf("failed to update context: %v", apiErr)
 			}
+			if res.MaxTokensExceeded {
+				return nil, fmt.Errorf("%s", tokenLimitExceededMsg(res))
+			}
 			msg = res.Msg
 		}
 
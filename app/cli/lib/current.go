@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"plandex/api"
+	"plandex/auth"
 	"plandex/fs"
 	"plandex/term"
 	"plandex/types"
@@ -186,7 +187,8 @@ func mustInitProject() {
 	// write project.json
 	path := filepath.Join(fs.PlandexDir, "project.json")
 	bytes, err := json.Marshal(types.CurrentProjectSettings{
-		Id: CurrentProjectId,
+		Id:  CurrentProjectId,
+		Org: auth.CurrentBinding(),
 	})
 
 	if err != nil {
@@ -0,0 +1,190 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/term"
+	"strings"
+)
+
+// devContainerTargetSpec is the apply target spec for the project's dev container
+const devContainerTargetSpec = "devcontainer"
+
+// devContainerConfigPaths are where the dev container spec puts a project's config, relative to the project root
+var devContainerConfigPaths = []string{
+	filepath.Join(".devcontainer", "devcontainer.json"),
+	".devcontainer.json",
+}
+
+// devContainer is a running container for the project's devcontainer.json
+type devContainer struct {
+	name            string
+	containerId     string
+	workspaceFolder string
+}
+
+// devContainerApplyTarget writes changes to the local project, which the dev container mounts, and runs the apply hooks in the container, so changes are checked in the project's canonical environment
+type devContainerApplyTarget struct {
+	*localApplyTarget
+	container *shellApplyTarget
+}
+
+func (t *devContainerApplyTarget) hookCommand(hookPath string, env []string) (*exec.Cmd, error) {
+	return t.container.hookCommand(hookPath, env)
+}
+
+func (c *devContainer) target() *devContainerApplyTarget {
+	return &devContainerApplyTarget{
+		localApplyTarget: &localApplyTarget{root: fs.ProjectRoot},
+		container:        newContainerApplyTarget(c.containerId, c.workspaceFolder),
+	}
+}
+
+// mustOfferDevContainer offers to run the project's apply hooks in its dev container, when it has hooks and the container is running. Otherwise, or if the offer is declined, the target is returned unchanged.
+func mustOfferDevContainer(target ApplyTarget) ApplyTarget {
+	if !hasApplyHooks() {
+		return target
+	}
+
+	devContainer, err := findDevContainer()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Couldn't check for a dev container: %v\n", err)
+		return target
+	}
+	if devContainer == nil {
+		return target
+	}
+
+	confirmed, err := term.ConfirmYesNo("Run apply hooks in the %s dev container?", devContainer.name)
+	if err != nil {
+		term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
+	}
+	if !confirmed {
+		return target
+	}
+
+	return devContainer.target()
+}
+
+// findDevContainer returns the running container for the project's devcontainer.json, or nil if the project doesn't have one or it isn't running. Containers started by editors and the devcontainer CLI are labeled with the folder they were started from, which is how the project's container is found.
+func findDevContainer() (*devContainer, error) {
+	if fs.ProjectRoot == "" {
+		return nil, nil
+	}
+
+	var configPath string
+	for _, p := range devContainerConfigPaths {
+		candidate := filepath.Join(fs.ProjectRoot, p)
+		if _, err := os.Stat(candidate); err == nil {
+			configPath = candidate
+			break
+		}
+	}
+	if configPath == "" {
+		return nil, nil
+	}
+
+	bytes, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", configPath, err)
+	}
+
+	var config struct {
+		Name            string `json:"name"`
+		WorkspaceFolder string `json:"workspaceFolder"`
+	}
+	err = json.Unmarshal(stripJsonComments(bytes), &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", configPath, err)
+	}
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, nil
+	}
+
+	out, err := exec.Command("docker", "ps", "-q", "--filter", "label=devcontainer.local_folder="+fs.ProjectRoot).Output()
+	if err != nil {
+		// docker isn't running, so neither is the container
+		return nil, nil
+	}
+
+	containerId, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if containerId == "" {
+		return nil, nil
+	}
+
+	workspaceFolder := config.WorkspaceFolder
+	if workspaceFolder == "" {
+		workspaceFolder = path.Join("/workspaces", filepath.Base(fs.ProjectRoot))
+	}
+
+	name := config.Name
+	if name == "" {
+		name = containerId
+	}
+
+	return &devContainer{
+		name:            name,
+		containerId:     containerId,
+		workspaceFolder: workspaceFolder,
+	}, nil
+}
+
+// stripJsonComments removes the comments and trailing commas that devcontainer.json allows, so it can be parsed as plain json
+func stripJsonComments(b []byte) []byte {
+	var res []byte
+	inString := false
+
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+
+		if inString {
+			res = append(res, c)
+			if c == '\\' && i+1 < len(b) {
+				i++
+				res = append(res, b[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			res = append(res, c)
+		case c == '/' && i+1 < len(b) && b[i+1] == '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+			if i < len(b) {
+				res = append(res, '\n')
+			}
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			i += 2
+			for i+1 < len(b) && !(b[i] == '*' && b[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == '}' || c == ']':
+			// drop a trailing comma before the closing bracket
+			j := len(res) - 1
+			for j >= 0 && (res[j] == ' ' || res[j] == '\t' || res[j] == '\n' || res[j] == '\r') {
+				j--
+			}
+			if j >= 0 && res[j] == ',' {
+				res = append(res[:j], res[j+1:]...)
+			}
+			res = append(res, c)
+		default:
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/fs"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// GetPlanDiffsAgainstHead renders the plan's pending changes as a diff against the project's git HEAD rather than the context the plan was built from, so local edits made since the files were loaded show up as part of the net change
+func GetPlanDiffsAgainstHead(currentPlanFiles *shared.CurrentPlanFiles) (string, error) {
+	if !fs.ProjectRootIsGitRepo() {
+		return "", fmt.Errorf("%s isn't in a git repository", fs.ProjectRoot)
+	}
+
+	tempDirPath, err := os.MkdirTemp("", "plandex-head-diffs-*")
+	if err != nil {
+		return "", fmt.Errorf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirPath)
+
+	res, err := exec.Command("git", "-C", tempDirPath, "init").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("error initializing git repo: %v, output: %s", err, string(res))
+	}
+
+	writeFile := func(path string, content []byte) error {
+		dstPath := filepath.Join(tempDirPath, path)
+		err := os.MkdirAll(filepath.Dir(dstPath), 0755)
+		if err != nil {
+			return fmt.Errorf("error creating directory for %s: %v", path, err)
+		}
+		return os.WriteFile(dstPath, content, 0644)
+	}
+
+	paths := currentPlanFiles.PendingPaths()
+	var newPaths []string
+
+	// the HEAD versions are staged, so the diff between the index and the working tree is the net change
+	for _, path := range paths {
+		head, err := exec.Command("git", "-C", fs.ProjectRoot, "show", "HEAD:./"+filepath.ToSlash(path)).Output()
+		if err != nil {
+			// not in HEAD, so the plan creates it
+			if _, ok := currentPlanFiles.Files[path]; ok {
+				newPaths = append(newPaths, path)
+			}
+			continue
+		}
+
+		err = writeFile(path, head)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	res, err = exec.Command("git", "-C", tempDirPath, "add", "-A").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("error staging HEAD versions: %v, output: %s", err, string(res))
+	}
+
+	for path, content := range currentPlanFiles.Files {
+		err = writeFile(path, []byte(content))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for path := range currentPlanFiles.Removed {
+		err = os.Remove(filepath.Join(tempDirPath, path))
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("error removing %s: %v", path, err)
+		}
+	}
+
+	// new files are marked with intent to add so they show up in the diff
+	if len(newPaths) > 0 {
+		res, err = exec.Command("git", append([]string{"-C", tempDirPath, "add", "-N", "--"}, newPaths...)...).CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("error marking new files: %v, output: %s", err, string(res))
+		}
+	}
+
+	res, err = exec.Command("git", "-C", tempDirPath, "diff", "--color=always").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("error getting diffs: %v, output: %s", err, string(res))
+	}
+
+	return string(res), nil
+}
@@ -0,0 +1,150 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/types"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// A plan's draft is a list of messages written over time and sent as one prompt with 'plandex tell --draft'. Drafts are kept locally per plan and aren't branch-specific.
+
+func draftPath() (string, error) {
+	if CurrentProjectId == "" {
+		return "", fmt.Errorf("no current project")
+	}
+
+	if CurrentPlanId == "" {
+		return "", fmt.Errorf("no current plan")
+	}
+
+	return filepath.Join(fs.HomePlandexDir, CurrentProjectId, CurrentPlanId, "draft.json"), nil
+}
+
+func LoadDraft() ([]*types.DraftMessage, error) {
+	path, err := draftPath()
+
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := os.ReadFile(path)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*types.DraftMessage{}, nil
+		}
+		return nil, fmt.Errorf("error reading draft: %v", err)
+	}
+
+	var messages []*types.DraftMessage
+	err = json.Unmarshal(bytes, &messages)
+
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling draft: %v", err)
+	}
+
+	return messages, nil
+}
+
+func AddDraftMessage(body string) ([]*types.DraftMessage, error) {
+	messages, err := LoadDraft()
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := shared.GetRandomAlphanumeric(8)
+
+	if err != nil {
+		return nil, fmt.Errorf("error generating id: %v", err)
+	}
+
+	messages = append(messages, &types.DraftMessage{
+		Id:        fmt.Sprintf("%x", b),
+		Body:      body,
+		CreatedAt: time.Now(),
+	})
+
+	err = writeDraft(messages)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func RemoveDraftMessage(id string) error {
+	messages, err := LoadDraft()
+
+	if err != nil {
+		return err
+	}
+
+	var res []*types.DraftMessage
+	for _, message := range messages {
+		if message.Id != id {
+			res = append(res, message)
+		}
+	}
+
+	return writeDraft(res)
+}
+
+func ClearDraft() error {
+	return writeDraft(nil)
+}
+
+// DraftPrompt joins a draft's messages into the prompt they're sent as
+func DraftPrompt(messages []*types.DraftMessage) string {
+	var bodies []string
+	for _, message := range messages {
+		bodies = append(bodies, strings.TrimSpace(message.Body))
+	}
+	return strings.Join(bodies, "\n\n")
+}
+
+func writeDraft(messages []*types.DraftMessage) error {
+	path, err := draftPath()
+
+	if err != nil {
+		return err
+	}
+
+	if len(messages) == 0 {
+		err = os.Remove(path)
+
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing draft: %v", err)
+		}
+
+		return nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+
+	if err != nil {
+		return fmt.Errorf("error creating plan dir: %v", err)
+	}
+
+	bytes, err := json.Marshal(messages)
+
+	if err != nil {
+		return fmt.Errorf("error marshalling draft: %v", err)
+	}
+
+	err = os.WriteFile(path, bytes, 0600)
+
+	if err != nil {
+		return fmt.Errorf("error writing draft: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"plandex/term"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/plandex/plandex/shared"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+var fileEncodings = map[string]encoding.Encoding{
+	shared.FileEncodingUTF16LE:  xunicode.UTF16(xunicode.LittleEndian, xunicode.ExpectBOM),
+	shared.FileEncodingUTF16BE:  xunicode.UTF16(xunicode.BigEndian, xunicode.ExpectBOM),
+	shared.FileEncodingShiftJIS: japanese.ShiftJIS,
+	shared.FileEncodingLatin1:   charmap.Windows1252,
+}
+
+// decodeFileContent converts a file's content to UTF-8 for the model and returns its encoding, which is empty for UTF-8 files. Files with a UTF-16 byte order mark, Japanese text in Shift-JIS, and Latin-1 text are detected. It returns false if the content isn't text in any of them, like a binary file.
+func decodeFileContent(content []byte) (string, string, bool) {
+	if !shared.IsBinaryContent(content) {
+		return string(content), "", true
+	}
+
+	var candidates []string
+	switch {
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		candidates = []string{shared.FileEncodingUTF16LE}
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		candidates = []string{shared.FileEncodingUTF16BE}
+	case bytes.IndexByte(content, 0) != -1:
+		// NUL bytes only show up in text as UTF-16
+		return "", "", false
+	default:
+		candidates = []string{shared.FileEncodingShiftJIS, shared.FileEncodingLatin1}
+	}
+
+	for _, name := range candidates {
+		decoded, err := fileEncodings[name].NewDecoder().Bytes(content)
+		if err != nil || !isDecodedText(decoded, name) {
+			continue
+		}
+
+		// the content has to convert back exactly, or applying changes would alter the lines the plan didn't touch
+		encoded, err := encodeFileContent(string(decoded), name)
+		if err != nil || !bytes.Equal(encoded, content) {
+			continue
+		}
+
+		return string(decoded), name, true
+	}
+
+	return "", "", false
+}
+
+// decodeFileContentAs converts a file's content from the encoding it had when it was loaded into context
+func decodeFileContentAs(content []byte, name string) (string, error) {
+	if name == "" {
+		return string(content), nil
+	}
+
+	enc, ok := fileEncodings[name]
+	if !ok {
+		return "", fmt.Errorf("unknown file encoding %s", name)
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s content: %v", name, err)
+	}
+
+	return string(decoded), nil
+}
+
+// encodeFileContent converts UTF-8 content to a file's encoding. It's an error if the content has characters the encoding can't represent.
+func encodeFileContent(content, name string) ([]byte, error) {
+	if name == "" {
+		return []byte(content), nil
+	}
+
+	enc, ok := fileEncodings[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown file encoding %s", name)
+	}
+
+	encoded, err := enc.NewEncoder().Bytes([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("the changes have characters that can't be written in %s: %v", name, err)
+	}
+
+	return encoded, nil
+}
+
+// isDecodedText checks that decoded content looks like text. Control characters and replacement characters mean the guess was wrong, and Shift-JIS is only accepted for content with full-width kana, since Latin-1 bytes often decode as valid Shift-JIS too.
+func isDecodedText(decoded []byte, name string) bool {
+	hasKana := false
+
+	for len(decoded) > 0 {
+		r, size := utf8.DecodeRune(decoded)
+		decoded = decoded[size:]
+
+		switch {
+		case r == utf8.RuneError:
+			return false
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r' && r != '\f':
+			return false
+		case r >= 0x7F && r < 0xA0:
+			return false
+		case r < 0xFF00 && unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			// half-width katakana are left out, since they're the Shift-JIS reading of single Latin-1 letters like Ä
+			hasKana = true
+		}
+	}
+
+	return name != shared.FileEncodingShiftJIS || hasKana
+}
+
+// mustEncodeApplyFiles converts the files an apply writes to the encodings they were loaded in. It runs before anything is applied, so a change that can't be written in its file's encoding stops the apply instead of mangling the file.
+func mustEncodeApplyFiles(currentPlanState *shared.CurrentPlanState, toApply map[string]string) map[string][]byte {
+	res := make(map[string][]byte, len(toApply))
+
+	for path, content := range toApply {
+		content = strings.ReplaceAll(content, "\\`\\`\\`", "```")
+
+		var encoding string
+		if context := currentPlanState.ContextsByPath[path]; context != nil {
+			encoding = context.Encoding
+		}
+
+		encoded, err := encodeFileContent(content, encoding)
+		if err != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("Can't apply changes to %s: %v", path, err)
+		}
+
+		res[path] = encoded
+	}
+
+	return res
+}
@@ -0,0 +1,139 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const MaxExploreBranches = 6
+
+const exploreStatusPollInterval = 2 * time.Second
+
+// ExploreVariant is one branch of an explore run: the same prompt sent on its own branch, optionally with a different model pack or extra instructions
+type ExploreVariant struct {
+	Branch    string
+	ModelPack *shared.ModelPack
+	Prompt    string
+}
+
+// NextExploreBranchNames returns names for n new branches forked from the current branch, skipping names that are already taken
+func NextExploreBranchNames(branches []*shared.Branch, n int) []string {
+	taken := map[string]bool{}
+	for _, branch := range branches {
+		taken[branch.Name] = true
+	}
+
+	var names []string
+	for i := 1; len(names) < n; i++ {
+		name := fmt.Sprintf("%s-explore-%d", CurrentBranch, i)
+		if !taken[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// StartExplore forks the current branch for each variant, sets the variant's model pack, and sends its prompt in the background so the branches' replies and builds run concurrently on the server
+func StartExplore(apiKeys map[string]string, contexts []*shared.Context, variants []*ExploreVariant, noBuild bool) error {
+	paths, err := fs.GetProjectPaths(fs.GetBaseDirForContexts(contexts))
+	if err != nil {
+		return fmt.Errorf("error getting project paths: %v", err)
+	}
+
+	var openAIBase, openAIOrgId string
+	if apiKeys["OPENAI_API_KEY"] != "" {
+		openAIBase = os.Getenv("OPENAI_API_BASE")
+		if openAIBase == "" {
+			openAIBase = os.Getenv("OPENAI_ENDPOINT")
+		}
+		openAIOrgId = apiKeys["OPENAI_ORG_ID"]
+	}
+
+	buildMode := shared.BuildModeAuto
+	if noBuild {
+		buildMode = shared.BuildModeNone
+	}
+
+	for _, variant := range variants {
+		apiErr := api.Client.CreateBranch(CurrentPlanId, CurrentBranch, shared.CreateBranchRequest{Name: variant.Branch})
+		if apiErr != nil {
+			return fmt.Errorf("error creating branch %s: %s", variant.Branch, apiErr.Msg)
+		}
+
+		if variant.ModelPack != nil {
+			settings, apiErr := api.Client.GetSettings(CurrentPlanId, variant.Branch)
+			if apiErr != nil {
+				return fmt.Errorf("error getting settings for branch %s: %s", variant.Branch, apiErr.Msg)
+			}
+
+			settings.ModelPack = variant.ModelPack
+
+			_, apiErr = api.Client.UpdateSettings(CurrentPlanId, variant.Branch, shared.UpdateSettingsRequest{
+				Settings: settings,
+			})
+			if apiErr != nil {
+				return fmt.Errorf("error setting model pack for branch %s: %s", variant.Branch, apiErr.Msg)
+			}
+		}
+
+		apiErr = api.Client.TellPlan(CurrentPlanId, variant.Branch, shared.TellPlanRequest{
+			Prompt:        variant.Prompt,
+			ConnectStream: false,
+			AutoContinue:  true,
+			ProjectPaths:  paths.ActivePaths,
+			BuildMode:     buildMode,
+			ApiKeys:       apiKeys,
+			OpenAIBase:    openAIBase,
+			OpenAIOrgId:   openAIOrgId,
+		}, nil)
+		if apiErr != nil {
+			return fmt.Errorf("error sending prompt on branch %s: %s", variant.Branch, apiErr.Msg)
+		}
+	}
+
+	return nil
+}
+
+// WaitForExplore polls the explore branches until each one has finished, stopped, errored, or is waiting on a missing file or continue prompt, and returns their final state
+func WaitForExplore(branchNames []string) ([]*shared.Branch, error) {
+	for {
+		branches, apiErr := api.Client.ListBranches(CurrentPlanId)
+		if apiErr != nil {
+			return nil, fmt.Errorf("error getting branches: %s", apiErr.Msg)
+		}
+
+		byName := map[string]*shared.Branch{}
+		for _, branch := range branches {
+			byName[branch.Name] = branch
+		}
+
+		var res []*shared.Branch
+		numDone := 0
+		for _, name := range branchNames {
+			branch := byName[name]
+			if branch == nil {
+				return nil, fmt.Errorf("branch %s not found", name)
+			}
+			res = append(res, branch)
+
+			switch branch.Status {
+			case shared.PlanStatusFinished, shared.PlanStatusStopped, shared.PlanStatusError, shared.PlanStatusMissingFile, shared.PlanStatusPromptingContinue:
+				numDone++
+			}
+		}
+
+		if numDone == len(branchNames) {
+			return res, nil
+		}
+
+		term.UpdateSpinnerMsg(fmt.Sprintf("Exploring %d branches • %d/%d finished", len(branchNames), numDone, len(branchNames)))
+
+		time.Sleep(exploreStatusPollInterval)
+	}
+}
@@ -198,6 +198,13 @@ func GitFileHasUncommittedChanges(path string) (bool, error) {
 	return strings.TrimSpace(string(res)) != "", nil
 }
 
+func GitFileIsTracked(repoDir, path string) bool {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	return exec.Command("git", "-C", repoDir, "ls-files", "--error-unmatch", path).Run() == nil
+}
+
 func GitCheckoutFile(path string) error {
 	gitMutex.Lock()
 	defer gitMutex.Unlock()
@@ -0,0 +1,198 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/term"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+const lockfileRegenerateTimeout = 5 * time.Minute
+
+// package manager config that's copied next to the manifest, so private registries and proxies still work when a lockfile is regenerated
+var lockfileConfigFiles = []string{".npmrc", ".pnpmfile.cjs"}
+
+// RegenerateLockfilesByDefault is whether lockfiles are regenerated when the apply command's --regen-lockfiles flag isn't set
+func RegenerateLockfilesByDefault() bool {
+	return os.Getenv("PLANDEX_REGEN_LOCKFILES") != ""
+}
+
+// staleLockfiles returns the project's lockfiles that were generated from a manifest in paths, which are out of date once the manifest is updated
+func staleLockfiles(paths []string) []string {
+	seen := map[string]bool{}
+	var res []string
+
+	for _, path := range paths {
+		for _, lockfilePath := range shared.LockfilePaths(path) {
+			if seen[lockfilePath] {
+				continue
+			}
+			seen[lockfilePath] = true
+
+			_, err := os.Stat(filepath.Join(fs.ProjectRoot, lockfilePath))
+			if err == nil {
+				res = append(res, lockfilePath)
+			}
+		}
+	}
+
+	sort.Strings(res)
+	return res
+}
+
+// regenerateLockfiles brings the lockfiles of updated manifests up to date. When regenerate is false, or a lockfile's tool can't regenerate it in isolation, the lockfile is listed with how to update it instead. It returns the lockfiles that changed, so they can be committed with the manifests.
+func regenerateLockfiles(updatedPaths []string, regenerate bool, runCommands shared.TrustLevel) []string {
+	stale := staleLockfiles(updatedPaths)
+	if len(stale) == 0 {
+		return nil
+	}
+
+	var manual []string
+	var toRegenerate []string
+
+	for _, path := range stale {
+		lockfile := shared.GetLockfile(path)
+		if regenerate && lockfile.RegenerateCmd != nil {
+			toRegenerate = append(toRegenerate, path)
+		} else {
+			manual = append(manual, path)
+		}
+	}
+
+	var updated []string
+
+	if len(toRegenerate) > 0 {
+		shouldRun, err := confirmRunCommand(runCommands, "lockfile regeneration")
+		if err != nil {
+			term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
+		}
+
+		if shouldRun {
+			for _, path := range toRegenerate {
+				fmt.Fprintln(os.Stderr, color.New(color.Bold, term.ColorHiCyan).Sprintf("🔒 Regenerating %s", path))
+
+				changed, err := regenerateLockfile(path)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "⚠️  Couldn't regenerate %s: %v\n", path, err)
+					manual = append(manual, path)
+					continue
+				}
+				if changed {
+					updated = append(updated, path)
+				}
+			}
+			fmt.Fprintln(os.Stderr)
+		} else {
+			manual = append(manual, toRegenerate...)
+		}
+	}
+
+	if len(manual) > 0 {
+		sort.Strings(manual)
+
+		fmt.Fprintln(os.Stderr, "🔒 These lockfiles may be out of date with their updated manifests:")
+		for _, path := range manual {
+			lockfile := shared.GetLockfile(path)
+			line := "  • " + path
+			if lockfile.RegenerateCmd != nil {
+				line += " → " + strings.Join(lockfile.RegenerateCmd, " ")
+			} else {
+				line += " → update it with your package manager"
+			}
+			fmt.Fprintln(os.Stderr, line)
+		}
+		if !regenerate {
+			fmt.Fprintf(os.Stderr, "Apply with %s or set PLANDEX_REGEN_LOCKFILES to regenerate supported lockfiles automatically\n", color.New(color.Bold, term.ColorHiCyan).Sprint("--regen-lockfiles"))
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+
+	return updated
+}
+
+// regenerateLockfile runs a lockfile's tool in a temporary directory with only the manifest, the current lockfile, and package manager config, so it can't read or change the rest of the project, and install scripts don't run. Only the regenerated lockfile is copied back. It returns whether the lockfile changed.
+func regenerateLockfile(path string) (bool, error) {
+	lockfile := shared.GetLockfile(path)
+
+	if _, err := exec.LookPath(lockfile.RegenerateCmd[0]); err != nil {
+		return false, fmt.Errorf("%s isn't installed", lockfile.RegenerateCmd[0])
+	}
+
+	srcDir := filepath.Join(fs.ProjectRoot, filepath.Dir(path))
+	lockfilePath := filepath.Join(fs.ProjectRoot, path)
+
+	current, err := os.ReadFile(lockfilePath)
+	if err != nil {
+		return false, fmt.Errorf("error reading lockfile: %v", err)
+	}
+
+	dir, err := os.MkdirTemp("", "plandex-lockfile-")
+	if err != nil {
+		return false, fmt.Errorf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range append([]string{lockfile.Manifest, lockfile.Name}, lockfileConfigFiles...) {
+		content, err := os.ReadFile(filepath.Join(srcDir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return false, fmt.Errorf("error reading %s: %v", name, err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name), content, 0644)
+		if err != nil {
+			return false, fmt.Errorf("error copying %s: %v", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lockfileRegenerateTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, lockfile.RegenerateCmd[0], lockfile.RegenerateCmd[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		// use the installed go toolchain rather than downloading the one the manifest asks for
+		"GOTOOLCHAIN=local",
+		"GOFLAGS=-mod=mod",
+	)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err = cmd.Run()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return false, fmt.Errorf("timed out after %s", lockfileRegenerateTimeout)
+		}
+		return false, fmt.Errorf("%s failed: %v\n%s", strings.Join(lockfile.RegenerateCmd, " "), err, strings.TrimSpace(output.String()))
+	}
+
+	regenerated, err := os.ReadFile(filepath.Join(dir, lockfile.Name))
+	if err != nil {
+		return false, fmt.Errorf("error reading regenerated lockfile: %v", err)
+	}
+
+	if bytes.Equal(current, regenerated) {
+		return false, nil
+	}
+
+	err = os.WriteFile(lockfilePath, regenerated, 0644)
+	if err != nil {
+		return false, fmt.Errorf("error writing lockfile: %v", err)
+	}
+
+	return true, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"plandex/api"
+	"plandex/secrets"
 	"plandex/term"
 
 	"github.com/fatih/color"
@@ -135,14 +136,19 @@ func SelectModelForRole(customModels []*shared.AvailableModel, role shared.Model
 }
 
 func MustVerifyApiKeys() map[string]string {
-	return mustVerifyApiKeys(false)
+	return mustVerifyApiKeys(false, nil)
 }
 
 func MustVerifyApiKeysSilent() map[string]string {
-	return mustVerifyApiKeys(true)
+	return mustVerifyApiKeys(true, nil)
 }
 
-func mustVerifyApiKeys(silent bool) map[string]string {
+// MustVerifyApiKeysForModelPacks verifies keys for the current plan's settings with each of the model packs swapped in, for running the same plan with several packs
+func MustVerifyApiKeysForModelPacks(modelPacks []*shared.ModelPack) map[string]string {
+	return mustVerifyApiKeys(false, modelPacks)
+}
+
+func mustVerifyApiKeys(silent bool, modelPacks []*shared.ModelPack) map[string]string {
 	if !silent {
 		term.StartSpinner("")
 	}
@@ -157,29 +163,100 @@ func mustVerifyApiKeys(silent bool) map[string]string {
 
 	requiredEnvVars := planSettings.GetRequiredEnvVars()
 
+	for _, modelPack := range modelPacks {
+		packSettings := *planSettings
+		packSettings.ModelPack = modelPack
+		for envVar := range packSettings.GetRequiredEnvVars() {
+			requiredEnvVars[envVar] = true
+		}
+	}
+
+	return mustGetApiKeys(requiredEnvVars)
+}
+
+// MustVerifyChatApiKeys verifies the key for the chat role in the org's default settings. Chats aren't tied to a plan, so they use the defaults.
+func MustVerifyChatApiKeys() map[string]string {
+	settings, apiErr := api.Client.GetOrgDefaultSettings()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting default settings: %v", apiErr)
+	}
+
+	modelPack := settings.ModelPack
+	if modelPack == nil {
+		modelPack = shared.DefaultModelPack
+	}
+
+	requiredEnvVars := map[string]bool{}
+	config := modelPack.GetChat().BaseModelConfig
+	// the mock provider runs on the server and doesn't need a key
+	if config.Provider != shared.ModelProviderMock {
+		requiredEnvVars[config.ApiKeyEnvVar] = true
+	}
+
+	return mustGetApiKeys(requiredEnvVars)
+}
+
+// mustGetApiKeys reads the keys for the required env vars from the environment or the keys stored with 'plandex auth set-key', and exits if any are missing that the org hasn't stored on the server
+func mustGetApiKeys(requiredEnvVars map[string]bool) map[string]string {
 	apiKeys := make(map[string]string)
 
-	if len(requiredEnvVars) == 1 && requiredEnvVars["OPENAI_API_KEY"] {
-		if os.Getenv("OPENAI_API_KEY") == "" {
-			term.OutputNoOpenAIApiKeyMsgAndExit()
+	var missing []string
+	for envVar := range requiredEnvVars {
+		if os.Getenv(envVar) != "" {
+			apiKeys[envVar] = os.Getenv(envVar)
+			continue
+		}
+
+		// fall back to a key stored with 'plandex auth set-key'
+		key, err := secrets.Get(secrets.ProviderKeyName(envVar))
+
+		if err != nil {
+			term.OutputErrorAndExit("Error loading stored key for %s: %v", envVar, err)
+		}
+
+		if key == "" {
+			missing = append(missing, envVar)
+		} else {
+			apiKeys[envVar] = key
 		}
-		apiKeys["OPENAI_API_KEY"] = os.Getenv("OPENAI_API_KEY")
+	}
+
+	if len(missing) == 0 {
 		return apiKeys
 	}
 
-	missingAny := false
-	for envVar := range requiredEnvVars {
-		if os.Getenv(envVar) == "" {
-			fmt.Fprintln(os.Stderr, color.New(color.Bold, term.ColorHiRed).Sprintf("🚨 %s environment variable is not set.\n", envVar))
-			missingAny = true
-		} else {
-			apiKeys[envVar] = os.Getenv(envVar)
+	// keys the org has stored on the server don't need to be set locally
+	credentials, apiErr := api.Client.ListOrgCredentials()
+	if apiErr == nil {
+		orgCredentials := map[string]bool{}
+		for _, credential := range credentials {
+			orgCredentials[credential.Name] = true
 		}
+
+		var stillMissing []string
+		for _, envVar := range missing {
+			if !orgCredentials[envVar] {
+				stillMissing = append(stillMissing, envVar)
+			}
+		}
+		missing = stillMissing
 	}
 
-	if missingAny {
-		os.Exit(1)
+	if len(missing) == 0 {
+		return apiKeys
 	}
 
-	return apiKeys
+	if len(requiredEnvVars) == 1 && requiredEnvVars["OPENAI_API_KEY"] {
+		term.OutputNoOpenAIApiKeyMsgAndExit()
+	}
+
+	for _, envVar := range missing {
+		fmt.Fprintln(os.Stderr, color.New(color.Bold, term.ColorHiRed).Sprintf("🚨 %s environment variable is not set.\n", envVar))
+	}
+
+	fmt.Fprintf(os.Stderr, "Set the missing variables, or store them securely with %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint("plandex auth set-key"))
+
+	os.Exit(1)
+	return nil
 }
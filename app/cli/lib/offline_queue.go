@@ -0,0 +1,260 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+	"plandex/types"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+// Tells and context loads are queued per plan while the server can't be reached, then sent in order once it's back
+
+// IsServerReachable checks that the server responds. Errors other than a failed connection still count as reachable, since they'll show up on the real request.
+func IsServerReachable() bool {
+	apiErr := api.Client.CheckHealth()
+	return apiErr == nil || apiErr.Type != shared.ApiErrorTypeServerUnreachable
+}
+
+func offlineQueuePath() (string, error) {
+	if CurrentProjectId == "" {
+		return "", fmt.Errorf("no current project")
+	}
+
+	if CurrentPlanId == "" {
+		return "", fmt.Errorf("no current plan")
+	}
+
+	return filepath.Join(fs.HomePlandexDir, CurrentProjectId, CurrentPlanId, "offline_queue.json"), nil
+}
+
+// LoadOfflineQueue loads the current plan's queued actions across all branches, oldest first
+func LoadOfflineQueue() ([]*types.QueuedAction, error) {
+	path, err := offlineQueuePath()
+
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := os.ReadFile(path)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*types.QueuedAction{}, nil
+		}
+		return nil, fmt.Errorf("error reading offline queue: %v", err)
+	}
+
+	var actions []*types.QueuedAction
+	err = json.Unmarshal(bytes, &actions)
+
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling offline queue: %v", err)
+	}
+
+	return actions, nil
+}
+
+// LoadBranchOfflineQueue loads the actions queued on the current branch
+func LoadBranchOfflineQueue() ([]*types.QueuedAction, error) {
+	actions, err := LoadOfflineQueue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*types.QueuedAction
+	for _, action := range actions {
+		if action.Branch == CurrentBranch {
+			res = append(res, action)
+		}
+	}
+
+	return res, nil
+}
+
+func QueueAction(action *types.QueuedAction) error {
+	actions, err := LoadOfflineQueue()
+
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.Getwd()
+
+	if err != nil {
+		return fmt.Errorf("error getting working directory: %v", err)
+	}
+
+	b, err := shared.GetRandomAlphanumeric(8)
+
+	if err != nil {
+		return fmt.Errorf("error generating id: %v", err)
+	}
+
+	action.Id = fmt.Sprintf("%x", b)
+	action.Branch = CurrentBranch
+	action.Dir = dir
+	action.CreatedAt = time.Now()
+
+	return writeOfflineQueue(append(actions, action))
+}
+
+func RemoveQueuedAction(id string) error {
+	actions, err := LoadOfflineQueue()
+
+	if err != nil {
+		return err
+	}
+
+	var res []*types.QueuedAction
+	for _, action := range actions {
+		if action.Id != id {
+			res = append(res, action)
+		}
+	}
+
+	return writeOfflineQueue(res)
+}
+
+func writeOfflineQueue(actions []*types.QueuedAction) error {
+	path, err := offlineQueuePath()
+
+	if err != nil {
+		return err
+	}
+
+	if len(actions) == 0 {
+		err = os.Remove(path)
+
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing offline queue: %v", err)
+		}
+
+		return nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+
+	if err != nil {
+		return fmt.Errorf("error creating plan dir: %v", err)
+	}
+
+	bytes, err := json.Marshal(actions)
+
+	if err != nil {
+		return fmt.Errorf("error marshalling offline queue: %v", err)
+	}
+
+	err = os.WriteFile(path, bytes, 0600)
+
+	if err != nil {
+		return fmt.Errorf("error writing offline queue: %v", err)
+	}
+
+	return nil
+}
+
+// MustQueueAction queues an action and explains when it will be sent
+func MustQueueAction(action *types.QueuedAction) {
+	term.StopSpinner()
+
+	err := QueueAction(action)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error queueing %s: %v", action.Type, err)
+	}
+
+	actions, err := LoadBranchOfflineQueue()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading offline queue: %v", err)
+	}
+
+	what := "Prompt"
+	if action.Type == types.QueuedActionLoad {
+		what = "Context load"
+	}
+
+	fmt.Printf("📥 Couldn't reach the server, so it was queued locally. %s saved (%d queued on this branch).\n", what, len(actions))
+	fmt.Printf("It will be sent when you run %s, or with your next tell or load once the server is back.\n", color.New(color.Bold, term.ColorHiCyan).Sprint("plandex queue sync"))
+	fmt.Println()
+	term.PrintCmds("", "queue", "queue sync")
+}
+
+// ReadPipedData reads data piped to the command, so a queued load keeps it
+func ReadPipedData() (string, error) {
+	fileInfo, err := os.Stdin.Stat()
+
+	if err != nil {
+		return "", fmt.Errorf("failed to stat stdin: %v", err)
+	}
+
+	if fileInfo.Mode()&os.ModeNamedPipe == 0 {
+		return "", nil
+	}
+
+	bytes, err := io.ReadAll(bufio.NewReader(os.Stdin))
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read piped data: %v", err)
+	}
+
+	return string(bytes), nil
+}
+
+// MustReplayQueuedLoad loads context for a queued action from the directory it was queued in, piping in any data that was piped to the original command
+func MustReplayQueuedLoad(action *types.QueuedAction) {
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error getting working directory: %v", err)
+	}
+
+	if action.Dir != "" && action.Dir != cwd {
+		err = os.Chdir(action.Dir)
+
+		if err != nil {
+			term.OutputErrorAndExit("Error changing to %s: %v", action.Dir, err)
+		}
+
+		defer os.Chdir(cwd)
+	}
+
+	if action.PipedData != "" {
+		r, w, err := os.Pipe()
+
+		if err != nil {
+			term.OutputErrorAndExit("Error creating pipe: %v", err)
+		}
+
+		go func() {
+			w.WriteString(action.PipedData)
+			w.Close()
+		}()
+
+		stdin := os.Stdin
+		os.Stdin = r
+
+		defer func() {
+			os.Stdin = stdin
+			r.Close()
+		}()
+	}
+
+	params := action.LoadParams
+	if params == nil {
+		params = &types.LoadContextParams{}
+	}
+
+	MustLoadContext(action.Resources, params)
+}
@@ -0,0 +1,29 @@
+package lib
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// matches CSI sequences (colors, cursor movement) and OSC sequences (titles, hyperlinks)
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// cleanPipedData strips terminal escape codes and progress bar redraws from command output, then trims it to its last MaxPipedDataTokens tokens. It returns the number of lines trimmed.
+func cleanPipedData(data string) (string, int, error) {
+	data = ansiPattern.ReplaceAllString(data, "")
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+
+	lines := strings.Split(data, "\n")
+	for i, line := range lines {
+		// a carriage return redraws the line, so only the text after the last one was visible
+		if idx := strings.LastIndex(line, "\r"); idx >= 0 {
+			lines[i] = line[idx+1:]
+		}
+	}
+
+	data = strings.Join(lines, "\n")
+
+	return shared.TrimPipedData(data)
+}
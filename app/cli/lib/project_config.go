@@ -0,0 +1,241 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/config"
+	"plandex/fs"
+	"plandex/term"
+	"plandex/types"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+func MustLoadProjectConfig() *config.ProjectConfig {
+	c, err := config.LoadProjectConfig()
+
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error loading %s: %v", config.ProjectConfigPath(), err)
+	}
+
+	return c
+}
+
+// ProjectContextPaths returns the project's files that match the context patterns in .plandex/config.yml, sorted. Ignored files aren't included.
+func ProjectContextPaths() ([]string, error) {
+	c := MustLoadProjectConfig()
+	if len(c.Context) == 0 {
+		return nil, nil
+	}
+
+	matcher := ignore.CompileIgnoreLines(c.Context...)
+
+	paths, err := fs.GetProjectPaths(fs.ProjectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error getting project paths: %v", err)
+	}
+
+	var res []string
+	for path := range paths.ActivePaths {
+		if !matcher.MatchesPath(path) {
+			continue
+		}
+
+		info, err := os.Stat(filepath.Join(fs.ProjectRoot, filepath.FromSlash(path)))
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		res = append(res, path)
+	}
+
+	sort.Strings(res)
+
+	return res, nil
+}
+
+// MustApplyProjectConfigToNewPlan sets up the current plan, which was just created, with the model pack and context in .plandex/config.yml. extraPaths are loaded into context along with the config's context, with note and noteName if set, in a single load.
+func MustApplyProjectConfigToNewPlan(extraPaths []string, note, noteName string) {
+	c := MustLoadProjectConfig()
+
+	if c.ModelPack != "" {
+		mustSetProjectModelPack(c.ModelPack)
+	}
+
+	configPaths, err := ProjectContextPaths()
+	if err != nil {
+		term.OutputErrorAndExit("Error getting context from %s: %v", config.ProjectConfigPath(), err)
+	}
+
+	if len(configPaths) > 0 {
+		fmt.Printf("📚 Loading %d file(s) matching the context patterns in %s\n", len(configPaths), config.ProjectConfigPath())
+	}
+
+	paths := mustFilterUnloadedPaths(append(extraPaths, configPaths...))
+
+	if len(paths) == 0 && note == "" {
+		return
+	}
+
+	MustLoadContext(paths, &types.LoadContextParams{
+		Note: note,
+		Name: noteName,
+	})
+}
+
+// mustFilterUnloadedPaths dedupes paths and drops the ones already in the current plan's context, like files added by an org's context bundles
+func mustFilterUnloadedPaths(paths []string) []string {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	contexts, apiErr := api.Client.ListContext(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error listing context: %v", apiErr.Msg)
+	}
+
+	loaded := map[string]bool{}
+	for _, context := range contexts {
+		if context.ContextType == shared.ContextFileType {
+			loaded[context.FilePath] = true
+		}
+	}
+
+	var res []string
+	for _, path := range paths {
+		if loaded[path] {
+			continue
+		}
+		loaded[path] = true
+		res = append(res, path)
+	}
+
+	return res
+}
+
+func mustSetProjectModelPack(name string) {
+	var modelPack *shared.ModelPack
+	for _, mp := range shared.BuiltInModelPacks {
+		if strings.EqualFold(mp.Name, name) {
+			modelPack = mp
+			break
+		}
+	}
+
+	term.StartSpinner("")
+
+	if modelPack == nil {
+		customModelPacks, apiErr := api.Client.ListModelPacks()
+		if apiErr != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("Error getting custom model packs: %v", apiErr.Msg)
+		}
+
+		for _, mp := range customModelPacks {
+			if strings.EqualFold(mp.Name, name) {
+				modelPack = mp
+				break
+			}
+		}
+	}
+
+	if modelPack == nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Model pack '%s' from %s not found. Use 'plandex model-packs' to list the available packs.", name, config.ProjectConfigPath())
+	}
+
+	settings, apiErr := api.Client.GetSettings(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting current settings: %v", apiErr.Msg)
+	}
+
+	settings.ModelPack = modelPack
+
+	_, apiErr = api.Client.UpdateSettings(CurrentPlanId, CurrentBranch, shared.UpdateSettingsRequest{
+		Settings: settings,
+	})
+
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error setting model pack: %v", apiErr.Msg)
+	}
+
+	fmt.Printf("🧠 Set model pack to %s from %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(modelPack.Name), config.ProjectConfigPath())
+}
+
+// ProjectConventions returns the contents of the conventions file in .plandex/config.yml, or an empty string if there isn't one. A configured file that can't be read is a warning rather than an error, so a prompt can still be sent.
+func ProjectConventions() string {
+	c := MustLoadProjectConfig()
+
+	path := c.ConventionsPath()
+	if path == "" {
+		return ""
+	}
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Couldn't read the conventions file %s from %s: %v\n", c.Conventions, config.ProjectConfigPath(), err)
+		return ""
+	}
+
+	return string(bytes)
+}
+
+// MaybeAutoApply applies the current plan's pending changes if the auto-apply policy in .plandex/config.yml calls for it. It's called once a plan finishes in the foreground. It returns true if the changes were applied, in which case the apply has already shown what to do next.
+func MaybeAutoApply() bool {
+	c := MustLoadProjectConfig()
+
+	policy := c.AutoApplyPolicy()
+	if policy == config.AutoApplyOff {
+		return false
+	}
+
+	state, apiErr := api.Client.GetCurrentPlanState(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr.Msg)
+	}
+
+	if state.HasPendingBuilds() {
+		return false
+	}
+
+	files := state.CurrentPlanFiles
+	if files == nil || (len(files.Files) == 0 && len(files.Removed) == 0) {
+		return false
+	}
+
+	fmt.Println()
+
+	if policy == config.AutoApplyConfirm {
+		shouldApply, err := term.ConfirmYesNo("Apply the plan's changes now?")
+
+		if err != nil {
+			term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
+		}
+
+		if !shouldApply {
+			fmt.Println()
+			return false
+		}
+	} else {
+		fmt.Printf("⚡️ Applying changes, since %s sets autoApply to %s\n", config.ProjectConfigPath(), policy)
+	}
+
+	MustApplyPlan(CurrentPlanId, CurrentBranch, ApplyFlags{
+		AutoConfirm:         policy == config.AutoApplyAlways,
+		WithProvenance:      CommitProvenanceByDefault(),
+		RegenerateLockfiles: RegenerateLockfilesByDefault(),
+		Target:              DefaultApplyTarget(),
+	})
+
+	return true
+}
@@ -0,0 +1,128 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// BindProjectOrg binds the current project to an org and server. A project is created in the org the first time the project is bound to it; after that, binding back reuses it along with its plans. Returns whether a project was created.
+func BindProjectOrg(binding *types.ProjectOrgBinding) (bool, error) {
+	return switchProjectOrg(binding, binding)
+}
+
+// UnbindProjectOrg removes the current project's binding so it follows the default org again
+func UnbindProjectOrg() (bool, error) {
+	target := auth.DefaultBinding()
+
+	if target == nil {
+		return false, fmt.Errorf("no default org—run 'plandex orgs switch' to choose one")
+	}
+
+	return switchProjectOrg(target, nil)
+}
+
+func switchProjectOrg(target, org *types.ProjectOrgBinding) (bool, error) {
+	settings, err := readProjectSettings()
+
+	if err != nil {
+		return false, err
+	}
+
+	// an unbound project uses the default org
+	prev := settings.Org
+	if prev == nil {
+		prev = auth.DefaultBinding()
+	}
+
+	if settings.ProjectIdsByOrg == nil {
+		settings.ProjectIdsByOrg = map[string]string{}
+	}
+
+	if prev != nil && settings.Id != "" {
+		settings.ProjectIdsByOrg[prev.Key()] = settings.Id
+	}
+
+	err = auth.UseBinding(target)
+
+	if err != nil {
+		return false, err
+	}
+
+	created := false
+	projectId, ok := settings.ProjectIdsByOrg[target.Key()]
+
+	if !ok {
+		res, apiErr := api.Client.CreateProject(shared.CreateProjectRequest{Name: filepath.Base(fs.ProjectRoot)})
+
+		if apiErr != nil {
+			return false, fmt.Errorf("error creating project in %s: %v", target.OrgName, apiErr.Msg)
+		}
+
+		projectId = res.Id
+		settings.ProjectIdsByOrg[target.Key()] = projectId
+		created = true
+	}
+
+	settings.Id = projectId
+	settings.Org = org
+
+	err = writeProjectSettings(settings)
+
+	if err != nil {
+		return false, err
+	}
+
+	auth.ProjectOrg = org
+	CurrentProjectId = projectId
+	HomeCurrentProjectDir = filepath.Join(fs.HomePlandexDir, CurrentProjectId)
+	HomeCurrentPlanPath = filepath.Join(HomeCurrentProjectDir, "current_plan.json")
+
+	err = os.MkdirAll(HomeCurrentProjectDir, os.ModePerm)
+
+	if err != nil {
+		return false, fmt.Errorf("error creating project dir: %v", err)
+	}
+
+	return created, nil
+}
+
+func readProjectSettings() (*types.CurrentProjectSettings, error) {
+	bytes, err := os.ReadFile(filepath.Join(fs.PlandexDir, "project.json"))
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading project.json: %v", err)
+	}
+
+	var settings types.CurrentProjectSettings
+	err = json.Unmarshal(bytes, &settings)
+
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling project.json: %v", err)
+	}
+
+	return &settings, nil
+}
+
+func writeProjectSettings(settings *types.CurrentProjectSettings) error {
+	bytes, err := json.Marshal(settings)
+
+	if err != nil {
+		return fmt.Errorf("error marshalling project settings: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(fs.PlandexDir, "project.json"), bytes, os.ModePerm)
+
+	if err != nil {
+		return fmt.Errorf("error writing project.json: %v", err)
+	}
+
+	return nil
+}
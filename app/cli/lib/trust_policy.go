@@ -0,0 +1,140 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/term"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+func MustGetTrustPolicy() *shared.EffectiveTrustPolicy {
+	policy, apiErr := api.Client.GetProjectTrustPolicy(CurrentProjectId)
+
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting trust policy: %v", apiErr.Msg)
+	}
+
+	return policy
+}
+
+// mustCheckNewFilesTrust checks the files an apply would create, the ones that aren't in existing, against the trust policy. Files it denies stop the apply, and files that need confirmation are confirmed even when the apply itself is auto-confirmed.
+func mustCheckNewFilesTrust(policy *shared.EffectiveTrustPolicy, paths []string, existing map[string][]byte) {
+	var denied []string
+	var toConfirm []string
+
+	for _, path := range paths {
+		if _, ok := existing[path]; ok {
+			continue
+		}
+
+		switch policy.CreateFileLevel(path) {
+		case shared.TrustLevelDeny:
+			denied = append(denied, path)
+		case shared.TrustLevelConfirm:
+			toConfirm = append(toConfirm, path)
+		}
+	}
+
+	sort.Strings(denied)
+	sort.Strings(toConfirm)
+
+	if len(denied) > 0 {
+		term.StopSpinner()
+		fmt.Println("🛡️  The trust policy doesn't allow creating these files:")
+		for _, path := range denied {
+			fmt.Println("  • " + path)
+		}
+		fmt.Println()
+		fmt.Printf("Reject them with %s, then apply again\n", color.New(color.Bold, term.ColorHiCyan).Sprint("plandex reject"))
+		fmt.Println()
+		term.PrintCmds("", "reject", "trust")
+		os.Exit(1)
+	}
+
+	if len(toConfirm) > 0 {
+		term.StopSpinner()
+		fmt.Println("🛡️  The trust policy asks for confirmation to create these files:")
+		for _, path := range toConfirm {
+			fmt.Println("  • " + path)
+		}
+		fmt.Println()
+
+		confirmed, err := term.ConfirmYesNo("Create them?")
+
+		if err != nil {
+			term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
+		}
+
+		if !confirmed {
+			fmt.Println("Apply plan canceled")
+			os.Exit(0)
+		}
+		term.ResumeSpinner()
+	}
+}
+
+// mustCheckRemovedFilesTrust checks the files an apply would remove against the trust policy's delete-files level, in the same way as mustCheckNewFilesTrust.
+func mustCheckRemovedFilesTrust(policy *shared.EffectiveTrustPolicy, removed map[string]bool) {
+	if len(removed) == 0 {
+		return
+	}
+
+	var paths []string
+	for path := range removed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	switch policy.Level(shared.TrustActionDeleteFiles) {
+	case shared.TrustLevelDeny:
+		term.StopSpinner()
+		fmt.Println("🛡️  The trust policy doesn't allow removing these files:")
+		for _, path := range paths {
+			fmt.Println("  • " + path)
+		}
+		fmt.Println()
+		fmt.Printf("Reject them with %s, then apply again\n", color.New(color.Bold, term.ColorHiCyan).Sprint("plandex reject"))
+		fmt.Println()
+		term.PrintCmds("", "reject", "trust")
+		os.Exit(1)
+
+	case shared.TrustLevelConfirm:
+		term.StopSpinner()
+		fmt.Println("🛡️  The trust policy asks for confirmation to remove these files:")
+		for _, path := range paths {
+			fmt.Println("  • " + path)
+		}
+		fmt.Println()
+
+		confirmed, err := term.ConfirmYesNo("Remove them?")
+
+		if err != nil {
+			term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
+		}
+
+		if !confirmed {
+			fmt.Println("Apply plan canceled")
+			os.Exit(0)
+		}
+		term.ResumeSpinner()
+	}
+}
+
+// confirmRunCommand checks whether the trust policy lets a command run. It returns false if the command should be skipped.
+func confirmRunCommand(level shared.TrustLevel, label string) (bool, error) {
+	switch level {
+	case shared.TrustLevelDeny:
+		term.StopSpinner()
+		fmt.Fprintf(os.Stderr, "🛡️  Skipping %s because the trust policy doesn't allow running commands\n", label)
+		return false, nil
+	case shared.TrustLevelConfirm:
+		term.StopSpinner()
+		return term.ConfirmYesNo("Run %s?", label)
+	}
+	return true, nil
+}
@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"plandex/config"
+	"runtime"
+	"strings"
+)
+
+// Desktop notifications are sent with each OS's own tools: osascript on macOS, notify-send on Linux, and a PowerShell toast on Windows. A notification that can't be sent is only logged, since it should never interrupt the command.
+
+// MaybeNotify sends a desktop notification if the CLI config enables them. In 'unfocused' mode it's skipped when the terminal is known to be focused.
+func MaybeNotify(title, body string) {
+	c, err := config.Load()
+
+	if err != nil {
+		log.Printf("Error loading config for notification: %v\n", err)
+		return
+	}
+
+	switch c.NotificationMode() {
+	case config.NotificationsOff:
+		return
+	case config.NotificationsUnfocused:
+		if isTerminalFocused() {
+			return
+		}
+	}
+
+	err = Send(title, body)
+
+	if err != nil {
+		log.Printf("Error sending notification: %v\n", err)
+	}
+}
+
+func Send(title, body string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux":
+		cmd = exec.Command("notify-send", "--app-name=Plandex", title, body)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript(title, body))
+	default:
+		return fmt.Errorf("notifications aren't supported on %s", runtime.GOOS)
+	}
+
+	out, err := cmd.CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("%s: %v %s", cmd.Args[0], err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// isTerminalFocused checks whether the terminal window running plandex is in front. When that can't be determined, it's treated as unfocused so the notification isn't lost.
+func isTerminalFocused() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("osascript", "-e", `tell application "System Events" to get name of first application process whose frontmost is true`).Output()
+
+		if err != nil {
+			return false
+		}
+
+		frontmost := strings.ToLower(strings.TrimSpace(string(out)))
+
+		for _, name := range macTerminalAppNames() {
+			if strings.Contains(frontmost, name) {
+				return true
+			}
+		}
+
+		return false
+
+	case "linux":
+		// most X11 terminals set WINDOWID to their window
+		windowId := os.Getenv("WINDOWID")
+
+		if windowId == "" {
+			return false
+		}
+
+		out, err := exec.Command("xdotool", "getactivewindow").Output()
+
+		if err != nil {
+			return false
+		}
+
+		return strings.TrimSpace(string(out)) == windowId
+	}
+
+	return false
+}
+
+// macTerminalAppNames maps TERM_PROGRAM to the process names its app shows as frontmost
+func macTerminalAppNames() []string {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "Apple_Terminal":
+		return []string{"terminal"}
+	case "iTerm.app":
+		return []string{"iterm"}
+	case "vscode":
+		return []string{"code", "cursor", "electron"}
+	case "WezTerm":
+		return []string{"wezterm"}
+	case "ghostty":
+		return []string{"ghostty"}
+	case "WarpTerminal":
+		return []string{"warp", "stable"}
+	case "Hyper":
+		return []string{"hyper"}
+	case "Tabby":
+		return []string{"tabby"}
+	}
+
+	if os.Getenv("KITTY_WINDOW_ID") != "" {
+		return []string{"kitty"}
+	}
+
+	if os.Getenv("ALACRITTY_WINDOW_ID") != "" || os.Getenv("ALACRITTY_SOCKET") != "" {
+		return []string{"alacritty"}
+	}
+
+	return nil
+}
+
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func windowsToastScript(title, body string) string {
+	escape := func(s string) string {
+		s = strings.ReplaceAll(s, "'", "''")
+		s = strings.ReplaceAll(s, "&", "&amp;")
+		s = strings.ReplaceAll(s, "<", "&lt;")
+		s = strings.ReplaceAll(s, ">", "&gt;")
+		return s
+	}
+
+	return fmt.Sprintf(`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] > $null
+$xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+$xml.LoadXml('<toast><visual><binding template="ToastGeneric"><text>%s</text><text>%s</text></binding></visual></toast>')
+$toast = New-Object Windows.UI.Notifications.ToastNotification $xml
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('Plandex').Show($toast)`, escape(title), escape(body))
+}
@@ -7,4 +7,13 @@ type ExecParams struct {
 	CurrentBranch        string
 	ApiKeys              map[string]string
 	CheckOutdatedContext func(maybeContexts []*shared.Context) (bool, bool)
+
+	// OnPromptSent is called once the server accepts the prompt
+	OnPromptSent func()
+
+	// OnServerUnreachable is called instead of exiting with an error when the prompt can't reach the server
+	OnServerUnreachable func()
+
+	// OnFinished is called when a plan that wasn't stopped early finishes streaming in the foreground. It returns true if it already showed what to do next.
+	OnFinished func() bool
 }
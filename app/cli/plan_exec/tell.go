@@ -7,6 +7,7 @@ import (
 	"plandex/api"
 	"plandex/auth"
 	"plandex/fs"
+	"plandex/lib"
 	"plandex/stream"
 	streamtui "plandex/stream_tui"
 	"plandex/term"
@@ -87,10 +88,16 @@ func TellPlan(
 			ApiKeys:        params.ApiKeys,
 			OpenAIBase:     openAIBase,
 			OpenAIOrgId:    openAIOrgId,
+			Conventions:    lib.ProjectConventions(),
 		}, stream.OnStreamPlan)
 
 		term.StopSpinner()
 
+		if apiErr != nil && apiErr.Type == shared.ApiErrorTypeServerUnreachable && params.OnServerUnreachable != nil {
+			params.OnServerUnreachable()
+			return false
+		}
+
 		if apiErr != nil {
 			if apiErr.Type == shared.ApiErrorTypeTrialMessagesExceeded {
 				fmt.Fprintf(os.Stderr, "\n🚨 You've reached the Plandex Cloud anonymous trial limit of %d messages per plan\n", apiErr.TrialMessagesExceededError.MaxReplies)
@@ -112,7 +119,27 @@ func TellPlan(
 				return false
 			}
 
-			term.OutputErrorAndExit("Prompt error: %v", apiErr.Msg)
+			if apiErr.Type == shared.ApiErrorTypeTrialTokensExceeded {
+				fmt.Fprintf(os.Stderr, "\n🚨 You've reached %s's trial limit of %d tokens\n", auth.Current.OrgName, apiErr.TrialTokensExceededError.MaxTokens)
+
+				res, err := term.ConfirmYesNo("Sign up to keep your plans and remove the limit?")
+
+				if err != nil {
+					term.OutputErrorAndExit("Error prompting upgrade trial: %v", err)
+				}
+
+				if res {
+					err := auth.ConvertTrial()
+					if err != nil {
+						term.OutputErrorAndExit("Error converting trial: %v", err)
+					}
+					// retry action after converting trial
+					return fn()
+				}
+				return false
+			}
+
+			term.OutputApiErrorAndExit("Prompt error: ", apiErr)
 		} else if apiErr != nil && isUserContinue && apiErr.Type == shared.ApiErrorTypeContinueNoMessages {
 			fmt.Println("🤷‍♂️ There's no plan yet to continue")
 			fmt.Println()
@@ -120,6 +147,10 @@ func TellPlan(
 			os.Exit(0)
 		}
 
+		if apiErr == nil && params.OnPromptSent != nil {
+			params.OnPromptSent()
+		}
+
 		if !tellBg {
 			go func() {
 				err := streamtui.StartStreamUI(prompt, false)
@@ -130,6 +161,10 @@ func TellPlan(
 
 				fmt.Println()
 
+				if !tellStop && params.OnFinished != nil && params.OnFinished() {
+					os.Exit(0)
+				}
+
 				if tellStop {
 					term.PrintCmds("", "continue", "changes", "diff", "apply", "reject", "log", "rewind")
 				} else {
@@ -0,0 +1,163 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Prefix is what an executable's name starts with to be run as a plandex subcommand: 'plandex foo' runs 'plandex-foo'
+const Prefix = "plandex-"
+
+// Context is written to a plugin's stdin as JSON so it can act on the same project, plan, and server as the CLI. Fields are empty when they don't apply, like the plan fields outside of a project.
+type Context struct {
+	Version     string `json:"version"`
+	Cwd         string `json:"cwd"`
+	ProjectRoot string `json:"projectRoot,omitempty"`
+	ProjectId   string `json:"projectId,omitempty"`
+	PlanId      string `json:"planId,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	ApiHost     string `json:"apiHost,omitempty"`
+	IsCloud     bool   `json:"isCloud"`
+	UserId      string `json:"userId,omitempty"`
+	Email       string `json:"email,omitempty"`
+	OrgId       string `json:"orgId,omitempty"`
+	OrgName     string `json:"orgName,omitempty"`
+	// sent as 'Authorization: Bearer <token>' on api requests
+	Token string `json:"token,omitempty"`
+}
+
+// Plugin is a plandex-<name> executable found on PATH
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// Find looks up the executable for a plugin name on PATH, returning an empty path if there isn't one
+func Find(name string) (string, error) {
+	if !isValidName(name) {
+		return "", nil
+	}
+
+	path, err := exec.LookPath(Prefix + name)
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error looking up plugin %s: %v", name, err)
+	}
+
+	return path, nil
+}
+
+// List returns the plugins on PATH, sorted by name. When the same name is in more than one PATH dir, the first one wins, as it does when running it.
+func List() []*Plugin {
+	byName := map[string]*Plugin{}
+	var res []*Plugin
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || byName[name] != nil {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+
+			plugin := &Plugin{Name: name, Path: path}
+			byName[name] = plugin
+			res = append(res, plugin)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
+
+// Run execs a plugin with the remaining args, the context as JSON on stdin, and the CLI's stdout and stderr. It returns the plugin's exit code.
+func Run(path string, args []string, ctx *Context) (int, error) {
+	bytes, err := json.Marshal(ctx)
+	if err != nil {
+		return 1, fmt.Errorf("error marshalling plugin context: %v", err)
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = strings.NewReader(string(bytes))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// ctrl-c goes to the plugin too, so let it decide how to exit
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	err = cmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		return 1, fmt.Errorf("error running plugin: %v", err)
+	}
+
+	return 0, nil
+}
+
+func isValidName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "-") && !strings.ContainsAny(name, `/\`)
+}
+
+// pluginName gets the plugin name from an executable's file name, dropping the extension on windows
+func pluginName(fileName string) (string, bool) {
+	if !strings.HasPrefix(fileName, Prefix) {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(fileName, Prefix)
+	if runtime.GOOS == "windows" {
+		ext := filepath.Ext(name)
+		if !strings.EqualFold(ext, ".exe") && !strings.EqualFold(ext, ".bat") && !strings.EqualFold(ext, ".cmd") {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	return name, isValidName(name)
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return info.Mode()&0111 != 0
+}
+
+func isNotFound(err error) bool {
+	if execErr, ok := err.(*exec.Error); ok {
+		return execErr.Err == exec.ErrNotFound || os.IsNotExist(execErr.Err)
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package plugins
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755)
+	if err != nil {
+		t.Fatalf("error writing script: %v", err)
+	}
+	return path
+}
+
+func skipOnWindows(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a unix shell")
+	}
+}
+
+func TestFind(t *testing.T) {
+	skipOnWindows(t)
+
+	dir := t.TempDir()
+	path := writeScript(t, dir, "plandex-deploy", "exit 0")
+	t.Setenv("PATH", dir)
+
+	found, err := Find("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if found != path {
+		t.Errorf("expected %s, got %s", path, found)
+	}
+
+	for _, name := range []string{"missing", "", "-deploy", "../plandex-deploy", "sub/deploy"} {
+		found, err := Find(name)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", name, err)
+		}
+		if found != "" {
+			t.Errorf("expected no plugin for %q, got %s", name, found)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	skipOnWindows(t)
+
+	first := t.TempDir()
+	second := t.TempDir()
+	writeScript(t, first, "plandex-b", "exit 0")
+	writeScript(t, second, "plandex-b", "exit 0")
+	writeScript(t, second, "plandex-a", "exit 0")
+	writeScript(t, second, "other-tool", "exit 0")
+
+	err := os.WriteFile(filepath.Join(second, "plandex-notes"), []byte("not executable"), 0644)
+	if err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+
+	t.Setenv("PATH", first+string(os.PathListSeparator)+second)
+
+	list := List()
+	if len(list) != 2 {
+		t.Fatalf("expected 2 plugins, got %d", len(list))
+	}
+	if list[0].Name != "a" || list[1].Name != "b" {
+		t.Errorf("expected plugins a and b, got %s and %s", list[0].Name, list[1].Name)
+	}
+	if filepath.Dir(list[1].Path) != first {
+		t.Errorf("expected plugin b from the first PATH dir, got %s", list[1].Path)
+	}
+}
+
+func TestRun(t *testing.T) {
+	skipOnWindows(t)
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	path := writeScript(t, dir, "plandex-deploy", `cat > "$1"; exit 3`)
+
+	ctx := &Context{
+		Version:     "1.0.0",
+		ProjectRoot: "/projects/app",
+		PlanId:      "plan-1",
+		Branch:      "main",
+		ApiHost:     "http://localhost:8080",
+		OrgId:       "org-1",
+		Token:       "token",
+	}
+
+	code, err := Run(path, []string{out}, ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != 3 {
+		t.Errorf("expected exit code 3, got %d", code)
+	}
+
+	bytes, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("error reading plugin output: %v", err)
+	}
+
+	var got Context
+	err = json.Unmarshal(bytes, &got)
+	if err != nil {
+		t.Fatalf("error unmarshalling context: %v", err)
+	}
+	if got != *ctx {
+		t.Errorf("expected context %+v, got %+v", *ctx, got)
+	}
+}
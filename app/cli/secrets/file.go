@@ -0,0 +1,306 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+)
+
+// The fallback file is encrypted with a random key kept in secrets.key (readable only by the user), or with a key derived from PLANDEX_SECRETS_PASSPHRASE if it's set. A passphrase also protects the secrets from anything else that can read the home dir.
+
+const passphraseEnvVar = "PLANDEX_SECRETS_PASSPHRASE"
+const kdfIterations = 210000
+
+type encryptedFile struct {
+	Version       int    `json:"version"`
+	UsePassphrase bool   `json:"usePassphrase"`
+	Salt          []byte `json:"salt,omitempty"`
+	Nonce         []byte `json:"nonce"`
+	Data          []byte `json:"data"`
+}
+
+func secretsFilePath() string {
+	return filepath.Join(fs.HomePlandexDir, "secrets.enc")
+}
+
+func secretsKeyPath() string {
+	return filepath.Join(fs.HomePlandexDir, "secrets.key")
+}
+
+func fileGet(name string) (string, error) {
+	values, _, err := readSecretsFile()
+	if err != nil {
+		return "", err
+	}
+	return values[name], nil
+}
+
+func fileSet(name, value string) error {
+	values, usePassphrase, err := readSecretsFile()
+	if err != nil {
+		return err
+	}
+	values[name] = value
+	return writeSecretsFile(values, usePassphrase || os.Getenv(passphraseEnvVar) != "", false)
+}
+
+func fileDelete(name string) error {
+	values, usePassphrase, err := readSecretsFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[name]; !ok {
+		return nil
+	}
+	delete(values, name)
+	return writeSecretsFile(values, usePassphrase, false)
+}
+
+// RotateFileKey re-encrypts the secrets file with a new key: a new random key, or a new salt for the passphrase if one is set
+func RotateFileKey() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	values, usePassphrase, err := readSecretsFile()
+	if err != nil {
+		return err
+	}
+
+	return writeSecretsFile(values, usePassphrase || os.Getenv(passphraseEnvVar) != "", true)
+}
+
+// FileUsesPassphrase checks whether the secrets file is encrypted with a key derived from PLANDEX_SECRETS_PASSPHRASE
+func FileUsesPassphrase() (bool, error) {
+	file, err := loadEncryptedFile()
+	if err != nil || file == nil {
+		return false, err
+	}
+	return file.UsePassphrase, nil
+}
+
+func loadEncryptedFile() (*encryptedFile, error) {
+	bytes, err := os.ReadFile(secretsFilePath())
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading secrets.enc: %v", err)
+	}
+
+	var file encryptedFile
+	err = json.Unmarshal(bytes, &file)
+
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling secrets.enc: %v", err)
+	}
+
+	return &file, nil
+}
+
+func readSecretsFile() (map[string]string, bool, error) {
+	values := map[string]string{}
+
+	file, err := loadEncryptedFile()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if file == nil {
+		return values, false, nil
+	}
+
+	key, err := fileKey(file.UsePassphrase, file.Salt, false)
+	if err != nil {
+		return nil, false, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		if file.UsePassphrase {
+			return nil, false, fmt.Errorf("couldn't decrypt secrets.enc—check %s", passphraseEnvVar)
+		}
+		return nil, false, fmt.Errorf("couldn't decrypt secrets.enc: %v", err)
+	}
+
+	err = json.Unmarshal(plaintext, &values)
+	if err != nil {
+		return nil, false, fmt.Errorf("error unmarshalling secrets: %v", err)
+	}
+
+	return values, file.UsePassphrase, nil
+}
+
+func writeSecretsFile(values map[string]string, usePassphrase, newKey bool) error {
+	file := encryptedFile{
+		Version:       1,
+		UsePassphrase: usePassphrase,
+	}
+
+	if usePassphrase {
+		existing, err := loadEncryptedFile()
+		if err != nil {
+			return err
+		}
+
+		if existing != nil && existing.UsePassphrase && !newKey {
+			file.Salt = existing.Salt
+		} else {
+			file.Salt = make([]byte, 16)
+			_, err = rand.Read(file.Salt)
+			if err != nil {
+				return fmt.Errorf("error generating salt: %v", err)
+			}
+		}
+	}
+
+	key, err := fileKey(usePassphrase, file.Salt, newKey)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	file.Nonce = make([]byte, gcm.NonceSize())
+	_, err = rand.Read(file.Nonce)
+	if err != nil {
+		return fmt.Errorf("error generating nonce: %v", err)
+	}
+
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("error marshalling secrets: %v", err)
+	}
+
+	file.Data = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	bytes, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("error marshalling secrets.enc: %v", err)
+	}
+
+	// write to a temp file and rename so a failed write can't lose the existing secrets
+	tmpPath := secretsFilePath() + ".tmp"
+	err = os.WriteFile(tmpPath, bytes, 0600)
+	if err != nil {
+		return fmt.Errorf("error writing secrets.enc: %v", err)
+	}
+
+	err = os.Rename(tmpPath, secretsFilePath())
+	if err != nil {
+		return fmt.Errorf("error writing secrets.enc: %v", err)
+	}
+
+	if usePassphrase {
+		// the random key is no longer needed
+		err = os.Remove(secretsKeyPath())
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing secrets.key: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func fileKey(usePassphrase bool, salt []byte, newKey bool) ([]byte, error) {
+	if usePassphrase {
+		passphrase := os.Getenv(passphraseEnvVar)
+		if passphrase == "" {
+			return nil, fmt.Errorf("secrets.enc is encrypted with a passphrase—set %s to use it", passphraseEnvVar)
+		}
+		return pbkdf2SHA256([]byte(passphrase), salt, kdfIterations, 32), nil
+	}
+
+	if !newKey {
+		key, err := os.ReadFile(secretsKeyPath())
+
+		if err == nil && len(key) == 32 {
+			return key, nil
+		}
+
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error reading secrets.key: %v", err)
+		}
+
+		// a missing key is only expected before the first secret is written
+		if _, statErr := os.Stat(secretsFilePath()); statErr == nil {
+			return nil, fmt.Errorf("secrets.key is missing or invalid, so secrets.enc can't be decrypted")
+		}
+	}
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, fmt.Errorf("error generating key: %v", err)
+	}
+
+	err = os.WriteFile(secretsKeyPath(), key, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error writing secrets.key: %v", err)
+	}
+
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %v", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating GCM: %v", err)
+	}
+
+	return gcm, nil
+}
+
+// pbkdf2SHA256 derives a key from a passphrase as in RFC 8018
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	var buf [4]byte
+	dk := make([]byte, 0, numBlocks*hashLen)
+	u := make([]byte, hashLen)
+
+	for block := 1; block <= numBlocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:])
+		dk = prf.Sum(dk)
+		t := dk[len(dk)-hashLen:]
+		copy(u, t)
+
+		for n := 2; n <= iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = u[:0]
+			u = prf.Sum(u)
+			for i := range u {
+				t[i] ^= u[i]
+			}
+		}
+	}
+
+	return dk[:keyLen]
+}
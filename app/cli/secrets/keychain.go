@@ -0,0 +1,153 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// The keychain is used through the OS's own command line tools: 'security' for the macOS Keychain and 'secret-tool' for the Secret Service (GNOME Keyring, KWallet) on Linux. There's no keychain backend on Windows, where the CLI runs under WSL and uses secret-tool if a session bus is available, or the encrypted file otherwise.
+
+func keychainService() string {
+	if os.Getenv("PLANDEX_ENV") == "development" {
+		return "plandex-dev"
+	}
+	return "plandex"
+}
+
+func keychainAvailable() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := exec.LookPath("security")
+		return err == nil
+	case "linux":
+		// secret-tool needs a session bus to reach the keyring, which headless machines usually don't have
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return false
+		}
+		_, err := exec.LookPath("secret-tool")
+		return err == nil
+	}
+	return false
+}
+
+func keychainGet(name string) (string, error) {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService(), "-a", name, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService(), "account", name)
+	default:
+		return "", fmt.Errorf("keychain isn't supported on %s", runtime.GOOS)
+	}
+
+	out, err := runKeychainCmd(cmd)
+
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func keychainSet(name, value string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		// the command is sent on stdin to 'security -i' so the secret isn't in the process's args, where other users could see it with 'ps'. -U updates the item if it already exists.
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("secrets can't contain line breaks")
+		}
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", securityQuote(keychainService()), securityQuote(name), securityQuote(value)))
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label=Plandex "+name, "service", keychainService(), "account", name)
+		cmd.Stdin = strings.NewReader(value)
+	default:
+		return fmt.Errorf("keychain isn't supported on %s", runtime.GOOS)
+	}
+
+	_, err := runKeychainCmd(cmd)
+	return err
+}
+
+// securityQuote quotes an argument for a command read by 'security -i'
+func securityQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func keychainDelete(name string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", keychainService(), "-a", name)
+	case "linux":
+		cmd = exec.Command("secret-tool", "clear", "service", keychainService(), "account", name)
+	default:
+		return fmt.Errorf("keychain isn't supported on %s", runtime.GOOS)
+	}
+
+	_, err := runKeychainCmd(cmd)
+
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+type notFoundError struct{}
+
+func (e notFoundError) Error() string {
+	return "not found"
+}
+
+func runKeychainCmd(cmd *exec.Cmd) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+
+	// 'security -i' exits cleanly even when a command fails, printing the error instead
+	if err == nil && len(cmd.Args) > 1 && cmd.Args[1] == "-i" && strings.TrimSpace(stderr.String()) != "" {
+		return "", fmt.Errorf("%s: %s", cmd.Args[0], strings.TrimSpace(stderr.String()))
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// 'security' exits with 44 when an item isn't found. 'secret-tool lookup' and 'clear' exit with 1 and print nothing.
+			if (runtime.GOOS == "darwin" && exitErr.ExitCode() == 44) ||
+				(runtime.GOOS == "linux" && exitErr.ExitCode() == 1 && strings.TrimSpace(stderr.String()) == "") {
+				return "", notFoundError{}
+			}
+		}
+
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("%s: %s", cmd.Args[0], msg)
+	}
+
+	return stdout.String(), nil
+}
+
+func isNotFound(err error) bool {
+	var nf notFoundError
+	return errors.As(err, &nf)
+}
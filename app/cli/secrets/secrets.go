@@ -0,0 +1,218 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Secrets (auth tokens and model provider keys) are kept out of the plaintext files in the home dir. They're stored in the OS keychain when one is available, and otherwise in a file encrypted with AES-256-GCM. An index of secret names, without values, records where each one lives.
+
+const (
+	BackendKeychain = "keychain"
+	BackendFile     = "file"
+)
+
+const authTokenPrefix = "auth-token/"
+const providerKeyPrefix = "provider-key/"
+
+func AuthTokenName(userId string) string {
+	return authTokenPrefix + userId
+}
+
+func ProviderKeyName(envVar string) string {
+	return providerKeyPrefix + envVar
+}
+
+type index struct {
+	BackendByName map[string]string `json:"backendByName"`
+}
+
+var mu sync.Mutex
+
+func indexPath() string {
+	return filepath.Join(fs.HomePlandexDir, "secrets.json")
+}
+
+// Backend is where new secrets are stored. Set PLANDEX_SECRETS_BACKEND to 'file' or 'keychain' to override the default.
+func Backend() string {
+	switch os.Getenv("PLANDEX_SECRETS_BACKEND") {
+	case BackendFile:
+		return BackendFile
+	case BackendKeychain:
+		return BackendKeychain
+	}
+
+	if keychainAvailable() {
+		return BackendKeychain
+	}
+	return BackendFile
+}
+
+func Get(name string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	idx, err := loadIndex()
+	if err != nil {
+		return "", err
+	}
+
+	backend, ok := idx.BackendByName[name]
+	if !ok {
+		return "", nil
+	}
+
+	if backend == BackendKeychain {
+		return keychainGet(name)
+	}
+	return fileGet(name)
+}
+
+func Set(name, value string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	idx, err := loadIndex()
+	if err != nil {
+		return err
+	}
+
+	backend := Backend()
+
+	if backend == BackendKeychain {
+		err = keychainSet(name, value)
+		if err != nil {
+			return fmt.Errorf("error storing %s in keychain: %v", name, err)
+		}
+	} else {
+		err = fileSet(name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	// clear out a copy left in the other backend
+	if prev, ok := idx.BackendByName[name]; ok && prev != backend {
+		err = deleteFrom(prev, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	idx.BackendByName[name] = backend
+
+	return writeIndex(idx)
+}
+
+func Delete(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	idx, err := loadIndex()
+	if err != nil {
+		return err
+	}
+
+	backend, ok := idx.BackendByName[name]
+	if !ok {
+		return nil
+	}
+
+	err = deleteFrom(backend, name)
+	if err != nil {
+		return err
+	}
+
+	delete(idx.BackendByName, name)
+
+	return writeIndex(idx)
+}
+
+// List returns the backend of each stored secret, by name
+func List() (map[string]string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	idx, err := loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	return idx.BackendByName, nil
+}
+
+// ProviderKeyNames lists the env vars that have a provider key stored
+func ProviderKeyNames() ([]string, error) {
+	byName, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for name := range byName {
+		if strings.HasPrefix(name, providerKeyPrefix) {
+			res = append(res, strings.TrimPrefix(name, providerKeyPrefix))
+		}
+	}
+	sort.Strings(res)
+
+	return res, nil
+}
+
+func deleteFrom(backend, name string) error {
+	if backend == BackendKeychain {
+		err := keychainDelete(name)
+		if err != nil {
+			return fmt.Errorf("error deleting %s from keychain: %v", name, err)
+		}
+		return nil
+	}
+	return fileDelete(name)
+}
+
+func loadIndex() (*index, error) {
+	idx := &index{BackendByName: map[string]string{}}
+
+	bytes, err := os.ReadFile(indexPath())
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return nil, fmt.Errorf("error reading secrets.json: %v", err)
+	}
+
+	err = json.Unmarshal(bytes, idx)
+
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling secrets.json: %v", err)
+	}
+
+	if idx.BackendByName == nil {
+		idx.BackendByName = map[string]string{}
+	}
+
+	return idx, nil
+}
+
+func writeIndex(idx *index) error {
+	bytes, err := json.Marshal(idx)
+
+	if err != nil {
+		return fmt.Errorf("error marshalling secrets index: %v", err)
+	}
+
+	err = os.WriteFile(indexPath(), bytes, 0600)
+
+	if err != nil {
+		return fmt.Errorf("error writing secrets.json: %v", err)
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package streamtui
 
 import (
+	"time"
+
 	bubbleKey "github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -41,9 +43,25 @@ type streamUIModel struct {
 	spinner      spinner.Model
 	buildSpinner spinner.Model
 
+	// how long the model has been thinking, for models that send their whole reply at once
+	reasoningSeconds int
+
 	building       bool
+	buildPaused    bool
 	tokensByPath   map[string]int
 	finishedByPath map[string]bool
+	etaByPath      map[string]time.Duration
+	errByPath      map[string]string
+
+	buildEstimate *shared.BuildCostEstimate
+	// set while the build waits for the user to confirm its estimated cost
+	confirmingBuildCost bool
+
+	// preview of the file that most recently had a change finish streaming
+	previewPath    string
+	previewContent string
+	previewLine    int
+	hidePreview    bool
 
 	ready  bool
 	width  int
@@ -58,6 +76,10 @@ type streamUIModel struct {
 	missingFileContent     string
 	missingFileTokens      int
 
+	// set while the plan waits for the user to confirm its next step
+	promptingContinue bool
+	continueNextTask  string
+
 	prompt string
 
 	stopped    bool
@@ -79,6 +101,8 @@ type keymap = struct {
 	up,
 	down,
 	quit,
+	preview,
+	decline,
 	enter bubbleKey.Binding
 }
 
@@ -142,6 +166,16 @@ func initialModel(prestartReply, prompt string, buildOnly bool) *streamUIModel {
 				bubbleKey.WithHelp("down", "next"),
 			),
 
+			preview: bubbleKey.NewBinding(
+				bubbleKey.WithKeys("p"),
+				bubbleKey.WithHelp("p", "toggle preview"),
+			),
+
+			decline: bubbleKey.NewBinding(
+				bubbleKey.WithKeys("n"),
+				bubbleKey.WithHelp("n", "don't continue"),
+			),
+
 			enter: bubbleKey.NewBinding(
 				bubbleKey.WithKeys("enter"),
 				bubbleKey.WithHelp("enter", "select"),
@@ -160,6 +194,8 @@ func initialModel(prestartReply, prompt string, buildOnly bool) *streamUIModel {
 
 		tokensByPath:   make(map[string]int),
 		finishedByPath: make(map[string]bool),
+		etaByPath:      make(map[string]time.Duration),
+		errByPath:      make(map[string]string),
 		spinner:        s,
 		buildSpinner:   buildSpinner,
 		atScrollBottom: true,
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"plandex/notify"
 	"plandex/term"
 	"sync"
 
@@ -22,7 +23,7 @@ var prestartAbort bool
 
 func StartStreamUI(prompt string, buildOnly bool) error {
 	if prestartErr != nil {
-		term.OutputErrorAndExit("Server error: " + prestartErr.Msg)
+		term.OutputApiErrorAndExit("Server error: ", prestartErr)
 	}
 
 	if prestartAbort {
@@ -55,6 +56,8 @@ func StartStreamUI(prompt string, buildOnly bool) error {
 
 	fmt.Println()
 
+	notifyFinished(mod)
+
 	if !mod.buildOnly {
 		fmt.Println(mod.mainDisplay)
 	}
@@ -70,7 +73,7 @@ func StartStreamUI(prompt string, buildOnly bool) error {
 
 	if mod.apiErr != nil {
 		fmt.Println()
-		term.OutputErrorAndExit("Server error: " + mod.apiErr.Msg)
+		term.OutputApiErrorAndExit("Server error: ", mod.apiErr)
 	}
 
 	if mod.stopped {
@@ -90,6 +93,44 @@ func StartStreamUI(prompt string, buildOnly bool) error {
 	return nil
 }
 
+// notifyFinished sends a desktop notification (if enabled in 'plandex config') when the stream ends on its own. Nothing is sent when the user stopped it or sent it to the background.
+func notifyFinished(mod *streamUIModel) {
+	if mod.stopped || mod.background {
+		return
+	}
+
+	title := "Plan finished"
+	if mod.buildOnly {
+		title = "Build finished"
+	}
+
+	if mod.err != nil || mod.apiErr != nil {
+		title = "Plan failed"
+		if mod.buildOnly {
+			title = "Build failed"
+		}
+
+		var msg string
+		if mod.err != nil {
+			msg = mod.err.Error()
+		} else {
+			msg = mod.apiErr.Msg
+		}
+
+		notify.MaybeNotify(title, msg)
+		return
+	}
+
+	body := "Ready for review"
+	if len(mod.errByPath) > 0 {
+		body = fmt.Sprintf("%d file(s) failed to build", len(mod.errByPath))
+	} else if len(mod.finishedByPath) > 0 {
+		body = fmt.Sprintf("Built %d file(s)—ready for review", len(mod.finishedByPath))
+	}
+
+	notify.MaybeNotify(title, body)
+}
+
 func Quit() {
 	if ui == nil {
 		log.Println("stream UI is nil, can't quit")
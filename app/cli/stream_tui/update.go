@@ -89,8 +89,25 @@ func (m streamUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.scrollStart()
 		case bubbleKey.Matches(msg, m.keymap.end) && !m.promptingMissingFile:
 			m.scrollEnd()
+		case bubbleKey.Matches(msg, m.keymap.preview) && !m.promptingMissingFile:
+			m.hidePreview = !m.hidePreview
+			m.updateViewportDimensions()
 		case m.promptingMissingFile && bubbleKey.Matches(msg, m.keymap.enter):
 			return m.selectedMissingFileOpt()
+		case m.promptingContinue && bubbleKey.Matches(msg, m.keymap.enter):
+			return m.respondContinue(true)
+		case m.promptingContinue && bubbleKey.Matches(msg, m.keymap.decline):
+			return m.respondContinue(false)
+		case m.confirmingBuildCost && bubbleKey.Matches(msg, m.keymap.enter):
+			return m.confirmBuildCost()
+		case m.confirmingBuildCost && bubbleKey.Matches(msg, m.keymap.decline):
+			apiErr := api.Client.StopPlan(lib.CurrentPlanId, lib.CurrentBranch)
+			if apiErr != nil {
+				log.Println("stop plan api error:", apiErr)
+				m.apiErr = apiErr
+			}
+			m.stopped = true
+			return m, tea.Quit
 
 		default:
 			m.resolveEscapeSequence(msg.String())
@@ -131,7 +148,7 @@ func (m *streamUIModel) updateReplyDisplay() {
 	}
 
 	if m.reply != "" {
-		replyMd, _ := term.GetMarkdown(m.reply)
+		replyMd, _ := term.GetMarkdown(lib.RenderCitations(m.reply))
 		s += "\n" + color.New(color.BgBlue, color.Bold, color.FgHiWhite).Sprintf(" 🤖 Plandex reply 👇 ")
 		s += "\n\n" + strings.TrimSpace(replyMd)
 	} else {
@@ -169,6 +186,10 @@ func (m *streamUIModel) getViewportDimensions() (int, int) {
 	// log.Println("building:", m.building)
 	// log.Println("buildHeight:", buildHeight)
 
+	if preview := m.renderPreview(); m.building && preview != "" {
+		buildHeight += lipgloss.Height(preview)
+	}
+
 	var processingHeight int
 	if m.starting || m.processing {
 		processingHeight = lipgloss.Height(m.renderProcessing())
@@ -232,29 +253,62 @@ func (m *streamUIModel) scrollEnd() {
 }
 
 func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage, deferUIUpdate bool) (tea.Model, tea.Cmd) {
-	checkMissingFileFn := func() {
-		if msg.MissingFilePath != "" {
-			m.promptingMissingFile = true
-			m.missingFilePath = msg.MissingFilePath
-
-			bytes, err := os.ReadFile(m.missingFilePath)
-			if err != nil {
-				log.Println("failed to read file:", err)
-				m.err = fmt.Errorf("failed to read file: %w", err)
-				return
+	checkPromptContinueFn := func() {
+		if msg.PromptingContinue {
+			m.promptingContinue = true
+			m.continueNextTask = msg.ContinueNextTask
+			m.processing = false
+			if !deferUIUpdate {
+				m.updateViewportDimensions()
 			}
-			m.missingFileContent = string(bytes)
+		}
+	}
+
+	checkMissingFileFn := func() tea.Cmd {
+		if msg.MissingFilePath == "" {
+			return nil
+		}
 
-			numTokens, err := shared.GetNumTokens(m.missingFileContent)
+		m.promptingMissingFile = true
+		m.missingFilePath = msg.MissingFilePath
 
-			if err != nil {
-				log.Println("failed to get num tokens:", err)
-				m.err = fmt.Errorf("failed to get num tokens: %w", err)
-				return
+		// when the trust policy or the plan's workspaces skip the file, it may not exist yet, so it isn't read
+		if msg.MissingFileAutoChoice == shared.RespondMissingFileChoiceSkip {
+			path := m.missingFilePath
+			_, cmd := m.respondMissingFile(shared.RespondMissingFileChoiceSkip)
+			if msg.MissingFileOutsideWorkspaces {
+				m.reply += fmt.Sprintf("\n\n📂 Skipped %s because it's outside the plan's workspaces\n", path)
+			} else {
+				m.reply += fmt.Sprintf("\n\n🛡️  Skipped %s because the trust policy doesn't allow it\n", path)
 			}
+			m.updateReplyDisplay()
+			return cmd
+		}
+
+		bytes, err := os.ReadFile(m.missingFilePath)
+		if err != nil {
+			log.Println("failed to read file:", err)
+			m.err = fmt.Errorf("failed to read file: %w", err)
+			return nil
+		}
+		m.missingFileContent = string(bytes)
+
+		numTokens, err := shared.GetNumTokens(m.missingFileContent)
+
+		if err != nil {
+			log.Println("failed to get num tokens:", err)
+			m.err = fmt.Errorf("failed to get num tokens: %w", err)
+			return nil
+		}
 
-			m.missingFileTokens = numTokens
+		m.missingFileTokens = numTokens
+
+		if msg.MissingFileAutoChoice == shared.RespondMissingFileChoiceLoad {
+			_, cmd := m.respondMissingFile(shared.RespondMissingFileChoiceLoad)
+			return cmd
 		}
+
+		return nil
 	}
 
 	// log.Println("streamUI received message:", msg.Type)
@@ -286,21 +340,42 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage, deferUIUpdate bo
 		if msg.InitBuildOnly {
 			m.buildOnly = true
 		}
+		if msg.InitBuildPaused {
+			m.buildPaused = true
+		}
+		if msg.BuildEstimate != nil {
+			m.buildEstimate = msg.BuildEstimate
+			m.building = true
+			m.confirmingBuildCost = msg.InitBuildPaused && msg.BuildEstimate.NeedsConfirmation
+		}
 		if len(msg.InitReplies) > 0 {
 			m.reply = strings.Join(msg.InitReplies, "\n\n👇\n")
 		}
 		m.updateReplyDisplay()
 
-		checkMissingFileFn()
+		if cmd := checkMissingFileFn(); cmd != nil {
+			return m, cmd
+		}
+		checkPromptContinueFn()
 
 	case shared.StreamMessagePromptMissingFile:
-		checkMissingFileFn()
+		if cmd := checkMissingFileFn(); cmd != nil {
+			return m, cmd
+		}
+
+	case shared.StreamMessagePromptContinue:
+		checkPromptContinueFn()
+
+	case shared.StreamMessageReasoning:
+		m.reasoningSeconds = msg.ReasoningSeconds
 
 	case shared.StreamMessageReply:
 		if m.starting {
 			m.starting = false
 		}
 
+		m.reasoningSeconds = 0
+
 		if m.processing {
 			m.processing = false
 			if m.promptedMissingFile {
@@ -327,9 +402,19 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage, deferUIUpdate bo
 		wasFinished := m.finishedByPath[msg.BuildInfo.Path]
 		nowFinished := msg.BuildInfo.Finished
 
-		if msg.BuildInfo.Finished {
+		if msg.BuildInfo.Error != "" {
+			m.errByPath[msg.BuildInfo.Path] = msg.BuildInfo.Error
+			m.tokensByPath[msg.BuildInfo.Path] = 0
+			m.finishedByPath[msg.BuildInfo.Path] = msg.BuildInfo.Finished
+			delete(m.etaByPath, msg.BuildInfo.Path)
+		} else if msg.BuildInfo.Finished {
 			m.tokensByPath[msg.BuildInfo.Path] = 0
 			m.finishedByPath[msg.BuildInfo.Path] = true
+			delete(m.etaByPath, msg.BuildInfo.Path)
+			if m.previewPath == msg.BuildInfo.Path {
+				m.previewPath = ""
+				m.previewContent = ""
+			}
 		} else {
 			if wasFinished && !nowFinished {
 				// delay for a second before marking not finished again (so check flashes green prior to restarting build)
@@ -338,7 +423,10 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage, deferUIUpdate bo
 				m.finishedByPath[msg.BuildInfo.Path] = false
 			}
 
+			delete(m.errByPath, msg.BuildInfo.Path)
+
 			m.tokensByPath[msg.BuildInfo.Path] += msg.BuildInfo.NumTokens
+			m.etaByPath[msg.BuildInfo.Path] = time.Duration(msg.BuildInfo.EtaSeconds) * time.Second
 		}
 
 		if !deferUIUpdate {
@@ -351,6 +439,25 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage, deferUIUpdate bo
 		}
 		return m, tea.Batch(cmds...)
 
+	case shared.StreamMessageBuildPreview:
+		preview := msg.BuildPreview
+		if m.finishedByPath[preview.Path] {
+			break
+		}
+
+		// show the part of the file that changed since the last preview
+		var prev string
+		if m.previewPath == preview.Path {
+			prev = m.previewContent
+		}
+		m.previewLine = firstChangedLine(prev, preview.Content)
+		m.previewPath = preview.Path
+		m.previewContent = preview.Content
+
+		if !deferUIUpdate {
+			m.updateViewportDimensions()
+		}
+
 	case shared.StreamMessageDescribing:
 		m.processing = true
 
@@ -371,6 +478,30 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage, deferUIUpdate bo
 		m.finished = true
 		return m, tea.Quit
 
+	case shared.StreamMessageBuildPaused:
+		m.buildPaused = true
+		if !deferUIUpdate {
+			m.updateViewportDimensions()
+		}
+
+	case shared.StreamMessageBuildEstimate:
+		m.buildEstimate = msg.BuildEstimate
+		m.building = true
+		if msg.BuildEstimate.NeedsConfirmation {
+			m.confirmingBuildCost = true
+			m.buildPaused = true
+		}
+		if !deferUIUpdate {
+			m.updateViewportDimensions()
+		}
+
+	case shared.StreamMessageBuildResumed:
+		m.buildPaused = false
+		m.confirmingBuildCost = false
+		if !deferUIUpdate {
+			m.updateViewportDimensions()
+		}
+
 	case shared.StreamMessageAborted:
 		m.stopped = true
 		return m, tea.Quit
@@ -386,6 +517,19 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage, deferUIUpdate bo
 	return m, nil
 }
 
+func firstChangedLine(prev, updated string) int {
+	prevLines := strings.Split(prev, "\n")
+	updatedLines := strings.Split(updated, "\n")
+
+	for i, line := range updatedLines {
+		if i >= len(prevLines) || prevLines[i] != line {
+			return i
+		}
+	}
+
+	return 0
+}
+
 type delayFileRestartMsg struct {
 	path string
 }
@@ -453,6 +597,10 @@ func (m *streamUIModel) selectedMissingFileOpt() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	return m.respondMissingFile(choice)
+}
+
+func (m *streamUIModel) respondMissingFile(choice shared.RespondMissingFileChoice) (tea.Model, tea.Cmd) {
 	apiErr := api.Client.RespondMissingFile(lib.CurrentPlanId, lib.CurrentBranch, shared.RespondMissingFileRequest{
 		Choice:   choice,
 		FilePath: m.missingFilePath,
@@ -481,3 +629,42 @@ func (m *streamUIModel) selectedMissingFileOpt() (tea.Model, tea.Cmd) {
 
 	return m, m.spinner.Tick
 }
+
+func (m *streamUIModel) confirmBuildCost() (tea.Model, tea.Cmd) {
+	apiErr := api.Client.ResumeBuild(lib.CurrentPlanId, lib.CurrentBranch)
+
+	if apiErr != nil {
+		log.Println("resume build api error:", apiErr)
+		m.apiErr = apiErr
+		return m, nil
+	}
+
+	m.confirmingBuildCost = false
+	m.buildPaused = false
+	m.updateViewportDimensions()
+
+	return m, m.buildSpinner.Tick
+}
+
+func (m *streamUIModel) respondContinue(shouldContinue bool) (tea.Model, tea.Cmd) {
+	apiErr := api.Client.RespondContinue(lib.CurrentPlanId, lib.CurrentBranch, shared.RespondContinueRequest{
+		Continue: shouldContinue,
+	})
+
+	if apiErr != nil {
+		log.Println("continue prompt api error:", apiErr)
+		m.apiErr = apiErr
+		return m, nil
+	}
+
+	m.promptingContinue = false
+	m.continueNextTask = ""
+	m.updateViewportDimensions()
+
+	if !shouldContinue {
+		return m, nil
+	}
+
+	m.processing = true
+	return m, m.spinner.Tick
+}
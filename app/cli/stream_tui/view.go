@@ -9,6 +9,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
 )
 
 var borderColor = lipgloss.Color("#444")
@@ -29,6 +30,9 @@ func (m streamUIModel) View() string {
 	}
 	if m.building {
 		views = append(views, m.renderBuild())
+		if preview := m.renderPreview(); preview != "" {
+			views = append(views, preview)
+		}
 	}
 	views = append(views, m.renderHelp())
 
@@ -42,15 +46,63 @@ func (m streamUIModel) renderMainView() string {
 func (m streamUIModel) renderHelp() string {
 	style := lipgloss.NewStyle().Width(m.width).Foreground(lipgloss.Color(helpTextColor)).BorderStyle(lipgloss.NormalBorder()).BorderTop(true).BorderForeground(lipgloss.Color(borderColor))
 
+	if m.promptingContinue {
+		prompt := color.New(color.Bold, term.ColorHiYellow).Sprint(" ⏭  Continue to the next step?")
+		if m.continueNextTask != "" {
+			prompt += " " + m.continueNextTask
+		}
+		return style.Render(prompt + "\n (enter) continue • (n) stop here • (s)top • (b)ackground")
+	}
+
+	if m.confirmingBuildCost && m.buildEstimate != nil {
+		prompt := color.New(color.Bold, term.ColorHiYellow).Sprintf(" 💸 This build could cost up to %s, which is more than the trust policy's %s limit. Build anyway?", shared.FormatCost(m.buildEstimate.CostMax), shared.FormatCost(m.buildEstimate.ConfirmAbove))
+		return style.Render(prompt + "\n (enter) build • (n) stop • (b)ackground")
+	}
+
 	if m.buildOnly {
-		return style.Render(" (s)top • (b)ackground")
+		return style.Render(" (s)top • (b)ackground • (p)review")
 	} else {
-		return style.Render(" (s)top • (b)ackground • (j/k) scroll • (d/u) page • (g/G) start/end")
+		return style.Render(" (s)top • (b)ackground • (p)review • (j/k) scroll • (d/u) page • (g/G) start/end")
 	}
 }
 
+const previewLines = 8
+
+func (m streamUIModel) renderPreview() string {
+	if m.hidePreview || m.previewContent == "" {
+		return ""
+	}
+
+	lines := strings.Split(m.previewContent, "\n")
+
+	// start a couple of lines above the latest change for context
+	start := max(0, min(m.previewLine-2, len(lines)-previewLines))
+	end := min(len(lines), start+previewLines)
+
+	numWidth := len(fmt.Sprint(end))
+	var rows []string
+	for i := start; i < end; i++ {
+		line := strings.ReplaceAll(lines[i], "\t", "  ")
+		maxWidth := m.width - numWidth - 2
+		if lipgloss.Width(line) > maxWidth {
+			runes := []rune(line)
+			line = string(runes[:max(0, min(len(runes), maxWidth-1))]) + "⋯"
+		}
+		rows = append(rows, color.New(color.FgHiBlack).Sprintf("%*d ", numWidth, i+1)+line)
+	}
+
+	head := color.New(color.BgBlue, color.FgHiWhite, color.Bold).Sprint(" 👀 ") + color.New(color.BgBlue, color.FgHiWhite).Sprintf("Preview %s ", m.previewPath)
+
+	style := lipgloss.NewStyle().Width(m.width).BorderStyle(lipgloss.NormalBorder()).BorderTop(true).BorderForeground(lipgloss.Color(borderColor))
+
+	return style.Render(head + "\n" + strings.Join(rows, "\n"))
+}
+
 func (m streamUIModel) renderProcessing() string {
 	if m.starting || m.processing {
+		if m.reasoningSeconds > 0 {
+			return "\n " + m.spinner.View() + " " + color.New(term.ColorHiMagenta).Sprintf("🧠 Reasoning… %ds", m.reasoningSeconds)
+		}
 		return "\n " + m.spinner.View()
 	} else {
 		return ""
@@ -104,6 +156,10 @@ func (m streamUIModel) getRows(static bool) []string {
 	lbl := "Building plan "
 	bgColor := color.BgGreen
 	built := false
+	if m.buildPaused {
+		lbl = "Build paused "
+		bgColor = color.BgYellow
+	}
 	if static {
 		// log.Printf("m.finished: %v, m.stopped: %v, len(m.finishedByPath): %d, len(m.tokensByPath): %d", m.finished, len(m.finishedByPath), len(m.tokensByPath))
 
@@ -118,6 +174,10 @@ func (m streamUIModel) getRows(static bool) []string {
 
 	head := color.New(bgColor, color.FgHiWhite, color.Bold).Sprint(" 🏗  ") + color.New(bgColor, color.FgHiWhite).Sprint(lbl)
 
+	if m.buildEstimate != nil && !static {
+		head += color.New(color.FgHiBlack).Sprintf(" estimated %s • %s", m.buildEstimate.TokensLabel(), m.buildEstimate.CostLabel())
+	}
+
 	var rows [][]string
 	rows = append(rows, []string{})
 	lineWidth := 0
@@ -129,10 +189,15 @@ func (m streamUIModel) getRows(static bool) []string {
 		finished := m.finished || m.finishedByPath[filePath] || built
 		block := fmt.Sprintf("📄 %s", filePath)
 
-		if finished {
+		if m.errByPath[filePath] != "" {
+			block += " ❌"
+		} else if finished {
 			block += " ✅"
 		} else if tokens > 0 {
 			block += fmt.Sprintf(" %d 🪙", tokens)
+			if eta := m.etaByPath[filePath]; eta > 0 {
+				block += fmt.Sprintf(" ~%s remaining", eta)
+			}
 		} else {
 			block += " " + m.buildSpinner.View()
 		}
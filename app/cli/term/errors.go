@@ -10,7 +10,7 @@ import (
 )
 
 func OutputNoOpenAIApiKeyMsgAndExit() {
-	fmt.Fprintln(os.Stderr, color.New(color.Bold, ColorHiRed).Sprintln("\n🚨 OPENAI_API_KEY environment variable is not set.")+color.New().Sprintln("\nSet it with:\n\nexport OPENAI_API_KEY=your-api-key\n\nThen try again.\n\n👉 If you don't have an OpenAI account, sign up here → https://platform.openai.com/signup\n\n🔑 Generate an api key here → https://platform.openai.com/api-keys"))
+	fmt.Fprintln(os.Stderr, color.New(color.Bold, ColorHiRed).Sprintln("\n🚨 OPENAI_API_KEY environment variable is not set.")+color.New().Sprintln("\nSet it with:\n\nexport OPENAI_API_KEY=your-api-key\n\nOr store it securely with:\n\nplandex auth set-key OPENAI_API_KEY\n\nThen try again.\n\n👉 If you don't have an OpenAI account, sign up here → https://platform.openai.com/signup\n\n🔑 Generate an api key here → https://platform.openai.com/api-keys"))
 	os.Exit(1)
 }
 
@@ -88,3 +88,87 @@ func OutputNoCurrentPlanErrorAndExit() {
 	PrintCmds("", "new", "cd")
 	os.Exit(1)
 }
+
+type apiErrorGuidance struct {
+	msg  string
+	cmds []string
+}
+
+var apiErrorGuidanceByType = map[shared.ApiErrorType]apiErrorGuidance{
+	shared.ApiErrorTypeProviderRateLimited: {
+		msg:  "The model provider is rate limiting requests. Wait a minute and then continue, or switch to models with higher rate limits.",
+		cmds: []string{"continue", "set-model"},
+	},
+	shared.ApiErrorTypeContextTooLong: {
+		msg:  "The plan's context and conversation are too long for the model. Remove context you don't need, or switch to a model with a larger context window.",
+		cmds: []string{"ls", "rm", "set-model"},
+	},
+	shared.ApiErrorTypeInvalidToolOutput: {
+		msg:  "The model's response couldn't be turned into changes. Try building again, or switch to a stronger builder model.",
+		cmds: []string{"build", "set-model"},
+	},
+	shared.ApiErrorTypeApplyConflict: {
+		msg:  "The changes couldn't be applied to the current state of the file. Review the pending changes, then reject the file's changes and build it again.",
+		cmds: []string{"diff", "reject", "build"},
+	},
+	shared.ApiErrorTypeBudgetExceeded: {
+		msg:  "Your model provider account is out of quota or credits. Add credits or raise your spending limit with the provider, then continue.",
+		cmds: []string{"continue"},
+	},
+}
+
+// OutputApiErrorAndExit outputs an error from the server, followed by what to do about it for error types that have guidance
+func OutputApiErrorAndExit(prefix string, apiErr *shared.ApiError) {
+	if apiErr.PromptTooLongError != nil {
+		outputPromptTooLongAndExit(apiErr.PromptTooLongError)
+	}
+
+	if apiErr.FileTooLargeError != nil {
+		outputFileTooLargeAndExit(apiErr.FileTooLargeError)
+	}
+
+	guidance, ok := apiErrorGuidanceByType[apiErr.Type]
+	if !ok {
+		OutputErrorAndExit("%s", prefix+apiErr.Msg)
+	}
+
+	StopSpinner()
+	fmt.Fprintln(os.Stderr, color.New(ColorHiRed, color.Bold).Sprint("🚨 "+shared.Capitalize(prefix+apiErr.Msg)))
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "👉 "+guidance.msg)
+	fmt.Fprintln(os.Stderr)
+	PrintCmds("", guidance.cmds...)
+	os.Exit(1)
+}
+
+func outputPromptTooLongAndExit(report *shared.PromptTooLongError) {
+	StopSpinner()
+
+	fmt.Fprintln(os.Stderr, color.New(ColorHiRed, color.Bold).Sprintf("🚨 The prompt to build %s is %s tokens, over the %d token limit for %s", report.Path, shared.FormatTokens(report.TotalTokens, report.Estimated), report.MaxTokens, report.Model))
+	fmt.Fprintln(os.Stderr)
+
+	for _, part := range report.Parts {
+		fmt.Fprintf(os.Stderr, "  %-22s %s 🪙\n", shared.Capitalize(part.Name), shared.FormatTokens(part.Tokens, report.Estimated))
+	}
+
+	if report.Estimated {
+		fmt.Fprintf(os.Stderr, "\n  ~ Estimated for the %s tokenizer, since its vocabulary isn't available\n", report.Model)
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "👉 The file and its changes are too large for the builder model. Switch to a builder model with a larger context window, or ask for the file to be split into smaller files.")
+	fmt.Fprintln(os.Stderr)
+	PrintCmds("", "set-model", "tell")
+	os.Exit(1)
+}
+
+func outputFileTooLargeAndExit(report *shared.FileTooLargeError) {
+	StopSpinner()
+
+	fmt.Fprintln(os.Stderr, color.New(ColorHiRed, color.Bold).Sprintf("🚨 %s is %d tokens, over the %d token limit for files the builder will update", report.Path, report.Tokens, report.MaxTokens))
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "👉 Ask for the file to be split into smaller files, or raise the limit with 'plandex build-file-limit' if your builder model has room for it. To continue without it, reject the file's changes.")
+	fmt.Fprintln(os.Stderr)
+	PrintCmds("", "tell", "build-file-limit", "reject")
+	os.Exit(1)
+}
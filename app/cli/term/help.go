@@ -10,58 +10,179 @@ import (
 )
 
 var CmdDesc = map[string][2]string{
-	"new":     {"", "start a new plan"},
-	"rename":  {"", "rename the current plan"},
-	"current": {"cu", "show current plan"},
-	"cd":      {"", "set current plan by name or index"},
-	"load":    {"l", "load files, dirs, urls, notes, images, or piped data into context"},
-	"tell":    {"t", "describe a task, ask a question, or chat"},
-	"changes": {"ch", "review pending changes in a TUI"},
-	"diff":    {"", "review pending changes in 'git diff' format"},
-	"summary": {"", "show the latest summary of the current plan"},
+	"new":           {"", "start a new plan"},
+	"rename":        {"", "rename the current plan"},
+	"rename --auto": {"", "generate a new name for the current plan from its conversation"},
+	"current":       {"cu", "show current plan"},
+	"cd":            {"", "set current plan by name or index"},
+	"load":          {"l", "load files, dirs, urls, notes, images, or piped data into context"},
+	"tell":          {"t", "describe a task, ask a question, or chat"},
+	"changes":       {"ch", "review pending changes in a TUI"},
+	"diff":          {"", "review pending changes in 'git diff' format"},
+	"summary":       {"", "show the latest summary of the current plan"},
 	// "preview":     {"pv", "preview the plan in a branch"},
-	"apply":     {"ap", "apply pending changes to project files"},
-	"reject":    {"rj", "reject pending changes to one or more project files"},
-	"archive":   {"arc", "archive a plan"},
-	"unarchive": {"unarc", "unarchive a plan"},
-	"continue":  {"c", "continue the plan"},
+	"apply":      {"ap", "apply pending changes to project files"},
+	"reject":     {"rj", "reject pending changes to one or more project files"},
+	"commit-msg": {"", "write a commit message and changelog entry for pending changes"},
+	"impact":     {"", "write an impact report for pending changes"},
+	"explore":    {"", "send a prompt on several branches at once and compare the results"},
+	"compare":    {"", "compare pending changes across plan branches"},
+	"archive":    {"arc", "archive a plan"},
+	"unarchive":  {"unarc", "unarchive a plan"},
+	"continue":   {"c", "continue the plan"},
 	// "status":      {"s", "show status of the plan"},
 	"rewind":                    {"rw", "rewind to a previous state"},
 	"ls":                        {"", "list everything in context"},
 	"rm":                        {"", "remove context by index, range, name, or glob"},
 	"clear":                     {"", "remove all context"},
+	"workspaces":                {"ws", "list workspace roots the plan is scoped to"},
+	"workspaces add":            {"", "scope the plan to one or more directories"},
+	"workspaces rm":             {"", "remove workspace roots from the plan"},
 	"delete-plan":               {"dp", "delete plan by name or index"},
 	"delete-branch":             {"db", "delete a branch by name or index"},
 	"plans":                     {"pl", "list plans"},
+	"search":                    {"", "search plan names, prompts, replies, and changed files"},
 	"plans --archived":          {"", "list archived plans"},
+	"plans --shared":            {"", "list plans shared with you from any org"},
+	"cd --shared":               {"", "set current plan to a plan shared with you"},
+	"share":                     {"", "share the current plan with a user"},
+	"shares":                    {"", "list users the current plan is shared with"},
+	"unshare":                   {"", "stop sharing the current plan with a user"},
+	"plans --tag":               {"", "list plans with a tag; also filter by --status, --since, --until, --author, and --sort"},
+	"tag":                       {"", "add tags to the current plan"},
+	"untag":                     {"", "remove tags from the current plan"},
+	"tags":                      {"", "list tags used across plans"},
 	"update":                    {"u", "update outdated context"},
 	"log":                       {"", "show log of plan updates"},
+	"auto-continue":             {"", "show or set whether the plan continues to its next step on its own"},
+	"repl":                      {"", "start an interactive session with prompts and slash commands"},
+	"tell --editor":             {"", "write a prompt in your editor from a task/constraints/files template"},
+	"tell --draft":              {"", "send the plan's draft messages as one prompt"},
+	"draft":                     {"", "list the messages in the plan's draft prompt"},
+	"ask":                       {"", "ask a question about the plan's context without changing any files"},
+	"chat":                      {"", "chat about the project without starting a plan"},
+	"chat show":                 {"", "show the project's chat"},
+	"chat plan":                 {"", "turn the chat into a new plan with its messages and files as context"},
+	"chat clear":                {"", "clear the project's chat"},
+	"draft add":                 {"", "add a message to the draft, from the editor, a file, or the clipboard"},
+	"draft show":                {"", "show the full prompt the draft will be sent as"},
+	"draft rm":                  {"", "remove a message from the draft"},
+	"draft clear":               {"", "remove all messages from the draft"},
+	"build-errors":              {"", "show or set what happens when a file fails to build"},
+	"build-strategy":            {"", "show or set whether builds are drafted by a cheaper model first"},
+	"build-file-limit":          {"", "show or set the size of the largest file the builder will update"},
+	"tests":                     {"", "show test scaffolding and the pending tests it wrote"},
+	"tests on":                  {"", "build the tests of each file a build changes"},
+	"tests off":                 {"", "stop building tests for changed files"},
+	"doc-sync":                  {"", "show doc sync and the pending doc updates it wrote"},
+	"doc-sync on":               {"", "update docs in context when a build changes a public API"},
+	"doc-sync off":              {"", "stop updating docs for API changes"},
+	"queue":                     {"", "list tells and loads queued while the server was unreachable"},
+	"queue sync":                {"", "send queued tells and loads now that the server is reachable"},
+	"queue rm":                  {"", "remove a queued tell or load"},
+	"queue clear":               {"", "remove all queued tells and loads on the current branch"},
+	"capture":                   {"", "show whether model requests are captured for builds"},
+	"capture on":                {"", "capture model requests and responses for builds"},
+	"capture off":               {"", "stop capturing model requests and responses"},
+	"capture ls":                {"", "list captured build requests"},
+	"capture dump":              {"", "output captured build requests and responses"},
+	"capture clear":             {"", "delete the plan's captures"},
 	"convo":                     {"", "show plan conversation"},
 	"convo 1":                   {"", "show a specific message in the conversation"},
 	"convo 2-5":                 {"", "show a range of messages in the conversation"},
 	"convo --plain":             {"", "show conversation in plain text"},
+	"citations":                 {"", "show the code cited in the latest reply"},
+	"edit-prompt":               {"", "edit an earlier prompt and mark everything after it stale"},
+	"rerun":                     {"", "replay the plan from an edited prompt"},
+	"compare --diffs":           {"", "compare branches and show each branch's diffs"},
+	"impact --plain":            {"", "output the impact report as markdown for a PR description"},
+	"impact --last":             {"", "show the last impact report"},
 	"branches":                  {"br", "list plan branches"},
 	"checkout":                  {"co", "checkout or create a branch"},
+	"checkout --from-message":   {"co -m", "create a branch rooted at an earlier conversation message"},
 	"build":                     {"b", "build any pending changes"},
 	"models":                    {"", "show current plan model settings"},
 	"models default":            {"", "show org-wide default model settings for new plans"},
+	"models reset":              {"", "reset current plan model settings to org-wide defaults"},
 	"models available":          {"", "show all available models"},
 	"models available --custom": {"", "show available custom models only"},
 	"models delete":             {"", "delete a custom model"},
 	"models add":                {"", "add a custom model"},
+	"models check":              {"", "check what a self-hosted or OpenAI-compatible endpoint supports"},
 	"model-packs":               {"", "show all available model packs"},
 	"model-packs create":        {"", "create a new custom model pack"},
 	"model-packs delete":        {"", "delete a custom model pack"},
+	"model-packs export":        {"", "export a model pack as JSON"},
+	"model-packs import":        {"", "import a model pack from a JSON file"},
 	"model-packs --custom":      {"", "show custom model packs only"},
+	"bench":                     {"", "compare builder models on the plan's latest changes"},
+	"bench history":             {"", "show your org's benchmark results by model"},
 	"set-model":                 {"", "update current plan model settings"},
 	"set-model default":         {"", "update org-wide default model settings for new plans"},
 	"ps":                        {"", "list active and recently finished plan streams"},
+	"builds":                    {"", "list the current branch's queued, running, and finished file builds"},
+	"provenance":                {"", "show which model and prompt produced each file change"},
 	"stop":                      {"", "stop an active plan stream"},
+	"steer":                     {"", "send a short note to an active plan's next reply without stopping it"},
+	"pause":                     {"", "pause an active plan's build after the current model calls finish"},
+	"resume":                    {"", "resume a paused plan build"},
 	"connect":                   {"conn", "connect to an active plan stream"},
 	"sign-in":                   {"", "sign in, accept an invite, or create an account"},
+	"orgs":                      {"", "list the orgs of all signed-in accounts"},
+	"orgs switch":               {"", "switch your default org"},
+	"orgs bind":                 {"", "bind the current project to an org and server"},
+	"orgs unbind":               {"", "remove the current project's org binding"},
 	"invite":                    {"", "invite a user to join your org"},
+	"invites":                   {"", "list your org's pending invites"},
+	"invites resend":            {"", "resend a pending invite with a new token"},
+	"invites accept":            {"", "join an org with an invite token"},
 	"revoke":                    {"", "revoke an invite or remove a user from your org"},
 	"users":                     {"", "list users and pending invites in your org"},
+	"stats":                     {"", "show builds, failure rates, and model token usage across the org"},
+	"stats --days":              {"", "show stats for a number of days, e.g. 'stats --days 7'"},
+	"trial":                     {"", "show your org's trial policy for guests"},
+	"trial set":                 {"", "update your org's trial policy"},
+	"trial join":                {"", "start a trial in an org on a self-hosted server"},
+	"trial upgrade":             {"", "sign up and keep your trial plans"},
+	"auth":                      {"", "show where auth tokens and provider keys are stored"},
+	"auth rotate":               {"", "replace your auth token and re-encrypt stored secrets"},
+	"auth set-key":              {"", "store a model provider key securely"},
+	"auth rm-key":               {"", "remove a stored model provider key"},
+	"retention":                 {"", "show your org's plan retention policy"},
+	"retention set":             {"", "update your org's plan retention policy"},
+	"digest":                    {"", "show how often you get an email digest of plan activity"},
+	"digest set":                {"", "set how often you get an email digest, e.g. 'digest set weekly'"},
+	"cache":                     {"", "show your org's model response cache"},
+	"cache set":                 {"", "update your org's response cache policy"},
+	"cache clear":               {"", "delete your org's cached responses"},
+	"reject --tests":            {"", "reject pending tests written by test scaffolding"},
+	"reject --docs":             {"", "reject pending doc updates written by doc sync"},
+	"scan":                      {"", "show pending changes flagged by code scanning"},
+	"scan policy":               {"", "show your org's code scan policy"},
+	"scan set":                  {"", "update your org's code scan policy"},
+	"bundles":                   {"", "list your org's context bundles"},
+	"bundles set":               {"", "create or update a context bundle that's attached to new plans"},
+	"bundles rm":                {"", "delete a context bundle"},
+	"bundles sync":              {"", "update the plan's context bundles to their latest versions"},
+	"knowledge":                 {"", "list your org's knowledge base"},
+	"knowledge add":             {"", "add a note or ADR to the knowledge base"},
+	"knowledge save":            {"", "save the plan's summary to the knowledge base"},
+	"knowledge show":            {"", "show a knowledge base entry"},
+	"knowledge rm":              {"", "remove a knowledge base entry"},
+	"knowledge search":          {"", "search the knowledge base"},
+	"preferences":               {"", "show what Plandex has learned from your rejected and edited changes"},
+	"preferences clear":         {"", "forget your learned preferences, or your org's with --org"},
+	"trust":                     {"", "show which actions Plandex can take without confirmation"},
+	"trust set":                 {"", "set an action to allow, confirm, or deny, e.g. 'trust set run-commands confirm'"},
+	"trust dirs":                {"", "set the dirs where new files can always be created"},
+	"trust cost":                {"", "confirm builds estimated to cost more than an amount"},
+	"trust reset":               {"", "remove the project's trust policy so the org's applies"},
+	"credentials":               {"", "list your org's model provider credentials"},
+	"credentials set":           {"", "set an org model provider credential"},
+	"credentials rm":            {"", "remove an org model provider credential"},
+	"config":                    {"", "show CLI settings for this machine"},
+	"config set":                {"", "update a CLI setting, e.g. 'config set notifications unfocused'"},
+	"plugins":                   {"", "list plandex-<name> executables on your PATH that run as 'plandex <name>'"},
 }
 
 func PrintCmds(prefix string, cmds ...string) {
@@ -139,39 +260,43 @@ func PrintCustomHelp(all bool) {
 		fmt.Fprintln(builder)
 
 		color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Plans ")
-		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "new", "plans", "cd", "current", "delete-plan", "rename", "archive", "plans --archived", "unarchive")
+		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "new", "plans", "cd", "current", "delete-plan", "rename", "rename --auto", "archive", "plans --archived", "unarchive", "search", "tag", "untag", "tags", "plans --tag", "share", "shares", "unshare", "plans --shared", "knowledge save")
 		fmt.Fprintln(builder)
 
 		color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Changes ")
-		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "changes", "diff", "apply", "reject")
+		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "changes", "diff", "apply", "reject", "scan", "commit-msg", "impact")
 		fmt.Fprintln(builder)
 
 		color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Context ")
-		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "load", "ls", "rm", "update", "clear")
+		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "load", "ls", "rm", "update", "clear", "workspaces", "bundles sync")
 		fmt.Fprintln(builder)
 
 		color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Branches ")
-		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "branches", "checkout", "delete-branch")
+		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "branches", "checkout", "checkout --from-message", "delete-branch", "explore", "compare")
 		fmt.Fprintln(builder)
 
 		color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " History ")
-		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "log", "rewind", "convo", "convo 1", "convo 2-5", "convo --plain", "summary")
+		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "log", "rewind", "convo", "convo 1", "convo 2-5", "convo --plain", "citations", "edit-prompt", "rerun", "summary")
 		fmt.Fprintln(builder)
 
 		color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Control ")
-		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "tell", "continue", "build")
+		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "tell", "repl", "tell --editor", "draft", "draft add", "tell --draft", "ask", "chat", "chat plan", "continue", "build", "auto-continue", "build-errors", "build-strategy", "build-file-limit", "tests", "doc-sync", "queue", "queue sync")
 		fmt.Fprintln(builder)
 
 		color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Streams ")
-		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "ps", "connect", "stop")
+		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "ps", "builds", "provenance", "connect", "steer", "stop", "pause", "resume", "capture")
 		fmt.Fprintln(builder)
 
 		color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " AI Models ")
-		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "models", "models default", "models available", "set-model", "set-model default", "models available --custom", "models add", "models delete", "model-packs", "model-packs --custom", "model-packs create", "model-packs delete")
+		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "models", "models default", "models available", "set-model", "set-model default", "models reset", "models available --custom", "models add", "models check", "models delete", "model-packs", "model-packs --custom", "model-packs create", "model-packs delete", "model-packs export", "model-packs import", "bench", "bench history")
 		fmt.Fprintln(builder)
 
 		color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Accounts ")
-		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "sign-in", "invite", "revoke", "users")
+		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "sign-in", "orgs", "orgs switch", "orgs bind", "orgs unbind", "invite", "invites", "invites resend", "invites accept", "revoke", "users", "trial", "trial set", "trial join", "trial upgrade", "auth", "auth rotate", "auth set-key", "auth rm-key", "retention", "retention set", "digest", "digest set", "trust", "trust set", "trust dirs", "trust cost", "trust reset", "cache", "cache set", "cache clear", "scan policy", "scan set", "bundles", "bundles set", "bundles rm", "knowledge", "knowledge add", "knowledge rm", "knowledge search", "preferences", "preferences clear", "credentials", "stats")
+		fmt.Fprintln(builder)
+
+		color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " CLI Settings ")
+		printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "config", "config set", "plugins")
 		fmt.Fprintln(builder)
 	} else {
 
@@ -31,6 +31,18 @@ func StartSpinner(msg string) {
 	active = true
 }
 
+// UpdateSpinnerMsg changes the message of an active spinner without restarting it
+func UpdateSpinnerMsg(msg string) {
+	if !active {
+		return
+	}
+
+	s.Lock()
+	s.Prefix = msg + " "
+	s.Unlock()
+	lastMessage = msg
+}
+
 func StopSpinner() {
 	elapsed := time.Since(startedAt)
 
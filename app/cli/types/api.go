@@ -13,13 +13,16 @@ type OnStreamPlan func(params OnStreamPlanParams)
 
 type ApiClient interface {
 	StartTrial() (*shared.StartTrialResponse, *shared.ApiError)
+	StartGuestTrial(req shared.StartGuestTrialRequest, host string) (*shared.StartTrialResponse, *shared.ApiError)
 	ConvertTrial(req shared.ConvertTrialRequest) (*shared.SessionResponse, *shared.ApiError)
 
 	CreateEmailVerification(email, customHost, userId string) (*shared.CreateEmailVerificationResponse, *shared.ApiError)
 
 	CreateAccount(req shared.CreateAccountRequest, customHost string) (*shared.SessionResponse, *shared.ApiError)
 	SignIn(req shared.SignInRequest, customHost string) (*shared.SessionResponse, *shared.ApiError)
+	CheckHealth() *shared.ApiError
 	SignOut() *shared.ApiError
+	RotateAuthToken() (*shared.RotateAuthTokenResponse, *shared.ApiError)
 
 	GetOrgSession() *shared.ApiError
 	ListOrgs() ([]*shared.Org, *shared.ApiError)
@@ -30,7 +33,9 @@ type ApiClient interface {
 
 	ListOrgRoles() ([]*shared.OrgRole, *shared.ApiError)
 
-	InviteUser(req shared.InviteRequest) *shared.ApiError
+	InviteUser(req shared.InviteRequest) (*shared.InviteResponse, *shared.ApiError)
+	ResendInvite(inviteId string, req shared.ResendInviteRequest) (*shared.InviteResponse, *shared.ApiError)
+	AcceptInvite(req shared.AcceptInviteRequest) (*shared.AcceptInviteResponse, *shared.ApiError)
 	ListPendingInvites() ([]*shared.Invite, *shared.ApiError)
 	ListAcceptedInvites() ([]*shared.Invite, *shared.ApiError)
 	ListAllInvites() ([]*shared.Invite, *shared.ApiError)
@@ -42,6 +47,8 @@ type ApiClient interface {
 	RenameProject(projectId string, req shared.RenameProjectRequest) *shared.ApiError
 
 	ListPlans(projectIds []string) ([]*shared.Plan, *shared.ApiError)
+	FilterPlans(projectIds []string, filter shared.ListPlansFilter) ([]*shared.Plan, *shared.ApiError)
+	SearchPlans(query, projectId string, limit int) (*shared.SearchResponse, *shared.ApiError)
 	ListArchivedPlans(projectIds []string) ([]*shared.Plan, *shared.ApiError)
 	ListPlansRunning(projectIds []string, includeRecent bool) (*shared.ListPlansRunningResponse, *shared.ApiError)
 
@@ -53,22 +60,41 @@ type ApiClient interface {
 	TellPlan(planId, branch string, req shared.TellPlanRequest, onStreamPlan OnStreamPlan) *shared.ApiError
 	BuildPlan(planId, branch string, req shared.BuildPlanRequest, onStreamPlan OnStreamPlan) *shared.ApiError
 	RespondMissingFile(planId, branch string, req shared.RespondMissingFileRequest) *shared.ApiError
+	RespondContinue(planId, branch string, req shared.RespondContinueRequest) *shared.ApiError
+	SteerPlan(planId, branch string, req shared.SteerPlanRequest) *shared.ApiError
 
 	DeletePlan(planId string) *shared.ApiError
 	DeleteAllPlans(projectId string) *shared.ApiError
 	ConnectPlan(planId, branch string, onStreamPlan OnStreamPlan) *shared.ApiError
 	StopPlan(planId, branch string) *shared.ApiError
+	PauseBuild(planId, branch string) *shared.ApiError
+	ResumeBuild(planId, branch string) *shared.ApiError
 
 	ArchivePlan(planId string) *shared.ApiError
 	UnarchivePlan(planId string) *shared.ApiError
 	RenamePlan(planId string, name string) *shared.ApiError
+	AutoRenamePlan(planId, branch string, req shared.AutoRenamePlanRequest) (*shared.AutoRenamePlanResponse, *shared.ApiError)
+	UpdatePlanTags(planId string, req shared.UpdatePlanTagsRequest) ([]string, *shared.ApiError)
+	ListPlanTags() ([]*shared.PlanTagCount, *shared.ApiError)
+	ListPlanShares(planId string) ([]*shared.PlanShare, *shared.ApiError)
+	SharePlan(planId string, req shared.SharePlanRequest) (*shared.PlanShare, *shared.ApiError)
+	UnsharePlan(planId, userId string) *shared.ApiError
+	ListSharedPlans() ([]*shared.Plan, *shared.ApiError)
+	GetOrgStats(days int, userId string) (*shared.OrgStatsResponse, *shared.ApiError)
 
 	GetCurrentPlanState(planId, branch string) (*shared.CurrentPlanState, *shared.ApiError)
 	ApplyPlan(planId, branch string, req shared.ApplyPlanRequest) (string, *shared.ApiError)
+	GetCommitMsg(planId, branch string, req shared.CommitMsgRequest) (*shared.CommitMsgResponse, *shared.ApiError)
+	CreateImpactReport(planId, branch string, req shared.ImpactReportRequest) (*shared.ImpactReport, *shared.ApiError)
+	GetImpactReport(planId, branch string) (*shared.ImpactReport, *shared.ApiError)
+	Bench(planId, branch string, req shared.BenchRequest) (*shared.BenchResponse, *shared.ApiError)
+	ListBenchResults(limit int) ([]*shared.BenchResult, *shared.ApiError)
+	RecordApplyHook(planId, branch string, req shared.RecordApplyHookRequest) *shared.ApiError
 	RejectAllChanges(planId, branch string) *shared.ApiError
 	RejectFile(planId, branch, filePath string) *shared.ApiError
 	RejectFiles(planId, branch string, paths []string) *shared.ApiError
-	GetPlanDiffs(planId, branch string) (string, *shared.ApiError)
+	GetPlanDiffs(planId, branch string, plain bool) (string, *shared.ApiError)
+	GetPlanProvenance(planId, branch string) (*shared.GetPlanProvenanceResponse, *shared.ApiError)
 
 	LoadContext(planId, branch string, req shared.LoadContextRequest) (*shared.LoadContextResponse, *shared.ApiError)
 	UpdateContext(planId, branch string, req shared.UpdateContextRequest) (*shared.UpdateContextResponse, *shared.ApiError)
@@ -78,7 +104,15 @@ type ApiClient interface {
 	ListConvo(planId, branch string) ([]*shared.ConvoMessage, *shared.ApiError)
 	GetPlanStatus(planId, branch string) (string, *shared.ApiError)
 	ListLogs(planId, branch string) (*shared.LogResponse, *shared.ApiError)
+
+	ListBuilds(planId, branch string) ([]*shared.FileBuild, *shared.ApiError)
+	ListBuildCaptures(planId, path string) ([]*shared.BuildCapture, *shared.ApiError)
+	GetBuildCapture(planId, captureId string) (*shared.BuildCapture, *shared.ApiError)
+	DeleteBuildCaptures(planId string) *shared.ApiError
+
 	RewindPlan(planId, branch string, req shared.RewindPlanRequest) (*shared.RewindPlanResponse, *shared.ApiError)
+	EditMessage(planId, branch string, req shared.EditMessageRequest) (*shared.EditMessageResponse, *shared.ApiError)
+	RerunPlan(planId, branch string) (*shared.RerunPlanResponse, *shared.ApiError)
 
 	ListBranches(planId string) ([]*shared.Branch, *shared.ApiError)
 	DeleteBranch(planId, branch string) *shared.ApiError
@@ -86,15 +120,58 @@ type ApiClient interface {
 
 	GetSettings(planId, branch string) (*shared.PlanSettings, *shared.ApiError)
 	UpdateSettings(planId, branch string, req shared.UpdateSettingsRequest) (*shared.UpdateSettingsResponse, *shared.ApiError)
+	ValidateSettings(planId, branch string, req shared.ValidateSettingsRequest) (*shared.ValidateSettingsResponse, *shared.ApiError)
 
 	GetOrgDefaultSettings() (*shared.PlanSettings, *shared.ApiError)
 	UpdateOrgDefaultSettings(req shared.UpdateSettingsRequest) (*shared.UpdateSettingsResponse, *shared.ApiError)
+	ValidateOrgDefaultSettings(req shared.ValidateSettingsRequest) (*shared.ValidateSettingsResponse, *shared.ApiError)
+
+	GetOrgRetentionPolicy() (*shared.OrgRetentionPolicy, *shared.ApiError)
+	UpdateOrgRetentionPolicy(req shared.UpdateOrgRetentionPolicyRequest) *shared.ApiError
+	GetUserDigestSettings() (*shared.GetUserDigestSettingsResponse, *shared.ApiError)
+	UpdateUserDigestSettings(req shared.UpdateUserDigestSettingsRequest) *shared.ApiError
+	GetOrgTrialPolicy() (*shared.OrgTrialPolicy, *shared.ApiError)
+	UpdateOrgTrialPolicy(req shared.UpdateOrgTrialPolicyRequest) (*shared.UpdateOrgTrialPolicyResponse, *shared.ApiError)
+	GetOrgTrustPolicy() (*shared.TrustPolicy, *shared.ApiError)
+	UpdateOrgTrustPolicy(req shared.UpdateTrustPolicyRequest) *shared.ApiError
+	GetProjectTrustPolicy(projectId string) (*shared.EffectiveTrustPolicy, *shared.ApiError)
+	UpdateProjectTrustPolicy(projectId string, req shared.UpdateTrustPolicyRequest) *shared.ApiError
+	DeleteProjectTrustPolicy(projectId string) *shared.ApiError
+	GetOrgResponseCache() (*shared.GetOrgResponseCacheResponse, *shared.ApiError)
+	UpdateOrgResponseCachePolicy(req shared.UpdateOrgResponseCachePolicyRequest) *shared.ApiError
+	ClearOrgResponseCache() *shared.ApiError
+	GetOrgCodeScanPolicy() (*shared.GetOrgCodeScanPolicyResponse, *shared.ApiError)
+	UpdateOrgCodeScanPolicy(req shared.UpdateOrgCodeScanPolicyRequest) *shared.ApiError
+
+	ListOrgContextBundles() ([]*shared.OrgContextBundle, *shared.ApiError)
+	UpdateOrgContextBundle(req shared.UpdateOrgContextBundleRequest) (*shared.OrgContextBundle, *shared.ApiError)
+	DeleteOrgContextBundle(name string) *shared.ApiError
+	SyncContextBundles(planId, branch string, req shared.SyncContextBundlesRequest) (*shared.SyncContextBundlesResponse, *shared.ApiError)
+
+	ListKnowledgeEntries() ([]*shared.KnowledgeEntry, *shared.ApiError)
+	GetKnowledgeEntry(id string) (*shared.KnowledgeEntry, *shared.ApiError)
+	CreateKnowledgeEntry(req shared.CreateKnowledgeEntryRequest) (*shared.KnowledgeEntry, *shared.ApiError)
+	SavePlanKnowledge(planId, branch string, req shared.SavePlanKnowledgeRequest) (*shared.KnowledgeEntry, *shared.ApiError)
+	DeleteKnowledgeEntry(id string) *shared.ApiError
+	SearchKnowledge(req shared.SearchKnowledgeRequest) (*shared.SearchKnowledgeResponse, *shared.ApiError)
+
+	GetLearnedPreferences() (*shared.GetLearnedPreferencesResponse, *shared.ApiError)
+	ClearLearnedPreferences(org bool) *shared.ApiError
+
+	Chat(req shared.ChatRequest, onChunk func(string)) *shared.ApiError
+	AskPlan(planId, branch string, req shared.AskPlanRequest, onChunk func(string)) *shared.ApiError
+
+	ListOrgCredentials() ([]*shared.OrgCredential, *shared.ApiError)
+	SetOrgCredential(req shared.SetOrgCredentialRequest) *shared.ApiError
+	DeleteOrgCredential(name string) *shared.ApiError
 
 	CreateCustomModel(model *shared.AvailableModel) *shared.ApiError
+	ProbeModelEndpoint(req shared.ProbeModelEndpointRequest) (*shared.ProbeModelEndpointResponse, *shared.ApiError)
 	ListCustomModels() ([]*shared.AvailableModel, *shared.ApiError)
 	DeleteAvailableModel(modelId string) *shared.ApiError
 
 	CreateModelPack(set *shared.ModelPack) *shared.ApiError
 	ListModelPacks() ([]*shared.ModelPack, *shared.ApiError)
+	UpdateModelPack(set *shared.ModelPack) *shared.ApiError
 	DeleteModelPack(setId string) *shared.ApiError
 }
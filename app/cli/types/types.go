@@ -1,6 +1,8 @@
 package types
 
 import (
+	"time"
+
 	"github.com/plandex/plandex/shared"
 	"github.com/sashabaranov/go-openai"
 )
@@ -11,7 +13,7 @@ type ClientAccount struct {
 	Email    string `json:"email"`
 	UserName string `json:"userName"`
 	UserId   string `json:"userId"`
-	Token    string `json:"token"`
+	Token    string `json:"token,omitempty"` // kept in the keychain or encrypted secrets file, not in accounts.json or auth.json
 	IsTrial  bool   `json:"isTrial"`
 }
 
@@ -23,6 +25,7 @@ type ClientAuth struct {
 
 type LoadContextParams struct {
 	Note            string
+	Name            string // label for piped data, or for the note if nothing is piped
 	Recursive       bool
 	NamesOnly       bool
 	ForceSkipIgnore bool
@@ -57,8 +60,45 @@ type PlanSettings struct {
 	Branch string `json:"branch"`
 }
 
+// CurrentProjectSettings is stored in the project's .plandex/project.json. When Org is set, commands run in the project use that org and server, whatever the default org is.
 type CurrentProjectSettings struct {
-	Id string `json:"id"`
+	Id  string             `json:"id"`
+	Org *ProjectOrgBinding `json:"org,omitempty"`
+
+	// the project's ids in orgs it was bound to before, by ProjectOrgBinding.Key(), so binding back to an org keeps its plans
+	ProjectIdsByOrg map[string]string `json:"projectIdsByOrg,omitempty"`
+}
+
+type ProjectOrgBinding struct {
+	IsCloud bool   `json:"isCloud"`
+	Host    string `json:"host,omitempty"`
+	OrgId   string `json:"orgId"`
+	OrgName string `json:"orgName"`
+
+	// the account used for the project when more than one signed-in account is on the same server
+	Email string `json:"email,omitempty"`
+}
+
+func (b *ProjectOrgBinding) Key() string {
+	if b.IsCloud {
+		return "cloud|" + b.OrgId
+	}
+	return b.Host + "|" + b.OrgId
+}
+
+func (b *ProjectOrgBinding) HostLabel() string {
+	if b.IsCloud {
+		return "Plandex Cloud"
+	}
+	return b.Host
+}
+
+// MatchesAccount checks whether an account is on the binding's server and, if the binding names one, is the binding's account
+func (b *ProjectOrgBinding) MatchesAccount(account *ClientAccount) bool {
+	if account.IsCloud != b.IsCloud || (!b.IsCloud && account.Host != b.Host) {
+		return false
+	}
+	return b.Email == "" || account.Email == b.Email
 }
 
 type ChangesUIScrollReplacement struct {
@@ -70,3 +110,41 @@ type ChangesUIScrollReplacement struct {
 type ChangesUIViewportsUpdate struct {
 	ScrollReplacement *ChangesUIScrollReplacement
 }
+
+type QueuedActionType string
+
+const (
+	QueuedActionTell QueuedActionType = "tell"
+	QueuedActionLoad QueuedActionType = "load"
+)
+
+// QueuedAction is a tell or context load saved while the server was unreachable, to be sent once it's back
+type QueuedAction struct {
+	Id        string           `json:"id"`
+	Type      QueuedActionType `json:"type"`
+	Branch    string           `json:"branch"`
+	Dir       string           `json:"dir"`
+	CreatedAt time.Time        `json:"createdAt"`
+
+	Prompt      string `json:"prompt,omitempty"`
+	TellBg      bool   `json:"tellBg,omitempty"`
+	TellStop    bool   `json:"tellStop,omitempty"`
+	TellNoBuild bool   `json:"tellNoBuild,omitempty"`
+
+	Resources  []string           `json:"resources,omitempty"`
+	LoadParams *LoadContextParams `json:"loadParams,omitempty"`
+	PipedData  string             `json:"pipedData,omitempty"`
+}
+
+// DraftMessage is part of a prompt saved locally with 'plandex draft add'. A plan's draft messages are sent together as one prompt.
+type DraftMessage struct {
+	Id        string    `json:"id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Chat is a project's chat with 'plandex chat'. Chats aren't stored on the server, so the history is kept locally and sent with each prompt. ContextPaths are relative to the project root, and their files are read again each time the chat is sent.
+type Chat struct {
+	Messages     []*shared.ChatMessage `json:"messages"`
+	ContextPaths []string              `json:"contextPaths"`
+}
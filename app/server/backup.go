@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"plandex-server/db"
+	"plandex-server/storage"
+	"strings"
+	"time"
+)
+
+// runBackupCmd writes an archive of all org data (db rows, plan files, and context blobs)
+func runBackupCmd(args []string) {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	out := flags.String("out", "", "path of the archive to write (default plandex-backup-{timestamp}.tar.gz)")
+	orgs := flags.String("org", "", "comma-separated ids of orgs to back up (default all orgs)")
+	flags.Parse(args)
+
+	initBackupCmd()
+
+	path := *out
+	if path == "" {
+		path = fmt.Sprintf("plandex-backup-%s.tar.gz", time.Now().UTC().Format("20060102-150405"))
+	}
+
+	var orgIds []string
+	if *orgs != "" {
+		orgIds = strings.Split(*orgs, ",")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal("Error creating backup file: ", err)
+	}
+
+	manifest, err := db.Backup(f, orgIds)
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err != nil {
+		os.Remove(path)
+		log.Fatal("Error creating backup: ", err)
+	}
+
+	numPlans := 0
+	for _, org := range manifest.Orgs {
+		numPlans += len(org.PlanIds)
+	}
+
+	log.Printf("Backed up %d org(s) and %d plan(s) to %s\n", len(manifest.Orgs), numPlans, path)
+}
+
+// runRestoreCmd restores an archive created with the backup command, optionally limited to a single org or plan
+func runRestoreCmd(args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	in := flags.String("in", "", "path of the archive to restore")
+	orgId := flags.String("org", "", "restore only this org")
+	planId := flags.String("plan", "", "restore only this plan (its org must already exist)")
+	overwrite := flags.Bool("overwrite", false, "replace existing orgs or plans with the same ids")
+	flags.Parse(args)
+
+	if *in == "" {
+		log.Fatal("Usage: plandex-server restore --in FILE [--org ID] [--plan ID] [--overwrite]")
+	}
+
+	initBackupCmd()
+
+	f, err := os.Open(*in)
+	if err != nil {
+		log.Fatal("Error opening backup file: ", err)
+	}
+	defer f.Close()
+
+	manifest, err := db.Restore(f, db.RestoreOptions{
+		OrgId:     *orgId,
+		PlanId:    *planId,
+		Overwrite: *overwrite,
+	})
+	if err != nil {
+		log.Fatal("Error restoring backup: ", err)
+	}
+
+	if *planId != "" {
+		log.Printf("Restored plan %s from backup created at %s\n", *planId, manifest.CreatedAt.Format(time.RFC3339))
+	} else {
+		log.Printf("Restored %d org(s) from backup created at %s\n", len(manifest.Orgs), manifest.CreatedAt.Format(time.RFC3339))
+	}
+}
+
+func initBackupCmd() {
+	err := db.Connect()
+	if err != nil {
+		log.Fatal("Error initializing database: ", err)
+	}
+
+	err = storage.Init(db.BaseDir)
+	if err != nil {
+		log.Fatal("Error initializing blob storage: ", err)
+	}
+}
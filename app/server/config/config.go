@@ -0,0 +1,259 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"plandex-server/model"
+	"plandex-server/model/plan"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// restartRequiredVars are read once at startup. Changes to them in the config file are reported on reload but only apply after a restart.
+var restartRequiredVars = []string{
+	"DATABASE_URL",
+	"DB_DRIVER",
+	"DB_HOST",
+	"DB_PORT",
+	"DB_NAME",
+	"DB_USER",
+	"DB_PASSWORD",
+	"PLANDEX_AUTO_MIGRATE",
+	"PLANDEX_BASE_DIR",
+	"PORT",
+	"IP",
+	"PLANDEX_SERVER_ROLE",
+	"PLANDEX_BUILD_WORKER_CONCURRENCY",
+	"GOENV",
+	"IS_CLOUD",
+	"PLANDEX_BLOB_STORAGE",
+	"PLANDEX_BLOB_BUCKET",
+	"PLANDEX_BLOB_REGION",
+	"PLANDEX_BLOB_ENDPOINT",
+	"PLANDEX_BLOB_PREFIX",
+	"PLANDEX_ENCRYPTION_PASSPHRASE",
+	"PLANDEX_ENCRYPTION_KMS_KEY_ID",
+	"PLANDEX_JOB_RUNNER_CONCURRENCY",
+}
+
+type ReloadResult struct {
+	// Changed lists the variables whose values changed, without their values since they can hold credentials
+	Changed         []string `json:"changed"`
+	RestartRequired []string `json:"restartRequired"`
+}
+
+var mu sync.Mutex
+
+// fileVars are the values applied from the config file by the last load
+var fileVars = map[string]string{}
+
+// originalEnv holds the process environment's value for each variable the config file has set, so it can be restored if the variable is removed from the file
+var originalEnv = map[string]*string{}
+
+// startupEnv holds the values of restartRequiredVars when the server started
+var startupEnv = map[string]string{}
+
+// Load applies the config file in PLANDEX_CONFIG_FILE, if set, to the environment. It's called at startup, before the environment is used to connect to the database or configure anything else. The file holds 'KEY=value' lines in the same format as a .env file.
+func Load() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	_, err := load()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range restartRequiredVars {
+		startupEnv[name] = os.Getenv(name)
+	}
+
+	// the timeout is first read when the plan package is initialized, before the config file is loaded
+	plan.LoadBuildFileTimeout()
+
+	err = plan.LoadCodeScanCorpus()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reload applies the config file again and re-initializes everything that can change while the server is running: the model request transport (proxy and CA bundle settings), model call slots, mock provider fixtures, the build file timeout, and the code scan corpus. SMTP settings are read each time an email is sent, so they apply right away. Model streams that are already running keep the settings they started with. Org settings like model defaults, credentials, and retention policies are stored in the database and always read fresh, so they don't need a reload.
+func Reload() (*ReloadResult, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	changed, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	err = model.InitTransport()
+	if err != nil {
+		return nil, fmt.Errorf("error initializing model transport: %v", err)
+	}
+
+	err = model.InitCallSlots()
+	if err != nil {
+		return nil, fmt.Errorf("error initializing model call slots: %v", err)
+	}
+
+	if model.MockProviderEnabled() {
+		err = model.InitMockProvider()
+		if err != nil {
+			return nil, fmt.Errorf("error initializing mock model provider: %v", err)
+		}
+	}
+
+	plan.LoadBuildFileTimeout()
+
+	err = plan.LoadCodeScanCorpus()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ReloadResult{Changed: changed}
+	for _, name := range restartRequiredVars {
+		if os.Getenv(name) != startupEnv[name] {
+			res.RestartRequired = append(res.RestartRequired, name)
+		}
+	}
+
+	log.Printf("Reloaded config. Changed: %v\n", changed)
+	if len(res.RestartRequired) > 0 {
+		log.Printf("Restart the server to apply changes to: %s\n", strings.Join(res.RestartRequired, ", "))
+	}
+
+	return res, nil
+}
+
+// WatchSignals reloads the config whenever the server receives SIGHUP
+func WatchSignals() {
+	sigHupChan := make(chan os.Signal, 1)
+	signal.Notify(sigHupChan, syscall.SIGHUP)
+
+	go func() {
+		for range sigHupChan {
+			log.Println("Received SIGHUP, reloading config")
+			_, err := Reload()
+			if err != nil {
+				log.Printf("Error reloading config: %v\n", err)
+			}
+		}
+	}()
+}
+
+// load sets the environment from the config file and returns the names of the variables that changed. Variables removed from the file since the last load go back to their original values.
+func load() ([]string, error) {
+	path := os.Getenv("PLANDEX_CONFIG_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening config file: %v", err)
+	}
+	defer f.Close()
+
+	vars, err := parseConfigFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+
+	var changed []string
+
+	for name, value := range vars {
+		if _, ok := originalEnv[name]; !ok {
+			if original, ok := os.LookupEnv(name); ok {
+				originalEnv[name] = &original
+			} else {
+				originalEnv[name] = nil
+			}
+		}
+
+		if os.Getenv(name) != value {
+			changed = append(changed, name)
+		}
+		os.Setenv(name, value)
+	}
+
+	for name := range fileVars {
+		if _, ok := vars[name]; ok {
+			continue
+		}
+
+		original := originalEnv[name]
+		if original == nil {
+			os.Unsetenv(name)
+		} else {
+			os.Setenv(name, *original)
+		}
+		changed = append(changed, name)
+	}
+
+	fileVars = vars
+	sort.Strings(changed)
+
+	return changed, nil
+}
+
+// parseConfigFile reads 'KEY=value' lines. Blank lines and lines starting with '#' are skipped, an 'export ' prefix is allowed, and values can be wrapped in single or double quotes.
+func parseConfigFile(r io.Reader) (map[string]string, error) {
+	vars := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		name, value, ok, err := parseConfigLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		if ok {
+			vars[name] = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+func parseConfigLine(line string) (string, string, bool, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false, nil
+	}
+
+	line = strings.TrimPrefix(line, "export ")
+
+	name, value, found := strings.Cut(line, "=")
+	if !found {
+		return "", "", false, fmt.Errorf("expected KEY=value")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", false, fmt.Errorf("invalid variable name %q", name)
+	}
+
+	if name == "PLANDEX_CONFIG_FILE" {
+		return "", "", false, fmt.Errorf("PLANDEX_CONFIG_FILE can't be set in the config file")
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+
+	return name, value, true, nil
+}
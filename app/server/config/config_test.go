@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseConfigFile(t *testing.T) {
+	file := `# model requests
+PLANDEX_MODEL_PROXY=http://proxy.internal:3128
+export PLANDEX_BUILD_FILE_TIMEOUT=3m
+
+SMTP_FROM="Plandex <plandex@example.com>"
+SMTP_PASSWORD='p@ss=word'
+`
+
+	vars, err := parseConfigFile(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"PLANDEX_MODEL_PROXY":        "http://proxy.internal:3128",
+		"PLANDEX_BUILD_FILE_TIMEOUT": "3m",
+		"SMTP_FROM":                  "Plandex <plandex@example.com>",
+		"SMTP_PASSWORD":              "p@ss=word",
+	}
+
+	if len(vars) != len(expected) {
+		t.Errorf("Expected %d vars, got %d: %v", len(expected), len(vars), vars)
+	}
+
+	for name, value := range expected {
+		if vars[name] != value {
+			t.Errorf("Expected %s to be %q, got %q", name, value, vars[name])
+		}
+	}
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	tests := []string{
+		"PLANDEX_MODEL_PROXY",
+		"=value",
+		"NOT VALID=value",
+		"PLANDEX_CONFIG_FILE=/etc/plandex.env",
+	}
+
+	for _, test := range tests {
+		_, err := parseConfigFile(strings.NewReader(test))
+		if err == nil {
+			t.Errorf("Expected an error for %q", test)
+		}
+	}
+}
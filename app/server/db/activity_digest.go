@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"plandex-server/email"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const defaultDigestHour = 9
+
+// SendActivityDigests emails each user whose digest is due a summary of plan activity in their orgs since their last digest. Digests go out at PLANDEX_ACTIVITY_DIGEST_HOUR (UTC, default 9): daily ones every day, and weekly ones on Mondays. A user with no activity gets no email. An error for one user doesn't stop the others.
+func SendActivityDigests(ctx context.Context) error {
+	hour := digestHour()
+	now := time.Now().UTC()
+
+	settings, err := ListEnabledDigestSettings()
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	numSent := 0
+	for _, s := range settings {
+		dueAt := digestDueAt(s.Frequency, now, hour)
+		if s.LastSentAt != nil && !s.LastSentAt.Before(dueAt) {
+			continue
+		}
+
+		since := dueAt.Add(-digestPeriod(s.Frequency))
+		if s.LastSentAt != nil {
+			since = *s.LastSentAt
+		}
+
+		sent, err := sendActivityDigest(ctx, s, since)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("user %s: %v", s.UserId, err))
+			continue
+		}
+		if sent {
+			numSent++
+		}
+
+		// the next digest starts from here even if there was nothing to send
+		err = SetDigestSent(s.Id, now)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("user %s: %v", s.UserId, err))
+		}
+	}
+
+	if numSent > 0 {
+		log.Printf("Sent %d activity digest(s)\n", numSent)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func sendActivityDigest(ctx context.Context, settings *UserDigestSettings, since time.Time) (bool, error) {
+	user, err := GetUser(settings.UserId)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, nil
+	}
+
+	plans, err := ListDigestPlans(user.Id, since)
+	if err != nil {
+		return false, err
+	}
+	if len(plans) == 0 {
+		return false, nil
+	}
+
+	planIds := make([]string, len(plans))
+	for i, plan := range plans {
+		planIds[i] = plan.Id
+	}
+
+	buildCounts, err := GetPlanBuildCounts(planIds, since)
+	if err != nil {
+		return false, err
+	}
+
+	digest := &email.ActivityDigest{Period: "in the last day"}
+	if settings.Frequency == shared.DigestFrequencyWeekly {
+		digest.Period = "in the last week"
+	}
+
+	orgsById := map[string]*email.ActivityDigestOrg{}
+
+	for _, plan := range plans {
+		item := &email.ActivityDigestPlan{
+			Name:    plan.Name,
+			Updated: !plan.UpdatedAt.Before(since),
+		}
+
+		if counts := buildCounts[plan.Id]; counts != nil {
+			item.BuildsFinished = counts.NumFinished
+			item.BuildsFailed = counts.NumFailed
+		}
+
+		// pending changes are the plan owner's to review
+		if plan.OwnerId == user.Id {
+			numPending, err := countPendingFiles(ctx, plan)
+			if err != nil {
+				log.Printf("Error counting pending changes for plan %s in activity digest: %v\n", plan.Id, err)
+			}
+			item.PendingFiles = numPending
+		}
+
+		if !item.Updated && item.BuildsFinished == 0 && item.BuildsFailed == 0 && item.PendingFiles == 0 {
+			continue
+		}
+
+		org, ok := orgsById[plan.OrgId]
+		if !ok {
+			org = &email.ActivityDigestOrg{Name: plan.OrgName}
+			orgsById[plan.OrgId] = org
+			digest.Orgs = append(digest.Orgs, org)
+		}
+		org.Plans = append(org.Plans, item)
+	}
+
+	if len(digest.Orgs) == 0 {
+		return false, nil
+	}
+
+	err = email.SendActivityDigestEmail(user.Email, user.Name, digest)
+	if err != nil {
+		return false, fmt.Errorf("error sending activity digest: %v", err)
+	}
+
+	return true, nil
+}
+
+// countPendingFiles counts the files with changes waiting for review on the plan's main branch
+func countPendingFiles(ctx context.Context, plan *Plan) (int, error) {
+	ctx, cancelFn := context.WithCancel(ctx)
+	defer cancelFn()
+
+	repoLockId, err := LockRepo(LockRepoParams{
+		OrgId:    plan.OrgId,
+		UserId:   plan.OwnerId,
+		PlanId:   plan.Id,
+		Branch:   "main",
+		Scope:    LockScopeRead,
+		Ctx:      ctx,
+		CancelFn: cancelFn,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error locking repo: %v", err)
+	}
+
+	defer func() {
+		err := DeleteRepoLock(repoLockId)
+		if err != nil {
+			log.Printf("Error unlocking repo for plan %s: %v\n", plan.Id, err)
+		}
+	}()
+
+	results, err := GetPlanFileResults(plan.OrgId, plan.Id)
+	if err != nil {
+		return 0, err
+	}
+
+	paths := map[string]bool{}
+	for _, result := range results {
+		if result.ToApi().IsPending() {
+			paths[result.Path] = true
+		}
+	}
+
+	return len(paths), nil
+}
+
+func digestHour() int {
+	s := os.Getenv("PLANDEX_ACTIVITY_DIGEST_HOUR")
+	if s == "" {
+		return defaultDigestHour
+	}
+
+	hour, err := strconv.Atoi(s)
+	if err != nil || hour < 0 || hour > 23 {
+		log.Printf("Invalid PLANDEX_ACTIVITY_DIGEST_HOUR %q, using default of %d\n", s, defaultDigestHour)
+		return defaultDigestHour
+	}
+
+	return hour
+}
+
+func digestPeriod(frequency shared.DigestFrequency) time.Duration {
+	if frequency == shared.DigestFrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// digestDueAt is the most recent time at or before now that a digest with the given frequency was scheduled: today at the digest hour (UTC) for daily digests, or the most recent Monday at that hour for weekly ones
+func digestDueAt(frequency shared.DigestFrequency, now time.Time, hour int) time.Time {
+	now = now.UTC()
+	due := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if due.After(now) {
+		due = due.AddDate(0, 0, -1)
+	}
+
+	if frequency == shared.DigestFrequencyWeekly {
+		for due.Weekday() != time.Monday {
+			due = due.AddDate(0, 0, -1)
+		}
+	}
+
+	return due
+}
@@ -0,0 +1,776 @@
+package db
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"plandex-server/storage"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Backups are gzipped tar archives with the following layout:
+//
+//	manifest.json                   BackupManifest
+//	orgs/{orgId}/db/{table}.json    the org's rows for each table in backupTables, as an array of objects
+//	orgs/{orgId}/plans/{planId}/... each plan's files (including its git repo)
+//	orgs/{orgId}/blobs/{sha}        context bodies from the blob store
+//	orgs/{orgId}/build-captures/{planId}/{captureId}  build capture bodies from the blob store
+//
+// Rows are restored into the current schema by column name, so an archive can be restored into a database at the same or a newer schema version. Auth tokens, email verifications, model streams, repo locks, and cached model responses aren't included -- users sign in again after a restore.
+
+const BackupFormatVersion = 1
+
+type BackupManifest struct {
+	FormatVersion int         `json:"formatVersion"`
+	CreatedAt     time.Time   `json:"createdAt"`
+	Driver        string      `json:"driver"`
+	SchemaVersion uint        `json:"schemaVersion"`
+	Orgs          []BackupOrg `json:"orgs"`
+}
+
+type BackupOrg struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	PlanIds []string `json:"planIds"`
+}
+
+type backupTable struct {
+	name  string
+	query string
+	// plan-scoped tables are included when restoring a single plan
+	planScoped bool
+}
+
+// in insertion order, so foreign keys are satisfied on restore
+var backupTables = []backupTable{
+	{name: "users", query: `SELECT * FROM users WHERE id IN (SELECT user_id FROM orgs_users WHERE org_id = $1)
+		OR id IN (SELECT owner_id FROM orgs WHERE id = $1)
+		OR id IN (SELECT inviter_id FROM invites WHERE org_id = $1)
+		OR id IN (SELECT invitee_id FROM invites WHERE org_id = $1)
+		OR id IN (SELECT user_id FROM plan_shares WHERE org_id = $1)
+		OR id IN (SELECT user_id FROM knowledge_entries WHERE org_id = $1)
+		OR id IN (SELECT user_id FROM learned_preferences WHERE org_id = $1)
+		OR id IN (SELECT user_id FROM change_feedback WHERE org_id = $1)`, planScoped: true},
+	{name: "orgs", query: "SELECT * FROM orgs WHERE id = $1"},
+	// wrapped data keys for encrypted content -- restoring them requires the same passphrase or KMS key
+	{name: "org_data_keys", query: "SELECT * FROM org_data_keys WHERE org_id = $1"},
+	{name: "org_credentials", query: "SELECT * FROM org_credentials WHERE org_id = $1"},
+	// global roles (org_id IS NULL) are included so role ids can be mapped by name to the roles in the restoring database
+	{name: "org_roles", query: "SELECT * FROM org_roles WHERE org_id = $1 OR org_id IS NULL"},
+	{name: "orgs_users", query: "SELECT * FROM orgs_users WHERE org_id = $1"},
+	{name: "invites", query: "SELECT * FROM invites WHERE org_id = $1"},
+	{name: "projects", query: "SELECT * FROM projects WHERE org_id = $1", planScoped: true},
+	{name: "plans", query: "SELECT * FROM plans WHERE org_id = $1", planScoped: true},
+	// parents are created before their children
+	{name: "branches", query: "SELECT * FROM branches WHERE org_id = $1 ORDER BY created_at", planScoped: true},
+	{name: "convo_summaries", query: "SELECT * FROM convo_summaries WHERE org_id = $1", planScoped: true},
+	{name: "file_change_summaries", query: "SELECT * FROM file_change_summaries WHERE org_id = $1", planScoped: true},
+	{name: "plan_builds", query: "SELECT * FROM plan_builds WHERE org_id = $1", planScoped: true},
+	{name: "build_captures", query: "SELECT * FROM build_captures WHERE org_id = $1", planScoped: true},
+	{name: "plan_tags", query: "SELECT * FROM plan_tags WHERE org_id = $1", planScoped: true},
+	// shares reference users besides the plan's owner, so they're only restored with the whole org
+	{name: "plan_shares", query: "SELECT * FROM plan_shares WHERE org_id = $1"},
+	{name: "model_usages", query: "SELECT * FROM model_usages WHERE org_id = $1"},
+	{name: "model_sets", query: "SELECT * FROM model_sets WHERE org_id = $1"},
+	{name: "custom_models", query: "SELECT * FROM custom_models WHERE org_id = $1"},
+	{name: "default_plan_settings", query: "SELECT * FROM default_plan_settings WHERE org_id = $1"},
+	{name: "org_retention_policies", query: "SELECT * FROM org_retention_policies WHERE org_id = $1"},
+	{name: "org_trial_policies", query: "SELECT * FROM org_trial_policies WHERE org_id = $1"},
+	{name: "org_code_scan_policies", query: "SELECT * FROM org_code_scan_policies WHERE org_id = $1"},
+	{name: "org_trust_policies", query: "SELECT * FROM org_trust_policies WHERE org_id = $1"},
+	{name: "project_trust_policies", query: "SELECT * FROM project_trust_policies WHERE org_id = $1"},
+	{name: "org_response_cache_policies", query: "SELECT * FROM org_response_cache_policies WHERE org_id = $1"},
+	{name: "bench_results", query: "SELECT * FROM bench_results WHERE org_id = $1"},
+	{name: "org_context_bundles", query: "SELECT * FROM org_context_bundles WHERE org_id = $1"},
+	{name: "org_context_bundle_files", query: "SELECT f.* FROM org_context_bundle_files f JOIN org_context_bundles b ON f.bundle_id = b.id WHERE b.org_id = $1"},
+	{name: "knowledge_entries", query: "SELECT * FROM knowledge_entries WHERE org_id = $1"},
+	{name: "learned_preferences", query: "SELECT * FROM learned_preferences WHERE org_id = $1"},
+	// feedback on a plan can come from users besides its owner, so like shares it's only restored with the whole org
+	{name: "change_feedback", query: "SELECT * FROM change_feedback WHERE org_id = $1"},
+	// digest settings belong to users rather than orgs, so like users they're shared between orgs
+	{name: "user_digest_settings", query: "SELECT * FROM user_digest_settings WHERE user_id IN (SELECT user_id FROM orgs_users WHERE org_id = $1)"},
+}
+
+type backupRow map[string]interface{}
+
+// Backup writes an archive of the given orgs (or every org if orgIds is empty) to w. For a consistent snapshot, stop the server (or make sure no plans are being updated) while the backup runs.
+func Backup(w io.Writer, orgIds []string) (*BackupManifest, error) {
+	status, err := GetMigrationStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(orgIds) == 0 {
+		err = Conn.Select(&orgIds, "SELECT id FROM orgs ORDER BY created_at")
+		if err != nil {
+			return nil, fmt.Errorf("error listing orgs: %v", err)
+		}
+	}
+
+	manifest := &BackupManifest{
+		FormatVersion: BackupFormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		Driver:        Driver,
+		SchemaVersion: status.Version,
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, orgId := range orgIds {
+		org, err := GetOrg(orgId)
+		if err != nil {
+			return nil, fmt.Errorf("error getting org %s: %v", orgId, err)
+		}
+
+		backupOrg := BackupOrg{Id: org.Id, Name: org.Name}
+
+		log.Printf("Backing up org %s (%s)\n", org.Name, org.Id)
+
+		for _, table := range backupTables {
+			rows, err := queryBackupRows(table.query, orgId)
+			if err != nil {
+				return nil, fmt.Errorf("error backing up %s for org %s: %v", table.name, orgId, err)
+			}
+
+			if table.name == "plans" {
+				for _, row := range rows {
+					backupOrg.PlanIds = append(backupOrg.PlanIds, fmt.Sprint(row["id"]))
+				}
+			}
+
+			bytes, err := json.Marshal(rows)
+			if err != nil {
+				return nil, fmt.Errorf("error marshalling %s: %v", table.name, err)
+			}
+
+			err = writeTarFile(tw, path.Join("orgs", orgId, "db", table.name+".json"), bytes, 0644, time.Now())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = backupOrgPlanFiles(tw, orgId)
+		if err != nil {
+			return nil, err
+		}
+
+		err = backupOrgBlobs(tw, orgId)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.Orgs = append(manifest.Orgs, backupOrg)
+	}
+
+	// the manifest is written last since it lists what was backed up -- restore reads the whole archive before applying anything
+	bytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling manifest: %v", err)
+	}
+	err = writeTarFile(tw, "manifest.json", bytes, 0644, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error closing archive: %v", err)
+	}
+
+	err = gz.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error closing archive: %v", err)
+	}
+
+	return manifest, nil
+}
+
+func queryBackupRows(query string, args ...interface{}) ([]backupRow, error) {
+	rows, err := Conn.Queryx(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []backupRow{}
+	for rows.Next() {
+		row := backupRow{}
+		err = rows.MapScan(row)
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range row {
+			// text, json, and uuid columns may be scanned as bytes
+			if b, ok := v.([]byte); ok {
+				row[k] = string(b)
+			}
+		}
+
+		res = append(res, row)
+	}
+
+	return res, rows.Err()
+}
+
+func writeTarFile(tw *tar.Writer, name string, body []byte, mode int64, modTime time.Time) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    int64(len(body)),
+		ModTime: modTime,
+	})
+	if err != nil {
+		return fmt.Errorf("error writing %s to archive: %v", name, err)
+	}
+
+	_, err = tw.Write(body)
+	if err != nil {
+		return fmt.Errorf("error writing %s to archive: %v", name, err)
+	}
+
+	return nil
+}
+
+func backupOrgPlanFiles(tw *tar.Writer, orgId string) error {
+	plansDir := filepath.Join(getOrgDir(orgId), "plans")
+
+	_, err := os.Stat(plansDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.Walk(plansDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(getOrgDir(orgId), p)
+		if err != nil {
+			return err
+		}
+		name := path.Join("orgs", orgId, filepath.ToSlash(rel))
+
+		if info.IsDir() {
+			return tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     name + "/",
+				Mode:     int64(info.Mode().Perm()),
+				ModTime:  info.ModTime(),
+			})
+		}
+
+		// git repos only contain regular files and dirs
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		bytes, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", p, err)
+		}
+
+		return writeTarFile(tw, name, bytes, int64(info.Mode().Perm()), info.ModTime())
+	})
+}
+
+func backupOrgBlobs(tw *tar.Writer, orgId string) error {
+	err := storage.Blobs.List(getOrgContextBlobsKey(orgId), func(key string, modTime time.Time) error {
+		bytes, err := storage.Blobs.Get(key)
+		if err != nil {
+			return fmt.Errorf("error reading blob %s: %v", key, err)
+		}
+		return writeTarFile(tw, path.Join("orgs", orgId, "blobs", path.Base(key)), bytes, 0644, modTime)
+	})
+	if err != nil {
+		return err
+	}
+
+	capturesKey := path.Join("orgs", orgId, "build-captures")
+	return storage.Blobs.List(capturesKey, func(key string, modTime time.Time) error {
+		bytes, err := storage.Blobs.Get(key)
+		if err != nil {
+			return fmt.Errorf("error reading build capture %s: %v", key, err)
+		}
+		return writeTarFile(tw, path.Join("orgs", orgId, "build-captures", strings.TrimPrefix(key, capturesKey+"/")), bytes, 0644, modTime)
+	})
+}
+
+type RestoreOptions struct {
+	// restore only this org (default all orgs in the archive)
+	OrgId string
+	// restore only this plan -- its org must already exist
+	PlanId string
+	// replace an existing org or plan with the same id instead of failing
+	Overwrite bool
+}
+
+// Restore applies an archive created by Backup. The archive is fully extracted and validated before anything is changed.
+func Restore(r io.Reader, opts RestoreOptions) (*BackupManifest, error) {
+	tmpDir, err := os.MkdirTemp(BaseDir, "restore-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	err = extractBackup(r, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := os.ReadFile(filepath.Join(tmpDir, "manifest.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest -- archive is incomplete or not a plandex backup: %v", err)
+	}
+
+	var manifest BackupManifest
+	err = json.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %v", err)
+	}
+
+	if manifest.FormatVersion > BackupFormatVersion {
+		return nil, fmt.Errorf("backup format version %d is newer than this version of plandex-server supports", manifest.FormatVersion)
+	}
+
+	status, err := GetMigrationStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Driver == Driver && manifest.SchemaVersion > status.Version {
+		return nil, fmt.Errorf("backup is from schema version %d, but the database is at version %d -- run 'plandex-server migrate up' first", manifest.SchemaVersion, status.Version)
+	}
+
+	var orgs []BackupOrg
+	for _, org := range manifest.Orgs {
+		if opts.OrgId != "" && org.Id != opts.OrgId {
+			continue
+		}
+		if opts.PlanId != "" && !slices.Contains(org.PlanIds, opts.PlanId) {
+			continue
+		}
+		orgs = append(orgs, org)
+	}
+
+	if len(orgs) == 0 {
+		if opts.PlanId != "" {
+			return nil, fmt.Errorf("plan %s isn't in the backup", opts.PlanId)
+		}
+		return nil, fmt.Errorf("org %s isn't in the backup", opts.OrgId)
+	}
+
+	for _, org := range orgs {
+		orgDir := filepath.Join(tmpDir, "orgs", org.Id)
+
+		if opts.PlanId != "" {
+			log.Printf("Restoring plan %s in org %s (%s)\n", opts.PlanId, org.Name, org.Id)
+		} else {
+			log.Printf("Restoring org %s (%s)\n", org.Name, org.Id)
+		}
+
+		err = restoreOrgRows(orgDir, org.Id, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error restoring org %s: %v", org.Id, err)
+		}
+
+		err = restoreOrgBlobs(orgDir, org.Id)
+		if err != nil {
+			return nil, fmt.Errorf("error restoring context blobs for org %s: %v", org.Id, err)
+		}
+
+		err = restoreOrgBuildCaptures(orgDir, org, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error restoring build captures for org %s: %v", org.Id, err)
+		}
+
+		err = restoreOrgPlanFiles(orgDir, org, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error restoring plan files for org %s: %v", org.Id, err)
+		}
+	}
+
+	manifest.Orgs = orgs
+
+	return &manifest, nil
+}
+
+func extractBackup(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("error reading archive: %v", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading archive: %v", err)
+		}
+
+		// guard against paths escaping the extraction dir
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, os.ModePerm)
+			if err != nil {
+				return fmt.Errorf("error creating dir: %v", err)
+			}
+		case tar.TypeReg:
+			err = os.MkdirAll(filepath.Dir(target), os.ModePerm)
+			if err != nil {
+				return fmt.Errorf("error creating dir: %v", err)
+			}
+
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return fmt.Errorf("error creating file: %v", err)
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("error extracting %s: %v", header.Name, err)
+			}
+			os.Chtimes(target, header.ModTime, header.ModTime)
+		}
+	}
+
+	return nil
+}
+
+func readBackupRows(orgDir, table string) ([]backupRow, error) {
+	f, err := os.Open(filepath.Join(orgDir, "db", table+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading %s: %v", table, err)
+	}
+	defer f.Close()
+
+	var rows []backupRow
+	dec := json.NewDecoder(f)
+	// keep numbers as strings so large integers aren't rounded
+	dec.UseNumber()
+	err = dec.Decode(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", table, err)
+	}
+
+	return rows, nil
+}
+
+func restoreOrgRows(orgDir, orgId string, opts RestoreOptions) error {
+	tx, err := Conn.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	err = clearRestoreTarget(tx, orgId, opts)
+	if err != nil {
+		return err
+	}
+
+	// global role ids differ between installs, so they're mapped by name
+	roleIds := map[string]string{}
+
+	// when restoring a single plan, its project and owner are restored too if they're missing
+	var planProjectId, planOwnerId interface{}
+	if opts.PlanId != "" {
+		var planRows []backupRow
+		planRows, err = readBackupRows(orgDir, "plans")
+		if err != nil {
+			return err
+		}
+		for _, row := range planRows {
+			if row["id"] == opts.PlanId {
+				planProjectId = row["project_id"]
+				planOwnerId = row["owner_id"]
+			}
+		}
+	}
+
+	for _, table := range backupTables {
+		if opts.PlanId != "" && !table.planScoped {
+			continue
+		}
+
+		var rows []backupRow
+		rows, err = readBackupRows(orgDir, table.name)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if opts.PlanId != "" {
+				switch table.name {
+				case "plans":
+					if row["id"] != opts.PlanId {
+						continue
+					}
+				case "projects":
+					if row["id"] != planProjectId {
+						continue
+					}
+				case "users":
+					if row["id"] != planOwnerId {
+						continue
+					}
+				default:
+					if row["plan_id"] != opts.PlanId {
+						continue
+					}
+				}
+			}
+
+			if table.name == "org_roles" && row["org_id"] == nil {
+				var localId string
+				err = tx.Get(&localId, "SELECT id FROM org_roles WHERE org_id IS NULL AND name = $1", row["name"])
+				if err != nil {
+					return fmt.Errorf("error getting org role %v: %v", row["name"], err)
+				}
+				roleIds[fmt.Sprint(row["id"])] = localId
+				continue
+			}
+
+			if roleId, ok := row["org_role_id"]; ok {
+				if localId, ok := roleIds[fmt.Sprint(roleId)]; ok {
+					row["org_role_id"] = localId
+				}
+			}
+
+			// users (and their digest settings) are shared between orgs and projects may already exist when restoring a plan
+			ignoreConflicts := table.name == "users" || table.name == "user_digest_settings" || (opts.PlanId != "" && table.name == "projects")
+
+			err = insertBackupRow(tx, table.name, row, ignoreConflicts)
+			if err != nil {
+				return fmt.Errorf("error restoring %s row %v: %v", table.name, row["id"], err)
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// clearRestoreTarget ensures the org or plan being restored doesn't exist, or removes it if overwriting
+func clearRestoreTarget(tx *sqlx.Tx, orgId string, opts RestoreOptions) error {
+	var orgExists bool
+	err := tx.Get(&orgExists, "SELECT EXISTS(SELECT 1 FROM orgs WHERE id = $1)", orgId)
+	if err != nil {
+		return fmt.Errorf("error checking org: %v", err)
+	}
+
+	if opts.PlanId != "" {
+		if !orgExists {
+			return fmt.Errorf("org %s doesn't exist -- restore the org before restoring a single plan", orgId)
+		}
+
+		var planExists bool
+		err = tx.Get(&planExists, "SELECT EXISTS(SELECT 1 FROM plans WHERE id = $1)", opts.PlanId)
+		if err != nil {
+			return fmt.Errorf("error checking plan: %v", err)
+		}
+
+		if planExists {
+			if !opts.Overwrite {
+				return fmt.Errorf("plan %s already exists -- use --overwrite to replace it", opts.PlanId)
+			}
+
+			_, err = tx.Exec("DELETE FROM plans WHERE id = $1", opts.PlanId)
+			if err != nil {
+				return fmt.Errorf("error deleting existing plan: %v", err)
+			}
+		}
+
+		return nil
+	}
+
+	if orgExists {
+		if !opts.Overwrite {
+			return fmt.Errorf("org %s already exists -- use --overwrite to replace it", orgId)
+		}
+
+		_, err = tx.Exec("DELETE FROM orgs WHERE id = $1", orgId)
+		if err != nil {
+			return fmt.Errorf("error deleting existing org: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func insertBackupRow(tx *sqlx.Tx, table string, row backupRow, ignoreConflicts bool) error {
+	var cols []string
+	for col := range row {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	placeholders := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+	for i, col := range cols {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+
+		val := row[col]
+		// timestamps are stored as RFC3339 in the archive
+		if s, ok := val.(string); ok && strings.HasSuffix(col, "_at") {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				val = t
+			}
+		}
+		args[i] = val
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(cols, ", "), strings.Join(placeholders, ", "))
+	if ignoreConflicts {
+		query += " ON CONFLICT DO NOTHING"
+	}
+
+	_, err := tx.Exec(query, args...)
+	return err
+}
+
+func restoreOrgBlobs(orgDir, orgId string) error {
+	blobsDir := filepath.Join(orgDir, "blobs")
+
+	entries, err := os.ReadDir(blobsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error reading blobs: %v", err)
+	}
+
+	for _, entry := range entries {
+		sha := entry.Name()
+		bytes, err := os.ReadFile(filepath.Join(blobsDir, sha))
+		if err != nil {
+			return fmt.Errorf("error reading blob %s: %v", sha, err)
+		}
+
+		// unreferenced blobs (e.g. from plans that weren't restored) are cleaned up by the blob GC job
+		err = storeRawContextBlob(orgId, sha, bytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreOrgBuildCaptures replaces the stored bodies of each restored plan's build captures with the ones in the backup. They're restored as-is, so they stay encrypted if they were.
+func restoreOrgBuildCaptures(orgDir string, org BackupOrg, opts RestoreOptions) error {
+	planIds := org.PlanIds
+	if opts.PlanId != "" {
+		planIds = []string{opts.PlanId}
+	}
+
+	for _, planId := range planIds {
+		err := deletePlanBuildCaptureBlobs(org.Id, planId)
+		if err != nil {
+			return err
+		}
+
+		capturesDir := filepath.Join(orgDir, "build-captures", planId)
+		entries, err := os.ReadDir(capturesDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("error reading build captures: %v", err)
+		}
+
+		for _, entry := range entries {
+			bytes, err := os.ReadFile(filepath.Join(capturesDir, entry.Name()))
+			if err != nil {
+				return fmt.Errorf("error reading build capture %s: %v", entry.Name(), err)
+			}
+
+			err = storage.Blobs.Put(getBuildCaptureKey(org.Id, planId, entry.Name()), bytes)
+			if err != nil {
+				return fmt.Errorf("error restoring build capture %s: %v", entry.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func restoreOrgPlanFiles(orgDir string, org BackupOrg, opts RestoreOptions) error {
+	targetPlansDir := filepath.Join(getOrgDir(org.Id), "plans")
+
+	planIds := org.PlanIds
+	if opts.PlanId != "" {
+		planIds = []string{opts.PlanId}
+	} else if opts.Overwrite {
+		// plans that aren't in the backup were removed along with the org's rows
+		err := os.RemoveAll(targetPlansDir)
+		if err != nil {
+			return fmt.Errorf("error removing existing plans: %v", err)
+		}
+	}
+
+	err := os.MkdirAll(targetPlansDir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("error creating plans dir: %v", err)
+	}
+
+	for _, planId := range planIds {
+		src := filepath.Join(orgDir, "plans", planId)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+
+		target := filepath.Join(targetPlansDir, planId)
+		err = os.RemoveAll(target)
+		if err != nil {
+			return fmt.Errorf("error removing existing plan dir: %v", err)
+		}
+
+		// the extraction dir is inside the base dir, so this is a cheap rename
+		err = os.Rename(src, target)
+		if err != nil {
+			return fmt.Errorf("error moving plan dir into place: %v", err)
+		}
+	}
+
+	return nil
+}
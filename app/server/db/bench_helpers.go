@@ -0,0 +1,38 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func StoreBenchResult(result *BenchResult) error {
+	query := `INSERT INTO bench_results (org_id, plan_id, bench_id, model_pack, builder_model, num_files, num_passed, duration_ms, prompt_tokens, completion_tokens, error)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	RETURNING id, created_at`
+
+	err := Conn.QueryRow(query, result.OrgId, result.PlanId, result.BenchId, result.ModelPack, result.BuilderModel, result.NumFiles, result.NumPassed, result.DurationMs, result.PromptTokens, result.CompletionTokens, result.Error).Scan(&result.Id, &result.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error storing bench result: %v", err)
+	}
+
+	return nil
+}
+
+// ListBenchResults returns the org's most recent bench results, newest first
+func ListBenchResults(orgId string, limit int) ([]*shared.BenchResult, error) {
+	var results []*BenchResult
+	err := Conn.Select(&results, "SELECT * FROM bench_results WHERE org_id = $1 ORDER BY created_at DESC LIMIT $2", orgId, limit)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing bench results: %v", err)
+	}
+
+	var res []*shared.BenchResult
+	for _, result := range results {
+		res = append(res, result.ToApi())
+	}
+
+	return res, nil
+}
@@ -0,0 +1,212 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"plandex-server/storage"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// a capture's request, response, and file bodies can be large, so they're kept in the blob store and only the capture's metadata is in the database
+
+type buildCaptureBodies struct {
+	Request       string `json:"request"`
+	Response      string `json:"response"`
+	PreBuildState string `json:"preBuildState"`
+}
+
+func getPlanBuildCapturesKey(orgId, planId string) string {
+	return path.Join("orgs", orgId, "build-captures", planId)
+}
+
+func getBuildCaptureKey(orgId, planId, id string) string {
+	return path.Join(getPlanBuildCapturesKey(orgId, planId), id)
+}
+
+func StoreBuildCapture(capture *BuildCapture) error {
+	query := `INSERT INTO build_captures (org_id, plan_id, plan_build_id, file_path, phase, model_name, request, response, error, pre_build_state, bodies_in_blob, started_at)
+	VALUES ($1, $2, $3, $4, $5, $6, '', '', $7, '', TRUE, $8)
+	RETURNING id, created_at`
+
+	err := Conn.QueryRow(query, capture.OrgId, capture.PlanId, capture.PlanBuildId, capture.FilePath, capture.Phase, capture.ModelName, capture.Error, capture.StartedAt).Scan(&capture.Id, &capture.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error storing build capture: %v", err)
+	}
+
+	err = storeBuildCaptureBodies(capture.OrgId, capture.PlanId, capture.Id, &buildCaptureBodies{
+		Request:       capture.Request,
+		Response:      capture.Response,
+		PreBuildState: capture.PreBuildState,
+	})
+
+	if err != nil {
+		_, delErr := Conn.Exec("DELETE FROM build_captures WHERE id = $1", capture.Id)
+		if delErr != nil {
+			log.Printf("Error removing build capture %s after failing to store its bodies: %v\n", capture.Id, delErr)
+		}
+		return err
+	}
+
+	capture.BodiesInBlob = true
+
+	return nil
+}
+
+func storeBuildCaptureBodies(orgId, planId, id string, bodies *buildCaptureBodies) error {
+	bytes, err := json.Marshal(bodies)
+	if err != nil {
+		return fmt.Errorf("error marshalling build capture: %v", err)
+	}
+
+	data, err := encryptOrgData(orgId, bytes)
+	if err != nil {
+		return fmt.Errorf("error encrypting build capture: %v", err)
+	}
+
+	err = storage.Blobs.Put(getBuildCaptureKey(orgId, planId, id), data)
+	if err != nil {
+		return fmt.Errorf("error storing build capture: %v", err)
+	}
+
+	return nil
+}
+
+func getBuildCaptureBodies(capture *BuildCapture) (*buildCaptureBodies, error) {
+	if !capture.BodiesInBlob {
+		var err error
+		bodies := &buildCaptureBodies{}
+
+		bodies.Request, err = decryptOrgString(capture.OrgId, capture.Request)
+		if err != nil {
+			return nil, err
+		}
+
+		bodies.Response, err = decryptOrgString(capture.OrgId, capture.Response)
+		if err != nil {
+			return nil, err
+		}
+
+		bodies.PreBuildState, err = decryptOrgString(capture.OrgId, capture.PreBuildState)
+		if err != nil {
+			return nil, err
+		}
+
+		return bodies, nil
+	}
+
+	data, err := storage.Blobs.Get(getBuildCaptureKey(capture.OrgId, capture.PlanId, capture.Id))
+	if err != nil {
+		return nil, fmt.Errorf("error reading build capture: %v", err)
+	}
+
+	data, err = decryptOrgData(capture.OrgId, data)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting build capture: %v", err)
+	}
+
+	var bodies buildCaptureBodies
+	err = json.Unmarshal(data, &bodies)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling build capture: %v", err)
+	}
+
+	return &bodies, nil
+}
+
+// deletePlanBuildCaptureBlobs removes the stored bodies of all a plan's captures
+func deletePlanBuildCaptureBlobs(orgId, planId string) error {
+	var keys []string
+	err := storage.Blobs.List(getPlanBuildCapturesKey(orgId, planId), func(key string, modTime time.Time) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error listing build captures: %v", err)
+	}
+
+	for _, key := range keys {
+		err = storage.Blobs.Delete(key)
+		if err != nil {
+			return fmt.Errorf("error deleting build capture: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// ListBuildCaptures returns a plan's captures, oldest first, without their request, response, and file bodies. Pass a path to only include captures for that file.
+func ListBuildCaptures(orgId, planId, path string) ([]*shared.BuildCapture, error) {
+	query := "SELECT id, org_id, plan_id, plan_build_id, file_path, phase, model_name, '' AS request, '' AS response, error, '' AS pre_build_state, started_at, created_at FROM build_captures WHERE org_id = $1 AND plan_id = $2"
+	args := []interface{}{orgId, planId}
+
+	if path != "" {
+		query += " AND file_path = $3"
+		args = append(args, path)
+	}
+
+	query += " ORDER BY started_at"
+
+	var captures []*BuildCapture
+	err := Conn.Select(&captures, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing build captures: %v", err)
+	}
+
+	var res []*shared.BuildCapture
+	for _, capture := range captures {
+		res = append(res, capture.ToApi())
+	}
+
+	return res, nil
+}
+
+// GetBuildCapture returns a capture with its decrypted request, response, and file bodies
+func GetBuildCapture(orgId, planId, id string) (*shared.BuildCapture, error) {
+	var capture BuildCapture
+	err := Conn.Get(&capture, "SELECT * FROM build_captures WHERE org_id = $1 AND plan_id = $2 AND id = $3", orgId, planId, id)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting build capture: %v", err)
+	}
+
+	bodies, err := getBuildCaptureBodies(&capture)
+	if err != nil {
+		return nil, err
+	}
+
+	capture.Request = bodies.Request
+	capture.Response = bodies.Response
+	capture.PreBuildState = bodies.PreBuildState
+
+	return capture.ToApi(), nil
+}
+
+func DeleteBuildCaptures(orgId, planId string) (int64, error) {
+	res, err := Conn.Exec("DELETE FROM build_captures WHERE org_id = $1 AND plan_id = $2", orgId, planId)
+
+	if err != nil {
+		return 0, fmt.Errorf("error deleting build captures: %v", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	err = deletePlanBuildCaptureBlobs(orgId, planId)
+	if err != nil {
+		return rowsAffected, err
+	}
+
+	return rowsAffected, nil
+}
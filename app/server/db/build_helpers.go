@@ -7,13 +7,16 @@ import (
 
 func StorePlanBuild(build *PlanBuild) error {
 
-	query := `INSERT INTO plan_builds (org_id, plan_id, convo_message_id, file_path) VALUES (:org_id, :plan_id, :convo_message_id, :file_path) RETURNING id, created_at, updated_at`
+	query := `INSERT INTO plan_builds (org_id, plan_id, user_id, convo_message_id, file_path, branch, is_verification) VALUES (:org_id, :plan_id, :user_id, :convo_message_id, :file_path, :branch, :is_verification) RETURNING id, created_at, updated_at`
 
 	args := map[string]interface{}{
 		"org_id":           build.OrgId,
 		"plan_id":          build.PlanId,
+		"user_id":          build.UserId,
 		"convo_message_id": build.ConvoMessageId,
 		"file_path":        build.FilePath,
+		"branch":           build.Branch,
+		"is_verification":  build.IsVerification,
 	}
 
 	row, err := Conn.NamedQuery(query, args)
@@ -37,12 +40,30 @@ func StorePlanBuild(build *PlanBuild) error {
 	return nil
 }
 
-func SetBuildError(build *PlanBuild) error {
-	_, err := Conn.Exec("UPDATE plan_builds SET error = $1 WHERE id = $2", build.Error, build.Id)
+// SetBuildFinished records how a build ended: its error, if any, the number of times the builder model was retried, and the number of tokens in its final response. A build that's fixed after it finishes is updated again when the fix finishes.
+func SetBuildFinished(build *PlanBuild) error {
+	now := time.Now()
+
+	_, err := Conn.Exec("UPDATE plan_builds SET error = $1, num_retries = $2, num_tokens = $3, finished_at = $4 WHERE id = $5", build.Error, build.NumRetries, build.NumTokens, now, build.Id)
 
 	if err != nil {
-		return fmt.Errorf("error setting build error: %v", err)
+		return fmt.Errorf("error setting build finished: %v", err)
 	}
 
+	build.FinishedAt = &now
+
 	return nil
 }
+
+// ListPlanBuilds returns a branch's builds, oldest first
+func ListPlanBuilds(orgId, planId, branch string) ([]*PlanBuild, error) {
+	var builds []*PlanBuild
+
+	err := Conn.Select(&builds, "SELECT id, org_id, plan_id, user_id, convo_message_id, file_path, branch, is_verification, num_retries, num_tokens, COALESCE(error, '') AS error, finished_at, created_at, updated_at FROM plan_builds WHERE org_id = $1 AND plan_id = $2 AND branch = $3 ORDER BY created_at", orgId, planId, branch)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing plan builds: %v", err)
+	}
+
+	return builds, nil
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// EnqueueBuildJob queues a build for a worker. The request includes the client's model provider keys, so it's only stored encrypted, and is cleared once the builds start.
+func EnqueueBuildJob(job *BuildJob, request string) error {
+	if !EncryptionEnabled() {
+		return fmt.Errorf("queueing builds requires encryption at rest -- set PLANDEX_ENCRYPTION_PASSPHRASE or PLANDEX_ENCRYPTION_KMS_KEY_ID on the server")
+	}
+
+	encrypted, err := encryptOrgString(job.OrgId, request)
+	if err != nil {
+		return fmt.Errorf("error encrypting build request: %v", err)
+	}
+
+	query := `INSERT INTO build_jobs (org_id, user_id, plan_id, branch, request, status)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, created_at`
+
+	job.Status = BuildJobStatusQueued
+
+	err = Conn.QueryRow(query, job.OrgId, job.UserId, job.PlanId, job.Branch, encrypted, job.Status).Scan(&job.Id, &job.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error enqueueing build job: %v", err)
+	}
+
+	return nil
+}
+
+func GetBuildJob(id string) (*BuildJob, error) {
+	var job BuildJob
+	err := Conn.Get(&job, "SELECT * FROM build_jobs WHERE id = $1", id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting build job: %v", err)
+	}
+
+	return &job, nil
+}
+
+// ClaimBuildJob assigns the oldest queued job to the worker, or returns nil if there isn't one. Rows locked by another worker's claim are skipped, so workers never claim the same job.
+func ClaimBuildJob(workerIp string) (*BuildJob, error) {
+	query := `UPDATE build_jobs SET status = $1, worker_ip = $2, started_at = NOW()
+	WHERE id = (
+		SELECT id FROM build_jobs WHERE status = $3 ORDER BY created_at LIMIT 1 FOR UPDATE SKIP LOCKED
+	)
+	RETURNING *`
+
+	var job BuildJob
+	err := Conn.Get(&job, query, BuildJobStatusClaimed, workerIp, BuildJobStatusQueued)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error claiming build job: %v", err)
+	}
+
+	return &job, nil
+}
+
+// GetBuildJobRequest returns a claimed job's decrypted request
+func GetBuildJobRequest(job *BuildJob) (string, error) {
+	if job.Request == nil {
+		return "", fmt.Errorf("build job %s has no request", job.Id)
+	}
+
+	return decryptOrgString(job.OrgId, *job.Request)
+}
+
+// SetBuildJobRunning records that the job's builds have started, clearing its request
+func SetBuildJobRunning(id string, numBuilds int) error {
+	_, err := Conn.Exec("UPDATE build_jobs SET status = $1, num_builds = $2, request = NULL WHERE id = $3", BuildJobStatusRunning, numBuilds, id)
+
+	if err != nil {
+		return fmt.Errorf("error setting build job running: %v", err)
+	}
+
+	return nil
+}
+
+// SetBuildJobFinished records that a job finished, or failed if errMsg is set, clearing its request
+func SetBuildJobFinished(id string, numBuilds int, errMsg string) error {
+	status := BuildJobStatusFinished
+	if errMsg != "" {
+		status = BuildJobStatusFailed
+	}
+
+	_, err := Conn.Exec("UPDATE build_jobs SET status = $1, num_builds = $2, error = $3, request = NULL, finished_at = NOW() WHERE id = $4", status, numBuilds, errMsg, id)
+
+	if err != nil {
+		return fmt.Errorf("error setting build job finished: %v", err)
+	}
+
+	return nil
+}
+
+// CancelQueuedBuildJob cancels a job if no worker has claimed it yet, and returns whether it did
+func CancelQueuedBuildJob(id string) (bool, error) {
+	res, err := Conn.Exec("UPDATE build_jobs SET status = $1, request = NULL, finished_at = NOW() WHERE id = $2 AND status = $3", BuildJobStatusCanceled, id, BuildJobStatusQueued)
+
+	if err != nil {
+		return false, fmt.Errorf("error canceling build job: %v", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// FailInterruptedBuildJobs marks jobs that a worker was running when it stopped as failed. It's called when the worker starts.
+func FailInterruptedBuildJobs(workerIp string) error {
+	_, err := Conn.Exec("UPDATE build_jobs SET status = $1, error = $2, request = NULL, finished_at = NOW() WHERE worker_ip = $3 AND status IN ($4, $5)", BuildJobStatusFailed, "Build worker restarted", workerIp, BuildJobStatusClaimed, BuildJobStatusRunning)
+
+	if err != nil {
+		return fmt.Errorf("error failing interrupted build jobs: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteOldBuildJobs deletes jobs that finished more than a day ago. Finished jobs are kept for a while so they can be inspected when debugging a worker.
+func DeleteOldBuildJobs() error {
+	_, err := Conn.Exec("DELETE FROM build_jobs WHERE finished_at IS NOT NULL AND finished_at < NOW() - INTERVAL '1 day'")
+
+	if err != nil {
+		return fmt.Errorf("error deleting old build jobs: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetOrgCodeScanPolicy(orgId string) (*shared.OrgCodeScanPolicy, error) {
+	var policy OrgCodeScanPolicy
+	err := Conn.Get(&policy, "SELECT * FROM org_code_scan_policies WHERE org_id = $1", orgId)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return shared.DefaultOrgCodeScanPolicy(), nil
+		}
+		return nil, fmt.Errorf("error getting code scan policy: %v", err)
+	}
+
+	return policy.ToApi(), nil
+}
+
+func StoreOrgCodeScanPolicy(orgId string, policy *shared.OrgCodeScanPolicy) error {
+	query := `INSERT INTO org_code_scan_policies (org_id, enabled, banned_licenses, min_verbatim_lines, match_context, match_corpus)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (org_id) DO UPDATE SET
+		enabled = excluded.enabled,
+		banned_licenses = excluded.banned_licenses,
+		min_verbatim_lines = excluded.min_verbatim_lines,
+		match_context = excluded.match_context,
+		match_corpus = excluded.match_corpus
+	`
+
+	_, err := Conn.Exec(query, orgId, policy.Enabled, strings.Join(policy.BannedLicenses, "\n"), policy.MinVerbatimLines, policy.MatchContext, policy.MatchCorpus)
+
+	if err != nil {
+		return fmt.Errorf("error storing code scan policy: %v", err)
+	}
+
+	return nil
+}
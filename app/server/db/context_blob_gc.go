@@ -0,0 +1,206 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"plandex-server/storage"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// blobs newer than this are never collected, which covers bodies that have been stored but not yet committed
+const contextBlobGCGracePeriod = 1 * time.Hour
+
+var contextMetaShaRegex = regexp.MustCompile(`"sha":\s*"([0-9a-f]{64})"`)
+
+// GCContextBlobs removes blobs in every org that aren't referenced by any context in any plan, including in plan history (so rewinding a plan never loses a body).
+func GCContextBlobs() (int, error) {
+	orgsDir := filepath.Join(BaseDir, "orgs")
+	orgDirs, err := os.ReadDir(orgsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("error reading orgs dir: %v", err)
+	}
+
+	total := 0
+	for _, orgDir := range orgDirs {
+		if !orgDir.IsDir() {
+			continue
+		}
+
+		numRemoved, err := gcOrgContextBlobs(orgDir.Name(), contextBlobGCGracePeriod)
+		if err != nil {
+			return total, fmt.Errorf("error running context blob GC for org %s: %v", orgDir.Name(), err)
+		}
+		total += numRemoved
+	}
+
+	return total, nil
+}
+
+// gcOrgContextBlobs removes an org's unreferenced blobs that are older than gracePeriod.
+func gcOrgContextBlobs(orgId string, gracePeriod time.Duration) (int, error) {
+	// collect blobs first so anything stored after this point is protected by the grace period
+	var candidates []string
+	cutoff := time.Now().Add(-gracePeriod)
+
+	err := storage.Blobs.List(getOrgContextBlobsKey(orgId), func(key string, modTime time.Time) error {
+		if modTime.Before(cutoff) {
+			candidates = append(candidates, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error listing context blobs: %v", err)
+	}
+
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+
+	referenced, err := getOrgReferencedContextShas(orgId)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteUnreferencedContextBlobs(candidates, referenced, cutoff)
+}
+
+// purgeContextBlobs removes the blobs for the given shas that no plan in the org references anymore, e.g. right after deleting the plans that referenced them. Only these shas are checked, so blobs that other plans are loading right now aren't touched by a short grace period.
+func purgeContextBlobs(orgId string, shas map[string]bool, gracePeriod time.Duration) (int, error) {
+	if len(shas) == 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-gracePeriod)
+
+	referenced, err := getOrgReferencedContextShas(orgId)
+	if err != nil {
+		return 0, err
+	}
+
+	var keys []string
+	for sha := range shas {
+		keys = append(keys, getContextBlobKey(orgId, sha))
+	}
+
+	return deleteUnreferencedContextBlobs(keys, referenced, cutoff)
+}
+
+func deleteUnreferencedContextBlobs(keys []string, referenced map[string]bool, cutoff time.Time) (int, error) {
+	numRemoved := 0
+	for _, key := range keys {
+		if referenced[path.Base(key)] {
+			continue
+		}
+
+		// a context stored while references were being collected touches its blob, so check again right before deleting
+		modTime, err := storage.Blobs.ModTime(key)
+		if err == storage.ErrNotFound {
+			continue
+		} else if err != nil {
+			return numRemoved, fmt.Errorf("error checking context blob: %v", err)
+		}
+		if !modTime.Before(cutoff) {
+			continue
+		}
+
+		err = storage.Blobs.Delete(key)
+		if err != nil {
+			return numRemoved, fmt.Errorf("error removing context blob: %v", err)
+		}
+		numRemoved++
+	}
+
+	return numRemoved, nil
+}
+
+// getOrgReferencedContextShas returns the shas of every context in the org's plans, both in the current working tree and in the history of every branch
+func getOrgReferencedContextShas(orgId string) (map[string]bool, error) {
+	referenced := map[string]bool{}
+
+	plansDir := filepath.Join(getOrgDir(orgId), "plans")
+	planDirs, err := os.ReadDir(plansDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return referenced, nil
+		}
+		return nil, fmt.Errorf("error reading plans dir: %v", err)
+	}
+
+	for _, planDir := range planDirs {
+		if !planDir.IsDir() {
+			continue
+		}
+
+		err = addPlanContextShas(referenced, filepath.Join(plansDir, planDir.Name()))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return referenced, nil
+}
+
+// getPlanContextShas returns the shas of every context in a plan, in its current working tree and its history
+func getPlanContextShas(orgId, planId string) (map[string]bool, error) {
+	referenced := map[string]bool{}
+
+	dir := getPlanDir(orgId, planId)
+	_, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return referenced, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error checking plan dir: %v", err)
+	}
+
+	err = addPlanContextShas(referenced, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return referenced, nil
+}
+
+func addPlanContextShas(referenced map[string]bool, dir string) error {
+	// current (possibly uncommitted) contexts
+	metaPaths, err := filepath.Glob(filepath.Join(dir, "context", "*.meta"))
+	if err != nil {
+		return fmt.Errorf("error listing context meta files: %v", err)
+	}
+	for _, metaPath := range metaPaths {
+		bytes, err := os.ReadFile(metaPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("error reading context meta file: %v", err)
+		}
+		addContextShas(referenced, string(bytes))
+	}
+
+	// every version of every context meta file in the plan's history
+	res, err := exec.Command("git", "-C", dir, "log", "--all", "--format=", "-p", "--", "context/*.meta").CombinedOutput()
+	if err != nil {
+		// a plan without commits yet has no history to check
+		if strings.Contains(string(res), "does not have any commits") {
+			return nil
+		}
+		return fmt.Errorf("error reading context history for plan %s: %v, output: %s", filepath.Base(dir), err, string(res))
+	}
+	addContextShas(referenced, string(res))
+
+	return nil
+}
+
+func addContextShas(referenced map[string]bool, s string) {
+	for _, match := range contextMetaShaRegex.FindAllStringSubmatch(s, -1) {
+		referenced[match[1]] = true
+	}
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"plandex-server/storage"
+	"strings"
+	"time"
+)
+
+// MigrateContextBlobs copies existing context bodies into the configured blob store: body files stored in plan dirs before the blob store existed, and (when the configured store isn't local) context and build capture blobs in the local blob store. Body files in plan dirs are left in place since they're part of each plan's history. If removeLocalBlobs is true, local blobs are deleted once copied.
+func MigrateContextBlobs(removeLocalBlobs bool) (int, error) {
+	orgsDir := filepath.Join(BaseDir, "orgs")
+	orgDirs, err := os.ReadDir(orgsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("error reading orgs dir: %v", err)
+	}
+
+	local := storage.NewLocal(BaseDir)
+	copyLocal := storage.Blobs.Name() != storage.BackendLocal
+
+	total := 0
+	for _, orgDir := range orgDirs {
+		if !orgDir.IsDir() {
+			continue
+		}
+		orgId := orgDir.Name()
+
+		num, err := migrateLegacyContextBodies(orgId)
+		if err != nil {
+			return total, fmt.Errorf("error migrating context bodies for org %s: %v", orgId, err)
+		}
+		total += num
+
+		if !copyLocal {
+			continue
+		}
+
+		// build captures are keyed by plan, under the org
+		for _, prefix := range []string{getOrgContextBlobsKey(orgId), path.Join("orgs", orgId, "build-captures")} {
+			num, err := copyLocalBlobs(local, prefix, removeLocalBlobs)
+			total += num
+			if err != nil {
+				return total, fmt.Errorf("error copying local blobs for org %s: %v", orgId, err)
+			}
+		}
+	}
+
+	return total, nil
+}
+
+func copyLocalBlobs(local storage.Store, prefix string, removeLocalBlobs bool) (int, error) {
+	num := 0
+	err := local.List(prefix, func(key string, modTime time.Time) error {
+		body, err := local.Get(key)
+		if err != nil {
+			return err
+		}
+
+		exists, err := storage.Blobs.Touch(key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			err = storage.Blobs.Put(key, body)
+			if err != nil {
+				return err
+			}
+			num++
+		}
+
+		if removeLocalBlobs {
+			return local.Delete(key)
+		}
+		return nil
+	})
+
+	return num, err
+}
+
+// MigrateBuildCaptureBodies moves the bodies of build captures stored before the blob store was used out of the database and into the blob store
+func MigrateBuildCaptureBodies() (int, error) {
+	var ids []string
+	err := Conn.Select(&ids, "SELECT id FROM build_captures WHERE bodies_in_blob = FALSE")
+	if err != nil {
+		return 0, fmt.Errorf("error listing build captures: %v", err)
+	}
+
+	num := 0
+	for _, id := range ids {
+		var capture BuildCapture
+		err = Conn.Get(&capture, "SELECT * FROM build_captures WHERE id = $1", id)
+		if err != nil {
+			return num, fmt.Errorf("error getting build capture: %v", err)
+		}
+
+		bodies, err := getBuildCaptureBodies(&capture)
+		if err != nil {
+			return num, err
+		}
+
+		err = storeBuildCaptureBodies(capture.OrgId, capture.PlanId, capture.Id, bodies)
+		if err != nil {
+			return num, err
+		}
+
+		_, err = Conn.Exec("UPDATE build_captures SET request = '', response = '', pre_build_state = '', bodies_in_blob = TRUE WHERE id = $1", capture.Id)
+		if err != nil {
+			return num, fmt.Errorf("error updating build capture: %v", err)
+		}
+		num++
+	}
+
+	return num, nil
+}
+
+func migrateLegacyContextBodies(orgId string) (int, error) {
+	bodyPaths, err := filepath.Glob(filepath.Join(getOrgDir(orgId), "plans", "*", "context", "*.body"))
+	if err != nil {
+		return 0, fmt.Errorf("error listing context body files: %v", err)
+	}
+
+	num := 0
+	for _, bodyPath := range bodyPaths {
+		metaPath := strings.TrimSuffix(bodyPath, ".body") + ".meta"
+		metaBytes, err := os.ReadFile(metaPath)
+		if err != nil {
+			log.Printf("Skipping context body without meta file: %s\n", bodyPath)
+			continue
+		}
+
+		var context Context
+		err = json.Unmarshal(metaBytes, &context)
+		if err != nil {
+			return num, fmt.Errorf("error unmarshalling context meta file %s: %v", metaPath, err)
+		}
+
+		if context.Sha == "" {
+			log.Printf("Skipping context body without sha: %s\n", bodyPath)
+			continue
+		}
+
+		body, err := os.ReadFile(bodyPath)
+		if err != nil {
+			return num, fmt.Errorf("error reading context body file %s: %v", bodyPath, err)
+		}
+
+		key := getContextBlobKey(orgId, context.Sha)
+		exists, err := storage.Blobs.Touch(key)
+		if err != nil {
+			return num, err
+		}
+		if exists {
+			continue
+		}
+
+		err = storage.Blobs.Put(key, body)
+		if err != nil {
+			return num, err
+		}
+		num++
+	}
+
+	return num, nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"fmt"
+	"path"
+	"plandex-server/storage"
+)
+
+// Context bodies are stored content-addressed by sha in a per-org blob store rather than in each plan's repo, so loading the same files into many plans (or many branches/versions of a plan) only stores each distinct body once. Context meta files reference bodies by sha. Unreferenced blobs are removed by the blob GC job. Blobs live in the configured storage backend (local disk by default, or S3/GCS).
+
+func getOrgContextBlobsKey(orgId string) string {
+	return path.Join("orgs", orgId, "context-blobs")
+}
+
+func getContextBlobKey(orgId, sha string) string {
+	prefix := sha
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+	return path.Join(getOrgContextBlobsKey(orgId), prefix, sha)
+}
+
+func storeContextBlob(orgId, sha string, body []byte) error {
+	data, err := encryptOrgData(orgId, body)
+	if err != nil {
+		return fmt.Errorf("error encrypting context blob: %v", err)
+	}
+
+	return storeRawContextBlob(orgId, sha, data)
+}
+
+// storeRawContextBlob stores a blob as-is, e.g. when restoring a backup (where blobs are already encrypted if encryption was enabled). Blobs are keyed by the sha of the plaintext body.
+func storeRawContextBlob(orgId, sha string, data []byte) error {
+	key := getContextBlobKey(orgId, sha)
+
+	// if it's already stored, touching it restarts the GC grace period
+	exists, err := storage.Blobs.Touch(key)
+	if err != nil {
+		return fmt.Errorf("error checking context blob: %v", err)
+	}
+	if exists {
+		return nil
+	}
+
+	err = storage.Blobs.Put(key, data)
+	if err != nil {
+		return fmt.Errorf("error storing context blob: %v", err)
+	}
+
+	return nil
+}
+
+func readContextBlob(orgId, sha string) ([]byte, error) {
+	bytes, err := storage.Blobs.Get(getContextBlobKey(orgId, sha))
+	if err != nil {
+		return nil, fmt.Errorf("error reading context blob %s: %v", sha, err)
+	}
+
+	bytes, err = decryptOrgData(orgId, bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting context blob %s: %v", sha, err)
+	}
+
+	return bytes, nil
+}
@@ -0,0 +1,285 @@
+package db
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// ListOrgContextBundles returns the org's context bundles, sorted by name. File bodies are only included if includeBodies is true.
+func ListOrgContextBundles(orgId string, includeBodies bool) ([]*shared.OrgContextBundle, error) {
+	var bundles []*OrgContextBundle
+	err := Conn.Select(&bundles, "SELECT * FROM org_context_bundles WHERE org_id = $1 ORDER BY name", orgId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting context bundles: %v", err)
+	}
+
+	if len(bundles) == 0 {
+		return []*shared.OrgContextBundle{}, nil
+	}
+
+	var files []*OrgContextBundleFile
+	err = Conn.Select(&files, "SELECT f.* FROM org_context_bundle_files f JOIN org_context_bundles b ON f.bundle_id = b.id WHERE b.org_id = $1 ORDER BY f.name", orgId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting context bundle files: %v", err)
+	}
+
+	filesByBundleId := map[string][]*shared.OrgContextBundleFile{}
+	for _, file := range files {
+		apiFile := &shared.OrgContextBundleFile{
+			Name:      file.Name,
+			NumTokens: file.NumTokens,
+		}
+		if includeBodies {
+			apiFile.Body, err = decryptOrgString(orgId, file.Body)
+			if err != nil {
+				return nil, fmt.Errorf("error decrypting context bundle file %s: %v", file.Name, err)
+			}
+		}
+		filesByBundleId[file.BundleId] = append(filesByBundleId[file.BundleId], apiFile)
+	}
+
+	res := make([]*shared.OrgContextBundle, len(bundles))
+	for i, bundle := range bundles {
+		res[i] = bundle.ToApi()
+		res[i].Files = filesByBundleId[bundle.Id]
+	}
+
+	return res, nil
+}
+
+// StoreOrgContextBundle creates the bundle, or replaces its description and files and bumps its version if it already exists. The bundle's Version is set to the stored version.
+func StoreOrgContextBundle(orgId string, bundle *shared.OrgContextBundle) error {
+	encryptedBodies := make([]string, len(bundle.Files))
+	for i, file := range bundle.Files {
+		numTokens, err := shared.GetNumTokens(file.Body)
+		if err != nil {
+			return fmt.Errorf("error getting num tokens for %s: %v", file.Name, err)
+		}
+		file.NumTokens = numTokens
+
+		encryptedBodies[i], err = encryptOrgString(orgId, file.Body)
+		if err != nil {
+			return fmt.Errorf("error encrypting context bundle file %s: %v", file.Name, err)
+		}
+	}
+
+	tx, err := Conn.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	query := `INSERT INTO org_context_bundles (org_id, name, description)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (org_id, name) DO UPDATE SET
+		description = excluded.description,
+		version = org_context_bundles.version + 1
+	RETURNING id, version`
+
+	var bundleId string
+	err = tx.QueryRow(query, orgId, bundle.Name, bundle.Description).Scan(&bundleId, &bundle.Version)
+	if err != nil {
+		return fmt.Errorf("error storing context bundle: %v", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM org_context_bundle_files WHERE bundle_id = $1", bundleId)
+	if err != nil {
+		return fmt.Errorf("error removing context bundle files: %v", err)
+	}
+
+	for i, file := range bundle.Files {
+		_, err = tx.Exec("INSERT INTO org_context_bundle_files (bundle_id, name, body, num_tokens) VALUES ($1, $2, $3, $4)", bundleId, file.Name, encryptedBodies[i], file.NumTokens)
+		if err != nil {
+			return fmt.Errorf("error storing context bundle file %s: %v", file.Name, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteOrgContextBundle deletes the bundle and its files. It returns false if the org doesn't have a bundle with the name. Plans it was attached to keep their copies until they're synced.
+func DeleteOrgContextBundle(orgId, name string) (bool, error) {
+	res, err := Conn.Exec("DELETE FROM org_context_bundles WHERE org_id = $1 AND name = $2", orgId, name)
+	if err != nil {
+		return false, fmt.Errorf("error deleting context bundle: %v", err)
+	}
+
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return numRows > 0, nil
+}
+
+type SyncPlanContextBundlesParams struct {
+	OrgId      string
+	UserId     string
+	Plan       *Plan
+	BranchName string
+	// bundles to attach if the plan doesn't have them yet. Bundles that are already attached are always updated to their latest versions.
+	Attach []string
+}
+
+// SyncPlanContextBundles attaches bundles to a plan and updates the ones it already has to their latest versions. Each bundle file is loaded as a note named like 'bundle/file'. Contexts from bundles that were deleted are removed. The repo must be locked for writing, and the changes are committed.
+func SyncPlanContextBundles(params SyncPlanContextBundlesParams) (*shared.SyncContextBundlesResponse, error) {
+	orgId := params.OrgId
+	plan := params.Plan
+	planId := plan.Id
+	branchName := params.BranchName
+
+	bundles, err := ListOrgContextBundles(orgId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts, err := GetPlanContexts(orgId, planId, false)
+	if err != nil {
+		return nil, fmt.Errorf("error getting contexts: %v", err)
+	}
+
+	attachedVersions := map[string]int{}
+	for _, context := range contexts {
+		if context.BundleName != "" {
+			attachedVersions[context.BundleName] = context.BundleVersion
+		}
+	}
+
+	toAttach := map[string]bool{}
+	for _, name := range params.Attach {
+		toAttach[name] = true
+	}
+
+	bundlesByName := map[string]*shared.OrgContextBundle{}
+	var synced []*shared.OrgContextBundle
+	for _, bundle := range bundles {
+		bundlesByName[bundle.Name] = bundle
+
+		version, attached := attachedVersions[bundle.Name]
+		if (attached && version != bundle.Version) || (!attached && toAttach[bundle.Name]) {
+			synced = append(synced, bundle)
+		}
+	}
+
+	for _, name := range params.Attach {
+		if bundlesByName[name] == nil {
+			return nil, fmt.Errorf("context bundle %s not found", name)
+		}
+	}
+
+	var toRemove []*Context
+	tokensRemoved := 0
+	for _, context := range contexts {
+		if context.BundleName == "" {
+			continue
+		}
+		bundle := bundlesByName[context.BundleName]
+		if bundle == nil || bundle.Version != context.BundleVersion {
+			toRemove = append(toRemove, context)
+			tokensRemoved += context.NumTokens
+		}
+	}
+
+	res := &shared.SyncContextBundlesResponse{Synced: []string{}}
+
+	if len(toRemove) == 0 && len(synced) == 0 {
+		return res, nil
+	}
+
+	var loadReq shared.LoadContextRequest
+	tokensAdded := 0
+	for _, bundle := range synced {
+		res.Synced = append(res.Synced, bundle.Name)
+		for _, file := range bundle.Files {
+			loadReq = append(loadReq, &shared.LoadContextParams{
+				ContextType:   shared.ContextNoteType,
+				Name:          bundle.ContextName(file),
+				Body:          file.Body,
+				BundleName:    bundle.Name,
+				BundleVersion: bundle.Version,
+			})
+			tokensAdded += file.NumTokens
+		}
+	}
+
+	branch, err := GetDbBranch(planId, branchName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting branch: %v", err)
+	}
+
+	settings, err := GetPlanSettings(plan, true)
+	if err != nil {
+		return nil, fmt.Errorf("error getting settings: %v", err)
+	}
+
+	// checked before anything is removed so a bundle that's grown too large doesn't leave the plan without its old version
+	totalTokens := branch.ContextTokens - tokensRemoved + tokensAdded
+	maxTokens := settings.GetPlannerEffectiveMaxTokens()
+	if settings.GetPlannerTokens(totalTokens) > maxTokens {
+		return nil, fmt.Errorf("context bundles would put the plan at %s tokens, over the maximum of %d", shared.FormatTokens(settings.GetPlannerTokens(totalTokens), settings.GetPlannerTokenizer().IsEstimate()), maxTokens)
+	}
+
+	if len(toRemove) > 0 {
+		err = ContextRemove(orgId, planId, toRemove)
+		if err != nil {
+			return nil, fmt.Errorf("error removing bundle contexts: %v", err)
+		}
+
+		err = AddPlanContextTokens(planId, branchName, -tokensRemoved)
+		if err != nil {
+			return nil, fmt.Errorf("error updating plan context tokens: %v", err)
+		}
+	}
+
+	if len(loadReq) > 0 {
+		loadRes, _, err := LoadContexts(LoadContextsParams{
+			Req:                      &loadReq,
+			OrgId:                    orgId,
+			Plan:                     plan,
+			BranchName:               branchName,
+			UserId:                   params.UserId,
+			SkipConflictInvalidation: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error loading bundle contexts: %v", err)
+		}
+		if loadRes.MaxTokensExceeded {
+			return nil, fmt.Errorf("context bundles would put the plan at %d tokens, over the maximum of %d", loadRes.TotalTokens, loadRes.MaxTokens)
+		}
+	}
+
+	res.TokensDiff = tokensAdded - tokensRemoved
+	res.TotalTokens = totalTokens
+
+	msg := fmt.Sprintf("📚 Synced context bundles | %+d 🪙 | total → %d 🪙", res.TokensDiff, totalTokens)
+	if len(res.Synced) > 0 {
+		msg += "\n\n"
+		for _, bundle := range synced {
+			msg += fmt.Sprintf("• %s v%d\n", bundle.Name, bundle.Version)
+		}
+	}
+
+	err = GitAddAndCommit(orgId, planId, branchName, msg)
+	if err != nil {
+		return nil, fmt.Errorf("error committing changes: %v", err)
+	}
+
+	return res, nil
+}
@@ -4,7 +4,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
@@ -18,6 +20,25 @@ import (
 
 func GetPlanContexts(orgId, planId string, includeBody bool) ([]*Context, error) {
 	var contexts []*Context
+
+	if !includeBody {
+		// metadata only -- serve from the context index
+		contextsById, err := GetPlanContextIndex(orgId, planId)
+		if err != nil {
+			return nil, fmt.Errorf("error getting context index: %v", err)
+		}
+
+		for _, context := range contextsById {
+			contexts = append(contexts, context)
+		}
+
+		sort.Slice(contexts, func(i, j int) bool {
+			return contexts[i].CreatedAt.Before(contexts[j].CreatedAt)
+		})
+
+		return contexts, nil
+	}
+
 	contextDir := getPlanContextDir(orgId, planId)
 
 	// get all context files
@@ -30,8 +51,15 @@ func GetPlanContexts(orgId, planId string, includeBody bool) ([]*Context, error)
 		return nil, fmt.Errorf("error reading context dir: %v", err)
 	}
 
-	errCh := make(chan error, len(files)/2)
-	contextCh := make(chan *Context, len(files)/2)
+	numContexts := 0
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".meta") {
+			numContexts++
+		}
+	}
+
+	errCh := make(chan error, numContexts)
+	contextCh := make(chan *Context, numContexts)
 
 	// read each context file
 	for _, file := range files {
@@ -49,7 +77,7 @@ func GetPlanContexts(orgId, planId string, includeBody bool) ([]*Context, error)
 		}
 	}
 
-	for i := 0; i < len(files)/2; i++ {
+	for i := 0; i < numContexts; i++ {
 		select {
 		case err := <-errCh:
 			return nil, fmt.Errorf("error reading context files: %v", err)
@@ -84,12 +112,16 @@ func GetContext(orgId, planId, contextId string, includeBody bool) (*Context, er
 	}
 
 	if includeBody {
-		// read the body file
+		// contexts stored before the blob store have a body file in the plan dir
 		bodyPath := filepath.Join(contextDir, strings.TrimSuffix(contextId, ".meta")+".body")
 		bodyBytes, err := os.ReadFile(bodyPath)
 
+		if os.IsNotExist(err) {
+			bodyBytes, err = readContextBlob(orgId, context.Sha)
+		}
+
 		if err != nil {
-			return nil, fmt.Errorf("error reading context body file: %v", err)
+			return nil, fmt.Errorf("error reading context body: %v", err)
 		}
 
 		context.Body = string(bodyBytes)
@@ -110,7 +142,12 @@ func ContextRemove(orgId, planId string, contexts []*Context) error {
 		contextDir := getPlanContextDir(orgId, planId)
 		for _, ext := range []string{".meta", ".body"} {
 			go func(context *Context, dir, ext string) {
-				errCh <- os.Remove(filepath.Join(dir, context.Id+ext))
+				err := os.Remove(filepath.Join(dir, context.Id+ext))
+				// body files only exist for contexts stored before the blob store
+				if os.IsNotExist(err) && ext == ".body" {
+					err = nil
+				}
+				errCh <- err
 			}(context, contextDir, ext)
 		}
 	}
@@ -148,24 +185,31 @@ func StoreContext(context *Context) error {
 	metaFilename := context.Id + ".meta"
 	metaPath := filepath.Join(contextDir, metaFilename)
 
-	originalBody := context.Body
-	originalBody = strings.ReplaceAll(originalBody, "\\`\\`\\`", "\\\\`\\\\`\\\\`")
-	originalBody = strings.ReplaceAll(originalBody, "```", "\\`\\`\\`")
+	originalBody := escapeContextBody(context.Body)
 
 	bodyFilename := context.Id + ".body"
 	bodyPath := filepath.Join(contextDir, bodyFilename)
 	body := []byte(originalBody)
 	context.Body = ""
 
+	if context.Sha == "" {
+		context.Sha = shared.ContextSha(originalBody)
+	}
+
 	// Convert the ModelContextPart to JSON
 	data, err := json.MarshalIndent(context, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal context context: %v", err)
 	}
 
-	// Write the body to the file
-	if err = os.WriteFile(bodyPath, body, 0644); err != nil {
-		return fmt.Errorf("failed to write context body to file %s: %v", bodyPath, err)
+	// Write the body to the org's blob store
+	if err = storeContextBlob(context.OrgId, context.Sha, body); err != nil {
+		return fmt.Errorf("failed to store context body: %v", err)
+	}
+
+	// Remove any body file from before the blob store so it isn't read instead of the blob
+	if err = os.Remove(bodyPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove context body file %s: %v", bodyPath, err)
 	}
 
 	// Write the meta data to the file
@@ -243,6 +287,22 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 			return nil, nil, fmt.Errorf("error getting num tokens: %v", err)
 		}
 
+		// clients that don't trim piped output themselves get the same trimming the CLI does
+		if context.ContextType == shared.ContextPipedDataType && numTokens > shared.MaxPipedDataTokens {
+			trimmed, numTrimmed, err := shared.TrimPipedData(context.Body)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error trimming piped data: %v", err)
+			}
+
+			log.Printf("Trimmed %d lines of piped data over %d tokens\n", numTrimmed, shared.MaxPipedDataTokens)
+
+			context.Body = trimmed
+			numTokens, err = shared.GetNumTokens(trimmed)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error getting num tokens: %v", err)
+			}
+		}
+
 		paramsByTempId[tempId] = context
 		numTokensByTempId[tempId] = numTokens
 
@@ -250,12 +310,14 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 		totalTokens += numTokens
 	}
 
-	if totalTokens > maxTokens {
+	// stored counts are cl100k counts, so they're converted to the planner's tokenizer to compare with its limit
+	if settings.GetPlannerTokens(totalTokens) > maxTokens {
 		return &shared.LoadContextResponse{
-			TokensAdded:       tokensAdded,
-			TotalTokens:       totalTokens,
+			TokensAdded:       settings.GetPlannerTokens(tokensAdded),
+			TotalTokens:       settings.GetPlannerTokens(totalTokens),
 			MaxTokens:         maxTokens,
 			MaxTokensExceeded: true,
+			TokensEstimated:   settings.GetPlannerTokenizer().IsEstimate(),
 		}, nil, nil
 	}
 
@@ -277,10 +339,14 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 				Url:             params.Url,
 				FilePath:        params.FilePath,
 				NumTokens:       numTokensByTempId[tempId],
+				NumTokensO200k:  getContextO200kTokens(params.ContextType, params.Body),
 				Sha:             sha,
 				Body:            params.Body,
 				ForceSkipIgnore: params.ForceSkipIgnore,
 				ImageDetail:     params.ImageDetail,
+				Encoding:        params.Encoding,
+				BundleName:      params.BundleName,
+				BundleVersion:   params.BundleVersion,
 			}
 
 			err := StoreContext(&context)
@@ -328,6 +394,114 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 	}, dbContexts, nil
 }
 
+var ErrContextDeltaMismatch = errors.New("context changed since delta was computed")
+
+// ResolveContextDeltas applies any deltas in an update request to the current context bodies, replacing each delta with the full updated body. Returns ErrContextDeltaMismatch if a delta's base doesn't match the stored body, in which case the client should resend full bodies.
+func ResolveContextDeltas(orgId, planId string, req *shared.UpdateContextRequest) error {
+	for id, params := range *req {
+		if params.Delta == nil {
+			continue
+		}
+
+		context, err := GetContext(orgId, planId, id, true)
+		if err != nil {
+			return fmt.Errorf("error getting context: %v", err)
+		}
+
+		if context.Sha != params.Delta.BaseSha {
+			return ErrContextDeltaMismatch
+		}
+
+		updated, err := shared.ApplyContextDelta(unescapeContextBody(context.Body), params.Delta)
+		if err != nil {
+			log.Printf("Error applying context delta for %s: %v\n", id, err)
+			return ErrContextDeltaMismatch
+		}
+
+		params.Body = updated
+		params.Delta = nil
+	}
+
+	return nil
+}
+
+// Context bodies are stored with triple backticks escaped, so they can't end a code block in the prompt. The escaping works on groups of three or more adjacent backticks, each with the run of backslashes before it: the number of backslashes before each backtick is doubled, plus one for the backticks of each ```, so ``` becomes \`\`\` and \`\`\` becomes \\`\\`\\`. Smaller groups can't form ``` and are left as they are. Unescaping halves each count, so it always gives back the original body, which deltas are computed against.
+
+func escapeContextBody(body string) string {
+	return transformBacktickGroups(body, func(levels []int) []int {
+		if len(levels) < 3 {
+			return levels
+		}
+
+		res := make([]int, len(levels))
+		for i, n := range levels {
+			res[i] = n * 2
+		}
+
+		// a backtick followed by two unescaped backticks is ```, matched from the left like a plain replace
+		for i := 0; i+2 < len(levels); {
+			if levels[i+1] == 0 && levels[i+2] == 0 {
+				res[i]++
+				res[i+1]++
+				res[i+2]++
+				i += 3
+			} else {
+				i++
+			}
+		}
+
+		return res
+	})
+}
+
+func unescapeContextBody(body string) string {
+	return transformBacktickGroups(body, func(levels []int) []int {
+		if len(levels) < 3 {
+			return levels
+		}
+
+		res := make([]int, len(levels))
+		for i, n := range levels {
+			res[i] = n / 2
+		}
+		return res
+	})
+}
+
+// transformBacktickGroups replaces the number of backslashes before each backtick in every group of adjacent backticks
+func transformBacktickGroups(body string, transform func(levels []int) []int) string {
+	var res strings.Builder
+
+	for i := 0; i < len(body); {
+		var levels []int
+		j := i
+		for {
+			n := 0
+			for j+n < len(body) && body[j+n] == '\\' {
+				n++
+			}
+			if j+n >= len(body) || body[j+n] != '`' {
+				break
+			}
+			levels = append(levels, n)
+			j += n + 1
+		}
+
+		if len(levels) == 0 {
+			res.WriteByte(body[i])
+			i++
+			continue
+		}
+
+		for _, n := range transform(levels) {
+			res.WriteString(strings.Repeat("\\", n) + "`")
+		}
+		i = j
+	}
+
+	return res.String()
+}
+
 type UpdateContextsParams struct {
 	Req                      *shared.UpdateContextRequest
 	OrgId                    string
@@ -422,6 +596,7 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 			totalTokens += tokenDiff
 
 			context.NumTokens = updateNumTokens
+			context.NumTokensO200k = getContextO200kTokens(context.ContextType, params.Body)
 
 			switch context.ContextType {
 			case shared.ContextFileType:
@@ -454,12 +629,13 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 		MaxTokens:       maxTokens,
 	}
 
-	if totalTokens > maxTokens {
+	if settings.GetPlannerTokens(totalTokens) > maxTokens {
 		return &shared.UpdateContextResponse{
-			TokensAdded:       tokensDiff,
-			TotalTokens:       totalTokens,
+			TokensAdded:       settings.GetPlannerTokens(tokensDiff),
+			TotalTokens:       settings.GetPlannerTokens(totalTokens),
 			MaxTokens:         maxTokens,
 			MaxTokensExceeded: true,
+			TokensEstimated:   settings.GetPlannerTokenizer().IsEstimate(),
 		}, nil
 	}
 
@@ -551,7 +727,7 @@ func invalidateConflictedResults(orgId, planId string, filesToUpdate map[string]
 				continue
 			}
 
-			for _, path := range desc.Files {
+			for _, path := range desc.ToApi().BuildPaths() {
 				if _, found := conflictPaths[path]; found {
 					if desc.BuildPathsInvalidated == nil {
 						desc.BuildPathsInvalidated = make(map[string]bool)
@@ -598,3 +774,17 @@ func invalidateConflictedResults(orgId, planId string, filesToUpdate map[string]
 
 	return nil
 }
+
+// getContextO200kTokens counts a context's body for GPT-4o models. If the o200k encoding can't be loaded, the error is logged and the count is left at 0, so the context's cl100k count is converted instead.
+func getContextO200kTokens(contextType shared.ContextType, body string) int {
+	if contextType == shared.ContextImageType {
+		return 0
+	}
+
+	numTokens, err := shared.TokenizerO200k.CountTokens(body)
+	if err != nil {
+		log.Printf("Error counting o200k tokens: %v\n", err)
+		return 0
+	}
+	return numTokens
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestEscapeContextBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "no backticks", body: "func main() {}\n", want: "func main() {}\n"},
+		{name: "single backticks", body: "use `go test` and \\`date\\`", want: "use `go test` and \\`date\\`"},
+		{name: "code block", body: "a\n```go\nx\n```\n", want: "a\n\\`\\`\\`go\nx\n\\`\\`\\`\n"},
+		{name: "four backticks", body: "````md", want: "\\`\\`\\``md"},
+		{name: "six backticks", body: "``````", want: "\\`\\`\\`\\`\\`\\`"},
+		{name: "escaped delimiter", body: "\\`\\`\\`", want: "\\\\`\\\\`\\\\`"},
+		{name: "double escaped delimiter", body: "\\\\`\\\\`\\\\`", want: "\\\\\\\\`\\\\\\\\`\\\\\\\\`"},
+		{name: "backslash before delimiter", body: "\\```", want: "\\\\\\`\\`\\`"},
+		{name: "five backticks", body: "`````", want: "\\`\\`\\```"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := escapeContextBody(test.body)
+			if got != test.want {
+				t.Errorf("escapeContextBody(%q) = %q, want %q", test.body, got, test.want)
+			}
+			if unescaped := unescapeContextBody(got); unescaped != test.body {
+				t.Errorf("unescapeContextBody(%q) = %q, want %q", got, unescaped, test.body)
+			}
+		})
+	}
+}
+
+func TestEscapeContextBodyRoundTrip(t *testing.T) {
+	// every body up to 8 bytes made of backslashes, backticks, and other characters
+	alphabet := []byte{'\\', '`', 'a', '\n'}
+
+	var check func(body []byte, depth int)
+	check = func(body []byte, depth int) {
+		escaped := escapeContextBody(string(body))
+		if unescaped := unescapeContextBody(escaped); unescaped != string(body) {
+			t.Fatalf("round trip of %q gave %q (escaped %q)", body, unescaped, escaped)
+		}
+
+		if depth == 0 {
+			return
+		}
+		for _, c := range alphabet {
+			check(append(body, c), depth-1)
+		}
+	}
+
+	check(nil, 8)
+}
+
+func TestEscapeContextBodyLegacy(t *testing.T) {
+	// bodies stored before the escaping was reversible still unescape for deltas
+	for _, body := range []string{"```", "````", "a\n```go\nx\n```\n", "\\`\\`\\`", "x \\`\\`\\` ```"} {
+		legacy := strings.ReplaceAll(body, "\\`\\`\\`", "\\\\`\\\\`\\\\`")
+		legacy = strings.ReplaceAll(legacy, "```", "\\`\\`\\`")
+
+		if got := unescapeContextBody(legacy); got != body {
+			t.Errorf("unescapeContextBody(%q) = %q, want %q", legacy, got, body)
+		}
+	}
+}
+
+func TestContextDeltaWithEscapedBody(t *testing.T) {
+	base := "# Docs\n\n```go\nfmt.Println(\"\\`\\`\\`\")\n```\n\n\\\\`\\\\`\\\\`\n"
+	updated := base + "````md\nmore\n````\n"
+
+	delta := shared.ComputeContextDelta(base, updated)
+
+	stored := escapeContextBody(base)
+	got, err := shared.ApplyContextDelta(unescapeContextBody(stored), delta)
+	if err != nil {
+		t.Fatalf("error applying delta to the stored body: %v", err)
+	}
+	if got != updated {
+		t.Errorf("applying the delta gave %q, want %q", got, updated)
+	}
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The context index caches the metadata of every context in a plan, keyed by context id, so listing context and checking hashes doesn't require reading and parsing a meta file per context. Each entry records the size and mod time of the meta file it was built from; any mismatch (including from a branch checkout) causes the entry to be re-read.
+
+type contextIndexEntry struct {
+	MetaSize    int64     `json:"metaSize"`
+	MetaModTime time.Time `json:"metaModTime"`
+	Context     *Context  `json:"context"`
+}
+
+type contextIndex map[string]*contextIndexEntry
+
+// the index is a server cache, so it's kept outside the plan's repo where it would be committed with the plan
+func getPlanContextIndexPath(orgId, planId string) string {
+	return filepath.Join(getOrgDir(orgId), "context-index", planId+".json")
+}
+
+// older versions kept the index in the plan's repo. Removing it there means the next commit drops it from the plan.
+func removeLegacyPlanContextIndex(orgId, planId string) {
+	err := os.Remove(filepath.Join(getPlanDir(orgId, planId), "context_index.json"))
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing legacy context index for plan %s: %v\n", planId, err)
+	}
+}
+
+// GetPlanContextIndex returns context metadata (without bodies) keyed by context id, refreshing the on-disk index for any contexts that changed since it was written.
+func GetPlanContextIndex(orgId, planId string) (map[string]*Context, error) {
+	contextDir := getPlanContextDir(orgId, planId)
+	indexPath := getPlanContextIndexPath(orgId, planId)
+
+	removeLegacyPlanContextIndex(orgId, planId)
+
+	files, err := os.ReadDir(contextDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]*Context{}, nil
+		}
+		return nil, fmt.Errorf("error reading context dir: %v", err)
+	}
+
+	index := contextIndex{}
+	indexBytes, err := os.ReadFile(indexPath)
+	if err == nil {
+		err = json.Unmarshal(indexBytes, &index)
+		if err != nil {
+			// a corrupt index is rebuilt rather than treated as an error
+			index = contextIndex{}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading context index: %v", err)
+	}
+
+	res := map[string]*Context{}
+	updated := contextIndex{}
+	changed := false
+
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".meta") {
+			continue
+		}
+
+		id := strings.TrimSuffix(file.Name(), ".meta")
+
+		info, err := file.Info()
+		if err != nil {
+			return nil, fmt.Errorf("error getting context meta file info: %v", err)
+		}
+
+		entry := index[id]
+		if entry == nil || entry.Context == nil || entry.MetaSize != info.Size() || !entry.MetaModTime.Equal(info.ModTime()) {
+			context, err := GetContext(orgId, planId, id, false)
+			if err != nil {
+				return nil, fmt.Errorf("error getting context: %v", err)
+			}
+
+			entry = &contextIndexEntry{
+				MetaSize:    info.Size(),
+				MetaModTime: info.ModTime(),
+				Context:     context,
+			}
+			changed = true
+		}
+
+		updated[id] = entry
+		res[id] = entry.Context
+	}
+
+	if len(updated) != len(index) {
+		changed = true
+	}
+
+	if changed {
+		err = writeContextIndex(indexPath, updated)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func writeContextIndex(indexPath string, index contextIndex) error {
+	bytes, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("error marshalling context index: %v", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(indexPath), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating context index dir: %v", err)
+	}
+
+	// write to a temp file and rename so concurrent readers never see a partial index
+	tmpFile, err := os.CreateTemp(filepath.Dir(indexPath), "context_index-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating context index temp file: %v", err)
+	}
+	tmpPath := tmpFile.Name()
+
+	_, err = tmpFile.Write(bytes)
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error writing context index: %v", err)
+	}
+
+	err = os.Rename(tmpPath, indexPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error renaming context index: %v", err)
+	}
+
+	return nil
+}
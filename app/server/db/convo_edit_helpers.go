@@ -0,0 +1,226 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// UpdateConvoMessages rewrites stored conversation messages in place, keeping their ids, numbers, and creation times
+func UpdateConvoMessages(orgId, planId string, messages []*ConvoMessage) error {
+	convoDir := getPlanConversationDir(orgId, planId)
+
+	for _, message := range messages {
+		bytes, err := json.Marshal(message)
+
+		if err != nil {
+			return fmt.Errorf("error marshalling convo message: %v", err)
+		}
+
+		err = writeOrgFile(orgId, filepath.Join(convoDir, message.Id+".json"), bytes, os.ModePerm)
+
+		if err != nil {
+			return fmt.Errorf("error writing convo message: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteConvoMessages removes messages from the conversation. They stay in the plan's git history.
+func DeleteConvoMessages(orgId, planId string, messageIds map[string]bool) error {
+	convoDir := getPlanConversationDir(orgId, planId)
+
+	for id := range messageIds {
+		err := os.Remove(filepath.Join(convoDir, id+".json"))
+
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing convo message: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// MarkResultsStale flags the file results built from the given messages' replies. Returns the number of results flagged.
+func MarkResultsStale(orgId, planId string, messageIds map[string]bool) (int, error) {
+	results, err := GetPlanFileResults(orgId, planId)
+
+	if err != nil {
+		return 0, fmt.Errorf("error getting plan file results: %v", err)
+	}
+
+	var stale []*PlanFileResult
+	for _, result := range results {
+		if messageIds[result.ConvoMessageId] && !result.Stale {
+			result.Stale = true
+			stale = append(stale, result)
+		}
+	}
+
+	err = writeResults(orgId, planId, stale)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(stale), nil
+}
+
+// RejectStaleResults rejects stale results that haven't been applied or rejected yet, so replayed replies aren't built on top of them
+func RejectStaleResults(orgId, planId string, now time.Time) error {
+	results, err := GetPlanFileResults(orgId, planId)
+
+	if err != nil {
+		return fmt.Errorf("error getting plan file results: %v", err)
+	}
+
+	var rejected []*PlanFileResult
+	for _, result := range results {
+		if result.Stale && result.AppliedAt == nil && result.RejectedAt == nil {
+			result.RejectedAt = &now
+			rejected = append(rejected, result)
+		}
+	}
+
+	return writeResults(orgId, planId, rejected)
+}
+
+func writeResults(orgId, planId string, results []*PlanFileResult) error {
+	resultsDir := getPlanResultsDir(orgId, planId)
+
+	for _, result := range results {
+		bytes, err := json.MarshalIndent(result, "", "  ")
+
+		if err != nil {
+			return fmt.Errorf("error marshalling result: %v", err)
+		}
+
+		err = writeOrgFile(orgId, filepath.Join(resultsDir, result.Id+".json"), bytes, 0644)
+
+		if err != nil {
+			return fmt.Errorf("error writing result file: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// MarkDescriptionsStale flags the pending descriptions of the given messages' replies so their files aren't built
+func MarkDescriptionsStale(orgId, planId string, messageIds map[string]bool) error {
+	descriptions, err := GetConvoMessageDescriptions(orgId, planId)
+
+	if err != nil {
+		return fmt.Errorf("error getting convo message descriptions: %v", err)
+	}
+
+	for _, desc := range descriptions {
+		if messageIds[desc.ConvoMessageId] && !desc.Stale {
+			desc.Stale = true
+
+			err = StoreDescription(desc)
+
+			if err != nil {
+				return fmt.Errorf("error storing description: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeleteDescriptionsForMessages removes the descriptions of the given messages' replies
+func DeleteDescriptionsForMessages(orgId, planId string, messageIds map[string]bool) error {
+	descriptionsDir := getPlanDescriptionsDir(orgId, planId)
+
+	files, err := os.ReadDir(descriptionsDir)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("error reading descriptions dir: %v", err)
+	}
+
+	for _, file := range files {
+		path := filepath.Join(descriptionsDir, file.Name())
+
+		bytes, err := os.ReadFile(path)
+
+		if err != nil {
+			return fmt.Errorf("error reading description file %s: %v", file.Name(), err)
+		}
+
+		var desc ConvoMessageDescription
+		err = json.Unmarshal(bytes, &desc)
+
+		if err != nil {
+			return fmt.Errorf("error unmarshalling description file %s: %v", file.Name(), err)
+		}
+
+		if messageIds[desc.ConvoMessageId] {
+			err = os.Remove(path)
+
+			if err != nil {
+				return fmt.Errorf("error removing description file %s: %v", file.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetReplayPrompts returns the prompts still waiting to be replayed by 'plandex rerun'
+func GetReplayPrompts(orgId, planId string) ([]string, error) {
+	var prompts []string
+
+	bytes, err := readOrgFile(orgId, getPlanReplayPath(orgId, planId))
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return prompts, nil
+		}
+
+		return nil, fmt.Errorf("error reading replay file: %v", err)
+	}
+
+	err = json.Unmarshal(bytes, &prompts)
+
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling replay file: %v", err)
+	}
+
+	return prompts, nil
+}
+
+// StoreReplayPrompts saves the prompts waiting to be replayed, removing the file once there are none left
+func StoreReplayPrompts(orgId, planId string, prompts []string) error {
+	path := getPlanReplayPath(orgId, planId)
+
+	if len(prompts) == 0 {
+		err := os.Remove(path)
+
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing replay file: %v", err)
+		}
+
+		return nil
+	}
+
+	bytes, err := json.Marshal(prompts)
+
+	if err != nil {
+		return fmt.Errorf("error marshalling replay prompts: %v", err)
+	}
+
+	err = writeOrgFile(orgId, path, bytes, os.ModePerm)
+
+	if err != nil {
+		return fmt.Errorf("error writing replay file: %v", err)
+	}
+
+	return nil
+}
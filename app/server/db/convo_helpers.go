@@ -31,7 +31,7 @@ func GetPlanConvo(orgId, planId string) ([]*ConvoMessage, error) {
 
 	for _, file := range files {
 		go func(file os.DirEntry) {
-			bytes, err := os.ReadFile(filepath.Join(convoDir, file.Name()))
+			bytes, err := readOrgFile(orgId, filepath.Join(convoDir, file.Name()))
 
 			if err != nil {
 				errCh <- fmt.Errorf("error reading convo file: %v", err)
@@ -90,7 +90,7 @@ func StoreConvoMessage(message *ConvoMessage, currentUserId, branch string, comm
 		return "", fmt.Errorf("error creating convo dir: %v", err)
 	}
 
-	err = os.WriteFile(filepath.Join(convoDir, message.Id+".json"), bytes, os.ModePerm)
+	err = writeOrgFile(message.OrgId, filepath.Join(convoDir, message.Id+".json"), bytes, os.ModePerm)
 
 	if err != nil {
 		return "", fmt.Errorf("error writing convo message: %v", err)
@@ -0,0 +1,406 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"plandex-server/storage"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// ExportOrgData exports all data for an org in the shared.DataExport format. If userId is set, the export is limited to that user and the plans they own. requestUserId is the user making the request, which is needed to take read locks on each plan.
+func ExportOrgData(orgId, userId, requestUserId string) (*shared.DataExport, error) {
+	org, err := GetOrg(orgId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting org: %v", err)
+	}
+
+	export := &shared.DataExport{
+		FormatVersion: shared.DataExportFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		Org:           org.ToApi(),
+		Users:         []*shared.User{},
+		OrgUsers:      []*shared.OrgUser{},
+		Invites:       []*shared.Invite{},
+		Projects:      []*shared.Project{},
+		Plans:         []*shared.PlanExport{},
+	}
+
+	var users []*User
+	var orgUsers []*OrgUser
+	var invites []*Invite
+	var projects []*Project
+	var plans []*Plan
+
+	if userId == "" {
+		users, err = ListUsers(orgId)
+		if err == nil {
+			err = Conn.Select(&orgUsers, "SELECT * FROM orgs_users WHERE org_id = $1", orgId)
+		}
+		if err == nil {
+			err = Conn.Select(&invites, "SELECT * FROM invites WHERE org_id = $1 ORDER BY created_at", orgId)
+		}
+		if err == nil {
+			err = Conn.Select(&projects, "SELECT * FROM projects WHERE org_id = $1 ORDER BY created_at", orgId)
+		}
+		if err == nil {
+			err = Conn.Select(&plans, "SELECT * FROM plans WHERE org_id = $1 ORDER BY created_at", orgId)
+		}
+	} else {
+		var user *User
+		user, err = GetUser(userId)
+		if err == nil && user != nil {
+			users = append(users, user)
+		}
+		if err == nil {
+			err = Conn.Select(&orgUsers, "SELECT * FROM orgs_users WHERE org_id = $1 AND user_id = $2", orgId, userId)
+		}
+		if err == nil {
+			err = Conn.Select(&invites, "SELECT * FROM invites WHERE org_id = $1 AND (inviter_id = $2 OR invitee_id = $2) ORDER BY created_at", orgId, userId)
+		}
+		if err == nil {
+			err = Conn.Select(&projects, "SELECT * FROM projects WHERE org_id = $1 AND id IN (SELECT project_id FROM plans WHERE org_id = $1 AND owner_id = $2) ORDER BY created_at", orgId, userId)
+		}
+		if err == nil {
+			err = Conn.Select(&plans, "SELECT * FROM plans WHERE org_id = $1 AND owner_id = $2 ORDER BY created_at", orgId, userId)
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error exporting org data: %v", err)
+	}
+
+	for _, user := range users {
+		export.Users = append(export.Users, user.ToApi())
+	}
+	for _, orgUser := range orgUsers {
+		export.OrgUsers = append(export.OrgUsers, orgUser.ToApi())
+	}
+	for _, invite := range invites {
+		export.Invites = append(export.Invites, invite.ToApi())
+	}
+	for _, project := range projects {
+		export.Projects = append(export.Projects, project.ToApi())
+	}
+
+	if userId == "" {
+		err = exportOrgPolicies(export, orgId)
+		if err != nil {
+			return nil, fmt.Errorf("error exporting org policies: %v", err)
+		}
+	}
+
+	entries, err := ListKnowledgeEntries(orgId)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if userId != "" && entry.UserId != userId {
+			continue
+		}
+		export.KnowledgeEntries = append(export.KnowledgeEntries, &shared.KnowledgeEntryExport{
+			UserId: entry.UserId,
+			Entry:  entry.ToApi(),
+		})
+	}
+
+	prefs, err := ListLearnedPreferences(orgId, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range prefs {
+		prefsExport := &shared.LearnedPreferencesExport{Preferences: item.ToApi()}
+		if item.UserId != nil {
+			prefsExport.UserId = *item.UserId
+		}
+		export.LearnedPreferences = append(export.LearnedPreferences, prefsExport)
+	}
+
+	feedback, err := ListChangeFeedback(orgId, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range feedback {
+		export.ChangeFeedback = append(export.ChangeFeedback, &shared.ChangeFeedbackExport{
+			UserId:    item.UserId,
+			PlanId:    item.PlanId,
+			Kind:      item.Kind,
+			Path:      item.Path,
+			Body:      item.Body,
+			CreatedAt: item.CreatedAt,
+		})
+	}
+
+	var digestSettings []*UserDigestSettings
+	if userId == "" {
+		err = Conn.Select(&digestSettings, "SELECT * FROM user_digest_settings WHERE user_id IN (SELECT user_id FROM orgs_users WHERE org_id = $1)", orgId)
+	} else {
+		err = Conn.Select(&digestSettings, "SELECT * FROM user_digest_settings WHERE user_id = $1", userId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting digest settings: %v", err)
+	}
+	for _, settings := range digestSettings {
+		export.DigestSettings = append(export.DigestSettings, &shared.UserDigestSettingsExport{
+			UserId:   settings.UserId,
+			Settings: settings.ToApi(),
+		})
+	}
+
+	for _, plan := range plans {
+		planExport, err := exportPlan(plan, requestUserId)
+		if err != nil {
+			return nil, fmt.Errorf("error exporting plan %s: %v", plan.Id, err)
+		}
+
+		export.Plans = append(export.Plans, planExport)
+
+		export.Usage.NumPlans++
+		export.Usage.TotalReplies += plan.TotalReplies
+		for _, branchExport := range planExport.Branches {
+			export.Usage.NumBranches++
+			export.Usage.NumMessages += len(branchExport.Conversation)
+			export.Usage.ContextTokens += branchExport.Branch.ContextTokens
+			export.Usage.ConvoTokens += branchExport.Branch.ConvoTokens
+		}
+	}
+
+	return export, nil
+}
+
+func exportOrgPolicies(export *shared.DataExport, orgId string) error {
+	var err error
+	export.TrustPolicy, err = GetOrgTrustPolicy(orgId)
+	if err != nil {
+		return err
+	}
+
+	var projectPolicies []*TrustPolicy
+	err = Conn.Select(&projectPolicies, "SELECT * FROM project_trust_policies WHERE org_id = $1 ORDER BY created_at", orgId)
+	if err != nil {
+		return fmt.Errorf("error getting project trust policies: %v", err)
+	}
+	for _, policy := range projectPolicies {
+		export.ProjectTrustPolicies = append(export.ProjectTrustPolicies, &shared.ProjectTrustPolicyExport{
+			ProjectId: *policy.ProjectId,
+			Policy:    policy.ToApi(),
+		})
+	}
+
+	export.ResponseCachePolicy, err = GetOrgResponseCachePolicy(orgId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func exportPlan(plan *Plan, requestUserId string) (*shared.PlanExport, error) {
+	settings, err := GetPlanSettings(plan, false)
+	if err != nil {
+		return nil, err
+	}
+
+	planExport := &shared.PlanExport{
+		Plan:     plan.ToApi(),
+		Settings: settings,
+		Branches: []*shared.BranchExport{},
+	}
+
+	branches, err := ListPlanBranches(plan.OrgId, plan.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, branch := range branches {
+		if branch.DeletedAt != nil {
+			continue
+		}
+
+		branchExport, err := exportBranch(plan, branch, requestUserId)
+		if err != nil {
+			return nil, fmt.Errorf("error exporting branch %s: %v", branch.Name, err)
+		}
+
+		planExport.Branches = append(planExport.Branches, branchExport)
+	}
+
+	return planExport, nil
+}
+
+func exportBranch(plan *Plan, branch *Branch, requestUserId string) (*shared.BranchExport, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// checks out the branch
+	repoLockId, err := LockRepo(LockRepoParams{
+		OrgId:    plan.OrgId,
+		UserId:   requestUserId,
+		PlanId:   plan.Id,
+		Branch:   branch.Name,
+		Scope:    LockScopeRead,
+		Ctx:      ctx,
+		CancelFn: cancel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error locking repo: %v", err)
+	}
+
+	defer func() {
+		err := DeleteRepoLock(repoLockId)
+		if err != nil {
+			log.Printf("Error unlocking repo: %v\n", err)
+		}
+	}()
+
+	branchExport := &shared.BranchExport{
+		Branch:       branch.ToApi(),
+		Conversation: []*shared.ConvoMessage{},
+		Contexts:     []*shared.Context{},
+	}
+
+	convo, err := GetPlanConvo(plan.OrgId, plan.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range convo {
+		branchExport.Conversation = append(branchExport.Conversation, msg.ToApi())
+	}
+
+	contexts, err := GetPlanContexts(plan.OrgId, plan.Id, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, context := range contexts {
+		branchExport.Contexts = append(branchExport.Contexts, context.ToApi())
+	}
+
+	return branchExport, nil
+}
+
+// blobs of deleted plans that were stored more recently than this may belong to a context that's being loaded into another plan right now, so they're left for the regular GC job
+const hardDeleteBlobGracePeriod = 1 * time.Minute
+
+// HardDeleteOrg permanently deletes an org along with all its plans, files, and context blobs
+func HardDeleteOrg(orgId string) error {
+	_, err := Conn.Exec("DELETE FROM orgs WHERE id = $1", orgId)
+	if err != nil {
+		return fmt.Errorf("error deleting org: %v", err)
+	}
+
+	forgetOrgDataKey(orgId)
+
+	err = os.RemoveAll(getOrgDir(orgId))
+	if err != nil {
+		return fmt.Errorf("error deleting org dir: %v", err)
+	}
+
+	// with remote blob storage, blobs (context bodies and build captures) aren't under the org dir
+	var keys []string
+	err = storage.Blobs.List(path.Join("orgs", orgId), func(key string, modTime time.Time) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error listing blobs: %v", err)
+	}
+
+	for _, key := range keys {
+		err = storage.Blobs.Delete(key)
+		if err != nil {
+			return fmt.Errorf("error deleting blob: %v", err)
+		}
+	}
+
+	log.Printf("Hard deleted org %s\n", orgId)
+
+	return nil
+}
+
+// HardDeleteUser permanently deletes a user, the plans they own in every org, and their memberships, invites, and auth tokens. Context blobs that are no longer referenced by any remaining plan are purged. A user who owns an org can't be deleted until the org is deleted.
+func HardDeleteUser(userId string) error {
+	var numOwnedOrgs int
+	err := Conn.Get(&numOwnedOrgs, "SELECT COUNT(*) FROM orgs WHERE owner_id = $1", userId)
+	if err != nil {
+		return fmt.Errorf("error checking owned orgs: %v", err)
+	}
+	if numOwnedOrgs > 0 {
+		return fmt.Errorf("user owns %d org(s) -- delete them first", numOwnedOrgs)
+	}
+
+	var plans []*Plan
+	err = Conn.Select(&plans, "SELECT * FROM plans WHERE owner_id = $1", userId)
+	if err != nil {
+		return fmt.Errorf("error listing plans: %v", err)
+	}
+
+	tx, err := Conn.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	// tables without cascading deletes on the user
+	for _, query := range []string{
+		"DELETE FROM plans WHERE owner_id = $1",
+		"DELETE FROM invites WHERE inviter_id = $1 OR invitee_id = $1",
+		"DELETE FROM email_verifications WHERE user_id = $1 OR email = (SELECT email FROM users WHERE id = $1)",
+		"DELETE FROM users WHERE id = $1",
+	} {
+		_, err = tx.Exec(query, userId)
+		if err != nil {
+			return fmt.Errorf("error deleting user data: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	deletedShasByOrg := map[string]map[string]bool{}
+	for _, plan := range plans {
+		if deletedShasByOrg[plan.OrgId] == nil {
+			deletedShasByOrg[plan.OrgId] = map[string]bool{}
+		}
+
+		var shas map[string]bool
+		shas, err = getPlanContextShas(plan.OrgId, plan.Id)
+		if err != nil {
+			return err
+		}
+		for sha := range shas {
+			deletedShasByOrg[plan.OrgId][sha] = true
+		}
+
+		err = DeletePlanDir(plan.OrgId, plan.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	for orgId, shas := range deletedShasByOrg {
+		_, err = purgeContextBlobs(orgId, shas, hardDeleteBlobGracePeriod)
+		if err != nil {
+			return fmt.Errorf("error purging context blobs: %v", err)
+		}
+	}
+
+	log.Printf("Hard deleted user %s and %d plan(s)\n", userId, len(plans))
+
+	return nil
+}
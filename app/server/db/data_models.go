@@ -1,6 +1,7 @@
 package db
 
 import (
+	"strings"
 	"time"
 
 	"github.com/plandex/plandex/shared"
@@ -62,29 +63,34 @@ func (user *User) ToApi() *shared.User {
 }
 
 type Invite struct {
-	Id         string     `db:"id"`
-	OrgId      string     `db:"org_id"`
-	Email      string     `db:"email"`
-	Name       string     `db:"name"`
-	InviterId  string     `db:"inviter_id"`
-	InviteeId  *string    `db:"invitee_id"`
-	OrgRoleId  string     `db:"org_role_id"`
-	AcceptedAt *time.Time `db:"accepted_at"`
-	CreatedAt  time.Time  `db:"created_at"`
-	UpdatedAt  time.Time  `db:"updated_at"`
+	Id          string     `db:"id"`
+	OrgId       string     `db:"org_id"`
+	Email       string     `db:"email"`
+	Name        string     `db:"name"`
+	InviterId   string     `db:"inviter_id"`
+	InviteeId   *string    `db:"invitee_id"`
+	OrgRoleId   string     `db:"org_role_id"`
+	TokenHash   *string    `db:"token_hash"`
+	ExpiresAt   *time.Time `db:"expires_at"`
+	EmailSentAt *time.Time `db:"email_sent_at"`
+	AcceptedAt  *time.Time `db:"accepted_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
 }
 
 func (invite *Invite) ToApi() *shared.Invite {
 	return &shared.Invite{
-		Id:         invite.Id,
-		OrgId:      invite.OrgId,
-		Email:      invite.Email,
-		Name:       invite.Name,
-		InviterId:  invite.InviterId,
-		InviteeId:  invite.InviteeId,
-		OrgRoleId:  invite.OrgRoleId,
-		AcceptedAt: invite.AcceptedAt,
-		CreatedAt:  invite.CreatedAt,
+		Id:          invite.Id,
+		OrgId:       invite.OrgId,
+		Email:       invite.Email,
+		Name:        invite.Name,
+		InviterId:   invite.InviterId,
+		InviteeId:   invite.InviteeId,
+		OrgRoleId:   invite.OrgRoleId,
+		ExpiresAt:   invite.ExpiresAt,
+		EmailSentAt: invite.EmailSentAt,
+		AcceptedAt:  invite.AcceptedAt,
+		CreatedAt:   invite.CreatedAt,
 	}
 }
 
@@ -121,17 +127,28 @@ func (project *Project) ToApi() *shared.Project {
 }
 
 type Plan struct {
-	Id              string     `db:"id"`
-	OrgId           string     `db:"org_id"`
-	OwnerId         string     `db:"owner_id"`
-	ProjectId       string     `db:"project_id"`
-	Name            string     `db:"name"`
-	SharedWithOrgAt *time.Time `db:"shared_with_org_at,omitempty"`
-	TotalReplies    int        `db:"total_replies"`
-	ActiveBranches  int        `db:"active_branches"`
-	ArchivedAt      *time.Time `db:"archived_at,omitempty"`
-	CreatedAt       time.Time  `db:"created_at"`
-	UpdatedAt       time.Time  `db:"updated_at"`
+	Id                 string     `db:"id"`
+	OrgId              string     `db:"org_id"`
+	OwnerId            string     `db:"owner_id"`
+	ProjectId          string     `db:"project_id"`
+	Name               string     `db:"name"`
+	SharedWithOrgAt    *time.Time `db:"shared_with_org_at,omitempty"`
+	TotalReplies       int        `db:"total_replies"`
+	ActiveBranches     int        `db:"active_branches"`
+	ArchivedAt         *time.Time `db:"archived_at,omitempty"`
+	DeletionNotifiedAt *time.Time `db:"deletion_notified_at,omitempty"`
+	CreatedAt          time.Time  `db:"created_at"`
+	UpdatedAt          time.Time  `db:"updated_at"`
+
+	// set when the plan was named from a prompt too short to say much about it, so it's named again after the next substantive prompt
+	AutoNamePending bool `db:"auto_name_pending"`
+
+	// loaded from plan_tags
+	Tags []string `db:"-"`
+
+	// loaded from plan_shares for the current user
+	SharedAccess shared.PlanShareAccess `db:"-"`
+	OrgName      string                 `db:"-"`
 }
 
 func (plan *Plan) ToApi() *shared.Plan {
@@ -144,8 +161,39 @@ func (plan *Plan) ToApi() *shared.Plan {
 		TotalReplies:    plan.TotalReplies,
 		ActiveBranches:  plan.ActiveBranches,
 		ArchivedAt:      plan.ArchivedAt,
+		Tags:            plan.Tags,
 		CreatedAt:       plan.CreatedAt,
 		UpdatedAt:       plan.UpdatedAt,
+		SharedAccess:    plan.SharedAccess,
+		OrgName:         plan.OrgName,
+	}
+}
+
+type PlanShare struct {
+	Id        string                 `db:"id"`
+	PlanId    string                 `db:"plan_id"`
+	OrgId     string                 `db:"org_id"`
+	UserId    string                 `db:"user_id"`
+	Access    shared.PlanShareAccess `db:"access"`
+	CreatedBy *string                `db:"created_by"`
+	UpdatedAt time.Time              `db:"updated_at"`
+	CreatedAt time.Time              `db:"created_at"`
+
+	// joined from users
+	UserEmail string `db:"user_email"`
+	UserName  string `db:"user_name"`
+}
+
+func (share *PlanShare) ToApi() *shared.PlanShare {
+	return &shared.PlanShare{
+		Id:        share.Id,
+		PlanId:    share.PlanId,
+		UserId:    share.UserId,
+		UserEmail: share.UserEmail,
+		UserName:  share.UserName,
+		Access:    share.Access,
+		CreatedAt: share.CreatedAt,
+		UpdatedAt: share.UpdatedAt,
 	}
 }
 
@@ -196,6 +244,16 @@ type ConvoSummary struct {
 	CreatedAt                   time.Time `db:"created_at"`
 }
 
+// FileChangeSummary is the summarizer's description of a file build's changes, shown when reviewing them. It's generated in the background after the build's result is stored, so it's kept apart from the result and added to it when the plan's state is loaded.
+type FileChangeSummary struct {
+	Id        string    `db:"id"`
+	OrgId     string    `db:"org_id"`
+	PlanId    string    `db:"plan_id"`
+	ResultId  string    `db:"result_id"`
+	Summary   string    `db:"summary"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
 func (summary *ConvoSummary) ToApi() *shared.ConvoSummary {
 	return &shared.ConvoSummary{
 		Id:                          summary.Id,
@@ -209,14 +267,20 @@ func (summary *ConvoSummary) ToApi() *shared.ConvoSummary {
 }
 
 type PlanBuild struct {
-	Id             string    `db:"id"`
-	OrgId          string    `db:"org_id"`
-	PlanId         string    `db:"plan_id"`
-	ConvoMessageId string    `db:"convo_message_id"`
-	FilePath       string    `db:"file_path"`
-	Error          string    `db:"error"`
-	CreatedAt      time.Time `db:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at"`
+	Id             string     `db:"id"`
+	OrgId          string     `db:"org_id"`
+	PlanId         string     `db:"plan_id"`
+	UserId         *string    `db:"user_id"`
+	ConvoMessageId string     `db:"convo_message_id"`
+	FilePath       string     `db:"file_path"`
+	Branch         string     `db:"branch"`
+	IsVerification bool       `db:"is_verification"`
+	NumRetries     int        `db:"num_retries"`
+	NumTokens      int        `db:"num_tokens"`
+	Error          string     `db:"error"`
+	FinishedAt     *time.Time `db:"finished_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
 }
 
 func (build *PlanBuild) ToApi() *shared.PlanBuild {
@@ -225,11 +289,59 @@ func (build *PlanBuild) ToApi() *shared.PlanBuild {
 		ConvoMessageId: build.ConvoMessageId,
 		Error:          build.Error,
 		FilePath:       build.FilePath,
+		Branch:         build.Branch,
+		IsVerification: build.IsVerification,
+		NumRetries:     build.NumRetries,
+		NumTokens:      build.NumTokens,
+		FinishedAt:     build.FinishedAt,
 		CreatedAt:      build.CreatedAt,
 		UpdatedAt:      build.UpdatedAt,
 	}
 }
 
+type ModelUsage struct {
+	Id           string               `db:"id"`
+	OrgId        string               `db:"org_id"`
+	UserId       *string              `db:"user_id"`
+	PlanId       *string              `db:"plan_id"`
+	Branch       string               `db:"branch"`
+	ModelRole    shared.ModelRole     `db:"model_role"`
+	Provider     shared.ModelProvider `db:"provider"`
+	ModelName    string               `db:"model_name"`
+	InputTokens  int                  `db:"input_tokens"`
+	OutputTokens int                  `db:"output_tokens"`
+	// ReasoningTokens are included in OutputTokens
+	ReasoningTokens int `db:"reasoning_tokens"`
+	// Cost is in USD, and nil when the model's price isn't known
+	Cost      *float64  `db:"cost"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// ModelPrice is an entry in the server's pricing catalog, which isn't scoped to an org
+type ModelPrice struct {
+	Id               string    `db:"id"`
+	Provider         string    `db:"provider"`
+	ModelName        string    `db:"model_name"`
+	InputPerMillion  float64   `db:"input_per_million"`
+	OutputPerMillion float64   `db:"output_per_million"`
+	Source           string    `db:"source"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+func (price *ModelPrice) ToApi() *shared.ModelPrice {
+	return &shared.ModelPrice{
+		Provider:  shared.ModelProvider(price.Provider),
+		ModelName: price.ModelName,
+		ModelPricing: shared.ModelPricing{
+			InputPerMillion:  price.InputPerMillion,
+			OutputPerMillion: price.OutputPerMillion,
+		},
+		Source:    shared.ModelPriceSource(price.Source),
+		UpdatedAt: price.UpdatedAt,
+	}
+}
+
 type OrgRole struct {
 	Id          string    `db:"id"`
 	OrgId       *string   `db:"org_id"`
@@ -244,6 +356,7 @@ func (role *OrgRole) ToApi() *shared.OrgRole {
 	return &shared.OrgRole{
 		Id:          role.Id,
 		IsDefault:   role.OrgId == nil,
+		Name:        role.Name,
 		Label:       role.Label,
 		Description: role.Description,
 	}
@@ -291,30 +404,38 @@ type repoLock struct {
 }
 
 type ModelPack struct {
-	Id          string                   `db:"id"`
-	OrgId       string                   `db:"org_id"`
-	Name        string                   `db:"name"`
-	Description string                   `db:"description"`
-	Planner     shared.PlannerRoleConfig `db:"planner"`
-	PlanSummary shared.ModelRoleConfig   `db:"plan_summary"`
-	Builder     shared.ModelRoleConfig   `db:"builder"`
-	Namer       shared.ModelRoleConfig   `db:"namer"`
-	CommitMsg   shared.ModelRoleConfig   `db:"commit_msg"`
-	ExecStatus  shared.ModelRoleConfig   `db:"exec_status"`
-	CreatedAt   time.Time                `db:"created_at"`
+	Id           string                   `db:"id"`
+	OrgId        string                   `db:"org_id"`
+	Name         string                   `db:"name"`
+	Description  string                   `db:"description"`
+	Planner      shared.PlannerRoleConfig `db:"planner"`
+	PlanSummary  shared.ModelRoleConfig   `db:"plan_summary"`
+	Builder      shared.ModelRoleConfig   `db:"builder"`
+	Namer        shared.ModelRoleConfig   `db:"namer"`
+	CommitMsg    shared.ModelRoleConfig   `db:"commit_msg"`
+	ExecStatus   shared.ModelRoleConfig   `db:"exec_status"`
+	Verifier     *shared.ModelRoleConfig  `db:"verifier"`
+	AutoFix      *shared.ModelRoleConfig  `db:"auto_fix"`
+	DraftBuilder *shared.ModelRoleConfig  `db:"draft_builder"`
+	Chat         *shared.ModelRoleConfig  `db:"chat"`
+	CreatedAt    time.Time                `db:"created_at"`
 }
 
 func (modelPack *ModelPack) ToApi() *shared.ModelPack {
 	return &shared.ModelPack{
-		Id:          modelPack.Id,
-		Name:        modelPack.Name,
-		Description: modelPack.Description,
-		Planner:     modelPack.Planner,
-		PlanSummary: modelPack.PlanSummary,
-		Builder:     modelPack.Builder,
-		Namer:       modelPack.Namer,
-		CommitMsg:   modelPack.CommitMsg,
-		ExecStatus:  modelPack.ExecStatus,
+		Id:           modelPack.Id,
+		Name:         modelPack.Name,
+		Description:  modelPack.Description,
+		Planner:      modelPack.Planner,
+		PlanSummary:  modelPack.PlanSummary,
+		Builder:      modelPack.Builder,
+		Namer:        modelPack.Namer,
+		CommitMsg:    modelPack.CommitMsg,
+		ExecStatus:   modelPack.ExecStatus,
+		Verifier:     modelPack.Verifier,
+		AutoFix:      modelPack.AutoFix,
+		DraftBuilder: modelPack.DraftBuilder,
+		Chat:         modelPack.Chat,
 	}
 }
 
@@ -333,6 +454,7 @@ type AvailableModel struct {
 	HasStreaming                bool                 `db:"has_streaming"`
 	HasFunctionCalling          bool                 `db:"has_function_calling"`
 	HasStreamingFunctionCalls   bool                 `db:"has_streaming_function_calls"`
+	HasStructuredOutputs        bool                 `db:"has_structured_outputs"`
 	DefaultMaxConvoTokens       int                  `db:"default_max_convo_tokens"`
 	DefaultReservedOutputTokens int                  `db:"default_reserved_output_tokens"`
 	CreatedAt                   time.Time            `db:"created_at"`
@@ -355,6 +477,7 @@ func (model *AvailableModel) ToApi() *shared.AvailableModel {
 				HasStreaming:              model.HasStreaming,
 				HasFunctionCalling:        model.HasFunctionCalling,
 				HasStreamingFunctionCalls: model.HasStreamingFunctionCalls,
+				HasStructuredOutputs:      model.HasStructuredOutputs,
 			}},
 		Description:                 model.Description,
 		DefaultMaxConvoTokens:       model.DefaultMaxConvoTokens,
@@ -372,6 +495,368 @@ type DefaultPlanSettings struct {
 	UpdatedAt    time.Time           `db:"updated_at"`
 }
 
+type OrgRetentionPolicy struct {
+	Id                      string    `db:"id"`
+	OrgId                   string    `db:"org_id"`
+	ArchiveAfterDays        int       `db:"archive_after_days"`
+	DeleteArchivedAfterDays int       `db:"delete_archived_after_days"`
+	NotifyDaysBeforeDelete  int       `db:"notify_days_before_delete"`
+	CreatedAt               time.Time `db:"created_at"`
+	UpdatedAt               time.Time `db:"updated_at"`
+}
+
+func (policy *OrgRetentionPolicy) ToApi() *shared.OrgRetentionPolicy {
+	return &shared.OrgRetentionPolicy{
+		ArchiveAfterDays:        policy.ArchiveAfterDays,
+		DeleteArchivedAfterDays: policy.DeleteArchivedAfterDays,
+		NotifyDaysBeforeDelete:  policy.NotifyDaysBeforeDelete,
+		UpdatedAt:               policy.UpdatedAt,
+	}
+}
+
+type UserDigestSettings struct {
+	Id         string                 `db:"id"`
+	UserId     string                 `db:"user_id"`
+	Frequency  shared.DigestFrequency `db:"frequency"`
+	LastSentAt *time.Time             `db:"last_sent_at"`
+	CreatedAt  time.Time              `db:"created_at"`
+	UpdatedAt  time.Time              `db:"updated_at"`
+}
+
+func (settings *UserDigestSettings) ToApi() *shared.UserDigestSettings {
+	return &shared.UserDigestSettings{
+		Frequency:  settings.Frequency,
+		LastSentAt: settings.LastSentAt,
+		UpdatedAt:  settings.UpdatedAt,
+	}
+}
+
+type OrgResponseCachePolicy struct {
+	Id         string    `db:"id"`
+	OrgId      string    `db:"org_id"`
+	Enabled    bool      `db:"enabled"`
+	Scope      string    `db:"scope"`
+	TtlHours   int       `db:"ttl_hours"`
+	Operations string    `db:"operations"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+func (policy *OrgResponseCachePolicy) ToApi() *shared.OrgResponseCachePolicy {
+	ops := []shared.ResponseCacheOperation{}
+	for _, op := range strings.Split(policy.Operations, "\n") {
+		if op != "" {
+			ops = append(ops, shared.ResponseCacheOperation(op))
+		}
+	}
+
+	return &shared.OrgResponseCachePolicy{
+		Enabled:    policy.Enabled,
+		Scope:      shared.ResponseCacheScope(policy.Scope),
+		TtlHours:   policy.TtlHours,
+		Operations: ops,
+		UpdatedAt:  policy.UpdatedAt,
+	}
+}
+
+type OrgCodeScanPolicy struct {
+	Id               string    `db:"id"`
+	OrgId            string    `db:"org_id"`
+	Enabled          bool      `db:"enabled"`
+	BannedLicenses   string    `db:"banned_licenses"`
+	MinVerbatimLines int       `db:"min_verbatim_lines"`
+	MatchContext     bool      `db:"match_context"`
+	MatchCorpus      bool      `db:"match_corpus"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+func (policy *OrgCodeScanPolicy) ToApi() *shared.OrgCodeScanPolicy {
+	licenses := []string{}
+	for _, license := range strings.Split(policy.BannedLicenses, "\n") {
+		if license != "" {
+			licenses = append(licenses, license)
+		}
+	}
+
+	return &shared.OrgCodeScanPolicy{
+		Enabled:          policy.Enabled,
+		BannedLicenses:   licenses,
+		MinVerbatimLines: policy.MinVerbatimLines,
+		MatchContext:     policy.MatchContext,
+		MatchCorpus:      policy.MatchCorpus,
+		UpdatedAt:        policy.UpdatedAt,
+	}
+}
+
+type OrgContextBundle struct {
+	Id          string    `db:"id"`
+	OrgId       string    `db:"org_id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	Version     int       `db:"version"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func (bundle *OrgContextBundle) ToApi() *shared.OrgContextBundle {
+	return &shared.OrgContextBundle{
+		Name:        bundle.Name,
+		Description: bundle.Description,
+		Version:     bundle.Version,
+		UpdatedAt:   bundle.UpdatedAt,
+	}
+}
+
+type OrgContextBundleFile struct {
+	Id        string    `db:"id"`
+	BundleId  string    `db:"bundle_id"`
+	Name      string    `db:"name"`
+	Body      string    `db:"body"`
+	NumTokens int       `db:"num_tokens"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type KnowledgeEntry struct {
+	Id           string               `db:"id"`
+	OrgId        string               `db:"org_id"`
+	UserId       string               `db:"user_id"`
+	SourcePlanId *string              `db:"source_plan_id"`
+	Kind         shared.KnowledgeKind `db:"kind"`
+	Title        string               `db:"title"`
+	Body         string               `db:"body"`
+	NumTokens    int                  `db:"num_tokens"`
+	// a JSON array of floats
+	Embedding      *string   `db:"embedding"`
+	EmbeddingModel *string   `db:"embedding_model"`
+	UserName       string    `db:"user_name"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+func (entry *KnowledgeEntry) ToApi() *shared.KnowledgeEntry {
+	var sourcePlanId string
+	if entry.SourcePlanId != nil {
+		sourcePlanId = *entry.SourcePlanId
+	}
+
+	return &shared.KnowledgeEntry{
+		Id:           entry.Id,
+		Kind:         entry.Kind,
+		Title:        entry.Title,
+		Body:         entry.Body,
+		SourcePlanId: sourcePlanId,
+		NumTokens:    entry.NumTokens,
+		HasEmbedding: entry.Embedding != nil,
+		CreatedBy:    entry.UserName,
+		CreatedAt:    entry.CreatedAt,
+		UpdatedAt:    entry.UpdatedAt,
+	}
+}
+
+type ChangeFeedback struct {
+	Id     string `db:"id"`
+	OrgId  string `db:"org_id"`
+	UserId string `db:"user_id"`
+	PlanId string `db:"plan_id"`
+	// the plan file result that was rejected, or that was applied and then edited
+	ResultId         string                    `db:"result_id"`
+	Kind             shared.ChangeFeedbackKind `db:"kind"`
+	Path             string                    `db:"path"`
+	Body             string                    `db:"body"`
+	DistilledForUser bool                      `db:"distilled_for_user"`
+	DistilledForOrg  bool                      `db:"distilled_for_org"`
+	CreatedAt        time.Time                 `db:"created_at"`
+}
+
+type LearnedPreferences struct {
+	Id    string `db:"id"`
+	OrgId string `db:"org_id"`
+	// nil for the org's preferences
+	UserId    *string   `db:"user_id"`
+	Summary   string    `db:"summary"`
+	NumEvents int       `db:"num_events"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (prefs *LearnedPreferences) ToApi() *shared.LearnedPreferences {
+	return &shared.LearnedPreferences{
+		Summary:   prefs.Summary,
+		NumEvents: prefs.NumEvents,
+		UpdatedAt: prefs.UpdatedAt,
+	}
+}
+
+// CachedModelResponse is a model response that can stand in for an identical call. Only a hash of the prompt is stored.
+type CachedModelResponse struct {
+	Id        string    `db:"id"`
+	OrgId     string    `db:"org_id"`
+	CacheKey  string    `db:"cache_key"`
+	Operation string    `db:"operation"`
+	Response  string    `db:"response"`
+	NumHits   int       `db:"num_hits"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type OrgTrialPolicy struct {
+	Id             string    `db:"id"`
+	OrgId          string    `db:"org_id"`
+	Enabled        bool      `db:"enabled"`
+	MaxPlans       int       `db:"max_plans"`
+	MaxTokens      int       `db:"max_tokens"`
+	AccessCodeHash *string   `db:"access_code_hash"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+func (policy *OrgTrialPolicy) ToApi() *shared.OrgTrialPolicy {
+	return &shared.OrgTrialPolicy{
+		Enabled:       policy.Enabled,
+		MaxPlans:      policy.MaxPlans,
+		MaxTokens:     policy.MaxTokens,
+		HasAccessCode: policy.AccessCodeHash != nil,
+		UpdatedAt:     policy.UpdatedAt,
+	}
+}
+
+// TrustPolicy is a row in org_trust_policies, or in project_trust_policies when ProjectId is set
+type TrustPolicy struct {
+	Id              string  `db:"id"`
+	OrgId           string  `db:"org_id"`
+	ProjectId       *string `db:"project_id"`
+	LoadContext     string  `db:"load_context"`
+	CreateFiles     string  `db:"create_files"`
+	DeleteFiles     string  `db:"delete_files"`
+	RunCommands     string  `db:"run_commands"`
+	CreateFilesDirs string  `db:"create_files_dirs"`
+	// BuildCostConfirmAbove is in USD
+	BuildCostConfirmAbove float64   `db:"build_cost_confirm_above"`
+	CreatedAt             time.Time `db:"created_at"`
+	UpdatedAt             time.Time `db:"updated_at"`
+}
+
+func (policy *TrustPolicy) ToApi() *shared.TrustPolicy {
+	dirs := []string{}
+	for _, dir := range strings.Split(policy.CreateFilesDirs, "\n") {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return &shared.TrustPolicy{
+		LoadContext:           shared.TrustLevel(policy.LoadContext),
+		CreateFiles:           shared.TrustLevel(policy.CreateFiles),
+		DeleteFiles:           shared.TrustLevel(policy.DeleteFiles),
+		RunCommands:           shared.TrustLevel(policy.RunCommands),
+		CreateFilesDirs:       dirs,
+		BuildCostConfirmAbove: policy.BuildCostConfirmAbove,
+		UpdatedAt:             policy.UpdatedAt,
+	}
+}
+
+type OrgDataKey struct {
+	Id         string    `db:"id"`
+	OrgId      string    `db:"org_id"`
+	KeySource  string    `db:"key_source"`
+	KmsKeyId   *string   `db:"kms_key_id"`
+	Salt       *string   `db:"salt"`
+	WrappedKey string    `db:"wrapped_key"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type BuildCapture struct {
+	Id            string `db:"id"`
+	OrgId         string `db:"org_id"`
+	PlanId        string `db:"plan_id"`
+	PlanBuildId   string `db:"plan_build_id"`
+	FilePath      string `db:"file_path"`
+	Phase         string `db:"phase"`
+	ModelName     string `db:"model_name"`
+	Request       string `db:"request"`
+	Response      string `db:"response"`
+	Error         string `db:"error"`
+	PreBuildState string `db:"pre_build_state"`
+	// bodies of captures stored before the blob store was used are in the request, response, and pre_build_state columns
+	BodiesInBlob bool      `db:"bodies_in_blob"`
+	StartedAt    time.Time `db:"started_at"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+func (capture *BuildCapture) ToApi() *shared.BuildCapture {
+	return &shared.BuildCapture{
+		Id:            capture.Id,
+		PlanBuildId:   capture.PlanBuildId,
+		Path:          capture.FilePath,
+		Phase:         shared.BuildCapturePhase(capture.Phase),
+		ModelName:     capture.ModelName,
+		Request:       capture.Request,
+		Response:      capture.Response,
+		Error:         capture.Error,
+		PreBuildState: capture.PreBuildState,
+		StartedAt:     capture.StartedAt,
+		CreatedAt:     capture.CreatedAt,
+	}
+}
+
+type BenchResult struct {
+	Id               string    `db:"id"`
+	OrgId            string    `db:"org_id"`
+	PlanId           *string   `db:"plan_id"`
+	BenchId          string    `db:"bench_id"`
+	ModelPack        string    `db:"model_pack"`
+	BuilderModel     string    `db:"builder_model"`
+	NumFiles         int       `db:"num_files"`
+	NumPassed        int       `db:"num_passed"`
+	DurationMs       int64     `db:"duration_ms"`
+	PromptTokens     int       `db:"prompt_tokens"`
+	CompletionTokens int       `db:"completion_tokens"`
+	Error            string    `db:"error"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+func (res *BenchResult) ToApi() *shared.BenchResult {
+	var planId string
+	if res.PlanId != nil {
+		planId = *res.PlanId
+	}
+
+	return &shared.BenchResult{
+		Id:               res.Id,
+		BenchId:          res.BenchId,
+		PlanId:           planId,
+		ModelPack:        res.ModelPack,
+		BuilderModel:     res.BuilderModel,
+		NumFiles:         res.NumFiles,
+		NumPassed:        res.NumPassed,
+		DurationMs:       res.DurationMs,
+		PromptTokens:     res.PromptTokens,
+		CompletionTokens: res.CompletionTokens,
+		Error:            res.Error,
+		CreatedAt:        res.CreatedAt,
+	}
+}
+
+type OrgCredential struct {
+	Id             string    `db:"id"`
+	OrgId          string    `db:"org_id"`
+	Name           string    `db:"name"`
+	EncryptedValue string    `db:"encrypted_value"`
+	CreatedBy      *string   `db:"created_by"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+func (credential *OrgCredential) ToApi() *shared.OrgCredential {
+	return &shared.OrgCredential{
+		Name:      credential.Name,
+		CreatedBy: credential.CreatedBy,
+		CreatedAt: credential.CreatedAt,
+		UpdatedAt: credential.UpdatedAt,
+	}
+}
+
 // Models below are stored in files, not in the database.
 // This allows us to store them in a git repo and use git to manage history.
 
@@ -386,9 +871,13 @@ type Context struct {
 	FilePath        string                `json:"filePath"`
 	Sha             string                `json:"sha"`
 	NumTokens       int                   `json:"numTokens"`
+	NumTokensO200k  int                   `json:"numTokensO200k,omitempty"`
 	Body            string                `json:"body,omitempty"`
 	ForceSkipIgnore bool                  `json:"forceSkipIgnore"`
 	ImageDetail     openai.ImageURLDetail `json:"imageDetail,omitempty"`
+	Encoding        string                `json:"encoding,omitempty"`
+	BundleName      string                `json:"bundleName,omitempty"`
+	BundleVersion   int                   `json:"bundleVersion,omitempty"`
 	CreatedAt       time.Time             `json:"createdAt"`
 	UpdatedAt       time.Time             `json:"updatedAt"`
 }
@@ -403,8 +892,12 @@ func (context *Context) ToApi() *shared.Context {
 		FilePath:        context.FilePath,
 		Sha:             context.Sha,
 		NumTokens:       context.NumTokens,
+		NumTokensO200k:  context.NumTokensO200k,
 		Body:            context.Body,
 		ForceSkipIgnore: context.ForceSkipIgnore,
+		Encoding:        context.Encoding,
+		BundleName:      context.BundleName,
+		BundleVersion:   context.BundleVersion,
 		CreatedAt:       context.CreatedAt,
 		UpdatedAt:       context.UpdatedAt,
 	}
@@ -421,6 +914,9 @@ type ConvoMessage struct {
 	Message   string    `json:"message"`
 	Stopped   bool      `json:"stopped"`
 	CreatedAt time.Time `json:"createdAt"`
+
+	EditedAt *time.Time `json:"editedAt,omitempty"`
+	Stale    bool       `json:"stale,omitempty"`
 }
 
 func (msg *ConvoMessage) ToApi() *shared.ConvoMessage {
@@ -433,24 +929,29 @@ func (msg *ConvoMessage) ToApi() *shared.ConvoMessage {
 		Message:   msg.Message,
 		Stopped:   msg.Stopped,
 		CreatedAt: msg.CreatedAt,
+		EditedAt:  msg.EditedAt,
+		Stale:     msg.Stale,
 	}
 }
 
 type ConvoMessageDescription struct {
-	Id                    string          `json:"id"`
-	OrgId                 string          `json:"orgId"`
-	PlanId                string          `json:"planId"`
-	ConvoMessageId        string          `json:"convoMessageId"`
-	SummarizedToMessageId string          `json:"summarizedToMessageId"`
-	MadePlan              bool            `json:"madePlan"`
-	CommitMsg             string          `json:"commitMsg"`
-	Files                 []string        `json:"files"`
-	Error                 string          `json:"error"`
-	DidBuild              bool            `json:"didBuild"`
-	BuildPathsInvalidated map[string]bool `json:"buildPathsInvalidated"`
-	AppliedAt             *time.Time      `json:"appliedAt,omitempty"`
-	CreatedAt             time.Time       `json:"createdAt"`
-	UpdatedAt             time.Time       `json:"updatedAt"`
+	Id                    string                 `json:"id"`
+	OrgId                 string                 `json:"orgId"`
+	PlanId                string                 `json:"planId"`
+	ConvoMessageId        string                 `json:"convoMessageId"`
+	SummarizedToMessageId string                 `json:"summarizedToMessageId"`
+	MadePlan              bool                   `json:"madePlan"`
+	CommitMsg             string                 `json:"commitMsg"`
+	Files                 []string               `json:"files"`
+	Renames               []*shared.SymbolRename `json:"renames,omitempty"`
+	RemovedFiles          []string               `json:"removedFiles,omitempty"`
+	Error                 string                 `json:"error"`
+	DidBuild              bool                   `json:"didBuild"`
+	BuildPathsInvalidated map[string]bool        `json:"buildPathsInvalidated"`
+	AppliedAt             *time.Time             `json:"appliedAt,omitempty"`
+	Stale                 bool                   `json:"stale,omitempty"`
+	CreatedAt             time.Time              `json:"createdAt"`
+	UpdatedAt             time.Time              `json:"updatedAt"`
 }
 
 func (desc *ConvoMessageDescription) ToApi() *shared.ConvoMessageDescription {
@@ -461,6 +962,8 @@ func (desc *ConvoMessageDescription) ToApi() *shared.ConvoMessageDescription {
 		MadePlan:              desc.MadePlan,
 		CommitMsg:             desc.CommitMsg,
 		Files:                 desc.Files,
+		Renames:               desc.Renames,
+		RemovedFiles:          desc.RemovedFiles,
 		DidBuild:              desc.DidBuild,
 		BuildPathsInvalidated: desc.BuildPathsInvalidated,
 		AppliedAt:             desc.AppliedAt,
@@ -483,6 +986,7 @@ type PlanFileResult struct {
 	Replacements        []*shared.Replacement `json:"replacements"`
 	AnyFailed           bool                  `json:"anyFailed"`
 	Error               string                `json:"error"`
+	Summary             string                `json:"summary,omitempty"`
 
 	CanVerify    bool       `json:"canVerify"`
 	RanVerifyAt  *time.Time `json:"ranVerifyAt,omitempty"`
@@ -497,6 +1001,19 @@ type PlanFileResult struct {
 	IsOtherFix  bool `json:"isOtherFix"`
 	FixEpoch    int  `json:"fixEpoch"`
 
+	Stale bool `json:"stale,omitempty"`
+
+	Provenance *shared.PlanFileResultProvenance `json:"provenance,omitempty"`
+
+	ScanFindings []*shared.CodeScanFinding `json:"scanFindings,omitempty"`
+
+	TestSourcePath    string `json:"testSourcePath,omitempty"`
+	DocSyncSourcePath string `json:"docSyncSourcePath,omitempty"`
+
+	RemovedFile bool `json:"removedFile,omitempty"`
+
+	NoOpChanges []string `json:"noOpChanges,omitempty"`
+
 	AppliedAt  *time.Time `json:"appliedAt,omitempty"`
 	RejectedAt *time.Time `json:"rejectedAt,omitempty"`
 	CreatedAt  time.Time  `json:"createdAt"`
@@ -513,6 +1030,7 @@ func (res *PlanFileResult) ToApi() *shared.PlanFileResult {
 		Path:                res.Path,
 		Content:             res.Content,
 		AnyFailed:           res.AnyFailed,
+		Summary:             res.Summary,
 		AppliedAt:           res.AppliedAt,
 		RejectedAt:          res.RejectedAt,
 		Replacements:        res.Replacements,
@@ -522,7 +1040,85 @@ func (res *PlanFileResult) ToApi() *shared.PlanFileResult {
 		IsFix:               res.IsFix,
 		IsSyntaxFix:         res.IsSyntaxFix,
 		IsOtherFix:          res.IsOtherFix,
+		Stale:               res.Stale,
+		Provenance:          res.Provenance,
+		ScanFindings:        res.ScanFindings,
+		TestSourcePath:      res.TestSourcePath,
+		DocSyncSourcePath:   res.DocSyncSourcePath,
+		RemovedFile:         res.RemovedFile,
+		NoOpChanges:         res.NoOpChanges,
 		CreatedAt:           res.CreatedAt,
 		UpdatedAt:           res.UpdatedAt,
 	}
 }
+
+type BuildJobStatus string
+
+const (
+	BuildJobStatusQueued   BuildJobStatus = "queued"
+	BuildJobStatusClaimed  BuildJobStatus = "claimed"
+	BuildJobStatusRunning  BuildJobStatus = "running"
+	BuildJobStatusFinished BuildJobStatus = "finished"
+	BuildJobStatusFailed   BuildJobStatus = "failed"
+	BuildJobStatusCanceled BuildJobStatus = "canceled"
+)
+
+// BuildJob is a build request queued by an api process for a build worker. The request, which can include api keys, is encrypted like other org data and cleared once a worker has started the build.
+type BuildJob struct {
+	Id         string         `db:"id"`
+	OrgId      string         `db:"org_id"`
+	UserId     string         `db:"user_id"`
+	PlanId     string         `db:"plan_id"`
+	Branch     string         `db:"branch"`
+	Request    *string        `db:"request"`
+	Status     BuildJobStatus `db:"status"`
+	WorkerIp   *string        `db:"worker_ip"`
+	NumBuilds  int            `db:"num_builds"`
+	Error      string         `db:"error"`
+	CreatedAt  time.Time      `db:"created_at"`
+	StartedAt  *time.Time     `db:"started_at"`
+	FinishedAt *time.Time     `db:"finished_at"`
+}
+
+// Job is a unit of background work for the server's job runner. A job that fails is retried with backoff until it has run MaxAttempts times. DedupeKey, when set, allows only one queued or running job with the same key, which keeps periodic jobs from piling up when several processes schedule them.
+type Job struct {
+	Id          string           `db:"id"`
+	Kind        string           `db:"kind"`
+	OrgId       *string          `db:"org_id"`
+	Payload     string           `db:"payload"`
+	DedupeKey   *string          `db:"dedupe_key"`
+	Status      shared.JobStatus `db:"status"`
+	Attempts    int              `db:"attempts"`
+	MaxAttempts int              `db:"max_attempts"`
+	LastError   string           `db:"last_error"`
+	WorkerIp    *string          `db:"worker_ip"`
+	RunAt       time.Time        `db:"run_at"`
+	CreatedAt   time.Time        `db:"created_at"`
+	StartedAt   *time.Time       `db:"started_at"`
+	HeartbeatAt *time.Time       `db:"heartbeat_at"`
+	FinishedAt  *time.Time       `db:"finished_at"`
+}
+
+func (job *Job) ToApi() *shared.Job {
+	res := &shared.Job{
+		Id:          job.Id,
+		Kind:        job.Kind,
+		Status:      job.Status,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		LastError:   job.LastError,
+		RunAt:       job.RunAt,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+	}
+
+	if job.OrgId != nil {
+		res.OrgId = *job.OrgId
+	}
+	if job.WorkerIp != nil {
+		res.WorkerIp = *job.WorkerIp
+	}
+
+	return res
+}
@@ -6,20 +6,32 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
 var Conn *sqlx.DB
 
+const (
+	DriverPostgres = "postgres"
+	DriverSqlite   = "sqlite"
+)
+
+// Driver is the database backend in use, either DriverPostgres (the default) or DriverSqlite
+var Driver = DriverPostgres
+
 func Connect() error {
 	var err error
 
 	dbUrl := os.Getenv("DATABASE_URL")
+
+	if os.Getenv("DB_DRIVER") == DriverSqlite || os.Getenv("DB_DRIVER") == "sqlite3" || strings.HasPrefix(dbUrl, "sqlite://") || strings.HasPrefix(dbUrl, "sqlite3://") {
+		return connectSqlite(dbUrl)
+	}
+
 	if dbUrl == "" {
 		if os.Getenv("DB_HOST") != "" &&
 			os.Getenv("DB_PORT") != "" &&
@@ -52,69 +64,28 @@ func Connect() error {
 	return nil
 }
 
-func MigrationsUp() error {
-	if Conn == nil {
-		return errors.New("db not initialized")
-	}
-
-	driver, err := postgres.WithInstance(Conn.DB, &postgres.Config{})
+// connectSqlite opens a SQLite database at the path in a sqlite:// DATABASE_URL, or at plandex.db in the base dir if no path is given
+func connectSqlite(dbUrl string) error {
+	var err error
 
-	if err != nil {
-		return fmt.Errorf("error creating postgres driver: %v", err)
+	dbPath := strings.TrimPrefix(strings.TrimPrefix(dbUrl, "sqlite3://"), "sqlite://")
+	if dbPath == "" {
+		dbPath = filepath.Join(BaseDir, "plandex.db")
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		"postgres", driver)
-
+	err = os.MkdirAll(filepath.Dir(dbPath), os.ModePerm)
 	if err != nil {
-		return fmt.Errorf("error creating migration instance: %v", err)
+		return fmt.Errorf("error creating sqlite db dir: %v", err)
 	}
 
-	// Uncomment below (and update migration version) to reset migration state to a specific version after a failure
-	// if os.Getenv("GOENV") == "development" {
-	// 	migrateVersion := 2024041500
-	// 	if err := m.Force(migrateVersion); err != nil {
-	// 		return fmt.Errorf("error forcing migration version: %v", err)
-	// 	}
-	// }
-
-	// Uncomment below to run down migrations (RESETS DATABASE!!)
-	// if os.Getenv("GOENV") == "development" {
-	// 	err = m.Down()
-	// 	if err != nil {
-	// 		if err == migrate.ErrNoChange {
-	// 			log.Println("no migrations to run down")
-	// 		} else {
-	// 			return fmt.Errorf("error running down migrations: %v", err)
-	// 		}
-	// 	}
-	// 	log.Println("ran down migrations - database was reset")
-	// }
-
-	// Uncomment below to go back ONE migration
-	// if os.Getenv("GOENV") == "development" {
-	// 	err = m.Steps(-1)
-	// 	if err != nil {
-	// 		return fmt.Errorf("error running down migrations: %v", err)
-	// 	}
-	// 	log.Println("went down 1 migration")
-	// }
-
-	err = m.Up()
-
+	Conn, err = sqlx.Connect(sqliteDriverName, getSqliteDsn(dbPath))
 	if err != nil {
-		if err == migrate.ErrNoChange {
-			log.Println("migration state is up to date")
-		} else {
-
-			return fmt.Errorf("error running migrations: %v", err)
-		}
+		return err
 	}
 
-	if err == nil {
-		log.Println("ran migrations successfully")
-	}
+	Driver = DriverSqlite
+
+	log.Printf("connected to sqlite database at %s\n", dbPath)
 
 	return nil
 }
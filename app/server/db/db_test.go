@@ -0,0 +1,84 @@
+package db
+
+import (
+	"path/filepath"
+	"plandex-server/storage"
+	"testing"
+)
+
+// setupTestDb connects to a new SQLite database in a temp dir, with every migration applied and plan files and blobs stored in the same dir
+func setupTestDb(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	BaseDir = dir
+	storage.Blobs = storage.NewLocal(filepath.Join(dir, "blobs"))
+	t.Setenv("DATABASE_URL", "sqlite://"+filepath.Join(dir, "plandex.db"))
+
+	err := Connect()
+	if err != nil {
+		t.Fatalf("error connecting to test db: %v", err)
+	}
+	t.Cleanup(func() {
+		Conn.Close()
+	})
+
+	err = MigrationsUp(0)
+	if err != nil {
+		t.Fatalf("error running migrations: %v", err)
+	}
+}
+
+func createTestUser(t *testing.T, email string) *User {
+	t.Helper()
+
+	user := &User{Name: "Test User", Email: email, Domain: "example.com"}
+	err := Conn.QueryRow("INSERT INTO users (name, email, domain, is_trial) VALUES ($1, $2, $3, $4) RETURNING id", user.Name, user.Email, user.Domain, false).Scan(&user.Id)
+	if err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+
+	return user
+}
+
+// createTestOrg creates an org owned by the user, with the user as its owner member, and a project to put plans in. It returns the org and the project id.
+func createTestOrg(t *testing.T, name string, owner *User) (*Org, string) {
+	t.Helper()
+
+	org := &Org{Name: name, OwnerId: owner.Id}
+	err := Conn.QueryRow("INSERT INTO orgs (name, owner_id, is_trial) VALUES ($1, $2, $3) RETURNING id", org.Name, org.OwnerId, false).Scan(&org.Id)
+	if err != nil {
+		t.Fatalf("error creating org: %v", err)
+	}
+
+	addTestOrgUser(t, org.Id, owner.Id, "owner")
+
+	var projectId string
+	err = Conn.QueryRow("INSERT INTO projects (org_id, name) VALUES ($1, $2) RETURNING id", org.Id, "project").Scan(&projectId)
+	if err != nil {
+		t.Fatalf("error creating project: %v", err)
+	}
+
+	return org, projectId
+}
+
+func addTestOrgUser(t *testing.T, orgId, userId, roleName string) {
+	t.Helper()
+
+	_, err := Conn.Exec("INSERT INTO orgs_users (org_id, user_id, org_role_id) VALUES ($1, $2, (SELECT id FROM org_roles WHERE org_id IS NULL AND name = $3))", orgId, userId, roleName)
+	if err != nil {
+		t.Fatalf("error adding org user: %v", err)
+	}
+}
+
+func createTestPlan(t *testing.T, orgId, projectId, ownerId, name string) *Plan {
+	t.Helper()
+
+	plan := &Plan{OrgId: orgId, ProjectId: projectId, OwnerId: ownerId, Name: name}
+	err := Conn.QueryRow("INSERT INTO plans (org_id, owner_id, project_id, name) VALUES ($1, $2, $3, $4) RETURNING id", orgId, ownerId, projectId, name).Scan(&plan.Id)
+	if err != nil {
+		t.Fatalf("error creating plan: %v", err)
+	}
+
+	return plan
+}
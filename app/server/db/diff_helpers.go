@@ -10,7 +10,8 @@ import (
 	"github.com/plandex/plandex/shared"
 )
 
-func GetPlanDiffs(orgId, planId string) (string, error) {
+// GetPlanDiffs returns the pending changes as a diff against the files as they were loaded into context. A plain diff has no color and detects renamed files, so it can be applied as a patch with 'git apply'.
+func GetPlanDiffs(orgId, planId string, plain bool) (string, error) {
 	planState, err := GetCurrentPlanState(CurrentPlanStateParams{
 		OrgId:  orgId,
 		PlanId: planId,
@@ -39,6 +40,7 @@ func GetPlanDiffs(orgId, planId string) (string, error) {
 	}
 
 	files := planState.CurrentPlanFiles.Files
+	removed := planState.CurrentPlanFiles.Removed
 
 	// write the original files to the temp dir
 	errCh := make(chan error, len(planState.ContextsByPath))
@@ -47,7 +49,7 @@ func GetPlanDiffs(orgId, planId string) (string, error) {
 	for path, context := range planState.ContextsByPath {
 		go func(path string, context *shared.Context) {
 			_, hasPath := files[path]
-			if hasPath {
+			if hasPath || removed[path] {
 				hasAnyOriginal = true
 				// ensure file directory exists
 				err = os.MkdirAll(filepath.Dir(filepath.Join(tempDirPath, path)), 0755)
@@ -114,12 +116,24 @@ func GetPlanDiffs(orgId, planId string) (string, error) {
 		}
 	}
 
+	for path := range removed {
+		err = os.Remove(filepath.Join(tempDirPath, path))
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("error removing file from temp dir: %v", err)
+		}
+	}
+
 	err = gitAdd(tempDirPath, ".")
 	if err != nil {
 		return "", fmt.Errorf("error adding files to git repository for dir: %s, err: %v", tempDirPath, err)
 	}
 
-	res, err := exec.Command("git", "-C", tempDirPath, "diff", "--cached", "--color=always").CombinedOutput()
+	args := []string{"-C", tempDirPath, "diff", "--cached", "--color=always"}
+	if plain {
+		args = []string{"-C", tempDirPath, "diff", "--cached", "--no-color", "--find-renames"}
+	}
+
+	res, err := exec.Command("git", args...).CombinedOutput()
 
 	if err != nil {
 		return "", fmt.Errorf("error getting diffs: %v", err)
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/plandex/plandex/shared"
+)
+
+func GetUserDigestSettings(userId string) (*shared.UserDigestSettings, error) {
+	var settings UserDigestSettings
+	err := Conn.Get(&settings, "SELECT * FROM user_digest_settings WHERE user_id = $1", userId)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &shared.UserDigestSettings{Frequency: shared.DigestFrequencyOff}, nil
+		}
+		return nil, fmt.Errorf("error getting digest settings: %v", err)
+	}
+
+	return settings.ToApi(), nil
+}
+
+// StoreUserDigestSettings sets how often the user gets a digest. Turning digests on starts the first one's period now, so it doesn't cover activity from before the user asked for it.
+func StoreUserDigestSettings(userId string, frequency shared.DigestFrequency) error {
+	query := `INSERT INTO user_digest_settings (user_id, frequency, last_sent_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE SET
+		frequency = excluded.frequency,
+		last_sent_at = CASE WHEN user_digest_settings.frequency = $4 THEN excluded.last_sent_at ELSE user_digest_settings.last_sent_at END
+	`
+
+	_, err := Conn.Exec(query, userId, frequency, time.Now(), shared.DigestFrequencyOff)
+
+	if err != nil {
+		return fmt.Errorf("error storing digest settings: %v", err)
+	}
+
+	return nil
+}
+
+// ListEnabledDigestSettings returns the settings of every user who gets digests. Trial users are left out, since they haven't given an email address.
+func ListEnabledDigestSettings() ([]*UserDigestSettings, error) {
+	var settings []*UserDigestSettings
+	err := Conn.Select(&settings, "SELECT user_digest_settings.* FROM user_digest_settings JOIN users ON users.id = user_digest_settings.user_id WHERE user_digest_settings.frequency IN ($1, $2) AND users.is_trial = FALSE", shared.DigestFrequencyDaily, shared.DigestFrequencyWeekly)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing digest settings: %v", err)
+	}
+
+	return settings, nil
+}
+
+func SetDigestSent(id string, sentAt time.Time) error {
+	_, err := Conn.Exec("UPDATE user_digest_settings SET last_sent_at = $1 WHERE id = $2", sentAt, id)
+
+	if err != nil {
+		return fmt.Errorf("error setting digest sent: %v", err)
+	}
+
+	return nil
+}
+
+// ListDigestPlans returns the unarchived plans the user can access that were updated since a time, along with the user's own unarchived plans, which might have changes waiting for review. Plans shared with the user directly are included even if they're in an org the user isn't a member of. Most recently updated first.
+func ListDigestPlans(userId string, since time.Time) ([]*Plan, error) {
+	query := `SELECT plans.* FROM plans
+	WHERE plans.archived_at IS NULL
+	AND (
+		(
+			EXISTS (SELECT 1 FROM orgs_users WHERE orgs_users.org_id = plans.org_id AND orgs_users.user_id = $1)
+			AND (plans.owner_id = $1 OR (plans.updated_at >= $2 AND plans.shared_with_org_at IS NOT NULL))
+		)
+		OR (
+			plans.updated_at >= $2
+			AND EXISTS (SELECT 1 FROM plan_shares WHERE plan_shares.plan_id = plans.id AND plan_shares.user_id = $1)
+		)
+	)
+	ORDER BY plans.updated_at DESC`
+
+	var plans []*Plan
+	err := Conn.Select(&plans, query, userId, since)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing plans for digest: %v", err)
+	}
+
+	if len(plans) == 0 {
+		return plans, nil
+	}
+
+	orgIds := make([]string, len(plans))
+	for i, plan := range plans {
+		orgIds[i] = plan.OrgId
+	}
+
+	var orgs []*Org
+	err = Conn.Select(&orgs, "SELECT * FROM orgs WHERE id = ANY($1)", pq.Array(orgIds))
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting orgs: %v", err)
+	}
+
+	orgNamesById := map[string]string{}
+	for _, org := range orgs {
+		orgNamesById[org.Id] = org.Name
+	}
+
+	for _, plan := range plans {
+		plan.OrgName = orgNamesById[plan.OrgId]
+	}
+
+	return plans, nil
+}
+
+type PlanBuildCounts struct {
+	PlanId      string `db:"plan_id"`
+	NumFinished int    `db:"num_finished"`
+	NumFailed   int    `db:"num_failed"`
+}
+
+// GetPlanBuildCounts counts each plan's builds that finished since a time, with and without an error
+func GetPlanBuildCounts(planIds []string, since time.Time) (map[string]*PlanBuildCounts, error) {
+	query := `SELECT plan_id,
+		SUM(CASE WHEN COALESCE(error, '') = '' THEN 1 ELSE 0 END) AS num_finished,
+		SUM(CASE WHEN COALESCE(error, '') = '' THEN 0 ELSE 1 END) AS num_failed
+	FROM plan_builds
+	WHERE plan_id = ANY($1) AND finished_at >= $2
+	GROUP BY plan_id`
+
+	var rows []*PlanBuildCounts
+	err := Conn.Select(&rows, query, pq.Array(planIds), since)
+
+	if err != nil {
+		return nil, fmt.Errorf("error counting plan builds: %v", err)
+	}
+
+	res := map[string]*PlanBuildCounts{}
+	for _, row := range rows {
+		res[row.PlanId] = row
+	}
+
+	return res, nil
+}
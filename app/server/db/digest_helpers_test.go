@@ -0,0 +1,58 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestListDigestPlansIncludesSharesFromOtherOrgs(t *testing.T) {
+	setupTestDb(t)
+
+	since := time.Now().Add(-time.Hour)
+
+	owner := createTestUser(t, "owner@example.com")
+	org, projectId := createTestOrg(t, "owner org", owner)
+
+	user := createTestUser(t, "user@example.com")
+	userOrg, userProjectId := createTestOrg(t, "user org", user)
+
+	sharedWithUser := createTestPlan(t, org.Id, projectId, owner.Id, "shared with user")
+	sharedWithOrg := createTestPlan(t, org.Id, projectId, owner.Id, "shared with org")
+	createTestPlan(t, org.Id, projectId, owner.Id, "private")
+	own := createTestPlan(t, userOrg.Id, userProjectId, user.Id, "own")
+
+	err := StorePlanShare(&PlanShare{PlanId: sharedWithUser.Id, OrgId: org.Id, UserId: user.Id, Access: shared.PlanShareAccessRead, CreatedBy: &owner.Id})
+	if err != nil {
+		t.Fatalf("error sharing plan: %v", err)
+	}
+
+	// sharing with the owner's org doesn't reach a user who isn't a member of it
+	_, err = Conn.Exec("UPDATE plans SET shared_with_org_at = $1 WHERE id = $2", time.Now(), sharedWithOrg.Id)
+	if err != nil {
+		t.Fatalf("error sharing plan with org: %v", err)
+	}
+
+	plans, err := ListDigestPlans(user.Id, since)
+	if err != nil {
+		t.Fatalf("error listing digest plans: %v", err)
+	}
+
+	got := map[string]*Plan{}
+	for _, plan := range plans {
+		got[plan.Id] = plan
+	}
+
+	if len(got) != 2 || got[sharedWithUser.Id] == nil || got[own.Id] == nil {
+		names := []string{}
+		for _, plan := range plans {
+			names = append(names, plan.Name)
+		}
+		t.Fatalf("expected the plan shared with the user and the user's own plan, got %v", names)
+	}
+
+	if name := got[sharedWithUser.Id].OrgName; name != "owner org" {
+		t.Errorf("expected the shared plan's org name to be %q, got %q", "owner org", name)
+	}
+}
@@ -0,0 +1,402 @@
+package db
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Context bodies, conversation messages, plan results, and conversation summaries can be encrypted at rest with envelope encryption. Each org gets a random 256-bit data key, which is stored in org_data_keys wrapped by either a passphrase (PLANDEX_ENCRYPTION_PASSPHRASE) or an AWS KMS key (PLANDEX_ENCRYPTION_KMS_KEY_ID). Data is encrypted with AES-256-GCM using the org id as additional data, so ciphertext can't be moved between orgs.
+//
+// Encrypted values start with encryptedPrefix. Anything without the prefix is treated as plaintext, so existing data stays readable after encryption is turned on, and is encrypted as it's rewritten.
+
+const (
+	DataKeySourcePassphrase = "passphrase"
+	DataKeySourceKms        = "kms"
+)
+
+var encryptedPrefix = []byte("pdxenc:v1:")
+
+// set by InitEncryption -- empty when encryption is disabled
+var dataKeySource string
+
+var (
+	dataKeysMu sync.Mutex
+	dataKeys   = map[string][]byte{}
+)
+
+// InitEncryption reads the encryption settings from the environment. It must be called after Connect.
+func InitEncryption() error {
+	passphrase := os.Getenv("PLANDEX_ENCRYPTION_PASSPHRASE")
+	kmsKeyId := os.Getenv("PLANDEX_ENCRYPTION_KMS_KEY_ID")
+
+	if passphrase != "" && kmsKeyId != "" {
+		return fmt.Errorf("only one of PLANDEX_ENCRYPTION_PASSPHRASE and PLANDEX_ENCRYPTION_KMS_KEY_ID can be set")
+	}
+
+	if passphrase != "" {
+		dataKeySource = DataKeySourcePassphrase
+	} else if kmsKeyId != "" {
+		dataKeySource = DataKeySourceKms
+	} else {
+		dataKeySource = ""
+		log.Println("Encryption at rest disabled")
+		return nil
+	}
+
+	log.Printf("Encryption at rest enabled with %s-wrapped org data keys\n", dataKeySource)
+
+	return nil
+}
+
+func EncryptionEnabled() bool {
+	return dataKeySource != ""
+}
+
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedPrefix)
+}
+
+// encryptOrgData encrypts data with the org's data key, creating the key if needed. When encryption is disabled, data is returned as-is.
+func encryptOrgData(orgId string, data []byte) ([]byte, error) {
+	if !EncryptionEnabled() {
+		return data, nil
+	}
+
+	key, err := getOrgDataKey(orgId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGcm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("error generating nonce: %v", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, data, []byte(orgId))
+
+	res := make([]byte, len(encryptedPrefix)+base64.StdEncoding.EncodedLen(len(sealed)))
+	copy(res, encryptedPrefix)
+	base64.StdEncoding.Encode(res[len(encryptedPrefix):], sealed)
+
+	return res, nil
+}
+
+// decryptOrgData decrypts data encrypted by encryptOrgData. Plaintext data is returned as-is. Encrypted data can still be read after encryption is disabled, as long as the passphrase or KMS key that wrapped the org's data key is still configured.
+func decryptOrgData(orgId string, data []byte) ([]byte, error) {
+	if !isEncrypted(data) {
+		return data, nil
+	}
+
+	sealed := make([]byte, base64.StdEncoding.DecodedLen(len(data)-len(encryptedPrefix)))
+	n, err := base64.StdEncoding.Decode(sealed, data[len(encryptedPrefix):])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding encrypted data: %v", err)
+	}
+	sealed = sealed[:n]
+
+	key, err := getOrgDataKey(orgId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGcm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+
+	res, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(orgId))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data: %v", err)
+	}
+
+	return res, nil
+}
+
+func encryptOrgString(orgId, s string) (string, error) {
+	res, err := encryptOrgData(orgId, []byte(s))
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+func decryptOrgString(orgId, s string) (string, error) {
+	res, err := decryptOrgData(orgId, []byte(s))
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+// writeOrgFile and readOrgFile are drop-in replacements for os.WriteFile and os.ReadFile for files with customer content
+func writeOrgFile(orgId, path string, data []byte, perm os.FileMode) error {
+	data, err := encryptOrgData(orgId, data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+func readOrgFile(orgId, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decryptOrgData(orgId, data)
+}
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %v", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating GCM: %v", err)
+	}
+
+	return gcm, nil
+}
+
+func forgetOrgDataKey(orgId string) {
+	dataKeysMu.Lock()
+	defer dataKeysMu.Unlock()
+	delete(dataKeys, orgId)
+}
+
+func getOrgDataKey(orgId string, create bool) ([]byte, error) {
+	dataKeysMu.Lock()
+	defer dataKeysMu.Unlock()
+
+	if key, ok := dataKeys[orgId]; ok {
+		return key, nil
+	}
+
+	var row OrgDataKey
+	err := Conn.Get(&row, "SELECT * FROM org_data_keys WHERE org_id = $1", orgId)
+
+	if err == sql.ErrNoRows && create {
+		err = createOrgDataKey(orgId)
+		if err != nil {
+			return nil, err
+		}
+		// another server may have created a key at the same time, so always use the stored one
+		err = Conn.Get(&row, "SELECT * FROM org_data_keys WHERE org_id = $1", orgId)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no data key found for org %s", orgId)
+		}
+		return nil, fmt.Errorf("error getting org data key: %v", err)
+	}
+
+	key, err := unwrapDataKey(&row)
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping data key for org %s: %v", orgId, err)
+	}
+
+	dataKeys[orgId] = key
+
+	return key, nil
+}
+
+func createOrgDataKey(orgId string) error {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return fmt.Errorf("error generating data key: %v", err)
+	}
+
+	row := OrgDataKey{
+		OrgId:     orgId,
+		KeySource: dataKeySource,
+	}
+
+	switch dataKeySource {
+	case DataKeySourcePassphrase:
+		salt := make([]byte, 16)
+		_, err = rand.Read(salt)
+		if err != nil {
+			return fmt.Errorf("error generating salt: %v", err)
+		}
+		saltStr := base64.StdEncoding.EncodeToString(salt)
+		row.Salt = &saltStr
+
+		row.WrappedKey, err = wrapWithPassphrase(key, salt, orgId)
+	case DataKeySourceKms:
+		kmsKeyId := os.Getenv("PLANDEX_ENCRYPTION_KMS_KEY_ID")
+		row.KmsKeyId = &kmsKeyId
+
+		row.WrappedKey, err = wrapWithKms(key, kmsKeyId, orgId)
+	default:
+		err = fmt.Errorf("encryption is disabled")
+	}
+
+	if err != nil {
+		return fmt.Errorf("error wrapping data key: %v", err)
+	}
+
+	_, err = Conn.Exec("INSERT INTO org_data_keys (org_id, key_source, kms_key_id, salt, wrapped_key) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (org_id) DO NOTHING", row.OrgId, row.KeySource, row.KmsKeyId, row.Salt, row.WrappedKey)
+
+	if err != nil {
+		return fmt.Errorf("error storing org data key: %v", err)
+	}
+
+	return nil
+}
+
+// keys are unwrapped with the source they were created with, so switching PLANDEX_ENCRYPTION_* settings only affects new orgs
+func unwrapDataKey(row *OrgDataKey) ([]byte, error) {
+	switch row.KeySource {
+	case DataKeySourcePassphrase:
+		if row.Salt == nil {
+			return nil, fmt.Errorf("missing salt")
+		}
+		salt, err := base64.StdEncoding.DecodeString(*row.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding salt: %v", err)
+		}
+		return unwrapWithPassphrase(row.WrappedKey, salt, row.OrgId)
+	case DataKeySourceKms:
+		return unwrapWithKms(row.WrappedKey, row.OrgId)
+	}
+
+	return nil, fmt.Errorf("unknown key source %q", row.KeySource)
+}
+
+func getPassphraseKek(salt []byte) ([]byte, error) {
+	passphrase := os.Getenv("PLANDEX_ENCRYPTION_PASSPHRASE")
+	if passphrase == "" {
+		return nil, fmt.Errorf("PLANDEX_ENCRYPTION_PASSPHRASE isn't set")
+	}
+
+	kek, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving key: %v", err)
+	}
+
+	return kek, nil
+}
+
+func wrapWithPassphrase(key, salt []byte, orgId string) (string, error) {
+	kek, err := getPassphraseKek(salt)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGcm(kek)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("error generating nonce: %v", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, key, []byte(orgId))), nil
+}
+
+func unwrapWithPassphrase(wrapped string, salt []byte, orgId string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding wrapped key: %v", err)
+	}
+
+	kek, err := getPassphraseKek(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGcm(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("wrapped key is too short")
+	}
+
+	key, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(orgId))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data key (is PLANDEX_ENCRYPTION_PASSPHRASE correct?): %v", err)
+	}
+
+	return key, nil
+}
+
+// KMS credentials and region are read from the standard AWS environment variables
+func newKmsClient() (*kms.KMS, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("error creating AWS session: %v", err)
+	}
+	return kms.New(sess), nil
+}
+
+func wrapWithKms(key []byte, kmsKeyId, orgId string) (string, error) {
+	client, err := newKmsClient()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := client.Encrypt(&kms.EncryptInput{
+		KeyId:             aws.String(kmsKeyId),
+		Plaintext:         key,
+		EncryptionContext: map[string]*string{"orgId": aws.String(orgId)},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encrypting data key with KMS: %v", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(res.CiphertextBlob), nil
+}
+
+func unwrapWithKms(wrapped, orgId string) ([]byte, error) {
+	blob, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding wrapped key: %v", err)
+	}
+
+	client, err := newKmsClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// the key id is part of the ciphertext blob
+	res, err := client.Decrypt(&kms.DecryptInput{
+		CiphertextBlob:    blob,
+		EncryptionContext: map[string]*string{"orgId": aws.String(orgId)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data key with KMS: %v", err)
+	}
+
+	return res.Plaintext, nil
+}
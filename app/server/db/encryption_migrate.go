@@ -0,0 +1,205 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"plandex-server/storage"
+	"time"
+)
+
+// EncryptExistingData encrypts data that was stored before encryption was enabled: context blobs, build captures, conversation and file change summaries, context bundle files, and the conversation and result files on every branch of every plan (committing the encrypted files to each branch). Earlier commits in each plan's history still contain the plaintext files. Stop the server while it runs.
+func EncryptExistingData() (int, error) {
+	if !EncryptionEnabled() {
+		return 0, fmt.Errorf("encryption isn't enabled -- set PLANDEX_ENCRYPTION_PASSPHRASE or PLANDEX_ENCRYPTION_KMS_KEY_ID")
+	}
+
+	var orgIds []string
+	err := Conn.Select(&orgIds, "SELECT id FROM orgs")
+	if err != nil {
+		return 0, fmt.Errorf("error listing orgs: %v", err)
+	}
+
+	total := 0
+	for _, orgId := range orgIds {
+		num, err := encryptExistingOrgData(orgId)
+		total += num
+		if err != nil {
+			return total, fmt.Errorf("error encrypting data for org %s: %v", orgId, err)
+		}
+	}
+
+	return total, nil
+}
+
+func encryptExistingOrgData(orgId string) (int, error) {
+	total := 0
+
+	for _, prefix := range []string{getOrgContextBlobsKey(orgId), path.Join("orgs", orgId, "build-captures")} {
+		err := storage.Blobs.List(prefix, func(key string, modTime time.Time) error {
+			data, err := storage.Blobs.Get(key)
+			if err != nil {
+				return err
+			}
+			if isEncrypted(data) {
+				return nil
+			}
+
+			data, err = encryptOrgData(orgId, data)
+			if err != nil {
+				return err
+			}
+
+			err = storage.Blobs.Put(key, data)
+			if err != nil {
+				return err
+			}
+			total++
+			return nil
+		})
+		if err != nil {
+			return total, fmt.Errorf("error encrypting blobs: %v", err)
+		}
+	}
+
+	var summaries []*ConvoSummary
+	err := Conn.Select(&summaries, "SELECT * FROM convo_summaries WHERE org_id = $1", orgId)
+	if err != nil {
+		return total, fmt.Errorf("error listing summaries: %v", err)
+	}
+
+	for _, summary := range summaries {
+		if isEncrypted([]byte(summary.Summary)) {
+			continue
+		}
+
+		encrypted, err := encryptOrgString(orgId, summary.Summary)
+		if err != nil {
+			return total, err
+		}
+
+		_, err = Conn.Exec("UPDATE convo_summaries SET summary = $1 WHERE id = $2", encrypted, summary.Id)
+		if err != nil {
+			return total, fmt.Errorf("error updating summary: %v", err)
+		}
+		total++
+	}
+
+	var fileChangeSummaries []*FileChangeSummary
+	err = Conn.Select(&fileChangeSummaries, "SELECT * FROM file_change_summaries WHERE org_id = $1", orgId)
+	if err != nil {
+		return total, fmt.Errorf("error listing file change summaries: %v", err)
+	}
+
+	for _, summary := range fileChangeSummaries {
+		if isEncrypted([]byte(summary.Summary)) {
+			continue
+		}
+
+		encrypted, err := encryptOrgString(orgId, summary.Summary)
+		if err != nil {
+			return total, err
+		}
+
+		_, err = Conn.Exec("UPDATE file_change_summaries SET summary = $1 WHERE id = $2", encrypted, summary.Id)
+		if err != nil {
+			return total, fmt.Errorf("error updating file change summary: %v", err)
+		}
+		total++
+	}
+
+	var bundleFiles []*OrgContextBundleFile
+	err = Conn.Select(&bundleFiles, "SELECT f.* FROM org_context_bundle_files f JOIN org_context_bundles b ON f.bundle_id = b.id WHERE b.org_id = $1", orgId)
+	if err != nil {
+		return total, fmt.Errorf("error listing context bundle files: %v", err)
+	}
+
+	for _, file := range bundleFiles {
+		if isEncrypted([]byte(file.Body)) {
+			continue
+		}
+
+		encrypted, err := encryptOrgString(orgId, file.Body)
+		if err != nil {
+			return total, err
+		}
+
+		_, err = Conn.Exec("UPDATE org_context_bundle_files SET body = $1 WHERE id = $2", encrypted, file.Id)
+		if err != nil {
+			return total, fmt.Errorf("error updating context bundle file: %v", err)
+		}
+		total++
+	}
+
+	var planIds []string
+	err = Conn.Select(&planIds, "SELECT id FROM plans WHERE org_id = $1", orgId)
+	if err != nil {
+		return total, fmt.Errorf("error listing plans: %v", err)
+	}
+
+	for _, planId := range planIds {
+		num, err := encryptExistingPlanFiles(orgId, planId)
+		total += num
+		if err != nil {
+			return total, fmt.Errorf("error encrypting files for plan %s: %v", planId, err)
+		}
+	}
+
+	return total, nil
+}
+
+func encryptExistingPlanFiles(orgId, planId string) (int, error) {
+	dir := getPlanDir(orgId, planId)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	branches, err := GitListBranches(orgId, planId)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, branch := range branches {
+		err = gitCheckoutBranch(dir, branch)
+		if err != nil {
+			return total, err
+		}
+
+		num := 0
+		for _, filesDir := range []string{getPlanConversationDir(orgId, planId), getPlanResultsDir(orgId, planId)} {
+			paths, err := filepath.Glob(filepath.Join(filesDir, "*.json"))
+			if err != nil {
+				return total, fmt.Errorf("error listing files: %v", err)
+			}
+
+			for _, path := range paths {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return total, fmt.Errorf("error reading file: %v", err)
+				}
+				if isEncrypted(data) {
+					continue
+				}
+
+				err = writeOrgFile(orgId, path, data, 0644)
+				if err != nil {
+					return total, fmt.Errorf("error writing file: %v", err)
+				}
+				num++
+			}
+		}
+
+		if num > 0 {
+			err = GitAddAndCommit(orgId, planId, branch, "Encrypted existing data")
+			if err != nil {
+				return total, err
+			}
+		}
+
+		total += num
+	}
+
+	return total, nil
+}
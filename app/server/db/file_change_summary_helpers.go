@@ -0,0 +1,43 @@
+package db
+
+import (
+	"fmt"
+)
+
+// StoreFileChangeSummary stores the summary of a file build's changes, replacing any earlier summary of the same result
+func StoreFileChangeSummary(summary *FileChangeSummary) error {
+	encrypted, err := encryptOrgString(summary.OrgId, summary.Summary)
+	if err != nil {
+		return fmt.Errorf("error encrypting file change summary: %v", err)
+	}
+
+	_, err = Conn.Exec(`INSERT INTO file_change_summaries (org_id, plan_id, result_id, summary)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (result_id) DO UPDATE SET summary = EXCLUDED.summary`, summary.OrgId, summary.PlanId, summary.ResultId, encrypted)
+
+	if err != nil {
+		return fmt.Errorf("error storing file change summary: %v", err)
+	}
+
+	return nil
+}
+
+// GetFileChangeSummaries returns the plan's file change summaries by result id
+func GetFileChangeSummaries(orgId, planId string) (map[string]string, error) {
+	var summaries []*FileChangeSummary
+	err := Conn.Select(&summaries, "SELECT * FROM file_change_summaries WHERE org_id = $1 AND plan_id = $2", orgId, planId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting file change summaries: %v", err)
+	}
+
+	res := map[string]string{}
+	for _, summary := range summaries {
+		res[summary.ResultId], err = decryptOrgString(orgId, summary.Summary)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting file change summary: %v", err)
+		}
+	}
+
+	return res, nil
+}
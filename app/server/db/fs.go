@@ -61,6 +61,17 @@ func DeletePlanDir(orgId, planId string) error {
 		return fmt.Errorf("error deleting plan dir: %v", err)
 	}
 
+	err = os.Remove(getPlanContextIndexPath(orgId, planId))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error deleting context index: %v", err)
+	}
+
+	// with remote blob storage, build captures aren't under the org dir
+	err = deletePlanBuildCaptureBlobs(orgId, planId)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -87,3 +98,7 @@ func getPlanResultsDir(orgId, planId string) string {
 func getPlanDescriptionsDir(orgId, planId string) string {
 	return filepath.Join(getPlanDir(orgId, planId), "descriptions")
 }
+
+func getPlanReplayPath(orgId, planId string) string {
+	return filepath.Join(getPlanDir(orgId, planId), "replay.json")
+}
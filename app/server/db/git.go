@@ -68,6 +68,20 @@ func GitAddAndCommit(orgId, planId, branch, message string) error {
 	return nil
 }
 
+// GitCommitWithoutChanges adds an entry to the plan's log for something that didn't change the plan's files
+func GitCommitWithoutChanges(orgId, planId, branch, message string) error {
+	dir := getPlanDir(orgId, planId)
+
+	err := retryGitWriteOperationIfIndexFileErr(func() error {
+		return gitCommitAllowEmpty(dir, message)
+	})
+	if err != nil {
+		return fmt.Errorf("error committing to git repository for dir: %s, err: %v", dir, err)
+	}
+
+	return nil
+}
+
 func GitRewindToSha(orgId, planId, branch, sha string) error {
 	dir := getPlanDir(orgId, planId)
 
@@ -81,6 +95,27 @@ func GitRewindToSha(orgId, planId, branch, sha string) error {
 	return nil
 }
 
+// GitGetConvoMessageSha returns the commit that added a conversation message to the plan
+func GitGetConvoMessageSha(orgId, planId, messageId string) (string, error) {
+	dir := getPlanDir(orgId, planId)
+
+	var out bytes.Buffer
+	cmd := exec.Command("git", "log", "--diff-filter=A", "--pretty=%h", "--", filepath.Join("conversation", messageId+".json"))
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("error getting convo message commit for dir: %s, err: %v", dir, err)
+	}
+
+	shas := strings.Fields(out.String())
+	if len(shas) == 0 {
+		return "", fmt.Errorf("no commit found for convo message %s", messageId)
+	}
+
+	return shas[0], nil
+}
+
 func GetGitCommitHistory(orgId, planId, branch string) (body string, shas []string, err error) {
 	dir := getPlanDir(orgId, planId)
 
@@ -333,6 +368,15 @@ func gitCommit(repoDir, commitMsg string) error {
 	return nil
 }
 
+func gitCommitAllowEmpty(repoDir, commitMsg string) error {
+	res, err := exec.Command("git", "-C", repoDir, "commit", "--allow-empty", "-m", commitMsg).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error committing to git repository for dir: %s, err: %v, output: %s", repoDir, err, string(res))
+	}
+
+	return nil
+}
+
 func gitRemoveIndexLockFileIfExists(repoDir string) error {
 	// Remove the lock file if it exists
 	lockFilePath := filepath.Join(repoDir, ".git", "index.lock")
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func getPlanImpactReportPath(orgId, planId string) string {
+	return filepath.Join(getPlanDir(orgId, planId), "impact_report.json")
+}
+
+// GetImpactReport returns the plan's latest impact report, or nil if one hasn't been written on the current branch
+func GetImpactReport(orgId, planId string) (*shared.ImpactReport, error) {
+	bytes, err := readOrgFile(orgId, getPlanImpactReportPath(orgId, planId))
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading impact report: %v", err)
+	}
+
+	var report shared.ImpactReport
+	err = json.Unmarshal(bytes, &report)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling impact report: %v", err)
+	}
+
+	return &report, nil
+}
+
+func StoreImpactReport(orgId, planId string, report *shared.ImpactReport) error {
+	bytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling impact report: %v", err)
+	}
+
+	err = writeOrgFile(orgId, getPlanImpactReportPath(orgId, planId), bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing impact report: %v", err)
+	}
+
+	return nil
+}
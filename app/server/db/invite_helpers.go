@@ -1,24 +1,103 @@
 package db
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
-func CreateInvite(invite *Invite, tx *sqlx.Tx) error {
-	err := tx.QueryRow("INSERT INTO invites (org_id, email, name, inviter_id, org_role_id) VALUES ($1, $2, $3, $4, $5) RETURNING id", invite.OrgId, invite.Email, invite.Name, invite.InviterId, invite.OrgRoleId).Scan(&invite.Id)
+// CreateInvite stores a new invite and returns its token. Only the token's hash is stored. Expired invites for the same email are replaced.
+func CreateInvite(invite *Invite, tx *sqlx.Tx) (string, error) {
+	_, err := tx.Exec("DELETE FROM invites WHERE org_id = $1 AND email = $2 AND accepted_at IS NULL AND expires_at <= $3", invite.OrgId, invite.Email, time.Now())
 
 	if err != nil {
-		return fmt.Errorf("error creating invite: %v", err)
+		return "", fmt.Errorf("error deleting expired invites: %v", err)
+	}
+
+	token, hash := newHashedToken()
+	invite.TokenHash = &hash
+
+	err = tx.QueryRow("INSERT INTO invites (org_id, email, name, inviter_id, org_role_id, token_hash, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id", invite.OrgId, invite.Email, invite.Name, invite.InviterId, invite.OrgRoleId, hash, invite.ExpiresAt).Scan(&invite.Id)
+
+	if err != nil {
+		return "", fmt.Errorf("error creating invite: %v", err)
+	}
+
+	return token, nil
+}
+
+// ResetInviteToken replaces a pending invite's token and expiration, so the old token stops working. Returns the new token.
+func ResetInviteToken(id string, expiresAt time.Time) (string, error) {
+	token, hash := newHashedToken()
+
+	_, err := Conn.Exec("UPDATE invites SET token_hash = $1, expires_at = $2, email_sent_at = NULL WHERE id = $3 AND accepted_at IS NULL", hash, expiresAt, id)
+
+	if err != nil {
+		return "", fmt.Errorf("error resetting invite token: %v", err)
+	}
+
+	return token, nil
+}
+
+func SetInviteEmailSent(id string, sentAt time.Time) error {
+	_, err := Conn.Exec("UPDATE invites SET email_sent_at = $1 WHERE id = $2", sentAt, id)
+
+	if err != nil {
+		return fmt.Errorf("error setting invite email sent: %v", err)
 	}
 
 	return nil
 }
 
+// GetInviteByToken returns the invite for a token, accepted or not, or nil if there isn't one
+func GetInviteByToken(token string) (*Invite, error) {
+	hash, ok := hashToken(token)
+
+	if !ok {
+		return nil, nil
+	}
+
+	var invite Invite
+	err := Conn.Get(&invite, "SELECT * FROM invites WHERE token_hash = $1", hash)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error getting invite: %v", err)
+	}
+
+	return &invite, nil
+}
+
+// newHashedToken generates a token the same way as auth tokens, returning it along with the hash that's stored. Used for invites and trial access codes.
+func newHashedToken() (token, hash string) {
+	uid := uuid.New()
+	hashBytes := sha256.Sum256(uid[:])
+	return uid.String(), hex.EncodeToString(hashBytes[:])
+}
+
+// hashToken returns the stored hash for a token from newHashedToken, or false if it isn't a valid token
+func hashToken(token string) (string, bool) {
+	uid, err := uuid.Parse(token)
+
+	if err != nil {
+		return "", false
+	}
+
+	hashBytes := sha256.Sum256(uid[:])
+	return hex.EncodeToString(hashBytes[:]), true
+}
+
 func GetInvite(id string) (*Invite, error) {
 	var invite Invite
 	err := Conn.Get(&invite, "SELECT * FROM invites WHERE id = $1", id)
@@ -36,7 +115,7 @@ func GetInvite(id string) (*Invite, error) {
 
 func GetActiveInviteByEmail(orgId, email string) (*Invite, error) {
 	var invite Invite
-	err := Conn.Get(&invite, "SELECT * FROM invites WHERE org_id = $1 AND email = $2 AND accepted_at IS NULL", orgId, email)
+	err := Conn.Get(&invite, "SELECT * FROM invites WHERE org_id = $1 AND email = $2 AND accepted_at IS NULL AND (expires_at IS NULL OR expires_at > $3)", orgId, email, time.Now())
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -49,9 +128,10 @@ func GetActiveInviteByEmail(orgId, email string) (*Invite, error) {
 	return &invite, nil
 }
 
+// ListPendingInvites lists the invites that haven't been accepted yet, including expired ones so they can be resent or revoked
 func ListPendingInvites(orgId string) ([]*Invite, error) {
 	var invites []*Invite
-	err := Conn.Select(&invites, "SELECT * FROM invites WHERE org_id = $1 AND accepted_at IS NULL", orgId)
+	err := Conn.Select(&invites, "SELECT * FROM invites WHERE org_id = $1 AND accepted_at IS NULL ORDER BY created_at", orgId)
 
 	if err != nil {
 		return nil, fmt.Errorf("error getting pending invites for org: %v", err)
@@ -85,7 +165,7 @@ func ListAcceptedInvites(orgId string) ([]*Invite, error) {
 func GetPendingInvitesForEmail(email string) ([]*Invite, error) {
 	email = strings.ToLower(email)
 	var invites []*Invite
-	err := Conn.Select(&invites, "SELECT * FROM invites WHERE email = $1 AND accepted_at IS NULL", email)
+	err := Conn.Select(&invites, "SELECT * FROM invites WHERE email = $1 AND accepted_at IS NULL AND (expires_at IS NULL OR expires_at > $2)", email, time.Now())
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -114,6 +194,9 @@ func DeleteInvite(id string, tx *sqlx.Tx) error {
 	return nil
 }
 
+var ErrInviteAlreadyAccepted = errors.New("invite already accepted")
+
+// AcceptInvite marks the invite accepted and adds the invitee to the org. Returns ErrInviteAlreadyAccepted if it was already accepted, including by a concurrent request, so an invite only ever adds one user.
 func AcceptInvite(invite *Invite, inviteeId string) error {
 	// start a transaction
 	tx, err := Conn.Beginx()
@@ -132,11 +215,21 @@ func AcceptInvite(invite *Invite, inviteeId string) error {
 		}
 	}()
 
-	_, err = tx.Exec(`UPDATE invites SET accepted_at = NOW(), invitee_id = $1 WHERE id = $2`, inviteeId, invite.Id)
+	res, err := tx.Exec(`UPDATE invites SET accepted_at = NOW(), invitee_id = $1 WHERE id = $2 AND accepted_at IS NULL`, inviteeId, invite.Id)
 	if err != nil {
 		return fmt.Errorf("error accepting invite: %v", err)
 	}
 
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		err = ErrInviteAlreadyAccepted
+		return err
+	}
+
 	// create org user
 	err = CreateOrgUser(invite.OrgId, inviteeId, invite.OrgRoleId, tx)
 
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAcceptInviteOnlyOnce(t *testing.T) {
+	setupTestDb(t)
+
+	owner := createTestUser(t, "owner@example.com")
+	org, _ := createTestOrg(t, "org", owner)
+	first := createTestUser(t, "first@example.com")
+	second := createTestUser(t, "second@example.com")
+
+	var memberRoleId string
+	err := Conn.Get(&memberRoleId, "SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'member'")
+	if err != nil {
+		t.Fatalf("error getting member role: %v", err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	invite := &Invite{OrgId: org.Id, Email: "invitee@example.com", Name: "Invitee", InviterId: owner.Id, OrgRoleId: memberRoleId, ExpiresAt: &expiresAt}
+
+	tx, err := Conn.Beginx()
+	if err != nil {
+		t.Fatalf("error starting transaction: %v", err)
+	}
+	_, err = CreateInvite(invite, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("error creating invite: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		t.Fatalf("error committing transaction: %v", err)
+	}
+
+	// both requests loaded the invite before either accepted it
+	stale := *invite
+
+	err = AcceptInvite(invite, first.Id)
+	if err != nil {
+		t.Fatalf("error accepting invite: %v", err)
+	}
+
+	err = AcceptInvite(&stale, second.Id)
+	if err != ErrInviteAlreadyAccepted {
+		t.Fatalf("expected ErrInviteAlreadyAccepted, got %v", err)
+	}
+
+	isMember, err := ValidateOrgMembership(second.Id, org.Id)
+	if err != nil {
+		t.Fatalf("error validating org membership: %v", err)
+	}
+	if isMember {
+		t.Errorf("expected the second user not to be added to the org")
+	}
+
+	isMember, err = ValidateOrgMembership(first.Id, org.Id)
+	if err != nil {
+		t.Fatalf("error validating org membership: %v", err)
+	}
+	if !isMember {
+		t.Errorf("expected the first user to be added to the org")
+	}
+
+	accepted, err := GetInvite(invite.Id)
+	if err != nil {
+		t.Fatalf("error getting invite: %v", err)
+	}
+	if accepted.InviteeId == nil || *accepted.InviteeId != first.Id {
+		t.Errorf("expected the invite to stay accepted by the first user, got %v", accepted.InviteeId)
+	}
+}
@@ -0,0 +1,247 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/plandex/plandex/shared"
+)
+
+// EnqueueJob queues a job to run at its RunAt time, or right away if it isn't set. Returns false without queueing it if a queued or running job has the same DedupeKey.
+func EnqueueJob(job *Job) (bool, error) {
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if job.MaxAttempts < 1 {
+		job.MaxAttempts = 1
+	}
+	job.Status = shared.JobStatusQueued
+
+	query := `INSERT INTO jobs (kind, org_id, payload, dedupe_key, status, max_attempts, run_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT DO NOTHING
+	RETURNING id, created_at`
+
+	err := Conn.QueryRow(query, job.Kind, job.OrgId, job.Payload, job.DedupeKey, job.Status, job.MaxAttempts, job.RunAt, time.Now()).Scan(&job.Id, &job.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("error enqueueing job: %v", err)
+	}
+
+	return true, nil
+}
+
+// RunQueuedJobNow moves up the queued job with the given dedupe key so it runs right away
+func RunQueuedJobNow(dedupeKey string) error {
+	_, err := Conn.Exec("UPDATE jobs SET run_at = $1 WHERE dedupe_key = $2 AND status = $3", time.Now(), dedupeKey, shared.JobStatusQueued)
+
+	if err != nil {
+		return fmt.Errorf("error updating job run time: %v", err)
+	}
+
+	return nil
+}
+
+func GetJob(id string) (*Job, error) {
+	var job Job
+	err := Conn.Get(&job, "SELECT * FROM jobs WHERE id = $1", id)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting job: %v", err)
+	}
+
+	return &job, nil
+}
+
+// GetLastFinishedJob returns the most recently finished job of a kind, or nil if none has finished
+func GetLastFinishedJob(kind string) (*Job, error) {
+	var job Job
+	err := Conn.Get(&job, "SELECT * FROM jobs WHERE kind = $1 AND finished_at IS NOT NULL ORDER BY finished_at DESC LIMIT 1", kind)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting last finished job: %v", err)
+	}
+
+	return &job, nil
+}
+
+// ListJobs returns the most recently created jobs, newest first. Empty filters match any status or kind.
+func ListJobs(status shared.JobStatus, kind string, limit int) ([]*Job, error) {
+	var conditions []string
+	var args []interface{}
+
+	if status != "" {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if kind != "" {
+		args = append(args, kind)
+		conditions = append(conditions, fmt.Sprintf("kind = $%d", len(args)))
+	}
+
+	query := "SELECT * FROM jobs"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+	var jobs []*Job
+	err := Conn.Select(&jobs, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing jobs: %v", err)
+	}
+
+	return jobs, nil
+}
+
+// ClaimJob starts the queued job of one of the given kinds that's been due the longest, or returns nil if none are due. Rows locked by another runner's claim are skipped, so runners never claim the same job.
+func ClaimJob(workerIp string, kinds []string) (*Job, error) {
+	query := `UPDATE jobs SET status = $1, attempts = attempts + 1, worker_ip = $2, started_at = $3, heartbeat_at = $3
+	WHERE id = (
+		SELECT id FROM jobs WHERE status = $4 AND run_at <= $3 AND kind = ANY($5) ORDER BY run_at LIMIT 1 FOR UPDATE SKIP LOCKED
+	)
+	RETURNING *`
+
+	var job Job
+	err := Conn.Get(&job, query, shared.JobStatusRunning, workerIp, time.Now(), shared.JobStatusQueued, pq.Array(kinds))
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error claiming job: %v", err)
+	}
+
+	return &job, nil
+}
+
+// JobHeartbeat records that a running job is still being worked on. Returns false if the job is no longer running, which means it was canceled.
+func JobHeartbeat(id string) (bool, error) {
+	res, err := Conn.Exec("UPDATE jobs SET heartbeat_at = $1 WHERE id = $2 AND status = $3", time.Now(), id, shared.JobStatusRunning)
+
+	if err != nil {
+		return false, fmt.Errorf("error updating job heartbeat: %v", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// SetJobSucceeded records that a running job finished. A job that was canceled while it ran stays canceled.
+func SetJobSucceeded(id string) error {
+	_, err := Conn.Exec("UPDATE jobs SET status = $1, finished_at = $2 WHERE id = $3 AND status = $4", shared.JobStatusSucceeded, time.Now(), id, shared.JobStatusRunning)
+
+	if err != nil {
+		return fmt.Errorf("error setting job succeeded: %v", err)
+	}
+
+	return nil
+}
+
+// SetJobFailed records a running job's error. If retryAt is set, the job is queued again to run then, otherwise it's failed for good. A job that was canceled while it ran stays canceled.
+func SetJobFailed(id, errMsg string, retryAt *time.Time) error {
+	var err error
+	if retryAt == nil {
+		_, err = Conn.Exec("UPDATE jobs SET status = $1, last_error = $2, finished_at = $3 WHERE id = $4 AND status = $5", shared.JobStatusFailed, errMsg, time.Now(), id, shared.JobStatusRunning)
+	} else {
+		_, err = Conn.Exec("UPDATE jobs SET status = $1, last_error = $2, run_at = $3 WHERE id = $4 AND status = $5", shared.JobStatusQueued, errMsg, *retryAt, id, shared.JobStatusRunning)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error setting job failed: %v", err)
+	}
+
+	return nil
+}
+
+// RequeueStaleJobs handles running jobs whose runner stopped sending heartbeats before the cutoff, usually because its process exited. Each counts as a failed attempt, so it's queued to run again right away if it has attempts left, or failed otherwise.
+func RequeueStaleJobs(cutoff time.Time) (int, error) {
+	now := time.Now()
+	errMsg := "Job runner stopped while the job was running"
+
+	_, err := Conn.Exec("UPDATE jobs SET status = $1, last_error = $2, finished_at = $3 WHERE status = $4 AND heartbeat_at < $5 AND attempts >= max_attempts", shared.JobStatusFailed, errMsg, now, shared.JobStatusRunning, cutoff)
+
+	if err != nil {
+		return 0, fmt.Errorf("error failing stale jobs: %v", err)
+	}
+
+	res, err := Conn.Exec("UPDATE jobs SET status = $1, last_error = $2, run_at = $3 WHERE status = $4 AND heartbeat_at < $5", shared.JobStatusQueued, errMsg, now, shared.JobStatusRunning, cutoff)
+
+	if err != nil {
+		return 0, fmt.Errorf("error requeueing stale jobs: %v", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// CancelJob cancels a queued or running job, and returns whether it did. A running job's runner finds out at its next heartbeat.
+func CancelJob(id string) (bool, error) {
+	res, err := Conn.Exec("UPDATE jobs SET status = $1, finished_at = $2 WHERE id = $3 AND status IN ($4, $5)", shared.JobStatusCanceled, time.Now(), id, shared.JobStatusQueued, shared.JobStatusRunning)
+
+	if err != nil {
+		return false, fmt.Errorf("error canceling job: %v", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// RetryJob queues a failed or canceled job to run again right away with a fresh set of attempts, and returns whether it did. A job isn't retried while another job with the same dedupe key is queued or running.
+func RetryJob(id string) (bool, error) {
+	query := `UPDATE jobs SET status = $1, attempts = 0, run_at = $2, started_at = NULL, heartbeat_at = NULL, finished_at = NULL
+	WHERE id = $3 AND status IN ($4, $5)
+	AND (dedupe_key IS NULL OR NOT EXISTS (
+		SELECT 1 FROM jobs pending WHERE pending.dedupe_key = jobs.dedupe_key AND pending.status IN ($1, $6)
+	))`
+
+	res, err := Conn.Exec(query, shared.JobStatusQueued, time.Now(), id, shared.JobStatusFailed, shared.JobStatusCanceled, shared.JobStatusRunning)
+
+	if err != nil {
+		return false, fmt.Errorf("error retrying job: %v", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// DeleteOldJobs deletes jobs that finished before the cutoff. Finished jobs are kept for a while so failures can be inspected and retried.
+func DeleteOldJobs(cutoff time.Time) error {
+	_, err := Conn.Exec("DELETE FROM jobs WHERE finished_at IS NOT NULL AND finished_at < $1", cutoff)
+
+	if err != nil {
+		return fmt.Errorf("error deleting old jobs: %v", err)
+	}
+
+	return nil
+}
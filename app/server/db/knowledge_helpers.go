@@ -0,0 +1,179 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+const knowledgeEntryColumns = "k.*, u.name AS user_name"
+
+// ListKnowledgeEntries returns the org's knowledge base entries with their bodies, newest first
+func ListKnowledgeEntries(orgId string) ([]*KnowledgeEntry, error) {
+	var entries []*KnowledgeEntry
+	err := Conn.Select(&entries, "SELECT "+knowledgeEntryColumns+" FROM knowledge_entries k JOIN users u ON k.user_id = u.id WHERE k.org_id = $1 ORDER BY k.created_at DESC", orgId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting knowledge entries: %v", err)
+	}
+
+	for _, entry := range entries {
+		entry.Body, err = decryptOrgString(orgId, entry.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting knowledge entry: %v", err)
+		}
+	}
+
+	return entries, nil
+}
+
+func HasKnowledgeEntries(orgId string) (bool, error) {
+	var count int
+	err := Conn.Get(&count, "SELECT COUNT(*) FROM knowledge_entries WHERE org_id = $1", orgId)
+	if err != nil {
+		return false, fmt.Errorf("error counting knowledge entries: %v", err)
+	}
+	return count > 0, nil
+}
+
+// GetKnowledgeEntry returns the entry, or nil if the org doesn't have one with the id
+func GetKnowledgeEntry(orgId, id string) (*KnowledgeEntry, error) {
+	var entry KnowledgeEntry
+	err := Conn.Get(&entry, "SELECT "+knowledgeEntryColumns+" FROM knowledge_entries k JOIN users u ON k.user_id = u.id WHERE k.org_id = $1 AND k.id = $2", orgId, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting knowledge entry: %v", err)
+	}
+
+	entry.Body, err = decryptOrgString(orgId, entry.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting knowledge entry: %v", err)
+	}
+
+	return &entry, nil
+}
+
+// StoreKnowledgeEntry creates the entry, or for an entry with a source plan, replaces the plan's existing entry. The entry's Id and timestamps are set. embedding can be nil, in which case the entry is matched by keyword until it's embedded.
+func StoreKnowledgeEntry(entry *KnowledgeEntry, embedding []float32, embeddingModel string) error {
+	body, err := encryptOrgString(entry.OrgId, entry.Body)
+	if err != nil {
+		return fmt.Errorf("error encrypting knowledge entry: %v", err)
+	}
+
+	embeddingJson, modelName, err := embeddingColumns(embedding, embeddingModel)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO knowledge_entries (org_id, user_id, source_plan_id, kind, title, body, num_tokens, embedding, embedding_model)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (source_plan_id) DO UPDATE SET
+		user_id = excluded.user_id,
+		title = excluded.title,
+		body = excluded.body,
+		num_tokens = excluded.num_tokens,
+		embedding = excluded.embedding,
+		embedding_model = excluded.embedding_model
+	RETURNING id, created_at, updated_at`
+
+	err = Conn.QueryRow(query, entry.OrgId, entry.UserId, entry.SourcePlanId, entry.Kind, entry.Title, body, entry.NumTokens, embeddingJson, modelName).Scan(&entry.Id, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error storing knowledge entry: %v", err)
+	}
+
+	entry.Embedding = embeddingJson
+	entry.EmbeddingModel = modelName
+
+	return nil
+}
+
+// SetKnowledgeEmbedding stores an embedding for an entry that was stored without one, or with a different embedding model
+func SetKnowledgeEmbedding(entry *KnowledgeEntry, embedding []float32, embeddingModel string) error {
+	embeddingJson, modelName, err := embeddingColumns(embedding, embeddingModel)
+	if err != nil {
+		return err
+	}
+
+	_, err = Conn.Exec("UPDATE knowledge_entries SET embedding = $1, embedding_model = $2 WHERE id = $3", embeddingJson, modelName, entry.Id)
+	if err != nil {
+		return fmt.Errorf("error storing knowledge embedding: %v", err)
+	}
+
+	entry.Embedding = embeddingJson
+	entry.EmbeddingModel = modelName
+
+	return nil
+}
+
+// ListUnembeddedKnowledgeOrgIds returns the orgs that have knowledge entries without an embedding from the embedding model
+func ListUnembeddedKnowledgeOrgIds(embeddingModel string) ([]string, error) {
+	var orgIds []string
+	err := Conn.Select(&orgIds, "SELECT DISTINCT org_id FROM knowledge_entries WHERE embedding IS NULL OR embedding_model IS NULL OR embedding_model != $1", embeddingModel)
+	if err != nil {
+		return nil, fmt.Errorf("error getting orgs with unembedded knowledge entries: %v", err)
+	}
+	return orgIds, nil
+}
+
+// ListUnembeddedKnowledgeEntries returns up to limit of the org's entries without an embedding from the embedding model, with their bodies, oldest first
+func ListUnembeddedKnowledgeEntries(orgId, embeddingModel string, limit int) ([]*KnowledgeEntry, error) {
+	var entries []*KnowledgeEntry
+	err := Conn.Select(&entries, "SELECT "+knowledgeEntryColumns+" FROM knowledge_entries k JOIN users u ON k.user_id = u.id WHERE k.org_id = $1 AND (k.embedding IS NULL OR k.embedding_model IS NULL OR k.embedding_model != $2) ORDER BY k.created_at LIMIT $3", orgId, embeddingModel, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting unembedded knowledge entries: %v", err)
+	}
+
+	for _, entry := range entries {
+		entry.Body, err = decryptOrgString(orgId, entry.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting knowledge entry: %v", err)
+		}
+	}
+
+	return entries, nil
+}
+
+// DeleteKnowledgeEntry deletes the entry. It returns false if the org doesn't have one with the id.
+func DeleteKnowledgeEntry(orgId, id string) (bool, error) {
+	res, err := Conn.Exec("DELETE FROM knowledge_entries WHERE org_id = $1 AND id = $2", orgId, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting knowledge entry: %v", err)
+	}
+
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return numRows > 0, nil
+}
+
+// GetEmbedding returns the entry's embedding if it has one for the given model, or nil
+func (entry *KnowledgeEntry) GetEmbedding(embeddingModel string) ([]float32, error) {
+	if entry.Embedding == nil || entry.EmbeddingModel == nil || *entry.EmbeddingModel != embeddingModel {
+		return nil, nil
+	}
+
+	var embedding []float32
+	err := json.Unmarshal([]byte(*entry.Embedding), &embedding)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling knowledge embedding: %v", err)
+	}
+
+	return embedding, nil
+}
+
+func embeddingColumns(embedding []float32, embeddingModel string) (*string, *string, error) {
+	if embedding == nil {
+		return nil, nil, nil
+	}
+
+	bytes, err := json.Marshal(embedding)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshalling knowledge embedding: %v", err)
+	}
+
+	embeddingJson := string(bytes)
+	return &embeddingJson, &embeddingModel, nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestListUnembeddedKnowledgeEntries(t *testing.T) {
+	setupTestDb(t)
+
+	owner := createTestUser(t, "owner@example.com")
+	org, _ := createTestOrg(t, "org", owner)
+	otherOrg, _ := createTestOrg(t, "other org", owner)
+
+	store := func(orgId, title string, embedding []float32, embeddingModel string) *KnowledgeEntry {
+		entry := &KnowledgeEntry{OrgId: orgId, UserId: owner.Id, Kind: shared.KnowledgeKindNote, Title: title, Body: title + " body"}
+		err := StoreKnowledgeEntry(entry, embedding, embeddingModel)
+		if err != nil {
+			t.Fatalf("error storing knowledge entry: %v", err)
+		}
+		return entry
+	}
+
+	unembedded := store(org.Id, "unembedded", nil, "")
+	outdated := store(org.Id, "outdated", []float32{1, 0}, "old-model")
+	store(org.Id, "embedded", []float32{0, 1}, "model")
+	store(otherOrg.Id, "embedded", []float32{0, 1}, "model")
+
+	orgIds, err := ListUnembeddedKnowledgeOrgIds("model")
+	if err != nil {
+		t.Fatalf("error listing orgs: %v", err)
+	}
+	if len(orgIds) != 1 || orgIds[0] != org.Id {
+		t.Fatalf("expected only the org with unembedded entries, got %v", orgIds)
+	}
+
+	entries, err := ListUnembeddedKnowledgeEntries(org.Id, "model", 10)
+	if err != nil {
+		t.Fatalf("error listing entries: %v", err)
+	}
+	got := map[string]*KnowledgeEntry{}
+	for _, entry := range entries {
+		got[entry.Id] = entry
+	}
+	if len(got) != 2 || got[unembedded.Id] == nil || got[outdated.Id] == nil {
+		t.Fatalf("expected the unembedded and outdated entries, got %d entries", len(entries))
+	}
+	if body := got[unembedded.Id].Body; body != "unembedded body" {
+		t.Errorf("expected the decrypted body, got %q", body)
+	}
+
+	err = SetKnowledgeEmbedding(got[unembedded.Id], []float32{1, 1}, "model")
+	if err != nil {
+		t.Fatalf("error setting embedding: %v", err)
+	}
+
+	entries, err = ListUnembeddedKnowledgeEntries(org.Id, "model", 10)
+	if err != nil {
+		t.Fatalf("error listing entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Id != outdated.Id {
+		t.Fatalf("expected only the outdated entry after embedding the other, got %d entries", len(entries))
+	}
+}
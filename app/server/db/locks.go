@@ -73,7 +73,7 @@ func lockRepo(params LockRepoParams, numRetry int) (string, error) {
 		log.Println("obtaining repo lock with query")
 		rows, err := tx.Query(query, queryArgs...)
 		if err != nil {
-			if pqErr, ok := err.(*pq.Error); ok && (pqErr.Code == "40001" || pqErr.Code == "40P01") {
+			if isConcurrencyErr(err) {
 				// return concurrency errors directly for retries
 				return err
 			}
@@ -113,7 +113,7 @@ func lockRepo(params LockRepoParams, numRetry int) (string, error) {
 	}
 	err = fn()
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && (pqErr.Code == "40001" || pqErr.Code == "40P01") {
+		if isConcurrencyErr(err) {
 			if numRetry > maxRetries {
 				err = fmt.Errorf("plan is currently being updated by another user")
 				log.Println("max retries reached on serialization error, returning error")
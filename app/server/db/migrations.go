@@ -0,0 +1,234 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"plandex-server/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Schema migrations are embedded in the binary (see the migrations package) and managed with the `plandex-server migrate` command. By default, pending migrations are also applied when the server starts. Set PLANDEX_AUTO_MIGRATE=false to require running them explicitly instead.
+
+type MigrationStatus struct {
+	Driver  string
+	Version uint // 0 if no migrations have been applied
+	Dirty   bool
+	Latest  uint
+	Pending []uint
+}
+
+func (s *MigrationStatus) IsUpToDate() bool {
+	return !s.Dirty && len(s.Pending) == 0 && s.Version == s.Latest
+}
+
+// IsAhead is true when the database has a migration applied that this version of the server doesn't know about, meaning the server is older than the schema
+func (s *MigrationStatus) IsAhead() bool {
+	return s.Version > s.Latest
+}
+
+func AutoMigrateEnabled() bool {
+	return os.Getenv("PLANDEX_AUTO_MIGRATE") != "false"
+}
+
+func getMigrationsSource() (source.Driver, error) {
+	var dir fs.FS = migrations.FS
+	var err error
+
+	if Driver == DriverSqlite {
+		dir, err = fs.Sub(migrations.FS, "sqlite")
+		if err != nil {
+			return nil, fmt.Errorf("error reading sqlite migrations: %v", err)
+		}
+	}
+
+	src, err := iofs.New(dir, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %v", err)
+	}
+
+	return src, nil
+}
+
+func newMigrate() (*migrate.Migrate, error) {
+	if Conn == nil {
+		return nil, errors.New("db not initialized")
+	}
+
+	var driver database.Driver
+	var err error
+
+	if Driver == DriverSqlite {
+		driver, err = sqlite3.WithInstance(Conn.DB, &sqlite3.Config{})
+	} else {
+		driver, err = postgres.WithInstance(Conn.DB, &postgres.Config{})
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s driver: %v", Driver, err)
+	}
+
+	src, err := getMigrationsSource()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, Driver, driver)
+	if err != nil {
+		return nil, fmt.Errorf("error creating migration instance: %v", err)
+	}
+
+	return m, nil
+}
+
+func GetMigrationStatus() (*MigrationStatus, error) {
+	m, err := newMigrate()
+	if err != nil {
+		return nil, err
+	}
+
+	return getMigrationStatus(m)
+}
+
+func getMigrationStatus(m *migrate.Migrate) (*MigrationStatus, error) {
+	status := MigrationStatus{Driver: Driver}
+
+	version, dirty, err := m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return nil, fmt.Errorf("error getting migration version: %v", err)
+	}
+	status.Version = version
+	status.Dirty = dirty
+
+	src, err := getMigrationsSource()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	v, err := src.First()
+	for err == nil {
+		status.Latest = v
+		if v > status.Version {
+			status.Pending = append(status.Pending, v)
+		}
+		v, err = src.Next(v)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error listing migrations: %v", err)
+	}
+
+	return &status, nil
+}
+
+// checkMigrationSafety refuses to run migrations against a dirty schema (a previous migration failed partway through) or a schema that's newer than this version of the server
+func checkMigrationSafety(status *MigrationStatus) error {
+	if status.Dirty {
+		return fmt.Errorf("database schema is dirty at version %d -- a previous migration failed partway through. Fix the schema manually, then run 'plandex-server migrate force %d' (or the previous version if the migration made no changes)", status.Version, status.Version)
+	}
+
+	if status.IsAhead() {
+		return fmt.Errorf("database schema is at version %d, but this version of plandex-server only knows about migrations up to %d. Upgrade plandex-server or roll back the schema with a newer version of plandex-server", status.Version, status.Latest)
+	}
+
+	return nil
+}
+
+// MigrationsUp applies pending migrations. If steps is greater than 0, at most that many are applied.
+func MigrationsUp(steps int) error {
+	m, err := newMigrate()
+	if err != nil {
+		return err
+	}
+
+	status, err := getMigrationStatus(m)
+	if err != nil {
+		return err
+	}
+
+	err = checkMigrationSafety(status)
+	if err != nil {
+		return err
+	}
+
+	if steps > 0 {
+		err = m.Steps(steps)
+	} else {
+		err = m.Up()
+	}
+
+	if err != nil {
+		if err == migrate.ErrNoChange {
+			log.Println("migration state is up to date")
+			return nil
+		}
+		return fmt.Errorf("error running migrations: %v", err)
+	}
+
+	log.Println("ran migrations successfully")
+
+	return nil
+}
+
+// MigrationsDown rolls back the given number of migrations. If all is true, every migration is rolled back, which drops all tables.
+func MigrationsDown(steps int, all bool) error {
+	if steps <= 0 && !all {
+		return errors.New("number of steps to roll back is required")
+	}
+
+	m, err := newMigrate()
+	if err != nil {
+		return err
+	}
+
+	status, err := getMigrationStatus(m)
+	if err != nil {
+		return err
+	}
+
+	err = checkMigrationSafety(status)
+	if err != nil {
+		return err
+	}
+
+	if all {
+		err = m.Down()
+	} else {
+		err = m.Steps(-steps)
+	}
+
+	if err != nil {
+		if err == migrate.ErrNoChange {
+			log.Println("no migrations to roll back")
+			return nil
+		}
+		return fmt.Errorf("error rolling back migrations: %v", err)
+	}
+
+	log.Println("rolled back migrations successfully")
+
+	return nil
+}
+
+// MigrationsForce sets the schema version without running any migrations and clears the dirty flag -- used to recover after a failed migration has been fixed manually
+func MigrationsForce(version int) error {
+	m, err := newMigrate()
+	if err != nil {
+		return err
+	}
+
+	err = m.Force(version)
+	if err != nil {
+		return fmt.Errorf("error forcing migration version: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// a pricing document is small, so anything much larger is a mistake
+const maxModelPricesBytes = 5 * 1024 * 1024
+
+var modelPricesHttpClient = &http.Client{Timeout: 30 * time.Second}
+
+// FetchModelPrices updates the catalog from the document at PLANDEX_MODEL_PRICES_URL, which has the same format as an admin update request. Prices set by an admin aren't replaced. Returns the number of catalog entries that were added or updated.
+func FetchModelPrices() (int, error) {
+	url := os.Getenv("PLANDEX_MODEL_PRICES_URL")
+	if url == "" {
+		return 0, fmt.Errorf("PLANDEX_MODEL_PRICES_URL isn't set")
+	}
+
+	resp, err := modelPricesHttpClient.Get(url)
+	if err != nil {
+		return 0, fmt.Errorf("error requesting model prices: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("model prices request returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxModelPricesBytes+1))
+	if err != nil {
+		return 0, fmt.Errorf("error reading model prices: %v", err)
+	}
+	if len(body) > maxModelPricesBytes {
+		return 0, fmt.Errorf("model prices document is larger than %d bytes", maxModelPricesBytes)
+	}
+
+	var doc shared.UpdateModelPricesRequest
+	err = json.Unmarshal(body, &doc)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing model prices: %v", err)
+	}
+
+	// an invalid entry is skipped rather than failing the whole update, since the document isn't under the server's control
+	var prices []*shared.ModelPrice
+	for _, price := range doc.Prices {
+		if price == nil {
+			continue
+		}
+		err := price.Validate()
+		if err != nil {
+			log.Printf("Skipping fetched model price: %v\n", err)
+			continue
+		}
+		prices = append(prices, price)
+	}
+
+	return StoreModelPrices(prices, shared.ModelPriceSourceFetched)
+}
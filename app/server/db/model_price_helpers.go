@@ -0,0 +1,162 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// the catalog is cached so pricing lookups don't query the db on every model call. Other servers pick up changes once their cache expires.
+const modelPriceCacheTTL = 5 * time.Minute
+
+var modelPriceCache map[string]*shared.ModelPricing
+var modelPriceCacheLoadedAt time.Time
+var modelPriceCacheMu sync.Mutex
+
+func modelPriceKey(provider, modelName string) string {
+	return provider + "|" + strings.ToLower(modelName)
+}
+
+func ListModelPrices() ([]*shared.ModelPrice, error) {
+	var prices []*ModelPrice
+	err := Conn.Select(&prices, "SELECT * FROM model_prices ORDER BY provider, model_name")
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing model prices: %v", err)
+	}
+
+	res := []*shared.ModelPrice{}
+	for _, price := range prices {
+		res = append(res, price.ToApi())
+	}
+
+	return res, nil
+}
+
+// StoreModelPrices adds or replaces catalog entries in a transaction. Fetched prices don't replace prices set by an admin. Returns the number of entries that were added or replaced.
+func StoreModelPrices(prices []*shared.ModelPrice, source shared.ModelPriceSource) (int, error) {
+	tx, err := Conn.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	query := `INSERT INTO model_prices (provider, model_name, input_per_million, output_per_million, source)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (provider, model_name) DO UPDATE SET
+		input_per_million = excluded.input_per_million,
+		output_per_million = excluded.output_per_million,
+		source = excluded.source`
+
+	if source == shared.ModelPriceSourceFetched {
+		query += `
+	WHERE model_prices.source = 'fetched'`
+	}
+
+	var numUpdated int
+	for _, price := range prices {
+		var res sql.Result
+		res, err = tx.Exec(query, price.Provider, price.ModelName, price.InputPerMillion, price.OutputPerMillion, source)
+		if err != nil {
+			return 0, fmt.Errorf("error storing price for %s: %v", price.ModelName, err)
+		}
+
+		var n int64
+		n, err = res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("error getting rows affected: %v", err)
+		}
+		numUpdated += int(n)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	resetModelPriceCache()
+
+	return numUpdated, nil
+}
+
+// DeleteModelPrice returns false if there was no entry to delete
+func DeleteModelPrice(provider, modelName string) (bool, error) {
+	res, err := Conn.Exec("DELETE FROM model_prices WHERE provider = $1 AND model_name = $2", provider, modelName)
+
+	if err != nil {
+		return false, fmt.Errorf("error deleting model price: %v", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	resetModelPriceCache()
+
+	return n > 0, nil
+}
+
+// GetModelPricing returns the price for a model from the catalog, preferring an entry for the model's provider over one for any provider, or else the model's built-in list price. Returns nil if the price isn't known. If the catalog can't be loaded, built-in prices are used.
+func GetModelPricing(provider shared.ModelProvider, modelName string) *shared.ModelPricing {
+	catalog, err := getModelPriceCatalog()
+
+	if err == nil {
+		if pricing, ok := catalog[modelPriceKey(string(provider), modelName)]; ok {
+			return pricing
+		}
+		if pricing, ok := catalog[modelPriceKey("", modelName)]; ok {
+			return pricing
+		}
+	} else {
+		log.Printf("Error loading model price catalog: %v\n", err)
+	}
+
+	return shared.GetModelPricing(modelName)
+}
+
+func getModelPriceCatalog() (map[string]*shared.ModelPricing, error) {
+	modelPriceCacheMu.Lock()
+	defer modelPriceCacheMu.Unlock()
+
+	if modelPriceCache != nil && time.Since(modelPriceCacheLoadedAt) < modelPriceCacheTTL {
+		return modelPriceCache, nil
+	}
+
+	prices, err := ListModelPrices()
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := map[string]*shared.ModelPricing{}
+	for _, price := range prices {
+		pricing := price.ModelPricing
+		catalog[modelPriceKey(string(price.Provider), price.ModelName)] = &pricing
+	}
+
+	modelPriceCache = catalog
+	modelPriceCacheLoadedAt = time.Now()
+
+	return catalog, nil
+}
+
+func resetModelPriceCache() {
+	modelPriceCacheMu.Lock()
+	defer modelPriceCacheMu.Unlock()
+	modelPriceCache = nil
+}
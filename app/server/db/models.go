@@ -5,10 +5,10 @@ import (
 )
 
 func CreateCustomModel(model *AvailableModel) error {
-	query := `INSERT INTO custom_models (org_id, provider, custom_provider, base_url, model_name, description, max_tokens, api_key_env_var, is_openai_compatible, has_json_mode, has_streaming, has_function_calling, has_streaming_function_calls, default_max_convo_tokens, default_reserved_output_tokens) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	query := `INSERT INTO custom_models (org_id, provider, custom_provider, base_url, model_name, description, max_tokens, api_key_env_var, is_openai_compatible, has_json_mode, has_streaming, has_function_calling, has_streaming_function_calls, has_structured_outputs, default_max_convo_tokens, default_reserved_output_tokens) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	RETURNING id, created_at, updated_at`
 
-	err := Conn.QueryRow(query, model.OrgId, model.Provider, model.CustomProvider, model.BaseUrl, model.ModelName, model.Description, model.MaxTokens, model.ApiKeyEnvVar, model.IsOpenAICompatible, model.HasJsonResponseMode, model.HasStreaming, model.HasFunctionCalling, model.HasStreamingFunctionCalls, model.DefaultMaxConvoTokens, model.DefaultReservedOutputTokens).Scan(&model.Id, &model.CreatedAt, &model.UpdatedAt)
+	err := Conn.QueryRow(query, model.OrgId, model.Provider, model.CustomProvider, model.BaseUrl, model.ModelName, model.Description, model.MaxTokens, model.ApiKeyEnvVar, model.IsOpenAICompatible, model.HasJsonResponseMode, model.HasStreaming, model.HasFunctionCalling, model.HasStreamingFunctionCalls, model.HasStructuredOutputs, model.DefaultMaxConvoTokens, model.DefaultReservedOutputTokens).Scan(&model.Id, &model.CreatedAt, &model.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("error inserting new custom model: %v", err)
@@ -43,10 +43,10 @@ func DeleteAvailableModel(modelId string) error {
 }
 
 func CreateModelPack(ms *ModelPack) error {
-	query := `INSERT INTO model_sets (org_id, name, description, planner, plan_summary, builder, namer, commit_msg, exec_status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	query := `INSERT INTO model_sets (org_id, name, description, planner, plan_summary, builder, namer, commit_msg, exec_status, verifier, auto_fix, draft_builder, chat) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	RETURNING id, created_at`
 
-	err := Conn.QueryRow(query, ms.OrgId, ms.Name, ms.Description, ms.Planner, ms.PlanSummary, ms.Builder, ms.Namer, ms.CommitMsg, ms.ExecStatus).Scan(&ms.Id, &ms.CreatedAt)
+	err := Conn.QueryRow(query, ms.OrgId, ms.Name, ms.Description, ms.Planner, ms.PlanSummary, ms.Builder, ms.Namer, ms.CommitMsg, ms.ExecStatus, ms.Verifier, ms.AutoFix, ms.DraftBuilder, ms.Chat).Scan(&ms.Id, &ms.CreatedAt)
 
 	if err != nil {
 		return fmt.Errorf("error inserting new model pack: %v", err)
@@ -68,6 +68,28 @@ func ListModelPacks(orgId string) ([]*ModelPack, error) {
 	return modelPacks, nil
 }
 
+func UpdateModelPack(ms *ModelPack) error {
+	query := `UPDATE model_sets SET name = $1, description = $2, planner = $3, plan_summary = $4, builder = $5, namer = $6, commit_msg = $7, exec_status = $8, verifier = $9, auto_fix = $10, draft_builder = $11, chat = $12 WHERE id = $13 AND org_id = $14`
+
+	res, err := Conn.Exec(query, ms.Name, ms.Description, ms.Planner, ms.PlanSummary, ms.Builder, ms.Namer, ms.CommitMsg, ms.ExecStatus, ms.Verifier, ms.AutoFix, ms.DraftBuilder, ms.Chat, ms.Id, ms.OrgId)
+
+	if err != nil {
+		return fmt.Errorf("error updating model pack: %v", err)
+	}
+
+	numRows, err := res.RowsAffected()
+
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if numRows == 0 {
+		return fmt.Errorf("model pack not found")
+	}
+
+	return nil
+}
+
 func DeleteModelPack(setId string) error {
 	query := `DELETE FROM model_sets WHERE id = $1`
 	_, err := Conn.Exec(query, setId)
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func ListOrgCredentials(orgId string) ([]*shared.OrgCredential, error) {
+	var credentials []*OrgCredential
+	err := Conn.Select(&credentials, "SELECT * FROM org_credentials WHERE org_id = $1 ORDER BY name", orgId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing org credentials: %v", err)
+	}
+
+	var res []*shared.OrgCredential
+	for _, credential := range credentials {
+		res = append(res, credential.ToApi())
+	}
+
+	return res, nil
+}
+
+// SetOrgCredential creates or replaces an org credential. Credentials are only stored encrypted, so encryption at rest must be enabled.
+func SetOrgCredential(orgId, userId, name, value string) error {
+	if !EncryptionEnabled() {
+		return fmt.Errorf("org credentials require encryption at rest -- set PLANDEX_ENCRYPTION_PASSPHRASE or PLANDEX_ENCRYPTION_KMS_KEY_ID on the server")
+	}
+
+	encrypted, err := encryptOrgString(orgId, value)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO org_credentials (org_id, name, encrypted_value, created_by)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (org_id, name) DO UPDATE SET
+		encrypted_value = excluded.encrypted_value,
+		created_by = excluded.created_by
+	`
+
+	_, err = Conn.Exec(query, orgId, name, encrypted, userId)
+
+	if err != nil {
+		return fmt.Errorf("error storing org credential: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteOrgCredential returns false if the org has no credential with the name
+func DeleteOrgCredential(orgId, name string) (bool, error) {
+	res, err := Conn.Exec("DELETE FROM org_credentials WHERE org_id = $1 AND name = $2", orgId, name)
+
+	if err != nil {
+		return false, fmt.Errorf("error deleting org credential: %v", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetOrgApiKeys returns an org's decrypted credentials keyed by name, for creating model clients
+func GetOrgApiKeys(orgId string) (map[string]string, error) {
+	var credentials []*OrgCredential
+	err := Conn.Select(&credentials, "SELECT * FROM org_credentials WHERE org_id = $1", orgId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting org credentials: %v", err)
+	}
+
+	res := map[string]string{}
+	for _, credential := range credentials {
+		value, err := decryptOrgString(orgId, credential.EncryptedValue)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting org credential %s: %v", credential.Name, err)
+		}
+		res[credential.Name] = value
+	}
+
+	return res, nil
+}
+
+// OrgCredentialBaseUrlName is the org credential that sets where an org api key is sent, like OPENAI_BASE_URL for OPENAI_API_KEY
+func OrgCredentialBaseUrlName(envVar string) string {
+	return strings.TrimSuffix(envVar, "_API_KEY") + "_BASE_URL"
+}
+
+// GetOrgKeyEndpoint returns where an org api key may be sent: the base url set with the org's credentials, or else the default url of the provider the key belongs to. Only admins can set org credentials, unlike the base urls in requests and model packs, which any member with write access controls. The result is empty if the key has neither.
+func GetOrgKeyEndpoint(envVar string, orgApiKeys map[string]string) string {
+	if baseUrl := orgApiKeys[OrgCredentialBaseUrlName(envVar)]; baseUrl != "" {
+		return baseUrl
+	}
+
+	for provider, providerEnvVar := range shared.ApiKeyByProvider {
+		if providerEnvVar == envVar {
+			return shared.BaseUrlByProvider[provider]
+		}
+	}
+
+	return ""
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -15,6 +16,12 @@ import (
 	"github.com/sashabaranov/go-openai"
 )
 
+// ErrPlanNameTaken is returned when another plan in the project already has the name. Drafts can share a name, since each user has their own.
+var ErrPlanNameTaken = errors.New("another plan in the project already has that name")
+
+// maxPlanNameAttempts is how many free variations of a name RenamePlanUnique tries before giving up
+const maxPlanNameAttempts = 5
+
 func CreatePlan(orgId, projectId, userId, name string) (*Plan, error) {
 	// start a transaction
 	tx, err := Conn.Beginx()
@@ -57,6 +64,10 @@ func CreatePlan(orgId, projectId, userId, name string) (*Plan, error) {
 	)
 
 	if err != nil {
+		if IsNonUniqueErr(err) {
+			err = ErrPlanNameTaken
+			return nil, err
+		}
 		return nil, fmt.Errorf("error creating plan: %v", err)
 	}
 
@@ -216,21 +227,89 @@ func SetPlanStatus(planId, branch string, status shared.PlanStatus, errStr strin
 	return nil
 }
 
-func RenamePlan(planId string, name string, tx *sqlx.Tx) error {
+// RenamePlan renames a plan. autoNamePending is set when the name was generated from a prompt that didn't say much about the plan, so it should be generated again later.
+func RenamePlan(planId string, name string, autoNamePending bool, tx *sqlx.Tx) error {
 	var err error
+	query := "UPDATE plans SET name = $1, auto_name_pending = $2 WHERE id = $3"
 	if tx == nil {
-		_, err = Conn.Exec("UPDATE plans SET name = $1 WHERE id = $2", name, planId)
+		_, err = Conn.Exec(query, name, autoNamePending, planId)
 	} else {
-		_, err = tx.Exec("UPDATE plans SET name = $1 WHERE id = $2", name, planId)
+		_, err = tx.Exec(query, name, autoNamePending, planId)
 	}
 
 	if err != nil {
+		if IsNonUniqueErr(err) {
+			return ErrPlanNameTaken
+		}
 		return fmt.Errorf("error renaming plan: %v", err)
 	}
 
 	return nil
 }
 
+// RenamePlanUnique renames a plan to the first free variation of name from UniquePlanName, and returns the name it got. The unique index on plan names backs the check, so if another plan takes the name between the check and the rename, the next free variation is tried. In a transaction, each attempt runs in a savepoint so a conflict doesn't abort the rest of it.
+func RenamePlanUnique(orgId, planId, name string, autoNamePending bool, tx *sqlx.Tx) (string, error) {
+	for i := 0; i < maxPlanNameAttempts; i++ {
+		uniqueName, err := UniquePlanName(orgId, name, planId)
+		if err != nil {
+			return "", err
+		}
+
+		if tx != nil {
+			_, err = tx.Exec("SAVEPOINT rename_plan")
+			if err != nil {
+				return "", fmt.Errorf("error creating savepoint: %v", err)
+			}
+		}
+
+		err = RenamePlan(planId, uniqueName, autoNamePending, tx)
+
+		if tx != nil {
+			savepointQuery := "RELEASE SAVEPOINT rename_plan"
+			if err == ErrPlanNameTaken {
+				savepointQuery = "ROLLBACK TO SAVEPOINT rename_plan"
+			}
+			_, spErr := tx.Exec(savepointQuery)
+			if spErr != nil {
+				return "", fmt.Errorf("error ending savepoint: %v", spErr)
+			}
+		}
+
+		if err == ErrPlanNameTaken {
+			log.Printf("Plan name %s was taken before the rename, trying again\n", uniqueName)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		return uniqueName, nil
+	}
+
+	return "", ErrPlanNameTaken
+}
+
+// UniquePlanName returns name, or name with the first free '-2', '-3', ... suffix if another plan in the org already has it. It's only a check -- use RenamePlanUnique to rename a plan, since another plan could take the name before it's used. excludePlanId is the plan being named, so it doesn't collide with itself.
+func UniquePlanName(orgId, name, excludePlanId string) (string, error) {
+	var names []string
+	err := Conn.Select(&names, "SELECT name FROM plans WHERE org_id = $1 AND id != $2 AND (name = $3 OR name LIKE $4)", orgId, excludePlanId, name, name+"-%")
+	if err != nil {
+		return "", fmt.Errorf("error getting plan names: %v", err)
+	}
+
+	taken := make(map[string]bool, len(names))
+	for _, n := range names {
+		taken[n] = true
+	}
+
+	res := name
+	for i := 2; taken[res]; i++ {
+		res = fmt.Sprintf("%s-%d", name, i)
+	}
+
+	return res, nil
+}
+
 func IncActiveBranches(planId string, inc int, tx *sqlx.Tx) error {
 	_, err := tx.Exec("UPDATE plans SET active_branches = active_branches + $1 WHERE id = $2", inc, planId)
 
@@ -365,43 +444,51 @@ func DeleteOwnerPlans(orgId, projectId, userId string) error {
 	return nil
 }
 
-func ValidatePlanAccess(planId, userId, orgId string) (*Plan, error) {
+// ValidatePlanAccess returns the plan if the user can access it from the org. A plan shared directly with the user can be accessed from any org, in which case the share is also returned so its access level can be enforced.
+func ValidatePlanAccess(planId, userId, orgId string) (*Plan, *PlanShare, error) {
 	// get plan
 	plan, err := GetPlan(planId)
 
 	if err != nil {
-		return nil, fmt.Errorf("error getting plan: %v", err)
+		return nil, nil, fmt.Errorf("error getting plan: %v", err)
 	}
 
 	if plan == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 
-	if plan.OrgId != orgId {
-		return nil, nil
-	}
+	if plan.OrgId == orgId {
+		hasProjectAccess, err := ProjectExists(orgId, plan.ProjectId)
 
-	hasProjectAccess, err := ProjectExists(orgId, plan.ProjectId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error validating project membership: %v", err)
+		}
 
-	if err != nil {
-		return nil, fmt.Errorf("error validating project membership: %v", err)
-	}
+		if hasProjectAccess {
+			// owner has access
+			if plan.OwnerId == userId {
+				return plan, nil, nil
+			}
 
-	if !hasProjectAccess {
-		return nil, nil
+			// plan is shared with org
+			if plan.SharedWithOrgAt != nil {
+				return plan, nil, nil
+			}
+		}
 	}
 
-	// owner has access
-	if plan.OwnerId == userId {
-		return plan, nil
+	// plan is shared with user
+	share, err := GetPlanShare(planId, userId)
+
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// plan is shared with org
-	if plan.SharedWithOrgAt != nil {
-		return plan, nil
+	if share != nil {
+		return plan, share, nil
 	}
 
-	return nil, nil
+	return nil, nil, nil
 }
 
 func BumpPlanUpdatedAt(planId string, t time.Time) error {
@@ -0,0 +1,63 @@
+package db
+
+import "testing"
+
+func TestPlanNamesUniqueInProject(t *testing.T) {
+	setupTestDb(t)
+
+	owner := createTestUser(t, "owner@example.com")
+	org, projectId := createTestOrg(t, "org", owner)
+	member := createTestUser(t, "member@example.com")
+	addTestOrgUser(t, org.Id, member.Id, "member")
+
+	createTestPlan(t, org.Id, projectId, owner.Id, "auth-refactor")
+	other := createTestPlan(t, org.Id, projectId, member.Id, "other")
+
+	// each user has their own draft
+	createTestPlan(t, org.Id, projectId, owner.Id, "draft")
+	createTestPlan(t, org.Id, projectId, member.Id, "draft")
+
+	_, err := CreatePlan(org.Id, projectId, member.Id, "auth-refactor")
+	if err != ErrPlanNameTaken {
+		t.Fatalf("expected ErrPlanNameTaken creating a plan with a taken name, got %v", err)
+	}
+
+	err = RenamePlan(other.Id, "auth-refactor", false, nil)
+	if err != ErrPlanNameTaken {
+		t.Fatalf("expected ErrPlanNameTaken renaming a plan to a taken name, got %v", err)
+	}
+
+	tx, err := Conn.Beginx()
+	if err != nil {
+		t.Fatalf("error starting transaction: %v", err)
+	}
+
+	name, err := RenamePlanUnique(org.Id, other.Id, "auth-refactor", false, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("error renaming plan: %v", err)
+	}
+	if name != "auth-refactor-2" {
+		t.Errorf("expected the plan to be renamed to auth-refactor-2, got %s", name)
+	}
+
+	// the transaction is still usable after the rename
+	err = IncNumNonDraftPlans(member.Id, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("error using transaction after rename: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		t.Fatalf("error committing transaction: %v", err)
+	}
+
+	renamed, err := GetPlan(other.Id)
+	if err != nil {
+		t.Fatalf("error getting plan: %v", err)
+	}
+	if renamed.Name != "auth-refactor-2" {
+		t.Errorf("expected the stored name to be auth-refactor-2, got %s", renamed.Name)
+	}
+}
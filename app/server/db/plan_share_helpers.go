@@ -0,0 +1,138 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/plandex/plandex/shared"
+)
+
+func GetPlanShare(planId, userId string) (*PlanShare, error) {
+	var share PlanShare
+	err := Conn.Get(&share, "SELECT * FROM plan_shares WHERE plan_id = $1 AND user_id = $2", planId, userId)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting plan share: %v", err)
+	}
+
+	return &share, nil
+}
+
+func ListPlanShares(planId string) ([]*PlanShare, error) {
+	var shares []*PlanShare
+	err := Conn.Select(&shares, "SELECT plan_shares.*, users.email AS user_email, users.name AS user_name FROM plan_shares JOIN users ON users.id = plan_shares.user_id WHERE plan_shares.plan_id = $1 ORDER BY plan_shares.created_at", planId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing plan shares: %v", err)
+	}
+
+	return shares, nil
+}
+
+// StorePlanShare shares a plan with a user, or changes the access of an existing share
+func StorePlanShare(share *PlanShare) error {
+	err := Conn.QueryRow(`INSERT INTO plan_shares (plan_id, org_id, user_id, access, created_by) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (plan_id, user_id) DO UPDATE SET access = EXCLUDED.access
+		RETURNING id, created_at, updated_at`,
+		share.PlanId, share.OrgId, share.UserId, share.Access, share.CreatedBy,
+	).Scan(&share.Id, &share.CreatedAt, &share.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error storing plan share: %v", err)
+	}
+
+	return nil
+}
+
+func DeletePlanShare(planId, userId string) error {
+	_, err := Conn.Exec("DELETE FROM plan_shares WHERE plan_id = $1 AND user_id = $2", planId, userId)
+
+	if err != nil {
+		return fmt.Errorf("error deleting plan share: %v", err)
+	}
+
+	return nil
+}
+
+// ListPlansSharedWithUser lists unarchived plans shared directly with a user, from any org, with their tags, access, and org names loaded
+func ListPlansSharedWithUser(userId string) ([]*Plan, error) {
+	var plans []*Plan
+	err := Conn.Select(&plans, "SELECT plans.* FROM plans JOIN plan_shares ON plan_shares.plan_id = plans.id WHERE plan_shares.user_id = $1 AND plans.archived_at IS NULL ORDER BY plans.updated_at DESC", userId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing shared plans: %v", err)
+	}
+
+	err = LoadPlanTags(plans)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = LoadPlanSharedAccess(plans, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plans) == 0 {
+		return plans, nil
+	}
+
+	orgIds := make([]string, len(plans))
+	for i, plan := range plans {
+		orgIds[i] = plan.OrgId
+	}
+
+	var orgs []*Org
+	err = Conn.Select(&orgs, "SELECT * FROM orgs WHERE id = ANY($1)", pq.Array(orgIds))
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting orgs: %v", err)
+	}
+
+	orgNamesById := map[string]string{}
+	for _, org := range orgs {
+		orgNamesById[org.Id] = org.Name
+	}
+
+	for _, plan := range plans {
+		plan.OrgName = orgNamesById[plan.OrgId]
+	}
+
+	return plans, nil
+}
+
+// LoadPlanSharedAccess sets the SharedAccess field on each plan that's shared directly with the user
+func LoadPlanSharedAccess(plans []*Plan, userId string) error {
+	if len(plans) == 0 {
+		return nil
+	}
+
+	planIds := make([]string, len(plans))
+	byId := map[string]*Plan{}
+	for i, plan := range plans {
+		planIds[i] = plan.Id
+		byId[plan.Id] = plan
+	}
+
+	var rows []struct {
+		PlanId string                 `db:"plan_id"`
+		Access shared.PlanShareAccess `db:"access"`
+	}
+	err := Conn.Select(&rows, "SELECT plan_id, access FROM plan_shares WHERE user_id = $1 AND plan_id = ANY($2)", userId, pq.Array(planIds))
+
+	if err != nil {
+		return fmt.Errorf("error getting plan shares: %v", err)
+	}
+
+	for _, row := range rows {
+		byId[row.PlanId].SharedAccess = row.Access
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/plandex/plandex/shared"
+)
+
+// PlanListParams are the filters for ListPlans after they've been checked against what the user can access. An empty AuthorId only lists the user's own plans.
+type PlanListParams struct {
+	OrgId         string
+	UserId        string
+	ProjectIds    []string
+	AuthorId      string
+	Tags          []string
+	Statuses      []shared.PlanStatus
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	Archived      bool
+	SortBy        shared.PlanSortBy
+	Asc           bool
+}
+
+// ListPlans lists the plans in the given projects that match the filters, with their tags loaded
+func ListPlans(params PlanListParams) ([]*Plan, error) {
+	qargs := []interface{}{}
+	arg := func(v interface{}) string {
+		qargs = append(qargs, v)
+		return fmt.Sprintf("$%d", len(qargs))
+	}
+
+	conds := []string{
+		"org_id = " + arg(params.OrgId),
+		"project_id = ANY(" + arg(pq.Array(params.ProjectIds)) + ")",
+	}
+
+	// plans shared directly with the user are listed alongside their own
+	sharedWithUser := func() string {
+		return "id IN (SELECT plan_id FROM plan_shares WHERE user_id = " + arg(params.UserId) + ")"
+	}
+
+	switch params.AuthorId {
+	case "":
+		conds = append(conds, fmt.Sprintf("(owner_id = %s OR %s)", arg(params.UserId), sharedWithUser()))
+	case shared.PlanAuthorAny:
+		conds = append(conds, fmt.Sprintf("(owner_id = %s OR shared_with_org_at IS NOT NULL OR %s)", arg(params.UserId), sharedWithUser()))
+	default:
+		conds = append(conds, "owner_id = "+arg(params.AuthorId))
+		if params.AuthorId != params.UserId {
+			conds = append(conds, fmt.Sprintf("(shared_with_org_at IS NOT NULL OR %s)", sharedWithUser()))
+		}
+	}
+
+	if params.Archived {
+		conds = append(conds, "archived_at IS NOT NULL")
+	} else {
+		conds = append(conds, "archived_at IS NULL")
+	}
+
+	for _, tag := range params.Tags {
+		conds = append(conds, "EXISTS (SELECT 1 FROM plan_tags WHERE plan_tags.plan_id = plans.id AND plan_tags.tag = "+arg(tag)+")")
+	}
+
+	if len(params.Statuses) > 0 {
+		statuses := make([]string, len(params.Statuses))
+		for i, status := range params.Statuses {
+			statuses[i] = string(status)
+		}
+		conds = append(conds, "EXISTS (SELECT 1 FROM branches WHERE branches.plan_id = plans.id AND branches.status = ANY("+arg(pq.Array(statuses))+"))")
+	}
+
+	if params.UpdatedAfter != nil {
+		conds = append(conds, "updated_at >= "+arg(*params.UpdatedAfter))
+	}
+
+	if params.UpdatedBefore != nil {
+		conds = append(conds, "updated_at < "+arg(*params.UpdatedBefore))
+	}
+
+	var orderBy string
+	switch params.SortBy {
+	case shared.PlanSortByCreated:
+		orderBy = "created_at"
+	case shared.PlanSortByName:
+		orderBy = "LOWER(name)"
+	default:
+		orderBy = "updated_at"
+	}
+
+	if params.Asc {
+		orderBy += " ASC"
+	} else {
+		orderBy += " DESC"
+	}
+
+	qs := "SELECT * FROM plans WHERE " + strings.Join(conds, " AND ") + " ORDER BY " + orderBy
+
+	var plans []*Plan
+	err := Conn.Select(&plans, qs, qargs...)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing plans: %v", err)
+	}
+
+	err = LoadPlanTags(plans)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = LoadPlanSharedAccess(plans, params.UserId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+// LoadPlanTags sets the Tags field on each plan
+func LoadPlanTags(plans []*Plan) error {
+	if len(plans) == 0 {
+		return nil
+	}
+
+	planIds := make([]string, len(plans))
+	byId := map[string]*Plan{}
+	for i, plan := range plans {
+		planIds[i] = plan.Id
+		byId[plan.Id] = plan
+	}
+
+	var rows []struct {
+		PlanId string `db:"plan_id"`
+		Tag    string `db:"tag"`
+	}
+	err := Conn.Select(&rows, "SELECT plan_id, tag FROM plan_tags WHERE plan_id = ANY($1) ORDER BY tag", pq.Array(planIds))
+
+	if err != nil {
+		return fmt.Errorf("error getting plan tags: %v", err)
+	}
+
+	for _, row := range rows {
+		plan := byId[row.PlanId]
+		plan.Tags = append(plan.Tags, row.Tag)
+	}
+
+	return nil
+}
+
+func GetPlanTags(planId string) ([]string, error) {
+	tags := []string{}
+	err := Conn.Select(&tags, "SELECT tag FROM plan_tags WHERE plan_id = $1 ORDER BY tag", planId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting plan tags: %v", err)
+	}
+
+	return tags, nil
+}
+
+// UpdatePlanTags removes and then adds tags in one transaction, returning the plan's tags afterward. Tags must already be normalized. Adding a tag the plan already has, or removing one it doesn't, is a no-op.
+func UpdatePlanTags(orgId, planId string, add, remove []string) ([]string, error) {
+	tx, err := Conn.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	if len(remove) > 0 {
+		_, err = tx.Exec("DELETE FROM plan_tags WHERE plan_id = $1 AND tag = ANY($2)", planId, pq.Array(remove))
+		if err != nil {
+			return nil, fmt.Errorf("error removing plan tags: %v", err)
+		}
+	}
+
+	for _, tag := range add {
+		_, err = tx.Exec("INSERT INTO plan_tags (plan_id, org_id, tag) VALUES ($1, $2, $3) ON CONFLICT (plan_id, tag) DO NOTHING", planId, orgId, tag)
+		if err != nil {
+			return nil, fmt.Errorf("error adding plan tag: %v", err)
+		}
+	}
+
+	tags := []string{}
+	err = tx.Select(&tags, "SELECT tag FROM plan_tags WHERE plan_id = $1 ORDER BY tag", planId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting plan tags: %v", err)
+	}
+
+	if len(tags) > shared.MaxPlanTags {
+		err = fmt.Errorf("a plan can have at most %d tags", shared.MaxPlanTags)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return tags, nil
+}
+
+// ListOrgPlanTags counts the tags used across the plans a user can access in an org: their own plans plus plans shared with the org
+func ListOrgPlanTags(orgId, userId string) ([]*shared.PlanTagCount, error) {
+	var counts []*shared.PlanTagCount
+
+	query := `SELECT plan_tags.tag AS tag, COUNT(*) AS num_plans
+	FROM plan_tags
+	JOIN plans ON plans.id = plan_tags.plan_id
+	WHERE plan_tags.org_id = $1 AND (plans.owner_id = $2 OR plans.shared_with_org_at IS NOT NULL)
+	GROUP BY plan_tags.tag
+	ORDER BY num_plans DESC, plan_tags.tag`
+
+	var rows []struct {
+		Tag      string `db:"tag"`
+		NumPlans int    `db:"num_plans"`
+	}
+	err := Conn.Select(&rows, query, orgId, userId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing org plan tags: %v", err)
+	}
+
+	for _, row := range rows {
+		counts = append(counts, &shared.PlanTagCount{Tag: row.Tag, NumPlans: row.NumPlans})
+	}
+
+	return counts, nil
+}
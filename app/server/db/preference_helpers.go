@@ -0,0 +1,274 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Learned preferences have two scopes: a user's, distilled from their own rejections and edits, and an org's, distilled from everyone's. Throughout, an empty userId means the org's scope.
+
+// StoreChangeFeedback records rejections and edits. Feedback of the same kind for a result that's already recorded is skipped, so an applied result only counts as edited the first time it changes.
+func StoreChangeFeedback(feedback []*ChangeFeedback) error {
+	for _, item := range feedback {
+		body, err := encryptOrgString(item.OrgId, item.Body)
+		if err != nil {
+			return fmt.Errorf("error encrypting change feedback: %v", err)
+		}
+
+		_, err = Conn.Exec(`INSERT INTO change_feedback (org_id, user_id, plan_id, result_id, kind, path, body)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (result_id, kind) DO NOTHING`, item.OrgId, item.UserId, item.PlanId, item.ResultId, item.Kind, item.Path, body)
+
+		if err != nil {
+			return fmt.Errorf("error storing change feedback: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// CountPendingChangeFeedback returns how much feedback hasn't been distilled yet into the user's preferences, and into the org's
+func CountPendingChangeFeedback(orgId, userId string) (int, int, error) {
+	var numUser int
+	err := Conn.Get(&numUser, "SELECT COUNT(*) FROM change_feedback WHERE org_id = $1 AND user_id = $2 AND NOT distilled_for_user", orgId, userId)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error counting change feedback: %v", err)
+	}
+
+	var numOrg int
+	err = Conn.Get(&numOrg, "SELECT COUNT(*) FROM change_feedback WHERE org_id = $1 AND NOT distilled_for_org", orgId)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error counting change feedback: %v", err)
+	}
+
+	return numUser, numOrg, nil
+}
+
+// GetPendingChangeFeedback returns the newest feedback, up to limit, that hasn't been distilled into the scope's preferences yet
+func GetPendingChangeFeedback(orgId, userId string, limit int) ([]*ChangeFeedback, error) {
+	var feedback []*ChangeFeedback
+	var err error
+
+	if userId == "" {
+		err = Conn.Select(&feedback, "SELECT * FROM change_feedback WHERE org_id = $1 AND NOT distilled_for_org ORDER BY created_at DESC LIMIT $2", orgId, limit)
+	} else {
+		err = Conn.Select(&feedback, "SELECT * FROM change_feedback WHERE org_id = $1 AND user_id = $2 AND NOT distilled_for_user ORDER BY created_at DESC LIMIT $3", orgId, userId, limit)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting change feedback: %v", err)
+	}
+
+	for _, item := range feedback {
+		item.Body, err = decryptOrgString(orgId, item.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting change feedback: %v", err)
+		}
+	}
+
+	return feedback, nil
+}
+
+// GetLearnedPreferences returns the scope's preferences, or nil if none have been learned
+func GetLearnedPreferences(orgId, userId string) (*LearnedPreferences, error) {
+	var prefs LearnedPreferences
+	var err error
+
+	if userId == "" {
+		err = Conn.Get(&prefs, "SELECT * FROM learned_preferences WHERE org_id = $1 AND user_id IS NULL", orgId)
+	} else {
+		err = Conn.Get(&prefs, "SELECT * FROM learned_preferences WHERE org_id = $1 AND user_id = $2", orgId, userId)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting learned preferences: %v", err)
+	}
+
+	prefs.Summary, err = decryptOrgString(orgId, prefs.Summary)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting learned preferences: %v", err)
+	}
+
+	return &prefs, nil
+}
+
+// ListLearnedPreferences returns the org's preferences and every user's in the org, or only the user's if userId is set
+func ListLearnedPreferences(orgId, userId string) ([]*LearnedPreferences, error) {
+	var prefs []*LearnedPreferences
+	var err error
+
+	if userId == "" {
+		err = Conn.Select(&prefs, "SELECT * FROM learned_preferences WHERE org_id = $1 ORDER BY created_at", orgId)
+	} else {
+		err = Conn.Select(&prefs, "SELECT * FROM learned_preferences WHERE org_id = $1 AND user_id = $2", orgId, userId)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing learned preferences: %v", err)
+	}
+
+	for _, item := range prefs {
+		item.Summary, err = decryptOrgString(orgId, item.Summary)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting learned preferences: %v", err)
+		}
+	}
+
+	return prefs, nil
+}
+
+// ListChangeFeedback returns the feedback recorded in the org, or only the user's if userId is set
+func ListChangeFeedback(orgId, userId string) ([]*ChangeFeedback, error) {
+	var feedback []*ChangeFeedback
+	var err error
+
+	if userId == "" {
+		err = Conn.Select(&feedback, "SELECT * FROM change_feedback WHERE org_id = $1 ORDER BY created_at", orgId)
+	} else {
+		err = Conn.Select(&feedback, "SELECT * FROM change_feedback WHERE org_id = $1 AND user_id = $2 ORDER BY created_at", orgId, userId)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing change feedback: %v", err)
+	}
+
+	for _, item := range feedback {
+		item.Body, err = decryptOrgString(orgId, item.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting change feedback: %v", err)
+		}
+	}
+
+	return feedback, nil
+}
+
+// StoreLearnedPreferences replaces the scope's preferences with a newly distilled summary, and marks the feedback it was distilled from so it's not distilled again
+func StoreLearnedPreferences(orgId, userId, summary string, feedbackIds []string) error {
+	encrypted, err := encryptOrgString(orgId, summary)
+	if err != nil {
+		return fmt.Errorf("error encrypting learned preferences: %v", err)
+	}
+
+	tx, err := Conn.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	var userIdArg *string
+	if userId != "" {
+		userIdArg = &userId
+	}
+
+	var existing LearnedPreferences
+	if userId == "" {
+		err = tx.Get(&existing, "SELECT * FROM learned_preferences WHERE org_id = $1 AND user_id IS NULL", orgId)
+	} else {
+		err = tx.Get(&existing, "SELECT * FROM learned_preferences WHERE org_id = $1 AND user_id = $2", orgId, userId)
+	}
+
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec("INSERT INTO learned_preferences (org_id, user_id, summary, num_events) VALUES ($1, $2, $3, $4)", orgId, userIdArg, encrypted, len(feedbackIds))
+	} else if err == nil {
+		_, err = tx.Exec("UPDATE learned_preferences SET summary = $1, num_events = $2 WHERE id = $3", encrypted, existing.NumEvents+len(feedbackIds), existing.Id)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error storing learned preferences: %v", err)
+	}
+
+	column := "distilled_for_user"
+	if userId == "" {
+		column = "distilled_for_org"
+	}
+
+	_, err = tx.Exec("UPDATE change_feedback SET "+column+" = TRUE WHERE id = ANY($1)", pq.Array(feedbackIds))
+	if err != nil {
+		return fmt.Errorf("error marking change feedback distilled: %v", err)
+	}
+
+	err = deleteDistilledChangeFeedback(tx, orgId)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// ClearLearnedPreferences deletes the scope's preferences and marks its pending feedback as distilled, so the same preferences aren't learned again from it
+func ClearLearnedPreferences(orgId, userId string) error {
+	tx, err := Conn.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	if userId == "" {
+		_, err = tx.Exec("DELETE FROM learned_preferences WHERE org_id = $1 AND user_id IS NULL", orgId)
+		if err == nil {
+			_, err = tx.Exec("UPDATE change_feedback SET distilled_for_org = TRUE WHERE org_id = $1", orgId)
+		}
+	} else {
+		_, err = tx.Exec("DELETE FROM learned_preferences WHERE org_id = $1 AND user_id = $2", orgId, userId)
+		if err == nil {
+			_, err = tx.Exec("UPDATE change_feedback SET distilled_for_user = TRUE WHERE org_id = $1 AND user_id = $2", orgId, userId)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("error clearing learned preferences: %v", err)
+	}
+
+	err = deleteDistilledChangeFeedback(tx, orgId)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// feedback that's been distilled into both scopes isn't needed anymore
+func deleteDistilledChangeFeedback(tx *sqlx.Tx, orgId string) error {
+	_, err := tx.Exec("DELETE FROM change_feedback WHERE org_id = $1 AND distilled_for_user AND distilled_for_org", orgId)
+	if err != nil {
+		return fmt.Errorf("error deleting distilled change feedback: %v", err)
+	}
+	return nil
+}
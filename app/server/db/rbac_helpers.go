@@ -23,3 +23,15 @@ func GetOrgMemberRoleId() (string, error) {
 
 	return roleId, nil
 }
+
+// GetOrgGuestRoleId returns the role of guests who join an org with a trial access code. It's like the member role, but can't change org settings.
+func GetOrgGuestRoleId() (string, error) {
+	var roleId string
+	err := Conn.Get(&roleId, "SELECT id FROM org_roles WHERE name = 'guest'")
+
+	if err != nil {
+		return "", fmt.Errorf("error getting guest role id: %v", err)
+	}
+
+	return roleId, nil
+}
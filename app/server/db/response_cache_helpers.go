@@ -0,0 +1,146 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetOrgResponseCachePolicy(orgId string) (*shared.OrgResponseCachePolicy, error) {
+	var policy OrgResponseCachePolicy
+	err := Conn.Get(&policy, "SELECT * FROM org_response_cache_policies WHERE org_id = $1", orgId)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return shared.DefaultOrgResponseCachePolicy(), nil
+		}
+		return nil, fmt.Errorf("error getting response cache policy: %v", err)
+	}
+
+	return policy.ToApi(), nil
+}
+
+// StoreOrgResponseCachePolicy upserts the org's policy. Turning the cache off or changing its scope clears any cached responses, so nothing cached under the old settings is served under the new ones.
+func StoreOrgResponseCachePolicy(orgId string, policy *shared.OrgResponseCachePolicy) error {
+	current, err := GetOrgResponseCachePolicy(orgId)
+	if err != nil {
+		return err
+	}
+
+	var ops []string
+	for _, op := range policy.Operations {
+		ops = append(ops, string(op))
+	}
+
+	query := `INSERT INTO org_response_cache_policies (org_id, enabled, scope, ttl_hours, operations)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (org_id) DO UPDATE SET
+		enabled = excluded.enabled,
+		scope = excluded.scope,
+		ttl_hours = excluded.ttl_hours,
+		operations = excluded.operations
+	`
+
+	_, err = Conn.Exec(query, orgId, policy.Enabled, policy.Scope, policy.TtlHours, strings.Join(ops, "\n"))
+
+	if err != nil {
+		return fmt.Errorf("error storing response cache policy: %v", err)
+	}
+
+	if !policy.Enabled || policy.Scope != current.Scope {
+		_, err = ClearResponseCache(orgId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetCachedModelResponse returns the response cached under a key, or an empty string if there's no response or it has expired
+func GetCachedModelResponse(orgId, cacheKey string) (string, error) {
+	var cached CachedModelResponse
+	err := Conn.Get(&cached, "SELECT * FROM model_response_cache WHERE org_id = $1 AND cache_key = $2 AND expires_at > $3", orgId, cacheKey, time.Now())
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("error getting cached model response: %v", err)
+	}
+
+	_, err = Conn.Exec("UPDATE model_response_cache SET num_hits = num_hits + 1 WHERE id = $1", cached.Id)
+	if err != nil {
+		// a missed hit count shouldn't stop the cached response from being used
+		log.Printf("Error updating cached model response hits: %v\n", err)
+	}
+
+	return decryptOrgString(orgId, cached.Response)
+}
+
+// StoreCachedModelResponse caches a response under a key, replacing any response already there and resetting its expiration
+func StoreCachedModelResponse(orgId, cacheKey string, op shared.ResponseCacheOperation, response string, ttl time.Duration) error {
+	encrypted, err := encryptOrgString(orgId, response)
+	if err != nil {
+		return fmt.Errorf("error encrypting model response: %v", err)
+	}
+
+	query := `INSERT INTO model_response_cache (org_id, cache_key, operation, response, expires_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (org_id, cache_key) DO UPDATE SET
+		operation = excluded.operation,
+		response = excluded.response,
+		num_hits = 0,
+		expires_at = excluded.expires_at
+	`
+
+	_, err = Conn.Exec(query, orgId, cacheKey, op, encrypted, time.Now().Add(ttl))
+
+	if err != nil {
+		return fmt.Errorf("error storing cached model response: %v", err)
+	}
+
+	return nil
+}
+
+// ClearResponseCache deletes all of an org's cached responses and returns the number deleted
+func ClearResponseCache(orgId string) (int, error) {
+	res, err := Conn.Exec("DELETE FROM model_response_cache WHERE org_id = $1", orgId)
+
+	if err != nil {
+		return 0, fmt.Errorf("error clearing response cache: %v", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return int(n), nil
+}
+
+func GetResponseCacheStats(orgId string) (*shared.ResponseCacheStats, error) {
+	var stats shared.ResponseCacheStats
+	err := Conn.QueryRow("SELECT COUNT(*), COALESCE(SUM(num_hits), 0) FROM model_response_cache WHERE org_id = $1 AND expires_at > $2", orgId, time.Now()).Scan(&stats.NumEntries, &stats.NumHits)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting response cache stats: %v", err)
+	}
+
+	return &stats, nil
+}
+
+// DeleteExpiredCachedModelResponses removes expired responses for all orgs
+func DeleteExpiredCachedModelResponses() error {
+	_, err := Conn.Exec("DELETE FROM model_response_cache WHERE expires_at <= $1", time.Now())
+
+	if err != nil {
+		return fmt.Errorf("error deleting expired cached model responses: %v", err)
+	}
+
+	return nil
+}
@@ -38,7 +38,7 @@ func StorePlanResult(result *PlanFileResult) error {
 
 	log.Printf("Storing plan result: %s", result.Id)
 
-	err = os.WriteFile(filepath.Join(resultsDir, result.Id+".json"), bytes, 0644)
+	err = writeOrgFile(result.OrgId, filepath.Join(resultsDir, result.Id+".json"), bytes, 0644)
 
 	if err != nil {
 		return fmt.Errorf("error writing result file: %v", err)
@@ -133,6 +133,15 @@ func GetCurrentPlanState(params CurrentPlanStateParams) (*shared.CurrentPlanStat
 		}
 	}
 
+	var fileChangeSummaries map[string]string
+	if len(dbPlanFileResults) > 0 {
+		var err error
+		fileChangeSummaries, err = GetFileChangeSummaries(orgId, planId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var apiPlanFileResults []*shared.PlanFileResult
 	pendingResultPaths := map[string]bool{}
 
@@ -142,6 +151,11 @@ func GetCurrentPlanState(params CurrentPlanStateParams) (*shared.CurrentPlanStat
 		apiResult := dbPlanFileResult.ToApi()
 		apiPlanFileResults = append(apiPlanFileResults, apiResult)
 
+		// summaries are generated in the background, so they're added to the result's own notes, like skipped no-op changes, once they're ready
+		if summary := fileChangeSummaries[apiResult.Id]; summary != "" {
+			apiResult.Summary = strings.TrimSpace(summary + "\n\n" + apiResult.Summary)
+		}
+
 		if apiResult.IsPending() {
 			// log.Printf("Pending result: %s", dbPlanFileResult.Id)
 
@@ -266,7 +280,7 @@ func GetPlanFileResults(orgId, planId string) ([]*PlanFileResult, error) {
 
 		go func(file os.DirEntry) {
 
-			bytes, err := os.ReadFile(filepath.Join(resultsDir, file.Name()))
+			bytes, err := readOrgFile(orgId, filepath.Join(resultsDir, file.Name()))
 
 			if err != nil {
 				errCh <- fmt.Errorf("error reading result file: %v", err)
@@ -432,6 +446,17 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) (*shared.CurrentPla
 		currentPlanState = res
 	}
 
+	// removed files leave the plan's context instead of being updated in it
+	var removedContexts []*Context
+	if currentPlanState != nil {
+		for path := range currentPlanState.CurrentPlanFiles.Removed {
+			delete(pendingUpdatedFilesSet, path)
+			if context := contextsByPath[path]; context != nil {
+				removedContexts = append(removedContexts, context)
+			}
+		}
+	}
+
 	errCh = make(chan error)
 	now := time.Now()
 
@@ -446,7 +471,7 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) (*shared.CurrentPla
 				return
 			}
 
-			err = os.WriteFile(filepath.Join(resultsDir, result.Id+".json"), bytes, 0644)
+			err = writeOrgFile(orgId, filepath.Join(resultsDir, result.Id+".json"), bytes, 0644)
 
 			if err != nil {
 				errCh <- fmt.Errorf("error writing result file: %v", err)
@@ -554,6 +579,13 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) (*shared.CurrentPla
 		}
 	}
 
+	if len(removedContexts) > 0 {
+		err := ContextRemove(orgId, plan.Id, removedContexts)
+		if err != nil {
+			return nil, fmt.Errorf("error removing context for removed files: %v", err)
+		}
+	}
+
 	msg := "✅ Marked pending results as applied"
 
 	if loadContextRes != nil && !loadContextRes.MaxTokensExceeded {
@@ -633,7 +665,7 @@ func DeletePendingResultsForPaths(orgId, planId string, paths map[string]bool) e
 		resultId := strings.TrimSuffix(file.Name(), ".json")
 
 		go func(resultId string) {
-			bytes, err := os.ReadFile(filepath.Join(resultsDir, resultId+".json"))
+			bytes, err := readOrgFile(orgId, filepath.Join(resultsDir, resultId+".json"))
 
 			if err != nil {
 				errCh <- fmt.Errorf("error reading result file: %v", err)
@@ -726,7 +758,7 @@ func RejectPlanFile(orgId, planId, file string, now time.Time) error {
 				errCh <- fmt.Errorf("error marshalling result: %v", err)
 			}
 
-			err = os.WriteFile(filepath.Join(resultsDir, result.Id+".json"), bytes, 0644)
+			err = writeOrgFile(orgId, filepath.Join(resultsDir, result.Id+".json"), bytes, 0644)
 
 			if err != nil {
 				errCh <- fmt.Errorf("error writing result file: %v", err)
@@ -749,7 +781,7 @@ func RejectPlanFile(orgId, planId, file string, now time.Time) error {
 func RejectReplacement(orgId, planId, resultId, replacementId string) error {
 	resultsDir := getPlanResultsDir(orgId, planId)
 
-	bytes, err := os.ReadFile(filepath.Join(resultsDir, resultId+".json"))
+	bytes, err := readOrgFile(orgId, filepath.Join(resultsDir, resultId+".json"))
 
 	if err != nil {
 		return fmt.Errorf("error reading result file: %v", err)
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"plandex-server/email"
+	"strings"
+	"time"
+)
+
+// ApplyRetentionPolicies archives inactive plans, notifies owners of upcoming deletions, and deletes plans whose notice period has passed, for every org with a retention policy. An error in one org doesn't stop the others.
+func ApplyRetentionPolicies() error {
+	policies, err := ListEnabledRetentionPolicies()
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, policy := range policies {
+		err := applyRetentionPolicy(policy)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("org %s: %v", policy.OrgId, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func applyRetentionPolicy(policy *OrgRetentionPolicy) error {
+	if policy.ArchiveAfterDays > 0 {
+		archived, err := ArchiveInactivePlans(policy.OrgId, policy.ArchiveAfterDays)
+		if err != nil {
+			return err
+		}
+		if len(archived) > 0 {
+			log.Printf("Retention: archived %d inactive plan(s) in org %s\n", len(archived), policy.OrgId)
+		}
+	}
+
+	if policy.DeleteArchivedAfterDays <= 0 {
+		return nil
+	}
+
+	// delete first so plans notified in this run always get the full notice period
+	plans, err := ListPlansDueForDeletion(policy.OrgId, policy)
+	if err != nil {
+		return err
+	}
+	if len(plans) > 0 {
+		numDeleted, err := DeleteArchivedPlans(policy.OrgId, plans)
+		if err != nil {
+			return err
+		}
+		log.Printf("Retention: deleted %d archived plan(s) in org %s\n", numDeleted, policy.OrgId)
+	}
+
+	plans, err = ListPlansDueForDeletionNotice(policy.OrgId, policy)
+	if err != nil {
+		return err
+	}
+	if len(plans) == 0 {
+		return nil
+	}
+
+	return sendDeletionNotices(policy, plans)
+}
+
+// sendDeletionNotices emails each owner a single notice listing their plans that are about to be deleted. If the server can't send email, the notice period still starts without an email, so plans don't wait for a notice that can never go out.
+func sendDeletionNotices(policy *OrgRetentionPolicy, plans []*Plan) error {
+	org, err := GetOrg(policy.OrgId)
+	if err != nil {
+		return err
+	}
+
+	// emails are only logged in development
+	canEmail := os.Getenv("GOENV") != "production" || email.CanSend()
+
+	var ownerIds []string
+	plansByOwnerId := map[string][]*Plan{}
+	for _, plan := range plans {
+		if _, ok := plansByOwnerId[plan.OwnerId]; !ok {
+			ownerIds = append(ownerIds, plan.OwnerId)
+		}
+		plansByOwnerId[plan.OwnerId] = append(plansByOwnerId[plan.OwnerId], plan)
+	}
+
+	deleteAfter := time.Now().AddDate(0, 0, policy.NotifyDaysBeforeDelete)
+
+	for _, ownerId := range ownerIds {
+		ownerPlans := plansByOwnerId[ownerId]
+
+		var planIds []string
+		var planNames []string
+		for _, plan := range ownerPlans {
+			planIds = append(planIds, plan.Id)
+			planNames = append(planNames, plan.Name)
+		}
+
+		numClaimed, err := ClaimPlansDeletionNotice(planIds)
+		if err != nil {
+			return err
+		}
+		if numClaimed == 0 {
+			// another server already sent this notice
+			continue
+		}
+
+		if !canEmail {
+			log.Printf("Retention: email isn't configured, so user %s wasn't notified that %d archived plan(s) in org %s will be deleted on or after %s\n", ownerId, len(ownerPlans), policy.OrgId, deleteAfter.Format("2006-01-02"))
+			continue
+		}
+
+		owner, err := GetUser(ownerId)
+		if err == nil {
+			err = email.SendPlanDeletionNoticeEmail(owner.Email, strings.Split(owner.Name, " ")[0], org.Name, planNames, deleteAfter)
+		}
+
+		if err != nil {
+			// the plans can't be deleted until a notice goes out, so it's retried on the next run
+			releaseErr := ReleasePlansDeletionNotice(planIds)
+			if releaseErr != nil {
+				log.Printf("Error releasing plans deletion notice: %v\n", releaseErr)
+			}
+			return fmt.Errorf("error sending deletion notice to user %s: %v", ownerId, err)
+		}
+
+		log.Printf("Retention: notified user %s that %d archived plan(s) in org %s will be deleted\n", ownerId, len(ownerPlans), policy.OrgId)
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/plandex/plandex/shared"
+)
+
+func GetOrgRetentionPolicy(orgId string) (*shared.OrgRetentionPolicy, error) {
+	var policy OrgRetentionPolicy
+	err := Conn.Get(&policy, "SELECT * FROM org_retention_policies WHERE org_id = $1", orgId)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return shared.DefaultOrgRetentionPolicy(), nil
+		}
+		return nil, fmt.Errorf("error getting retention policy: %v", err)
+	}
+
+	return policy.ToApi(), nil
+}
+
+func StoreOrgRetentionPolicy(orgId string, policy *shared.OrgRetentionPolicy) error {
+	query := `INSERT INTO org_retention_policies (org_id, archive_after_days, delete_archived_after_days, notify_days_before_delete)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (org_id) DO UPDATE SET
+		archive_after_days = excluded.archive_after_days,
+		delete_archived_after_days = excluded.delete_archived_after_days,
+		notify_days_before_delete = excluded.notify_days_before_delete
+	`
+
+	_, err := Conn.Exec(query, orgId, policy.ArchiveAfterDays, policy.DeleteArchivedAfterDays, policy.NotifyDaysBeforeDelete)
+
+	if err != nil {
+		return fmt.Errorf("error storing retention policy: %v", err)
+	}
+
+	return nil
+}
+
+// ListEnabledRetentionPolicies returns the policies of every org that archives or deletes plans automatically
+func ListEnabledRetentionPolicies() ([]*OrgRetentionPolicy, error) {
+	var policies []*OrgRetentionPolicy
+	err := Conn.Select(&policies, "SELECT * FROM org_retention_policies WHERE archive_after_days > 0 OR delete_archived_after_days > 0")
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing retention policies: %v", err)
+	}
+
+	return policies, nil
+}
+
+// ArchiveInactivePlans archives an org's plans that haven't been updated on any branch for inactiveDays. Plans with an active stream are skipped.
+func ArchiveInactivePlans(orgId string, inactiveDays int) ([]*Plan, error) {
+	cutoff := time.Now().AddDate(0, 0, -inactiveDays)
+
+	var plans []*Plan
+	err := Conn.Select(&plans, `SELECT * FROM plans
+	WHERE org_id = $1 AND archived_at IS NULL AND updated_at < $2
+	AND NOT EXISTS (SELECT 1 FROM branches WHERE branches.plan_id = plans.id AND branches.updated_at >= $2)
+	AND NOT EXISTS (SELECT 1 FROM model_streams WHERE model_streams.plan_id = plans.id AND model_streams.finished_at IS NULL)`, orgId, cutoff)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing inactive plans: %v", err)
+	}
+
+	if len(plans) == 0 {
+		return nil, nil
+	}
+
+	var ids []string
+	for _, plan := range plans {
+		ids = append(ids, plan.Id)
+	}
+
+	_, err = Conn.Exec("UPDATE plans SET archived_at = NOW(), deletion_notified_at = NULL WHERE id = ANY($1) AND archived_at IS NULL", pq.Array(ids))
+
+	if err != nil {
+		return nil, fmt.Errorf("error archiving inactive plans: %v", err)
+	}
+
+	return plans, nil
+}
+
+// ListPlansDueForDeletionNotice returns an org's archived plans that will be deleted within the policy's notice period and whose owners haven't been notified yet
+func ListPlansDueForDeletionNotice(orgId string, policy *OrgRetentionPolicy) ([]*Plan, error) {
+	cutoff := time.Now().AddDate(0, 0, -(policy.DeleteArchivedAfterDays - policy.NotifyDaysBeforeDelete))
+
+	var plans []*Plan
+	err := Conn.Select(&plans, "SELECT * FROM plans WHERE org_id = $1 AND archived_at IS NOT NULL AND archived_at < $2 AND deletion_notified_at IS NULL ORDER BY owner_id, archived_at", orgId, cutoff)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing plans due for deletion notice: %v", err)
+	}
+
+	return plans, nil
+}
+
+// ClaimPlansDeletionNotice marks plans as notified and returns the number that weren't already, so that only one server sends each notice
+func ClaimPlansDeletionNotice(planIds []string) (int64, error) {
+	res, err := Conn.Exec("UPDATE plans SET deletion_notified_at = NOW() WHERE id = ANY($1) AND deletion_notified_at IS NULL", pq.Array(planIds))
+
+	if err != nil {
+		return 0, fmt.Errorf("error claiming plans deletion notice: %v", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// ReleasePlansDeletionNotice clears the notice so it's sent again on the next run (when sending it failed)
+func ReleasePlansDeletionNotice(planIds []string) error {
+	_, err := Conn.Exec("UPDATE plans SET deletion_notified_at = NULL WHERE id = ANY($1)", pq.Array(planIds))
+
+	if err != nil {
+		return fmt.Errorf("error releasing plans deletion notice: %v", err)
+	}
+
+	return nil
+}
+
+// ListPlansDueForDeletion returns an org's plans that have been archived for longer than the policy allows. Plans are only included once their owners have been notified for the full notice period, so shortening a policy never deletes plans without warning.
+func ListPlansDueForDeletion(orgId string, policy *OrgRetentionPolicy) ([]*Plan, error) {
+	archivedCutoff := time.Now().AddDate(0, 0, -policy.DeleteArchivedAfterDays)
+	notifiedCutoff := time.Now().AddDate(0, 0, -policy.NotifyDaysBeforeDelete)
+
+	var plans []*Plan
+	err := Conn.Select(&plans, "SELECT * FROM plans WHERE org_id = $1 AND archived_at IS NOT NULL AND archived_at < $2 AND deletion_notified_at IS NOT NULL AND deletion_notified_at < $3", orgId, archivedCutoff, notifiedCutoff)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing plans due for deletion: %v", err)
+	}
+
+	return plans, nil
+}
+
+// DeleteArchivedPlans permanently deletes archived plans along with their files, then purges any context blobs they were the last to reference. Plans that were unarchived in the meantime are skipped.
+func DeleteArchivedPlans(orgId string, plans []*Plan) (int, error) {
+	numDeleted := 0
+	deletedShas := map[string]bool{}
+
+	for _, plan := range plans {
+		res, err := Conn.Exec("DELETE FROM plans WHERE id = $1 AND org_id = $2 AND archived_at IS NOT NULL", plan.Id, orgId)
+		if err != nil {
+			return numDeleted, fmt.Errorf("error deleting plan: %v", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return numDeleted, fmt.Errorf("error getting rows affected: %v", err)
+		}
+
+		if rowsAffected == 0 {
+			continue
+		}
+
+		shas, err := getPlanContextShas(orgId, plan.Id)
+		if err != nil {
+			log.Printf("Error getting context shas for plan %s: %v\n", plan.Id, err)
+		}
+		for sha := range shas {
+			deletedShas[sha] = true
+		}
+
+		err = DeletePlanDir(orgId, plan.Id)
+		if err != nil {
+			return numDeleted, err
+		}
+
+		numDeleted++
+	}
+
+	if len(deletedShas) > 0 {
+		_, err := purgeContextBlobs(orgId, deletedShas, hardDeleteBlobGracePeriod)
+		if err != nil {
+			log.Printf("Error purging context blobs for org %s: %v\n", orgId, err)
+		}
+	}
+
+	return numDeleted, nil
+}
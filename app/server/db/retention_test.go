@@ -0,0 +1,59 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetentionWithoutMailer(t *testing.T) {
+	setupTestDb(t)
+
+	t.Setenv("GOENV", "production")
+	t.Setenv("IS_CLOUD", "")
+	t.Setenv("SMTP_HOST", "")
+
+	owner := createTestUser(t, "owner@example.com")
+	org, projectId := createTestOrg(t, "org", owner)
+	plan := createTestPlan(t, org.Id, projectId, owner.Id, "old plan")
+
+	_, err := Conn.Exec("UPDATE plans SET archived_at = $1 WHERE id = $2", time.Now().AddDate(0, 0, -40), plan.Id)
+	if err != nil {
+		t.Fatalf("error archiving plan: %v", err)
+	}
+
+	policy := &OrgRetentionPolicy{OrgId: org.Id, DeleteArchivedAfterDays: 30, NotifyDaysBeforeDelete: 7}
+
+	err = applyRetentionPolicy(policy)
+	if err != nil {
+		t.Fatalf("expected retention to succeed without a mailer, got: %v", err)
+	}
+
+	var notifiedAt *time.Time
+	err = Conn.Get(&notifiedAt, "SELECT deletion_notified_at FROM plans WHERE id = $1", plan.Id)
+	if err != nil {
+		t.Fatalf("error getting plan: %v", err)
+	}
+	if notifiedAt == nil {
+		t.Fatalf("expected the notice period to start without a mailer")
+	}
+
+	// once the notice period has passed, the plan is deleted
+	_, err = Conn.Exec("UPDATE plans SET deletion_notified_at = $1 WHERE id = $2", time.Now().AddDate(0, 0, -8), plan.Id)
+	if err != nil {
+		t.Fatalf("error updating plan: %v", err)
+	}
+
+	err = applyRetentionPolicy(policy)
+	if err != nil {
+		t.Fatalf("error applying retention policy: %v", err)
+	}
+
+	var numPlans int
+	err = Conn.Get(&numPlans, "SELECT COUNT(*) FROM plans WHERE id = $1", plan.Id)
+	if err != nil {
+		t.Fatalf("error counting plans: %v", err)
+	}
+	if numPlans != 0 {
+		t.Errorf("expected the plan to be deleted after the notice period")
+	}
+}
@@ -0,0 +1,247 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// SearchDoc is a piece of plan content that can be matched by search: a prompt, a reply, or the path of a file the plan changed
+type SearchDoc struct {
+	Kind       shared.SearchResultKind
+	Branch     string
+	MessageNum int
+	Path       string
+	Text       string
+	CreatedAt  time.Time
+}
+
+// ListSearchablePlans lists the plans a user can access in an org: their own plans and plans shared with the org, including archived plans. If projectId is set, only that project's plans are listed.
+func ListSearchablePlans(orgId, userId, projectId string) ([]*Plan, error) {
+	qs := "SELECT * FROM plans WHERE org_id = $1 AND (owner_id = $2 OR shared_with_org_at IS NOT NULL)"
+	qargs := []interface{}{orgId, userId}
+
+	if projectId != "" {
+		qs += " AND project_id = $3"
+		qargs = append(qargs, projectId)
+	}
+
+	qs += " ORDER BY updated_at DESC"
+
+	var plans []*Plan
+	err := Conn.Select(&plans, qs, qargs...)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing searchable plans: %v", err)
+	}
+
+	return plans, nil
+}
+
+// PlanSearchDocs is what was read of a plan for search. If the plan didn't fit in the byte budget, Truncated is set and Docs only covers the part that did.
+type PlanSearchDocs struct {
+	Docs      []*SearchDoc
+	NumBytes  int64
+	Truncated bool
+}
+
+// GetPlanSearchDocs reads the conversation and file results of every branch of a plan straight from its git history, so no branch needs to be checked out and no repo lock is needed. Messages and files shared by several branches are only returned once, for the first branch they're found on, with main checked first. At most maxBytes of plan content are read.
+func GetPlanSearchDocs(orgId, planId string, maxBytes int64) (*PlanSearchDocs, error) {
+	res := &PlanSearchDocs{}
+
+	dir := getPlanDir(orgId, planId)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return res, nil
+	}
+
+	branches, err := GitListBranches(orgId, planId)
+	if err != nil {
+		return nil, err
+	}
+
+	// check main first so shared history is attributed to it
+	for i, branch := range branches {
+		if branch == "main" && i > 0 {
+			branches[0], branches[i] = branches[i], branches[0]
+			break
+		}
+	}
+
+	seen := map[string]bool{}
+
+	for _, branch := range branches {
+		if res.Truncated {
+			break
+		}
+
+		files, numBytes, truncated, err := readGitFiles(dir, branch, maxBytes-res.NumBytes, "conversation", "results")
+		if err != nil {
+			return nil, err
+		}
+		res.NumBytes += numBytes
+		res.Truncated = truncated
+
+		messageNumsById := map[string]int{}
+		var results []*PlanFileResult
+
+		for path, data := range files {
+			data, err = decryptOrgData(orgId, data)
+			if err != nil {
+				return nil, fmt.Errorf("error decrypting %s: %v", path, err)
+			}
+
+			if strings.HasPrefix(path, "conversation/") {
+				var msg ConvoMessage
+				err = json.Unmarshal(data, &msg)
+				if err != nil {
+					return nil, fmt.Errorf("error unmarshalling convo message %s: %v", path, err)
+				}
+
+				messageNumsById[msg.Id] = msg.Num
+
+				if seen[msg.Id] {
+					continue
+				}
+				seen[msg.Id] = true
+
+				kind := shared.SearchResultKindResponse
+				if msg.Role == openai.ChatMessageRoleUser {
+					kind = shared.SearchResultKindPrompt
+				}
+
+				res.Docs = append(res.Docs, &SearchDoc{
+					Kind:       kind,
+					Branch:     branch,
+					MessageNum: msg.Num,
+					Text:       msg.Message,
+					CreatedAt:  msg.CreatedAt,
+				})
+			} else {
+				var result PlanFileResult
+				err = json.Unmarshal(data, &result)
+				if err != nil {
+					return nil, fmt.Errorf("error unmarshalling result %s: %v", path, err)
+				}
+				results = append(results, &result)
+			}
+		}
+
+		for _, result := range results {
+			key := "path|" + result.Path
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			res.Docs = append(res.Docs, &SearchDoc{
+				Kind:       shared.SearchResultKindFilePath,
+				Branch:     branch,
+				MessageNum: messageNumsById[result.ConvoMessageId],
+				Path:       result.Path,
+				Text:       result.Path,
+				CreatedAt:  result.CreatedAt,
+			})
+		}
+	}
+
+	return res, nil
+}
+
+// readGitFiles returns the contents of the json files in the given dirs as of a branch's latest commit, keyed by path, along with their total size. Files are read in path order until the next one would go over maxBytes, and the rest are skipped, which the returned bool reports.
+func readGitFiles(repoDir, branch string, maxBytes int64, dirs ...string) (map[string][]byte, int64, bool, error) {
+	var out bytes.Buffer
+	args := append([]string{"-C", repoDir, "ls-tree", "-r", "--long", branch, "--"}, dirs...)
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &out
+	err := cmd.Run()
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("error listing git files for dir: %s, branch: %s, err: %v", repoDir, branch, err)
+	}
+
+	var paths []string
+	var numBytes int64
+	truncated := false
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		// each line is "<mode> <type> <object> <size>\t<path>"
+		info, path, found := strings.Cut(line, "\t")
+		if !found || !strings.HasSuffix(path, ".json") {
+			continue
+		}
+
+		fields := strings.Fields(info)
+		if len(fields) != 4 {
+			continue
+		}
+
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("error parsing git file size for %s: %v", path, err)
+		}
+
+		if numBytes+size > maxBytes {
+			truncated = true
+			break
+		}
+		numBytes += size
+		paths = append(paths, path)
+	}
+
+	files := map[string][]byte{}
+	if len(paths) == 0 {
+		return files, 0, truncated, nil
+	}
+
+	var in bytes.Buffer
+	for _, path := range paths {
+		fmt.Fprintf(&in, "%s:%s\n", branch, path)
+	}
+
+	out.Reset()
+	cmd = exec.Command("git", "-C", repoDir, "cat-file", "--batch")
+	cmd.Stdin = &in
+	cmd.Stdout = &out
+	err = cmd.Run()
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("error reading git files for dir: %s, branch: %s, err: %v", repoDir, branch, err)
+	}
+
+	reader := bufio.NewReader(&out)
+	for _, path := range paths {
+		// each object is a "<sha> <type> <size>" header line, the content, and a newline
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("error reading git object header for %s: %v", path, err)
+		}
+
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			// "<object> missing"
+			continue
+		}
+
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("error parsing git object size for %s: %v", path, err)
+		}
+
+		data := make([]byte, size+1)
+		_, err = io.ReadFull(reader, data)
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("error reading git object for %s: %v", path, err)
+		}
+
+		files[path] = data[:size]
+	}
+
+	return files, numBytes, truncated, nil
+}
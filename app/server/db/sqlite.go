@@ -0,0 +1,339 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Queries in the db package are written for Postgres. When running on SQLite, they go through a thin driver wrapper that rewrites the handful of Postgres-specific constructs the db package uses into SQLite equivalents, so call sites don't need to know which database they're talking to:
+//   - $N placeholders become positional ? placeholders
+//   - = ANY($N) with a pq.Array argument is expanded to IN (?, ?, ...)
+//   - NOW() and NOW() - INTERVAL '...' become UTC timestamps
+//   - FOR UPDATE (and SKIP LOCKED) is dropped (SQLite write transactions already lock the whole database)
+//   - ON CONFLICT ON CONSTRAINT name becomes a plain ON CONFLICT
+//   - ILIKE becomes LIKE, which is already case-insensitive for ASCII on SQLite
+//
+// String literals, quoted identifiers, and comments are left as they are, so a '$1' or 'NOW()' in a literal isn't rewritten.
+
+const sqliteDriverName = "plandex_sqlite3"
+
+const sqliteNowExpr = "strftime('%Y-%m-%d %H:%M:%f', 'now')"
+
+var (
+	sqliteParamRegex        = regexp.MustCompile(`(?i)=\s*ANY\s*\(\s*\$(\d+)\s*\)|\$(\d+)`)
+	sqliteIntervalRegex     = regexp.MustCompile(`(?i)NOW\(\)\s*-\s*INTERVAL\s*\x00(\d+)\x00`)
+	sqliteIntervalLitRegex  = regexp.MustCompile(`(?i)^'(\d+)\s*([a-z]+?)s?'$`)
+	sqliteNowRegex          = regexp.MustCompile(`(?i)\bNOW\(\)`)
+	sqliteForUpdateRegex    = regexp.MustCompile(`(?i)\s+FOR\s+UPDATE(\s+SKIP\s+LOCKED)?\b`)
+	sqliteOnConstraintRegex = regexp.MustCompile(`(?i)ON\s+CONFLICT\s+ON\s+CONSTRAINT\s+\w+`)
+	sqliteIlikeRegex        = regexp.MustCompile(`(?i)\bILIKE\b`)
+	sqliteMaskRegex         = regexp.MustCompile(`\x00(\d+)\x00`)
+)
+
+func init() {
+	sql.Register(sqliteDriverName, &sqliteDriver{
+		SQLiteDriver: sqlite3.SQLiteDriver{},
+	})
+	// named queries are bound with $N placeholders like on Postgres, then rewritten by the driver
+	sqlx.BindDriver(sqliteDriverName, sqlx.DOLLAR)
+}
+
+type sqliteDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+func (d *sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+type sqliteConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	query, args, err := rewriteSqliteQuery(query, args, true)
+	if err != nil {
+		return nil, err
+	}
+	return c.SQLiteConn.ExecContext(ctx, query, args)
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	query, args, err := rewriteSqliteQuery(query, args, true)
+	if err != nil {
+		return nil, err
+	}
+	return c.SQLiteConn.QueryContext(ctx, query, args)
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	query, _, err := rewriteSqliteQuery(query, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	return c.SQLiteConn.PrepareContext(ctx, query)
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// rewriteSqliteQuery converts a Postgres query into its SQLite equivalent. When withArgs is false (for prepared statements), $N placeholders become ?N and arrays can't be expanded.
+func rewriteSqliteQuery(query string, args []driver.NamedValue, withArgs bool) (string, []driver.NamedValue, error) {
+	query, literals := maskSqlLiterals(query)
+
+	query = sqliteIntervalRegex.ReplaceAllStringFunc(query, func(match string) string {
+		literal := literals[maskIndex(match)]
+		parts := sqliteIntervalLitRegex.FindStringSubmatch(literal)
+		if parts == nil {
+			return match
+		}
+		return fmt.Sprintf("strftime('%%Y-%%m-%%d %%H:%%M:%%f', 'now', '-%s %s')", parts[1], parts[2])
+	})
+	query = sqliteNowRegex.ReplaceAllString(query, sqliteNowExpr)
+	query = sqliteForUpdateRegex.ReplaceAllString(query, "")
+	query = sqliteOnConstraintRegex.ReplaceAllString(query, "ON CONFLICT")
+	query = sqliteIlikeRegex.ReplaceAllString(query, "LIKE")
+
+	for i, arg := range args {
+		// store all timestamps in UTC so they compare correctly as strings
+		if t, ok := arg.Value.(time.Time); ok {
+			args[i].Value = t.UTC()
+		}
+	}
+
+	// queries without $N placeholders (like those issued by the migration driver) are passed through as-is
+	if !strings.Contains(query, "$") {
+		return unmaskSqlLiterals(query, literals), args, nil
+	}
+
+	var rewritten strings.Builder
+	var newArgs []driver.NamedValue
+	var err error
+
+	addArg := func(value driver.Value) {
+		newArgs = append(newArgs, driver.NamedValue{Ordinal: len(newArgs) + 1, Value: value})
+	}
+
+	getArg := func(s string) (driver.Value, bool) {
+		n, convErr := strconv.Atoi(s)
+		if convErr != nil || n < 1 || n > len(args) {
+			return nil, false
+		}
+		return args[n-1].Value, true
+	}
+
+	last := 0
+	for _, match := range sqliteParamRegex.FindAllStringSubmatchIndex(query, -1) {
+		rewritten.WriteString(query[last:match[0]])
+		last = match[1]
+
+		if !withArgs {
+			if match[2] >= 0 {
+				err = fmt.Errorf("ANY() queries can't be prepared on sqlite: %s", query)
+				break
+			}
+			rewritten.WriteString("?" + query[match[4]:match[5]])
+			continue
+		}
+
+		if match[2] >= 0 {
+			// = ANY($N)
+			value, ok := getArg(query[match[2]:match[3]])
+			if !ok {
+				err = fmt.Errorf("missing argument for placeholder in query: %s", query)
+				break
+			}
+
+			elements, parseErr := parsePgArray(value)
+			if parseErr != nil {
+				err = parseErr
+				break
+			}
+
+			placeholders := make([]string, len(elements))
+			for i, el := range elements {
+				placeholders[i] = "?"
+				addArg(el)
+			}
+			rewritten.WriteString("IN (" + strings.Join(placeholders, ", ") + ")")
+			continue
+		}
+
+		// $N
+		value, ok := getArg(query[match[4]:match[5]])
+		if !ok {
+			err = fmt.Errorf("missing argument for placeholder in query: %s", query)
+			break
+		}
+		rewritten.WriteString("?")
+		addArg(value)
+	}
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	rewritten.WriteString(query[last:])
+
+	return unmaskSqlLiterals(rewritten.String(), literals), newArgs, nil
+}
+
+// maskSqlLiterals replaces each string literal, quoted identifier, and comment in a query with a \x00N\x00 marker, where N is its index in the returned literals, so the query can be rewritten without touching them
+func maskSqlLiterals(query string) (string, []string) {
+	var masked strings.Builder
+	var literals []string
+
+	mask := func(literal string) {
+		masked.WriteString("\x00" + strconv.Itoa(len(literals)) + "\x00")
+		literals = append(literals, literal)
+	}
+
+	for i := 0; i < len(query); {
+		var end int
+
+		switch {
+		case query[i] == '\'' || query[i] == '"':
+			// a doubled quote is an escaped quote, not the end of the literal
+			quote := query[i]
+			end = len(query)
+			for j := i + 1; j < len(query); j++ {
+				if query[j] != quote {
+					continue
+				}
+				if j+1 < len(query) && query[j+1] == quote {
+					j++
+					continue
+				}
+				end = j + 1
+				break
+			}
+
+		case strings.HasPrefix(query[i:], "--"):
+			end = len(query)
+			if n := strings.IndexByte(query[i:], '\n'); n >= 0 {
+				end = i + n
+			}
+
+		case strings.HasPrefix(query[i:], "/*"):
+			end = len(query)
+			if n := strings.Index(query[i+2:], "*/"); n >= 0 {
+				end = i + 2 + n + 2
+			}
+
+		default:
+			masked.WriteByte(query[i])
+			i++
+			continue
+		}
+
+		mask(query[i:end])
+		i = end
+	}
+
+	return masked.String(), literals
+}
+
+func unmaskSqlLiterals(query string, literals []string) string {
+	if len(literals) == 0 {
+		return query
+	}
+	return sqliteMaskRegex.ReplaceAllStringFunc(query, func(match string) string {
+		return literals[maskIndex(match)]
+	})
+}
+
+// maskIndex is the literal index in the first \x00N\x00 marker in s
+func maskIndex(s string) int {
+	n, _ := strconv.Atoi(sqliteMaskRegex.FindStringSubmatch(s)[1])
+	return n
+}
+
+// parsePgArray parses an array argument as encoded by pq.Array (e.g. {"a","b"}) into its elements
+func parsePgArray(value driver.Value) ([]driver.Value, error) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported array argument type: %T", value)
+	}
+
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("invalid array argument: %s", s)
+	}
+	s = s[1 : len(s)-1]
+
+	var elements []driver.Value
+	if s == "" {
+		return elements, nil
+	}
+
+	var current strings.Builder
+	quoted := false
+	inQuotes := false
+	escaped := false
+
+	flush := func() {
+		el := current.String()
+		if !quoted && el == "NULL" {
+			elements = append(elements, nil)
+		} else {
+			elements = append(elements, el)
+		}
+		current.Reset()
+		quoted = false
+	}
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+			quoted = true
+		case r == ',' && !inQuotes:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return elements, nil
+}
+
+func getSqliteDsn(dbPath string) string {
+	return "file:" + dbPath + "?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=10000&_txlock=immediate"
+}
+
+func isSqliteErrCode(err error, codes ...sqlite3.ErrNo) bool {
+	if sqliteErr, ok := err.(sqlite3.Error); ok {
+		for _, code := range codes {
+			if sqliteErr.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestRewriteSqliteQuery(t *testing.T) {
+	args := func(values ...driver.Value) []driver.NamedValue {
+		res := make([]driver.NamedValue, len(values))
+		for i, value := range values {
+			res[i] = driver.NamedValue{Ordinal: i + 1, Value: value}
+		}
+		return res
+	}
+
+	arrayValue, err := pq.Array([]string{"a", "b"}).Value()
+	if err != nil {
+		t.Fatalf("error encoding array: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		args     []driver.NamedValue
+		want     string
+		wantArgs []driver.NamedValue
+	}{
+		{
+			name:     "placeholders",
+			query:    "SELECT * FROM plans WHERE org_id = $1 AND id = $2",
+			args:     args("org", "plan"),
+			want:     "SELECT * FROM plans WHERE org_id = ? AND id = ?",
+			wantArgs: args("org", "plan"),
+		},
+		{
+			name:     "placeholders out of order and reused",
+			query:    "UPDATE plans SET name = $2 WHERE id = $1 OR name = $2",
+			args:     args("plan", "name"),
+			want:     "UPDATE plans SET name = ? WHERE id = ? OR name = ?",
+			wantArgs: args("name", "plan", "name"),
+		},
+		{
+			name:     "any",
+			query:    "SELECT * FROM plans WHERE id = ANY($1) AND org_id = $2",
+			args:     args(arrayValue, "org"),
+			want:     "SELECT * FROM plans WHERE id IN (?, ?) AND org_id = ?",
+			wantArgs: args("a", "b", "org"),
+		},
+		{
+			name:     "literals containing $",
+			query:    "SELECT * FROM plans WHERE name = '$1 costs $2' AND id = $1",
+			args:     args("plan"),
+			want:     "SELECT * FROM plans WHERE name = '$1 costs $2' AND id = ?",
+			wantArgs: args("plan"),
+		},
+		{
+			name:     "escaped quotes in literals",
+			query:    "SELECT * FROM plans WHERE name = 'it''s $1' AND id = $1",
+			args:     args("plan"),
+			want:     "SELECT * FROM plans WHERE name = 'it''s $1' AND id = ?",
+			wantArgs: args("plan"),
+		},
+		{
+			name:     "quoted identifiers and comments",
+			query:    "SELECT \"$1\" FROM plans -- where id = $2\nWHERE id = $1 /* NOW() $2 */",
+			args:     args("plan"),
+			want:     "SELECT \"$1\" FROM plans -- where id = $2\nWHERE id = ? /* NOW() $2 */",
+			wantArgs: args("plan"),
+		},
+		{
+			name:  "now",
+			query: "UPDATE plans SET updated_at = NOW(), name = 'NOW()'",
+			want:  "UPDATE plans SET updated_at = " + sqliteNowExpr + ", name = 'NOW()'",
+		},
+		{
+			name:     "now minus interval",
+			query:    "DELETE FROM build_jobs WHERE finished_at < NOW() - INTERVAL '1 day' AND id = $1",
+			args:     args("job"),
+			want:     "DELETE FROM build_jobs WHERE finished_at < strftime('%Y-%m-%d %H:%M:%f', 'now', '-1 day') AND id = ?",
+			wantArgs: args("job"),
+		},
+		{
+			name:  "now minus plural interval",
+			query: "SELECT * FROM plans WHERE archived_at < NOW() - INTERVAL '30 days'",
+			want:  "SELECT * FROM plans WHERE archived_at < strftime('%Y-%m-%d %H:%M:%f', 'now', '-30 day')",
+		},
+		{
+			name:     "ilike",
+			query:    "SELECT * FROM plans WHERE name ILIKE $1 AND name NOT ilike 'ILIKE'",
+			args:     args("%plan%"),
+			want:     "SELECT * FROM plans WHERE name LIKE ? AND name NOT LIKE 'ILIKE'",
+			wantArgs: args("%plan%"),
+		},
+		{
+			name:     "returning",
+			query:    "INSERT INTO plans (name, note) VALUES ($1, 'RETURNING $2') RETURNING id, created_at",
+			args:     args("plan"),
+			want:     "INSERT INTO plans (name, note) VALUES (?, 'RETURNING $2') RETURNING id, created_at",
+			wantArgs: args("plan"),
+		},
+		{
+			name:     "for update",
+			query:    "SELECT id FROM build_jobs WHERE status = $1 LIMIT 1 FOR UPDATE SKIP LOCKED",
+			args:     args("queued"),
+			want:     "SELECT id FROM build_jobs WHERE status = ? LIMIT 1",
+			wantArgs: args("queued"),
+		},
+		{
+			name:  "on conflict on constraint",
+			query: "INSERT INTO plans (name) VALUES ('a') ON CONFLICT ON CONSTRAINT plans_name_key DO NOTHING",
+			want:  "INSERT INTO plans (name) VALUES ('a') ON CONFLICT DO NOTHING",
+		},
+		{
+			name:  "unterminated literal",
+			query: "SELECT '$1 NOW()",
+			want:  "SELECT '$1 NOW()",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotArgs, err := rewriteSqliteQuery(test.query, test.args, true)
+			if err != nil {
+				t.Fatalf("rewriteSqliteQuery(%q) returned error: %v", test.query, err)
+			}
+			if got != test.want {
+				t.Errorf("rewriteSqliteQuery(%q) = %q, want %q", test.query, got, test.want)
+			}
+			if len(gotArgs) != 0 || len(test.wantArgs) != 0 {
+				if !reflect.DeepEqual(gotArgs, test.wantArgs) {
+					t.Errorf("rewriteSqliteQuery(%q) args = %v, want %v", test.query, gotArgs, test.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestRewriteSqliteQueryPrepared(t *testing.T) {
+	got, _, err := rewriteSqliteQuery("SELECT * FROM plans WHERE id = $1 AND name = '$2'", nil, false)
+	if err != nil {
+		t.Fatalf("error rewriting query: %v", err)
+	}
+	if want := "SELECT * FROM plans WHERE id = ?1 AND name = '$2'"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	_, _, err = rewriteSqliteQuery("SELECT * FROM plans WHERE id = ANY($1)", nil, false)
+	if err == nil {
+		t.Errorf("expected an error preparing an ANY() query")
+	}
+
+	_, _, err = rewriteSqliteQuery("SELECT * FROM plans WHERE id = $2", []driver.NamedValue{{Ordinal: 1, Value: "plan"}}, true)
+	if err == nil {
+		t.Errorf("expected an error for a placeholder without an argument")
+	}
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/plandex/plandex/shared"
+)
+
+func GetOrgTrialPolicy(orgId string) (*shared.OrgTrialPolicy, error) {
+	var policy OrgTrialPolicy
+	err := Conn.Get(&policy, "SELECT * FROM org_trial_policies WHERE org_id = $1", orgId)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return shared.DefaultOrgTrialPolicy(), nil
+		}
+		return nil, fmt.Errorf("error getting trial policy: %v", err)
+	}
+
+	return policy.ToApi(), nil
+}
+
+// StoreOrgTrialPolicy saves an org's trial policy. When rotateAccessCode is set, or the org doesn't have an access code yet, a new code is generated and returned—otherwise the returned code is empty.
+func StoreOrgTrialPolicy(orgId string, policy *shared.OrgTrialPolicy, rotateAccessCode bool) (string, error) {
+	current, err := GetOrgTrialPolicy(orgId)
+
+	if err != nil {
+		return "", err
+	}
+
+	var accessCode string
+	var accessCodeHash *string
+
+	if rotateAccessCode || (policy.Enabled && !current.HasAccessCode) {
+		var hash string
+		accessCode, hash = newHashedToken()
+		accessCodeHash = &hash
+	}
+
+	query := `INSERT INTO org_trial_policies (org_id, enabled, max_plans, max_tokens, access_code_hash)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (org_id) DO UPDATE SET
+		enabled = excluded.enabled,
+		max_plans = excluded.max_plans,
+		max_tokens = excluded.max_tokens,
+		access_code_hash = COALESCE(excluded.access_code_hash, org_trial_policies.access_code_hash)
+	`
+
+	_, err = Conn.Exec(query, orgId, policy.Enabled, policy.MaxPlans, policy.MaxTokens, accessCodeHash)
+
+	if err != nil {
+		return "", fmt.Errorf("error storing trial policy: %v", err)
+	}
+
+	return accessCode, nil
+}
+
+// GetEnabledTrialPolicyByAccessCode returns the trial policy an access code belongs to, or nil if the code is invalid or the org's trials are disabled
+func GetEnabledTrialPolicyByAccessCode(accessCode string) (*OrgTrialPolicy, error) {
+	hash, ok := hashToken(accessCode)
+
+	if !ok {
+		return nil, nil
+	}
+
+	var policy OrgTrialPolicy
+	err := Conn.Get(&policy, "SELECT * FROM org_trial_policies WHERE access_code_hash = $1 AND enabled = TRUE", hash)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting trial policy: %v", err)
+	}
+
+	return &policy, nil
+}
+
+// TransferTrialUserData moves a guest trial user's plans and usage in an org to another user, then removes the guest from the org and signs it out everywhere
+func TransferTrialUserData(orgId, fromUserId, toUserId string, tx *sqlx.Tx) error {
+	_, err := tx.Exec("UPDATE plans SET owner_id = $1 WHERE org_id = $2 AND owner_id = $3", toUserId, orgId, fromUserId)
+
+	if err != nil {
+		return fmt.Errorf("error transferring plans: %v", err)
+	}
+
+	_, err = tx.Exec("UPDATE plan_builds SET user_id = $1 WHERE org_id = $2 AND user_id = $3", toUserId, orgId, fromUserId)
+
+	if err != nil {
+		return fmt.Errorf("error transferring builds: %v", err)
+	}
+
+	_, err = tx.Exec("UPDATE model_usages SET user_id = $1 WHERE org_id = $2 AND user_id = $3", toUserId, orgId, fromUserId)
+
+	if err != nil {
+		return fmt.Errorf("error transferring model usage: %v", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM orgs_users WHERE org_id = $1 AND user_id = $2", orgId, fromUserId)
+
+	if err != nil {
+		return fmt.Errorf("error removing trial user from org: %v", err)
+	}
+
+	_, err = tx.Exec("UPDATE auth_tokens SET deleted_at = NOW() WHERE user_id = $1 AND deleted_at IS NULL", fromUserId)
+
+	if err != nil {
+		return fmt.Errorf("error deleting trial user auth tokens: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetOrgTrustPolicy(orgId string) (*shared.TrustPolicy, error) {
+	var policy TrustPolicy
+	err := Conn.Get(&policy, "SELECT * FROM org_trust_policies WHERE org_id = $1", orgId)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return shared.DefaultTrustPolicy(), nil
+		}
+		return nil, fmt.Errorf("error getting org trust policy: %v", err)
+	}
+
+	return policy.ToApi(), nil
+}
+
+// GetProjectTrustPolicy returns nil if the project doesn't have its own policy
+func GetProjectTrustPolicy(projectId string) (*shared.TrustPolicy, error) {
+	var policy TrustPolicy
+	err := Conn.Get(&policy, "SELECT * FROM project_trust_policies WHERE project_id = $1", projectId)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting project trust policy: %v", err)
+	}
+
+	return policy.ToApi(), nil
+}
+
+func GetEffectiveTrustPolicy(orgId, projectId string) (*shared.EffectiveTrustPolicy, error) {
+	orgPolicy, err := GetOrgTrustPolicy(orgId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	projectPolicy, err := GetProjectTrustPolicy(projectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &shared.EffectiveTrustPolicy{
+		Org:     orgPolicy,
+		Project: projectPolicy,
+	}, nil
+}
+
+func StoreOrgTrustPolicy(orgId string, policy *shared.TrustPolicy) error {
+	query := `INSERT INTO org_trust_policies (org_id, load_context, create_files, delete_files, run_commands, create_files_dirs, build_cost_confirm_above)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (org_id) DO UPDATE SET
+		load_context = excluded.load_context,
+		create_files = excluded.create_files,
+		delete_files = excluded.delete_files,
+		run_commands = excluded.run_commands,
+		create_files_dirs = excluded.create_files_dirs,
+		build_cost_confirm_above = excluded.build_cost_confirm_above
+	`
+
+	_, err := Conn.Exec(query, orgId, policy.LoadContext, policy.CreateFiles, policy.DeleteFiles, policy.RunCommands, strings.Join(policy.CreateFilesDirs, "\n"), policy.BuildCostConfirmAbove)
+
+	if err != nil {
+		return fmt.Errorf("error storing org trust policy: %v", err)
+	}
+
+	return nil
+}
+
+func StoreProjectTrustPolicy(orgId, projectId string, policy *shared.TrustPolicy) error {
+	query := `INSERT INTO project_trust_policies (org_id, project_id, load_context, create_files, delete_files, run_commands, create_files_dirs, build_cost_confirm_above)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (project_id) DO UPDATE SET
+		load_context = excluded.load_context,
+		create_files = excluded.create_files,
+		delete_files = excluded.delete_files,
+		run_commands = excluded.run_commands,
+		create_files_dirs = excluded.create_files_dirs,
+		build_cost_confirm_above = excluded.build_cost_confirm_above
+	`
+
+	_, err := Conn.Exec(query, orgId, projectId, policy.LoadContext, policy.CreateFiles, policy.DeleteFiles, policy.RunCommands, strings.Join(policy.CreateFilesDirs, "\n"), policy.BuildCostConfirmAbove)
+
+	if err != nil {
+		return fmt.Errorf("error storing project trust policy: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteProjectTrustPolicy removes a project's own policy so only the org's applies
+func DeleteProjectTrustPolicy(projectId string) error {
+	_, err := Conn.Exec("DELETE FROM project_trust_policies WHERE project_id = $1", projectId)
+
+	if err != nil {
+		return fmt.Errorf("error deleting project trust policy: %v", err)
+	}
+
+	return nil
+}
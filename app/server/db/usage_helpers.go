@@ -0,0 +1,162 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func StoreModelUsage(usage *ModelUsage) error {
+	query := `INSERT INTO model_usages (org_id, user_id, plan_id, branch, model_role, provider, model_name, input_tokens, output_tokens, reasoning_tokens, cost)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := Conn.Exec(query, usage.OrgId, usage.UserId, usage.PlanId, usage.Branch, usage.ModelRole, usage.Provider, usage.ModelName, usage.InputTokens, usage.OutputTokens, usage.ReasoningTokens, usage.Cost)
+
+	if err != nil {
+		return fmt.Errorf("error storing model usage: %v", err)
+	}
+
+	return nil
+}
+
+// BuildStatRow is the part of a build that org stats are computed from
+type BuildStatRow struct {
+	Error      string     `db:"error"`
+	FinishedAt *time.Time `db:"finished_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+// ListBuildStatRows returns the org's builds started since a time, oldest first. With a userId, only builds the user started are included.
+func ListBuildStatRows(orgId, userId string, since time.Time) ([]*BuildStatRow, error) {
+	qs := "SELECT COALESCE(error, '') AS error, finished_at, created_at FROM plan_builds WHERE org_id = $1 AND created_at >= $2"
+	qargs := []interface{}{orgId, since}
+
+	if userId != "" {
+		qs += " AND user_id = $3"
+		qargs = append(qargs, userId)
+	}
+
+	qs += " ORDER BY created_at"
+
+	var rows []*BuildStatRow
+	err := Conn.Select(&rows, qs, qargs...)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing builds for stats: %v", err)
+	}
+
+	return rows, nil
+}
+
+// GetModelUsageStats totals the org's model usage since a time by role and model, most tokens first. With a userId, only the user's usage is included.
+func GetModelUsageStats(orgId, userId string, since time.Time) ([]*shared.ModelUsageStats, error) {
+	qs := `SELECT model_role, provider, model_name, COUNT(*) AS num_calls, SUM(input_tokens) AS input_tokens, SUM(output_tokens) AS output_tokens, SUM(reasoning_tokens) AS reasoning_tokens, COALESCE(SUM(cost), 0) AS cost
+	FROM model_usages
+	WHERE org_id = $1 AND created_at >= $2`
+	qargs := []interface{}{orgId, since}
+
+	if userId != "" {
+		qs += " AND user_id = $3"
+		qargs = append(qargs, userId)
+	}
+
+	qs += `
+	GROUP BY model_role, provider, model_name
+	ORDER BY SUM(input_tokens) + SUM(output_tokens) DESC`
+
+	var rows []struct {
+		ModelRole       shared.ModelRole     `db:"model_role"`
+		Provider        shared.ModelProvider `db:"provider"`
+		ModelName       string               `db:"model_name"`
+		NumCalls        int                  `db:"num_calls"`
+		InputTokens     int                  `db:"input_tokens"`
+		OutputTokens    int                  `db:"output_tokens"`
+		ReasoningTokens int                  `db:"reasoning_tokens"`
+		Cost            float64              `db:"cost"`
+	}
+	err := Conn.Select(&rows, qs, qargs...)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting model usage stats: %v", err)
+	}
+
+	res := []*shared.ModelUsageStats{}
+	for _, row := range rows {
+		res = append(res, &shared.ModelUsageStats{
+			Role:            row.ModelRole,
+			Provider:        row.Provider,
+			ModelName:       row.ModelName,
+			NumCalls:        row.NumCalls,
+			InputTokens:     row.InputTokens,
+			OutputTokens:    row.OutputTokens,
+			ReasoningTokens: row.ReasoningTokens,
+			Cost:            row.Cost,
+		})
+	}
+
+	return res, nil
+}
+
+// GetUserUsageStats totals the org's model usage since a time by user, most tokens first. Usage by users who've since been deleted is grouped under an empty user id. With a userId, only the user's usage is included.
+func GetUserUsageStats(orgId, userId string, since time.Time) ([]*shared.UserUsageStats, error) {
+	qs := `SELECT COALESCE(CAST(users.id AS TEXT), '') AS user_id, COALESCE(users.name, '') AS user_name, COALESCE(users.email, '') AS user_email,
+		COUNT(*) AS num_calls, SUM(model_usages.input_tokens) AS input_tokens, SUM(model_usages.output_tokens) AS output_tokens, SUM(model_usages.reasoning_tokens) AS reasoning_tokens, COALESCE(SUM(model_usages.cost), 0) AS cost
+	FROM model_usages
+	LEFT JOIN users ON users.id = model_usages.user_id
+	WHERE model_usages.org_id = $1 AND model_usages.created_at >= $2`
+	qargs := []interface{}{orgId, since}
+
+	if userId != "" {
+		qs += " AND model_usages.user_id = $3"
+		qargs = append(qargs, userId)
+	}
+
+	qs += `
+	GROUP BY users.id, users.name, users.email
+	ORDER BY SUM(model_usages.input_tokens) + SUM(model_usages.output_tokens) DESC`
+
+	var rows []struct {
+		UserId          string  `db:"user_id"`
+		UserName        string  `db:"user_name"`
+		UserEmail       string  `db:"user_email"`
+		NumCalls        int     `db:"num_calls"`
+		InputTokens     int     `db:"input_tokens"`
+		OutputTokens    int     `db:"output_tokens"`
+		ReasoningTokens int     `db:"reasoning_tokens"`
+		Cost            float64 `db:"cost"`
+	}
+	err := Conn.Select(&rows, qs, qargs...)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting user usage stats: %v", err)
+	}
+
+	res := []*shared.UserUsageStats{}
+	for _, row := range rows {
+		res = append(res, &shared.UserUsageStats{
+			UserId:          row.UserId,
+			UserName:        row.UserName,
+			UserEmail:       row.UserEmail,
+			NumCalls:        row.NumCalls,
+			InputTokens:     row.InputTokens,
+			OutputTokens:    row.OutputTokens,
+			ReasoningTokens: row.ReasoningTokens,
+			Cost:            row.Cost,
+		})
+	}
+
+	return res, nil
+}
+
+// GetUserTokensUsed totals the model tokens a user has used in an org
+func GetUserTokensUsed(orgId, userId string) (int, error) {
+	var total int
+	err := Conn.Get(&total, "SELECT COALESCE(SUM(input_tokens + output_tokens), 0) FROM model_usages WHERE org_id = $1 AND user_id = $2", orgId, userId)
+
+	if err != nil {
+		return 0, fmt.Errorf("error getting tokens used: %v", err)
+	}
+
+	return total, nil
+}
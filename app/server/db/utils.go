@@ -1,6 +1,9 @@
 package db
 
-import "github.com/lib/pq"
+import (
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
 
 func IsNonUniqueErr(err error) bool {
 	if err, ok := err.(*pq.Error); ok {
@@ -8,5 +11,18 @@ func IsNonUniqueErr(err error) bool {
 			return true
 		}
 	}
+	if err, ok := err.(sqlite3.Error); ok {
+		if err.ExtendedCode == sqlite3.ErrConstraintUnique || err.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+			return true
+		}
+	}
 	return false
 }
+
+// isConcurrencyErr returns true for serialization failures and deadlocks on Postgres, or a busy/locked database on SQLite -- these are safe to retry
+func isConcurrencyErr(err error) bool {
+	if pqErr, ok := err.(*pq.Error); ok && (pqErr.Code == "40001" || pqErr.Code == "40P01") {
+		return true
+	}
+	return isSqliteErrCode(err, sqlite3.ErrBusy, sqlite3.ErrLocked)
+}
@@ -0,0 +1,98 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strings"
+)
+
+// ActivityDigest is a user's plan activity over a period, grouped by org
+type ActivityDigest struct {
+	// like 'in the last day', used in the subject and intro
+	Period string
+	Orgs   []*ActivityDigestOrg
+}
+
+type ActivityDigestOrg struct {
+	Name  string
+	Plans []*ActivityDigestPlan
+}
+
+type ActivityDigestPlan struct {
+	Name           string
+	Updated        bool
+	BuildsFinished int
+	BuildsFailed   int
+	// files with changes waiting for review on the plan's main branch
+	PendingFiles int
+}
+
+func (d *ActivityDigest) totals() (numUpdated, numFinished, numFailed, numPending int) {
+	for _, org := range d.Orgs {
+		for _, plan := range org.Plans {
+			if plan.Updated {
+				numUpdated++
+			}
+			numFinished += plan.BuildsFinished
+			numFailed += plan.BuildsFailed
+			numPending += plan.PendingFiles
+		}
+	}
+	return
+}
+
+func (p *ActivityDigestPlan) details() string {
+	var parts []string
+	if p.Updated {
+		parts = append(parts, "updated")
+	}
+	if p.BuildsFinished > 0 {
+		parts = append(parts, fmt.Sprintf("%d build(s) completed", p.BuildsFinished))
+	}
+	if p.BuildsFailed > 0 {
+		parts = append(parts, fmt.Sprintf("%d build(s) failed", p.BuildsFailed))
+	}
+	if p.PendingFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d file(s) with pending changes", p.PendingFiles))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func SendActivityDigestEmail(email, firstName string, digest *ActivityDigest) error {
+	numUpdated, numFinished, numFailed, numPending := digest.totals()
+	summary := fmt.Sprintf("%d plan(s) updated, %d build(s) completed, %d failed, %d file(s) with pending changes", numUpdated, numFinished, numFailed, numPending)
+
+	// Check if the environment is production
+	if os.Getenv("GOENV") == "production" {
+		subject := fmt.Sprintf("Your Plandex activity %s: %s", digest.Period, summary)
+
+		var htmlSections []string
+		var textSections []string
+		for _, org := range digest.Orgs {
+			var htmlItems []string
+			var textItems []string
+			for _, plan := range org.Plans {
+				htmlItems = append(htmlItems, fmt.Sprintf("<li><strong>%s</strong>: %s</li>", html.EscapeString(plan.Name), plan.details()))
+				textItems = append(textItems, fmt.Sprintf("- %s: %s", plan.Name, plan.details()))
+			}
+			htmlSections = append(htmlSections, fmt.Sprintf("<p><strong>%s</strong></p><ul>%s</ul>", html.EscapeString(org.Name), strings.Join(htmlItems, "")))
+			textSections = append(textSections, fmt.Sprintf("%s\n%s", org.Name, strings.Join(textItems, "\n")))
+		}
+
+		htmlBody := fmt.Sprintf(`<p>Hi %s,</p><p>Here's your plan activity %s: %s.</p>%s<p>To review pending changes, run 'plandex diff' in the plan's project directory. To change how often you get this email, run 'plandex digest set'.</p>`, html.EscapeString(firstName), digest.Period, summary, strings.Join(htmlSections, ""))
+
+		textBody := fmt.Sprintf("Hi %s,\n\nHere's your plan activity %s: %s.\n\n%s\n\nTo review pending changes, run 'plandex diff' in the plan's project directory. To change how often you get this email, run 'plandex digest set'.", firstName, digest.Period, summary, strings.Join(textSections, "\n\n"))
+
+		if os.Getenv("IS_CLOUD") == "" {
+			return sendEmailViaSMTP(email, subject, htmlBody, textBody)
+		} else {
+			return sendEmailViaSES(email, subject, htmlBody, textBody)
+		}
+	}
+
+	log.Printf("Development mode: activity digest for %s %s: %s\n", email, digest.Period, summary)
+
+	return nil
+}
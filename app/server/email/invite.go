@@ -2,33 +2,59 @@ package email
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"time"
 
 	"github.com/gen2brain/beeep"
 )
 
-func SendInviteEmail(email, inviteeFirstName, inviterName, orgName string) error {
+// SendInviteEmail emails an invite if the server can send email. Returns whether it was sent—when it wasn't, the inviter shares the invite's token instead.
+func SendInviteEmail(email, inviteeFirstName, inviterName, orgName, token string, expiresAt time.Time) (bool, error) {
+	date := expiresAt.Format("January 2, 2006")
+
 	// Check if the environment is production
 	if os.Getenv("GOENV") == "production" {
+		if !CanSend() {
+			log.Printf("Email isn't configured, so the invite for %s wasn't emailed\n", email)
+			return false, nil
+		}
+
 		// Production environment - send email using AWS SES
 		subject := fmt.Sprintf("%s, you've been invited to join %s on Plandex", inviteeFirstName, orgName)
 
-		htmlBody := fmt.Sprintf(`<p>Hi %s,</p><p>%s has invited you to join the org <strong>%s</strong> on <a href="https://plandex.ai">Plandex.</a></p><p>Plandex is a terminal-based AI programming engine for complex tasks.</p><p>To accept the invite, first <a href="https://github.com/plandex-ai/plandex?tab=readme-ov-file#install">install Plandex</a>, then open a terminal and run 'plandex sign-in'. Enter '%s' when asked for your email and follow the prompts from there.</p><p>If you have questions, feedback, or run into a problem, you can reply directly to this email, <a href="https://github.com/plandex-ai/plandex/discussions">start a discussion</a>, or <a href="https://github.com/plandex-ai/plandex/issues">open an issue.</a></p>`, inviteeFirstName, inviterName, orgName, email)
+		htmlBody := fmt.Sprintf(`<p>Hi %s,</p><p>%s has invited you to join the org <strong>%s</strong> on <a href="https://plandex.ai">Plandex.</a></p><p>Plandex is a terminal-based AI programming engine for complex tasks.</p><p>To accept the invite, first <a href="https://github.com/plandex-ai/plandex?tab=readme-ov-file#install">install Plandex</a>, then open a terminal and run 'plandex sign-in'. Enter '%s' when asked for your email and follow the prompts from there.</p><p>If you're already signed in to Plandex, you can instead run:<br><strong>plandex invites accept %s</strong></p><p>The invite expires on %s.</p><p>If you have questions, feedback, or run into a problem, you can reply directly to this email, <a href="https://github.com/plandex-ai/plandex/discussions">start a discussion</a>, or <a href="https://github.com/plandex-ai/plandex/issues">open an issue.</a></p>`, inviteeFirstName, inviterName, orgName, email, token, date)
 
-		textBody := fmt.Sprintf(`Hi %s,\n\n%s has invited you to join the org %s on Plandex.\n\nPlandex is a terminal-based AI programming engine for complex tasks.\n\nTo accept the invite, first install Plandex (https://github.com/plandex-ai/plandex?tab=readme-ov-file#install), then open a terminal and run 'plandex sign-in'. Enter '%s' when asked for your email and follow the prompts from there.\n\nIf you have questions, feedback, or run into a problem, you can reply directly to this email, start a discussion (https://github.com/plandex-ai/plandex/discussions), or open an issue (https://github.com/plandex-ai/plandex/issues).`, inviteeFirstName, inviterName, orgName, email)
+		textBody := fmt.Sprintf("Hi %s,\n\n%s has invited you to join the org %s on Plandex.\n\nPlandex is a terminal-based AI programming engine for complex tasks.\n\nTo accept the invite, first install Plandex (https://github.com/plandex-ai/plandex?tab=readme-ov-file#install), then open a terminal and run 'plandex sign-in'. Enter '%s' when asked for your email and follow the prompts from there.\n\nIf you're already signed in to Plandex, you can instead run:\nplandex invites accept %s\n\nThe invite expires on %s.\n\nIf you have questions, feedback, or run into a problem, you can reply directly to this email, start a discussion (https://github.com/plandex-ai/plandex/discussions), or open an issue (https://github.com/plandex-ai/plandex/issues).", inviteeFirstName, inviterName, orgName, email, token, date)
 
+		var err error
 		if os.Getenv("IS_CLOUD") == "" {
-			return sendEmailViaSMTP(email, subject, htmlBody, textBody)
+			err = sendEmailViaSMTP(email, subject, htmlBody, textBody)
 		} else {
-			return sendEmailViaSES(email, subject, htmlBody, textBody)
+			err = sendEmailViaSES(email, subject, htmlBody, textBody)
+		}
+
+		if err != nil {
+			return false, err
 		}
+
+		return true, nil
 	} else {
 		// Send notification
 		err := beeep.Notify("Invite Sent", fmt.Sprintf("Invite sent to %s (email not sent in development)", email), "")
 		if err != nil {
-			return fmt.Errorf("error sending notification in dev: %v", err)
+			return false, fmt.Errorf("error sending notification in dev: %v", err)
 		}
 	}
 
-	return nil
+	return false, nil
+}
+
+// CanSend checks whether the server is set up to deliver email: through SES on Plandex Cloud, or SMTP otherwise
+func CanSend() bool {
+	if os.Getenv("IS_CLOUD") != "" {
+		return true
+	}
+
+	return os.Getenv("SMTP_HOST") != "" && os.Getenv("SMTP_PORT") != "" && os.Getenv("SMTP_USER") != "" && os.Getenv("SMTP_PASSWORD") != ""
 }
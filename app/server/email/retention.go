@@ -0,0 +1,40 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+func SendPlanDeletionNoticeEmail(email, firstName, orgName string, planNames []string, deleteAfter time.Time) error {
+	date := deleteAfter.Format("January 2, 2006")
+
+	// Check if the environment is production
+	if os.Getenv("GOENV") == "production" {
+		subject := fmt.Sprintf("%d archived Plandex plan(s) in %s will be deleted on %s", len(planNames), orgName, date)
+
+		var htmlItems []string
+		var textItems []string
+		for _, name := range planNames {
+			htmlItems = append(htmlItems, "<li>"+html.EscapeString(name)+"</li>")
+			textItems = append(textItems, "- "+name)
+		}
+
+		htmlBody := fmt.Sprintf(`<p>Hi %s,</p><p>The following archived plans in the org <strong>%s</strong> will be permanently deleted on or after %s, as set by your org's retention policy:</p><ul>%s</ul><p>To keep a plan, run 'plandex unarchive' in its project directory before then.</p>`, html.EscapeString(firstName), html.EscapeString(orgName), date, strings.Join(htmlItems, ""))
+
+		textBody := fmt.Sprintf("Hi %s,\n\nThe following archived plans in the org %s will be permanently deleted on or after %s, as set by your org's retention policy:\n\n%s\n\nTo keep a plan, run 'plandex unarchive' in its project directory before then.", firstName, orgName, date, strings.Join(textItems, "\n"))
+
+		if os.Getenv("IS_CLOUD") == "" {
+			return sendEmailViaSMTP(email, subject, htmlBody, textBody)
+		} else {
+			return sendEmailViaSES(email, subject, htmlBody, textBody)
+		}
+	}
+
+	log.Printf("Development mode: %d archived plan(s) in org %s owned by %s will be deleted on or after %s: %s\n", len(planNames), orgName, email, date, strings.Join(planNames, ", "))
+
+	return nil
+}
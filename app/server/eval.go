@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex-server/model"
+	"plandex-server/model/plan"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// runEvalCmd runs the builder eval cases against a model and writes a markdown report, so prompt and strategy changes can be checked before they're released
+func runEvalCmd(args []string) {
+	flags := flag.NewFlagSet("eval", flag.ExitOnError)
+	casesDir := flags.String("cases", "evals/build", "directory of eval cases")
+	only := flags.String("case", "", "comma-separated names of cases to run (default all)")
+	packName := flags.String("pack", shared.DefaultModelPack.Name, "built-in model pack whose builder model is evaluated")
+	modelName := flags.String("model", "", "evaluate this model instead of the pack's builder model")
+	baseUrl := flags.String("base-url", "", "override the model's base url")
+	concurrency := flags.Int("concurrency", 4, "number of cases to run at once")
+	out := flags.String("out", "", "path of the markdown report (default stdout)")
+	minPassRate := flags.Float64("min-pass-rate", 0, "exit with an error if fewer than this share of cases pass, from 0 to 1")
+	flags.Parse(args)
+
+	config, err := getEvalModelConfig(*packName, *modelName, *baseUrl)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cases, err := plan.LoadBuildEvalCases(*casesDir)
+	if err != nil {
+		log.Fatal("Error loading eval cases: ", err)
+	}
+
+	if *only != "" {
+		names := map[string]bool{}
+		for _, name := range strings.Split(*only, ",") {
+			names[strings.TrimSpace(name)] = true
+		}
+		var filtered []*plan.BuildEvalCase
+		for _, evalCase := range cases {
+			if names[evalCase.Name] {
+				filtered = append(filtered, evalCase)
+			}
+		}
+		cases = filtered
+	}
+
+	if len(cases) == 0 {
+		log.Fatal("No eval cases to run")
+	}
+
+	envVar := config.BaseModelConfig.ApiKeyEnvVar
+	apiKey := os.Getenv(envVar)
+	if config.BaseModelConfig.Provider == shared.ModelProviderMock {
+		// useful for checking the eval setup itself, since the mock builder doesn't change anything by default
+		apiKey = "mock"
+		err = model.InitMockProvider()
+		if err != nil {
+			log.Fatal("Error initializing mock model provider: ", err)
+		}
+	}
+	if apiKey == "" {
+		log.Fatalf("%s is required to run evals with %s", envVar, config.BaseModelConfig.ModelName)
+	}
+
+	err = model.InitTransport()
+	if err != nil {
+		log.Fatal("Error initializing model transport: ", err)
+	}
+
+	clients := model.InitClients(
+		map[string]string{envVar: apiKey},
+		map[string]string{envVar: config.BaseModelConfig.BaseUrl},
+		config.BaseModelConfig.BaseUrl,
+		os.Getenv("OPENAI_ORG_ID"),
+	)
+	client := clients[envVar]
+
+	log.Printf("Running %d eval case(s) with %s\n", len(cases), config.BaseModelConfig.ModelName)
+
+	results := make([]*plan.BuildEvalResult, len(cases))
+	sem := make(chan struct{}, max(*concurrency, 1))
+	var wg sync.WaitGroup
+	for i, evalCase := range cases {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, evalCase *plan.BuildEvalCase) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res := plan.RunBuildEval(context.Background(), client, config, evalCase)
+			results[i] = res
+
+			status := "pass"
+			if !res.Passed() {
+				status = "fail"
+			}
+			log.Printf("%s: %s (%.0f%% similar, %s)\n", evalCase.Name, status, res.Similarity*100, res.Duration.Round(time.Millisecond))
+		}(i, evalCase)
+	}
+	wg.Wait()
+
+	report := getEvalReport(config, results)
+
+	if *out == "" {
+		fmt.Print(report)
+	} else {
+		err = os.WriteFile(*out, []byte(report), 0644)
+		if err != nil {
+			log.Fatal("Error writing eval report: ", err)
+		}
+		log.Printf("Wrote eval report to %s\n", *out)
+	}
+
+	numPassed := 0
+	for _, res := range results {
+		if res.Passed() {
+			numPassed++
+		}
+	}
+	passRate := float64(numPassed) / float64(len(results))
+
+	log.Printf("%d/%d cases passed\n", numPassed, len(results))
+
+	if passRate < *minPassRate {
+		log.Fatalf("Pass rate %.2f is below the minimum of %.2f", passRate, *minPassRate)
+	}
+}
+
+func getEvalModelConfig(packName, modelName, baseUrl string) (shared.ModelRoleConfig, error) {
+	var config shared.ModelRoleConfig
+	found := false
+	for _, pack := range shared.BuiltInModelPacks {
+		if pack.Name == packName {
+			config = pack.Builder
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return config, fmt.Errorf("model pack %s not found", packName)
+	}
+
+	if modelName != "" {
+		available := shared.AvailableModelsByName[modelName]
+		if available == nil {
+			return config, fmt.Errorf("model %s not found", modelName)
+		}
+		config.BaseModelConfig = available.BaseModelConfig
+	}
+
+	if baseUrl != "" {
+		config.BaseModelConfig.BaseUrl = baseUrl
+	}
+
+	return config, nil
+}
+
+func getEvalReport(config shared.ModelRoleConfig, results []*plan.BuildEvalResult) string {
+	var b strings.Builder
+
+	numPassed := 0
+	var totalSimilarity float64
+	for _, res := range results {
+		if res.Passed() {
+			numPassed++
+		}
+		totalSimilarity += res.Similarity
+	}
+
+	fmt.Fprintf(&b, "# Builder eval: %s\n\n", config.BaseModelConfig.ModelName)
+	fmt.Fprintf(&b, "%s | temperature %.2f | top p %.2f\n\n", time.Now().UTC().Format(time.RFC3339), config.Temperature, config.TopP)
+	fmt.Fprintf(&b, "**%d/%d passed** | average similarity %.1f%%\n\n", numPassed, len(results), totalSimilarity/float64(len(results))*100)
+
+	fmt.Fprintln(&b, "| Case | Result | Replacements | Syntax | Similarity | Time |")
+	fmt.Fprintln(&b, "| --- | --- | --- | --- | --- | --- |")
+	for _, res := range results {
+		result := "✅ pass"
+		if !res.Passed() {
+			result = "❌ fail"
+		}
+		replacements := "ok"
+		if !res.AllSucceeded {
+			replacements = "failed"
+		}
+		syntax := "ok"
+		if !res.SyntaxValid {
+			syntax = "invalid"
+		}
+		if res.Err != nil {
+			replacements = "-"
+			syntax = "-"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %.1f%% | %s |\n", res.Case.Name, result, replacements, syntax, res.Similarity*100, res.Duration.Round(time.Millisecond))
+	}
+
+	for _, res := range results {
+		if res.Passed() {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n## %s\n\n", res.Case.Name)
+
+		if res.Err != nil {
+			fmt.Fprintf(&b, "Error: %v\n", res.Err)
+			continue
+		}
+
+		diff, err := getEvalDiff(res.Case.Path, res.Case.Expected, res.Updated)
+		if err != nil {
+			fmt.Fprintf(&b, "Error getting diff: %v\n", err)
+			continue
+		}
+
+		fmt.Fprintf(&b, "```diff\n%s```\n", diff)
+	}
+
+	return b.String()
+}
+
+// getEvalDiff diffs the expected and updated files with git, like plan diffs
+func getEvalDiff(path, expected, updated string) (string, error) {
+	dir, err := os.MkdirTemp("", "plandex-eval-*")
+	if err != nil {
+		return "", fmt.Errorf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Base(path)
+	expectedPath := filepath.Join(dir, "expected", name)
+	updatedPath := filepath.Join(dir, "updated", name)
+
+	// trailing newlines aren't scored, so they're normalized to keep them out of the diff
+	for filePath, content := range map[string]string{expectedPath: expected, updatedPath: updated} {
+		content = strings.TrimRight(content, "\n") + "\n"
+		err = os.MkdirAll(filepath.Dir(filePath), 0755)
+		if err != nil {
+			return "", fmt.Errorf("error creating directory: %v", err)
+		}
+		err = os.WriteFile(filePath, []byte(content), 0644)
+		if err != nil {
+			return "", fmt.Errorf("error writing file: %v", err)
+		}
+	}
+
+	cmd := exec.Command("git", "diff", "--no-index", "--no-color", "expected/"+name, "updated/"+name)
+	cmd.Dir = dir
+	res, err := cmd.Output()
+
+	// git diff --no-index exits with 1 when the files differ
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error running git diff: %v", err)
+	}
+
+	return string(res), nil
+}
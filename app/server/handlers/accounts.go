@@ -322,6 +322,22 @@ func ConvertTrialHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	org, err := db.GetOrg(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting org: %v\n", err)
+		http.Error(w, "Error getting org: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// guests who joined through an org's trial policy are members of a real org, so there's no trial org to convert
+	isGuest := !org.IsTrial
+
+	if isGuest && req.ExistingAccount {
+		convertGuestToExistingAccount(w, auth, req, emailVerificationId)
+		return
+	}
+
 	emailSplit := strings.Split(req.Email, "@")
 	if len(emailSplit) != 2 {
 		log.Printf("Invalid email: %v\n", req.Email)
@@ -330,7 +346,7 @@ func ConvertTrialHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	userDomain := emailSplit[1]
 	var domain *string
-	if req.OrgAutoAddDomainUsers {
+	if req.OrgAutoAddDomainUsers && !isGuest {
 		if shared.IsEmailServiceDomain(userDomain) {
 			log.Printf("Invalid domain: %v\n", userDomain)
 			http.Error(w, "Invalid domain: "+userDomain, http.StatusBadRequest)
@@ -399,18 +415,31 @@ func ConvertTrialHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if isGuest {
+		// a guest who signs up becomes a regular member
+		_, err = tx.Exec("UPDATE orgs_users SET org_role_id = (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'member') WHERE org_id = $1 AND user_id = $2 AND org_role_id = (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'guest')", auth.OrgId, auth.User.Id)
+
+		if err != nil {
+			log.Printf("Error updating org user role: %v\n", err)
+			http.Error(w, "Error updating org user role: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// update org
-	_, err = tx.Exec("UPDATE orgs SET name = $1, domain = $2, auto_add_domain_users = $3, is_trial = false WHERE id = $4", req.OrgName, domain, req.OrgAutoAddDomainUsers, auth.OrgId)
+	if !isGuest {
+		_, err = tx.Exec("UPDATE orgs SET name = $1, domain = $2, auto_add_domain_users = $3, is_trial = false WHERE id = $4", req.OrgName, domain, req.OrgAutoAddDomainUsers, auth.OrgId)
 
-	if err != nil {
-		if db.IsNonUniqueErr(err) {
-			log.Printf("Org already exists for domain: %v\n", userDomain)
-			http.Error(w, "Org already exists for domain: "+userDomain, http.StatusConflict)
+		if err != nil {
+			if db.IsNonUniqueErr(err) {
+				log.Printf("Org already exists for domain: %v\n", userDomain)
+				http.Error(w, "Org already exists for domain: "+userDomain, http.StatusConflict)
+				return
+			}
+			log.Printf("Error updating org: %v\n", err)
+			http.Error(w, "Error updating org: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
-		log.Printf("Error updating org: %v\n", err)
-		http.Error(w, "Error updating org: "+err.Error(), http.StatusInternalServerError)
-		return
 	}
 
 	// commit transaction
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"plandex-server/config"
+	"strings"
+)
+
+// ReloadConfigHandler reloads the server's config without a restart. It's only available when PLANDEX_ADMIN_TOKEN is set, and requires the token as a bearer token, since the config applies to every org on the server.
+func ReloadConfigHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ReloadConfigHandler")
+
+	if !authenticateAdmin(w, r) {
+		return
+	}
+
+	res, err := config.Reload()
+	if err != nil {
+		log.Printf("Error reloading config: %v\n", err)
+		http.Error(w, "Error reloading config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(res)
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully reloaded config")
+}
+
+func authenticateAdmin(w http.ResponseWriter, r *http.Request) bool {
+	adminToken := os.Getenv("PLANDEX_ADMIN_TOKEN")
+	if adminToken == "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return false
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+		log.Println("Invalid admin token")
+		http.Error(w, "Invalid admin token", http.StatusUnauthorized)
+		return false
+	}
+
+	return true
+}
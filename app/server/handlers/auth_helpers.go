@@ -110,7 +110,22 @@ func authenticate(w http.ResponseWriter, r *http.Request, requireOrg bool) *type
 
 			err := db.AcceptInvite(invite, authToken.UserId)
 
-			if err != nil {
+			if err == db.ErrInviteAlreadyAccepted {
+				// another request may have accepted it for this user at the same time
+				isMember, err = db.ValidateOrgMembership(authToken.UserId, parsed.OrgId)
+
+				if err != nil {
+					log.Printf("error validating org membership: %v\n", err)
+					http.Error(w, "error validating org membership", http.StatusInternalServerError)
+					return nil
+				}
+
+				if !isMember {
+					log.Println("invite was already accepted and user is not a member of the org")
+					http.Error(w, "not a member of org", http.StatusUnauthorized)
+					return nil
+				}
+			} else if err != nil {
 				log.Printf("error accepting invite: %v\n", err)
 				http.Error(w, "error accepting invite", http.StatusInternalServerError)
 				return nil
@@ -204,7 +219,7 @@ func authorizeProjectDelete(w http.ResponseWriter, projectId string, auth *types
 func authorizePlan(w http.ResponseWriter, planId string, auth *types.ServerAuth) *db.Plan {
 	log.Println("authorizing plan")
 
-	plan, err := db.ValidatePlanAccess(planId, auth.User.Id, auth.OrgId)
+	plan, share, err := db.ValidatePlanAccess(planId, auth.User.Id, auth.OrgId)
 
 	if err != nil {
 		log.Printf("error validating plan membership: %v\n", err)
@@ -218,6 +233,49 @@ func authorizePlan(w http.ResponseWriter, planId string, auth *types.ServerAuth)
 		return nil
 	}
 
+	if share != nil {
+		log.Printf("plan is shared with user with %s access\n", share.Access)
+
+		// a share only grants access to the plan itself, in the plan's org, and none of the user's org permissions apply to it
+		auth.PlanShare = share
+		auth.OrgId = plan.OrgId
+		auth.Permissions = nil
+	}
+
+	return plan
+}
+
+// authorizePlanWrite is for changes to a plan's context, conversation, branches, or pending changes. Anyone with access can make them except users the plan is shared with read-only.
+func authorizePlanWrite(w http.ResponseWriter, planId string, auth *types.ServerAuth) *db.Plan {
+	plan := authorizePlan(w, planId, auth)
+
+	if plan == nil {
+		return nil
+	}
+
+	if auth.PlanShare != nil && auth.PlanShare.Access != shared.PlanShareAccessWrite {
+		log.Println("Plan is shared with user read-only")
+		http.Error(w, "Plan is shared with you read-only", http.StatusForbidden)
+		return nil
+	}
+
+	return plan
+}
+
+// authorizePlanShares is for listing and changing who a plan is shared with, which only the owner or users with permission to manage any plan's shares can do
+func authorizePlanShares(w http.ResponseWriter, planId string, auth *types.ServerAuth) *db.Plan {
+	plan := authorizePlan(w, planId, auth)
+
+	if plan == nil {
+		return nil
+	}
+
+	if plan.OwnerId != auth.User.Id && !auth.HasPermission(types.PermissionManageAnyPlanShares) {
+		log.Println("User does not have permission to manage plan shares")
+		http.Error(w, "User does not have permission to manage plan shares", http.StatusForbidden)
+		return nil
+	}
+
 	return plan
 }
 
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	modelPlan "plandex-server/model/plan"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+const maxBenchModelPacks = 6
+
+const defaultBenchResultsLimit = 50
+
+func BenchHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for BenchHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	plan := authorizePlanWrite(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	var req shared.BenchRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request: %v\n", err)
+		http.Error(w, "Error decoding request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.ModelPacks) < 2 || len(req.ModelPacks) > maxBenchModelPacks {
+		http.Error(w, fmt.Sprintf("Between 2 and %d model packs are required", maxBenchModelPacks), http.StatusBadRequest)
+		return
+	}
+
+	modelPacks, err := getBenchModelPacks(auth.OrgId, req.ModelPacks)
+	if err != nil {
+		log.Printf("Error getting model packs: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the repo is only locked while the files are loaded, since the builds can take minutes and don't touch the plan
+	var files []*modelPlan.BenchFile
+	func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+		if unlockFn == nil {
+			err = fmt.Errorf("couldn't lock repo")
+			return
+		}
+		defer func() {
+			(*unlockFn)(err)
+		}()
+
+		var planState *shared.CurrentPlanState
+		planState, err = db.GetCurrentPlanState(db.CurrentPlanStateParams{
+			OrgId:  auth.OrgId,
+			PlanId: planId,
+		})
+		if err != nil {
+			log.Printf("Error getting current plan state: %v\n", err)
+			http.Error(w, "Error getting current plan state: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var convo []*db.ConvoMessage
+		convo, err = db.GetPlanConvo(auth.OrgId, planId)
+		if err != nil {
+			log.Printf("Error getting plan convo: %v\n", err)
+			http.Error(w, "Error getting plan convo: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		files, err = modelPlan.GetBenchFiles(planState, convo)
+		if err != nil {
+			log.Printf("Error getting bench files: %v\n", err)
+			http.Error(w, "Error getting bench files: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}()
+
+	if err != nil {
+		return
+	}
+
+	if len(files) == 0 {
+		http.Error(w, shared.NoBenchFilesErr, http.StatusNotFound)
+		return
+	}
+
+	var builderConfigs []shared.ModelRoleConfig
+	for _, modelPack := range modelPacks {
+		builderConfigs = append(builderConfigs, modelPack.Builder)
+	}
+
+	clients := initClients(
+		initClientsParams{
+			w:                w,
+			apiKeys:          req.ApiKeys,
+			openAIBase:       req.OpenAIBase,
+			openAIOrgId:      req.OpenAIOrgId,
+			plan:             plan,
+			user:             auth.User,
+			extraRoleConfigs: builderConfigs,
+		},
+	)
+	if clients == nil {
+		return
+	}
+
+	benchId := uuid.New().String()
+
+	results := make([]*db.BenchResult, len(modelPacks))
+	done := make(chan struct{}, len(modelPacks))
+
+	// packs run concurrently, since they usually call different providers
+	for i, modelPack := range modelPacks {
+		go func(i int, modelPack *shared.ModelPack) {
+			defer func() { done <- struct{}{} }()
+
+			config := modelPack.Builder
+			result := &db.BenchResult{
+				OrgId:        auth.OrgId,
+				PlanId:       &planId,
+				BenchId:      benchId,
+				ModelPack:    modelPack.Name,
+				BuilderModel: config.BaseModelConfig.ModelName,
+				NumFiles:     len(files),
+			}
+			results[i] = result
+
+			client := clients[config.BaseModelConfig.ApiKeyEnvVar]
+			if client == nil {
+				result.Error = fmt.Sprintf("%s isn't set", config.BaseModelConfig.ApiKeyEnvVar)
+				return
+			}
+
+			run := modelPlan.RunBench(r.Context(), client, config, files)
+			result.NumPassed = run.NumPassed
+			result.DurationMs = run.Duration.Milliseconds()
+			result.PromptTokens = run.PromptTokens
+			result.CompletionTokens = run.CompletionTokens
+		}(i, modelPack)
+	}
+
+	for range modelPacks {
+		<-done
+	}
+
+	if r.Context().Err() != nil {
+		log.Println("Bench request canceled")
+		return
+	}
+
+	res := shared.BenchResponse{BenchId: benchId}
+	for _, result := range results {
+		err = db.StoreBenchResult(result)
+		if err != nil {
+			log.Printf("Error storing bench result: %v\n", err)
+			http.Error(w, "Error storing bench result: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		res.Results = append(res.Results, result.ToApi())
+	}
+
+	bytes, err := json.Marshal(res)
+	if err != nil {
+		log.Printf("Error marshalling bench response: %v\n", err)
+		http.Error(w, "Error marshalling bench response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Printf("Successfully ran bench %s for plan %s\n", benchId, planId)
+}
+
+func ListBenchResultsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListBenchResultsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	limit := defaultBenchResultsLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := db.ListBenchResults(auth.OrgId, limit)
+	if err != nil {
+		log.Printf("Error listing bench results: %v\n", err)
+		http.Error(w, "Error listing bench results: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(results)
+	if err != nil {
+		log.Printf("Error marshalling bench results: %v\n", err)
+		http.Error(w, "Error marshalling bench results: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+}
+
+// getBenchModelPacks resolves model pack names to built-in packs or the org's custom packs
+func getBenchModelPacks(orgId string, names []string) ([]*shared.ModelPack, error) {
+	customModelPacks, err := db.ListModelPacks(orgId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting custom model packs: %v", err)
+	}
+
+	var res []*shared.ModelPack
+	for _, name := range names {
+		var modelPack *shared.ModelPack
+		for _, mp := range shared.BuiltInModelPacks {
+			if strings.EqualFold(mp.Name, name) {
+				modelPack = mp
+				break
+			}
+		}
+
+		if modelPack == nil {
+			for _, mp := range customModelPacks {
+				if strings.EqualFold(mp.Name, name) {
+					modelPack = mp.ToApi()
+					break
+				}
+			}
+		}
+
+		if modelPack == nil {
+			return nil, fmt.Errorf("model pack %s not found", name)
+		}
+
+		res = append(res, modelPack)
+	}
+
+	return res, nil
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -76,7 +77,7 @@ func CreateBranchHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("planId: ", planId)
 
-	plan := authorizePlan(w, planId, auth)
+	plan := authorizePlanWrite(w, planId, auth)
 	if plan == nil {
 		return
 	}
@@ -117,6 +118,38 @@ func CreateBranchHandler(w http.ResponseWriter, r *http.Request) {
 		}()
 	}
 
+	var fromSha string
+	if req.FromMessageNum > 0 {
+		var convo []*db.ConvoMessage
+		convo, err = db.GetPlanConvo(auth.OrgId, planId)
+		if err != nil {
+			log.Printf("Error getting plan convo: %v\n", err)
+			http.Error(w, "Error getting plan convo: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var fromMsg *db.ConvoMessage
+		for _, msg := range convo {
+			if msg.Num == req.FromMessageNum {
+				fromMsg = msg
+				break
+			}
+		}
+
+		if fromMsg == nil {
+			log.Printf("Message %d not found\n", req.FromMessageNum)
+			http.Error(w, fmt.Sprintf("Message %d not found", req.FromMessageNum), http.StatusBadRequest)
+			return
+		}
+
+		fromSha, err = db.GitGetConvoMessageSha(auth.OrgId, planId, fromMsg.Id)
+		if err != nil {
+			log.Printf("Error getting message commit: %v\n", err)
+			http.Error(w, "Error getting message commit: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	tx, err := db.Conn.Beginx()
 	if err != nil {
 		log.Printf("Error starting transaction: %v\n", err)
@@ -143,6 +176,17 @@ func CreateBranchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the new branch is checked out, so rewinding it leaves the parent branch as is
+	if fromSha != "" {
+		err = db.GitRewindToSha(auth.OrgId, planId, req.Name, fromSha)
+
+		if err != nil {
+			log.Printf("Error rewinding new branch: %v\n", err)
+			http.Error(w, "Error rewinding new branch: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// commit the transaction
 	if err := tx.Commit(); err != nil {
 		log.Printf("Error committing transaction: %v\n", err)
@@ -150,6 +194,16 @@ func CreateBranchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if fromSha != "" {
+		err = db.SyncPlanTokens(auth.OrgId, planId, req.Name)
+
+		if err != nil {
+			log.Printf("Error syncing plan tokens: %v\n", err)
+			http.Error(w, "Error syncing plan tokens: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	log.Println("Successfully created branch")
 }
 
@@ -167,7 +221,7 @@ func DeleteBranchHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("planId: ", planId)
 
-	if authorizePlan(w, planId, auth) == nil {
+	if authorizePlanWrite(w, planId, auth) == nil {
 		return
 	}
 
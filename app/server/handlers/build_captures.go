@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+
+	"github.com/gorilla/mux"
+)
+
+func ListBuildCapturesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListBuildCapturesHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	planId := mux.Vars(r)["planId"]
+	log.Println("planId: ", planId)
+
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	captures, err := db.ListBuildCaptures(auth.OrgId, planId, r.URL.Query().Get("path"))
+
+	if err != nil {
+		log.Printf("Error listing build captures: %v\n", err)
+		http.Error(w, "Error listing build captures: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(captures)
+
+	if err != nil {
+		log.Printf("Error marshalling build captures: %v\n", err)
+		http.Error(w, "Error marshalling build captures: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully listed build captures")
+}
+
+func GetBuildCaptureHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetBuildCaptureHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	captureId := vars["captureId"]
+	log.Println("planId: ", planId, "captureId: ", captureId)
+
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	capture, err := db.GetBuildCapture(auth.OrgId, planId, captureId)
+
+	if err != nil {
+		log.Printf("Error getting build capture: %v\n", err)
+		http.Error(w, "Error getting build capture: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if capture == nil {
+		log.Println("Build capture not found")
+		http.Error(w, "Build capture not found", http.StatusNotFound)
+		return
+	}
+
+	bytes, err := json.Marshal(capture)
+
+	if err != nil {
+		log.Printf("Error marshalling build capture: %v\n", err)
+		http.Error(w, "Error marshalling build capture: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved build capture")
+}
+
+func DeleteBuildCapturesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for DeleteBuildCapturesHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	planId := mux.Vars(r)["planId"]
+	log.Println("planId: ", planId)
+
+	if authorizePlanExecUpdate(w, planId, auth) == nil {
+		return
+	}
+
+	numDeleted, err := db.DeleteBuildCaptures(auth.OrgId, planId)
+
+	if err != nil {
+		log.Printf("Error deleting build captures: %v\n", err)
+		http.Error(w, "Error deleting build captures: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Successfully deleted %d build captures\n", numDeleted)
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"plandex-server/db"
+	"plandex-server/host"
+	"plandex-server/model"
+	modelPlan "plandex-server/model/plan"
+	"plandex-server/types"
+	"strconv"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const defaultBuildWorkerConcurrency = 4
+
+const buildJobPollInterval = 500 * time.Millisecond
+
+// how often the api checks whether a worker has started a queued job
+const buildJobWaitInterval = 100 * time.Millisecond
+
+// how long the api waits for a worker to claim a job before giving up on it
+const buildJobClaimTimeout = 30 * time.Second
+
+const buildJobCleanupInterval = time.Hour
+
+// StartBuildWorker claims queued build jobs and runs them. Each job's builds run in this process, so requests for the plan's stream are forwarded here while they're running. Concurrency is set with PLANDEX_BUILD_WORKER_CONCURRENCY.
+func StartBuildWorker() error {
+	concurrency := defaultBuildWorkerConcurrency
+	if s := os.Getenv("PLANDEX_BUILD_WORKER_CONCURRENCY"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid PLANDEX_BUILD_WORKER_CONCURRENCY %q -- must be a positive integer", s)
+		}
+		concurrency = n
+	}
+
+	err := db.FailInterruptedBuildJobs(host.Ip)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < concurrency; i++ {
+		go runBuildWorkerLoop()
+	}
+
+	go func() {
+		for {
+			err := db.DeleteOldBuildJobs()
+			if err != nil {
+				log.Printf("Error deleting old build jobs: %v\n", err)
+			}
+			time.Sleep(buildJobCleanupInterval)
+		}
+	}()
+
+	log.Printf("Started build worker with concurrency %d\n", concurrency)
+
+	return nil
+}
+
+func runBuildWorkerLoop() {
+	for {
+		// stop taking new jobs on shutdown so the jobs already running can finish
+		if shuttingDown.Load() {
+			return
+		}
+
+		job, err := db.ClaimBuildJob(host.Ip)
+		if err != nil {
+			log.Printf("Error claiming build job: %v\n", err)
+		}
+
+		if job == nil {
+			time.Sleep(buildJobPollInterval)
+			continue
+		}
+
+		log.Printf("Claimed build job %s for plan %s\n", job.Id, job.PlanId)
+
+		numBuilds, err := runBuildJob(job)
+		errMsg := ""
+		if err != nil {
+			log.Printf("Error running build job %s: %v\n", job.Id, err)
+			errMsg = err.Error()
+		}
+
+		err = db.SetBuildJobFinished(job.Id, numBuilds, errMsg)
+		if err != nil {
+			log.Printf("Error setting build job %s finished: %v\n", job.Id, err)
+		}
+	}
+}
+
+// runBuildJob starts a job's builds and waits for them to finish. The api authorized the request before queueing it.
+func runBuildJob(job *db.BuildJob) (int, error) {
+	request, err := db.GetBuildJobRequest(job)
+	if err != nil {
+		return 0, err
+	}
+
+	var req shared.BuildPlanRequest
+	err = json.Unmarshal([]byte(request), &req)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing build request: %v", err)
+	}
+
+	plan, err := db.GetPlan(job.PlanId)
+	if err != nil {
+		return 0, fmt.Errorf("error getting plan: %v", err)
+	}
+	if plan == nil {
+		return 0, fmt.Errorf("plan not found")
+	}
+
+	user, err := db.GetUser(job.UserId)
+	if err != nil {
+		return 0, fmt.Errorf("error getting user: %v", err)
+	}
+
+	auth := &types.ServerAuth{
+		User:  user,
+		OrgId: job.OrgId,
+	}
+
+	clients, _, err := getClients(initClientsParams{
+		apiKey:      req.ApiKey,
+		apiKeys:     req.ApiKeys,
+		endpoint:    req.Endpoint,
+		openAIBase:  req.OpenAIBase,
+		openAIOrgId: req.OpenAIOrgId,
+		plan:        plan,
+		user:        user,
+
+		requireApiKey: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	numBuilds, err := modelPlan.Build(clients, plan, job.Branch, auth, model.CallPriorityForStream(req.ConnectStream))
+	if err != nil {
+		return 0, fmt.Errorf("error building plan: %v", err)
+	}
+
+	if numBuilds == 0 {
+		return 0, nil
+	}
+
+	err = db.SetBuildJobRunning(job.Id, numBuilds)
+	if err != nil {
+		log.Printf("Error setting build job %s running: %v\n", job.Id, err)
+	}
+
+	active := modelPlan.GetActivePlan(job.PlanId, job.Branch)
+	if active != nil {
+		<-active.Ctx.Done()
+	}
+
+	return numBuilds, nil
+}
+
+// queueBuild is BuildPlanHandler on an api instance. It queues the build for a worker, waits for a worker to start it, and then forwards the stream from the worker if the client asked to connect.
+func queueBuild(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth, planId, branch string, body []byte, connectStream bool) {
+	job := &db.BuildJob{
+		OrgId:  auth.OrgId,
+		UserId: auth.User.Id,
+		PlanId: planId,
+		Branch: branch,
+	}
+
+	err := db.EnqueueBuildJob(job, string(body))
+	if err != nil {
+		log.Printf("Error enqueueing build job: %v\n", err)
+		http.Error(w, "Error enqueueing build job: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Queued build job %s for plan %s\n", job.Id, planId)
+
+	deadline := time.Now().Add(buildJobClaimTimeout)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Printf("Build request canceled while waiting for job %s\n", job.Id)
+			_, err = db.CancelQueuedBuildJob(job.Id)
+			if err != nil {
+				log.Printf("Error canceling build job %s: %v\n", job.Id, err)
+			}
+			return
+		case <-time.After(buildJobWaitInterval):
+		}
+
+		job, err = db.GetBuildJob(job.Id)
+		if err != nil {
+			log.Printf("Error getting build job: %v\n", err)
+			http.Error(w, "Error getting build job: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		switch job.Status {
+		case db.BuildJobStatusFailed:
+			log.Printf("Build job %s failed: %s\n", job.Id, job.Error)
+			http.Error(w, "Error building plan: "+job.Error, http.StatusInternalServerError)
+			return
+
+		case db.BuildJobStatusRunning, db.BuildJobStatusFinished:
+			if job.NumBuilds == 0 {
+				log.Println("No builds were executed")
+				http.Error(w, shared.NoBuildsErr, http.StatusNotFound)
+				return
+			}
+
+			if connectStream && job.Status == db.BuildJobStatusRunning && job.WorkerIp != nil {
+				proxyUrl := fmt.Sprintf("http://%s:%s/plans/%s/%s/connect?proxy=true", *job.WorkerIp, os.Getenv("PORT"), planId, branch)
+				log.Printf("Forwarding build stream from %s\n", proxyUrl)
+				proxyStreamRequest(w, r, proxyUrl)
+			}
+
+			log.Printf("Build job %s started\n", job.Id)
+			return
+
+		case db.BuildJobStatusCanceled:
+			http.Error(w, "Build was canceled", http.StatusServiceUnavailable)
+			return
+
+		case db.BuildJobStatusQueued:
+			if time.Now().After(deadline) {
+				canceled, err := db.CancelQueuedBuildJob(job.Id)
+				if err != nil {
+					log.Printf("Error canceling build job %s: %v\n", job.Id, err)
+				}
+				if canceled || err != nil {
+					log.Printf("No build worker claimed job %s\n", job.Id)
+					http.Error(w, "No build worker available", http.StatusServiceUnavailable)
+					return
+				}
+				// a worker claimed it just as it timed out, so keep waiting for it to start
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"plandex-server/db"
+	"plandex-server/host"
+	modelPlan "plandex-server/model/plan"
+
+	"github.com/gorilla/mux"
+)
+
+func ListBuildsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListBuildsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	isProxy := r.URL.Query().Get("proxy") == "true"
+
+	// queued and running builds are only known to the host the plan is active on
+	if modelPlan.GetActivePlan(planId, branch) == nil && !isProxy {
+		modelStream, err := db.GetActiveModelStream(planId, branch)
+		if err != nil {
+			log.Printf("Error getting active model stream: %v\n", err)
+			http.Error(w, "Error getting active model stream: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if modelStream != nil && modelStream.InternalIp != host.Ip {
+			proxyUrl := fmt.Sprintf("http://%s:%s/plans/%s/%s/builds?proxy=true", modelStream.InternalIp, os.Getenv("PORT"), planId, branch)
+			log.Printf("Forwarding request to %s\n", proxyUrl)
+			proxyRequest(w, r, proxyUrl)
+			return
+		}
+	}
+
+	builds, err := modelPlan.ListBuilds(auth.OrgId, planId, branch)
+
+	if err != nil {
+		log.Printf("Error listing builds: %v\n", err)
+		http.Error(w, "Error listing builds: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(builds)
+
+	if err != nil {
+		log.Printf("Error marshalling builds: %v\n", err)
+		http.Error(w, "Error marshalling builds: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully listed builds")
+}
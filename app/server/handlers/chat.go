@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	modelPlan "plandex-server/model/plan"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// ChatHandler replies to a chat started with 'plandex chat'. Chats aren't tied to a plan and nothing about them is stored, other than the model usage.
+func ChatHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ChatHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody shared.ChatRequest
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	numMessages := len(requestBody.Messages)
+	if numMessages == 0 || requestBody.Messages[numMessages-1].Role != shared.ChatMessageRoleUser {
+		log.Println("Chat request doesn't end with a user message")
+		http.Error(w, "Chat must end with a user message", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := db.GetOrgDefaultSettings(auth.OrgId, true)
+	if err != nil {
+		log.Printf("Error getting org default settings: %v\n", err)
+		http.Error(w, "Error getting org default settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if settings.ModelPack == nil {
+		settings.ModelPack = shared.DefaultModelPack
+	}
+
+	config := settings.ModelPack.GetChat()
+
+	clients := initClients(initClientsParams{
+		w:           w,
+		apiKeys:     requestBody.ApiKeys,
+		openAIBase:  requestBody.OpenAIBase,
+		openAIOrgId: requestBody.OpenAIOrgId,
+		orgId:       auth.OrgId,
+		user:        auth.User,
+		settings:    settings,
+	})
+	if clients == nil {
+		return
+	}
+
+	err = streamReply(w, func(onChunk func(string) error) error {
+		_, err := modelPlan.StreamChat(r.Context(), clients, config, auth.OrgId, auth.User.Id, requestBody, onChunk)
+		return err
+	})
+
+	if err != nil {
+		log.Printf("Error streaming chat: %v\n", err)
+		return
+	}
+
+	log.Println("Successfully streamed chat reply")
+}
@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"plandex-server/db"
 	"plandex-server/model"
+	modelPlan "plandex-server/model/plan"
+	"strings"
 
+	"github.com/plandex/plandex/shared"
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -17,10 +22,66 @@ type initClientsParams struct {
 	openAIBase  string
 	openAIOrgId string
 	plan        *db.Plan
+
+	// the user the clients are for. Every model call goes through here, so this is where a guest's trial token limit is enforced.
+	user *db.User
+
+	// for requests that aren't tied to a plan, like chats, the org and settings to use instead of the plan's
+	orgId    string
+	settings *shared.PlanSettings
+
+	// when set, responds with an error unless the client or the org supplies at least one api key
+	requireApiKey bool
+
+	// role configs outside the plan's model pack that the clients will be used with, like the builders compared by a benchmark
+	extraRoleConfigs []shared.ModelRoleConfig
 }
 
 func initClients(params initClientsParams) map[string]*openai.Client {
-	w := params.w
+	clients, status, err := getClients(params)
+	if err != nil {
+		writeClientsError(params.w, status, err)
+		return nil
+	}
+	return clients
+}
+
+// writeClientsError responds with an error from getting clients. A guest's trial limit is sent as an api error so the client can show it.
+func writeClientsError(w http.ResponseWriter, status int, err error) {
+	var trialErr *trialLimitError
+	if errors.As(err, &trialErr) {
+		writeApiError(w, *trialErr.apiErr)
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// trialLimitError is returned by getClients when a guest has used up the org's trial tokens, so that initClients can respond with the api error the client knows how to show
+type trialLimitError struct {
+	apiErr *shared.ApiError
+}
+
+func (e *trialLimitError) Error() string {
+	return e.apiErr.Msg
+}
+
+// checkGuestTrialTokens returns a trialLimitError if the user is a guest who has used up the org's trial tokens
+func checkGuestTrialTokens(orgId string, user *db.User) (int, error) {
+	trialErr, err := modelPlan.GetGuestTrialTokensError(orgId, user)
+	if err != nil {
+		log.Printf("Error checking trial limits: %v\n", err)
+		return http.StatusInternalServerError, fmt.Errorf("Error checking trial limits: %v", err)
+	}
+
+	if trialErr != nil {
+		return trialErr.Status, &trialLimitError{apiErr: trialErr}
+	}
+
+	return http.StatusOK, nil
+}
+
+// getClients is initClients without writing the error to a response, for callers outside of a request like the build worker. On failure it returns the http status the error corresponds to.
+func getClients(params initClientsParams) (map[string]*openai.Client, int, error) {
 	apiKey := params.apiKey
 	apiKeys := params.apiKeys
 	openAIOrgId := params.openAIOrgId
@@ -34,13 +95,109 @@ func initClients(params initClientsParams) map[string]*openai.Client {
 		apiKeys = map[string]string{"OPENAI_API_KEY": apiKey}
 	}
 
-	planSettings, err := db.GetPlanSettings(plan, true)
+	orgId := params.orgId
+	if plan != nil {
+		orgId = plan.OrgId
+	}
+
+	status, err := checkGuestTrialTokens(orgId, params.user)
 	if err != nil {
-		log.Printf("Error getting plan settings: %v\n", err)
-		http.Error(w, "Error getting plan settings", http.StatusInternalServerError)
-		return nil
+		return nil, status, err
+	}
+
+	orgApiKeys, err := db.GetOrgApiKeys(orgId)
+	if err != nil {
+		log.Printf("Error getting org api keys: %v\n", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error getting org api keys: %v", err)
+	}
+
+	apiKeys, orgEndpoints := mergeOrgApiKeys(apiKeys, orgApiKeys)
+
+	if _, ok := orgEndpoints[shared.OpenAIEnvVar]; ok {
+		// the client's OpenAI org id belongs to a different account
+		openAIOrgId = orgApiKeys["OPENAI_ORG_ID"]
+	}
+
+	planSettings := params.settings
+	if planSettings == nil {
+		planSettings, err = db.GetPlanSettings(plan, true)
+		if err != nil {
+			log.Printf("Error getting plan settings: %v\n", err)
+			return nil, http.StatusInternalServerError, fmt.Errorf("Error getting plan settings")
+		}
+	}
+
+	usesMockProvider := planSettings.UsesMockProvider()
+	for _, config := range params.extraRoleConfigs {
+		if config.BaseModelConfig.Provider == shared.ModelProviderMock {
+			usesMockProvider = true
+		}
+	}
+
+	if usesMockProvider {
+		if !model.MockProviderEnabled() {
+			log.Println("Mock provider isn't enabled")
+			return nil, http.StatusBadRequest, fmt.Errorf("The mock model provider isn't enabled on this server")
+		}
+
+		// the mock provider runs in process, so it only needs a placeholder key
+		withMock := map[string]string{shared.MockApiKeyEnvVar: "mock"}
+		for envVar, key := range apiKeys {
+			withMock[envVar] = key
+		}
+		apiKeys = withMock
+	}
+
+	if params.requireApiKey {
+		hasApiKey := false
+		for _, key := range apiKeys {
+			if key != "" {
+				hasApiKey = true
+				break
+			}
+		}
+
+		if !hasApiKey {
+			log.Println("API key is required")
+			return nil, http.StatusBadRequest, fmt.Errorf("API key is required")
+		}
+	}
+
+	endpointsByApiKeyEnvVar, endpoint := getClientEndpoints(apiKeys, orgEndpoints, planSettings, params.extraRoleConfigs, endpoint)
+
+	clients := model.InitClients(apiKeys, endpointsByApiKeyEnvVar, endpoint, openAIOrgId)
+
+	return clients, http.StatusOK, nil
+}
+
+// mergeOrgApiKeys merges the org's api keys over the client's, so that usage is billed to the org, and returns the endpoint each org key that's used must be sent to. An org key without an allowed endpoint isn't used, and the client's own key for it is kept instead.
+func mergeOrgApiKeys(apiKeys, orgApiKeys map[string]string) (map[string]string, map[string]string) {
+	merged := map[string]string{}
+	for envVar, key := range apiKeys {
+		merged[envVar] = key
+	}
+
+	orgEndpoints := map[string]string{}
+	for envVar, key := range orgApiKeys {
+		if envVar == "OPENAI_ORG_ID" || strings.HasSuffix(envVar, "_BASE_URL") {
+			continue
+		}
+
+		orgEndpoint := db.GetOrgKeyEndpoint(envVar, orgApiKeys)
+		if orgEndpoint == "" {
+			log.Printf("Not using org credential %s, since it has no default endpoint and %s isn't set\n", envVar, db.OrgCredentialBaseUrlName(envVar))
+			continue
+		}
+
+		merged[envVar] = key
+		orgEndpoints[envVar] = orgEndpoint
 	}
 
+	return merged, orgEndpoints
+}
+
+// getClientEndpoints returns the endpoint for each api key, from the model configs that use it, and the endpoint for the OpenAI key. Org keys always go to their own endpoints, ignoring any base url from the client or the model pack.
+func getClientEndpoints(apiKeys, orgEndpoints map[string]string, planSettings *shared.PlanSettings, extraRoleConfigs []shared.ModelRoleConfig, endpoint string) (map[string]string, string) {
 	endpointsByApiKeyEnvVar := map[string]string{}
 	for envVar := range apiKeys {
 		if planSettings.ModelPack.Planner.BaseModelConfig.ApiKeyEnvVar == envVar {
@@ -82,9 +239,32 @@ func initClients(params initClientsParams) map[string]*openai.Client {
 			endpointsByApiKeyEnvVar[envVar] = planSettings.ModelPack.GetAutoFix().BaseModelConfig.BaseUrl
 			continue
 		}
+
+		if planSettings.ModelPack.GetDraftBuilder().BaseModelConfig.ApiKeyEnvVar == envVar {
+			endpointsByApiKeyEnvVar[envVar] = planSettings.ModelPack.GetDraftBuilder().BaseModelConfig.BaseUrl
+			continue
+		}
+
+		if planSettings.ModelPack.GetChat().BaseModelConfig.ApiKeyEnvVar == envVar {
+			endpointsByApiKeyEnvVar[envVar] = planSettings.ModelPack.GetChat().BaseModelConfig.BaseUrl
+			continue
+		}
 	}
 
-	clients := model.InitClients(apiKeys, endpointsByApiKeyEnvVar, endpoint, openAIOrgId)
+	for _, config := range extraRoleConfigs {
+		envVar := config.BaseModelConfig.ApiKeyEnvVar
+		if _, ok := endpointsByApiKeyEnvVar[envVar]; !ok {
+			endpointsByApiKeyEnvVar[envVar] = config.BaseModelConfig.BaseUrl
+		}
+	}
 
-	return clients
+	for envVar, orgEndpoint := range orgEndpoints {
+		endpointsByApiKeyEnvVar[envVar] = orgEndpoint
+	}
+
+	if orgEndpoint, ok := orgEndpoints[shared.OpenAIEnvVar]; ok {
+		endpoint = orgEndpoint
+	}
+
+	return endpointsByApiKeyEnvVar, endpoint
 }
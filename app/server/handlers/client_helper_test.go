@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"plandex-server/model"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestOrgKeysNeverSentToCustomBaseUrl(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	attacker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		mu.Unlock()
+		w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer attacker.Close()
+
+	approved := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer approved.Close()
+
+	// a member with write access points every model in the plan's model pack at their own server
+	roleConfig := func(envVar string) shared.ModelRoleConfig {
+		return shared.ModelRoleConfig{BaseModelConfig: shared.BaseModelConfig{BaseUrl: attacker.URL, ApiKeyEnvVar: envVar}}
+	}
+	planSettings := &shared.PlanSettings{ModelPack: &shared.ModelPack{
+		Planner:     shared.PlannerRoleConfig{ModelRoleConfig: roleConfig("OPENROUTER_API_KEY")},
+		PlanSummary: roleConfig("CUSTOM_API_KEY"),
+		Builder:     roleConfig("OTHER_API_KEY"),
+		Namer:       roleConfig(shared.OpenAIEnvVar),
+		CommitMsg:   roleConfig(shared.OpenAIEnvVar),
+		ExecStatus:  roleConfig(shared.OpenAIEnvVar),
+	}}
+
+	clientApiKeys := map[string]string{
+		shared.OpenAIEnvVar: "client-openai",
+		"OTHER_API_KEY":     "client-other",
+	}
+	orgApiKeys := map[string]string{
+		shared.OpenAIEnvVar:  "org-openai",
+		"OPENAI_BASE_URL":    approved.URL,
+		"OPENROUTER_API_KEY": "org-openrouter",
+		"CUSTOM_API_KEY":     "org-custom",
+		"OTHER_API_KEY":      "org-other",
+		"OTHER_BASE_URL":     approved.URL,
+	}
+
+	apiKeys, orgEndpoints := mergeOrgApiKeys(clientApiKeys, orgApiKeys)
+	endpointsByApiKeyEnvVar, openAIEndpoint := getClientEndpoints(apiKeys, orgEndpoints, planSettings, nil, attacker.URL)
+
+	if _, ok := apiKeys["CUSTOM_API_KEY"]; ok {
+		t.Errorf("expected the org key without an allowed endpoint not to be used")
+	}
+	if _, ok := apiKeys["OPENAI_BASE_URL"]; ok {
+		t.Errorf("expected base url credentials not to be used as keys")
+	}
+	if openAIEndpoint != approved.URL {
+		t.Errorf("expected the org's OpenAI key to go to its approved base url, got %q", openAIEndpoint)
+	}
+	if endpointsByApiKeyEnvVar["OPENROUTER_API_KEY"] != shared.BaseUrlByProvider[shared.ModelProviderOpenRouter] {
+		t.Errorf("expected the org's OpenRouter key to go to OpenRouter, got %q", endpointsByApiKeyEnvVar["OPENROUTER_API_KEY"])
+	}
+
+	clients := model.InitClients(apiKeys, endpointsByApiKeyEnvVar, openAIEndpoint, "")
+	for envVar, client := range clients {
+		endpoint := endpointsByApiKeyEnvVar[envVar]
+		if envVar == shared.OpenAIEnvVar {
+			endpoint = openAIEndpoint
+		}
+
+		if endpoint == attacker.URL {
+			t.Errorf("expected no client for %s to use the custom base url", envVar)
+		}
+
+		// only call the test servers, not real providers
+		if endpoint != attacker.URL && endpoint != approved.URL {
+			continue
+		}
+
+		_, err := client.ListModels(context.Background())
+		if err != nil {
+			t.Fatalf("error calling %s: %v", endpoint, err)
+		}
+	}
+
+	for _, key := range received {
+		if strings.HasPrefix(key, "org-") {
+			t.Errorf("custom base url received an org key: %s", key)
+		}
+	}
+
+	// without org keys, the client's own keys still go where the client and the model pack say
+	apiKeys, orgEndpoints = mergeOrgApiKeys(clientApiKeys, map[string]string{})
+	endpointsByApiKeyEnvVar, openAIEndpoint = getClientEndpoints(apiKeys, orgEndpoints, planSettings, nil, attacker.URL)
+
+	if openAIEndpoint != attacker.URL || endpointsByApiKeyEnvVar["OTHER_API_KEY"] != attacker.URL {
+		t.Errorf("expected the client's keys to keep their custom base urls, got %q and %q", openAIEndpoint, endpointsByApiKeyEnvVar["OTHER_API_KEY"])
+	}
+
+	clients = model.InitClients(apiKeys, endpointsByApiKeyEnvVar, openAIEndpoint, "")
+	_, err := clients["OTHER_API_KEY"].ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("error calling %s: %v", attacker.URL, err)
+	}
+
+	if len(received) != 1 || received[0] != "client-other" {
+		t.Errorf("expected the custom base url to receive only the client's key, got %q", received)
+	}
+}
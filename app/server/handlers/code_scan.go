@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	modelPlan "plandex-server/model/plan"
+	"plandex-server/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetOrgCodeScanPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetOrgCodeScanPolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	policy, err := db.GetOrgCodeScanPolicy(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting code scan policy: %v\n", err)
+		http.Error(w, "Error getting code scan policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.GetOrgCodeScanPolicyResponse{
+		Policy:         policy,
+		NumCorpusFiles: modelPlan.NumCodeScanCorpusFiles(),
+	})
+
+	if err != nil {
+		log.Printf("Error marshalling code scan policy: %v\n", err)
+		http.Error(w, "Error marshalling code scan policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved code scan policy")
+}
+
+func UpdateOrgCodeScanPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateOrgCodeScanPolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	// the policy flags results on every plan in the org
+	if !auth.HasPermission(types.PermissionUpdateAnyPlan) {
+		log.Println("User doesn't have permission to update code scan policy")
+		http.Error(w, "User doesn't have permission to update code scan policy", http.StatusForbidden)
+		return
+	}
+
+	var req shared.UpdateOrgCodeScanPolicyRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Policy == nil {
+		log.Println("Missing code scan policy")
+		http.Error(w, "Missing code scan policy", http.StatusBadRequest)
+		return
+	}
+
+	err = req.Policy.Validate()
+
+	if err != nil {
+		log.Printf("Invalid code scan policy: %v\n", err)
+		http.Error(w, "Invalid code scan policy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = db.StoreOrgCodeScanPolicy(auth.OrgId, req.Policy)
+
+	if err != nil {
+		log.Printf("Error storing code scan policy: %v\n", err)
+		http.Error(w, "Error storing code scan policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully updated code scan policy")
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/types"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func ListOrgContextBundlesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListOrgContextBundlesHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	bundles, err := db.ListOrgContextBundles(auth.OrgId, false)
+
+	if err != nil {
+		log.Printf("Error getting context bundles: %v\n", err)
+		http.Error(w, "Error getting context bundles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(bundles)
+
+	if err != nil {
+		log.Printf("Error marshalling context bundles: %v\n", err)
+		http.Error(w, "Error marshalling context bundles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved context bundles")
+}
+
+func UpdateOrgContextBundleHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateOrgContextBundleHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	// bundles are attached to every new plan in the org
+	if !auth.HasPermission(types.PermissionUpdateAnyPlan) {
+		log.Println("User doesn't have permission to update context bundles")
+		http.Error(w, "User doesn't have permission to update context bundles", http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	// bundles can be as large as a context load, so they're read the same way
+	body, ok := readContextRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req shared.UpdateOrgContextBundleRequest
+	err := json.Unmarshal(body, &req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Bundle == nil {
+		log.Println("Missing context bundle")
+		http.Error(w, "Missing context bundle", http.StatusBadRequest)
+		return
+	}
+
+	req.Bundle.Name = name
+	err = req.Bundle.Validate()
+
+	if err != nil {
+		log.Printf("Invalid context bundle: %v\n", err)
+		http.Error(w, "Invalid context bundle: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = db.StoreOrgContextBundle(auth.OrgId, req.Bundle)
+
+	if err != nil {
+		log.Printf("Error storing context bundle: %v\n", err)
+		http.Error(w, "Error storing context bundle: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, file := range req.Bundle.Files {
+		file.Body = ""
+	}
+
+	bytes, err := json.Marshal(req.Bundle)
+
+	if err != nil {
+		log.Printf("Error marshalling context bundle: %v\n", err)
+		http.Error(w, "Error marshalling context bundle: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Printf("Successfully updated context bundle %s to version %d\n", name, req.Bundle.Version)
+}
+
+func DeleteOrgContextBundleHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for DeleteOrgContextBundleHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if !auth.HasPermission(types.PermissionUpdateAnyPlan) {
+		log.Println("User doesn't have permission to delete context bundles")
+		http.Error(w, "User doesn't have permission to delete context bundles", http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	found, err := db.DeleteOrgContextBundle(auth.OrgId, name)
+
+	if err != nil {
+		log.Printf("Error deleting context bundle: %v\n", err)
+		http.Error(w, "Error deleting context bundle: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !found {
+		log.Printf("Context bundle %s not found\n", name)
+		http.Error(w, fmt.Sprintf("Context bundle %s not found", name), http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Successfully deleted context bundle %s\n", name)
+}
+
+func SyncContextBundlesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for SyncContextBundlesHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branchName := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branchName)
+
+	plan := authorizePlanWrite(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	var req shared.SyncContextBundlesRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	res, err := db.SyncPlanContextBundles(db.SyncPlanContextBundlesParams{
+		OrgId:      auth.OrgId,
+		UserId:     auth.User.Id,
+		Plan:       plan,
+		BranchName: branchName,
+		Attach:     req.Attach,
+	})
+
+	if err != nil {
+		log.Printf("Error syncing context bundles: %v\n", err)
+		http.Error(w, "Error syncing context bundles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Printf("Successfully synced context bundles: %v\n", res.Synced)
+}
+
+// attachContextBundles attaches the org's context bundles to a new plan. A plan that can't have its bundles attached, like when they're too large for its context limit, is still created, so errors are logged and the attached bundles are returned.
+func attachContextBundles(auth *types.ServerAuth, plan *db.Plan) []string {
+	bundles, err := db.ListOrgContextBundles(auth.OrgId, false)
+	if err != nil {
+		log.Printf("Error getting context bundles: %v\n", err)
+		return nil
+	}
+
+	if len(bundles) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, bundle := range bundles {
+		names = append(names, bundle.Name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repoLockId, err := db.LockRepo(db.LockRepoParams{
+		OrgId:    auth.OrgId,
+		UserId:   auth.User.Id,
+		PlanId:   plan.Id,
+		Branch:   "main",
+		Scope:    db.LockScopeWrite,
+		Ctx:      ctx,
+		CancelFn: cancel,
+	})
+	if err != nil {
+		log.Printf("Error locking repo: %v\n", err)
+		return nil
+	}
+
+	res, err := db.SyncPlanContextBundles(db.SyncPlanContextBundlesParams{
+		OrgId:      auth.OrgId,
+		UserId:     auth.User.Id,
+		Plan:       plan,
+		BranchName: "main",
+		Attach:     names,
+	})
+
+	if err != nil {
+		log.Printf("Error attaching context bundles: %v\n", err)
+
+		rbErr := RollbackRepoIfErr(auth.OrgId, plan.Id, err)
+		if rbErr != nil {
+			log.Printf("Error rolling back repo: %v\n", rbErr)
+		}
+	}
+
+	unlockErr := db.DeleteRepoLock(repoLockId)
+	if unlockErr != nil {
+		log.Printf("Error unlocking repo: %v\n", unlockErr)
+	}
+
+	if err != nil {
+		return nil
+	}
+
+	log.Printf("Attached context bundles %v to plan %s\n", res.Synced, plan.Id)
+
+	return res.Synced
+}
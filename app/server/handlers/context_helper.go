@@ -1,15 +1,18 @@
 package handlers
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"plandex-server/db"
 	"plandex-server/model"
 	"plandex-server/types"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/plandex/plandex/shared"
 	"github.com/sashabaranov/go-openai"
 )
@@ -19,6 +22,13 @@ func loadContexts(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth
 	var settings *shared.PlanSettings
 	var client *openai.Client
 
+	if !validateContextSize(w, len(*loadReq), len(*loadReq), func(i int) (string, int) {
+		context := (*loadReq)[i]
+		return context.Name, len(context.Body)
+	}) {
+		return nil, nil
+	}
+
 	for _, context := range *loadReq {
 		if context.ContextType == shared.ContextPipedDataType || context.ContextType == shared.ContextNoteType || context.ContextType == shared.ContextImageType {
 			settings, err = db.GetPlanSettings(plan, true)
@@ -36,8 +46,12 @@ func loadContexts(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth
 					openAIBase:  context.OpenAIBase,
 					openAIOrgId: context.OpenAIOrgId,
 					plan:        plan,
+					user:        auth.User,
 				},
 			)
+			if clients == nil {
+				return nil, nil
+			}
 
 			envVar := settings.ModelPack.Namer.BaseModelConfig.ApiKeyEnvVar
 			client = clients[envVar]
@@ -57,15 +71,20 @@ func loadContexts(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth
 		}
 	}
 
-	// get name for piped data or notes if present
+	// get name for piped data or notes if present, unless the user named them
+	cacheOwner := model.ResponseCacheOwner{OrgId: auth.OrgId, UserId: auth.User.Id}
 	num := 0
 	errCh := make(chan error, len(*loadReq))
 	for _, context := range *loadReq {
+		if context.Name != "" {
+			continue
+		}
+
 		if context.ContextType == shared.ContextPipedDataType {
 			num++
 
 			go func(context *shared.LoadContextParams) {
-				name, err := model.GenPipedDataName(client, settings.ModelPack.Namer, context.Body)
+				name, err := model.GenPipedDataName(client, settings.ModelPack.Namer, context.Body, cacheOwner)
 
 				if err != nil {
 					errCh <- fmt.Errorf("error generating name for piped data: %v", err)
@@ -78,7 +97,7 @@ func loadContexts(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth
 			num++
 
 			go func(context *shared.LoadContextParams) {
-				name, err := model.GenNoteName(client, settings.ModelPack.Namer, context.Body)
+				name, err := model.GenNoteName(client, settings.ModelPack.Namer, context.Body, cacheOwner)
 
 				if err != nil {
 					errCh <- fmt.Errorf("error generating name for note: %v", err)
@@ -148,3 +167,95 @@ func loadContexts(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth
 
 	return res, dbContexts
 }
+
+// requestEncodings are the Content-Encodings accepted for uploaded context, best first
+const requestEncodings = "zstd, gzip"
+
+// maxZstdWindow caps the memory a zstd frame can make the decoder allocate. It's the default window of common encoders at their usual levels.
+const maxZstdWindow = 8 << 20
+
+// AdvertiseRequestEncodings sets the Accept-Encoding response header (RFC 7694) so clients know which encodings they can compress uploads with
+func AdvertiseRequestEncodings(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Encoding", requestEncodings)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readContextRequestBody reads a context load/update request body, decompressing it if the client sent it with zstd or gzip. The size limit applies to the decompressed body too, so a small compressed payload can't expand past it.
+func readContextRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	defer r.Body.Close()
+
+	var reader io.Reader = http.MaxBytesReader(w, r.Body, maxContextRequestBytes)
+
+	switch r.Header.Get("Content-Encoding") {
+	case "":
+	case "zstd":
+		zr, err := zstd.NewReader(reader, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(maxZstdWindow), zstd.WithDecoderMaxMemory(uint64(maxContextRequestBytes)))
+		if err != nil {
+			log.Printf("Error creating zstd reader: %v\n", err)
+			http.Error(w, "Error decompressing request body: "+err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		defer zr.Close()
+		reader = zr
+	case "gzip":
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			log.Printf("Error creating gzip reader: %v\n", err)
+			http.Error(w, "Error decompressing request body: "+err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		defer gz.Close()
+		reader = gz
+	default:
+		log.Printf("Unsupported content encoding: %s\n", r.Header.Get("Content-Encoding"))
+		http.Error(w, "Unsupported content encoding: "+r.Header.Get("Content-Encoding"), http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxContextRequestBytes+1))
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body: "+err.Error(), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+
+	if int64(len(body)) > maxContextRequestBytes {
+		log.Printf("Request body exceeds limit of %d bytes\n", maxContextRequestBytes)
+		http.Error(w, fmt.Sprintf("Request body exceeds limit of %d bytes", maxContextRequestBytes), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+
+	return body, true
+}
+
+// validateContextSize is a server-side guard so that huge vendored trees or generated files can't be loaded by accident (or by an outdated client that doesn't check limits). Only numNew, the pieces of context the request adds, count toward MaxContextCount, so a plan that has built up more context over several loads can still update all of it.
+func validateContextSize(w http.ResponseWriter, num, numNew int, getNameAndSize func(i int) (string, int)) bool {
+	if numNew > shared.MaxContextCount {
+		log.Printf("Error loading context: %d pieces of context exceeds the limit of %d\n", numNew, shared.MaxContextCount)
+		http.Error(w, fmt.Sprintf("Error loading context: %d pieces of context exceeds the limit of %d per request", numNew, shared.MaxContextCount), http.StatusRequestEntityTooLarge)
+		return false
+	}
+
+	totalSize := 0
+	for i := 0; i < num; i++ {
+		name, size := getNameAndSize(i)
+
+		if size > shared.MaxContextBodySize {
+			log.Printf("Error loading context: %s is %d bytes, which exceeds the limit of %d\n", name, size, shared.MaxContextBodySize)
+			http.Error(w, fmt.Sprintf("Error loading context: %s exceeds the size limit of %d MB", name, shared.MaxContextBodySize/(1024*1024)), http.StatusRequestEntityTooLarge)
+			return false
+		}
+
+		totalSize += size
+	}
+
+	if totalSize > shared.MaxContextTotalSize {
+		log.Printf("Error loading context: total size %d bytes exceeds the limit of %d\n", totalSize, shared.MaxContextTotalSize)
+		http.Error(w, fmt.Sprintf("Error loading context: total size exceeds the limit of %d MB per request", shared.MaxContextTotalSize/(1024*1024)), http.StatusRequestEntityTooLarge)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestReadContextRequestBody(t *testing.T) {
+	payload := []byte(`[{"contextType":"file","file_path":"main.go","body":"package main"}]`)
+
+	compress := func(encoding string, data []byte) []byte {
+		var buf bytes.Buffer
+		var w io.WriteCloser
+		switch encoding {
+		case "gzip":
+			w = gzip.NewWriter(&buf)
+		case "zstd":
+			zw, err := zstd.NewWriter(&buf)
+			if err != nil {
+				t.Fatalf("error creating zstd writer: %v", err)
+			}
+			w = zw
+		}
+		_, err := w.Write(data)
+		if err != nil {
+			t.Fatalf("error compressing: %v", err)
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatalf("error compressing: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name       string
+		encoding   string
+		body       []byte
+		wantStatus int
+	}{
+		{name: "identity", encoding: "", body: payload, wantStatus: http.StatusOK},
+		{name: "gzip", encoding: "gzip", body: compress("gzip", payload), wantStatus: http.StatusOK},
+		{name: "zstd", encoding: "zstd", body: compress("zstd", payload), wantStatus: http.StatusOK},
+		{name: "unsupported", encoding: "br", body: payload, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "zstd expanding past the limit", encoding: "zstd", body: compress("zstd", make([]byte, maxContextRequestBytes+1)), wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/plans/plan/main/context", bytes.NewReader(test.body))
+			if test.encoding != "" {
+				r.Header.Set("Content-Encoding", test.encoding)
+			}
+			w := httptest.NewRecorder()
+
+			body, ok := readContextRequestBody(w, r)
+
+			if test.wantStatus == http.StatusOK {
+				if !ok {
+					t.Fatalf("expected the body to be read, got %d: %s", w.Code, w.Body.String())
+				}
+				if !bytes.Equal(body, payload) {
+					t.Errorf("got body %q, want %q", body, payload)
+				}
+				return
+			}
+
+			if ok {
+				t.Fatalf("expected the body to be rejected")
+			}
+			if w.Code != test.wantStatus {
+				t.Errorf("got status %d, want %d", w.Code, test.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAdvertiseRequestEncodings(t *testing.T) {
+	handler := AdvertiseRequestEncodings(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Unsupported content encoding: br", http.StatusUnsupportedMediaType)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/plans/plan/main/context", nil))
+
+	if got := w.Header().Get("Accept-Encoding"); got != "zstd, gzip" {
+		t.Errorf("got Accept-Encoding %q, want %q", got, "zstd, gzip")
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/types"
+
+	"github.com/gorilla/mux"
+)
+
+func ExportOrgDataHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ExportOrgDataHandler")
+
+	auth := authorizeOrgAdmin(w, r)
+	if auth == nil {
+		return
+	}
+
+	export, err := db.ExportOrgData(auth.OrgId, "", auth.User.Id)
+	if err != nil {
+		log.Printf("Error exporting org data: %v\n", err)
+		http.Error(w, "Error exporting org data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeDataExport(w, export, "org-"+auth.OrgId)
+
+	log.Println("Successfully exported org data")
+}
+
+func HardDeleteOrgHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for HardDeleteOrgHandler")
+
+	auth := authorizeOrgAdmin(w, r)
+	if auth == nil {
+		return
+	}
+
+	err := db.HardDeleteOrg(auth.OrgId)
+	if err != nil {
+		log.Printf("Error deleting org: %v\n", err)
+		http.Error(w, "Error deleting org: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully deleted org", auth.OrgId)
+}
+
+func ExportUserDataHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ExportUserDataHandler")
+
+	auth, userId := authorizeUserAdmin(w, r, false)
+	if auth == nil {
+		return
+	}
+
+	export, err := db.ExportOrgData(auth.OrgId, userId, auth.User.Id)
+	if err != nil {
+		log.Printf("Error exporting user data: %v\n", err)
+		http.Error(w, "Error exporting user data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeDataExport(w, export, "user-"+userId)
+
+	log.Println("Successfully exported user data")
+}
+
+func HardDeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for HardDeleteUserHandler")
+
+	auth, userId := authorizeUserAdmin(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	err := db.HardDeleteUser(userId)
+	if err != nil {
+		log.Printf("Error deleting user: %v\n", err)
+		http.Error(w, "Error deleting user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully deleted user", userId)
+}
+
+// authorizeOrgAdmin ensures the org in the path is the current org and the user is allowed to delete it (org owners)
+func authorizeOrgAdmin(w http.ResponseWriter, r *http.Request) *types.ServerAuth {
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return nil
+	}
+
+	orgId := mux.Vars(r)["orgId"]
+
+	if orgId != auth.OrgId {
+		log.Printf("Org %s doesn't match current org %s\n", orgId, auth.OrgId)
+		http.Error(w, "Org doesn't match current org", http.StatusForbidden)
+		return nil
+	}
+
+	if !auth.HasPermission(types.PermissionDeleteOrg) {
+		log.Println("User doesn't have permission to manage org data")
+		http.Error(w, "User doesn't have permission to manage org data", http.StatusForbidden)
+		return nil
+	}
+
+	return auth
+}
+
+// authorizeUserAdmin allows users to manage their own data, and org owners to manage data for members of their org. Deleting a user who also belongs to other orgs requires the user themselves.
+func authorizeUserAdmin(w http.ResponseWriter, r *http.Request, isDelete bool) (*types.ServerAuth, string) {
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return nil, ""
+	}
+
+	userId := mux.Vars(r)["userId"]
+
+	if userId == auth.User.Id {
+		return auth, userId
+	}
+
+	if !auth.HasPermission(types.PermissionDeleteOrg) {
+		log.Println("User doesn't have permission to manage other users' data")
+		http.Error(w, "User doesn't have permission to manage other users' data", http.StatusForbidden)
+		return nil, ""
+	}
+
+	isMember, err := db.ValidateOrgMembership(userId, auth.OrgId)
+	if err != nil {
+		log.Printf("Error validating org membership: %v\n", err)
+		http.Error(w, "Error validating org membership: "+err.Error(), http.StatusInternalServerError)
+		return nil, ""
+	}
+
+	if !isMember {
+		log.Printf("User %s is not a member of org %s\n", userId, auth.OrgId)
+		http.Error(w, "User "+userId+" is not a member of org "+auth.OrgId, http.StatusForbidden)
+		return nil, ""
+	}
+
+	if isDelete {
+		var numOrgs int
+		err = db.Conn.Get(&numOrgs, "SELECT COUNT(*) FROM orgs_users WHERE user_id = $1", userId)
+		if err != nil {
+			log.Printf("Error counting user orgs: %v\n", err)
+			http.Error(w, "Error counting user orgs: "+err.Error(), http.StatusInternalServerError)
+			return nil, ""
+		}
+
+		if numOrgs > 1 {
+			log.Printf("User %s belongs to other orgs\n", userId)
+			http.Error(w, "User belongs to other orgs and can only be deleted by themselves", http.StatusForbidden)
+			return nil, ""
+		}
+	}
+
+	return auth, userId
+}
+
+func writeDataExport(w http.ResponseWriter, export interface{}, name string) {
+	bytes, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling export: %v\n", err)
+		http.Error(w, "Error marshalling export: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"plandex-export-%s.json\"", name))
+	w.Write(bytes)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"plandex-server/db"
+	"plandex-server/email"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// GetUserDigestSettingsHandler returns how often the current user gets an email digest of plan activity in their orgs
+func GetUserDigestSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetUserDigestSettingsHandler")
+
+	auth := authenticate(w, r, false)
+	if auth == nil {
+		return
+	}
+
+	settings, err := db.GetUserDigestSettings(auth.User.Id)
+
+	if err != nil {
+		log.Printf("Error getting digest settings: %v\n", err)
+		http.Error(w, "Error getting digest settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.GetUserDigestSettingsResponse{
+		Settings:     settings,
+		CanSendEmail: os.Getenv("GOENV") != "production" || email.CanSend(),
+	})
+
+	if err != nil {
+		log.Printf("Error marshalling digest settings: %v\n", err)
+		http.Error(w, "Error marshalling digest settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved digest settings")
+}
+
+func UpdateUserDigestSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateUserDigestSettingsHandler")
+
+	auth := authenticate(w, r, false)
+	if auth == nil {
+		return
+	}
+
+	var req shared.UpdateUserDigestSettingsRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = req.Frequency.Validate()
+
+	if err != nil {
+		log.Printf("Invalid digest frequency: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if auth.User.IsTrial && req.Frequency != shared.DigestFrequencyOff {
+		log.Println("Trial user can't get digests")
+		http.Error(w, "Trial users can't get digests -- sign up with an email address first", http.StatusForbidden)
+		return
+	}
+
+	err = db.StoreUserDigestSettings(auth.User.Id, req.Frequency)
+
+	if err != nil {
+		log.Printf("Error storing digest settings: %v\n", err)
+		http.Error(w, "Error storing digest settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully updated digest settings")
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/model/plan"
+	"plandex-server/storage"
+	"sync/atomic"
+	"time"
+)
+
+const readinessCheckTimeout = 5 * time.Second
+
+const (
+	healthStatusOk    = "ok"
+	healthStatusError = "error"
+)
+
+type healthResponse struct {
+	Status      string            `json:"status"`
+	ActivePlans int               `json:"activePlans"`
+	Checks      map[string]string `json:"checks,omitempty"`
+}
+
+var shuttingDown atomic.Bool
+
+// SetShuttingDown makes the readiness check fail, so load balancers stop sending new requests while active plans finish
+func SetShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+// HealthzHandler is a liveness check. It only verifies that the server is responding, so a slow dependency doesn't get the server restarted.
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealthResponse(w, healthResponse{
+		Status:      healthStatusOk,
+		ActivePlans: plan.NumActivePlans(),
+	})
+}
+
+// ReadyzHandler is a readiness check. It verifies that the database and blob storage can be reached, and with '?providers=true', that each model provider's api and each self-hosted endpoint in PLANDEX_SELF_HOSTED_MODEL_URLS can be reached too. It fails while the server is shutting down.
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	res := healthResponse{
+		Status:      healthStatusOk,
+		ActivePlans: plan.NumActivePlans(),
+		Checks:      map[string]string{},
+	}
+
+	setCheck := func(name string, err error) {
+		if err == nil {
+			res.Checks[name] = healthStatusOk
+			return
+		}
+		log.Printf("Readiness check %s failed: %v\n", name, err)
+		res.Checks[name] = err.Error()
+		res.Status = healthStatusError
+	}
+
+	if shuttingDown.Load() {
+		res.Checks["server"] = "shutting down"
+		res.Status = healthStatusError
+	}
+
+	setCheck("db", db.Conn.PingContext(ctx))
+	setCheck("blobs", storage.Check())
+
+	if r.URL.Query().Get("providers") == "true" {
+		for provider, err := range model.CheckProvidersReachable(ctx) {
+			setCheck("provider:"+string(provider), err)
+		}
+		for baseUrl, err := range model.CheckSelfHostedEndpoints(ctx) {
+			setCheck("self-hosted:"+baseUrl, err)
+		}
+	}
+
+	writeHealthResponse(w, res)
+}
+
+func writeHealthResponse(w http.ResponseWriter, res healthResponse) {
+	bytes, err := json.Marshal(res)
+	if err != nil {
+		log.Printf("Error marshalling health response: %v\n", err)
+		http.Error(w, "Error marshalling health response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if res.Status != healthStatusOk {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(bytes)
+}
@@ -8,6 +8,7 @@ import (
 	"plandex-server/email"
 	"plandex-server/types"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/plandex/plandex/shared"
@@ -41,6 +42,14 @@ func InviteUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	req.Email = strings.ToLower(req.Email)
 
+	expiresAt, err := shared.GetInviteExpiresAt(req.ExpiresInDays, time.Now())
+
+	if err != nil {
+		log.Printf("Invalid invite expiration: %v\n", err)
+		http.Error(w, "Invalid invite expiration: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// ensure current user can invite target user
 	permission := types.Permission(strings.Join([]string{string(types.PermissionInviteUser), req.OrgRoleId}, "|"))
 
@@ -69,6 +78,7 @@ func InviteUserHandler(w http.ResponseWriter, r *http.Request) {
 	if org.AutoAddDomainUsers && org.Domain == domain {
 		log.Printf("User already has access to org via domain: %v\n", domain)
 		http.Error(w, "User already has access to org via domain: "+*domain, http.StatusBadRequest)
+		return
 	}
 
 	// ensure user with this email isn't already in the org
@@ -130,13 +140,16 @@ func InviteUserHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	err = db.CreateInvite(&db.Invite{
+	invite = &db.Invite{
 		OrgId:     auth.OrgId,
 		OrgRoleId: req.OrgRoleId,
 		Email:     req.Email,
 		Name:      req.Name,
 		InviterId: currentUserId,
-	}, tx)
+		ExpiresAt: &expiresAt,
+	}
+
+	token, err := db.CreateInvite(invite, tx)
 
 	if err != nil {
 		log.Printf("Error creating invite: %v\n", err)
@@ -144,7 +157,7 @@ func InviteUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = email.SendInviteEmail(req.Email, req.Name, auth.User.Name, org.Name)
+	emailSent, err := email.SendInviteEmail(req.Email, req.Name, auth.User.Name, org.Name, token, expiresAt)
 
 	if err != nil {
 		log.Printf("Error sending invite email: %v\n", err)
@@ -160,6 +173,23 @@ func InviteUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if emailSent {
+		err = db.SetInviteEmailSent(invite.Id, time.Now())
+
+		if err != nil {
+			log.Printf("Error setting invite email sent: %v\n", err)
+			http.Error(w, "Error setting invite email sent: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeInviteResponse(w, &shared.InviteResponse{
+		Id:        invite.Id,
+		Token:     token,
+		ExpiresAt: expiresAt,
+		EmailSent: emailSent,
+	})
+
 	log.Println("Successfully created invite")
 }
 
@@ -341,3 +371,230 @@ func DeleteInviteHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("Successfully deleted invite")
 }
+
+func ResendInviteHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received a request for ResendInviteHandler")
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if auth.User.IsTrial {
+		writeApiError(w, shared.ApiError{
+			Type:   shared.ApiErrorTypeTrialActionNotAllowed,
+			Status: http.StatusForbidden,
+			Msg:    "Anonymous trial user can't resend invites",
+		})
+		return
+	}
+
+	vars := mux.Vars(r)
+	inviteId := vars["inviteId"]
+
+	var req shared.ResendInviteRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error unmarshalling request: %v\n", err)
+		http.Error(w, "Error unmarshalling request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	expiresAt, err := shared.GetInviteExpiresAt(req.ExpiresInDays, time.Now())
+
+	if err != nil {
+		log.Printf("Invalid invite expiration: %v\n", err)
+		http.Error(w, "Invalid invite expiration: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	invite, err := db.GetInvite(inviteId)
+
+	if err != nil {
+		log.Printf("Error getting invite: %v\n", err)
+		http.Error(w, "Error getting invite: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if invite == nil || invite.OrgId != auth.OrgId {
+		log.Printf("Invite not found: %v\n", inviteId)
+		http.Error(w, "Invite not found: "+inviteId, http.StatusNotFound)
+		return
+	}
+
+	if invite.AcceptedAt != nil {
+		log.Printf("Invite already accepted: %v\n", inviteId)
+		http.Error(w, "Invite already accepted", http.StatusBadRequest)
+		return
+	}
+
+	// same permissions as revoking the invite
+	removePermission := types.Permission(strings.Join([]string{string(types.PermissionRemoveUser), invite.OrgRoleId}, "|"))
+
+	invitePermission := types.Permission(strings.Join([]string{string(types.PermissionInviteUser), invite.OrgRoleId}, "|"))
+
+	if !(auth.HasPermission(removePermission) ||
+		(auth.User.Id == invite.InviterId && auth.HasPermission(invitePermission))) {
+		log.Printf("User does not have permission to resend invite with role: %v\n", invite.OrgRoleId)
+		http.Error(w, "User does not have permission to resend invite with role: "+invite.OrgRoleId, http.StatusForbidden)
+		return
+	}
+
+	org, err := db.GetOrg(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting org: %v\n", err)
+		http.Error(w, "Error getting org: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	token, err := db.ResetInviteToken(invite.Id, expiresAt)
+
+	if err != nil {
+		log.Printf("Error resetting invite token: %v\n", err)
+		http.Error(w, "Error resetting invite token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	emailSent, err := email.SendInviteEmail(invite.Email, invite.Name, auth.User.Name, org.Name, token, expiresAt)
+
+	if err != nil {
+		log.Printf("Error sending invite email: %v\n", err)
+		http.Error(w, "Error sending invite email: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if emailSent {
+		err = db.SetInviteEmailSent(invite.Id, time.Now())
+
+		if err != nil {
+			log.Printf("Error setting invite email sent: %v\n", err)
+			http.Error(w, "Error setting invite email sent: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeInviteResponse(w, &shared.InviteResponse{
+		Id:        invite.Id,
+		Token:     token,
+		ExpiresAt: expiresAt,
+		EmailSent: emailSent,
+	})
+
+	log.Println("Successfully processed request for ResendInviteHandler")
+}
+
+// AcceptInviteHandler adds the signed-in user to an invite's org with the invite's role. Anyone with the token can accept it once, whatever their email, so it can be shared when email isn't configured.
+func AcceptInviteHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received a request for AcceptInviteHandler")
+	auth := authenticate(w, r, false)
+	if auth == nil {
+		return
+	}
+
+	if auth.User.IsTrial {
+		writeApiError(w, shared.ApiError{
+			Type:   shared.ApiErrorTypeTrialActionNotAllowed,
+			Status: http.StatusForbidden,
+			Msg:    "Anonymous trial user can't accept invites",
+		})
+		return
+	}
+
+	var req shared.AcceptInviteRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error unmarshalling request: %v\n", err)
+		http.Error(w, "Error unmarshalling request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	invite, err := db.GetInviteByToken(strings.TrimSpace(req.Token))
+
+	if err != nil {
+		log.Printf("Error getting invite: %v\n", err)
+		http.Error(w, "Error getting invite: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if invite == nil {
+		log.Println("Invite not found for token")
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return
+	}
+
+	if invite.AcceptedAt != nil {
+		log.Printf("Invite already accepted: %v\n", invite.Id)
+		http.Error(w, "Invite already accepted", http.StatusBadRequest)
+		return
+	}
+
+	if invite.ToApi().IsExpired(time.Now()) {
+		log.Printf("Invite expired: %v\n", invite.Id)
+		http.Error(w, "Invite expired—ask an org admin to resend it", http.StatusBadRequest)
+		return
+	}
+
+	isMember, err := db.ValidateOrgMembership(auth.User.Id, invite.OrgId)
+
+	if err != nil {
+		log.Printf("Error validating org membership: %v\n", err)
+		http.Error(w, "Error validating org membership: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if isMember {
+		log.Println("User is already a member of org")
+		http.Error(w, "You're already a member of this org", http.StatusBadRequest)
+		return
+	}
+
+	org, err := db.GetOrg(invite.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting org: %v\n", err)
+		http.Error(w, "Error getting org: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = db.AcceptInvite(invite, auth.User.Id)
+
+	if err == db.ErrInviteAlreadyAccepted {
+		log.Printf("Invite already accepted: %v\n", invite.Id)
+		http.Error(w, "Invite already accepted", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		log.Printf("Error accepting invite: %v\n", err)
+		http.Error(w, "Error accepting invite: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.AcceptInviteResponse{
+		OrgId:     org.Id,
+		OrgName:   org.Name,
+		OrgRoleId: invite.OrgRoleId,
+	})
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for AcceptInviteHandler")
+}
+
+func writeInviteResponse(w http.ResponseWriter, res *shared.InviteResponse) {
+	bytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+}
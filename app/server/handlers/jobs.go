@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+const defaultJobsListLimit = 100
+const maxJobsListLimit = 1000
+
+// ListJobsHandler returns the server's background jobs, newest first. Filter with the 'status' and 'kind' query params, and set the number of jobs with 'limit'. Like the other admin endpoints, it requires PLANDEX_ADMIN_TOKEN as a bearer token.
+func ListJobsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListJobsHandler")
+
+	if !authenticateAdmin(w, r) {
+		return
+	}
+
+	status := shared.JobStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", shared.JobStatusQueued, shared.JobStatusRunning, shared.JobStatusSucceeded, shared.JobStatusFailed, shared.JobStatusCanceled:
+	default:
+		log.Printf("Invalid job status: %s\n", status)
+		http.Error(w, "Invalid job status: "+string(status), http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultJobsListLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxJobsListLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	jobs, err := db.ListJobs(status, r.URL.Query().Get("kind"), limit)
+
+	if err != nil {
+		log.Printf("Error listing jobs: %v\n", err)
+		http.Error(w, "Error listing jobs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := shared.ListJobsResponse{Jobs: []*shared.Job{}}
+	for _, job := range jobs {
+		res.Jobs = append(res.Jobs, job.ToApi())
+	}
+
+	bytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Printf("Error marshalling jobs: %v\n", err)
+		http.Error(w, "Error marshalling jobs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Printf("Successfully listed %d jobs\n", len(jobs))
+}
+
+// RetryJobHandler queues a failed or canceled job to run again right away, with a fresh set of attempts
+func RetryJobHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for RetryJobHandler")
+
+	if !authenticateAdmin(w, r) {
+		return
+	}
+
+	jobId := mux.Vars(r)["jobId"]
+	log.Println("jobId: ", jobId)
+
+	if getJobOrNotFound(w, jobId) == nil {
+		return
+	}
+
+	retried, err := db.RetryJob(jobId)
+
+	if err != nil {
+		log.Printf("Error retrying job: %v\n", err)
+		http.Error(w, "Error retrying job: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !retried {
+		log.Printf("Job %s can't be retried\n", jobId)
+		http.Error(w, "Only failed or canceled jobs can be retried, and not while another run of the same job is queued or running", http.StatusConflict)
+		return
+	}
+
+	log.Println("Successfully retried job")
+}
+
+// CancelJobHandler cancels a queued or running job. A running job stops at its next heartbeat if its work can be interrupted, and is recorded as canceled either way.
+func CancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for CancelJobHandler")
+
+	if !authenticateAdmin(w, r) {
+		return
+	}
+
+	jobId := mux.Vars(r)["jobId"]
+	log.Println("jobId: ", jobId)
+
+	if getJobOrNotFound(w, jobId) == nil {
+		return
+	}
+
+	canceled, err := db.CancelJob(jobId)
+
+	if err != nil {
+		log.Printf("Error canceling job: %v\n", err)
+		http.Error(w, "Error canceling job: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !canceled {
+		log.Printf("Job %s has already finished\n", jobId)
+		http.Error(w, "Job has already finished", http.StatusConflict)
+		return
+	}
+
+	log.Println("Successfully canceled job")
+}
+
+func getJobOrNotFound(w http.ResponseWriter, jobId string) *db.Job {
+	job, err := db.GetJob(jobId)
+
+	if err != nil {
+		log.Printf("Error getting job: %v\n", err)
+		http.Error(w, "Error getting job: "+err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+
+	if job == nil {
+		log.Printf("Job %s not found\n", jobId)
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return nil
+	}
+
+	return job
+}
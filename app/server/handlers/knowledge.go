@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/model"
+	modelPlan "plandex-server/model/plan"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+func ListKnowledgeEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListKnowledgeEntriesHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	entries, err := db.ListKnowledgeEntries(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting knowledge entries: %v\n", err)
+		http.Error(w, "Error getting knowledge entries: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	apiEntries := make([]*shared.KnowledgeEntry, len(entries))
+	for i, entry := range entries {
+		apiEntries[i] = entry.ToApi()
+		apiEntries[i].Body = ""
+	}
+
+	bytes, err := json.Marshal(apiEntries)
+
+	if err != nil {
+		log.Printf("Error marshalling knowledge entries: %v\n", err)
+		http.Error(w, "Error marshalling knowledge entries: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved knowledge entries")
+}
+
+func GetKnowledgeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetKnowledgeEntryHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	id := mux.Vars(r)["entryId"]
+
+	entry, err := db.GetKnowledgeEntry(auth.OrgId, id)
+
+	if err != nil {
+		log.Printf("Error getting knowledge entry: %v\n", err)
+		http.Error(w, "Error getting knowledge entry: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if entry == nil {
+		log.Printf("Knowledge entry %s not found\n", id)
+		http.Error(w, fmt.Sprintf("Knowledge entry %s not found", id), http.StatusNotFound)
+		return
+	}
+
+	bytes, err := json.Marshal(entry.ToApi())
+
+	if err != nil {
+		log.Printf("Error marshalling knowledge entry: %v\n", err)
+		http.Error(w, "Error marshalling knowledge entry: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved knowledge entry")
+}
+
+func CreateKnowledgeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for CreateKnowledgeEntryHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	// entries can be whole documents, so they're read like a context load
+	body, ok := readContextRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req shared.CreateKnowledgeEntryRequest
+	err := json.Unmarshal(body, &req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Entry == nil {
+		log.Println("Missing knowledge entry")
+		http.Error(w, "Missing knowledge entry", http.StatusBadRequest)
+		return
+	}
+
+	// plan summaries are saved from their plan so that saving again replaces them
+	if req.Entry.Kind == shared.KnowledgeKindPlanSummary {
+		log.Println("Plan summaries can't be created directly")
+		http.Error(w, "Plan summaries are saved from a plan", http.StatusBadRequest)
+		return
+	}
+
+	clients, status, err := knowledgeEmbeddingClients(auth.OrgId, auth.User, req.ApiKeys, req.OpenAIBase, req.OpenAIOrgId)
+
+	if err != nil {
+		log.Printf("Error getting clients: %v\n", err)
+		writeClientsError(w, status, fmt.Errorf("Error getting clients: %w", err))
+		return
+	}
+
+	entry := &db.KnowledgeEntry{
+		OrgId:    auth.OrgId,
+		UserId:   auth.User.Id,
+		Kind:     req.Entry.Kind,
+		Title:    strings.TrimSpace(req.Entry.Title),
+		Body:     req.Entry.Body,
+		UserName: auth.User.Name,
+	}
+
+	storeKnowledgeEntry(w, r.Context(), clients, entry)
+}
+
+func SavePlanKnowledgeHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for SavePlanKnowledgeHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	plan := authorizePlanWrite(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	// a share doesn't make the user a member of the plan's org, so they can't add to its knowledge base
+	if auth.PlanShare != nil {
+		log.Println("Plan is shared with user from outside its org")
+		http.Error(w, "Only members of the plan's org can save it to the knowledge base", http.StatusForbidden)
+		return
+	}
+
+	var req shared.SavePlanKnowledgeRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary, err := getLatestPlanSummary(w, r, auth, planId)
+	if err != nil {
+		return
+	}
+
+	if summary == "" {
+		log.Println("No summaries found for plan")
+		http.Error(w, "Plan doesn't have a summary yet", http.StatusBadRequest)
+		return
+	}
+
+	clients := initClients(
+		initClientsParams{
+			w:           w,
+			apiKeys:     req.ApiKeys,
+			openAIBase:  req.OpenAIBase,
+			openAIOrgId: req.OpenAIOrgId,
+			plan:        plan,
+			user:        auth.User,
+		},
+	)
+	if clients == nil {
+		return
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = plan.Name
+	}
+
+	entry := &db.KnowledgeEntry{
+		OrgId:        auth.OrgId,
+		UserId:       auth.User.Id,
+		SourcePlanId: &plan.Id,
+		Kind:         shared.KnowledgeKindPlanSummary,
+		Title:        title,
+		Body:         summary,
+		UserName:     auth.User.Name,
+	}
+
+	storeKnowledgeEntry(w, r.Context(), clients, entry)
+}
+
+func DeleteKnowledgeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for DeleteKnowledgeEntryHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	id := mux.Vars(r)["entryId"]
+
+	entry, err := db.GetKnowledgeEntry(auth.OrgId, id)
+
+	if err != nil {
+		log.Printf("Error getting knowledge entry: %v\n", err)
+		http.Error(w, "Error getting knowledge entry: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if entry == nil {
+		log.Printf("Knowledge entry %s not found\n", id)
+		http.Error(w, fmt.Sprintf("Knowledge entry %s not found", id), http.StatusNotFound)
+		return
+	}
+
+	if entry.UserId != auth.User.Id && !auth.HasPermission(types.PermissionUpdateAnyPlan) {
+		log.Println("User doesn't have permission to delete knowledge entry")
+		http.Error(w, "User doesn't have permission to delete knowledge entry", http.StatusForbidden)
+		return
+	}
+
+	_, err = db.DeleteKnowledgeEntry(auth.OrgId, id)
+
+	if err != nil {
+		log.Printf("Error deleting knowledge entry: %v\n", err)
+		http.Error(w, "Error deleting knowledge entry: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Successfully deleted knowledge entry %s\n", id)
+}
+
+func SearchKnowledgeHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for SearchKnowledgeHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	var req shared.SearchKnowledgeRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		http.Error(w, "No search query provided", http.StatusBadRequest)
+		return
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = shared.DefaultSearchLimit
+	}
+	if limit < 1 || limit > shared.MaxSearchLimit {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	clients, status, err := knowledgeEmbeddingClients(auth.OrgId, auth.User, req.ApiKeys, req.OpenAIBase, req.OpenAIOrgId)
+
+	if err != nil {
+		log.Printf("Error getting clients: %v\n", err)
+		writeClientsError(w, status, fmt.Errorf("Error getting clients: %w", err))
+		return
+	}
+
+	res, err := modelPlan.SearchKnowledge(r.Context(), clients, auth.OrgId, []string{query}, limit)
+
+	if err != nil {
+		log.Printf("Error searching knowledge base: %v\n", err)
+		http.Error(w, "Error searching knowledge base: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// results show a snippet, so full bodies aren't sent
+	for _, result := range res.Results {
+		result.Entry.Body = ""
+	}
+
+	bytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Printf("Error marshalling search results: %v\n", err)
+		http.Error(w, "Error marshalling search results: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Printf("Successfully searched knowledge base, found %d results\n", len(res.Results))
+}
+
+// storeKnowledgeEntry validates, embeds, and stores the entry, then writes it to the response without its body. The entry is still stored if it can't be embedded, and it's matched by keyword until a search embeds it.
+func storeKnowledgeEntry(w http.ResponseWriter, ctx context.Context, clients map[string]*openai.Client, entry *db.KnowledgeEntry) {
+	apiEntry := entry.ToApi()
+	err := apiEntry.Validate()
+
+	if err != nil {
+		log.Printf("Invalid knowledge entry: %v\n", err)
+		http.Error(w, "Invalid knowledge entry: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entry.NumTokens, err = shared.GetNumTokens(entry.Body)
+
+	if err != nil {
+		log.Printf("Error getting num tokens: %v\n", err)
+		http.Error(w, "Error getting num tokens: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var embedding []float32
+	if client := model.EmbeddingClient(clients); client != nil {
+		embeddings, err := model.CreateEmbeddings(client, ctx, []string{modelPlan.KnowledgeEmbeddingText(entry.Title, entry.Body)})
+		if err != nil {
+			log.Printf("Error embedding knowledge entry, storing without an embedding: %v\n", err)
+		} else {
+			embedding = embeddings[0]
+		}
+	}
+
+	err = db.StoreKnowledgeEntry(entry, embedding, string(model.EmbeddingModel))
+
+	if err != nil {
+		log.Printf("Error storing knowledge entry: %v\n", err)
+		http.Error(w, "Error storing knowledge entry: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	apiEntry = entry.ToApi()
+	apiEntry.Body = ""
+
+	bytes, err := json.Marshal(apiEntry)
+
+	if err != nil {
+		log.Printf("Error marshalling knowledge entry: %v\n", err)
+		http.Error(w, "Error marshalling knowledge entry: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Printf("Successfully stored knowledge entry %s\n", entry.Id)
+}
+
+// knowledgeEmbeddingClients returns the client that knowledge entries and queries are embedded with when they aren't tied to a plan. The org's OpenAI key takes precedence over the client's. The result is empty if neither has one, in which case entries are matched by keyword. On failure it returns the http status the error corresponds to.
+func knowledgeEmbeddingClients(orgId string, user *db.User, apiKeys map[string]string, openAIBase, openAIOrgId string) (map[string]*openai.Client, int, error) {
+	status, err := checkGuestTrialTokens(orgId, user)
+	if err != nil {
+		return nil, status, err
+	}
+
+	orgApiKeys, err := db.GetOrgApiKeys(orgId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("error getting org api keys: %v", err)
+	}
+
+	apiKey := apiKeys[shared.OpenAIEnvVar]
+	if orgApiKeys[shared.OpenAIEnvVar] != "" {
+		orgEndpoint := db.GetOrgKeyEndpoint(shared.OpenAIEnvVar, orgApiKeys)
+
+		// the org's key is only sent to its own endpoint, never to one the client picks
+		if openAIBase != "" && strings.TrimSuffix(openAIBase, "/") != strings.TrimSuffix(orgEndpoint, "/") {
+			return nil, http.StatusBadRequest, fmt.Errorf("a custom OpenAI base url can't be used with the org's OpenAI credential -- unset OPENAI_API_BASE, or ask an org admin to set %s", db.OrgCredentialBaseUrlName(shared.OpenAIEnvVar))
+		}
+
+		apiKey = orgApiKeys[shared.OpenAIEnvVar]
+		openAIBase = orgEndpoint
+		// the client's OpenAI org id belongs to a different account
+		openAIOrgId = orgApiKeys["OPENAI_ORG_ID"]
+	}
+
+	if apiKey == "" {
+		return map[string]*openai.Client{}, http.StatusOK, nil
+	}
+
+	return model.InitClients(map[string]string{shared.OpenAIEnvVar: apiKey}, nil, openAIBase, openAIOrgId), http.StatusOK, nil
+}
+
+// getLatestPlanSummary returns the plan's latest conversation summary, or an empty string if it doesn't have one yet. On error, it's written to the response.
+func getLatestPlanSummary(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth, planId string) (string, error) {
+	var err error
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return "", fmt.Errorf("error locking repo")
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	convoMessages, err := db.GetPlanConvo(auth.OrgId, planId)
+
+	if err != nil {
+		log.Println("Error getting plan convo: ", err)
+		http.Error(w, "Error getting plan convo: "+err.Error(), http.StatusInternalServerError)
+		return "", err
+	}
+
+	if len(convoMessages) == 0 {
+		return "", nil
+	}
+
+	convoMessageIds := make([]string, len(convoMessages))
+	for i, convoMessage := range convoMessages {
+		convoMessageIds[i] = convoMessage.Id
+	}
+
+	summaries, err := db.GetPlanSummaries(planId, convoMessageIds)
+
+	if err != nil {
+		log.Println("Error getting plan summaries: ", err)
+		http.Error(w, "Error getting plan summaries: "+err.Error(), http.StatusInternalServerError)
+		return "", err
+	}
+
+	if len(summaries) == 0 {
+		return "", nil
+	}
+
+	return summaries[len(summaries)-1].Summary, nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"plandex-server/db"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// ListModelPricesHandler returns the server's pricing catalog. Like the other admin endpoints, it requires PLANDEX_ADMIN_TOKEN as a bearer token, since the catalog applies to every org on the server.
+func ListModelPricesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListModelPricesHandler")
+
+	if !authenticateAdmin(w, r) {
+		return
+	}
+
+	prices, err := db.ListModelPrices()
+
+	if err != nil {
+		log.Printf("Error listing model prices: %v\n", err)
+		http.Error(w, "Error listing model prices: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(prices)
+
+	if err != nil {
+		log.Printf("Error marshalling model prices: %v\n", err)
+		http.Error(w, "Error marshalling model prices: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully listed model prices")
+}
+
+// UpdateModelPricesHandler adds or replaces catalog entries. Prices set here aren't replaced by fetched prices until they're deleted.
+func UpdateModelPricesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateModelPricesHandler")
+
+	if !authenticateAdmin(w, r) {
+		return
+	}
+
+	var req shared.UpdateModelPricesRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Prices) == 0 {
+		log.Println("No model prices to update")
+		http.Error(w, "No model prices to update", http.StatusBadRequest)
+		return
+	}
+
+	for _, price := range req.Prices {
+		if price == nil {
+			log.Println("Missing model price")
+			http.Error(w, "Missing model price", http.StatusBadRequest)
+			return
+		}
+
+		err = price.Validate()
+		if err != nil {
+			log.Printf("Invalid model price: %v\n", err)
+			http.Error(w, "Invalid model price: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, err = db.StoreModelPrices(req.Prices, shared.ModelPriceSourceAdmin)
+
+	if err != nil {
+		log.Printf("Error storing model prices: %v\n", err)
+		http.Error(w, "Error storing model prices: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Successfully updated %d model prices\n", len(req.Prices))
+}
+
+// DeleteModelPriceHandler removes a catalog entry given by the 'provider' and 'model' query params. Leave out 'provider' for an entry that matches any provider.
+func DeleteModelPriceHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for DeleteModelPriceHandler")
+
+	if !authenticateAdmin(w, r) {
+		return
+	}
+
+	provider := r.URL.Query().Get("provider")
+	modelName := r.URL.Query().Get("model")
+
+	if modelName == "" {
+		log.Println("Missing model")
+		http.Error(w, "Missing model", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := db.DeleteModelPrice(provider, modelName)
+
+	if err != nil {
+		log.Printf("Error deleting model price: %v\n", err)
+		http.Error(w, "Error deleting model price: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !deleted {
+		log.Printf("No model price for %s\n", modelName)
+		http.Error(w, "No model price for "+modelName, http.StatusNotFound)
+		return
+	}
+
+	log.Println("Successfully deleted model price")
+}
+
+// FetchModelPricesHandler updates the catalog from PLANDEX_MODEL_PRICES_URL right away, without waiting for the fetch job
+func FetchModelPricesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for FetchModelPricesHandler")
+
+	if !authenticateAdmin(w, r) {
+		return
+	}
+
+	if os.Getenv("PLANDEX_MODEL_PRICES_URL") == "" {
+		log.Println("PLANDEX_MODEL_PRICES_URL isn't set")
+		http.Error(w, "PLANDEX_MODEL_PRICES_URL isn't set", http.StatusBadRequest)
+		return
+	}
+
+	numUpdated, err := db.FetchModelPrices()
+
+	if err != nil {
+		log.Printf("Error fetching model prices: %v\n", err)
+		http.Error(w, "Error fetching model prices: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.FetchModelPricesResponse{NumUpdated: numUpdated})
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully fetched model prices")
+}
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/types"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/plandex/plandex/shared"
@@ -18,12 +22,27 @@ func CreateCustomModelHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.HasPermission(types.PermissionUpdateOrgSettings) {
+		log.Println("User doesn't have permission to create custom models")
+		http.Error(w, "User doesn't have permission to create custom models", http.StatusForbidden)
+		return
+	}
+
 	var model shared.AvailableModel
 	if err := json.NewDecoder(r.Body).Decode(&model); err != nil {
 		log.Printf("Error decoding request body: %v\n", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+
+	model.BaseUrl = shared.NormalizeModelBaseUrl(model.BaseUrl)
+
+	if err := model.Validate(); err != nil {
+		log.Printf("Invalid custom model: %v\n", err)
+		http.Error(w, "Invalid custom model: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	dbModel := &db.AvailableModel{
 		Id:                          model.Id,
 		OrgId:                       auth.OrgId,
@@ -38,6 +57,7 @@ func CreateCustomModelHandler(w http.ResponseWriter, r *http.Request) {
 		HasStreaming:                model.HasStreaming,
 		HasFunctionCalling:          model.HasFunctionCalling,
 		HasStreamingFunctionCalls:   model.HasStreamingFunctionCalls,
+		HasStructuredOutputs:        model.HasStructuredOutputs,
 		DefaultMaxConvoTokens:       model.DefaultMaxConvoTokens,
 		DefaultReservedOutputTokens: model.DefaultReservedOutputTokens,
 	}
@@ -53,6 +73,41 @@ func CreateCustomModelHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Successfully created custom model")
 }
 
+func ProbeModelEndpointHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ProbeModelEndpointHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	var req shared.ProbeModelEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		log.Printf("Invalid probe request: %v\n", err)
+		http.Error(w, "Invalid probe request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res := model.ProbeModelEndpoint(r.Context(), req)
+
+	bytes, err := json.Marshal(res)
+	if err != nil {
+		log.Printf("Error marshalling probe response: %v\n", err)
+		http.Error(w, "Error marshalling probe response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Printf("Successfully probed model endpoint, %d checks failed\n", len(res.Errors))
+}
+
 func ListCustomModelsHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for ListCustomModelsHandler")
 
@@ -81,6 +136,12 @@ func DeleteAvailableModelHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.HasPermission(types.PermissionUpdateOrgSettings) {
+		log.Println("User doesn't have permission to delete custom models")
+		http.Error(w, "User doesn't have permission to delete custom models", http.StatusForbidden)
+		return
+	}
+
 	modelId := mux.Vars(r)["modelId"]
 	if err := db.DeleteAvailableModel(modelId); err != nil {
 		log.Printf("Error deleting custom model: %v\n", err)
@@ -101,24 +162,29 @@ func CreateModelPackHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.HasPermission(types.PermissionUpdateOrgSettings) {
+		log.Println("User doesn't have permission to create model packs")
+		http.Error(w, "User doesn't have permission to create model packs", http.StatusForbidden)
+		return
+	}
+
 	var ms shared.ModelPack
 	if err := json.NewDecoder(r.Body).Decode(&ms); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	dbMs := &db.ModelPack{
-		OrgId:       auth.OrgId,
-		Name:        ms.Name,
-		Description: ms.Description,
-		Planner:     ms.Planner,
-		PlanSummary: ms.PlanSummary,
-		Builder:     ms.Builder,
-		Namer:       ms.Namer,
-		CommitMsg:   ms.CommitMsg,
-		ExecStatus:  ms.ExecStatus,
+	if err := ms.Validate(); err != nil {
+		http.Error(w, "Invalid model pack: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !validateModelPackName(w, auth.OrgId, &ms) {
+		return
 	}
 
+	dbMs := toDbModelPack(auth.OrgId, &ms)
+
 	if err := db.CreateModelPack(dbMs); err != nil {
 		log.Printf("Error creating model pack: %v\n", err)
 		http.Error(w, "Failed to create model pack: "+err.Error(), http.StatusInternalServerError)
@@ -156,6 +222,51 @@ func ListModelPacksHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Successfully fetched model packs")
 }
 
+func UpdateModelPackHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateModelPackHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if !auth.HasPermission(types.PermissionUpdateOrgSettings) {
+		log.Println("User doesn't have permission to update model packs")
+		http.Error(w, "User doesn't have permission to update model packs", http.StatusForbidden)
+		return
+	}
+
+	setId := mux.Vars(r)["setId"]
+
+	var ms shared.ModelPack
+	if err := json.NewDecoder(r.Body).Decode(&ms); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	ms.Id = setId
+
+	if err := ms.Validate(); err != nil {
+		http.Error(w, "Invalid model pack: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !validateModelPackName(w, auth.OrgId, &ms) {
+		return
+	}
+
+	dbMs := toDbModelPack(auth.OrgId, &ms)
+
+	if err := db.UpdateModelPack(dbMs); err != nil {
+		log.Printf("Error updating model pack: %v\n", err)
+		http.Error(w, "Failed to update model pack: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	log.Println("Successfully updated model pack")
+}
+
 func DeleteModelPackHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for DeleteModelPackHandler")
 
@@ -164,6 +275,12 @@ func DeleteModelPackHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.HasPermission(types.PermissionUpdateOrgSettings) {
+		log.Println("User doesn't have permission to delete model packs")
+		http.Error(w, "User doesn't have permission to delete model packs", http.StatusForbidden)
+		return
+	}
+
 	setId := mux.Vars(r)["setId"]
 
 	log.Printf("Deleting model pack with id: %s\n", setId)
@@ -178,3 +295,48 @@ func DeleteModelPackHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("Successfully deleted model pack")
 }
+
+// validateModelPackName makes sure a custom model pack's name doesn't clash with a built-in pack or another of the org's custom packs, since packs are selected by name
+func validateModelPackName(w http.ResponseWriter, orgId string, ms *shared.ModelPack) bool {
+	for _, builtIn := range shared.BuiltInModelPacks {
+		if strings.EqualFold(builtIn.Name, ms.Name) {
+			http.Error(w, fmt.Sprintf("%s is the name of a built-in model pack", ms.Name), http.StatusBadRequest)
+			return false
+		}
+	}
+
+	existing, err := db.ListModelPacks(orgId)
+	if err != nil {
+		log.Printf("Error fetching model packs: %v\n", err)
+		http.Error(w, "Failed to fetch model packs: "+err.Error(), http.StatusInternalServerError)
+		return false
+	}
+
+	for _, mp := range existing {
+		if mp.Id != ms.Id && strings.EqualFold(mp.Name, ms.Name) {
+			http.Error(w, fmt.Sprintf("A model pack named %s already exists", ms.Name), http.StatusConflict)
+			return false
+		}
+	}
+
+	return true
+}
+
+func toDbModelPack(orgId string, ms *shared.ModelPack) *db.ModelPack {
+	return &db.ModelPack{
+		Id:           ms.Id,
+		OrgId:        orgId,
+		Name:         ms.Name,
+		Description:  ms.Description,
+		Planner:      ms.Planner,
+		PlanSummary:  ms.PlanSummary,
+		Builder:      ms.Builder,
+		Namer:        ms.Namer,
+		CommitMsg:    ms.CommitMsg,
+		ExecStatus:   ms.ExecStatus,
+		Verifier:     ms.Verifier,
+		AutoFix:      ms.AutoFix,
+		DraftBuilder: ms.DraftBuilder,
+		Chat:         ms.Chat,
+	}
+}
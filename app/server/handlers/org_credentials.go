@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/types"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func ListOrgCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListOrgCredentialsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	// only names and timestamps are returned, so any org member can see which credentials are set
+	credentials, err := db.ListOrgCredentials(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error listing org credentials: %v\n", err)
+		http.Error(w, "Error listing org credentials: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(credentials)
+
+	if err != nil {
+		log.Printf("Error marshalling org credentials: %v\n", err)
+		http.Error(w, "Error marshalling org credentials: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully listed org credentials")
+}
+
+func SetOrgCredentialHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for SetOrgCredentialHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	// model usage is billed to the org's credentials
+	if !auth.HasPermission(types.PermissionManageBilling) {
+		log.Println("User doesn't have permission to manage org credentials")
+		http.Error(w, "User doesn't have permission to manage org credentials", http.StatusForbidden)
+		return
+	}
+
+	var req shared.SetOrgCredentialRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = shared.ValidateOrgCredentialName(req.Name)
+
+	if err != nil {
+		log.Printf("Invalid credential name: %v\n", err)
+		http.Error(w, "Invalid credential name: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Value == "" {
+		log.Println("Missing credential value")
+		http.Error(w, "Missing credential value", http.StatusBadRequest)
+		return
+	}
+
+	if !db.EncryptionEnabled() {
+		log.Println("Encryption at rest isn't enabled")
+		http.Error(w, "Org credentials require encryption at rest -- set PLANDEX_ENCRYPTION_PASSPHRASE or PLANDEX_ENCRYPTION_KMS_KEY_ID on the server", http.StatusBadRequest)
+		return
+	}
+
+	err = db.SetOrgCredential(auth.OrgId, auth.User.Id, req.Name, req.Value)
+
+	if err != nil {
+		log.Printf("Error setting org credential: %v\n", err)
+		http.Error(w, "Error setting org credential: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully set org credential")
+}
+
+func DeleteOrgCredentialHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for DeleteOrgCredentialHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if !auth.HasPermission(types.PermissionManageBilling) {
+		log.Println("User doesn't have permission to manage org credentials")
+		http.Error(w, "User doesn't have permission to manage org credentials", http.StatusForbidden)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+
+	found, err := db.DeleteOrgCredential(auth.OrgId, name)
+
+	if err != nil {
+		log.Printf("Error deleting org credential: %v\n", err)
+		http.Error(w, "Error deleting org credential: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !found {
+		log.Printf("Org credential %s not found\n", name)
+		http.Error(w, "Org credential not found", http.StatusNotFound)
+		return
+	}
+
+	log.Println("Successfully deleted org credential")
+}
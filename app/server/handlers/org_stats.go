@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	modelPlan "plandex-server/model/plan"
+	"plandex-server/types"
+	"strconv"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetOrgStatsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetOrgStatsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	days := shared.DefaultStatsDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days < 1 || days > shared.MaxStatsDays {
+			http.Error(w, "Invalid days", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// spend is visible to those who manage the org's billing--everyone else only sees their own activity
+	userId := r.URL.Query().Get("userId")
+	if !auth.HasPermission(types.PermissionManageBilling) {
+		if userId != "" && userId != auth.User.Id {
+			log.Println("User doesn't have permission to view other users' stats")
+			http.Error(w, "User doesn't have permission to view other users' stats", http.StatusForbidden)
+			return
+		}
+		userId = auth.User.Id
+	}
+
+	res, err := modelPlan.GetOrgStats(auth.OrgId, userId, days, time.Now())
+
+	if err != nil {
+		log.Printf("Error getting org stats: %v\n", err)
+		http.Error(w, "Error getting org stats: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Printf("Error marshalling org stats: %v\n", err)
+		http.Error(w, "Error marshalling org stats: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for GetOrgStatsHandler")
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func ListPlanSharesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListPlanSharesHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+
+	log.Println("planId: ", planId)
+
+	plan := authorizePlanShares(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	shares, err := db.ListPlanShares(planId)
+
+	if err != nil {
+		log.Printf("Error listing plan shares: %v\n", err)
+		http.Error(w, "Error listing plan shares: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	apiShares := []*shared.PlanShare{}
+	for _, share := range shares {
+		apiShares = append(apiShares, share.ToApi())
+	}
+
+	bytes, err := json.Marshal(apiShares)
+
+	if err != nil {
+		log.Printf("Error marshalling plan shares: %v\n", err)
+		http.Error(w, "Error marshalling plan shares: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully listed plan shares")
+}
+
+func SharePlanHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for SharePlanHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if auth.User.IsTrial {
+		writeApiError(w, shared.ApiError{
+			Type:   shared.ApiErrorTypeTrialActionNotAllowed,
+			Status: http.StatusForbidden,
+			Msg:    "Anonymous trial user can't share plans",
+		})
+
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+
+	log.Println("planId: ", planId)
+
+	plan := authorizePlanShares(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	var req shared.SharePlanRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = req.Access.Validate()
+
+	if err != nil {
+		log.Printf("Invalid access: %v\n", err)
+		http.Error(w, "Invalid access: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := db.GetUserByEmail(strings.ToLower(strings.TrimSpace(req.Email)))
+
+	if err != nil {
+		log.Printf("Error getting user: %v\n", err)
+		http.Error(w, "Error getting user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if user == nil {
+		log.Printf("No user found for email: %v\n", req.Email)
+		http.Error(w, "No user with email "+req.Email+"—they need to sign up before a plan can be shared with them", http.StatusNotFound)
+		return
+	}
+
+	if user.Id == plan.OwnerId {
+		log.Println("Can't share a plan with its owner")
+		http.Error(w, "Can't share a plan with its owner", http.StatusBadRequest)
+		return
+	}
+
+	share := &db.PlanShare{
+		PlanId:    planId,
+		OrgId:     plan.OrgId,
+		UserId:    user.Id,
+		Access:    req.Access,
+		CreatedBy: &auth.User.Id,
+		UserEmail: user.Email,
+		UserName:  user.Name,
+	}
+
+	err = db.StorePlanShare(share)
+
+	if err != nil {
+		log.Printf("Error sharing plan: %v\n", err)
+		http.Error(w, "Error sharing plan: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(share.ToApi())
+
+	if err != nil {
+		log.Printf("Error marshalling plan share: %v\n", err)
+		http.Error(w, "Error marshalling plan share: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully shared plan")
+}
+
+func UnsharePlanHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UnsharePlanHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	userId := vars["userId"]
+
+	log.Println("planId: ", planId, "userId: ", userId)
+
+	var plan *db.Plan
+	if userId == auth.User.Id {
+		// users can always remove a plan that was shared with them
+		plan = authorizePlan(w, planId, auth)
+	} else {
+		plan = authorizePlanShares(w, planId, auth)
+	}
+
+	if plan == nil {
+		return
+	}
+
+	err := db.DeletePlanShare(planId, userId)
+
+	if err != nil {
+		log.Printf("Error removing plan share: %v\n", err)
+		http.Error(w, "Error removing plan share: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully removed plan share")
+}
+
+// ListSharedPlansHandler lists plans shared directly with the user from any org, including orgs they aren't a member of
+func ListSharedPlansHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListSharedPlansHandler")
+
+	auth := authenticate(w, r, false)
+	if auth == nil {
+		return
+	}
+
+	plans, err := db.ListPlansSharedWithUser(auth.User.Id)
+
+	if err != nil {
+		log.Printf("Error listing shared plans: %v\n", err)
+		http.Error(w, "Error listing shared plans: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	apiPlans := []*shared.Plan{}
+	for _, plan := range plans {
+		apiPlans = append(apiPlans, plan.ToApi())
+	}
+
+	bytes, err := json.Marshal(apiPlans)
+
+	if err != nil {
+		log.Printf("Error marshalling plans: %v\n", err)
+		http.Error(w, "Error marshalling plans: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully listed shared plans")
+}
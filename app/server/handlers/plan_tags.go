@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func UpdatePlanTagsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdatePlanTagsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+
+	log.Println("planId: ", planId)
+
+	plan := authorizePlanUpdate(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody shared.UpdatePlanTagsRequest
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(requestBody.Add) == 0 && len(requestBody.Remove) == 0 {
+		log.Println("No tags to add or remove")
+		http.Error(w, "No tags to add or remove", http.StatusBadRequest)
+		return
+	}
+
+	add, err := normalizePlanTags(requestBody.Add)
+	if err != nil {
+		log.Printf("Invalid tag: %v\n", err)
+		http.Error(w, "Invalid tag: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	remove, err := normalizePlanTags(requestBody.Remove)
+	if err != nil {
+		log.Printf("Invalid tag: %v\n", err)
+		http.Error(w, "Invalid tag: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tags, err := db.UpdatePlanTags(auth.OrgId, planId, add, remove)
+
+	if err != nil {
+		log.Printf("Error updating plan tags: %v\n", err)
+		http.Error(w, "Error updating plan tags: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(tags)
+
+	if err != nil {
+		log.Printf("Error marshalling plan tags: %v\n", err)
+		http.Error(w, "Error marshalling plan tags: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for UpdatePlanTagsHandler")
+}
+
+func ListPlanTagsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListPlanTagsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	counts, err := db.ListOrgPlanTags(auth.OrgId, auth.User.Id)
+
+	if err != nil {
+		log.Printf("Error listing plan tags: %v\n", err)
+		http.Error(w, "Error listing plan tags: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(counts)
+
+	if err != nil {
+		log.Printf("Error marshalling plan tags: %v\n", err)
+		http.Error(w, "Error marshalling plan tags: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for ListPlanTagsHandler")
+}
+
+func normalizePlanTags(tags []string) ([]string, error) {
+	var res []string
+	seen := map[string]bool{}
+
+	for _, tag := range tags {
+		normalized, err := shared.NormalizePlanTag(tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[normalized] {
+			seen[normalized] = true
+			res = append(res, normalized)
+		}
+	}
+
+	return res, nil
+}
+
+// getPlanListParams reads the 'plandex plans' filters from the query string. The caller sets the org, user, and authorized projects.
+func getPlanListParams(r *http.Request) (*db.PlanListParams, error) {
+	q := r.URL.Query()
+
+	tags, err := normalizePlanTags(q["tag"])
+	if err != nil {
+		return nil, err
+	}
+
+	params := &db.PlanListParams{
+		Tags:     tags,
+		AuthorId: q.Get("author"),
+		Archived: q.Get("archived") == "true",
+		SortBy:   shared.PlanSortBy(q.Get("sort")),
+		Asc:      q.Get("asc") == "true",
+	}
+
+	for _, s := range q["status"] {
+		status := shared.PlanStatus(s)
+		if !status.Valid() {
+			return nil, fmt.Errorf("invalid status '%s'", s)
+		}
+		params.Statuses = append(params.Statuses, status)
+	}
+
+	if params.SortBy != "" && !params.SortBy.Valid() {
+		return nil, fmt.Errorf("invalid sort '%s'", params.SortBy)
+	}
+
+	for _, bound := range []struct {
+		key string
+		dst **time.Time
+	}{
+		{"updatedAfter", &params.UpdatedAfter},
+		{"updatedBefore", &params.UpdatedBefore},
+	} {
+		if s := q.Get(bound.key); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s '%s'", bound.key, s)
+			}
+			*bound.dst = &t
+		}
+	}
+
+	return params, nil
+}
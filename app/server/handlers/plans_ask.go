@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	modelPlan "plandex-server/model/plan"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+// AskPlanHandler answers a question about the plan's context. The answer isn't added to the conversation and nothing is built, so it only needs read access to the plan.
+func AskPlanHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for AskPlanHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	plan := authorizePlan(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	var req shared.AskPlanRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request: %v\n", err)
+		http.Error(w, "Error decoding request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.Question) == "" {
+		log.Println("Question is empty")
+		http.Error(w, "Question is required", http.StatusBadRequest)
+		return
+	}
+
+	settings, contexts, err := getAskContext(w, r, auth, plan)
+	if err != nil {
+		return
+	}
+
+	clients := initClients(initClientsParams{
+		w:           w,
+		apiKeys:     req.ApiKeys,
+		openAIBase:  req.OpenAIBase,
+		openAIOrgId: req.OpenAIOrgId,
+		plan:        plan,
+		user:        auth.User,
+		settings:    settings,
+	})
+	if clients == nil {
+		return
+	}
+
+	err = streamReply(w, func(onChunk func(string) error) error {
+		_, err := modelPlan.StreamAsk(r.Context(), clients, settings, auth.OrgId, auth.User.Id, planId, branch, contexts, req.Question, onChunk)
+		return err
+	})
+
+	if err != nil {
+		log.Printf("Error streaming answer: %v\n", err)
+		return
+	}
+
+	log.Println("Successfully streamed answer")
+}
+
+// getAskContext reads the plan's settings and contexts with the repo locked for reading. The lock is released before the question is sent to the model, so a long answer doesn't hold up the plan. On error, it's written to the response.
+func getAskContext(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth, plan *db.Plan) (*shared.PlanSettings, []*db.Context, error) {
+	var err error
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return nil, nil, fmt.Errorf("error locking repo")
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	settings, err := db.GetPlanSettings(plan, true)
+	if err != nil {
+		log.Printf("Error getting plan settings: %v\n", err)
+		http.Error(w, "Error getting plan settings: "+err.Error(), http.StatusInternalServerError)
+		return nil, nil, err
+	}
+
+	contexts, err := db.GetPlanContexts(auth.OrgId, plan.Id, true)
+	if err != nil {
+		log.Printf("Error getting contexts: %v\n", err)
+		http.Error(w, "Error getting contexts: "+err.Error(), http.StatusInternalServerError)
+		return nil, nil, err
+	}
+
+	return settings, contexts, nil
+}
@@ -81,7 +81,7 @@ func ApplyPlanHandler(w http.ResponseWriter, r *http.Request) {
 	branch := vars["branch"]
 	log.Println("planId: ", planId)
 
-	plan := authorizePlan(w, planId, auth)
+	plan := authorizePlanWrite(w, planId, auth)
 	if plan == nil {
 		return
 	}
@@ -136,25 +136,193 @@ func ApplyPlanHandler(w http.ResponseWriter, r *http.Request) {
 			openAIBase:  requestBody.OpenAIBase,
 			openAIOrgId: requestBody.OpenAIOrgId,
 			plan:        plan,
+			user:        auth.User,
 		},
 	)
+	if clients == nil {
+		return
+	}
+
+	convo, err := db.GetPlanConvo(auth.OrgId, planId)
+	if err != nil {
+		log.Printf("Error getting plan convo: %v\n", err)
+		http.Error(w, "Error getting plan convo: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	envVar := settings.ModelPack.CommitMsg.BaseModelConfig.ApiKeyEnvVar
 	client := clients[envVar]
 
-	s, err := modelPlan.GenCommitMsgForPendingResults(client, settings.ModelPack.CommitMsg, currentPlan, r.Context())
+	res, err := modelPlan.GenCommitMsgAndChangelog(client, settings.ModelPack.CommitMsg, currentPlan, convo, r.Context())
 
-	if err != nil {
+	if err != nil && err.Error() != shared.NoPendingChangesErr {
 		log.Printf("Error generating commit message: %v\n", err)
 		http.Error(w, "Error generating commit message: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Write([]byte(s))
+	if res != nil {
+		w.Write([]byte(res.CommitMsg))
+	}
 
 	log.Println("Successfully applied plan", planId)
 }
 
+func CommitMsgHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for CommitMsgHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	plan := authorizePlanWrite(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	var req shared.CommitMsgRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request: %v\n", err)
+		http.Error(w, "Error decoding request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	settings, err := db.GetPlanSettings(plan, true)
+	if err != nil {
+		log.Printf("Error getting plan settings: %v\n", err)
+		http.Error(w, "Error getting plan settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	planState, err := db.GetCurrentPlanState(db.CurrentPlanStateParams{
+		OrgId:  auth.OrgId,
+		PlanId: planId,
+	})
+	if err != nil {
+		log.Printf("Error getting current plan state: %v\n", err)
+		http.Error(w, "Error getting current plan state: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	convo, err := db.GetPlanConvo(auth.OrgId, planId)
+	if err != nil {
+		log.Printf("Error getting plan convo: %v\n", err)
+		http.Error(w, "Error getting plan convo: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	clients := initClients(
+		initClientsParams{
+			w:           w,
+			apiKeys:     req.ApiKeys,
+			openAIBase:  req.OpenAIBase,
+			openAIOrgId: req.OpenAIOrgId,
+			plan:        plan,
+			user:        auth.User,
+		},
+	)
+	if clients == nil {
+		return
+	}
+
+	envVar := settings.ModelPack.CommitMsg.BaseModelConfig.ApiKeyEnvVar
+	client := clients[envVar]
+
+	res, err := modelPlan.GenCommitMsgAndChangelog(client, settings.ModelPack.CommitMsg, planState, convo, r.Context())
+
+	if err != nil {
+		if err.Error() == shared.NoPendingChangesErr {
+			http.Error(w, shared.NoPendingChangesErr, http.StatusNotFound)
+			return
+		}
+		log.Printf("Error generating commit message: %v\n", err)
+		http.Error(w, "Error generating commit message: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(res)
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully generated commit message for plan", planId)
+}
+
+func RecordApplyHookHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for RecordApplyHookHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	if authorizePlanWrite(w, planId, auth) == nil {
+		return
+	}
+
+	var req shared.RecordApplyHookRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request: %v\n", err)
+		http.Error(w, "Error decoding request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Hook != shared.ApplyHookPre && req.Hook != shared.ApplyHookPost {
+		log.Printf("Invalid apply hook: %s\n", req.Hook)
+		http.Error(w, "Invalid apply hook: "+string(req.Hook), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	err = db.GitCommitWithoutChanges(auth.OrgId, planId, branch, req.CommitMsg())
+
+	if err != nil {
+		log.Printf("Error committing apply hook result: %v\n", err)
+		http.Error(w, "Error committing apply hook result: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully recorded apply hook result for plan", planId)
+}
+
 func RejectAllChangesHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for RejectAllChangesHandler")
 
@@ -168,7 +336,7 @@ func RejectAllChangesHandler(w http.ResponseWriter, r *http.Request) {
 	branch := vars["branch"]
 	log.Println("planId: ", planId, "branch: ", branch)
 
-	if authorizePlan(w, planId, auth) == nil {
+	if authorizePlanWrite(w, planId, auth) == nil {
 		return
 	}
 
@@ -183,6 +351,8 @@ func RejectAllChangesHandler(w http.ResponseWriter, r *http.Request) {
 		}()
 	}
 
+	recordRejectionFeedback(auth, planId, nil)
+
 	err = db.RejectAllResults(auth.OrgId, planId)
 
 	if err != nil {
@@ -216,7 +386,7 @@ func RejectFileHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("planId: ", planId, "branch: ", branch)
 
-	if authorizePlan(w, planId, auth) == nil {
+	if authorizePlanWrite(w, planId, auth) == nil {
 		return
 	}
 
@@ -238,6 +408,8 @@ func RejectFileHandler(w http.ResponseWriter, r *http.Request) {
 		}()
 	}
 
+	recordRejectionFeedback(auth, planId, map[string]bool{req.FilePath: true})
+
 	err = db.RejectPlanFile(auth.OrgId, planId, req.FilePath, time.Now())
 
 	if err != nil {
@@ -271,7 +443,7 @@ func RejectFilesHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("planId: ", planId, "branch: ", branch)
 
-	if authorizePlan(w, planId, auth) == nil {
+	if authorizePlanWrite(w, planId, auth) == nil {
 		return
 	}
 
@@ -293,6 +465,12 @@ func RejectFilesHandler(w http.ResponseWriter, r *http.Request) {
 		}()
 	}
 
+	rejectedPaths := map[string]bool{}
+	for _, path := range req.Paths {
+		rejectedPaths[path] = true
+	}
+	recordRejectionFeedback(auth, planId, rejectedPaths)
+
 	err = db.RejectPlanFiles(auth.OrgId, planId, req.Paths, time.Now())
 
 	if err != nil {
@@ -393,7 +571,7 @@ func UnarchivePlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := db.Conn.Exec("UPDATE plans SET archived_at = NULL WHERE id = $1", planId)
+	res, err := db.Conn.Exec("UPDATE plans SET archived_at = NULL, deletion_notified_at = NULL WHERE id = $1", planId)
 
 	if err != nil {
 		log.Printf("Error archiving plan: %v\n", err)
@@ -446,7 +624,7 @@ func GetPlanDiffsHandler(w http.ResponseWriter, r *http.Request) {
 		}()
 	}
 
-	diffs, err := db.GetPlanDiffs(auth.OrgId, planId)
+	diffs, err := db.GetPlanDiffs(auth.OrgId, planId, r.URL.Query().Get("plain") == "true")
 
 	if err != nil {
 		log.Printf("Error getting plan diffs: %v\n", err)
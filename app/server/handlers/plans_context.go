@@ -12,6 +12,9 @@ import (
 	"github.com/plandex/plandex/shared"
 )
 
+// allows for JSON encoding overhead on top of the max total context size
+const maxContextRequestBytes = int64(shared.MaxContextTotalSize) * 2
+
 func ListContextHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for ListContextHandler")
 
@@ -77,19 +80,16 @@ func LoadContextHandler(w http.ResponseWriter, r *http.Request) {
 	branchName := vars["branch"]
 	log.Println("planId: ", planId)
 
-	plan := authorizePlan(w, planId, auth)
+	plan := authorizePlanWrite(w, planId, auth)
 	if plan == nil {
 		return
 	}
 
 	// read the request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Error reading request body: %v\n", err)
-		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+	body, ok := readContextRequestBody(w, r)
+	if !ok {
 		return
 	}
-	defer r.Body.Close()
 
 	var requestBody shared.LoadContextRequest
 	if err := json.Unmarshal(body, &requestBody); err != nil {
@@ -130,19 +130,16 @@ func UpdateContextHandler(w http.ResponseWriter, r *http.Request) {
 	branchName := vars["branch"]
 	log.Println("planId: ", planId)
 
-	plan := authorizePlan(w, planId, auth)
+	plan := authorizePlanWrite(w, planId, auth)
 	if plan == nil {
 		return
 	}
 
 	// read the request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Error reading request body: %v\n", err)
-		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+	body, ok := readContextRequestBody(w, r)
+	if !ok {
 		return
 	}
-	defer r.Body.Close()
 
 	var requestBody shared.UpdateContextRequest
 	if err := json.Unmarshal(body, &requestBody); err != nil {
@@ -151,6 +148,19 @@ func UpdateContextHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var updateIds []string
+	for id := range requestBody {
+		updateIds = append(updateIds, id)
+	}
+	// updates only replace the bodies of existing context (UpdateContexts fails on unknown ids), so none of them are new
+	if !validateContextSize(w, len(updateIds), 0, func(i int) (string, int) {
+		id := updateIds[i]
+		return id, len(requestBody[id].Body)
+	}) {
+		return
+	}
+
+	var err error
 	ctx, cancel := context.WithCancel(context.Background())
 	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
 	if unlockFn == nil {
@@ -161,6 +171,32 @@ func UpdateContextHandler(w http.ResponseWriter, r *http.Request) {
 		}()
 	}
 
+	err = db.ResolveContextDeltas(auth.OrgId, planId, &requestBody)
+
+	if err == db.ErrContextDeltaMismatch {
+		writeApiError(w, shared.ApiError{
+			Type:   shared.ApiErrorTypeContextDeltaMismatch,
+			Status: http.StatusConflict,
+			Msg:    "Context has changed since the update was computed. Send full bodies instead.",
+		})
+		return
+	} else if err != nil {
+		log.Printf("Error resolving context deltas: %v\n", err)
+		http.Error(w, "Error resolving context deltas: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// check sizes again now that deltas are expanded to full bodies
+	if !validateContextSize(w, len(updateIds), 0, func(i int) (string, int) {
+		id := updateIds[i]
+		return id, len(requestBody[id].Body)
+	}) {
+		return
+	}
+
+	// the update replaces the bodies applied changes are compared with, so edits to them are found first
+	recordEditFeedback(auth, planId, requestBody)
+
 	updateRes, err := db.UpdateContexts(db.UpdateContextsParams{
 		Req:        &requestBody,
 		OrgId:      auth.OrgId,
@@ -222,7 +258,7 @@ func DeleteContextHandler(w http.ResponseWriter, r *http.Request) {
 	branchName := vars["branch"]
 	log.Println("planId: ", planId)
 
-	plan := authorizePlan(w, planId, auth)
+	plan := authorizePlanWrite(w, planId, auth)
 
 	if plan == nil {
 		return
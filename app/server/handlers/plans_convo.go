@@ -3,11 +3,16 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"plandex-server/db"
+	modelPlan "plandex-server/model/plan"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
 )
 
 func ListConvoHandler(w http.ResponseWriter, r *http.Request) {
@@ -127,3 +132,157 @@ func GetPlanStatusHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("Successfully processed request for GetPlanStatusHandler")
 }
+
+func EditMessageHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received a request for EditMessageHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	if authorizePlanWrite(w, planId, auth) == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody shared.EditMessageRequest
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	message := strings.TrimSpace(requestBody.Message)
+	if message == "" {
+		http.Error(w, "Edited prompt can't be empty", http.StatusBadRequest)
+		return
+	}
+
+	if modelPlan.GetActivePlan(planId, branch) != nil {
+		http.Error(w, "Plan is active—stop it before editing the conversation", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	res, err := modelPlan.EditMessage(auth.OrgId, planId, branch, requestBody.Num, message)
+
+	if err != nil {
+		log.Println("Error editing message: ", err)
+		if errors.Is(err, modelPlan.ErrInvalidMessageEdit) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Error editing message: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = db.SyncPlanTokens(auth.OrgId, planId, branch)
+
+	if err != nil {
+		log.Println("Error syncing plan tokens: ", err)
+		http.Error(w, "Error syncing plan tokens: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Println("Error marshalling response: ", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for EditMessageHandler")
+}
+
+func RerunPlanHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received a request for RerunPlanHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	if authorizePlanWrite(w, planId, auth) == nil {
+		return
+	}
+
+	if modelPlan.GetActivePlan(planId, branch) != nil {
+		http.Error(w, "Plan is already active", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	res, err := modelPlan.PrepareRerun(auth.OrgId, planId, branch)
+
+	if err != nil {
+		log.Println("Error preparing re-run: ", err)
+		if errors.Is(err, modelPlan.ErrNothingToRerun) {
+			http.Error(w, "Nothing to re-run—edit a prompt with 'plandex edit-prompt' first", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Error preparing re-run: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = db.SyncPlanTokens(auth.OrgId, planId, branch)
+
+	if err != nil {
+		log.Println("Error syncing plan tokens: ", err)
+		http.Error(w, "Error syncing plan tokens: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Println("Error marshalling response: ", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for RerunPlanHandler")
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,6 +9,8 @@ import (
 	"net/http"
 	"os"
 	"plandex-server/db"
+	"plandex-server/model"
+	modelPlan "plandex-server/model/plan"
 	"plandex-server/types"
 	"sort"
 	"strings"
@@ -17,6 +20,9 @@ import (
 	"github.com/plandex/plandex/shared"
 )
 
+// maxCreatePlanAttempts is how many free names CreatePlanHandler tries if other plans keep taking them first
+const maxCreatePlanAttempts = 5
+
 func CreatePlanHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for CreatePlanHandler")
 
@@ -64,6 +70,19 @@ func CreatePlanHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	trialErr, err := modelPlan.GetGuestTrialPlansError(auth.OrgId, auth.User)
+
+	if err != nil {
+		log.Printf("Error checking trial limits: %v\n", err)
+		http.Error(w, "Error checking trial limits: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if trialErr != nil {
+		writeApiError(w, *trialErr)
+		return
+	}
+
 	// read the request body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -94,30 +113,43 @@ func CreatePlanHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Error deleting draft plans: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
-	} else {
-		i := 2
-		originalName := name
-		for {
-			var count int
-			err := db.Conn.Get(&count, "SELECT COUNT(*) FROM plans WHERE project_id = $1 AND owner_id = $2 AND name = $3", projectId, auth.User.Id, name)
-
-			if err != nil {
-				log.Printf("Error checking if plan exists: %v\n", err)
-				http.Error(w, "Error checking if plan exists: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
+	}
 
-			if count == 0 {
-				break
+	// names are unique in a project, so a name another plan takes first is retried with the next free suffix
+	var plan *db.Plan
+	originalName := name
+	for attempt := 1; ; attempt++ {
+		if originalName != "draft" {
+			i := 2
+			name = originalName
+			for {
+				var count int
+				err := db.Conn.Get(&count, "SELECT COUNT(*) FROM plans WHERE project_id = $1 AND name = $2", projectId, name)
+
+				if err != nil {
+					log.Printf("Error checking if plan exists: %v\n", err)
+					http.Error(w, "Error checking if plan exists: "+err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				if count == 0 {
+					break
+				}
+
+				name = originalName + "." + fmt.Sprint(i)
+				i++
 			}
+		}
 
-			name = originalName + "." + fmt.Sprint(i)
-			i++
+		plan, err = db.CreatePlan(auth.OrgId, projectId, auth.User.Id, name)
+
+		if err == db.ErrPlanNameTaken && attempt < maxCreatePlanAttempts {
+			log.Printf("Plan name %s was taken before the plan was created, trying again\n", name)
+			continue
 		}
+		break
 	}
 
-	plan, err := db.CreatePlan(auth.OrgId, projectId, auth.User.Id, name)
-
 	if err != nil {
 		log.Printf("Error creating plan: %v\n", err)
 		http.Error(w, "Error creating plan: "+err.Error(), http.StatusInternalServerError)
@@ -125,8 +157,9 @@ func CreatePlanHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := shared.CreatePlanResponse{
-		Id:   plan.Id,
-		Name: plan.Name,
+		Id:             plan.Id,
+		Name:           plan.Name,
+		ContextBundles: attachContextBundles(auth, plan),
 	}
 
 	bytes, err := json.Marshal(resp)
@@ -161,6 +194,14 @@ func GetPlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err := db.LoadPlanTags([]*db.Plan{plan})
+
+	if err != nil {
+		log.Printf("Error loading plan tags: %v\n", err)
+		http.Error(w, "Error loading plan tags: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	bytes, err := json.Marshal(plan)
 
 	if err != nil {
@@ -210,7 +251,13 @@ func RenamePlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := db.RenamePlan(planId, requestBody.Name, nil)
+	err := db.RenamePlan(planId, requestBody.Name, false, nil)
+
+	if err == db.ErrPlanNameTaken {
+		log.Printf("Plan name %s is taken\n", requestBody.Name)
+		http.Error(w, fmt.Sprintf("A plan named '%s' already exists in this project", requestBody.Name), http.StatusConflict)
+		return
+	}
 
 	if err != nil {
 		log.Printf("Error renaming plan: %v\n", err)
@@ -221,6 +268,157 @@ func RenamePlanHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Successfully renamed plan")
 }
 
+// maxAutoNameConvoTokens is the most of the conversation's prompts the namer is sent
+const maxAutoNameConvoTokens = 4000
+
+// AutoRenamePlanHandler names the plan again from the prompts in its conversation, for plans whose name came from a prompt that didn't say much about them
+func AutoRenamePlanHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for AutoRenamePlanHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	plan := authorizePlan(w, planId, auth)
+
+	if plan == nil {
+		return
+	}
+
+	if plan.OwnerId != auth.User.Id {
+		log.Println("Only the plan owner can rename a plan")
+		http.Error(w, "Only the plan owner can rename a plan", http.StatusForbidden)
+		return
+	}
+
+	var requestBody shared.AutoRenamePlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	settings, convo, err := getAutoRenameConvo(w, r, auth, plan)
+	if err != nil {
+		return
+	}
+
+	content, err := planNameConvoContent(convo)
+	if err != nil {
+		log.Printf("Error getting conversation for plan name: %v\n", err)
+		http.Error(w, "Error getting conversation for plan name: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if content == "" {
+		log.Println("No prompts to name the plan from")
+		http.Error(w, "The plan doesn't have any prompts to name it from yet", http.StatusBadRequest)
+		return
+	}
+
+	clients := initClients(initClientsParams{
+		w:           w,
+		apiKeys:     requestBody.ApiKeys,
+		openAIBase:  requestBody.OpenAIBase,
+		openAIOrgId: requestBody.OpenAIOrgId,
+		plan:        plan,
+		user:        auth.User,
+		settings:    settings,
+	})
+	if clients == nil {
+		return
+	}
+
+	namer := settings.ModelPack.Namer
+	client := clients[namer.BaseModelConfig.ApiKeyEnvVar]
+
+	name, err := model.GenPlanName(client, namer, content, model.ResponseCacheOwner{OrgId: auth.OrgId, UserId: auth.User.Id})
+
+	if err != nil {
+		log.Printf("Error generating plan name: %v\n", err)
+		http.Error(w, "Error generating plan name: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name, err = db.RenamePlanUnique(auth.OrgId, planId, name, false, nil)
+
+	if err != nil {
+		log.Printf("Error renaming plan: %v\n", err)
+		http.Error(w, "Error renaming plan: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.AutoRenamePlanResponse{Name: name})
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Printf("Successfully renamed plan to %s\n", name)
+}
+
+// getAutoRenameConvo reads the plan's settings and conversation with the repo locked for reading. The lock is released before the namer is called. On error, it's written to the response.
+func getAutoRenameConvo(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth, plan *db.Plan) (*shared.PlanSettings, []*db.ConvoMessage, error) {
+	var err error
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return nil, nil, fmt.Errorf("error locking repo")
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	settings, err := db.GetPlanSettings(plan, true)
+	if err != nil {
+		log.Printf("Error getting plan settings: %v\n", err)
+		http.Error(w, "Error getting plan settings: "+err.Error(), http.StatusInternalServerError)
+		return nil, nil, err
+	}
+
+	convo, err := db.GetPlanConvo(auth.OrgId, plan.Id)
+	if err != nil {
+		log.Printf("Error getting plan convo: %v\n", err)
+		http.Error(w, "Error getting plan convo: "+err.Error(), http.StatusInternalServerError)
+		return nil, nil, err
+	}
+
+	return settings, convo, nil
+}
+
+// planNameConvoContent returns the user's prompts in a conversation, which say what the plan is for better than the replies do. The earliest prompts are kept if they don't all fit.
+func planNameConvoContent(convo []*db.ConvoMessage) (string, error) {
+	var prompts []string
+	for _, msg := range convo {
+		if msg.Role == "user" && strings.TrimSpace(msg.Message) != "" {
+			prompts = append(prompts, strings.TrimSpace(msg.Message))
+		}
+	}
+
+	if len(prompts) == 0 {
+		return "", nil
+	}
+
+	res, err := shared.TruncateToTokens(strings.Join(prompts, "\n\n---\n\n"), maxAutoNameConvoTokens, shared.TokenizerCl100k, shared.TruncateKeepStart)
+	if err != nil {
+		return "", err
+	}
+
+	return res.Text, nil
+}
+
 func DeletePlanHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for DeletePlanHandler")
 
@@ -337,7 +535,19 @@ func ListPlansHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	plans, err := db.ListOwnedPlans(authorizedProjectIds, auth.User.Id, false)
+	params, err := getPlanListParams(r)
+
+	if err != nil {
+		log.Printf("Invalid plan filters: %v\n", err)
+		http.Error(w, "Invalid plan filters: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params.OrgId = auth.OrgId
+	params.UserId = auth.User.Id
+	params.ProjectIds = authorizedProjectIds
+
+	plans, err := db.ListPlans(*params)
 
 	if err != nil {
 		log.Printf("Error listing plans: %v\n", err)
@@ -392,6 +602,14 @@ func ListArchivedPlansHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = db.LoadPlanTags(plans)
+
+	if err != nil {
+		log.Printf("Error loading plan tags: %v\n", err)
+		http.Error(w, "Error loading plan tags: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	var apiPlans []*shared.Plan
 	for _, plan := range plans {
 		apiPlans = append(apiPlans, plan.ToApi())
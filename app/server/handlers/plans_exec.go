@@ -9,6 +9,7 @@ import (
 	"os"
 	"plandex-server/db"
 	"plandex-server/host"
+	"plandex-server/model"
 	modelPlan "plandex-server/model/plan"
 	"plandex-server/types"
 	"time"
@@ -56,12 +57,6 @@ func TellPlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if requestBody.ApiKey == "" && len(requestBody.ApiKeys) == 0 {
-		log.Println("API key is required")
-		http.Error(w, "API key is required", http.StatusBadRequest)
-		return
-	}
-
 	if os.Getenv("IS_CLOUD") != "" {
 		user, err := db.GetUser(auth.User.Id)
 
@@ -95,8 +90,15 @@ func TellPlanHandler(w http.ResponseWriter, r *http.Request) {
 			openAIBase:  requestBody.OpenAIBase,
 			openAIOrgId: requestBody.OpenAIOrgId,
 			plan:        plan,
+			user:        auth.User,
+
+			requireApiKey: true,
 		},
 	)
+	if clients == nil {
+		return
+	}
+
 	err = modelPlan.Tell(clients, plan, branch, auth, &requestBody)
 
 	if err != nil {
@@ -147,12 +149,6 @@ func BuildPlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if requestBody.ApiKey == "" && len(requestBody.ApiKeys) == 0 {
-		log.Println("API key is required")
-		http.Error(w, "API key is required", http.StatusBadRequest)
-		return
-	}
-
 	clients := initClients(
 		initClientsParams{
 			w:           w,
@@ -162,9 +158,21 @@ func BuildPlanHandler(w http.ResponseWriter, r *http.Request) {
 			openAIBase:  requestBody.OpenAIBase,
 			openAIOrgId: requestBody.OpenAIOrgId,
 			plan:        plan,
+			user:        auth.User,
+
+			requireApiKey: true,
 		},
 	)
-	numBuilds, err := modelPlan.Build(clients, plan, branch, auth)
+	if clients == nil {
+		return
+	}
+
+	if host.Role == host.RoleApi {
+		queueBuild(w, r, auth, planId, branch, body, requestBody.ConnectStream)
+		return
+	}
+
+	numBuilds, err := modelPlan.Build(clients, plan, branch, auth, model.CallPriorityForStream(requestBody.ConnectStream))
 
 	if err != nil {
 		log.Printf("Error building plan: %v\n", err)
@@ -252,7 +260,7 @@ func StopPlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if authorizePlan(w, planId, auth) == nil {
+	if authorizePlanWrite(w, planId, auth) == nil {
 		return
 	}
 
@@ -299,6 +307,233 @@ func StopPlanHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func PauseBuildHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for PauseBuildHandler", "ip:", host.Ip)
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+	log.Println("planId: ", planId)
+	log.Println("branch: ", branch)
+	active := modelPlan.GetActivePlan(planId, branch)
+	isProxy := r.URL.Query().Get("proxy") == "true"
+
+	if active == nil {
+		if isProxy {
+			log.Println("No active plan on proxied request")
+			http.Error(w, "No active plan", http.StatusNotFound)
+			return
+		}
+		proxyActivePlanMethod(w, r, planId, branch, "pause_build")
+		return
+	}
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if authorizePlanWrite(w, planId, auth) == nil {
+		return
+	}
+
+	if active.IsBuildPaused() {
+		log.Println("Build is already paused")
+		http.Error(w, "Build is already paused", http.StatusBadRequest)
+		return
+	}
+
+	err := modelPlan.PauseBuild(planId, branch)
+
+	if err != nil {
+		log.Printf("Error pausing build: %v\n", err)
+		http.Error(w, "Error pausing build: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully processed request for PauseBuildHandler")
+}
+
+func ResumeBuildHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ResumeBuildHandler", "ip:", host.Ip)
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+	log.Println("planId: ", planId)
+	log.Println("branch: ", branch)
+	active := modelPlan.GetActivePlan(planId, branch)
+	isProxy := r.URL.Query().Get("proxy") == "true"
+
+	if active == nil {
+		if isProxy {
+			log.Println("No active plan on proxied request")
+			http.Error(w, "No active plan", http.StatusNotFound)
+			return
+		}
+		proxyActivePlanMethod(w, r, planId, branch, "resume_build")
+		return
+	}
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if authorizePlanWrite(w, planId, auth) == nil {
+		return
+	}
+
+	if !active.IsBuildPaused() {
+		log.Println("Build isn't paused")
+		http.Error(w, "Build isn't paused", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	err = modelPlan.ResumeBuild(auth.OrgId, planId, branch)
+
+	if err != nil {
+		log.Printf("Error resuming build: %v\n", err)
+		http.Error(w, "Error resuming build: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully processed request for ResumeBuildHandler")
+}
+
+func RespondContinueHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for RespondContinueHandler", "ip:", host.Ip)
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+	log.Println("planId: ", planId)
+	log.Println("branch: ", branch)
+	isProxy := r.URL.Query().Get("proxy") == "true"
+
+	active := modelPlan.GetActivePlan(planId, branch)
+	if active == nil {
+		if isProxy {
+			log.Println("No active plan on proxied request")
+			http.Error(w, "No active plan", http.StatusNotFound)
+			return
+		}
+
+		proxyActivePlanMethod(w, r, planId, branch, "respond_continue")
+		return
+	}
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if authorizePlanWrite(w, planId, auth) == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody shared.RespondContinueRequest
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	if !active.PromptingContinue {
+		log.Println("Plan isn't waiting for a continue response")
+		http.Error(w, "Plan isn't waiting for a continue response", http.StatusBadRequest)
+		return
+	}
+
+	log.Println("continue choice:", requestBody.Continue)
+
+	select {
+	case active.ContinueResponseCh <- requestBody.Continue:
+	case <-active.Ctx.Done():
+		log.Println("Plan stopped before continue response was received")
+		http.Error(w, "Plan stopped", http.StatusNotFound)
+		return
+	}
+
+	log.Println("Successfully processed request for RespondContinueHandler")
+}
+
+func SteerPlanHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for SteerPlanHandler", "ip:", host.Ip)
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+	log.Println("planId: ", planId)
+	log.Println("branch: ", branch)
+	isProxy := r.URL.Query().Get("proxy") == "true"
+
+	active := modelPlan.GetActivePlan(planId, branch)
+	if active == nil {
+		if isProxy {
+			log.Println("No active plan on proxied request")
+			http.Error(w, "No active plan", http.StatusNotFound)
+			return
+		}
+
+		proxyActivePlanMethod(w, r, planId, branch, "steer")
+		return
+	}
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if authorizePlanWrite(w, planId, auth) == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody shared.SteerPlanRequest
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	err = modelPlan.Steer(planId, branch, requestBody.Note)
+
+	if err != nil {
+		log.Printf("Error steering plan: %v\n", err)
+		http.Error(w, "Error steering plan: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Println("Successfully processed request for SteerPlanHandler")
+}
+
 func RespondMissingFileHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for RespondMissingFileHandler", "ip:", host.Ip)
 
@@ -326,7 +561,7 @@ func RespondMissingFileHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	plan := authorizePlan(w, planId, auth)
+	plan := authorizePlanWrite(w, planId, auth)
 	if plan == nil {
 		return
 	}
@@ -350,6 +585,24 @@ func RespondMissingFileHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("missing file choice:", requestBody.Choice)
 
 	if requestBody.Choice == shared.RespondMissingFileChoiceLoad {
+		trustPolicy, err := db.GetEffectiveTrustPolicy(plan.OrgId, plan.ProjectId)
+
+		if err != nil {
+			log.Printf("Error getting trust policy: %v\n", err)
+			http.Error(w, "Error getting trust policy: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if trustPolicy.Level(shared.TrustActionLoadContext) == shared.TrustLevelDeny {
+			log.Println("Trust policy doesn't allow loading missing file")
+			writeApiError(w, shared.ApiError{
+				Type:   shared.ApiErrorTypeTrustPolicyDenied,
+				Status: http.StatusForbidden,
+				Msg:    "The trust policy doesn't allow loading files into context during a plan",
+			})
+			return
+		}
+
 		log.Println("loading missing file")
 		res, dbContexts := loadContexts(w, r, auth, &shared.LoadContextRequest{
 			&shared.LoadContextParams{
@@ -386,6 +639,16 @@ func authorizePlanExecUpdate(w http.ResponseWriter, planId string, auth *types.S
 		return nil
 	}
 
+	if auth.PlanShare != nil {
+		if auth.PlanShare.Access != shared.PlanShareAccessWrite {
+			log.Println("Plan is shared with user read-only")
+			http.Error(w, "Plan is shared with you read-only", http.StatusForbidden)
+			return nil
+		}
+
+		return plan
+	}
+
 	if plan.OwnerId != auth.User.Id && !auth.HasPermission(types.PermissionUpdateAnyPlan) {
 		log.Println("User does not have permission to update plan")
 		http.Error(w, "User does not have permission to update plan", http.StatusForbidden)
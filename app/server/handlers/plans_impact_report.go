@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	modelPlan "plandex-server/model/plan"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func CreateImpactReportHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for CreateImpactReportHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	plan := authorizePlanWrite(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	var req shared.ImpactReportRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request: %v\n", err)
+		http.Error(w, "Error decoding request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	settings, err := db.GetPlanSettings(plan, true)
+	if err != nil {
+		log.Printf("Error getting plan settings: %v\n", err)
+		http.Error(w, "Error getting plan settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	planState, err := db.GetCurrentPlanState(db.CurrentPlanStateParams{
+		OrgId:  auth.OrgId,
+		PlanId: planId,
+	})
+	if err != nil {
+		log.Printf("Error getting current plan state: %v\n", err)
+		http.Error(w, "Error getting current plan state: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	convo, err := db.GetPlanConvo(auth.OrgId, planId)
+	if err != nil {
+		log.Printf("Error getting plan convo: %v\n", err)
+		http.Error(w, "Error getting plan convo: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	diffs, err := db.GetPlanDiffs(auth.OrgId, planId, false)
+	if err != nil {
+		log.Printf("Error getting plan diffs: %v\n", err)
+		http.Error(w, "Error getting plan diffs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	clients := initClients(
+		initClientsParams{
+			w:           w,
+			apiKeys:     req.ApiKeys,
+			openAIBase:  req.OpenAIBase,
+			openAIOrgId: req.OpenAIOrgId,
+			plan:        plan,
+			user:        auth.User,
+		},
+	)
+	if clients == nil {
+		return
+	}
+
+	config := settings.ModelPack.Planner.ModelRoleConfig
+	client := clients[config.BaseModelConfig.ApiKeyEnvVar]
+
+	report, err := modelPlan.GenImpactReport(client, config, planState, convo, diffs, r.Context())
+
+	if err != nil {
+		if err.Error() == shared.NoPendingChangesErr {
+			http.Error(w, shared.NoPendingChangesErr, http.StatusNotFound)
+			return
+		}
+		log.Printf("Error generating impact report: %v\n", err)
+		http.Error(w, "Error generating impact report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = db.StoreImpactReport(auth.OrgId, planId, report)
+	if err != nil {
+		log.Printf("Error storing impact report: %v\n", err)
+		http.Error(w, "Error storing impact report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = db.GitAddAndCommit(auth.OrgId, planId, branch, "📋 Wrote impact report for pending changes")
+	if err != nil {
+		log.Printf("Error committing impact report: %v\n", err)
+		http.Error(w, "Error committing impact report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(report)
+	if err != nil {
+		log.Printf("Error marshalling impact report: %v\n", err)
+		http.Error(w, "Error marshalling impact report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully generated impact report for plan", planId)
+}
+
+func GetImpactReportHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetImpactReportHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	var err error
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	report, err := db.GetImpactReport(auth.OrgId, planId)
+	if err != nil {
+		log.Printf("Error getting impact report: %v\n", err)
+		http.Error(w, "Error getting impact report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if report == nil {
+		http.Error(w, shared.NoImpactReportErr, http.StatusNotFound)
+		return
+	}
+
+	bytes, err := json.Marshal(report)
+	if err != nil {
+		log.Printf("Error marshalling impact report: %v\n", err)
+		http.Error(w, "Error marshalling impact report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	modelPlan "plandex-server/model/plan"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+// GetPlanProvenanceHandler lists the provenance of a plan's file results, oldest first, with whether each signature is valid. Results built before provenance was recorded are left out.
+func GetPlanProvenanceHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetPlanProvenanceHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+
+	log.Println("planId: ", planId)
+
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	var err error
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	results, err := db.GetPlanFileResults(auth.OrgId, planId)
+
+	if err != nil {
+		log.Printf("Error getting plan file results: %v\n", err)
+		http.Error(w, "Error getting plan file results: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := shared.GetPlanProvenanceResponse{
+		Entries: []*shared.PlanProvenanceEntry{},
+		Signing: modelPlan.ProvenanceSigningEnabled(),
+	}
+
+	for _, result := range results {
+		if result.Provenance == nil {
+			continue
+		}
+
+		res.Entries = append(res.Entries, &shared.PlanProvenanceEntry{
+			ResultId:       result.Id,
+			Provenance:     result.Provenance,
+			AppliedAt:      result.AppliedAt,
+			RejectedAt:     result.RejectedAt,
+			SignatureValid: modelPlan.VerifyProvenance(result.Provenance),
+		})
+	}
+
+	jsonBytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Printf("Error marshalling provenance: %v\n", err)
+		http.Error(w, "Error marshalling provenance: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully retrieved plan provenance")
+
+	w.Write(jsonBytes)
+}
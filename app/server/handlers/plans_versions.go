@@ -81,7 +81,7 @@ func RewindPlanHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("planId: ", planId)
 
-	if authorizePlan(w, planId, auth) == nil {
+	if authorizePlanWrite(w, planId, auth) == nil {
 		return
 	}
 
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	modelPlan "plandex-server/model/plan"
+	"plandex-server/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetLearnedPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetLearnedPreferencesHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	userPrefs, err := db.GetLearnedPreferences(auth.OrgId, auth.User.Id)
+
+	if err != nil {
+		log.Printf("Error getting learned preferences: %v\n", err)
+		http.Error(w, "Error getting learned preferences: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	orgPrefs, err := db.GetLearnedPreferences(auth.OrgId, "")
+
+	if err != nil {
+		log.Printf("Error getting learned preferences: %v\n", err)
+		http.Error(w, "Error getting learned preferences: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	numUser, numOrg, err := db.CountPendingChangeFeedback(auth.OrgId, auth.User.Id)
+
+	if err != nil {
+		log.Printf("Error counting change feedback: %v\n", err)
+		http.Error(w, "Error counting change feedback: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := shared.GetLearnedPreferencesResponse{
+		PendingUserEvents: numUser,
+		PendingOrgEvents:  numOrg,
+	}
+	if userPrefs != nil {
+		res.User = userPrefs.ToApi()
+	}
+	if orgPrefs != nil {
+		res.Org = orgPrefs.ToApi()
+	}
+
+	bytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Printf("Error marshalling learned preferences: %v\n", err)
+		http.Error(w, "Error marshalling learned preferences: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved learned preferences")
+}
+
+func ClearLearnedPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ClearLearnedPreferencesHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	userId := auth.User.Id
+
+	if r.URL.Query().Get("org") == "true" {
+		// the org's preferences are added to every member's prompts
+		if !auth.HasPermission(types.PermissionUpdateAnyPlan) {
+			log.Println("User doesn't have permission to clear the org's learned preferences")
+			http.Error(w, "User doesn't have permission to clear the org's learned preferences", http.StatusForbidden)
+			return
+		}
+		userId = ""
+	}
+
+	err := db.ClearLearnedPreferences(auth.OrgId, userId)
+
+	if err != nil {
+		log.Printf("Error clearing learned preferences: %v\n", err)
+		http.Error(w, "Error clearing learned preferences: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully cleared learned preferences")
+}
+
+// recordRejectionFeedback records the pending changes that are about to be rejected, so preferences can be learned from them: those for the given paths, or all of them if paths is nil. It's called with the repo locked. Errors are only logged, since they shouldn't stop the rejection.
+func recordRejectionFeedback(auth *types.ServerAuth, planId string, paths map[string]bool) {
+	results, err := db.GetPlanFileResults(auth.OrgId, planId)
+	if err != nil {
+		log.Printf("Error getting plan file results for change feedback: %v\n", err)
+		return
+	}
+
+	feedback := modelPlan.RejectionFeedback(auth.OrgId, auth.User.Id, planId, results, paths)
+	if len(feedback) == 0 {
+		return
+	}
+
+	err = db.StoreChangeFeedback(feedback)
+	if err != nil {
+		log.Printf("Error storing change feedback: %v\n", err)
+	}
+}
+
+// recordEditFeedback records edits the user made to applied changes, found by comparing a context update with the bodies the plan's contexts were given when they were applied. It's called with the repo locked, before the update is stored. Errors are only logged, since they shouldn't stop the update.
+func recordEditFeedback(auth *types.ServerAuth, planId string, req shared.UpdateContextRequest) {
+	results, err := db.GetPlanFileResults(auth.OrgId, planId)
+	if err != nil {
+		log.Printf("Error getting plan file results for change feedback: %v\n", err)
+		return
+	}
+
+	anyApplied := false
+	for _, result := range results {
+		if result.AppliedAt != nil {
+			anyApplied = true
+			break
+		}
+	}
+	if !anyApplied {
+		return
+	}
+
+	contexts, err := db.GetPlanContexts(auth.OrgId, planId, true)
+	if err != nil {
+		log.Printf("Error getting contexts for change feedback: %v\n", err)
+		return
+	}
+
+	feedback := modelPlan.EditFeedback(auth.OrgId, auth.User.Id, planId, results, contexts, req)
+	if len(feedback) == 0 {
+		return
+	}
+
+	err = db.StoreChangeFeedback(feedback)
+	if err != nil {
+		log.Printf("Error storing change feedback: %v\n", err)
+	}
+}
@@ -102,3 +102,56 @@ func proxyRequest(w http.ResponseWriter, originalRequest *http.Request, url stri
 		http.Error(w, "Error copying response body", http.StatusInternalServerError)
 	}
 }
+
+// proxyStreamRequest is proxyRequest for a streaming response. It has no timeout, stops when the original request is canceled, and flushes each chunk as it arrives.
+func proxyStreamRequest(w http.ResponseWriter, originalRequest *http.Request, url string) {
+	req, err := http.NewRequestWithContext(originalRequest.Context(), http.MethodPatch, url, nil)
+	if err != nil {
+		log.Printf("Error creating request for proxy: %v\n", err)
+		http.Error(w, "Error creating request for proxy", http.StatusInternalServerError)
+		return
+	}
+
+	for name, headers := range originalRequest.Header {
+		for _, h := range headers {
+			req.Header.Add(name, h)
+		}
+	}
+	req.Header.Del("Content-Length")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("Error forwarding request: %v\n", err)
+		http.Error(w, "Error forwarding request", http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+
+	for name, headers := range resp.Header {
+		for _, h := range headers {
+			w.Header().Add(name, h)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
+				log.Printf("Error writing proxied stream: %v\n", writeErr)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("Error reading proxied stream: %v\n", err)
+			}
+			return
+		}
+	}
+}
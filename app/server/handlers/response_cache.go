@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetOrgResponseCacheHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetOrgResponseCacheHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	policy, err := db.GetOrgResponseCachePolicy(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting response cache policy: %v\n", err)
+		http.Error(w, "Error getting response cache policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	stats, err := db.GetResponseCacheStats(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting response cache stats: %v\n", err)
+		http.Error(w, "Error getting response cache stats: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.GetOrgResponseCacheResponse{
+		Policy: policy,
+		Stats:  stats,
+	})
+
+	if err != nil {
+		log.Printf("Error marshalling response cache: %v\n", err)
+		http.Error(w, "Error marshalling response cache: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved response cache")
+}
+
+func UpdateOrgResponseCachePolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateOrgResponseCachePolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	// the cache is a cost control, and shares responses between everyone in the org
+	if !auth.HasPermission(types.PermissionManageBilling) {
+		log.Println("User doesn't have permission to update response cache policy")
+		http.Error(w, "User doesn't have permission to update response cache policy", http.StatusForbidden)
+		return
+	}
+
+	var req shared.UpdateOrgResponseCachePolicyRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Policy == nil {
+		log.Println("Missing response cache policy")
+		http.Error(w, "Missing response cache policy", http.StatusBadRequest)
+		return
+	}
+
+	err = req.Policy.Validate()
+
+	if err != nil {
+		log.Printf("Invalid response cache policy: %v\n", err)
+		http.Error(w, "Invalid response cache policy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = db.StoreOrgResponseCachePolicy(auth.OrgId, req.Policy)
+
+	if err != nil {
+		log.Printf("Error storing response cache policy: %v\n", err)
+		http.Error(w, "Error storing response cache policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully updated response cache policy")
+}
+
+func ClearOrgResponseCacheHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ClearOrgResponseCacheHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if !auth.HasPermission(types.PermissionManageBilling) {
+		log.Println("User doesn't have permission to clear response cache")
+		http.Error(w, "User doesn't have permission to clear response cache", http.StatusForbidden)
+		return
+	}
+
+	numDeleted, err := db.ClearResponseCache(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error clearing response cache: %v\n", err)
+		http.Error(w, "Error clearing response cache: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Successfully cleared response cache, %d responses deleted\n", numDeleted)
+}
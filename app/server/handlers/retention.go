@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetOrgRetentionPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetOrgRetentionPolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	policy, err := db.GetOrgRetentionPolicy(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting retention policy: %v\n", err)
+		http.Error(w, "Error getting retention policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(policy)
+
+	if err != nil {
+		log.Printf("Error marshalling retention policy: %v\n", err)
+		http.Error(w, "Error marshalling retention policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved retention policy")
+}
+
+func UpdateOrgRetentionPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateOrgRetentionPolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	// the policy archives and deletes plans owned by anyone in the org
+	if !auth.HasPermission(types.PermissionArchiveAnyPlan) || !auth.HasPermission(types.PermissionDeleteAnyPlan) {
+		log.Println("User doesn't have permission to update retention policy")
+		http.Error(w, "User doesn't have permission to update retention policy", http.StatusForbidden)
+		return
+	}
+
+	var req shared.UpdateOrgRetentionPolicyRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Policy == nil {
+		log.Println("Missing retention policy")
+		http.Error(w, "Missing retention policy", http.StatusBadRequest)
+		return
+	}
+
+	err = req.Policy.Validate()
+
+	if err != nil {
+		log.Printf("Invalid retention policy: %v\n", err)
+		http.Error(w, "Invalid retention policy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = db.StoreOrgRetentionPolicy(auth.OrgId, req.Policy)
+
+	if err != nil {
+		log.Printf("Error storing retention policy: %v\n", err)
+		http.Error(w, "Error storing retention policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully updated retention policy")
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	modelPlan "plandex-server/model/plan"
+	"strconv"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func SearchPlansHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for SearchPlansHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "No search query provided", http.StatusBadRequest)
+		return
+	}
+
+	projectId := r.URL.Query().Get("projectId")
+	if projectId != "" && !authorizeProject(w, projectId, auth) {
+		return
+	}
+
+	limit := shared.DefaultSearchLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > shared.MaxSearchLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	plans, err := db.ListSearchablePlans(auth.OrgId, auth.User.Id, projectId)
+
+	if err != nil {
+		log.Printf("Error listing plans: %v\n", err)
+		http.Error(w, "Error listing plans: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := modelPlan.Search(auth.OrgId, plans, query, limit)
+
+	bytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Printf("Error marshalling search results: %v\n", err)
+		http.Error(w, "Error marshalling search results: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for SearchPlansHandler")
+}
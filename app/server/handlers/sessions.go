@@ -241,3 +241,68 @@ func SignOutHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("Successfully signed out")
 }
+
+// RotateAuthTokenHandler replaces the token used for the request with a new one. The old token stops working immediately.
+func RotateAuthTokenHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for RotateAuthTokenHandler")
+
+	auth := authenticate(w, r, false)
+	if auth == nil {
+		return
+	}
+
+	// start a transaction
+	tx, err := db.Conn.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v\n", err)
+		http.Error(w, "Error starting transaction: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	token, _, err := db.CreateAuthToken(auth.User.Id, auth.User.IsTrial, tx)
+
+	if err != nil {
+		log.Printf("Error creating auth token: %v\n", err)
+		http.Error(w, "Error creating auth token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = tx.Exec("UPDATE auth_tokens SET deleted_at = NOW() WHERE token_hash = $1", auth.AuthToken.TokenHash)
+
+	if err != nil {
+		log.Printf("Error deleting auth token: %v\n", err)
+		http.Error(w, "Error deleting auth token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing transaction: %v\n", err)
+		http.Error(w, "Error committing transaction: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.RotateAuthTokenResponse{Token: token})
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully rotated auth token")
+}
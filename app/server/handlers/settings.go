@@ -7,7 +7,10 @@ import (
 	"log"
 	"net/http"
 	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/types"
 	"reflect"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/plandex/plandex/shared"
@@ -78,7 +81,7 @@ func UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("planId: ", planId, "branch: ", branch)
 
-	plan := authorizePlan(w, planId, auth)
+	plan := authorizePlanWrite(w, planId, auth)
 
 	if plan == nil {
 		return
@@ -93,6 +96,53 @@ func UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Settings != nil {
+		if !validateWorkspaceRoots(w, req.Settings.Workspaces) {
+			return
+		}
+		req.Settings.Workspaces = shared.NormalizeWorkspaceRoots(req.Settings.Workspaces)
+
+		if !req.Settings.AutoContinue.Valid() {
+			log.Println("Invalid auto-continue policy: ", req.Settings.AutoContinue)
+			http.Error(w, "Invalid auto-continue policy: "+string(req.Settings.AutoContinue), http.StatusBadRequest)
+			return
+		}
+
+		if !req.Settings.OnBuildError.Valid() {
+			log.Println("Invalid build error policy: ", req.Settings.OnBuildError)
+			http.Error(w, "Invalid build error policy: "+string(req.Settings.OnBuildError), http.StatusBadRequest)
+			return
+		}
+
+		if !req.Settings.BuildStrategy.Valid() {
+			log.Println("Invalid build strategy: ", req.Settings.BuildStrategy)
+			http.Error(w, "Invalid build strategy: "+string(req.Settings.BuildStrategy), http.StatusBadRequest)
+			return
+		}
+
+		if req.Settings.MaxBuildFileTokens < 0 {
+			log.Println("Invalid max build file tokens: ", req.Settings.MaxBuildFileTokens)
+			http.Error(w, "Invalid max build file tokens: "+strconv.Itoa(req.Settings.MaxBuildFileTokens), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Settings != nil {
+		if !validateWorkspaceRoots(w, req.Settings.Workspaces) {
+			return
+		}
+		req.Settings.Workspaces = shared.NormalizeWorkspaceRoots(req.Settings.Workspaces)
+	}
+
+	if req.Settings != nil && req.Settings.ModelPack != nil {
+		err = req.Settings.ModelPack.Validate()
+		if err != nil {
+			log.Println("Invalid model settings: ", err)
+			http.Error(w, "Invalid model settings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
 	if unlockFn == nil {
@@ -152,6 +202,141 @@ func UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
+func ValidateSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ValidateSettingsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	plan := authorizePlan(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	var req shared.ValidateSettingsRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Println("Error decoding request body: ", err)
+		http.Error(w, "Error decoding request body", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	originalSettings, err := db.GetPlanSettings(plan, true)
+
+	if err != nil {
+		log.Println("Error getting settings: ", err)
+		http.Error(w, "Error getting settings", http.StatusInternalServerError)
+		return
+	}
+
+	err = writeSettingsCheck(w, auth.OrgId, req.Settings, originalSettings)
+
+	if err != nil {
+		return
+	}
+
+	log.Println("ValidateSettingsHandler processed successfully")
+}
+
+func ValidateDefaultSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ValidateDefaultSettingsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	var req shared.ValidateSettingsRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Println("Error decoding request body: ", err)
+		http.Error(w, "Error decoding request body", http.StatusInternalServerError)
+		return
+	}
+
+	originalSettings, err := db.GetOrgDefaultSettings(auth.OrgId, true)
+
+	if err != nil {
+		log.Println("Error getting default settings: ", err)
+		http.Error(w, "Error getting default settings", http.StatusInternalServerError)
+		return
+	}
+
+	err = writeSettingsCheck(w, auth.OrgId, req.Settings, originalSettings)
+
+	if err != nil {
+		return
+	}
+
+	log.Println("ValidateDefaultSettingsHandler processed successfully")
+}
+
+// writeSettingsCheck checks proposed settings against the org's custom models, the built-in models, and the pricing catalog, and writes the issues found. Errors are written to the response before they're returned.
+func writeSettingsCheck(w http.ResponseWriter, orgId string, settings, originalSettings *shared.PlanSettings) error {
+	if settings == nil {
+		log.Println("Settings are required")
+		http.Error(w, "Settings are required", http.StatusBadRequest)
+		return fmt.Errorf("settings are required")
+	}
+
+	customModels, err := db.ListCustomModels(orgId)
+
+	if err != nil {
+		log.Println("Error getting custom models: ", err)
+		http.Error(w, "Error getting custom models", http.StatusInternalServerError)
+		return err
+	}
+
+	var apiCustomModels []*shared.AvailableModel
+	for _, m := range customModels {
+		apiCustomModels = append(apiCustomModels, m.ToApi())
+	}
+
+	issues := model.CheckSettings(model.CheckSettingsParams{
+		Settings:     settings,
+		Original:     originalSettings,
+		CustomModels: apiCustomModels,
+		GetPricing:   db.GetModelPricing,
+	})
+
+	res := shared.ValidateSettingsResponse{
+		Valid:  !shared.SettingsIssuesHaveErrors(issues),
+		Issues: issues,
+	}
+
+	bytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Println("Error marshalling response: ", err)
+		http.Error(w, "Error marshalling response", http.StatusInternalServerError)
+		return err
+	}
+
+	w.Write(bytes)
+
+	return nil
+}
+
 func GetDefaultSettingsHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for GetDefaultSettingsHandler")
 
@@ -190,6 +375,12 @@ func UpdateDefaultSettingsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.HasPermission(types.PermissionUpdateOrgSettings) {
+		log.Println("User doesn't have permission to update default settings")
+		http.Error(w, "User doesn't have permission to update default settings", http.StatusForbidden)
+		return
+	}
+
 	var req shared.UpdateSettingsRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 
@@ -199,6 +390,22 @@ func UpdateDefaultSettingsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Settings != nil {
+		if !validateWorkspaceRoots(w, req.Settings.Workspaces) {
+			return
+		}
+		req.Settings.Workspaces = shared.NormalizeWorkspaceRoots(req.Settings.Workspaces)
+	}
+
+	if req.Settings != nil && req.Settings.ModelPack != nil {
+		err = req.Settings.ModelPack.Validate()
+		if err != nil {
+			log.Println("Invalid model settings: ", err)
+			http.Error(w, "Invalid model settings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	tx, err := db.Conn.Beginx()
 
 	if err != nil {
@@ -272,6 +479,19 @@ func UpdateDefaultSettingsHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("UpdateDefaultSettingsHandler processed successfully")
 }
 
+// validateWorkspaceRoots rejects workspace roots outside the project, since paths in model output are resolved against them
+func validateWorkspaceRoots(w http.ResponseWriter, roots []string) bool {
+	for _, root := range roots {
+		err := shared.ValidateWorkspaceRoot(root)
+		if err != nil {
+			log.Println("Invalid workspace root: ", err)
+			http.Error(w, "Invalid workspace root: "+err.Error(), http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
 func getUpdateCommitMsg(settings *shared.PlanSettings, originalSettings *shared.PlanSettings, isOrgDefault bool) string {
 	// log.Println("Comparing settings")
 	// log.Println("Original:")
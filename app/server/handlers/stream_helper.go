@@ -66,6 +66,15 @@ func startResponseStream(w http.ResponseWriter, auth *types.ServerAuth, planId,
 		time.Sleep(50 * time.Millisecond)
 	} else {
 		time.Sleep(100 * time.Millisecond)
+
+		// a build's estimate is streamed before the client that started it is subscribed
+		if active.BuildEstimate != nil {
+			err = sendBuildEstimate(w, active)
+			if err != nil {
+				log.Println("Response stream manager: error sending build estimate:", err)
+				return
+			}
+		}
 	}
 
 	for {
@@ -99,6 +108,33 @@ func sendStreamMessage(w http.ResponseWriter, msg string) error {
 	return nil
 }
 
+func sendBuildEstimate(w http.ResponseWriter, active *types.ActivePlan) error {
+	msgs := []shared.StreamMessage{{
+		Type:          shared.StreamMessageBuildEstimate,
+		BuildEstimate: active.BuildEstimate,
+	}}
+
+	if active.IsBuildPaused() {
+		msgs = append(msgs, shared.StreamMessage{
+			Type: shared.StreamMessageBuildPaused,
+		})
+	}
+
+	for _, msg := range msgs {
+		bytes, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("error marshalling message: %v", err)
+		}
+
+		err = sendStreamMessage(w, string(bytes))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func initConnectActive(auth *types.ServerAuth, planId, branch string, w http.ResponseWriter) error {
 	log.Println("Response stream manager: initializing connection to active plan")
 
@@ -120,6 +156,12 @@ func initConnectActive(auth *types.ServerAuth, planId, branch string, w http.Res
 		msg.InitBuildOnly = true
 	}
 
+	if active.IsBuildPaused() {
+		msg.InitBuildPaused = true
+	}
+
+	msg.BuildEstimate = active.BuildEstimate
+
 	if len(active.StoredReplyIds) > 0 {
 		convo, err := db.GetPlanConvo(auth.OrgId, active.Id)
 		if err != nil {
@@ -144,6 +186,13 @@ func initConnectActive(auth *types.ServerAuth, planId, branch string, w http.Res
 
 	if active.MissingFilePath != "" {
 		msg.MissingFilePath = active.MissingFilePath
+		msg.MissingFileAutoChoice = active.MissingFileAutoChoice
+		msg.MissingFileOutsideWorkspaces = active.MissingFileOutsideWorkspaces
+	}
+
+	if active.PromptingContinue {
+		msg.PromptingContinue = true
+		msg.ContinueNextTask = active.ContinueNextTask
 	}
 
 	bytes, err := json.Marshal(msg)
@@ -201,3 +250,35 @@ func initConnectActive(auth *types.ServerAuth, planId, branch string, w http.Res
 
 	return nil
 }
+
+// streamReply streams a reply that isn't part of a plan's stream, like a chat, with the same messages as a plan's stream: reply chunks, then finished or error. An error from stream is sent to the client as well as returned.
+func streamReply(w http.ResponseWriter, stream func(onChunk func(string) error) error) error {
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	send := func(msg shared.StreamMessage) error {
+		bytes, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return sendStreamMessage(w, string(bytes))
+	}
+
+	err := stream(func(chunk string) error {
+		return send(shared.StreamMessage{Type: shared.StreamMessageReply, ReplyChunk: chunk})
+	})
+
+	if err != nil {
+		send(shared.StreamMessage{
+			Type: shared.StreamMessageError,
+			Error: &shared.ApiError{
+				Type:   shared.ApiErrorTypeOther,
+				Status: http.StatusInternalServerError,
+				Msg:    err.Error(),
+			},
+		})
+		return err
+	}
+
+	return send(shared.StreamMessage{Type: shared.StreamMessageFinished})
+}
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"plandex-server/db"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetOrgTrialPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetOrgTrialPolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	policy, err := db.GetOrgTrialPolicy(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting trial policy: %v\n", err)
+		http.Error(w, "Error getting trial policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(policy)
+
+	if err != nil {
+		log.Printf("Error marshalling trial policy: %v\n", err)
+		http.Error(w, "Error marshalling trial policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved trial policy")
+}
+
+func UpdateOrgTrialPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateOrgTrialPolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if os.Getenv("IS_CLOUD") != "" {
+		log.Println("Trial policies are only available on self-hosted servers")
+		http.Error(w, "Trial policies are only available on self-hosted servers", http.StatusBadRequest)
+		return
+	}
+
+	// trial users join as members, so managing trials takes the same permission as inviting members
+	memberRoleId, err := db.GetOrgMemberRoleId()
+
+	if err != nil {
+		log.Printf("Error getting member role: %v\n", err)
+		http.Error(w, "Error getting member role: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	permission := types.Permission(strings.Join([]string{string(types.PermissionInviteUser), memberRoleId}, "|"))
+
+	if !auth.HasPermission(permission) {
+		log.Println("User doesn't have permission to update trial policy")
+		http.Error(w, "User doesn't have permission to update trial policy", http.StatusForbidden)
+		return
+	}
+
+	var req shared.UpdateOrgTrialPolicyRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Policy == nil {
+		log.Println("Missing trial policy")
+		http.Error(w, "Missing trial policy", http.StatusBadRequest)
+		return
+	}
+
+	err = req.Policy.Validate()
+
+	if err != nil {
+		log.Printf("Invalid trial policy: %v\n", err)
+		http.Error(w, "Invalid trial policy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	accessCode, err := db.StoreOrgTrialPolicy(auth.OrgId, req.Policy, req.RotateAccessCode)
+
+	if err != nil {
+		log.Printf("Error storing trial policy: %v\n", err)
+		http.Error(w, "Error storing trial policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	policy, err := db.GetOrgTrialPolicy(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting trial policy: %v\n", err)
+		http.Error(w, "Error getting trial policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.UpdateOrgTrialPolicyResponse{
+		Policy:     policy,
+		AccessCode: accessCode,
+	})
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully updated trial policy")
+}
+
+// StartGuestTrialHandler creates a trial user in the org an access code belongs to. The user joins with the guest role, which can't change org settings, and is limited by the org's trial policy until they sign up.
+func StartGuestTrialHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for StartGuestTrialHandler")
+
+	if os.Getenv("IS_CLOUD") != "" {
+		log.Println("Guest trials are only available on self-hosted servers")
+		http.Error(w, "Guest trials are only available on self-hosted servers", http.StatusBadRequest)
+		return
+	}
+
+	var req shared.StartGuestTrialRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	policy, err := db.GetEnabledTrialPolicyByAccessCode(strings.TrimSpace(req.AccessCode))
+
+	if err != nil {
+		log.Printf("Error getting trial policy: %v\n", err)
+		http.Error(w, "Error getting trial policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if policy == nil {
+		log.Println("Invalid trial access code")
+		http.Error(w, "Invalid trial access code, or trials are disabled for the org", http.StatusForbidden)
+		return
+	}
+
+	org, err := db.GetOrg(policy.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting org: %v\n", err)
+		http.Error(w, "Error getting org: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	guestRoleId, err := db.GetOrgGuestRoleId()
+
+	if err != nil {
+		log.Printf("Error getting guest role: %v\n", err)
+		http.Error(w, "Error getting guest role: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// start a transaction
+	tx, err := db.Conn.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v\n", err)
+		http.Error(w, "Error starting transaction: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	b, err := shared.GetRandomAlphanumeric(6)
+	if err != nil {
+		log.Printf("Error generating random tag: %v\n", err)
+		http.Error(w, "Error generating random tag: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tag := fmt.Sprintf("%x", b)
+	tag = strings.ToLower(tag)
+
+	user := &db.User{
+		Name:    "Trial User " + tag,
+		Email:   tag + "@trial.plandex.ai",
+		Domain:  "trial.plandex.ai",
+		IsTrial: true,
+	}
+	err = db.CreateUser(user, tx)
+
+	if err != nil {
+		log.Printf("Error creating user: %v\n", err)
+		http.Error(w, "Error creating user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = db.CreateOrgUser(org.Id, user.Id, guestRoleId, tx)
+	if err != nil {
+		log.Printf("Error inserting org user: %v\n", err)
+		http.Error(w, "Error inserting org user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	token, _, err := db.CreateAuthToken(user.Id, true, tx)
+
+	if err != nil {
+		log.Printf("Error creating auth token: %v\n", err)
+		http.Error(w, "Error creating auth token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing transaction: %v\n", err)
+		http.Error(w, "Error committing transaction: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.StartTrialResponse{
+		UserId:   user.Id,
+		OrgId:    org.Id,
+		Token:    token,
+		UserName: user.Name,
+		OrgName:  org.Name,
+		Email:    user.Email,
+	})
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully started guest trial")
+}
+
+// convertGuestToExistingAccount moves a guest's plans, builds, and usage to an account that already exists on the server, adding that account to the org as a member if needed
+func convertGuestToExistingAccount(w http.ResponseWriter, auth *types.ServerAuth, req shared.ConvertTrialRequest, emailVerificationId string) {
+	user, err := db.GetUserByEmail(req.Email)
+
+	if err != nil {
+		log.Printf("Error getting user: %v\n", err)
+		http.Error(w, "Error getting user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if user == nil {
+		log.Printf("User not found for email: %v\n", req.Email)
+		http.Error(w, "User not found for email: "+req.Email, http.StatusNotFound)
+		return
+	}
+
+	orgUser, err := db.GetOrgUser(user.Id, auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting org user: %v\n", err)
+		http.Error(w, "Error getting org user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	memberRoleId, err := db.GetOrgMemberRoleId()
+
+	if err != nil {
+		log.Printf("Error getting member role: %v\n", err)
+		http.Error(w, "Error getting member role: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// start a transaction
+	tx, err := db.Conn.Beginx()
+	if err != nil {
+		log.Printf("Error starting transaction: %v\n", err)
+		http.Error(w, "Error starting transaction: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	err = db.TransferTrialUserData(auth.OrgId, auth.User.Id, user.Id, tx)
+
+	if err != nil {
+		log.Printf("Error transferring trial data: %v\n", err)
+		http.Error(w, "Error transferring trial data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if orgUser == nil {
+		err = db.CreateOrgUser(auth.OrgId, user.Id, memberRoleId, tx)
+
+		if err != nil {
+			log.Printf("Error inserting org user: %v\n", err)
+			http.Error(w, "Error inserting org user: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	token, authTokenId, err := db.CreateAuthToken(user.Id, false, tx)
+
+	if err != nil {
+		log.Printf("Error creating auth token: %v\n", err)
+		http.Error(w, "Error creating auth token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = tx.Exec("UPDATE email_verifications SET user_id = $1, auth_token_id = $2 WHERE id = $3", user.Id, authTokenId, emailVerificationId)
+
+	if err != nil {
+		log.Printf("Error updating email verification: %v\n", err)
+		http.Error(w, "Error updating email verification: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing transaction: %v\n", err)
+		http.Error(w, "Error committing transaction: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	orgs, err := db.GetAccessibleOrgsForUser(user)
+
+	if err != nil {
+		log.Printf("Error getting orgs for user: %v\n", err)
+		http.Error(w, "Error getting orgs for user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var apiOrgs []*shared.Org
+	for _, org := range orgs {
+		apiOrgs = append(apiOrgs, org.ToApi())
+	}
+
+	bytes, err := json.Marshal(shared.SessionResponse{
+		UserId:   user.Id,
+		Token:    token,
+		Email:    user.Email,
+		UserName: user.Name,
+		Orgs:     apiOrgs,
+	})
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully moved trial data to existing account")
+}
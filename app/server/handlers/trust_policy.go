@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/types"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func GetOrgTrustPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetOrgTrustPolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	policy, err := db.GetOrgTrustPolicy(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error getting trust policy: %v\n", err)
+		http.Error(w, "Error getting trust policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(policy)
+
+	if err != nil {
+		log.Printf("Error marshalling trust policy: %v\n", err)
+		http.Error(w, "Error marshalling trust policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved org trust policy")
+}
+
+func UpdateOrgTrustPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateOrgTrustPolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	// the org policy applies to every plan in the org
+	if !auth.HasPermission(types.PermissionUpdateAnyPlan) {
+		log.Println("User doesn't have permission to update org trust policy")
+		http.Error(w, "User doesn't have permission to update org trust policy", http.StatusForbidden)
+		return
+	}
+
+	policy := decodeTrustPolicy(w, r)
+	if policy == nil {
+		return
+	}
+
+	err := db.StoreOrgTrustPolicy(auth.OrgId, policy)
+
+	if err != nil {
+		log.Printf("Error storing trust policy: %v\n", err)
+		http.Error(w, "Error storing trust policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully updated org trust policy")
+}
+
+// GetProjectTrustPolicyHandler returns the org's policy along with the project's own policy, if it has one
+func GetProjectTrustPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetProjectTrustPolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectId := vars["projectId"]
+
+	log.Println("projectId: ", projectId)
+
+	if !authorizeProject(w, projectId, auth) {
+		return
+	}
+
+	policy, err := db.GetEffectiveTrustPolicy(auth.OrgId, projectId)
+
+	if err != nil {
+		log.Printf("Error getting trust policy: %v\n", err)
+		http.Error(w, "Error getting trust policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(policy)
+
+	if err != nil {
+		log.Printf("Error marshalling trust policy: %v\n", err)
+		http.Error(w, "Error marshalling trust policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully retrieved project trust policy")
+}
+
+// UpdateProjectTrustPolicyHandler sets a project's own policy. Since the stricter of the org and project levels always applies, a project policy can only add confirmations, so any org member can set a new one. Only users who can update any plan can loosen an existing one; other members can only tighten it.
+func UpdateProjectTrustPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateProjectTrustPolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if auth.User.IsTrial {
+		writeApiError(w, shared.ApiError{
+			Type:   shared.ApiErrorTypeTrialActionNotAllowed,
+			Status: http.StatusForbidden,
+			Msg:    "Anonymous trial user can't update trust policies",
+		})
+
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectId := vars["projectId"]
+
+	log.Println("projectId: ", projectId)
+
+	if !authorizeProject(w, projectId, auth) {
+		return
+	}
+
+	policy := decodeTrustPolicy(w, r)
+	if policy == nil {
+		return
+	}
+
+	if !auth.HasPermission(types.PermissionUpdateAnyPlan) {
+		currentPolicy, err := db.GetProjectTrustPolicy(projectId)
+
+		if err != nil {
+			log.Printf("Error getting trust policy: %v\n", err)
+			http.Error(w, "Error getting trust policy: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if currentPolicy != nil && !policy.AtLeastAsStrictAs(currentPolicy) {
+			log.Println("User doesn't have permission to loosen the project's trust policy")
+			http.Error(w, "User doesn't have permission to loosen the project's trust policy", http.StatusForbidden)
+			return
+		}
+	}
+
+	err := db.StoreProjectTrustPolicy(auth.OrgId, projectId, policy)
+
+	if err != nil {
+		log.Printf("Error storing trust policy: %v\n", err)
+		http.Error(w, "Error storing trust policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully updated project trust policy")
+}
+
+func DeleteProjectTrustPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for DeleteProjectTrustPolicyHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if auth.User.IsTrial {
+		writeApiError(w, shared.ApiError{
+			Type:   shared.ApiErrorTypeTrialActionNotAllowed,
+			Status: http.StatusForbidden,
+			Msg:    "Anonymous trial user can't update trust policies",
+		})
+
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectId := vars["projectId"]
+
+	log.Println("projectId: ", projectId)
+
+	if !authorizeProject(w, projectId, auth) {
+		return
+	}
+
+	if !auth.HasPermission(types.PermissionUpdateAnyPlan) {
+		log.Println("User doesn't have permission to delete the project's trust policy")
+		http.Error(w, "User doesn't have permission to delete the project's trust policy", http.StatusForbidden)
+		return
+	}
+
+	err := db.DeleteProjectTrustPolicy(projectId)
+
+	if err != nil {
+		log.Printf("Error deleting trust policy: %v\n", err)
+		http.Error(w, "Error deleting trust policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully deleted project trust policy")
+}
+
+func decodeTrustPolicy(w http.ResponseWriter, r *http.Request) *shared.TrustPolicy {
+	var req shared.UpdateTrustPolicyRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body: "+err.Error(), http.StatusBadRequest)
+		return nil
+	}
+
+	if req.Policy == nil {
+		log.Println("Missing trust policy")
+		http.Error(w, "Missing trust policy", http.StatusBadRequest)
+		return nil
+	}
+
+	req.Policy.NormalizeDirs()
+
+	err = req.Policy.Validate()
+
+	if err != nil {
+		log.Printf("Invalid trust policy: %v\n", err)
+		http.Error(w, "Invalid trust policy: "+err.Error(), http.StatusBadRequest)
+		return nil
+	}
+
+	return req.Policy
+}
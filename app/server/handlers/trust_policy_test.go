@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"plandex-server/db"
+	"plandex-server/storage"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func TestProjectTrustPolicyMemberCanOnlyTighten(t *testing.T) {
+	dir := t.TempDir()
+	db.BaseDir = dir
+	storage.Blobs = storage.NewLocal(filepath.Join(dir, "blobs"))
+	t.Setenv("DATABASE_URL", "sqlite://"+filepath.Join(dir, "plandex.db"))
+
+	err := db.Connect()
+	if err != nil {
+		t.Fatalf("error connecting to test db: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Conn.Close()
+	})
+
+	err = db.MigrationsUp(0)
+	if err != nil {
+		t.Fatalf("error running migrations: %v", err)
+	}
+
+	createUser := func(email string) string {
+		var userId string
+		err := db.Conn.QueryRow("INSERT INTO users (name, email, domain, is_trial) VALUES ($1, $2, $3, $4) RETURNING id", "Test User", email, "example.com", false).Scan(&userId)
+		if err != nil {
+			t.Fatalf("error creating user: %v", err)
+		}
+		return userId
+	}
+	ownerId := createUser("owner@example.com")
+	memberId := createUser("member@example.com")
+
+	var orgId string
+	err = db.Conn.QueryRow("INSERT INTO orgs (name, owner_id, is_trial) VALUES ($1, $2, $3) RETURNING id", "org", ownerId, false).Scan(&orgId)
+	if err != nil {
+		t.Fatalf("error creating org: %v", err)
+	}
+
+	authHeader := func(userId, roleName string) string {
+		_, err := db.Conn.Exec("INSERT INTO orgs_users (org_id, user_id, org_role_id) VALUES ($1, $2, (SELECT id FROM org_roles WHERE org_id IS NULL AND name = $3))", orgId, userId, roleName)
+		if err != nil {
+			t.Fatalf("error adding org user: %v", err)
+		}
+
+		tx, err := db.Conn.Beginx()
+		if err != nil {
+			t.Fatalf("error starting transaction: %v", err)
+		}
+		token, _, err := db.CreateAuthToken(userId, false, tx)
+		if err != nil {
+			tx.Rollback()
+			t.Fatalf("error creating auth token: %v", err)
+		}
+		err = tx.Commit()
+		if err != nil {
+			t.Fatalf("error committing transaction: %v", err)
+		}
+
+		bytes, err := json.Marshal(shared.AuthHeader{Token: token, OrgId: orgId})
+		if err != nil {
+			t.Fatalf("error marshalling auth header: %v", err)
+		}
+		return "Bearer " + base64.StdEncoding.EncodeToString(bytes)
+	}
+	ownerAuth := authHeader(ownerId, "owner")
+	memberAuth := authHeader(memberId, "member")
+
+	var projectId string
+	err = db.Conn.QueryRow("INSERT INTO projects (org_id, name) VALUES ($1, $2) RETURNING id", orgId, "project").Scan(&projectId)
+	if err != nil {
+		t.Fatalf("error creating project: %v", err)
+	}
+
+	call := func(handler http.HandlerFunc, auth string, policy *shared.TrustPolicy) int {
+		var body []byte
+		if policy != nil {
+			body, err = json.Marshal(shared.UpdateTrustPolicyRequest{Policy: policy})
+			if err != nil {
+				t.Fatalf("error marshalling request: %v", err)
+			}
+		}
+
+		r := httptest.NewRequest(http.MethodPut, "/projects/"+projectId+"/trust_policy", bytes.NewReader(body))
+		r.Header.Set("Authorization", auth)
+		r = mux.SetURLVars(r, map[string]string{"projectId": projectId})
+
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+
+	strict := &shared.TrustPolicy{
+		LoadContext:           shared.TrustLevelConfirm,
+		CreateFiles:           shared.TrustLevelConfirm,
+		DeleteFiles:           shared.TrustLevelDeny,
+		RunCommands:           shared.TrustLevelConfirm,
+		CreateFilesDirs:       []string{"docs"},
+		BuildCostConfirmAbove: 1,
+	}
+	if code := call(UpdateProjectTrustPolicyHandler, ownerAuth, strict); code != http.StatusOK {
+		t.Fatalf("expected the owner to set the policy, got %d", code)
+	}
+
+	loosened := map[string]func(p *shared.TrustPolicy){
+		"action level":       func(p *shared.TrustPolicy) { p.DeleteFiles = shared.TrustLevelConfirm },
+		"create files dir":   func(p *shared.TrustPolicy) { p.CreateFilesDirs = []string{"docs", "src"} },
+		"build cost":         func(p *shared.TrustPolicy) { p.BuildCostConfirmAbove = 5 },
+		"no build cost cap":  func(p *shared.TrustPolicy) { p.BuildCostConfirmAbove = 0 },
+		"create files level": func(p *shared.TrustPolicy) { p.CreateFiles = shared.TrustLevelAllow },
+	}
+	for name, loosen := range loosened {
+		policy := *strict
+		loosen(&policy)
+		if code := call(UpdateProjectTrustPolicyHandler, memberAuth, &policy); code != http.StatusForbidden {
+			t.Errorf("expected a member loosening the policy's %s to be forbidden, got %d", name, code)
+		}
+	}
+
+	stored, err := db.GetProjectTrustPolicy(projectId)
+	if err != nil {
+		t.Fatalf("error getting policy: %v", err)
+	}
+	if stored == nil || !stored.AtLeastAsStrictAs(strict) {
+		t.Fatalf("expected the owner's policy to be unchanged, got %+v", stored)
+	}
+
+	if code := call(DeleteProjectTrustPolicyHandler, memberAuth, nil); code != http.StatusForbidden {
+		t.Errorf("expected a member deleting the policy to be forbidden, got %d", code)
+	}
+
+	tightened := *strict
+	tightened.RunCommands = shared.TrustLevelDeny
+	tightened.CreateFilesDirs = []string{"docs/generated"}
+	tightened.BuildCostConfirmAbove = 0.5
+	if code := call(UpdateProjectTrustPolicyHandler, memberAuth, &tightened); code != http.StatusOK {
+		t.Errorf("expected a member to tighten the policy, got %d", code)
+	}
+
+	if code := call(DeleteProjectTrustPolicyHandler, ownerAuth, nil); code != http.StatusOK {
+		t.Errorf("expected the owner to delete the policy, got %d", code)
+	}
+
+	stored, err = db.GetProjectTrustPolicy(projectId)
+	if err != nil {
+		t.Fatalf("error getting policy: %v", err)
+	}
+	if stored != nil {
+		t.Errorf("expected the policy to be deleted")
+	}
+}
@@ -0,0 +1,38 @@
+package host
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	// RoleAll runs the api and builds in one process
+	RoleAll = "all"
+	// RoleApi handles requests and queues builds for workers
+	RoleApi = "api"
+	// RoleWorker runs queued builds
+	RoleWorker = "worker"
+)
+
+var Role = RoleAll
+
+// LoadRole reads PLANDEX_SERVER_ROLE. Split roles need an address that other instances can reach, since requests for a build are forwarded to the worker running it.
+func LoadRole() error {
+	role := os.Getenv("PLANDEX_SERVER_ROLE")
+
+	switch role {
+	case "", RoleAll:
+		Role = RoleAll
+		return nil
+	case RoleApi, RoleWorker:
+		Role = role
+	default:
+		return fmt.Errorf("invalid PLANDEX_SERVER_ROLE %q -- must be '%s', '%s', or '%s'", role, RoleAll, RoleApi, RoleWorker)
+	}
+
+	if Ip == "" {
+		return fmt.Errorf("PLANDEX_SERVER_ROLE=%s requires IP to be set to an address other server instances can reach", Role)
+	}
+
+	return nil
+}
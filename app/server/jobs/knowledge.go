@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/model/plan"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// how many entries are embedded per request
+const knowledgeIndexBatchSize = 50
+
+// indexKnowledge embeds the knowledge entries that were stored without an embedding, or with a different embedding model, for each org that has an OpenAI org credential. Other orgs' entries are still embedded when they're searched with a client's key. An org that fails is logged and the rest are still indexed; the first error fails the run so it's retried.
+func indexKnowledge(ctx context.Context) error {
+	orgIds, err := db.ListUnembeddedKnowledgeOrgIds(string(model.EmbeddingModel))
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	numIndexed := 0
+	for _, orgId := range orgIds {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		n, err := indexOrgKnowledge(ctx, orgId)
+		numIndexed += n
+		if err != nil {
+			log.Printf("Error indexing knowledge entries for org %s: %v\n", orgId, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if numIndexed > 0 {
+		log.Printf("Knowledge indexing embedded %d entries\n", numIndexed)
+	}
+
+	return firstErr
+}
+
+func indexOrgKnowledge(ctx context.Context, orgId string) (int, error) {
+	orgApiKeys, err := db.GetOrgApiKeys(orgId)
+	if err != nil {
+		return 0, err
+	}
+
+	apiKey := orgApiKeys[shared.OpenAIEnvVar]
+	if apiKey == "" {
+		return 0, nil
+	}
+
+	// same as for searches: the org's key is only sent to its own endpoint
+	endpoint := db.GetOrgKeyEndpoint(shared.OpenAIEnvVar, orgApiKeys)
+	clients := model.InitClients(map[string]string{shared.OpenAIEnvVar: apiKey}, nil, endpoint, orgApiKeys["OPENAI_ORG_ID"])
+	client := model.EmbeddingClient(clients)
+
+	numIndexed := 0
+	for {
+		entries, err := db.ListUnembeddedKnowledgeEntries(orgId, string(model.EmbeddingModel), knowledgeIndexBatchSize)
+		if err != nil {
+			return numIndexed, err
+		}
+
+		if len(entries) == 0 {
+			return numIndexed, nil
+		}
+
+		texts := make([]string, len(entries))
+		for i, entry := range entries {
+			texts[i] = plan.KnowledgeEmbeddingText(entry.Title, entry.Body)
+		}
+
+		embeddings, err := model.CreateEmbeddings(client, ctx, texts)
+		if err != nil {
+			return numIndexed, fmt.Errorf("error embedding knowledge entries: %v", err)
+		}
+
+		for i, entry := range entries {
+			err = db.SetKnowledgeEmbedding(entry, embeddings[i], string(model.EmbeddingModel))
+			if err != nil {
+				return numIndexed, err
+			}
+			numIndexed++
+		}
+
+		if len(entries) < knowledgeIndexBatchSize {
+			return numIndexed, nil
+		}
+	}
+}
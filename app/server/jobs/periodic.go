@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"os"
+	"plandex-server/db"
+	"plandex-server/email"
+	"time"
+)
+
+const (
+	JobKindRetention        = "retention"
+	JobKindResponseCacheGC  = "response_cache_gc"
+	JobKindContextBlobGC    = "context_blob_gc"
+	JobKindModelPricesFetch = "model_prices_fetch"
+	JobKindActivityDigest   = "activity_digest"
+	JobKindKnowledgeIndex   = "knowledge_index"
+)
+
+const periodicJobMaxAttempts = 3
+
+// periodicJob is server work that's scheduled every interval, read from intervalEnvVar (if it has one) each time it's scheduled so a config reload applies from the next run. An interval of 0 disables it.
+type periodicJob struct {
+	kind            string
+	intervalEnvVar  string
+	defaultInterval time.Duration
+	// also run when the server starts, even with an interval of 0
+	runAtStartup bool
+	// a job that needs config to run is only scheduled when the config is set
+	enabled func() bool
+	run     func(ctx context.Context) error
+}
+
+var periodicJobs = []periodicJob{
+	{
+		kind:            JobKindRetention,
+		intervalEnvVar:  "PLANDEX_RETENTION_INTERVAL",
+		defaultInterval: 1 * time.Hour,
+		run: func(ctx context.Context) error {
+			return db.ApplyRetentionPolicies()
+		},
+	},
+	{
+		kind:            JobKindResponseCacheGC,
+		intervalEnvVar:  "PLANDEX_RETENTION_INTERVAL",
+		defaultInterval: 1 * time.Hour,
+		run: func(ctx context.Context) error {
+			return db.DeleteExpiredCachedModelResponses()
+		},
+	},
+	{
+		kind:            JobKindContextBlobGC,
+		intervalEnvVar:  "PLANDEX_CONTEXT_BLOB_GC_INTERVAL",
+		defaultInterval: 24 * time.Hour,
+		run: func(ctx context.Context) error {
+			numRemoved, err := db.GCContextBlobs()
+			if err != nil {
+				return err
+			}
+			log.Printf("Context blob GC removed %d blobs\n", numRemoved)
+			return nil
+		},
+	},
+	{
+		kind:            JobKindModelPricesFetch,
+		intervalEnvVar:  "PLANDEX_MODEL_PRICES_INTERVAL",
+		defaultInterval: 24 * time.Hour,
+		runAtStartup:    true,
+		enabled: func() bool {
+			return os.Getenv("PLANDEX_MODEL_PRICES_URL") != ""
+		},
+		run: func(ctx context.Context) error {
+			numUpdated, err := db.FetchModelPrices()
+			if err != nil {
+				return err
+			}
+			log.Printf("Fetched model prices, %d updated\n", numUpdated)
+			return nil
+		},
+	},
+	{
+		// checks for due digests, which go out at the digest hour
+		kind:            JobKindActivityDigest,
+		defaultInterval: 15 * time.Minute,
+		enabled: func() bool {
+			// digests are only logged in development
+			return os.Getenv("GOENV") != "production" || email.CanSend()
+		},
+		run: func(ctx context.Context) error {
+			return db.SendActivityDigests(ctx)
+		},
+	},
+	{
+		kind:            JobKindKnowledgeIndex,
+		intervalEnvVar:  "PLANDEX_KNOWLEDGE_INDEX_INTERVAL",
+		defaultInterval: 15 * time.Minute,
+		run:             indexKnowledge,
+	},
+}
+
+func registerPeriodicJobs() {
+	for _, p := range periodicJobs {
+		run := p.run
+		Register(p.kind, func(ctx context.Context, job *db.Job) error {
+			return run(ctx)
+		})
+	}
+}
+
+// schedulePeriodicJobs queues the next run of each periodic job that doesn't have one queued or running. Each kind's dedupe key is the kind itself, so processes scheduling at the same time don't queue extra runs.
+func schedulePeriodicJobs(startup bool) {
+	for _, p := range periodicJobs {
+		if p.enabled != nil && !p.enabled() {
+			continue
+		}
+
+		interval := p.interval(startup)
+		kind := p.kind
+
+		if startup && p.runAtStartup {
+			queued, err := db.EnqueueJob(&db.Job{Kind: kind, DedupeKey: &kind, MaxAttempts: periodicJobMaxAttempts})
+			if err != nil {
+				log.Printf("Error queueing %s job: %v\n", kind, err)
+				continue
+			}
+			if !queued {
+				err = db.RunQueuedJobNow(kind)
+				if err != nil {
+					log.Printf("Error running queued %s job now: %v\n", kind, err)
+				}
+			}
+			continue
+		}
+
+		if interval <= 0 {
+			if startup {
+				log.Printf("Periodic %s job disabled\n", kind)
+			}
+			continue
+		}
+
+		last, err := db.GetLastFinishedJob(kind)
+		if err != nil {
+			log.Printf("Error getting last %s job: %v\n", kind, err)
+			continue
+		}
+
+		var lastFinishedAt *time.Time
+		if last != nil {
+			lastFinishedAt = last.FinishedAt
+		}
+
+		_, err = db.EnqueueJob(&db.Job{
+			Kind:        kind,
+			DedupeKey:   &kind,
+			MaxAttempts: periodicJobMaxAttempts,
+			RunAt:       nextRunAt(lastFinishedAt, interval, time.Now()),
+		})
+		if err != nil {
+			log.Printf("Error queueing %s job: %v\n", kind, err)
+		}
+	}
+}
+
+// interval reads the job's interval from its env var. An invalid value is only logged at startup, since it's read again every time jobs are scheduled.
+func (p periodicJob) interval(logInvalid bool) time.Duration {
+	if p.intervalEnvVar == "" {
+		return p.defaultInterval
+	}
+
+	s := os.Getenv(p.intervalEnvVar)
+	if s == "" {
+		return p.defaultInterval
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		if logInvalid {
+			log.Printf("Invalid %s %q, using default of %s: %v\n", p.intervalEnvVar, s, p.defaultInterval, err)
+		}
+		return p.defaultInterval
+	}
+
+	return d
+}
+
+// nextRunAt is one interval after the last run finished, or one interval from now if there hasn't been a run. A run that's overdue, like after the server was down, runs right away.
+func nextRunAt(lastFinishedAt *time.Time, interval time.Duration, now time.Time) time.Time {
+	if lastFinishedAt == nil {
+		return now.Add(interval)
+	}
+
+	next := lastFinishedAt.Add(interval)
+	if next.Before(now) {
+		return now
+	}
+	return next
+}
@@ -0,0 +1,205 @@
+// Package jobs runs the server's background work from the jobs table, so it's shared across server processes, retried when it fails, and visible (and retryable or cancelable) through the admin jobs endpoints. Knowledge entries that were stored without an embedding are indexed here with the org's OpenAI credential. Plan and file change summaries aren't run here, since they use the api keys sent with the plan's request, which are never stored. The server doesn't deliver webhooks.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"plandex-server/db"
+	"plandex-server/host"
+	"runtime/debug"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const defaultRunnerConcurrency = 2
+
+const jobPollInterval = 5 * time.Second
+
+// how often periodic jobs are scheduled, stale jobs are requeued, and old jobs are deleted
+const jobSchedulerInterval = 1 * time.Minute
+
+const jobHeartbeatInterval = 15 * time.Second
+
+// a running job whose runner hasn't sent a heartbeat for this long is assumed to have stopped with its process
+const staleJobTimeout = 5 * time.Minute
+
+const finishedJobRetention = 7 * 24 * time.Hour
+
+const baseRetryBackoff = 30 * time.Second
+const maxRetryBackoff = 1 * time.Hour
+
+// Handler runs a job. ctx is canceled if the job is canceled while it's running. A returned error fails the attempt, and the job is retried with backoff if it has attempts left.
+type Handler func(ctx context.Context, job *db.Job) error
+
+var handlers = map[string]Handler{}
+
+var shuttingDown atomic.Bool
+
+// Register sets the handler for a kind of job. It must be called before Start.
+func Register(kind string, handler Handler) {
+	handlers[kind] = handler
+}
+
+// Start schedules the server's periodic jobs and starts running queued jobs, at most PLANDEX_JOB_RUNNER_CONCURRENCY (default 2) at a time. Every server process runs jobs, and each job is only claimed by one of them.
+func Start() error {
+	concurrency := defaultRunnerConcurrency
+	if s := os.Getenv("PLANDEX_JOB_RUNNER_CONCURRENCY"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid PLANDEX_JOB_RUNNER_CONCURRENCY %q -- must be a positive integer", s)
+		}
+		concurrency = n
+	}
+
+	registerPeriodicJobs()
+
+	schedulePeriodicJobs(true)
+
+	go func() {
+		for {
+			time.Sleep(jobSchedulerInterval)
+
+			numRequeued, err := db.RequeueStaleJobs(time.Now().Add(-staleJobTimeout))
+			if err != nil {
+				log.Printf("Error requeueing stale jobs: %v\n", err)
+			} else if numRequeued > 0 {
+				log.Printf("Requeued %d stale job(s)\n", numRequeued)
+			}
+
+			schedulePeriodicJobs(false)
+
+			err = db.DeleteOldJobs(time.Now().Add(-finishedJobRetention))
+			if err != nil {
+				log.Printf("Error deleting old jobs: %v\n", err)
+			}
+		}
+	}()
+
+	var kinds []string
+	for kind := range handlers {
+		kinds = append(kinds, kind)
+	}
+
+	for i := 0; i < concurrency; i++ {
+		go runLoop(kinds)
+	}
+
+	log.Printf("Started job runner with concurrency %d\n", concurrency)
+
+	return nil
+}
+
+// SetShuttingDown stops the runner from claiming new jobs. Jobs that are interrupted when the process exits are requeued once their heartbeats stop.
+func SetShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+func runLoop(kinds []string) {
+	for {
+		if shuttingDown.Load() {
+			return
+		}
+
+		job, err := db.ClaimJob(host.Ip, kinds)
+		if err != nil {
+			log.Printf("Error claiming job: %v\n", err)
+		}
+
+		if job == nil {
+			time.Sleep(jobPollInterval)
+			continue
+		}
+
+		runJob(job)
+	}
+}
+
+func runJob(job *db.Job) {
+	log.Printf("Running %s job %s (attempt %d of %d)\n", job.Kind, job.Id, job.Attempts, job.MaxAttempts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(jobHeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				running, err := db.JobHeartbeat(job.Id)
+				if err != nil {
+					log.Printf("Error sending heartbeat for job %s: %v\n", job.Id, err)
+					continue
+				}
+				if !running {
+					log.Printf("Job %s was canceled\n", job.Id)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	err := callHandler(ctx, job)
+
+	if err == nil {
+		log.Printf("Finished %s job %s\n", job.Kind, job.Id)
+		err = db.SetJobSucceeded(job.Id)
+		if err != nil {
+			log.Printf("Error setting job %s succeeded: %v\n", job.Id, err)
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	if job.Attempts < job.MaxAttempts {
+		t := time.Now().Add(retryBackoff(job.Attempts))
+		retryAt = &t
+		log.Printf("Error running %s job %s, retrying at %s: %v\n", job.Kind, job.Id, t.Format(time.RFC3339), err)
+	} else {
+		log.Printf("Error running %s job %s, no attempts left: %v\n", job.Kind, job.Id, err)
+	}
+
+	err = db.SetJobFailed(job.Id, err.Error(), retryAt)
+	if err != nil {
+		log.Printf("Error setting job %s failed: %v\n", job.Id, err)
+	}
+}
+
+// callHandler runs the job's handler, turning a panic into an error so it fails the attempt instead of the server
+func callHandler(ctx context.Context, job *db.Job) (err error) {
+	handler, ok := handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler for job kind %s", job.Kind)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic in %s job %s: %v\n%s\n", job.Kind, job.Id, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+// retryBackoff is how long to wait before retrying a job that has failed the given number of attempts, doubling from baseRetryBackoff up to maxRetryBackoff
+func retryBackoff(attempts int) time.Duration {
+	backoff := baseRetryBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= maxRetryBackoff {
+			return maxRetryBackoff
+		}
+	}
+	return backoff
+}
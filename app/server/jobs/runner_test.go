@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, 1 * time.Minute},
+		{3, 2 * time.Minute},
+		{8, 1 * time.Hour},
+		{50, 1 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		got := retryBackoff(tt.attempts)
+		if got != tt.want {
+			t.Errorf("retryBackoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestNextRunAt(t *testing.T) {
+	now := time.Date(2024, 5, 29, 12, 0, 0, 0, time.UTC)
+
+	got := nextRunAt(nil, time.Hour, now)
+	if !got.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected a first run one interval from now, got %s", got)
+	}
+
+	lastFinished := now.Add(-20 * time.Minute)
+	got = nextRunAt(&lastFinished, time.Hour, now)
+	if !got.Equal(now.Add(40 * time.Minute)) {
+		t.Errorf("expected the next run one interval after the last, got %s", got)
+	}
+
+	lastFinished = now.Add(-3 * time.Hour)
+	got = nextRunAt(&lastFinished, time.Hour, now)
+	if !got.Equal(now) {
+		t.Errorf("expected an overdue run to run now, got %s", got)
+	}
+}
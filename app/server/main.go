@@ -1,14 +1,20 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"plandex-server/config"
 	"plandex-server/db"
+	"plandex-server/handlers"
 	"plandex-server/host"
+	"plandex-server/jobs"
+	"plandex-server/model"
 	"plandex-server/model/plan"
+	"plandex-server/storage"
 	"syscall"
 	"time"
 
@@ -16,20 +22,110 @@ import (
 )
 
 func main() {
+	err := config.Load()
+	if err != nil {
+		log.Fatal("Error loading config file: ", err)
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrateCmd(os.Args[2:])
+			return
+		case "migrate-blobs":
+			migrateBlobs(os.Args[2:])
+			return
+		case "backup":
+			runBackupCmd(os.Args[2:])
+			return
+		case "restore":
+			runRestoreCmd(os.Args[2:])
+			return
+		case "encrypt-existing":
+			encryptExisting(os.Args[2:])
+			return
+		case "replay":
+			runReplayCmd(os.Args[2:])
+			return
+		case "eval":
+			runEvalCmd(os.Args[2:])
+			return
+		}
+	}
 
-	err := host.LoadIp()
+	err = host.LoadIp()
 	if err != nil {
 		log.Fatal("Error loading IP: ", err)
 	}
 
+	err = host.LoadRole()
+	if err != nil {
+		log.Fatal("Error loading server role: ", err)
+	}
+
 	err = db.Connect()
 	if err != nil {
 		log.Fatal("Error initializing database: ", err)
 	}
 
-	err = db.MigrationsUp()
+	if db.AutoMigrateEnabled() {
+		err = db.MigrationsUp(0)
+		if err != nil {
+			log.Fatal("Error running migrations: ", err)
+		}
+	} else {
+		var status *db.MigrationStatus
+		status, err = db.GetMigrationStatus()
+		if err != nil {
+			log.Fatal("Error getting migration status: ", err)
+		}
+		if !status.IsUpToDate() {
+			log.Fatalf("Database schema is at version %d (dirty: %t), but the latest migration is %d. Run 'plandex-server migrate up' before starting the server.", status.Version, status.Dirty, status.Latest)
+		}
+	}
+
+	err = db.InitEncryption()
 	if err != nil {
-		log.Fatal("Error running migrations: ", err)
+		log.Fatal("Error initializing encryption: ", err)
+	}
+
+	// queued builds hold the client's model provider keys in the database until a worker starts them, so they must only be stored encrypted
+	if host.Role != host.RoleAll && !db.EncryptionEnabled() {
+		log.Fatalf("PLANDEX_SERVER_ROLE=%s requires encryption at rest -- set PLANDEX_ENCRYPTION_PASSPHRASE or PLANDEX_ENCRYPTION_KMS_KEY_ID", host.Role)
+	}
+
+	err = model.InitTransport()
+	if err != nil {
+		log.Fatal("Error initializing model transport: ", err)
+	}
+
+	err = model.InitCallSlots()
+	if err != nil {
+		log.Fatal("Error initializing model call slots: ", err)
+	}
+
+	if model.MockProviderEnabled() {
+		err = model.InitMockProvider()
+		if err != nil {
+			log.Fatal("Error initializing mock model provider: ", err)
+		}
+	}
+
+	err = storage.Init(db.BaseDir)
+	if err != nil {
+		log.Fatal("Error initializing blob storage: ", err)
+	}
+
+	err = jobs.Start()
+	if err != nil {
+		log.Fatal("Error starting job runner: ", err)
+	}
+
+	if host.Role == host.RoleWorker {
+		err = handlers.StartBuildWorker()
+		if err != nil {
+			log.Fatal("Error starting build worker: ", err)
+		}
 	}
 
 	if os.Getenv("GOENV") == "development" {
@@ -42,6 +138,8 @@ func main() {
 		externalPort = "8080"
 	}
 
+	config.WatchSignals()
+
 	go startServer(externalPort, routes())
 	log.Println("Started server on port " + externalPort)
 
@@ -51,6 +149,9 @@ func main() {
 	go func() {
 		<-sigTermChan
 
+		handlers.SetShuttingDown()
+		jobs.SetShuttingDown()
+
 		for {
 			l := plan.NumActivePlans()
 			if l == 0 {
@@ -72,3 +173,70 @@ func startServer(port string, routes *mux.Router) {
 		log.Fatalf("Failed to start server on port %s: %v", port, err)
 	}
 }
+
+// migrateBlobs copies existing context bodies and build captures into the configured blob store (see PLANDEX_BLOB_STORAGE)
+func migrateBlobs(args []string) {
+	flags := flag.NewFlagSet("migrate-blobs", flag.ExitOnError)
+	removeLocal := flags.Bool("remove-local", false, "delete local blobs after copying them to remote storage")
+	flags.Parse(args)
+
+	err := db.InitEncryption()
+	if err != nil {
+		log.Fatal("Error initializing encryption: ", err)
+	}
+
+	// build captures are moved out of the database, and legacy context bodies are encrypted as they're copied, which needs each org's data key from the database
+	err = db.Connect()
+	if err != nil {
+		log.Fatal("Error initializing database: ", err)
+	}
+
+	err = storage.Init(db.BaseDir)
+	if err != nil {
+		log.Fatal("Error initializing blob storage: ", err)
+	}
+
+	log.Printf("Migrating context bodies to %s blob storage...\n", storage.Blobs.Name())
+
+	num, err := db.MigrateContextBlobs(*removeLocal)
+	if err != nil {
+		log.Fatal("Error migrating context bodies: ", err)
+	}
+
+	log.Printf("Migrated %d context bodies\n", num)
+
+	num, err = db.MigrateBuildCaptureBodies()
+	if err != nil {
+		log.Fatal("Error migrating build captures: ", err)
+	}
+
+	log.Printf("Migrated %d build captures\n", num)
+}
+
+// encryptExisting encrypts data stored before encryption at rest was enabled (see PLANDEX_ENCRYPTION_PASSPHRASE and PLANDEX_ENCRYPTION_KMS_KEY_ID)
+func encryptExisting(args []string) {
+	flags := flag.NewFlagSet("encrypt-existing", flag.ExitOnError)
+	flags.Parse(args)
+
+	err := db.Connect()
+	if err != nil {
+		log.Fatal("Error initializing database: ", err)
+	}
+
+	err = db.InitEncryption()
+	if err != nil {
+		log.Fatal("Error initializing encryption: ", err)
+	}
+
+	err = storage.Init(db.BaseDir)
+	if err != nil {
+		log.Fatal("Error initializing blob storage: ", err)
+	}
+
+	num, err := db.EncryptExistingData()
+	if err != nil {
+		log.Fatal("Error encrypting existing data: ", err)
+	}
+
+	log.Printf("Encrypted %d existing items\n", num)
+}
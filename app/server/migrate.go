@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"plandex-server/db"
+	"strconv"
+	"strings"
+)
+
+const migrateUsage = `Usage: plandex-server migrate <command> [flags]
+
+Commands:
+  status          Show the current schema version and any pending migrations
+  up              Apply pending migrations (--steps N to apply at most N)
+  down            Roll back migrations (--steps N, or --all to roll back everything)
+  force VERSION   Set the schema version without running migrations, clearing the dirty flag after a failed migration has been fixed manually
+`
+
+// runMigrateCmd manages the database schema with the migrations embedded in the binary
+func runMigrateCmd(args []string) {
+	if len(args) == 0 {
+		fmt.Print(migrateUsage)
+		os.Exit(1)
+	}
+
+	cmd := args[0]
+	args = args[1:]
+
+	switch cmd {
+	case "status", "up", "down", "force":
+	default:
+		fmt.Print(migrateUsage)
+		os.Exit(1)
+	}
+
+	err := db.Connect()
+	if err != nil {
+		log.Fatal("Error initializing database: ", err)
+	}
+
+	switch cmd {
+	case "status":
+		printMigrationStatus()
+
+	case "up":
+		flags := flag.NewFlagSet("migrate up", flag.ExitOnError)
+		steps := flags.Int("steps", 0, "apply at most this many migrations (default all)")
+		flags.Parse(args)
+
+		err = db.MigrationsUp(*steps)
+		if err != nil {
+			log.Fatal("Error running migrations: ", err)
+		}
+		printMigrationStatus()
+
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		steps := flags.Int("steps", 0, "number of migrations to roll back")
+		all := flags.Bool("all", false, "roll back every migration (drops all tables)")
+		yes := flags.Bool("yes", false, "skip the confirmation prompt")
+		flags.Parse(args)
+
+		if *steps <= 0 && !*all {
+			log.Fatal("Specify the number of migrations to roll back with --steps N, or --all to roll back everything")
+		}
+
+		if !*yes {
+			desc := fmt.Sprintf("%d migration(s)", *steps)
+			if *all {
+				desc = "ALL migrations, dropping every table"
+			}
+			if !confirmMigration(fmt.Sprintf("This will roll back %s. Data in affected tables will be lost.", desc)) {
+				log.Println("Aborted")
+				return
+			}
+		}
+
+		err = db.MigrationsDown(*steps, *all)
+		if err != nil {
+			log.Fatal("Error rolling back migrations: ", err)
+		}
+		printMigrationStatus()
+
+	case "force":
+		if len(args) != 1 {
+			log.Fatal("Usage: plandex-server migrate force VERSION")
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			log.Fatal("Invalid version: ", args[0])
+		}
+
+		err = db.MigrationsForce(version)
+		if err != nil {
+			log.Fatal("Error forcing migration version: ", err)
+		}
+		printMigrationStatus()
+	}
+}
+
+func printMigrationStatus() {
+	status, err := db.GetMigrationStatus()
+	if err != nil {
+		log.Fatal("Error getting migration status: ", err)
+	}
+
+	fmt.Printf("Driver:  %s\n", status.Driver)
+	fmt.Printf("Version: %d\n", status.Version)
+	fmt.Printf("Latest:  %d\n", status.Latest)
+	fmt.Printf("Dirty:   %t\n", status.Dirty)
+
+	if status.IsAhead() {
+		fmt.Println("\nThe database schema is newer than this version of plandex-server.")
+	} else if len(status.Pending) > 0 {
+		fmt.Println("\nPending migrations:")
+		for _, v := range status.Pending {
+			fmt.Printf("  %d\n", v)
+		}
+	} else if !status.Dirty {
+		fmt.Println("\nSchema is up to date.")
+	}
+}
+
+func confirmMigration(msg string) bool {
+	fmt.Printf("%s\nType 'yes' to continue: ", msg)
+	reader := bufio.NewReader(os.Stdin)
+	res, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(res) == "yes"
+}
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS org_retention_policies (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  archive_after_days INTEGER NOT NULL DEFAULT 0,
+  delete_archived_after_days INTEGER NOT NULL DEFAULT 0,
+  notify_days_before_delete INTEGER NOT NULL DEFAULT 7,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_org_retention_policies_modtime BEFORE UPDATE ON org_retention_policies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE UNIQUE INDEX org_retention_policies_org_idx ON org_retention_policies(org_id);
+
+ALTER TABLE plans ADD COLUMN deletion_notified_at TIMESTAMP;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS org_data_keys (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  key_source VARCHAR(32) NOT NULL,
+  kms_key_id VARCHAR(2048),
+  salt VARCHAR(64),
+  wrapped_key TEXT NOT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX org_data_keys_org_idx ON org_data_keys(org_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS org_credentials (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  encrypted_value TEXT NOT NULL,
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_org_credentials_modtime BEFORE UPDATE ON org_credentials FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE UNIQUE INDEX org_credentials_name_idx ON org_credentials(org_id, name);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS build_captures (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  plan_build_id UUID NOT NULL REFERENCES plan_builds(id) ON DELETE CASCADE,
+  file_path VARCHAR(255) NOT NULL,
+  phase VARCHAR(32) NOT NULL,
+  model_name VARCHAR(255) NOT NULL,
+  request TEXT NOT NULL,
+  response TEXT NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  started_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX build_captures_plan_idx ON build_captures(plan_id, started_at);
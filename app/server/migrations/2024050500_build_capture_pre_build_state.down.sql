@@ -0,0 +1 @@
+ALTER TABLE build_captures DROP COLUMN IF EXISTS pre_build_state;
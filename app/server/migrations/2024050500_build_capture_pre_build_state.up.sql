@@ -0,0 +1 @@
+ALTER TABLE build_captures ADD COLUMN pre_build_state TEXT NOT NULL DEFAULT '';
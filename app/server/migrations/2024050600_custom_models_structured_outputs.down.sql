@@ -0,0 +1 @@
+ALTER TABLE custom_models DROP COLUMN IF EXISTS has_structured_outputs;
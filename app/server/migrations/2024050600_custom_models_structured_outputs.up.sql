@@ -0,0 +1 @@
+ALTER TABLE custom_models ADD COLUMN has_structured_outputs BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS bench_results;
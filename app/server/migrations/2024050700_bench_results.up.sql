@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS bench_results (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  plan_id UUID REFERENCES plans(id) ON DELETE SET NULL,
+  bench_id UUID NOT NULL,
+  model_pack VARCHAR(255) NOT NULL,
+  builder_model VARCHAR(255) NOT NULL,
+  num_files INTEGER NOT NULL,
+  num_passed INTEGER NOT NULL,
+  duration_ms BIGINT NOT NULL,
+  prompt_tokens INTEGER NOT NULL,
+  completion_tokens INTEGER NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX bench_results_org_idx ON bench_results(org_id, created_at);
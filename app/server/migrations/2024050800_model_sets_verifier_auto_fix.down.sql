@@ -0,0 +1,2 @@
+ALTER TABLE model_sets DROP COLUMN IF EXISTS verifier;
+ALTER TABLE model_sets DROP COLUMN IF EXISTS auto_fix;
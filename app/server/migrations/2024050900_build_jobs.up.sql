@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS build_jobs (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  branch VARCHAR(255) NOT NULL,
+  request TEXT,
+  status VARCHAR(32) NOT NULL DEFAULT 'queued',
+  worker_ip VARCHAR(255),
+  num_builds INTEGER NOT NULL DEFAULT 0,
+  error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP
+);
+
+CREATE INDEX build_jobs_status_idx ON build_jobs(status, created_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS plan_builds_plan_branch_idx;
+
+ALTER TABLE plan_builds DROP COLUMN IF EXISTS branch;
+ALTER TABLE plan_builds DROP COLUMN IF EXISTS is_verification;
+ALTER TABLE plan_builds DROP COLUMN IF EXISTS num_retries;
+ALTER TABLE plan_builds DROP COLUMN IF EXISTS num_tokens;
+ALTER TABLE plan_builds DROP COLUMN IF EXISTS finished_at;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS plan_builds_org_created_idx;
+DROP TABLE IF EXISTS model_usages;
+
+ALTER TABLE plan_builds DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,18 @@
+ALTER TABLE plan_builds ADD COLUMN user_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS model_usages (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  plan_id UUID REFERENCES plans(id) ON DELETE SET NULL,
+  branch VARCHAR(255) NOT NULL DEFAULT '',
+  model_role VARCHAR(64) NOT NULL,
+  provider VARCHAR(64) NOT NULL,
+  model_name VARCHAR(255) NOT NULL,
+  input_tokens INTEGER NOT NULL DEFAULT 0,
+  output_tokens INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX model_usages_org_created_idx ON model_usages(org_id, created_at);
+CREATE INDEX plan_builds_org_created_idx ON plan_builds(org_id, created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE invites ADD COLUMN token_hash VARCHAR(64);
+ALTER TABLE invites ADD COLUMN expires_at TIMESTAMP;
+ALTER TABLE invites ADD COLUMN email_sent_at TIMESTAMP;
+
+CREATE UNIQUE INDEX invites_token_hash_idx ON invites(token_hash);
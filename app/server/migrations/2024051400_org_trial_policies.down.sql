@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS model_usages_org_user_idx;
+
+DROP TABLE IF EXISTS org_trial_policies;
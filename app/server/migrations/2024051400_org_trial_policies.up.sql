@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS org_trial_policies (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  max_plans INTEGER NOT NULL DEFAULT 5,
+  max_tokens INTEGER NOT NULL DEFAULT 1000000,
+  access_code_hash VARCHAR(64),
+
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_org_trial_policies_modtime BEFORE UPDATE ON org_trial_policies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE UNIQUE INDEX org_trial_policies_org_idx ON org_trial_policies(org_id);
+CREATE UNIQUE INDEX org_trial_policies_access_code_idx ON org_trial_policies(access_code_hash);
+
+CREATE INDEX model_usages_org_user_idx ON model_usages(org_id, user_id);
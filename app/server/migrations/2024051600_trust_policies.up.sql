@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS org_trust_policies (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  load_context VARCHAR(16) NOT NULL DEFAULT 'confirm',
+  create_files VARCHAR(16) NOT NULL DEFAULT 'allow',
+  delete_files VARCHAR(16) NOT NULL DEFAULT 'confirm',
+  run_commands VARCHAR(16) NOT NULL DEFAULT 'allow',
+  create_files_dirs TEXT NOT NULL DEFAULT '',
+
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_org_trust_policies_modtime BEFORE UPDATE ON org_trust_policies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE UNIQUE INDEX org_trust_policies_org_idx ON org_trust_policies(org_id);
+
+CREATE TABLE IF NOT EXISTS project_trust_policies (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+
+  load_context VARCHAR(16) NOT NULL DEFAULT 'confirm',
+  create_files VARCHAR(16) NOT NULL DEFAULT 'allow',
+  delete_files VARCHAR(16) NOT NULL DEFAULT 'confirm',
+  run_commands VARCHAR(16) NOT NULL DEFAULT 'allow',
+  create_files_dirs TEXT NOT NULL DEFAULT '',
+
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_project_trust_policies_modtime BEFORE UPDATE ON project_trust_policies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE UNIQUE INDEX project_trust_policies_project_idx ON project_trust_policies(project_id);
@@ -0,0 +1,2 @@
+ALTER TABLE project_trust_policies DROP COLUMN IF EXISTS build_cost_confirm_above;
+ALTER TABLE org_trust_policies DROP COLUMN IF EXISTS build_cost_confirm_above;
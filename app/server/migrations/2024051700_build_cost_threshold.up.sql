@@ -0,0 +1,2 @@
+ALTER TABLE org_trust_policies ADD COLUMN build_cost_confirm_above DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE project_trust_policies ADD COLUMN build_cost_confirm_above DOUBLE PRECISION NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS model_prices (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  provider VARCHAR(64) NOT NULL DEFAULT '',
+  model_name VARCHAR(255) NOT NULL,
+  input_per_million DOUBLE PRECISION NOT NULL,
+  output_per_million DOUBLE PRECISION NOT NULL,
+  source VARCHAR(16) NOT NULL DEFAULT 'admin',
+
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_model_prices_modtime BEFORE UPDATE ON model_prices FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE UNIQUE INDEX model_prices_provider_model_idx ON model_prices(provider, model_name);
+
+ALTER TABLE model_usages ADD COLUMN cost DOUBLE PRECISION;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS model_response_cache;
+DROP TABLE IF EXISTS org_response_cache_policies;
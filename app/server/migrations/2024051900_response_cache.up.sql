@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS org_response_cache_policies (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  scope VARCHAR(16) NOT NULL DEFAULT 'org',
+  ttl_hours INTEGER NOT NULL DEFAULT 168,
+  operations TEXT NOT NULL DEFAULT '',
+
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_org_response_cache_policies_modtime BEFORE UPDATE ON org_response_cache_policies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE UNIQUE INDEX org_response_cache_policies_org_idx ON org_response_cache_policies(org_id);
+
+CREATE TABLE IF NOT EXISTS model_response_cache (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  cache_key VARCHAR(64) NOT NULL,
+  operation VARCHAR(32) NOT NULL,
+  response TEXT NOT NULL,
+  num_hits INTEGER NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX model_response_cache_org_key_idx ON model_response_cache(org_id, cache_key);
+CREATE INDEX model_response_cache_expires_idx ON model_response_cache(expires_at);
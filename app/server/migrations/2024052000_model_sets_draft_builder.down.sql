@@ -0,0 +1 @@
+ALTER TABLE model_sets DROP COLUMN IF EXISTS draft_builder;
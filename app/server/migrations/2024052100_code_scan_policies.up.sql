@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS org_code_scan_policies (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  banned_licenses TEXT NOT NULL DEFAULT '',
+  min_verbatim_lines INTEGER NOT NULL DEFAULT 20,
+  match_context BOOLEAN NOT NULL DEFAULT TRUE,
+  match_corpus BOOLEAN NOT NULL DEFAULT TRUE,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_org_code_scan_policies_modtime BEFORE UPDATE ON org_code_scan_policies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE UNIQUE INDEX org_code_scan_policies_org_idx ON org_code_scan_policies(org_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS org_context_bundle_files;
+DROP TABLE IF EXISTS org_context_bundles;
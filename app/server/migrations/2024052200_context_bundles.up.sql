@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS org_context_bundles (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  name VARCHAR(255) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  version INTEGER NOT NULL DEFAULT 1,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_org_context_bundles_modtime BEFORE UPDATE ON org_context_bundles FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE UNIQUE INDEX org_context_bundles_org_name_idx ON org_context_bundles(org_id, name);
+
+CREATE TABLE IF NOT EXISTS org_context_bundle_files (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  bundle_id UUID NOT NULL REFERENCES org_context_bundles(id) ON DELETE CASCADE,
+
+  name TEXT NOT NULL,
+  body TEXT NOT NULL,
+  num_tokens INTEGER NOT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX org_context_bundle_files_bundle_name_idx ON org_context_bundle_files(bundle_id, name);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS knowledge_entries (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  source_plan_id UUID REFERENCES plans(id) ON DELETE SET NULL,
+
+  kind VARCHAR(32) NOT NULL,
+  title TEXT NOT NULL,
+  body TEXT NOT NULL,
+  num_tokens INTEGER NOT NULL,
+
+  embedding TEXT,
+  embedding_model VARCHAR(255),
+
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_knowledge_entries_modtime BEFORE UPDATE ON knowledge_entries FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE INDEX knowledge_entries_org_idx ON knowledge_entries(org_id);
+CREATE UNIQUE INDEX knowledge_entries_source_plan_idx ON knowledge_entries(source_plan_id);
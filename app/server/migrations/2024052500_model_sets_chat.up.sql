@@ -0,0 +1 @@
+ALTER TABLE model_sets ADD COLUMN chat JSON;
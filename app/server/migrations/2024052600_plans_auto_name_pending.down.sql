@@ -0,0 +1 @@
+ALTER TABLE plans DROP COLUMN IF EXISTS auto_name_pending;
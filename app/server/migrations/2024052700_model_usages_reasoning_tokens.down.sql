@@ -0,0 +1 @@
+ALTER TABLE model_usages DROP COLUMN IF EXISTS reasoning_tokens;
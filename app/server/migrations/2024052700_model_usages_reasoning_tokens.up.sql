@@ -0,0 +1 @@
+ALTER TABLE model_usages ADD COLUMN reasoning_tokens INTEGER NOT NULL DEFAULT 0;
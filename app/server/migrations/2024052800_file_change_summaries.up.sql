@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS file_change_summaries (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+
+  result_id VARCHAR(255) NOT NULL,
+  summary TEXT NOT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX file_change_summaries_result_idx ON file_change_summaries(result_id);
+CREATE INDEX file_change_summaries_plan_idx ON file_change_summaries(plan_id);
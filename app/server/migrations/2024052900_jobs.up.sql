@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS jobs (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  kind VARCHAR(64) NOT NULL,
+  org_id UUID REFERENCES orgs(id) ON DELETE CASCADE,
+  payload TEXT NOT NULL DEFAULT '',
+  dedupe_key VARCHAR(255),
+  status VARCHAR(32) NOT NULL DEFAULT 'queued',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  max_attempts INTEGER NOT NULL DEFAULT 3,
+  last_error TEXT NOT NULL DEFAULT '',
+  worker_ip VARCHAR(255),
+  run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  started_at TIMESTAMP,
+  heartbeat_at TIMESTAMP,
+  finished_at TIMESTAMP
+);
+
+CREATE INDEX jobs_status_run_at_idx ON jobs(status, run_at);
+CREATE INDEX jobs_kind_idx ON jobs(kind, created_at);
+CREATE UNIQUE INDEX jobs_pending_dedupe_key_idx ON jobs(dedupe_key) WHERE status IN ('queued', 'running');
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS user_digest_settings (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+  frequency VARCHAR(32) NOT NULL DEFAULT 'off',
+  last_sent_at TIMESTAMP,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_user_digest_settings_modtime BEFORE UPDATE ON user_digest_settings FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE UNIQUE INDEX user_digest_settings_user_idx ON user_digest_settings(user_id);
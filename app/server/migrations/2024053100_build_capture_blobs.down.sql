@@ -0,0 +1 @@
+ALTER TABLE build_captures DROP COLUMN bodies_in_blob;
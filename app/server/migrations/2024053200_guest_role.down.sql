@@ -0,0 +1,7 @@
+UPDATE orgs_users SET org_role_id = (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'member')
+WHERE org_role_id = (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'guest');
+
+DELETE FROM permissions WHERE name = 'update_org_settings'
+  OR resource_id = (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'guest');
+
+DELETE FROM org_roles WHERE org_id IS NULL AND name = 'guest';
@@ -0,0 +1,9 @@
+// Package migrations embeds the versioned schema migrations in the server binary so upgrades don't depend on migration files being present at runtime.
+package migrations
+
+import "embed"
+
+// Postgres migrations are in the root of the package, SQLite migrations are in sqlite/
+
+//go:embed *.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,359 @@
+-- SQLite schema, equivalent to the Postgres migrations up to 2024042600_default_plan_settings.
+-- UUIDs are generated as random v4 strings and timestamps are stored in UTC with millisecond precision.
+
+CREATE TABLE IF NOT EXISTS users (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  name VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL UNIQUE,
+  domain VARCHAR(255) NOT NULL,
+  is_trial BOOLEAN NOT NULL,
+  num_non_draft_plans INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_users_modtime AFTER UPDATE ON users FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE users SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE INDEX users_domain_idx ON users(domain);
+
+CREATE TABLE IF NOT EXISTS orgs (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  name VARCHAR(255) NOT NULL,
+  domain VARCHAR(255) UNIQUE,
+  auto_add_domain_users BOOLEAN NOT NULL DEFAULT FALSE,
+  owner_id UUID NOT NULL REFERENCES users(id),
+  is_trial BOOLEAN NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_orgs_modtime AFTER UPDATE ON orgs FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE orgs SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE TABLE IF NOT EXISTS org_roles (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID REFERENCES orgs(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  label VARCHAR(255) NOT NULL,
+  description TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_org_roles_modtime AFTER UPDATE ON org_roles FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE org_roles SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX org_roles_org_idx ON org_roles(org_id, name);
+
+CREATE TABLE IF NOT EXISTS orgs_users (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  org_role_id UUID NOT NULL REFERENCES org_roles(id) ON DELETE RESTRICT,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  CONSTRAINT org_user_unique UNIQUE (org_id, user_id)
+);
+CREATE TRIGGER update_orgs_users_modtime AFTER UPDATE ON orgs_users FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE orgs_users SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE INDEX orgs_users_user_idx ON orgs_users(user_id);
+CREATE INDEX orgs_users_org_idx ON orgs_users(org_id);
+CREATE INDEX orgs_users_org_role_idx ON orgs_users(org_id, org_role_id);
+
+CREATE TABLE IF NOT EXISTS invites (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  inviter_id UUID NOT NULL REFERENCES users(id),
+  invitee_id UUID REFERENCES users(id),
+  org_role_id UUID NOT NULL REFERENCES org_roles(id) ON DELETE RESTRICT,
+  accepted_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_invites_modtime AFTER UPDATE ON invites FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE invites SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE INDEX invites_pending_idx ON invites(org_id, (accepted_at IS NULL));
+CREATE INDEX invites_email_idx ON invites(email, (accepted_at IS NULL));
+CREATE INDEX invites_org_user_idx ON invites(org_id, invitee_id);
+CREATE INDEX invites_org_role_idx ON invites(org_id, org_role_id);
+
+CREATE TABLE IF NOT EXISTS auth_tokens (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash VARCHAR(64) NOT NULL,
+  is_trial BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  deleted_at TIMESTAMP
+);
+CREATE UNIQUE INDEX auth_tokens_idx ON auth_tokens(token_hash);
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  email VARCHAR(255) NOT NULL,
+  pin_hash VARCHAR(64) NOT NULL,
+  user_id UUID REFERENCES users(id),
+  auth_token_id UUID REFERENCES auth_tokens(id),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_email_verifications_modtime AFTER UPDATE ON email_verifications FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE email_verifications SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX email_verifications_idx ON email_verifications(pin_hash, email, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS projects (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_projects_modtime AFTER UPDATE ON projects FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE projects SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE TABLE IF NOT EXISTS plans (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  shared_with_org_at TIMESTAMP,
+  total_replies INTEGER NOT NULL DEFAULT 0,
+  active_branches INTEGER NOT NULL DEFAULT 0,
+  archived_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_plans_modtime AFTER UPDATE ON plans FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE plans SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE INDEX plans_name_idx ON plans(project_id, owner_id, name);
+CREATE INDEX plans_archived_idx ON plans(project_id, owner_id, archived_at);
+
+CREATE TABLE IF NOT EXISTS branches (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  parent_branch_id UUID REFERENCES branches(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  status VARCHAR(32) NOT NULL,
+  error TEXT,
+  context_tokens INTEGER NOT NULL DEFAULT 0,
+  convo_tokens INTEGER NOT NULL DEFAULT 0,
+  shared_with_org_at TIMESTAMP,
+  archived_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  deleted_at TIMESTAMP
+);
+CREATE TRIGGER update_branches_modtime AFTER UPDATE ON branches FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE branches SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX branches_name_idx ON branches(plan_id, name, archived_at, deleted_at);
+
+CREATE TABLE IF NOT EXISTS convo_summaries (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  latest_convo_message_id UUID NOT NULL,
+  latest_convo_message_created_at TIMESTAMP NOT NULL,
+  summary TEXT NOT NULL,
+  tokens INTEGER NOT NULL,
+  num_messages INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS plan_builds (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  convo_message_id UUID NOT NULL,
+  file_path VARCHAR(255) NOT NULL,
+  error TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_plan_builds_modtime AFTER UPDATE ON plan_builds FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE plan_builds SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE TABLE IF NOT EXISTS permissions (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  name VARCHAR(255) NOT NULL,
+  description TEXT NOT NULL,
+  resource_id UUID,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS org_roles_permissions (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_role_id UUID NOT NULL REFERENCES org_roles(id) ON DELETE CASCADE,
+  permission_id UUID NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+INSERT INTO org_roles (name, label, description) VALUES
+  ('owner', 'Owner', 'Can read and update any plan, invite other owners/admins/members, manage email domain auth, manage billing, read audit logs, delete the org'),
+  ('admin', 'Admin', 'Can read and update any plan, invite other admins/members'),
+  ('member', 'Member', 'Can read and update their own plans or plans shared with them');
+
+INSERT INTO permissions (name, description, resource_id) VALUES
+  ('delete_org', 'Delete an org', NULL),
+  ('manage_email_domain_auth', 'Configure whether orgs_users from the org''s email domain are auto-admitted to org', NULL),
+  ('manage_billing', 'Manage an org''s billing', NULL),
+
+  ('invite_user', 'Invite owners to an org', (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'owner')),
+  ('invite_user', 'Invite admins to an org', (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'admin')),
+  ('invite_user', 'Invite members to an org', (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'member')),
+
+  ('remove_user', 'Remove owners from an org', (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'owner')),
+  ('remove_user', 'Remove admins from an org', (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'admin')),
+  ('remove_user', 'Remove members from an org', (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'member')),
+
+  ('set_user_role', 'Update an owner''s role in an org', (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'owner')),
+  ('set_user_role', 'Update an admin''s role in an org', (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'admin')),
+  ('set_user_role', 'Update a member''s role in an org', (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'member')),
+
+  ('list_org_roles', 'List org roles', NULL),
+
+  ('create_project', 'Create a project', NULL),
+  ('rename_any_project', 'Rename a project', NULL),
+  ('delete_any_project', 'Delete a project', NULL),
+
+  ('create_plan', 'Create a plan', NULL),
+
+  ('manage_any_plan_shares', 'Unshare a plan any user shared', NULL),
+  ('rename_any_plan', 'Rename a plan', NULL),
+  ('delete_any_plan', 'Delete a plan', NULL),
+  ('update_any_plan', 'Update a plan', NULL),
+  ('archive_any_plan', 'Archive a plan', NULL);
+
+INSERT INTO org_roles_permissions (org_role_id, permission_id)
+SELECT (SELECT id FROM org_roles WHERE name = 'owner'), p.id
+FROM permissions p;
+
+INSERT INTO org_roles_permissions (org_role_id, permission_id)
+SELECT (SELECT id FROM org_roles WHERE name = 'admin'), p.id
+FROM permissions p
+WHERE p.name NOT IN ('delete_org', 'manage_email_domain_auth', 'manage_billing')
+  AND (p.resource_id IS NULL OR p.resource_id NOT IN (SELECT id FROM org_roles WHERE name = 'owner'));
+
+INSERT INTO org_roles_permissions (org_role_id, permission_id)
+SELECT (SELECT id FROM org_roles WHERE name = 'member'), p.id
+FROM permissions p
+WHERE p.name IN ('create_project', 'create_plan');
+
+CREATE TABLE IF NOT EXISTS model_streams (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  branch VARCHAR(255) NOT NULL,
+  internal_ip VARCHAR(45) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  finished_at TIMESTAMP,
+  last_heartbeat_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE UNIQUE INDEX model_streams_plan_idx ON model_streams(plan_id, branch, finished_at);
+
+CREATE TABLE IF NOT EXISTS repo_locks (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  plan_build_id UUID REFERENCES plan_builds(id) ON DELETE CASCADE,
+  scope VARCHAR(1) NOT NULL,
+  branch VARCHAR(255),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  last_heartbeat_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX repo_locks_plan_idx ON repo_locks(plan_id);
+
+CREATE TABLE IF NOT EXISTS model_sets (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  name VARCHAR(255) NOT NULL,
+  description TEXT,
+
+  planner JSON,
+  plan_summary JSON,
+  builder JSON,
+  namer JSON,
+  commit_msg JSON,
+  exec_status JSON,
+
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX model_sets_org_idx ON model_sets(org_id);
+
+CREATE TABLE IF NOT EXISTS custom_models (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  provider VARCHAR(255) NOT NULL,
+  custom_provider VARCHAR(255),
+  base_url VARCHAR(255) NOT NULL,
+  model_name VARCHAR(255) NOT NULL,
+  description TEXT,
+  max_tokens INTEGER NOT NULL,
+  api_key_env_var VARCHAR(255),
+
+  is_openai_compatible BOOLEAN NOT NULL,
+  has_json_mode BOOLEAN NOT NULL,
+  has_streaming BOOLEAN NOT NULL,
+  has_function_calling BOOLEAN NOT NULL,
+  has_streaming_function_calls BOOLEAN NOT NULL,
+
+  default_max_convo_tokens INTEGER NOT NULL,
+  default_reserved_output_tokens INTEGER NOT NULL,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_custom_models_modtime AFTER UPDATE ON custom_models FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE custom_models SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE INDEX custom_models_org_idx ON custom_models(org_id);
+
+CREATE TABLE IF NOT EXISTS default_plan_settings (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  plan_settings JSON,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_default_plan_settings_modtime AFTER UPDATE ON default_plan_settings FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE default_plan_settings SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX default_plan_settings_org_idx ON default_plan_settings(org_id);
@@ -0,0 +1,3 @@
+ALTER TABLE plans DROP COLUMN deletion_notified_at;
+
+DROP TABLE IF EXISTS org_retention_policies;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS org_retention_policies (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  archive_after_days INTEGER NOT NULL DEFAULT 0,
+  delete_archived_after_days INTEGER NOT NULL DEFAULT 0,
+  notify_days_before_delete INTEGER NOT NULL DEFAULT 7,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_org_retention_policies_modtime AFTER UPDATE ON org_retention_policies FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE org_retention_policies SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX org_retention_policies_org_idx ON org_retention_policies(org_id);
+
+ALTER TABLE plans ADD COLUMN deletion_notified_at TIMESTAMP;
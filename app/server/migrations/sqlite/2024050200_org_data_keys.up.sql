@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS org_data_keys (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  key_source VARCHAR(32) NOT NULL,
+  kms_key_id VARCHAR(2048),
+  salt VARCHAR(64),
+  wrapped_key TEXT NOT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE UNIQUE INDEX org_data_keys_org_idx ON org_data_keys(org_id);
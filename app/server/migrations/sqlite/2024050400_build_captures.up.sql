@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS build_captures (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  plan_build_id UUID NOT NULL REFERENCES plan_builds(id) ON DELETE CASCADE,
+  file_path VARCHAR(255) NOT NULL,
+  phase VARCHAR(32) NOT NULL,
+  model_name VARCHAR(255) NOT NULL,
+  request TEXT NOT NULL,
+  response TEXT NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  started_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX build_captures_plan_idx ON build_captures(plan_id, started_at);
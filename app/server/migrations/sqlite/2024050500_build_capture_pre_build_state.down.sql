@@ -0,0 +1 @@
+ALTER TABLE build_captures DROP COLUMN pre_build_state;
@@ -0,0 +1 @@
+ALTER TABLE custom_models DROP COLUMN has_structured_outputs;
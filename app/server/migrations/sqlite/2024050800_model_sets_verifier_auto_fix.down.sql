@@ -0,0 +1,2 @@
+ALTER TABLE model_sets DROP COLUMN verifier;
+ALTER TABLE model_sets DROP COLUMN auto_fix;
@@ -0,0 +1,2 @@
+ALTER TABLE model_sets ADD COLUMN verifier JSON;
+ALTER TABLE model_sets ADD COLUMN auto_fix JSON;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS plan_builds_plan_branch_idx;
+
+ALTER TABLE plan_builds DROP COLUMN branch;
+ALTER TABLE plan_builds DROP COLUMN is_verification;
+ALTER TABLE plan_builds DROP COLUMN num_retries;
+ALTER TABLE plan_builds DROP COLUMN num_tokens;
+ALTER TABLE plan_builds DROP COLUMN finished_at;
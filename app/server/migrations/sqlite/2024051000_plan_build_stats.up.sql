@@ -0,0 +1,7 @@
+ALTER TABLE plan_builds ADD COLUMN branch VARCHAR(255) NOT NULL DEFAULT 'main';
+ALTER TABLE plan_builds ADD COLUMN is_verification BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE plan_builds ADD COLUMN num_retries INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE plan_builds ADD COLUMN num_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE plan_builds ADD COLUMN finished_at TIMESTAMP;
+
+CREATE INDEX plan_builds_plan_branch_idx ON plan_builds(plan_id, branch, created_at);
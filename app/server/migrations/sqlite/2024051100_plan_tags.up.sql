@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS plan_tags (
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  tag VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  PRIMARY KEY (plan_id, tag)
+);
+
+CREATE INDEX plan_tags_org_tag_idx ON plan_tags(org_id, tag);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS invites_token_hash_idx;
+
+ALTER TABLE invites DROP COLUMN email_sent_at;
+ALTER TABLE invites DROP COLUMN expires_at;
+ALTER TABLE invites DROP COLUMN token_hash;
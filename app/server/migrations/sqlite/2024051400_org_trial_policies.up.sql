@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS org_trial_policies (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  max_plans INTEGER NOT NULL DEFAULT 5,
+  max_tokens INTEGER NOT NULL DEFAULT 1000000,
+  access_code_hash VARCHAR(64),
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_org_trial_policies_modtime AFTER UPDATE ON org_trial_policies FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE org_trial_policies SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX org_trial_policies_org_idx ON org_trial_policies(org_id);
+CREATE UNIQUE INDEX org_trial_policies_access_code_idx ON org_trial_policies(access_code_hash);
+
+CREATE INDEX model_usages_org_user_idx ON model_usages(org_id, user_id);
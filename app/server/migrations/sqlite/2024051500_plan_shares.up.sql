@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS plan_shares (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  access VARCHAR(16) NOT NULL,
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_plan_shares_modtime AFTER UPDATE ON plan_shares FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE plan_shares SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX plan_shares_plan_user_idx ON plan_shares(plan_id, user_id);
+CREATE INDEX plan_shares_user_idx ON plan_shares(user_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS project_trust_policies;
+DROP TABLE IF EXISTS org_trust_policies;
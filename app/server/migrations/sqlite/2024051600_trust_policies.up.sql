@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS org_trust_policies (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  load_context VARCHAR(16) NOT NULL DEFAULT 'confirm',
+  create_files VARCHAR(16) NOT NULL DEFAULT 'allow',
+  delete_files VARCHAR(16) NOT NULL DEFAULT 'confirm',
+  run_commands VARCHAR(16) NOT NULL DEFAULT 'allow',
+  create_files_dirs TEXT NOT NULL DEFAULT '',
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_org_trust_policies_modtime AFTER UPDATE ON org_trust_policies FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE org_trust_policies SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX org_trust_policies_org_idx ON org_trust_policies(org_id);
+
+CREATE TABLE IF NOT EXISTS project_trust_policies (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+
+  load_context VARCHAR(16) NOT NULL DEFAULT 'confirm',
+  create_files VARCHAR(16) NOT NULL DEFAULT 'allow',
+  delete_files VARCHAR(16) NOT NULL DEFAULT 'confirm',
+  run_commands VARCHAR(16) NOT NULL DEFAULT 'allow',
+  create_files_dirs TEXT NOT NULL DEFAULT '',
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_project_trust_policies_modtime AFTER UPDATE ON project_trust_policies FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE project_trust_policies SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX project_trust_policies_project_idx ON project_trust_policies(project_id);
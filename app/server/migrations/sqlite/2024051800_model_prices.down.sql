@@ -0,0 +1,3 @@
+ALTER TABLE model_usages DROP COLUMN cost;
+
+DROP TABLE IF EXISTS model_prices;
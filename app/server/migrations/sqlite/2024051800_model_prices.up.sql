@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS model_prices (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  provider VARCHAR(64) NOT NULL DEFAULT '',
+  model_name VARCHAR(255) NOT NULL,
+  input_per_million REAL NOT NULL,
+  output_per_million REAL NOT NULL,
+  source VARCHAR(16) NOT NULL DEFAULT 'admin',
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_model_prices_modtime AFTER UPDATE ON model_prices FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE model_prices SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX model_prices_provider_model_idx ON model_prices(provider, model_name);
+
+ALTER TABLE model_usages ADD COLUMN cost REAL;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS org_response_cache_policies (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  scope VARCHAR(16) NOT NULL DEFAULT 'org',
+  ttl_hours INTEGER NOT NULL DEFAULT 168,
+  operations TEXT NOT NULL DEFAULT '',
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_org_response_cache_policies_modtime AFTER UPDATE ON org_response_cache_policies FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE org_response_cache_policies SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX org_response_cache_policies_org_idx ON org_response_cache_policies(org_id);
+
+CREATE TABLE IF NOT EXISTS model_response_cache (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  cache_key VARCHAR(64) NOT NULL,
+  operation VARCHAR(32) NOT NULL,
+  response TEXT NOT NULL,
+  num_hits INTEGER NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE UNIQUE INDEX model_response_cache_org_key_idx ON model_response_cache(org_id, cache_key);
+CREATE INDEX model_response_cache_expires_idx ON model_response_cache(expires_at);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS org_code_scan_policies (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  banned_licenses TEXT NOT NULL DEFAULT '',
+  min_verbatim_lines INTEGER NOT NULL DEFAULT 20,
+  match_context BOOLEAN NOT NULL DEFAULT TRUE,
+  match_corpus BOOLEAN NOT NULL DEFAULT TRUE,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_org_code_scan_policies_modtime AFTER UPDATE ON org_code_scan_policies FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE org_code_scan_policies SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX org_code_scan_policies_org_idx ON org_code_scan_policies(org_id);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS knowledge_entries (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  source_plan_id UUID REFERENCES plans(id) ON DELETE SET NULL,
+
+  kind VARCHAR(32) NOT NULL,
+  title TEXT NOT NULL,
+  body TEXT NOT NULL,
+  num_tokens INTEGER NOT NULL,
+
+  embedding TEXT,
+  embedding_model VARCHAR(255),
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_knowledge_entries_modtime AFTER UPDATE ON knowledge_entries FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE knowledge_entries SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE INDEX knowledge_entries_org_idx ON knowledge_entries(org_id);
+CREATE UNIQUE INDEX knowledge_entries_source_plan_idx ON knowledge_entries(source_plan_id);
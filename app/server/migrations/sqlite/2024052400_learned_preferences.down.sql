@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS learned_preferences;
+DROP TABLE IF EXISTS change_feedback;
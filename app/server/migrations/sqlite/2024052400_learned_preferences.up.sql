@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS change_feedback (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+
+  result_id VARCHAR(255) NOT NULL,
+  kind VARCHAR(32) NOT NULL,
+  path TEXT NOT NULL,
+  body TEXT NOT NULL,
+
+  distilled_for_user BOOLEAN NOT NULL DEFAULT FALSE,
+  distilled_for_org BOOLEAN NOT NULL DEFAULT FALSE,
+
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE UNIQUE INDEX change_feedback_result_kind_idx ON change_feedback(result_id, kind);
+CREATE INDEX change_feedback_org_idx ON change_feedback(org_id);
+
+CREATE TABLE IF NOT EXISTS learned_preferences (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  -- null for the org's preferences
+  user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+
+  summary TEXT NOT NULL,
+  num_events INTEGER NOT NULL DEFAULT 0,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_learned_preferences_modtime AFTER UPDATE ON learned_preferences FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE learned_preferences SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX learned_preferences_user_idx ON learned_preferences(org_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX learned_preferences_org_idx ON learned_preferences(org_id) WHERE user_id IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE plans DROP COLUMN auto_name_pending;
@@ -0,0 +1 @@
+ALTER TABLE plans ADD COLUMN auto_name_pending BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE model_usages DROP COLUMN reasoning_tokens;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS file_change_summaries (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+
+  result_id VARCHAR(255) NOT NULL,
+  summary TEXT NOT NULL,
+
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE UNIQUE INDEX file_change_summaries_result_idx ON file_change_summaries(result_id);
+CREATE INDEX file_change_summaries_plan_idx ON file_change_summaries(plan_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS user_digest_settings (
+  id UUID PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+  frequency VARCHAR(32) NOT NULL DEFAULT 'off',
+  last_sent_at TIMESTAMP,
+
+  updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE TRIGGER update_user_digest_settings_modtime AFTER UPDATE ON user_digest_settings FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+  UPDATE user_digest_settings SET updated_at = (strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = NEW.id;
+END;
+
+CREATE UNIQUE INDEX user_digest_settings_user_idx ON user_digest_settings(user_id);
@@ -0,0 +1 @@
+ALTER TABLE build_captures ADD COLUMN bodies_in_blob BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,27 @@
+INSERT INTO org_roles (name, label, description) VALUES
+  ('guest', 'Guest', 'Joined with a trial access code. Can read and update their own plans, but can''t change org settings');
+
+INSERT INTO permissions (name, description, resource_id) VALUES
+  ('update_org_settings', 'Update an org''s default settings, custom models, and model packs', NULL),
+  ('remove_user', 'Remove guests from an org', (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'guest'));
+
+INSERT INTO org_roles_permissions (org_role_id, permission_id)
+SELECT r.id, p.id
+FROM org_roles r, permissions p
+WHERE r.org_id IS NULL AND r.name IN ('owner', 'admin')
+  AND (p.name = 'update_org_settings' OR p.resource_id = (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'guest'));
+
+INSERT INTO org_roles_permissions (org_role_id, permission_id)
+SELECT (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'member'), p.id
+FROM permissions p
+WHERE p.name = 'update_org_settings';
+
+INSERT INTO org_roles_permissions (org_role_id, permission_id)
+SELECT (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'guest'), p.id
+FROM permissions p
+WHERE p.name IN ('create_project', 'create_plan');
+
+-- guests who already joined an org with an access code, as opposed to users trying Plandex Cloud in their own trial org
+UPDATE orgs_users SET org_role_id = (SELECT id FROM org_roles WHERE org_id IS NULL AND name = 'guest')
+WHERE user_id IN (SELECT id FROM users WHERE is_trial)
+  AND org_id IN (SELECT id FROM orgs WHERE NOT is_trial);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS plans_unique_name_idx;
@@ -0,0 +1,13 @@
+-- plans that already share a name in a project keep the oldest one's name, and the others get part of their id appended
+UPDATE plans SET name = substr(plans.name, 1, 246) || '-' || substr(plans.id, 1, 8)
+WHERE plans.name != 'draft'
+  AND EXISTS (
+    SELECT 1 FROM plans older
+    WHERE older.org_id = plans.org_id
+      AND older.project_id = plans.project_id
+      AND older.name = plans.name
+      AND (older.created_at < plans.created_at OR (older.created_at = plans.created_at AND older.id < plans.id))
+  );
+
+-- each user can have their own draft plan in a project, so drafts are left out
+CREATE UNIQUE INDEX plans_unique_name_idx ON plans(org_id, project_id, name) WHERE name != 'draft';
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/plandex/plandex/shared"
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -37,6 +39,11 @@ func newClient(apiKey, endpoint, orgId string) *openai.Client {
 	if orgId != "" {
 		config.OrgID = orgId
 	}
+	base := getHttpClient()
+	if endpoint == shared.MockBaseUrl {
+		base = mockHttpClient
+	}
+	config.HTTPClient = &http.Client{Transport: callSlotsTransport{next: usageCaptureTransport{next: bodyParamsTransport{base: base}}}}
 
 	return openai.NewClientWithConfig(config)
 }
@@ -45,8 +52,25 @@ func CreateChatCompletionStreamWithRetries(
 	client *openai.Client,
 	ctx context.Context,
 	req openai.ChatCompletionRequest,
-) (*openai.ChatCompletionStream, error) {
-	return createChatCompletionStream(client, ctx, req, 0)
+) (ChatCompletionStream, error) {
+	if isNoStreaming(ctx) {
+		stream, err := createNonStreamingFallback(client, ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return newNormalizedStream(stream), nil
+	}
+
+	if ReportedUsageFromContext(ctx) != nil {
+		// the usage comes in a final chunk, after the finish reason
+		req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+
+	stream, err := createChatCompletionStream(client, ctx, req, 0)
+	if err != nil {
+		return nil, err
+	}
+	return newNormalizedStream(stream), nil
 }
 
 func createChatCompletionStream(
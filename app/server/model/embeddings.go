@@ -0,0 +1,78 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// EmbeddingModel embeds knowledge base entries and the queries they're searched with. Entries embedded with a different model are re-embedded when they're searched.
+const EmbeddingModel = openai.SmallEmbedding3
+
+// text beyond this is left out of an embedding, which keeps inputs under the model's token limit
+const maxEmbeddingInputChars = 20000
+
+// EmbeddingClient returns the client for the OpenAI key, which embeddings are created with, or nil if there isn't one
+func EmbeddingClient(clients map[string]*openai.Client) *openai.Client {
+	if clients == nil {
+		return nil
+	}
+	return clients[shared.OpenAIEnvVar]
+}
+
+// CreateEmbeddings embeds each of the texts, retrying on retriable errors
+func CreateEmbeddings(client *openai.Client, ctx context.Context, texts []string) ([][]float32, error) {
+	return createEmbeddings(client, ctx, texts, 0)
+}
+
+func createEmbeddings(client *openai.Client, ctx context.Context, texts []string, numRetry int) ([][]float32, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	input := make([]string, len(texts))
+	for i, text := range texts {
+		runes := []rune(text)
+		if len(runes) > maxEmbeddingInputChars {
+			text = string(runes[:maxEmbeddingInputChars])
+		}
+		input[i] = text
+	}
+
+	resp, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: input,
+		Model: EmbeddingModel,
+	})
+
+	if err != nil {
+		log.Printf("Error creating embeddings: %v, retry: %d\n", err, numRetry)
+
+		// other client errors, like a base url without an embeddings endpoint, won't succeed on a retry either
+		isClientErr := strings.Contains(err.Error(), "status code: 4") && !strings.Contains(err.Error(), "status code: 429")
+
+		if isNonRetriableErr(err) || isClientErr || numRetry >= 3 {
+			return nil, err
+		}
+
+		waitBackoff(numRetry)
+		return createEmbeddings(client, ctx, texts, numRetry+1)
+	}
+
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
+	}
+
+	res := make([][]float32, len(texts))
+	for _, embedding := range resp.Data {
+		if embedding.Index < 0 || embedding.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", embedding.Index)
+		}
+		res[embedding.Index] = embedding.Embedding
+	}
+
+	return res, nil
+}
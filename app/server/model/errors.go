@@ -0,0 +1,65 @@
+package model
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// ModelErrorType classifies an error from a model provider so the client can show what to do about it. Errors that don't match a known type are ApiErrorTypeOther.
+func ModelErrorType(err error) shared.ApiErrorType {
+	if err == nil {
+		return shared.ApiErrorTypeOther
+	}
+
+	errStr := strings.ToLower(err.Error())
+
+	if strings.Contains(errStr, "status code: 402") ||
+		strings.Contains(errStr, "exceeded your current quota") ||
+		strings.Contains(errStr, "insufficient_quota") ||
+		strings.Contains(errStr, "insufficient credits") {
+		return shared.ApiErrorTypeBudgetExceeded
+	}
+
+	if strings.Contains(errStr, "status code: 429") || strings.Contains(errStr, "rate limit") {
+		return shared.ApiErrorTypeProviderRateLimited
+	}
+
+	if strings.Contains(errStr, "reduce the length of the messages") ||
+		strings.Contains(errStr, "maximum context length") ||
+		strings.Contains(errStr, "context_length_exceeded") ||
+		strings.Contains(errStr, "token limit exceeded") {
+		return shared.ApiErrorTypeContextTooLong
+	}
+
+	return shared.ApiErrorTypeOther
+}
+
+// NewApiError returns an ApiError with a status that matches its type
+func NewApiError(errType shared.ApiErrorType, msg string) *shared.ApiError {
+	status := http.StatusInternalServerError
+	switch errType {
+	case shared.ApiErrorTypeProviderRateLimited:
+		status = http.StatusTooManyRequests
+	case shared.ApiErrorTypeContextTooLong:
+		status = http.StatusRequestEntityTooLarge
+	case shared.ApiErrorTypeBudgetExceeded:
+		status = http.StatusPaymentRequired
+	case shared.ApiErrorTypeInvalidToolOutput:
+		status = http.StatusBadGateway
+	case shared.ApiErrorTypeApplyConflict:
+		status = http.StatusConflict
+	}
+
+	return &shared.ApiError{
+		Type:   errType,
+		Status: status,
+		Msg:    msg,
+	}
+}
+
+// ModelApiError returns an ApiError for a failed model request, typed with ModelErrorType
+func ModelApiError(msg string, err error) *shared.ApiError {
+	return NewApiError(ModelErrorType(err), msg)
+}
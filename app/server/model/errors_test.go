@@ -0,0 +1,28 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestModelErrorType(t *testing.T) {
+	tests := []struct {
+		err      string
+		expected shared.ApiErrorType
+	}{
+		{"error, status code: 429, message: Rate limit reached for gpt-4o. Please try again in 2s.", shared.ApiErrorTypeProviderRateLimited},
+		{"error, status code: 429, message: You exceeded your current quota, please check your plan and billing details.", shared.ApiErrorTypeBudgetExceeded},
+		{"error, status code: 402, message: Insufficient credits", shared.ApiErrorTypeBudgetExceeded},
+		{"error, status code: 400, message: This model's maximum context length is 128000 tokens. Please reduce the length of the messages.", shared.ApiErrorTypeContextTooLong},
+		{"error, status code: 500, message: The server had an error while processing your request.", shared.ApiErrorTypeOther},
+	}
+
+	for _, test := range tests {
+		errType := ModelErrorType(errors.New(test.err))
+		if errType != test.expected {
+			t.Errorf("Expected %s for %q, got %s", test.expected, test.err, errType)
+		}
+	}
+}
@@ -9,19 +9,34 @@ import (
 )
 
 func FormatModelContext(context []*db.Context) (string, int, error) {
+	return formatModelContext(context, false)
+}
+
+// FormatModelContextWithLineNums numbers the lines of file contexts, like the build prompts do, so the model can refer to them
+func FormatModelContextWithLineNums(context []*db.Context) (string, int, error) {
+	return formatModelContext(context, true)
+}
+
+func formatModelContext(context []*db.Context, withLineNums bool) (string, int, error) {
 	var contextMessages []string
 	var numTokens int
 	for _, part := range context {
 		var message string
 		var fmtStr string
 		var args []any
+		var numberedBody string
 
 		if part.ContextType == shared.ContextDirectoryTreeType {
 			fmtStr = "\n\n- %s | directory tree:\n\n```\n%s\n```"
 			args = append(args, part.FilePath, part.Body)
 		} else if part.ContextType == shared.ContextFileType {
 			fmtStr = "\n\n- %s:\n\n```\n%s\n```"
-			args = append(args, part.FilePath, part.Body)
+			if withLineNums {
+				numberedBody = shared.AddLineNums(part.Body)
+				args = append(args, part.FilePath, numberedBody)
+			} else {
+				args = append(args, part.FilePath, part.Body)
+			}
 		} else if part.Url != "" {
 			fmtStr = "\n\n- %s:\n\n```\n%s\n```"
 			args = append(args, part.Url, part.Body)
@@ -39,7 +54,17 @@ func FormatModelContext(context []*db.Context) (string, int, error) {
 				return "", 0, err
 			}
 
-			numTokens += part.NumTokens + numContextTokens
+			bodyTokens := part.NumTokens
+			if numberedBody != "" {
+				// the numbers add tokens to every line
+				bodyTokens, err = shared.GetNumTokens(numberedBody)
+				if err != nil {
+					err = fmt.Errorf("failed to get the number of tokens in the context: %v", err)
+					return "", 0, err
+				}
+			}
+
+			numTokens += bodyTokens + numContextTokens
 			message = fmt.Sprintf(fmtStr, args...)
 			contextMessages = append(contextMessages, message)
 		}
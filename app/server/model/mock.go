@@ -0,0 +1,412 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// MockFixture is a scripted response for the mock provider. Fixtures are matched in order, and the first match is used. A fixture without a tool only matches requests that don't ask for a function call.
+type MockFixture struct {
+	// Tool is the name of the function the request asks the model to call, or of the schema for a json_schema response format
+	Tool string `json:"tool,omitempty"`
+	// Contains must be a substring of the request's last message
+	Contains string `json:"contains,omitempty"`
+	// Times limits how many requests the fixture answers, so consecutive requests can get different responses. 0 is unlimited.
+	Times int `json:"times,omitempty"`
+
+	Content   string          `json:"content,omitempty"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+
+	// ChunkSize is the number of characters sent in each streamed chunk (default 20), and ChunkDelayMs the delay before each one (default 10, or -1 for none)
+	ChunkSize    int `json:"chunkSize,omitempty"`
+	ChunkDelayMs int `json:"chunkDelayMs,omitempty"`
+
+	numUsed int
+}
+
+const mockDemoReply = `This is a reply from the mock model provider, which returns scripted responses without calling a model. It's useful for trying Plandex offline and for testing a server end to end.
+
+Here's a new file for the plan:
+
+- plandex-demo.md:
+` + "```markdown" + `
+# Plandex demo
+
+This file was created by the mock model provider.
+` + "```" + `
+
+Run 'plandex diff' to review it, then 'plandex apply' to write it to your project.`
+
+var mockFixtures []*MockFixture
+var mockMu sync.Mutex
+var mockHttpClient = &http.Client{Transport: mockTransport{}}
+
+// MockProviderEnabled is true if the server can use the mock provider. It's enabled by PLANDEX_MOCK_PROVIDER, or by default in development.
+func MockProviderEnabled() bool {
+	return os.Getenv("PLANDEX_MOCK_PROVIDER") != "" || os.Getenv("GOENV") == "development"
+}
+
+// InitMockProvider loads fixtures from the directory in PLANDEX_MOCK_FIXTURES (sorted by file name), followed by the built-in demo fixture. Each file holds a fixture or an array of fixtures.
+func InitMockProvider() error {
+	mockMu.Lock()
+	defer mockMu.Unlock()
+
+	mockFixtures = nil
+
+	dir := os.Getenv("PLANDEX_MOCK_FIXTURES")
+	if dir != "" {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return fmt.Errorf("error listing mock fixtures: %v", err)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			fixtures, err := loadMockFixtures(path)
+			if err != nil {
+				return err
+			}
+			mockFixtures = append(mockFixtures, fixtures...)
+		}
+
+		log.Printf("Loaded %d mock provider fixture(s) from %s\n", len(mockFixtures), dir)
+	}
+
+	mockFixtures = append(mockFixtures, &MockFixture{Content: mockDemoReply})
+
+	return nil
+}
+
+func loadMockFixtures(path string) ([]*MockFixture, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading mock fixture %s: %v", path, err)
+	}
+
+	var fixtures []*MockFixture
+	if strings.HasPrefix(strings.TrimSpace(string(bytes)), "[") {
+		err = json.Unmarshal(bytes, &fixtures)
+	} else {
+		var fixture MockFixture
+		err = json.Unmarshal(bytes, &fixture)
+		fixtures = append(fixtures, &fixture)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling mock fixture %s: %v", path, err)
+	}
+
+	return fixtures, nil
+}
+
+// mockTransport answers chat completion requests in process, so the mock provider works offline with the regular openai client
+type mockTransport struct{}
+
+func (t mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		return mockResponse(http.StatusNotFound, "application/json", fmt.Sprintf(`{"error": {"message": "mock provider doesn't support %s"}}`, req.URL.Path)), nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading mock request: %v", err)
+	}
+
+	var chatReq openai.ChatCompletionRequest
+	err = json.Unmarshal(body, &chatReq)
+	if err != nil {
+		return mockResponse(http.StatusBadRequest, "application/json", `{"error": {"message": "invalid request"}}`), nil
+	}
+
+	tool, isJsonSchema := getMockRequestTool(chatReq, body)
+	fixture := matchMockFixture(chatReq, tool)
+
+	var toolCall *openai.ToolCall
+	content := fixture.Content
+	if tool != nil {
+		args := string(fixture.Arguments)
+		if args == "" {
+			args = getMockArguments(tool.Parameters)
+		}
+
+		if isJsonSchema {
+			content = args
+		} else {
+			toolCall = &openai.ToolCall{
+				ID:   "call_mock",
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      tool.Name,
+					Arguments: args,
+				},
+			}
+			content = ""
+		}
+	}
+
+	if chatReq.Stream {
+		pr, pw := io.Pipe()
+		go writeMockStream(req, pw, fixture, chatReq.Model, content, toolCall)
+		res := mockResponse(http.StatusOK, "text/event-stream", "")
+		res.Body = pr
+		return res, nil
+	}
+
+	message := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleAssistant,
+		Content: content,
+	}
+	finishReason := openai.FinishReasonStop
+	if toolCall != nil {
+		message.ToolCalls = []openai.ToolCall{*toolCall}
+		finishReason = openai.FinishReasonToolCalls
+	}
+
+	bytes, err := json.Marshal(openai.ChatCompletionResponse{
+		ID:      "chatcmpl-mock",
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   chatReq.Model,
+		Choices: []openai.ChatCompletionChoice{
+			{
+				Message:      message,
+				FinishReason: finishReason,
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling mock response: %v", err)
+	}
+
+	return mockResponse(http.StatusOK, "application/json", string(bytes)), nil
+}
+
+func mockResponse(status int, contentType, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:     http.Header{"Content-Type": []string{contentType}},
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+	}
+}
+
+func writeMockStream(req *http.Request, pw *io.PipeWriter, fixture *MockFixture, modelName, content string, toolCall *openai.ToolCall) {
+	defer pw.Close()
+
+	chunkSize := fixture.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 20
+	}
+	delay := time.Duration(fixture.ChunkDelayMs) * time.Millisecond
+	if fixture.ChunkDelayMs == 0 {
+		delay = 10 * time.Millisecond
+	}
+
+	text := content
+	if toolCall != nil {
+		text = toolCall.Function.Arguments
+	}
+	runes := []rune(text)
+
+	send := func(delta openai.ChatCompletionStreamChoiceDelta, finishReason openai.FinishReason) bool {
+		bytes, err := json.Marshal(openai.ChatCompletionStreamResponse{
+			ID:      "chatcmpl-mock",
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   modelName,
+			Choices: []openai.ChatCompletionStreamChoice{
+				{
+					Delta:        delta,
+					FinishReason: finishReason,
+				},
+			},
+		})
+		if err != nil {
+			log.Printf("Error marshalling mock stream chunk: %v\n", err)
+			return false
+		}
+
+		_, err = fmt.Fprintf(pw, "data: %s\n\n", bytes)
+		return err == nil
+	}
+
+	for i := 0; i < len(runes); i += chunkSize {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-time.After(delay):
+		}
+
+		end := i + chunkSize
+		if end > len(runes) {
+			end = len(runes)
+		}
+		chunk := string(runes[i:end])
+
+		delta := openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant}
+		if toolCall == nil {
+			delta.Content = chunk
+		} else {
+			index := 0
+			call := openai.ToolCall{Index: &index, Function: openai.FunctionCall{Arguments: chunk}}
+			if i == 0 {
+				call.ID = toolCall.ID
+				call.Type = toolCall.Type
+				call.Function.Name = toolCall.Function.Name
+			}
+			delta.ToolCalls = []openai.ToolCall{call}
+		}
+
+		if !send(delta, "") {
+			return
+		}
+	}
+
+	finishReason := openai.FinishReasonStop
+	if toolCall != nil {
+		finishReason = openai.FinishReasonToolCalls
+	}
+	if !send(openai.ChatCompletionStreamChoiceDelta{}, finishReason) {
+		return
+	}
+
+	fmt.Fprint(pw, "data: [DONE]\n\n")
+}
+
+// getMockRequestTool returns the function the request asks the model to call. A json_schema response format is returned as a function too, with isJsonSchema true, since the response is generated the same way but sent as content.
+func getMockRequestTool(req openai.ChatCompletionRequest, body []byte) (tool *openai.FunctionDefinition, isJsonSchema bool) {
+	var format struct {
+		ResponseFormat struct {
+			Type       string `json:"type"`
+			JsonSchema struct {
+				Name   string                 `json:"name"`
+				Schema map[string]interface{} `json:"schema"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+	if json.Unmarshal(body, &format) == nil && format.ResponseFormat.Type == "json_schema" {
+		return &openai.FunctionDefinition{
+			Name:       format.ResponseFormat.JsonSchema.Name,
+			Parameters: format.ResponseFormat.JsonSchema.Schema,
+		}, true
+	}
+
+	if len(req.Tools) == 0 {
+		return nil, false
+	}
+
+	// tool choice is unmarshalled as a map since it can also be a string
+	if choice, ok := req.ToolChoice.(map[string]interface{}); ok {
+		if fn, ok := choice["function"].(map[string]interface{}); ok {
+			for _, tool := range req.Tools {
+				if tool.Function != nil && tool.Function.Name == fn["name"] {
+					return tool.Function, false
+				}
+			}
+		}
+	}
+
+	return req.Tools[0].Function, false
+}
+
+func matchMockFixture(req openai.ChatCompletionRequest, tool *openai.FunctionDefinition) *MockFixture {
+	var lastMessage string
+	if len(req.Messages) > 0 {
+		lastMessage = req.Messages[len(req.Messages)-1].Content
+	}
+
+	toolName := ""
+	if tool != nil {
+		toolName = tool.Name
+	}
+
+	mockMu.Lock()
+	defer mockMu.Unlock()
+
+	for _, fixture := range mockFixtures {
+		if fixture.Tool != toolName {
+			continue
+		}
+		if fixture.Contains != "" && !strings.Contains(lastMessage, fixture.Contains) {
+			continue
+		}
+		if fixture.Times > 0 && fixture.numUsed >= fixture.Times {
+			continue
+		}
+		fixture.numUsed++
+		return fixture
+	}
+
+	// function calls without a fixture get arguments generated from the function's schema
+	return &MockFixture{Tool: toolName}
+}
+
+// getMockArguments generates the simplest arguments that fit a function's json schema: empty arrays, false booleans, zero numbers, and placeholder strings
+func getMockArguments(params any) string {
+	bytes, err := json.Marshal(params)
+	if err != nil {
+		return "{}"
+	}
+
+	var schema map[string]interface{}
+	err = json.Unmarshal(bytes, &schema)
+	if err != nil {
+		return "{}"
+	}
+
+	bytes, err = json.Marshal(getMockValue(schema))
+	if err != nil {
+		return "{}"
+	}
+
+	return string(bytes)
+}
+
+func getMockValue(schema map[string]interface{}) interface{} {
+	schemaType := schema["type"]
+	// nullable properties in strict schemas have a list of types
+	if types, ok := schemaType.([]interface{}); ok {
+		for _, t := range types {
+			if t != "null" {
+				schemaType = t
+				break
+			}
+		}
+	}
+
+	switch schemaType {
+	case "object":
+		obj := map[string]interface{}{}
+		if props, ok := schema["properties"].(map[string]interface{}); ok {
+			for name, prop := range props {
+				if propSchema, ok := prop.(map[string]interface{}); ok {
+					obj[name] = getMockValue(propSchema)
+				}
+			}
+		}
+		return obj
+	case "array":
+		return []interface{}{}
+	case "boolean":
+		return false
+	case "integer", "number":
+		return 0
+	default:
+		if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+			return enum[0]
+		}
+		return "mock"
+	}
+}
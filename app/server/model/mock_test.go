@@ -0,0 +1,181 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+var mockTestFn = openai.FunctionDefinition{
+	Name: "checkFile",
+	Parameters: &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"reasoning": {Type: jsonschema.String},
+			"isCorrect": {Type: jsonschema.Boolean},
+		},
+	},
+}
+
+func initMockTestProvider(t *testing.T, fixtures string) *openai.Client {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "fixtures.json"), []byte(fixtures), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PLANDEX_MOCK_FIXTURES", dir)
+	err = InitMockProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newClient("mock", shared.MockBaseUrl, "")
+}
+
+func readMockStream(t *testing.T, stream *openai.ChatCompletionStream) (string, openai.FinishReason) {
+	defer stream.Close()
+
+	var res string
+	var finishReason openai.FinishReason
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return res, finishReason
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		delta := chunk.Choices[0].Delta
+		res += delta.Content
+		for _, toolCall := range delta.ToolCalls {
+			res += toolCall.Function.Arguments
+		}
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+	}
+}
+
+func TestMockProviderStreamsFixtures(t *testing.T) {
+	client := initMockTestProvider(t, `[
+		{"contains": "first", "times": 1, "content": "first reply", "chunkSize": 3, "chunkDelayMs": -1},
+		{"content": "later reply", "chunkDelayMs": -1}
+	]`)
+
+	req := openai.ChatCompletionRequest{
+		Model:    "mock",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "the first prompt"}},
+	}
+
+	for _, expected := range []string{"first reply", "later reply"} {
+		stream, err := client.CreateChatCompletionStream(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, finishReason := readMockStream(t, stream)
+		if content != expected {
+			t.Errorf("Expected %q, got %q", expected, content)
+		}
+		if finishReason != openai.FinishReasonStop {
+			t.Errorf("Expected finish reason stop, got %q", finishReason)
+		}
+	}
+}
+
+func TestMockProviderToolCalls(t *testing.T) {
+	client := initMockTestProvider(t, `{"tool": "checkFile", "contains": "broken", "arguments": {"reasoning": "it's broken", "isCorrect": false}}`)
+
+	getReq := func(content string) openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model:    "mock",
+			Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: content}},
+			Tools:    []openai.Tool{{Type: openai.ToolTypeFunction, Function: &mockTestFn}},
+			ToolChoice: openai.ToolChoice{
+				Type:     openai.ToolTypeFunction,
+				Function: openai.ToolFunction{Name: mockTestFn.Name},
+			},
+		}
+	}
+
+	resp, err := client.CreateChatCompletion(context.Background(), getReq("a broken file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := resp.Choices[0].Message.ToolCalls[0].Function.Arguments
+	if args != `{"reasoning": "it's broken", "isCorrect": false}` {
+		t.Errorf("Expected fixture arguments, got %s", args)
+	}
+
+	// without a matching fixture, arguments are generated from the schema
+	stream, err := client.CreateChatCompletionStream(context.Background(), getReq("a fine file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, finishReason := readMockStream(t, stream)
+	if args != `{"isCorrect":false,"reasoning":"mock"}` {
+		t.Errorf("Expected generated arguments, got %s", args)
+	}
+	if finishReason != openai.FinishReasonToolCalls {
+		t.Errorf("Expected finish reason tool_calls, got %q", finishReason)
+	}
+}
+
+func TestMockProviderJsonSchemaResponse(t *testing.T) {
+	client := initMockTestProvider(t, `{"tool": "checkFile", "arguments": {"reasoning": "looks good", "isCorrect": true}, "chunkDelayMs": -1}`)
+
+	req := openai.ChatCompletionRequest{
+		Model:    "mock",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "check the file"}},
+	}
+
+	ctx := WithJsonSchemaResponse(context.Background(), mockTestFn.Name, mockTestFn.Parameters.(*jsonschema.Definition))
+
+	stream, err := client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, finishReason := readMockStream(t, stream)
+	if content != `{"reasoning": "looks good", "isCorrect": true}` {
+		t.Errorf("Expected fixture arguments as content, got %s", content)
+	}
+	if finishReason != openai.FinishReasonStop {
+		t.Errorf("Expected finish reason stop, got %q", finishReason)
+	}
+}
+
+func TestStrictJsonSchema(t *testing.T) {
+	schema := StrictJsonSchema(&jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"old": {
+				Type: jsonschema.Object,
+				Properties: map[string]jsonschema.Definition{
+					"entireFile":      {Type: jsonschema.Boolean},
+					"startLineString": {Type: jsonschema.String},
+				},
+				Required: []string{"startLineString"},
+			},
+		},
+		Required: []string{"old"},
+	})
+
+	bytes, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"additionalProperties":false,"properties":{"old":{"additionalProperties":false,"properties":{"entireFile":{"type":["boolean","null"]},"startLineString":{"type":"string"}},"required":["entireFile","startLineString"],"type":"object"}},"required":["old"],"type":"object"}`
+	if string(bytes) != expected {
+		t.Errorf("Expected %s, got %s", expected, bytes)
+	}
+}
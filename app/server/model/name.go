@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"log"
 	"plandex-server/model/prompts"
+	"regexp"
+	"strings"
 
 	"github.com/plandex/plandex/shared"
 	"github.com/sashabaranov/go-openai"
 )
 
-func GenPlanName(client *openai.Client, config shared.ModelRoleConfig, planContent string) (string, error) {
+func GenPlanName(client *openai.Client, config shared.ModelRoleConfig, planContent string, owner ResponseCacheOwner) (string, error) {
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
@@ -24,29 +26,26 @@ func GenPlanName(client *openai.Client, config shared.ModelRoleConfig, planConte
 		responseFormat = &openai.ChatCompletionResponseFormat{Type: "json_object"}
 	}
 
-	resp, err := CreateChatCompletionWithRetries(
-		client,
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: config.BaseModelConfig.ModelName,
-			Tools: []openai.Tool{
-				{
-					Type:     "function",
-					Function: &prompts.PlanNameFn,
-				},
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Tools: []openai.Tool{
+			{
+				Type:     "function",
+				Function: &prompts.PlanNameFn,
 			},
-			ToolChoice: openai.ToolChoice{
-				Type: "function",
-				Function: openai.ToolFunction{
-					Name: prompts.PlanNameFn.Name,
-				},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type: "function",
+			Function: openai.ToolFunction{
+				Name: prompts.PlanNameFn.Name,
 			},
-			Temperature:    config.Temperature,
-			TopP:           config.TopP,
-			Messages:       messages,
-			ResponseFormat: responseFormat,
 		},
-	)
+		Messages:       messages,
+		ResponseFormat: responseFormat,
+	}
+	reqCtx := ApplyRoleConfig(context.Background(), &modelReq, config)
+
+	resp, err := CreateChatCompletionWithCache(client, reqCtx, modelReq, shared.ResponseCacheOperationPlanNames, owner)
 
 	var res string
 	var nameRes prompts.PlanNameRes
@@ -78,11 +77,47 @@ func GenPlanName(client *openai.Client, config shared.ModelRoleConfig, planConte
 		return "", err
 	}
 
-	return nameRes.PlanName, nil
+	return SlugifyPlanName(nameRes.PlanName), nil
 
 }
 
-func GenPipedDataName(client *openai.Client, config shared.ModelRoleConfig, pipedContent string) (string, error) {
+const maxPlanNameLen = 40
+
+// minSubstantivePromptWords is the fewest words a prompt needs to say enough about a plan to name it
+const minSubstantivePromptWords = 5
+
+var planNameInvalidRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SlugifyPlanName makes a generated name safe to use as a plan name: lowercase letters and numbers separated by single dashes, at most maxPlanNameLen characters. It never returns 'draft', since plans with that name are replaced when a new draft is created.
+func SlugifyPlanName(name string) string {
+	slug := planNameInvalidRegex.ReplaceAllString(strings.ToLower(name), "-")
+	slug = strings.Trim(slug, "-")
+
+	if len(slug) > maxPlanNameLen {
+		slug = slug[:maxPlanNameLen]
+		// cut at a word boundary if there is one
+		if i := strings.LastIndex(slug, "-"); i > 0 {
+			slug = slug[:i]
+		}
+		slug = strings.Trim(slug, "-")
+	}
+
+	if slug == "" {
+		return "plan"
+	}
+	if slug == "draft" {
+		return "draft-plan"
+	}
+
+	return slug
+}
+
+// IsSubstantivePrompt returns whether a prompt says enough about a plan to name it from. Greetings and short follow-ups like 'hi' or 'go ahead' don't.
+func IsSubstantivePrompt(prompt string) bool {
+	return len(strings.Fields(prompt)) >= minSubstantivePromptWords
+}
+
+func GenPipedDataName(client *openai.Client, config shared.ModelRoleConfig, pipedContent string, owner ResponseCacheOwner) (string, error) {
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
@@ -103,29 +138,26 @@ func GenPipedDataName(client *openai.Client, config shared.ModelRoleConfig, pipe
 	// log.Printf("messages: %v\n", messages)
 	// log.Println(spew.Sdump(messages))
 
-	resp, err := CreateChatCompletionWithRetries(
-		client,
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: config.BaseModelConfig.ModelName,
-			Tools: []openai.Tool{
-				{
-					Type:     "function",
-					Function: &prompts.PipedDataNameFn,
-				},
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Tools: []openai.Tool{
+			{
+				Type:     "function",
+				Function: &prompts.PipedDataNameFn,
 			},
-			ToolChoice: openai.ToolChoice{
-				Type: "function",
-				Function: openai.ToolFunction{
-					Name: prompts.PipedDataNameFn.Name,
-				},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type: "function",
+			Function: openai.ToolFunction{
+				Name: prompts.PipedDataNameFn.Name,
 			},
-			Temperature:    config.Temperature,
-			TopP:           config.TopP,
-			Messages:       messages,
-			ResponseFormat: responseFormat,
 		},
-	)
+		Messages:       messages,
+		ResponseFormat: responseFormat,
+	}
+	reqCtx := ApplyRoleConfig(context.Background(), &modelReq, config)
+
+	resp, err := CreateChatCompletionWithCache(client, reqCtx, modelReq, shared.ResponseCacheOperationContextNames, owner)
 
 	var res string
 	var nameRes prompts.PipedDataNameRes
@@ -161,7 +193,7 @@ func GenPipedDataName(client *openai.Client, config shared.ModelRoleConfig, pipe
 
 }
 
-func GenNoteName(client *openai.Client, config shared.ModelRoleConfig, note string) (string, error) {
+func GenNoteName(client *openai.Client, config shared.ModelRoleConfig, note string, owner ResponseCacheOwner) (string, error) {
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
@@ -182,29 +214,26 @@ func GenNoteName(client *openai.Client, config shared.ModelRoleConfig, note stri
 	// log.Printf("messages: %v\n", messages)
 	// log.Println(spew.Sdump(messages))
 
-	resp, err := CreateChatCompletionWithRetries(
-		client,
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: config.BaseModelConfig.ModelName,
-			Tools: []openai.Tool{
-				{
-					Type:     "function",
-					Function: &prompts.NoteNameFn,
-				},
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Tools: []openai.Tool{
+			{
+				Type:     "function",
+				Function: &prompts.NoteNameFn,
 			},
-			ToolChoice: openai.ToolChoice{
-				Type: "function",
-				Function: openai.ToolFunction{
-					Name: prompts.NoteNameFn.Name,
-				},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type: "function",
+			Function: openai.ToolFunction{
+				Name: prompts.NoteNameFn.Name,
 			},
-			Temperature:    config.Temperature,
-			TopP:           config.TopP,
-			Messages:       messages,
-			ResponseFormat: responseFormat,
 		},
-	)
+		Messages:       messages,
+		ResponseFormat: responseFormat,
+	}
+	reqCtx := ApplyRoleConfig(context.Background(), &modelReq, config)
+
+	resp, err := CreateChatCompletionWithCache(client, reqCtx, modelReq, shared.ResponseCacheOperationContextNames, owner)
 
 	var res string
 	var nameRes prompts.NoteNameRes
@@ -0,0 +1,45 @@
+package model
+
+import "testing"
+
+func TestSlugifyPlanName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"auth-refactor", "auth-refactor"},
+		{"Auth Refactor", "auth-refactor"},
+		{"  add OAuth2 / SSO!  ", "add-oauth2-sso"},
+		{"fix--the__parser", "fix-the-parser"},
+		{"", "plan"},
+		{"???", "plan"},
+		{"Draft", "draft-plan"},
+		{"migrate-the-billing-service-to-the-new-payments-provider", "migrate-the-billing-service-to-the-new"},
+	}
+
+	for _, test := range tests {
+		got := SlugifyPlanName(test.name)
+		if got != test.expected {
+			t.Errorf("SlugifyPlanName(%q) = %q, want %q", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestIsSubstantivePrompt(t *testing.T) {
+	tests := []struct {
+		prompt   string
+		expected bool
+	}{
+		{"hi", false},
+		{"go ahead and continue", false},
+		{"add rate limiting to the api handlers", true},
+		{"  refactor   the auth middleware to use sessions  ", true},
+	}
+
+	for _, test := range tests {
+		got := IsSubstantivePrompt(test.prompt)
+		if got != test.expected {
+			t.Errorf("IsSubstantivePrompt(%q) = %v, want %v", test.prompt, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultOsvApiUrl = "https://api.osv.dev"
+
+// advisories change rarely, so results are cached to avoid querying the same versions on every build of a manifest
+const advisoryCacheTtl = 6 * time.Hour
+
+// queries run concurrently, up to this many at a time
+const maxConcurrentAdvisoryQueries = 8
+
+// AdvisoriesEnabled is whether dependency versions are checked against the OSV advisory database, set with PLANDEX_VULN_ADVISORIES. It's off by default since checking sends dependency names and versions to the advisory api.
+func AdvisoriesEnabled() bool {
+	return os.Getenv("PLANDEX_VULN_ADVISORIES") != ""
+}
+
+// osvApiUrl is the OSV api, or a mirror of it set with PLANDEX_OSV_API_URL
+func osvApiUrl() string {
+	url := os.Getenv("PLANDEX_OSV_API_URL")
+	if url == "" {
+		url = defaultOsvApiUrl
+	}
+	return strings.TrimRight(url, "/")
+}
+
+// PackageVersion is a version of a package in an OSV ecosystem, like 'Go', 'npm', or 'PyPI'
+type PackageVersion struct {
+	Ecosystem string
+	Name      string
+	Version   string
+}
+
+func (p PackageVersion) String() string {
+	return p.Name + "@" + p.Version
+}
+
+// Advisory is a known vulnerability that affects a package version
+type Advisory struct {
+	Id      string
+	Aliases []string
+	Summary string
+	// versions the vulnerability is fixed in, from each affected range
+	FixedVersions []string
+}
+
+type cachedAdvisories struct {
+	advisories []*Advisory
+	expiresAt  time.Time
+}
+
+var advisoryCache = map[PackageVersion]cachedAdvisories{}
+var advisoryCacheMu sync.Mutex
+
+// QueryAdvisories returns the advisories that affect each package version. Versions without advisories are left out. A failed query fails the whole call, so callers don't mistake a partial result for a clean one.
+func QueryAdvisories(ctx context.Context, pkgs []PackageVersion) (map[PackageVersion][]*Advisory, error) {
+	res := map[PackageVersion][]*Advisory{}
+	var toQuery []PackageVersion
+
+	advisoryCacheMu.Lock()
+	for _, pkg := range pkgs {
+		cached, ok := advisoryCache[pkg]
+		if ok && time.Now().Before(cached.expiresAt) {
+			if len(cached.advisories) > 0 {
+				res[pkg] = cached.advisories
+			}
+			continue
+		}
+		toQuery = append(toQuery, pkg)
+	}
+	advisoryCacheMu.Unlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var queryErr error
+	sem := make(chan struct{}, maxConcurrentAdvisoryQueries)
+
+	for _, pkg := range toQuery {
+		wg.Add(1)
+		go func(pkg PackageVersion) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			advisories, err := queryOsv(ctx, pkg)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				queryErr = fmt.Errorf("error querying advisories for %s: %v", pkg, err)
+				return
+			}
+
+			if len(advisories) > 0 {
+				res[pkg] = advisories
+			}
+
+			advisoryCacheMu.Lock()
+			advisoryCache[pkg] = cachedAdvisories{advisories: advisories, expiresAt: time.Now().Add(advisoryCacheTtl)}
+			advisoryCacheMu.Unlock()
+		}(pkg)
+	}
+
+	wg.Wait()
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	return res, nil
+}
+
+type osvQuery struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Version string `json:"version"`
+}
+
+type osvVuln struct {
+	Id       string   `json:"id"`
+	Summary  string   `json:"summary"`
+	Aliases  []string `json:"aliases"`
+	Affected []struct {
+		Package struct {
+			Name      string `json:"name"`
+			Ecosystem string `json:"ecosystem"`
+		} `json:"package"`
+		Ranges []struct {
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+func queryOsv(ctx context.Context, pkg PackageVersion) ([]*Advisory, error) {
+	var query osvQuery
+	query.Package.Name = pkg.Name
+	query.Package.Ecosystem = pkg.Ecosystem
+	query.Version = pkg.Version
+
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling query: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, osvApiUrl()+"/v1/query", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := getHttpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	var res struct {
+		Vulns []osvVuln `json:"vulns"`
+	}
+	err = json.Unmarshal(respBody, &res)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing response: %v", err)
+	}
+
+	var advisories []*Advisory
+	for _, vuln := range res.Vulns {
+		advisory := &Advisory{
+			Id:      vuln.Id,
+			Aliases: vuln.Aliases,
+			Summary: vuln.Summary,
+		}
+
+		for _, affected := range vuln.Affected {
+			if affected.Package.Name != pkg.Name {
+				continue
+			}
+			for _, r := range affected.Ranges {
+				for _, event := range r.Events {
+					if event.Fixed != "" {
+						advisory.FixedVersions = append(advisory.FixedVersions, event.Fixed)
+					}
+				}
+			}
+		}
+
+		advisories = append(advisories, advisory)
+	}
+
+	return advisories, nil
+}
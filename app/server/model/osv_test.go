@@ -0,0 +1,84 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestQueryAdvisories(t *testing.T) {
+	var numQueries atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numQueries.Add(1)
+
+		var query osvQuery
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if query.Package.Name != "osv-test-vulnerable" {
+			w.Write([]byte(`{}`))
+			return
+		}
+
+		w.Write([]byte(`{"vulns": [{
+			"id": "GHSA-test",
+			"summary": "Test vulnerability",
+			"affected": [
+				{"package": {"name": "osv-test-vulnerable", "ecosystem": "npm"}, "ranges": [{"events": [{"introduced": "0"}, {"fixed": "1.2.4"}]}]},
+				{"package": {"name": "other", "ecosystem": "npm"}, "ranges": [{"events": [{"fixed": "9.9.9"}]}]}
+			]
+		}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("PLANDEX_OSV_API_URL", server.URL+"/")
+
+	vulnerable := PackageVersion{Ecosystem: "npm", Name: "osv-test-vulnerable", Version: "1.2.3"}
+	clean := PackageVersion{Ecosystem: "npm", Name: "osv-test-clean", Version: "1.0.0"}
+
+	res, err := QueryAdvisories(context.Background(), []PackageVersion{vulnerable, clean})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := res[clean]; ok {
+		t.Errorf("expected no advisories for %s", clean)
+	}
+
+	advisories := res[vulnerable]
+	if len(advisories) != 1 {
+		t.Fatalf("got %d advisories, want 1", len(advisories))
+	}
+	if advisories[0].Id != "GHSA-test" || len(advisories[0].FixedVersions) != 1 || advisories[0].FixedVersions[0] != "1.2.4" {
+		t.Errorf("unexpected advisory: %+v", advisories[0])
+	}
+
+	// cached results aren't queried again
+	_, err = QueryAdvisories(context.Background(), []PackageVersion{vulnerable, clean})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := numQueries.Load(); n != 2 {
+		t.Errorf("got %d queries, want 2", n)
+	}
+}
+
+func TestQueryAdvisoriesError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	t.Setenv("PLANDEX_OSV_API_URL", server.URL)
+
+	_, err := QueryAdvisories(context.Background(), []PackageVersion{{Ecosystem: "npm", Name: "osv-test-error", Version: "1.0.0"}})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
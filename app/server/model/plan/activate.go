@@ -5,13 +5,14 @@ import (
 	"log"
 	"plandex-server/db"
 	"plandex-server/host"
+	"plandex-server/model"
 	"plandex-server/types"
 
 	"github.com/plandex/plandex/shared"
 	"github.com/sashabaranov/go-openai"
 )
 
-func activatePlan(clients map[string]*openai.Client, plan *db.Plan, branch string, auth *types.ServerAuth, prompt string, buildOnly bool) (*types.ActivePlan, error) {
+func activatePlan(clients map[string]*openai.Client, plan *db.Plan, branch string, auth *types.ServerAuth, prompt string, buildOnly bool, priority model.CallPriority) (*types.ActivePlan, error) {
 	active := GetActivePlan(plan.Id, branch)
 	if active != nil {
 		log.Printf("Tell: Active plan found for plan ID %s on branch %s\n", plan.Id, branch) // Log if an active plan is found
@@ -29,7 +30,7 @@ func activatePlan(clients map[string]*openai.Client, plan *db.Plan, branch strin
 		return nil, fmt.Errorf("plan %s branch %s already has an active stream on host %s", plan.Id, branch, modelStream.InternalIp)
 	}
 
-	active = CreateActivePlan(auth.OrgId, auth.User.Id, plan.Id, branch, prompt, buildOnly)
+	active = CreateActivePlan(auth.OrgId, auth.User.Id, plan.Id, branch, prompt, buildOnly, priority)
 
 	modelStream = &db.ModelStream{
 		OrgId:      auth.OrgId,
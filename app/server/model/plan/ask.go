@@ -0,0 +1,87 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"plandex-server/db"
+	"plandex-server/model/lib"
+	"plandex-server/model/prompts"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// askModelMessages returns the messages a question about the plan's context is sent to the model as, and their token count. Images aren't included, since answers cite lines of text. The context and question must fit in maxTokens; nothing is dropped to make them fit.
+func askModelMessages(contexts []*db.Context, question string, count func(string) (int, error), maxTokens int) ([]openai.ChatCompletionMessage, int, error) {
+	var textContexts []*db.Context
+	for _, context := range contexts {
+		if context.ContextType != shared.ContextImageType {
+			textContexts = append(textContexts, context)
+		}
+	}
+
+	contextText, _, err := lib.FormatModelContextWithLineNums(textContexts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error formatting context: %v", err)
+	}
+
+	sys := prompts.SysAsk + contextText
+	if len(textContexts) == 0 {
+		sys = prompts.SysAsk + prompts.AskNoContextPrompt
+	}
+
+	sysTokens, err := count(sys)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting num tokens for context: %v", err)
+	}
+
+	questionTokens, err := count(question)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting num tokens for question: %v", err)
+	}
+
+	numTokens := sysTokens + questionTokens
+	if numTokens > maxTokens {
+		return nil, 0, fmt.Errorf("context and question are %d 🪙, which is over the planner model's limit of %d 🪙—remove some context with 'plandex rm' and try again", numTokens, maxTokens)
+	}
+
+	return []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: sys,
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: question,
+		},
+	}, numTokens, nil
+}
+
+// StreamAsk answers a question about the plan's context with the planner model, calling onChunk with each part of the answer as it streams. The answer isn't added to the plan's conversation, and it can't produce builds. Usage is recorded under the planner role.
+func StreamAsk(ctx context.Context, clients map[string]*openai.Client, settings *shared.PlanSettings, orgId, userId, planId, branch string, contexts []*db.Context, question string, onChunk func(string) error) (string, error) {
+	config := settings.ModelPack.Planner.ModelRoleConfig
+
+	client := clients[config.BaseModelConfig.ApiKeyEnvVar]
+	if client == nil {
+		return "", fmt.Errorf("no api key set for the planner model: %s", config.BaseModelConfig.ApiKeyEnvVar)
+	}
+
+	tokenizer := settings.GetPlannerTokenizer()
+
+	messages, inputTokens, err := askModelMessages(contexts, question, chatTokenCounter(tokenizer), settings.GetPlannerEffectiveMaxTokens())
+	if err != nil {
+		return "", err
+	}
+
+	reply, reported, err := streamModelReply(ctx, client, config, messages, onChunk)
+	if err != nil {
+		return reply, err
+	}
+
+	outputTokens, err := tokenizer.CountTokens(reply)
+	if err == nil {
+		recordModelUsage(orgId, userId, planId, branch, shared.ModelRolePlanner, config, inputTokens, outputTokens, reported)
+	}
+
+	return reply, nil
+}
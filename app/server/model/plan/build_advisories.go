@@ -0,0 +1,305 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const advisoryQueryTimeout = 15 * time.Second
+
+// manifestDependency is a dependency version pinned in a manifest, with the 1-based line it's on
+type manifestDependency struct {
+	pkg model.PackageVersion
+	// the version as written in the manifest, which can differ from the version advisories are queried with
+	version string
+	line    int
+}
+
+var goModRequireRegex = regexp.MustCompile(`^(?:require\s+)?([^\s()]+)\s+(v\d+\.\d+\.\d+[^\s]*)`)
+
+// matches '"name": "1.2.3"' with an optional '^', '~', '=', or 'v' prefix, so ranges, tags, and urls are skipped
+var packageJsonDependencyRegex = regexp.MustCompile(`^\s*"([^"]+)"\s*:\s*"([\^~=]?v?(\d+\.\d+\.\d+[^"]*))"`)
+
+var requirementsRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(?:\[[^\]]*\])?\s*==\s*([^\s;#]+)`)
+
+// isDependencyManifest is whether dependency versions in a file can be checked against advisories
+func isDependencyManifest(path string) bool {
+	base := filepath.Base(path)
+	return base == "go.mod" || base == "package.json" || (strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt"))
+}
+
+// parseManifestDependencies finds the pinned dependency versions in a manifest. It works line by line, so it handles the partial manifests in a plan's proposed changes as well as whole files.
+func parseManifestDependencies(path, content string) []manifestDependency {
+	base := filepath.Base(path)
+
+	var deps []manifestDependency
+
+	for i, line := range strings.Split(content, "\n") {
+		var dep *manifestDependency
+
+		switch {
+		case base == "go.mod":
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "module ") || strings.HasPrefix(line, "go ") || strings.HasPrefix(line, "//") {
+				continue
+			}
+			// replace directives don't match since their second field is an arrow
+			m := goModRequireRegex.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			dep = &manifestDependency{
+				pkg:     model.PackageVersion{Ecosystem: "Go", Name: m[1], Version: strings.TrimPrefix(m[2], "v")},
+				version: m[2],
+			}
+
+		case base == "package.json":
+			m := packageJsonDependencyRegex.FindStringSubmatch(line)
+			if m == nil || m[1] == "version" {
+				continue
+			}
+			dep = &manifestDependency{
+				pkg:     model.PackageVersion{Ecosystem: "npm", Name: m[1], Version: m[3]},
+				version: m[2],
+			}
+
+		default:
+			m := requirementsRegex.FindStringSubmatch(strings.TrimSpace(line))
+			if m == nil {
+				continue
+			}
+			dep = &manifestDependency{
+				pkg:     model.PackageVersion{Ecosystem: "PyPI", Name: m[1], Version: m[2]},
+				version: m[2],
+			}
+		}
+
+		dep.line = i + 1
+		deps = append(deps, *dep)
+	}
+
+	return deps
+}
+
+// newManifestDependencies returns the dependency versions in updated that original doesn't have, which are the versions a change introduces
+func newManifestDependencies(path, original, updated string) []manifestDependency {
+	existing := map[model.PackageVersion]bool{}
+	for _, dep := range parseManifestDependencies(path, original) {
+		existing[dep.pkg] = true
+	}
+
+	var deps []manifestDependency
+	for _, dep := range parseManifestDependencies(path, updated) {
+		if !existing[dep.pkg] {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// queryDependencyAdvisories returns the advisories for each dependency that has any, in the order the dependencies are listed
+func queryDependencyAdvisories(ctx context.Context, deps []manifestDependency) ([]manifestDependency, map[model.PackageVersion][]*model.Advisory, error) {
+	var pkgs []model.PackageVersion
+	for _, dep := range deps {
+		pkgs = append(pkgs, dep.pkg)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, advisoryQueryTimeout)
+	defer cancel()
+
+	advisoriesByPkg, err := model.QueryAdvisories(ctx, pkgs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var vulnerable []manifestDependency
+	for _, dep := range deps {
+		if len(advisoriesByPkg[dep.pkg]) > 0 {
+			vulnerable = append(vulnerable, dep)
+		}
+	}
+
+	return vulnerable, advisoriesByPkg, nil
+}
+
+// dependencyAdvisoriesPrompt warns the builder about vulnerable versions in the plan's proposed changes to a manifest. It's empty when advisories are off, the file isn't a manifest, or nothing proposed is vulnerable. A failed check is logged and the build continues without warnings.
+func (fileState *activeBuildStreamFileState) dependencyAdvisoriesPrompt() string {
+	if fileState.advisoriesChecked {
+		return fileState.advisoriesPrompt
+	}
+	fileState.advisoriesChecked = true
+
+	if !model.AdvisoriesEnabled() || !isDependencyManifest(fileState.filePath) {
+		return ""
+	}
+
+	deps := newManifestDependencies(fileState.filePath, fileState.preBuildState, fileState.activeBuild.FileContent)
+	if len(deps) == 0 {
+		return ""
+	}
+
+	vulnerable, advisoriesByPkg, err := queryDependencyAdvisories(fileState.attemptCtx, deps)
+	if err != nil {
+		log.Printf("Error checking advisories for %s, building without them: %v\n", fileState.filePath, err)
+		return ""
+	}
+
+	if len(vulnerable) == 0 {
+		return ""
+	}
+
+	var warnings []string
+	for _, dep := range vulnerable {
+		warnings = append(warnings, formatDependencyAdvisories(dep, advisoriesByPkg[dep.pkg]))
+	}
+
+	log.Printf("Warning builder about %d vulnerable dependency versions in %s\n", len(vulnerable), fileState.filePath)
+
+	fileState.advisoriesPrompt = prompts.GetDependencyAdvisoriesPrompt(warnings)
+	return fileState.advisoriesPrompt
+}
+
+// buildFileDescription is the planner's description of the changes to the file, with any dependency advisory warnings
+func (fileState *activeBuildStreamFileState) buildFileDescription() string {
+	return fileState.activeBuild.FileDescription + fileState.dependencyAdvisoriesPrompt() + fileState.learnedPreferencesPrompt()
+}
+
+// dependencyFindings flags vulnerable dependency versions that a result introduces to a manifest, so they're reviewed before they're applied even if the builder didn't heed its warnings
+func (fileState *activeBuildStreamFileState) dependencyFindings(updated string) []*shared.CodeScanFinding {
+	if !model.AdvisoriesEnabled() || !isDependencyManifest(fileState.filePath) {
+		return nil
+	}
+
+	original := ""
+	if fileState.currentPlanState != nil {
+		if context := fileState.currentPlanState.ContextsByPath[fileState.filePath]; context != nil {
+			original = context.Body
+		}
+	}
+
+	deps := newManifestDependencies(fileState.filePath, original, updated)
+	if len(deps) == 0 {
+		return nil
+	}
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		return nil
+	}
+
+	vulnerable, advisoriesByPkg, err := queryDependencyAdvisories(activePlan.Ctx, deps)
+	if err != nil {
+		log.Printf("Error checking advisories for %s, skipping dependency check: %v\n", fileState.filePath, err)
+		return nil
+	}
+
+	var findings []*shared.CodeScanFinding
+	for _, dep := range vulnerable {
+		findings = append(findings, &shared.CodeScanFinding{
+			Rule:      shared.CodeScanRuleVulnerableDependency,
+			StartLine: dep.line,
+			EndLine:   dep.line,
+			Match:     formatDependencyAdvisories(dep, advisoriesByPkg[dep.pkg]),
+		})
+	}
+
+	if len(findings) > 0 {
+		log.Printf("Flagged %d vulnerable dependency versions in %s\n", len(findings), fileState.filePath)
+	}
+
+	return findings
+}
+
+// formatDependencyAdvisories describes a dependency's advisories on one line, like 'lodash@4.17.15: GHSA-p6mc-m468-83gw (Prototype Pollution in lodash). Fixed in 4.17.19.'
+func formatDependencyAdvisories(dep manifestDependency, advisories []*model.Advisory) string {
+	var ids []string
+	for _, advisory := range advisories {
+		id := advisory.Id
+		if advisory.Summary != "" {
+			id += " (" + advisory.Summary + ")"
+		}
+		ids = append(ids, id)
+	}
+
+	s := fmt.Sprintf("%s@%s: %s.", dep.pkg.Name, dep.version, strings.Join(ids, ", "))
+
+	fixed := lowestFixedVersion(dep.pkg.Version, advisories)
+	if fixed != "" {
+		if dep.pkg.Ecosystem == "Go" {
+			fixed = "v" + strings.TrimPrefix(fixed, "v")
+		}
+		s += " Fixed in " + fixed + "."
+	}
+
+	return s
+}
+
+// lowestFixedVersion is the lowest version above the current one that fixes every advisory, or an empty string if some advisory has no fix
+func lowestFixedVersion(current string, advisories []*model.Advisory) string {
+	res := ""
+	for _, advisory := range advisories {
+		// an advisory can list fixes in several ranges, like one for each major version, so the lowest one above the current version applies
+		fixed := ""
+		for _, v := range advisory.FixedVersions {
+			if compareVersions(v, current) > 0 && (fixed == "" || compareVersions(v, fixed) < 0) {
+				fixed = v
+			}
+		}
+		if fixed == "" {
+			return ""
+		}
+		if res == "" || compareVersions(fixed, res) > 0 {
+			res = fixed
+		}
+	}
+	return res
+}
+
+// compareVersions compares dotted versions part by part, numerically where both parts are numbers. It's loose enough for the semver-like versions in Go, npm, and PyPI advisories.
+func compareVersions(a, b string) int {
+	split := func(v string) []string {
+		return strings.FieldsFunc(strings.TrimPrefix(v, "v"), func(r rune) bool {
+			return r == '.' || r == '-' || r == '+'
+		})
+	}
+
+	aParts, bParts := split(a), split(b)
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		if i >= len(aParts) {
+			return -1
+		}
+		if i >= len(bParts) {
+			return 1
+		}
+
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+
+		if aErr == nil && bErr == nil {
+			if aNum != bNum {
+				if aNum < bNum {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+
+		if c := strings.Compare(aParts[i], bParts[i]); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
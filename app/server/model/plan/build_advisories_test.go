@@ -0,0 +1,130 @@
+package plan
+
+import (
+	"plandex-server/model"
+	"testing"
+)
+
+func TestParseManifestDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    []manifestDependency
+	}{
+		{
+			name:    "go.mod",
+			path:    "app/go.mod",
+			content: "module example.com/app\n\ngo 1.21\n\nrequire github.com/pkg/errors v0.9.1\n\nrequire (\n\tgolang.org/x/net v0.17.0 // indirect\n)\n\nreplace github.com/pkg/errors => ../errors\n",
+			want: []manifestDependency{
+				{pkg: model.PackageVersion{Ecosystem: "Go", Name: "github.com/pkg/errors", Version: "0.9.1"}, version: "v0.9.1", line: 5},
+				{pkg: model.PackageVersion{Ecosystem: "Go", Name: "golang.org/x/net", Version: "0.17.0"}, version: "v0.17.0", line: 8},
+			},
+		},
+		{
+			name:    "package.json",
+			path:    "package.json",
+			content: "{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\",\n  \"dependencies\": {\n    \"lodash\": \"^4.17.15\",\n    \"express\": \">=4.0.0\",\n    \"left-pad\": \"latest\"\n  }\n}\n",
+			want: []manifestDependency{
+				{pkg: model.PackageVersion{Ecosystem: "npm", Name: "lodash", Version: "4.17.15"}, version: "^4.17.15", line: 5},
+			},
+		},
+		{
+			name:    "requirements.txt",
+			path:    "requirements-dev.txt",
+			content: "# dev\nrequests[security]==2.19.0 ; python_version >= '3'\nflask>=2.0\n",
+			want: []manifestDependency{
+				{pkg: model.PackageVersion{Ecosystem: "PyPI", Name: "requests", Version: "2.19.0"}, version: "2.19.0", line: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !isDependencyManifest(tt.path) {
+				t.Fatalf("expected %s to be a manifest", tt.path)
+			}
+
+			got := parseManifestDependencies(tt.path, tt.content)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d dependencies, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("dependency %d: got %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if isDependencyManifest("main.go") {
+		t.Errorf("expected main.go not to be a manifest")
+	}
+}
+
+func TestNewManifestDependencies(t *testing.T) {
+	original := "lodash==1.0.0\nrequests==2.19.0\n"
+	updated := "lodash==1.0.0\nrequests==2.20.0\nflask==2.0.0\n"
+
+	got := newManifestDependencies("requirements.txt", original, updated)
+	if len(got) != 2 {
+		t.Fatalf("got %d dependencies, want 2: %+v", len(got), got)
+	}
+	if got[0].pkg.Name != "requests" || got[0].version != "2.20.0" || got[0].line != 2 {
+		t.Errorf("got %+v, want requests 2.20.0 on line 2", got[0])
+	}
+	if got[1].pkg.Name != "flask" || got[1].line != 3 {
+		t.Errorf("got %+v, want flask on line 3", got[1])
+	}
+}
+
+func TestLowestFixedVersion(t *testing.T) {
+	advisories := []*model.Advisory{
+		{Id: "A", FixedVersions: []string{"3.0.1", "4.17.12"}},
+		{Id: "B", FixedVersions: []string{"4.17.19"}},
+	}
+
+	if got := lowestFixedVersion("4.17.10", advisories); got != "4.17.19" {
+		t.Errorf("got %q, want 4.17.19", got)
+	}
+
+	if got := lowestFixedVersion("2.0.0", advisories[:1]); got != "3.0.1" {
+		t.Errorf("got %q, want 3.0.1", got)
+	}
+
+	advisories = append(advisories, &model.Advisory{Id: "C"})
+	if got := lowestFixedVersion("4.17.10", advisories); got != "" {
+		t.Errorf("got %q, want no fix when an advisory is unfixed", got)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.10", "1.2.9", 1},
+		{"v0.17.0", "0.17.0", 0},
+		{"1.2", "1.2.1", -1},
+		{"2.0.0-rc1", "2.0.0-rc2", -1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDependencyAdvisories(t *testing.T) {
+	dep := manifestDependency{
+		pkg:     model.PackageVersion{Ecosystem: "Go", Name: "golang.org/x/net", Version: "0.17.0"},
+		version: "v0.17.0",
+	}
+	advisories := []*model.Advisory{{Id: "GO-2024-2687", Summary: "HTTP/2 CONTINUATION flood", FixedVersions: []string{"0.23.0"}}}
+
+	want := "golang.org/x/net@v0.17.0: GO-2024-2687 (HTTP/2 CONTINUATION flood). Fixed in v0.23.0."
+	if got := formatDependencyAdvisories(dep, advisories); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
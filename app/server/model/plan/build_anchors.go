@@ -0,0 +1,65 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"plandex-server/model/prompts"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// onAnchorMismatches checks that the lines each change says it replaces, and the context lines around them, match the file's lines at those line numbers. When they don't, the change would be applied somewhere other than where the builder meant, so the model is asked once to correct its changes, and after that the build is retried. It returns true if the result was handled here.
+func (fileState *activeBuildStreamFileState) onAnchorMismatches(res types.ChangesWithLineNums) bool {
+	filePath := fileState.filePath
+	lines := strings.Split(fileState.preBuildState, "\n")
+
+	var mismatches []string
+	for _, change := range res.Changes {
+		mismatches = append(mismatches, change.AnchorMismatches(lines)...)
+	}
+
+	if len(mismatches) == 0 {
+		return false
+	}
+
+	log.Printf("Changes for file '%s' don't match the file's lines:\n%s\n", filePath, strings.Join(mismatches, "\n"))
+
+	mismatchErr := newBuildError(shared.ApiErrorTypeApplyConflict, fmt.Errorf("changes for file '%s' don't match the file's lines: %s", filePath, strings.Join(mismatches, "; ")))
+
+	if fileState.isDraft {
+		fileState.escalateDraft(mismatchErr)
+		return true
+	}
+
+	if fileState.anchorsCorrected {
+		fileState.lineNumsRetryOrError(mismatchErr)
+		return true
+	}
+	fileState.anchorsCorrected = true
+
+	log.Printf("Asking model to correct the line anchors of its changes for file '%s'\n", filePath)
+
+	response, err := json.Marshal(res)
+	if err != nil {
+		log.Printf("Error marshalling changes for file '%s': %v\n", filePath, err)
+		fileState.lineNumsRetryOrError(mismatchErr)
+		return true
+	}
+
+	corrected, err := fileState.getCorrectedReplacements(string(response), prompts.GetBuildAnchorCorrectionPrompt(mismatches))
+	if err != nil {
+		log.Printf("Error getting corrected changes for file '%s': %v\n", filePath, err)
+		if fileState.attemptTimedOut() {
+			fileState.onBuildFileError(fileState.attemptErr(err))
+			return true
+		}
+		fileState.lineNumsRetryOrError(mismatchErr)
+		return true
+	}
+
+	fileState.onBuildResult(*corrected)
+	return true
+}
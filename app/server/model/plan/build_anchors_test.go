@@ -0,0 +1,104 @@
+package plan
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestAnchorMismatches(t *testing.T) {
+	lines := strings.Split("package main\n\nfunc main() {\n\tfmt.Println(\"a\")\n}\n", "\n")
+
+	tests := []struct {
+		name   string
+		old    shared.StreamedChangeSection
+		wantOk bool
+	}{
+		{
+			name: "matching lines and context",
+			old: shared.StreamedChangeSection{
+				StartLineString:     "pdx-4: \tfmt.Println(\"a\")",
+				ContextBeforeString: "pdx-3: func main() {",
+				ContextAfterString:  "pdx-5: }",
+			},
+			wantOk: true,
+		},
+		{
+			name: "whitespace differences",
+			old: shared.StreamedChangeSection{
+				StartLineString: "pdx-4:   fmt.Println(\"a\")  ",
+			},
+			wantOk: true,
+		},
+		{
+			name: "line number doesn't match text",
+			old: shared.StreamedChangeSection{
+				StartLineString: "pdx-4: func main() {",
+			},
+			wantOk: false,
+		},
+		{
+			name: "wrong context line number",
+			old: shared.StreamedChangeSection{
+				StartLineString:     "pdx-4: \tfmt.Println(\"a\")",
+				ContextBeforeString: "pdx-2: func main() {",
+			},
+			wantOk: false,
+		},
+		{
+			name: "wrong end line",
+			old: shared.StreamedChangeSection{
+				StartLineString: "pdx-3: func main() {",
+				EndLineString:   "pdx-5: fmt.Println(\"a\")",
+			},
+			wantOk: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			change := shared.StreamedChangeWithLineNums{HasChange: true, Old: test.old}
+			mismatches := change.AnchorMismatches(lines)
+			if ok := len(mismatches) == 0; ok != test.wantOk {
+				t.Errorf("AnchorMismatches() = %v, want ok %v", mismatches, test.wantOk)
+			}
+		})
+	}
+}
+
+func TestReplacementAnchors(t *testing.T) {
+	original := "func a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 1\n}\n"
+
+	res, _, allSucceeded, err := GetPlanResult(context.Background(), PlanResultParams{
+		FilePath:      "main.go",
+		PreBuildState: original,
+		ChangesWithLineNums: []*shared.StreamedChangeWithLineNums{
+			{
+				HasChange: true,
+				Old:       shared.StreamedChangeSection{StartLineString: "pdx-6: \treturn 1"},
+				New:       "\treturn 2",
+			},
+		},
+		OverlapStrategy: OverlapStrategyError,
+	})
+	if err != nil || !allSucceeded {
+		t.Fatalf("GetPlanResult() error = %v, allSucceeded = %v", err, allSucceeded)
+	}
+
+	anchor := res.Replacements[0].Anchor
+	if anchor == nil || anchor.StartLine != 6 || anchor.EndLine != 6 {
+		t.Fatalf("expected an anchor for line 6, got %+v", anchor)
+	}
+
+	if _, ok := shared.ApplyReplacements(shared.AddLineNums(original), res.Replacements, false); !ok {
+		t.Errorf("expected the replacement to apply to the file it was built against")
+	}
+
+	// the same line at the same line number, but in a different function
+	changed := "func a() {\n\treturn 1\n}\n\nfunc c() {\n\treturn 1\n}\n"
+	if _, ok := shared.ApplyReplacements(shared.AddLineNums(changed), res.Replacements, false); ok {
+		t.Errorf("expected the replacement not to apply when its context changed")
+	}
+}
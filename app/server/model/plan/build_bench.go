@@ -0,0 +1,115 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"plandex-server/db"
+	"plandex-server/types"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// BenchFile is a file change from a plan reply, built again with each model pack in a benchmark
+type BenchFile struct {
+	Path          string
+	PreBuildState string
+	Description   string
+	Changes       string
+}
+
+type BenchRun struct {
+	NumFiles         int
+	NumPassed        int
+	Duration         time.Duration
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// GetBenchFiles returns the file changes proposed in the plan's latest reply that has any. Each file's pre-build state is the file as it was before that reply's changes, so every model builds from the same starting point as the plan's own build.
+func GetBenchFiles(planState *shared.CurrentPlanState, convo []*db.ConvoMessage) ([]*BenchFile, error) {
+	for i := len(convo) - 1; i >= 0; i-- {
+		msg := convo[i]
+		if msg.Role != openai.ChatMessageRoleAssistant {
+			continue
+		}
+
+		parser := types.NewReplyParser()
+		parser.AddChunk(msg.Message, false)
+		parserRes := parser.FinishAndRead()
+
+		if len(parserRes.Files) == 0 {
+			continue
+		}
+
+		var files []*BenchFile
+		for j, path := range parserRes.Files {
+			path, preBuildState, err := getBenchPreBuildState(planState, msg.Id, path)
+			if err != nil {
+				return nil, err
+			}
+
+			files = append(files, &BenchFile{
+				Path:          path,
+				PreBuildState: preBuildState,
+				Description:   parserRes.FileDescriptions[j],
+				Changes:       parserRes.FileContents[j],
+			})
+		}
+
+		return files, nil
+	}
+
+	return nil, nil
+}
+
+// getBenchPreBuildState returns a reply file's full path and its content before the reply's changes. Paths in the reply may be relative to a workspace root. New files start empty.
+func getBenchPreBuildState(planState *shared.CurrentPlanState, convoMessageId, path string) (string, string, error) {
+	if planState.PlanResult != nil {
+		for resultPath, results := range planState.PlanResult.FileResultsByPath {
+			if resultPath != path && !strings.HasSuffix(resultPath, "/"+path) {
+				continue
+			}
+
+			for _, result := range results {
+				if result.ConvoMessageId != convoMessageId || result.IsFix || len(result.Replacements) == 0 {
+					continue
+				}
+
+				files, err := planState.GetFilesBeforeReplacement(result.Replacements[0].Id)
+				if err != nil {
+					return "", "", fmt.Errorf("error getting files before replacement: %v", err)
+				}
+
+				return resultPath, files.Files[resultPath], nil
+			}
+		}
+	}
+
+	if context := planState.ContextsByPath[path]; context != nil {
+		return path, context.Body, nil
+	}
+
+	return path, "", nil
+}
+
+// RunBench builds each file with the given builder model, one at a time so durations aren't skewed by concurrent requests to the same provider
+func RunBench(ctx context.Context, client *openai.Client, config shared.ModelRoleConfig, files []*BenchFile) *BenchRun {
+	res := &BenchRun{NumFiles: len(files)}
+
+	for _, file := range files {
+		run := runBuild(ctx, client, config, file.Path, file.PreBuildState, file.Description, file.Changes)
+
+		res.Duration += run.duration
+		res.PromptTokens += run.usage.PromptTokens
+		res.CompletionTokens += run.usage.CompletionTokens
+
+		if run.err == nil && run.allSucceeded && run.syntaxValid {
+			res.NumPassed++
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,50 @@
+package plan
+
+import (
+	"plandex-server/db"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestGetBenchFiles(t *testing.T) {
+	reply := "Here are the changes.\n\n- file: main.go\n\n```go\nfunc main() {\n\tgreet()\n}\n```\n\n- file: greet.go\n\n```go\nfunc greet() {}\n```\n"
+
+	convo := []*db.ConvoMessage{
+		{Id: "1", Role: openai.ChatMessageRoleUser, Message: "add a greeting"},
+		{Id: "2", Role: openai.ChatMessageRoleAssistant, Message: reply},
+		{Id: "3", Role: openai.ChatMessageRoleAssistant, Message: "Let me know if you need anything else."},
+	}
+
+	state := &shared.CurrentPlanState{
+		ContextsByPath: map[string]*shared.Context{
+			"main.go": {FilePath: "main.go", Body: "func main() {}\n"},
+		},
+	}
+
+	files, err := GetBenchFiles(state, convo)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(files))
+	}
+
+	if files[0].Path != "main.go" || files[0].PreBuildState != "func main() {}\n" {
+		t.Errorf("Expected main.go to start from its context body, got %q with %q", files[0].Path, files[0].PreBuildState)
+	}
+
+	if files[1].Path != "greet.go" || files[1].PreBuildState != "" {
+		t.Errorf("Expected greet.go to start empty, got %q with %q", files[1].Path, files[1].PreBuildState)
+	}
+
+	files, err = GetBenchFiles(state, convo[:1])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected no files without a reply, got %d", len(files))
+	}
+}
@@ -0,0 +1,133 @@
+package plan
+
+import (
+	"encoding/json"
+	"log"
+	"plandex-server/db"
+	"plandex-server/model"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// captureStream wraps a model stream and records the response as it's received
+type captureStream struct {
+	model.ChatCompletionStream
+	fileState *activeBuildStreamFileState
+	capture   *db.BuildCapture
+	response  strings.Builder
+	closed    bool
+}
+
+func (s *captureStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	res, err := s.ChatCompletionStream.Recv()
+
+	if err != nil {
+		s.capture.Error = err.Error()
+	} else if len(res.Choices) > 0 {
+		delta := res.Choices[0].Delta
+		s.response.WriteString(delta.Content)
+		for _, toolCall := range delta.ToolCalls {
+			s.response.WriteString(toolCall.Function.Arguments)
+		}
+	}
+
+	return res, err
+}
+
+// Close stores the capture with whatever was received, so streams that time out or fail to parse are captured too
+func (s *captureStream) Close() error {
+	if !s.closed {
+		s.closed = true
+		s.capture.Response = s.response.String()
+		s.fileState.storeBuildCapture(s.capture)
+	}
+	return s.ChatCompletionStream.Close()
+}
+
+func (fileState *activeBuildStreamFileState) newBuildCapture(phase shared.BuildCapturePhase, req openai.ChatCompletionRequest) *db.BuildCapture {
+	// message contents are redacted before marshalling so the patterns match the text the model saw
+	redactedReq := req
+	redactedReq.Messages = make([]openai.ChatCompletionMessage, len(req.Messages))
+	for i, msg := range req.Messages {
+		msg.Content = model.RedactSecrets(msg.Content)
+		redactedReq.Messages[i] = msg
+	}
+
+	reqBytes, err := json.MarshalIndent(redactedReq, "", "  ")
+	if err != nil {
+		log.Printf("Error marshalling model request for capture: %v\n", err)
+	}
+
+	// build changes are applied to the file as it was before the build, and fixes and verification to the updated file
+	preBuildState := fileState.preBuildState
+	if phase != shared.BuildCapturePhaseBuild {
+		preBuildState = fileState.updated
+	}
+
+	return &db.BuildCapture{
+		OrgId:         fileState.currentOrgId,
+		PlanId:        fileState.plan.Id,
+		PlanBuildId:   fileState.build.Id,
+		FilePath:      fileState.filePath,
+		Phase:         string(phase),
+		ModelName:     req.Model,
+		Request:       string(reqBytes),
+		PreBuildState: model.RedactSecrets(preBuildState),
+		StartedAt:     time.Now(),
+	}
+}
+
+// captureModelStream wraps a build stream so its request and response are recorded if the plan has CaptureModelIO on. A stream that couldn't be created (err != nil) is recorded immediately.
+func (fileState *activeBuildStreamFileState) captureModelStream(phase shared.BuildCapturePhase, req openai.ChatCompletionRequest, stream model.ChatCompletionStream, err error) model.ChatCompletionStream {
+	if !fileState.settings.CaptureModelIO {
+		return stream
+	}
+
+	capture := fileState.newBuildCapture(phase, req)
+
+	if err != nil {
+		capture.Error = err.Error()
+		fileState.storeBuildCapture(capture)
+		return stream
+	}
+
+	return &captureStream{
+		ChatCompletionStream: stream,
+		fileState:            fileState,
+		capture:              capture,
+	}
+}
+
+// captureModelResponse records a non-streaming build request and its response if the plan has CaptureModelIO on
+func (fileState *activeBuildStreamFileState) captureModelResponse(phase shared.BuildCapturePhase, req openai.ChatCompletionRequest, resp openai.ChatCompletionResponse, err error) {
+	if !fileState.settings.CaptureModelIO {
+		return
+	}
+
+	capture := fileState.newBuildCapture(phase, req)
+
+	if err != nil {
+		capture.Error = err.Error()
+	} else {
+		respBytes, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			log.Printf("Error marshalling model response for capture: %v\n", err)
+		}
+		capture.Response = string(respBytes)
+	}
+
+	fileState.storeBuildCapture(capture)
+}
+
+// storeBuildCapture doesn't return an error since a failed capture shouldn't fail the build
+func (fileState *activeBuildStreamFileState) storeBuildCapture(capture *db.BuildCapture) {
+	capture.Response = model.RedactSecrets(capture.Response)
+
+	err := db.StoreBuildCapture(capture)
+	if err != nil {
+		log.Printf("Error storing build capture for file %s: %v\n", capture.FilePath, err)
+	}
+}
@@ -0,0 +1,339 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"plandex-server/types"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// tokens held back from the verifier's limit for the consistency check's response
+const consistencyCheckOutputTokens = 4096
+
+type consistencyFix struct {
+	Path    string `json:"path"`
+	Problem string `json:"problem"`
+	Changes string `json:"changes"`
+}
+
+// checkConsistency checks the files a multi-file build changed, and the files in context that reference symbols whose public API it changed, for inconsistencies between them, like callers that weren't updated for a changed signature. A fix build is queued for each file with problems. The check is optional, so errors are logged and the build finishes without it. It returns whether fixes were queued, in which case the build finishes after they're built.
+func (state *activeBuildStreamFileState) checkConsistency() bool {
+	planId := state.plan.Id
+	branch := state.branch
+
+	var paths []string
+	replyIdsByPath := map[string]string{}
+	claimed := false
+
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		if !ap.ConsistencyCheckPending() || ap.IsCheckingConsistency {
+			return
+		}
+
+		for path := range ap.ConsistencyCheckPaths {
+			paths = append(paths, path)
+
+			replyIdsByPath[path] = state.activeBuild.ReplyId
+			builds := ap.BuildQueuesByPath[path]
+			if len(builds) > 0 {
+				replyIdsByPath[path] = builds[len(builds)-1].ReplyId
+			}
+		}
+
+		ap.ConsistencyCheckPaths = map[string]bool{}
+		ap.IsCheckingConsistency = true
+		claimed = true
+	})
+
+	if !claimed {
+		return false
+	}
+
+	sort.Strings(paths)
+
+	fixes := state.getConsistencyFixes(paths, replyIdsByPath)
+	if len(fixes) > 0 {
+		state.queueBuilds(fixes)
+	}
+
+	// fixes that finished before the check was done couldn't finish the build, so finish it here
+	queuesFinished := false
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		ap.IsCheckingConsistency = false
+		queuesFinished = ap.QueuesFinished()
+	})
+
+	return len(fixes) > 0 && !queuesFinished
+}
+
+func (state *activeBuildStreamFileState) getConsistencyFixes(paths []string, replyIdsByPath map[string]string) []*types.ActiveBuild {
+	planId := state.plan.Id
+	branch := state.branch
+
+	activePlan := GetActivePlan(planId, branch)
+	if activePlan == nil {
+		log.Println("checkConsistency - Active plan not found")
+		return nil
+	}
+
+	currentPlan, err := state.getConsistencyCheckPlanState(activePlan)
+	if err != nil {
+		log.Printf("Error getting current plan state for consistency check: %v\n", err)
+		return nil
+	}
+
+	changesByPath := map[string][]apiChange{}
+	var changeLines []string
+	for _, path := range paths {
+		updated, ok := currentPlan.CurrentPlanFiles.Files[path]
+		if !ok {
+			continue
+		}
+
+		var original string
+		if context := activePlan.ContextsByPath[path]; context != nil {
+			original = context.Body
+		}
+
+		changes := apiChanges(path, original, updated)
+		if len(changes) == 0 {
+			continue
+		}
+		changesByPath[path] = changes
+		changeLines = append(changeLines, fmt.Sprintf("- %s:\n%s", path, formatApiChanges(changes)))
+	}
+
+	if len(changesByPath) == 0 {
+		log.Println("No public API changes, skipping consistency check")
+		return nil
+	}
+
+	config := state.settings.ModelPack.GetVerifier()
+	tokenizer := config.BaseModelConfig.GetTokenizer()
+	maxTokens := config.BaseModelConfig.MaxTokens - consistencyCheckOutputTokens
+
+	files := consistencyCheckFiles(paths, changesByPath, activePlan.Contexts, currentPlan.CurrentPlanFiles.Files, func(s string) int {
+		numTokens, err := tokenizer.CountTokens(s)
+		if err != nil {
+			log.Printf("Error getting num tokens for consistency check: %v\n", err)
+		}
+		return numTokens
+	}, maxTokens)
+
+	if len(files) < 2 {
+		log.Println("Less than 2 files fit in the consistency check, skipping it")
+		return nil
+	}
+
+	log.Printf("Checking consistency of %d files\n", len(files))
+
+	client := state.clients[config.BaseModelConfig.ApiKeyEnvVar]
+
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: prompts.GetConsistencyCheckSysPrompt(strings.Join(changeLines, "\n\n"), files),
+			},
+		},
+	}
+
+	reqCtx := model.ApplyRoleConfig(activePlan.Ctx, &modelReq, config)
+
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+	if err != nil {
+		log.Printf("Error checking consistency: %v\n", err)
+		return nil
+	}
+
+	recordModelUsage(state.currentOrgId, state.currentUserId, planId, branch, shared.ModelRoleVerifier, config, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, model.ReportedUsageFromContext(reqCtx))
+
+	if len(resp.Choices) == 0 {
+		log.Println("No choices in consistency check response")
+		return nil
+	}
+
+	checked := map[string]bool{}
+	for _, file := range files {
+		checked[file.Path] = true
+	}
+
+	fixes, err := parseConsistencyFixes(resp.Choices[0].Message.Content, checked)
+	if err != nil {
+		log.Printf("Error parsing consistency check response: %v\n", err)
+		return nil
+	}
+
+	var builds []*types.ActiveBuild
+	for _, fix := range fixes {
+		numTokens, err := shared.GetNumTokens(fix.Changes)
+		if err != nil {
+			log.Printf("Error getting num tokens for consistency fix: %v\n", err)
+		}
+
+		replyId, ok := replyIdsByPath[fix.Path]
+		if !ok {
+			replyId = state.activeBuild.ReplyId
+		}
+
+		log.Printf("Queueing consistency fix for %s: %s\n", fix.Path, fix.Problem)
+
+		builds = append(builds, &types.ActiveBuild{
+			ReplyId:           replyId,
+			Path:              fix.Path,
+			FileDescription:   "Fix inconsistencies with the changes to other files: " + fix.Problem,
+			FileContent:       fix.Changes,
+			FileContentTokens: numTokens,
+			ConsistencyFix:    true,
+		})
+	}
+
+	return builds
+}
+
+// getConsistencyCheckPlanState loads the plan's current state, with all of the build's files applied
+func (state *activeBuildStreamFileState) getConsistencyCheckPlanState(activePlan *types.ActivePlan) (*shared.CurrentPlanState, error) {
+	repoLockId, err := db.LockRepo(
+		db.LockRepoParams{
+			OrgId:       state.currentOrgId,
+			UserId:      state.currentUserId,
+			PlanId:      state.plan.Id,
+			Branch:      state.branch,
+			PlanBuildId: state.build.Id,
+			Scope:       db.LockScopeRead,
+			Ctx:         activePlan.Ctx,
+			CancelFn:    activePlan.CancelFn,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error locking repo: %v", err)
+	}
+
+	defer func() {
+		err := db.DeleteRepoLock(repoLockId)
+		if err != nil {
+			log.Printf("Error unlocking repo: %v\n", err)
+		}
+	}()
+
+	return db.GetCurrentPlanState(db.CurrentPlanStateParams{
+		OrgId:  state.currentOrgId,
+		PlanId: state.plan.Id,
+	})
+}
+
+// consistencyCheckFiles returns the files to include in a consistency check: the built files, then the code files in context that reference a changed or removed symbol, while they fit in maxTokens. Files are included in their current state, with the plan's pending changes.
+func consistencyCheckFiles(builtPaths []string, changesByPath map[string][]apiChange, contexts []*db.Context, pendingFiles map[string]string, numTokens func(s string) int, maxTokens int) []prompts.ConsistencyCheckFile {
+	var files []prompts.ConsistencyCheckFile
+	included := map[string]bool{}
+	total := 0
+
+	add := func(path, content string) {
+		if included[path] {
+			return
+		}
+		tokens := numTokens(content)
+		if total+tokens > maxTokens {
+			log.Printf("Skipping %s in consistency check, it doesn't fit in the token limit\n", path)
+			return
+		}
+		total += tokens
+		included[path] = true
+		files = append(files, prompts.ConsistencyCheckFile{Path: path, Content: content})
+	}
+
+	for _, path := range builtPaths {
+		if content, ok := pendingFiles[path]; ok {
+			add(path, content)
+		}
+	}
+
+	var sourcePaths []string
+	for path := range changesByPath {
+		sourcePaths = append(sourcePaths, path)
+	}
+	sort.Strings(sourcePaths)
+
+	for _, sourcePath := range sourcePaths {
+		// added symbols can't break other files
+		var changes []apiChange
+		for _, change := range changesByPath[sourcePath] {
+			if change.before != "" {
+				changes = append(changes, change)
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		paths := referencingContexts(sourcePath, changes, contexts, func(path string) bool {
+			return !docExts[strings.ToLower(filepath.Ext(path))] && !included[path]
+		})
+
+		for _, path := range paths {
+			content, ok := pendingFiles[path]
+			if !ok {
+				for _, context := range contexts {
+					if context.FilePath == path {
+						content = context.Body
+						break
+					}
+				}
+			}
+			add(path, content)
+		}
+	}
+
+	return files
+}
+
+// parseConsistencyFixes parses the fixes from a consistency check response, keeping those for files that were checked and combining fixes for the same file
+func parseConsistencyFixes(response string, checked map[string]bool) ([]*consistencyFix, error) {
+	content, ok := getCodeBlockContent(response)
+	if !ok {
+		content = response
+	}
+
+	var res []*consistencyFix
+	err := json.Unmarshal([]byte(content), &res)
+	if err != nil {
+		// repairJson only extracts objects, so the array is wrapped in one
+		var wrapped struct {
+			Fixes []*consistencyFix `json:"fixes"`
+		}
+		err = json.Unmarshal([]byte(repairJson(`{"fixes": `+strings.TrimSpace(content)+`}`)), &wrapped)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshalling consistency check response: %v", err)
+		}
+		res = wrapped.Fixes
+	}
+
+	var fixes []*consistencyFix
+	byPath := map[string]*consistencyFix{}
+	for _, fix := range res {
+		if fix == nil || !checked[fix.Path] || strings.TrimSpace(fix.Changes) == "" {
+			continue
+		}
+
+		if existing, ok := byPath[fix.Path]; ok {
+			existing.Problem += " " + fix.Problem
+			existing.Changes += "\n\n" + fix.Changes
+			continue
+		}
+
+		byPath[fix.Path] = fix
+		fixes = append(fixes, fix)
+	}
+
+	return fixes, nil
+}
@@ -0,0 +1,100 @@
+package plan
+
+import (
+	"plandex-server/db"
+	"reflect"
+	"testing"
+)
+
+func TestConsistencyCheckFiles(t *testing.T) {
+	changesByPath := map[string][]apiChange{
+		"lib/user.go": {
+			{name: "LoadUser", before: "func LoadUser(id string) (*User, error)", after: "func LoadUser(id string, opts LoadOpts) (*User, error)"},
+			{name: "LoadOpts", after: "type LoadOpts struct"},
+		},
+	}
+
+	contexts := []*db.Context{
+		{FilePath: "lib/user.go", Body: "func LoadUser(id string) (*User, error) {}"},
+		{FilePath: "cmd/profile.go", Body: "u, err := lib.LoadUser(id)"},
+		{FilePath: "cmd/settings.go", Body: "u, err := lib.LoadUser(id)"},
+		{FilePath: "README.md", Body: "Call LoadUser to load a user."},
+		{FilePath: "cmd/other.go", Body: "loadUserSettings(id)"},
+	}
+
+	pendingFiles := map[string]string{
+		"lib/user.go":    "func LoadUser(id string, opts LoadOpts) (*User, error) {}",
+		"lib/opts.go":    "type LoadOpts struct {}",
+		"cmd/profile.go": "u, err := lib.LoadUser(id, lib.LoadOpts{})",
+	}
+
+	numTokens := func(s string) int {
+		return len(s)
+	}
+
+	files := consistencyCheckFiles([]string{"lib/opts.go", "lib/user.go"}, changesByPath, contexts, pendingFiles, numTokens, 1000)
+
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+
+	want := []string{"lib/opts.go", "lib/user.go", "cmd/profile.go", "cmd/settings.go"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got paths %v, want %v", paths, want)
+	}
+
+	if files[2].Content != pendingFiles["cmd/profile.go"] {
+		t.Errorf("expected cmd/profile.go with pending changes, got %q", files[2].Content)
+	}
+	if files[3].Content != "u, err := lib.LoadUser(id)" {
+		t.Errorf("expected cmd/settings.go from context, got %q", files[3].Content)
+	}
+
+	limited := consistencyCheckFiles([]string{"lib/opts.go", "lib/user.go"}, changesByPath, contexts, pendingFiles, numTokens, 100)
+	if len(limited) != 2 {
+		t.Errorf("expected only the files that fit in the token limit, got %d files", len(limited))
+	}
+}
+
+func TestParseConsistencyFixes(t *testing.T) {
+	checked := map[string]bool{
+		"cmd/profile.go":  true,
+		"cmd/settings.go": true,
+	}
+
+	response := "```json\n" + `[
+  {"path": "cmd/settings.go", "problem": "LoadUser is missing opts.", "changes": "u, err := lib.LoadUser(id, lib.LoadOpts{})"},
+  {"path": "cmd/settings.go", "problem": "LoadOpts isn't imported.", "changes": "import \"lib\""},
+  {"path": "cmd/unknown.go", "problem": "Not checked.", "changes": "x"},
+  {"path": "cmd/profile.go", "problem": "No changes.", "changes": ""},
+]` + "\n```"
+
+	fixes, err := parseConsistencyFixes(response, checked)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(fixes) != 1 {
+		t.Fatalf("expected 1 fix, got %d", len(fixes))
+	}
+
+	fix := fixes[0]
+	if fix.Path != "cmd/settings.go" {
+		t.Errorf("got path %s", fix.Path)
+	}
+	if fix.Problem != "LoadUser is missing opts. LoadOpts isn't imported." {
+		t.Errorf("got problem %q", fix.Problem)
+	}
+	if fix.Changes != "u, err := lib.LoadUser(id, lib.LoadOpts{})\n\nimport \"lib\"" {
+		t.Errorf("got changes %q", fix.Changes)
+	}
+
+	empty, err := parseConsistencyFixes("```json\n[]\n```", checked)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("expected no fixes, got %d", len(empty))
+	}
+}
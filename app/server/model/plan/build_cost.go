@@ -0,0 +1,159 @@
+package plan
+
+import (
+	"fmt"
+	"log"
+	"plandex-server/db"
+	"plandex-server/model/prompts"
+	"plandex-server/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// expectedBuildResponseTokens is about how long a build response is. The response restates the proposed changes as line-numbered replacements wrapped in JSON, so it's around 1.5x the proposed changes' tokens.
+func expectedBuildResponseTokens(fileContentTokens int) int {
+	return fileContentTokens*3/2 + 100
+}
+
+// estimateBuildCost estimates the tokens and cost of building the pending builds with the builder model, before any calls are made. The low end assumes one builder call per build, and that files that aren't in context are new, so they don't need a call for their first build. The high end assumes every build also needs a verification or fix pass of about the same size, and that files that aren't in context were created earlier in the plan and are about as long as their proposed changes. instructionsTokens is the size of the build prompt without the file, description, or changes. pricing is nil when the builder model's price isn't known.
+func estimateBuildCost(config shared.ModelRoleConfig, pricing *shared.ModelPricing, pendingBuildsByPath map[string][]*types.ActiveBuild, contextsByPath map[string]*db.Context, instructionsTokens int) (*shared.BuildCostEstimate, error) {
+	tokenizer := config.BaseModelConfig.GetTokenizer()
+
+	estimate := &shared.BuildCostEstimate{
+		Model:    config.BaseModelConfig.ModelName,
+		NumFiles: len(pendingBuildsByPath),
+	}
+
+	for path, builds := range pendingBuildsByPath {
+		var currentFileTokens int
+		inContext := false
+		if context, ok := contextsByPath[path]; ok {
+			currentFileTokens = context.NumTokens
+			inContext = true
+		}
+
+		for i, build := range builds {
+			estimate.NumBuilds++
+
+			var descriptionTokens int
+			if build.FileDescription != "" {
+				numTokens, err := shared.GetNumTokens(build.FileDescription)
+				if err != nil {
+					return nil, fmt.Errorf("error counting file description tokens: %v", err)
+				}
+				descriptionTokens = numTokens
+			}
+
+			if !inContext && i == 0 {
+				// for the high end, and for later builds that update the file its first build creates
+				currentFileTokens = build.FileContentTokens
+			}
+
+			input := tokenizer.FromBaseTokens(instructionsTokens + currentFileTokens + descriptionTokens + build.FileContentTokens)
+			output := tokenizer.FromBaseTokens(expectedBuildResponseTokens(build.FileContentTokens))
+
+			if inContext || i > 0 {
+				estimate.InputTokensMin += input
+				estimate.OutputTokensMin += output
+			}
+			estimate.InputTokensMax += input * 2
+			estimate.OutputTokensMax += output * 2
+		}
+	}
+
+	if pricing != nil {
+		estimate.HasPricing = true
+		estimate.CostMin = pricing.Cost(estimate.InputTokensMin, estimate.OutputTokensMin)
+		estimate.CostMax = pricing.Cost(estimate.InputTokensMax, estimate.OutputTokensMax)
+	}
+
+	return estimate, nil
+}
+
+// addDraftPass adjusts a builder estimate for the speculative build strategy. At the low end, every draft is used, so the builder's low-end calls are made by the draft-builder instead. At the high end, every draft fails, so a draft call is made on top of the builder's calls. Token counts are in the builder's tokenizer. draftPricing is nil when the draft-builder model's price isn't known.
+func addDraftPass(estimate *shared.BuildCostEstimate, pricing, draftPricing *shared.ModelPricing, draftModel string) {
+	draftInputMax := estimate.InputTokensMax / 2
+	draftOutputMax := estimate.OutputTokensMax / 2
+
+	if pricing != nil && draftPricing != nil {
+		estimate.CostMin = draftPricing.Cost(estimate.InputTokensMin, estimate.OutputTokensMin)
+		estimate.CostMax = pricing.Cost(estimate.InputTokensMax, estimate.OutputTokensMax) + draftPricing.Cost(draftInputMax, draftOutputMax)
+	} else {
+		estimate.HasPricing = false
+		estimate.CostMin = 0
+		estimate.CostMax = 0
+		if draftPricing == nil {
+			estimate.Model = draftModel
+		}
+	}
+
+	estimate.InputTokensMax += draftInputMax
+	estimate.OutputTokensMax += draftOutputMax
+}
+
+// getBuildInstructionsTokens counts the build prompt without a file, description, or changes
+func getBuildInstructionsTokens() (int, error) {
+	numTokens, err := shared.GetNumTokens(prompts.GetBuildLineNumbersSysPrompt("", "", ""))
+	if err != nil {
+		return 0, fmt.Errorf("error counting build instructions tokens: %v", err)
+	}
+	return numTokens, nil
+}
+
+// streamBuildEstimate estimates the build's cost and streams it to the client. If the estimate is above the trust policy's threshold, the build is paused before any builder calls are made, so it only goes ahead once it's resumed. An estimate is best-effort, so errors are logged and the build goes ahead.
+func (state *activeBuildStreamState) streamBuildEstimate(pendingBuildsByPath map[string][]*types.ActiveBuild) {
+	planId := state.plan.Id
+	branch := state.branch
+
+	active := GetActivePlan(planId, branch)
+	if active == nil {
+		log.Printf("streamBuildEstimate - Active plan not found for plan ID %s and branch %s\n", planId, branch)
+		return
+	}
+
+	instructionsTokens, err := getBuildInstructionsTokens()
+	if err != nil {
+		log.Printf("Error estimating build cost: %v\n", err)
+		return
+	}
+
+	config := state.settings.ModelPack.Builder
+	pricing := db.GetModelPricing(config.BaseModelConfig.Provider, config.BaseModelConfig.ModelName)
+
+	estimate, err := estimateBuildCost(config, pricing, pendingBuildsByPath, active.ContextsByPath, instructionsTokens)
+	if err != nil {
+		log.Printf("Error estimating build cost: %v\n", err)
+		return
+	}
+
+	if state.settings.GetBuildStrategy() == shared.BuildStrategySpeculative {
+		draftConfig := state.settings.ModelPack.GetDraftBuilder()
+		draftPricing := db.GetModelPricing(draftConfig.BaseModelConfig.Provider, draftConfig.BaseModelConfig.ModelName)
+		addDraftPass(estimate, pricing, draftPricing, draftConfig.BaseModelConfig.ModelName)
+	}
+
+	policy, err := db.GetEffectiveTrustPolicy(state.currentOrgId, state.plan.ProjectId)
+	if err != nil {
+		log.Printf("Error getting trust policy for build estimate: %v\n", err)
+	} else if threshold := policy.BuildCostConfirmAbove(); threshold > 0 && estimate.HasPricing && estimate.CostMax > threshold {
+		estimate.NeedsConfirmation = active.PauseBuild()
+		estimate.ConfirmAbove = threshold
+	}
+
+	log.Printf("Build estimate for plan %s: %d builds, %s, %s, needs confirmation: %v\n", planId, estimate.NumBuilds, estimate.TokensLabel(), estimate.CostLabel(), estimate.NeedsConfirmation)
+
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		ap.BuildEstimate = estimate
+	})
+
+	active.Stream(shared.StreamMessage{
+		Type:          shared.StreamMessageBuildEstimate,
+		BuildEstimate: estimate,
+	})
+
+	if estimate.NeedsConfirmation {
+		active.Stream(shared.StreamMessage{
+			Type: shared.StreamMessageBuildPaused,
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package plan
+
+import (
+	"plandex-server/db"
+	"plandex-server/types"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestEstimateBuildCost(t *testing.T) {
+	config := shared.ModelRoleConfig{
+		BaseModelConfig: shared.BaseModelConfig{ModelName: "gpt-4-turbo"},
+	}
+
+	pendingBuildsByPath := map[string][]*types.ActiveBuild{
+		"in_context.go": {{Path: "in_context.go", FileContentTokens: 100}},
+		"new.go":        {{Path: "new.go", FileContentTokens: 200}},
+	}
+	contextsByPath := map[string]*db.Context{
+		"in_context.go": {FilePath: "in_context.go", NumTokens: 1000},
+	}
+
+	estimate, err := estimateBuildCost(config, shared.GetModelPricing(config.BaseModelConfig.ModelName), pendingBuildsByPath, contextsByPath, 500)
+	if err != nil {
+		t.Fatalf("estimateBuildCost() error = %v", err)
+	}
+
+	if estimate.NumFiles != 2 || estimate.NumBuilds != 2 {
+		t.Errorf("got %d files and %d builds, want 2 and 2", estimate.NumFiles, estimate.NumBuilds)
+	}
+
+	// only the file in context needs a builder call at the low end
+	if estimate.InputTokensMin != 500+1000+100 || estimate.OutputTokensMin != 250 {
+		t.Errorf("got min tokens %d in, %d out, want 1600 in, 250 out", estimate.InputTokensMin, estimate.OutputTokensMin)
+	}
+
+	// both files, each with a second pass, at the high end
+	if estimate.InputTokensMax != 2*(1600+500+200+200) || estimate.OutputTokensMax != 2*(250+400) {
+		t.Errorf("got max tokens %d in, %d out, want 5000 in, 1300 out", estimate.InputTokensMax, estimate.OutputTokensMax)
+	}
+
+	if !estimate.HasPricing {
+		t.Fatal("expected pricing for gpt-4-turbo")
+	}
+
+	wantMin := (1600*10.0 + 250*30.0) / 1_000_000
+	if estimate.CostMin != wantMin {
+		t.Errorf("got min cost %v, want %v", estimate.CostMin, wantMin)
+	}
+
+	config.BaseModelConfig.ModelName = "my-custom-model"
+	estimate, err = estimateBuildCost(config, nil, pendingBuildsByPath, contextsByPath, 500)
+	if err != nil {
+		t.Fatalf("estimateBuildCost() error = %v", err)
+	}
+	if estimate.HasPricing || estimate.CostMax != 0 {
+		t.Errorf("expected no pricing for a custom model, got %v", estimate.CostMax)
+	}
+}
+
+func TestAddDraftPass(t *testing.T) {
+	estimate := &shared.BuildCostEstimate{
+		Model:           "gpt-4o",
+		InputTokensMin:  1000,
+		InputTokensMax:  4000,
+		OutputTokensMin: 100,
+		OutputTokensMax: 400,
+		HasPricing:      true,
+	}
+	pricing := &shared.ModelPricing{InputPerMillion: 5, OutputPerMillion: 15}
+	draftPricing := &shared.ModelPricing{InputPerMillion: 0.5, OutputPerMillion: 1.5}
+
+	addDraftPass(estimate, pricing, draftPricing, "gpt-3.5-turbo")
+
+	if estimate.InputTokensMin != 1000 || estimate.OutputTokensMin != 100 {
+		t.Errorf("got min tokens %d in, %d out, want 1000 in, 100 out", estimate.InputTokensMin, estimate.OutputTokensMin)
+	}
+	if estimate.InputTokensMax != 6000 || estimate.OutputTokensMax != 600 {
+		t.Errorf("got max tokens %d in, %d out, want 6000 in, 600 out", estimate.InputTokensMax, estimate.OutputTokensMax)
+	}
+
+	if want := draftPricing.Cost(1000, 100); estimate.CostMin != want {
+		t.Errorf("got min cost %v, want %v", estimate.CostMin, want)
+	}
+	if want := pricing.Cost(4000, 400) + draftPricing.Cost(2000, 200); estimate.CostMax != want {
+		t.Errorf("got max cost %v, want %v", estimate.CostMax, want)
+	}
+
+	estimate = &shared.BuildCostEstimate{Model: "gpt-4o", HasPricing: true, CostMax: 1}
+	addDraftPass(estimate, pricing, nil, "my-draft-model")
+	if estimate.HasPricing || estimate.CostMax != 0 || estimate.Model != "my-draft-model" {
+		t.Errorf("expected no pricing for an unknown draft model, got %+v", estimate)
+	}
+}
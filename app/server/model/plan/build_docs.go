@@ -0,0 +1,333 @@
+package plan
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"plandex-server/types"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+var docExts = map[string]bool{
+	".md":       true,
+	".mdx":      true,
+	".markdown": true,
+	".rst":      true,
+	".adoc":     true,
+}
+
+var goFuncRegex = regexp.MustCompile(`^func\s+(\([^)]*\)\s*)?([A-Z]\w*)`)
+var goTypeRegex = regexp.MustCompile(`^type\s+([A-Z]\w*)`)
+var goValueRegex = regexp.MustCompile(`^(?:var|const)\s+([A-Z]\w*)`)
+var goBlockValueRegex = regexp.MustCompile(`^\t([A-Z]\w*)`)
+
+var tsExportRegex = regexp.MustCompile(`^export\s+(?:default\s+)?(?:declare\s+)?(?:async\s+)?(?:abstract\s+)?(?:function\*?|class|interface|type|enum|const|let|var)\s+(\w+)`)
+
+var apiExts = map[string]bool{
+	".go":  true,
+	".ts":  true,
+	".tsx": true,
+	".js":  true,
+	".jsx": true,
+	".mjs": true,
+}
+
+// apiSymbols returns the signatures of a file's public API by name: exported Go functions, methods, types, vars, and consts, and exported TypeScript and JavaScript declarations. A signature is its declaration's first line, without the body, with whitespace collapsed.
+func apiSymbols(path, content string) map[string]string {
+	ext := filepath.Ext(path)
+	res := map[string]string{}
+
+	signature := func(line string) string {
+		if idx := strings.Index(line, "{"); idx >= 0 {
+			line = line[:idx]
+		}
+		return strings.Join(strings.Fields(line), " ")
+	}
+
+	valueBlock := false
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if ext == ".go" {
+			if valueBlock {
+				if strings.HasPrefix(line, ")") {
+					valueBlock = false
+				} else if m := goBlockValueRegex.FindStringSubmatch(line); m != nil {
+					res[m[1]] = signature(line)
+				}
+				continue
+			}
+
+			if line == "var (" || line == "const (" {
+				valueBlock = true
+				continue
+			}
+
+			if m := goFuncRegex.FindStringSubmatch(line); m != nil {
+				name := m[2]
+				// methods are keyed by receiver type so methods with the same name don't collide
+				if m[1] != "" {
+					receiver := strings.Trim(m[1], "() ")
+					fields := strings.Fields(receiver)
+					if len(fields) > 0 {
+						name = strings.TrimLeft(fields[len(fields)-1], "*") + "." + name
+					}
+				}
+				res[name] = signature(line)
+			} else if m := goTypeRegex.FindStringSubmatch(line); m != nil {
+				res[m[1]] = signature(line)
+			} else if m := goValueRegex.FindStringSubmatch(line); m != nil {
+				res[m[1]] = signature(line)
+			}
+			continue
+		}
+
+		if m := tsExportRegex.FindStringSubmatch(line); m != nil {
+			res[m[1]] = signature(line)
+		}
+	}
+
+	return res
+}
+
+type apiChange struct {
+	name   string
+	before string
+	after  string
+}
+
+// apiChanges compares the public API of a file before and after a build. It's empty for files whose API isn't checked.
+func apiChanges(path, original, updated string) []apiChange {
+	if !apiExts[filepath.Ext(path)] || shared.IsTestPath(path) {
+		return nil
+	}
+
+	before := apiSymbols(path, original)
+	after := apiSymbols(path, updated)
+
+	var changes []apiChange
+	for name, sig := range before {
+		if after[name] != sig {
+			changes = append(changes, apiChange{name: name, before: sig, after: after[name]})
+		}
+	}
+	for name, sig := range after {
+		if _, ok := before[name]; !ok {
+			changes = append(changes, apiChange{name: name, after: sig})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].name < changes[j].name
+	})
+
+	return changes
+}
+
+func formatApiChanges(changes []apiChange) string {
+	var lines []string
+	for _, change := range changes {
+		switch {
+		case change.before == "":
+			lines = append(lines, fmt.Sprintf("- Added: `%s`", change.after))
+		case change.after == "":
+			lines = append(lines, fmt.Sprintf("- Removed: `%s`", change.before))
+		default:
+			lines = append(lines, fmt.Sprintf("- Changed: `%s` → `%s`", change.before, change.after))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// referencingDocs returns the docs in context that an API change could make out of date: docs that mention a changed or removed symbol, and for added symbols, docs that mention the file
+func referencingDocs(sourcePath string, changes []apiChange, contexts []*db.Context) []string {
+	return referencingContexts(sourcePath, changes, contexts, func(path string) bool {
+		return docExts[strings.ToLower(filepath.Ext(path))]
+	})
+}
+
+// referencingContexts returns the files in context, of those include accepts, that mention a changed or removed symbol, and for added symbols, that mention the file
+func referencingContexts(sourcePath string, changes []apiChange, contexts []*db.Context, include func(path string) bool) []string {
+	var names []string
+	added := false
+	for _, change := range changes {
+		if change.before == "" {
+			added = true
+			continue
+		}
+		// methods are mentioned by their own name
+		name := change.name
+		if idx := strings.LastIndex(name, "."); idx >= 0 {
+			name = name[idx+1:]
+		}
+		names = append(names, regexp.QuoteMeta(name))
+	}
+
+	var symbolRegex *regexp.Regexp
+	if len(names) > 0 {
+		symbolRegex = regexp.MustCompile(`\b(` + strings.Join(names, "|") + `)\b`)
+	}
+
+	var res []string
+	for _, context := range contexts {
+		if context.FilePath == "" || !include(context.FilePath) {
+			continue
+		}
+		if (symbolRegex != nil && symbolRegex.MatchString(context.Body)) ||
+			(added && strings.Contains(context.Body, filepath.Base(sourcePath))) {
+			res = append(res, context.FilePath)
+		}
+	}
+
+	sort.Strings(res)
+	return res
+}
+
+// queueDocSync queues builds of the docs in context that reference a file's public API after the file is built, when the plan has doc sync on and the build changed the API. Docs the same reply already changes are skipped.
+func (fileState *activeBuildStreamFileState) queueDocSync(updated string) {
+	filePath := fileState.filePath
+	activeBuild := fileState.activeBuild
+
+	if fileState.settings == nil || !fileState.settings.SyncDocs || activeBuild.TestSourcePath != "" || activeBuild.DocSyncSourcePath != "" || updated == "" {
+		return
+	}
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Println("queueDocSync - Active plan not found")
+		return
+	}
+
+	// the API is compared to the project's file, so changes from earlier builds of the plan are included
+	original := ""
+	if context := activePlan.ContextsByPath[filePath]; context != nil {
+		original = context.Body
+	}
+
+	changes := apiChanges(filePath, original, updated)
+	if len(changes) == 0 {
+		return
+	}
+
+	docPaths := referencingDocs(filePath, changes, activePlan.Contexts)
+	if len(docPaths) == 0 {
+		log.Printf("API of %s changed, but no docs in context reference it\n", filePath)
+		return
+	}
+
+	var toQueue []string
+	for _, docPath := range docPaths {
+		skip := false
+		for _, build := range activePlan.BuildQueuesByPath[docPath] {
+			if build.IsReplyBuild() && build.ReplyId == activeBuild.ReplyId {
+				log.Printf("Reply already changes %s, skipping doc sync for %s\n", docPath, filePath)
+				skip = true
+				break
+			}
+			if build.DocSyncSourcePath == filePath && !build.BuildFinished() {
+				log.Printf("Doc sync of %s for %s is already queued\n", docPath, filePath)
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			toQueue = append(toQueue, docPath)
+		}
+	}
+
+	formatted := formatApiChanges(changes)
+
+	for _, docPath := range toQueue {
+		log.Printf("Queueing doc sync of %s for API changes to %s\n", docPath, filePath)
+
+		fileState.queueBuilds([]*types.ActiveBuild{
+			{
+				ReplyId:           activeBuild.ReplyId,
+				Path:              docPath,
+				DocSyncSourcePath: filePath,
+				DocSyncApiChanges: formatted,
+			},
+		})
+	}
+}
+
+// syncDocs asks the builder how a doc should change for the API changes it was queued for, then builds the changes into the doc like a reply's proposed changes. Doc updates are optional, so if the builder doesn't have any, the build is skipped rather than failed.
+func (fileState *activeBuildStreamFileState) syncDocs() {
+	filePath := fileState.filePath
+	activeBuild := fileState.activeBuild
+	sourcePath := activeBuild.DocSyncSourcePath
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Printf("Active plan not found for plan ID %s and branch %s\n", fileState.plan.Id, fileState.branch)
+		return
+	}
+
+	skip := func(reason string) {
+		log.Printf("Skipping doc sync of %s for %s: %s\n", filePath, sourcePath, reason)
+		fileState.finishSkippedBuild()
+	}
+
+	doc, ok := fileState.pendingFileState(activePlan, filePath)
+	if !ok || strings.TrimSpace(doc) == "" {
+		skip("doc isn't in context")
+		return
+	}
+
+	log.Printf("Syncing %s with API changes to %s\n", filePath, sourcePath)
+
+	config := fileState.builderConfig()
+	client := fileState.clients[config.BaseModelConfig.ApiKeyEnvVar]
+
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: prompts.GetDocSyncSysPrompt(sourcePath, activeBuild.DocSyncApiChanges, filePath, doc),
+			},
+		},
+	}
+
+	attemptCtx := fileState.startBuildAttempt(activePlan.Ctx)
+	reqCtx := model.ApplyRoleConfig(attemptCtx, &modelReq, config)
+
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+	if err != nil {
+		skip(fileState.attemptErr(fmt.Errorf("error getting doc updates from model: %v", err)).Error())
+		return
+	}
+
+	recordModelUsage(fileState.currentOrgId, fileState.currentUserId, fileState.plan.Id, fileState.branch, shared.ModelRoleBuilder, config, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, model.ReportedUsageFromContext(reqCtx))
+
+	if len(resp.Choices) == 0 {
+		skip("no choices in model response")
+		return
+	}
+
+	updates, ok := getCodeBlockContent(resp.Choices[0].Message.Content)
+	if !ok || strings.TrimSpace(updates) == "" {
+		skip("no updates needed")
+		return
+	}
+
+	numTokens, err := shared.GetNumTokens(updates)
+	if err != nil {
+		log.Printf("Error getting num tokens for doc updates: %v\n", err)
+	}
+
+	activeBuild.FileDescription = fmt.Sprintf("Update the docs in %s for the changes to the API of %s:\n\n%s", filePath, sourcePath, activeBuild.DocSyncApiChanges)
+	activeBuild.FileContent = updates
+	activeBuild.FileContentTokens = numTokens
+
+	fileState.buildFile()
+}
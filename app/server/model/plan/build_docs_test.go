@@ -0,0 +1,139 @@
+package plan
+
+import (
+	"plandex-server/db"
+	"reflect"
+	"testing"
+)
+
+func TestApiSymbols(t *testing.T) {
+	goFile := `package term
+
+const (
+	MaxWidth = 80
+	minWidth = 20
+)
+
+var DefaultStyle = "plain"
+
+type Printer struct {
+	width int
+}
+
+func NewPrinter(width int) *Printer {
+	return &Printer{width: width}
+}
+
+func (p *Printer) Print(s string) error {
+	return nil
+}
+
+func (p *Printer) wrap(s string) string {
+	return s
+}
+
+func helper() {}
+`
+
+	got := apiSymbols("term/printer.go", goFile)
+	want := map[string]string{
+		"MaxWidth":      "MaxWidth = 80",
+		"DefaultStyle":  `var DefaultStyle = "plain"`,
+		"Printer":       "type Printer struct",
+		"NewPrinter":    "func NewPrinter(width int) *Printer",
+		"Printer.Print": "func (p *Printer) Print(s string) error",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("apiSymbols(go) = %v, want %v", got, want)
+	}
+
+	tsFile := `import { x } from "./x";
+
+export async function fetchUser(id: string): Promise<User> {
+  return x(id);
+}
+
+export default class Client {}
+
+export const VERSION = "1.0";
+
+function internal() {}
+`
+
+	got = apiSymbols("src/client.ts", tsFile)
+	want = map[string]string{
+		"fetchUser": "export async function fetchUser(id: string): Promise<User>",
+		"Client":    "export default class Client",
+		"VERSION":   `export const VERSION = "1.0";`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("apiSymbols(ts) = %v, want %v", got, want)
+	}
+}
+
+func TestApiChanges(t *testing.T) {
+	original := `package term
+
+func Render(s string) string {
+	return s
+}
+
+func Remove() {}
+
+func helper() {}
+`
+	updated := `package term
+
+func Render(s string, width int) string {
+	return s
+}
+
+func Added() {}
+
+func helper(x int) {}
+`
+
+	got := apiChanges("term/render.go", original, updated)
+	want := []apiChange{
+		{name: "Added", after: "func Added()"},
+		{name: "Remove", before: "func Remove()"},
+		{name: "Render", before: "func Render(s string) string", after: "func Render(s string, width int) string"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("apiChanges() = %v, want %v", got, want)
+	}
+
+	if changes := apiChanges("term/render_test.go", original, updated); changes != nil {
+		t.Errorf("apiChanges() for a test file = %v, want nil", changes)
+	}
+	if changes := apiChanges("README.md", original, updated); changes != nil {
+		t.Errorf("apiChanges() for a doc = %v, want nil", changes)
+	}
+	if changes := apiChanges("term/render.go", original, original); len(changes) != 0 {
+		t.Errorf("apiChanges() with no changes = %v, want none", changes)
+	}
+}
+
+func TestReferencingDocs(t *testing.T) {
+	contexts := []*db.Context{
+		{FilePath: "README.md", Body: "Call `Render` to format output."},
+		{FilePath: "docs/printer.md", Body: "The printer is defined in render.go."},
+		{FilePath: "docs/other.md", Body: "Nothing relevant. Rendering is covered elsewhere."},
+		{FilePath: "term/render.go", Body: "func Render() {}"},
+		{Body: "a note mentioning Render"},
+	}
+
+	changed := []apiChange{{name: "Printer.Render", before: "func (p *Printer) Render()", after: "func (p *Printer) Render(width int)"}}
+	got := referencingDocs("term/render.go", changed, contexts)
+	want := []string{"README.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("referencingDocs(changed) = %v, want %v", got, want)
+	}
+
+	added := []apiChange{{name: "Added", after: "func Added()"}}
+	got = referencingDocs("term/render.go", added, contexts)
+	want = []string{"docs/printer.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("referencingDocs(added) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"plandex-server/syntax"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// With the speculative build strategy, a file's changes are first drafted by the draft-builder model. A draft that applies cleanly and doesn't add syntax errors is used without a verification pass. If the draft fails in any way, it's thrown out and the file is built again with the builder model, which is then verified as usual, so the stronger models are only called when the cheap one falls short.
+
+// builderConfig is the model config for the current build call: the draft-builder while drafting, and the builder otherwise
+func (fileState *activeBuildStreamFileState) builderConfig() shared.ModelRoleConfig {
+	if fileState.isDraft {
+		return fileState.settings.ModelPack.GetDraftBuilder()
+	}
+	return fileState.settings.ModelPack.Builder
+}
+
+// usageRoleConfig is the role and config that the build's tokens are recorded under for 'plandex stats'
+func (fileState *activeBuildStreamFileState) usageRoleConfig() (shared.ModelRole, shared.ModelRoleConfig) {
+	if fileState.isDraft || fileState.draftAccepted {
+		return shared.ModelRoleDraftBuilder, fileState.settings.ModelPack.GetDraftBuilder()
+	}
+	return shared.ModelRoleBuilder, fileState.settings.ModelPack.Builder
+}
+
+// draftValidationError returns why a draft that applied cleanly can't be used, or nil if it can. A draft can't add syntax errors, but it isn't blamed for errors the file already had.
+func (fileState *activeBuildStreamFileState) draftValidationError(ctx context.Context, updated string) error {
+	filePath := fileState.filePath
+
+	res, err := syntax.Validate(ctx, filePath, updated)
+	if err != nil {
+		return fmt.Errorf("error validating draft syntax: %v", err)
+	}
+
+	if !res.HasParser || res.TimedOut || res.Valid {
+		return nil
+	}
+
+	preBuildRes, err := syntax.Validate(ctx, filePath, fileState.preBuildState)
+	if err != nil {
+		return fmt.Errorf("error validating syntax before draft: %v", err)
+	}
+
+	if preBuildRes.HasParser && !preBuildRes.TimedOut && !preBuildRes.Valid {
+		log.Printf("Draft of file '%s' has syntax errors, but the file already had them\n", filePath)
+		return nil
+	}
+
+	return fmt.Errorf("draft added syntax errors: %s", strings.Join(res.Errors, "; "))
+}
+
+// escalateDraft throws out a failed draft and builds the file again with the builder model. The draft's tokens are recorded first, since they're spent either way.
+func (fileState *activeBuildStreamFileState) escalateDraft(reason error) {
+	filePath := fileState.filePath
+	activeBuild := fileState.activeBuild
+
+	log.Printf("Draft of file '%s' failed, building with the builder model: %v\n", filePath, reason)
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Println("escalateDraft - Active plan not found")
+		return
+	}
+
+	if activePlan.Ctx.Err() != nil {
+		log.Println("escalateDraft - Context canceled. Exiting.")
+		return
+	}
+
+	config := fileState.builderConfig()
+	inputTokens := config.BaseModelConfig.GetTokenizer().FromBaseTokens(activeBuild.FileContentTokens + activeBuild.CurrentFileTokens)
+	recordModelUsage(fileState.currentOrgId, fileState.currentUserId, fileState.plan.Id, fileState.branch, shared.ModelRoleDraftBuilder, config, inputTokens, activeBuild.WithLineNumsBufferTokens, nil)
+
+	fileState.isDraft = false
+	fileState.lineNumsNumRetry = 0
+	fileState.lineNumsCorrected = false
+	fileState.anchorsCorrected = false
+	fileState.isWholeFileBuild = false
+	fileState.numPreviewChanges = 0
+	activeBuild.WithLineNumsBuffer = ""
+	activeBuild.WithLineNumsBufferTokens = 0
+
+	fileState.buildFileLineNums()
+}
@@ -0,0 +1,103 @@
+package plan
+
+import (
+	"fmt"
+	"log"
+	"plandex-server/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// skipFailedBuild handles a failed file build when the plan's build error policy is skip. The file's remaining builds are skipped too, since they'd be applied on top of changes that never happened, and the other files keep building. When the build finishes, the file is left pending so 'plandex build' can try it again.
+func (fileState *activeBuildStreamFileState) skipFailedBuild(err error) {
+	planId := fileState.plan.Id
+	branch := fileState.branch
+	filePath := fileState.filePath
+	activeBuild := fileState.activeBuild
+
+	activePlan := GetActivePlan(planId, branch)
+	if activePlan == nil {
+		log.Println("skipFailedBuild - Active plan not found")
+		return
+	}
+
+	log.Printf("Skipping file %s for the rest of the build\n", filePath)
+
+	activeBuild.Success = false
+	activeBuild.Error = err
+
+	fileState.setBuildFinished(err.Error())
+
+	var buildFinished bool
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		ap.FailedBuildPaths[filePath] = err.Error()
+		skipQueuedBuilds(ap, filePath)
+		ap.IsBuildingByPath[filePath] = false
+		buildFinished = ap.QueuesFinished()
+	})
+
+	activePlan.Stream(shared.StreamMessage{
+		Type: shared.StreamMessageBuildInfo,
+		BuildInfo: &shared.BuildInfo{
+			Path:     filePath,
+			Finished: true,
+			Error:    err.Error(),
+		},
+	})
+
+	if buildFinished {
+		log.Println("Finished building plan with skipped files, calling onFinishBuild")
+		fileState.onFinishBuild()
+	}
+}
+
+// skipQueuedBuilds fails every unfinished build queued for a path that has failed
+func skipQueuedBuilds(ap *types.ActivePlan, filePath string) {
+	for _, queued := range ap.BuildQueuesByPath[filePath] {
+		if !queued.BuildFinished() {
+			queued.Error = fmt.Errorf("skipped because an earlier build of %s failed: %s", filePath, ap.FailedBuildPaths[filePath])
+		}
+	}
+}
+
+// pauseFailedBuild handles a failed file build when the plan's build error policy is pause. The whole build is paused, and the file is built again from the start once the build is resumed, with the plan's context reloaded.
+func (fileState *activeBuildStreamFileState) pauseFailedBuild(err error) {
+	planId := fileState.plan.Id
+	branch := fileState.branch
+	filePath := fileState.filePath
+	activeBuild := fileState.activeBuild
+
+	activePlan := GetActivePlan(planId, branch)
+	if activePlan == nil {
+		log.Println("pauseFailedBuild - Active plan not found")
+		return
+	}
+
+	log.Printf("Pausing build after file %s failed\n", filePath)
+
+	fileState.setBuildFinished(err.Error())
+
+	activePlan.Stream(shared.StreamMessage{
+		Type: shared.StreamMessageBuildInfo,
+		BuildInfo: &shared.BuildInfo{
+			Path:  filePath,
+			Error: err.Error(),
+		},
+	})
+
+	if activePlan.PauseBuild() {
+		activePlan.Stream(shared.StreamMessage{
+			Type: shared.StreamMessageBuildPaused,
+		})
+	}
+
+	activeBuild.WithLineNumsBuffer = ""
+	activeBuild.WithLineNumsBufferTokens = 0
+	activeBuild.VerifyBuffer = ""
+	activeBuild.VerifyBufferTokens = 0
+	activeBuild.FixBuffer = ""
+	activeBuild.FixBufferTokens = 0
+
+	// waits for the build to be resumed before starting over
+	go fileState.execPlanBuild(activeBuild)
+}
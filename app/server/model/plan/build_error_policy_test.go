@@ -0,0 +1,48 @@
+package plan
+
+import (
+	"errors"
+	"plandex-server/types"
+	"testing"
+)
+
+func TestSkipQueuedBuilds(t *testing.T) {
+	earlierErr := errors.New("earlier error")
+
+	active := &types.ActivePlan{
+		FailedBuildPaths: map[string]string{"a.go": "timed out"},
+		BuildQueuesByPath: map[string][]*types.ActiveBuild{
+			"a.go": {
+				{Path: "a.go", Success: true},
+				{Path: "a.go", Error: earlierErr},
+				{Path: "a.go", ReplyId: "r2"},
+				{Path: "a.go", ReplyId: "r3"},
+			},
+			"b.go": {
+				{Path: "b.go", ReplyId: "r2"},
+			},
+		},
+	}
+
+	skipQueuedBuilds(active, "a.go")
+
+	queue := active.BuildQueuesByPath["a.go"]
+
+	if queue[0].Error != nil {
+		t.Errorf("expected successful build to be left alone, got error %v", queue[0].Error)
+	}
+
+	if queue[1].Error != earlierErr {
+		t.Errorf("expected failed build to keep its error, got %v", queue[1].Error)
+	}
+
+	for _, build := range queue[2:] {
+		if !build.BuildFinished() || build.Error == nil {
+			t.Errorf("expected queued build for reply %s to be skipped", build.ReplyId)
+		}
+	}
+
+	if active.BuildQueuesByPath["b.go"][0].BuildFinished() {
+		t.Error("expected build for other path to be left alone")
+	}
+}
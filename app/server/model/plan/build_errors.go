@@ -0,0 +1,35 @@
+package plan
+
+import (
+	"errors"
+	"plandex-server/model"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// buildError tags a build failure with the type of error sent to the client, so it can show what to do about it
+type buildError struct {
+	errType shared.ApiErrorType
+	err     error
+}
+
+func newBuildError(errType shared.ApiErrorType, err error) error {
+	return &buildError{errType: errType, err: err}
+}
+
+func (e *buildError) Error() string {
+	return e.err.Error()
+}
+
+func (e *buildError) Unwrap() error {
+	return e.err
+}
+
+// buildErrorType returns the type of a tagged build error, or classifies it as a model provider error
+func buildErrorType(err error) shared.ApiErrorType {
+	var buildErr *buildError
+	if errors.As(err, &buildErr) {
+		return buildErr.errType
+	}
+	return model.ModelErrorType(err)
+}
@@ -0,0 +1,28 @@
+package plan
+
+import (
+	"time"
+)
+
+// a rate from the first few tokens of a response is mostly noise
+const minEtaTokens = 20
+const minEtaElapsed = time.Second
+
+// estimateBuildProgress returns the rate a build response is streaming at and about how long it will take to finish, based on the response's expected length. Returns 0 for either value when there isn't enough to estimate from, or when the response is already longer than expected.
+func estimateBuildProgress(numTokens, fileContentTokens int, elapsed time.Duration) (float64, time.Duration) {
+	if numTokens < minEtaTokens || elapsed < minEtaElapsed {
+		return 0, 0
+	}
+
+	tokensPerSecond := float64(numTokens) / elapsed.Seconds()
+
+	expectedTokens := expectedBuildResponseTokens(fileContentTokens)
+	remaining := expectedTokens - numTokens
+	if remaining <= 0 {
+		return tokensPerSecond, 0
+	}
+
+	eta := time.Duration(float64(remaining) / tokensPerSecond * float64(time.Second))
+
+	return tokensPerSecond, eta.Round(time.Second)
+}
@@ -0,0 +1,31 @@
+package plan
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateBuildProgress(t *testing.T) {
+	tests := []struct {
+		name              string
+		numTokens         int
+		fileContentTokens int
+		elapsed           time.Duration
+		wantRate          float64
+		wantEta           time.Duration
+	}{
+		{"too few tokens", 10, 1000, 5 * time.Second, 0, 0},
+		{"too soon", 100, 1000, 500 * time.Millisecond, 0, 0},
+		{"halfway", 800, 1000, 10 * time.Second, 80, 10 * time.Second},
+		{"longer than expected", 2000, 1000, 20 * time.Second, 100, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, eta := estimateBuildProgress(tt.numTokens, tt.fileContentTokens, tt.elapsed)
+			if rate != tt.wantRate || eta != tt.wantEta {
+				t.Errorf("estimateBuildProgress() = %v, %v, want %v, %v", rate, eta, tt.wantRate, tt.wantEta)
+			}
+		})
+	}
+}
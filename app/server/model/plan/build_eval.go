@@ -0,0 +1,202 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"plandex-server/types"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// BuildEvalCase is a builder test case. It's stored as a directory holding case.json (the file's path and the change description), 'original' (the file before the change), 'changes' (the proposed code the builder applies, as written in a plan reply), and 'expected' (the file after the change).
+type BuildEvalCase struct {
+	Name        string `json:"-"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+	Original    string `json:"-"`
+	Changes     string `json:"-"`
+	Expected    string `json:"-"`
+}
+
+type BuildEvalResult struct {
+	Case         *BuildEvalCase
+	Updated      string
+	AllSucceeded bool
+	SyntaxValid  bool
+	ExactMatch   bool
+	// Similarity is the share of lines the updated and expected files have in common, from 0 to 1
+	Similarity float64
+	Duration   time.Duration
+	Err        error
+}
+
+func (res *BuildEvalResult) Passed() bool {
+	return res.Err == nil && res.AllSucceeded && res.SyntaxValid && res.ExactMatch
+}
+
+// LoadBuildEvalCases loads every case directory in dir, sorted by name
+func LoadBuildEvalCases(dir string) ([]*BuildEvalCase, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading eval cases dir: %v", err)
+	}
+
+	var cases []*BuildEvalCase
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		caseDir := filepath.Join(dir, entry.Name())
+
+		bytes, err := os.ReadFile(filepath.Join(caseDir, "case.json"))
+		if err != nil {
+			return nil, fmt.Errorf("error reading case.json for eval case %s: %v", entry.Name(), err)
+		}
+
+		evalCase := BuildEvalCase{Name: entry.Name()}
+		err = json.Unmarshal(bytes, &evalCase)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshalling case.json for eval case %s: %v", entry.Name(), err)
+		}
+
+		if evalCase.Path == "" {
+			return nil, fmt.Errorf("eval case %s has no path", entry.Name())
+		}
+
+		for name, dest := range map[string]*string{
+			"original": &evalCase.Original,
+			"changes":  &evalCase.Changes,
+			"expected": &evalCase.Expected,
+		} {
+			bytes, err := os.ReadFile(filepath.Join(caseDir, name))
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s for eval case %s: %v", name, entry.Name(), err)
+			}
+			*dest = string(bytes)
+		}
+
+		cases = append(cases, &evalCase)
+	}
+
+	sort.Slice(cases, func(i, j int) bool {
+		return cases[i].Name < cases[j].Name
+	})
+
+	return cases, nil
+}
+
+// RunBuildEval builds a case's file with the given builder model, using the same prompt and replacement logic as a plan build, and scores the result against the expected file. Errors are returned on the result rather than stopping the run.
+func RunBuildEval(ctx context.Context, client *openai.Client, config shared.ModelRoleConfig, evalCase *BuildEvalCase) *BuildEvalResult {
+	run := runBuild(ctx, client, config, evalCase.Path, evalCase.Original, evalCase.Description, evalCase.Changes)
+
+	res := &BuildEvalResult{
+		Case:         evalCase,
+		Updated:      run.updated,
+		AllSucceeded: run.allSucceeded,
+		SyntaxValid:  run.syntaxValid,
+		Duration:     run.duration,
+		Err:          run.err,
+	}
+
+	if run.err != nil {
+		return res
+	}
+
+	// the pipeline can add or drop a trailing newline, which isn't a meaningful difference
+	res.ExactMatch = strings.TrimRight(run.updated, "\n") == strings.TrimRight(evalCase.Expected, "\n")
+	res.Similarity = lineSimilarity(run.updated, evalCase.Expected)
+
+	return res
+}
+
+// buildRun is the outcome of building a single file outside of a plan, for evals and benchmarks
+type buildRun struct {
+	updated      string
+	allSucceeded bool
+	syntaxValid  bool
+	usage        openai.Usage
+	duration     time.Duration
+	err          error
+}
+
+// runBuild builds a file with the given builder model, using the same prompt and replacement logic as a plan build, without storing anything
+func runBuild(ctx context.Context, client *openai.Client, config shared.ModelRoleConfig, path, preBuildState, description, changes string) *buildRun {
+	res := &buildRun{}
+	start := time.Now()
+	defer func() {
+		res.duration = time.Since(start)
+	}()
+
+	modelReq, reqCtx := getBuildModelRequest(ctx, config, path, preBuildState, description, changes)
+
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+	if err != nil {
+		res.err = fmt.Errorf("error calling model: %v", err)
+		return res
+	}
+	res.usage = resp.Usage
+
+	args := getReplacementsArgsFromResponse(resp)
+	if args == "" {
+		res.err = fmt.Errorf("no %s function call or structured output found in response", prompts.ListReplacementsFn.Name)
+		return res
+	}
+
+	var replacements types.ChangesWithLineNums
+	err = json.Unmarshal([]byte(args), &replacements)
+	if err != nil {
+		res.err = fmt.Errorf("error unmarshalling build response: %v", err)
+		return res
+	}
+
+	planFileResult, updated, allSucceeded, err := GetPlanResult(ctx, PlanResultParams{
+		FilePath:            path,
+		PreBuildState:       preBuildState,
+		ChangesWithLineNums: replacements.Changes,
+		OverlapStrategy:     OverlapStrategyError,
+		CheckSyntax:         true,
+	})
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	res.updated = updated
+	res.allSucceeded = allSucceeded
+	res.syntaxValid = !planFileResult.WillCheckSyntax || planFileResult.SyntaxValid
+
+	return res
+}
+
+// lineSimilarity is 2 * the longest common subsequence of lines / the total number of lines, ignoring trailing whitespace
+func lineSimilarity(a, b string) float64 {
+	aLines := strings.Split(strings.TrimRight(a, "\n"), "\n")
+	bLines := strings.Split(strings.TrimRight(b, "\n"), "\n")
+
+	prev := make([]int, len(bLines)+1)
+	cur := make([]int, len(bLines)+1)
+	for i := 1; i <= len(aLines); i++ {
+		for j := 1; j <= len(bLines); j++ {
+			if strings.TrimRight(aLines[i-1], " \t") == strings.TrimRight(bLines[j-1], " \t") {
+				cur[j] = prev[j-1] + 1
+			} else if prev[j] > cur[j-1] {
+				cur[j] = prev[j]
+			} else {
+				cur[j] = cur[j-1]
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return 2 * float64(prev[len(bLines)]) / float64(len(aLines)+len(bLines))
+}
@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -20,6 +21,7 @@ func Build(
 	plan *db.Plan,
 	branch string,
 	auth *types.ServerAuth,
+	priority model.CallPriority,
 ) (int, error) {
 	log.Printf("Build: Called with plan ID %s on branch %s\n", plan.Id, branch)
 	log.Println("Build: Starting Build operation")
@@ -31,6 +33,7 @@ func Build(
 		currentUserId: auth.User.Id,
 		plan:          plan,
 		branch:        branch,
+		priority:      priority,
 	}
 
 	streamDone := func() {
@@ -46,6 +49,11 @@ func Build(
 		return 0, err
 	}
 
+	// builds started by a prompt already have the preferences loaded for it
+	if active := GetActivePlan(plan.Id, branch); active != nil && active.PreferencesPrompt == "" {
+		loadLearnedPreferences(plan.Id, branch, auth.OrgId, auth.User.Id)
+	}
+
 	pendingBuildsByPath, err := state.loadPendingBuilds()
 	if err != nil {
 		return onErr(err)
@@ -64,6 +72,8 @@ func Build(
 		return onErr(fmt.Errorf("error setting plan status to building: %v", err))
 	}
 
+	state.streamBuildEstimate(pendingBuildsByPath)
+
 	log.Printf("Starting %d builds\n", len(pendingBuildsByPath))
 
 	for _, pendingBuilds := range pendingBuildsByPath {
@@ -84,14 +94,23 @@ func (state *activeBuildStreamState) queueBuilds(activeBuilds []*types.ActiveBui
 		// spew.Dump(activePlan.BuildQueuesByPath[filePath])
 
 		var isBuilding bool
+		var failed bool
 
 		UpdateActivePlan(planId, branch, func(active *types.ActivePlan) {
 			active.BuildQueuesByPath[filePath] = append(active.BuildQueuesByPath[filePath], activeBuilds...)
 			isBuilding = active.IsBuildingByPath[filePath]
+
+			if active.FailedBuildPaths[filePath] != "" {
+				failed = true
+				skipQueuedBuilds(active, filePath)
+			}
 		})
 		log.Printf("Queued %d build(s) for file %s\n", len(activeBuilds), filePath)
 
-		if isBuilding {
+		if failed {
+			log.Printf("File %s failed earlier in this build, skipping\n", filePath)
+			return
+		} else if isBuilding {
 			log.Printf("Already building file %s\n", filePath)
 			return
 		} else {
@@ -140,6 +159,26 @@ func (buildState *activeBuildStreamState) execPlanBuild(activeBuild *types.Activ
 		})
 	}
 
+	// the path keeps its place while the build is paused, so the plan doesn't look finished while its next build waits
+	if activePlan.IsBuildPaused() {
+		log.Printf("Build paused, holding next build for file %s\n", filePath)
+		UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+			ap.BuildHeldByPath[filePath] = true
+		})
+
+		resumed := activePlan.WaitForBuildResume()
+
+		UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+			delete(ap.BuildHeldByPath, filePath)
+		})
+
+		if !resumed {
+			log.Printf("Plan stopped while build for file %s was paused\n", filePath)
+			return
+		}
+		log.Printf("Build resumed for file %s\n", filePath)
+	}
+
 	// stream initial status to client
 	log.Printf("streaming initial build info for file %s\n", filePath)
 	buildInfo := &shared.BuildInfo{
@@ -165,6 +204,16 @@ func (buildState *activeBuildStreamState) execPlanBuild(activeBuild *types.Activ
 
 	if activeBuild.IsVerification {
 		fileState.verifyFileBuild()
+	} else if activeBuild.TestSourcePath != "" && activeBuild.FileContent == "" {
+		fileState.scaffoldTests()
+	} else if activeBuild.DocSyncSourcePath != "" && activeBuild.FileContent == "" {
+		fileState.syncDocs()
+	} else if activeBuild.SymbolRename != nil {
+		fileState.buildSymbolRename()
+	} else if activeBuild.ImportFix {
+		fileState.buildImportFix()
+	} else if activeBuild.RemoveFile {
+		fileState.buildFileRemoval()
 	} else {
 		fileState.buildFile()
 	}
@@ -188,6 +237,11 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 
 	log.Printf("Building file %s\n", filePath)
 
+	if shared.IsLockfile(filePath) {
+		fileState.skipLockfileBuild()
+		return
+	}
+
 	log.Println("activePlan.ContextsByPath files:")
 	for k := range activePlan.ContextsByPath {
 		log.Println(k)
@@ -205,7 +259,7 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 
 		// log.Println("\n\nCurrent state:\n", currentState, "\n\n")
 
-	} else if contextPart != nil {
+	} else if contextPart != nil && !currentPlan.CurrentPlanFiles.Removed[filePath] {
 		log.Printf("File %s found in model context. Using context state.\n", filePath)
 		currentState = contextPart.Body
 
@@ -273,8 +327,20 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 		log.Printf("Current state num tokens: %d\n", currentNumTokens)
 
 		activeBuild.CurrentFileTokens = currentNumTokens
+
+		if fileState.buildDeclarationRemoval(currentState) {
+			return
+		}
+
+		err = checkBuildFileSize(filePath, currentNumTokens, fileState.settings.GetMaxBuildFileTokens())
+		if err != nil {
+			fileState.onBuildFileError(err)
+			return
+		}
 	}
 
+	fileState.isDraft = fileState.settings.GetBuildStrategy() == shared.BuildStrategySpeculative
+
 	fileState.buildFileLineNums()
 }
 
@@ -284,7 +350,7 @@ func (fileState *activeBuildStreamFileState) buildFileLineNums() {
 	clients := fileState.clients
 	planId := fileState.plan.Id
 	branch := fileState.branch
-	config := fileState.settings.ModelPack.Builder
+	config := fileState.builderConfig()
 	originalFile := fileState.preBuildState
 
 	activePlan := GetActivePlan(planId, branch)
@@ -299,89 +365,63 @@ func (fileState *activeBuildStreamFileState) buildFileLineNums() {
 
 	// log.Println("currentState:", currentState)
 
-	sysPrompt := prompts.GetBuildLineNumbersSysPrompt(filePath, originalFile, fmt.Sprintf("%s\n\n```%s```", activeBuild.FileDescription, activeBuild.FileContent))
-
-	fileMessages := []openai.ChatCompletionMessage{
-		{
-			Role:    openai.ChatMessageRoleSystem,
-			Content: sysPrompt,
-		},
-	}
+	attemptCtx := fileState.startBuildAttempt(activePlan.Ctx)
+	fileDescription := fileState.buildFileDescription()
+	modelReq, reqCtx := getBuildModelRequest(attemptCtx, config, filePath, originalFile, fileDescription, activeBuild.FileContent)
 
-	log.Println("buildFileLineNums - calling model for file: " + filePath)
-
-	// for _, msg := range fileMessages {
-	// 	log.Printf("%s: %s\n", msg.Role, msg.Content)
-	// }
-
-	var responseFormat *openai.ChatCompletionResponseFormat
-	if config.BaseModelConfig.HasJsonResponseMode {
-		responseFormat = &openai.ChatCompletionResponseFormat{Type: "json_object"}
+	// retrying can't help if the prompt doesn't fit, so fail right away with what's taking up the space
+	err := checkBuildPromptSize(config, modelReq, filePath, originalFile, fileDescription, activeBuild.FileContent)
+	if err != nil {
+		log.Printf("Build prompt check failed for file '%s': %v\n", filePath, err)
+		fileState.onBuildFileError(err)
+		return
 	}
 
-	// log.Println("responseFormat:", responseFormat)
-	// log.Println("Model:", config.BaseModelConfig.ModelName)
-
-	modelReq := openai.ChatCompletionRequest{
-		Model: config.BaseModelConfig.ModelName,
-		Tools: []openai.Tool{
-			{
-				Type:     "function",
-				Function: &prompts.ListReplacementsFn,
-			},
-		},
-		ToolChoice: openai.ToolChoice{
-			Type: "function",
-			Function: openai.ToolFunction{
-				Name: prompts.ListReplacementsFn.Name,
-			},
-		},
-		Messages:       fileMessages,
-		Temperature:    config.Temperature,
-		TopP:           config.TopP,
-		ResponseFormat: responseFormat,
-	}
+	fileState.setPrompt(modelReq)
 
 	envVar := config.BaseModelConfig.ApiKeyEnvVar
 	client := clients[envVar]
 
-	if config.BaseModelConfig.HasStreamingFunctionCalls {
-		stream, err := model.CreateChatCompletionStreamWithRetries(client, activePlan.Ctx, modelReq)
+	// structured outputs stream as regular content, so they don't depend on streaming function call support
+	if config.BaseModelConfig.HasStreamingFunctionCalls || config.BaseModelConfig.HasStructuredOutputs {
+		stream, err := model.CreateChatCompletionStreamWithRetries(client, reqCtx, modelReq)
+		capturedStream := fileState.captureModelStream(shared.BuildCapturePhaseBuild, modelReq, stream, err)
 		if err != nil {
 			log.Printf("Error creating plan file stream for path '%s': %v\n", filePath, err)
+			if fileState.attemptTimedOut() {
+				fileState.lineNumsRetryOrError(fileState.attemptErr(err))
+				return
+			}
 			fileState.onBuildFileError(fmt.Errorf("error creating plan file stream for path '%s': %v", filePath, err))
 			return
 		}
 
-		go fileState.listenStreamChangesWithLineNums(stream)
+		go fileState.listenStreamChangesWithLineNums(capturedStream)
 	} else {
 
 		log.Println("request:")
 		log.Println(spew.Sdump(modelReq))
 
-		resp, err := model.CreateChatCompletionWithRetries(client, activePlan.Ctx, modelReq)
+		resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+		fileState.captureModelResponse(shared.BuildCapturePhaseBuild, modelReq, resp, err)
 
 		if err != nil {
 			log.Printf("Error building file '%s': %v\n", filePath, err)
+			if fileState.attemptTimedOut() {
+				fileState.lineNumsRetryOrError(fileState.attemptErr(err))
+				return
+			}
 			fileState.onBuildFileError(fmt.Errorf("error building file '%s': %v", filePath, err))
 			return
 		}
 
-		var s string
 		var res types.ChangesWithLineNums
 
-		for _, choice := range resp.Choices {
-			if len(choice.Message.ToolCalls) == 1 &&
-				choice.Message.ToolCalls[0].Function.Name == prompts.ListReplacementsFn.Name {
-				fnCall := choice.Message.ToolCalls[0].Function
-				s = fnCall.Arguments
-				break
-			}
-		}
+		s := getReplacementsArgsFromResponse(resp)
 
 		if s == "" {
 			log.Println("no ListReplacements function call found in response")
-			fileState.lineNumsRetryOrError(fmt.Errorf("no ListReplacements function call found in response"))
+			fileState.lineNumsRetryOrError(newBuildError(shared.ApiErrorTypeInvalidToolOutput, fmt.Errorf("no ListReplacements function call found in response")))
 			return
 		}
 
@@ -390,7 +430,7 @@ func (fileState *activeBuildStreamFileState) buildFileLineNums() {
 		err = json.Unmarshal(bytes, &res)
 		if err != nil {
 			log.Printf("Error unmarshalling build response: %v\n", err)
-			fileState.lineNumsRetryOrError(fmt.Errorf("error unmarshalling build response: %v", err))
+			fileState.onMalformedReplacements(s, fmt.Errorf("error unmarshalling build response: %v", err))
 			return
 		}
 
@@ -398,3 +438,53 @@ func (fileState *activeBuildStreamFileState) buildFileLineNums() {
 	}
 
 }
+
+// getBuildModelRequest returns the request that asks the builder model for the changes to make to a file, as replacements of numbered lines, along with the context to send it with. Models with structured outputs get the replacements schema as a strict json_schema response format, which can't produce malformed JSON. Other models call the ListReplacements function.
+func getBuildModelRequest(ctx context.Context, config shared.ModelRoleConfig, filePath, preBuildState, fileDescription, fileContent string) (openai.ChatCompletionRequest, context.Context) {
+	sysPrompt := prompts.GetBuildLineNumbersSysPrompt(filePath, preBuildState, fmt.Sprintf("%s\n\n```%s```", fileDescription, fileContent))
+
+	if config.BaseModelConfig.HasStructuredOutputs {
+		modelReq := openai.ChatCompletionRequest{
+			Model: config.BaseModelConfig.ModelName,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: sysPrompt + prompts.ListReplacementsStructuredOutputsPrompt,
+				},
+			},
+		}
+		reqCtx := model.ApplyRoleConfig(ctx, &modelReq, config)
+		reqCtx = model.WithJsonSchemaResponse(reqCtx, prompts.ListReplacementsFn.Name, prompts.ListReplacementsSchema)
+		return modelReq, reqCtx
+	}
+
+	var responseFormat *openai.ChatCompletionResponseFormat
+	if config.BaseModelConfig.HasJsonResponseMode {
+		responseFormat = &openai.ChatCompletionResponseFormat{Type: "json_object"}
+	}
+
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Tools: []openai.Tool{
+			{
+				Type:     "function",
+				Function: &prompts.ListReplacementsFn,
+			},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type: "function",
+			Function: openai.ToolFunction{
+				Name: prompts.ListReplacementsFn.Name,
+			},
+		},
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: sysPrompt,
+			},
+		},
+		ResponseFormat: responseFormat,
+	}
+
+	return modelReq, model.ApplyRoleConfig(ctx, &modelReq, config)
+}
@@ -5,8 +5,10 @@ import (
 	"log"
 	"net/http"
 	"plandex-server/db"
+	"plandex-server/model"
 	"plandex-server/types"
 	"strings"
+	"time"
 
 	"github.com/plandex/plandex/shared"
 )
@@ -49,11 +51,28 @@ func (state *activeBuildStreamFileState) onFinishBuild() {
 		return
 	}
 
-	if !ap.BuildFinished() {
+	if !ap.QueuesFinished() {
 		log.Println("Build not finished after waiting for reply to finish streaming")
 		return
 	}
 
+	if state.checkConsistency() {
+		log.Println("Queued consistency fixes, waiting for them before finishing build")
+		return
+	}
+
+	ap = GetActivePlan(planId, branch)
+
+	if ap == nil {
+		log.Println("onFinishBuild - Active plan not found")
+		return
+	}
+
+	if !ap.BuildFinished() {
+		log.Println("Build not finished after consistency check")
+		return
+	}
+
 	log.Println("Locking repo for finished build")
 
 	repoLockId, err := db.LockRepo(
@@ -111,7 +130,7 @@ func (state *activeBuildStreamFileState) onFinishBuild() {
 
 		var unbuiltDescs []*db.ConvoMessageDescription
 		for _, desc := range planDescs {
-			if !desc.DidBuild || len(desc.BuildPathsInvalidated) > 0 {
+			if !desc.Stale && (!desc.DidBuild || len(desc.BuildPathsInvalidated) > 0) {
 				unbuiltDescs = append(unbuiltDescs, desc)
 			}
 		}
@@ -130,9 +149,16 @@ func (state *activeBuildStreamFileState) onFinishBuild() {
 
 		descErrCh := make(chan error)
 		for _, desc := range unbuiltDescs {
-			if len(desc.Files) > 0 {
+			if len(desc.Files) > 0 || len(desc.Renames) > 0 || len(desc.RemovedFiles) > 0 {
 				desc.DidBuild = true
 				desc.BuildPathsInvalidated = map[string]bool{}
+
+				// files skipped after failing stay pending so they can be built again
+				for _, path := range desc.ToApi().BuildPaths() {
+					if ap.FailedBuildPaths[path] != "" {
+						desc.BuildPathsInvalidated[path] = true
+					}
+				}
 			}
 
 			go func(desc *db.ConvoMessageDescription) {
@@ -191,7 +217,30 @@ func (state *activeBuildStreamFileState) onFinishBuild() {
 	}
 }
 
+// finishSkippedBuild finishes the file's build without a result, for builds that have nothing to write, like a lockfile build or a follow-up build whose model had no changes to make
+func (fileState *activeBuildStreamFileState) finishSkippedBuild() {
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Printf("Active plan not found for plan ID %s and branch %s\n", fileState.plan.Id, fileState.branch)
+		return
+	}
+
+	activePlan.Stream(shared.StreamMessage{
+		Type: shared.StreamMessageBuildInfo,
+		BuildInfo: &shared.BuildInfo{
+			Path:     fileState.filePath,
+			Finished: true,
+		},
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	fileState.skippedBuild = true
+	fileState.onFinishBuildFile(nil, "")
+}
+
 func (fileState *activeBuildStreamFileState) onFinishBuildFile(planRes *db.PlanFileResult, updated string) {
+	fileState.endBuildAttempt()
+
 	planId := fileState.plan.Id
 	branch := fileState.branch
 	currentOrgId := fileState.currentOrgId
@@ -211,6 +260,20 @@ func (fileState *activeBuildStreamFileState) onFinishBuildFile(planRes *db.PlanF
 	log.Println("onFinishBuildFile: " + filePath)
 
 	if planRes != nil {
+		if len(planRes.NoOpChanges) > 0 {
+			planRes.Summary = noOpSummary(planRes.NoOpChanges) + "."
+		}
+		planRes.Provenance = fileState.provenance(planRes, updated)
+		planRes.ScanFindings = append(fileState.scanFindings(updated), fileState.dependencyFindings(updated)...)
+		if !activeBuild.IsReplyBuild() {
+			planRes.TestSourcePath = activeBuild.TestSourcePath
+			planRes.DocSyncSourcePath = activeBuild.DocSyncSourcePath
+			// the verifier checks results against the changes proposed in the reply, which doesn't have the scaffolded tests, doc updates, renamed references, consistency fixes, or import fixes
+			planRes.CanVerify = false
+		}
+		if fileState.isDeterministic {
+			planRes.CanVerify = false
+		}
 
 		repoLockId, err := db.LockRepo(
 			db.LockRepoParams{
@@ -273,6 +336,11 @@ func (fileState *activeBuildStreamFileState) onFinishBuildFile(planRes *db.PlanF
 		if err != nil {
 			return
 		}
+
+		// syntax fixes are too small to be worth their own summary
+		if !planRes.RemovedFile && !planRes.IsFix {
+			fileState.queueFileChangesSummary(planRes.Id, updated)
+		}
 	}
 
 	// if we have a syntax error, fix it if we aren't out of retries
@@ -302,6 +370,8 @@ func (fileState *activeBuildStreamFileState) onFinishBuildFile(planRes *db.PlanF
 
 	activeBuild.Success = true
 
+	fileState.setBuildFinished("")
+
 	// if more builds are queued, start the next one regardless of whether this is a verification build or not, then return
 	if !activePlan.PathQueueEmpty(filePath) {
 		log.Printf("Processing next build for file %s\n", filePath)
@@ -327,15 +397,29 @@ func (fileState *activeBuildStreamFileState) onFinishBuildFile(planRes *db.PlanF
 	}
 
 	// otherwise:
-	// if this is a verification build or a new file build (new files aren't verified), check if the build is finished and call onFinishBuild if it is
+	// if this is a verification build, a new file build (new files aren't verified), or an accepted draft, check if the build is finished and call onFinishBuild if it is
 	// if this is not a verification build, trigger the verification build
-	if activeBuild.IsVerification || fileState.isNewFile || (planRes != nil && !planRes.CanVerify) {
+	if activeBuild.IsVerification || fileState.isNewFile || fileState.draftAccepted || fileState.skippedBuild || (planRes != nil && !planRes.CanVerify) {
+		// the file's tests, docs, renames, and import fixes are queued before checking whether the build is finished, so the build waits for them
+		builtState := updated
+		if planRes == nil {
+			builtState = activeBuild.ToVerifyUpdatedState
+		}
+		fileState.queueTestScaffold()
+		fileState.queueDocSync(builtState)
+		fileState.queueSymbolRenameCheck(builtState)
+		fileState.queueImportFix(builtState)
+
 		buildFinished := false
 
 		UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
 			ap.BuiltFiles[filePath] = true
 			ap.IsBuildingByPath[filePath] = false
-			if ap.BuildFinished() {
+			if activeBuild.IsReplyBuild() && !fileState.skippedBuild {
+				ap.ConsistencyCheckPaths[filePath] = true
+			}
+			// the build's consistency check runs in onFinishBuild, once the queues are done
+			if ap.QueuesFinished() {
 				buildFinished = true
 			}
 		})
@@ -360,11 +444,41 @@ func (fileState *activeBuildStreamFileState) onFinishBuildFile(planRes *db.PlanF
 
 }
 
+// setBuildFinished stores the build's error, retries, and tokens so 'plandex builds' can show them after the plan is no longer active
+func (fileState *activeBuildStreamFileState) setBuildFinished(errMsg string) {
+	build := fileState.build
+	activeBuild := fileState.activeBuild
+
+	if build == nil {
+		return
+	}
+
+	build.Error = errMsg
+	build.NumRetries = fileState.lineNumsNumRetry + fileState.verifyFileNumRetry + fileState.fixFileNumRetry + fileState.syntaxNumRetry
+	build.NumTokens = activeBuild.WithLineNumsBufferTokens + activeBuild.VerifyBufferTokens + activeBuild.FixBufferTokens
+
+	err := db.SetBuildFinished(build)
+	if err != nil {
+		log.Printf("Error setting build finished: %v\n", err)
+	}
+
+	role, config := fileState.usageRoleConfig()
+
+	var inputTokens int
+	if fileState.recordedOutputTokens == 0 {
+		tokenizer := config.BaseModelConfig.GetTokenizer()
+		inputTokens = tokenizer.FromBaseTokens(activeBuild.FileContentTokens + activeBuild.CurrentFileTokens)
+	}
+	recordModelUsage(fileState.currentOrgId, fileState.currentUserId, fileState.plan.Id, fileState.branch, role, config, inputTokens, build.NumTokens-fileState.recordedOutputTokens, nil)
+	fileState.recordedOutputTokens = build.NumTokens
+}
+
 func (fileState *activeBuildStreamFileState) onBuildFileError(err error) {
+	fileState.endBuildAttempt()
+
 	planId := fileState.plan.Id
 	branch := fileState.branch
 	filePath := fileState.filePath
-	build := fileState.build
 	activeBuild := fileState.activeBuild
 	currentOrgId := fileState.currentOrgId
 
@@ -377,25 +491,33 @@ func (fileState *activeBuildStreamFileState) onBuildFileError(err error) {
 
 	log.Printf("Error for file %s: %v\n", filePath, err)
 
+	if fileState.isDraft {
+		fileState.escalateDraft(err)
+		return
+	}
+
+	switch fileState.settings.GetBuildErrorPolicy() {
+	case shared.BuildErrorSkip:
+		fileState.skipFailedBuild(err)
+		return
+	case shared.BuildErrorPause:
+		fileState.pauseFailedBuild(err)
+		return
+	}
+
 	activeBuild.Success = false
 	activeBuild.Error = err
 
-	activePlan.StreamDoneCh <- &shared.ApiError{
-		Type:   shared.ApiErrorTypeOther,
-		Status: http.StatusInternalServerError,
-		Msg:    err.Error(),
-	}
+	apiErr := model.NewApiError(buildErrorType(err), err.Error())
+	apiErr.PromptTooLongError = buildPromptTooLongReport(err)
+	apiErr.FileTooLargeError = buildFileTooLargeReport(err)
+	activePlan.StreamDoneCh <- apiErr
 
 	if err != nil {
 		log.Printf("Error storing plan error result: %v\n", err)
 	}
 
-	build.Error = err.Error()
-
-	err = db.SetBuildError(build)
-	if err != nil {
-		log.Printf("Error setting build error: %v\n", err)
-	}
+	fileState.setBuildFinished(err.Error())
 
 	// rollback repo in case there are uncommitted builds
 	err = db.GitClearUncommittedChanges(currentOrgId, planId)
@@ -90,24 +90,29 @@ func (fileState *activeBuildStreamFileState) fixFileLineNums() {
 			},
 		},
 		Messages:       fileMessages,
-		Temperature:    config.Temperature,
-		TopP:           config.TopP,
 		ResponseFormat: responseFormat,
 	}
+	reqCtx := model.ApplyRoleConfig(fileState.startBuildAttempt(activePlan.Ctx), &modelReq, config)
+	fileState.setPrompt(modelReq)
 
 	envVar := config.BaseModelConfig.ApiKeyEnvVar
 	client := clients[envVar]
 
 	if config.BaseModelConfig.HasStreamingFunctionCalls {
 
-		stream, err := model.CreateChatCompletionStreamWithRetries(client, activePlan.Ctx, modelReq)
+		stream, err := model.CreateChatCompletionStreamWithRetries(client, reqCtx, modelReq)
+		capturedStream := fileState.captureModelStream(shared.BuildCapturePhaseFix, modelReq, stream, err)
 		if err != nil {
 			log.Printf("Error creating plan file stream for path '%s': %v\n", filePath, err)
+			if fileState.attemptTimedOut() {
+				fileState.fixRetryOrAbort(fileState.attemptErr(err))
+				return
+			}
 			fileState.onBuildFileError(fmt.Errorf("error creating plan file stream for path '%s': %v", filePath, err))
 			return
 		}
 
-		go fileState.listenStreamFixChanges(stream)
+		go fileState.listenStreamFixChanges(capturedStream)
 	} else {
 		buildInfo := &shared.BuildInfo{
 			Path:      filePath,
@@ -119,10 +124,15 @@ func (fileState *activeBuildStreamFileState) fixFileLineNums() {
 			BuildInfo: buildInfo,
 		})
 
-		resp, err := model.CreateChatCompletionWithRetries(client, activePlan.Ctx, modelReq)
+		resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+		fileState.captureModelResponse(shared.BuildCapturePhaseFix, modelReq, resp, err)
 
 		if err != nil {
 			log.Printf("Error building file '%s': %v\n", filePath, err)
+			if fileState.attemptTimedOut() {
+				fileState.fixRetryOrAbort(fileState.attemptErr(err))
+				return
+			}
 			fileState.onBuildFileError(fmt.Errorf("error building file '%s': %v", filePath, err))
 			return
 		}
@@ -104,7 +104,7 @@ func (fileState *activeBuildStreamFileState) onFixResult(res types.ChangesWithLi
 		}
 
 		// no retry here as this should never happen
-		fileState.onBuildFileError(fmt.Errorf("listenStreamFixChanges - replacements failed for file '%s'", filePath))
+		fileState.onBuildFileError(newBuildError(shared.ApiErrorTypeApplyConflict, fmt.Errorf("listenStreamFixChanges - replacements failed for file '%s'", filePath)))
 		return
 
 	}
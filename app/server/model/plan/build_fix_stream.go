@@ -12,10 +12,9 @@ import (
 	"time"
 
 	"github.com/plandex/plandex/shared"
-	"github.com/sashabaranov/go-openai"
 )
 
-func (fileState *activeBuildStreamFileState) listenStreamFixChanges(stream *openai.ChatCompletionStream) {
+func (fileState *activeBuildStreamFileState) listenStreamFixChanges(stream model.ChatCompletionStream) {
 	filePath := fileState.filePath
 	planId := fileState.plan.Id
 	branch := fileState.branch
@@ -59,7 +58,7 @@ func (fileState *activeBuildStreamFileState) listenStreamFixChanges(stream *open
 					return
 				}
 
-				fileState.fixRetryOrAbort(fmt.Errorf("listenStreamFixChanges - stream error: %v", err))
+				fileState.fixRetryOrAbort(fileState.attemptErr(fmt.Errorf("listenStreamFixChanges - stream error: %v", err)))
 				return
 			}
 
@@ -0,0 +1,168 @@
+package plan
+
+import (
+	"log"
+	"path/filepath"
+	"plandex-server/syntax"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// queueImportFix queues a build that fixes a built file's unused and missing imports, so the most common build defects are corrected without another model call. It's only queued when the file's imports need fixing.
+func (fileState *activeBuildStreamFileState) queueImportFix(updated string) {
+	filePath := fileState.filePath
+	activeBuild := fileState.activeBuild
+
+	if activeBuild.ImportFix || activeBuild.SymbolRename != nil || updated == "" {
+		return
+	}
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Println("queueImportFix - Active plan not found")
+		return
+	}
+
+	_, fixes, err := syntax.FixImports(activePlan.Ctx, filePath, updated, fileState.goPackageDecls(activePlan, filePath, updated))
+	if err != nil {
+		log.Printf("Error checking imports for %s: %v\n", filePath, err)
+		return
+	}
+	if len(fixes) == 0 {
+		return
+	}
+
+	for _, build := range activePlan.BuildQueuesByPath[filePath] {
+		if build.ImportFix && !build.BuildFinished() {
+			log.Printf("Import fix for %s is already queued\n", filePath)
+			return
+		}
+	}
+
+	log.Printf("Queueing import fix for %s: %s\n", filePath, strings.Join(fixes, ", "))
+
+	fileState.queueBuilds([]*types.ActiveBuild{
+		{
+			ReplyId:   activeBuild.ReplyId,
+			Path:      filePath,
+			ImportFix: true,
+		},
+	})
+}
+
+// buildImportFix fixes the imports in the file's current state. The fixed lines are a change, so the fix is reviewed like any other build. If the imports don't need fixing anymore, the build is skipped.
+func (fileState *activeBuildStreamFileState) buildImportFix() {
+	filePath := fileState.filePath
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Printf("Active plan not found for plan ID %s and branch %s\n", fileState.plan.Id, fileState.branch)
+		return
+	}
+
+	skip := func(reason string) {
+		log.Printf("Skipping import fix for %s: %s\n", filePath, reason)
+		fileState.finishSkippedBuild()
+	}
+
+	currentState, ok := fileState.pendingFileState(activePlan, filePath)
+	if !ok {
+		skip("file isn't in context")
+		return
+	}
+
+	updated, fixes, err := syntax.FixImports(activePlan.Ctx, filePath, currentState, fileState.goPackageDecls(activePlan, filePath, currentState))
+	if err != nil {
+		skip(err.Error())
+		return
+	}
+	if len(fixes) == 0 {
+		skip("imports don't need fixing")
+		return
+	}
+
+	log.Printf("Fixing imports in %s: %s\n", filePath, strings.Join(fixes, ", "))
+
+	fileState.preBuildState = currentState
+	fileState.onBuildResult(types.ChangesWithLineNums{
+		Changes: lineChanges(currentState, updated, strings.Join(fixes, ". ")),
+	})
+}
+
+// goPackageDecls returns the top-level names declared by the other files in context in a go file's package, which references in the file can use without an import
+func (fileState *activeBuildStreamFileState) goPackageDecls(activePlan *types.ActivePlan, path, file string) map[string]bool {
+	if filepath.Ext(path) != ".go" {
+		return nil
+	}
+
+	pkg, _ := syntax.GoFileDecls(file)
+	if pkg == "" {
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+	res := map[string]bool{}
+
+	for _, context := range activePlan.Contexts {
+		siblingPath := context.FilePath
+		if siblingPath == "" || siblingPath == path || filepath.Ext(siblingPath) != ".go" || filepath.Dir(siblingPath) != dir {
+			continue
+		}
+
+		sibling, _ := fileState.pendingFileState(activePlan, siblingPath)
+		siblingPkg, names := syntax.GoFileDecls(sibling)
+		if siblingPkg != pkg {
+			continue
+		}
+		for _, name := range names {
+			res[name] = true
+		}
+	}
+
+	return res
+}
+
+// lineChanges returns a single change that replaces the lines that differ between the original and updated file. A change can't be empty on either side, so pure insertions and deletions include the line before them, or after them at the start of the file.
+func lineChanges(original, updated, summary string) []*shared.StreamedChangeWithLineNums {
+	originalLines := strings.Split(original, "\n")
+	updatedLines := strings.Split(updated, "\n")
+
+	prefix := 0
+	for prefix < len(originalLines) && prefix < len(updatedLines) && originalLines[prefix] == updatedLines[prefix] {
+		prefix++
+	}
+	if prefix == len(originalLines) && prefix == len(updatedLines) {
+		return nil
+	}
+
+	suffix := 0
+	for suffix < len(originalLines)-prefix && suffix < len(updatedLines)-prefix &&
+		originalLines[len(originalLines)-1-suffix] == updatedLines[len(updatedLines)-1-suffix] {
+		suffix++
+	}
+
+	start, originalEnd, updatedEnd := prefix, len(originalLines)-suffix, len(updatedLines)-suffix
+
+	if start == originalEnd || start == updatedEnd {
+		if start > 0 {
+			start--
+		} else {
+			originalEnd++
+			updatedEnd++
+		}
+	}
+
+	return []*shared.StreamedChangeWithLineNums{
+		{
+			Summary:   summary,
+			HasChange: true,
+			Old: shared.StreamedChangeSection{
+				StartLine: start + 1,
+				EndLine:   originalEnd,
+			},
+			New: strings.Join(updatedLines[start:updatedEnd], "\n"),
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package plan
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLineChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		updated  string
+	}{
+		{
+			name:     "replaced lines",
+			original: "package lib\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc A() {}\n",
+			updated:  "package lib\n\nimport (\n\t\"log\"\n\t\"os\"\n)\n\nfunc A() {}\n",
+		},
+		{
+			name:     "inserted lines",
+			original: "package lib\n\nfunc A() {}\n",
+			updated:  "package lib\n\nimport \"fmt\"\n\nfunc A() {}\n",
+		},
+		{
+			name:     "deleted lines",
+			original: "package lib\n\nimport \"fmt\"\n\nfunc A() {}\n",
+			updated:  "package lib\n\nfunc A() {}\n",
+		},
+		{
+			name:     "deleted first line",
+			original: "import { a } from './a';\nimport './b';\n",
+			updated:  "import './b';\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes := lineChanges(test.original, test.updated, "Fixed imports")
+			if len(changes) != 1 {
+				t.Fatalf("lineChanges() returned %d changes, want 1", len(changes))
+			}
+
+			_, updated, allSucceeded, err := GetPlanResult(context.Background(), PlanResultParams{
+				FilePath:            "lib/a.go",
+				PreBuildState:       test.original,
+				ChangesWithLineNums: changes,
+				OverlapStrategy:     OverlapStrategyError,
+			})
+			if err != nil {
+				t.Fatalf("GetPlanResult() error = %v", err)
+			}
+			if !allSucceeded {
+				t.Fatalf("GetPlanResult() replacements failed")
+			}
+			if strings.TrimRight(updated, "\n") != strings.TrimRight(test.updated, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", updated, test.updated)
+			}
+		})
+	}
+
+	if changes := lineChanges("a\nb\n", "a\nb\n", ""); len(changes) != 0 {
+		t.Errorf("expected no changes for identical files, got %d", len(changes))
+	}
+}
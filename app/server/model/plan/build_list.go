@@ -0,0 +1,105 @@
+package plan
+
+import (
+	"plandex-server/db"
+	"plandex-server/types"
+	"sort"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// ListBuilds returns a branch's builds, oldest first. Stored builds that haven't finished are building if the plan is active on this host and stopped otherwise. Builds still waiting in the active plan's queues are listed after the stored builds.
+func ListBuilds(orgId, planId, branch string) ([]*shared.FileBuild, error) {
+	stored, err := db.ListPlanBuilds(orgId, planId, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	return listFileBuilds(stored, GetActivePlan(planId, branch)), nil
+}
+
+func listFileBuilds(stored []*db.PlanBuild, active *types.ActivePlan) []*shared.FileBuild {
+	// a path builds one file at a time, so only the path's latest unfinished build can be running
+	latestUnfinished := map[string]int{}
+	for i, build := range stored {
+		if build.FinishedAt == nil {
+			latestUnfinished[build.FilePath] = i
+		}
+	}
+
+	var res []*shared.FileBuild
+
+	for i, build := range stored {
+		startedAt := build.CreatedAt
+		fileBuild := &shared.FileBuild{
+			Id:             build.Id,
+			Path:           build.FilePath,
+			ConvoMessageId: build.ConvoMessageId,
+			IsVerification: build.IsVerification,
+			NumRetries:     build.NumRetries,
+			NumTokens:      build.NumTokens,
+			Error:          build.Error,
+			StartedAt:      &startedAt,
+			FinishedAt:     build.FinishedAt,
+		}
+
+		switch {
+		case build.FinishedAt != nil && build.Error != "":
+			fileBuild.Status = shared.FileBuildStatusFailed
+		case build.FinishedAt != nil:
+			fileBuild.Status = shared.FileBuildStatusFinished
+		case active != nil && active.IsBuildingByPath[build.FilePath] && !active.BuildHeldByPath[build.FilePath] && latestUnfinished[build.FilePath] == i:
+			fileBuild.Status = shared.FileBuildStatusBuilding
+			if current := currentActiveBuild(active, build.FilePath); current != nil {
+				fileBuild.NumTokens = current.WithLineNumsBufferTokens + current.VerifyBufferTokens + current.FixBufferTokens
+			}
+		default:
+			fileBuild.Status = shared.FileBuildStatusStopped
+		}
+
+		res = append(res, fileBuild)
+	}
+
+	if active == nil {
+		return res
+	}
+
+	var paths []string
+	for path := range active.BuildQueuesByPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		// the first unfinished build in a building path's queue is the one running, unless it's held by a paused build
+		skipRunning := active.IsBuildingByPath[path] && !active.BuildHeldByPath[path]
+
+		for _, activeBuild := range active.BuildQueuesByPath[path] {
+			if activeBuild.BuildFinished() {
+				continue
+			}
+			if skipRunning {
+				skipRunning = false
+				continue
+			}
+
+			res = append(res, &shared.FileBuild{
+				Path:           path,
+				ConvoMessageId: activeBuild.ReplyId,
+				IsVerification: activeBuild.IsVerification,
+				Status:         shared.FileBuildStatusQueued,
+			})
+		}
+	}
+
+	return res
+}
+
+func currentActiveBuild(active *types.ActivePlan, path string) *types.ActiveBuild {
+	for _, activeBuild := range active.BuildQueuesByPath[path] {
+		if !activeBuild.BuildFinished() {
+			return activeBuild
+		}
+	}
+	return nil
+}
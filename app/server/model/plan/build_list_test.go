@@ -0,0 +1,90 @@
+package plan
+
+import (
+	"errors"
+	"plandex-server/db"
+	"plandex-server/types"
+	"testing"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestListFileBuilds(t *testing.T) {
+	finishedAt := time.Now()
+
+	stored := []*db.PlanBuild{
+		{Id: "1", FilePath: "a.go", NumRetries: 1, NumTokens: 200, FinishedAt: &finishedAt},
+		{Id: "2", FilePath: "b.go", Error: "timed out", FinishedAt: &finishedAt},
+		{Id: "3", FilePath: "c.go"},
+		{Id: "4", FilePath: "a.go"},
+	}
+
+	active := &types.ActivePlan{
+		IsBuildingByPath: map[string]bool{"a.go": true},
+		BuildQueuesByPath: map[string][]*types.ActiveBuild{
+			"a.go": {
+				{Path: "a.go", Success: true},
+				{Path: "a.go", WithLineNumsBufferTokens: 50},
+				{Path: "a.go", ReplyId: "r2"},
+			},
+			"b.go": {
+				{Path: "b.go", Error: errors.New("timed out")},
+			},
+			"d.go": {
+				{Path: "d.go", ReplyId: "r3"},
+			},
+		},
+	}
+
+	builds := listFileBuilds(stored, active)
+
+	want := []struct {
+		path      string
+		status    shared.FileBuildStatus
+		numTokens int
+	}{
+		{"a.go", shared.FileBuildStatusFinished, 200},
+		{"b.go", shared.FileBuildStatusFailed, 0},
+		{"c.go", shared.FileBuildStatusStopped, 0},
+		{"a.go", shared.FileBuildStatusBuilding, 50},
+		{"a.go", shared.FileBuildStatusQueued, 0},
+		{"d.go", shared.FileBuildStatusQueued, 0},
+	}
+
+	if len(builds) != len(want) {
+		t.Fatalf("got %d builds, want %d", len(builds), len(want))
+	}
+
+	for i, w := range want {
+		b := builds[i]
+		if b.Path != w.path || b.Status != w.status || b.NumTokens != w.numTokens {
+			t.Errorf("build %d = %s %s %d, want %s %s %d", i, b.Path, b.Status, b.NumTokens, w.path, w.status, w.numTokens)
+		}
+	}
+
+	for _, b := range listFileBuilds(stored, nil) {
+		if b.Status == shared.FileBuildStatusBuilding || b.Status == shared.FileBuildStatusQueued {
+			t.Errorf("build %s is %s without an active plan", b.Id, b.Status)
+		}
+	}
+}
+
+func TestListFileBuildsHeld(t *testing.T) {
+	active := &types.ActivePlan{
+		IsBuildingByPath: map[string]bool{"a.go": true},
+		BuildHeldByPath:  map[string]bool{"a.go": true},
+		BuildQueuesByPath: map[string][]*types.ActiveBuild{
+			"a.go": {
+				{Path: "a.go", Success: true},
+				{Path: "a.go", ReplyId: "r2"},
+			},
+		},
+	}
+
+	builds := listFileBuilds(nil, active)
+
+	if len(builds) != 1 || builds[0].Status != shared.FileBuildStatusQueued {
+		t.Fatalf("held build should be listed as queued, got %d builds", len(builds))
+	}
+}
@@ -16,7 +16,7 @@ func (state *activeBuildStreamState) loadPendingBuilds() (map[string][]*types.Ac
 	branch := state.branch
 	auth := state.auth
 
-	active, err := activatePlan(clients, plan, branch, auth, "", true)
+	active, err := activatePlan(clients, plan, branch, auth, "", true, state.priority)
 
 	if err != nil {
 		log.Printf("Error activating plan: %v\n", err)
@@ -137,8 +137,11 @@ func (state *activeBuildStreamFileState) loadBuildFile(activeBuild *types.Active
 	build := &db.PlanBuild{
 		OrgId:          currentOrgId,
 		PlanId:         planId,
+		UserId:         &currentUserId,
 		ConvoMessageId: convoMessageId,
 		FilePath:       filePath,
+		Branch:         branch,
+		IsVerification: activeBuild.IsVerification,
 	}
 	err := db.StorePlanBuild(build)
 
@@ -0,0 +1,11 @@
+package plan
+
+import (
+	"log"
+)
+
+// skipLockfileBuild finishes a lockfile's build without a result, so a model never writes a lockfile's hashes. Lockfiles are regenerated from their manifests when changes are applied instead.
+func (fileState *activeBuildStreamFileState) skipLockfileBuild() {
+	log.Printf("Skipping build of lockfile %s, which is regenerated from its manifest\n", fileState.filePath)
+	fileState.finishSkippedBuild()
+}
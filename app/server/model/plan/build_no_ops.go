@@ -0,0 +1,103 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// isNoOpChange returns true if a change that replaces lines startLine through endLine, 1-indexed, with new would leave the file as it is, or would add code that's already next to those lines. That happens when a build is run again on a file that already has its changes, and applying the change would duplicate the code.
+func isNoOpChange(lines []string, startLine, endLine int, new string) bool {
+	// removing code is never a no-op, even when the lines removed are blank
+	if new == "" || startLine < 1 || endLine > len(lines) || startLine > endLine {
+		return false
+	}
+
+	oldLines := lines[startLine-1 : endLine]
+	newLines := strings.Split(new, "\n")
+
+	if linesEqual(oldLines, newLines) {
+		return true
+	}
+
+	if len(newLines) <= len(oldLines) {
+		return false
+	}
+
+	// the old lines are kept at the start of the new ones, and the lines added after them are already after them
+	if linesEqual(newLines[:len(oldLines)], oldLines) {
+		added := newLines[len(oldLines):]
+		if endLine+len(added) <= len(lines) && !allBlank(added) && linesEqual(lines[endLine:endLine+len(added)], added) {
+			return true
+		}
+	}
+
+	// the old lines are kept at the end of the new ones, and the lines added before them are already before them
+	if linesEqual(newLines[len(newLines)-len(oldLines):], oldLines) {
+		added := newLines[:len(newLines)-len(oldLines)]
+		before := startLine - 1 - len(added)
+		if before >= 0 && !allBlank(added) && linesEqual(lines[before:startLine-1], added) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// noOpChangeSummary describes a skipped change for the build's summary
+func noOpChangeSummary(change *shared.StreamedChangeWithLineNums) string {
+	if change.Summary != "" {
+		return change.Summary
+	}
+
+	if change.Old.EntireFile {
+		return "Replace the entire file"
+	}
+
+	startLine, endLine, err := change.GetLines()
+	if err != nil {
+		return "Unknown change"
+	}
+	if startLine == endLine {
+		return fmt.Sprintf("Change to line %d", startLine)
+	}
+	return fmt.Sprintf("Change to lines %d-%d", startLine, endLine)
+}
+
+// noOpSummary is added to a file's build summary when changes were skipped because the file already had them
+func noOpSummary(noOpChanges []string) string {
+	if len(noOpChanges) == 1 {
+		return fmt.Sprintf("Skipped a change that was already in the file: %s", strings.TrimSuffix(noOpChanges[0], "."))
+	}
+	return fmt.Sprintf("Skipped %d changes that were already in the file: %s", len(noOpChanges), strings.Join(trimPeriods(noOpChanges), "; "))
+}
+
+func trimPeriods(lines []string) []string {
+	res := make([]string, len(lines))
+	for i, line := range lines {
+		res[i] = strings.TrimSuffix(line, ".")
+	}
+	return res
+}
+
+func linesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if strings.TrimRight(a[i], " \t\r") != strings.TrimRight(b[i], " \t\r") {
+			return false
+		}
+	}
+	return true
+}
+
+func allBlank(lines []string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package plan
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestIsNoOpChange(t *testing.T) {
+	file := "package main\n\nfunc a() {}\n\nfunc b() {}\n"
+	lines := strings.Split(file, "\n")
+
+	tests := []struct {
+		name       string
+		start, end int
+		new        string
+		want       bool
+	}{
+		{"same lines", 3, 3, "func a() {}", true},
+		{"same lines with trailing spaces", 3, 3, "func a() {}  ", true},
+		{"changed line", 3, 3, "func a() { return }", false},
+		{"insertion already after", 3, 3, "func a() {}\n\nfunc b() {}", true},
+		{"insertion already before", 5, 5, "func a() {}\n\nfunc b() {}", true},
+		{"new insertion", 5, 5, "func b() {}\n\nfunc c() {}", false},
+		{"added blank line", 3, 3, "func a() {}\n", false},
+		{"removed blank line", 4, 4, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isNoOpChange(lines, test.start, test.end, test.new); got != test.want {
+				t.Errorf("isNoOpChange() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetPlanResultSkipsNoOps(t *testing.T) {
+	original := "package main\n\nfunc a() {}\n\nfunc b() {}\n"
+
+	res, updated, allSucceeded, err := GetPlanResult(context.Background(), PlanResultParams{
+		FilePath:      "main.go",
+		PreBuildState: original,
+		ChangesWithLineNums: []*shared.StreamedChangeWithLineNums{
+			{
+				Summary:   "Add function b after function a.",
+				HasChange: true,
+				Old:       shared.StreamedChangeSection{StartLineString: "pdx-3: func a() {}"},
+				New:       "func a() {}\n\nfunc b() {}",
+			},
+		},
+		OverlapStrategy: OverlapStrategyError,
+	})
+	if err != nil || !allSucceeded {
+		t.Fatalf("GetPlanResult() error = %v, allSucceeded = %v", err, allSucceeded)
+	}
+
+	if strings.TrimRight(updated, "\n") != strings.TrimRight(original, "\n") {
+		t.Errorf("expected the file to be unchanged, got:\n%s", updated)
+	}
+	if len(res.Replacements) != 0 {
+		t.Errorf("expected no replacements, got %d", len(res.Replacements))
+	}
+	if len(res.NoOpChanges) != 1 || res.NoOpChanges[0] != "Add function b after function a." {
+		t.Errorf("NoOpChanges = %v", res.NoOpChanges)
+	}
+
+	if got, want := noOpSummary(res.NoOpChanges), "Skipped a change that was already in the file: Add function b after function a"; got != want {
+		t.Errorf("noOpSummary() = %q, want %q", got, want)
+	}
+}
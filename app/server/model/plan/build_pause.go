@@ -0,0 +1,71 @@
+package plan
+
+import (
+	"fmt"
+	"log"
+	"plandex-server/db"
+	"plandex-server/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// PauseBuild holds the plan's build queues. Builds that are already calling the builder model finish, but each file's next build waits until the build is resumed.
+func PauseBuild(planId, branch string) error {
+	active := GetActivePlan(planId, branch)
+
+	if active == nil {
+		return fmt.Errorf("no active plan with id %s", planId)
+	}
+
+	if !active.PauseBuild() {
+		return fmt.Errorf("build is already paused")
+	}
+
+	log.Printf("Paused build for plan %s on branch %s\n", planId, branch)
+
+	active.Stream(shared.StreamMessage{
+		Type: shared.StreamMessageBuildPaused,
+	})
+
+	return nil
+}
+
+// ResumeBuild reloads the plan's context, so changes made while the build was paused are used by the builds still to come, then releases the held builds. The caller must hold a read lock on the plan's repo.
+func ResumeBuild(orgId, planId, branch string) error {
+	active := GetActivePlan(planId, branch)
+
+	if active == nil {
+		return fmt.Errorf("no active plan with id %s", planId)
+	}
+
+	if !active.IsBuildPaused() {
+		return fmt.Errorf("build isn't paused")
+	}
+
+	modelContext, err := db.GetPlanContexts(orgId, planId, true)
+	if err != nil {
+		return fmt.Errorf("error getting plan contexts: %v", err)
+	}
+
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		ap.Contexts = modelContext
+		ap.ContextsByPath = map[string]*db.Context{}
+		for _, context := range modelContext {
+			if context.FilePath != "" {
+				ap.ContextsByPath[context.FilePath] = context
+			}
+		}
+	})
+
+	if !active.ResumeBuild() {
+		return fmt.Errorf("build isn't paused")
+	}
+
+	log.Printf("Resumed build for plan %s on branch %s\n", planId, branch)
+
+	active.Stream(shared.StreamMessage{
+		Type: shared.StreamMessageBuildResumed,
+	})
+
+	return nil
+}
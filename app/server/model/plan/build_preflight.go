@@ -0,0 +1,157 @@
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"plandex-server/model/prompts"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// promptTooLongBuildError is a build error for a prompt that was too long for the builder model, with the breakdown sent to the client
+type promptTooLongBuildError struct {
+	report *shared.PromptTooLongError
+}
+
+func (e *promptTooLongBuildError) Error() string {
+	var parts []string
+	for _, part := range e.report.Parts {
+		parts = append(parts, fmt.Sprintf("%s %d", part.Name, part.Tokens))
+	}
+
+	return fmt.Sprintf("build prompt for %s is %d tokens, which is more than the %d token limit for %s (%s)", e.report.Path, e.report.TotalTokens, e.report.MaxTokens, e.report.Model, strings.Join(parts, ", "))
+}
+
+// buildPromptTooLongReport returns the breakdown from a prompt too long error, or nil for other errors
+func buildPromptTooLongReport(err error) *shared.PromptTooLongError {
+	var tooLongErr *promptTooLongBuildError
+	if errors.As(err, &tooLongErr) {
+		return tooLongErr.report
+	}
+	return nil
+}
+
+// fileTooLargeBuildError is a build error for a file that's larger than the plan's max build file size, with the sizes sent to the client
+type fileTooLargeBuildError struct {
+	report *shared.FileTooLargeError
+}
+
+func (e *fileTooLargeBuildError) Error() string {
+	return fmt.Sprintf("%s is %d tokens, which is more than the %d token limit for files the builder will update", e.report.Path, e.report.Tokens, e.report.MaxTokens)
+}
+
+// buildFileTooLargeReport returns the sizes from a file too large error, or nil for other errors
+func buildFileTooLargeReport(err error) *shared.FileTooLargeError {
+	var tooLargeErr *fileTooLargeBuildError
+	if errors.As(err, &tooLargeErr) {
+		return tooLargeErr.report
+	}
+	return nil
+}
+
+// checkBuildFileSize returns an error if a file is larger than the plan's max build file size, so oversized files fail before the builder model is called rather than partway through the build
+func checkBuildFileSize(filePath string, numTokens, maxTokens int) error {
+	if numTokens <= maxTokens {
+		return nil
+	}
+
+	return newBuildError(shared.ApiErrorTypeFileTooLarge, &fileTooLargeBuildError{
+		report: &shared.FileTooLargeError{
+			Path:      filePath,
+			Tokens:    numTokens,
+			MaxTokens: maxTokens,
+		},
+	})
+}
+
+// checkBuildPromptSize counts the tokens in a build request before it's sent, and returns an error with a breakdown of the prompt if the prompt and the model's max response tokens don't fit in the model's context window. The current file, the change description, and the proposed changes are counted separately, and everything else in the request (instructions and the response schema) is counted as instructions.
+func checkBuildPromptSize(config shared.ModelRoleConfig, modelReq openai.ChatCompletionRequest, filePath, preBuildState, fileDescription, fileContent string) error {
+	maxTokens := config.BaseModelConfig.MaxTokens
+	if maxTokens == 0 {
+		return nil
+	}
+
+	report, err := getBuildPromptTokens(config, modelReq, filePath, preBuildState, fileDescription, fileContent)
+	if err != nil {
+		return err
+	}
+
+	if report.TotalTokens <= report.MaxTokens {
+		return nil
+	}
+
+	return newBuildError(shared.ApiErrorTypeContextTooLong, &promptTooLongBuildError{report: report})
+}
+
+func getBuildPromptTokens(config shared.ModelRoleConfig, modelReq openai.ChatCompletionRequest, filePath, preBuildState, fileDescription, fileContent string) (*shared.PromptTooLongError, error) {
+	tokenizer := config.BaseModelConfig.GetTokenizer()
+
+	count := func(s string) (int, error) {
+		numTokens, err := tokenizer.CountTokens(s)
+		if err != nil {
+			return 0, fmt.Errorf("error counting build prompt tokens: %v", err)
+		}
+		return numTokens, nil
+	}
+
+	var total int
+	for _, msg := range modelReq.Messages {
+		numTokens, err := count(msg.Content)
+		if err != nil {
+			return nil, err
+		}
+		total += numTokens
+	}
+
+	schema := prompts.ListReplacementsSchema
+	if config.BaseModelConfig.HasStructuredOutputs || len(modelReq.Tools) > 0 {
+		bytes, err := json.Marshal(schema)
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling replacements schema: %v", err)
+		}
+		numTokens, err := count(string(bytes))
+		if err != nil {
+			return nil, err
+		}
+		total += numTokens
+	}
+
+	currentFileTokens, err := count(shared.AddLineNums(preBuildState))
+	if err != nil {
+		return nil, err
+	}
+	descriptionTokens, err := count(fileDescription)
+	if err != nil {
+		return nil, err
+	}
+	changesTokens, err := count(fileContent)
+	if err != nil {
+		return nil, err
+	}
+
+	instructionsTokens := max(total-currentFileTokens-descriptionTokens-changesTokens, 0)
+
+	parts := []shared.PromptTokensPart{
+		{Name: "current file", Tokens: currentFileTokens},
+		{Name: "proposed changes", Tokens: changesTokens},
+		{Name: "change description", Tokens: descriptionTokens},
+		{Name: "instructions", Tokens: instructionsTokens},
+	}
+
+	if config.MaxResponseTokens != nil {
+		parts = append(parts, shared.PromptTokensPart{Name: "reserved for response", Tokens: *config.MaxResponseTokens})
+		total += *config.MaxResponseTokens
+	}
+
+	return &shared.PromptTooLongError{
+		Model:       config.BaseModelConfig.ModelName,
+		Path:        filePath,
+		MaxTokens:   config.BaseModelConfig.MaxTokens,
+		TotalTokens: total,
+		Parts:       parts,
+		Estimated:   tokenizer.IsEstimate(),
+	}, nil
+}
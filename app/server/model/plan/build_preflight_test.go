@@ -0,0 +1,69 @@
+package plan
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestPromptTooLongBuildError(t *testing.T) {
+	report := &shared.PromptTooLongError{
+		Model:       "gpt-4",
+		Path:        "main.go",
+		MaxTokens:   8000,
+		TotalTokens: 9500,
+		Parts: []shared.PromptTokensPart{
+			{Name: "current file", Tokens: 7000},
+			{Name: "proposed changes", Tokens: 1500},
+			{Name: "change description", Tokens: 200},
+			{Name: "instructions", Tokens: 800},
+		},
+	}
+
+	err := newBuildError(shared.ApiErrorTypeContextTooLong, &promptTooLongBuildError{report: report})
+
+	if got := buildErrorType(err); got != shared.ApiErrorTypeContextTooLong {
+		t.Errorf("buildErrorType = %s, want %s", got, shared.ApiErrorTypeContextTooLong)
+	}
+
+	if got := buildPromptTooLongReport(err); got != report {
+		t.Errorf("buildPromptTooLongReport didn't return the report")
+	}
+
+	msg := err.Error()
+	for _, want := range []string{"main.go", "9500", "8000", "gpt-4", "current file 7000"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q doesn't contain %q", msg, want)
+		}
+	}
+
+	if buildPromptTooLongReport(errors.New("other")) != nil {
+		t.Errorf("expected no report for other errors")
+	}
+}
+
+func TestCheckBuildFileSize(t *testing.T) {
+	if err := checkBuildFileSize("main.go", 1000, 1000); err != nil {
+		t.Errorf("expected no error for a file at the limit, got %v", err)
+	}
+
+	err := checkBuildFileSize("main.go", 1001, 1000)
+	if err == nil {
+		t.Fatalf("expected an error for a file over the limit")
+	}
+
+	if got := buildErrorType(err); got != shared.ApiErrorTypeFileTooLarge {
+		t.Errorf("buildErrorType = %s, want %s", got, shared.ApiErrorTypeFileTooLarge)
+	}
+
+	report := buildFileTooLargeReport(err)
+	if report == nil || report.Path != "main.go" || report.Tokens != 1001 || report.MaxTokens != 1000 {
+		t.Errorf("buildFileTooLargeReport = %+v", report)
+	}
+
+	if buildFileTooLargeReport(errors.New("other")) != nil {
+		t.Errorf("expected no report for other errors")
+	}
+}
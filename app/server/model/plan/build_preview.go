@@ -0,0 +1,105 @@
+package plan
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// streamBuildPreview applies the changes that have finished streaming so far and sends the partially updated file to the client. A preview is only sent when another change is complete.
+func (fileState *activeBuildStreamFileState) streamBuildPreview() {
+	filePath := fileState.filePath
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		return
+	}
+
+	changes := getStreamedChanges(fileState.activeBuild.WithLineNumsBuffer)
+	if len(changes) <= fileState.numPreviewChanges {
+		return
+	}
+	fileState.numPreviewChanges = len(changes)
+
+	var withChange []*shared.StreamedChangeWithLineNums
+	for _, change := range changes {
+		if change.HasChange {
+			withChange = append(withChange, change)
+		}
+	}
+	if len(withChange) == 0 {
+		return
+	}
+
+	sort.SliceStable(withChange, func(i, j int) bool {
+		iStartLine, _, _ := withChange[i].GetLines()
+		jStartLine, _, _ := withChange[j].GetLines()
+		return iStartLine < jStartLine
+	})
+
+	// changes that don't apply cleanly are skipped--the final result is still checked as usual once the response is complete
+	_, updated, _, err := GetPlanResult(activePlan.Ctx, PlanResultParams{
+		FilePath:            filePath,
+		PreBuildState:       fileState.preBuildState,
+		ChangesWithLineNums: withChange,
+		OverlapStrategy:     OverlapStrategySkip,
+	})
+	if err != nil {
+		log.Printf("Error getting build preview for file '%s': %v\n", filePath, err)
+		return
+	}
+
+	activePlan.Stream(shared.StreamMessage{
+		Type: shared.StreamMessageBuildPreview,
+		BuildPreview: &shared.BuildPreview{
+			Path:       filePath,
+			Content:    updated,
+			NumChanges: len(withChange),
+		},
+	})
+}
+
+// getStreamedChanges returns the changes that are complete in a partial ListReplacements response
+func getStreamedChanges(buffer string) []*shared.StreamedChangeWithLineNums {
+	dec := json.NewDecoder(strings.NewReader(buffer))
+
+	tok, err := dec.Token()
+	if err != nil || tok != json.Delim('{') {
+		return nil
+	}
+
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return nil
+		}
+
+		if key, ok := tok.(string); ok && key == "changes" {
+			tok, err = dec.Token()
+			if err != nil || tok != json.Delim('[') {
+				return nil
+			}
+
+			var changes []*shared.StreamedChangeWithLineNums
+			for dec.More() {
+				var change shared.StreamedChangeWithLineNums
+				if dec.Decode(&change) != nil {
+					break
+				}
+				changes = append(changes, &change)
+			}
+			return changes
+		}
+
+		// skip the values of other keys
+		var value json.RawMessage
+		if dec.Decode(&value) != nil {
+			return nil
+		}
+	}
+
+	return nil
+}
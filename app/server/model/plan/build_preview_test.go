@@ -0,0 +1,19 @@
+package plan
+
+import "testing"
+
+func TestGetStreamedChanges(t *testing.T) {
+	buffer := `{"comments": [{"txt": "// }", "reference": false}], "problems": "none", "changes": [{"summary": "first", "hasChange": true, "old": {"startLineString": "pdx-1: a", "endLineString": "pdx-1: a"}, "new": "b"}, {"summary": "second", "hasChange": true, "old": {"startLineStr`
+
+	changes := getStreamedChanges(buffer)
+	if len(changes) != 1 {
+		t.Fatalf("Expected 1 complete change, got %d", len(changes))
+	}
+	if changes[0].Summary != "first" || changes[0].New != "b" {
+		t.Errorf("Unexpected change: %+v", changes[0])
+	}
+
+	if changes := getStreamedChanges(`{"comments": [{"txt": "// x"`); len(changes) != 0 {
+		t.Errorf("Expected no changes before the changes key, got %d", len(changes))
+	}
+}
@@ -0,0 +1,104 @@
+package plan
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"plandex-server/db"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+const provenanceSignaturePrefix = "hmac-sha256:"
+
+// provenanceSigningKey is the secret provenance is signed with. Provenance isn't signed when PLANDEX_PROVENANCE_SIGNING_KEY is unset.
+func provenanceSigningKey() []byte {
+	return []byte(os.Getenv("PLANDEX_PROVENANCE_SIGNING_KEY"))
+}
+
+func ProvenanceSigningEnabled() bool {
+	return len(provenanceSigningKey()) > 0
+}
+
+func signProvenance(p *shared.PlanFileResultProvenance) {
+	key := provenanceSigningKey()
+	if len(key) == 0 {
+		return
+	}
+	p.Signature = provenanceSignature(key, p)
+}
+
+func provenanceSignature(key []byte, p *shared.PlanFileResultProvenance) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(p.SigningPayload()))
+	return provenanceSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyProvenance returns whether provenance's signature matches its fields, or nil if it isn't signed or the server has no signing key
+func VerifyProvenance(p *shared.PlanFileResultProvenance) *bool {
+	key := provenanceSigningKey()
+	if len(key) == 0 || p.Signature == "" || !strings.HasPrefix(p.Signature, provenanceSignaturePrefix) {
+		return nil
+	}
+	valid := hmac.Equal([]byte(p.Signature), []byte(provenanceSignature(key, p)))
+	return &valid
+}
+
+// hashModelPrompt hashes the messages sent to a model in the same way as content hashes. The messages are joined with their roles so that moving text between messages changes the hash.
+func hashModelPrompt(messages []openai.ChatCompletionMessage) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		sb.WriteString(msg.Role)
+		sb.WriteString("\n")
+		sb.WriteString(msg.Content)
+		sb.WriteString("\n\n")
+	}
+	return shared.ContentHash(sb.String())
+}
+
+// setPrompt records the request the file's next result will be built from
+func (fileState *activeBuildStreamFileState) setPrompt(req openai.ChatCompletionRequest) {
+	fileState.promptHash = hashModelPrompt(req.Messages)
+}
+
+// provenance returns where a file result's code came from. New files are written in the plan's reply, so they come from the planner. Fixes come from the auto-fix role, and other builds from the builder, or the draft-builder when its draft was used.
+func (fileState *activeBuildStreamFileState) provenance(planRes *db.PlanFileResult, updated string) *shared.PlanFileResultProvenance {
+	var role shared.ModelRole
+	var config shared.ModelRoleConfig
+	promptHash := fileState.promptHash
+
+	switch {
+	case fileState.isNewFile:
+		role = shared.ModelRolePlanner
+		config = fileState.settings.ModelPack.Planner.ModelRoleConfig
+		promptHash = ""
+	case planRes.IsFix:
+		role = shared.ModelRoleAutoFix
+		config = fileState.settings.ModelPack.GetAutoFix()
+	default:
+		role, config = fileState.usageRoleConfig()
+	}
+
+	p := &shared.PlanFileResultProvenance{
+		Role:           role,
+		Provider:       config.BaseModelConfig.Provider,
+		CustomProvider: config.BaseModelConfig.CustomProvider,
+		ModelName:      config.BaseModelConfig.ModelName,
+		PromptHash:     promptHash,
+		PlanId:         fileState.plan.Id,
+		Branch:         fileState.branch,
+		PlanBuildId:    planRes.PlanBuildId,
+		ConvoMessageId: planRes.ConvoMessageId,
+		Path:           fileState.filePath,
+		ContentHash:    shared.ContentHash(updated),
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	signProvenance(p)
+
+	return p
+}
@@ -0,0 +1,94 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+func testProvenance() *shared.PlanFileResultProvenance {
+	return &shared.PlanFileResultProvenance{
+		Role:        shared.ModelRoleBuilder,
+		Provider:    shared.ModelProviderOpenAI,
+		ModelName:   "gpt-4o",
+		PromptHash:  hashModelPrompt([]openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleSystem, Content: "build main.go"}}),
+		PlanId:      "plan-1",
+		Branch:      "main",
+		PlanBuildId: "build-1",
+		Path:        "main.go",
+		ContentHash: shared.ContentHash("package main\n"),
+		CreatedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestVerifyProvenance(t *testing.T) {
+	t.Setenv("PLANDEX_PROVENANCE_SIGNING_KEY", "secret")
+
+	p := testProvenance()
+	signProvenance(p)
+
+	if !strings.HasPrefix(p.Signature, provenanceSignaturePrefix) {
+		t.Fatalf("expected a signature, got %q", p.Signature)
+	}
+
+	valid := VerifyProvenance(p)
+	if valid == nil || !*valid {
+		t.Errorf("expected signature to be valid, got %v", valid)
+	}
+
+	p.ContentHash = shared.ContentHash("package main\n\nfunc main() {}\n")
+	valid = VerifyProvenance(p)
+	if valid == nil || *valid {
+		t.Errorf("expected signature to be invalid after the content hash changed, got %v", valid)
+	}
+
+	t.Setenv("PLANDEX_PROVENANCE_SIGNING_KEY", "")
+	if VerifyProvenance(p) != nil {
+		t.Errorf("expected no verification without a signing key")
+	}
+}
+
+func TestSignProvenanceWithoutKey(t *testing.T) {
+	t.Setenv("PLANDEX_PROVENANCE_SIGNING_KEY", "")
+
+	p := testProvenance()
+	signProvenance(p)
+
+	if p.Signature != "" {
+		t.Errorf("expected no signature without a signing key, got %q", p.Signature)
+	}
+}
+
+func TestHashModelPrompt(t *testing.T) {
+	a := hashModelPrompt([]openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "one"},
+		{Role: openai.ChatMessageRoleUser, Content: "two"},
+	})
+	b := hashModelPrompt([]openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleUser, Content: "one"},
+		{Role: openai.ChatMessageRoleUser, Content: "two"},
+	})
+
+	if a == b {
+		t.Errorf("expected messages with different roles to hash differently")
+	}
+	if !strings.HasPrefix(a, "sha256:") {
+		t.Errorf("expected a sha256 hash, got %q", a)
+	}
+}
+
+func TestProvenanceTrailers(t *testing.T) {
+	p := testProvenance()
+	p.Path = "cmd/my file.go"
+	p.Signature = "hmac-sha256:abc"
+
+	msg := shared.WithProvenanceTrailers("feat: add main\n", []*shared.PlanFileResultProvenance{p})
+
+	want := "feat: add main\n\nPlandex-Provenance: path=\"cmd/my file.go\"; model=openai/gpt-4o; role=builder; plan=plan-1; branch=main; build=build-1; prompt=" + p.PromptHash + "; content=" + p.ContentHash + "; sig=hmac-sha256:abc"
+	if msg != want {
+		t.Errorf("got message\n%s\nwant\n%s", msg, want)
+	}
+}
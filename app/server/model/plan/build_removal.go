@@ -0,0 +1,168 @@
+package plan
+
+import (
+	"fmt"
+	"log"
+	"plandex-server/db"
+	"plandex-server/syntax"
+	"plandex-server/types"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const removalKinds = `function|func|method|type|class|interface|struct|enum|const|constant|var|variable|def|module|trait`
+
+// a removal comment names what was removed, like '// Plandex: removed the fooBar function' or '# Plandex: removed `fooBar`'. The name needs backticks or a kind like 'function' so comments like 'removed the loop' aren't taken for a declaration.
+var removalCommentRegex = regexp.MustCompile(`^(?://|#|--|/\*|<!--)?\s*Plandex: removed\s+(?:the\s+)?(?:(` + removalKinds + `)\s+)?(?:` + "`" + `([A-Za-z_$][\w$]*)` + "`" + `|([A-Za-z_$][\w$]*))(?:\s+(` + removalKinds + `))?\s*\.?\s*(?:\*/|-->)?$`)
+
+var existingCodeCommentRegex = regexp.MustCompile(`(?i)^(?://|#|--|/\*|<!--)\s*\.\.\.\s*existing code\s*\.\.\.\s*(?:\*/|-->)?$`)
+
+// removalOnlyNames returns the declarations a file block removes when it only has removal comments and existing code markers, which can be removed without a builder model call
+func removalOnlyNames(content string) ([]string, bool) {
+	var names []string
+	seen := map[string]bool{}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || existingCodeCommentRegex.MatchString(line) {
+			continue
+		}
+
+		m := removalCommentRegex.FindStringSubmatch(line)
+		if m == nil {
+			return nil, false
+		}
+
+		name := m[2]
+		if name == "" {
+			if m[1] == "" && m[4] == "" {
+				return nil, false
+			}
+			name = m[3]
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names, len(names) > 0
+}
+
+// buildDeclarationRemoval removes the declarations a removal-only file block names from the file's current state, found in its syntax tree, so the removal doesn't need a builder model call. It returns false, and the file is built by the model as usual, if the file block has anything besides removals or a declaration can't be found.
+func (fileState *activeBuildStreamFileState) buildDeclarationRemoval(currentState string) bool {
+	filePath := fileState.filePath
+
+	names, ok := removalOnlyNames(fileState.activeBuild.FileContent)
+	if !ok {
+		return false
+	}
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Printf("Active plan not found for plan ID %s and branch %s\n", fileState.plan.Id, fileState.branch)
+		return false
+	}
+
+	updated := currentState
+	for _, name := range names {
+		start, end, err := syntax.FindDeclaration(activePlan.Ctx, filePath, updated, name)
+		if err != nil {
+			log.Printf("Error finding declaration of %s in %s: %v\n", name, filePath, err)
+			return false
+		}
+		if start == 0 {
+			log.Printf("Couldn't find a single declaration of %s in %s, building removal with the model\n", name, filePath)
+			return false
+		}
+		updated = removeLines(updated, start, end)
+	}
+
+	log.Printf("Removing %s from %s without a model call\n", strings.Join(names, ", "), filePath)
+
+	fileState.isDeterministic = true
+	fileState.onBuildResult(types.ChangesWithLineNums{
+		Changes: lineChanges(currentState, updated, "Remove "+strings.Join(names, ", ")),
+	})
+
+	return true
+}
+
+// removeLines removes lines start through end, 1-indexed, along with a blank line after them if that would leave two blank lines in a row
+func removeLines(file string, start, end int) string {
+	lines := strings.Split(file, "\n")
+
+	blankBefore := start == 1 || strings.TrimSpace(lines[start-2]) == ""
+	if blankBefore && end < len(lines) && strings.TrimSpace(lines[end]) == "" {
+		// at the end of the file, the line after is the final line break, so the blank line before goes instead
+		if end+1 < len(lines) {
+			end++
+		} else if start > 1 {
+			start--
+		}
+	}
+
+	res := append([]string{}, lines[:start-1]...)
+	return strings.Join(append(res, lines[end:]...), "\n")
+}
+
+// queueFileRemoval queues a build of a file removal the planner declared
+func (state *activeBuildStreamState) queueFileRemoval(replyId, path string) {
+	log.Printf("Queueing removal of %s\n", path)
+
+	state.queueBuilds([]*types.ActiveBuild{
+		{
+			ReplyId:    replyId,
+			Path:       path,
+			RemoveFile: true,
+		},
+	})
+}
+
+// buildFileRemoval stores a result that removes the file, without a model call. Files that aren't in context or are already removed are skipped.
+func (fileState *activeBuildStreamFileState) buildFileRemoval() {
+	filePath := fileState.filePath
+	build := fileState.build
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Printf("Active plan not found for plan ID %s and branch %s\n", fileState.plan.Id, fileState.branch)
+		return
+	}
+
+	currentState, ok := fileState.pendingFileState(activePlan, filePath)
+	if !ok {
+		log.Printf("Skipping removal of %s: file isn't in context or is already removed\n", filePath)
+		fileState.finishSkippedBuild()
+		return
+	}
+
+	log.Printf("Removing %s\n", filePath)
+
+	activePlan.Stream(shared.StreamMessage{
+		Type: shared.StreamMessageBuildInfo,
+		BuildInfo: &shared.BuildInfo{
+			Path:     filePath,
+			Finished: true,
+		},
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	fileState.preBuildState = currentState
+	fileState.isDeterministic = true
+
+	fileState.onFinishBuildFile(&db.PlanFileResult{
+		TypeVersion:    1,
+		OrgId:          fileState.currentOrgId,
+		PlanId:         fileState.plan.Id,
+		PlanBuildId:    build.Id,
+		ConvoMessageId: build.ConvoMessageId,
+		Path:           filePath,
+		RemovedFile:    true,
+		Summary:        fmt.Sprintf("Removed %s", filePath),
+	}, "")
+}
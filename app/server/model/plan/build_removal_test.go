@@ -0,0 +1,67 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemovalOnlyNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		ok      bool
+	}{
+		{
+			name:    "kind after name",
+			content: "// ... existing code ...\n\n// Plandex: removed the fooBar function\n\n// ... existing code ...",
+			want:    []string{"fooBar"},
+			ok:      true,
+		},
+		{
+			name:    "backticks",
+			content: "# Plandex: removed `parse_args`\n# Plandex: removed the `Config` class",
+			want:    []string{"parse_args", "Config"},
+			ok:      true,
+		},
+		{
+			name:    "name without kind or backticks",
+			content: "// Plandex: removed the loop",
+			ok:      false,
+		},
+		{
+			name:    "other code",
+			content: "// Plandex: removed the fooBar function\nfunc baz() {}",
+			ok:      false,
+		},
+		{
+			name:    "only existing code",
+			content: "// ... existing code ...",
+			ok:      false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := removalOnlyNames(test.content)
+			if ok != test.ok {
+				t.Fatalf("removalOnlyNames() ok = %v, want %v", ok, test.ok)
+			}
+			if ok && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("removalOnlyNames() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRemoveLines(t *testing.T) {
+	file := "package lib\n\nfunc A() {}\n\n// B does b\nfunc B() {}\n\nfunc C() {}\n"
+
+	if got, want := removeLines(file, 5, 6), "package lib\n\nfunc A() {}\n\nfunc C() {}\n"; got != want {
+		t.Errorf("removeLines() middle = %q, want %q", got, want)
+	}
+
+	if got, want := removeLines(file, 8, 8), "package lib\n\nfunc A() {}\n\n// B does b\nfunc B() {}\n"; got != want {
+		t.Errorf("removeLines() end = %q, want %q", got, want)
+	}
+}
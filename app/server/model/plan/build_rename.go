@@ -0,0 +1,168 @@
+package plan
+
+import (
+	"fmt"
+	"log"
+	"plandex-server/syntax"
+	"plandex-server/types"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// queueSymbolRename queues a build of a symbol rename for each file in context that references the symbol
+func (state *activeBuildStreamState) queueSymbolRename(replyId string, rename *shared.SymbolRename) {
+	for _, path := range rename.Paths {
+		log.Printf("Queueing rename of %s to %s in %s\n", rename.From, rename.To, path)
+
+		state.queueBuilds([]*types.ActiveBuild{
+			{
+				ReplyId:      replyId,
+				Path:         path,
+				SymbolRename: rename,
+			},
+		})
+	}
+}
+
+// buildSymbolRename renames a symbol's references in the file's current state, found in its syntax tree, then checks that none remain. Each renamed line is a change, so the rename is reviewed like any other build. If the file doesn't reference the symbol anymore, like when an earlier build already renamed it, the build is skipped.
+func (fileState *activeBuildStreamFileState) buildSymbolRename() {
+	filePath := fileState.filePath
+	rename := fileState.activeBuild.SymbolRename
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Printf("Active plan not found for plan ID %s and branch %s\n", fileState.plan.Id, fileState.branch)
+		return
+	}
+
+	skip := func(reason string) {
+		log.Printf("Skipping rename of %s to %s in %s: %s\n", rename.From, rename.To, filePath, reason)
+		fileState.finishSkippedBuild()
+	}
+
+	currentState, ok := fileState.pendingFileState(activePlan, filePath)
+	if !ok {
+		skip("file isn't in context")
+		return
+	}
+
+	log.Printf("Renaming %s to %s in %s\n", rename.From, rename.To, filePath)
+
+	updated, refs, err := syntax.RenameSymbol(activePlan.Ctx, filePath, currentState, rename.From, rename.To)
+	if err != nil {
+		fileState.onBuildFileError(fmt.Errorf("error renaming %s to %s in '%s': %v", rename.From, rename.To, filePath, err))
+		return
+	}
+
+	if len(refs) == 0 {
+		skip("no references")
+		return
+	}
+
+	remaining, _, err := syntax.FindSymbol(activePlan.Ctx, filePath, updated, rename.From)
+	if err != nil {
+		fileState.onBuildFileError(fmt.Errorf("error checking rename of %s to %s in '%s': %v", rename.From, rename.To, filePath, err))
+		return
+	}
+	if len(remaining) > 0 {
+		fileState.onBuildFileError(newBuildError(shared.ApiErrorTypeApplyConflict, fmt.Errorf("%d references to %s remain in '%s' after renaming it to %s", len(remaining), rename.From, filePath, rename.To)))
+		return
+	}
+
+	fileState.preBuildState = currentState
+	fileState.onBuildResult(types.ChangesWithLineNums{
+		Changes: symbolRenameChanges(rename, refs, strings.Split(currentState, "\n"), strings.Split(updated, "\n")),
+	})
+}
+
+// symbolRenameChanges returns a change for each line with a renamed reference. Renaming doesn't add or remove lines, so the original and updated files line up.
+func symbolRenameChanges(rename *shared.SymbolRename, refs []syntax.SymbolRef, originalLines, updatedLines []string) []*shared.StreamedChangeWithLineNums {
+	var changes []*shared.StreamedChangeWithLineNums
+	seen := map[int]bool{}
+
+	for _, ref := range refs {
+		if seen[ref.Line] || ref.Line > len(updatedLines) || ref.Line > len(originalLines) {
+			continue
+		}
+		seen[ref.Line] = true
+
+		changes = append(changes, &shared.StreamedChangeWithLineNums{
+			Summary:   fmt.Sprintf("Rename %s to %s", rename.From, rename.To),
+			HasChange: true,
+			Old: shared.StreamedChangeSection{
+				StartLine: ref.Line,
+				EndLine:   ref.Line,
+			},
+			New: updatedLines[ref.Line-1],
+		})
+	}
+
+	return changes
+}
+
+// queueSymbolRenameCheck checks a file built from its reply's proposed changes for references to symbols the same reply renamed, like when the proposed changes still use the old name, and queues another rename build for the file if any remain
+func (fileState *activeBuildStreamFileState) queueSymbolRenameCheck(updated string) {
+	filePath := fileState.filePath
+	activeBuild := fileState.activeBuild
+
+	if activeBuild.SymbolRename != nil || updated == "" {
+		return
+	}
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Println("queueSymbolRenameCheck - Active plan not found")
+		return
+	}
+
+	renamesByFrom := map[string]*shared.SymbolRename{}
+	for _, builds := range activePlan.BuildQueuesByPath {
+		for _, build := range builds {
+			if build.SymbolRename != nil && build.ReplyId == activeBuild.ReplyId {
+				renamesByFrom[build.SymbolRename.From] = build.SymbolRename
+			}
+		}
+	}
+
+	var froms []string
+	for from := range renamesByFrom {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+
+	for _, from := range froms {
+		rename := renamesByFrom[from]
+
+		refs, _, err := syntax.FindSymbol(activePlan.Ctx, filePath, updated, from)
+		if err != nil {
+			log.Printf("Error checking %s for references to %s: %v\n", filePath, from, err)
+			continue
+		}
+		if len(refs) == 0 {
+			continue
+		}
+
+		queued := false
+		for _, build := range activePlan.BuildQueuesByPath[filePath] {
+			if build.SymbolRename != nil && build.SymbolRename.From == from && !build.BuildFinished() {
+				queued = true
+				break
+			}
+		}
+		if queued {
+			continue
+		}
+
+		log.Printf("%d references to %s remain in %s, queueing rename to %s\n", len(refs), from, filePath, rename.To)
+
+		fileState.queueBuilds([]*types.ActiveBuild{
+			{
+				ReplyId:      activeBuild.ReplyId,
+				Path:         filePath,
+				SymbolRename: rename,
+			},
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package plan
+
+import (
+	"context"
+	"plandex-server/syntax"
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestSymbolRenameChanges(t *testing.T) {
+	original := "package term\n\nfunc Render(s string) string {\n\treturn s\n}\n\nfunc Print(s string) {\n\tfmt.Println(Render(s), Render(s))\n}\n"
+
+	rename := &shared.SymbolRename{From: "Render", To: "Format"}
+	updated, refs, err := syntax.RenameSymbol(context.Background(), "term/render.go", original, rename.From, rename.To)
+	if err != nil {
+		t.Fatalf("RenameSymbol() error = %v", err)
+	}
+
+	changes := symbolRenameChanges(rename, refs, strings.Split(original, "\n"), strings.Split(updated, "\n"))
+
+	// two references on line 8 are a single change
+	if len(changes) != 2 {
+		t.Fatalf("symbolRenameChanges() returned %d changes, want 2", len(changes))
+	}
+
+	want := []struct {
+		line int
+		new  string
+	}{
+		{3, "func Format(s string) string {"},
+		{8, "\tfmt.Println(Format(s), Format(s))"},
+	}
+	for i, w := range want {
+		change := changes[i]
+		startLine, endLine, err := change.GetLines()
+		if err != nil {
+			t.Fatalf("GetLines() error = %v", err)
+		}
+		if startLine != w.line || endLine != w.line {
+			t.Errorf("change %d lines = %d-%d, want %d", i, startLine, endLine, w.line)
+		}
+		if change.New != w.new {
+			t.Errorf("change %d new = %q, want %q", i, change.New, w.new)
+		}
+		if !change.HasChange {
+			t.Errorf("change %d HasChange = false, want true", i)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// onMalformedReplacements recovers from a builder response that isn't valid JSON. The JSON is repaired if possible, then the model is asked once to correct it, and if that fails too, the whole updated file is requested instead of a list of changes.
+func (fileState *activeBuildStreamFileState) onMalformedReplacements(response string, parseErr error) {
+	filePath := fileState.filePath
+
+	fileState.activeBuild.WithLineNumsBuffer = ""
+	fileState.activeBuild.WithLineNumsBufferTokens = 0
+	fileState.numPreviewChanges = 0
+
+	var res types.ChangesWithLineNums
+	err := json.Unmarshal([]byte(repairJson(response)), &res)
+	if err == nil {
+		log.Printf("Repaired malformed build response JSON for file '%s'\n", filePath)
+		fileState.onBuildResult(res)
+		return
+	}
+
+	if fileState.isDraft {
+		fileState.escalateDraft(parseErr)
+		return
+	}
+
+	if !fileState.lineNumsCorrected {
+		fileState.lineNumsCorrected = true
+		fileState.correctReplacements(response, parseErr)
+		return
+	}
+
+	fileState.buildWholeFile()
+}
+
+// correctReplacements sends the malformed response back to the model along with the parsing error and asks for valid JSON
+func (fileState *activeBuildStreamFileState) correctReplacements(response string, parseErr error) {
+	filePath := fileState.filePath
+
+	log.Printf("Asking model to correct malformed build response JSON for file '%s'\n", filePath)
+
+	res, err := fileState.getCorrectedReplacements(response, prompts.GetBuildCorrectionPrompt(parseErr.Error()))
+	if err != nil {
+		log.Printf("Error getting corrected build response for file '%s': %v\n", filePath, err)
+		if fileState.attemptTimedOut() {
+			fileState.onBuildFileError(fileState.attemptErr(err))
+			return
+		}
+		fileState.buildWholeFile()
+		return
+	}
+
+	fileState.onBuildResult(*res)
+}
+
+// getCorrectedReplacements sends a build response back to the model with a prompt describing what's wrong with it, and returns the corrected list of changes
+func (fileState *activeBuildStreamFileState) getCorrectedReplacements(response, correctionPrompt string) (*types.ChangesWithLineNums, error) {
+	filePath := fileState.filePath
+	activeBuild := fileState.activeBuild
+	config := fileState.builderConfig()
+
+	modelReq, reqCtx := getBuildModelRequest(fileState.attemptCtx, config, filePath, fileState.preBuildState, fileState.buildFileDescription(), activeBuild.FileContent)
+	modelReq.Messages = append(modelReq.Messages,
+		openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: response,
+		},
+		openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: correctionPrompt,
+		},
+	)
+	fileState.setPrompt(modelReq)
+
+	client := fileState.clients[config.BaseModelConfig.ApiKeyEnvVar]
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+	fileState.captureModelResponse(shared.BuildCapturePhaseBuild, modelReq, resp, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var res types.ChangesWithLineNums
+	err = json.Unmarshal([]byte(repairJson(getReplacementsArgsFromResponse(resp))), &res)
+	if err != nil {
+		return nil, fmt.Errorf("corrected build response is still malformed: %v", err)
+	}
+
+	return &res, nil
+}
+
+// buildWholeFile asks the model for the full updated file and applies it as a single change that replaces the entire file
+func (fileState *activeBuildStreamFileState) buildWholeFile() {
+	filePath := fileState.filePath
+	activeBuild := fileState.activeBuild
+	config := fileState.builderConfig()
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Printf("buildWholeFile - Active plan not found for plan ID %s and branch %s\n", fileState.plan.Id, fileState.branch)
+		return
+	}
+
+	if fileState.isWholeFileBuild {
+		fileState.onBuildFileError(newBuildError(shared.ApiErrorTypeInvalidToolOutput, fmt.Errorf("couldn't build file '%s' as a list of changes or as a whole file", filePath)))
+		return
+	}
+	fileState.isWholeFileBuild = true
+
+	log.Printf("Falling back to building the whole file for '%s'\n", filePath)
+
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: prompts.GetBuildWholeFileSysPrompt(filePath, fileState.preBuildState, fmt.Sprintf("%s\n\n```%s```", fileState.buildFileDescription(), activeBuild.FileContent)),
+			},
+		},
+	}
+	reqCtx := model.ApplyRoleConfig(fileState.attemptCtx, &modelReq, config)
+	fileState.setPrompt(modelReq)
+
+	// whole file responses aren't captured since they can't be replayed as a list of changes
+	client := fileState.clients[config.BaseModelConfig.ApiKeyEnvVar]
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+	if err != nil {
+		log.Printf("Error building whole file '%s': %v\n", filePath, err)
+		fileState.onBuildFileError(fileState.attemptErr(fmt.Errorf("error building whole file '%s': %v", filePath, err)))
+		return
+	}
+
+	if len(resp.Choices) == 0 {
+		fileState.onBuildFileError(newBuildError(shared.ApiErrorTypeInvalidToolOutput, fmt.Errorf("no choices in whole file response for '%s'", filePath)))
+		return
+	}
+
+	updated, ok := getCodeBlockContent(resp.Choices[0].Message.Content)
+	if !ok || strings.TrimSpace(updated) == "" {
+		fileState.onBuildFileError(newBuildError(shared.ApiErrorTypeInvalidToolOutput, fmt.Errorf("no code block in whole file response for '%s'", filePath)))
+		return
+	}
+
+	if strings.HasSuffix(fileState.preBuildState, "\n") && !strings.HasSuffix(updated, "\n") {
+		updated += "\n"
+	}
+
+	fileState.onBuildResult(types.ChangesWithLineNums{
+		Changes: []*shared.StreamedChangeWithLineNums{
+			{
+				Summary:   "Replace the entire file",
+				HasChange: true,
+				Old:       shared.StreamedChangeSection{EntireFile: true},
+				New:       updated,
+			},
+		},
+	})
+}
+
+// repairJson fixes the ways models most often produce invalid JSON in an otherwise complete response: markdown fences or text around the object, unescaped newlines, tabs, and other control characters in strings, invalid escape sequences, and trailing commas. It doesn't complete truncated JSON, since that would silently drop part of a change.
+func repairJson(s string) string {
+	start := strings.Index(s, "{")
+	end := strings.LastIndex(s, "}")
+	if start == -1 || end < start {
+		return s
+	}
+	s = s[start : end+1]
+
+	var b strings.Builder
+	inString := false
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if inString {
+			switch {
+			case r == '\\':
+				if i+1 < len(runes) && strings.ContainsRune(`"\/bfnrtu`, runes[i+1]) {
+					b.WriteRune(r)
+					b.WriteRune(runes[i+1])
+					i++
+				} else {
+					b.WriteString(`\\`)
+				}
+			case r == '"':
+				inString = false
+				b.WriteRune(r)
+			case r == '\n':
+				b.WriteString(`\n`)
+			case r == '\r':
+				b.WriteString(`\r`)
+			case r == '\t':
+				b.WriteString(`\t`)
+			case r < 0x20:
+				b.WriteString(fmt.Sprintf(`\u%04x`, r))
+			default:
+				b.WriteRune(r)
+			}
+			continue
+		}
+
+		if r == '"' {
+			inString = true
+		} else if r == ',' {
+			j := i + 1
+			for j < len(runes) && strings.ContainsRune(" \t\r\n", runes[j]) {
+				j++
+			}
+			if j < len(runes) && (runes[j] == '}' || runes[j] == ']') {
+				continue
+			}
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// getCodeBlockContent returns the content between the first and last code fences in s, so code blocks nested in the file (like in markdown) are kept
+func getCodeBlockContent(s string) (string, bool) {
+	lines := strings.Split(s, "\n")
+
+	start, end := -1, -1
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if start == -1 {
+				start = i
+			} else {
+				end = i
+			}
+		}
+	}
+
+	if start == -1 || end == -1 {
+		return "", false
+	}
+
+	return strings.Join(lines[start+1:end], "\n"), true
+}
@@ -0,0 +1,69 @@
+package plan
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRepairJson(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "code fence",
+			input:    "```json\n{\"problems\": \"\"}\n```",
+			expected: `{"problems": ""}`,
+		},
+		{
+			name:     "raw newline and tab in string",
+			input:    "{\"new\": \"func main() {\n\tfmt.Println()\n}\"}",
+			expected: `{"new": "func main() {\n\tfmt.Println()\n}"}`,
+		},
+		{
+			name:     "invalid escape",
+			input:    `{"new": "it\'s \"quoted\""}`,
+			expected: `{"new": "it\\'s \"quoted\""}`,
+		},
+		{
+			name:     "trailing commas",
+			input:    "{\"changes\": [{\"summary\": \"a, b\",}, ],\n}",
+			expected: "{\"changes\": [{\"summary\": \"a, b\"} ]\n}",
+		},
+	}
+
+	for _, test := range tests {
+		repaired := repairJson(test.input)
+		if repaired != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, repaired)
+		}
+
+		var v interface{}
+		if err := json.Unmarshal([]byte(repaired), &v); err != nil {
+			t.Errorf("%s: repaired JSON is invalid: %v", test.name, err)
+		}
+	}
+}
+
+func TestRepairJsonDoesNotCompleteTruncated(t *testing.T) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(repairJson(`{"changes": [{"summary": "cut off`)), &v); err == nil {
+		t.Error("Expected truncated JSON to stay invalid")
+	}
+}
+
+func TestGetCodeBlockContent(t *testing.T) {
+	content, ok := getCodeBlockContent("Here's the file:\n```markdown\n# Title\n\n```go\nfunc main() {}\n```\n```")
+	if !ok {
+		t.Fatal("Expected a code block")
+	}
+	expected := "# Title\n\n```go\nfunc main() {}\n```"
+	if content != expected {
+		t.Errorf("Expected %q, got %q", expected, content)
+	}
+
+	if _, ok := getCodeBlockContent("```go\nfunc main() {"); ok {
+		t.Error("Expected an unclosed code block to be rejected")
+	}
+}
@@ -0,0 +1,95 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"plandex-server/db"
+	"plandex-server/model/prompts"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+type ReplayResult struct {
+	PlanFileResult *db.PlanFileResult
+	Updated        string
+	AllSucceeded   bool
+}
+
+// ReplayBuildCapture re-runs replacement application and syntax validation on a captured build or fix response, without calling the model, so the result is deterministic. Overlapping replacements are errors, as on a build's first attempt.
+func ReplayBuildCapture(ctx context.Context, capture *shared.BuildCapture) (*ReplayResult, error) {
+	if capture.Phase != shared.BuildCapturePhaseBuild && capture.Phase != shared.BuildCapturePhaseFix {
+		return nil, fmt.Errorf("only build and fix captures can be replayed, got %s", capture.Phase)
+	}
+
+	if capture.Error != "" && capture.Response == "" {
+		return nil, fmt.Errorf("capture has no response: %s", capture.Error)
+	}
+
+	args, err := getCapturedReplacementsArgs(capture.Response)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes types.ChangesWithLineNums
+	err = json.Unmarshal([]byte(args), &changes)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling captured changes: %v", err)
+	}
+
+	planFileResult, updated, allSucceeded, err := GetPlanResult(ctx, PlanResultParams{
+		FilePath:            capture.Path,
+		PreBuildState:       capture.PreBuildState,
+		ChangesWithLineNums: changes.Changes,
+		OverlapStrategy:     OverlapStrategyError,
+		CheckSyntax:         true,
+		IsFix:               capture.Phase == shared.BuildCapturePhaseFix,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReplayResult{
+		PlanFileResult: planFileResult,
+		Updated:        updated,
+		AllSucceeded:   allSucceeded,
+	}, nil
+}
+
+// getCapturedReplacementsArgs returns the listChangesWithLineNums arguments from a captured response. Non-streaming responses are captured as the full completion response, and streamed responses as the arguments themselves.
+func getCapturedReplacementsArgs(response string) (string, error) {
+	var resp openai.ChatCompletionResponse
+	err := json.Unmarshal([]byte(response), &resp)
+	if err != nil || len(resp.Choices) == 0 {
+		return response, nil
+	}
+
+	args := getReplacementsArgsFromResponse(resp)
+	if args == "" {
+		return "", fmt.Errorf("no %s function call or structured output found in captured response", prompts.ListReplacementsFn.Name)
+	}
+
+	return args, nil
+}
+
+// getReplacementsArgsFromResponse returns the arguments of a ListReplacements function call, or the message content for a response with structured outputs
+func getReplacementsArgsFromResponse(resp openai.ChatCompletionResponse) string {
+	for _, choice := range resp.Choices {
+		if len(choice.Message.ToolCalls) == 1 &&
+			choice.Message.ToolCalls[0].Function.Name == prompts.ListReplacementsFn.Name {
+			return choice.Message.ToolCalls[0].Function.Arguments
+		}
+	}
+
+	for _, choice := range resp.Choices {
+		if len(choice.Message.ToolCalls) == 0 && strings.HasPrefix(strings.TrimSpace(choice.Message.Content), "{") {
+			return choice.Message.Content
+		}
+	}
+
+	return ""
+}
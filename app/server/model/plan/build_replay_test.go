@@ -0,0 +1,74 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// Each example is a capture from 'plandex capture dump --json' (a single object) with a matching .expected file holding the updated file. To reproduce a build regression, add its capture here and run 'plandex-server replay --in CAPTURE --out EXPECTED' to write the expected file once it's been checked by hand.
+func TestReplayBuildCaptures(t *testing.T) {
+	paths, err := filepath.Glob("replay_test_examples/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paths) == 0 {
+		t.Fatal("No replay examples found")
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+
+		t.Run(name, func(t *testing.T) {
+			bytes, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var capture shared.BuildCapture
+			err = json.Unmarshal(bytes, &capture)
+			if err != nil {
+				t.Fatalf("Error unmarshalling capture: %v", err)
+			}
+
+			expected, err := os.ReadFile(strings.TrimSuffix(path, ".json") + ".expected")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := ReplayBuildCapture(context.Background(), &capture)
+			if err != nil {
+				t.Fatalf("Error replaying capture: %v", err)
+			}
+
+			if !res.AllSucceeded {
+				t.Error("Expected all replacements to succeed")
+			}
+
+			if res.PlanFileResult.WillCheckSyntax && !res.PlanFileResult.SyntaxValid {
+				t.Errorf("Expected valid syntax, got errors: %v", res.PlanFileResult.SyntaxErrors)
+			}
+
+			if res.Updated != string(expected) {
+				t.Errorf("Updated file doesn't match %s.expected. Got:\n%s", name, res.Updated)
+			}
+		})
+	}
+}
+
+func TestReplayBuildCaptureRejectsVerify(t *testing.T) {
+	_, err := ReplayBuildCapture(context.Background(), &shared.BuildCapture{
+		Phase:    shared.BuildCapturePhaseVerify,
+		Response: `{"reasoning": "", "isCorrect": true}`,
+	})
+
+	if err == nil {
+		t.Error("Expected an error replaying a verify capture")
+	}
+}
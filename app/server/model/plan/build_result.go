@@ -50,6 +50,8 @@ func GetPlanResult(ctx context.Context, params PlanResultParams) (*db.PlanFileRe
 	preBuildState := params.PreBuildState
 	streamedChangesWithLineNums := params.ChangesWithLineNums
 
+	originalLines := strings.Split(preBuildState, "\n")
+
 	preBuildState = shared.AddLineNums(preBuildState)
 
 	preBuildStateLines := strings.Split(preBuildState, "\n")
@@ -64,6 +66,7 @@ func GetPlanResult(ctx context.Context, params PlanResultParams) (*db.PlanFileRe
 	// log.Print("\n\n")
 
 	var replacements []*shared.Replacement
+	var noOpChanges []string
 
 	var highestEndLine int = 0
 
@@ -77,6 +80,10 @@ func GetPlanResult(ctx context.Context, params PlanResultParams) (*db.PlanFileRe
 		new := streamedChange.New
 
 		if streamedChange.Old.EntireFile {
+			if new == params.PreBuildState {
+				noOpChanges = append(noOpChanges, noOpChangeSummary(streamedChange))
+				continue
+			}
 			replacements = append(replacements, &shared.Replacement{
 				EntireFile:     true,
 				Old:            old,
@@ -145,10 +152,17 @@ func GetPlanResult(ctx context.Context, params PlanResultParams) (*db.PlanFileRe
 
 		// log.Printf("getPlanResult - old: %s\n", old)
 
+		if isNoOpChange(originalLines, startLine, endLine, new) {
+			log.Printf("getPlanResult - skipping change at lines %d-%d that's already in the file\n", startLine, endLine)
+			noOpChanges = append(noOpChanges, noOpChangeSummary(streamedChange))
+			continue
+		}
+
 		replacement := &shared.Replacement{
 			Old:            old,
 			New:            new,
 			StreamedChange: streamedChange,
+			Anchor:         shared.NewReplacementAnchor(preBuildStateLines, startLine, endLine),
 		}
 
 		replacements = append(replacements, replacement)
@@ -191,6 +205,7 @@ func GetPlanResult(ctx context.Context, params PlanResultParams) (*db.PlanFileRe
 		IsSyntaxFix:         params.IsSyntaxFix,
 		IsOtherFix:          params.IsOtherFix,
 		FixEpoch:            params.FixEpoch,
+		NoOpChanges:         noOpChanges,
 	}
 
 	if params.CheckSyntax {
@@ -264,6 +279,10 @@ func (fileState *activeBuildStreamFileState) onBuildResult(res types.ChangesWith
 
 	fileState.streamedChangesWithLineNums = sorted
 
+	if fileState.onAnchorMismatches(res) {
+		return
+	}
+
 	var overlapStrategy OverlapStrategy = OverlapStrategyError
 	if fileState.lineNumsNumRetry > 1 {
 		overlapStrategy = OverlapStrategySkip
@@ -286,7 +305,7 @@ func (fileState *activeBuildStreamFileState) onBuildResult(res types.ChangesWith
 
 	if err != nil {
 		log.Println("listenStream - Error getting plan result:", err)
-		fileState.lineNumsRetryOrError(fmt.Errorf("listenStream - error getting plan result for file '%s': %v", filePath, err))
+		fileState.lineNumsRetryOrError(newBuildError(shared.ApiErrorTypeApplyConflict, fmt.Errorf("listenStream - error getting plan result for file '%s': %v", filePath, err)))
 		return
 	}
 
@@ -299,10 +318,21 @@ func (fileState *activeBuildStreamFileState) onBuildResult(res types.ChangesWith
 		}
 
 		// no retry here as this should never happen
-		fileState.onBuildFileError(fmt.Errorf("listenStream - replacements failed for file '%s'", filePath))
+		fileState.onBuildFileError(newBuildError(shared.ApiErrorTypeApplyConflict, fmt.Errorf("listenStream - replacements failed for file '%s'", filePath)))
 		return
 	}
 
+	if fileState.isDraft {
+		err = fileState.draftValidationError(activePlan.Ctx, updatedFile)
+		if err != nil {
+			fileState.escalateDraft(err)
+			return
+		}
+		log.Printf("Using draft for file '%s'\n", filePath)
+		fileState.isDraft = false
+		fileState.draftAccepted = true
+	}
+
 	buildInfo := &shared.BuildInfo{
 		Path:      filePath,
 		NumTokens: 0,
@@ -323,10 +353,17 @@ func (fileState *activeBuildStreamFileState) onBuildResult(res types.ChangesWith
 }
 
 func (fileState *activeBuildStreamFileState) lineNumsRetryOrError(err error) {
+	// a draft isn't retried, since the builder model is the better fallback
+	if fileState.isDraft {
+		fileState.escalateDraft(err)
+		return
+	}
+
 	if fileState.lineNumsNumRetry < MaxBuildStreamErrorRetries {
 		fileState.lineNumsNumRetry++
 		fileState.activeBuild.WithLineNumsBuffer = ""
 		fileState.activeBuild.WithLineNumsBufferTokens = 0
+		fileState.numPreviewChanges = 0
 		log.Printf("Retrying line nums build file '%s' due to error: %v\n", fileState.filePath, err)
 
 		activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
@@ -0,0 +1,381 @@
+package plan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"plandex-server/db"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// corpus files over this size are skipped, since they're unlikely to be source files
+const maxCodeScanCorpusFileSize = 1024 * 1024
+
+// codeScanSource is a file or url that generated code is checked for verbatim copies of
+type codeScanSource struct {
+	name  string
+	lines []string
+}
+
+type codeScanCorpus struct {
+	sources []codeScanSource
+
+	mu sync.Mutex
+	// indexes are built on first use for each min block size, since orgs can set different sizes
+	indexes map[int]codeScanIndex
+}
+
+var codeScanCorpusPtr atomic.Pointer[codeScanCorpus]
+
+// LoadCodeScanCorpus reads the files in PLANDEX_CODE_SCAN_CORPUS_DIR, which generated code is checked against when an org's code scan policy matches the corpus. It's called again when the server's config is reloaded.
+func LoadCodeScanCorpus() error {
+	dir := os.Getenv("PLANDEX_CODE_SCAN_CORPUS_DIR")
+	if dir == "" {
+		codeScanCorpusPtr.Store(nil)
+		return nil
+	}
+
+	corpus := &codeScanCorpus{indexes: map[int]codeScanIndex{}}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxCodeScanCorpusFileSize {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// binary files can't match generated code
+		if bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content) {
+			return nil
+		}
+
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			name = path
+		}
+
+		corpus.sources = append(corpus.sources, newCodeScanSource(name, string(content)))
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("error reading code scan corpus dir %s: %v", dir, err)
+	}
+
+	log.Printf("Loaded %d code scan corpus files from %s\n", len(corpus.sources), dir)
+
+	codeScanCorpusPtr.Store(corpus)
+
+	return nil
+}
+
+// NumCodeScanCorpusFiles is the number of files in the server's scan corpus, or 0 if it doesn't have one
+func NumCodeScanCorpusFiles() int {
+	corpus := codeScanCorpusPtr.Load()
+	if corpus == nil {
+		return 0
+	}
+	return len(corpus.sources)
+}
+
+func (corpus *codeScanCorpus) index(minLines int) codeScanIndex {
+	corpus.mu.Lock()
+	defer corpus.mu.Unlock()
+
+	index, ok := corpus.indexes[minLines]
+	if !ok {
+		index = newCodeScanIndex(corpus.sources, minLines)
+		corpus.indexes[minLines] = index
+	}
+	return index
+}
+
+// scanFindings runs the org's code scan on a file result's content. New license headers and verbatim blocks are only flagged when they weren't already in the file, so editing a file that has them doesn't flag every change. A scan that can't run is logged rather than failing the build.
+func (fileState *activeBuildStreamFileState) scanFindings(updated string) []*shared.CodeScanFinding {
+	policy, err := db.GetOrgCodeScanPolicy(fileState.currentOrgId)
+	if err != nil {
+		log.Printf("Error getting code scan policy, skipping scan of %s: %v\n", fileState.filePath, err)
+		return nil
+	}
+
+	if !policy.Enabled {
+		return nil
+	}
+
+	original := ""
+	if fileState.currentPlanState != nil {
+		if context := fileState.currentPlanState.ContextsByPath[fileState.filePath]; context != nil {
+			original = context.Body
+		}
+	}
+
+	findings := scanLicenses(original, updated, policy.BannedLicenses)
+
+	if policy.MatchContext {
+		contexts := fileState.modelContext
+		if contexts == nil {
+			contexts, err = db.GetPlanContexts(fileState.currentOrgId, fileState.plan.Id, true)
+			if err != nil {
+				log.Printf("Error getting contexts, skipping context scan of %s: %v\n", fileState.filePath, err)
+			}
+		}
+
+		var sources []codeScanSource
+		for _, context := range contexts {
+			// the file's own context is the original, which is compared separately
+			if context.FilePath == fileState.filePath || context.ContextType == shared.ContextImageType {
+				continue
+			}
+			name := context.Name
+			if context.FilePath != "" {
+				name = context.FilePath
+			} else if context.Url != "" {
+				name = context.Url
+			}
+			sources = append(sources, newCodeScanSource(name, context.Body))
+		}
+
+		index := newCodeScanIndex(sources, policy.MinVerbatimLines)
+		findings = append(findings, scanVerbatim(original, updated, index, policy.MinVerbatimLines, shared.CodeScanRuleVerbatimContext)...)
+	}
+
+	if policy.MatchCorpus {
+		corpus := codeScanCorpusPtr.Load()
+		if corpus != nil {
+			index := corpus.index(policy.MinVerbatimLines)
+			findings = append(findings, scanVerbatim(original, updated, index, policy.MinVerbatimLines, shared.CodeScanRuleVerbatimCorpus)...)
+		}
+	}
+
+	if len(findings) > 0 {
+		log.Printf("Code scan flagged %d findings in %s\n", len(findings), fileState.filePath)
+	}
+
+	return findings
+}
+
+// scanLicenses flags lines that mention a license and name a banned one, unless the line was already in the original file
+func scanLicenses(original, updated string, banned []string) []*shared.CodeScanFinding {
+	if len(banned) == 0 {
+		return nil
+	}
+
+	originalLines := map[string]bool{}
+	for _, line := range strings.Split(original, "\n") {
+		originalLines[normalizeScanLine(line)] = true
+	}
+
+	var findings []*shared.CodeScanFinding
+
+	for i, line := range strings.Split(updated, "\n") {
+		norm := normalizeScanLine(line)
+		if originalLines[norm] {
+			continue
+		}
+
+		lower := strings.ToLower(norm)
+		if !strings.Contains(lower, "license") && !strings.Contains(lower, "licence") {
+			continue
+		}
+
+		for _, license := range banned {
+			if containsLicenseName(lower, strings.ToLower(strings.TrimSpace(license))) {
+				findings = append(findings, &shared.CodeScanFinding{
+					Rule:      shared.CodeScanRuleLicense,
+					StartLine: i + 1,
+					EndLine:   i + 1,
+					Match:     license,
+				})
+				break
+			}
+		}
+	}
+
+	return findings
+}
+
+// containsLicenseName matches a license name as a whole word, so 'gpl' doesn't match 'lgpl', but does match versions like 'gpl-3.0' and 'gplv3'
+func containsLicenseName(line, name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for start := 0; start < len(line); {
+		idx := strings.Index(line[start:], name)
+		if idx < 0 {
+			return false
+		}
+		idx += start
+		end := idx + len(name)
+
+		before, _ := utf8.DecodeLastRuneInString(line[:idx])
+		after, size := utf8.DecodeRuneInString(line[end:])
+
+		wordBefore := idx > 0 && isWordRune(before)
+		wordAfter := end < len(line) && isWordRune(after)
+		if wordAfter && after == 'v' {
+			next, _ := utf8.DecodeRuneInString(line[end+size:])
+			wordAfter = !unicode.IsDigit(next)
+		}
+
+		if !wordBefore && !wordAfter {
+			return true
+		}
+
+		start = idx + 1
+	}
+
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// codeScanIndex maps the hash of each block of significant lines in a set of sources to the first source it appears in
+type codeScanIndex map[[sha256.Size]byte]string
+
+func newCodeScanSource(name, content string) codeScanSource {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		norm := normalizeScanLine(line)
+		if isSignificantScanLine(norm) {
+			lines = append(lines, norm)
+		}
+	}
+	return codeScanSource{name: name, lines: lines}
+}
+
+func newCodeScanIndex(sources []codeScanSource, minLines int) codeScanIndex {
+	index := codeScanIndex{}
+	for _, source := range sources {
+		for i := 0; i+minLines <= len(source.lines); i++ {
+			key := hashScanBlock(source.lines[i : i+minLines])
+			if _, ok := index[key]; !ok {
+				index[key] = source.name
+			}
+		}
+	}
+	return index
+}
+
+// scanVerbatim flags blocks of at least minLines significant lines that match a source in the index. Only lines that weren't in the original file count toward a block, and overlapping matches from the same source are merged into one finding.
+func scanVerbatim(original, updated string, index codeScanIndex, minLines int, rule shared.CodeScanRule) []*shared.CodeScanFinding {
+	if len(index) == 0 {
+		return nil
+	}
+
+	originalLines := map[string]bool{}
+	for _, line := range strings.Split(original, "\n") {
+		originalLines[normalizeScanLine(line)] = true
+	}
+
+	type scanLine struct {
+		num  int
+		norm string
+	}
+
+	// runs of consecutive significant lines that are new to the file
+	var runs [][]scanLine
+	var run []scanLine
+	for i, line := range strings.Split(updated, "\n") {
+		norm := normalizeScanLine(line)
+		if !isSignificantScanLine(norm) {
+			continue
+		}
+		if originalLines[norm] {
+			if len(run) > 0 {
+				runs = append(runs, run)
+			}
+			run = nil
+			continue
+		}
+		run = append(run, scanLine{num: i + 1, norm: norm})
+	}
+	if len(run) > 0 {
+		runs = append(runs, run)
+	}
+
+	var findings []*shared.CodeScanFinding
+
+	for _, run := range runs {
+		var current *shared.CodeScanFinding
+		currentEnd := -1
+
+		for i := 0; i+minLines <= len(run); i++ {
+			block := make([]string, minLines)
+			for j := range block {
+				block[j] = run[i+j].norm
+			}
+
+			source, ok := index[hashScanBlock(block)]
+			if !ok {
+				continue
+			}
+
+			end := i + minLines - 1
+			if current != nil && current.Match == source && i <= currentEnd+1 {
+				current.EndLine = run[end].num
+				currentEnd = end
+				continue
+			}
+
+			current = &shared.CodeScanFinding{
+				Rule:      rule,
+				StartLine: run[i].num,
+				EndLine:   run[end].num,
+				Match:     source,
+			}
+			currentEnd = end
+			findings = append(findings, current)
+		}
+	}
+
+	return findings
+}
+
+func hashScanBlock(lines []string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(strings.Join(lines, "\n")))
+}
+
+// normalizeScanLine collapses whitespace so reindented copies still match
+func normalizeScanLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// isSignificantScanLine skips blank lines and lines that are only brackets and punctuation, which match everywhere
+func isSignificantScanLine(norm string) bool {
+	n := 0
+	for _, r := range norm {
+		if isWordRune(r) {
+			n++
+			if n >= 3 {
+				return true
+			}
+		}
+	}
+	return false
+}
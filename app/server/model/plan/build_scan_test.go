@@ -0,0 +1,119 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestScanLicenses(t *testing.T) {
+	banned := []string{"GPL", "GNU General Public License"}
+
+	tests := []struct {
+		name     string
+		original string
+		updated  string
+		want     []string
+	}{
+		{
+			name:    "spdx header",
+			updated: "// SPDX-License-Identifier: GPL-3.0-only\npackage main\n",
+			want:    []string{"GPL"},
+		},
+		{
+			name:    "license name",
+			updated: "/*\n * This program is free software under the terms of the\n * GNU General Public License, version 3.\n */\n",
+			want:    []string{"GNU General Public License"},
+		},
+		{
+			name:    "versioned name",
+			updated: "// Licensed under GPLv2\n",
+			want:    []string{"GPL"},
+		},
+		{
+			name:    "other licenses containing the name don't match",
+			updated: "// SPDX-License-Identifier: LGPL-2.1\n// License: AGPL\n",
+		},
+		{
+			name:    "lines that don't mention a license are skipped",
+			updated: "// unlike GPL code, this is fine\n",
+		},
+		{
+			name:     "headers already in the file are skipped",
+			original: "// SPDX-License-Identifier: GPL-3.0-only\npackage main\n",
+			updated:  "// SPDX-License-Identifier: GPL-3.0-only\npackage main\n\nfunc main() {}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := scanLicenses(tt.original, tt.updated, banned)
+
+			var got []string
+			for _, f := range findings {
+				if f.Rule != shared.CodeScanRuleLicense {
+					t.Errorf("unexpected rule %s", f.Rule)
+				}
+				got = append(got, f.Match)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got matches %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func numberedLines(prefix string, n int) string {
+	var lines []string
+	for i := 0; i < n; i++ {
+		lines = append(lines, fmt.Sprintf("\tresult = append(result, %s(%d))", prefix, i))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestScanVerbatim(t *testing.T) {
+	copied := numberedLines("copied", 8)
+	index := newCodeScanIndex([]codeScanSource{
+		newCodeScanSource("vendor/lib.go", "func lib() {\n"+copied+"\n}\n"),
+	}, 5)
+
+	t.Run("copied block is flagged", func(t *testing.T) {
+		// reindented, with a blank line and a lone bracket in between that don't count
+		block := strings.ReplaceAll(copied, "\t", "    ")
+		lines := strings.Split(block, "\n")
+		updated := "package main\n\nfunc main() {\n" + strings.Join(lines[:4], "\n") + "\n\n}\n" + strings.Join(lines[4:], "\n") + "\n}\n"
+
+		findings := scanVerbatim("", updated, index, 5, shared.CodeScanRuleVerbatimContext)
+
+		if len(findings) != 1 {
+			t.Fatalf("expected 1 finding, got %d", len(findings))
+		}
+		f := findings[0]
+		if f.Match != "vendor/lib.go" || f.Rule != shared.CodeScanRuleVerbatimContext {
+			t.Errorf("unexpected finding %+v", f)
+		}
+		if f.StartLine != 4 || f.EndLine != 13 {
+			t.Errorf("got lines %d-%d, want 4-13", f.StartLine, f.EndLine)
+		}
+	})
+
+	t.Run("short blocks aren't flagged", func(t *testing.T) {
+		lines := strings.Split(copied, "\n")
+		updated := strings.Join(lines[:4], "\n") + "\n" + numberedLines("other", 4)
+
+		findings := scanVerbatim("", updated, index, 5, shared.CodeScanRuleVerbatimContext)
+		if len(findings) != 0 {
+			t.Errorf("expected no findings, got %d", len(findings))
+		}
+	})
+
+	t.Run("lines already in the file are skipped", func(t *testing.T) {
+		findings := scanVerbatim(copied, copied+"\n"+numberedLines("new", 3), index, 5, shared.CodeScanRuleVerbatimContext)
+		if len(findings) != 0 {
+			t.Errorf("expected no findings, got %d", len(findings))
+		}
+	})
+}
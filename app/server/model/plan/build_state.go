@@ -1,8 +1,11 @@
 package plan
 
 import (
+	"context"
 	"plandex-server/db"
+	"plandex-server/model"
 	"plandex-server/types"
+	"time"
 
 	"github.com/plandex/plandex/shared"
 	"github.com/sashabaranov/go-openai"
@@ -20,6 +23,7 @@ type activeBuildStreamState struct {
 	currentUserId string
 	plan          *db.Plan
 	branch        string
+	priority      model.CallPriority
 	settings      *shared.PlanSettings
 	modelContext  []*db.Context
 	convo         []*db.ConvoMessage
@@ -34,9 +38,17 @@ type activeBuildStreamFileState struct {
 	activeBuild        *types.ActiveBuild
 	preBuildState      string
 	lineNumsNumRetry   int
+	lineNumsCorrected  bool
+	anchorsCorrected   bool
+	isWholeFileBuild   bool
+	numPreviewChanges  int
 	verifyFileNumRetry int
 	fixFileNumRetry    int
 
+	attemptCtx     context.Context
+	cancelAttempt  context.CancelFunc
+	attemptTimeout time.Duration
+
 	syntaxNumRetry int
 	syntaxNumEpoch int
 
@@ -50,4 +62,24 @@ type activeBuildStreamFileState struct {
 	syntaxErrors       []string
 
 	isNewFile bool
+
+	// set when a build finishes without a result on purpose, like a lockfile build or test scaffolding that didn't write any tests, so there's nothing to verify
+	skippedBuild bool
+
+	// hash of the request the file's latest result is built from, for its provenance
+	promptHash string
+
+	// warnings about vulnerable dependency versions in the proposed changes, checked once per file build
+	advisoriesChecked bool
+	advisoriesPrompt  string
+
+	// set when the build removes declarations a removal-only file block names, or removes the file, without a builder model call, so there's nothing to verify
+	isDeterministic bool
+
+	// isDraft is set while the draft-builder is building the file with the speculative build strategy, and draftAccepted once its draft is used
+	isDraft       bool
+	draftAccepted bool
+
+	// builder tokens already recorded for 'plandex stats', since a build that's fixed after it finishes is recorded again
+	recordedOutputTokens int
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"plandex-server/model"
@@ -12,13 +13,13 @@ import (
 	"time"
 
 	"github.com/plandex/plandex/shared"
-	"github.com/sashabaranov/go-openai"
 )
 
-func (fileState *activeBuildStreamFileState) listenStreamChangesWithLineNums(stream *openai.ChatCompletionStream) {
+func (fileState *activeBuildStreamFileState) listenStreamChangesWithLineNums(stream model.ChatCompletionStream) {
 	filePath := fileState.filePath
 	planId := fileState.plan.Id
 	branch := fileState.branch
+	hasStructuredOutputs := fileState.builderConfig().BaseModelConfig.HasStructuredOutputs
 
 	activePlan := GetActivePlan(planId, branch)
 
@@ -54,6 +55,11 @@ func (fileState *activeBuildStreamFileState) listenStreamChangesWithLineNums(str
 			} else {
 				log.Printf("listenStream - File %s: Error receiving stream chunk: %v\n", filePath, err)
 
+				if fileState.attemptTimedOut() {
+					fileState.lineNumsRetryOrError(fileState.attemptErr(err))
+					return
+				}
+
 				if err == context.Canceled {
 					log.Printf("listenStream - File %s: Stream canceled\n", filePath)
 					// log.Println("current buffer:")
@@ -61,6 +67,12 @@ func (fileState *activeBuildStreamFileState) listenStreamChangesWithLineNums(str
 					return
 				}
 
+				// a stream that ends before the JSON is complete was cut off
+				if err == io.EOF && strings.TrimSpace(fileState.activeBuild.WithLineNumsBuffer) != "" {
+					fileState.onMalformedReplacements(fileState.activeBuild.WithLineNumsBuffer, fmt.Errorf("response ended before the JSON was complete"))
+					return
+				}
+
 				fileState.lineNumsRetryOrError(fmt.Errorf("listenStream - stream error for file '%s': %v", filePath, err))
 				return
 			}
@@ -74,21 +86,37 @@ func (fileState *activeBuildStreamFileState) listenStreamChangesWithLineNums(str
 			var content string
 			delta := response.Choices[0].Delta
 
-			if len(delta.ToolCalls) > 0 {
+			// with structured outputs, the replacements are streamed as content instead of function call arguments, and chunks before the finish reason may be empty
+			hasChunk := len(delta.ToolCalls) > 0
+			if hasChunk {
 				content = delta.ToolCalls[0].Function.Arguments
+			} else if hasStructuredOutputs && (delta.Content != "" || choice.FinishReason == "") {
+				content = delta.Content
+				hasChunk = true
+			}
+
+			if hasChunk {
 
 				trimmed := strings.TrimSpace(content)
 				if trimmed == "{%invalidjson%}" || trimmed == "``(no output)``````" {
 					log.Println("File", filePath+":", "%invalidjson%} token in streamed chunk")
-					fileState.lineNumsRetryOrError(fmt.Errorf("invalid JSON in streamed chunk for file '%s'", filePath))
+					fileState.onMalformedReplacements(fileState.activeBuild.WithLineNumsBuffer, fmt.Errorf("invalid JSON in streamed chunk for file '%s'", filePath))
 
 					return
 				}
 
+				if fileState.activeBuild.WithLineNumsBufferTokens == 0 {
+					fileState.activeBuild.StreamStartedAt = time.Now()
+				}
+
+				tokensPerSecond, eta := estimateBuildProgress(fileState.activeBuild.WithLineNumsBufferTokens+1, fileState.activeBuild.FileContentTokens, time.Since(fileState.activeBuild.StreamStartedAt))
+
 				buildInfo := &shared.BuildInfo{
-					Path:      filePath,
-					NumTokens: 1,
-					Finished:  false,
+					Path:            filePath,
+					NumTokens:       1,
+					Finished:        false,
+					TokensPerSecond: tokensPerSecond,
+					EtaSeconds:      int(eta.Seconds()),
 				}
 
 				// log.Printf("%s: %s", filePath, content)
@@ -125,7 +153,10 @@ func (fileState *activeBuildStreamFileState) listenStreamChangesWithLineNums(str
 
 				fileState.onBuildResult(streamed)
 				return
-			} else if len(delta.ToolCalls) == 0 {
+			} else if hasChunk && strings.Contains(content, "}") {
+				// a change can only be complete at the end of an object
+				fileState.streamBuildPreview()
+			} else if !hasChunk {
 				log.Println("listenStream - Stream chunk missing function call.")
 				// log.Println(spew.Sdump(response))
 				// log.Println(spew.Sdump(fileState))
@@ -133,7 +164,12 @@ func (fileState *activeBuildStreamFileState) listenStreamChangesWithLineNums(str
 				// log.Println("Current buffer:")
 				// log.Println(fileState.activeBuild.WithLineNumsBuffer)
 
-				fileState.lineNumsRetryOrError(fmt.Errorf("listenStream - stream chunk missing function call. Reason: %s, File: %s", choice.FinishReason, filePath))
+				if choice.FinishReason != "" && strings.TrimSpace(fileState.activeBuild.WithLineNumsBuffer) != "" {
+					fileState.onMalformedReplacements(fileState.activeBuild.WithLineNumsBuffer, fmt.Errorf("response finished (%s) with invalid JSON: %v", choice.FinishReason, err))
+					return
+				}
+
+				fileState.lineNumsRetryOrError(newBuildError(shared.ApiErrorTypeInvalidToolOutput, fmt.Errorf("listenStream - stream chunk missing function call. Reason: %s, File: %s", choice.FinishReason, filePath)))
 				return
 			}
 		}
@@ -0,0 +1,267 @@
+package plan
+
+import (
+	"context"
+	"log"
+	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+const fileSummaryTimeout = 60 * time.Second
+
+// how long a queued file change waits for others to be summarized with it, since builds of a reply's files usually finish close together
+const fileSummaryBatchDelay = 3 * time.Second
+
+// summarizing several files in one request sends the instructions once, and keeps the number of background requests down
+const maxFileSummaryBatchSize = 8
+
+// maxFileSummaryDiffChars keeps each file's part of a batch small for large new files or rewrites, where the start of the diff is enough to describe the changes
+const maxFileSummaryDiffChars = 8000
+
+type fileSummaryJob struct {
+	resultId      string
+	path          string
+	preBuildState string
+	updated       string
+}
+
+// fileSummaryQueue holds a plan branch's file changes waiting to be summarized. Summaries are generated in the background by the summarizer role, in batches, so they never hold up a build or the plan's stream. They're stored apart from results and show up in the plan's state once they're ready.
+type fileSummaryQueue struct {
+	clients       map[string]*openai.Client
+	config        shared.ModelRoleConfig
+	ctx           context.Context
+	currentOrgId  string
+	currentUserId string
+	planId        string
+	branch        string
+	jobs          []*fileSummaryJob
+}
+
+var fileSummaryQueues = map[string]*fileSummaryQueue{}
+var fileSummaryQueuesMu sync.Mutex
+
+// queueFileChangesSummary queues a stored result's changes to be summarized in the background
+func (fileState *activeBuildStreamFileState) queueFileChangesSummary(resultId, updated string) {
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Println("queueFileChangesSummary - Active plan not found")
+		return
+	}
+
+	job := &fileSummaryJob{
+		resultId:      resultId,
+		path:          fileState.filePath,
+		preBuildState: fileState.preBuildState,
+		updated:       updated,
+	}
+
+	key := fileState.plan.Id + "|" + fileState.branch
+
+	fileSummaryQueuesMu.Lock()
+	defer fileSummaryQueuesMu.Unlock()
+
+	if q, ok := fileSummaryQueues[key]; ok {
+		q.jobs = append(q.jobs, job)
+		return
+	}
+
+	q := &fileSummaryQueue{
+		clients: fileState.clients,
+		config:  fileState.settings.ModelPack.PlanSummary,
+		// summaries outlive the build, like conversation summaries, and only yield to requests the user is waiting on
+		ctx:           model.WithCallPriority(activePlan.SummaryCtx, model.CallPriorityBackground),
+		currentOrgId:  fileState.currentOrgId,
+		currentUserId: fileState.currentUserId,
+		planId:        fileState.plan.Id,
+		branch:        fileState.branch,
+		jobs:          []*fileSummaryJob{job},
+	}
+	fileSummaryQueues[key] = q
+
+	go q.run(key)
+}
+
+// run summarizes batches of queued file changes until the queue is empty
+func (q *fileSummaryQueue) run(key string) {
+	for {
+		select {
+		case <-q.ctx.Done():
+			fileSummaryQueuesMu.Lock()
+			delete(fileSummaryQueues, key)
+			fileSummaryQueuesMu.Unlock()
+			return
+		case <-time.After(fileSummaryBatchDelay):
+		}
+
+		fileSummaryQueuesMu.Lock()
+		batch := q.takeBatch()
+		if len(batch) == 0 {
+			delete(fileSummaryQueues, key)
+			fileSummaryQueuesMu.Unlock()
+			return
+		}
+		fileSummaryQueuesMu.Unlock()
+
+		q.summarizeBatch(batch)
+	}
+}
+
+// takeBatch removes the next batch from the queue. A file with more than one queued change only has its first change in a batch, so each summary in a reply is about a single change. Must be called with fileSummaryQueuesMu held.
+func (q *fileSummaryQueue) takeBatch() []*fileSummaryJob {
+	var batch, rest []*fileSummaryJob
+	inBatch := map[string]bool{}
+
+	for _, job := range q.jobs {
+		if len(batch) >= maxFileSummaryBatchSize || inBatch[job.path] {
+			rest = append(rest, job)
+			continue
+		}
+		inBatch[job.path] = true
+		batch = append(batch, job)
+	}
+
+	q.jobs = rest
+	return batch
+}
+
+// summarizeBatch writes and stores a one-paragraph summary of each file's changes. Summaries are optional, so errors are only logged.
+func (q *fileSummaryQueue) summarizeBatch(batch []*fileSummaryJob) {
+	var files []prompts.FileChangesDiff
+	resultIdsByPath := map[string]string{}
+
+	for _, job := range batch {
+		diff, err := db.GetDiffsForBuild(job.preBuildState, job.updated)
+		if err != nil {
+			log.Printf("Error getting diffs to summarize file '%s': %v\n", job.path, err)
+			continue
+		}
+		if strings.TrimSpace(diff) == "" {
+			continue
+		}
+		if len(diff) > maxFileSummaryDiffChars {
+			diff = strings.ToValidUTF8(diff[:maxFileSummaryDiffChars], "") + "\n…"
+		}
+
+		files = append(files, prompts.FileChangesDiff{Path: job.path, Diff: diff})
+		resultIdsByPath[job.path] = job.resultId
+	}
+
+	if len(files) == 0 {
+		return
+	}
+
+	client := q.clients[q.config.BaseModelConfig.ApiKeyEnvVar]
+	if client == nil {
+		log.Printf("No client for the summarizer model's api key %s, skipping file change summaries\n", q.config.BaseModelConfig.ApiKeyEnvVar)
+		return
+	}
+
+	modelReq := openai.ChatCompletionRequest{
+		Model: q.config.BaseModelConfig.ModelName,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: prompts.SysFileChangesSummary,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompts.GetFileChangesSummaryPrompt(files),
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(q.ctx, fileSummaryTimeout)
+	defer cancel()
+	reqCtx := model.ApplyRoleConfig(ctx, &modelReq, q.config)
+
+	log.Printf("Summarizing changes to %d file(s) for plan %s\n", len(files), q.planId)
+
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+	if err != nil {
+		log.Printf("Error summarizing file changes for plan %s: %v\n", q.planId, err)
+		return
+	}
+
+	recordModelUsage(q.currentOrgId, q.currentUserId, q.planId, q.branch, shared.ModelRolePlanSummary, q.config, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, model.ReportedUsageFromContext(reqCtx))
+
+	if len(resp.Choices) == 0 {
+		log.Printf("No choices in file changes summary response for plan %s\n", q.planId)
+		return
+	}
+
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+
+	summaries := parseFileChangeSummaries(resp.Choices[0].Message.Content, paths)
+
+	for path, summary := range summaries {
+		err := db.StoreFileChangeSummary(&db.FileChangeSummary{
+			OrgId:    q.currentOrgId,
+			PlanId:   q.planId,
+			ResultId: resultIdsByPath[path],
+			Summary:  summary,
+		})
+		if err != nil {
+			log.Printf("Error storing summary of changes to file '%s': %v\n", path, err)
+		}
+	}
+
+	if len(summaries) < len(files) {
+		log.Printf("File changes summary for plan %s only covered %d of %d file(s)\n", q.planId, len(summaries), len(files))
+	}
+}
+
+// parseFileChangeSummaries splits a reply into each file's summary, by the '### path' heading before each one. Headings for paths that weren't asked about are ignored. A single file's summary is accepted without a heading, since models sometimes leave it off.
+func parseFileChangeSummaries(reply string, paths []string) map[string]string {
+	expected := map[string]bool{}
+	for _, path := range paths {
+		expected[path] = true
+	}
+
+	res := map[string]string{}
+
+	var currentPath string
+	var current []string
+	sawHeading := false
+
+	flush := func() {
+		summary := strings.TrimSpace(strings.Join(current, "\n"))
+		if currentPath != "" && summary != "" {
+			res[currentPath] = summary
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(reply, "\n") {
+		if heading, ok := strings.CutPrefix(strings.TrimSpace(line), "### "); ok {
+			flush()
+			sawHeading = true
+			path := strings.Trim(strings.TrimSpace(heading), "`")
+			if expected[path] {
+				currentPath = path
+			} else {
+				currentPath = ""
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	if !sawHeading && len(paths) == 1 {
+		if summary := strings.TrimSpace(reply); summary != "" {
+			res[paths[0]] = summary
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,82 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFileChangeSummaries(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		paths []string
+		want  map[string]string
+	}{
+		{
+			name:  "one summary per heading",
+			reply: "### src/a.go\nAdds retries.\n\n### `src/b.go`\nRenames the config\nloader.\n",
+			paths: []string{"src/a.go", "src/b.go"},
+			want:  map[string]string{"src/a.go": "Adds retries.", "src/b.go": "Renames the config\nloader."},
+		},
+		{
+			name:  "headings for other paths are ignored",
+			reply: "### src/a.go\nAdds retries.\n### src/other.go\nSomething else.",
+			paths: []string{"src/a.go"},
+			want:  map[string]string{"src/a.go": "Adds retries."},
+		},
+		{
+			name:  "missing summaries are left out",
+			reply: "### src/a.go\n\n### src/b.go\nRenames the config loader.",
+			paths: []string{"src/a.go", "src/b.go"},
+			want:  map[string]string{"src/b.go": "Renames the config loader."},
+		},
+		{
+			name:  "a single file's summary can leave off the heading",
+			reply: "Adds retries.\n",
+			paths: []string{"src/a.go"},
+			want:  map[string]string{"src/a.go": "Adds retries."},
+		},
+		{
+			name:  "several files' summaries need headings",
+			reply: "Adds retries.",
+			paths: []string{"src/a.go", "src/b.go"},
+			want:  map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseFileChangeSummaries(tt.reply, tt.paths)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileSummaryQueueTakeBatch(t *testing.T) {
+	q := &fileSummaryQueue{}
+	for _, path := range []string{"a", "b", "a", "c", "d", "e", "f", "g", "h", "i"} {
+		q.jobs = append(q.jobs, &fileSummaryJob{path: path})
+	}
+
+	var paths []string
+	for _, job := range q.takeBatch() {
+		paths = append(paths, job.path)
+	}
+
+	want := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got batch %v, want %v", paths, want)
+	}
+
+	paths = nil
+	for _, job := range q.jobs {
+		paths = append(paths, job.path)
+	}
+
+	want = []string{"a", "i"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %v left in the queue, want %v", paths, want)
+	}
+}
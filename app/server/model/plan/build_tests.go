@@ -0,0 +1,162 @@
+package plan
+
+import (
+	"fmt"
+	"log"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// pickTestPath returns where a file's tests go: the first conventional test path that already exists, or the most common convention for the language if none do. It's empty for files without a test convention.
+func pickTestPath(path string, exists func(path string) bool) string {
+	candidates := shared.TestPathCandidates(path)
+	if len(candidates) == 0 {
+		return ""
+	}
+	for _, candidate := range candidates {
+		if exists(candidate) {
+			return candidate
+		}
+	}
+	return candidates[0]
+}
+
+// pendingFileState returns the current content of a file, from the plan's pending changes or from context. Files the pending changes remove aren't found.
+func (fileState *activeBuildStreamFileState) pendingFileState(activePlan *types.ActivePlan, path string) (string, bool) {
+	if fileState.currentPlanState != nil {
+		if fileState.currentPlanState.CurrentPlanFiles.Removed[path] {
+			return "", false
+		}
+		if content, ok := fileState.currentPlanState.CurrentPlanFiles.Files[path]; ok {
+			return content, true
+		}
+	}
+	if context := activePlan.ContextsByPath[path]; context != nil {
+		return context.Body, true
+	}
+	return "", false
+}
+
+// queueTestScaffold queues a build of the file's tests after the file is built, when the plan has test scaffolding on. Test files, files without a test convention, and files whose tests the same reply already changes are skipped.
+func (fileState *activeBuildStreamFileState) queueTestScaffold() {
+	filePath := fileState.filePath
+	activeBuild := fileState.activeBuild
+
+	if fileState.settings == nil || !fileState.settings.ScaffoldTests || !activeBuild.IsReplyBuild() || activeBuild.RemoveFile {
+		return
+	}
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Println("queueTestScaffold - Active plan not found")
+		return
+	}
+
+	testPath := pickTestPath(filePath, func(path string) bool {
+		_, ok := fileState.pendingFileState(activePlan, path)
+		return ok
+	})
+	if testPath == "" {
+		return
+	}
+
+	for _, build := range activePlan.BuildQueuesByPath[testPath] {
+		if build.IsReplyBuild() && build.ReplyId == activeBuild.ReplyId {
+			log.Printf("Reply already changes %s, skipping test scaffolding for %s\n", testPath, filePath)
+			return
+		}
+		if build.TestSourcePath == filePath && !build.BuildFinished() {
+			log.Printf("Test scaffolding for %s is already queued\n", filePath)
+			return
+		}
+	}
+
+	log.Printf("Queueing test scaffolding for %s in %s\n", filePath, testPath)
+
+	fileState.queueBuilds([]*types.ActiveBuild{
+		{
+			ReplyId:        activeBuild.ReplyId,
+			Path:           testPath,
+			TestSourcePath: filePath,
+		},
+	})
+}
+
+// scaffoldTests asks the builder for the tests of the file the build was queued for, then builds them into the test file like a reply's proposed changes. Tests are optional, so if the builder can't write them, the build is skipped rather than failed.
+func (fileState *activeBuildStreamFileState) scaffoldTests() {
+	filePath := fileState.filePath
+	activeBuild := fileState.activeBuild
+	sourcePath := activeBuild.TestSourcePath
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Printf("Active plan not found for plan ID %s and branch %s\n", fileState.plan.Id, fileState.branch)
+		return
+	}
+
+	skip := func(reason string) {
+		log.Printf("Skipping test scaffolding for %s: %s\n", sourcePath, reason)
+		fileState.finishSkippedBuild()
+	}
+
+	source, ok := fileState.currentPlanState.CurrentPlanFiles.Files[sourcePath]
+	if !ok || strings.TrimSpace(source) == "" {
+		skip("no pending changes to the file")
+		return
+	}
+
+	existingTests, _ := fileState.pendingFileState(activePlan, filePath)
+
+	log.Printf("Scaffolding tests for %s in %s\n", sourcePath, filePath)
+
+	config := fileState.builderConfig()
+	client := fileState.clients[config.BaseModelConfig.ApiKeyEnvVar]
+
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: prompts.GetTestScaffoldSysPrompt(sourcePath, source, filePath, existingTests),
+			},
+		},
+	}
+
+	attemptCtx := fileState.startBuildAttempt(activePlan.Ctx)
+	reqCtx := model.ApplyRoleConfig(attemptCtx, &modelReq, config)
+
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+	if err != nil {
+		skip(fileState.attemptErr(fmt.Errorf("error getting tests from model: %v", err)).Error())
+		return
+	}
+
+	recordModelUsage(fileState.currentOrgId, fileState.currentUserId, fileState.plan.Id, fileState.branch, shared.ModelRoleBuilder, config, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, model.ReportedUsageFromContext(reqCtx))
+
+	if len(resp.Choices) == 0 {
+		skip("no choices in model response")
+		return
+	}
+
+	tests, ok := getCodeBlockContent(resp.Choices[0].Message.Content)
+	if !ok || strings.TrimSpace(tests) == "" {
+		skip("no code block in model response")
+		return
+	}
+
+	numTokens, err := shared.GetNumTokens(tests)
+	if err != nil {
+		log.Printf("Error getting num tokens for scaffolded tests: %v\n", err)
+	}
+
+	activeBuild.FileDescription = fmt.Sprintf("Add or update the tests in %s for the changes to %s.", filePath, sourcePath)
+	activeBuild.FileContent = tests
+	activeBuild.FileContentTokens = numTokens
+
+	fileState.buildFile()
+}
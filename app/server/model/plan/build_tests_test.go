@@ -0,0 +1,41 @@
+package plan
+
+import "testing"
+
+func TestPickTestPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		existing []string
+		want     string
+	}{
+		{path: "lib/term.go", want: "lib/term_test.go"},
+		{path: "lib/term_test.go", want: ""},
+		{path: "src/util/format.ts", want: "src/util/format.test.ts"},
+		{path: "src/util/format.ts", existing: []string{"src/util/__tests__/format.test.ts"}, want: "src/util/__tests__/format.test.ts"},
+		{path: "src/util/format.spec.ts", want: ""},
+		{path: "types/index.d.ts", want: ""},
+		{path: "app/models.py", want: "app/test_models.py"},
+		{path: "app/models.py", existing: []string{"tests/test_models.py"}, want: "tests/test_models.py"},
+		{path: "app/__init__.py", want: ""},
+		{path: "tests/helpers.py", want: ""},
+		{path: "app/models/user.rb", want: "spec/models/user_spec.rb"},
+		{path: "lib/parser.rb", existing: []string{"test/parser_test.rb"}, want: "test/parser_test.rb"},
+		{path: "core/src/main/java/com/example/Parser.java", want: "core/src/test/java/com/example/ParserTest.java"},
+		{path: "src/Http/Client.php", want: "tests/Http/ClientTest.php"},
+		{path: "lib/app/worker.ex", want: "test/app/worker_test.exs"},
+		{path: "src/main.rs", want: ""},
+		{path: "package.json", want: ""},
+	}
+
+	for _, tt := range tests {
+		existing := map[string]bool{}
+		for _, path := range tt.existing {
+			existing[path] = true
+		}
+
+		got := pickTestPath(tt.path, func(path string) bool { return existing[path] })
+		if got != tt.want {
+			t.Errorf("pickTestPath(%q) with %v = %q, want %q", tt.path, tt.existing, got, tt.want)
+		}
+	}
+}
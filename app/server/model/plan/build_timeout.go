@@ -0,0 +1,79 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const defaultBuildFileTimeout = 5 * time.Minute
+
+var buildFileTimeoutNs atomic.Int64
+
+func init() {
+	LoadBuildFileTimeout()
+}
+
+// LoadBuildFileTimeout applies PLANDEX_BUILD_FILE_TIMEOUT. It's called again when the server's config is reloaded; build attempts already in progress keep the timeout they started with.
+func LoadBuildFileTimeout() {
+	buildFileTimeoutNs.Store(int64(getBuildFileTimeout()))
+}
+
+func getBuildFileTimeoutSetting() time.Duration {
+	return time.Duration(buildFileTimeoutNs.Load())
+}
+
+// getBuildFileTimeout reads PLANDEX_BUILD_FILE_TIMEOUT, a duration like '3m' (default 5m) that limits each attempt at building a file. Set it to '0' to disable the timeout.
+func getBuildFileTimeout() time.Duration {
+	s := os.Getenv("PLANDEX_BUILD_FILE_TIMEOUT")
+	if s == "" {
+		return defaultBuildFileTimeout
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		log.Printf("Invalid PLANDEX_BUILD_FILE_TIMEOUT %q, using default of %s: %v\n", s, defaultBuildFileTimeout, err)
+		return defaultBuildFileTimeout
+	}
+
+	return d
+}
+
+// startBuildAttempt returns the context for one attempt at a build step for the file--the model call and applying its result--which is cancelled after the build file timeout so a hung provider connection can't block the file's build queue. Starting an attempt cancels the previous one.
+func (fileState *activeBuildStreamFileState) startBuildAttempt(ctx context.Context) context.Context {
+	fileState.endBuildAttempt()
+
+	timeout := getBuildFileTimeoutSetting()
+	fileState.attemptTimeout = timeout
+
+	if timeout > 0 {
+		fileState.attemptCtx, fileState.cancelAttempt = context.WithTimeout(ctx, timeout)
+	} else {
+		fileState.attemptCtx, fileState.cancelAttempt = context.WithCancel(ctx)
+	}
+
+	return fileState.attemptCtx
+}
+
+func (fileState *activeBuildStreamFileState) endBuildAttempt() {
+	if fileState.cancelAttempt != nil {
+		fileState.cancelAttempt()
+		fileState.cancelAttempt = nil
+	}
+}
+
+func (fileState *activeBuildStreamFileState) attemptTimedOut() bool {
+	return fileState.attemptCtx != nil && errors.Is(fileState.attemptCtx.Err(), context.DeadlineExceeded)
+}
+
+// attemptErr returns a timeout error if the current attempt timed out, and err otherwise
+func (fileState *activeBuildStreamFileState) attemptErr(err error) error {
+	if fileState.attemptTimedOut() {
+		return fmt.Errorf("build of file '%s' timed out after %s", fileState.filePath, fileState.attemptTimeout)
+	}
+	return err
+}
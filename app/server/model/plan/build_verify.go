@@ -107,23 +107,27 @@ func (fileState *activeBuildStreamFileState) verifyFileBuild() {
 			},
 		},
 		Messages:       fileMessages,
-		Temperature:    config.Temperature,
-		TopP:           config.TopP,
 		ResponseFormat: responseFormat,
 	}
+	reqCtx := model.ApplyRoleConfig(fileState.startBuildAttempt(activePlan.Ctx), &modelReq, config)
 
 	envVar := config.BaseModelConfig.ApiKeyEnvVar
 	client := clients[envVar]
 
 	if config.BaseModelConfig.HasStreamingFunctionCalls {
-		stream, err := model.CreateChatCompletionStreamWithRetries(client, activePlan.Ctx, modelReq)
+		stream, err := model.CreateChatCompletionStreamWithRetries(client, reqCtx, modelReq)
+		capturedStream := fileState.captureModelStream(shared.BuildCapturePhaseVerify, modelReq, stream, err)
 		if err != nil {
 			log.Printf("Error creating plan file stream for path '%s': %v\n", filePath, err)
+			if fileState.attemptTimedOut() {
+				fileState.verifyRetryOrAbort(fileState.attemptErr(err))
+				return
+			}
 			fileState.onBuildFileError(fmt.Errorf("error creating plan file stream for path '%s': %v", filePath, err))
 			return
 		}
 
-		go fileState.listenStreamVerifyOutput(stream)
+		go fileState.listenStreamVerifyOutput(capturedStream)
 	} else {
 		buildInfo := &shared.BuildInfo{
 			Path:      filePath,
@@ -135,10 +139,15 @@ func (fileState *activeBuildStreamFileState) verifyFileBuild() {
 			BuildInfo: buildInfo,
 		})
 
-		resp, err := model.CreateChatCompletionWithRetries(client, activePlan.Ctx, modelReq)
+		resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+		fileState.captureModelResponse(shared.BuildCapturePhaseVerify, modelReq, resp, err)
 
 		if err != nil {
 			log.Printf("Error verifying file '%s': %v\n", filePath, err)
+			if fileState.attemptTimedOut() {
+				fileState.verifyRetryOrAbort(fileState.attemptErr(err))
+				return
+			}
 			fileState.onBuildFileError(fmt.Errorf("error verifying file '%s': %v", filePath, err))
 			return
 		}
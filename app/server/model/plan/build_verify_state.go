@@ -6,6 +6,8 @@ import (
 	"plandex-server/db"
 	"plandex-server/types"
 	"time"
+
+	"github.com/plandex/plandex/shared"
 )
 
 type verifyState struct {
@@ -52,7 +54,7 @@ func (fileState *activeBuildStreamFileState) GetVerifyState() (*verifyState, err
 			parserRes := parser.FinishAndRead()
 
 			for i, file := range parserRes.Files {
-				if file == path {
+				if file == path || resolveVerifyReplyPath(planState, fileState.settings, file) == path {
 					desc := parserRes.FileDescriptions[i]
 					fileContents := parserRes.FileContents[i]
 
@@ -162,3 +164,25 @@ func (fileState *activeBuildStreamFileState) MarkLatestResultVerified(passed boo
 
 	return nil
 }
+
+// resolveVerifyReplyPath resolves a path from a reply the same way it was resolved when the reply's results were stored, with the plan's contexts and results as the known paths. Paths relative to a workspace root only resolve when the plan has workspaces.
+func resolveVerifyReplyPath(planState *shared.CurrentPlanState, settings *shared.PlanSettings, p string) string {
+	isKnownPath := func(path string) bool {
+		return planState.ContextsByPath[path] != nil || planState.PlanResult.FileResultsByPath[path] != nil
+	}
+
+	resolved := resolveKnownPath(p, isKnownPath, func(yield func(string)) {
+		for path := range planState.ContextsByPath {
+			yield(path)
+		}
+		for path := range planState.PlanResult.FileResultsByPath {
+			yield(path)
+		}
+	})
+
+	if settings != nil && len(settings.Workspaces) > 0 {
+		resolved = shared.ResolveWorkspacePath(resolved, settings.Workspaces, isKnownPath)
+	}
+
+	return resolved
+}
@@ -0,0 +1,50 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestResolveVerifyReplyPath(t *testing.T) {
+	planState := &shared.CurrentPlanState{
+		ContextsByPath: map[string]*shared.Context{
+			"apps/api/main.go": {},
+			"apps/web/main.go": {},
+			"lib/util.go":      {},
+		},
+		PlanResult: &shared.PlanResult{
+			FileResultsByPath: shared.PlanFileResultsByPath{
+				"apps/api/server.go": {},
+			},
+		},
+	}
+
+	workspaces := &shared.PlanSettings{Workspaces: []string{"apps/api"}}
+	twoWorkspaces := &shared.PlanSettings{Workspaces: []string{"apps/api", "apps/web"}}
+
+	tests := []struct {
+		name     string
+		settings *shared.PlanSettings
+		path     string
+		want     string
+	}{
+		{name: "exact", path: "lib/util.go", want: "lib/util.go"},
+		{name: "dot prefix", path: "./lib/util.go", want: "lib/util.go"},
+		{name: "result path", path: "apps/api/server.go", want: "apps/api/server.go"},
+		{name: "suffix without workspaces", settings: &shared.PlanSettings{}, path: "main.go", want: "main.go"},
+		{name: "suffix with nil settings", settings: nil, path: "util.go", want: "util.go"},
+		{name: "workspace relative", settings: workspaces, path: "main.go", want: "apps/api/main.go"},
+		{name: "workspace relative result", settings: workspaces, path: "server.go", want: "apps/api/server.go"},
+		{name: "ambiguous workspaces", settings: twoWorkspaces, path: "main.go", want: "main.go"},
+		{name: "one workspace match", settings: twoWorkspaces, path: "server.go", want: "apps/api/server.go"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := resolveVerifyReplyPath(planState, test.settings, test.path); got != test.want {
+				t.Errorf("resolveVerifyReplyPath(%q) = %q, want %q", test.path, got, test.want)
+			}
+		})
+	}
+}
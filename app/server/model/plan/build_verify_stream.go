@@ -11,10 +11,9 @@ import (
 	"time"
 
 	"github.com/plandex/plandex/shared"
-	"github.com/sashabaranov/go-openai"
 )
 
-func (fileState *activeBuildStreamFileState) listenStreamVerifyOutput(stream *openai.ChatCompletionStream) {
+func (fileState *activeBuildStreamFileState) listenStreamVerifyOutput(stream model.ChatCompletionStream) {
 
 	filePath := fileState.filePath
 	planId := fileState.plan.Id
@@ -59,7 +58,7 @@ func (fileState *activeBuildStreamFileState) listenStreamVerifyOutput(stream *op
 					return
 				}
 
-				fileState.verifyRetryOrAbort(fmt.Errorf("listenStreamVerifyOutput - stream error: %v", err))
+				fileState.verifyRetryOrAbort(fileState.attemptErr(fmt.Errorf("listenStreamVerifyOutput - stream error: %v", err)))
 				return
 			}
 
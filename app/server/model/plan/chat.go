@@ -0,0 +1,164 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// chatModelMessages returns the messages a chat is sent to the model as, and their token count. The system prompt and context files always fit or an error is returned. Messages are kept from the most recent back until maxTokens is reached, so a long chat drops its oldest messages rather than failing.
+func chatModelMessages(req shared.ChatRequest, count func(string) (int, error), maxTokens int) ([]openai.ChatCompletionMessage, int, error) {
+	var sys strings.Builder
+	sys.WriteString(prompts.SysChat)
+
+	if len(req.Context) > 0 {
+		sys.WriteString(prompts.ChatContextPrompt)
+		for _, file := range req.Context {
+			fmt.Fprintf(&sys, "- %s:\n\n```\n%s\n```\n\n", file.Path, file.Body)
+		}
+	}
+
+	sysTokens, err := count(sys.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting num tokens for chat context: %v", err)
+	}
+
+	if sysTokens > maxTokens {
+		return nil, 0, fmt.Errorf("chat context is %d 🪙, which is over the chat model's limit of %d 🪙", sysTokens, maxTokens)
+	}
+
+	numTokens := sysTokens
+	start := len(req.Messages)
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		msgTokens, err := count(req.Messages[i].Content)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error getting num tokens for chat message: %v", err)
+		}
+
+		if numTokens+msgTokens > maxTokens {
+			break
+		}
+		numTokens += msgTokens
+		start = i
+	}
+
+	if start == len(req.Messages) {
+		return nil, 0, fmt.Errorf("chat prompt is over the chat model's limit of %d 🪙", maxTokens)
+	}
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: sys.String(),
+		},
+	}
+
+	for _, msg := range req.Messages[start:] {
+		role := openai.ChatMessageRoleUser
+		if msg.Role == shared.ChatMessageRoleAssistant {
+			role = openai.ChatMessageRoleAssistant
+		}
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    role,
+			Content: msg.Content,
+		})
+	}
+
+	return messages, numTokens, nil
+}
+
+// StreamChat sends a chat to the chat model, calling onChunk with each part of the reply as it streams. It returns the full reply. Usage is recorded under the chat role without a plan.
+func StreamChat(ctx context.Context, clients map[string]*openai.Client, config shared.ModelRoleConfig, orgId, userId string, req shared.ChatRequest, onChunk func(string) error) (string, error) {
+	client := clients[config.BaseModelConfig.ApiKeyEnvVar]
+	if client == nil {
+		return "", fmt.Errorf("no api key set for the chat model: %s", config.BaseModelConfig.ApiKeyEnvVar)
+	}
+
+	// room is left for the reply. Models without a known context size aren't limited.
+	maxTokens := math.MaxInt
+	if config.BaseModelConfig.MaxTokens > 0 {
+		maxTokens = config.BaseModelConfig.MaxTokens
+		if config.MaxResponseTokens != nil {
+			maxTokens -= *config.MaxResponseTokens
+		} else if available := shared.AvailableModelsByName[config.BaseModelConfig.ModelName]; available != nil {
+			maxTokens -= available.DefaultReservedOutputTokens
+		}
+	}
+
+	tokenizer := config.BaseModelConfig.GetTokenizer()
+
+	messages, inputTokens, err := chatModelMessages(req, chatTokenCounter(tokenizer), maxTokens)
+	if err != nil {
+		return "", err
+	}
+
+	reply, reported, err := streamModelReply(ctx, client, config, messages, onChunk)
+	if err != nil {
+		return reply, err
+	}
+
+	outputTokens, err := tokenizer.CountTokens(reply)
+	if err == nil {
+		recordModelUsage(orgId, userId, "", "", shared.ModelRoleChat, config, inputTokens, outputTokens, reported)
+	}
+
+	return reply, nil
+}
+
+// streamModelReply streams a plain text reply, with no function calls, calling onChunk with each part as it arrives. It returns the reply so far on error, and the usage the provider reports for the call, if it's captured for the model.
+func streamModelReply(ctx context.Context, client *openai.Client, config shared.ModelRoleConfig, messages []openai.ChatCompletionMessage, onChunk func(string) error) (string, *model.ReportedUsage, error) {
+	modelReq := openai.ChatCompletionRequest{
+		Model:    config.BaseModelConfig.ModelName,
+		Messages: messages,
+		Stream:   true,
+	}
+	reqCtx := model.ApplyRoleConfig(ctx, &modelReq, config)
+	reported := model.ReportedUsageFromContext(reqCtx)
+
+	stream, err := model.CreateChatCompletionStreamWithRetries(client, reqCtx, modelReq)
+	if err != nil {
+		return "", nil, fmt.Errorf("error starting reply stream: %v", err)
+	}
+	defer stream.Close()
+
+	var reply strings.Builder
+	for {
+		response, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return reply.String(), nil, fmt.Errorf("error receiving reply stream: %v", err)
+		}
+
+		if len(response.Choices) == 0 {
+			continue
+		}
+
+		chunk := response.Choices[0].Delta.Content
+		if chunk == "" {
+			continue
+		}
+
+		reply.WriteString(chunk)
+		err = onChunk(chunk)
+		if err != nil {
+			return reply.String(), nil, err
+		}
+	}
+
+	return reply.String(), reported, nil
+}
+
+// chatTokenCounter counts tokens in a model's tokenizer
+func chatTokenCounter(tokenizer shared.Tokenizer) func(string) (int, error) {
+	return tokenizer.CountTokens
+}
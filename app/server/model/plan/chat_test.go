@@ -0,0 +1,57 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// countWords stands in for a tokenizer
+func countWords(s string) (int, error) {
+	return len(strings.Fields(s)), nil
+}
+
+func TestChatModelMessages(t *testing.T) {
+	req := shared.ChatRequest{
+		Messages: []*shared.ChatMessage{
+			{Role: shared.ChatMessageRoleUser, Content: strings.Repeat("old question ", 100)},
+			{Role: shared.ChatMessageRoleAssistant, Content: "an answer"},
+			{Role: shared.ChatMessageRoleUser, Content: "a follow-up"},
+		},
+		Context: []*shared.ChatContextFile{{Path: "main.go", Body: "package main"}},
+	}
+
+	messages, _, err := chatModelMessages(req, countWords, 100000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("expected the system message and every chat message, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0].Content, "- main.go:") {
+		t.Errorf("expected the context file in the system message")
+	}
+	if messages[2].Role != openai.ChatMessageRoleAssistant {
+		t.Errorf("expected an assistant message, got %s", messages[2].Role)
+	}
+
+	sysTokens, _ := countWords(messages[0].Content)
+
+	messages, numTokens, err := chatModelMessages(req, countWords, sysTokens+50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 || messages[1].Content != "an answer" {
+		t.Fatalf("expected the oldest message to be dropped, got %d messages", len(messages))
+	}
+	if numTokens != sysTokens+4 {
+		t.Errorf("expected %d tokens, got %d", sysTokens+4, numTokens)
+	}
+
+	_, _, err = chatModelMessages(req, countWords, 10)
+	if err == nil {
+		t.Errorf("expected an error when the context doesn't fit")
+	}
+}
@@ -3,10 +3,12 @@ package plan
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"plandex-server/db"
 	"plandex-server/model"
 	"plandex-server/model/prompts"
+	"strings"
 
 	"github.com/plandex/plandex/shared"
 	"github.com/sashabaranov/go-openai"
@@ -23,38 +25,35 @@ func genPlanDescription(client *openai.Client, config shared.ModelRoleConfig, pl
 		responseFormat = &openai.ChatCompletionResponseFormat{Type: "json_object"}
 	}
 
-	descResp, err := model.CreateChatCompletionWithRetries(
-		client,
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: config.BaseModelConfig.ModelName,
-			Tools: []openai.Tool{
-				{
-					Type:     "function",
-					Function: &prompts.DescribePlanFn,
-				},
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Tools: []openai.Tool{
+			{
+				Type:     "function",
+				Function: &prompts.DescribePlanFn,
 			},
-			ToolChoice: openai.ToolChoice{
-				Type: "function",
-				Function: openai.ToolFunction{
-					Name: prompts.DescribePlanFn.Name,
-				},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type: "function",
+			Function: openai.ToolFunction{
+				Name: prompts.DescribePlanFn.Name,
+			},
+		},
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: prompts.SysDescribe,
 			},
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: prompts.SysDescribe,
-				},
-				{
-					Role:    openai.ChatMessageRoleAssistant,
-					Content: activePlan.CurrentReplyContent,
-				},
+			{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: activePlan.CurrentReplyContent,
 			},
-			Temperature:    config.Temperature,
-			TopP:           config.TopP,
-			ResponseFormat: responseFormat,
 		},
-	)
+		ResponseFormat: responseFormat,
+	}
+	reqCtx := model.ApplyRoleConfig(ctx, &modelReq, config)
+
+	descResp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
 
 	if err != nil {
 		fmt.Printf("Error during plan description model call: %v\n", err)
@@ -92,54 +91,199 @@ func genPlanDescription(client *openai.Client, config shared.ModelRoleConfig, pl
 	}, nil
 }
 
-func GenCommitMsgForPendingResults(client *openai.Client, config shared.ModelRoleConfig, current *shared.CurrentPlanState, ctx context.Context) (string, error) {
-	s := ""
+type commitMsgArgs struct {
+	Type      string `json:"type"`
+	Scope     string `json:"scope"`
+	Subject   string `json:"subject"`
+	Body      string `json:"body"`
+	Breaking  bool   `json:"breaking"`
+	Changelog string `json:"changelog"`
+}
 
-	num := 0
-	for _, desc := range current.ConvoMessageDescriptions {
-		if desc.MadePlan && desc.DidBuild && len(desc.BuildPathsInvalidated) == 0 && desc.AppliedAt == nil {
-			s += desc.CommitMsg + "\n"
-			num++
-		}
+// maxConvoRequestTokens limits each user message included when describing pending changes, so long prompts with pasted content don't crowd out the changes themselves
+const maxConvoRequestTokens = 500
+
+// maxConvoRequestsTokens limits all the user messages included together. In long conversations, the latest requests are kept.
+const maxConvoRequestsTokens = 8000
+
+// GenCommitMsgAndChangelog writes a conventional commit message and a changelog entry for a plan's pending changes, based on the plan's conversation and a summary of the changes to each file
+func GenCommitMsgAndChangelog(client *openai.Client, config shared.ModelRoleConfig, current *shared.CurrentPlanState, convo []*db.ConvoMessage, ctx context.Context) (*shared.CommitMsgResponse, error) {
+	fileSummaries := getPendingFileSummaries(current)
+	if fileSummaries == "" {
+		return nil, errors.New(shared.NoPendingChangesErr)
+	}
+
+	convoRequests, err := getConvoRequests(convo, config.BaseModelConfig.GetTokenizer())
+	if err != nil {
+		return nil, err
 	}
 
-	if num <= 1 {
-		return s, nil
+	var responseFormat *openai.ChatCompletionResponseFormat
+	if config.BaseModelConfig.HasJsonResponseMode {
+		responseFormat = &openai.ChatCompletionResponseFormat{Type: "json_object"}
 	}
 
-	messages := []openai.ChatCompletionMessage{
-		{
-			Role:    openai.ChatMessageRoleSystem,
-			Content: prompts.SysPendingResults,
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Tools: []openai.Tool{
+			{
+				Type:     "function",
+				Function: &prompts.WriteCommitMsgFn,
+			},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type: "function",
+			Function: openai.ToolFunction{
+				Name: prompts.WriteCommitMsgFn.Name,
+			},
 		},
-		{
-			Role:    openai.ChatMessageRoleUser,
-			Content: "Pending changes:\n\n" + s,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: prompts.SysCommitMsgAndChangelog,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: "Requests in the conversation:\n\n" + convoRequests + "\n\nPending changes by file:\n\n" + fileSummaries,
+			},
 		},
+		ResponseFormat: responseFormat,
 	}
+	reqCtx := model.ApplyRoleConfig(ctx, &modelReq, config)
 
-	resp, err := model.CreateChatCompletionWithRetries(
-		client,
-		ctx,
-		openai.ChatCompletionRequest{
-			Model:       config.BaseModelConfig.ModelName,
-			Messages:    messages,
-			Temperature: config.Temperature,
-			TopP:        config.TopP,
-		},
-	)
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+
+	if err != nil {
+		fmt.Printf("Error during commit message model call: %v\n", err)
+		return nil, err
+	}
+
+	var argsStr string
+	for _, choice := range resp.Choices {
+		if len(choice.Message.ToolCalls) == 1 &&
+			choice.Message.ToolCalls[0].Function.Name == prompts.WriteCommitMsgFn.Name {
+			argsStr = choice.Message.ToolCalls[0].Function.Arguments
+			break
+		}
+	}
 
+	if argsStr == "" {
+		return nil, fmt.Errorf("no writeCommitMsg function call found in response")
+	}
+
+	var args commitMsgArgs
+	err = json.Unmarshal([]byte(argsStr), &args)
 	if err != nil {
-		fmt.Println("PlanSummary err:", err)
+		return nil, fmt.Errorf("error unmarshalling commit message response: %v", err)
+	}
+
+	return &shared.CommitMsgResponse{
+		CommitMsg: args.commitMsg(),
+		Changelog: strings.TrimSpace(args.Changelog),
+	}, nil
+}
+
+// getConvoRequests lists the user's messages in the conversation, which describe what the pending changes are for. Each message and the list as a whole are trimmed to fit the model's tokenizer, keeping the latest messages.
+func getConvoRequests(convo []*db.ConvoMessage, tokenizer shared.Tokenizer) (string, error) {
+	var requests []string
+	var numTokens []int
+	for _, msg := range convo {
+		if msg.Role != openai.ChatMessageRoleUser {
+			continue
+		}
+		res, err := shared.TruncateToTokens(strings.TrimSpace(msg.Message), maxConvoRequestTokens, tokenizer, shared.TruncateKeepStart)
+		if err != nil {
+			return "", fmt.Errorf("error truncating request: %v", err)
+		}
+		content := strings.TrimSpace(res.Text)
+		if res.Truncated {
+			content += "…"
+		}
+		requests = append(requests, "- "+content)
+		numTokens = append(numTokens, res.NumTokens)
+	}
+
+	numKept := shared.NumLatestThatFit(numTokens, maxConvoRequestsTokens, tokenizer)
+	requests = requests[len(requests)-numKept:]
+
+	return strings.Join(requests, "\n"), nil
+}
+
+// commitMsg formats the args as a conventional commit message
+func (args commitMsgArgs) commitMsg() string {
+	commitType := strings.ToLower(strings.TrimSpace(args.Type))
+	if commitType == "" {
+		commitType = "chore"
+	}
 
-		return "", err
+	header := commitType
+	if scope := strings.TrimSpace(args.Scope); scope != "" {
+		header += "(" + scope + ")"
 	}
+	if args.Breaking {
+		header += "!"
+	}
+	header += ": " + strings.TrimSuffix(strings.TrimSpace(args.Subject), ".")
+
+	if body := strings.TrimSpace(args.Body); body != "" {
+		return header + "\n\n" + body
+	}
+	return header
+}
 
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response from GPT")
+// getPendingFileSummaries lists each file with pending changes, whether it's new or how many changes it has, and the descriptions of the plan replies that changed it
+func getPendingFileSummaries(current *shared.CurrentPlanState) string {
+	if current.PlanResult == nil {
+		return ""
 	}
 
-	content := resp.Choices[0].Message.Content
+	descByConvoMessageId := map[string]*shared.ConvoMessageDescription{}
+	for _, desc := range current.ConvoMessageDescriptions {
+		descByConvoMessageId[desc.ConvoMessageId] = desc
+	}
+
+	var lines []string
+	for _, path := range current.PlanResult.SortedPaths {
+		var isNew bool
+		var numChanges int
+		descsSet := map[string]bool{}
+		var descs []string
+
+		for _, res := range current.PlanResult.FileResultsByPath[path] {
+			if !res.IsPending() {
+				continue
+			}
+			if res.Content != "" {
+				isNew = true
+			}
+			numChanges += res.NumPendingReplacements()
+
+			desc := descByConvoMessageId[res.ConvoMessageId]
+			if desc != nil && desc.CommitMsg != "" && !descsSet[desc.CommitMsg] {
+				descsSet[desc.CommitMsg] = true
+				descs = append(descs, desc.CommitMsg)
+			}
+		}
+
+		if !isNew && numChanges == 0 {
+			continue
+		}
+
+		var line string
+		if isNew {
+			line = fmt.Sprintf("- %s (new file)", path)
+		} else {
+			suffix := "s"
+			if numChanges == 1 {
+				suffix = ""
+			}
+			line = fmt.Sprintf("- %s (%d change%s)", path, numChanges, suffix)
+		}
+		for _, desc := range descs {
+			line += "\n  - " + desc
+		}
+		lines = append(lines, line)
+	}
 
-	return content, nil
+	return strings.Join(lines, "\n")
 }
@@ -0,0 +1,55 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestCommitMsgArgsCommitMsg(t *testing.T) {
+	tests := []struct {
+		args     commitMsgArgs
+		expected string
+	}{
+		{commitMsgArgs{Type: "feat", Scope: "cli", Subject: "add apply hooks."}, "feat(cli): add apply hooks"},
+		{commitMsgArgs{Type: "Fix", Subject: "handle empty files", Body: "Empty files were skipped when building."}, "fix: handle empty files\n\nEmpty files were skipped when building."},
+		{commitMsgArgs{Type: "refactor", Scope: "api", Subject: "rename endpoints", Breaking: true}, "refactor(api)!: rename endpoints"},
+		{commitMsgArgs{Subject: "update deps"}, "chore: update deps"},
+	}
+
+	for _, test := range tests {
+		msg := test.args.commitMsg()
+		if msg != test.expected {
+			t.Errorf("Expected %q, got %q", test.expected, msg)
+		}
+	}
+}
+
+func TestGetPendingFileSummaries(t *testing.T) {
+	state := &shared.CurrentPlanState{
+		PlanResult: &shared.PlanResult{
+			SortedPaths: []string{"main.go", "new.go", "rejected.go"},
+			FileResultsByPath: shared.PlanFileResultsByPath{
+				"main.go": {
+					{ConvoMessageId: "1", Replacements: []*shared.Replacement{{New: "a"}, {New: "b"}}},
+				},
+				"new.go": {
+					{ConvoMessageId: "2", Content: "package main"},
+				},
+				"rejected.go": {
+					{ConvoMessageId: "2", Replacements: []*shared.Replacement{{New: "a", Failed: true}}},
+				},
+			},
+		},
+		ConvoMessageDescriptions: []*shared.ConvoMessageDescription{
+			{ConvoMessageId: "1", CommitMsg: "Add a flag"},
+			{ConvoMessageId: "2", CommitMsg: "Add a new file"},
+		},
+	}
+
+	expected := "- main.go (2 changes)\n  - Add a flag\n- new.go (new file)\n  - Add a new file"
+	summaries := getPendingFileSummaries(state)
+	if summaries != expected {
+		t.Errorf("Expected %q, got %q", expected, summaries)
+	}
+}
@@ -0,0 +1,29 @@
+package plan
+
+import (
+	"log"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// loadProjectConventions stores the conventions file sent with a prompt on the active plan, so each planner call in the stream follows it. Like learned preferences, it's best effort: on error, the plan continues without it.
+func loadProjectConventions(planId, branch, conventions string) {
+	conventions = strings.TrimSpace(conventions)
+
+	numTokens := 0
+	if conventions != "" {
+		var err error
+		numTokens, err = shared.GetNumTokens(conventions)
+		if err != nil {
+			log.Printf("Error getting num tokens for project conventions: %v\n", err)
+			return
+		}
+	}
+
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		ap.ConventionsPrompt = conventions
+		ap.ConventionsTokens = numTokens
+	})
+}
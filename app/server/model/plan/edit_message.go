@@ -0,0 +1,195 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"plandex-server/db"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+var ErrInvalidMessageEdit = errors.New("invalid message edit")
+var ErrNothingToRerun = errors.New("nothing to re-run")
+
+// EditMessage replaces the text of an earlier prompt and marks every later message, and the file results built from their replies, as stale. The plan's history isn't changed any further until 'plandex rerun' replays from the edited prompt. The caller must hold a write lock on the plan's repo.
+func EditMessage(orgId, planId, branch string, num int, message string) (*shared.EditMessageResponse, error) {
+	convo, err := db.GetPlanConvo(orgId, planId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting plan convo: %v", err)
+	}
+
+	edited, staleIds, err := getMessageEdit(convo, num)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := shared.GetNumTokens(message)
+	if err != nil {
+		return nil, fmt.Errorf("error getting num tokens: %v", err)
+	}
+
+	now := time.Now()
+	edited.Message = message
+	edited.Tokens = tokens
+	edited.EditedAt = &now
+
+	toUpdate := []*db.ConvoMessage{edited}
+	for _, msg := range convo {
+		if staleIds[msg.Id] {
+			msg.Stale = true
+			toUpdate = append(toUpdate, msg)
+		}
+	}
+
+	err = db.UpdateConvoMessages(orgId, planId, toUpdate)
+	if err != nil {
+		return nil, fmt.Errorf("error updating convo messages: %v", err)
+	}
+
+	err = db.MarkDescriptionsStale(orgId, planId, staleIds)
+	if err != nil {
+		return nil, fmt.Errorf("error marking descriptions stale: %v", err)
+	}
+
+	numStaleResults, err := db.MarkResultsStale(orgId, planId, staleIds)
+	if err != nil {
+		return nil, fmt.Errorf("error marking results stale: %v", err)
+	}
+
+	commitMsg := fmt.Sprintf("✏️  Edited message #%d | %d later messages marked stale", num, len(staleIds))
+	err = db.GitAddAndCommit(orgId, planId, branch, commitMsg)
+	if err != nil {
+		return nil, fmt.Errorf("error committing edited message: %v", err)
+	}
+
+	log.Printf("Edited message #%d for plan %s on branch %s | %d stale messages | %d stale results\n", num, planId, branch, len(staleIds), numStaleResults)
+
+	return &shared.EditMessageResponse{
+		NumStaleMessages: len(staleIds),
+		NumStaleResults:  numStaleResults,
+	}, nil
+}
+
+// getMessageEdit finds the prompt to edit and the ids of the messages that come after it
+func getMessageEdit(convo []*db.ConvoMessage, num int) (*db.ConvoMessage, map[string]bool, error) {
+	var edited *db.ConvoMessage
+	for _, msg := range convo {
+		if msg.Num == num {
+			edited = msg
+			break
+		}
+	}
+
+	if edited == nil {
+		return nil, nil, fmt.Errorf("%w: message %d not found", ErrInvalidMessageEdit, num)
+	}
+
+	if edited.Role != openai.ChatMessageRoleUser {
+		return nil, nil, fmt.Errorf("%w: message %d isn't a prompt", ErrInvalidMessageEdit, num)
+	}
+
+	if edited.Stale {
+		return nil, nil, fmt.Errorf("%w: message %d is stale after an earlier edit—re-run the plan first", ErrInvalidMessageEdit, num)
+	}
+
+	staleIds := map[string]bool{}
+	for _, msg := range convo {
+		if msg.Num > num {
+			staleIds[msg.Id] = true
+		}
+	}
+
+	return edited, staleIds, nil
+}
+
+// PrepareRerun sets up the next step of replaying a plan from an edited prompt. The first time, stale messages are dropped from the conversation (they stay in the plan's history), their pending file results are rejected, and their prompts are queued. Each step after that either continues from a prompt that has no reply yet or takes the next queued prompt. The caller must hold a write lock on the plan's repo.
+func PrepareRerun(orgId, planId, branch string) (*shared.RerunPlanResponse, error) {
+	convo, err := db.GetPlanConvo(orgId, planId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting plan convo: %v", err)
+	}
+
+	prompts, err := db.GetReplayPrompts(orgId, planId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting replay prompts: %v", err)
+	}
+
+	fresh, staleIds, stalePrompts := splitStaleConvo(convo)
+
+	var commitMsg string
+
+	if len(staleIds) > 0 {
+		err = db.DeleteConvoMessages(orgId, planId, staleIds)
+		if err != nil {
+			return nil, fmt.Errorf("error deleting stale messages: %v", err)
+		}
+
+		err = db.DeleteDescriptionsForMessages(orgId, planId, staleIds)
+		if err != nil {
+			return nil, fmt.Errorf("error deleting stale descriptions: %v", err)
+		}
+
+		err = db.RejectStaleResults(orgId, planId, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("error rejecting stale results: %v", err)
+		}
+
+		prompts = stalePrompts
+		commitMsg = fmt.Sprintf("🔁 Re-running from edited message #%d | %d prompts to replay", fresh[len(fresh)-1].Num, len(prompts))
+	}
+
+	res := &shared.RerunPlanResponse{}
+
+	if len(fresh) > 0 && fresh[len(fresh)-1].Role == openai.ChatMessageRoleUser {
+		res.IsUserContinue = true
+		res.FromMessageNum = fresh[len(fresh)-1].Num
+	} else if len(prompts) > 0 {
+		res.Prompt = prompts[0]
+		res.FromMessageNum = len(fresh) + 1
+		prompts = prompts[1:]
+
+		if commitMsg == "" {
+			commitMsg = fmt.Sprintf("🔁 Replaying prompt as message #%d | %d more to replay", res.FromMessageNum, len(prompts))
+		}
+	} else {
+		return nil, ErrNothingToRerun
+	}
+
+	res.NumRemaining = len(prompts)
+
+	if commitMsg != "" {
+		err = db.StoreReplayPrompts(orgId, planId, prompts)
+		if err != nil {
+			return nil, fmt.Errorf("error storing replay prompts: %v", err)
+		}
+
+		err = db.GitAddAndCommit(orgId, planId, branch, commitMsg)
+		if err != nil {
+			return nil, fmt.Errorf("error committing re-run: %v", err)
+		}
+	}
+
+	return res, nil
+}
+
+// splitStaleConvo separates a conversation into the messages up to an edited prompt and the stale ones after it, returning the stale prompts in order
+func splitStaleConvo(convo []*db.ConvoMessage) (fresh []*db.ConvoMessage, staleIds map[string]bool, stalePrompts []string) {
+	staleIds = map[string]bool{}
+
+	for _, msg := range convo {
+		if !msg.Stale {
+			fresh = append(fresh, msg)
+			continue
+		}
+
+		staleIds[msg.Id] = true
+		if msg.Role == openai.ChatMessageRoleUser {
+			stalePrompts = append(stalePrompts, msg.Message)
+		}
+	}
+
+	return fresh, staleIds, stalePrompts
+}
@@ -0,0 +1,67 @@
+package plan
+
+import (
+	"errors"
+	"plandex-server/db"
+	"testing"
+)
+
+func testConvo() []*db.ConvoMessage {
+	return []*db.ConvoMessage{
+		{Id: "1", Num: 1, Role: "user", Message: "add a cache"},
+		{Id: "2", Num: 2, Role: "assistant", Message: "added a cache"},
+		{Id: "3", Num: 3, Role: "user", Message: "now add tests"},
+		{Id: "4", Num: 4, Role: "assistant", Message: "added tests"},
+	}
+}
+
+func TestGetMessageEdit(t *testing.T) {
+	convo := testConvo()
+
+	edited, staleIds, err := getMessageEdit(convo, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if edited.Id != "1" {
+		t.Errorf("expected message 1 to be edited, got %s", edited.Id)
+	}
+
+	if len(staleIds) != 3 || staleIds["1"] || !staleIds["2"] || !staleIds["3"] || !staleIds["4"] {
+		t.Errorf("expected messages 2-4 to be stale, got %v", staleIds)
+	}
+
+	if _, _, err := getMessageEdit(convo, 2); !errors.Is(err, ErrInvalidMessageEdit) {
+		t.Error("editing a reply should be rejected")
+	}
+
+	if _, _, err := getMessageEdit(convo, 9); !errors.Is(err, ErrInvalidMessageEdit) {
+		t.Error("editing a missing message should be rejected")
+	}
+
+	convo[2].Stale = true
+	if _, _, err := getMessageEdit(convo, 3); !errors.Is(err, ErrInvalidMessageEdit) {
+		t.Error("editing a stale prompt should be rejected")
+	}
+}
+
+func TestSplitStaleConvo(t *testing.T) {
+	convo := testConvo()
+	for _, msg := range convo[1:] {
+		msg.Stale = true
+	}
+
+	fresh, staleIds, stalePrompts := splitStaleConvo(convo)
+
+	if len(fresh) != 1 || fresh[0].Id != "1" {
+		t.Errorf("expected only message 1 to be fresh, got %d messages", len(fresh))
+	}
+
+	if len(staleIds) != 3 {
+		t.Errorf("expected 3 stale messages, got %d", len(staleIds))
+	}
+
+	if len(stalePrompts) != 1 || stalePrompts[0] != "now add tests" {
+		t.Errorf("expected the later prompt to be queued for replay, got %q", stalePrompts)
+	}
+}
@@ -87,29 +87,26 @@ func (state *activeTellStreamState) execStatusShouldContinue(message string, lat
 		responseFormat = &openai.ChatCompletionResponseFormat{Type: "json_object"}
 	}
 
-	resp, err := model.CreateChatCompletionWithRetries(
-		client,
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: config.BaseModelConfig.ModelName,
-			Tools: []openai.Tool{
-				{
-					Type:     "function",
-					Function: &prompts.ShouldAutoContinueFn,
-				},
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Tools: []openai.Tool{
+			{
+				Type:     "function",
+				Function: &prompts.ShouldAutoContinueFn,
 			},
-			ToolChoice: openai.ToolChoice{
-				Type: "function",
-				Function: openai.ToolFunction{
-					Name: prompts.ShouldAutoContinueFn.Name,
-				},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type: "function",
+			Function: openai.ToolFunction{
+				Name: prompts.ShouldAutoContinueFn.Name,
 			},
-			Messages:       messages,
-			ResponseFormat: responseFormat,
-			Temperature:    config.Temperature,
-			TopP:           config.TopP,
 		},
-	)
+		Messages:       messages,
+		ResponseFormat: responseFormat,
+	}
+	reqCtx := model.ApplyRoleConfig(ctx, &modelReq, config)
+
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
 
 	if err != nil {
 		log.Printf("Error during plan exec status check model call: %v\n", err)
@@ -0,0 +1,104 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// maxImpactReportDiffTokens keeps the diff in an impact report request well within the planner's context. The per-file summaries still cover every file when the diff is cut off.
+const maxImpactReportDiffTokens = 15000
+
+// GenImpactReport asks the planner for a report on what a plan's pending changes affect, based on the plan's conversation, the changes to each file, and their diff
+func GenImpactReport(client *openai.Client, config shared.ModelRoleConfig, current *shared.CurrentPlanState, convo []*db.ConvoMessage, diffs string, ctx context.Context) (*shared.ImpactReport, error) {
+	fileSummaries := getPendingFileSummaries(current)
+	if fileSummaries == "" {
+		return nil, errors.New(shared.NoPendingChangesErr)
+	}
+
+	tokenizer := config.BaseModelConfig.GetTokenizer()
+
+	res, err := shared.TruncateToTokens(diffs, maxImpactReportDiffTokens, tokenizer, shared.TruncateKeepStart)
+	if err != nil {
+		return nil, fmt.Errorf("error truncating diff: %v", err)
+	}
+	if res.Truncated {
+		diffs = res.Text + "… (diff truncated)"
+	}
+
+	convoRequests, err := getConvoRequests(convo, tokenizer)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseFormat *openai.ChatCompletionResponseFormat
+	if config.BaseModelConfig.HasJsonResponseMode {
+		responseFormat = &openai.ChatCompletionResponseFormat{Type: "json_object"}
+	}
+
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Tools: []openai.Tool{
+			{
+				Type:     "function",
+				Function: &prompts.WriteImpactReportFn,
+			},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type: "function",
+			Function: openai.ToolFunction{
+				Name: prompts.WriteImpactReportFn.Name,
+			},
+		},
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: prompts.SysImpactReport,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompts.GetImpactReportPrompt(convoRequests, fileSummaries, diffs),
+			},
+		},
+		ResponseFormat: responseFormat,
+	}
+	reqCtx := model.ApplyRoleConfig(ctx, &modelReq, config)
+
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+
+	if err != nil {
+		fmt.Printf("Error during impact report model call: %v\n", err)
+		return nil, err
+	}
+
+	var argsStr string
+	for _, choice := range resp.Choices {
+		if len(choice.Message.ToolCalls) == 1 &&
+			choice.Message.ToolCalls[0].Function.Name == prompts.WriteImpactReportFn.Name {
+			argsStr = choice.Message.ToolCalls[0].Function.Arguments
+			break
+		}
+	}
+
+	if argsStr == "" {
+		return nil, fmt.Errorf("no writeImpactReport function call found in response")
+	}
+
+	var report shared.ImpactReport
+	err = json.Unmarshal([]byte(argsStr), &report)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling impact report response: %v", err)
+	}
+
+	report.CreatedAt = time.Now()
+
+	return &report, nil
+}
@@ -0,0 +1,243 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"plandex-server/db"
+	"plandex-server/model"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// entries less similar to every query than this aren't considered relevant
+const minKnowledgeSimilarity = 0.3
+
+// a keyword match needs at least this fraction of a query's terms
+const minKnowledgeKeywordMatch = 0.5
+
+// keyword matches are less precise than embedding matches, so their scores are weighted down to rank below close embedding matches
+const knowledgeKeywordWeight = 0.5
+
+// knowledgeCandidate is an entry being ranked, with its embedding if it has one for the current embedding model
+type knowledgeCandidate struct {
+	entry     *db.KnowledgeEntry
+	embedding []float32
+}
+
+// SearchKnowledge returns the org's knowledge base entries most relevant to any of the queries, best first, with their bodies. Entries and queries are compared by embedding when there's an OpenAI client to embed them with, and by keyword otherwise. Entries that were stored without an embedding, or with a different embedding model, are embedded along the way.
+func SearchKnowledge(ctx context.Context, clients map[string]*openai.Client, orgId string, queries []string, limit int) (*shared.SearchKnowledgeResponse, error) {
+	entries, err := db.ListKnowledgeEntries(orgId)
+	if err != nil {
+		return nil, err
+	}
+
+	return searchKnowledgeEntries(ctx, clients, entries, queries, limit), nil
+}
+
+func searchKnowledgeEntries(ctx context.Context, clients map[string]*openai.Client, entries []*db.KnowledgeEntry, queries []string, limit int) *shared.SearchKnowledgeResponse {
+	res := &shared.SearchKnowledgeResponse{Results: []*shared.KnowledgeSearchResult{}}
+
+	var nonEmpty []string
+	for _, query := range queries {
+		if strings.TrimSpace(query) != "" {
+			nonEmpty = append(nonEmpty, query)
+		}
+	}
+	queries = nonEmpty
+
+	if len(entries) == 0 || len(queries) == 0 {
+		return res
+	}
+
+	candidates := make([]*knowledgeCandidate, len(entries))
+	for i, entry := range entries {
+		embedding, err := entry.GetEmbedding(string(model.EmbeddingModel))
+		if err != nil {
+			// the entry can still be matched by keyword
+			log.Printf("Error getting embedding for knowledge entry %s: %v\n", entry.Id, err)
+		}
+		candidates[i] = &knowledgeCandidate{entry: entry, embedding: embedding}
+	}
+
+	var queryEmbeddings [][]float32
+	client := model.EmbeddingClient(clients)
+	if client != nil {
+		embedMissingKnowledge(ctx, client, candidates)
+
+		var err error
+		queryEmbeddings, err = model.CreateEmbeddings(client, ctx, queries)
+		if err != nil {
+			log.Printf("Error embedding knowledge queries, falling back to keyword search: %v\n", err)
+			queryEmbeddings = nil
+		}
+	}
+
+	res.UsedEmbeddings = queryEmbeddings != nil
+	res.Results = rankKnowledge(candidates, queries, queryEmbeddings, limit)
+
+	return res
+}
+
+// embedMissingKnowledge embeds the candidates that don't have an embedding for the current model and stores them. Failures are logged, and those candidates are matched by keyword.
+func embedMissingKnowledge(ctx context.Context, client *openai.Client, candidates []*knowledgeCandidate) {
+	var missing []*knowledgeCandidate
+	var texts []string
+	for _, candidate := range candidates {
+		if candidate.embedding == nil {
+			missing = append(missing, candidate)
+			texts = append(texts, KnowledgeEmbeddingText(candidate.entry.Title, candidate.entry.Body))
+		}
+	}
+
+	if len(missing) == 0 {
+		return
+	}
+
+	log.Printf("Embedding %d knowledge entries\n", len(missing))
+
+	embeddings, err := model.CreateEmbeddings(client, ctx, texts)
+	if err != nil {
+		log.Printf("Error embedding knowledge entries: %v\n", err)
+		return
+	}
+
+	for i, candidate := range missing {
+		err := db.SetKnowledgeEmbedding(candidate.entry, embeddings[i], string(model.EmbeddingModel))
+		if err != nil {
+			log.Printf("Error storing embedding for knowledge entry %s: %v\n", candidate.entry.Id, err)
+		}
+		candidate.embedding = embeddings[i]
+	}
+}
+
+// KnowledgeEmbeddingText is the text an entry is embedded from
+func KnowledgeEmbeddingText(title, body string) string {
+	return title + "\n\n" + body
+}
+
+// rankKnowledge scores each candidate by its best match with any query: cosine similarity when both have embeddings, or the weighted fraction of the query's terms found in its title and body otherwise. Candidates below the relevance thresholds are dropped.
+func rankKnowledge(candidates []*knowledgeCandidate, queries []string, queryEmbeddings [][]float32, limit int) []*shared.KnowledgeSearchResult {
+	var results []*shared.KnowledgeSearchResult
+
+	for _, candidate := range candidates {
+		best := 0.0
+		isKeywordMatch := false
+		var matchedTerms []string
+
+		for i, query := range queries {
+			if queryEmbeddings != nil && candidate.embedding != nil {
+				score := cosineSimilarity(queryEmbeddings[i], candidate.embedding)
+				if score >= minKnowledgeSimilarity && score > best {
+					best = score
+					isKeywordMatch = false
+				}
+				continue
+			}
+
+			terms := parseSearchTerms(query)
+			fraction := keywordMatchFraction(candidate.entry.Title+"\n"+candidate.entry.Body, terms)
+			if fraction >= minKnowledgeKeywordMatch && fraction*knowledgeKeywordWeight > best {
+				best = fraction * knowledgeKeywordWeight
+				isKeywordMatch = true
+				matchedTerms = terms
+			}
+		}
+
+		if best == 0 {
+			continue
+		}
+
+		entry := candidate.entry.ToApi()
+		snippetTerms := matchedTerms
+		if len(snippetTerms) == 0 {
+			snippetTerms = []string{""}
+		}
+
+		results = append(results, &shared.KnowledgeSearchResult{
+			Entry:          entry,
+			Score:          best,
+			IsKeywordMatch: isKeywordMatch,
+			Snippet:        getSearchSnippet(entry.Body, snippetTerms),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Entry.CreatedAt.After(results[j].Entry.CreatedAt)
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// keywordMatchFraction returns the fraction of terms that appear in text, ignoring case
+func keywordMatchFraction(text string, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	lower := strings.ToLower(text)
+	found := 0
+	for _, term := range terms {
+		if strings.Contains(lower, term) {
+			found++
+		}
+	}
+
+	return float64(found) / float64(len(terms))
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// formatKnowledgeResults formats search results for the planner's prompt, best first, up to maxTokens. Results that don't fit are skipped so a smaller one after them can still be included.
+func formatKnowledgeResults(results []*shared.KnowledgeSearchResult, maxTokens int) (string, int) {
+	var b strings.Builder
+	numTokens := 0
+
+	for _, result := range results {
+		entry := result.Entry
+		text := fmt.Sprintf("### %s (%s)\n\n%s\n\n", entry.Title, entry.Kind, strings.TrimSpace(entry.Body))
+
+		entryTokens, err := shared.GetNumTokens(text)
+		if err != nil {
+			log.Printf("Error getting num tokens for knowledge entry %s: %v\n", entry.Id, err)
+			continue
+		}
+
+		if numTokens+entryTokens > maxTokens {
+			continue
+		}
+
+		b.WriteString(text)
+		numTokens += entryTokens
+	}
+
+	return b.String(), numTokens
+}
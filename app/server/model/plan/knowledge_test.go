@@ -0,0 +1,74 @@
+package plan
+
+import (
+	"math"
+	"plandex-server/db"
+	"testing"
+	"time"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	if score := cosineSimilarity([]float32{1, 0}, []float32{2, 0}); math.Abs(score-1) > 1e-9 {
+		t.Errorf("expected 1 for parallel vectors, got %f", score)
+	}
+
+	if score := cosineSimilarity([]float32{1, 0}, []float32{0, 1}); score != 0 {
+		t.Errorf("expected 0 for orthogonal vectors, got %f", score)
+	}
+
+	if score := cosineSimilarity([]float32{1, 0}, []float32{1, 0, 0}); score != 0 {
+		t.Errorf("expected 0 for vectors of different lengths, got %f", score)
+	}
+}
+
+func TestKeywordMatchFraction(t *testing.T) {
+	text := "We use sqlc for Postgres queries"
+
+	if fraction := keywordMatchFraction(text, []string{"postgres", "sqlc"}); fraction != 1 {
+		t.Errorf("expected 1, got %f", fraction)
+	}
+
+	if fraction := keywordMatchFraction(text, []string{"postgres", "mysql"}); fraction != 0.5 {
+		t.Errorf("expected 0.5, got %f", fraction)
+	}
+}
+
+func TestRankKnowledge(t *testing.T) {
+	now := time.Now()
+	candidates := []*knowledgeCandidate{
+		{
+			entry:     &db.KnowledgeEntry{Id: "grpc", Kind: "adr", Title: "Switch to gRPC", Body: "Services talk over gRPC.", CreatedAt: now},
+			embedding: []float32{1, 0},
+		},
+		{
+			entry:     &db.KnowledgeEntry{Id: "css", Kind: "note", Title: "CSS conventions", Body: "Use tailwind.", CreatedAt: now},
+			embedding: []float32{0, 1},
+		},
+		{
+			// not embedded yet, so it's matched by keyword
+			entry: &db.KnowledgeEntry{Id: "errors", Kind: "note", Title: "Error handling", Body: "Wrap grpc errors with status codes.", CreatedAt: now},
+		},
+	}
+
+	results := rankKnowledge(candidates, []string{"grpc errors"}, [][]float32{{0.9, 0.1}}, 10)
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Entry.Id != "grpc" || results[0].IsKeywordMatch {
+		t.Errorf("expected the embedding match first, got %s", results[0].Entry.Id)
+	}
+	if results[1].Entry.Id != "errors" || !results[1].IsKeywordMatch {
+		t.Errorf("expected the keyword match second, got %s", results[1].Entry.Id)
+	}
+
+	results = rankKnowledge(candidates, []string{"grpc errors"}, nil, 10)
+	if len(results) != 2 || results[0].Entry.Id != "errors" {
+		t.Errorf("expected keyword matches without query embeddings, got %d results", len(results))
+	}
+
+	results = rankKnowledge(candidates, []string{"grpc errors"}, [][]float32{{0.9, 0.1}}, 1)
+	if len(results) != 1 {
+		t.Errorf("expected results to be limited to 1, got %d", len(results))
+	}
+}
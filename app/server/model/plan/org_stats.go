@@ -0,0 +1,84 @@
+package plan
+
+import (
+	"fmt"
+	"plandex-server/db"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// GetOrgStats reports builds, build failures and durations, and model usage for the org over the last number of days, counting today. With a userId, only that user's activity is included.
+func GetOrgStats(orgId, userId string, days int, now time.Time) (*shared.OrgStatsResponse, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	builds, err := db.ListBuildStatRows(orgId, userId, since)
+	if err != nil {
+		return nil, fmt.Errorf("error listing builds: %v", err)
+	}
+
+	usageByModel, err := db.GetModelUsageStats(orgId, userId, since)
+	if err != nil {
+		return nil, fmt.Errorf("error getting model usage: %v", err)
+	}
+
+	usageByUser, err := db.GetUserUsageStats(orgId, userId, since)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user usage: %v", err)
+	}
+
+	res := &shared.OrgStatsResponse{
+		Since:        since,
+		Until:        now,
+		IsOrgWide:    userId == "",
+		UsageByModel: usageByModel,
+		UsageByUser:  usageByUser,
+	}
+
+	addBuildStats(res, builds, since, days)
+
+	return res, nil
+}
+
+// addBuildStats counts builds per day, with a row for every day in the window, along with the failure rate and average duration of finished builds
+func addBuildStats(res *shared.OrgStatsResponse, builds []*db.BuildStatRow, since time.Time, days int) {
+	res.BuildsPerDay = make([]*shared.BuildDayStats, days)
+	byDay := map[string]*shared.BuildDayStats{}
+
+	for i := 0; i < days; i++ {
+		day := since.AddDate(0, 0, i).Format("2006-01-02")
+		res.BuildsPerDay[i] = &shared.BuildDayStats{Day: day}
+		byDay[day] = res.BuildsPerDay[i]
+	}
+
+	var totalDuration time.Duration
+
+	for _, build := range builds {
+		res.NumBuilds++
+
+		dayStats := byDay[build.CreatedAt.UTC().Format("2006-01-02")]
+		if dayStats != nil {
+			dayStats.NumBuilds++
+		}
+
+		if build.FinishedAt == nil {
+			continue
+		}
+
+		res.NumFinishedBuilds++
+		totalDuration += build.FinishedAt.Sub(build.CreatedAt)
+
+		if build.Error != "" {
+			res.NumFailedBuilds++
+			if dayStats != nil {
+				dayStats.NumFailed++
+			}
+		}
+	}
+
+	if res.NumFinishedBuilds > 0 {
+		res.FailureRate = float64(res.NumFailedBuilds) / float64(res.NumFinishedBuilds)
+		res.AvgBuildDurationMs = totalDuration.Milliseconds() / int64(res.NumFinishedBuilds)
+	}
+}
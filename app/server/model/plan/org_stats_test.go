@@ -0,0 +1,70 @@
+package plan
+
+import (
+	"plandex-server/db"
+	"testing"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestAddBuildStats(t *testing.T) {
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	at := func(day, minute int) time.Time {
+		return since.AddDate(0, 0, day).Add(time.Duration(minute) * time.Minute)
+	}
+	finishedAt := func(day, minute int) *time.Time {
+		t := at(day, minute)
+		return &t
+	}
+
+	builds := []*db.BuildStatRow{
+		{CreatedAt: at(0, 0), FinishedAt: finishedAt(0, 1)},
+		{CreatedAt: at(0, 10), FinishedAt: finishedAt(0, 13), Error: "syntax error"},
+		{CreatedAt: at(2, 0), FinishedAt: finishedAt(2, 2)},
+		// still running, so it counts as a build but not toward failures or durations
+		{CreatedAt: at(2, 5)},
+	}
+
+	res := &shared.OrgStatsResponse{}
+	addBuildStats(res, builds, since, 3)
+
+	if res.NumBuilds != 4 || res.NumFinishedBuilds != 3 || res.NumFailedBuilds != 1 {
+		t.Fatalf("unexpected counts: builds %d, finished %d, failed %d", res.NumBuilds, res.NumFinishedBuilds, res.NumFailedBuilds)
+	}
+
+	if res.FailureRate < 0.333 || res.FailureRate > 0.334 {
+		t.Errorf("expected failure rate of 1/3, got %f", res.FailureRate)
+	}
+
+	if res.AvgBuildDurationMs != (2 * time.Minute).Milliseconds() {
+		t.Errorf("expected average duration of 2m, got %dms", res.AvgBuildDurationMs)
+	}
+
+	expected := []shared.BuildDayStats{
+		{Day: "2024-05-01", NumBuilds: 2, NumFailed: 1},
+		{Day: "2024-05-02"},
+		{Day: "2024-05-03", NumBuilds: 2},
+	}
+
+	if len(res.BuildsPerDay) != len(expected) {
+		t.Fatalf("expected %d days, got %d", len(expected), len(res.BuildsPerDay))
+	}
+
+	for i, day := range res.BuildsPerDay {
+		if *day != expected[i] {
+			t.Errorf("day %d: expected %+v, got %+v", i, expected[i], *day)
+		}
+	}
+}
+
+func TestAddBuildStatsNoFinishedBuilds(t *testing.T) {
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	res := &shared.OrgStatsResponse{}
+	addBuildStats(res, []*db.BuildStatRow{{CreatedAt: since}}, since, 1)
+
+	if res.FailureRate != 0 || res.AvgBuildDurationMs != 0 {
+		t.Errorf("expected no failure rate or duration without finished builds, got %f and %dms", res.FailureRate, res.AvgBuildDurationMs)
+	}
+}
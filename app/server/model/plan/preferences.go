@@ -0,0 +1,338 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"plandex-server/types"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// feedback bodies are cut off after this, so one large rejection or rewrite doesn't crowd out the rest when they're distilled
+const maxChangeFeedbackChars = 3000
+
+// preferences are distilled once a scope has at least this much new feedback
+const minPreferenceFeedback = 5
+
+// the most feedback distilled at once. Older pending feedback is distilled the next time.
+const maxDistillFeedback = 50
+
+const distillPreferencesTimeout = 2 * time.Minute
+
+// scopes with a distill in progress, so a burst of prompts doesn't start several
+var distillingPreferences sync.Map
+
+// RejectionFeedback returns feedback for the pending results that are about to be rejected: those for the given paths, or all of them if paths is nil
+func RejectionFeedback(orgId, userId, planId string, results []*db.PlanFileResult, paths map[string]bool) []*db.ChangeFeedback {
+	var feedback []*db.ChangeFeedback
+
+	for _, result := range results {
+		if result.AppliedAt != nil || result.RejectedAt != nil {
+			continue
+		}
+		if paths != nil && !paths[result.Path] {
+			continue
+		}
+
+		body := rejectedResultText(result)
+		if body == "" {
+			continue
+		}
+
+		feedback = append(feedback, &db.ChangeFeedback{
+			OrgId:    orgId,
+			UserId:   userId,
+			PlanId:   planId,
+			ResultId: result.Id,
+			Kind:     shared.ChangeFeedbackKindReject,
+			Path:     result.Path,
+			Body:     truncateChangeFeedback(body),
+		})
+	}
+
+	return feedback
+}
+
+func rejectedResultText(result *db.PlanFileResult) string {
+	if result.RemovedFile {
+		return "Removed the file."
+	}
+
+	if len(result.Replacements) == 0 {
+		if result.Content == "" {
+			return ""
+		}
+		return fmt.Sprintf("Created the file:\n```\n%s\n```", result.Content)
+	}
+
+	var b strings.Builder
+	for _, replacement := range result.Replacements {
+		if replacement.Failed || replacement.RejectedAt != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "Replaced:\n```\n%s\n```\nWith:\n```\n%s\n```\n\n", replacement.Old, replacement.New)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// EditFeedback returns feedback for context updates that change a file after one of the plan's results for it was applied. The update's body is compared with the body the context was given when the result was applied, so only the first update after an apply counts. updates must have full bodies, not deltas.
+func EditFeedback(orgId, userId, planId string, results []*db.PlanFileResult, contexts []*db.Context, updates shared.UpdateContextRequest) []*db.ChangeFeedback {
+	latestAppliedByPath := map[string]*db.PlanFileResult{}
+	for _, result := range results {
+		if result.AppliedAt == nil {
+			continue
+		}
+		latest := latestAppliedByPath[result.Path]
+		if latest == nil || result.AppliedAt.After(*latest.AppliedAt) {
+			latestAppliedByPath[result.Path] = result
+		}
+	}
+
+	if len(latestAppliedByPath) == 0 {
+		return nil
+	}
+
+	var feedback []*db.ChangeFeedback
+
+	for _, context := range contexts {
+		update := updates[context.Id]
+		if update == nil || context.FilePath == "" {
+			continue
+		}
+
+		result := latestAppliedByPath[context.FilePath]
+
+		// a context that hasn't been updated since the result was applied doesn't have its applied body
+		if result == nil || context.UpdatedAt.Before(*result.AppliedAt) {
+			continue
+		}
+
+		if isFormattingOnlyEdit(context.Body, update.Body) {
+			continue
+		}
+
+		diff, err := db.GetDiffsForBuild(context.Body, update.Body)
+		if err != nil {
+			log.Printf("Error getting diff for edit to %s: %v\n", context.FilePath, err)
+			continue
+		}
+
+		feedback = append(feedback, &db.ChangeFeedback{
+			OrgId:    orgId,
+			UserId:   userId,
+			PlanId:   planId,
+			ResultId: result.Id,
+			Kind:     shared.ChangeFeedbackKindEdit,
+			Path:     context.FilePath,
+			Body:     truncateChangeFeedback(diff),
+		})
+	}
+
+	return feedback
+}
+
+// isFormattingOnlyEdit is true if the bodies only differ in whitespace, like after running a formatter, which says nothing about what the user wanted changed
+func isFormattingOnlyEdit(original, updated string) bool {
+	return strings.Join(strings.Fields(original), " ") == strings.Join(strings.Fields(updated), " ")
+}
+
+func truncateChangeFeedback(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxChangeFeedbackChars {
+		return s
+	}
+	return string(runes[:maxChangeFeedbackChars]) + "\n[truncated]"
+}
+
+// DistillLearnedPreferences updates the user's preferences, and their org's, from the feedback recorded since they were last distilled, once either has enough new feedback. It's meant to run in the background, so errors are only logged.
+func DistillLearnedPreferences(clients map[string]*openai.Client, config shared.ModelRoleConfig, orgId, userId string) {
+	numUser, numOrg, err := db.CountPendingChangeFeedback(orgId, userId)
+	if err != nil {
+		log.Printf("Error counting change feedback: %v\n", err)
+		return
+	}
+
+	client := clients[config.BaseModelConfig.ApiKeyEnvVar]
+	if client == nil {
+		return
+	}
+
+	if numUser >= minPreferenceFeedback {
+		distillPreferencesScope(client, config, orgId, userId)
+	}
+	if numOrg >= minPreferenceFeedback {
+		distillPreferencesScope(client, config, orgId, "")
+	}
+}
+
+// distillPreferencesScope distills the pending feedback for a user, or for the org if userId is empty
+func distillPreferencesScope(client *openai.Client, config shared.ModelRoleConfig, orgId, userId string) {
+	key := orgId + "|" + userId
+	if _, loaded := distillingPreferences.LoadOrStore(key, true); loaded {
+		return
+	}
+	defer distillingPreferences.Delete(key)
+
+	existing, err := db.GetLearnedPreferences(orgId, userId)
+	if err != nil {
+		log.Printf("Error getting learned preferences: %v\n", err)
+		return
+	}
+
+	feedback, err := db.GetPendingChangeFeedback(orgId, userId, maxDistillFeedback)
+	if err != nil {
+		log.Printf("Error getting change feedback: %v\n", err)
+		return
+	}
+
+	if len(feedback) == 0 {
+		return
+	}
+
+	var existingSummary string
+	if existing != nil {
+		existingSummary = existing.Summary
+	}
+
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: prompts.SysDistillPreferences,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompts.GetDistillPreferencesPrompt(existingSummary, formatChangeFeedback(feedback)),
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), distillPreferencesTimeout)
+	defer cancel()
+	reqCtx := model.ApplyRoleConfig(ctx, &modelReq, config)
+
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+	if err != nil {
+		log.Printf("Error distilling learned preferences: %v\n", err)
+		return
+	}
+
+	if len(resp.Choices) == 0 {
+		log.Println("No response distilling learned preferences")
+		return
+	}
+
+	summary := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if strings.EqualFold(strings.Trim(summary, ".*"), "none") {
+		summary = ""
+	}
+
+	ids := make([]string, len(feedback))
+	for i, item := range feedback {
+		ids[i] = item.Id
+	}
+
+	err = db.StoreLearnedPreferences(orgId, userId, summary, ids)
+	if err != nil {
+		log.Printf("Error storing learned preferences: %v\n", err)
+		return
+	}
+
+	scope := "org"
+	if userId != "" {
+		scope = "user"
+	}
+	log.Printf("Distilled %d feedback items into %s preferences for org %s\n", len(feedback), scope, orgId)
+}
+
+// formatChangeFeedback formats feedback for the distill prompt, oldest first, so later feedback reads as the more recent evidence
+func formatChangeFeedback(feedback []*db.ChangeFeedback) string {
+	sorted := make([]*db.ChangeFeedback, len(feedback))
+	copy(sorted, feedback)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	var b strings.Builder
+	for _, item := range sorted {
+		switch item.Kind {
+		case shared.ChangeFeedbackKindReject:
+			fmt.Fprintf(&b, "### Rejected a change to %s\n\n", item.Path)
+		case shared.ChangeFeedbackKindEdit:
+			fmt.Fprintf(&b, "### Edited %s after applying changes (diff from the applied version)\n\n", item.Path)
+		}
+		b.WriteString(item.Body)
+		b.WriteString("\n\n")
+	}
+
+	return b.String()
+}
+
+// loadLearnedPreferences stores the user's and org's learned preferences on the active plan, formatted for a prompt, so the planner and builders for the plan's stream can use them. Preferences are best effort: on error, the plan continues without them.
+func loadLearnedPreferences(planId, branch, orgId, userId string) {
+	text, err := learnedPreferencesText(orgId, userId)
+	if err != nil {
+		log.Printf("Error getting learned preferences: %v\n", err)
+		return
+	}
+
+	numTokens := 0
+	if text != "" {
+		numTokens, err = shared.GetNumTokens(text)
+		if err != nil {
+			log.Printf("Error getting num tokens for learned preferences: %v\n", err)
+			return
+		}
+	}
+
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		ap.PreferencesPrompt = text
+		ap.PreferencesTokens = numTokens
+	})
+}
+
+func learnedPreferencesText(orgId, userId string) (string, error) {
+	orgPrefs, err := db.GetLearnedPreferences(orgId, "")
+	if err != nil {
+		return "", err
+	}
+
+	userPrefs, err := db.GetLearnedPreferences(orgId, userId)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if orgPrefs != nil && orgPrefs.Summary != "" {
+		b.WriteString("**Your organization's preferences:**\n")
+		b.WriteString(orgPrefs.Summary)
+		b.WriteString("\n\n")
+	}
+	if userPrefs != nil && userPrefs.Summary != "" {
+		b.WriteString("**The user's preferences:**\n")
+		b.WriteString(userPrefs.Summary)
+		b.WriteString("\n\n")
+	}
+
+	return b.String(), nil
+}
+
+// learnedPreferencesPrompt adds the active plan's learned preferences to a build prompt
+func (fileState *activeBuildStreamFileState) learnedPreferencesPrompt() string {
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil || activePlan.PreferencesPrompt == "" {
+		return ""
+	}
+	return prompts.BuildPreferencesPrompt + activePlan.PreferencesPrompt
+}
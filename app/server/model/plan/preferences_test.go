@@ -0,0 +1,68 @@
+package plan
+
+import (
+	"plandex-server/db"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestRejectionFeedback(t *testing.T) {
+	now := time.Now()
+	results := []*db.PlanFileResult{
+		{Id: "pending", Path: "main.go", Replacements: []*shared.Replacement{{Old: "if err != nil {", New: "if err != nil { panic(err)"}}},
+		{Id: "new-file", Path: "util.go", Content: "package main"},
+		{Id: "applied", Path: "main.go", AppliedAt: &now, Replacements: []*shared.Replacement{{Old: "a", New: "b"}}},
+		{Id: "other-path", Path: "other.go", Replacements: []*shared.Replacement{{Old: "a", New: "b"}}},
+	}
+
+	feedback := RejectionFeedback("org", "user", "plan", results, map[string]bool{"main.go": true, "util.go": true})
+
+	if len(feedback) != 2 {
+		t.Fatalf("expected feedback for the 2 pending results on the rejected paths, got %d", len(feedback))
+	}
+	if feedback[0].ResultId != "pending" || !strings.Contains(feedback[0].Body, "panic(err)") {
+		t.Errorf("expected the replacement in the feedback, got %q", feedback[0].Body)
+	}
+	if !strings.HasPrefix(feedback[1].Body, "Created the file") {
+		t.Errorf("expected a new file's content in the feedback, got %q", feedback[1].Body)
+	}
+
+	if all := RejectionFeedback("org", "user", "plan", results, nil); len(all) != 3 {
+		t.Errorf("expected feedback for every pending result, got %d", len(all))
+	}
+}
+
+func TestEditFeedback(t *testing.T) {
+	appliedAt := time.Now()
+	results := []*db.PlanFileResult{
+		{Id: "result", Path: "main.go", AppliedAt: &appliedAt},
+	}
+	contexts := []*db.Context{
+		{Id: "edited", FilePath: "main.go", Body: "func main() {\n\tfmt.Println(\"hi\")\n}\n", UpdatedAt: appliedAt.Add(time.Second)},
+	}
+
+	updates := shared.UpdateContextRequest{
+		"edited": {Body: "func main() {\n\tlog.Println(\"hi\")\n}\n"},
+	}
+	feedback := EditFeedback("org", "user", "plan", results, contexts, updates)
+	if len(feedback) != 1 {
+		t.Fatalf("expected feedback for the edit, got %d", len(feedback))
+	}
+	if !strings.Contains(feedback[0].Body, "+\tlog.Println") {
+		t.Errorf("expected the diff in the feedback, got %q", feedback[0].Body)
+	}
+
+	updates["edited"].Body = "func main() {\n    fmt.Println(\"hi\")\n}\n"
+	if feedback := EditFeedback("org", "user", "plan", results, contexts, updates); len(feedback) != 0 {
+		t.Errorf("expected no feedback for a formatting-only edit, got %d", len(feedback))
+	}
+
+	contexts[0].UpdatedAt = appliedAt.Add(-time.Second)
+	updates["edited"].Body = "package other"
+	if feedback := EditFeedback("org", "user", "plan", results, contexts, updates); len(feedback) != 0 {
+		t.Errorf("expected no feedback for a context that wasn't updated by the apply, got %d", len(feedback))
+	}
+}
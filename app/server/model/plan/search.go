@@ -0,0 +1,199 @@
+package plan
+
+import (
+	"log"
+	"plandex-server/db"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const searchSnippetChars = 160
+
+// plan content is read from git on every search, so a search reads at most this many plans and this much content -- plans are listed most recently updated first, so older plans are what's left out
+const searchMaxPlans = 500
+const searchMaxBytes = 64 * 1024 * 1024
+
+// Search matches a query against the names, prompts, replies, and changed file paths of the given plans. Every term in the query has to appear in a match, ignoring case, and a quoted phrase counts as one term. Results are ranked by how often the terms appear, weighted by where they were found, then by how recent they are.
+func Search(orgId string, plans []*db.Plan, query string, limit int) *shared.SearchResponse {
+	return search(orgId, plans, query, limit, searchMaxPlans, searchMaxBytes)
+}
+
+func search(orgId string, plans []*db.Plan, query string, limit, maxPlans int, maxBytes int64) *shared.SearchResponse {
+	terms := parseSearchTerms(query)
+	res := &shared.SearchResponse{}
+
+	if len(terms) == 0 {
+		res.NumPlansSearched = len(plans)
+		return res
+	}
+
+	if len(plans) > maxPlans {
+		plans = plans[:maxPlans]
+		res.Partial = true
+	}
+
+	var numBytes int64
+
+	for _, plan := range plans {
+		if numBytes >= maxBytes {
+			res.Partial = true
+			break
+		}
+		res.NumPlansSearched++
+
+		if score := scoreSearchText(plan.Name, terms); score > 0 {
+			res.Results = append(res.Results, &shared.SearchResult{
+				PlanId:    plan.Id,
+				PlanName:  plan.Name,
+				ProjectId: plan.ProjectId,
+				Kind:      shared.SearchResultKindPlanName,
+				Snippet:   plan.Name,
+				Score:     score * searchKindWeight(shared.SearchResultKindPlanName),
+				CreatedAt: plan.UpdatedAt,
+			})
+		}
+
+		planDocs, err := db.GetPlanSearchDocs(orgId, plan.Id, maxBytes-numBytes)
+		if err != nil {
+			// one unreadable plan shouldn't fail the whole search
+			log.Printf("Error getting search docs for plan %s: %v\n", plan.Id, err)
+			continue
+		}
+
+		numBytes += planDocs.NumBytes
+		if planDocs.Truncated {
+			// searching past a plan that didn't fit would mean skipping over it, so stop here
+			res.Partial = true
+			numBytes = maxBytes
+		}
+
+		for _, doc := range planDocs.Docs {
+			score := scoreSearchText(doc.Text, terms)
+			if score == 0 {
+				continue
+			}
+
+			res.Results = append(res.Results, &shared.SearchResult{
+				PlanId:     plan.Id,
+				PlanName:   plan.Name,
+				ProjectId:  plan.ProjectId,
+				Branch:     doc.Branch,
+				Kind:       doc.Kind,
+				MessageNum: doc.MessageNum,
+				Path:       doc.Path,
+				Snippet:    getSearchSnippet(doc.Text, terms),
+				Score:      score * searchKindWeight(doc.Kind),
+				CreatedAt:  doc.CreatedAt,
+			})
+		}
+	}
+
+	sort.SliceStable(res.Results, func(i, j int) bool {
+		if res.Results[i].Score != res.Results[j].Score {
+			return res.Results[i].Score > res.Results[j].Score
+		}
+		return res.Results[i].CreatedAt.After(res.Results[j].CreatedAt)
+	})
+
+	if len(res.Results) > limit {
+		res.Results = res.Results[:limit]
+	}
+
+	return res
+}
+
+// parseSearchTerms lowercases a query and splits it on whitespace, keeping double-quoted phrases together
+func parseSearchTerms(query string) []string {
+	var terms []string
+	var current strings.Builder
+	inQuotes := false
+
+	flush := func() {
+		term := strings.TrimSpace(current.String())
+		if term != "" {
+			terms = append(terms, term)
+		}
+		current.Reset()
+	}
+
+	for _, r := range strings.ToLower(query) {
+		switch {
+		case r == '"':
+			flush()
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return terms
+}
+
+// scoreSearchText returns the total number of times the terms appear in text, or 0 if any term is missing
+func scoreSearchText(text string, terms []string) int {
+	lower := strings.ToLower(text)
+	score := 0
+
+	for _, term := range terms {
+		n := strings.Count(lower, term)
+		if n == 0 {
+			return 0
+		}
+		score += n
+	}
+
+	return score
+}
+
+func searchKindWeight(kind shared.SearchResultKind) int {
+	switch kind {
+	case shared.SearchResultKindPlanName:
+		return 4
+	case shared.SearchResultKindPrompt, shared.SearchResultKindFilePath:
+		return 2
+	}
+	return 1
+}
+
+// getSearchSnippet returns a single line of text around the first match of the first term
+func getSearchSnippet(text string, terms []string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+
+	if len(runes) <= searchSnippetChars {
+		return text
+	}
+
+	lower := strings.ToLower(text)
+	idx := strings.Index(lower, terms[0])
+	runeIdx := 0
+	if idx > 0 {
+		runeIdx = len([]rune(lower[:idx]))
+	}
+
+	start := runeIdx - searchSnippetChars/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + searchSnippetChars
+	if end > len(runes) {
+		end = len(runes)
+		start = end - searchSnippetChars
+	}
+
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+
+	return snippet
+}
@@ -0,0 +1,74 @@
+package plan
+
+import (
+	"plandex-server/db"
+	"strings"
+	"testing"
+)
+
+func TestParseSearchTerms(t *testing.T) {
+	terms := parseSearchTerms(`Rate  limiter "redis client" `)
+
+	expected := []string{"rate", "limiter", "redis client"}
+	if len(terms) != len(expected) {
+		t.Fatalf("expected %q, got %q", expected, terms)
+	}
+	for i := range expected {
+		if terms[i] != expected[i] {
+			t.Errorf("expected %q, got %q", expected, terms)
+		}
+	}
+}
+
+func TestScoreSearchText(t *testing.T) {
+	text := "Add a rate limiter to the API. The limiter uses a Redis client."
+
+	if score := scoreSearchText(text, []string{"limiter", "redis client"}); score != 3 {
+		t.Errorf("expected score 3, got %d", score)
+	}
+
+	if score := scoreSearchText(text, []string{"limiter", "postgres"}); score != 0 {
+		t.Errorf("expected no match when a term is missing, got %d", score)
+	}
+}
+
+func TestGetSearchSnippet(t *testing.T) {
+	short := "add a\nrate limiter"
+	if snippet := getSearchSnippet(short, []string{"rate"}); snippet != "add a rate limiter" {
+		t.Errorf("expected short text on one line, got %q", snippet)
+	}
+
+	long := strings.Repeat("padding ", 50) + "the rate limiter" + strings.Repeat(" more", 50)
+	snippet := getSearchSnippet(long, []string{"rate limiter"})
+
+	if !strings.Contains(snippet, "rate limiter") {
+		t.Errorf("expected snippet to contain the match, got %q", snippet)
+	}
+	if !strings.HasPrefix(snippet, "…") || !strings.HasSuffix(snippet, "…") {
+		t.Errorf("expected snippet to be cut on both sides, got %q", snippet)
+	}
+}
+
+func TestSearchLimits(t *testing.T) {
+	// plans without a repo on disk are searched by name only
+	plans := []*db.Plan{
+		{Id: "plan-1", Name: "rate limiter"},
+		{Id: "plan-2", Name: "redis rate limiter"},
+		{Id: "plan-3", Name: "old rate limiter"},
+	}
+
+	res := search("org", plans, "limiter", 10, 2, searchMaxBytes)
+	if !res.Partial || res.NumPlansSearched != 2 || len(res.Results) != 2 {
+		t.Errorf("expected 2 of 3 plans searched and a partial result, got %d plans, %d results, partial %v", res.NumPlansSearched, len(res.Results), res.Partial)
+	}
+
+	res = search("org", plans, "limiter", 10, searchMaxPlans, searchMaxBytes)
+	if res.Partial || res.NumPlansSearched != 3 || len(res.Results) != 3 {
+		t.Errorf("expected every plan searched, got %d plans, %d results, partial %v", res.NumPlansSearched, len(res.Results), res.Partial)
+	}
+
+	res = search("org", plans, "limiter", 10, searchMaxPlans, 0)
+	if !res.Partial || res.NumPlansSearched != 0 {
+		t.Errorf("expected no plans searched without a byte budget, got %d plans, partial %v", res.NumPlansSearched, res.Partial)
+	}
+}
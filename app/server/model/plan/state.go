@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"plandex-server/db"
+	"plandex-server/model"
 	"plandex-server/types"
 	"strings"
 	"time"
@@ -19,8 +20,8 @@ func GetActivePlan(planId, branch string) *types.ActivePlan {
 	return activePlans.Get(strings.Join([]string{planId, branch}, "|"))
 }
 
-func CreateActivePlan(orgId, userId, planId, branch, prompt string, buildOnly bool) *types.ActivePlan {
-	activePlan := types.NewActivePlan(orgId, userId, planId, branch, prompt, buildOnly)
+func CreateActivePlan(orgId, userId, planId, branch, prompt string, buildOnly bool, priority model.CallPriority) *types.ActivePlan {
+	activePlan := types.NewActivePlan(model.WithCallPriority(context.Background(), priority), orgId, userId, planId, branch, prompt, buildOnly)
 	key := strings.Join([]string{planId, branch}, "|")
 
 	activePlans.Set(key, activePlan)
@@ -0,0 +1,42 @@
+package plan
+
+import (
+	"fmt"
+	"log"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// Steer queues a note for the planner. The reply that's streaming isn't interrupted; the note is added to the planner's next call and every call after it until the plan's stream ends.
+func Steer(planId, branch, note string) error {
+	active := GetActivePlan(planId, branch)
+
+	if active == nil {
+		return fmt.Errorf("no active plan with id %s", planId)
+	}
+
+	note = strings.TrimSpace(note)
+
+	if note == "" {
+		return fmt.Errorf("note is empty")
+	}
+
+	if len(note) > shared.MaxSteeringNoteChars {
+		return fmt.Errorf("note is longer than %d characters", shared.MaxSteeringNoteChars)
+	}
+
+	// builds only apply changes the planner already wrote, so a note sent after the last reply would never reach a model
+	if active.BuildOnly || active.RepliesFinished {
+		return fmt.Errorf("plan has no more replies in this stream")
+	}
+
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		ap.SteeringNotes = append(ap.SteeringNotes, note)
+	})
+
+	log.Printf("Queued steering note for plan %s on branch %s\n", planId, branch)
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package plan
+
+import (
+	"plandex-server/types"
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestSteer(t *testing.T) {
+	active := &types.ActivePlan{Id: "steer-plan", Branch: "main"}
+	activePlans.Set("steer-plan|main", active)
+	defer activePlans.Delete("steer-plan|main")
+
+	if err := Steer("steer-plan", "main", "  don't touch the tests  "); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(active.SteeringNotes) != 1 || active.SteeringNotes[0] != "don't touch the tests" {
+		t.Fatalf("got notes %q", active.SteeringNotes)
+	}
+
+	if err := Steer("steer-plan", "main", "   "); err == nil {
+		t.Error("empty note should be rejected")
+	}
+
+	if err := Steer("steer-plan", "main", strings.Repeat("a", shared.MaxSteeringNoteChars+1)); err == nil {
+		t.Error("long note should be rejected")
+	}
+
+	active.RepliesFinished = true
+	if err := Steer("steer-plan", "main", "use the existing logger"); err == nil {
+		t.Error("note should be rejected once replies are finished")
+	}
+}
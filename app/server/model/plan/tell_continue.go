@@ -0,0 +1,72 @@
+package plan
+
+import (
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// applyAutoContinuePolicy decides whether a plan the planner wants to continue actually continues, based on the plan's auto-continue setting. With 'ask', the user is prompted for the next step and this waits for their response. ok is false if the plan stopped or errored while deciding, in which case the caller should return.
+func (state *activeTellStreamState) applyAutoContinuePolicy(nextTask string) (willContinue, ok bool) {
+	planId := state.plan.Id
+	branch := state.branch
+
+	switch state.settings.GetAutoContinuePolicy() {
+	case shared.AutoContinueNever:
+		log.Println("Auto-continue policy is never, won't continue plan")
+		return false, true
+	case shared.AutoContinueAsk:
+	default:
+		return true, true
+	}
+
+	active := GetActivePlan(planId, branch)
+	if active == nil {
+		log.Printf("Active plan not found for plan ID %s and branch %s\n", planId, branch)
+		return false, false
+	}
+
+	err := db.SetPlanStatus(planId, branch, shared.PlanStatusPromptingContinue, "")
+	if err != nil {
+		log.Printf("Error setting plan %s status to prompting continue: %v\n", planId, err)
+		active.StreamDoneCh <- &shared.ApiError{
+			Type:   shared.ApiErrorTypeOther,
+			Status: http.StatusInternalServerError,
+			Msg:    "Error setting plan status to prompting continue",
+		}
+		return false, false
+	}
+
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		ap.PromptingContinue = true
+		ap.ContinueNextTask = nextTask
+	})
+
+	log.Println("Prompting user to continue plan")
+
+	active.Stream(shared.StreamMessage{
+		Type:              shared.StreamMessagePromptContinue,
+		PromptingContinue: true,
+		ContinueNextTask:  nextTask,
+	})
+
+	var userChoice bool
+	select {
+	case <-active.Ctx.Done():
+		log.Println("Context cancelled while waiting for continue response")
+		return false, false
+	case userChoice = <-active.ContinueResponseCh:
+	}
+
+	log.Printf("User choice for continue: %v\n", userChoice)
+
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		ap.PromptingContinue = false
+		ap.ContinueNextTask = ""
+	})
+
+	return userChoice, true
+}
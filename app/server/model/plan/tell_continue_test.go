@@ -0,0 +1,32 @@
+package plan
+
+import (
+	"plandex-server/db"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestApplyAutoContinuePolicy(t *testing.T) {
+	tests := []struct {
+		policy       shared.AutoContinuePolicy
+		willContinue bool
+	}{
+		{"", true},
+		{shared.AutoContinueAlways, true},
+		{shared.AutoContinueNever, false},
+	}
+
+	for _, tt := range tests {
+		state := &activeTellStreamState{
+			plan:     &db.Plan{Id: "plan"},
+			branch:   "main",
+			settings: &shared.PlanSettings{AutoContinue: tt.policy},
+		}
+
+		willContinue, ok := state.applyAutoContinuePolicy("next task")
+		if !ok || willContinue != tt.willContinue {
+			t.Errorf("policy %q: got (%v, %v), want (%v, true)", tt.policy, willContinue, ok, tt.willContinue)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"plandex-server/db"
 	"plandex-server/model"
@@ -20,7 +21,7 @@ import (
 func Tell(clients map[string]*openai.Client, plan *db.Plan, branch string, auth *types.ServerAuth, req *shared.TellPlanRequest) error {
 	log.Printf("Tell: Called with plan ID %s on branch %s\n", plan.Id, branch)
 
-	_, err := activatePlan(clients, plan, branch, auth, req.Prompt, false)
+	_, err := activatePlan(clients, plan, branch, auth, req.Prompt, false, model.CallPriorityForStream(req.ConnectStream))
 
 	if err != nil {
 		log.Printf("Error activating plan: %v\n", err)
@@ -85,6 +86,25 @@ func execTellPlan(
 		}
 	}
 
+	if missingFileResponse == "" {
+		trialErr, err := GetGuestTrialTokensError(currentOrgId, auth.User)
+
+		if err != nil {
+			log.Printf("execTellPlan: error checking trial limits: %v\n", err)
+			active.StreamDoneCh <- &shared.ApiError{
+				Type:   shared.ApiErrorTypeOther,
+				Status: http.StatusInternalServerError,
+				Msg:    "Error checking trial limits",
+			}
+			return
+		}
+
+		if trialErr != nil {
+			active.StreamDoneCh <- trialErr
+			return
+		}
+	}
+
 	planId := plan.Id
 	err := db.SetPlanStatus(planId, branch, shared.PlanStatusReplying, "")
 	if err != nil {
@@ -127,6 +147,12 @@ func execTellPlan(
 				}
 			}
 		})
+
+		loadLearnedPreferences(planId, branch, currentOrgId, currentUserId)
+		loadProjectConventions(planId, branch, req.Conventions)
+
+		// feedback recorded since the last prompt is distilled in time for the next one
+		go DistillLearnedPreferences(clients, state.settings.ModelPack.PlanSummary, currentOrgId, currentUserId)
 	} else if missingFileResponse == "" {
 		// reset current reply content and num tokens
 		UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
@@ -152,7 +178,7 @@ func execTellPlan(
 		}
 	}
 
-	modelContextText, modelContextTokens, err := lib.FormatModelContext(state.modelContext)
+	modelContextText, modelContextTokens, err := lib.FormatModelContextWithLineNums(state.modelContext)
 	if err != nil {
 		err = fmt.Errorf("error formatting model modelContext: %v", err)
 		log.Println(err)
@@ -166,9 +192,12 @@ func execTellPlan(
 	}
 
 	systemMessageText := prompts.SysCreate + modelContextText
-	systemMessage := openai.ChatCompletionMessage{
-		Role:    openai.ChatMessageRoleSystem,
-		Content: systemMessageText,
+
+	if len(state.settings.Workspaces) > 0 {
+		systemMessageText += prompts.WorkspacesPrompt
+		for _, root := range state.settings.Workspaces {
+			systemMessageText += fmt.Sprintf("- %s\n", root)
+		}
 	}
 
 	if len(active.SkippedPaths) > 0 {
@@ -178,6 +207,18 @@ func execTellPlan(
 		}
 	}
 
+	if len(active.SteeringNotes) > 0 {
+		systemMessageText += prompts.SteeringNotesPrompt
+		for _, note := range active.SteeringNotes {
+			systemMessageText += fmt.Sprintf("- %s\n", note)
+		}
+	}
+
+	systemMessage := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: systemMessageText,
+	}
+
 	state.messages = []openai.ChatCompletionMessage{
 		systemMessage,
 	}
@@ -235,24 +276,45 @@ func execTellPlan(
 		promptTokens = prompts.PromptWrapperTokens + numPromptTokens
 	}
 
-	state.tokensBeforeConvo = prompts.CreateSysMsgNumTokens + modelContextTokens + state.latestSummaryTokens + promptTokens
+	tokensBeforeKnowledge := prompts.CreateSysMsgNumTokens + modelContextTokens + state.latestSummaryTokens + promptTokens
+
+	// entries retrieved for the prompt are kept on the active plan, so auto-continued replies get them too
+	if iteration == 0 && missingFileResponse == "" && !req.IsUserContinue {
+		state.retrieveKnowledge(req.Prompt, knowledgeTokenBudget(state.settings, tokensBeforeKnowledge))
+	}
+
+	knowledgeTokens := 0
+	if active.KnowledgePrompt != "" {
+		state.messages[0].Content += prompts.KnowledgePrompt + active.KnowledgePrompt
+		knowledgeTokens = active.KnowledgeTokens
+	}
+
+	if active.PreferencesPrompt != "" {
+		state.messages[0].Content += prompts.LearnedPreferencesPrompt + active.PreferencesPrompt
+	}
+
+	if active.ConventionsPrompt != "" {
+		state.messages[0].Content += prompts.ProjectConventionsPrompt + active.ConventionsPrompt
+	}
+
+	state.tokensBeforeConvo = tokensBeforeKnowledge + knowledgeTokens + active.PreferencesTokens + active.ConventionsTokens
 
 	// print out breakdown of token usage
 	log.Printf("System message tokens: %d\n", prompts.CreateSysMsgNumTokens)
 	log.Printf("Context tokens: %d\n", modelContextTokens)
 	log.Printf("Prompt tokens: %d\n", promptTokens)
+	log.Printf("Knowledge tokens: %d\n", knowledgeTokens)
+	log.Printf("Learned preferences tokens: %d\n", active.PreferencesTokens)
+	log.Printf("Project conventions tokens: %d\n", active.ConventionsTokens)
 	log.Printf("Latest summary tokens: %d\n", state.latestSummaryTokens)
 	log.Printf("Total tokens before convo: %d\n", state.tokensBeforeConvo)
+	log.Printf("Total tokens before convo for %s tokenizer: %d\n", state.settings.GetPlannerTokenizer(), state.settings.GetPlannerTokens(state.tokensBeforeConvo))
 
-	if state.tokensBeforeConvo > state.settings.GetPlannerEffectiveMaxTokens() {
+	if state.settings.GetPlannerTokens(state.tokensBeforeConvo) > state.settings.GetPlannerEffectiveMaxTokens() {
 		// token limit already exceeded before adding conversation
 		err := fmt.Errorf("token limit exceeded before adding conversation")
 		log.Printf("Error: %v\n", err)
-		active.StreamDoneCh <- &shared.ApiError{
-			Type:   shared.ApiErrorTypeOther,
-			Status: http.StatusInternalServerError,
-			Msg:    "Token limit exceeded before adding conversation",
-		}
+		active.StreamDoneCh <- model.NewApiError(shared.ApiErrorTypeContextTooLong, "Token limit exceeded before adding conversation")
 		return
 	}
 
@@ -338,7 +400,7 @@ func execTellPlan(
 
 		state.replyParser.AddChunk(active.CurrentReplyContent, true)
 		res := state.replyParser.Read()
-		currentFile := res.CurrentFilePath
+		currentFile := state.resolveReplyPath(active, res.CurrentFilePath)
 
 		log.Printf("Current file: %s\n", currentFile)
 		// log.Println("Current reply content:\n", active.CurrentReplyContent)
@@ -404,25 +466,29 @@ func execTellPlan(
 	// }
 
 	modelReq := openai.ChatCompletionRequest{
-		Model:       state.settings.ModelPack.Planner.BaseModelConfig.ModelName,
-		Messages:    state.messages,
-		Stream:      true,
-		Temperature: state.settings.ModelPack.Planner.Temperature,
-		TopP:        state.settings.ModelPack.Planner.TopP,
+		Model:    state.settings.ModelPack.Planner.BaseModelConfig.ModelName,
+		Messages: state.messages,
+		Stream:   true,
 	}
+	reqCtx := model.ApplyRoleConfig(active.ModelStreamCtx, &modelReq, state.settings.ModelPack.Planner.ModelRoleConfig)
+	state.reportedUsage = model.ReportedUsageFromContext(reqCtx)
+
+	// models that can't stream send their whole reply at once, so the client is shown how long the model has been thinking until it arrives
+	reqCtx = model.WithWaitProgress(reqCtx, func(elapsed time.Duration) {
+		active.Stream(shared.StreamMessage{
+			Type:             shared.StreamMessageReasoning,
+			ReasoningSeconds: int(elapsed.Seconds()),
+		})
+	})
 
 	envVar := state.settings.ModelPack.Planner.BaseModelConfig.ApiKeyEnvVar
 	client := clients[envVar]
 
-	stream, err := model.CreateChatCompletionStreamWithRetries(client, active.ModelStreamCtx, modelReq)
+	stream, err := model.CreateChatCompletionStreamWithRetries(client, reqCtx, modelReq)
 	if err != nil {
 		log.Printf("Error starting reply stream: %v\n", err)
 
-		active.StreamDoneCh <- &shared.ApiError{
-			Type:   shared.ApiErrorTypeOther,
-			Status: http.StatusInternalServerError,
-			Msg:    "Error starting reply stream: " + err.Error(),
-		}
+		active.StreamDoneCh <- model.ModelApiError("Error starting reply stream: "+err.Error(), err)
 		return
 	}
 
@@ -459,6 +525,8 @@ func execTellPlan(
 				modelContext:  state.modelContext,
 			}
 
+			buildState.streamBuildEstimate(pendingBuildsByPath)
+
 			for _, pendingBuilds := range pendingBuildsByPath {
 				buildState.queueBuilds(pendingBuilds)
 			}
@@ -0,0 +1,160 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// the most entry titles the planner is shown when it decides what to search for
+const maxKnowledgeSearchTitles = 200
+
+// the most entries added to a prompt
+const maxKnowledgeResults = 8
+
+// retrieveKnowledge gives the planner a search tool for the org's knowledge base before it replies to a new prompt, and stores the entries it finds on the active plan, so they're added to every planner call in the stream. maxTokens limits the retrieved entries. Retrieval is best effort: on any error, the plan continues without them.
+func (state *activeTellStreamState) retrieveKnowledge(prompt string, maxTokens int) {
+	active := GetActivePlan(state.plan.Id, state.branch)
+	if active == nil {
+		return
+	}
+
+	if maxTokens <= 0 || strings.TrimSpace(prompt) == "" {
+		return
+	}
+
+	entries, err := db.ListKnowledgeEntries(state.currentOrgId)
+	if err != nil {
+		log.Printf("Error getting knowledge entries: %v\n", err)
+		return
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	var titles strings.Builder
+	for i, entry := range entries {
+		if i >= maxKnowledgeSearchTitles {
+			break
+		}
+		fmt.Fprintf(&titles, "- %s (%s)\n", entry.Title, entry.Kind)
+	}
+
+	queries, err := state.knowledgeSearchQueries(active, titles.String(), prompt)
+	if err != nil {
+		log.Printf("Error getting knowledge search queries: %v\n", err)
+		return
+	}
+
+	if len(queries) == 0 {
+		log.Println("Planner didn't search the knowledge base")
+		return
+	}
+
+	log.Printf("Searching knowledge base for: %v\n", queries)
+
+	res := searchKnowledgeEntries(active.Ctx, state.clients, entries, queries, maxKnowledgeResults)
+
+	text, numTokens := formatKnowledgeResults(res.Results, maxTokens)
+	if text == "" {
+		log.Println("No relevant knowledge entries found")
+		return
+	}
+
+	log.Printf("Adding %d tokens of knowledge entries to the prompt\n", numTokens)
+
+	UpdateActivePlan(state.plan.Id, state.branch, func(ap *types.ActivePlan) {
+		ap.KnowledgePrompt = text
+		ap.KnowledgeTokens = numTokens
+	})
+}
+
+// knowledgeSearchQueries calls the planner with the searchKnowledgeBase tool, returning the queries it wants to search, which are empty if it doesn't think the knowledge base will help
+func (state *activeTellStreamState) knowledgeSearchQueries(active *types.ActivePlan, titles, prompt string) ([]string, error) {
+	config := state.settings.ModelPack.Planner
+
+	client := state.clients[config.BaseModelConfig.ApiKeyEnvVar]
+	if client == nil {
+		return nil, fmt.Errorf("no client for %s", config.BaseModelConfig.ApiKeyEnvVar)
+	}
+
+	var responseFormat *openai.ChatCompletionResponseFormat
+	if config.BaseModelConfig.HasJsonResponseMode {
+		responseFormat = &openai.ChatCompletionResponseFormat{Type: "json_object"}
+	}
+
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Tools: []openai.Tool{
+			{
+				Type:     "function",
+				Function: &prompts.SearchKnowledgeBaseFn,
+			},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type: "function",
+			Function: openai.ToolFunction{
+				Name: prompts.SearchKnowledgeBaseFn.Name,
+			},
+		},
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: prompts.GetKnowledgeSearchPrompt(titles, prompt),
+			},
+		},
+		ResponseFormat: responseFormat,
+	}
+	reqCtx := model.ApplyRoleConfig(active.ModelStreamCtx, &modelReq, config.ModelRoleConfig)
+
+	resp, err := model.CreateChatCompletionWithRetries(client, reqCtx, modelReq)
+	if err != nil {
+		return nil, fmt.Errorf("error calling model: %v", err)
+	}
+
+	var strRes string
+	for _, choice := range resp.Choices {
+		if len(choice.Message.ToolCalls) == 1 &&
+			choice.Message.ToolCalls[0].Function.Name == prompts.SearchKnowledgeBaseFn.Name {
+			strRes = choice.Message.ToolCalls[0].Function.Arguments
+			break
+		}
+	}
+
+	if strRes == "" {
+		return nil, fmt.Errorf("no %s function call found in response", prompts.SearchKnowledgeBaseFn.Name)
+	}
+
+	var res struct {
+		Queries []string `json:"queries"`
+	}
+	err = json.Unmarshal([]byte(strRes), &res)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %v", err)
+	}
+
+	if len(res.Queries) > 3 {
+		res.Queries = res.Queries[:3]
+	}
+
+	return res.Queries, nil
+}
+
+// knowledgeTokenBudget is how many tokens of knowledge entries can be added to a prompt: at most shared.MaxKnowledgeRetrievalTokens, and at most a quarter of what's left of the planner's limit after the rest of the prompt, so the conversation still has room
+func knowledgeTokenBudget(settings *shared.PlanSettings, tokensBeforeKnowledge int) int {
+	remaining := settings.GetPlannerEffectiveMaxTokens() - settings.GetPlannerTokens(tokensBeforeKnowledge)
+	budget := remaining / 4
+	if budget > shared.MaxKnowledgeRetrievalTokens {
+		budget = shared.MaxKnowledgeRetrievalTokens
+	}
+	return budget
+}
@@ -61,9 +61,10 @@ func (state *activeTellStreamState) loadTellPlan() error {
 	var convo []*db.ConvoMessage
 	var summaries []*db.ConvoSummary
 	var settings *shared.PlanSettings
+	var trustPolicy *shared.EffectiveTrustPolicy
 	var latestSummaryTokens int
 
-	// get name for plan and rename it's a draft
+	// get settings and trust policy, plus name for plan and rename it's a draft or its name came from a prompt that didn't say much
 	go func() {
 		res, err := db.GetPlanSettings(plan, true)
 		if err != nil {
@@ -73,11 +74,21 @@ func (state *activeTellStreamState) loadTellPlan() error {
 		}
 		settings = res
 
-		if plan.Name == "draft" {
+		trustPolicy, err = db.GetEffectiveTrustPolicy(plan.OrgId, plan.ProjectId)
+		if err != nil {
+			log.Printf("Error getting trust policy: %v\n", err)
+			errCh <- fmt.Errorf("error getting trust policy: %v", err)
+			return
+		}
+
+		isDraft := plan.Name == "draft"
+		substantive := model.IsSubstantivePrompt(req.Prompt)
+
+		if isDraft || (plan.AutoNamePending && substantive) {
 			envVar := settings.ModelPack.Namer.BaseModelConfig.ApiKeyEnvVar
 			client := clients[envVar]
 
-			name, err := model.GenPlanName(client, settings.ModelPack.Namer, req.Prompt)
+			name, err := model.GenPlanName(client, settings.ModelPack.Namer, req.Prompt, model.ResponseCacheOwner{OrgId: currentOrgId, UserId: currentUserId})
 
 			if err != nil {
 				log.Printf("Error generating plan name: %v\n", err)
@@ -102,7 +113,7 @@ func (state *activeTellStreamState) loadTellPlan() error {
 				}
 			}()
 
-			err = db.RenamePlan(planId, name, tx)
+			_, err = db.RenamePlanUnique(currentOrgId, planId, name, !substantive, tx)
 
 			if err != nil {
 				log.Printf("Error renaming plan: %v\n", err)
@@ -110,12 +121,14 @@ func (state *activeTellStreamState) loadTellPlan() error {
 				return
 			}
 
-			err = db.IncNumNonDraftPlans(currentUserId, tx)
+			if isDraft {
+				err = db.IncNumNonDraftPlans(currentUserId, tx)
 
-			if err != nil {
-				log.Printf("Error incrementing num non draft plans: %v\n", err)
-				errCh <- fmt.Errorf("error incrementing num non draft plans: %v", err)
-				return
+				if err != nil {
+					log.Printf("Error incrementing num non draft plans: %v\n", err)
+					errCh <- fmt.Errorf("error incrementing num non draft plans: %v", err)
+					return
+				}
 			}
 
 			err = tx.Commit()
@@ -152,6 +165,14 @@ func (state *activeTellStreamState) loadTellPlan() error {
 			return
 		}
 		convo = res
+
+		for _, msg := range convo {
+			if msg.Stale {
+				errCh <- fmt.Errorf("an earlier prompt was edited, so message %d and later are stale—use 'plandex rerun' to replay from the edit or 'plandex rewind' to undo it", msg.Num)
+				return
+			}
+		}
+
 		UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
 			ap.MessageNum = len(convo)
 		})
@@ -293,6 +314,7 @@ func (state *activeTellStreamState) loadTellPlan() error {
 	state.summaries = summaries
 	state.latestSummaryTokens = latestSummaryTokens
 	state.settings = settings
+	state.trustPolicy = trustPolicy
 
 	return nil
 }
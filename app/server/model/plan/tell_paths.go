@@ -0,0 +1,61 @@
+package plan
+
+import (
+	"plandex-server/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// resolveReplyPath resolves a path output by the model to a path in the plan's context or project. Paths are matched exactly first, then with normalized separators, then regardless of case if only one known path matches. Paths relative to a workspace root are resolved after that. Paths that don't match are normalized, since they're new files.
+func (state *activeTellStreamState) resolveReplyPath(active *types.ActivePlan, p string) string {
+	if p == "" {
+		return p
+	}
+
+	isKnownPath := func(path string) bool {
+		return active.ContextsByPath[path] != nil || state.req.ProjectPaths[path]
+	}
+
+	resolved := resolveKnownPath(p, isKnownPath, func(yield func(string)) {
+		for path := range active.ContextsByPath {
+			yield(path)
+		}
+		for path := range state.req.ProjectPaths {
+			yield(path)
+		}
+	})
+
+	if state.settings != nil && len(state.settings.Workspaces) > 0 {
+		resolved = shared.ResolveWorkspacePath(resolved, state.settings.Workspaces, isKnownPath)
+	}
+
+	return resolved
+}
+
+// resolveKnownPath returns the known path p refers to. Known paths are only listed when p doesn't match exactly, since that's the common case.
+func resolveKnownPath(p string, isKnown func(string) bool, listKnown func(yield func(string))) string {
+	if isKnown(p) {
+		return p
+	}
+
+	normalized := shared.NormalizeFilePath(p)
+	if isKnown(normalized) {
+		return normalized
+	}
+
+	key := shared.PathMatchKey(p)
+	matches := map[string]bool{}
+	listKnown(func(path string) {
+		if shared.PathMatchKey(path) == key {
+			matches[path] = true
+		}
+	})
+
+	if len(matches) == 1 {
+		for match := range matches {
+			return match
+		}
+	}
+
+	return normalized
+}
@@ -0,0 +1,43 @@
+package plan
+
+import "testing"
+
+func TestResolveKnownPath(t *testing.T) {
+	known := map[string]bool{
+		"src/app.go":      true,
+		`lib\legacy.go`:   true,
+		"docs/README.md":  true,
+		"docs/readme.txt": true,
+		"web/Index.ts":    true,
+		"web/index.ts":    true,
+	}
+
+	isKnown := func(path string) bool { return known[path] }
+	listKnown := func(yield func(string)) {
+		for path := range known {
+			yield(path)
+		}
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "exact", path: "src/app.go", want: "src/app.go"},
+		{name: "dot prefix", path: "./src/app.go", want: "src/app.go"},
+		{name: "backslashes", path: `src\app.go`, want: "src/app.go"},
+		{name: "backslash context", path: "lib/legacy.go", want: `lib\legacy.go`},
+		{name: "case", path: "docs/readme.md", want: "docs/README.md"},
+		{name: "ambiguous case", path: "web/INDEX.ts", want: "web/INDEX.ts"},
+		{name: "new file", path: `new\file.go`, want: "new/file.go"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := resolveKnownPath(test.path, isKnown, listKnown); got != test.want {
+				t.Errorf("resolveKnownPath(%q) = %q, want %q", test.path, got, test.want)
+			}
+		})
+	}
+}
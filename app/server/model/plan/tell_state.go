@@ -2,6 +2,7 @@ package plan
 
 import (
 	"plandex-server/db"
+	"plandex-server/model"
 	"plandex-server/types"
 
 	"github.com/plandex/plandex/shared"
@@ -30,6 +31,9 @@ type activeTellStreamState struct {
 	replyNumTokens         int
 	messages               []openai.ChatCompletionMessage
 	tokensBeforeConvo      int
+	requestNumTokens       int
+	reportedUsage          *model.ReportedUsage
 	settings               *shared.PlanSettings
+	trustPolicy            *shared.EffectiveTrustPolicy
 	currentReplyNumRetries int
 }
@@ -19,7 +19,7 @@ const MaxAutoContinueIterations = 100
 const MaxSendRate = 30 * time.Millisecond
 const MaxTellStreamRetries = 4
 
-func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionStream) {
+func (state *activeTellStreamState) listenStream(stream model.ChatCompletionStream) {
 	defer stream.Close()
 
 	clients := state.clients
@@ -47,6 +47,10 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 	}
 
 	replyFiles := []string{}
+	replyRenames := []*shared.SymbolRename{}
+	replyRemovedFiles := []string{}
+	// removals outside the plan's workspaces, which aren't stored with the reply
+	numSkippedRemovals := 0
 	chunksReceived := 0
 	maybeRedundantBacktickContent := ""
 
@@ -120,7 +124,7 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 				var errCh = make(chan error, 2)
 
 				go func() {
-					if len(replyFiles) > 0 {
+					if len(replyFiles) > 0 || len(replyRenames) > 0 || len(replyRemovedFiles) > 0 {
 						log.Println("Generating plan description")
 
 						envVar := settings.ModelPack.CommitMsg.BaseModelConfig.ApiKeyEnvVar
@@ -137,6 +141,8 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 						generatedDescription.SummarizedToMessageId = summarizedToMessageId
 						generatedDescription.MadePlan = true
 						generatedDescription.Files = replyFiles
+						generatedDescription.Renames = replyRenames
+						generatedDescription.RemovedFiles = replyRemovedFiles
 					}
 					errCh <- nil
 				}()
@@ -222,7 +228,7 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 					log.Println("getting description for assistant message: ", assistantMsg.Id)
 
 					var description *db.ConvoMessageDescription
-					if len(replyFiles) == 0 {
+					if generatedDescription == nil {
 						description = &db.ConvoMessageDescription{
 							OrgId:                 currentOrgId,
 							PlanId:                planId,
@@ -269,14 +275,15 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 
 				// summarize in the background
 				go summarizeConvo(client, settings.ModelPack.PlanSummary, summarizeConvoParams{
-					planId:       planId,
-					branch:       branch,
-					convo:        convo,
-					summaries:    summaries,
-					userPrompt:   state.userPrompt,
-					currentOrgId: currentOrgId,
-					currentReply: active.CurrentReplyContent,
-				}, active.SummaryCtx)
+					planId:        planId,
+					branch:        branch,
+					convo:         convo,
+					summaries:     summaries,
+					userPrompt:    state.userPrompt,
+					currentOrgId:  currentOrgId,
+					currentUserId: currentUserId,
+					currentReply:  active.CurrentReplyContent,
+				}, model.WithCallPriority(active.SummaryCtx, model.CallPriorityBackground))
 
 				log.Println("Sending active.CurrentReplyDoneCh <- true")
 
@@ -289,7 +296,16 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 					ap.CurrentReplyDoneCh = nil
 				})
 
-				if req.AutoContinue && shouldContinue && iteration < MaxAutoContinueIterations {
+				willContinue := req.AutoContinue && shouldContinue && iteration < MaxAutoContinueIterations
+				if willContinue {
+					var ok bool
+					willContinue, ok = state.applyAutoContinuePolicy(nextTask)
+					if !ok {
+						return
+					}
+				}
+
+				if willContinue {
 					log.Println("Auto continue plan")
 					// continue plan
 					execTellPlan(clients, plan, branch, auth, req, iteration+1, "", false, nextTask, 0)
@@ -373,16 +389,53 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 			currentFile := parserRes.CurrentFilePath
 			fileDescriptions := parserRes.FileDescriptions
 
+			// resolve paths to the paths of files in context or in the project, which can differ in separators or case, or be relative to a workspace root
+			currentFile = state.resolveReplyPath(active, currentFile)
+			resolvedFiles := make([]string, len(files))
+			for i, file := range files {
+				resolvedFiles[i] = state.resolveReplyPath(active, file)
+			}
+			files = resolvedFiles
+
 			// log.Printf("currentFile: %s\n", currentFile)
 			// log.Println("files:")
 			// spew.Dump(files)
 
+			isMissingFile := currentFile != "" &&
+				active.ContextsByPath[currentFile] == nil &&
+				req.ProjectPaths[currentFile] && !active.AllowOverwritePaths[currentFile]
+
+			// a new file outside the dirs the trust policy allows is handled like a missing file that's always skipped
+			isDeniedNewFile := currentFile != "" &&
+				active.ContextsByPath[currentFile] == nil &&
+				!req.ProjectPaths[currentFile] &&
+				state.trustPolicy.CreateFileLevel(currentFile) == shared.TrustLevelDeny
+
+			// the prompt asks the model to stay inside the plan's workspaces, but a file outside them is always skipped too
+			isOutsideWorkspaces := currentFile != "" &&
+				state.settings != nil &&
+				!shared.PathInWorkspaces(currentFile, state.settings.Workspaces)
+
 			// Handle file that is present in project paths but not in context
 			// Prompt user for what to do on the client side, stop the stream, and wait for user response before proceeding
-			if currentFile != "" &&
-				active.ContextsByPath[currentFile] == nil &&
-				req.ProjectPaths[currentFile] && !active.AllowOverwritePaths[currentFile] {
-				log.Printf("Attempting to overwrite a file that isn't in context: %s\n", currentFile)
+			if isMissingFile || isDeniedNewFile || isOutsideWorkspaces {
+				var autoChoice shared.RespondMissingFileChoice
+				if isOutsideWorkspaces {
+					log.Printf("File is outside the plan's workspaces: %s\n", currentFile)
+					autoChoice = shared.RespondMissingFileChoiceSkip
+				} else if isDeniedNewFile {
+					log.Printf("Trust policy doesn't allow creating file: %s\n", currentFile)
+					autoChoice = shared.RespondMissingFileChoiceSkip
+				} else {
+					log.Printf("Attempting to overwrite a file that isn't in context: %s\n", currentFile)
+
+					switch state.trustPolicy.Level(shared.TrustActionLoadContext) {
+					case shared.TrustLevelAllow:
+						autoChoice = shared.RespondMissingFileChoiceLoad
+					case shared.TrustLevelDeny:
+						autoChoice = shared.RespondMissingFileChoiceSkip
+					}
+				}
 
 				// attempting to overwrite a file that isn't in context
 				// we will stop the stream and ask the user what to do
@@ -400,13 +453,17 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 
 				UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
 					ap.MissingFilePath = currentFile
+					ap.MissingFileAutoChoice = autoChoice
+					ap.MissingFileOutsideWorkspaces = isOutsideWorkspaces
 				})
 
 				log.Printf("Prompting user for missing file: %s\n", currentFile)
 
 				active.Stream(shared.StreamMessage{
-					Type:            shared.StreamMessagePromptMissingFile,
-					MissingFilePath: currentFile,
+					Type:                         shared.StreamMessagePromptMissingFile,
+					MissingFilePath:              currentFile,
+					MissingFileAutoChoice:        autoChoice,
+					MissingFileOutsideWorkspaces: isOutsideWorkspaces,
 				})
 
 				log.Printf("Stopping stream for missing file: %s\n", currentFile)
@@ -429,10 +486,18 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 
 				log.Printf("User choice for missing file: %s\n", userChoice)
 
+				// the policy's choice can't be overridden by the client
+				if autoChoice == shared.RespondMissingFileChoiceSkip && userChoice != shared.RespondMissingFileChoiceSkip {
+					log.Printf("Trust policy requires skipping %s, ignoring choice: %s\n", currentFile, userChoice)
+					userChoice = shared.RespondMissingFileChoiceSkip
+				}
+
 				active.ResetModelCtx()
 
 				UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
 					ap.MissingFilePath = ""
+					ap.MissingFileAutoChoice = ""
+					ap.MissingFileOutsideWorkspaces = false
 				})
 
 				log.Println("Continuing stream")
@@ -461,6 +526,56 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 			// log.Println("replyFiles:")
 			// spew.Dump(replyFiles)
 
+			if len(parserRes.Renames) > len(replyRenames) {
+				for _, rename := range parserRes.Renames[len(replyRenames):] {
+					rename.Paths = active.SymbolRenamePaths(rename.From)
+					log.Printf("Detected rename of %s to %s, referenced in %d file(s)\n", rename.From, rename.To, len(rename.Paths))
+
+					if req.BuildMode == shared.BuildModeAuto && len(rename.Paths) > 0 {
+						buildState := &activeBuildStreamState{
+							clients:       clients,
+							auth:          auth,
+							currentOrgId:  currentOrgId,
+							currentUserId: currentUserId,
+							plan:          plan,
+							branch:        branch,
+							settings:      settings,
+							modelContext:  state.modelContext,
+						}
+						buildState.queueSymbolRename(replyId, rename)
+					}
+					replyRenames = append(replyRenames, rename)
+				}
+			}
+
+			if len(parserRes.RemovedFiles) > len(replyRemovedFiles)+numSkippedRemovals {
+				for _, path := range parserRes.RemovedFiles[len(replyRemovedFiles)+numSkippedRemovals:] {
+					path = state.resolveReplyPath(active, path)
+					log.Printf("Detected removal of %s\n", path)
+
+					if state.settings != nil && !shared.PathInWorkspaces(path, state.settings.Workspaces) {
+						log.Printf("Skipping removal outside the plan's workspaces: %s\n", path)
+						numSkippedRemovals++
+						continue
+					}
+
+					if req.BuildMode == shared.BuildModeAuto {
+						buildState := &activeBuildStreamState{
+							clients:       clients,
+							auth:          auth,
+							currentOrgId:  currentOrgId,
+							currentUserId: currentUserId,
+							plan:          plan,
+							branch:        branch,
+							settings:      settings,
+							modelContext:  state.modelContext,
+						}
+						buildState.queueFileRemoval(replyId, path)
+					}
+					replyRemovedFiles = append(replyRemovedFiles, path)
+				}
+			}
+
 			if len(files) > len(replyFiles) {
 				log.Printf("%d new files\n", len(files)-len(replyFiles))
 
@@ -553,6 +668,8 @@ func (state *activeTellStreamState) storeAssistantReply() (*db.ConvoMessage, str
 		ap.StoredReplyIds = append(ap.StoredReplyIds, replyId)
 	})
 
+	recordModelUsage(currentOrgId, currentUserId, planId, branch, shared.ModelRolePlanner, state.settings.ModelPack.Planner.ModelRoleConfig, state.requestNumTokens, state.settings.GetPlannerTokens(replyNumTokens), state.reportedUsage)
+
 	convo = append(convo, &assistantMsg)
 	state.convo = convo
 
@@ -654,9 +771,5 @@ func (state *activeTellStreamState) onError(streamErr error, storeDesc bool, con
 
 	storeDescAndReply()
 
-	active.StreamDoneCh <- &shared.ApiError{
-		Type:   shared.ApiErrorTypeOther,
-		Status: http.StatusInternalServerError,
-		Msg:    "Stream error: " + streamErr.Error(),
-	}
+	active.StreamDoneCh <- model.ModelApiError("Stream error: "+streamErr.Error(), streamErr)
 }
@@ -43,12 +43,15 @@ func (state *activeTellStreamState) injectSummariesAsNeeded() bool {
 	// log.Println("Tokens up to timestamp:")
 	// spew.Dump(tokensUpToTimestamp)
 
-	log.Printf("Total tokens: %d\n", tokensBeforeConvo+conversationTokens)
+	// stored counts are cl100k counts, so they're converted to the planner's tokenizer before comparing them with its limits
+	plannerTokens := state.settings.GetPlannerTokens
+
+	log.Printf("Total tokens: %d\n", plannerTokens(tokensBeforeConvo+conversationTokens))
 	log.Printf("Max tokens: %d\n", state.settings.GetPlannerEffectiveMaxTokens())
 
 	var summary *db.ConvoSummary
-	if (tokensBeforeConvo+conversationTokens) > state.settings.GetPlannerEffectiveMaxTokens() ||
-		conversationTokens > state.settings.GetPlannerMaxConvoTokens() {
+	if plannerTokens(tokensBeforeConvo+conversationTokens) > state.settings.GetPlannerEffectiveMaxTokens() ||
+		plannerTokens(conversationTokens) > state.settings.GetPlannerMaxConvoTokens() {
 		log.Println("Token limit exceeded. Attempting to reduce via conversation summary.")
 
 		// log.Printf("(tokensBeforeConvo+conversationTokens) > state.settings.GetPlannerEffectiveMaxTokens(): %v\n", (tokensBeforeConvo+conversationTokens) > state.settings.GetPlannerEffectiveMaxTokens())
@@ -93,8 +96,8 @@ func (state *activeTellStreamState) injectSummariesAsNeeded() bool {
 			log.Printf("Updated conversation tokens: %d\n", updatedConversationTokens)
 			log.Printf("Saved tokens: %d\n", savedTokens)
 
-			if updatedConversationTokens <= state.settings.GetPlannerMaxConvoTokens() &&
-				(tokensBeforeConvo+updatedConversationTokens) <= state.settings.GetPlannerEffectiveMaxTokens() {
+			if plannerTokens(updatedConversationTokens) <= state.settings.GetPlannerMaxConvoTokens() &&
+				plannerTokens(tokensBeforeConvo+updatedConversationTokens) <= state.settings.GetPlannerEffectiveMaxTokens() {
 				log.Printf("Summarizing up to %s | saving %d tokens\n", s.LatestConvoMessageCreatedAt.Format(time.RFC3339), savedTokens)
 				summary = s
 				break
@@ -104,11 +107,7 @@ func (state *activeTellStreamState) injectSummariesAsNeeded() bool {
 		if summary == nil {
 			err := errors.New("couldn't get under token limit with conversation summary")
 			log.Printf("Error: %v\n", err)
-			active.StreamDoneCh <- &shared.ApiError{
-				Type:   shared.ApiErrorTypeOther,
-				Status: http.StatusInternalServerError,
-				Msg:    "Couldn't get under token limit with conversation summary",
-			}
+			active.StreamDoneCh <- model.NewApiError(shared.ApiErrorTypeContextTooLong, "Couldn't get under token limit with conversation summary")
 			return false
 		}
 	}
@@ -119,6 +118,8 @@ func (state *activeTellStreamState) injectSummariesAsNeeded() bool {
 	}
 
 	if summary == nil {
+		state.requestNumTokens = plannerTokens(tokensBeforeConvo + conversationTokens)
+
 		for _, convoMessage := range convo {
 			state.messages = append(state.messages, openai.ChatCompletionMessage{
 				Role:    convoMessage.Role,
@@ -134,15 +135,14 @@ func (state *activeTellStreamState) injectSummariesAsNeeded() bool {
 			}
 		}
 	} else {
-		if (tokensBeforeConvo + summary.Tokens) > state.settings.GetPlannerEffectiveMaxTokens() {
-			active.StreamDoneCh <- &shared.ApiError{
-				Type:   shared.ApiErrorTypeOther,
-				Status: http.StatusInternalServerError,
-				Msg:    "Token limit still exceeded after summarizing conversation",
-			}
+		if plannerTokens(tokensBeforeConvo+summary.Tokens) > state.settings.GetPlannerEffectiveMaxTokens() {
+			active.StreamDoneCh <- model.NewApiError(shared.ApiErrorTypeContextTooLong, "Token limit still exceeded after summarizing conversation")
 			return false
 		}
 		state.summarizedToMessageId = summary.LatestConvoMessageId
+		summaryTimestamp := summary.LatestConvoMessageCreatedAt.UnixNano() / int64(time.Millisecond)
+		state.requestNumTokens = plannerTokens(tokensBeforeConvo + summary.Tokens + conversationTokens - tokensUpToTimestamp[summaryTimestamp])
+
 		state.messages = append(state.messages, openai.ChatCompletionMessage{
 			Role:    openai.ChatMessageRoleAssistant,
 			Content: summary.Summary,
@@ -171,13 +171,14 @@ func (state *activeTellStreamState) injectSummariesAsNeeded() bool {
 }
 
 type summarizeConvoParams struct {
-	planId       string
-	branch       string
-	convo        []*db.ConvoMessage
-	summaries    []*db.ConvoSummary
-	userPrompt   string
-	currentReply string
-	currentOrgId string
+	planId        string
+	branch        string
+	convo         []*db.ConvoMessage
+	summaries     []*db.ConvoSummary
+	userPrompt    string
+	currentReply  string
+	currentOrgId  string
+	currentUserId string
 }
 
 func summarizeConvo(client *openai.Client, config shared.ModelRoleConfig, params summarizeConvoParams, ctx context.Context) error {
@@ -189,6 +190,7 @@ func summarizeConvo(client *openai.Client, config shared.ModelRoleConfig, params
 	summaries := params.summaries
 	userPrompt := params.userPrompt
 	currentOrgId := params.currentOrgId
+	currentUserId := params.currentUserId
 	currentReply := params.currentReply
 	active := GetActivePlan(planId, branch)
 
@@ -307,6 +309,7 @@ func summarizeConvo(client *openai.Client, config shared.ModelRoleConfig, params
 		LatestConvoMessageCreatedAt: latestMessageSummarizedAt,
 		NumMessages:                 numMessagesSummarized,
 		OrgId:                       currentOrgId,
+		UserId:                      currentUserId,
 		PlanId:                      planId,
 	}, ctx)
 
@@ -0,0 +1,90 @@
+package plan
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"plandex-server/db"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// isGuestTrial checks whether a user is a guest that started a trial with an org's access code. Trials on Plandex Cloud have their own limits.
+func isGuestTrial(user *db.User) bool {
+	return user.IsTrial && os.Getenv("IS_CLOUD") == ""
+}
+
+// GetGuestTrialPlansError returns an error to send when a guest trial user can't create another plan, or nil if they can
+func GetGuestTrialPlansError(orgId string, user *db.User) (*shared.ApiError, error) {
+	if !isGuestTrial(user) {
+		return nil, nil
+	}
+
+	policy, err := db.GetOrgTrialPolicy(orgId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting trial policy: %v", err)
+	}
+
+	if !policy.Enabled {
+		return trialDisabledError(), nil
+	}
+
+	if user.NumNonDraftPlans >= policy.MaxPlans {
+		return &shared.ApiError{
+			Type:   shared.ApiErrorTypeTrialPlansExceeded,
+			Status: http.StatusForbidden,
+			Msg:    "User has reached the org's max number of trial plans",
+			TrialPlansExceededError: &shared.TrialPlansExceededError{
+				MaxPlans: policy.MaxPlans,
+			},
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// GetGuestTrialTokensError returns an error to send when a guest trial user has used up the org's trial tokens, or nil if they haven't
+func GetGuestTrialTokensError(orgId string, user *db.User) (*shared.ApiError, error) {
+	if !isGuestTrial(user) {
+		return nil, nil
+	}
+
+	policy, err := db.GetOrgTrialPolicy(orgId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting trial policy: %v", err)
+	}
+
+	if !policy.Enabled {
+		return trialDisabledError(), nil
+	}
+
+	used, err := db.GetUserTokensUsed(orgId, user.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if used >= policy.MaxTokens {
+		return &shared.ApiError{
+			Type:   shared.ApiErrorTypeTrialTokensExceeded,
+			Status: http.StatusForbidden,
+			Msg:    "User has reached the org's trial token limit",
+			TrialTokensExceededError: &shared.TrialTokensExceededError{
+				MaxTokens:  policy.MaxTokens,
+				UsedTokens: used,
+			},
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func trialDisabledError() *shared.ApiError {
+	return &shared.ApiError{
+		Type:   shared.ApiErrorTypeTrialActionNotAllowed,
+		Status: http.StatusForbidden,
+		Msg:    "Trials are no longer enabled for this org—sign up to keep using it",
+	}
+}
@@ -0,0 +1,62 @@
+package plan
+
+import (
+	"log"
+	"plandex-server/db"
+	"plandex-server/model"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// how long to wait for a provider to report a call's usage after the reply has finished
+const reportedUsageTimeout = 10 * time.Second
+
+// recordModelUsage stores the tokens a model call used, and their cost at the model's current price if it's known, so they show up in 'plandex stats'. Token counts are estimates in the model's tokenizer, unless the provider reported the call's usage, which it does for reasoning models, in which case the reported counts are used instead, along with the reasoning tokens. reported is nil for calls that don't capture usage. Errors are only logged so tracking usage never interrupts a plan.
+func recordModelUsage(orgId, userId, planId, branch string, role shared.ModelRole, config shared.ModelRoleConfig, inputTokens, outputTokens int, reported *model.ReportedUsage) {
+	if inputTokens == 0 && outputTokens == 0 && reported == nil {
+		return
+	}
+
+	usage := &db.ModelUsage{
+		OrgId:        orgId,
+		Branch:       branch,
+		ModelRole:    role,
+		Provider:     config.BaseModelConfig.Provider,
+		ModelName:    config.BaseModelConfig.ModelName,
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+	}
+
+	if userId != "" {
+		usage.UserId = &userId
+	}
+
+	if planId != "" {
+		usage.PlanId = &planId
+	}
+
+	// runs in the background so it doesn't hold up the stream
+	go func() {
+		if reportedInput, reportedOutput, reasoning, ok := reported.Wait(reportedUsageTimeout); ok {
+			usage.InputTokens = reportedInput
+			usage.OutputTokens = reportedOutput
+			usage.ReasoningTokens = reasoning
+		}
+
+		if usage.InputTokens == 0 && usage.OutputTokens == 0 {
+			return
+		}
+
+		pricing := db.GetModelPricing(usage.Provider, usage.ModelName)
+		if pricing != nil {
+			cost := pricing.Cost(usage.InputTokens, usage.OutputTokens)
+			usage.Cost = &cost
+		}
+
+		err := db.StoreModelUsage(usage)
+		if err != nil {
+			log.Printf("Error recording %s model usage for plan %s: %v\n", role, planId, err)
+		}
+	}()
+}
@@ -0,0 +1,220 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// CallPriority is the scheduling class of a model request. When PLANDEX_MODEL_CALL_SLOTS limits concurrent requests to a provider, interactive requests are given free slots before background requests.
+type CallPriority int
+
+const (
+	// CallPriorityInteractive is for plans a user is watching the stream of. It's the default for requests without a priority.
+	CallPriorityInteractive CallPriority = iota
+	// CallPriorityBackground is for plans running without a connected client, like 'plandex tell --bg' or builds triggered by scripts
+	CallPriorityBackground
+)
+
+func (p CallPriority) String() string {
+	if p == CallPriorityBackground {
+		return "background"
+	}
+	return "interactive"
+}
+
+// CallPriorityForStream is interactive when a client is connected to the plan's stream, and background otherwise
+func CallPriorityForStream(connectStream bool) CallPriority {
+	if connectStream {
+		return CallPriorityInteractive
+	}
+	return CallPriorityBackground
+}
+
+type callPriorityKey struct{}
+
+// WithCallPriority sets the priority of model requests made with ctx or a context derived from it
+func WithCallPriority(ctx context.Context, priority CallPriority) context.Context {
+	return context.WithValue(ctx, callPriorityKey{}, priority)
+}
+
+func callPriorityFromContext(ctx context.Context) CallPriority {
+	priority, ok := ctx.Value(callPriorityKey{}).(CallPriority)
+	if !ok {
+		return CallPriorityInteractive
+	}
+	return priority
+}
+
+// callSlots limits concurrent model requests to a single provider host. A slot is held until the response body is closed, so a stream keeps its slot until it finishes.
+type callSlots struct {
+	mu          sync.Mutex
+	limit       int
+	inUse       int
+	interactive []chan struct{}
+	background  []chan struct{}
+}
+
+func newCallSlots(limit int) *callSlots {
+	return &callSlots{limit: limit}
+}
+
+// acquire waits for a free slot. Interactive requests wait behind other interactive requests but ahead of all background requests.
+func (s *callSlots) acquire(ctx context.Context, priority CallPriority) error {
+	s.mu.Lock()
+	hasWaiting := len(s.interactive) > 0
+	if priority == CallPriorityBackground {
+		hasWaiting = hasWaiting || len(s.background) > 0
+	}
+	if s.inUse < s.limit && !hasWaiting {
+		s.inUse++
+		s.mu.Unlock()
+		return nil
+	}
+
+	ch := make(chan struct{})
+	if priority == CallPriorityBackground {
+		s.background = append(s.background, ch)
+	} else {
+		s.interactive = append(s.interactive, ch)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		removed := removeWaiter(&s.interactive, ch) || removeWaiter(&s.background, ch)
+		s.mu.Unlock()
+
+		if !removed {
+			// the slot was granted as the context was canceled
+			s.release()
+		}
+		return ctx.Err()
+	}
+}
+
+func (s *callSlots) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inUse--
+
+	var next chan struct{}
+	if len(s.interactive) > 0 {
+		next = s.interactive[0]
+		s.interactive = s.interactive[1:]
+	} else if len(s.background) > 0 {
+		next = s.background[0]
+		s.background = s.background[1:]
+	}
+
+	if next != nil {
+		s.inUse++
+		close(next)
+	}
+}
+
+func removeWaiter(waiters *[]chan struct{}, ch chan struct{}) bool {
+	for i, w := range *waiters {
+		if w == ch {
+			*waiters = append((*waiters)[:i], (*waiters)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+var callSlotsLimit int
+var callSlotsByHost = map[string]*callSlots{}
+var callSlotsMu sync.Mutex
+
+// InitCallSlots reads PLANDEX_MODEL_CALL_SLOTS, the maximum number of concurrent model requests to each provider host. It's unset by default, which doesn't limit requests. On a config reload, requests already waiting or running keep the limit they started with.
+func InitCallSlots() error {
+	limit := 0
+	if s := os.Getenv("PLANDEX_MODEL_CALL_SLOTS"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid PLANDEX_MODEL_CALL_SLOTS %q -- must be a non-negative integer", s)
+		}
+		limit = n
+	}
+
+	callSlotsMu.Lock()
+	defer callSlotsMu.Unlock()
+
+	if limit != callSlotsLimit {
+		callSlotsLimit = limit
+		callSlotsByHost = map[string]*callSlots{}
+	}
+
+	return nil
+}
+
+func getCallSlots(host string) *callSlots {
+	callSlotsMu.Lock()
+	defer callSlotsMu.Unlock()
+
+	if callSlotsLimit == 0 {
+		return nil
+	}
+
+	slots := callSlotsByHost[host]
+	if slots == nil {
+		slots = newCallSlots(callSlotsLimit)
+		callSlotsByHost[host] = slots
+	}
+	return slots
+}
+
+// callSlotsTransport holds a slot for the provider's host from the start of each request until its response body is closed
+type callSlotsTransport struct {
+	next http.RoundTripper
+}
+
+func (t callSlotsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	slots := getCallSlots(req.URL.Host)
+	if slots == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	err := slots.acquire(req.Context(), callPriorityFromContext(req.Context()))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		slots.release()
+		return nil, err
+	}
+
+	resp.Body = &slotReleasingBody{ReadCloser: resp.Body, release: slots.release}
+	return resp, nil
+}
+
+type slotReleasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// Read releases the slot as soon as the body is fully read or fails, in case the caller doesn't close it
+func (b *slotReleasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+func (b *slotReleasingBody) Close() error {
+	b.once.Do(b.release)
+	return b.ReadCloser.Close()
+}
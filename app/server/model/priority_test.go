@@ -0,0 +1,84 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCallSlotsInteractiveFirst(t *testing.T) {
+	slots := newCallSlots(1)
+	ctx := context.Background()
+
+	if err := slots.acquire(ctx, CallPriorityBackground); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	order := make(chan CallPriority, 2)
+	wait := func(priority CallPriority) {
+		if err := slots.acquire(ctx, priority); err != nil {
+			t.Errorf("acquire: %v", err)
+			return
+		}
+		order <- priority
+		slots.release()
+	}
+
+	go wait(CallPriorityBackground)
+	waitForWaiters(t, slots, 0, 1)
+	go wait(CallPriorityInteractive)
+	waitForWaiters(t, slots, 1, 1)
+
+	slots.release()
+
+	if got := <-order; got != CallPriorityInteractive {
+		t.Fatalf("first slot went to %s, want interactive", got)
+	}
+	if got := <-order; got != CallPriorityBackground {
+		t.Fatalf("second slot went to %s, want background", got)
+	}
+}
+
+func TestCallSlotsCanceledWaiter(t *testing.T) {
+	slots := newCallSlots(1)
+
+	if err := slots.acquire(context.Background(), CallPriorityInteractive); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- slots.acquire(ctx, CallPriorityBackground)
+	}()
+	waitForWaiters(t, slots, 0, 1)
+
+	cancel()
+	if err := <-done; err == nil {
+		t.Fatal("expected an error for a canceled waiter")
+	}
+
+	slots.release()
+
+	if err := slots.acquire(context.Background(), CallPriorityBackground); err != nil {
+		t.Fatalf("acquire after cancel: %v", err)
+	}
+	if slots.inUse != 1 {
+		t.Fatalf("inUse = %d, want 1", slots.inUse)
+	}
+}
+
+func waitForWaiters(t *testing.T, slots *callSlots, interactive, background int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		slots.mu.Lock()
+		ok := len(slots.interactive) == interactive && len(slots.background) == background
+		slots.mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d interactive and %d background waiters", interactive, background)
+}
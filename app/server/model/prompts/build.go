@@ -2,6 +2,7 @@ package prompts
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/plandex/plandex/shared"
 	"github.com/sashabaranov/go-openai"
@@ -29,6 +30,34 @@ func GetBuildFixesLineNumbersSysPrompt(original, changes, updated, reasoning str
 	return getFixChangesLineNumsPrompt() + "\n\n" + getBuildPromptForFixesWithLineNums(original, changes, updatedWithLineNums, reasoning)
 }
 
+// GetBuildCorrectionPrompt asks the builder to call 'listChangesWithLineNums' again after it responded with JSON that couldn't be parsed
+func GetBuildCorrectionPrompt(parseErr string) string {
+	return fmt.Sprintf("Your response couldn't be parsed as valid JSON: %s\n\nCall the 'listChangesWithLineNums' function again with the same changes as a complete, valid JSON object. Make sure the response isn't cut off, that double quotes, backslashes, newlines, and tabs within strings are properly escaped, and that there are no trailing commas. Don't call any other function.", parseErr)
+}
+
+// GetBuildAnchorCorrectionPrompt asks the builder to call 'listChangesWithLineNums' again after the lines in its changes didn't match the file's lines at the line numbers it gave, with one entry for each line that didn't match
+func GetBuildAnchorCorrectionPrompt(mismatches []string) string {
+	return "Some of the lines in your changes don't match the original file at the line numbers you gave, so the changes can't be applied safely:\n\n- " + strings.Join(mismatches, "\n- ") + "\n\nLook at the original file again and find where each change really belongs. Then call the 'listChangesWithLineNums' function again with the same changes, where 'startLineString', 'endLineString', 'contextBeforeString', and 'contextAfterString' are each the entire, exact line from the original file, including its line number. Don't call any other function."
+}
+
+// GetDependencyAdvisoriesPrompt is added to the description of proposed changes to a dependency manifest when they use versions with known vulnerabilities, with one line for each version
+func GetDependencyAdvisoriesPrompt(advisories []string) string {
+	return "\n\n## Known vulnerabilities\n\nThe proposed updates use dependency versions with known vulnerabilities in the OSV advisory database:\n\n- " + strings.Join(advisories, "\n- ") + "\n\nWhen applying the proposed updates, use the fixed version instead of a vulnerable version where one is listed. Apply the rest of the updates exactly as proposed."
+}
+
+// GetBuildWholeFileSysPrompt asks the builder for the full updated file. It's a fallback for when the model can't produce a valid list of changes.
+func GetBuildWholeFileSysPrompt(filePath, preBuildState, changes string) string {
+	s := "You are an AI that applies an AI-generated plan's proposed updates to a code file and outputs the complete updated file."
+
+	s += "\n\n" + getPreBuildStatePrompt(filePath, preBuildState)
+
+	s += "Proposed updates:\n```\n" + changes + "\n```"
+
+	s += "\n\n" + "Now output the entire updated file in a single code block, with the proposed updates merged into the original file. Include ALL of the original code that the proposed updates don't change or remove—if the proposed updates contain comments that refer to the original code, like '// rest of the function...' or '# existing init code...', replace them with the exact code from the original file. Don't include line numbers, and don't output anything besides the code block."
+
+	return s
+}
+
 func getBuildPromptWithLineNums(changes string) string {
 	s := ""
 
@@ -105,6 +134,14 @@ The 'old' property is an object with 3 properties: 'entireFile', 'startLineStrin
 	If 'hasChange' is false, both 'startLineString' and 'endLineString' must be empty strings. If 'hasChange' is true, 'startLineString' and 'endLineString' must be valid strings that exactly match lines from the original file. If 'hasChange' is true, 'startLineString' and 'endLineString' MUST NEVER be empty strings.
 
 	If you are replacing the entire file, 'startLineString' MUST be the first line of the original file and 'endLineString' MUST be the last line of the original file.
+
+The 'old' object also has 2 properties that anchor the change: 'contextBeforeString' and 'contextAfterString'.
+
+	'contextBeforeString' is the **entire, exact line** just before 'startLineString' in the original file, including the line number. If 'startLineString' starts with 'pdx-22: ', 'contextBeforeString' MUST start with 'pdx-21: '. If the change starts at the first line of the file, 'contextBeforeString' must be an empty string.
+
+	'contextAfterString' is the **entire, exact line** just after the last line being replaced in the original file, including the line number. That's the line after 'endLineString', or the line after 'startLineString' for a single line replacement. If the change ends at the last line of the file, 'contextAfterString' must be an empty string.
+
+	Every line number and line in 'startLineString', 'endLineString', 'contextBeforeString', and 'contextAfterString' is checked against the original file before the change is applied. If any of them don't match, the change is rejected.
 `
 
 const changeLineInclusionAndNewPrompt = `
@@ -202,6 +239,8 @@ func getListChangesLineNumsPrompt() string {
    	old: {
       startLineString: "pdx-5: for i := 0; i < 10; i++ { ",
       endLineString: "pdx-7: }",
+      contextBeforeString: "pdx-4: func main() {",
+      contextAfterString: "pdx-8: }",
     },
     new: "for i := 0; i < 10; i++ {\n  execQuery()\n  }\n  }\n}",
   }
@@ -263,6 +302,8 @@ func getFixChangesLineNumsPrompt() string {
     old: {
       startLineString: "pdx-5: for i := 0; i < 10; i++ { ",
       endLineString: "pdx-7: }",
+      contextBeforeString: "pdx-4: func main() {",
+      contextAfterString: "pdx-8: }",
     },
     new: "for i := 0; i < 10; i++ {\n  execQuery()\n  }\n  }\n}",
   }
@@ -278,86 +319,97 @@ func getFixChangesLineNumsPrompt() string {
 `
 }
 
-var ListReplacementsFn = openai.FunctionDefinition{
-	Name: "listChangesWithLineNums",
-	Parameters: &jsonschema.Definition{
-		Type: jsonschema.Object,
-		Properties: map[string]jsonschema.Definition{
-			"comments": {
-				Type: jsonschema.Array,
-				Items: &jsonschema.Definition{
-					Type: jsonschema.Object,
-					Properties: map[string]jsonschema.Definition{
-						"txt": {
-							Type: jsonschema.String,
-						},
-						"reference": {
-							Type: jsonschema.Boolean,
-						},
+// ListReplacementsStructuredOutputsPrompt is added to the build prompt when the builder responds with the json_schema response format instead of calling ListReplacementsFn
+const ListReplacementsStructuredOutputsPrompt = "\n\n" + "There is no 'listChangesWithLineNums' function to call in this conversation. Instead, respond with only the JSON object you would have called 'listChangesWithLineNums' with. The response is checked against the function's schema, so it must include the 'comments', 'problems', and 'changes' keys."
+
+var ListReplacementsSchema = &jsonschema.Definition{
+	Type: jsonschema.Object,
+	Properties: map[string]jsonschema.Definition{
+		"comments": {
+			Type: jsonschema.Array,
+			Items: &jsonschema.Definition{
+				Type: jsonschema.Object,
+				Properties: map[string]jsonschema.Definition{
+					"txt": {
+						Type: jsonschema.String,
+					},
+					"reference": {
+						Type: jsonschema.Boolean,
 					},
-					Required: []string{"txt", "reference"},
 				},
+				Required: []string{"txt", "reference"},
 			},
-			"problems": {
-				Type: jsonschema.String,
-			},
-			"changes": {
-				Type: jsonschema.Array,
-				Items: &jsonschema.Definition{
-					Type: jsonschema.Object,
-					Properties: map[string]jsonschema.Definition{
-						"summary": {
-							Type: jsonschema.String,
-						},
-						"hasChange": {
-							Type: jsonschema.Boolean,
-						},
-						"old": {
-							Type: jsonschema.Object,
-							Properties: map[string]jsonschema.Definition{
-								"entireFile": {
-									Type: jsonschema.Boolean,
-								},
-								"startLineString": {
-									Type: jsonschema.String,
-								},
-								"endLineString": {
-									Type: jsonschema.String,
-								},
+		},
+		"problems": {
+			Type: jsonschema.String,
+		},
+		"changes": {
+			Type: jsonschema.Array,
+			Items: &jsonschema.Definition{
+				Type: jsonschema.Object,
+				Properties: map[string]jsonschema.Definition{
+					"summary": {
+						Type: jsonschema.String,
+					},
+					"hasChange": {
+						Type: jsonschema.Boolean,
+					},
+					"old": {
+						Type: jsonschema.Object,
+						Properties: map[string]jsonschema.Definition{
+							"entireFile": {
+								Type: jsonschema.Boolean,
+							},
+							"startLineString": {
+								Type: jsonschema.String,
+							},
+							"endLineString": {
+								Type: jsonschema.String,
+							},
+							"contextBeforeString": {
+								Type: jsonschema.String,
+							},
+							"contextAfterString": {
+								Type: jsonschema.String,
 							},
-							Required: []string{"startLineString", "endLineString"},
-						},
-						"startLineIncludedReasoning": {
-							Type: jsonschema.String,
-						},
-						"startLineIncluded": {
-							Type: jsonschema.Boolean,
-						},
-						"endLineIncludedReasoning": {
-							Type: jsonschema.String,
-						},
-						"endLineIncluded": {
-							Type: jsonschema.Boolean,
-						},
-						"new": {
-							Type: jsonschema.String,
 						},
+						Required: []string{"startLineString", "endLineString"},
 					},
-					Required: []string{
-						"summary",
-						"hasChange",
-						"old",
-						"startLineIncludedReasoning",
-						"startLineIncluded",
-						"endLineIncludedReasoning",
-						"endLineIncluded",
-						"new",
+					"startLineIncludedReasoning": {
+						Type: jsonschema.String,
+					},
+					"startLineIncluded": {
+						Type: jsonschema.Boolean,
+					},
+					"endLineIncludedReasoning": {
+						Type: jsonschema.String,
+					},
+					"endLineIncluded": {
+						Type: jsonschema.Boolean,
+					},
+					"new": {
+						Type: jsonschema.String,
 					},
 				},
+				Required: []string{
+					"summary",
+					"hasChange",
+					"old",
+					"startLineIncludedReasoning",
+					"startLineIncluded",
+					"endLineIncludedReasoning",
+					"endLineIncluded",
+					"new",
+				},
 			},
 		},
-		Required: []string{"comments", "problems", "changes"},
 	},
+	Required: []string{"comments", "problems", "changes"},
+}
+
+var ListReplacementsFn = openai.FunctionDefinition{
+	Name:       "listChangesWithLineNums",
+	Parameters: ListReplacementsSchema,
 }
 
 func GetVerifyPrompt(preBuildState, updated, changes, diff string) string {
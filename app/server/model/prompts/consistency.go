@@ -0,0 +1,38 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ConsistencyCheckFile struct {
+	Path    string
+	Content string
+}
+
+// GetConsistencyCheckSysPrompt asks for the places a multi-file build left files inconsistent with each other, given the public API changes it made. Each problem comes with the changes that fix it, which are then built into the file like a plan's proposed updates.
+func GetConsistencyCheckSysPrompt(apiChanges string, files []ConsistencyCheckFile) string {
+	s := "You are an AI that checks code an AI-generated plan has written or updated across multiple files for inconsistencies between the files."
+
+	s += fmt.Sprintf("\n\nThe plan changed the public API of these files:\n\n%s", apiChanges)
+
+	s += "\n\nHere is the current state of the files the plan changed, and of other files that reference the changed symbols:"
+	for _, file := range files {
+		s += fmt.Sprintf("\n\n- %s:\n```\n%s\n```", file.Path, file.Content)
+	}
+
+	s += "\n\nFind the places where the files are inconsistent with the API changes: callers that weren't updated for a changed signature, references to symbols that were removed or renamed, missing or incorrect imports of changed or added symbols, and implementations that no longer satisfy a changed interface or type. Only report problems that would cause a compile error or a clear runtime error. Don't report style issues, possible improvements, or problems unrelated to the API changes."
+
+	s += "\n\nFor each problem, output the path of the file to fix, a one-sentence description of the problem, and the changes that fix it. Write the changes like a plan's proposed updates to the file: only the updated code, with enough surrounding code to show where it goes. Don't output the whole file."
+
+	s += "\n\nOutput a JSON array in a single ```json code block, with an object for each file that needs fixes:\n```json\n" + strings.TrimSpace(`
+[
+  {
+    "path": "path/to/file",
+    "problem": "The call to loadUser in getProfile doesn't pass the new options argument.",
+    "changes": "the updated code"
+  }
+]`) + "\n```\n\nIf a file has multiple problems, combine them into one object. If the files are consistent, output an empty array. Don't output anything besides the code block."
+
+	return s
+}
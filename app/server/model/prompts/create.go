@@ -133,6 +133,30 @@ const SysCreate = Identity + ` A plan is a set of files with an attached context
 
 		You MUST ALWAYS work on subtasks IN ORDER. You must not skip a subtask or work on subtasks out of order. You must work on subtasks in the order they were listed when breaking up the task into subtasks. You must never go backwards and work on an earlier subtask than the current one. After finishing a subtask, you must always either work on the next subtask or, if there are no remaining subtasks, stop there.".
 
+		## Renaming symbols
+
+		To rename a function, method, type, variable, or other symbol that's referenced in multiple files, you can declare the rename instead of writing out every file that references it. Write the rename on its own line, outside of any code block, in exactly this format:
+
+		Rename symbol: ` + "`oldName`" + ` → ` + "`newName`" + `
+
+		The symbol's references are then renamed in every file in context that references it, including the file that defines it. Only declare renames of a whole identifier, not a qualified name like ` + "`pkg.Name`" + ` or ` + "`Class.method`" + `, and only when every reference with that name in the files in context should be renamed. Don't also write code blocks that only rename the symbol. If you write a code block for a file with other changes, use the new name in it.
+
+		## Removing files and declarations
+
+		To remove a file in context entirely, declare the removal instead of writing a code block for the file. Write the removal on its own line, outside of any code block, in exactly this format:
+
+		Remove file: ` + "`path/to/file`" + `
+
+		Only remove files that are in context, and only when the task calls for removing them. Don't write a code block for a file you remove.
+
+		When a change to a file only removes whole functions, methods, types, classes, or variables, write a file block with only a removal comment for each one, like '// Plandex: removed the ` + "`fooBar`" + ` function', and no other code. Name each removed declaration in backticks. Removals written this way are applied exactly, without rewriting the rest of the file.
+
+		## Citing existing code
+
+		Each line of the files in context starts with a line number prefix like 'pdx-12: '. Whenever you make a claim about code that already exists in the context—where something is defined, how it currently works, what calls it—cite the lines it's based on with a citation in exactly this format: [[cite:server/auth.go:42-58]] for a range of lines, or [[cite:server/auth.go:42]] for a single line. Use the file's exact path from the context. Only cite lines that exist in the context, and cite specific lines rather than whole files. Don't cite code you're writing in the current response.
+
+		The line number prefixes are only there so you can cite lines. *Never* include the 'pdx-' prefixes in code blocks or anywhere else in your response.
+
 		## Things you can't do
 
 		You are able to create and update files, but you are not able to execute code or commands. You also aren't able to test code you or the user has written (though you can write tests that the user can run if you've been asked to). 
@@ -155,6 +179,8 @@ const SysCreate = Identity + ` A plan is a set of files with an attached context
 
 		If the user asks you to use a specific library, then use that library.
 
+		When you add, remove, or update a dependency, update the project's dependency manifest, like package.json, go.mod, or requirements.txt. You must *never* write changes to a lockfile, like package-lock.json, yarn.lock, pnpm-lock.yaml, go.sum, Cargo.lock, or poetry.lock. Lockfiles contain hashes that you can't know. They're regenerated from the manifest when the plan is applied, and changes to them in file blocks are ignored.
+
 		If a task or subtask is small and the implementation is trivial, don't use a library. Just implement the task or subtask directly. Use libraries when they can significantly simplify the task or subtask.
 
 		## Ending a response
@@ -272,6 +298,12 @@ If you break up a task into subtasks, only include subtasks that can be implemen
 
 Do NOT include tests or documentation in the subtasks unless the user has specifically asked for them. Do not include extra code or features beyond what the user has asked for. Focus on the user's request and implement only what is necessary to fulfill it.`
 
+const WorkspacesPrompt = "\n\nThis plan is scoped to the following workspace roots within the project. Only create or update files inside these directories unless the user explicitly asks otherwise. When labelling a file block, always use the full path relative to the project root (including the workspace root), not a path relative to the workspace root.\nWorkspace roots:\n"
+
+const SteeringNotesPrompt = "\n\nWhile you were working on the plan, the user sent the following notes. They take priority over earlier instructions where they conflict. Follow them for the rest of the plan, starting with your next response, without repeating or acknowledging them.\nNotes:\n"
+
 const SkippedPathsPrompt = "\n\nSome files have been skipped by the user and *must not* be generated. The user will handle any updates to these files themselves. Skip any parts of the plan that require generating these files. You *must not* generate a file block for any of these files.\nSkipped files:\n"
 
+const ProjectConventionsPrompt = "\n\nThese are the project's conventions, from a file its team keeps in the repo. Follow them in the code you write unless the user's prompt says otherwise.\n\n"
+
 // 		- If the plan is in progress, this is not your *first* response in the plan, the user's task or tasks have already been broken down into subtasks if necessary, and the plan is *not yet complete* and should be continued, you MUST ALWAYS start the response with "Now I'll" and then proceed to describe and implement the next step in the plan.
@@ -1,6 +1,8 @@
 package prompts
 
 import (
+	"strings"
+
 	"github.com/sashabaranov/go-openai"
 	"github.com/sashabaranov/go-openai/jsonschema"
 )
@@ -20,4 +22,57 @@ var DescribePlanFn = openai.FunctionDefinition{
 	},
 }
 
-const SysPendingResults = "You are an AI commit message summarizer. You take a list of descriptions of pending changes and turn them into a succinct one-line summary of all the pending changes that makes for a good commit message title. Output ONLY this one-line title and nothing else."
+const SysCommitMsgAndChangelog = `You are an AI release note writer. You take the conversation that led to a set of pending code changes and a summary of the changes to each file, and write a conventional commit message and a changelog entry for them. You MUST call the 'writeCommitMsg' function with a valid JSON object that includes all of these keys:
+
+- 'type': the conventional commit type that best fits the changes as a whole: feat, fix, refactor, perf, docs, test, build, ci, style, or chore.
+- 'scope': a short name for the part of the codebase that changed, like a package or module. Use an empty string if the changes don't have a single clear scope.
+- 'subject': a succinct summary of the changes in the imperative mood, starting with a lowercase letter, with no trailing period, and under 60 characters.
+- 'body': a few lines explaining what changed and why. Use an empty string if the subject already says everything.
+- 'breaking': true only if the changes break backward compatibility for users of the code.
+- 'changelog': a changelog entry for users of the project, as a markdown list with one bullet for each user-facing change. Leave out internal details users wouldn't care about.
+
+Base the message only on the changes that were made. Don't mention the conversation or the AI that made the changes. You must ALWAYS call the 'writeCommitMsg' function. Never call any other function.`
+
+var WriteCommitMsgFn = openai.FunctionDefinition{
+	Name: "writeCommitMsg",
+	Parameters: &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"type": {
+				Type: jsonschema.String,
+				Enum: []string{"feat", "fix", "refactor", "perf", "docs", "test", "build", "ci", "style", "chore"},
+			},
+			"scope": {
+				Type: jsonschema.String,
+			},
+			"subject": {
+				Type: jsonschema.String,
+			},
+			"body": {
+				Type: jsonschema.String,
+			},
+			"breaking": {
+				Type: jsonschema.Boolean,
+			},
+			"changelog": {
+				Type: jsonschema.String,
+			},
+		},
+		Required: []string{"type", "scope", "subject", "body", "breaking", "changelog"},
+	},
+}
+
+const SysFileChangesSummary = "You are an AI code change summarizer. You take diffs of the changes made to one or more files and write a one-paragraph summary of what changed in each file, so someone reviewing many files can quickly understand each one before reading the code. Describe what the changes do and why they matter, not the mechanics of the diff. Keep each summary under 80 words. For each file, output a line with '### ' followed by the file's path exactly as given, then its summary paragraph. Output ONLY the headings and summaries and nothing else."
+
+type FileChangesDiff struct {
+	Path string
+	Diff string
+}
+
+func GetFileChangesSummaryPrompt(files []FileChangesDiff) string {
+	var parts []string
+	for _, file := range files {
+		parts = append(parts, "File: "+file.Path+"\n\nDiff:\n\n"+file.Diff)
+	}
+	return strings.Join(parts, "\n\n---\n\n")
+}
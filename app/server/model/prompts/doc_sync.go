@@ -0,0 +1,20 @@
+package prompts
+
+import "fmt"
+
+// GetDocSyncSysPrompt asks the builder how a doc should change for changes to a file's public API. It asks only for the sections to update, which are then built into the doc like a plan's proposed updates.
+func GetDocSyncSysPrompt(sourcePath, apiChanges, docPath, doc string) string {
+	s := "You are an AI that keeps documentation up to date with code that an AI-generated plan has written or updated."
+
+	s += fmt.Sprintf("\n\nThe plan changed the public API of '%s':\n\n%s", sourcePath, apiChanges)
+
+	s += fmt.Sprintf("\n\nHere is the current state of the doc '%s':\n```\n%s\n```", docPath, doc)
+
+	s += "\n\nWrite the updates to the doc that it needs to describe the API accurately: fix references to changed or removed functions, types, and signatures, and document new ones where the doc already documents similar ones. Only output the sections that are new or changed. Don't output the parts of the doc that don't need changes. Your output will be merged into the existing doc."
+
+	s += "\n\nMatch the doc's existing structure, tone, and formatting. Don't rewrite parts of the doc that aren't affected by the API changes. If the doc doesn't need any changes, output an empty code block."
+
+	s += "\n\nOutput the updates in a single code block. Don't include line numbers, and don't output anything besides the code block."
+
+	return s
+}
@@ -0,0 +1,60 @@
+package prompts
+
+import (
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const SysImpactReport = `You are an expert software architect reviewing a set of pending code changes before they are merged. You are given the requests that led to the changes, a summary of the changes to each file, and the full diff. Write an impact report that helps reviewers understand what the changes affect and what to watch out for. You MUST call the 'writeImpactReport' function with a valid JSON object that includes all of these keys:
+
+- 'summary': one short paragraph describing the overall purpose and effect of the changes.
+- 'modulesTouched': a list of the modules, packages, or components that changed. Each item has a 'name' and a one-sentence 'changes' description. Group files that belong to the same module.
+- 'apiChanges': a list of changes to public interfaces: exported functions and types, HTTP endpoints, CLI commands and flags, config options, database schemas, and file formats. Mention whether each one is backward compatible. Use an empty list if there are none.
+- 'migrationSteps': an ordered list of steps users or operators need to take when deploying or upgrading, like running migrations, setting new environment variables, or updating callers. Use an empty list if there are none.
+- 'risks': a list of specific risks, like behavior changes, edge cases that may not be handled, performance or security concerns, and missing tests. Don't list generic risks that apply to any change. Use an empty list if there are none.
+
+Base the report only on what the changes actually do. Be concise and specific. You must ALWAYS call the 'writeImpactReport' function. Never call any other function.`
+
+var WriteImpactReportFn = openai.FunctionDefinition{
+	Name: "writeImpactReport",
+	Parameters: &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"summary": {
+				Type: jsonschema.String,
+			},
+			"modulesTouched": {
+				Type: jsonschema.Array,
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"name": {
+							Type: jsonschema.String,
+						},
+						"changes": {
+							Type: jsonschema.String,
+						},
+					},
+					Required: []string{"name", "changes"},
+				},
+			},
+			"apiChanges": {
+				Type:  jsonschema.Array,
+				Items: &jsonschema.Definition{Type: jsonschema.String},
+			},
+			"migrationSteps": {
+				Type:  jsonschema.Array,
+				Items: &jsonschema.Definition{Type: jsonschema.String},
+			},
+			"risks": {
+				Type:  jsonschema.Array,
+				Items: &jsonschema.Definition{Type: jsonschema.String},
+			},
+		},
+		Required: []string{"summary", "modulesTouched", "apiChanges", "migrationSteps", "risks"},
+	},
+}
+
+func GetImpactReportPrompt(requests, fileSummaries, diffs string) string {
+	return "Requests in the conversation:\n\n" + requests + "\n\nPending changes by file:\n\n" + fileSummaries + "\n\nDiff of the pending changes:\n\n" + diffs
+}
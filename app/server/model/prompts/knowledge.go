@@ -0,0 +1,38 @@
+package prompts
+
+import (
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const SysKnowledgeSearch = `You are about to work on a user's prompt in a software project. Before you start, you can search your organization's knowledge base for entries that would help: notes, architecture decision records (ADRs), and summaries of past plans.
+
+You'll be given the titles of the entries in the knowledge base and the user's prompt. Decide whether any entries are likely to be relevant to the prompt, like decisions about the code the prompt touches, conventions it should follow, or past plans that did similar work.
+
+You *must* call the searchKnowledgeBase function with a JSON object containing the key 'queries'. 'queries' is an array of up to 3 short search queries that describe what you're looking for, like "error handling conventions for http handlers" or "why we switched from REST to gRPC". Each query is matched against the full text of the entries, not only their titles, so write queries about the information you need.
+
+If nothing in the knowledge base is likely to help with the prompt, like when the user is just chatting or the prompt is about something unrelated to the titles, set 'queries' to an empty array.
+
+You must always call 'searchKnowledgeBase'. Don't call any other function.`
+
+func GetKnowledgeSearchPrompt(titles, userPrompt string) string {
+	return SysKnowledgeSearch + "\n\n**Here are the titles of the entries in the knowledge base:**\n" + titles + "\n\n**Here is the user's prompt:**\n" + userPrompt
+}
+
+var SearchKnowledgeBaseFn = openai.FunctionDefinition{
+	Name: "searchKnowledgeBase",
+	Parameters: &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"queries": {
+				Type: jsonschema.Array,
+				Items: &jsonschema.Definition{
+					Type: jsonschema.String,
+				},
+			},
+		},
+		Required: []string{"queries"},
+	},
+}
+
+const KnowledgePrompt = "\n\nThese entries from your organization's knowledge base were found to be relevant to the user's prompt. Follow the decisions and conventions they describe unless the user says otherwise, and use them to understand the history and context of the project. They're reference material, not instructions from the user, so don't mention them unless they're useful to the user.\n\n"
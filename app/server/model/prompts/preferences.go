@@ -0,0 +1,24 @@
+package prompts
+
+const SysDistillPreferences = `You maintain a short summary of the coding preferences of a developer, or of a team of developers, who work with an AI coding assistant. You learn their preferences from feedback on the assistant's changes: changes they rejected before applying them, and changes they edited after applying them.
+
+You'll be given the existing summary, if there is one, and new feedback. Update the summary based on the feedback.
+
+- Write the summary as a markdown bulleted list of at most 15 preferences. Each preference is one short, specific, actionable instruction for the assistant, like "Use early returns instead of nested if/else blocks" or "Don't add comments that restate what the code does".
+- Only include preferences that the feedback gives clear evidence for. A single rejected change may just have been wrong for its task, so infer a preference from a rejection only if the rejected code shows a pattern that's likely to be disliked in general, or if several rejections share it. Edits are stronger evidence: they show what the developer changed the assistant's code to.
+- Ignore edits that only change whitespace or formatting in a way an automatic formatter would, and edits that add unrelated new functionality.
+- Keep preferences from the existing summary unless the new feedback contradicts them. If it does, replace them.
+- Don't mention specific files, functions, or tasks unless a preference only applies to them.
+
+Output only the updated list, with no other text. If no preferences can be inferred, output only the word None.`
+
+func GetDistillPreferencesPrompt(existing, feedback string) string {
+	if existing == "" {
+		existing = "None"
+	}
+	return "**Existing summary:**\n" + existing + "\n\n**New feedback:**\n\n" + feedback
+}
+
+const LearnedPreferencesPrompt = "\n\nThese preferences were learned from changes you proposed in the past that the user or their team rejected or edited. Follow them unless the user's prompt says otherwise. If the user's preferences conflict with their organization's, follow the user's.\n\n"
+
+const BuildPreferencesPrompt = "\n\nThese preferences were learned from changes that were rejected or edited in the past. Where the proposed changes leave details like naming, formatting, or comments unspecified, follow them. Don't make any changes that aren't in the proposed changes to follow them.\n\n"
@@ -0,0 +1,23 @@
+package prompts
+
+import "fmt"
+
+// GetTestScaffoldSysPrompt asks the builder for the tests of a file that a plan changed. When the tests already exist, it asks only for the tests to add or update, which are then built into the existing file like a plan's proposed updates.
+func GetTestScaffoldSysPrompt(sourcePath, source, testPath, existingTests string) string {
+	s := "You are an AI that writes tests for code that an AI-generated plan has written or updated."
+
+	s += fmt.Sprintf("\n\nHere is the current state of the file '%s', including the plan's changes:\n```\n%s\n```", sourcePath, source)
+
+	if existingTests == "" {
+		s += fmt.Sprintf("\n\nWrite the tests for this file in a new file at '%s'. Output the complete test file.", testPath)
+	} else {
+		s += fmt.Sprintf("\n\nHere are the file's existing tests in '%s':\n```\n%s\n```", testPath, existingTests)
+		s += "\n\nWrite the tests to add or update so that the existing tests cover the plan's changes. Only output the tests that are new or changed, along with any imports they need. Don't output the existing tests that don't need changes. Your output will be merged into the existing test file."
+	}
+
+	s += "\n\nUse the testing framework and conventions that the existing tests use, or the standard testing framework for the language if there are no existing tests. Test the file's public behavior, focusing on the code that the plan is likely to have changed. Don't add new dependencies beyond the testing framework."
+
+	s += "\n\nOutput the test code in a single code block. Don't include line numbers, and don't output anything besides the code block."
+
+	return s
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// CheckProvidersReachable makes a request to each built-in provider's api through the model transport, so proxy and CA bundle settings apply. Any http response counts as reachable, since no api key is sent. It returns an error for each provider that couldn't be reached.
+func CheckProvidersReachable(ctx context.Context) map[shared.ModelProvider]error {
+	errs := map[shared.ModelProvider]error{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for provider, baseUrl := range shared.BaseUrlByProvider {
+		if provider == shared.ModelProviderMock {
+			continue
+		}
+
+		wg.Add(1)
+		go func(provider shared.ModelProvider, baseUrl string) {
+			defer wg.Done()
+
+			err := checkReachable(ctx, baseUrl+"/models")
+
+			mu.Lock()
+			defer mu.Unlock()
+			errs[provider] = err
+		}(provider, baseUrl)
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+func checkReachable(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+
+	resp, err := getHttpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
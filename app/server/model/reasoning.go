@@ -0,0 +1,239 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// restructureForModel rewrites a request's messages for models that don't accept system messages, like o1-mini. Each system message becomes a user message, merged with the user messages next to it, since some providers reject consecutive user messages. The request gets a new messages slice, so callers can keep reusing theirs.
+func restructureForModel(req *openai.ChatCompletionRequest, base shared.BaseModelConfig) {
+	if base.HasSystemRole() {
+		return
+	}
+
+	var messages []openai.ChatCompletionMessage
+	for _, msg := range req.Messages {
+		if msg.Role == openai.ChatMessageRoleSystem {
+			msg.Role = openai.ChatMessageRoleUser
+		}
+
+		n := len(messages)
+		if n > 0 && msg.Role == openai.ChatMessageRoleUser && messages[n-1].Role == openai.ChatMessageRoleUser && len(msg.MultiContent) == 0 && len(messages[n-1].MultiContent) == 0 {
+			messages[n-1].Content += "\n\n" + msg.Content
+			continue
+		}
+
+		messages = append(messages, msg)
+	}
+
+	req.Messages = messages
+}
+
+// ReportedUsage is the usage a provider reports for a call, which for reasoning models includes the reasoning tokens that are billed as output but never show up in the reply. It's filled in by the transport as the response is read.
+type ReportedUsage struct {
+	mu               sync.Mutex
+	reported         bool
+	promptTokens     int
+	completionTokens int
+	reasoningTokens  int
+	done             chan struct{}
+	doneOnce         sync.Once
+}
+
+func newReportedUsage() *ReportedUsage {
+	return &ReportedUsage{done: make(chan struct{})}
+}
+
+// Wait waits until the usage is reported or the response is closed, for up to timeout, since streams report usage after the reply has finished. It returns the same values as Get.
+func (u *ReportedUsage) Wait(timeout time.Duration) (inputTokens, outputTokens, reasoningTokens int, ok bool) {
+	if u == nil {
+		return 0, 0, 0, false
+	}
+
+	select {
+	case <-u.done:
+	case <-time.After(timeout):
+	}
+
+	return u.Get()
+}
+
+func (u *ReportedUsage) finish() {
+	u.doneOnce.Do(func() { close(u.done) })
+}
+
+// Get returns the reported input, output, and reasoning tokens. Output includes the reasoning tokens. ok is false if the provider didn't report usage, or the response hasn't been read yet.
+func (u *ReportedUsage) Get() (inputTokens, outputTokens, reasoningTokens int, ok bool) {
+	if u == nil {
+		return 0, 0, 0, false
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.promptTokens, u.completionTokens, u.reasoningTokens, u.reported
+}
+
+func (u *ReportedUsage) set(usage *providerUsage) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.reported = true
+	u.promptTokens = usage.PromptTokens
+	u.completionTokens = usage.CompletionTokens
+	if usage.CompletionTokensDetails != nil {
+		u.reasoningTokens = usage.CompletionTokensDetails.ReasoningTokens
+	}
+	u.finish()
+}
+
+// providerUsage is the usage object in OpenAI-compatible responses. The openai client doesn't have a field for the reasoning tokens, so it's parsed from the raw response.
+type providerUsage struct {
+	PromptTokens            int `json:"prompt_tokens"`
+	CompletionTokens        int `json:"completion_tokens"`
+	CompletionTokensDetails *struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+type reportedUsageKey struct{}
+
+// ReportedUsageFromContext returns the usage captured for a request sent with ctx, or nil if usage isn't captured for the request's model. ApplyRoleConfig sets it up for reasoning models.
+func ReportedUsageFromContext(ctx context.Context) *ReportedUsage {
+	usage, _ := ctx.Value(reportedUsageKey{}).(*ReportedUsage)
+	return usage
+}
+
+func withReportedUsage(ctx context.Context) context.Context {
+	return context.WithValue(ctx, reportedUsageKey{}, newReportedUsage())
+}
+
+// usageCaptureTransport reads the usage from responses to requests that have a ReportedUsage on their context
+type usageCaptureTransport struct {
+	next http.RoundTripper
+}
+
+func (t usageCaptureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	usage := ReportedUsageFromContext(req.Context())
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || usage == nil || resp.StatusCode >= 400 {
+		return resp, err
+	}
+
+	resp.Body = &usageCaptureBody{
+		ReadCloser: resp.Body,
+		usage:      usage,
+		isStream:   strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream"),
+	}
+	return resp, nil
+}
+
+// usageCaptureBody parses the usage out of a response body as it's read. Streams are parsed line by line, since the usage comes in its own chunk at the end. Other responses are parsed once they're fully read.
+type usageCaptureBody struct {
+	io.ReadCloser
+	usage      *ReportedUsage
+	isStream   bool
+	buf        bytes.Buffer
+	sawFinish  bool
+	parsedBody bool
+}
+
+func (b *usageCaptureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+
+	if b.isStream {
+		b.parseStreamLines()
+	} else if err == io.EOF {
+		b.parseBody()
+	}
+
+	return n, err
+}
+
+// Close parses responses that weren't read to the end, since the JSON decoder stops at the end of the object. For a stream that has already finished, it reads the rest of the stream, since listeners stop at the finish reason, and the usage chunk comes right after it.
+func (b *usageCaptureBody) Close() error {
+	if !b.isStream {
+		b.parseBody()
+	} else if b.sawFinish || hasFinishReason(b.buf.String()) {
+		if _, _, _, ok := b.usage.Get(); !ok {
+			rest := io.MultiReader(bytes.NewReader(b.buf.Bytes()), io.LimitReader(b.ReadCloser, 64*1024))
+			scanner := bufio.NewScanner(rest)
+			for scanner.Scan() {
+				b.parseStreamLine(strings.TrimSpace(scanner.Text()))
+				if _, _, _, ok := b.usage.Get(); ok {
+					break
+				}
+			}
+		}
+	}
+
+	b.usage.finish()
+
+	return b.ReadCloser.Close()
+}
+
+func (b *usageCaptureBody) parseStreamLines() {
+	for {
+		line, err := b.buf.ReadString('\n')
+		if err != nil {
+			// keep the partial line for the next read
+			rest := line
+			b.buf.Reset()
+			b.buf.WriteString(rest)
+			return
+		}
+		b.parseStreamLine(strings.TrimSpace(line))
+	}
+}
+
+func (b *usageCaptureBody) parseStreamLine(line string) {
+	data, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return
+	}
+	data = strings.TrimSpace(data)
+
+	if hasFinishReason(data) {
+		b.sawFinish = true
+	}
+
+	if !strings.Contains(data, `"usage"`) {
+		return
+	}
+
+	var chunk struct {
+		Usage *providerUsage `json:"usage"`
+	}
+	if json.Unmarshal([]byte(data), &chunk) == nil && chunk.Usage != nil {
+		b.usage.set(chunk.Usage)
+	}
+}
+
+// hasFinishReason is true for stream data with a non-null finish reason
+func hasFinishReason(data string) bool {
+	return strings.Contains(data, `"finish_reason":"`) || strings.Contains(data, `"finish_reason": "`)
+}
+
+func (b *usageCaptureBody) parseBody() {
+	if b.parsedBody {
+		return
+	}
+	b.parsedBody = true
+
+	var res struct {
+		Usage *providerUsage `json:"usage"`
+	}
+	if json.Unmarshal(b.buf.Bytes(), &res) == nil && res.Usage != nil {
+		b.usage.set(res.Usage)
+	}
+	b.buf.Reset()
+}
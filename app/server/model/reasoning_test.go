@@ -0,0 +1,210 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestRestructureForModel(t *testing.T) {
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "instructions"},
+		{Role: openai.ChatMessageRoleUser, Content: "prompt"},
+		{Role: openai.ChatMessageRoleAssistant, Content: "reply"},
+		{Role: openai.ChatMessageRoleSystem, Content: "more instructions"},
+	}
+
+	req := openai.ChatCompletionRequest{Messages: messages}
+	restructureForModel(&req, shared.BaseModelConfig{ModelName: "gpt-4o"})
+	if len(req.Messages) != 4 || req.Messages[0].Role != openai.ChatMessageRoleSystem {
+		t.Errorf("expected messages to be unchanged for a model with a system role, got %v", req.Messages)
+	}
+
+	req = openai.ChatCompletionRequest{Messages: messages}
+	restructureForModel(&req, shared.BaseModelConfig{ModelName: "o1-mini"})
+
+	want := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleUser, Content: "instructions\n\nprompt"},
+		{Role: openai.ChatMessageRoleAssistant, Content: "reply"},
+		{Role: openai.ChatMessageRoleUser, Content: "more instructions"},
+	}
+	if len(req.Messages) != len(want) {
+		t.Fatalf("got %d messages %v, want %d", len(req.Messages), req.Messages, len(want))
+	}
+	for i := range want {
+		if req.Messages[i].Role != want[i].Role || req.Messages[i].Content != want[i].Content {
+			t.Errorf("message %d = %s %q, want %s %q", i, req.Messages[i].Role, req.Messages[i].Content, want[i].Role, want[i].Content)
+		}
+	}
+
+	if messages[0].Role != openai.ChatMessageRoleSystem || messages[1].Content != "prompt" {
+		t.Errorf("expected the caller's messages not to change, got %v", messages)
+	}
+}
+
+func TestUsageCaptureBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		isStream bool
+		// the body is read up to the end of this before it's closed, or all of it if empty
+		readUntil     string
+		wantOk        bool
+		wantInput     int
+		wantOutput    int
+		wantReasoning int
+	}{
+		{
+			name:          "json response",
+			body:          `{"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":50,"completion_tokens_details":{"reasoning_tokens":40}}}`,
+			wantOk:        true,
+			wantInput:     10,
+			wantOutput:    50,
+			wantReasoning: 40,
+		},
+		{
+			name:          "stream read to the end",
+			body:          "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\ndata: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\ndata: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":20,\"completion_tokens_details\":{\"reasoning_tokens\":12}}}\n\ndata: [DONE]\n\n",
+			isStream:      true,
+			wantOk:        true,
+			wantInput:     5,
+			wantOutput:    20,
+			wantReasoning: 12,
+		},
+		{
+			name:          "stream closed after the finish reason",
+			body:          "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\ndata: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":20,\"completion_tokens_details\":{\"reasoning_tokens\":12}}}\n\ndata: [DONE]\n\n",
+			isStream:      true,
+			readUntil:     `"stop"}]}`,
+			wantOk:        true,
+			wantInput:     5,
+			wantOutput:    20,
+			wantReasoning: 12,
+		},
+		{
+			name:      "stream closed before it finished",
+			body:      "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\ndata: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":20}}\n\n",
+			isStream:  true,
+			readUntil: `"hi"}}]}`,
+			wantOk:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := newReportedUsage()
+			body := &usageCaptureBody{
+				ReadCloser: io.NopCloser(strings.NewReader(tt.body)),
+				usage:      usage,
+				isStream:   tt.isStream,
+			}
+
+			if tt.readUntil == "" {
+				_, err := io.ReadAll(body)
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				n := strings.Index(tt.body, tt.readUntil) + len(tt.readUntil)
+				_, err := io.ReadFull(body, make([]byte, n))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			body.Close()
+
+			input, output, reasoning, ok := usage.Wait(time.Second)
+			if ok != tt.wantOk {
+				t.Fatalf("got ok %v, want %v", ok, tt.wantOk)
+			}
+			if input != tt.wantInput || output != tt.wantOutput || reasoning != tt.wantReasoning {
+				t.Errorf("got %d input, %d output, %d reasoning tokens, want %d, %d, %d", input, output, reasoning, tt.wantInput, tt.wantOutput, tt.wantReasoning)
+			}
+		})
+	}
+}
+
+func TestApplyRoleConfigReasoningModel(t *testing.T) {
+	maxTokens := 1000
+	config := shared.ModelRoleConfig{
+		BaseModelConfig:   shared.BaseModelConfig{ModelName: "o3-mini", ModelCompatibility: shared.ModelCompatibility{HasStreaming: true}},
+		MaxResponseTokens: &maxTokens,
+	}
+
+	req := openai.ChatCompletionRequest{}
+	ctx := ApplyRoleConfig(context.Background(), &req, config)
+
+	if req.MaxTokens != 0 {
+		t.Errorf("expected max tokens to be moved out of the request, got %d", req.MaxTokens)
+	}
+	params, _ := ctx.Value(bodyParamsKey{}).(map[string]interface{})
+	if params["max_completion_tokens"] != 1000 {
+		t.Errorf("expected max_completion_tokens to be set, got %v", params)
+	}
+	if ReportedUsageFromContext(ctx) == nil {
+		t.Error("expected usage to be captured for a reasoning model")
+	}
+	if isNoStreaming(ctx) {
+		t.Error("expected a model with streaming to stream")
+	}
+
+	config.BaseModelConfig = shared.BaseModelConfig{ModelName: "gpt-4o", ModelCompatibility: shared.ModelCompatibility{HasStreaming: true}}
+	req = openai.ChatCompletionRequest{}
+	ctx = ApplyRoleConfig(context.Background(), &req, config)
+
+	if req.MaxTokens != 1000 {
+		t.Errorf("expected max tokens to be set on the request, got %d", req.MaxTokens)
+	}
+	if ReportedUsageFromContext(ctx) != nil {
+		t.Error("expected usage not to be captured for other models")
+	}
+}
+
+func TestNonStreamingFallback(t *testing.T) {
+	client := initMockTestProvider(t, `{"content": "first line\nsecond line", "chunkDelayMs": -1}`)
+
+	config := shared.ModelRoleConfig{BaseModelConfig: shared.BaseModelConfig{ModelName: "mock"}}
+	req := openai.ChatCompletionRequest{
+		Model:    "mock",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "prompt"}},
+		Stream:   true,
+	}
+	ctx := ApplyRoleConfig(context.Background(), &req, config)
+
+	stream, err := CreateChatCompletionStreamWithRetries(client, ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var chunks []string
+	var finishReason openai.FinishReason
+	for {
+		res, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Choices[0].Delta.Content != "" {
+			chunks = append(chunks, res.Choices[0].Delta.Content)
+		}
+		if res.Choices[0].FinishReason != "" {
+			finishReason = res.Choices[0].FinishReason
+		}
+	}
+
+	if len(chunks) != 2 || chunks[0] != "first line\n" || chunks[1] != "second line" {
+		t.Errorf("expected the reply to be replayed line by line, got %q", chunks)
+	}
+	if finishReason != openai.FinishReasonStop {
+		t.Errorf("expected finish reason stop, got %q", finishReason)
+	}
+}
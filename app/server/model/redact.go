@@ -0,0 +1,46 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// private key blocks are replaced with the same number of lines, so line numbers in redacted files still match the original
+var privateKeyPattern = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+
+// secretPatterns match common credential formats. Each match is replaced entirely.
+var secretPatterns = []*regexp.Regexp{
+	// OpenAI, Anthropic, and other 'sk-' keys
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),
+	// AWS access key ids
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	// GitHub tokens
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+	// Slack tokens
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`),
+	// Google API keys
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	// Stripe keys
+	regexp.MustCompile(`\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}\b`),
+}
+
+// secretAssignmentPattern matches values assigned to names that look like secrets (e.g. 'password = "..."' or 'API_KEY: ...'), including inside JSON strings where the quotes are escaped. Only the value is replaced.
+var secretAssignmentPattern = regexp.MustCompile(`(?i)((?:api[_-]?key|secret|passw(?:or)?d|token|access[_-]?key|private[_-]?key)[A-Za-z0-9_-]*\\?["']?\s*[:=]\s*\\?["']?)([^\s"',;\\]{8,})`)
+
+var bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]{16,}`)
+
+// RedactSecrets replaces likely credentials in s. It's a best effort for debugging output, not a guarantee that no secrets remain.
+func RedactSecrets(s string) string {
+	s = privateKeyPattern.ReplaceAllStringFunc(s, func(match string) string {
+		return redacted + strings.Repeat("\n", strings.Count(match, "\n"))
+	})
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, redacted)
+	}
+	s = secretAssignmentPattern.ReplaceAllString(s, "${1}"+redacted)
+	s = bearerPattern.ReplaceAllString(s, "${1}"+redacted)
+	return s
+}
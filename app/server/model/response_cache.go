@@ -0,0 +1,152 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"plandex-server/db"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// ResponseCacheOwner is the org and user a cacheable model call is made for. The org's response cache policy decides whether the call uses the cache. A zero value never uses it.
+type ResponseCacheOwner struct {
+	OrgId  string
+	UserId string
+}
+
+// CreateChatCompletionWithCache works like CreateChatCompletionWithRetries, but returns a cached response for an identical request when the owner's org caches the operation, and caches new responses. Cache errors are only logged, so a call never fails because of the cache.
+func CreateChatCompletionWithCache(
+	client *openai.Client,
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+	op shared.ResponseCacheOperation,
+	owner ResponseCacheOwner,
+) (openai.ChatCompletionResponse, error) {
+	if owner.OrgId == "" {
+		return CreateChatCompletionWithRetries(client, ctx, req)
+	}
+
+	policy, err := db.GetOrgResponseCachePolicy(owner.OrgId)
+	if err != nil {
+		log.Printf("Error getting response cache policy: %v\n", err)
+		return CreateChatCompletionWithRetries(client, ctx, req)
+	}
+
+	if !policy.Caches(op) {
+		return CreateChatCompletionWithRetries(client, ctx, req)
+	}
+
+	var userId string
+	if policy.Scope == shared.ResponseCacheScopeUser {
+		userId = owner.UserId
+	}
+
+	cacheKey, err := responseCacheKey(ctx, req, op, userId)
+	if err != nil {
+		log.Printf("Error getting response cache key: %v\n", err)
+		return CreateChatCompletionWithRetries(client, ctx, req)
+	}
+
+	cached, err := db.GetCachedModelResponse(owner.OrgId, cacheKey)
+	if err != nil {
+		log.Printf("Error getting cached model response: %v\n", err)
+	} else if cached != "" {
+		var resp openai.ChatCompletionResponse
+		err = json.Unmarshal([]byte(cached), &resp)
+		if err == nil {
+			log.Printf("Using cached model response for %s\n", op)
+			return resp, nil
+		}
+		log.Printf("Error unmarshalling cached model response: %v\n", err)
+	}
+
+	resp, err := CreateChatCompletionWithRetries(client, ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	// only complete responses are cached, so a truncated response isn't reused
+	if len(resp.Choices) == 0 || resp.Choices[0].FinishReason == openai.FinishReasonLength {
+		return resp, nil
+	}
+
+	bytes, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error marshalling model response for cache: %v\n", err)
+		return resp, nil
+	}
+
+	err = db.StoreCachedModelResponse(owner.OrgId, cacheKey, op, string(bytes), time.Duration(policy.TtlHours)*time.Hour)
+	if err != nil {
+		log.Printf("Error caching model response: %v\n", err)
+	}
+
+	return resp, nil
+}
+
+var trailingSpacePattern = regexp.MustCompile(`[ \t]+\n`)
+
+// normalizePromptText removes differences in line endings and trailing whitespace that don't change a prompt's meaning, so they don't cause cache misses
+func normalizePromptText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = trailingSpacePattern.ReplaceAllString(s, "\n")
+	return strings.TrimSpace(s)
+}
+
+// responseCacheKey is a sha256 hash of everything that affects a request's response: the model, its settings, tools, response format, and normalized messages. The operation and, for user-scoped caches, the user id are included so responses are only shared where the policy allows.
+func responseCacheKey(ctx context.Context, req openai.ChatCompletionRequest, op shared.ResponseCacheOperation, userId string) (string, error) {
+	type keyMessage struct {
+		Role    string                   `json:"role"`
+		Content string                   `json:"content"`
+		Parts   []openai.ChatMessagePart `json:"parts,omitempty"`
+		Name    string                   `json:"name,omitempty"`
+	}
+
+	messages := make([]keyMessage, len(req.Messages))
+	for i, msg := range req.Messages {
+		parts := make([]openai.ChatMessagePart, len(msg.MultiContent))
+		for j, part := range msg.MultiContent {
+			part.Text = normalizePromptText(part.Text)
+			parts[j] = part
+		}
+		messages[i] = keyMessage{
+			Role:    msg.Role,
+			Content: normalizePromptText(msg.Content),
+			Parts:   parts,
+			Name:    msg.Name,
+		}
+	}
+
+	bodyParams, _ := ctx.Value(bodyParamsKey{}).(map[string]interface{})
+
+	bytes, err := json.Marshal(map[string]interface{}{
+		"operation":        op,
+		"userId":           userId,
+		"model":            req.Model,
+		"temperature":      req.Temperature,
+		"topP":             req.TopP,
+		"maxTokens":        req.MaxTokens,
+		"presencePenalty":  req.PresencePenalty,
+		"frequencyPenalty": req.FrequencyPenalty,
+		"seed":             req.Seed,
+		"tools":            req.Tools,
+		"toolChoice":       req.ToolChoice,
+		"responseFormat":   req.ResponseFormat,
+		"bodyParams":       bodyParams,
+		"messages":         messages,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error marshalling request: %v", err)
+	}
+
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:]), nil
+}
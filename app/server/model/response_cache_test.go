@@ -0,0 +1,67 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestResponseCacheKey(t *testing.T) {
+	ctx := context.Background()
+	op := shared.ResponseCacheOperationPlanNames
+
+	req := func(content string) openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model:       "gpt-4o",
+			Temperature: 0.2,
+			Messages: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleSystem, Content: content},
+			},
+		}
+	}
+
+	key := func(req openai.ChatCompletionRequest, ctx context.Context, op shared.ResponseCacheOperation, userId string) string {
+		k, err := responseCacheKey(ctx, req, op, userId)
+		if err != nil {
+			t.Fatalf("responseCacheKey() error = %v", err)
+		}
+		return k
+	}
+
+	base := key(req("Name this plan:\nadd a login page"), ctx, op, "")
+
+	if got := key(req("  Name this plan:  \r\nadd a login page\n"), ctx, op, ""); got != base {
+		t.Error("expected line endings and trailing whitespace to be ignored")
+	}
+
+	if key(req("Name this plan:\nadd a signup page"), ctx, op, "") == base {
+		t.Error("expected a different prompt to have a different key")
+	}
+
+	other := req("Name this plan:\nadd a login page")
+	other.Model = "gpt-4-turbo"
+	if key(other, ctx, op, "") == base {
+		t.Error("expected a different model to have a different key")
+	}
+
+	other = req("Name this plan:\nadd a login page")
+	other.Temperature = 0.8
+	if key(other, ctx, op, "") == base {
+		t.Error("expected a different temperature to have a different key")
+	}
+
+	if key(req("Name this plan:\nadd a login page"), ctx, shared.ResponseCacheOperationSummaries, "") == base {
+		t.Error("expected a different operation to have a different key")
+	}
+
+	if key(req("Name this plan:\nadd a login page"), ctx, op, "user-1") == base {
+		t.Error("expected a user-scoped key to differ from an org-scoped key")
+	}
+
+	paramsCtx := withBodyParams(ctx, map[string]interface{}{"reasoning_effort": "high"})
+	if key(req("Name this plan:\nadd a login page"), paramsCtx, op, "") == base {
+		t.Error("expected different body params to have a different key")
+	}
+}
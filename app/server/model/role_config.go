@@ -0,0 +1,128 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+type bodyParamsKey struct{}
+
+// ApplyRoleConfig sets a role's sampling settings on a request, and adapts it to what the model supports: system messages are sent as user messages to models without a system role, and streams from models that can't stream are sent without streaming. Settings the openai client doesn't have fields for (like reasoning effort) are added to the request body by the transport, so the returned context must be used to send the request. For reasoning models, the context also captures the usage the provider reports, with ReportedUsageFromContext.
+func ApplyRoleConfig(ctx context.Context, req *openai.ChatCompletionRequest, config shared.ModelRoleConfig) context.Context {
+	bodyParams := map[string]interface{}{}
+
+	restructureForModel(req, config.BaseModelConfig)
+
+	if !config.BaseModelConfig.HasStreaming {
+		ctx = withNoStreaming(ctx)
+	}
+
+	if config.BaseModelConfig.IsReasoningModel() {
+		// reasoning models only accept the default sampling settings
+		req.Temperature = 0
+		req.TopP = 0
+		if config.ReasoningEffort != "" {
+			bodyParams["reasoning_effort"] = string(config.ReasoningEffort)
+		}
+	} else {
+		req.Temperature = config.Temperature
+		req.TopP = config.TopP
+	}
+
+	if config.MaxResponseTokens != nil {
+		req.MaxTokens = *config.MaxResponseTokens
+	}
+	if config.PresencePenalty != nil {
+		req.PresencePenalty = *config.PresencePenalty
+	}
+	if config.FrequencyPenalty != nil {
+		req.FrequencyPenalty = *config.FrequencyPenalty
+	}
+	if config.Seed != nil {
+		req.Seed = config.Seed
+	}
+
+	if config.BaseModelConfig.IsReasoningModel() {
+		// reasoning models reject max_tokens, since their limit also covers reasoning tokens
+		if req.MaxTokens > 0 {
+			bodyParams["max_completion_tokens"] = req.MaxTokens
+			req.MaxTokens = 0
+		}
+		ctx = withReportedUsage(ctx)
+	}
+
+	return withBodyParams(ctx, bodyParams)
+}
+
+// withBodyParams adds params to the request body for requests sent with the returned context, on top of any params already set on ctx
+func withBodyParams(ctx context.Context, params map[string]interface{}) context.Context {
+	if len(params) == 0 {
+		return ctx
+	}
+
+	merged := map[string]interface{}{}
+	if existing, ok := ctx.Value(bodyParamsKey{}).(map[string]interface{}); ok {
+		for k, v := range existing {
+			merged[k] = v
+		}
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, bodyParamsKey{}, merged)
+}
+
+// bodyParamsTransport adds params set by ApplyRoleConfig to the request body
+type bodyParamsTransport struct {
+	base *http.Client
+}
+
+func (t bodyParamsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.base.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	params, ok := req.Context().Value(bodyParamsKey{}).(map[string]interface{})
+	if !ok || req.Body == nil {
+		return transport.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading model request body: %v", err)
+	}
+
+	var fields map[string]interface{}
+	err = json.Unmarshal(body, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling model request body: %v", err)
+	}
+
+	for k, v := range params {
+		fields[k] = v
+	}
+
+	body, err = json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling model request body: %v", err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return transport.RoundTrip(req)
+}
@@ -0,0 +1,343 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// self-hosted servers can be slow to answer the first request while a model loads, so each probe gets a generous timeout
+const modelProbeTimeout = 60 * time.Second
+
+// ListServedModels lists the models an OpenAI-compatible endpoint serves from its '/models' route. vLLM and most servers return a list, while older TGI versions return the single model they serve.
+func ListServedModels(ctx context.Context, baseUrl, apiKey string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, shared.NormalizeModelBaseUrl(baseUrl)+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := getHttpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("%s listing models: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var list struct {
+		Data []struct {
+			Id string `json:"id"`
+		} `json:"data"`
+		Id string `json:"id"`
+	}
+	err = json.Unmarshal(body, &list)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing models response: %v", err)
+	}
+
+	models := []string{}
+	for _, model := range list.Data {
+		models = append(models, model.Id)
+	}
+	if len(models) == 0 && list.Id != "" {
+		models = append(models, list.Id)
+	}
+
+	return models, nil
+}
+
+// CheckSelfHostedEndpoints checks each base url in PLANDEX_SELF_HOSTED_MODEL_URLS (comma-separated), for readiness checks. Unlike built-in providers, a self-hosted endpoint has to answer its '/models' route without a server error, since a proxy in front of a server that's down still responds.
+func CheckSelfHostedEndpoints(ctx context.Context) map[string]error {
+	errs := map[string]error{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, baseUrl := range strings.Split(os.Getenv("PLANDEX_SELF_HOSTED_MODEL_URLS"), ",") {
+		baseUrl = shared.NormalizeModelBaseUrl(baseUrl)
+		if baseUrl == "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(baseUrl string) {
+			defer wg.Done()
+
+			_, err := ListServedModels(ctx, baseUrl, os.Getenv("PLANDEX_SELF_HOSTED_MODEL_API_KEY"))
+
+			mu.Lock()
+			defer mu.Unlock()
+			errs[baseUrl] = err
+		}(baseUrl)
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+var probeSchema = &jsonschema.Definition{
+	Type: jsonschema.Object,
+	Properties: map[string]jsonschema.Definition{
+		"ok": {Type: jsonschema.Boolean},
+	},
+	Required: []string{"ok"},
+}
+
+const probeJsonPrompt = "Respond with a JSON object with a single key 'ok' set to true."
+
+// ProbeModelEndpoint checks what an OpenAI-compatible endpoint supports by making a tiny request for each capability plandex uses, so a self-hosted model's compatibility settings don't have to be guessed. Each capability is checked with the same request shape the plan and build streams use.
+func ProbeModelEndpoint(ctx context.Context, req shared.ProbeModelEndpointRequest) *shared.ProbeModelEndpointResponse {
+	baseUrl := shared.NormalizeModelBaseUrl(req.BaseUrl)
+
+	res := &shared.ProbeModelEndpointResponse{
+		ServedModels: []string{},
+		Errors:       map[shared.ModelProbeCheck]string{},
+	}
+
+	setErr := func(check shared.ModelProbeCheck, err error) {
+		res.Errors[check] = err.Error()
+	}
+
+	start := time.Now()
+	served, err := ListServedModels(ctx, baseUrl, req.ApiKey)
+	res.LatencyMs = time.Since(start).Milliseconds()
+
+	// some servers don't have a '/models' route, so a failed listing doesn't stop the other checks
+	if err == nil {
+		res.Reachable = true
+		res.ServedModels = served
+		for _, model := range served {
+			if model == req.ModelName {
+				res.ModelFound = true
+			}
+		}
+		if !res.ModelFound {
+			setErr(shared.ModelProbeCheckModels, fmt.Errorf("'%s' isn't served by this endpoint", req.ModelName))
+		}
+	} else {
+		setErr(shared.ModelProbeCheckModels, err)
+	}
+
+	client := newClient(req.ApiKey, baseUrl, "")
+
+	baseReq := func() openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model: req.ModelName,
+			Messages: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleUser, Content: "Reply with the word 'ok'."},
+			},
+			MaxTokens: 50,
+		}
+	}
+
+	chatCtx, cancel := context.WithTimeout(ctx, modelProbeTimeout)
+	defer cancel()
+
+	_, err = probeCompletion(chatCtx, client, baseReq())
+	if err != nil {
+		setErr(shared.ModelProbeCheckChat, err)
+		// every other check needs basic chat completions to work
+		return res
+	}
+	res.Reachable = true
+	res.Compatibility.IsOpenAICompatible = true
+
+	tool := openai.Tool{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        "respond",
+			Description: "Respond with ok set to true",
+			Parameters:  probeSchema,
+		},
+	}
+	toolChoice := openai.ToolChoice{
+		Type:     openai.ToolTypeFunction,
+		Function: openai.ToolFunction{Name: "respond"},
+	}
+
+	jsonReq := func() openai.ChatCompletionRequest {
+		r := baseReq()
+		r.Messages[0].Content = probeJsonPrompt
+		return r
+	}
+
+	checks := map[shared.ModelProbeCheck]func(ctx context.Context) error{
+		shared.ModelProbeCheckStreaming: func(ctx context.Context) error {
+			_, err := probeStream(ctx, client, baseReq())
+			return err
+		},
+		shared.ModelProbeCheckJsonMode: func(ctx context.Context) error {
+			r := jsonReq()
+			r.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+			content, err := probeCompletion(ctx, client, r)
+			if err != nil {
+				return err
+			}
+			return probeJson(content)
+		},
+		shared.ModelProbeCheckFunctionCalling: func(ctx context.Context) error {
+			r := jsonReq()
+			r.Tools = []openai.Tool{tool}
+			r.ToolChoice = toolChoice
+			args, err := probeCompletion(ctx, client, r)
+			if err != nil {
+				return err
+			}
+			return probeJson(args)
+		},
+		shared.ModelProbeCheckStreamingFunctionCalls: func(ctx context.Context) error {
+			r := jsonReq()
+			r.Tools = []openai.Tool{tool}
+			r.ToolChoice = toolChoice
+			args, err := probeStream(ctx, client, r)
+			if err != nil {
+				return err
+			}
+			return probeJson(args)
+		},
+		shared.ModelProbeCheckStructuredOutputs: func(ctx context.Context) error {
+			content, err := probeCompletion(WithJsonSchemaResponse(ctx, "respond", probeSchema), client, jsonReq())
+			if err != nil {
+				return err
+			}
+			return probeJson(content)
+		},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for check, fn := range checks {
+		wg.Add(1)
+		go func(check shared.ModelProbeCheck, fn func(ctx context.Context) error) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, modelProbeTimeout)
+			defer cancel()
+
+			err := fn(checkCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				setErr(check, err)
+				return
+			}
+
+			switch check {
+			case shared.ModelProbeCheckStreaming:
+				res.Compatibility.HasStreaming = true
+			case shared.ModelProbeCheckJsonMode:
+				res.Compatibility.HasJsonResponseMode = true
+			case shared.ModelProbeCheckFunctionCalling:
+				res.Compatibility.HasFunctionCalling = true
+			case shared.ModelProbeCheckStreamingFunctionCalls:
+				res.Compatibility.HasStreamingFunctionCalls = true
+			case shared.ModelProbeCheckStructuredOutputs:
+				res.Compatibility.HasStructuredOutputs = true
+			}
+		}(check, fn)
+	}
+
+	wg.Wait()
+
+	return res
+}
+
+// probeCompletion makes a non-streaming request and returns the response's content, or its tool call's arguments if it called a tool
+func probeCompletion(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest) (string, error) {
+	resp, err := client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("response has no choices")
+	}
+
+	msg := resp.Choices[0].Message
+
+	if len(req.Tools) > 0 {
+		if len(msg.ToolCalls) == 0 {
+			return "", fmt.Errorf("response didn't call the function")
+		}
+		return msg.ToolCalls[0].Function.Arguments, nil
+	}
+
+	return msg.Content, nil
+}
+
+// probeStream makes a streaming request through the same normalization as plan and build streams, and returns the streamed content, or the streamed tool call arguments if it called a tool
+func probeStream(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest) (string, error) {
+	req.Stream = true
+
+	stream, err := client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	normalized := newNormalizedStream(stream)
+	defer normalized.Close()
+
+	var content, args strings.Builder
+
+	for {
+		chunk, err := normalized.Recv()
+		if errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("stream ended without a response")
+		}
+		if err != nil {
+			return "", err
+		}
+
+		choice := chunk.Choices[0]
+		content.WriteString(choice.Delta.Content)
+		for _, toolCall := range choice.Delta.ToolCalls {
+			args.WriteString(toolCall.Function.Arguments)
+		}
+
+		if choice.FinishReason == "" {
+			continue
+		}
+
+		if len(req.Tools) > 0 {
+			if args.Len() == 0 {
+				return "", fmt.Errorf("stream didn't call the function")
+			}
+			return args.String(), nil
+		}
+
+		return content.String(), nil
+	}
+}
+
+func probeJson(s string) error {
+	var v map[string]interface{}
+	err := json.Unmarshal([]byte(strings.TrimSpace(s)), &v)
+	if err != nil {
+		return fmt.Errorf("response isn't a JSON object: %v", err)
+	}
+	return nil
+}
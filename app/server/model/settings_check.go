@@ -0,0 +1,381 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// a model change that at least doubles a role's price gets a warning
+const settingsPriceIncreaseWarnRatio = 2.0
+
+type CheckSettingsParams struct {
+	Settings *shared.PlanSettings
+
+	// the settings being replaced, if any, so price increases can be flagged
+	Original *shared.PlanSettings
+
+	// the org's custom models, checked before the built-in models
+	CustomModels []*shared.AvailableModel
+
+	GetPricing func(provider shared.ModelProvider, modelName string) *shared.ModelPricing
+}
+
+// CheckSettings checks proposed settings against what's known about each role's model: its context window, whether it supports the streaming, function calling, and JSON mode the role needs, and its price. Nothing is stored, so it can be called before settings are saved.
+func CheckSettings(params CheckSettingsParams) []*shared.SettingsIssue {
+	issues := []*shared.SettingsIssue{}
+
+	settings := params.Settings
+	if settings == nil || settings.ModelPack == nil {
+		return issues
+	}
+
+	issues = append(issues, checkSettingsPolicies(settings)...)
+
+	err := settings.ModelPack.Validate()
+	if err != nil {
+		issues = append(issues, &shared.SettingsIssue{
+			Kind:     shared.SettingsIssueInvalid,
+			Severity: shared.SettingsIssueError,
+			Msg:      err.Error(),
+		})
+	}
+
+	var unpricedModels []string
+	unpricedRoles := map[string][]string{}
+
+	for _, role := range checkedModelRoles(settings) {
+		config := *settings.ModelPack.GetRoleConfig(role)
+		base := config.BaseModelConfig
+
+		known := findKnownModel(params.CustomModels, base)
+		if known == nil {
+			issues = append(issues, &shared.SettingsIssue{
+				Role:     role,
+				Kind:     shared.SettingsIssueUnknownModel,
+				Severity: shared.SettingsIssueWarning,
+				Msg:      fmt.Sprintf("%s isn't a built-in or custom model, so its context window and capabilities can't be checked", base.ModelName),
+				Fix:      "add it with 'plandex models add' so its limits are known",
+			})
+		} else {
+			issues = append(issues, checkModelCapabilities(role, base, known)...)
+			issues = append(issues, checkContextWindow(role, config, known)...)
+		}
+
+		issues = append(issues, checkRoleRequirements(role, base)...)
+
+		if params.GetPricing == nil {
+			continue
+		}
+
+		pricing := params.GetPricing(base.Provider, base.ModelName)
+		if pricing == nil {
+			if len(unpricedRoles[base.ModelName]) == 0 {
+				unpricedModels = append(unpricedModels, base.ModelName)
+			}
+			unpricedRoles[base.ModelName] = append(unpricedRoles[base.ModelName], string(role))
+			continue
+		}
+
+		if params.Original != nil && params.Original.ModelPack != nil {
+			issue := checkPriceIncrease(role, base, pricing, params.Original.ModelPack, params.GetPricing)
+			if issue != nil {
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	issues = append(issues, checkPlannerContextWindow(settings)...)
+
+	for _, modelName := range unpricedModels {
+		issues = append(issues, &shared.SettingsIssue{
+			Kind:     shared.SettingsIssuePricing,
+			Severity: shared.SettingsIssueWarning,
+			Msg:      fmt.Sprintf("no price is known for %s (used by %s), so its calls won't be included in cost estimates or usage costs", modelName, strings.Join(unpricedRoles[modelName], ", ")),
+			Fix:      "a server admin can add its price to the pricing catalog",
+		})
+	}
+
+	return issues
+}
+
+// checkedModelRoles are the roles whose models are actually called with these settings. Roles without their own config use the builder's or summarizer's, which are already checked, and the draft builder is only used by the speculative build strategy.
+func checkedModelRoles(settings *shared.PlanSettings) []shared.ModelRole {
+	pack := settings.ModelPack
+
+	var roles []shared.ModelRole
+	for _, role := range shared.AllModelRoles {
+		switch role {
+		case shared.ModelRoleVerifier:
+			if pack.Verifier == nil {
+				continue
+			}
+		case shared.ModelRoleAutoFix:
+			if pack.AutoFix == nil {
+				continue
+			}
+		case shared.ModelRoleDraftBuilder:
+			if pack.DraftBuilder == nil || settings.BuildStrategy != shared.BuildStrategySpeculative {
+				continue
+			}
+		case shared.ModelRoleChat:
+			if pack.Chat == nil {
+				continue
+			}
+		}
+		roles = append(roles, role)
+	}
+
+	return roles
+}
+
+func checkSettingsPolicies(settings *shared.PlanSettings) []*shared.SettingsIssue {
+	var issues []*shared.SettingsIssue
+
+	invalid := func(msg string) {
+		issues = append(issues, &shared.SettingsIssue{
+			Kind:     shared.SettingsIssueInvalid,
+			Severity: shared.SettingsIssueError,
+			Msg:      msg,
+		})
+	}
+
+	if !settings.AutoContinue.Valid() {
+		invalid("invalid auto-continue policy: " + string(settings.AutoContinue))
+	}
+	if !settings.OnBuildError.Valid() {
+		invalid("invalid build error policy: " + string(settings.OnBuildError))
+	}
+	if !settings.BuildStrategy.Valid() {
+		invalid("invalid build strategy: " + string(settings.BuildStrategy))
+	}
+	if settings.MaxBuildFileTokens < 0 {
+		invalid(fmt.Sprintf("invalid max build file tokens: %d", settings.MaxBuildFileTokens))
+	}
+
+	return issues
+}
+
+// findKnownModel looks up a model by provider and name, first in the org's custom models, then in the built-in models
+func findKnownModel(customModels []*shared.AvailableModel, base shared.BaseModelConfig) *shared.AvailableModel {
+	matches := func(m *shared.AvailableModel) bool {
+		if m.Provider != base.Provider || !strings.EqualFold(m.ModelName, base.ModelName) {
+			return false
+		}
+		if base.Provider == shared.ModelProviderCustom {
+			return m.CustomProvider != nil && base.CustomProvider != nil && *m.CustomProvider == *base.CustomProvider
+		}
+		return true
+	}
+
+	for _, m := range customModels {
+		if matches(m) {
+			return m
+		}
+	}
+
+	for _, m := range shared.AvailableModels {
+		if matches(m) {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// checkModelCapabilities flags capabilities the settings claim for a model that the model doesn't have, since requests that use them will be rejected by the provider
+func checkModelCapabilities(role shared.ModelRole, base shared.BaseModelConfig, known *shared.AvailableModel) []*shared.SettingsIssue {
+	var issues []*shared.SettingsIssue
+
+	claims := []struct {
+		kind    shared.SettingsIssueKind
+		name    string
+		claimed bool
+		has     bool
+	}{
+		{shared.SettingsIssueStreaming, "streaming", base.HasStreaming, known.HasStreaming},
+		{shared.SettingsIssueFunctionCalling, "function calling", base.HasFunctionCalling, known.HasFunctionCalling},
+		{shared.SettingsIssueFunctionCalling, "streaming function calls", base.HasStreamingFunctionCalls, known.HasStreamingFunctionCalls},
+		{shared.SettingsIssueFunctionCalling, "structured outputs", base.HasStructuredOutputs, known.HasStructuredOutputs},
+		{shared.SettingsIssueJsonMode, "JSON mode", base.HasJsonResponseMode, known.HasJsonResponseMode},
+	}
+
+	for _, c := range claims {
+		if c.claimed && !c.has {
+			issues = append(issues, &shared.SettingsIssue{
+				Role:     role,
+				Kind:     c.kind,
+				Severity: shared.SettingsIssueError,
+				Msg:      fmt.Sprintf("the settings use %s with %s, which doesn't support it", c.name, base.ModelName),
+				Fix:      fmt.Sprintf("set the %s role to a model that supports %s, or re-add the model with its actual capabilities", role, c.name),
+			})
+		}
+	}
+
+	return issues
+}
+
+// checkRoleRequirements flags models that lack a capability the role depends on
+func checkRoleRequirements(role shared.ModelRole, base shared.BaseModelConfig) []*shared.SettingsIssue {
+	var issues []*shared.SettingsIssue
+
+	switch role {
+	case shared.ModelRolePlanner, shared.ModelRoleChat:
+		if !base.HasStreaming {
+			issues = append(issues, &shared.SettingsIssue{
+				Role:     role,
+				Kind:     shared.SettingsIssueStreaming,
+				Severity: shared.SettingsIssueWarning,
+				Msg:      fmt.Sprintf("%s can't stream, so each reply will arrive all at once when it's done", base.ModelName),
+			})
+		}
+
+	case shared.ModelRoleBuilder, shared.ModelRoleVerifier, shared.ModelRoleAutoFix, shared.ModelRoleDraftBuilder:
+		if !base.HasFunctionCalling && !base.HasStructuredOutputs {
+			issues = append(issues, &shared.SettingsIssue{
+				Role:     role,
+				Kind:     shared.SettingsIssueFunctionCalling,
+				Severity: shared.SettingsIssueError,
+				Msg:      fmt.Sprintf("builds need function calling or structured outputs, and %s supports neither", base.ModelName),
+				Fix:      fmt.Sprintf("set the %s role to a model with function calling", role),
+			})
+		} else if !base.HasStreamingFunctionCalls && !base.HasStructuredOutputs {
+			issues = append(issues, &shared.SettingsIssue{
+				Role:     role,
+				Kind:     shared.SettingsIssueFunctionCalling,
+				Severity: shared.SettingsIssueWarning,
+				Msg:      fmt.Sprintf("%s can't stream function calls, so build progress won't be shown until each file is done", base.ModelName),
+			})
+		}
+
+	case shared.ModelRoleName, shared.ModelRoleCommitMsg, shared.ModelRoleExecStatus:
+		if !base.HasFunctionCalling {
+			issues = append(issues, &shared.SettingsIssue{
+				Role:     role,
+				Kind:     shared.SettingsIssueFunctionCalling,
+				Severity: shared.SettingsIssueError,
+				Msg:      fmt.Sprintf("this role calls a function, but %s doesn't support function calling", base.ModelName),
+				Fix:      fmt.Sprintf("set the %s role to a model with function calling", role),
+			})
+		} else if !base.HasJsonResponseMode {
+			issues = append(issues, &shared.SettingsIssue{
+				Role:     role,
+				Kind:     shared.SettingsIssueJsonMode,
+				Severity: shared.SettingsIssueWarning,
+				Msg:      fmt.Sprintf("%s doesn't have JSON mode, so its function call arguments are more likely to be malformed", base.ModelName),
+			})
+		}
+	}
+
+	return issues
+}
+
+// checkContextWindow flags limits in the settings that are larger than the model's context window
+func checkContextWindow(role shared.ModelRole, config shared.ModelRoleConfig, known *shared.AvailableModel) []*shared.SettingsIssue {
+	var issues []*shared.SettingsIssue
+
+	base := config.BaseModelConfig
+	window := known.MaxTokens
+	if window <= 0 {
+		return nil
+	}
+
+	if base.MaxTokens > window {
+		issues = append(issues, &shared.SettingsIssue{
+			Role:     role,
+			Kind:     shared.SettingsIssueContextWindow,
+			Severity: shared.SettingsIssueError,
+			Msg:      fmt.Sprintf("max tokens is %d, but %s's context window is %d, so large prompts will be rejected", base.MaxTokens, base.ModelName, window),
+			Fix:      fmt.Sprintf("lower max tokens to %d", window),
+		})
+	}
+
+	if config.MaxResponseTokens != nil && *config.MaxResponseTokens > window {
+		issues = append(issues, &shared.SettingsIssue{
+			Role:     role,
+			Kind:     shared.SettingsIssueContextWindow,
+			Severity: shared.SettingsIssueError,
+			Msg:      fmt.Sprintf("max-response-tokens is %d, but %s's context window is %d", *config.MaxResponseTokens, base.ModelName, window),
+			Fix:      fmt.Sprintf("lower max-response-tokens to %d or less, or clear it to use the model's limit", window),
+		})
+	}
+
+	return issues
+}
+
+// checkPlannerContextWindow checks that the planner's conversation limit and reserved output, with any overrides, leave room in its context window for the plan's context
+func checkPlannerContextWindow(settings *shared.PlanSettings) []*shared.SettingsIssue {
+	planner := settings.ModelPack.Planner
+	overrides := settings.ModelOverrides
+
+	maxTokens := planner.BaseModelConfig.MaxTokens
+	if overrides.MaxTokens != nil {
+		maxTokens = *overrides.MaxTokens
+	}
+	maxConvoTokens := planner.MaxConvoTokens
+	if overrides.MaxConvoTokens != nil {
+		maxConvoTokens = *overrides.MaxConvoTokens
+	}
+	reservedOutputTokens := planner.ReservedOutputTokens
+	if overrides.ReservedOutputTokens != nil {
+		reservedOutputTokens = *overrides.ReservedOutputTokens
+	}
+
+	var issues []*shared.SettingsIssue
+
+	if overrides.MaxTokens != nil && planner.BaseModelConfig.MaxTokens > 0 && *overrides.MaxTokens > planner.BaseModelConfig.MaxTokens {
+		issues = append(issues, &shared.SettingsIssue{
+			Role:     shared.ModelRolePlanner,
+			Kind:     shared.SettingsIssueContextWindow,
+			Severity: shared.SettingsIssueError,
+			Msg:      fmt.Sprintf("the max-tokens override is %d, but %s's context window is %d", *overrides.MaxTokens, planner.BaseModelConfig.ModelName, planner.BaseModelConfig.MaxTokens),
+			Fix:      fmt.Sprintf("lower the override to %d or less", planner.BaseModelConfig.MaxTokens),
+		})
+	}
+
+	if maxTokens > 0 && maxConvoTokens+reservedOutputTokens >= maxTokens {
+		issues = append(issues, &shared.SettingsIssue{
+			Role:     shared.ModelRolePlanner,
+			Kind:     shared.SettingsIssueContextWindow,
+			Severity: shared.SettingsIssueError,
+			Msg:      fmt.Sprintf("max convo tokens (%d) plus reserved output tokens (%d) fill the context window of %d, leaving no room for context", maxConvoTokens, reservedOutputTokens, maxTokens),
+			Fix:      "lower max-convo-tokens or max-output-tokens",
+		})
+	}
+
+	return issues
+}
+
+// checkPriceIncrease warns when a role's new model costs at least settingsPriceIncreaseWarnRatio times its current model
+func checkPriceIncrease(role shared.ModelRole, base shared.BaseModelConfig, pricing *shared.ModelPricing, originalPack *shared.ModelPack, getPricing func(shared.ModelProvider, string) *shared.ModelPricing) *shared.SettingsIssue {
+	// a copy, since GetRoleConfig fills in roles that fall back to another role's config
+	original := *originalPack
+	originalBase := original.GetRoleConfig(role).BaseModelConfig
+
+	if originalBase.Provider == base.Provider && strings.EqualFold(originalBase.ModelName, base.ModelName) {
+		return nil
+	}
+
+	originalPricing := getPricing(originalBase.Provider, originalBase.ModelName)
+	if originalPricing == nil {
+		return nil
+	}
+
+	// weighted toward input, which is most of the tokens for every role
+	cost := pricing.Cost(3, 1)
+	originalCost := originalPricing.Cost(3, 1)
+	if originalCost <= 0 || cost/originalCost < settingsPriceIncreaseWarnRatio {
+		return nil
+	}
+
+	return &shared.SettingsIssue{
+		Role:     role,
+		Kind:     shared.SettingsIssuePricing,
+		Severity: shared.SettingsIssueWarning,
+		Msg: fmt.Sprintf("%s costs %s/%s per million input/output tokens, %.1fx %s's %s/%s",
+			base.ModelName, shared.FormatCost(pricing.InputPerMillion), shared.FormatCost(pricing.OutputPerMillion),
+			cost/originalCost,
+			originalBase.ModelName, shared.FormatCost(originalPricing.InputPerMillion), shared.FormatCost(originalPricing.OutputPerMillion)),
+	}
+}
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func testSettings(t *testing.T, pack *shared.ModelPack) *shared.PlanSettings {
+	// deep copy so tests can change the built-in packs' configs
+	bytes, err := json.Marshal(pack)
+	if err != nil {
+		t.Fatalf("error marshalling model pack: %v", err)
+	}
+	var copied shared.ModelPack
+	err = json.Unmarshal(bytes, &copied)
+	if err != nil {
+		t.Fatalf("error unmarshalling model pack: %v", err)
+	}
+	return &shared.PlanSettings{ModelPack: &copied}
+}
+
+func testPricing(provider shared.ModelProvider, modelName string) *shared.ModelPricing {
+	return shared.GetModelPricing(modelName)
+}
+
+func findIssue(issues []*shared.SettingsIssue, role shared.ModelRole, kind shared.SettingsIssueKind, severity shared.SettingsIssueSeverity) *shared.SettingsIssue {
+	for _, issue := range issues {
+		if issue.Role == role && issue.Kind == kind && issue.Severity == severity {
+			return issue
+		}
+	}
+	return nil
+}
+
+func TestCheckSettingsBuiltInPacks(t *testing.T) {
+	for _, pack := range shared.BuiltInModelPacks {
+		issues := CheckSettings(CheckSettingsParams{
+			Settings:   testSettings(t, pack),
+			GetPricing: testPricing,
+		})
+
+		for _, issue := range issues {
+			if issue.Severity == shared.SettingsIssueError {
+				t.Errorf("built-in pack %s: unexpected error: %s", pack.Name, issue)
+			}
+		}
+	}
+}
+
+func TestCheckSettingsBuilderWithoutFunctionCalling(t *testing.T) {
+	settings := testSettings(t, &shared.Gpt4oLatestModelPack)
+	settings.ModelPack.Builder.BaseModelConfig.HasFunctionCalling = false
+	settings.ModelPack.Builder.BaseModelConfig.HasStreamingFunctionCalls = false
+	settings.ModelPack.Builder.BaseModelConfig.HasStructuredOutputs = false
+
+	issues := CheckSettings(CheckSettingsParams{Settings: settings})
+
+	if findIssue(issues, shared.ModelRoleBuilder, shared.SettingsIssueFunctionCalling, shared.SettingsIssueError) == nil {
+		t.Errorf("expected a function calling error for the builder, got %v", issues)
+	}
+	if !shared.SettingsIssuesHaveErrors(issues) {
+		t.Errorf("expected issues to have errors")
+	}
+}
+
+func TestCheckSettingsCapabilityNotSupported(t *testing.T) {
+	settings := testSettings(t, &shared.Gpt4oLatestModelPack)
+	settings.ModelPack.Namer.BaseModelConfig.HasStructuredOutputs = true
+	known := findKnownModel(nil, settings.ModelPack.Namer.BaseModelConfig)
+	if known == nil || known.HasStructuredOutputs {
+		t.Skip("namer model supports structured outputs")
+	}
+
+	issues := CheckSettings(CheckSettingsParams{Settings: settings})
+
+	if findIssue(issues, shared.ModelRoleName, shared.SettingsIssueFunctionCalling, shared.SettingsIssueError) == nil {
+		t.Errorf("expected an error for a structured outputs claim the model doesn't support, got %v", issues)
+	}
+}
+
+func TestCheckSettingsContextWindow(t *testing.T) {
+	settings := testSettings(t, &shared.Gpt4oLatestModelPack)
+	window := settings.ModelPack.Planner.BaseModelConfig.MaxTokens
+
+	convo := window - 1000
+	settings.ModelOverrides.MaxConvoTokens = &convo
+
+	issues := CheckSettings(CheckSettingsParams{Settings: settings})
+
+	if findIssue(issues, shared.ModelRolePlanner, shared.SettingsIssueContextWindow, shared.SettingsIssueError) == nil {
+		t.Errorf("expected a context window error for the planner, got %v", issues)
+	}
+
+	settings = testSettings(t, &shared.Gpt4oLatestModelPack)
+	settings.ModelPack.Builder.BaseModelConfig.MaxTokens = window * 2
+
+	issues = CheckSettings(CheckSettingsParams{Settings: settings})
+
+	if findIssue(issues, shared.ModelRoleBuilder, shared.SettingsIssueContextWindow, shared.SettingsIssueError) == nil {
+		t.Errorf("expected a context window error for the builder, got %v", issues)
+	}
+}
+
+func TestCheckSettingsUnknownModel(t *testing.T) {
+	settings := testSettings(t, &shared.Gpt4oLatestModelPack)
+	settings.ModelPack.Namer.BaseModelConfig.ModelName = "acme-namer-1"
+
+	issues := CheckSettings(CheckSettingsParams{Settings: settings, GetPricing: testPricing})
+
+	if findIssue(issues, shared.ModelRoleName, shared.SettingsIssueUnknownModel, shared.SettingsIssueWarning) == nil {
+		t.Errorf("expected an unknown model warning, got %v", issues)
+	}
+	if findIssue(issues, "", shared.SettingsIssuePricing, shared.SettingsIssueWarning) == nil {
+		t.Errorf("expected a missing price warning, got %v", issues)
+	}
+
+	custom := settings.ModelPack.Namer.BaseModelConfig
+	issues = CheckSettings(CheckSettingsParams{
+		Settings:     settings,
+		CustomModels: []*shared.AvailableModel{{BaseModelConfig: custom}},
+	})
+
+	if findIssue(issues, shared.ModelRoleName, shared.SettingsIssueUnknownModel, shared.SettingsIssueWarning) != nil {
+		t.Errorf("expected custom models to be known, got %v", issues)
+	}
+}
+
+func TestCheckSettingsPriceIncrease(t *testing.T) {
+	original := testSettings(t, &shared.Gpt4oLatestModelPack)
+	original.ModelPack.Builder.BaseModelConfig.ModelName = "gpt-3.5-turbo"
+
+	settings := testSettings(t, &shared.Gpt4oLatestModelPack)
+	settings.ModelPack.Builder.BaseModelConfig.ModelName = "gpt-4-turbo"
+
+	issues := CheckSettings(CheckSettingsParams{
+		Settings:   settings,
+		Original:   original,
+		GetPricing: testPricing,
+	})
+
+	if findIssue(issues, shared.ModelRoleBuilder, shared.SettingsIssuePricing, shared.SettingsIssueWarning) == nil {
+		t.Errorf("expected a price increase warning for the builder, got %v", issues)
+	}
+
+	issues = CheckSettings(CheckSettingsParams{
+		Settings:   original,
+		Original:   settings,
+		GetPricing: testPricing,
+	})
+
+	if findIssue(issues, shared.ModelRoleBuilder, shared.SettingsIssuePricing, shared.SettingsIssueWarning) != nil {
+		t.Errorf("expected no warning for a cheaper builder, got %v", issues)
+	}
+}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// how often OnWaitProgress callbacks are called while waiting for a model that can't stream
+const waitProgressInterval = 5 * time.Second
+
+type noStreamingKey struct{}
+type waitProgressKey struct{}
+
+// WithWaitProgress sets a callback for requests sent with the returned context to models that can't stream. Those requests are sent without streaming, and the callback is called every few seconds with the time spent waiting, so the user can be shown that the model is still working.
+func WithWaitProgress(ctx context.Context, onProgress func(elapsed time.Duration)) context.Context {
+	return context.WithValue(ctx, waitProgressKey{}, onProgress)
+}
+
+func withNoStreaming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noStreamingKey{}, true)
+}
+
+func isNoStreaming(ctx context.Context) bool {
+	noStreaming, _ := ctx.Value(noStreamingKey{}).(bool)
+	return noStreaming
+}
+
+// createNonStreamingFallback sends a streaming request without streaming, for models that can't stream, and returns a stream that replays the response in chunks, so stream listeners don't need a separate path. It waits for the whole response, calling the wait progress callback set on ctx along the way.
+func createNonStreamingFallback(client *openai.Client, ctx context.Context, req openai.ChatCompletionRequest) (ChatCompletionStream, error) {
+	req.Stream = false
+	req.StreamOptions = nil
+
+	done := make(chan struct{})
+	defer close(done)
+
+	if onProgress, ok := ctx.Value(waitProgressKey{}).(func(time.Duration)); ok && onProgress != nil {
+		go func() {
+			start := time.Now()
+			ticker := time.NewTicker(waitProgressInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					onProgress(time.Since(start))
+				}
+			}
+		}()
+	}
+
+	log.Printf("Model %s can't stream, sending request without streaming\n", req.Model)
+
+	resp, err := createChatCompletion(client, ctx, req, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return newReplayStream(resp), nil
+}
+
+// replayStream returns a complete response as stream chunks: the content line by line, then any tool calls, then the finish reason
+type replayStream struct {
+	chunks []openai.ChatCompletionStreamResponse
+}
+
+func newReplayStream(resp openai.ChatCompletionResponse) *replayStream {
+	s := &replayStream{}
+
+	chunk := func(delta openai.ChatCompletionStreamChoiceDelta, finishReason openai.FinishReason) openai.ChatCompletionStreamResponse {
+		return openai.ChatCompletionStreamResponse{
+			ID:      resp.ID,
+			Object:  "chat.completion.chunk",
+			Created: resp.Created,
+			Model:   resp.Model,
+			Choices: []openai.ChatCompletionStreamChoice{
+				{Delta: delta, FinishReason: finishReason},
+			},
+		}
+	}
+
+	if len(resp.Choices) == 0 {
+		return s
+	}
+
+	choice := resp.Choices[0]
+
+	content := choice.Message.Content
+	for content != "" {
+		line := content
+		if i := strings.Index(content, "\n"); i >= 0 {
+			line = content[:i+1]
+		}
+		content = content[len(line):]
+
+		s.chunks = append(s.chunks, chunk(openai.ChatCompletionStreamChoiceDelta{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: line,
+		}, ""))
+	}
+
+	if len(choice.Message.ToolCalls) > 0 {
+		var toolCalls []openai.ToolCall
+		for i, toolCall := range choice.Message.ToolCalls {
+			index := i
+			toolCall.Index = &index
+			toolCalls = append(toolCalls, toolCall)
+		}
+		s.chunks = append(s.chunks, chunk(openai.ChatCompletionStreamChoiceDelta{
+			Role:      openai.ChatMessageRoleAssistant,
+			ToolCalls: toolCalls,
+		}, ""))
+	}
+
+	finishReason := choice.FinishReason
+	if finishReason == "" {
+		finishReason = openai.FinishReasonStop
+	}
+	s.chunks = append(s.chunks, chunk(openai.ChatCompletionStreamChoiceDelta{}, finishReason))
+
+	return s
+}
+
+func (s *replayStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	if len(s.chunks) == 0 {
+		return openai.ChatCompletionStreamResponse{}, io.EOF
+	}
+
+	res := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return res, nil
+}
+
+func (s *replayStream) Close() error {
+	return nil
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"errors"
+	"io"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ChatCompletionStream is implemented by *openai.ChatCompletionStream and by the wrappers around it
+type ChatCompletionStream interface {
+	Recv() (openai.ChatCompletionStreamResponse, error)
+	Close() error
+}
+
+// finish reasons sent by self-hosted servers (TGI, and vLLM/llama.cpp with some models) that mean the same thing as 'stop'
+var stopFinishReasons = map[openai.FinishReason]bool{
+	"eos_token":     true,
+	"eos":           true,
+	"stop_sequence": true,
+	"end_turn":      true,
+}
+
+// normalizedStream smooths over the ways OpenAI-compatible servers diverge from OpenAI's streaming format, so stream listeners only have to handle one shape of stream:
+//   - chunks with no choices (usage-only chunks and keep-alives) are skipped
+//   - non-standard finish reasons that mean 'stop' are reported as 'stop'
+//   - a chunk that has both content and a finish reason is split in two, so the last content isn't dropped by listeners that stop at the finish reason
+//   - tool call deltas without an index get their position as the index
+//   - a stream that ends without a finish reason after sending content gets a final 'stop' chunk
+//
+// Streams from OpenAI and other providers that follow its format pass through unchanged.
+type normalizedStream struct {
+	stream   ChatCompletionStream
+	pending  *openai.ChatCompletionStreamResponse
+	last     openai.ChatCompletionStreamResponse
+	received bool
+	finished bool
+}
+
+func newNormalizedStream(stream ChatCompletionStream) *normalizedStream {
+	return &normalizedStream{stream: stream}
+}
+
+func (s *normalizedStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	if s.pending != nil {
+		res := *s.pending
+		s.pending = nil
+		return res, nil
+	}
+
+	for {
+		res, err := s.stream.Recv()
+
+		if errors.Is(err, io.EOF) && s.received && !s.finished {
+			s.finished = true
+			return openai.ChatCompletionStreamResponse{
+				ID:      s.last.ID,
+				Object:  s.last.Object,
+				Created: s.last.Created,
+				Model:   s.last.Model,
+				Choices: []openai.ChatCompletionStreamChoice{
+					{FinishReason: openai.FinishReasonStop},
+				},
+			}, nil
+		}
+
+		if err != nil {
+			return res, err
+		}
+
+		if len(res.Choices) == 0 {
+			continue
+		}
+
+		s.received = true
+		s.last = res
+
+		choice := &res.Choices[0]
+
+		if stopFinishReasons[choice.FinishReason] {
+			choice.FinishReason = openai.FinishReasonStop
+		}
+
+		for i := range choice.Delta.ToolCalls {
+			if choice.Delta.ToolCalls[i].Index == nil {
+				index := i
+				choice.Delta.ToolCalls[i].Index = &index
+			}
+		}
+
+		if choice.FinishReason == "" {
+			return res, nil
+		}
+
+		s.finished = true
+
+		if choice.Delta.Content == "" && len(choice.Delta.ToolCalls) == 0 {
+			return res, nil
+		}
+
+		finish := res
+		finish.Choices = []openai.ChatCompletionStreamChoice{
+			{Index: choice.Index, FinishReason: choice.FinishReason},
+		}
+		s.pending = &finish
+
+		choice.FinishReason = ""
+		return res, nil
+	}
+}
+
+func (s *normalizedStream) Close() error {
+	return s.stream.Close()
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type fakeStream struct {
+	chunks []openai.ChatCompletionStreamResponse
+	err    error
+}
+
+func (s *fakeStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	if len(s.chunks) == 0 {
+		if s.err != nil {
+			return openai.ChatCompletionStreamResponse{}, s.err
+		}
+		return openai.ChatCompletionStreamResponse{}, io.EOF
+	}
+	res := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return res, nil
+}
+
+func (s *fakeStream) Close() error {
+	return nil
+}
+
+func chunk(content string, finishReason openai.FinishReason) openai.ChatCompletionStreamResponse {
+	return openai.ChatCompletionStreamResponse{
+		Choices: []openai.ChatCompletionStreamChoice{
+			{Delta: openai.ChatCompletionStreamChoiceDelta{Content: content}, FinishReason: finishReason},
+		},
+	}
+}
+
+type streamedChunk struct {
+	content      string
+	finishReason openai.FinishReason
+}
+
+func recvAll(t *testing.T, stream ChatCompletionStream) ([]streamedChunk, error) {
+	var res []streamedChunk
+	for {
+		chunk, err := stream.Recv()
+		if err != nil {
+			return res, err
+		}
+		if len(chunk.Choices) != 1 {
+			t.Fatalf("expected 1 choice, got %d", len(chunk.Choices))
+		}
+		res = append(res, streamedChunk{chunk.Choices[0].Delta.Content, chunk.Choices[0].FinishReason})
+	}
+}
+
+func TestNormalizedStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []openai.ChatCompletionStreamResponse
+		err    error
+		want   []streamedChunk
+		// the error after the last chunk
+		wantErr error
+	}{
+		{
+			name:    "standard stream passes through",
+			chunks:  []openai.ChatCompletionStreamResponse{chunk("a", ""), chunk("b", ""), chunk("", "stop")},
+			want:    []streamedChunk{{"a", ""}, {"b", ""}, {"", "stop"}},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "chunks with no choices are skipped",
+			chunks:  []openai.ChatCompletionStreamResponse{chunk("a", ""), {}, chunk("", "stop"), {}},
+			want:    []streamedChunk{{"a", ""}, {"", "stop"}},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "tgi finish reasons become stop",
+			chunks:  []openai.ChatCompletionStreamResponse{chunk("a", ""), chunk("", "eos_token")},
+			want:    []streamedChunk{{"a", ""}, {"", "stop"}},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "content with a finish reason is split",
+			chunks:  []openai.ChatCompletionStreamResponse{chunk("a", ""), chunk("b", "length")},
+			want:    []streamedChunk{{"a", ""}, {"b", ""}, {"", "length"}},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "stream that ends without a finish reason gets one",
+			chunks:  []openai.ChatCompletionStreamResponse{chunk("a", ""), chunk("b", "")},
+			want:    []streamedChunk{{"a", ""}, {"b", ""}, {"", "stop"}},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "empty stream isn't given a finish reason",
+			want:    nil,
+			wantErr: io.EOF,
+		},
+		{
+			name:    "errors pass through",
+			chunks:  []openai.ChatCompletionStreamResponse{chunk("a", "")},
+			err:     io.ErrUnexpectedEOF,
+			want:    []streamedChunk{{"a", ""}},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := recvAll(t, newNormalizedStream(&fakeStream{chunks: tt.chunks, err: tt.err}))
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d chunks %v, want %d %v", len(got), got, len(tt.want), tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("chunk %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizedStreamToolCallIndex(t *testing.T) {
+	stream := newNormalizedStream(&fakeStream{chunks: []openai.ChatCompletionStreamResponse{
+		{Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{
+			ToolCalls: []openai.ToolCall{{Function: openai.FunctionCall{Arguments: `{"ok":`}}},
+		}}}},
+	}})
+
+	res, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+
+	index := res.Choices[0].Delta.ToolCalls[0].Index
+	if index == nil || *index != 0 {
+		t.Errorf("expected a missing tool call index to be set to 0, got %v", index)
+	}
+}
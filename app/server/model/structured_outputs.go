@@ -0,0 +1,84 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// WithJsonSchemaResponse asks the model to respond with JSON that matches a schema, using the json_schema response format in strict mode. It's sent by the transport since the openai client only supports the json_object format, so the returned context must be used to send the request.
+func WithJsonSchemaResponse(ctx context.Context, name string, schema *jsonschema.Definition) context.Context {
+	return withBodyParams(ctx, map[string]interface{}{
+		"response_format": map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   name,
+				"strict": true,
+				"schema": StrictJsonSchema(schema),
+			},
+		},
+	})
+}
+
+// StrictJsonSchema converts a schema to the form strict mode requires: every object lists all its properties as required and disallows additional ones. Properties that weren't required become nullable so they can still be left out.
+func StrictJsonSchema(schema *jsonschema.Definition) map[string]interface{} {
+	bytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+
+	var res map[string]interface{}
+	err = json.Unmarshal(bytes, &res)
+	if err != nil {
+		return nil
+	}
+
+	makeStrict(res)
+
+	return res
+}
+
+func makeStrict(schema map[string]interface{}) {
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		makeStrict(items)
+	}
+
+	if schema["type"] != "object" {
+		// the openai client's schema type always includes properties, which only belong on objects
+		delete(schema, "properties")
+		return
+	}
+
+	isRequired := map[string]bool{}
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if s, ok := name.(string); ok {
+				isRequired[s] = true
+			}
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	required := []string{}
+	for name, prop := range props {
+		propSchema, ok := prop.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		makeStrict(propSchema)
+
+		if !isRequired[name] {
+			if t, ok := propSchema["type"].(string); ok {
+				propSchema["type"] = []string{t, "null"}
+			}
+		}
+		required = append(required, name)
+	}
+	// map order is random, but a stable schema keeps requests repeatable
+	sort.Strings(required)
+
+	schema["required"] = required
+	schema["additionalProperties"] = false
+}
@@ -17,6 +17,7 @@ type PlanSummaryParams struct {
 	LatestConvoMessageCreatedAt time.Time
 	NumMessages                 int
 	OrgId                       string
+	UserId                      string
 	PlanId                      string
 }
 
@@ -40,16 +41,13 @@ func PlanSummary(client *openai.Client, config shared.ModelRoleConfig, params Pl
 	fmt.Println("summarizing messages:")
 	// spew.Dump(messages)
 
-	resp, err := CreateChatCompletionWithRetries(
-		client,
-		ctx,
-		openai.ChatCompletionRequest{
-			Model:       config.BaseModelConfig.ModelName,
-			Messages:    messages,
-			Temperature: config.Temperature,
-			TopP:        config.TopP,
-		},
-	)
+	modelReq := openai.ChatCompletionRequest{
+		Model:    config.BaseModelConfig.ModelName,
+		Messages: messages,
+	}
+	reqCtx := ApplyRoleConfig(ctx, &modelReq, config)
+
+	resp, err := CreateChatCompletionWithCache(client, reqCtx, modelReq, shared.ResponseCacheOperationSummaries, ResponseCacheOwner{OrgId: params.OrgId, UserId: params.UserId})
 
 	if err != nil {
 		fmt.Println("PlanSummary err:", err)
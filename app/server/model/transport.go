@@ -0,0 +1,165 @@
+package model
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// httpClient is shared by all model clients so that connections are reused. It's replaced by InitTransport when a proxy or CA bundle is configured. Clients keep the http client they were created with, so replacing it on a config reload doesn't affect active streams.
+var httpClient = &http.Client{}
+var httpClientMu sync.RWMutex
+
+func getHttpClient() *http.Client {
+	httpClientMu.RLock()
+	defer httpClientMu.RUnlock()
+	return httpClient
+}
+
+// InitTransport configures outbound model requests from the environment:
+//
+//   - PLANDEX_MODEL_PROXY: proxy url for all model requests. When unset, the standard HTTPS_PROXY, HTTP_PROXY, and NO_PROXY variables apply.
+//   - PLANDEX_MODEL_PROXY_OVERRIDES: comma-separated 'provider=url' or 'host=url' pairs that override the proxy for one provider or host (e.g. 'openrouter=http://proxy2:3128,llm.internal=direct'). Use 'direct' to bypass the proxy.
+//   - PLANDEX_MODEL_CA_BUNDLE: path to a PEM file of extra CA certificates to trust, for proxies that inspect TLS traffic.
+func InitTransport() error {
+	proxy := os.Getenv("PLANDEX_MODEL_PROXY")
+	overrides := os.Getenv("PLANDEX_MODEL_PROXY_OVERRIDES")
+	caBundle := os.Getenv("PLANDEX_MODEL_CA_BUNDLE")
+
+	if proxy == "" && overrides == "" && caBundle == "" {
+		httpClientMu.Lock()
+		httpClient = &http.Client{}
+		httpClientMu.Unlock()
+		return nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxyFn, err := newProxyFunc(proxy, overrides)
+	if err != nil {
+		return err
+	}
+	transport.Proxy = proxyFn
+
+	if caBundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			log.Printf("Error loading system cert pool, using only %s: %v\n", caBundle, err)
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return fmt.Errorf("error reading PLANDEX_MODEL_CA_BUNDLE: %v", err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in PLANDEX_MODEL_CA_BUNDLE %s", caBundle)
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	httpClientMu.Lock()
+	httpClient = &http.Client{Transport: transport}
+	httpClientMu.Unlock()
+
+	log.Println("Configured model request transport")
+	if proxy != "" {
+		log.Printf("Model requests use proxy %s\n", redactProxyUrl(proxy))
+	}
+	if overrides != "" {
+		log.Println("Model request proxy overrides are set")
+	}
+	if caBundle != "" {
+		log.Printf("Model requests trust CA bundle %s\n", caBundle)
+	}
+
+	return nil
+}
+
+func newProxyFunc(proxy, overrides string) (func(*http.Request) (*url.URL, error), error) {
+	var defaultProxy *url.URL
+	if proxy != "" {
+		u, err := parseProxyUrl(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PLANDEX_MODEL_PROXY: %v", err)
+		}
+		defaultProxy = u
+	}
+
+	// a nil url means the host bypasses the proxy
+	proxiesByHost := map[string]*url.URL{}
+	for _, pair := range strings.Split(overrides, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid PLANDEX_MODEL_PROXY_OVERRIDES entry -- expected comma-separated 'provider=url' or 'host=url' pairs")
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		host := key
+		if baseUrl, ok := shared.BaseUrlByProvider[shared.ModelProvider(key)]; ok {
+			u, err := url.Parse(baseUrl)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing base url for provider %s: %v", key, err)
+			}
+			host = u.Hostname()
+		}
+
+		if value == "direct" {
+			proxiesByHost[host] = nil
+			continue
+		}
+
+		u, err := parseProxyUrl(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PLANDEX_MODEL_PROXY_OVERRIDES entry for %s: %v", key, err)
+		}
+		proxiesByHost[host] = u
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if u, ok := proxiesByHost[strings.ToLower(req.URL.Hostname())]; ok {
+			return u, nil
+		}
+		if defaultProxy != nil {
+			return defaultProxy, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}, nil
+}
+
+func parseProxyUrl(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		// the parse error includes the url, which may contain credentials
+		return nil, fmt.Errorf("couldn't parse proxy url")
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%q isn't a url like http://proxy:3128", redactProxyUrl(s))
+	}
+	return u, nil
+}
+
+// redactProxyUrl hides credentials in a proxy url before it's logged
+func redactProxyUrl(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"plandex-server/model/plan"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// runReplayCmd re-applies captured build responses (from 'plandex capture dump --json') to their captured files without a database or model provider, so apply regressions can be reproduced locally
+func runReplayCmd(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	in := flags.String("in", "", "path of a capture, or an array of captures, as output by 'plandex capture dump --json'")
+	id := flags.String("id", "", "only replay the capture with this id")
+	out := flags.String("out", "", "write the updated file to this path instead of stdout (requires a single capture)")
+	flags.Parse(args)
+
+	if *in == "" {
+		log.Fatal("Usage: plandex-server replay --in FILE [--id ID] [--out FILE]")
+	}
+
+	bytes, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatal("Error reading captures: ", err)
+	}
+
+	captures, err := parseReplayCaptures(bytes)
+	if err != nil {
+		log.Fatal("Error parsing captures: ", err)
+	}
+
+	var replayable []*shared.BuildCapture
+	for _, capture := range captures {
+		if *id != "" && capture.Id != *id {
+			continue
+		}
+		if capture.Phase == shared.BuildCapturePhaseVerify {
+			continue
+		}
+		replayable = append(replayable, capture)
+	}
+
+	if len(replayable) == 0 {
+		log.Fatal("No build or fix captures to replay")
+	}
+
+	if *out != "" && len(replayable) > 1 {
+		log.Fatal("--out requires a single capture. Use --id to choose one.")
+	}
+
+	anyFailed := false
+	for _, capture := range replayable {
+		res, err := plan.ReplayBuildCapture(context.Background(), capture)
+		if err != nil {
+			log.Fatalf("Error replaying capture %s: %v", capture.Id, err)
+		}
+
+		status := "ok"
+		var problems []string
+		if !res.AllSucceeded {
+			problems = append(problems, "some replacements failed")
+		}
+		if res.PlanFileResult.WillCheckSyntax && !res.PlanFileResult.SyntaxValid {
+			problems = append(problems, fmt.Sprintf("syntax errors:\n%s", strings.Join(res.PlanFileResult.SyntaxErrors, "\n")))
+		}
+		if len(problems) > 0 {
+			anyFailed = true
+			status = strings.Join(problems, "; ")
+		}
+
+		log.Printf("Replayed %s capture %s for %s: %s\n", capture.Phase, capture.Id, capture.Path, status)
+
+		if *out != "" {
+			err = os.WriteFile(*out, []byte(res.Updated), 0644)
+			if err != nil {
+				log.Fatal("Error writing updated file: ", err)
+			}
+		} else {
+			fmt.Println(res.Updated)
+		}
+	}
+
+	if anyFailed {
+		os.Exit(1)
+	}
+}
+
+func parseReplayCaptures(bytes []byte) ([]*shared.BuildCapture, error) {
+	var captures []*shared.BuildCapture
+	trimmed := strings.TrimSpace(string(bytes))
+
+	if strings.HasPrefix(trimmed, "[") {
+		err := json.Unmarshal(bytes, &captures)
+		return captures, err
+	}
+
+	var capture shared.BuildCapture
+	err := json.Unmarshal(bytes, &capture)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(captures, &capture), nil
+}
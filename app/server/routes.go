@@ -11,10 +11,13 @@ import (
 
 func routes() *mux.Router {
 	r := mux.NewRouter()
+	r.Use(handlers.AdvertiseRequestEncodings)
 
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, "OK")
 	})
+	r.HandleFunc("/healthz", handlers.HealthzHandler).Methods("GET")
+	r.HandleFunc("/readyz", handlers.ReadyzHandler).Methods("GET")
 
 	r.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
 		// get version from version.txt
@@ -28,37 +31,93 @@ func routes() *mux.Router {
 		fmt.Fprint(w, string(bytes))
 	})
 
+	r.HandleFunc("/admin/reload_config", handlers.ReloadConfigHandler).Methods("POST")
+	r.HandleFunc("/admin/model_prices", handlers.ListModelPricesHandler).Methods("GET")
+	r.HandleFunc("/admin/model_prices", handlers.UpdateModelPricesHandler).Methods("PUT")
+	r.HandleFunc("/admin/model_prices", handlers.DeleteModelPriceHandler).Methods("DELETE")
+	r.HandleFunc("/admin/model_prices/fetch", handlers.FetchModelPricesHandler).Methods("POST")
+	r.HandleFunc("/admin/jobs", handlers.ListJobsHandler).Methods("GET")
+	r.HandleFunc("/admin/jobs/{jobId}/retry", handlers.RetryJobHandler).Methods("POST")
+	r.HandleFunc("/admin/jobs/{jobId}/cancel", handlers.CancelJobHandler).Methods("POST")
+
 	r.HandleFunc("/accounts/start_trial", handlers.StartTrialHandler).Methods("POST")
+	r.HandleFunc("/accounts/start_guest_trial", handlers.StartGuestTrialHandler).Methods("POST")
 	r.HandleFunc("/accounts/email_verifications", handlers.CreateEmailVerificationHandler).Methods("POST")
 	r.HandleFunc("/accounts/sign_in", handlers.SignInHandler).Methods("POST")
 	r.HandleFunc("/accounts/sign_out", handlers.SignOutHandler).Methods("POST")
+	r.HandleFunc("/accounts/rotate_token", handlers.RotateAuthTokenHandler).Methods("POST")
 	r.HandleFunc("/accounts", handlers.CreateAccountHandler).Methods("POST")
 	r.HandleFunc("/accounts/convert_trial", handlers.ConvertTrialHandler).Methods("POST")
 
 	r.HandleFunc("/orgs/session", handlers.GetOrgSessionHandler).Methods("GET")
 	r.HandleFunc("/orgs", handlers.ListOrgsHandler).Methods("GET")
 	r.HandleFunc("/orgs", handlers.CreateOrgHandler).Methods("POST")
+	r.HandleFunc("/orgs/retention_policy", handlers.GetOrgRetentionPolicyHandler).Methods("GET")
+	r.HandleFunc("/orgs/retention_policy", handlers.UpdateOrgRetentionPolicyHandler).Methods("PUT")
+	r.HandleFunc("/orgs/trial_policy", handlers.GetOrgTrialPolicyHandler).Methods("GET")
+	r.HandleFunc("/orgs/trial_policy", handlers.UpdateOrgTrialPolicyHandler).Methods("PUT")
+	r.HandleFunc("/orgs/trust_policy", handlers.GetOrgTrustPolicyHandler).Methods("GET")
+	r.HandleFunc("/orgs/trust_policy", handlers.UpdateOrgTrustPolicyHandler).Methods("PUT")
+	r.HandleFunc("/orgs/response_cache", handlers.GetOrgResponseCacheHandler).Methods("GET")
+	r.HandleFunc("/orgs/response_cache", handlers.ClearOrgResponseCacheHandler).Methods("DELETE")
+	r.HandleFunc("/orgs/response_cache_policy", handlers.UpdateOrgResponseCachePolicyHandler).Methods("PUT")
+	r.HandleFunc("/orgs/code_scan_policy", handlers.GetOrgCodeScanPolicyHandler).Methods("GET")
+	r.HandleFunc("/orgs/code_scan_policy", handlers.UpdateOrgCodeScanPolicyHandler).Methods("PUT")
+
+	r.HandleFunc("/orgs/context_bundles", handlers.ListOrgContextBundlesHandler).Methods("GET")
+	r.HandleFunc("/orgs/context_bundles/{name}", handlers.UpdateOrgContextBundleHandler).Methods("PUT")
+	r.HandleFunc("/orgs/context_bundles/{name}", handlers.DeleteOrgContextBundleHandler).Methods("DELETE")
+
+	r.HandleFunc("/orgs/knowledge", handlers.ListKnowledgeEntriesHandler).Methods("GET")
+	r.HandleFunc("/orgs/knowledge", handlers.CreateKnowledgeEntryHandler).Methods("POST")
+	r.HandleFunc("/orgs/knowledge/search", handlers.SearchKnowledgeHandler).Methods("POST")
+	r.HandleFunc("/orgs/knowledge/{entryId}", handlers.GetKnowledgeEntryHandler).Methods("GET")
+	r.HandleFunc("/orgs/knowledge/{entryId}", handlers.DeleteKnowledgeEntryHandler).Methods("DELETE")
+
+	r.HandleFunc("/orgs/learned_preferences", handlers.GetLearnedPreferencesHandler).Methods("GET")
+	r.HandleFunc("/orgs/learned_preferences", handlers.ClearLearnedPreferencesHandler).Methods("DELETE")
+
+	r.HandleFunc("/chat", handlers.ChatHandler).Methods("POST")
+
+	r.HandleFunc("/orgs/credentials", handlers.ListOrgCredentialsHandler).Methods("GET")
+	r.HandleFunc("/orgs/credentials", handlers.SetOrgCredentialHandler).Methods("POST")
+	r.HandleFunc("/orgs/credentials/{name}", handlers.DeleteOrgCredentialHandler).Methods("DELETE")
+	r.HandleFunc("/orgs/stats", handlers.GetOrgStatsHandler).Methods("GET")
+	r.HandleFunc("/orgs/{orgId}/export", handlers.ExportOrgDataHandler).Methods("GET")
+	r.HandleFunc("/orgs/{orgId}", handlers.HardDeleteOrgHandler).Methods("DELETE")
 
 	r.HandleFunc("/users", handlers.ListUsersHandler).Methods("GET")
+	r.HandleFunc("/users/digest_settings", handlers.GetUserDigestSettingsHandler).Methods("GET")
+	r.HandleFunc("/users/digest_settings", handlers.UpdateUserDigestSettingsHandler).Methods("PUT")
 	r.HandleFunc("/orgs/users/{userId}", handlers.DeleteOrgUserHandler).Methods("DELETE")
+	r.HandleFunc("/users/{userId}/export", handlers.ExportUserDataHandler).Methods("GET")
+	r.HandleFunc("/users/{userId}", handlers.HardDeleteUserHandler).Methods("DELETE")
 	r.HandleFunc("/orgs/roles", handlers.ListOrgRolesHandler).Methods("GET")
 
 	r.HandleFunc("/invites", handlers.InviteUserHandler).Methods("POST")
 	r.HandleFunc("/invites/pending", handlers.ListPendingInvitesHandler).Methods("GET")
 	r.HandleFunc("/invites/accepted", handlers.ListAcceptedInvitesHandler).Methods("GET")
 	r.HandleFunc("/invites/all", handlers.ListAllInvitesHandler).Methods("GET")
+	r.HandleFunc("/invites/accept", handlers.AcceptInviteHandler).Methods("POST")
+	r.HandleFunc("/invites/{inviteId}/resend", handlers.ResendInviteHandler).Methods("POST")
 	r.HandleFunc("/invites/{inviteId}", handlers.DeleteInviteHandler).Methods("DELETE")
 
 	r.HandleFunc("/projects", handlers.CreateProjectHandler).Methods("POST")
 	r.HandleFunc("/projects", handlers.ListProjectsHandler).Methods("GET")
 	r.HandleFunc("/projects/{projectId}/set_plan", handlers.ProjectSetPlanHandler).Methods("PUT")
 	r.HandleFunc("/projects/{projectId}/rename", handlers.RenameProjectHandler).Methods("PUT")
+	r.HandleFunc("/projects/{projectId}/trust_policy", handlers.GetProjectTrustPolicyHandler).Methods("GET")
+	r.HandleFunc("/projects/{projectId}/trust_policy", handlers.UpdateProjectTrustPolicyHandler).Methods("PUT")
+	r.HandleFunc("/projects/{projectId}/trust_policy", handlers.DeleteProjectTrustPolicyHandler).Methods("DELETE")
 
 	r.HandleFunc("/projects/{projectId}/plans/current_branches", handlers.GetCurrentBranchByPlanIdHandler).Methods("POST")
 
 	r.HandleFunc("/plans", handlers.ListPlansHandler).Methods("GET")
 	r.HandleFunc("/plans/archive", handlers.ListArchivedPlansHandler).Methods("GET")
 	r.HandleFunc("/plans/ps", handlers.ListPlansRunningHandler).Methods("GET")
+	r.HandleFunc("/plans/search", handlers.SearchPlansHandler).Methods("GET")
+	r.HandleFunc("/plans/tags", handlers.ListPlanTagsHandler).Methods("GET")
+	r.HandleFunc("/plans/shared", handlers.ListSharedPlansHandler).Methods("GET")
 
 	r.HandleFunc("/projects/{projectId}/plans", handlers.CreatePlanHandler).Methods("POST")
 
@@ -70,28 +129,48 @@ func routes() *mux.Router {
 	r.HandleFunc("/plans/{planId}/{branch}/tell", handlers.TellPlanHandler).Methods("POST")
 
 	r.HandleFunc("/plans/{planId}/{branch}/respond_missing_file", handlers.RespondMissingFileHandler).Methods("POST")
+	r.HandleFunc("/plans/{planId}/{branch}/respond_continue", handlers.RespondContinueHandler).Methods("POST")
+	r.HandleFunc("/plans/{planId}/{branch}/steer", handlers.SteerPlanHandler).Methods("POST")
 
 	r.HandleFunc("/plans/{planId}/{branch}/build", handlers.BuildPlanHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/{branch}/connect", handlers.ConnectPlanHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/{branch}/stop", handlers.StopPlanHandler).Methods("DELETE")
+	r.HandleFunc("/plans/{planId}/{branch}/pause_build", handlers.PauseBuildHandler).Methods("PATCH")
+	r.HandleFunc("/plans/{planId}/{branch}/resume_build", handlers.ResumeBuildHandler).Methods("PATCH")
 
 	r.HandleFunc("/plans/{planId}/{branch}/current_plan", handlers.CurrentPlanHandler).Methods("GET")
 	r.HandleFunc("/plans/{planId}/{branch}/apply", handlers.ApplyPlanHandler).Methods("PATCH")
+	r.HandleFunc("/plans/{planId}/{branch}/commit_msg", handlers.CommitMsgHandler).Methods("POST")
+	r.HandleFunc("/plans/{planId}/{branch}/impact_report", handlers.GetImpactReportHandler).Methods("GET")
+	r.HandleFunc("/plans/{planId}/{branch}/impact_report", handlers.CreateImpactReportHandler).Methods("POST")
+	r.HandleFunc("/plans/{planId}/{branch}/ask", handlers.AskPlanHandler).Methods("POST")
+	r.HandleFunc("/plans/{planId}/{branch}/bench", handlers.BenchHandler).Methods("POST")
+	r.HandleFunc("/plans/{planId}/{branch}/apply_hooks", handlers.RecordApplyHookHandler).Methods("POST")
 	r.HandleFunc("/plans/{planId}/archive", handlers.ArchivePlanHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/unarchive", handlers.UnarchivePlanHandler).Methods("PATCH")
 
 	r.HandleFunc("/plans/{planId}/rename", handlers.RenamePlanHandler).Methods("PATCH")
+	r.HandleFunc("/plans/{planId}/{branch}/auto-rename", handlers.AutoRenamePlanHandler).Methods("POST")
+	r.HandleFunc("/plans/{planId}/tags", handlers.UpdatePlanTagsHandler).Methods("PATCH")
+	r.HandleFunc("/plans/{planId}/shares", handlers.ListPlanSharesHandler).Methods("GET")
+	r.HandleFunc("/plans/{planId}/shares", handlers.SharePlanHandler).Methods("POST")
+	r.HandleFunc("/plans/{planId}/shares/{userId}", handlers.UnsharePlanHandler).Methods("DELETE")
 	r.HandleFunc("/plans/{planId}/{branch}/reject_all", handlers.RejectAllChangesHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/{branch}/reject_file", handlers.RejectFileHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/{branch}/reject_files", handlers.RejectFilesHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/{branch}/diffs", handlers.GetPlanDiffsHandler).Methods("GET")
+	r.HandleFunc("/plans/{planId}/{branch}/provenance", handlers.GetPlanProvenanceHandler).Methods("GET")
 
 	r.HandleFunc("/plans/{planId}/{branch}/context", handlers.ListContextHandler).Methods("GET")
 	r.HandleFunc("/plans/{planId}/{branch}/context", handlers.LoadContextHandler).Methods("POST")
 	r.HandleFunc("/plans/{planId}/{branch}/context", handlers.UpdateContextHandler).Methods("PUT")
 	r.HandleFunc("/plans/{planId}/{branch}/context", handlers.DeleteContextHandler).Methods("DELETE")
+	r.HandleFunc("/plans/{planId}/{branch}/context_bundles/sync", handlers.SyncContextBundlesHandler).Methods("POST")
+	r.HandleFunc("/plans/{planId}/{branch}/knowledge", handlers.SavePlanKnowledgeHandler).Methods("POST")
 
 	r.HandleFunc("/plans/{planId}/{branch}/convo", handlers.ListConvoHandler).Methods("GET")
+	r.HandleFunc("/plans/{planId}/{branch}/convo", handlers.EditMessageHandler).Methods("PATCH")
+	r.HandleFunc("/plans/{planId}/{branch}/rerun", handlers.RerunPlanHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/{branch}/rewind", handlers.RewindPlanHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/{branch}/logs", handlers.ListLogsHandler).Methods("GET")
 
@@ -101,19 +180,30 @@ func routes() *mux.Router {
 
 	r.HandleFunc("/plans/{planId}/{branch}/settings", handlers.GetSettingsHandler).Methods("GET")
 	r.HandleFunc("/plans/{planId}/{branch}/settings", handlers.UpdateSettingsHandler).Methods("PUT")
+	r.HandleFunc("/plans/{planId}/{branch}/settings/validate", handlers.ValidateSettingsHandler).Methods("POST")
 
 	r.HandleFunc("/plans/{planId}/{branch}/status", handlers.GetPlanStatusHandler).Methods("GET")
+	r.HandleFunc("/plans/{planId}/{branch}/builds", handlers.ListBuildsHandler).Methods("GET")
+
+	r.HandleFunc("/bench_results", handlers.ListBenchResultsHandler).Methods("GET")
+
+	r.HandleFunc("/plans/{planId}/build_captures", handlers.ListBuildCapturesHandler).Methods("GET")
+	r.HandleFunc("/plans/{planId}/build_captures", handlers.DeleteBuildCapturesHandler).Methods("DELETE")
+	r.HandleFunc("/plans/{planId}/build_captures/{captureId}", handlers.GetBuildCaptureHandler).Methods("GET")
 
 	r.HandleFunc("/custom_models", handlers.ListCustomModelsHandler).Methods("GET")
 	r.HandleFunc("/custom_models", handlers.CreateCustomModelHandler).Methods("POST")
+	r.HandleFunc("/custom_models/probe", handlers.ProbeModelEndpointHandler).Methods("POST")
 	r.HandleFunc("/custom_models/{modelId}", handlers.DeleteAvailableModelHandler).Methods("DELETE")
 
 	r.HandleFunc("/model_sets", handlers.ListModelPacksHandler).Methods("GET")
 	r.HandleFunc("/model_sets", handlers.CreateModelPackHandler).Methods("POST")
+	r.HandleFunc("/model_sets/{setId}", handlers.UpdateModelPackHandler).Methods("PUT")
 	r.HandleFunc("/model_sets/{setId}", handlers.DeleteModelPackHandler).Methods("DELETE")
 
 	r.HandleFunc("/default_settings", handlers.GetDefaultSettingsHandler).Methods("GET")
 	r.HandleFunc("/default_settings", handlers.UpdateDefaultSettingsHandler).Methods("PUT")
+	r.HandleFunc("/default_settings/validate", handlers.ValidateDefaultSettingsHandler).Methods("POST")
 
 	return r
 
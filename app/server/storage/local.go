@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type localStore struct {
+	root string
+}
+
+func NewLocal(root string) Store {
+	return &localStore{root: root}
+}
+
+func (s *localStore) Name() string {
+	return BackendLocal
+}
+
+func (s *localStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+func (s *localStore) Put(key string, body []byte) error {
+	path := s.path(key)
+
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("error creating blob dir: %v", err)
+	}
+
+	// write to a temp file and rename so a partially written blob is never read
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating blob temp file: %v", err)
+	}
+	tmpPath := tmpFile.Name()
+
+	_, err = tmpFile.Write(body)
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error writing blob: %v", err)
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error renaming blob: %v", err)
+	}
+
+	return nil
+}
+
+func (s *localStore) Get(key string) ([]byte, error) {
+	bytes, err := os.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading blob: %v", err)
+	}
+	return bytes, nil
+}
+
+func (s *localStore) Touch(key string) (bool, error) {
+	now := time.Now()
+	err := os.Chtimes(s.path(key), now, now)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error touching blob: %v", err)
+	}
+	return true, nil
+}
+
+func (s *localStore) ModTime(key string) (time.Time, error) {
+	info, err := os.Stat(s.path(key))
+	if os.IsNotExist(err) {
+		return time.Time{}, ErrNotFound
+	} else if err != nil {
+		return time.Time{}, fmt.Errorf("error checking blob: %v", err)
+	}
+	return info.ModTime(), nil
+}
+
+func (s *localStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error deleting blob: %v", err)
+	}
+	return nil
+}
+
+func (s *localStore) List(prefix string, fn func(key string, modTime time.Time) error) error {
+	dir := s.path(prefix)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+
+		return fn(filepath.ToSlash(rel), info.ModTime())
+	})
+
+	if err != nil {
+		return fmt.Errorf("error listing blobs: %v", err)
+	}
+
+	return nil
+}
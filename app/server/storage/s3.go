@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+type S3Config struct {
+	Bucket string
+	// optional prefix for all keys, e.g. 'plandex/'
+	Prefix string
+	Region string
+	// optional endpoint for S3-compatible storage (MinIO, GCS, etc.)
+	Endpoint string
+	Backend  string
+}
+
+type s3Store struct {
+	client *s3.S3
+	config S3Config
+}
+
+// NewS3 creates a store backed by S3 or an S3-compatible service. Credentials come from the standard AWS environment variables or config files.
+func NewS3(config S3Config) (Store, error) {
+	awsConfig := aws.NewConfig()
+	if config.Region != "" {
+		awsConfig = awsConfig.WithRegion(config.Region)
+	} else if config.Endpoint != "" {
+		// S3-compatible services generally ignore the region, but the sdk requires one
+		awsConfig = awsConfig.WithRegion("auto")
+	}
+	if config.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(config.Endpoint).WithS3ForcePathStyle(true)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating AWS session: %v", err)
+	}
+
+	if config.Backend == "" {
+		config.Backend = BackendS3
+	}
+
+	return &s3Store{
+		client: s3.New(sess),
+		config: config,
+	}, nil
+}
+
+func (s *s3Store) Name() string {
+	return s.config.Backend
+}
+
+func (s *s3Store) key(key string) string {
+	return s.config.Prefix + key
+}
+
+func (s *s3Store) Put(key string, body []byte) error {
+	_, err := s.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(s.key(key)),
+		Body:   bytes.NewReader(body),
+	})
+	if err != nil {
+		return fmt.Errorf("error putting blob: %v", err)
+	}
+	return nil
+}
+
+func (s *s3Store) Get(key string) ([]byte, error) {
+	res, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if isS3NotFound(err) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting blob: %v", err)
+	}
+	defer res.Body.Close()
+
+	bytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading blob: %v", err)
+	}
+	return bytes, nil
+}
+
+func (s *s3Store) Touch(key string) (bool, error) {
+	_, err := s.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if isS3NotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error checking blob: %v", err)
+	}
+
+	// copying an object onto itself with replaced metadata updates its last modified time
+	_, err = s.client.CopyObject(&s3.CopyObjectInput{
+		Bucket:            aws.String(s.config.Bucket),
+		Key:               aws.String(s.key(key)),
+		CopySource:        aws.String(url.PathEscape(s.config.Bucket + "/" + s.key(key))),
+		MetadataDirective: aws.String(s3.MetadataDirectiveReplace),
+	})
+	if err != nil {
+		return false, fmt.Errorf("error touching blob: %v", err)
+	}
+
+	return true, nil
+}
+
+func (s *s3Store) ModTime(key string) (time.Time, error) {
+	res, err := s.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if isS3NotFound(err) {
+		return time.Time{}, ErrNotFound
+	} else if err != nil {
+		return time.Time{}, fmt.Errorf("error checking blob: %v", err)
+	}
+	return aws.TimeValue(res.LastModified), nil
+}
+
+func (s *s3Store) Delete(key string) error {
+	_, err := s.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if err != nil && !isS3NotFound(err) {
+		return fmt.Errorf("error deleting blob: %v", err)
+	}
+	return nil
+}
+
+func (s *s3Store) List(prefix string, fn func(key string, modTime time.Time) error) error {
+	var fnErr error
+
+	err := s.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(s.config.Bucket),
+		Prefix: aws.String(s.key(prefix)),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			key := strings.TrimPrefix(aws.StringValue(obj.Key), s.config.Prefix)
+			fnErr = fn(key, aws.TimeValue(obj.LastModified))
+			if fnErr != nil {
+				return false
+			}
+		}
+		return true
+	})
+
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("error listing blobs: %v", err)
+	}
+
+	return nil
+}
+
+func isS3NotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	if aerr, ok := err.(awserr.RequestFailure); ok && aerr.StatusCode() == 404 {
+		return true
+	}
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == s3.ErrCodeNoSuchKey || aerr.Code() == "NotFound"
+	}
+	return false
+}
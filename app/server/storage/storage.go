@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Store is a blob storage backend. Keys are slash-separated paths like 'orgs/{orgId}/context-blobs/ab/{sha}'.
+type Store interface {
+	Name() string
+	Put(key string, body []byte) error
+	Get(key string) ([]byte, error)
+	// Touch returns whether the key exists, bumping its mod time if it does (the GC grace period is based on mod time)
+	Touch(key string) (bool, error)
+	// ModTime returns when the key was last modified, or ErrNotFound
+	ModTime(key string) (time.Time, error)
+	Delete(key string) error
+	// List calls fn for every key under prefix along with when it was last modified
+	List(prefix string, fn func(key string, modTime time.Time) error) error
+}
+
+var ErrNotFound = errors.New("blob not found")
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+	BackendGCS   = "gcs"
+)
+
+// Blobs is the configured blob store
+var Blobs Store
+
+// Init sets up the blob store from PLANDEX_BLOB_STORAGE ('local' (default), 's3', or 'gcs'). Local storage is rooted at baseDir.
+func Init(baseDir string) error {
+	store, err := New(os.Getenv("PLANDEX_BLOB_STORAGE"), baseDir)
+	if err != nil {
+		return err
+	}
+	Blobs = store
+	return nil
+}
+
+func New(backend, baseDir string) (Store, error) {
+	switch backend {
+	case "", BackendLocal:
+		return NewLocal(baseDir), nil
+	case BackendS3, BackendGCS:
+		bucket := os.Getenv("PLANDEX_BLOB_BUCKET")
+		if bucket == "" {
+			return nil, fmt.Errorf("PLANDEX_BLOB_BUCKET must be set for %s blob storage", backend)
+		}
+
+		endpoint := os.Getenv("PLANDEX_BLOB_ENDPOINT")
+		if backend == BackendGCS && endpoint == "" {
+			// GCS is accessed through its S3-compatible XML API using HMAC keys
+			endpoint = "https://storage.googleapis.com"
+		}
+
+		return NewS3(S3Config{
+			Bucket:   bucket,
+			Prefix:   os.Getenv("PLANDEX_BLOB_PREFIX"),
+			Region:   os.Getenv("PLANDEX_BLOB_REGION"),
+			Endpoint: endpoint,
+			Backend:  backend,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob storage backend: %s", backend)
+	}
+}
+
+const healthCheckKey = "health-check"
+
+// Check verifies that the blob store can be reached, without writing anything
+func Check() error {
+	if Blobs == nil {
+		return fmt.Errorf("blob storage isn't initialized")
+	}
+
+	_, err := Blobs.Touch(healthCheckKey)
+	return err
+}
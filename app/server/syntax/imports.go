@@ -0,0 +1,506 @@
+package syntax
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	tree_sitter "github.com/smacker/go-tree-sitter"
+)
+
+// standard library packages that can be added when a go file uses them without importing them. Names shared by more than one package, like 'rand' and 'template', are left out since the right one can't be known.
+var goStdImports = map[string]string{
+	"atomic":    "sync/atomic",
+	"base64":    "encoding/base64",
+	"big":       "math/big",
+	"binary":    "encoding/binary",
+	"bits":      "math/bits",
+	"bufio":     "bufio",
+	"bytes":     "bytes",
+	"cmp":       "cmp",
+	"context":   "context",
+	"csv":       "encoding/csv",
+	"errors":    "errors",
+	"exec":      "os/exec",
+	"filepath":  "path/filepath",
+	"flag":      "flag",
+	"fmt":       "fmt",
+	"fs":        "io/fs",
+	"gzip":      "compress/gzip",
+	"heap":      "container/heap",
+	"hex":       "encoding/hex",
+	"hmac":      "crypto/hmac",
+	"http":      "net/http",
+	"httptest":  "net/http/httptest",
+	"io":        "io",
+	"ioutil":    "io/ioutil",
+	"json":      "encoding/json",
+	"log":       "log",
+	"maps":      "maps",
+	"math":      "math",
+	"md5":       "crypto/md5",
+	"os":        "os",
+	"reflect":   "reflect",
+	"regexp":    "regexp",
+	"runtime":   "runtime",
+	"sha1":      "crypto/sha1",
+	"sha256":    "crypto/sha256",
+	"signal":    "os/signal",
+	"slices":    "slices",
+	"sort":      "sort",
+	"strconv":   "strconv",
+	"strings":   "strings",
+	"sync":      "sync",
+	"syscall":   "syscall",
+	"tabwriter": "text/tabwriter",
+	"testing":   "testing",
+	"time":      "time",
+	"tls":       "crypto/tls",
+	"unicode":   "unicode",
+	"unsafe":    "unsafe",
+	"url":       "net/url",
+	"utf8":      "unicode/utf8",
+	"xml":       "encoding/xml",
+}
+
+var goMajorVersionRegex = regexp.MustCompile(`^v[0-9]+$`)
+var goVersionSuffixRegex = regexp.MustCompile(`\.v[0-9]+$`)
+
+// FixImports removes unused imports from a go, typescript, or javascript file, and adds missing go imports from the standard library, without reformatting the rest of the file. pkgDecls are the names declared at the top level of the other files in a go file's package, which aren't missing imports. It returns the updated file and a description of each fix. Files that don't parse are returned unchanged.
+func FixImports(ctx context.Context, path, file string, pkgDecls map[string]bool) (string, []string, error) {
+	switch filepath.Ext(path) {
+	case ".go":
+		return fixGoImports(path, file, pkgDecls)
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs":
+		return fixJsImports(ctx, path, file)
+	}
+	return file, nil, nil
+}
+
+// GoFileDecls returns a go file's package name and the names it declares at the top level
+func GoFileDecls(file string) (string, []string) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", file, 0)
+	if err != nil {
+		return "", nil
+	}
+
+	var names []string
+	for name := range f.Scope.Objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return f.Name.Name, names
+}
+
+// goImportName returns the package name an import path is used by, when it can be known from the path: its last element, skipping a major version element and dropping a gopkg.in-style version suffix. It's empty for paths whose package name can't be known, like those with a 'go-' prefix.
+func goImportName(path string) string {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+	if goMajorVersionRegex.MatchString(name) && len(elems) > 1 {
+		name = elems[len(elems)-2]
+	}
+	name = goVersionSuffixRegex.ReplaceAllString(name, "")
+	if !token.IsIdentifier(name) {
+		return ""
+	}
+	return name
+}
+
+func isGoStdImport(path string) bool {
+	return !strings.Contains(strings.Split(path, "/")[0], ".")
+}
+
+func fixGoImports(path, file string, pkgDecls map[string]bool) (string, []string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, file, parser.ParseComments)
+	if err != nil {
+		return file, nil, nil
+	}
+
+	// selectors on names that aren't declared in the file are package references
+	used := map[string]bool{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	type importInfo struct {
+		spec *ast.ImportSpec
+		decl *ast.GenDecl
+		path string
+		name string
+	}
+
+	var imports []importInfo
+	imported := map[string]bool{}
+	var unknownElems []string
+
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			importSpec := spec.(*ast.ImportSpec)
+			importPath, err := strconv.Unquote(importSpec.Path.Value)
+			if err != nil {
+				return file, nil, nil
+			}
+
+			name := goImportName(importPath)
+			if importSpec.Name != nil {
+				name = importSpec.Name.Name
+			}
+			if name == "" {
+				elems := strings.Split(importPath, "/")
+				unknownElems = append(unknownElems, elems[len(elems)-1])
+			} else {
+				imported[name] = true
+			}
+
+			imports = append(imports, importInfo{spec: importSpec, decl: genDecl, path: importPath, name: name})
+		}
+	}
+
+	var missing []string
+	unaccounted := false
+	for name := range used {
+		if imported[name] || pkgDecls[name] || f.Scope.Lookup(name) != nil {
+			continue
+		}
+
+		// an import whose name can't be known from its path might provide it
+		providedByUnknown := false
+		for _, elem := range unknownElems {
+			if strings.Contains(elem, name) {
+				providedByUnknown = true
+				break
+			}
+		}
+
+		if importPath, ok := goStdImports[name]; ok && !providedByUnknown {
+			missing = append(missing, importPath)
+		} else {
+			unaccounted = true
+		}
+	}
+	sort.Strings(missing)
+
+	var unused []importInfo
+	for _, info := range imports {
+		if info.name == "" || info.name == "_" || info.name == "." || info.path == "C" || used[info.name] {
+			continue
+		}
+		// when a package reference can't be matched to an import, the import that provides it could be one whose name was guessed from its path, so only standard library and named imports are removed
+		if unaccounted && !isGoStdImport(info.path) && info.spec.Name == nil {
+			continue
+		}
+		unused = append(unused, info)
+	}
+
+	if len(missing) == 0 && len(unused) == 0 {
+		return file, nil, nil
+	}
+
+	lines := strings.Split(file, "\n")
+	lineOf := func(pos token.Pos) int {
+		return fset.Position(pos).Line - 1
+	}
+
+	specsByLine := map[int]int{}
+	for _, info := range imports {
+		for i := lineOf(info.spec.Pos()); i <= lineOf(info.spec.End()); i++ {
+			specsByLine[i]++
+		}
+	}
+
+	removeLines := map[int]bool{}
+	insertBefore := map[int][]string{}
+	var fixes []string
+
+	unusedByDecl := map[*ast.GenDecl]int{}
+	for _, info := range unused {
+		unusedByDecl[info.decl]++
+	}
+
+	for _, info := range unused {
+		start, end := lineOf(info.spec.Pos()), lineOf(info.spec.End())
+
+		if info.decl.Lparen.IsValid() && unusedByDecl[info.decl] < len(info.decl.Specs) {
+			shared := false
+			for i := start; i <= end; i++ {
+				if specsByLine[i] > 1 {
+					shared = true
+				}
+			}
+			if shared {
+				continue
+			}
+		} else {
+			start, end = lineOf(info.decl.Pos()), lineOf(info.decl.End())
+			// don't leave two blank lines where the declaration was
+			if start > 0 && end+1 < len(lines) && strings.TrimSpace(lines[start-1]) == "" && strings.TrimSpace(lines[end+1]) == "" {
+				end++
+			}
+		}
+
+		for i := start; i <= end; i++ {
+			removeLines[i] = true
+		}
+		fixes = append(fixes, fmt.Sprintf("Removed unused import %q", info.path))
+	}
+
+	if len(missing) > 0 {
+		var block *ast.GenDecl
+		var lastImport *ast.GenDecl
+		for _, info := range imports {
+			if block == nil && info.decl.Lparen.IsValid() && !removeLines[lineOf(info.decl.Lparen)] {
+				block = info.decl
+			}
+			lastImport = info.decl
+		}
+
+		switch {
+		case block != nil:
+			// std imports go in sorted order in the block's first group, or in a new group before it if it isn't std imports
+			var group []*ast.ImportSpec
+			for i, spec := range block.Specs {
+				if i > 0 && lineOf(spec.Pos()) > lineOf(block.Specs[i-1].End())+1 {
+					break
+				}
+				group = append(group, spec.(*ast.ImportSpec))
+			}
+
+			firstPath := ""
+			if len(group) > 0 {
+				firstPath, _ = strconv.Unquote(group[0].Path.Value)
+			}
+
+			if firstPath == "" || !isGoStdImport(firstPath) {
+				var newLines []string
+				for _, importPath := range missing {
+					newLines = append(newLines, "\t"+strconv.Quote(importPath))
+				}
+				if firstPath != "" {
+					newLines = append(newLines, "")
+				}
+				insertBefore[lineOf(block.Lparen)+1] = append(insertBefore[lineOf(block.Lparen)+1], newLines...)
+				break
+			}
+
+			for _, importPath := range missing {
+				at := lineOf(group[len(group)-1].End()) + 1
+				for _, spec := range group {
+					specPath, _ := strconv.Unquote(spec.Path.Value)
+					if specPath > importPath {
+						at = lineOf(spec.Pos())
+						break
+					}
+				}
+				insertBefore[at] = append(insertBefore[at], "\t"+strconv.Quote(importPath))
+			}
+
+		case lastImport != nil:
+			at := lineOf(lastImport.End()) + 1
+			for _, importPath := range missing {
+				insertBefore[at] = append(insertBefore[at], "import "+strconv.Quote(importPath))
+			}
+
+		default:
+			at := lineOf(f.Name.End()) + 1
+			newLines := []string{""}
+			if len(missing) == 1 {
+				newLines = append(newLines, "import "+strconv.Quote(missing[0]))
+			} else {
+				newLines = append(newLines, "import (")
+				for _, importPath := range missing {
+					newLines = append(newLines, "\t"+strconv.Quote(importPath))
+				}
+				newLines = append(newLines, ")")
+			}
+			insertBefore[at] = newLines
+		}
+
+		for _, importPath := range missing {
+			fixes = append(fixes, fmt.Sprintf("Added missing import %q", importPath))
+		}
+	}
+
+	var res []string
+	for i, line := range lines {
+		res = append(res, insertBefore[i]...)
+		if !removeLines[i] {
+			res = append(res, line)
+		}
+	}
+	res = append(res, insertBefore[len(lines)]...)
+
+	return strings.Join(res, "\n"), fixes, nil
+}
+
+func fixJsImports(ctx context.Context, path, file string) (string, []string, error) {
+	parser, _, _, _ := getParserForExt(filepath.Ext(path))
+	if parser == nil {
+		return file, nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, parserTimeout)
+	defer cancel()
+
+	src := []byte(file)
+
+	tree, err := parser.ParseCtx(ctx, nil, src)
+	if err != nil || tree == nil {
+		return "", nil, fmt.Errorf("failed to parse the content: %v", err)
+	}
+	defer tree.Close()
+
+	root := tree.RootNode()
+	if root.HasError() {
+		return file, nil, nil
+	}
+
+	var statements []*tree_sitter.Node
+	used := map[string]bool{}
+	hasJsx := false
+
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		child := root.NamedChild(i)
+		if child.Type() == "import_statement" {
+			statements = append(statements, child)
+			continue
+		}
+		visitNodes(child, func(n *tree_sitter.Node) {
+			if strings.HasPrefix(n.Type(), "jsx_") {
+				hasJsx = true
+			}
+			if n.ChildCount() == 0 && isIdentifierNode(n.Type()) {
+				used[n.Content(src)] = true
+			}
+		})
+	}
+
+	// the classic jsx transform uses React without referencing it
+	if hasJsx {
+		used["React"] = true
+	}
+
+	type edit struct {
+		start, end uint32
+		content    string
+	}
+	var edits []edit
+	var fixes []string
+
+	for _, statement := range statements {
+		var clause *tree_sitter.Node
+		for i := 0; i < int(statement.NamedChildCount()); i++ {
+			if statement.NamedChild(i).Type() == "import_clause" {
+				clause = statement.NamedChild(i)
+			}
+		}
+		// side effect imports have no bindings
+		if clause == nil {
+			continue
+		}
+
+		var kept []string
+		var keptNamed []string
+		var removed []string
+		hasNamed := false
+
+		for i := 0; i < int(clause.NamedChildCount()); i++ {
+			binding := clause.NamedChild(i)
+			switch binding.Type() {
+			case "identifier":
+				if used[binding.Content(src)] {
+					kept = append(kept, binding.Content(src))
+				} else {
+					removed = append(removed, binding.Content(src))
+				}
+			case "namespace_import":
+				name := ""
+				for j := 0; j < int(binding.NamedChildCount()); j++ {
+					name = binding.NamedChild(j).Content(src)
+				}
+				if name == "" || used[name] {
+					kept = append(kept, binding.Content(src))
+				} else {
+					removed = append(removed, name)
+				}
+			case "named_imports":
+				hasNamed = true
+				for j := 0; j < int(binding.NamedChildCount()); j++ {
+					specifier := binding.NamedChild(j)
+					if specifier.Type() != "import_specifier" {
+						continue
+					}
+					local := specifier.ChildByFieldName("alias")
+					if local == nil {
+						local = specifier.ChildByFieldName("name")
+					}
+					if local == nil || used[local.Content(src)] {
+						keptNamed = append(keptNamed, specifier.Content(src))
+					} else {
+						removed = append(removed, local.Content(src))
+					}
+				}
+			default:
+				kept = append(kept, binding.Content(src))
+			}
+		}
+
+		if len(removed) == 0 {
+			continue
+		}
+
+		source := ""
+		if sourceNode := statement.ChildByFieldName("source"); sourceNode != nil {
+			source = sourceNode.Content(src)
+		}
+		fixes = append(fixes, fmt.Sprintf("Removed unused import %s from %s", strings.Join(removed, ", "), source))
+
+		if hasNamed && len(keptNamed) > 0 {
+			kept = append(kept, "{ "+strings.Join(keptNamed, ", ")+" }")
+		}
+
+		if len(kept) == 0 {
+			// remove the whole statement, along with its line break
+			end := statement.EndByte()
+			if int(end) < len(src) && src[end] == '\n' {
+				end++
+			}
+			edits = append(edits, edit{start: statement.StartByte(), end: end})
+			continue
+		}
+
+		edits = append(edits, edit{start: clause.StartByte(), end: clause.EndByte(), content: strings.Join(kept, ", ")})
+	}
+
+	if len(edits) == 0 {
+		return file, nil, nil
+	}
+
+	var b strings.Builder
+	prev := uint32(0)
+	for _, e := range edits {
+		b.Write(src[prev:e.start])
+		b.WriteString(e.content)
+		prev = e.end
+	}
+	b.Write(src[prev:])
+
+	return b.String(), fixes, nil
+}
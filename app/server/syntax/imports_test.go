@@ -0,0 +1,110 @@
+package syntax
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFixImports(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		file     string
+		pkgDecls map[string]bool
+		want     string
+		numFixes int
+	}{
+		{
+			name:     "go unused and missing std imports in a block",
+			path:     "lib/user.go",
+			file:     "package lib\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\t\"strings\"\n\n\t\"github.com/google/uuid\"\n)\n\nfunc Id(s string) string {\n\tlog.Println(s)\n\treturn strings.TrimSpace(s) + uuid.New().String() + strconv.Itoa(1)\n}\n",
+			want:     "package lib\n\nimport (\n\t\"log\"\n\t\"strconv\"\n\t\"strings\"\n\n\t\"github.com/google/uuid\"\n)\n\nfunc Id(s string) string {\n\tlog.Println(s)\n\treturn strings.TrimSpace(s) + uuid.New().String() + strconv.Itoa(1)\n}\n",
+			numFixes: 4,
+		},
+		{
+			name:     "go imports added to a file without imports",
+			path:     "lib/user.go",
+			file:     "package lib\n\nfunc Print(s string) {\n\tfmt.Println(s)\n}\n",
+			want:     "package lib\n\nimport \"fmt\"\n\nfunc Print(s string) {\n\tfmt.Println(s)\n}\n",
+			numFixes: 1,
+		},
+		{
+			name:     "go unused single import removed",
+			path:     "lib/user.go",
+			file:     "package lib\n\nimport \"fmt\"\n\nfunc Print(s string) {}\n",
+			want:     "package lib\n\nfunc Print(s string) {}\n",
+			numFixes: 1,
+		},
+		{
+			name: "go package names declared in other files, locals, and blank imports",
+			path: "lib/user.go",
+			file: "package lib\n\nimport (\n\t_ \"embed\"\n\t\"github.com/sashabaranov/go-openai\"\n)\n\nfunc Load(json Decoder) {\n\tjson.Decode()\n\tlog.Info()\n\topenai.New()\n}\n",
+			pkgDecls: map[string]bool{
+				"log": true,
+			},
+			want: "package lib\n\nimport (\n\t_ \"embed\"\n\t\"github.com/sashabaranov/go-openai\"\n)\n\nfunc Load(json Decoder) {\n\tjson.Decode()\n\tlog.Info()\n\topenai.New()\n}\n",
+		},
+		{
+			name: "go imports whose name can't be matched are kept when a reference isn't accounted for",
+			path: "lib/user.go",
+			file: "package lib\n\nimport \"github.com/acme/client\"\n\nfunc Load() {\n\tapi.Get()\n}\n",
+			want: "package lib\n\nimport \"github.com/acme/client\"\n\nfunc Load() {\n\tapi.Get()\n}\n",
+		},
+		{
+			name: "go files that don't parse are unchanged",
+			path: "lib/user.go",
+			file: "package lib\n\nimport \"fmt\"\n\nfunc Load( {\n",
+			want: "package lib\n\nimport \"fmt\"\n\nfunc Load( {\n",
+		},
+		{
+			name:     "typescript unused specifiers, defaults, and namespaces",
+			path:     "src/user.tsx",
+			file:     "import React, { useState, useEffect as onMount, type FC } from \"react\";\nimport * as path from 'path';\nimport { unused } from './unused';\nimport './styles.css';\n\nexport const User: FC = () => {\n  const [x] = useState(0);\n  return <div>{x}</div>;\n};\n",
+			want:     "import React, { useState, type FC } from \"react\";\nimport './styles.css';\n\nexport const User: FC = () => {\n  const [x] = useState(0);\n  return <div>{x}</div>;\n};\n",
+			numFixes: 3,
+		},
+		{
+			name: "typescript imports used in types and re-exports are kept",
+			path: "src/user.ts",
+			file: "import { User } from './types';\nimport { load } from './load';\n\nexport { load };\nexport function get(): User {\n  return {} as User;\n}\n",
+			want: "import { User } from './types';\nimport { load } from './load';\n\nexport { load };\nexport function get(): User {\n  return {} as User;\n}\n",
+		},
+		{
+			name: "other languages are unchanged",
+			path: "app/models.py",
+			file: "import os\n",
+			want: "import os\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, fixes, err := FixImports(context.Background(), test.path, test.file, test.pkgDecls)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+			if len(fixes) != test.numFixes {
+				t.Errorf("got %d fixes, want %d: %v", len(fixes), test.numFixes, fixes)
+			}
+		})
+	}
+}
+
+func TestGoFileDecls(t *testing.T) {
+	pkg, names := GoFileDecls("package lib\n\nvar log = newLogger()\n\ntype Client struct{}\n\nfunc newLogger() {}\n\nfunc (c *Client) Get() {}\n")
+	if pkg != "lib" {
+		t.Errorf("got package %s", pkg)
+	}
+	want := []string{"Client", "log", "newLogger"}
+	if len(names) != len(want) {
+		t.Fatalf("got %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("got %v, want %v", names, want)
+		}
+	}
+}
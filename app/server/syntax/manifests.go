@@ -0,0 +1,227 @@
+package syntax
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// manifests are checked by parsing them the way their package manager would, which catches more than a generic parser, like a go.mod require without a version or a package.json dependency that isn't a string
+var manifestValidators = map[string]func(file string) []string{
+	"go.mod":       validateGoMod,
+	"package.json": validatePackageJson,
+}
+
+func getManifestValidator(path string) (func(file string) []string, string) {
+	base := filepath.Base(path)
+	if validate, ok := manifestValidators[base]; ok {
+		return validate, base
+	}
+	if strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt") {
+		return validateRequirements, "requirements"
+	}
+	return nil, ""
+}
+
+func validateManifest(path, file string) *ValidationRes {
+	validate, lang := getManifestValidator(path)
+	if validate == nil {
+		return nil
+	}
+
+	errs := validate(file)
+
+	return &ValidationRes{
+		Ext:       filepath.Ext(path),
+		Lang:      lang,
+		HasParser: true,
+		Valid:     len(errs) == 0,
+		Errors:    errs,
+	}
+}
+
+var goModVerbs = map[string]bool{
+	"module":    true,
+	"go":        true,
+	"toolchain": true,
+	"godebug":   true,
+	"require":   true,
+	"replace":   true,
+	"exclude":   true,
+	"retract":   true,
+	"tool":      true,
+	"ignore":    true,
+}
+
+var goModVersionRegex = regexp.MustCompile(`^v\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+func validateGoMod(file string) []string {
+	var errs []string
+	errorf := func(line int, format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf("Line %d: %s", line, fmt.Sprintf(format, args...)))
+	}
+
+	hasModule := false
+	block := ""
+	blockStart := 0
+
+	for i, line := range strings.Split(file, "\n") {
+		num := i + 1
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if block != "" {
+			if len(fields) == 1 && fields[0] == ")" {
+				block = ""
+				continue
+			}
+			validateGoModDirective(block, fields, num, errorf)
+			continue
+		}
+
+		verb := fields[0]
+		if !goModVerbs[verb] {
+			errorf(num, "unknown directive '%s'", verb)
+			continue
+		}
+
+		args := fields[1:]
+		if len(args) == 1 && args[0] == "(" {
+			if verb == "module" || verb == "go" || verb == "toolchain" {
+				errorf(num, "'%s' can't be a block", verb)
+			}
+			block = verb
+			blockStart = num
+			continue
+		}
+
+		if verb == "module" {
+			if hasModule {
+				errorf(num, "repeated module directive")
+			}
+			hasModule = true
+		}
+
+		validateGoModDirective(verb, args, num, errorf)
+	}
+
+	if block != "" {
+		errorf(blockStart, "'%s' block isn't closed", block)
+	}
+
+	if !hasModule {
+		errs = append(errs, "Missing module directive")
+	}
+
+	return errs
+}
+
+func validateGoModDirective(verb string, args []string, num int, errorf func(line int, format string, args ...interface{})) {
+	switch verb {
+	case "module", "go", "toolchain":
+		if len(args) != 1 {
+			errorf(num, "'%s' takes one argument", verb)
+		}
+
+	case "require", "exclude":
+		if len(args) != 2 {
+			errorf(num, "'%s' takes a module path and version", verb)
+			return
+		}
+		if !goModVersionRegex.MatchString(args[1]) {
+			errorf(num, "invalid version '%s' for %s", args[1], args[0])
+		}
+
+	case "replace":
+		arrow := -1
+		for i, arg := range args {
+			if arg == "=>" {
+				arrow = i
+				break
+			}
+		}
+		if arrow < 1 || arrow > 2 || len(args)-arrow-1 < 1 || len(args)-arrow-1 > 2 {
+			errorf(num, "'replace' must be 'module [version] => replacement [version]'")
+			return
+		}
+		if arrow == 2 && !goModVersionRegex.MatchString(args[1]) {
+			errorf(num, "invalid version '%s' for %s", args[1], args[0])
+		}
+		if len(args)-arrow-1 == 2 && !goModVersionRegex.MatchString(args[len(args)-1]) {
+			errorf(num, "invalid version '%s' for %s", args[len(args)-1], args[arrow+1])
+		}
+
+	case "godebug", "retract", "tool", "ignore":
+		if len(args) == 0 {
+			errorf(num, "'%s' needs an argument", verb)
+		}
+	}
+}
+
+var packageJsonDependencyFields = []string{
+	"dependencies",
+	"devDependencies",
+	"peerDependencies",
+	"optionalDependencies",
+}
+
+func validatePackageJson(file string) []string {
+	var pkg map[string]json.RawMessage
+	err := json.Unmarshal([]byte(file), &pkg)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line := strings.Count(file[:syntaxErr.Offset], "\n") + 1
+			return []string{fmt.Sprintf("Line %d: %v", line, err)}
+		}
+		return []string{fmt.Sprintf("Invalid package.json: %v", err)}
+	}
+
+	var errs []string
+
+	for _, field := range packageJsonDependencyFields {
+		raw, ok := pkg[field]
+		if !ok {
+			continue
+		}
+		var deps map[string]string
+		if err := json.Unmarshal(raw, &deps); err != nil {
+			errs = append(errs, fmt.Sprintf("'%s' must map package names to version strings", field))
+		}
+	}
+
+	return errs
+}
+
+// a requirement is a name with optional extras and version specifiers, a url requirement, or an editable or local path
+var requirementRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*(\[[A-Za-z0-9._,\s-]*\])?\s*(((===|==|!=|~=|<=|>=|<|>)\s*[^\s,;]+\s*,?\s*)*|@\s*\S+)\s*(;.*)?$`)
+
+func validateRequirements(file string) []string {
+	var errs []string
+
+	for i, line := range strings.Split(file, "\n") {
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+
+		// options like '-r other.txt' or '--index-url', and local paths or urls
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") || strings.HasPrefix(line, ".") || strings.HasPrefix(line, "/") || strings.Contains(line, "://") {
+			continue
+		}
+
+		if !requirementRegex.MatchString(line) {
+			errs = append(errs, fmt.Sprintf("Line %d: invalid requirement '%s'", i+1, line))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,96 @@
+package syntax
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidateManifests(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		file  string
+		valid bool
+		err   string
+	}{
+		{
+			name:  "valid go.mod",
+			path:  "go.mod",
+			file:  "module example.com/app\n\ngo 1.21\n\nrequire github.com/pkg/errors v0.9.1 // indirect\n\nrequire (\n\tgolang.org/x/net v0.17.0\n\tgithub.com/google/uuid v1.6.0\n)\n\nreplace example.com/lib => ../lib\n",
+			valid: true,
+		},
+		{
+			name: "go.mod require without a version",
+			path: "app/go.mod",
+			file: "module example.com/app\n\nrequire (\n\tgolang.org/x/net\n)\n",
+			err:  "Line 4: 'require' takes a module path and version",
+		},
+		{
+			name: "go.mod with an invalid version",
+			path: "go.mod",
+			file: "module example.com/app\n\nrequire golang.org/x/net latest\n",
+			err:  "Line 3: invalid version 'latest' for golang.org/x/net",
+		},
+		{
+			name: "go.mod with an unclosed block",
+			path: "go.mod",
+			file: "module example.com/app\n\nrequire (\n\tgolang.org/x/net v0.17.0\n",
+			err:  "Line 3: 'require' block isn't closed",
+		},
+		{
+			name:  "valid package.json",
+			path:  "web/package.json",
+			file:  "{\n  \"name\": \"app\",\n  \"dependencies\": {\n    \"lodash\": \"^4.17.21\"\n  }\n}\n",
+			valid: true,
+		},
+		{
+			name: "package.json with a trailing comma",
+			path: "package.json",
+			file: "{\n  \"dependencies\": {\n    \"lodash\": \"^4.17.21\",\n  }\n}\n",
+			err:  "Line 4:",
+		},
+		{
+			name: "package.json with a non-string version",
+			path: "package.json",
+			file: "{\"devDependencies\": {\"jest\": 29}}",
+			err:  "'devDependencies' must map package names to version strings",
+		},
+		{
+			name:  "valid requirements",
+			path:  "requirements-dev.txt",
+			file:  "# tools\n-r requirements.txt\nrequests[security]>=2.20,<3 ; python_version >= '3.8'\nflask==2.0.1  # web\npkg @ https://example.com/pkg.zip\n-e ./local\n",
+			valid: true,
+		},
+		{
+			name: "invalid requirement",
+			path: "requirements.txt",
+			file: "flask==2.0.1\nrequests = 2.20\n",
+			err:  "Line 2: invalid requirement 'requests = 2.20'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Validate(context.Background(), tt.path, tt.file)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !res.HasParser {
+				t.Fatalf("expected a parser for %s", tt.path)
+			}
+			if res.Valid != tt.valid {
+				t.Fatalf("got valid %v, want %v: %v", res.Valid, tt.valid, res.Errors)
+			}
+			if tt.err == "" {
+				return
+			}
+			for _, e := range res.Errors {
+				if strings.Contains(e, tt.err) {
+					return
+				}
+			}
+			t.Errorf("expected an error containing %q, got %v", tt.err, res.Errors)
+		})
+	}
+}
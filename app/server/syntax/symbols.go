@@ -0,0 +1,191 @@
+package syntax
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/smacker/go-tree-sitter"
+)
+
+type SymbolRef struct {
+	Line  int
+	Start int
+	End   int
+}
+
+// node types that hold a name, across languages: 'identifier' and its variants like 'type_identifier', 'field_identifier', and 'property_identifier', plus ruby's 'constant' and php's 'name'
+func isIdentifierNode(nodeType string) bool {
+	return strings.Contains(nodeType, "identifier") || nodeType == "constant" || nodeType == "name"
+}
+
+// FindSymbol returns the references to a symbol in a file, from its syntax tree, so the same word in strings and comments isn't included. hasParser is false when there's no parser for the file's language.
+func FindSymbol(ctx context.Context, path, file, name string) (refs []SymbolRef, hasParser bool, err error) {
+	parser, _, fallbackParser, _ := getParserForExt(filepath.Ext(path))
+	if parser == nil {
+		return nil, false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, parserTimeout)
+	defer cancel()
+
+	src := []byte(file)
+
+	tree, err := parser.ParseCtx(ctx, nil, src)
+	if err != nil || tree == nil {
+		return nil, true, fmt.Errorf("failed to parse the content: %v", err)
+	}
+	defer tree.Close()
+
+	root := tree.RootNode()
+
+	if root.HasError() && fallbackParser != nil {
+		fallbackTree, err := fallbackParser.ParseCtx(ctx, nil, src)
+		if err == nil && fallbackTree != nil {
+			defer fallbackTree.Close()
+			if !fallbackTree.RootNode().HasError() {
+				root = fallbackTree.RootNode()
+			}
+		}
+	}
+
+	visitNodes(root, func(n *tree_sitter.Node) {
+		if n.ChildCount() > 0 || !isIdentifierNode(n.Type()) || n.Content(src) != name {
+			return
+		}
+		refs = append(refs, SymbolRef{
+			Line:  int(n.StartPoint().Row) + 1,
+			Start: int(n.StartByte()),
+			End:   int(n.EndByte()),
+		})
+	})
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Start < refs[j].Start
+	})
+
+	return refs, true, nil
+}
+
+// RenameSymbol renames every reference to a symbol in a file. It returns the updated file and the references that were renamed, which are empty when the file doesn't reference the symbol or there's no parser for its language.
+func RenameSymbol(ctx context.Context, path, file, from, to string) (string, []SymbolRef, error) {
+	refs, _, err := FindSymbol(ctx, path, file, from)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(refs) == 0 {
+		return file, nil, nil
+	}
+
+	var b strings.Builder
+	prev := 0
+	for _, ref := range refs {
+		b.WriteString(file[prev:ref.Start])
+		b.WriteString(to)
+		prev = ref.End
+	}
+	b.WriteString(file[prev:])
+
+	return b.String(), refs, nil
+}
+
+// node types that wrap a single declaration, which is removed along with its wrapper
+var declarationWrappers = map[string]bool{
+	"export_statement":     true,
+	"decorated_definition": true,
+	"ambient_declaration":  true,
+	"type_declaration":     true,
+	"lexical_declaration":  true,
+	"variable_declaration": true,
+	"var_declaration":      true,
+	"const_declaration":    true,
+}
+
+func isDeclarationNode(nodeType string) bool {
+	// parameters and struct fields have names, but aren't removed on their own
+	if strings.Contains(nodeType, "parameter") || strings.Contains(nodeType, "field") {
+		return false
+	}
+	for _, suffix := range []string{"_declaration", "_definition", "_spec", "_declarator", "_item"} {
+		if strings.HasSuffix(nodeType, suffix) {
+			return true
+		}
+	}
+	return nodeType == "method" || nodeType == "class" || nodeType == "module"
+}
+
+// FindDeclaration returns the lines of a symbol's declaration in a file, from its syntax tree, including the comments directly above it and any wrapper like an export statement. start is 0 when the file doesn't declare the symbol, declares it more than once, or the declaration shares its lines with other code, since then it can't be removed by lines.
+func FindDeclaration(ctx context.Context, path, file, name string) (start, end int, err error) {
+	parser, _, _, _ := getParserForExt(filepath.Ext(path))
+	if parser == nil {
+		return 0, 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, parserTimeout)
+	defer cancel()
+
+	src := []byte(file)
+
+	tree, err := parser.ParseCtx(ctx, nil, src)
+	if err != nil || tree == nil {
+		return 0, 0, fmt.Errorf("failed to parse the content: %v", err)
+	}
+	defer tree.Close()
+
+	root := tree.RootNode()
+	if root.HasError() {
+		return 0, 0, nil
+	}
+
+	var matches []*tree_sitter.Node
+	visitNodes(root, func(n *tree_sitter.Node) {
+		if !isDeclarationNode(n.Type()) {
+			return
+		}
+		nameNode := n.ChildByFieldName("name")
+		if nameNode != nil && nameNode.Content(src) == name {
+			matches = append(matches, n)
+		}
+	})
+
+	if len(matches) != 1 {
+		return 0, 0, nil
+	}
+
+	node := matches[0]
+	for {
+		parent := node.Parent()
+		if parent == nil || !declarationWrappers[parent.Type()] {
+			break
+		}
+		numDecls := 0
+		for i := 0; i < int(parent.NamedChildCount()); i++ {
+			if isDeclarationNode(parent.NamedChild(i).Type()) {
+				numDecls++
+			}
+		}
+		if numDecls > 1 {
+			break
+		}
+		node = parent
+	}
+
+	lines := strings.Split(file, "\n")
+	startRow, endRow := int(node.StartPoint().Row), int(node.EndPoint().Row)
+
+	if strings.TrimSpace(lines[startRow][:node.StartPoint().Column]) != "" ||
+		strings.TrimSpace(lines[endRow][node.EndPoint().Column:]) != "" {
+		return 0, 0, nil
+	}
+
+	for prev := node.PrevNamedSibling(); prev != nil && strings.Contains(prev.Type(), "comment") && int(prev.EndPoint().Row) == startRow-1; prev = prev.PrevNamedSibling() {
+		if strings.TrimSpace(lines[prev.StartPoint().Row][:prev.StartPoint().Column]) != "" {
+			break
+		}
+		startRow = int(prev.StartPoint().Row)
+	}
+
+	return startRow + 1, endRow + 1, nil
+}
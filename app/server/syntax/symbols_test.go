@@ -0,0 +1,177 @@
+package syntax
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRenameSymbol(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		file    string
+		from    string
+		to      string
+		want    string
+		numRefs int
+	}{
+		{
+			name:    "go function, method, and type references",
+			path:    "term/render.go",
+			file:    "package term\n\n// Render formats s\nfunc Render(s string) string {\n\treturn s\n}\n\nfunc (p *Printer) Print(s string) {\n\tfmt.Println(p.Render(s), \"Render\")\n\tx := Render(s)\n}\n",
+			from:    "Render",
+			to:      "Format",
+			want:    "package term\n\n// Render formats s\nfunc Format(s string) string {\n\treturn s\n}\n\nfunc (p *Printer) Print(s string) {\n\tfmt.Println(p.Format(s), \"Render\")\n\tx := Format(s)\n}\n",
+			numRefs: 3,
+		},
+		{
+			name:    "typescript identifiers and properties, not strings or longer names",
+			path:    "src/user.ts",
+			file:    "export function loadUser(id: string) {\n  return api.loadUser(id, 'loadUser');\n}\nconst loadUsers = () => loadUser('1');\n",
+			from:    "loadUser",
+			to:      "fetchUser",
+			want:    "export function fetchUser(id: string) {\n  return api.fetchUser(id, 'loadUser');\n}\nconst loadUsers = () => fetchUser('1');\n",
+			numRefs: 3,
+		},
+		{
+			name:    "python",
+			path:    "app/models.py",
+			file:    "def get_user(id):\n    # get_user looks up a user\n    return db.get_user(id)\n",
+			from:    "get_user",
+			to:      "find_user",
+			want:    "def find_user(id):\n    # get_user looks up a user\n    return db.find_user(id)\n",
+			numRefs: 2,
+		},
+		{
+			name: "no references",
+			path: "term/render.go",
+			file: "package term\n\nfunc Print() {}\n",
+			from: "Render",
+			to:   "Format",
+			want: "package term\n\nfunc Print() {}\n",
+		},
+		{
+			name: "no parser",
+			path: "README.md",
+			file: "Call Render to format output.\n",
+			from: "Render",
+			to:   "Format",
+			want: "Call Render to format output.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, refs, err := RenameSymbol(context.Background(), tt.path, tt.file, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("RenameSymbol() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenameSymbol() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if len(refs) != tt.numRefs {
+				t.Errorf("RenameSymbol() renamed %d references, want %d", len(refs), tt.numRefs)
+			}
+		})
+	}
+}
+
+func TestFindSymbolLines(t *testing.T) {
+	refs, hasParser, err := FindSymbol(context.Background(), "main.go", "package main\n\nfunc run() {}\n\nfunc main() {\n\trun()\n}\n", "run")
+	if err != nil {
+		t.Fatalf("FindSymbol() error = %v", err)
+	}
+	if !hasParser {
+		t.Fatal("FindSymbol() hasParser = false, want true")
+	}
+
+	var lines []int
+	for _, ref := range refs {
+		lines = append(lines, ref.Line)
+	}
+	if len(lines) != 2 || lines[0] != 3 || lines[1] != 6 {
+		t.Errorf("FindSymbol() lines = %v, want [3 6]", lines)
+	}
+}
+
+func TestFindDeclaration(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		file  string
+		decl  string
+		start int
+		end   int
+	}{
+		{
+			name:  "go function with doc comment",
+			path:  "term/render.go",
+			file:  "package term\n\n// Render formats s\n// for output\nfunc Render(s string) string {\n\treturn s\n}\n\nfunc Print() {}\n",
+			decl:  "Render",
+			start: 3,
+			end:   7,
+		},
+		{
+			name:  "go type",
+			path:  "term/render.go",
+			file:  "package term\n\ntype Printer struct {\n\twidth int\n}\n",
+			decl:  "Printer",
+			start: 3,
+			end:   5,
+		},
+		{
+			name:  "typescript exported function",
+			path:  "src/user.ts",
+			file:  "import { api } from './api';\n\n/** Loads a user */\nexport function loadUser(id: string) {\n  return api.get(id);\n}\n",
+			decl:  "loadUser",
+			start: 3,
+			end:   6,
+		},
+		{
+			name:  "typescript exported arrow function",
+			path:  "src/user.ts",
+			file:  "export const loadUser = (id: string) => {\n  return id;\n};\n\nexport const other = 1;\n",
+			decl:  "loadUser",
+			start: 1,
+			end:   3,
+		},
+		{
+			name:  "python decorated function",
+			path:  "app/views.py",
+			file:  "import os\n\n@route('/')\ndef index():\n    return 'hi'\n",
+			decl:  "index",
+			start: 3,
+			end:   5,
+		},
+		{
+			name: "ambiguous method names",
+			path: "term/render.go",
+			file: "package term\n\nfunc (a *A) Print() {}\n\nfunc (b *B) Print() {}\n",
+			decl: "Print",
+		},
+		{
+			name: "parameters aren't declarations",
+			path: "term/render.go",
+			file: "package term\n\nfunc Print(width int) {}\n",
+			decl: "width",
+		},
+		{
+			name: "not declared",
+			path: "term/render.go",
+			file: "package term\n\nfunc Print() {}\n",
+			decl: "Render",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end, err := FindDeclaration(context.Background(), test.path, test.file, test.decl)
+			if err != nil {
+				t.Fatalf("FindDeclaration() error = %v", err)
+			}
+			if start != test.start || end != test.end {
+				t.Errorf("FindDeclaration() = %d-%d, want %d-%d", start, end, test.start, test.end)
+			}
+		})
+	}
+}
@@ -25,6 +25,10 @@ type ValidationRes = struct {
 func Validate(ctx context.Context, path, file string) (*ValidationRes, error) {
 	ext := filepath.Ext(path)
 
+	if res := validateManifest(path, file); res != nil {
+		return res, nil
+	}
+
 	parser, lang, fallbackParser, fallbackLang := getParserForExt(ext)
 
 	if parser == nil {
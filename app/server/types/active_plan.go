@@ -27,14 +27,29 @@ type ActiveBuild struct {
 	Idx                      int
 	WithLineNumsBuffer       string
 	WithLineNumsBufferTokens int
-	VerifyBuffer             string
-	VerifyBufferTokens       int
-	FixBuffer                string
-	FixBufferTokens          int
-	Success                  bool
-	Error                    error
-	IsVerification           bool
-	ToVerifyUpdatedState     string
+	// StreamStartedAt is when the first token of the current build response arrived, for estimating how long the rest will take
+	StreamStartedAt      time.Time
+	VerifyBuffer         string
+	VerifyBufferTokens   int
+	FixBuffer            string
+	FixBufferTokens      int
+	Success              bool
+	Error                error
+	IsVerification       bool
+	ToVerifyUpdatedState string
+	// TestSourcePath is set for builds queued by test scaffolding, which write the tests for the changes to the file at this path
+	TestSourcePath string
+	// DocSyncSourcePath is set for builds queued by doc sync, which update a doc for the changes to the public API of the file at this path, described in DocSyncApiChanges
+	DocSyncSourcePath string
+	DocSyncApiChanges string
+	// SymbolRename is set for builds of a symbol rename the planner declared, which rename the symbol's references in the file instead of building proposed changes
+	SymbolRename *shared.SymbolRename
+	// ConsistencyFix is set for builds queued by a consistency check, which fix a file that's inconsistent with changes to other files
+	ConsistencyFix bool
+	// ImportFix is set for builds that fix a built file's unused and missing imports, without a model call
+	ImportFix bool
+	// RemoveFile is set for builds of a file removal the planner declared, which remove the file instead of building proposed changes
+	RemoveFile bool
 }
 
 type subscription struct {
@@ -47,38 +62,67 @@ type subscription struct {
 }
 
 type ActivePlan struct {
-	Id                      string
-	UserId                  string
-	OrgId                   string
-	CurrentStreamingReplyId string
-	CurrentReplyDoneCh      chan bool
-	Branch                  string
-	Prompt                  string
-	BuildOnly               bool
-	Ctx                     context.Context
-	CancelFn                context.CancelFunc
-	ModelStreamCtx          context.Context
-	CancelModelStreamFn     context.CancelFunc
-	SummaryCtx              context.Context
-	SummaryCancelFn         context.CancelFunc
-	LatestSummaryCh         chan *db.ConvoSummary
-	Contexts                []*db.Context
-	ContextsByPath          map[string]*db.Context
-	Files                   []string
-	BuiltFiles              map[string]bool
-	IsBuildingByPath        map[string]bool
-	CurrentReplyContent     string
-	NumTokens               int
-	MessageNum              int
-	BuildQueuesByPath       map[string][]*ActiveBuild
-	RepliesFinished         bool
-	StreamDoneCh            chan *shared.ApiError
-	ModelStreamId           string
-	MissingFilePath         string
-	MissingFileResponseCh   chan shared.RespondMissingFileChoice
-	AllowOverwritePaths     map[string]bool
-	SkippedPaths            map[string]bool
-	StoredReplyIds          []string
+	Id                           string
+	UserId                       string
+	OrgId                        string
+	CurrentStreamingReplyId      string
+	CurrentReplyDoneCh           chan bool
+	Branch                       string
+	Prompt                       string
+	BuildOnly                    bool
+	Ctx                          context.Context
+	CancelFn                     context.CancelFunc
+	ModelStreamCtx               context.Context
+	CancelModelStreamFn          context.CancelFunc
+	SummaryCtx                   context.Context
+	SummaryCancelFn              context.CancelFunc
+	LatestSummaryCh              chan *db.ConvoSummary
+	Contexts                     []*db.Context
+	ContextsByPath               map[string]*db.Context
+	Files                        []string
+	BuiltFiles                   map[string]bool
+	IsBuildingByPath             map[string]bool
+	CurrentReplyContent          string
+	NumTokens                    int
+	MessageNum                   int
+	BuildQueuesByPath            map[string][]*ActiveBuild
+	RepliesFinished              bool
+	StreamDoneCh                 chan *shared.ApiError
+	ModelStreamId                string
+	MissingFilePath              string
+	MissingFileAutoChoice        shared.RespondMissingFileChoice
+	MissingFileOutsideWorkspaces bool
+	MissingFileResponseCh        chan shared.RespondMissingFileChoice
+	PromptingContinue            bool
+	ContinueNextTask             string
+	ContinueResponseCh           chan bool
+	AllowOverwritePaths          map[string]bool
+	SkippedPaths                 map[string]bool
+	StoredReplyIds               []string
+	// SteeringNotes are short instructions the user sent while the plan was streaming, added to each planner call made after they arrive
+	SteeringNotes []string
+	// KnowledgePrompt is the knowledge base entries retrieved for the plan's prompt, added to each planner call in the stream. KnowledgeTokens is their size.
+	KnowledgePrompt string
+	KnowledgeTokens int
+	// PreferencesPrompt is the user's and org's learned preferences, added to planner and builder prompts. PreferencesTokens is its size.
+	PreferencesPrompt string
+	PreferencesTokens int
+	// ConventionsPrompt is the project's conventions file, sent with the prompt and added to each planner call in the stream. ConventionsTokens is its size.
+	ConventionsPrompt string
+	ConventionsTokens int
+	// FailedBuildPaths maps paths that failed to build, and were skipped for the rest of the build, to their error
+	FailedBuildPaths map[string]string
+	// BuildHeldByPath is true for paths whose next build is waiting for the build to be resumed
+	BuildHeldByPath map[string]bool
+	// BuildEstimate is the cost estimate for the plan's latest batch of builds, sent to clients that connect after it was streamed
+	BuildEstimate *shared.BuildCostEstimate
+	// ConsistencyCheckPaths are the files built from the plan's replies since the last consistency check, which runs once they're all built if there are at least two. IsCheckingConsistency is true while it runs.
+	ConsistencyCheckPaths map[string]bool
+	IsCheckingConsistency bool
+
+	buildPaused   bool
+	buildResumeCh chan struct{}
+	buildPauseMu  sync.Mutex
 
 	subscriptions  map[string]*subscription
 	subscriptionMu sync.Mutex
@@ -89,13 +133,14 @@ type ActivePlan struct {
 	streamMessageBuffer   []shared.StreamMessage
 }
 
-func NewActivePlan(orgId, userId, planId, branch, prompt string, buildOnly bool) *ActivePlan {
-	ctx, cancel := context.WithCancel(context.Background())
+// NewActivePlan creates the plan's contexts from baseCtx, which carries values like the plan's model call priority and isn't canceled
+func NewActivePlan(baseCtx context.Context, orgId, userId, planId, branch, prompt string, buildOnly bool) *ActivePlan {
+	ctx, cancel := context.WithCancel(baseCtx)
 	// child context for model stream so we can cancel it separately if needed
 	modelStreamCtx, cancelModelStream := context.WithCancel(ctx)
 
 	// we don't want to cancel summaries unless the whole plan is stopped or there's an error -- if the active plan finishes, we want summaries to continue -- so they get their own context
-	summaryCtx, cancelSummary := context.WithCancel(context.Background())
+	summaryCtx, cancelSummary := context.WithCancel(baseCtx)
 
 	active := ActivePlan{
 		Id:                    planId,
@@ -115,11 +160,15 @@ func NewActivePlan(orgId, userId, planId, branch, prompt string, buildOnly bool)
 		ContextsByPath:        map[string]*db.Context{},
 		Files:                 []string{},
 		BuiltFiles:            map[string]bool{},
+		ConsistencyCheckPaths: map[string]bool{},
 		IsBuildingByPath:      map[string]bool{},
 		StreamDoneCh:          make(chan *shared.ApiError),
 		MissingFileResponseCh: make(chan shared.RespondMissingFileChoice),
+		ContinueResponseCh:    make(chan bool),
 		AllowOverwritePaths:   map[string]bool{},
 		SkippedPaths:          map[string]bool{},
+		BuildHeldByPath:       map[string]bool{},
+		FailedBuildPaths:      map[string]string{},
 		streamCh:              make(chan string),
 		subscriptions:         map[string]*subscription{},
 		subscriptionMu:        sync.Mutex{},
@@ -248,7 +297,12 @@ func (ap *ActivePlan) ResetModelCtx() {
 	ap.ModelStreamCtx, ap.CancelModelStreamFn = context.WithCancel(ap.Ctx)
 }
 
+// BuildFinished is whether every queued build is done, and the build's consistency check, if it has one, has run
 func (ap *ActivePlan) BuildFinished() bool {
+	return ap.QueuesFinished() && !ap.ConsistencyCheckPending() && !ap.IsCheckingConsistency
+}
+
+func (ap *ActivePlan) QueuesFinished() bool {
 	for path := range ap.BuildQueuesByPath {
 		if ap.IsBuildingByPath[path] || !ap.PathQueueEmpty(path) {
 			return false
@@ -257,6 +311,10 @@ func (ap *ActivePlan) BuildFinished() bool {
 	return true
 }
 
+func (ap *ActivePlan) ConsistencyCheckPending() bool {
+	return len(ap.ConsistencyCheckPaths) >= 2
+}
+
 func (ap *ActivePlan) PathQueueEmpty(path string) bool {
 	for _, build := range ap.BuildQueuesByPath[path] {
 		if !build.BuildFinished() {
@@ -266,6 +324,59 @@ func (ap *ActivePlan) PathQueueEmpty(path string) bool {
 	return true
 }
 
+// PauseBuild holds the build queues: model calls already in progress finish, but no file starts its next build until ResumeBuild is called. It returns false if the build was already paused.
+func (ap *ActivePlan) PauseBuild() bool {
+	ap.buildPauseMu.Lock()
+	defer ap.buildPauseMu.Unlock()
+
+	if ap.buildPaused {
+		return false
+	}
+
+	ap.buildPaused = true
+	ap.buildResumeCh = make(chan struct{})
+	return true
+}
+
+// ResumeBuild releases builds held by PauseBuild. It returns false if the build wasn't paused.
+func (ap *ActivePlan) ResumeBuild() bool {
+	ap.buildPauseMu.Lock()
+	defer ap.buildPauseMu.Unlock()
+
+	if !ap.buildPaused {
+		return false
+	}
+
+	ap.buildPaused = false
+	close(ap.buildResumeCh)
+	return true
+}
+
+func (ap *ActivePlan) IsBuildPaused() bool {
+	ap.buildPauseMu.Lock()
+	defer ap.buildPauseMu.Unlock()
+	return ap.buildPaused
+}
+
+// WaitForBuildResume blocks while the build is paused. It returns false if the plan is stopped first.
+func (ap *ActivePlan) WaitForBuildResume() bool {
+	ap.buildPauseMu.Lock()
+	paused := ap.buildPaused
+	resumeCh := ap.buildResumeCh
+	ap.buildPauseMu.Unlock()
+
+	if !paused {
+		return true
+	}
+
+	select {
+	case <-resumeCh:
+		return true
+	case <-ap.Ctx.Done():
+		return false
+	}
+}
+
 func (ap *ActivePlan) Subscribe() (string, chan string) {
 	ap.subscriptionMu.Lock()
 	defer ap.subscriptionMu.Unlock()
@@ -298,6 +409,11 @@ func (b *ActiveBuild) BuildFinished() bool {
 	return b.Success || b.Error != nil
 }
 
+// IsReplyBuild is whether the build is of changes proposed in a reply, rather than a follow-up build the server queued, like a test scaffold, doc sync, symbol rename, consistency fix, or import fix
+func (b *ActiveBuild) IsReplyBuild() bool {
+	return b.TestSourcePath == "" && b.DocSyncSourcePath == "" && b.SymbolRename == nil && !b.ConsistencyFix && !b.ImportFix
+}
+
 func newSubscription() *subscription {
 	ctx, cancel := context.WithCancel(context.Background())
 	sub := &subscription{
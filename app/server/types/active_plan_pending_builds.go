@@ -38,7 +38,11 @@ func (ap *ActivePlan) PendingBuildsByPath(orgId, userId string, convoMessagesArg
 	activeBuildsByPath := map[string][]*ActiveBuild{}
 
 	for _, desc := range planDescs {
-		if (!desc.DidBuild && len(desc.Files) > 0) || len(desc.BuildPathsInvalidated) > 0 {
+		if desc.Stale {
+			continue
+		}
+
+		if (!desc.DidBuild && (len(desc.Files) > 0 || len(desc.Renames) > 0 || len(desc.RemovedFiles) > 0)) || len(desc.BuildPathsInvalidated) > 0 {
 			if desc.ConvoMessageId == "" {
 				log.Printf("No convo message ID for description: %v\n", desc)
 				return nil, fmt.Errorf("no convo message ID for description: %v", desc)
@@ -84,6 +88,32 @@ func (ap *ActivePlan) PendingBuildsByPath(orgId, userId string, convoMessagesArg
 					FileDescription:   fileDesc,
 				})
 			}
+
+			for _, rename := range desc.Renames {
+				for _, file := range rename.Paths {
+					if desc.DidBuild && !desc.BuildPathsInvalidated[file] {
+						continue
+					}
+
+					activeBuildsByPath[file] = append(activeBuildsByPath[file], &ActiveBuild{
+						ReplyId:      desc.ConvoMessageId,
+						Path:         file,
+						SymbolRename: rename,
+					})
+				}
+			}
+
+			for _, file := range desc.RemovedFiles {
+				if desc.DidBuild && !desc.BuildPathsInvalidated[file] {
+					continue
+				}
+
+				activeBuildsByPath[file] = append(activeBuildsByPath[file], &ActiveBuild{
+					ReplyId:    desc.ConvoMessageId,
+					Path:       file,
+					RemoveFile: true,
+				})
+			}
 		}
 	}
 
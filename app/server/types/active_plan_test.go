@@ -0,0 +1,50 @@
+package types
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBuildPauseResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ap := &ActivePlan{Ctx: ctx}
+
+	if !ap.WaitForBuildResume() {
+		t.Fatal("wait should return right away when the build isn't paused")
+	}
+
+	if !ap.PauseBuild() || ap.PauseBuild() {
+		t.Fatal("only the first pause should succeed")
+	}
+
+	resumed := make(chan bool)
+	go func() {
+		resumed <- ap.WaitForBuildResume()
+	}()
+
+	select {
+	case <-resumed:
+		t.Fatal("wait returned while the build was paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if !ap.ResumeBuild() || ap.ResumeBuild() {
+		t.Fatal("only the first resume should succeed")
+	}
+
+	if !<-resumed {
+		t.Fatal("wait should report the build was resumed")
+	}
+
+	ap.PauseBuild()
+	go func() {
+		resumed <- ap.WaitForBuildResume()
+	}()
+	cancel()
+
+	if <-resumed {
+		t.Fatal("wait should report the plan was stopped")
+	}
+}
@@ -10,6 +10,9 @@ type ServerAuth struct {
 	User        *db.User
 	OrgId       string
 	Permissions map[Permission]bool
+
+	// set when the plan being accessed was shared directly with the user
+	PlanShare *db.PlanShare
 }
 
 func (a *ServerAuth) HasPermission(permission Permission) bool {
@@ -46,4 +49,5 @@ const (
 	PermissionDeleteAnyPlan         Permission = "delete_any_plan"
 	PermissionUpdateAnyPlan         Permission = "update_any_plan"
 	PermissionArchiveAnyPlan        Permission = "archive_any_plan"
+	PermissionUpdateOrgSettings     Permission = "update_org_settings"
 )
@@ -2,7 +2,10 @@ package types
 
 import (
 	"os"
+	"regexp"
 	"strings"
+
+	"github.com/plandex/plandex/shared"
 )
 
 type ReplyParserRes struct {
@@ -12,6 +15,8 @@ type ReplyParserRes struct {
 	FileContents       []string
 	FileDescriptions   []string
 	RepliesBeforeFiles []string
+	Renames            []*shared.SymbolRename
+	RemovedFiles       []string
 	NumTokensByFile    map[string]int
 	TotalTokens        int
 }
@@ -28,6 +33,8 @@ type ReplyParser struct {
 	fileDescriptions          []string
 	currentDescriptionLines   []string
 	currentDescriptionLineIdx int
+	renames                   []*shared.SymbolRename
+	removedFiles              []string
 	numTokens                 int
 	numTokensByFile           map[string]int
 }
@@ -158,6 +165,16 @@ func (r *ReplyParser) AddChunk(chunk string, addToTotal bool) {
 	}
 
 	if r.currentFilePath == "" {
+		if rename := parseSymbolRename(prevFullLineTrimmed); rename != nil {
+			r.addRename(rename)
+			return
+		}
+
+		if path := parseFileRemoval(prevFullLineTrimmed); path != "" {
+			r.addRemovedFile(path)
+			return
+		}
+
 		// log.Println("Current file path is empty--checking for possible file path...")
 
 		var gotPath string
@@ -186,8 +203,10 @@ func (r *ReplyParser) AddChunk(chunk string, addToTotal bool) {
 		} else {
 			// log.Println("Adding tokens to current file...") // Logging token addition
 
-			r.fileContents[r.currentFileIdx] += prevFullLine + "\n"
-			r.currentFileLines = append(r.currentFileLines, prevFullLine)
+			// the context is line-numbered so the planner can cite it; numbers it copies into a file block aren't part of the file
+			fileLine := shared.RemoveLineNums(prevFullLine)
+			r.fileContents[r.currentFileIdx] += fileLine + "\n"
+			r.currentFileLines = append(r.currentFileLines, fileLine)
 			// log.Printf("Added %d tokens to %s\n", tokens, r.currentFilePath) // Logging token addition
 		}
 	}
@@ -202,6 +221,8 @@ func (r *ReplyParser) Read() ReplyParserRes {
 		NumTokensByFile:  r.numTokensByFile,
 		TotalTokens:      r.numTokens,
 		FileDescriptions: r.fileDescriptions,
+		Renames:          r.renames,
+		RemovedFiles:     r.removedFiles,
 	}
 }
 
@@ -284,3 +305,43 @@ func extractFilePath(line string) string {
 
 	return p
 }
+
+// a symbol rename is declared on its own line, like: Rename symbol: `oldName` → `newName`
+var symbolRenameRegex = regexp.MustCompile(`^(?:\*\*)?Rename symbol:?(?:\*\*)?:?\s*` + "`" + `([A-Za-z_$][\w$]*)` + "`" + `\s*(?:→|->|to)\s*` + "`" + `([A-Za-z_$][\w$]*)` + "`" + `\.?$`)
+
+func parseSymbolRename(line string) *shared.SymbolRename {
+	m := symbolRenameRegex.FindStringSubmatch(line)
+	if m == nil || m[1] == m[2] {
+		return nil
+	}
+	return &shared.SymbolRename{From: m[1], To: m[2]}
+}
+
+func (r *ReplyParser) addRename(rename *shared.SymbolRename) {
+	for _, existing := range r.renames {
+		if existing.From == rename.From {
+			return
+		}
+	}
+	r.renames = append(r.renames, rename)
+}
+
+// a file removal is declared on its own line, like: Remove file: `src/old.ts`
+var fileRemovalRegex = regexp.MustCompile(`^(?:\*\*)?Remove file:?(?:\*\*)?:?\s*` + "`" + `([^` + "`" + `\s]+)` + "`" + `\.?$`)
+
+func parseFileRemoval(line string) string {
+	m := fileRemovalRegex.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+func (r *ReplyParser) addRemovedFile(path string) {
+	for _, existing := range r.removedFiles {
+		if existing == path {
+			return
+		}
+	}
+	r.removedFiles = append(r.removedFiles, path)
+}
@@ -118,3 +118,68 @@ func TestReplyTokenCounter(t *testing.T) {
 		// }
 	}
 }
+
+func TestReplyParserRenames(t *testing.T) {
+	reply := "Renaming the function everywhere it's used.\n\n" +
+		"Rename symbol: `loadUser` → `fetchUser`\n\n" +
+		"**Rename symbol:** `Render` -> `Format`\n\n" +
+		"Rename symbol: `loadUser` → `getUser`\n\n" +
+		"- src/api.ts:\n\n```ts\n// Rename symbol: `inCode` → `notParsed`\nexport const x = 1;\n```\n\n" +
+		"Rename symbol: `same` → `same`\n"
+
+	parser := NewReplyParser()
+	parser.AddChunk(reply, true)
+	res := parser.FinishAndRead()
+
+	if len(res.Files) != 1 || res.Files[0] != "src/api.ts" {
+		t.Errorf("Files = %v, want [src/api.ts]", res.Files)
+	}
+
+	var got []string
+	for _, rename := range res.Renames {
+		got = append(got, rename.From+"->"+rename.To)
+	}
+	want := []string{"loadUser->fetchUser", "Render->Format"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Renames = %v, want %v", got, want)
+	}
+}
+
+func TestReplyParserRemovedFiles(t *testing.T) {
+	reply := "The old client is no longer used.\n\n" +
+		"Remove file: `src/oldClient.ts`\n\n" +
+		"**Remove file:** `lib/legacy.go`.\n\n" +
+		"Remove file: `src/oldClient.ts`\n\n" +
+		"- src/api.ts:\n\n```ts\n// Remove file: `src/inCode.ts`\nexport const x = 1;\n```\n"
+
+	parser := NewReplyParser()
+	parser.AddChunk(reply, true)
+	res := parser.FinishAndRead()
+
+	if len(res.Files) != 1 || res.Files[0] != "src/api.ts" {
+		t.Errorf("Files = %v, want [src/api.ts]", res.Files)
+	}
+
+	want := []string{"src/oldClient.ts", "lib/legacy.go"}
+	if fmt.Sprint(res.RemovedFiles) != fmt.Sprint(want) {
+		t.Errorf("RemovedFiles = %v, want %v", res.RemovedFiles, want)
+	}
+}
+
+func TestReplyParserStripsLineNums(t *testing.T) {
+	reply := "The handler is registered in [[cite:server/routes.go:12]].\n\n" +
+		"- server/routes.go:\n\n```go\npdx-12: r.HandleFunc(\"/a\", a)\nr.HandleFunc(\"/b\", b)\n```\n"
+
+	parser := NewReplyParser()
+	parser.AddChunk(reply, true)
+	res := parser.FinishAndRead()
+
+	if len(res.FileContents) != 1 {
+		t.Fatalf("FileContents = %v, want 1 file", res.FileContents)
+	}
+
+	want := "r.HandleFunc(\"/a\", a)\nr.HandleFunc(\"/b\", b)\n"
+	if res.FileContents[0] != want {
+		t.Errorf("FileContents[0] = %q, want %q", res.FileContents[0], want)
+	}
+}
@@ -0,0 +1,30 @@
+package types
+
+import (
+	"log"
+	"plandex-server/syntax"
+	"sort"
+)
+
+// SymbolRenamePaths returns the files in context that reference a symbol, which a rename of the symbol is built into. Only files with a parser for their language are included, since references are found in the syntax tree.
+func (ap *ActivePlan) SymbolRenamePaths(name string) []string {
+	var res []string
+	for _, context := range ap.Contexts {
+		if context.FilePath == "" {
+			continue
+		}
+
+		refs, _, err := syntax.FindSymbol(ap.Ctx, context.FilePath, context.Body, name)
+		if err != nil {
+			log.Printf("Error finding references to %s in %s: %v\n", name, context.FilePath, err)
+			continue
+		}
+
+		if len(refs) > 0 {
+			res = append(res, context.FilePath)
+		}
+	}
+
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,39 @@
+package shared
+
+import (
+	"fmt"
+	"time"
+)
+
+type DigestFrequency string
+
+const (
+	DigestFrequencyOff    DigestFrequency = "off"
+	DigestFrequencyDaily  DigestFrequency = "daily"
+	DigestFrequencyWeekly DigestFrequency = "weekly"
+)
+
+// UserDigestSettings controls the email digest of plan activity in a user's orgs. Digests go out at the server's digest hour (UTC), daily or on Mondays.
+type UserDigestSettings struct {
+	Frequency  DigestFrequency `json:"frequency"`
+	LastSentAt *time.Time      `json:"lastSentAt,omitempty"`
+	UpdatedAt  time.Time       `json:"updatedAt"`
+}
+
+func (f DigestFrequency) Validate() error {
+	switch f {
+	case DigestFrequencyOff, DigestFrequencyDaily, DigestFrequencyWeekly:
+		return nil
+	}
+	return fmt.Errorf("invalid digest frequency %q -- must be 'daily', 'weekly', or 'off'", f)
+}
+
+type GetUserDigestSettingsResponse struct {
+	Settings *UserDigestSettings `json:"settings"`
+	// false if the server isn't set up to send email, in which case no digests are sent
+	CanSendEmail bool `json:"canSendEmail"`
+}
+
+type UpdateUserDigestSettingsRequest struct {
+	Frequency DigestFrequency `json:"frequency"`
+}
@@ -16,6 +16,16 @@ var fullCompatibility = ModelCompatibility{
 	HasImageSupport:           true,
 }
 
+var fullCompatibilityWithStructuredOutputs = ModelCompatibility{
+	IsOpenAICompatible:        true,
+	HasJsonResponseMode:       true,
+	HasStreaming:              true,
+	HasFunctionCalling:        true,
+	HasStreamingFunctionCalls: true,
+	HasImageSupport:           true,
+	HasStructuredOutputs:      true,
+}
+
 var fullCompatibilityExceptImage = ModelCompatibility{
 	IsOpenAICompatible:        true,
 	HasJsonResponseMode:       true,
@@ -35,7 +45,7 @@ var AvailableModels = []*AvailableModel{
 			ModelName:          openai.GPT4o,
 			MaxTokens:          128000,
 			ApiKeyEnvVar:       OpenAIEnvVar,
-			ModelCompatibility: fullCompatibility,
+			ModelCompatibility: fullCompatibilityWithStructuredOutputs,
 			BaseUrl:            OpenAIV1BaseUrl,
 		},
 	},
@@ -136,6 +146,68 @@ var AvailableModels = []*AvailableModel{
 			BaseUrl:            OpenAIV1BaseUrl,
 		},
 	},
+	{
+		Description:                 "OpenAI's o1 reasoning model. It doesn't stream, so replies arrive all at once.",
+		DefaultMaxConvoTokens:       15000,
+		DefaultReservedOutputTokens: 25000,
+		BaseModelConfig: BaseModelConfig{
+			Provider:     ModelProviderOpenAI,
+			ModelName:    "o1",
+			MaxTokens:    200000,
+			ApiKeyEnvVar: OpenAIEnvVar,
+			ModelCompatibility: ModelCompatibility{
+				IsOpenAICompatible:        true,
+				HasJsonResponseMode:       true,
+				HasStreaming:              false,
+				HasFunctionCalling:        true,
+				HasStreamingFunctionCalls: false,
+				HasImageSupport:           true,
+				HasStructuredOutputs:      true,
+			},
+			BaseUrl: OpenAIV1BaseUrl,
+		},
+	},
+	{
+		Description:                 "OpenAI's o1-mini reasoning model. It doesn't take system messages or function calls.",
+		DefaultMaxConvoTokens:       10000,
+		DefaultReservedOutputTokens: 25000,
+		BaseModelConfig: BaseModelConfig{
+			Provider:     ModelProviderOpenAI,
+			ModelName:    "o1-mini",
+			MaxTokens:    128000,
+			ApiKeyEnvVar: OpenAIEnvVar,
+			ModelCompatibility: ModelCompatibility{
+				IsOpenAICompatible:        true,
+				HasJsonResponseMode:       false,
+				HasStreaming:              false,
+				HasFunctionCalling:        false,
+				HasStreamingFunctionCalls: false,
+				HasImageSupport:           false,
+			},
+			BaseUrl: OpenAIV1BaseUrl,
+		},
+	},
+	{
+		Description:                 "OpenAI's o3-mini reasoning model",
+		DefaultMaxConvoTokens:       15000,
+		DefaultReservedOutputTokens: 25000,
+		BaseModelConfig: BaseModelConfig{
+			Provider:     ModelProviderOpenAI,
+			ModelName:    "o3-mini",
+			MaxTokens:    200000,
+			ApiKeyEnvVar: OpenAIEnvVar,
+			ModelCompatibility: ModelCompatibility{
+				IsOpenAICompatible:        true,
+				HasJsonResponseMode:       true,
+				HasStreaming:              true,
+				HasFunctionCalling:        true,
+				HasStreamingFunctionCalls: true,
+				HasImageSupport:           false,
+				HasStructuredOutputs:      true,
+			},
+			BaseUrl: OpenAIV1BaseUrl,
+		},
+	},
 	{
 		Description:                 "Anthropic Claude 3.5 Sonnet via OpenRouter",
 		DefaultMaxConvoTokens:       15000,
@@ -289,6 +361,19 @@ var AvailableModels = []*AvailableModel{
 			BaseUrl: BaseUrlByProvider[ModelProviderOpenRouter],
 		},
 	},
+	{
+		Description:                 "Scripted responses for tests and offline demos. Requires a server with the mock provider enabled.",
+		DefaultMaxConvoTokens:       10000,
+		DefaultReservedOutputTokens: 4096,
+		BaseModelConfig: BaseModelConfig{
+			Provider:           ModelProviderMock,
+			ModelName:          "mock",
+			MaxTokens:          128000,
+			ApiKeyEnvVar:       MockApiKeyEnvVar,
+			ModelCompatibility: fullCompatibilityWithStructuredOutputs,
+			BaseUrl:            MockBaseUrl,
+		},
+	},
 }
 
 var AvailableModelsByName = map[string]*AvailableModel{}
@@ -297,6 +382,7 @@ var Gpt4TurboLatestModelPack ModelPack
 var OpenRouterClaude3Dot5SonnetGPT4TurboModelPack ModelPack
 var OpenRouterClaude3Dot5SonnetModelPack ModelPack
 var TogetherMixtral8x22BModelPack ModelPack
+var MockModelPack ModelPack
 var Gpt4oLatestModelPack ModelPack
 
 var BuiltInModelPacks = []*ModelPack{
@@ -305,6 +391,7 @@ var BuiltInModelPacks = []*ModelPack{
 	&OpenRouterClaude3Dot5SonnetModelPack,
 	&OpenRouterClaude3Dot5SonnetGPT4TurboModelPack,
 	&TogetherMixtral8x22BModelPack,
+	&MockModelPack,
 }
 
 var DefaultModelPack *ModelPack = &Gpt4oLatestModelPack
@@ -349,6 +436,14 @@ var DefaultConfigByRole = map[ModelRole]ModelRoleConfig{
 		Temperature: 0.1,
 		TopP:        0.1,
 	},
+	ModelRoleDraftBuilder: {
+		Temperature: 0.1,
+		TopP:        0.1,
+	},
+	ModelRoleChat: {
+		Temperature: 0.5,
+		TopP:        0.5,
+	},
 }
 
 var RequiredCompatibilityByRole = map[ModelRole]ModelCompatibility{
@@ -356,9 +451,9 @@ var RequiredCompatibilityByRole = map[ModelRole]ModelCompatibility{
 		IsOpenAICompatible: true,
 		HasStreaming:       true,
 	},
+	// summaries are generated in the background without streaming
 	ModelRolePlanSummary: {
 		IsOpenAICompatible: true,
-		HasStreaming:       true,
 	},
 	ModelRoleBuilder: {
 		IsOpenAICompatible: true,
@@ -388,6 +483,14 @@ var RequiredCompatibilityByRole = map[ModelRole]ModelCompatibility{
 		HasStreaming:       true,
 		HasFunctionCalling: true,
 		// HasStreamingFunctionCalls: true -- no longer required
+	}, ModelRoleDraftBuilder: {
+		IsOpenAICompatible: true,
+		HasStreaming:       true,
+		HasFunctionCalling: true,
+	},
+	ModelRoleChat: {
+		IsOpenAICompatible: true,
+		HasStreaming:       true,
 	},
 }
 
@@ -639,6 +742,33 @@ func init() {
 			TopP:            DefaultConfigByRole[ModelRoleExecStatus].TopP,
 		},
 	}
+
+	mockRoleConfig := func(role ModelRole) ModelRoleConfig {
+		return ModelRoleConfig{
+			Role:            role,
+			BaseModelConfig: AvailableModelsByName["mock"].BaseModelConfig,
+			Temperature:     DefaultConfigByRole[role].Temperature,
+			TopP:            DefaultConfigByRole[role].TopP,
+		}
+	}
+	mockVerifier := mockRoleConfig(ModelRoleVerifier)
+	mockAutoFix := mockRoleConfig(ModelRoleAutoFix)
+
+	MockModelPack = ModelPack{
+		Name:        "mock",
+		Description: "Uses the mock provider's scripted responses for every role, for tests and offline demos without api keys. Requires a server with the mock provider enabled.",
+		Planner: PlannerRoleConfig{
+			ModelRoleConfig:    mockRoleConfig(ModelRolePlanner),
+			PlannerModelConfig: getPlannerModelConfig("mock"),
+		},
+		PlanSummary: mockRoleConfig(ModelRolePlanSummary),
+		Builder:     mockRoleConfig(ModelRoleBuilder),
+		Namer:       mockRoleConfig(ModelRoleName),
+		CommitMsg:   mockRoleConfig(ModelRoleCommitMsg),
+		ExecStatus:  mockRoleConfig(ModelRoleExecStatus),
+		Verifier:    &mockVerifier,
+		AutoFix:     &mockAutoFix,
+	}
 }
 
 func FilterCompatibleModels(models []*AvailableModel, role ModelRole) []*AvailableModel {
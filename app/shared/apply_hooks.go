@@ -0,0 +1,46 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ApplyHookType string
+
+const (
+	ApplyHookPre  ApplyHookType = "pre-apply"
+	ApplyHookPost ApplyHookType = "post-apply"
+)
+
+// RecordApplyHookRequest adds the result of running an apply hook script in the project to the plan's history
+type RecordApplyHookRequest struct {
+	Hook         ApplyHookType `json:"hook"`
+	ExitCode     int           `json:"exitCode"`
+	Output       string        `json:"output"`
+	AbortedApply bool          `json:"abortedApply"`
+}
+
+// MaxApplyHookOutput is the most hook output kept in plan history. Longer output keeps the end, where errors usually are.
+const MaxApplyHookOutput = 10000
+
+func (r RecordApplyHookRequest) CommitMsg() string {
+	var msg string
+	if r.ExitCode == 0 {
+		msg = fmt.Sprintf("🪝 Ran %s hook", r.Hook)
+	} else {
+		msg = fmt.Sprintf("🪝 %s hook failed with exit code %d", r.Hook, r.ExitCode)
+	}
+	if r.AbortedApply {
+		msg += ", so the pending changes weren't applied"
+	}
+
+	output := strings.TrimSpace(r.Output)
+	if len(output) > MaxApplyHookOutput {
+		output = "…" + strings.ToValidUTF8(output[len(output)-MaxApplyHookOutput:], "")
+	}
+	if output != "" {
+		msg += "\n\n" + output
+	}
+
+	return msg
+}
@@ -12,9 +12,24 @@ const (
 	ApiErrorTypeTrialPlansExceeded    ApiErrorType = "trial_plans_exceeded"
 	ApiErrorTypeTrialMessagesExceeded ApiErrorType = "trial_messages_exceeded"
 	ApiErrorTypeTrialActionNotAllowed ApiErrorType = "trial_action_not_allowed"
+	ApiErrorTypeTrialTokensExceeded   ApiErrorType = "trial_tokens_exceeded"
 
 	ApiErrorTypeContinueNoMessages ApiErrorType = "continue_no_messages"
 
+	ApiErrorTypeContextDeltaMismatch ApiErrorType = "context_delta_mismatch"
+
+	ApiErrorTypeProviderRateLimited ApiErrorType = "provider_rate_limited"
+	ApiErrorTypeContextTooLong      ApiErrorType = "context_too_long"
+	ApiErrorTypeInvalidToolOutput   ApiErrorType = "invalid_tool_output"
+	ApiErrorTypeApplyConflict       ApiErrorType = "apply_conflict"
+	ApiErrorTypeBudgetExceeded      ApiErrorType = "budget_exceeded"
+	ApiErrorTypeFileTooLarge        ApiErrorType = "file_too_large"
+
+	// set by the CLI when a request can't reach the server
+	ApiErrorTypeServerUnreachable ApiErrorType = "server_unreachable"
+
+	ApiErrorTypeTrustPolicyDenied ApiErrorType = "trust_policy_denied"
+
 	ApiErrorTypeOther ApiErrorType = "other"
 )
 
@@ -26,6 +41,33 @@ type TrialMessagesExceededError struct {
 	MaxReplies int `json:"maxMessages"`
 }
 
+type TrialTokensExceededError struct {
+	MaxTokens  int `json:"maxTokens"`
+	UsedTokens int `json:"usedTokens"`
+}
+
+type PromptTokensPart struct {
+	Name   string `json:"name"`
+	Tokens int    `json:"tokens"`
+}
+
+// PromptTooLongError breaks down a prompt that was too long for a model by what it's made of. Tokens are counted for the model's tokenizer, and Estimated is set if its vocabulary isn't available.
+type PromptTooLongError struct {
+	Model       string             `json:"model"`
+	Path        string             `json:"path,omitempty"`
+	MaxTokens   int                `json:"maxTokens"`
+	TotalTokens int                `json:"totalTokens"`
+	Parts       []PromptTokensPart `json:"parts"`
+	Estimated   bool               `json:"estimated,omitempty"`
+}
+
+// FileTooLargeError is a file that was too large for the builder to attempt, with the limit from the plan's settings. Tokens are counted with the base tokenizer.
+type FileTooLargeError struct {
+	Path      string `json:"path"`
+	Tokens    int    `json:"tokens"`
+	MaxTokens int    `json:"maxTokens"`
+}
+
 type ApiError struct {
 	Type   ApiErrorType `json:"type"`
 	Status int          `json:"status"`
@@ -36,4 +78,13 @@ type ApiError struct {
 
 	// only used for trial messages exceeded error
 	TrialMessagesExceededError *TrialMessagesExceededError `json:"trialMessagesExceededError,omitempty"`
+
+	// only used for trial tokens exceeded error
+	TrialTokensExceededError *TrialTokensExceededError `json:"trialTokensExceededError,omitempty"`
+
+	// only used for context too long errors when the prompt was checked before calling the model
+	PromptTooLongError *PromptTooLongError `json:"promptTooLongError,omitempty"`
+
+	// only used for file too large errors
+	FileTooLargeError *FileTooLargeError `json:"fileTooLargeError,omitempty"`
 }
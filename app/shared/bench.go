@@ -0,0 +1,40 @@
+package shared
+
+import "time"
+
+type BenchRequest struct {
+	ModelPacks  []string          `json:"modelPacks"`
+	ApiKeys     map[string]string `json:"apiKeys"`
+	OpenAIBase  string            `json:"openAIBase"`
+	OpenAIOrgId string            `json:"openAIOrgId"`
+}
+
+const NoBenchFilesErr string = "No file changes to benchmark"
+
+// BenchResult is how one model pack's builder did on a benchmark: the plan's latest file changes built again with that pack. A file passes when all its replacements apply and the result is syntactically valid.
+type BenchResult struct {
+	Id               string    `json:"id"`
+	BenchId          string    `json:"benchId"`
+	PlanId           string    `json:"planId,omitempty"`
+	ModelPack        string    `json:"modelPack"`
+	BuilderModel     string    `json:"builderModel"`
+	NumFiles         int       `json:"numFiles"`
+	NumPassed        int       `json:"numPassed"`
+	DurationMs       int64     `json:"durationMs"`
+	PromptTokens     int       `json:"promptTokens"`
+	CompletionTokens int       `json:"completionTokens"`
+	Error            string    `json:"error,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+func (r *BenchResult) PassRate() float64 {
+	if r.NumFiles == 0 {
+		return 0
+	}
+	return float64(r.NumPassed) / float64(r.NumFiles)
+}
+
+type BenchResponse struct {
+	BenchId string         `json:"benchId"`
+	Results []*BenchResult `json:"results"`
+}
@@ -0,0 +1,26 @@
+package shared
+
+import "time"
+
+type BuildCapturePhase string
+
+const (
+	BuildCapturePhaseBuild  BuildCapturePhase = "build"
+	BuildCapturePhaseVerify BuildCapturePhase = "verify"
+	BuildCapturePhaseFix    BuildCapturePhase = "fix"
+)
+
+// BuildCapture is a model request made while building a file, along with the model's raw response, recorded when a plan's CaptureModelIO setting is on. Request is the chat completion request as JSON. Response is the full response as JSON, or for streamed responses, the concatenated content and tool call arguments. PreBuildState is the file the model was asked to change, so the response can be replayed without calling the model. Likely secrets are redacted from all three before they're stored.
+type BuildCapture struct {
+	Id            string            `json:"id"`
+	PlanBuildId   string            `json:"planBuildId"`
+	Path          string            `json:"path"`
+	Phase         BuildCapturePhase `json:"phase"`
+	ModelName     string            `json:"modelName"`
+	Request       string            `json:"request,omitempty"`
+	Response      string            `json:"response,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	PreBuildState string            `json:"preBuildState,omitempty"`
+	StartedAt     time.Time         `json:"startedAt"`
+	CreatedAt     time.Time         `json:"createdAt"`
+}
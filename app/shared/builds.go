@@ -0,0 +1,27 @@
+package shared
+
+import "time"
+
+type FileBuildStatus string
+
+const (
+	FileBuildStatusQueued   FileBuildStatus = "queued"
+	FileBuildStatusBuilding FileBuildStatus = "building"
+	FileBuildStatusFinished FileBuildStatus = "finished"
+	FileBuildStatusFailed   FileBuildStatus = "failed"
+	FileBuildStatusStopped  FileBuildStatus = "stopped"
+)
+
+// FileBuild is a build of a single file listed by 'plandex builds'. Queued builds haven't started yet, so they have no id or start time. NumTokens is the number of tokens in the builder model's final response, or in its response so far for a build that's running.
+type FileBuild struct {
+	Id             string          `json:"id,omitempty"`
+	Path           string          `json:"path"`
+	ConvoMessageId string          `json:"convoMessageId"`
+	IsVerification bool            `json:"isVerification"`
+	Status         FileBuildStatus `json:"status"`
+	NumRetries     int             `json:"numRetries"`
+	NumTokens      int             `json:"numTokens"`
+	Error          string          `json:"error,omitempty"`
+	StartedAt      *time.Time      `json:"startedAt,omitempty"`
+	FinishedAt     *time.Time      `json:"finishedAt,omitempty"`
+}
@@ -0,0 +1,32 @@
+package shared
+
+import "time"
+
+// Chats started with 'plandex chat' aren't stored on the server. The CLI keeps each project's chat locally and sends its history with every prompt.
+
+type ChatMessageRole string
+
+const (
+	ChatMessageRoleUser      ChatMessageRole = "user"
+	ChatMessageRoleAssistant ChatMessageRole = "assistant"
+)
+
+type ChatMessage struct {
+	Role      ChatMessageRole `json:"role"`
+	Content   string          `json:"content"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// ChatContextFile is a file the user added to a chat. Its body is read from disk each time the chat is sent, so it's always current.
+type ChatContextFile struct {
+	Path string `json:"path"`
+	Body string `json:"body"`
+}
+
+type ChatRequest struct {
+	Messages    []*ChatMessage     `json:"messages"`
+	Context     []*ChatContextFile `json:"context"`
+	ApiKeys     map[string]string  `json:"apiKeys"`
+	OpenAIBase  string             `json:"openAIBase"`
+	OpenAIOrgId string             `json:"openAIOrgId"`
+}
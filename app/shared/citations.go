@@ -0,0 +1,55 @@
+package shared
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Citation is a reference the planner makes to lines of a file in context, written like '[[cite:server/auth.go:42-58]]' or '[[cite:server/auth.go:42]]'. Lines are 1-indexed and inclusive.
+type Citation struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	Raw       string `json:"raw"`
+}
+
+var citationRegex = regexp.MustCompile(`\[\[cite:([^\]\s]+?):(\d+)(?:-(\d+))?\]\]`)
+
+// Location returns the citation as 'path:start' or 'path:start-end'
+func (c *Citation) Location() string {
+	if c.EndLine == c.StartLine {
+		return fmt.Sprintf("%s:%d", c.Path, c.StartLine)
+	}
+	return fmt.Sprintf("%s:%d-%d", c.Path, c.StartLine, c.EndLine)
+}
+
+// ParseCitations returns the citations in a reply, in the order they appear. A citation whose range ends before it starts is kept as written, so it can be reported as invalid.
+func ParseCitations(text string) []*Citation {
+	var res []*Citation
+	for _, match := range citationRegex.FindAllStringSubmatch(text, -1) {
+		res = append(res, citationFromMatch(match))
+	}
+	return res
+}
+
+// ReplaceCitations replaces each citation in a reply with the result of fn
+func ReplaceCitations(text string, fn func(c *Citation) string) string {
+	return citationRegex.ReplaceAllStringFunc(text, func(raw string) string {
+		return fn(citationFromMatch(citationRegex.FindStringSubmatch(raw)))
+	})
+}
+
+func citationFromMatch(match []string) *Citation {
+	start, _ := strconv.Atoi(match[2])
+	end := start
+	if match[3] != "" {
+		end, _ = strconv.Atoi(match[3])
+	}
+	return &Citation{
+		Path:      match[1],
+		StartLine: start,
+		EndLine:   end,
+		Raw:       match[0],
+	}
+}
@@ -0,0 +1,135 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CodeScanRule is a check run on each file result after it's built
+type CodeScanRule string
+
+const (
+	// a license header for a banned license that wasn't already in the file
+	CodeScanRuleLicense CodeScanRule = "license"
+	// a large block copied verbatim from another file or url in context
+	CodeScanRuleVerbatimContext CodeScanRule = "verbatim-context"
+	// a large block copied verbatim from a file in the server's scan corpus
+	CodeScanRuleVerbatimCorpus CodeScanRule = "verbatim-corpus"
+	// a dependency version with known vulnerabilities added to a manifest, checked when the server has advisories turned on
+	CodeScanRuleVulnerableDependency CodeScanRule = "vulnerable-dependency"
+)
+
+var CodeScanRuleDescriptions = map[CodeScanRule]string{
+	CodeScanRuleLicense:              "Banned license header",
+	CodeScanRuleVerbatimContext:      "Copied from context",
+	CodeScanRuleVerbatimCorpus:       "Copied from scan corpus",
+	CodeScanRuleVulnerableDependency: "Vulnerable dependency",
+}
+
+const DefaultCodeScanMinVerbatimLines = 20
+const MinCodeScanVerbatimLines = 5
+
+var DefaultCodeScanBannedLicenses = []string{
+	"GPL",
+	"AGPL",
+	"LGPL",
+	"SSPL",
+	"GNU General Public License",
+	"GNU Affero General Public License",
+	"GNU Lesser General Public License",
+	"Server Side Public License",
+}
+
+// OrgCodeScanPolicy controls scanning of generated code for license and copying issues. It's off by default. When it's on, each file result is scanned after it's built, and results with findings are flagged for review before they're applied.
+type OrgCodeScanPolicy struct {
+	Enabled bool `json:"enabled"`
+
+	// license names or identifiers, like 'GPL' or 'GNU General Public License'. They're matched as whole words, ignoring case, on lines that mention a license.
+	BannedLicenses []string `json:"bannedLicenses"`
+
+	// blocks of at least this many lines, not counting blank lines and lone brackets, are flagged when they match a source verbatim
+	MinVerbatimLines int `json:"minVerbatimLines"`
+
+	// whether blocks are matched against the plan's other context
+	MatchContext bool `json:"matchContext"`
+
+	// whether blocks are matched against the files in the server's PLANDEX_CODE_SCAN_CORPUS_DIR
+	MatchCorpus bool `json:"matchCorpus"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func DefaultOrgCodeScanPolicy() *OrgCodeScanPolicy {
+	return &OrgCodeScanPolicy{
+		BannedLicenses:   append([]string{}, DefaultCodeScanBannedLicenses...),
+		MinVerbatimLines: DefaultCodeScanMinVerbatimLines,
+		MatchContext:     true,
+		MatchCorpus:      true,
+	}
+}
+
+func (p *OrgCodeScanPolicy) Validate() error {
+	if p.MinVerbatimLines < MinCodeScanVerbatimLines {
+		return fmt.Errorf("min verbatim lines must be at least %d", MinCodeScanVerbatimLines)
+	}
+
+	for _, license := range p.BannedLicenses {
+		if strings.TrimSpace(license) == "" {
+			return fmt.Errorf("banned licenses can't be blank")
+		}
+		if strings.Contains(license, "\n") {
+			return fmt.Errorf("banned license '%s' can't contain a newline", license)
+		}
+	}
+
+	return nil
+}
+
+type UpdateOrgCodeScanPolicyRequest struct {
+	Policy *OrgCodeScanPolicy `json:"policy"`
+}
+
+type GetOrgCodeScanPolicyResponse struct {
+	Policy *OrgCodeScanPolicy `json:"policy"`
+	// number of files in the server's scan corpus, which is 0 when it doesn't have one
+	NumCorpusFiles int `json:"numCorpusFiles"`
+}
+
+// CodeScanFinding is a part of a file result flagged by a code scan rule. Lines are 1-based lines of the result's content.
+type CodeScanFinding struct {
+	Rule      CodeScanRule `json:"rule"`
+	StartLine int          `json:"startLine"`
+	EndLine   int          `json:"endLine"`
+	// the banned license that matched, the source a verbatim block matched, or the vulnerable dependency and its advisories
+	Match string `json:"match"`
+}
+
+func (f *CodeScanFinding) Lines() string {
+	if f.StartLine == f.EndLine {
+		return fmt.Sprintf("line %d", f.StartLine)
+	}
+	return fmt.Sprintf("lines %d-%d", f.StartLine, f.EndLine)
+}
+
+// PendingScanFindingsByPath returns the findings for the latest pending result of each path, which is the result whose content is applied. Paths without findings are left out.
+func (state *CurrentPlanState) PendingScanFindingsByPath() map[string][]*CodeScanFinding {
+	res := map[string][]*CodeScanFinding{}
+	if state.PlanResult == nil {
+		return res
+	}
+
+	for path, results := range state.PlanResult.FileResultsByPath {
+		var latest *PlanFileResult
+		for _, result := range results {
+			if result.IsPending() {
+				latest = result
+			}
+		}
+		if latest != nil && len(latest.ScanFindings) > 0 {
+			res[path] = latest.ScanFindings
+		}
+	}
+
+	return res
+}
@@ -1,14 +1,76 @@
 package shared
 
 import (
+	"bytes"
 	"fmt"
 	"math"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/olekukonko/tablewriter"
 )
 
+// Guards against accidentally loading huge vendored trees or generated files into context.
+// These are enforced by the CLI when collecting paths and again by the server when a load request is received.
+const (
+	MaxContextBodySize  = 5 * 1024 * 1024  // 5MB per piece of context
+	MaxContextTotalSize = 50 * 1024 * 1024 // 50MB per load request
+	MaxContextCount     = 1000             // pieces of context per load request
+)
+
+// Piped command output past this many tokens is trimmed to its end, since the end of build or test output is usually what matters
+const MaxPipedDataTokens = 60000
+
+// room left for the line that says how many lines were trimmed
+const pipedDataTrimmedLineTokens = 20
+
+// TrimPipedData trims piped command output to its last MaxPipedDataTokens tokens, starting at a line boundary, with a line at the top that says how many lines were trimmed. The CLI trims output before it's loaded, and the server trims output from clients that don't, with the same limit. It returns the number of lines trimmed.
+func TrimPipedData(data string) (string, int, error) {
+	res, err := TruncateToTokens(data, MaxPipedDataTokens-pipedDataTrimmedLineTokens, TokenizerCl100k, TruncateKeepEnd)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if !res.Truncated {
+		return data, 0, nil
+	}
+
+	return fmt.Sprintf("[%d earlier lines trimmed]\n", res.NumLinesRemoved) + res.Text, res.NumLinesRemoved, nil
+}
+
+// number of bytes to check when determining whether a file is binary
+const binarySniffLen = 8000
+
+func IsBinaryContent(content []byte) bool {
+	sniff := content
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+	}
+
+	if bytes.IndexByte(sniff, 0) != -1 {
+		return true
+	}
+
+	// trim a possibly truncated multi-byte rune at the end of the sniffed bytes
+	for i := 0; i < utf8.UTFMax && len(sniff) > 0; i++ {
+		if utf8.Valid(sniff) {
+			return false
+		}
+		sniff = sniff[:len(sniff)-1]
+	}
+
+	return !utf8.Valid(sniff)
+}
+
+// Encodings of files loaded into context that aren't UTF-8. Bodies are always converted to UTF-8 for the model, and the CLI converts changes back to the file's encoding when they're applied.
+const (
+	FileEncodingUTF16LE  = "utf-16le"
+	FileEncodingUTF16BE  = "utf-16be"
+	FileEncodingShiftJIS = "shift_jis"
+	FileEncodingLatin1   = "windows-1252"
+)
+
 type ContextUpdateResult struct {
 	UpdatedContexts []*Context
 	TokenDiffsById  map[string]int
@@ -0,0 +1,93 @@
+package shared
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// OrgContextBundle is a set of documents, like style guides, architecture docs, or shared type definitions, that's attached to every new plan in an org. Each update bumps its version, and existing plans are synced to the latest versions on request.
+type OrgContextBundle struct {
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Version     int                     `json:"version"`
+	Files       []*OrgContextBundleFile `json:"files"`
+	UpdatedAt   time.Time               `json:"updatedAt"`
+}
+
+// OrgContextBundleFile is a document in a bundle. Listing bundles leaves out the bodies.
+type OrgContextBundleFile struct {
+	Name      string `json:"name"`
+	Body      string `json:"body,omitempty"`
+	NumTokens int    `json:"numTokens"`
+}
+
+const MaxContextBundleFiles = 100
+
+var contextBundleNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+func ValidateContextBundleName(name string) error {
+	if !contextBundleNameRegex.MatchString(name) {
+		return fmt.Errorf("bundle names can only have letters, numbers, '.', '_', and '-', like 'style-guide'")
+	}
+	return nil
+}
+
+func (b *OrgContextBundle) Validate() error {
+	err := ValidateContextBundleName(b.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(b.Files) == 0 {
+		return fmt.Errorf("a bundle needs at least one file")
+	}
+	if len(b.Files) > MaxContextBundleFiles {
+		return fmt.Errorf("a bundle can have at most %d files", MaxContextBundleFiles)
+	}
+
+	seen := map[string]bool{}
+	totalSize := 0
+	for _, file := range b.Files {
+		if strings.TrimSpace(file.Name) == "" {
+			return fmt.Errorf("bundle file names can't be blank")
+		}
+		if seen[file.Name] {
+			return fmt.Errorf("%s is in the bundle more than once", file.Name)
+		}
+		seen[file.Name] = true
+
+		if len(file.Body) > MaxContextBodySize {
+			return fmt.Errorf("%s is larger than the %d MB limit for context", file.Name, MaxContextBodySize/1024/1024)
+		}
+		totalSize += len(file.Body)
+	}
+
+	if totalSize > MaxContextTotalSize {
+		return fmt.Errorf("the bundle's files are larger than the %d MB limit for a context load", MaxContextTotalSize/1024/1024)
+	}
+
+	return nil
+}
+
+// ContextName is the name of a bundle file's context in a plan
+func (b *OrgContextBundle) ContextName(file *OrgContextBundleFile) string {
+	return b.Name + "/" + file.Name
+}
+
+type UpdateOrgContextBundleRequest struct {
+	Bundle *OrgContextBundle `json:"bundle"`
+}
+
+type SyncContextBundlesRequest struct {
+	// bundles to attach to the plan if it doesn't have them yet
+	Attach []string `json:"attach"`
+}
+
+type SyncContextBundlesResponse struct {
+	// the names of the bundles whose contexts were added or updated
+	Synced      []string `json:"synced"`
+	TokensDiff  int      `json:"tokensDiff"`
+	TotalTokens int      `json:"totalTokens"`
+}
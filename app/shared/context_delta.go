@@ -0,0 +1,132 @@
+package shared
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// A context delta describes an updated context body in terms of the previous body, so a small edit to a large file only transfers the changed lines. Bodies are split into lines (keeping line endings); each op either copies a run of lines from the base body or inserts new text.
+
+type ContextDeltaOp struct {
+	// copy NumLines lines from the base body starting at line Start
+	Start    int `json:"s,omitempty"`
+	NumLines int `json:"n,omitempty"`
+
+	Insert string `json:"i,omitempty"`
+}
+
+type ContextDelta struct {
+	BaseSha string           `json:"baseSha"`
+	Sha     string           `json:"sha"`
+	Ops     []ContextDeltaOp `json:"ops"`
+}
+
+// lines shorter than this don't start a new copy op on their own, since matching them elsewhere in the file would just fragment the delta
+const minDeltaAnchorLen = 8
+
+func ContextSha(body string) string {
+	hash := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(hash[:])
+}
+
+func ComputeContextDelta(base, updated string) *ContextDelta {
+	baseLines := strings.SplitAfter(base, "\n")
+	updatedLines := strings.SplitAfter(updated, "\n")
+
+	linesByContent := map[string][]int{}
+	for i, line := range baseLines {
+		linesByContent[line] = append(linesByContent[line], i)
+	}
+
+	delta := &ContextDelta{
+		BaseSha: ContextSha(base),
+		Sha:     ContextSha(updated),
+	}
+
+	var insert strings.Builder
+	var current *ContextDeltaOp
+
+	flushInsert := func() {
+		if insert.Len() > 0 {
+			delta.Ops = append(delta.Ops, ContextDeltaOp{Insert: insert.String()})
+			insert.Reset()
+		}
+	}
+
+	flushCopy := func() {
+		if current != nil {
+			delta.Ops = append(delta.Ops, *current)
+			current = nil
+		}
+	}
+
+	for _, line := range updatedLines {
+		if line == "" {
+			continue
+		}
+
+		// extend the current copy if the next base line matches
+		if current != nil {
+			next := current.Start + current.NumLines
+			if next < len(baseLines) && baseLines[next] == line {
+				current.NumLines++
+				continue
+			}
+			flushCopy()
+		}
+
+		candidates := linesByContent[line]
+		if len(candidates) > 0 && len(line) >= minDeltaAnchorLen {
+			flushInsert()
+			current = &ContextDeltaOp{Start: candidates[0], NumLines: 1}
+			continue
+		}
+
+		insert.WriteString(line)
+	}
+
+	flushCopy()
+	flushInsert()
+
+	return delta
+}
+
+func ApplyContextDelta(base string, delta *ContextDelta) (string, error) {
+	if ContextSha(base) != delta.BaseSha {
+		return "", fmt.Errorf("base sha mismatch")
+	}
+
+	baseLines := strings.SplitAfter(base, "\n")
+
+	var res strings.Builder
+	for _, op := range delta.Ops {
+		if op.NumLines > 0 {
+			if op.Start < 0 || op.Start+op.NumLines > len(baseLines) {
+				return "", fmt.Errorf("copy op out of range")
+			}
+			for _, line := range baseLines[op.Start : op.Start+op.NumLines] {
+				res.WriteString(line)
+			}
+		} else {
+			res.WriteString(op.Insert)
+		}
+	}
+
+	updated := res.String()
+	if ContextSha(updated) != delta.Sha {
+		return "", fmt.Errorf("updated sha mismatch")
+	}
+
+	return updated, nil
+}
+
+// DeltaSize estimates the encoded size of a delta for deciding whether it's worth sending instead of the full body
+func (delta *ContextDelta) DeltaSize() int {
+	size := len(delta.BaseSha) + len(delta.Sha)
+	for _, op := range delta.Ops {
+		size += len(op.Insert) + 24
+	}
+	return size
+}
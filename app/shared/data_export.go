@@ -0,0 +1,86 @@
+package shared
+
+import "time"
+
+const DataExportFormatVersion = 1
+
+// DataExport is the documented format for org and user data exports (see the self-hosting docs). An org export includes every plan in the org. A user export includes the user's own plans in the org.
+type DataExport struct {
+	FormatVersion int             `json:"formatVersion"`
+	ExportedAt    time.Time       `json:"exportedAt"`
+	Org           *Org            `json:"org"`
+	Users         []*User         `json:"users"`
+	OrgUsers      []*OrgUser      `json:"orgUsers"`
+	Invites       []*Invite       `json:"invites"`
+	Projects      []*Project      `json:"projects"`
+	Plans         []*PlanExport   `json:"plans"`
+	Usage         DataExportUsage `json:"usage"`
+
+	// org-wide policies are only included in org exports
+	TrustPolicy          *TrustPolicy                `json:"trustPolicy,omitempty"`
+	ProjectTrustPolicies []*ProjectTrustPolicyExport `json:"projectTrustPolicies,omitempty"`
+	ResponseCachePolicy  *OrgResponseCachePolicy     `json:"responseCachePolicy,omitempty"`
+
+	// a user export only includes the entries the user created
+	KnowledgeEntries []*KnowledgeEntryExport `json:"knowledgeEntries,omitempty"`
+
+	// a user export only includes the user's own preferences and feedback, not the org's preferences
+	LearnedPreferences []*LearnedPreferencesExport `json:"learnedPreferences,omitempty"`
+	ChangeFeedback     []*ChangeFeedbackExport     `json:"changeFeedback,omitempty"`
+
+	// for each exported user who has changed their digest settings
+	DigestSettings []*UserDigestSettingsExport `json:"digestSettings,omitempty"`
+}
+
+type ProjectTrustPolicyExport struct {
+	ProjectId string       `json:"projectId"`
+	Policy    *TrustPolicy `json:"policy"`
+}
+
+type KnowledgeEntryExport struct {
+	UserId string          `json:"userId"`
+	Entry  *KnowledgeEntry `json:"entry"`
+}
+
+type LearnedPreferencesExport struct {
+	// empty for the org's preferences
+	UserId      string              `json:"userId,omitempty"`
+	Preferences *LearnedPreferences `json:"preferences"`
+}
+
+// ChangeFeedbackExport is a rejection or edit that preferences are learned from. Feedback is deleted once it's been distilled into both the user's and the org's preferences.
+type ChangeFeedbackExport struct {
+	UserId    string             `json:"userId"`
+	PlanId    string             `json:"planId"`
+	Kind      ChangeFeedbackKind `json:"kind"`
+	Path      string             `json:"path"`
+	Body      string             `json:"body"`
+	CreatedAt time.Time          `json:"createdAt"`
+}
+
+type UserDigestSettingsExport struct {
+	UserId   string              `json:"userId"`
+	Settings *UserDigestSettings `json:"settings"`
+}
+
+type PlanExport struct {
+	Plan     *Plan           `json:"plan"`
+	Settings *PlanSettings   `json:"settings"`
+	Branches []*BranchExport `json:"branches"`
+}
+
+type BranchExport struct {
+	Branch       *Branch         `json:"branch"`
+	Conversation []*ConvoMessage `json:"conversation"`
+	Contexts     []*Context      `json:"contexts"`
+}
+
+// DataExportUsage totals usage across all exported plans
+type DataExportUsage struct {
+	NumPlans      int `json:"numPlans"`
+	NumBranches   int `json:"numBranches"`
+	NumMessages   int `json:"numMessages"`
+	TotalReplies  int `json:"totalReplies"`
+	ContextTokens int `json:"contextTokens"`
+	ConvoTokens   int `json:"convoTokens"`
+}
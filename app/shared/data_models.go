@@ -30,15 +30,17 @@ type OrgUser struct {
 }
 
 type Invite struct {
-	Id         string     `json:"id"`
-	OrgId      string     `json:"orgId"`
-	Email      string     `json:"email"`
-	Name       string     `json:"name"`
-	OrgRoleId  string     `json:"orgRoleId"`
-	InviterId  string     `json:"inviterId"`
-	InviteeId  *string    `json:"inviteeId"`
-	AcceptedAt *time.Time `json:"acceptedAt"`
-	CreatedAt  time.Time  `json:"createdAt"`
+	Id          string     `json:"id"`
+	OrgId       string     `json:"orgId"`
+	Email       string     `json:"email"`
+	Name        string     `json:"name"`
+	OrgRoleId   string     `json:"orgRoleId"`
+	InviterId   string     `json:"inviterId"`
+	InviteeId   *string    `json:"inviteeId"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	EmailSentAt *time.Time `json:"emailSentAt,omitempty"`
+	AcceptedAt  *time.Time `json:"acceptedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
 }
 
 type Project struct {
@@ -55,8 +57,14 @@ type Plan struct {
 	TotalReplies    int        `json:"totalReplies"`
 	ActiveBranches  int        `json:"activeBranches"`
 	ArchivedAt      *time.Time `json:"archivedAt,omitempty"`
+	Tags            []string   `json:"tags,omitempty"`
 	CreatedAt       time.Time  `json:"createdAt"`
 	UpdatedAt       time.Time  `json:"updatedAt"`
+
+	// set when the current user can access the plan because it was shared with them directly
+	SharedAccess PlanShareAccess `json:"sharedAccess,omitempty"`
+	// set for plans shared with the current user from another org
+	OrgName string `json:"orgName,omitempty"`
 }
 
 type Branch struct {
@@ -97,8 +105,15 @@ type Context struct {
 	Body            string                `json:"body,omitempty"`
 	ForceSkipIgnore bool                  `json:"forceSkipIgnore"`
 	ImageDetail     openai.ImageURLDetail `json:"imageDetail,omitempty"`
-	CreatedAt       time.Time             `json:"createdAt"`
-	UpdatedAt       time.Time             `json:"updatedAt"`
+	// exact o200k count, for GPT-4o -- 0 for contexts stored before o200k counts were
+	NumTokensO200k int `json:"numTokensO200k,omitempty"`
+	// the file's encoding if it isn't UTF-8, like FileEncodingShiftJIS
+	Encoding string `json:"encoding,omitempty"`
+	// the org context bundle the context was attached from, and the bundle's version when it was
+	BundleName    string    `json:"bundleName,omitempty"`
+	BundleVersion int       `json:"bundleVersion,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
 }
 
 type ConvoMessage struct {
@@ -110,6 +125,10 @@ type ConvoMessage struct {
 	Message   string    `json:"message"`
 	Stopped   bool      `json:"stopped"`
 	CreatedAt time.Time `json:"createdAt"`
+
+	// set when an earlier prompt was edited—stale messages are replayed or dropped by 'plandex rerun'
+	EditedAt *time.Time `json:"editedAt,omitempty"`
+	Stale    bool       `json:"stale,omitempty"`
 }
 
 type ConvoSummary struct {
@@ -129,6 +148,8 @@ type ConvoMessageDescription struct {
 	MadePlan              bool            `json:"madePlan"`
 	CommitMsg             string          `json:"commitMsg"`
 	Files                 []string        `json:"files"`
+	Renames               []*SymbolRename `json:"renames,omitempty"`
+	RemovedFiles          []string        `json:"removedFiles,omitempty"`
 	DidBuild              bool            `json:"didBuild"`
 	BuildPathsInvalidated map[string]bool `json:"buildPathsInvalidated"`
 	Error                 string          `json:"error"`
@@ -138,12 +159,17 @@ type ConvoMessageDescription struct {
 }
 
 type PlanBuild struct {
-	Id             string    `json:"id"`
-	ConvoMessageId string    `json:"convoMessageId"`
-	FilePath       string    `json:"filePath"`
-	Error          string    `json:"error"`
-	CreatedAt      time.Time `json:"createdAt"`
-	UpdatedAt      time.Time `json:"updatedAt"`
+	Id             string     `json:"id"`
+	ConvoMessageId string     `json:"convoMessageId"`
+	FilePath       string     `json:"filePath"`
+	Branch         string     `json:"branch"`
+	IsVerification bool       `json:"isVerification"`
+	NumRetries     int        `json:"numRetries"`
+	NumTokens      int        `json:"numTokens"`
+	Error          string     `json:"error"`
+	FinishedAt     *time.Time `json:"finishedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
 }
 
 type Replacement struct {
@@ -154,6 +180,16 @@ type Replacement struct {
 	Failed         bool                        `json:"failed"`
 	RejectedAt     *time.Time                  `json:"rejectedAt,omitempty"`
 	StreamedChange *StreamedChangeWithLineNums `json:"streamedChange"`
+	// Anchor is where the replacement was placed when it was built. It's nil for replacements built before anchors were added.
+	Anchor *ReplacementAnchor `json:"anchor,omitempty"`
+}
+
+// ReplacementAnchor pins a replacement to the lines it replaced in the file it was built against, with hashes of the lines just before and after them
+type ReplacementAnchor struct {
+	StartLine       int    `json:"startLine"`
+	EndLine         int    `json:"endLine"`
+	PreContextHash  string `json:"preContextHash"`
+	PostContextHash string `json:"postContextHash"`
 }
 
 type PlanFileResult struct {
@@ -165,6 +201,7 @@ type PlanFileResult struct {
 	Path                string         `json:"path"`
 	Content             string         `json:"content"`
 	AnyFailed           bool           `json:"anyFailed"`
+	Summary             string         `json:"summary,omitempty"`
 	AppliedAt           *time.Time     `json:"appliedAt,omitempty"`
 	RejectedAt          *time.Time     `json:"rejectedAt,omitempty"`
 	Replacements        []*Replacement `json:"replacements"`
@@ -177,6 +214,26 @@ type PlanFileResult struct {
 	IsSyntaxFix bool `json:"isSyntaxFix"`
 	IsOtherFix  bool `json:"isOtherFix"`
 
+	// built from a reply that came after an edited prompt
+	Stale bool `json:"stale,omitempty"`
+
+	Provenance *PlanFileResultProvenance `json:"provenance,omitempty"`
+
+	// set when the org's code scan flags the result for review before it's applied
+	ScanFindings []*CodeScanFinding `json:"scanFindings,omitempty"`
+
+	// set when the result adds or updates tests for the changes to another file, with test scaffolding on
+	TestSourcePath string `json:"testSourcePath,omitempty"`
+
+	// set when the result updates a doc for the changes to another file's public API, with doc sync on
+	DocSyncSourcePath string `json:"docSyncSourcePath,omitempty"`
+
+	// set when the result removes the file, which the planner declared instead of building changes
+	RemovedFile bool `json:"removedFile,omitempty"`
+
+	// summaries of changes the builder listed that were skipped because the file already had them, like when a build is run again
+	NoOpChanges []string `json:"noOpChanges,omitempty"`
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -184,6 +241,8 @@ type PlanFileResult struct {
 type CurrentPlanFiles struct {
 	Files           map[string]string    `json:"files"`
 	UpdatedAtByPath map[string]time.Time `json:"updatedAtByPath"`
+	// Removed are the files the plan's pending changes remove, which aren't in Files
+	Removed map[string]bool `json:"removed,omitempty"`
 }
 
 type PlanFileResultsByPath map[string][]*PlanFileResult
@@ -205,6 +264,7 @@ type CurrentPlanState struct {
 type OrgRole struct {
 	Id          string `json:"id"`
 	IsDefault   bool   `json:"isDefault"`
+	Name        string `json:"name"`
 	Label       string `json:"label"`
 	Description string `json:"description"`
 }
@@ -216,6 +276,7 @@ type ModelCompatibility struct {
 	HasFunctionCalling        bool `json:"hasFunctionCalling"`
 	HasStreamingFunctionCalls bool `json:"hasStreamingFunctionCalls"`
 	HasImageSupport           bool `json:"hasImageSupport"`
+	HasStructuredOutputs      bool `json:"hasStructuredOutputs"`
 }
 
 type BaseModelConfig struct {
@@ -248,6 +309,13 @@ type ModelRoleConfig struct {
 	BaseModelConfig BaseModelConfig `json:"baseModelConfig"`
 	Temperature     float32         `json:"temperature"`
 	TopP            float32         `json:"topP"`
+
+	// optional overrides, sent to the provider only when set
+	MaxResponseTokens *int            `json:"maxResponseTokens,omitempty"`
+	PresencePenalty   *float32        `json:"presencePenalty,omitempty"`
+	FrequencyPenalty  *float32        `json:"frequencyPenalty,omitempty"`
+	Seed              *int            `json:"seed,omitempty"`
+	ReasoningEffort   ReasoningEffort `json:"reasoningEffort,omitempty"`
 }
 
 func (m *ModelRoleConfig) Scan(src interface{}) error {
@@ -305,6 +373,27 @@ type ModelPack struct {
 	// optional for backwards compatibility
 	Verifier *ModelRoleConfig `json:"verifier"`
 	AutoFix  *ModelRoleConfig `json:"autoFix"`
+
+	// only used with the speculative build strategy
+	DraftBuilder *ModelRoleConfig `json:"draftBuilder,omitempty"`
+
+	// only used by 'plandex chat'
+	Chat *ModelRoleConfig `json:"chat,omitempty"`
+}
+
+func (m *ModelPack) baseModelConfigs() []BaseModelConfig {
+	return []BaseModelConfig{
+		m.Planner.BaseModelConfig,
+		m.Builder.BaseModelConfig,
+		m.PlanSummary.BaseModelConfig,
+		m.Namer.BaseModelConfig,
+		m.CommitMsg.BaseModelConfig,
+		m.ExecStatus.BaseModelConfig,
+		m.GetVerifier().BaseModelConfig,
+		m.GetAutoFix().BaseModelConfig,
+		m.GetDraftBuilder().BaseModelConfig,
+		m.GetChat().BaseModelConfig,
+	}
 }
 
 func (m *ModelPack) GetVerifier() ModelRoleConfig {
@@ -321,6 +410,21 @@ func (m *ModelPack) GetAutoFix() ModelRoleConfig {
 	return *m.AutoFix
 }
 
+func (m *ModelPack) GetDraftBuilder() ModelRoleConfig {
+	if m.DraftBuilder == nil {
+		return m.Builder
+	}
+	return *m.DraftBuilder
+}
+
+// GetChat falls back to the summarizer, which is meant for conversation rather than code, and is usually a lighter model than the planner
+func (m *ModelPack) GetChat() ModelRoleConfig {
+	if m.Chat == nil {
+		return m.PlanSummary
+	}
+	return *m.Chat
+}
+
 type ModelOverrides struct {
 	MaxConvoTokens       *int `json:"maxConvoTokens"`
 	MaxTokens            *int `json:"maxContextTokens"`
@@ -330,7 +434,21 @@ type ModelOverrides struct {
 type PlanSettings struct {
 	ModelOverrides ModelOverrides `json:"modelOverrides"`
 	ModelPack      *ModelPack     `json:"modelPack"`
-	UpdatedAt      time.Time      `json:"updatedAt"`
+	Workspaces     []string       `json:"workspaces,omitempty"`
+	CaptureModelIO bool           `json:"captureModelIO,omitempty"`
+	// AutoContinue controls whether the planner moves on to the plan's next step on its own. Empty means always.
+	AutoContinue AutoContinuePolicy `json:"autoContinue,omitempty"`
+	// OnBuildError controls what happens to the rest of a build when a file fails to build. Empty means abort.
+	OnBuildError BuildErrorPolicy `json:"onBuildError,omitempty"`
+	// BuildStrategy controls whether builds are drafted by a cheaper model first. Empty means standard.
+	BuildStrategy BuildStrategy `json:"buildStrategy,omitempty"`
+	// ScaffoldTests queues a build of each changed file's tests after the file is built
+	ScaffoldTests bool `json:"scaffoldTests,omitempty"`
+	// SyncDocs queues builds of the docs in context that reference a file's public API after a build changes it
+	SyncDocs bool `json:"syncDocs,omitempty"`
+	// MaxBuildFileTokens is the size of the largest file the builder will attempt to update. Zero means DefaultMaxBuildFileTokens.
+	MaxBuildFileTokens int       `json:"maxBuildFileTokens,omitempty"`
+	UpdatedAt          time.Time `json:"updatedAt"`
 }
 
 func (p *PlanSettings) Scan(src interface{}) error {
@@ -0,0 +1,23 @@
+package shared
+
+// PendingDocSyncsByPath maps the path of each pending doc updated by doc sync to the file whose API changes it was updated for, from the latest pending result for the path
+func (state *CurrentPlanState) PendingDocSyncsByPath() map[string]string {
+	res := map[string]string{}
+	if state.PlanResult == nil {
+		return res
+	}
+
+	for path, results := range state.PlanResult.FileResultsByPath {
+		var latest *PlanFileResult
+		for _, result := range results {
+			if result.IsPending() {
+				latest = result
+			}
+		}
+		if latest != nil && latest.DocSyncSourcePath != "" {
+			res[path] = latest.DocSyncSourcePath
+		}
+	}
+
+	return res
+}
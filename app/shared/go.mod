@@ -4,7 +4,7 @@ go 1.21.3
 
 require (
 	github.com/davecgh/go-spew v1.1.1
-	github.com/pkoukk/tiktoken-go v0.1.6
+	github.com/pkoukk/tiktoken-go v0.1.7
 )
 
 require (
@@ -0,0 +1,65 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ImpactReportModule struct {
+	Name    string `json:"name"`
+	Changes string `json:"changes"`
+}
+
+// ImpactReport describes what a plan's pending changes affect, for reviewing them before applying or as a PR description
+type ImpactReport struct {
+	Summary        string               `json:"summary"`
+	ModulesTouched []ImpactReportModule `json:"modulesTouched"`
+	ApiChanges     []string             `json:"apiChanges"`
+	MigrationSteps []string             `json:"migrationSteps"`
+	Risks          []string             `json:"risks"`
+	CreatedAt      time.Time            `json:"createdAt"`
+}
+
+type ImpactReportRequest struct {
+	ApiKeys     map[string]string `json:"apiKeys"`
+	OpenAIBase  string            `json:"openAIBase"`
+	OpenAIOrgId string            `json:"openAIOrgId"`
+}
+
+const NoImpactReportErr string = "No impact report"
+
+// ToMarkdown formats the report for a PR description. Empty sections are left out.
+func (r *ImpactReport) ToMarkdown() string {
+	var b strings.Builder
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString(strings.TrimSpace(r.Summary) + "\n")
+
+	if len(r.ModulesTouched) > 0 {
+		b.WriteString("\n## Modules Touched\n\n")
+		for _, module := range r.ModulesTouched {
+			b.WriteString(fmt.Sprintf("- **%s**: %s\n", module.Name, strings.TrimSpace(module.Changes)))
+		}
+	}
+
+	writeList := func(title string, items []string, numbered bool) {
+		if len(items) == 0 {
+			return
+		}
+		b.WriteString("\n## " + title + "\n\n")
+		for i, item := range items {
+			bullet := "-"
+			if numbered {
+				bullet = fmt.Sprintf("%d.", i+1)
+			}
+			b.WriteString(bullet + " " + strings.TrimSpace(item) + "\n")
+		}
+	}
+
+	writeList("API Changes", r.ApiChanges, false)
+	writeList("Migration Steps", r.MigrationSteps, true)
+	writeList("Risks", r.Risks, false)
+
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package shared
+
+import (
+	"fmt"
+	"time"
+)
+
+const DefaultInviteExpirationDays = 14
+const MaxInviteExpirationDays = 90
+
+// InviteResponse is returned when an invite is created or resent. The token is only ever returned here—the server keeps just its hash—so it can be shared with the invitee when email isn't configured.
+type InviteResponse struct {
+	Id        string    `json:"id"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	EmailSent bool      `json:"emailSent"`
+}
+
+type ResendInviteRequest struct {
+	ExpiresInDays int `json:"expiresInDays,omitempty"`
+}
+
+type AcceptInviteRequest struct {
+	Token string `json:"token"`
+}
+
+type AcceptInviteResponse struct {
+	OrgId     string `json:"orgId"`
+	OrgName   string `json:"orgName"`
+	OrgRoleId string `json:"orgRoleId"`
+}
+
+// IsExpired checks whether a pending invite can no longer be accepted. Invites created before invites expired never do.
+func (invite *Invite) IsExpired(now time.Time) bool {
+	return invite.AcceptedAt == nil && invite.ExpiresAt != nil && !now.Before(*invite.ExpiresAt)
+}
+
+func GetInviteExpiresAt(expiresInDays int, now time.Time) (time.Time, error) {
+	if expiresInDays == 0 {
+		expiresInDays = DefaultInviteExpirationDays
+	}
+
+	if expiresInDays < 0 || expiresInDays > MaxInviteExpirationDays {
+		return time.Time{}, fmt.Errorf("invites must expire in 1 to %d days", MaxInviteExpirationDays)
+	}
+
+	return now.AddDate(0, 0, expiresInDays), nil
+}
@@ -0,0 +1,33 @@
+package shared
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCanceled  JobStatus = "canceled"
+)
+
+// Job is a background job run by the server's job runner, as listed by the admin jobs endpoints
+type Job struct {
+	Id          string     `json:"id"`
+	Kind        string     `json:"kind"`
+	OrgId       string     `json:"orgId,omitempty"`
+	Status      JobStatus  `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"maxAttempts"`
+	LastError   string     `json:"lastError,omitempty"`
+	WorkerIp    string     `json:"workerIp,omitempty"`
+	RunAt       time.Time  `json:"runAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+type ListJobsResponse struct {
+	Jobs []*Job `json:"jobs"`
+}
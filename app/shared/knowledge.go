@@ -0,0 +1,120 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type KnowledgeKind string
+
+const (
+	KnowledgeKindNote        KnowledgeKind = "note"
+	KnowledgeKindPlanSummary KnowledgeKind = "plan-summary"
+	KnowledgeKindADR         KnowledgeKind = "adr"
+)
+
+var KnowledgeKinds = []KnowledgeKind{KnowledgeKindNote, KnowledgeKindPlanSummary, KnowledgeKindADR}
+
+const (
+	MaxKnowledgeTitleChars = 200
+	MaxKnowledgeBodySize   = 256 * 1024
+
+	// the most tokens of knowledge base entries that are added to a planner prompt
+	MaxKnowledgeRetrievalTokens = 6000
+)
+
+// KnowledgeEntry is an entry in an org's knowledge base, like a note, an architecture decision record, or a past plan's summary. The planner searches the knowledge base for entries relevant to a prompt and adds them to its context.
+type KnowledgeEntry struct {
+	Id    string        `json:"id"`
+	Kind  KnowledgeKind `json:"kind"`
+	Title string        `json:"title"`
+	Body  string        `json:"body,omitempty"`
+	// the plan a plan summary was saved from
+	SourcePlanId string `json:"sourcePlanId,omitempty"`
+	NumTokens    int    `json:"numTokens"`
+	// entries without an embedding are matched by keyword until they're embedded
+	HasEmbedding bool      `json:"hasEmbedding"`
+	CreatedBy    string    `json:"createdBy"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func ParseKnowledgeKind(s string) (KnowledgeKind, error) {
+	for _, kind := range KnowledgeKinds {
+		if string(kind) == s {
+			return kind, nil
+		}
+	}
+
+	var names []string
+	for _, kind := range KnowledgeKinds {
+		names = append(names, string(kind))
+	}
+	return "", fmt.Errorf("unknown knowledge kind %q, expected one of: %s", s, strings.Join(names, ", "))
+}
+
+func (e *KnowledgeEntry) Validate() error {
+	_, err := ParseKnowledgeKind(string(e.Kind))
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(e.Title) == "" {
+		return fmt.Errorf("title can't be blank")
+	}
+	if len([]rune(e.Title)) > MaxKnowledgeTitleChars {
+		return fmt.Errorf("title can be at most %d characters", MaxKnowledgeTitleChars)
+	}
+
+	if strings.TrimSpace(e.Body) == "" {
+		return fmt.Errorf("body can't be blank")
+	}
+	if len(e.Body) > MaxKnowledgeBodySize {
+		return fmt.Errorf("body is larger than the %d KB limit", MaxKnowledgeBodySize/1024)
+	}
+
+	return nil
+}
+
+type CreateKnowledgeEntryRequest struct {
+	Entry *KnowledgeEntry `json:"entry"`
+
+	// for embedding the entry
+	ApiKeys     map[string]string `json:"apiKeys"`
+	OpenAIBase  string            `json:"openAIBase"`
+	OpenAIOrgId string            `json:"openAIOrgId"`
+}
+
+type SavePlanKnowledgeRequest struct {
+	// defaults to the plan's name
+	Title string `json:"title"`
+
+	ApiKeys     map[string]string `json:"apiKeys"`
+	OpenAIBase  string            `json:"openAIBase"`
+	OpenAIOrgId string            `json:"openAIOrgId"`
+}
+
+type SearchKnowledgeRequest struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit"`
+
+	ApiKeys     map[string]string `json:"apiKeys"`
+	OpenAIBase  string            `json:"openAIBase"`
+	OpenAIOrgId string            `json:"openAIOrgId"`
+}
+
+type KnowledgeSearchResult struct {
+	Entry *KnowledgeEntry `json:"entry"`
+	// cosine similarity for embedding matches, or the fraction of query terms found for keyword matches
+	Score float64 `json:"score"`
+	// true if the entry was matched by keyword instead of by embedding
+	IsKeywordMatch bool   `json:"isKeywordMatch"`
+	Snippet        string `json:"snippet"`
+}
+
+type SearchKnowledgeResponse struct {
+	Results []*KnowledgeSearchResult `json:"results"`
+	// false if the server couldn't embed the query, like when there's no OpenAI key, so every result is a keyword match
+	UsedEmbeddings bool `json:"usedEmbeddings"`
+}
@@ -0,0 +1,54 @@
+package shared
+
+import "path/filepath"
+
+// Lockfile is a file a package manager generates from a dependency manifest. Lockfiles are full of hashes a model can't know, so plans never edit them. They're regenerated from the manifest instead.
+type Lockfile struct {
+	Name     string
+	Manifest string
+	// regenerates the lockfile in a directory with only the manifest and lockfile, without installing packages or running install scripts. It's nil for lockfiles whose tools need the whole project or run project code to lock.
+	RegenerateCmd []string
+}
+
+var Lockfiles = []Lockfile{
+	{Name: "package-lock.json", Manifest: "package.json", RegenerateCmd: []string{"npm", "install", "--package-lock-only", "--ignore-scripts", "--no-audit", "--no-fund"}},
+	{Name: "npm-shrinkwrap.json", Manifest: "package.json", RegenerateCmd: []string{"npm", "install", "--package-lock-only", "--ignore-scripts", "--no-audit", "--no-fund"}},
+	{Name: "pnpm-lock.yaml", Manifest: "package.json", RegenerateCmd: []string{"pnpm", "install", "--lockfile-only", "--ignore-scripts"}},
+	{Name: "yarn.lock", Manifest: "package.json"},
+	{Name: "go.sum", Manifest: "go.mod", RegenerateCmd: []string{"go", "mod", "download"}},
+	{Name: "Cargo.lock", Manifest: "Cargo.toml"},
+	{Name: "poetry.lock", Manifest: "pyproject.toml"},
+	{Name: "uv.lock", Manifest: "pyproject.toml"},
+	{Name: "Pipfile.lock", Manifest: "Pipfile"},
+	{Name: "composer.lock", Manifest: "composer.json"},
+	{Name: "Gemfile.lock", Manifest: "Gemfile"},
+}
+
+// GetLockfile returns the lockfile at a path, or nil if the path isn't a lockfile
+func GetLockfile(path string) *Lockfile {
+	base := filepath.Base(path)
+	for i := range Lockfiles {
+		if Lockfiles[i].Name == base {
+			return &Lockfiles[i]
+		}
+	}
+	return nil
+}
+
+func IsLockfile(path string) bool {
+	return GetLockfile(path) != nil
+}
+
+// LockfilePaths returns the paths of the lockfiles that could be generated from a manifest, which are in the manifest's directory
+func LockfilePaths(manifestPath string) []string {
+	base := filepath.Base(manifestPath)
+	dir := filepath.Dir(manifestPath)
+
+	var paths []string
+	for _, lockfile := range Lockfiles {
+		if lockfile.Manifest == base {
+			paths = append(paths, filepath.Join(dir, lockfile.Name))
+		}
+	}
+	return paths
+}
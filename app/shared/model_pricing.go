@@ -0,0 +1,139 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ModelPricing is a model's price in USD per million tokens
+type ModelPricing struct {
+	InputPerMillion  float64 `json:"inputPerMillion"`
+	OutputPerMillion float64 `json:"outputPerMillion"`
+}
+
+func (p ModelPricing) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.InputPerMillion + float64(outputTokens)*p.OutputPerMillion) / 1_000_000
+}
+
+// modelPricingByName holds list prices for the built-in models, matched against lowercased model names in order, so more specific names must come first. Providers change prices, so a server's pricing catalog takes precedence over these.
+var modelPricingByName = []struct {
+	name    string
+	pricing ModelPricing
+}{
+	{"gpt-4o", ModelPricing{5, 15}},
+	{"gpt-4-turbo", ModelPricing{10, 30}},
+	{"gpt-4", ModelPricing{30, 60}},
+	{"gpt-3.5-turbo-1106", ModelPricing{1, 2}},
+	{"gpt-3.5-turbo", ModelPricing{0.5, 1.5}},
+	{"claude-3.5-sonnet", ModelPricing{3, 15}},
+	{"claude-3-5-sonnet", ModelPricing{3, 15}},
+	{"claude-3-opus", ModelPricing{15, 75}},
+	{"claude-3-sonnet", ModelPricing{3, 15}},
+	{"claude-3-haiku", ModelPricing{0.25, 1.25}},
+	{"gemini-pro-1.5", ModelPricing{3.5, 10.5}},
+	{"mixtral-8x22b", ModelPricing{1.2, 1.2}},
+	{"mixtral-8x7b", ModelPricing{0.6, 0.6}},
+	{"codellama-34b", ModelPricing{0.78, 0.78}},
+	{"o1-mini", ModelPricing{1.1, 4.4}},
+	{"o1-preview", ModelPricing{15, 60}},
+	{"o3-mini", ModelPricing{1.1, 4.4}},
+	{"o1", ModelPricing{15, 60}},
+}
+
+// GetModelPricing returns the built-in list price for a model, or nil for models without a known price, like custom models
+func GetModelPricing(modelName string) *ModelPricing {
+	name := strings.ToLower(modelName)
+
+	for _, p := range modelPricingByName {
+		if strings.Contains(name, p.name) {
+			pricing := p.pricing
+			return &pricing
+		}
+	}
+
+	return nil
+}
+
+type ModelPriceSource string
+
+const (
+	// set through the admin API, and never replaced by fetched prices
+	ModelPriceSourceAdmin ModelPriceSource = "admin"
+	// fetched from the server's pricing source
+	ModelPriceSourceFetched ModelPriceSource = "fetched"
+)
+
+// ModelPrice is an entry in a server's pricing catalog. An empty Provider matches the model from any provider.
+type ModelPrice struct {
+	Provider  ModelProvider `json:"provider"`
+	ModelName string        `json:"modelName"`
+	ModelPricing
+	Source    ModelPriceSource `json:"source,omitempty"`
+	UpdatedAt time.Time        `json:"updatedAt,omitempty"`
+}
+
+func (p *ModelPrice) Validate() error {
+	if strings.TrimSpace(p.ModelName) == "" {
+		return fmt.Errorf("model name is required")
+	}
+	if p.InputPerMillion < 0 || p.OutputPerMillion < 0 {
+		return fmt.Errorf("prices for %s can't be negative", p.ModelName)
+	}
+	return nil
+}
+
+// UpdateModelPricesRequest adds or replaces entries in the pricing catalog. It's also the format of the document a server fetches prices from.
+type UpdateModelPricesRequest struct {
+	Prices []*ModelPrice `json:"prices"`
+}
+
+type FetchModelPricesResponse struct {
+	NumUpdated int `json:"numUpdated"`
+}
+
+// BuildCostEstimate is the expected size of a build, worked out before any builder model calls are made. Response lengths vary and some builds need a verification or fix pass, so tokens and cost are given as ranges.
+type BuildCostEstimate struct {
+	Model           string `json:"model"`
+	NumFiles        int    `json:"numFiles"`
+	NumBuilds       int    `json:"numBuilds"`
+	InputTokensMin  int    `json:"inputTokensMin"`
+	InputTokensMax  int    `json:"inputTokensMax"`
+	OutputTokensMin int    `json:"outputTokensMin"`
+	OutputTokensMax int    `json:"outputTokensMax"`
+
+	// HasPricing is false when the builder model's price isn't known, in which case only tokens are estimated
+	HasPricing bool    `json:"hasPricing"`
+	CostMin    float64 `json:"costMin"`
+	CostMax    float64 `json:"costMax"`
+
+	// NeedsConfirmation is set when the estimate is above the trust policy's threshold, in which case the build is paused until it's resumed
+	NeedsConfirmation bool    `json:"needsConfirmation,omitempty"`
+	ConfirmAbove      float64 `json:"confirmAbove,omitempty"`
+}
+
+func (e *BuildCostEstimate) TokensLabel() string {
+	return fmt.Sprintf("%s–%s tokens", formatTokenCount(e.InputTokensMin+e.OutputTokensMin), formatTokenCount(e.InputTokensMax+e.OutputTokensMax))
+}
+
+func (e *BuildCostEstimate) CostLabel() string {
+	if !e.HasPricing {
+		return "cost unknown for " + e.Model
+	}
+	return fmt.Sprintf("%s–%s", FormatCost(e.CostMin), FormatCost(e.CostMax))
+}
+
+// FormatCost formats a USD amount, with more precision for amounts under a cent
+func FormatCost(usd float64) string {
+	if usd > 0 && usd < 0.01 {
+		return fmt.Sprintf("$%.4f", usd)
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+func formatTokenCount(n int) string {
+	if n >= 1000 {
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,226 @@
+package shared
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type ReasoningEffort string
+
+const (
+	ReasoningEffortLow    ReasoningEffort = "low"
+	ReasoningEffortMedium ReasoningEffort = "medium"
+	ReasoningEffortHigh   ReasoningEffort = "high"
+)
+
+// ModelRolePropsDasherized are the settings that can be changed for each role with 'plandex set-model [role] [prop] [value]'
+var ModelRolePropsDasherized = []string{"temperature", "top-p", "max-response-tokens", "presence-penalty", "frequency-penalty", "seed", "reasoning-effort"}
+
+var ModelRolePropDescriptions = map[string]string{
+	"temperature":         "sampling temperature (0.0 to 2.0)",
+	"top-p":               "nucleus sampling probability mass (0.0 to 1.0)",
+	"max-response-tokens": "max 🪙 in each response (blank for the model's limit)",
+	"presence-penalty":    "penalty for tokens already used at all (-2.0 to 2.0, blank for none)",
+	"frequency-penalty":   "penalty for tokens by how often they've been used (-2.0 to 2.0, blank for none)",
+	"seed":                "seed for more repeatable sampling (blank for none)",
+	"reasoning-effort":    "low, medium, or high, for reasoning models (blank for the default)",
+}
+
+var reasoningModelPattern = regexp.MustCompile(`^(?:openai/)?o[1-9](?:$|-)`)
+
+// IsReasoningModel is true for OpenAI's o-series models, which take a reasoning effort and don't support sampling settings
+func (c BaseModelConfig) IsReasoningModel() bool {
+	return reasoningModelPattern.MatchString(c.ModelName)
+}
+
+var noSystemRoleModelPattern = regexp.MustCompile(`^(?:openai/)?o1-(?:mini|preview)(?:$|-)`)
+
+// HasSystemRole is false for models that reject system messages, like o1-mini and o1-preview. Their system prompts are sent as user messages instead.
+func (c BaseModelConfig) HasSystemRole() bool {
+	return !noSystemRoleModelPattern.MatchString(c.ModelName)
+}
+
+// GetRoleConfig returns the config for a role, so it can be changed in place. The verifier, auto-fix, and draft-builder roles get their own copy of the builder config if they don't have one yet, and the chat role a copy of the summarizer config.
+func (m *ModelPack) GetRoleConfig(role ModelRole) *ModelRoleConfig {
+	switch role {
+	case ModelRolePlanner:
+		return &m.Planner.ModelRoleConfig
+	case ModelRolePlanSummary:
+		return &m.PlanSummary
+	case ModelRoleBuilder:
+		return &m.Builder
+	case ModelRoleName:
+		return &m.Namer
+	case ModelRoleCommitMsg:
+		return &m.CommitMsg
+	case ModelRoleExecStatus:
+		return &m.ExecStatus
+	case ModelRoleVerifier:
+		if m.Verifier == nil {
+			verifier := m.Builder
+			verifier.Role = ModelRoleVerifier
+			m.Verifier = &verifier
+		}
+		return m.Verifier
+	case ModelRoleAutoFix:
+		if m.AutoFix == nil {
+			autoFix := m.Builder
+			autoFix.Role = ModelRoleAutoFix
+			m.AutoFix = &autoFix
+		}
+		return m.AutoFix
+	case ModelRoleDraftBuilder:
+		if m.DraftBuilder == nil {
+			draftBuilder := m.Builder
+			draftBuilder.Role = ModelRoleDraftBuilder
+			m.DraftBuilder = &draftBuilder
+		}
+		return m.DraftBuilder
+	case ModelRoleChat:
+		if m.Chat == nil {
+			chat := m.PlanSummary
+			chat.Role = ModelRoleChat
+			m.Chat = &chat
+		}
+		return m.Chat
+	}
+	return nil
+}
+
+// SetProp parses and sets one of ModelRolePropsDasherized. A blank value clears optional settings.
+func (c *ModelRoleConfig) SetProp(prop, value string) error {
+	value = strings.TrimSpace(value)
+
+	parseFloat := func() (*float32, error) {
+		if value == "" {
+			return nil, nil
+		}
+		f, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", prop, value)
+		}
+		f32 := float32(f)
+		return &f32, nil
+	}
+
+	parseInt := func() (*int, error) {
+		if value == "" {
+			return nil, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", prop, value)
+		}
+		return &n, nil
+	}
+
+	var err error
+	switch Compact(prop) {
+	case "temperature", "topp":
+		var f *float32
+		f, err = parseFloat()
+		if err == nil && f == nil {
+			err = fmt.Errorf("%s is required", prop)
+		}
+		if err == nil {
+			if Compact(prop) == "temperature" {
+				c.Temperature = *f
+			} else {
+				c.TopP = *f
+			}
+		}
+	case "maxresponsetokens":
+		c.MaxResponseTokens, err = parseInt()
+	case "presencepenalty":
+		c.PresencePenalty, err = parseFloat()
+	case "frequencypenalty":
+		c.FrequencyPenalty, err = parseFloat()
+	case "seed":
+		c.Seed, err = parseInt()
+	case "reasoningeffort":
+		c.ReasoningEffort = ReasoningEffort(strings.ToLower(value))
+	default:
+		return fmt.Errorf("unknown setting %s", prop)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return c.Validate()
+}
+
+// Validate checks settings against the limits of the role's model and provider
+func (c ModelRoleConfig) Validate() error {
+	base := c.BaseModelConfig
+	isReasoning := base.IsReasoningModel()
+
+	if c.Temperature < 0 || c.Temperature > 2 {
+		return fmt.Errorf("%s temperature must be between 0.0 and 2.0", c.Role)
+	}
+	if c.TopP < 0 || c.TopP > 1 {
+		return fmt.Errorf("%s top-p must be between 0.0 and 1.0", c.Role)
+	}
+
+	if c.MaxResponseTokens != nil {
+		if *c.MaxResponseTokens < 1 {
+			return fmt.Errorf("%s max-response-tokens must be at least 1", c.Role)
+		}
+		if base.MaxTokens > 0 && *c.MaxResponseTokens > base.MaxTokens {
+			return fmt.Errorf("%s max-response-tokens can't be more than %s's limit of %d", c.Role, base.ModelName, base.MaxTokens)
+		}
+	}
+
+	for name, penalty := range map[string]*float32{"presence-penalty": c.PresencePenalty, "frequency-penalty": c.FrequencyPenalty} {
+		if penalty == nil {
+			continue
+		}
+		if *penalty < -2 || *penalty > 2 {
+			return fmt.Errorf("%s %s must be between -2.0 and 2.0", c.Role, name)
+		}
+		if isReasoning {
+			return fmt.Errorf("%s doesn't support %s", base.ModelName, name)
+		}
+	}
+
+	if c.ReasoningEffort != "" {
+		switch c.ReasoningEffort {
+		case ReasoningEffortLow, ReasoningEffortMedium, ReasoningEffortHigh:
+		default:
+			return fmt.Errorf("%s reasoning-effort must be low, medium, or high", c.Role)
+		}
+		if !isReasoning {
+			return fmt.Errorf("%s doesn't support reasoning-effort. It's only supported by reasoning models like o1 and o3-mini.", base.ModelName)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks every role's settings
+func (m *ModelPack) Validate() error {
+	for _, role := range AllModelRoles {
+		var config ModelRoleConfig
+		switch role {
+		case ModelRoleVerifier:
+			config = m.GetVerifier()
+		case ModelRoleAutoFix:
+			config = m.GetAutoFix()
+		case ModelRoleDraftBuilder:
+			config = m.GetDraftBuilder()
+		case ModelRoleChat:
+			config = m.GetChat()
+		default:
+			config = *m.GetRoleConfig(role)
+		}
+
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
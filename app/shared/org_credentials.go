@@ -0,0 +1,29 @@
+package shared
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// OrgCredential is a model provider API key stored by an org. Credentials are named like the environment variables they replace (e.g. OPENAI_API_KEY), and are used for any model whose ApiKeyEnvVar matches the name. The value is never returned by the API.
+type OrgCredential struct {
+	Name      string    `json:"name"`
+	CreatedBy *string   `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type SetOrgCredentialRequest struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+var orgCredentialNameRegex = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+func ValidateOrgCredentialName(name string) error {
+	if !orgCredentialNameRegex.MatchString(name) {
+		return fmt.Errorf("credential names must be uppercase environment variable names like OPENAI_API_KEY")
+	}
+	return nil
+}
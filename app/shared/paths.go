@@ -0,0 +1,21 @@
+package shared
+
+import (
+	"path"
+	"strings"
+)
+
+// NormalizeFilePath converts a file path to the form contexts are stored in: relative to the project root with forward slashes. Models often write paths with forward slashes on Windows, and Windows clients send backslashes, so both sides are normalized before paths are compared.
+func NormalizeFilePath(p string) string {
+	p = strings.ReplaceAll(strings.TrimSpace(p), "\\", "/")
+	if p == "" {
+		return ""
+	}
+	p = path.Clean(p)
+	return strings.TrimPrefix(p, "./")
+}
+
+// PathMatchKey is the key paths are matched by when the exact path isn't known, so a model's path matches a file in the project regardless of separators and case, as it would on a case-insensitive file system
+func PathMatchKey(p string) string {
+	return strings.ToLower(NormalizeFilePath(p))
+}
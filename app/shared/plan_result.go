@@ -1,6 +1,7 @@
 package shared
 
 import (
+	"sort"
 	"time"
 )
 
@@ -23,7 +24,7 @@ func (res *PlanFileResult) NumPendingReplacements() int {
 }
 
 func (res *PlanFileResult) IsPending() bool {
-	return res.AppliedAt == nil && res.RejectedAt == nil && (res.Content != "" || res.NumPendingReplacements() > 0)
+	return res.AppliedAt == nil && res.RejectedAt == nil && (res.Content != "" || res.RemovedFile || res.NumPendingReplacements() > 0)
 }
 
 func (p PlanFileResultsByPath) SetApplied(t time.Time) {
@@ -133,8 +134,8 @@ func (r PlanResult) NumPendingForPath(path string) int {
 
 func (desc *ConvoMessageDescription) NumBuildsPendingByPath() map[string]int {
 	res := map[string]int{}
-	if (!desc.DidBuild && len(desc.Files) > 0) || len(desc.BuildPathsInvalidated) > 0 {
-		for _, file := range desc.Files {
+	if (!desc.DidBuild && (len(desc.Files) > 0 || len(desc.Renames) > 0 || len(desc.RemovedFiles) > 0)) || len(desc.BuildPathsInvalidated) > 0 {
+		for _, file := range desc.BuildPaths() {
 			res[file]++
 		}
 	}
@@ -166,3 +167,18 @@ func (c *CurrentPlanState) NumBuildsPendingByPath() map[string]int {
 func (c *CurrentPlanState) HasPendingBuilds() bool {
 	return len(c.NumBuildsPendingByPath()) > 0
 }
+
+// PendingPaths returns the sorted paths of the files with pending changes, including the files the changes remove
+func (files *CurrentPlanFiles) PendingPaths() []string {
+	res := make([]string, 0, len(files.Files)+len(files.Removed))
+	for path := range files.Files {
+		res = append(res, path)
+	}
+	for path := range files.Removed {
+		if _, ok := files.Files[path]; !ok {
+			res = append(res, path)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
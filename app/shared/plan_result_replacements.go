@@ -1,6 +1,8 @@
 package shared
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"strings"
@@ -10,6 +12,8 @@ import (
 )
 
 func ApplyReplacements(content string, replacements []*Replacement, setFailed bool) (string, bool) {
+	contentLines := strings.Split(content, "\n")
+
 	apply := func(replacements []*Replacement) (string, int) {
 		updated := content
 		lastInsertedIdx := 0
@@ -26,6 +30,9 @@ func ApplyReplacements(content string, replacements []*Replacement, setFailed bo
 
 			if replacement.EntireFile {
 				originalIdx = 0
+			} else if !replacement.Anchor.Verify(contentLines) {
+				log.Println("Replacement anchor doesn't match at index:", i)
+				originalIdx = -1
 			} else {
 				originalIdx = strings.Index(sub, replacement.Old)
 			}
@@ -111,6 +118,50 @@ func ApplyReplacements(content string, replacements []*Replacement, setFailed bo
 
 }
 
+// NewReplacementAnchor returns the anchor for a replacement of lines startLine through endLine, 1-indexed, in a file split into lines. Line numbers added with AddLineNums are ignored in the hashes.
+func NewReplacementAnchor(lines []string, startLine, endLine int) *ReplacementAnchor {
+	pre, post := anchorContext(lines, startLine, endLine)
+
+	return &ReplacementAnchor{
+		StartLine:       startLine,
+		EndLine:         endLine,
+		PreContextHash:  anchorHash(pre),
+		PostContextHash: anchorHash(post),
+	}
+}
+
+// Verify checks that the anchor's lines are in the file and that the lines just before and after them are the ones the replacement was built against. A nil anchor always passes.
+func (anchor *ReplacementAnchor) Verify(lines []string) bool {
+	if anchor == nil {
+		return true
+	}
+
+	if anchor.StartLine < 1 || anchor.EndLine < anchor.StartLine || anchor.EndLine > len(lines) {
+		return false
+	}
+
+	pre, post := anchorContext(lines, anchor.StartLine, anchor.EndLine)
+
+	return anchorHash(pre) == anchor.PreContextHash && anchorHash(post) == anchor.PostContextHash
+}
+
+// anchorContext returns the lines just before and after a range, which are empty at the start and end of the file
+func anchorContext(lines []string, startLine, endLine int) (string, string) {
+	var pre, post string
+	if startLine > 1 && startLine-2 < len(lines) {
+		pre = lines[startLine-2]
+	}
+	if endLine < len(lines) {
+		post = lines[endLine]
+	}
+	return pre, post
+}
+
+func anchorHash(line string) string {
+	hash := sha256.Sum256([]byte(RemoveLineNums(line)))
+	return hex.EncodeToString(hash[:8])
+}
+
 func (planState *CurrentPlanState) GetFiles() (*CurrentPlanFiles, error) {
 	return planState.GetFilesBeforeReplacement("")
 }
@@ -123,9 +174,11 @@ func (planState *CurrentPlanState) GetFilesBeforeReplacement(
 	files := make(map[string]string)
 	shas := make(map[string]string)
 	updatedAtByPath := make(map[string]time.Time)
+	removed := make(map[string]bool)
 
 	for path, planResults := range planRes.FileResultsByPath {
 		updated := files[path]
+		isRemoved := false
 		// log.Println("path: ", path)
 
 	PlanResLoop:
@@ -138,11 +191,20 @@ func (planState *CurrentPlanState) GetFilesBeforeReplacement(
 				continue
 			}
 
+			if planRes.RemovedFile {
+				updated = ""
+				isRemoved = true
+				updatedAtByPath[path] = planRes.CreatedAt
+				continue
+			}
+
 			if len(planRes.Replacements) == 0 {
 				if updated != "" {
 					return nil, fmt.Errorf("plan updates out of order: %s", path)
 				}
 
+				// a file created after it's removed is no longer removed
+				isRemoved = false
 				updated = planRes.Content
 				files[path] = updated
 				updatedAtByPath[path] = planRes.CreatedAt
@@ -150,6 +212,8 @@ func (planState *CurrentPlanState) GetFilesBeforeReplacement(
 				// log.Println("No replacements for plan result -- creating file and continuing loop")
 
 				continue
+			} else if isRemoved {
+				return nil, fmt.Errorf("plan updates a removed file: %s", path)
 			} else if updated == "" {
 				context := planState.ContextsByPath[path]
 
@@ -218,10 +282,15 @@ func (planState *CurrentPlanState) GetFilesBeforeReplacement(
 
 		// log.Println("Setting updated content for path: ", path)
 
-		files[path] = updated
+		if isRemoved {
+			delete(files, path)
+			removed[path] = true
+		} else {
+			files[path] = updated
+		}
 	}
 
-	return &CurrentPlanFiles{Files: files, UpdatedAtByPath: updatedAtByPath}, nil
+	return &CurrentPlanFiles{Files: files, UpdatedAtByPath: updatedAtByPath, Removed: removed}, nil
 }
 
 // func getUniqueFuzzyIndex(doc, s string) int {
@@ -1,5 +1,7 @@
 package shared
 
+import "reflect"
+
 type ModelProvider string
 
 const (
@@ -7,8 +9,14 @@ const (
 	ModelProviderTogether   ModelProvider = "together"
 	ModelProviderOpenRouter ModelProvider = "openrouter"
 	ModelProviderCustom     ModelProvider = "custom"
+
+	// ModelProviderMock returns scripted responses from the server itself, for tests and offline demos. It needs no api key.
+	ModelProviderMock ModelProvider = "mock"
 )
 
+const MockBaseUrl = "mock://plandex"
+const MockApiKeyEnvVar = "PLANDEX_MOCK_API_KEY"
+
 var AllModelProviders = []string{
 	string(ModelProviderOpenAI),
 	string(ModelProviderOpenRouter),
@@ -20,37 +28,43 @@ var BaseUrlByProvider = map[ModelProvider]string{
 	ModelProviderOpenAI:     OpenAIV1BaseUrl,
 	ModelProviderTogether:   "https://api.together.xyz/v1",
 	ModelProviderOpenRouter: "https://openrouter.ai/api/v1",
+	ModelProviderMock:       MockBaseUrl,
 }
 
 var ApiKeyByProvider = map[ModelProvider]string{
 	ModelProviderOpenAI:     OpenAIEnvVar,
 	ModelProviderTogether:   "TOGETHER_API_KEY",
 	ModelProviderOpenRouter: "OPENROUTER_API_KEY",
+	ModelProviderMock:       MockApiKeyEnvVar,
 }
 
 type ModelRole string
 
 const (
-	ModelRolePlanner     ModelRole = "planner"
-	ModelRolePlanSummary ModelRole = "summarizer"
-	ModelRoleBuilder     ModelRole = "builder"
-	ModelRoleName        ModelRole = "names"
-	ModelRoleCommitMsg   ModelRole = "commit-messages"
-	ModelRoleExecStatus  ModelRole = "auto-continue"
-	ModelRoleVerifier    ModelRole = "verifier"
-	ModelRoleAutoFix     ModelRole = "auto-fix"
+	ModelRolePlanner      ModelRole = "planner"
+	ModelRolePlanSummary  ModelRole = "summarizer"
+	ModelRoleBuilder      ModelRole = "builder"
+	ModelRoleName         ModelRole = "names"
+	ModelRoleCommitMsg    ModelRole = "commit-messages"
+	ModelRoleExecStatus   ModelRole = "auto-continue"
+	ModelRoleVerifier     ModelRole = "verifier"
+	ModelRoleAutoFix      ModelRole = "auto-fix"
+	ModelRoleDraftBuilder ModelRole = "draft-builder"
+	ModelRoleChat         ModelRole = "chat"
 )
 
-var AllModelRoles = []ModelRole{ModelRolePlanner, ModelRolePlanSummary, ModelRoleBuilder, ModelRoleName, ModelRoleCommitMsg, ModelRoleExecStatus, ModelRoleVerifier, ModelRoleAutoFix}
+var AllModelRoles = []ModelRole{ModelRolePlanner, ModelRolePlanSummary, ModelRoleBuilder, ModelRoleName, ModelRoleCommitMsg, ModelRoleExecStatus, ModelRoleVerifier, ModelRoleAutoFix, ModelRoleDraftBuilder, ModelRoleChat}
 var ModelRoleDescriptions = map[ModelRole]string{
-	ModelRolePlanner:     "replies to prompts and makes plans",
-	ModelRolePlanSummary: "summarizes conversations exceeding max-convo-tokens",
-	ModelRoleBuilder:     "builds a plan into file diffs",
-	ModelRoleName:        "names plans",
-	ModelRoleCommitMsg:   "writes commit messages",
-	ModelRoleExecStatus:  "determines whether to auto-continue",
-	ModelRoleVerifier:    "verifies file correctness",
-	ModelRoleAutoFix:     "automatically fixes syntax errors",
+	ModelRolePlanner:      "replies to prompts and makes plans",
+	ModelRolePlanSummary:  "summarizes conversations and file changes in the background",
+	ModelRoleBuilder:      "builds a plan into file diffs",
+	ModelRoleName:         "names plans",
+	ModelRoleCommitMsg:    "writes commit messages",
+	ModelRoleExecStatus:   "determines whether to auto-continue",
+	ModelRoleVerifier:     "verifies file correctness",
+	ModelRoleAutoFix:      "automatically fixes syntax errors",
+	ModelRoleDraftBuilder: "drafts builds with the speculative build strategy",
+	ModelRoleChat:         "replies in chats started with 'plandex chat'",
 }
 var SettingDescriptions = map[string]string{
 	"max-convo-tokens":       "max conversation 🪙 before summarization",
@@ -100,6 +114,71 @@ func (ps PlanSettings) GetPlannerEffectiveMaxTokens() int {
 	return ps.GetPlannerMaxTokens() - ps.GetPlannerReservedOutputTokens()
 }
 
+func (ps PlanSettings) GetPlannerTokenizer() Tokenizer {
+	if ps.ModelPack == nil {
+		return DefaultModelPack.Planner.BaseModelConfig.GetTokenizer()
+	}
+	return ps.ModelPack.Planner.BaseModelConfig.GetTokenizer()
+}
+
+// GetPlannerTokens converts a stored cl100k count to the planner model's tokenizer, so it can be compared with the planner's token limits
+func (ps PlanSettings) GetPlannerTokens(baseTokens int) int {
+	return ps.GetPlannerTokenizer().FromBaseTokens(baseTokens)
+}
+
+// OverriddenRoles returns the roles whose model config differs from the org's default settings, which is how a plan or branch uses a different model for a role without changing the defaults for other plans
+func (ps PlanSettings) OverriddenRoles(defaults *PlanSettings) []ModelRole {
+	planPack := ps.ModelPack
+	if planPack == nil {
+		planPack = DefaultModelPack
+	}
+	defaultPack := DefaultModelPack
+	if defaults != nil && defaults.ModelPack != nil {
+		defaultPack = defaults.ModelPack
+	}
+
+	// copies, since GetRoleConfig fills in missing verifier and auto-fix configs
+	planCopy := *planPack
+	defaultCopy := *defaultPack
+
+	var roles []ModelRole
+	for _, role := range AllModelRoles {
+		planConfig := planCopy.GetRoleConfig(role)
+		defaultConfig := defaultCopy.GetRoleConfig(role)
+		if !reflect.DeepEqual(planConfig, defaultConfig) {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
+// OverridesOrgDefaults is true if the plan's model settings differ from the org's default settings in any way
+func (ps PlanSettings) OverridesOrgDefaults(defaults *PlanSettings) bool {
+	var defaultOverrides ModelOverrides
+	if defaults != nil {
+		defaultOverrides = defaults.ModelOverrides
+	}
+
+	return len(ps.OverriddenRoles(defaults)) > 0 || !reflect.DeepEqual(ps.ModelOverrides, defaultOverrides)
+}
+
+// UsesMockProvider is true if any role uses the mock provider
+func (ps PlanSettings) UsesMockProvider() bool {
+	ms := ps.ModelPack
+	if ms == nil {
+		return false
+	}
+
+	for _, config := range ms.baseModelConfigs() {
+		if config.Provider == ModelProviderMock {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (ps PlanSettings) GetRequiredEnvVars() map[string]bool {
 	envVars := map[string]bool{}
 
@@ -108,19 +187,118 @@ func (ps PlanSettings) GetRequiredEnvVars() map[string]bool {
 		ms = DefaultModelPack
 	}
 
-	envVars[ms.Planner.BaseModelConfig.ApiKeyEnvVar] = true
-	envVars[ms.Builder.BaseModelConfig.ApiKeyEnvVar] = true
-	envVars[ms.PlanSummary.BaseModelConfig.ApiKeyEnvVar] = true
-	envVars[ms.Namer.BaseModelConfig.ApiKeyEnvVar] = true
-	envVars[ms.CommitMsg.BaseModelConfig.ApiKeyEnvVar] = true
-	envVars[ms.ExecStatus.BaseModelConfig.ApiKeyEnvVar] = true
-	envVars[ms.GetVerifier().BaseModelConfig.ApiKeyEnvVar] = true
-	envVars[ms.GetAutoFix().BaseModelConfig.ApiKeyEnvVar] = true
+	for _, config := range ms.baseModelConfigs() {
+		// the mock provider runs on the server and doesn't need a key
+		if config.Provider == ModelProviderMock {
+			continue
+		}
+		envVars[config.ApiKeyEnvVar] = true
+	}
 
 	// for backward compatibility with <= 0.8.4 server versions
-	if len(envVars) == 0 {
+	if len(envVars) == 0 && !ps.UsesMockProvider() {
 		envVars["OPENAI_API_KEY"] = true
 	}
 
 	return envVars
 }
+
+type AutoContinuePolicy string
+
+const (
+	AutoContinueAlways AutoContinuePolicy = "always"
+	AutoContinueAsk    AutoContinuePolicy = "ask"
+	AutoContinueNever  AutoContinuePolicy = "never"
+)
+
+var AllAutoContinuePolicies = []string{
+	string(AutoContinueAlways),
+	string(AutoContinueAsk),
+	string(AutoContinueNever),
+}
+
+func (ps PlanSettings) GetAutoContinuePolicy() AutoContinuePolicy {
+	if ps.AutoContinue == "" {
+		return AutoContinueAlways
+	}
+	return ps.AutoContinue
+}
+
+func (p AutoContinuePolicy) Valid() bool {
+	switch p {
+	case "", AutoContinueAlways, AutoContinueAsk, AutoContinueNever:
+		return true
+	}
+	return false
+}
+
+type BuildErrorPolicy string
+
+const (
+	// BuildErrorAbort stops the whole plan when a file fails to build
+	BuildErrorAbort BuildErrorPolicy = "abort"
+	// BuildErrorSkip leaves a failed file unbuilt, so it can be built again later, and keeps building the other files
+	BuildErrorSkip BuildErrorPolicy = "skip"
+	// BuildErrorPause pauses the build when a file fails, then retries the file when the build is resumed
+	BuildErrorPause BuildErrorPolicy = "pause"
+)
+
+var AllBuildErrorPolicies = []string{
+	string(BuildErrorAbort),
+	string(BuildErrorSkip),
+	string(BuildErrorPause),
+}
+
+func (ps PlanSettings) GetBuildErrorPolicy() BuildErrorPolicy {
+	if ps.OnBuildError == "" {
+		return BuildErrorAbort
+	}
+	return ps.OnBuildError
+}
+
+func (p BuildErrorPolicy) Valid() bool {
+	switch p {
+	case "", BuildErrorAbort, BuildErrorSkip, BuildErrorPause:
+		return true
+	}
+	return false
+}
+
+type BuildStrategy string
+
+const (
+	// BuildStrategyStandard builds each file with the builder model, then checks the result with the verifier model
+	BuildStrategyStandard BuildStrategy = "standard"
+	// BuildStrategySpeculative drafts each file's changes with the draft-builder model, which is meant to be fast and cheap. A draft that applies cleanly without adding syntax errors is used as-is. Otherwise the file is built again with the builder model, then verified as usual.
+	BuildStrategySpeculative BuildStrategy = "speculative"
+)
+
+var AllBuildStrategies = []string{
+	string(BuildStrategyStandard),
+	string(BuildStrategySpeculative),
+}
+
+func (ps PlanSettings) GetBuildStrategy() BuildStrategy {
+	if ps.BuildStrategy == "" {
+		return BuildStrategyStandard
+	}
+	return ps.BuildStrategy
+}
+
+func (s BuildStrategy) Valid() bool {
+	switch s {
+	case "", BuildStrategyStandard, BuildStrategySpeculative:
+		return true
+	}
+	return false
+}
+
+// DefaultMaxBuildFileTokens is the size of the largest file the builder will attempt to update when a plan doesn't set its own limit. Larger files usually fail partway through the build, or are too large for the builder model's context window.
+const DefaultMaxBuildFileTokens = 50000
+
+func (ps PlanSettings) GetMaxBuildFileTokens() int {
+	if ps.MaxBuildFileTokens == 0 {
+		return DefaultMaxBuildFileTokens
+	}
+	return ps.MaxBuildFileTokens
+}
@@ -0,0 +1,37 @@
+package shared
+
+import (
+	"fmt"
+	"time"
+)
+
+// PlanShareAccess is what a user a plan is shared with can do with it. Shares can go to users outside the plan's org, who then only have access to that plan.
+type PlanShareAccess string
+
+const (
+	PlanShareAccessRead  PlanShareAccess = "read"
+	PlanShareAccessWrite PlanShareAccess = "write"
+)
+
+func (a PlanShareAccess) Validate() error {
+	if a != PlanShareAccessRead && a != PlanShareAccessWrite {
+		return fmt.Errorf("access must be '%s' or '%s'", PlanShareAccessRead, PlanShareAccessWrite)
+	}
+	return nil
+}
+
+type PlanShare struct {
+	Id        string          `json:"id"`
+	PlanId    string          `json:"planId"`
+	UserId    string          `json:"userId"`
+	UserEmail string          `json:"userEmail"`
+	UserName  string          `json:"userName"`
+	Access    PlanShareAccess `json:"access"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+type SharePlanRequest struct {
+	Email  string          `json:"email"`
+	Access PlanShareAccess `json:"access"`
+}
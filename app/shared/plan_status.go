@@ -8,7 +8,30 @@ const (
 	PlanStatusDescribing  PlanStatus = "describing"
 	PlanStatusBuilding    PlanStatus = "building"
 	PlanStatusMissingFile PlanStatus = "missingFile"
-	PlanStatusFinished    PlanStatus = "finished"
-	PlanStatusStopped     PlanStatus = "stopped"
-	PlanStatusError       PlanStatus = "error"
+	// PlanStatusPromptingContinue means the plan is waiting for the user to confirm its next step, with the 'ask' auto-continue policy
+	PlanStatusPromptingContinue PlanStatus = "promptingContinue"
+	PlanStatusFinished          PlanStatus = "finished"
+	PlanStatusStopped           PlanStatus = "stopped"
+	PlanStatusError             PlanStatus = "error"
 )
+
+var AllPlanStatuses = []PlanStatus{
+	PlanStatusDraft,
+	PlanStatusReplying,
+	PlanStatusDescribing,
+	PlanStatusBuilding,
+	PlanStatusMissingFile,
+	PlanStatusPromptingContinue,
+	PlanStatusFinished,
+	PlanStatusStopped,
+	PlanStatusError,
+}
+
+func (s PlanStatus) Valid() bool {
+	for _, status := range AllPlanStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package shared
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const MaxPlanTags = 20
+const MaxPlanTagLength = 32
+
+var planTagRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9._/:-]*$`)
+
+// NormalizePlanTag lowercases and trims a tag, then checks it only uses letters, numbers, and . _ / : - so tags are easy to type in filters
+func NormalizePlanTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	tag = strings.TrimPrefix(tag, "#")
+
+	if tag == "" {
+		return "", fmt.Errorf("tag can't be empty")
+	}
+
+	if len(tag) > MaxPlanTagLength {
+		return "", fmt.Errorf("tag '%s' is longer than %d characters", tag, MaxPlanTagLength)
+	}
+
+	if !planTagRegex.MatchString(tag) {
+		return "", fmt.Errorf("tag '%s' can only use letters, numbers, and . _ / : -", tag)
+	}
+
+	return tag, nil
+}
+
+type UpdatePlanTagsRequest struct {
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
+type PlanTagCount struct {
+	Tag      string `json:"tag"`
+	NumPlans int    `json:"numPlans"`
+}
+
+type PlanSortBy string
+
+const (
+	PlanSortByUpdated PlanSortBy = "updated"
+	PlanSortByCreated PlanSortBy = "created"
+	PlanSortByName    PlanSortBy = "name"
+)
+
+var AllPlanSorts = []PlanSortBy{PlanSortByUpdated, PlanSortByCreated, PlanSortByName}
+
+func (s PlanSortBy) Valid() bool {
+	for _, sort := range AllPlanSorts {
+		if s == sort {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanAuthorAny lists plans from any author in the org that the user can access: their own plans plus plans shared with the org
+const PlanAuthorAny = "any"
+
+// ListPlansFilter narrows and orders 'plandex plans'. Zero values keep the default listing: the user's own unarchived plans, most recently updated first. A plan matches a status if any of its branches has it, and must have every tag in Tags.
+type ListPlansFilter struct {
+	Tags          []string     `json:"tags,omitempty"`
+	Statuses      []PlanStatus `json:"statuses,omitempty"`
+	UpdatedAfter  *time.Time   `json:"updatedAfter,omitempty"`
+	UpdatedBefore *time.Time   `json:"updatedBefore,omitempty"`
+	AuthorId      string       `json:"authorId,omitempty"`
+	Archived      bool         `json:"archived,omitempty"`
+	SortBy        PlanSortBy   `json:"sortBy,omitempty"`
+	Asc           bool         `json:"asc,omitempty"`
+}
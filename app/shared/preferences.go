@@ -0,0 +1,30 @@
+package shared
+
+import "time"
+
+type ChangeFeedbackKind string
+
+const (
+	// a pending change the user rejected
+	ChangeFeedbackKindReject ChangeFeedbackKind = "reject"
+	// an applied change the user edited before it was next loaded into context
+	ChangeFeedbackKindEdit ChangeFeedbackKind = "edit"
+)
+
+// LearnedPreferences summarizes what a user, or everyone in an org, tends to reject or edit in Plandex's changes. It's distilled from their feedback by a model and added to planner and builder prompts.
+type LearnedPreferences struct {
+	Summary string `json:"summary"`
+	// how many rejections and edits it was distilled from
+	NumEvents int       `json:"numEvents"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type GetLearnedPreferencesResponse struct {
+	// nil if nothing has been learned yet
+	User *LearnedPreferences `json:"user"`
+	Org  *LearnedPreferences `json:"org"`
+
+	// rejections and edits recorded since the last time each summary was distilled
+	PendingUserEvents int `json:"pendingUserEvents"`
+	PendingOrgEvents  int `json:"pendingOrgEvents"`
+}
@@ -0,0 +1,149 @@
+package shared
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ProvenanceTrailerKey = "Plandex-Provenance"
+
+// PlanFileResultProvenance records where a file result's code came from, for audit trails of generated code. It's attached to each result when it's built, and signed by the server when a signing key is configured.
+type PlanFileResultProvenance struct {
+	// the role whose model wrote the code: the planner for new files, which are written in the plan's reply, or the builder, draft-builder, or auto-fix role
+	Role           ModelRole     `json:"role"`
+	Provider       ModelProvider `json:"provider"`
+	CustomProvider *string       `json:"customProvider,omitempty"`
+	ModelName      string        `json:"modelName"`
+
+	// hash of the messages sent to the model, so a result can be matched to its prompt without storing the prompt. Empty for new files, which come from the plan's reply.
+	PromptHash string `json:"promptHash,omitempty"`
+
+	PlanId         string `json:"planId"`
+	Branch         string `json:"branch"`
+	PlanBuildId    string `json:"planBuildId"`
+	ConvoMessageId string `json:"convoMessageId"`
+	Path           string `json:"path"`
+
+	// hash of the file's content once the result is applied
+	ContentHash string `json:"contentHash"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	Signature string `json:"signature,omitempty"`
+}
+
+// ContentHash is the format of provenance hashes: 'sha256:' followed by the hex digest
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (p *PlanFileResultProvenance) ModelLabel() string {
+	provider := string(p.Provider)
+	if p.Provider == ModelProviderCustom && p.CustomProvider != nil {
+		provider = *p.CustomProvider
+	}
+	return provider + "/" + p.ModelName
+}
+
+// SigningPayload is the canonical form of every field except the signature, which is what the signature covers
+func (p *PlanFileResultProvenance) SigningPayload() string {
+	customProvider := ""
+	if p.CustomProvider != nil {
+		customProvider = *p.CustomProvider
+	}
+
+	return strings.Join([]string{
+		"role=" + string(p.Role),
+		"provider=" + string(p.Provider),
+		"customProvider=" + customProvider,
+		"model=" + p.ModelName,
+		"prompt=" + p.PromptHash,
+		"plan=" + p.PlanId,
+		"branch=" + p.Branch,
+		"build=" + p.PlanBuildId,
+		"message=" + p.ConvoMessageId,
+		"path=" + p.Path,
+		"content=" + p.ContentHash,
+		"createdAt=" + p.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}, "\n")
+}
+
+// Trailer formats the provenance as a git commit trailer, one per file
+func (p *PlanFileResultProvenance) Trailer() string {
+	path := p.Path
+	if strings.ContainsAny(path, " ;\"\t\n") {
+		path = strconv.Quote(path)
+	}
+
+	parts := []string{
+		"path=" + path,
+		"model=" + p.ModelLabel(),
+		"role=" + string(p.Role),
+		"plan=" + p.PlanId,
+		"branch=" + p.Branch,
+		"build=" + p.PlanBuildId,
+	}
+	if p.PromptHash != "" {
+		parts = append(parts, "prompt="+p.PromptHash)
+	}
+	parts = append(parts, "content="+p.ContentHash)
+	if p.Signature != "" {
+		parts = append(parts, "sig="+p.Signature)
+	}
+
+	return fmt.Sprintf("%s: %s", ProvenanceTrailerKey, strings.Join(parts, "; "))
+}
+
+// WithProvenanceTrailers appends a provenance trailer for each file to a commit message, in its own paragraph as git expects
+func WithProvenanceTrailers(msg string, provenance []*PlanFileResultProvenance) string {
+	if len(provenance) == 0 {
+		return msg
+	}
+
+	var trailers []string
+	for _, p := range provenance {
+		trailers = append(trailers, p.Trailer())
+	}
+
+	return strings.TrimRight(msg, "\n") + "\n\n" + strings.Join(trailers, "\n")
+}
+
+type PlanProvenanceEntry struct {
+	ResultId   string                    `json:"resultId"`
+	Provenance *PlanFileResultProvenance `json:"provenance"`
+	AppliedAt  *time.Time                `json:"appliedAt,omitempty"`
+	RejectedAt *time.Time                `json:"rejectedAt,omitempty"`
+
+	// whether the signature matches the provenance, or nil if it isn't signed or the server has no signing key
+	SignatureValid *bool `json:"signatureValid,omitempty"`
+}
+
+type GetPlanProvenanceResponse struct {
+	Entries []*PlanProvenanceEntry `json:"entries"`
+	// whether the server signs provenance
+	Signing bool `json:"signing"`
+}
+
+// LatestProvenanceByPath returns the provenance of the latest pending result for each path, which is the result whose content is applied
+func (state *CurrentPlanState) LatestProvenanceByPath() map[string]*PlanFileResultProvenance {
+	res := map[string]*PlanFileResultProvenance{}
+	if state.PlanResult == nil {
+		return res
+	}
+
+	for path, results := range state.PlanResult.FileResultsByPath {
+		for _, result := range results {
+			if result.Provenance == nil || result.AppliedAt != nil || result.RejectedAt != nil {
+				continue
+			}
+			res[path] = result.Provenance
+		}
+	}
+
+	return res
+}
@@ -43,6 +43,10 @@ type SessionResponse struct {
 	Orgs     []*Org `json:"orgs"`
 }
 
+type RotateAuthTokenResponse struct {
+	Token string `json:"token"`
+}
+
 type CreateOrgRequest struct {
 	Name               string `json:"name"`
 	AutoAddDomainUsers bool   `json:"autoAddDomainUsers"`
@@ -54,6 +58,9 @@ type ConvertTrialRequest struct {
 	UserName              string `json:"userName"`
 	OrgName               string `json:"orgName"`
 	OrgAutoAddDomainUsers bool   `json:"orgAutoAddDomainUsers"`
+
+	// for guest trials in an org: move the trial's plans to the existing account with this email instead of creating one
+	ExistingAccount bool `json:"existingAccount"`
 }
 
 type CreateOrgResponse struct {
@@ -64,6 +71,9 @@ type InviteRequest struct {
 	Email     string `json:"email"`
 	Name      string `json:"name"`
 	OrgRoleId string `json:"orgRoleId"`
+
+	// defaults to DefaultInviteExpirationDays
+	ExpiresInDays int `json:"expiresInDays,omitempty"`
 }
 
 type CreateProjectRequest struct {
@@ -89,6 +99,8 @@ type CreatePlanRequest struct {
 type CreatePlanResponse struct {
 	Id   string `json:"id"`
 	Name string `json:"name"`
+	// the org context bundles that were attached to the plan
+	ContextBundles []string `json:"contextBundles,omitempty"`
 }
 
 type GetCurrentBranchByPlanIdRequest struct {
@@ -122,6 +134,17 @@ type TellPlanRequest struct {
 	OpenAIBase     string            `json:"openAIBase"`
 	OpenAIOrgId    string            `json:"openAIOrgId"`
 	ProjectPaths   map[string]bool   `json:"projectPaths"`
+
+	// Conventions is the project's conventions file, from the conventions path in .plandex/config.yml
+	Conventions string `json:"conventions,omitempty"`
+}
+
+// AskPlanRequest is a question about the plan's context. It's answered without adding to the plan's conversation or building any changes.
+type AskPlanRequest struct {
+	Question    string            `json:"question"`
+	ApiKeys     map[string]string `json:"apiKeys"`
+	OpenAIBase  string            `json:"openAIBase"`
+	OpenAIOrgId string            `json:"openAIOrgId"`
 }
 
 type BuildPlanRequest struct {
@@ -150,6 +173,17 @@ type RespondMissingFileRequest struct {
 	Body     string                   `json:"body"`
 }
 
+type RespondContinueRequest struct {
+	Continue bool `json:"continue"`
+}
+
+// MaxSteeringNoteChars limits steering notes to short instructions; anything longer belongs in a new prompt
+const MaxSteeringNoteChars = 500
+
+type SteerPlanRequest struct {
+	Note string `json:"note"`
+}
+
 type LoadContextParams struct {
 	ContextType     ContextType           `json:"contextType"`
 	Name            string                `json:"name"`
@@ -158,6 +192,11 @@ type LoadContextParams struct {
 	Body            string                `json:"body"`
 	ForceSkipIgnore bool                  `json:"forceSkipIgnore"`
 	ImageDetail     openai.ImageURLDetail `json:"imageDetail"`
+	// the file's encoding if it isn't UTF-8. Body is always UTF-8.
+	Encoding string `json:"encoding,omitempty"`
+	// set when the context is attached from an org context bundle
+	BundleName    string `json:"bundleName,omitempty"`
+	BundleVersion int    `json:"bundleVersion,omitempty"`
 
 	// For naming piped data
 	ApiKeys     map[string]string `json:"apiKeys"`
@@ -173,10 +212,16 @@ type LoadContextResponse struct {
 	MaxTokensExceeded bool   `json:"maxTokensExceeded"`
 	MaxTokens         int    `json:"maxTokens"`
 	Msg               string `json:"msg"`
+
+	// TokensEstimated is set when TokensAdded and TotalTokens are estimates for a planner model whose tokenizer isn't available
+	TokensEstimated bool `json:"tokensEstimated,omitempty"`
 }
 
 type UpdateContextParams struct {
 	Body string `json:"body"`
+
+	// if set, Body is empty and the updated body is built by applying the delta to the current body
+	Delta *ContextDelta `json:"delta,omitempty"`
 }
 
 type UpdateContextRequest map[string]*UpdateContextParams
@@ -210,6 +255,24 @@ type RewindPlanResponse struct {
 	LatestCommit string `json:"latestCommit"`
 }
 
+type EditMessageRequest struct {
+	Num     int    `json:"num"`
+	Message string `json:"message"`
+}
+
+type EditMessageResponse struct {
+	NumStaleMessages int `json:"numStaleMessages"`
+	NumStaleResults  int `json:"numStaleResults"`
+}
+
+type RerunPlanResponse struct {
+	// Prompt is empty when the plan should continue from its last message, which is a prompt without a reply
+	Prompt         string `json:"prompt"`
+	IsUserContinue bool   `json:"isUserContinue"`
+	FromMessageNum int    `json:"fromMessageNum"`
+	NumRemaining   int    `json:"numRemaining"`
+}
+
 type LogResponse struct {
 	Shas []string `json:"shas"`
 	Body string   `json:"body"`
@@ -217,6 +280,8 @@ type LogResponse struct {
 
 type CreateBranchRequest struct {
 	Name string `json:"name"`
+	// FromMessageNum roots the new branch at an earlier conversation message instead of the parent branch's latest state
+	FromMessageNum int `json:"fromMessageNum,omitempty"`
 }
 
 type UpdateSettingsRequest struct {
@@ -227,6 +292,17 @@ type UpdateSettingsResponse struct {
 	Msg string `json:"msg"`
 }
 
+// ValidateSettingsRequest checks settings before they're saved. Nothing is stored.
+type ValidateSettingsRequest struct {
+	Settings *PlanSettings `json:"settings"`
+}
+
+type ValidateSettingsResponse struct {
+	// Valid is false if any issue is an error, meaning some model calls would fail with the settings
+	Valid  bool             `json:"valid"`
+	Issues []*SettingsIssue `json:"issues"`
+}
+
 type ListUsersResponse struct {
 	Users            []*User             `json:"users"`
 	OrgUsersByUserId map[string]*OrgUser `json:"orgUsersByUserId"`
@@ -238,6 +314,32 @@ type ApplyPlanRequest struct {
 	OpenAIOrgId string            `json:"openAIOrgId"`
 }
 
+type CommitMsgRequest struct {
+	ApiKeys     map[string]string `json:"apiKeys"`
+	OpenAIBase  string            `json:"openAIBase"`
+	OpenAIOrgId string            `json:"openAIOrgId"`
+}
+
+const NoPendingChangesErr string = "No pending changes"
+
+type CommitMsgResponse struct {
+	// CommitMsg is a conventional commit message, like 'feat(cli): add apply hooks' followed by a body
+	CommitMsg string `json:"commitMsg"`
+	// Changelog is a markdown list of the user-facing changes
+	Changelog string `json:"changelog"`
+}
+
 type RenamePlanRequest struct {
 	Name string `json:"name"`
 }
+
+// AutoRenamePlanRequest asks for the plan to be named again from its conversation
+type AutoRenamePlanRequest struct {
+	ApiKeys     map[string]string `json:"apiKeys"`
+	OpenAIBase  string            `json:"openAIBase"`
+	OpenAIOrgId string            `json:"openAIOrgId"`
+}
+
+type AutoRenamePlanResponse struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,109 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ResponseCacheOperation is a kind of model call whose response only depends on its prompt, so a cached response can stand in for a new call
+type ResponseCacheOperation string
+
+const (
+	ResponseCacheOperationSummaries    ResponseCacheOperation = "summaries"
+	ResponseCacheOperationPlanNames    ResponseCacheOperation = "plan-names"
+	ResponseCacheOperationContextNames ResponseCacheOperation = "context-names"
+)
+
+var ResponseCacheOperations = []ResponseCacheOperation{
+	ResponseCacheOperationSummaries,
+	ResponseCacheOperationPlanNames,
+	ResponseCacheOperationContextNames,
+}
+
+var ResponseCacheOperationDescriptions = map[ResponseCacheOperation]string{
+	ResponseCacheOperationSummaries:    "Conversation summaries",
+	ResponseCacheOperationPlanNames:    "Plan names",
+	ResponseCacheOperationContextNames: "Names for piped data and notes",
+}
+
+// ResponseCacheScope is who can use a cached response
+type ResponseCacheScope string
+
+const (
+	// anyone in the org
+	ResponseCacheScopeOrg ResponseCacheScope = "org"
+	// only the user whose call was cached
+	ResponseCacheScopeUser ResponseCacheScope = "user"
+)
+
+const DefaultResponseCacheTtlHours = 24 * 7
+const MaxResponseCacheTtlHours = 24 * 90
+
+// OrgResponseCachePolicy controls the org's shared cache of model responses. It's off by default. Cached responses are keyed by a hash of the normalized prompt, so prompts themselves aren't stored, and responses are encrypted like other org data when the server encrypts data at rest.
+type OrgResponseCachePolicy struct {
+	Enabled    bool                     `json:"enabled"`
+	Scope      ResponseCacheScope       `json:"scope"`
+	TtlHours   int                      `json:"ttlHours"`
+	Operations []ResponseCacheOperation `json:"operations"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func DefaultOrgResponseCachePolicy() *OrgResponseCachePolicy {
+	return &OrgResponseCachePolicy{
+		Scope:      ResponseCacheScopeOrg,
+		TtlHours:   DefaultResponseCacheTtlHours,
+		Operations: append([]ResponseCacheOperation{}, ResponseCacheOperations...),
+	}
+}
+
+// Caches is true when the policy is enabled for an operation
+func (p *OrgResponseCachePolicy) Caches(op ResponseCacheOperation) bool {
+	if !p.Enabled {
+		return false
+	}
+	for _, o := range p.Operations {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *OrgResponseCachePolicy) Validate() error {
+	if p.Scope != ResponseCacheScopeOrg && p.Scope != ResponseCacheScopeUser {
+		return fmt.Errorf("invalid scope '%s'—must be org or user", p.Scope)
+	}
+
+	if p.TtlHours < 1 || p.TtlHours > MaxResponseCacheTtlHours {
+		return fmt.Errorf("ttl must be between 1 and %d hours", MaxResponseCacheTtlHours)
+	}
+
+	for _, op := range p.Operations {
+		if _, ok := ResponseCacheOperationDescriptions[op]; !ok {
+			var ops []string
+			for _, o := range ResponseCacheOperations {
+				ops = append(ops, string(o))
+			}
+			return fmt.Errorf("invalid operation '%s'—must be one of %s", op, strings.Join(ops, ", "))
+		}
+	}
+
+	return nil
+}
+
+type UpdateOrgResponseCachePolicyRequest struct {
+	Policy *OrgResponseCachePolicy `json:"policy"`
+}
+
+// ResponseCacheStats describes the org's cached responses that haven't expired
+type ResponseCacheStats struct {
+	NumEntries int `json:"numEntries"`
+	NumHits    int `json:"numHits"`
+}
+
+type GetOrgResponseCacheResponse struct {
+	Policy *OrgResponseCachePolicy `json:"policy"`
+	Stats  *ResponseCacheStats     `json:"stats"`
+}
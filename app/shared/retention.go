@@ -0,0 +1,51 @@
+package shared
+
+import (
+	"fmt"
+	"time"
+)
+
+const DefaultRetentionNotifyDaysBeforeDelete = 7
+
+// OrgRetentionPolicy controls automatic archival and deletion of an org's plans. A value of 0 for ArchiveAfterDays or DeleteArchivedAfterDays disables that step.
+type OrgRetentionPolicy struct {
+	// archive plans that haven't been touched for this many days
+	ArchiveAfterDays int `json:"archiveAfterDays"`
+	// permanently delete plans that have been archived for this many days
+	DeleteArchivedAfterDays int `json:"deleteArchivedAfterDays"`
+	// plan owners are notified this many days before their archived plans are deleted
+	NotifyDaysBeforeDelete int `json:"notifyDaysBeforeDelete"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func DefaultOrgRetentionPolicy() *OrgRetentionPolicy {
+	return &OrgRetentionPolicy{
+		NotifyDaysBeforeDelete: DefaultRetentionNotifyDaysBeforeDelete,
+	}
+}
+
+func (p *OrgRetentionPolicy) IsEnabled() bool {
+	return p.ArchiveAfterDays > 0 || p.DeleteArchivedAfterDays > 0
+}
+
+func (p *OrgRetentionPolicy) Validate() error {
+	if p.ArchiveAfterDays < 0 || p.DeleteArchivedAfterDays < 0 || p.NotifyDaysBeforeDelete < 0 {
+		return fmt.Errorf("retention days can't be negative")
+	}
+
+	if p.DeleteArchivedAfterDays > 0 {
+		if p.NotifyDaysBeforeDelete < 1 {
+			return fmt.Errorf("plan owners must be notified at least 1 day before archived plans are deleted")
+		}
+		if p.NotifyDaysBeforeDelete > p.DeleteArchivedAfterDays {
+			return fmt.Errorf("notify-days-before-delete (%d) can't be greater than delete-archived-after-days (%d)", p.NotifyDaysBeforeDelete, p.DeleteArchivedAfterDays)
+		}
+	}
+
+	return nil
+}
+
+type UpdateOrgRetentionPolicyRequest struct {
+	Policy *OrgRetentionPolicy `json:"policy"`
+}
@@ -0,0 +1,36 @@
+package shared
+
+import "time"
+
+type SearchResultKind string
+
+const (
+	SearchResultKindPlanName SearchResultKind = "planName"
+	SearchResultKindPrompt   SearchResultKind = "prompt"
+	SearchResultKindResponse SearchResultKind = "response"
+	SearchResultKindFilePath SearchResultKind = "filePath"
+)
+
+const DefaultSearchLimit = 25
+const MaxSearchLimit = 200
+
+// SearchResult is a match for 'plandex search' in a plan's name, one of its prompts or replies, or a file it changed. MessageNum is set for prompts and replies, and for file paths when the reply that changed the file is known.
+type SearchResult struct {
+	PlanId     string           `json:"planId"`
+	PlanName   string           `json:"planName"`
+	ProjectId  string           `json:"projectId"`
+	Branch     string           `json:"branch"`
+	Kind       SearchResultKind `json:"kind"`
+	MessageNum int              `json:"messageNum,omitempty"`
+	Path       string           `json:"path,omitempty"`
+	Snippet    string           `json:"snippet"`
+	Score      int              `json:"score"`
+	CreatedAt  time.Time        `json:"createdAt"`
+}
+
+// SearchResponse is set Partial when the search stopped before reading every plan it could access, because there were too many plans or too much plan content. Only the most recently updated plans are searched then.
+type SearchResponse struct {
+	Results          []*SearchResult `json:"results"`
+	NumPlansSearched int             `json:"numPlansSearched"`
+	Partial          bool            `json:"partial,omitempty"`
+}
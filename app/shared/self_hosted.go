@@ -0,0 +1,174 @@
+package shared
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var envVarNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// NormalizeModelBaseUrl trims whitespace and trailing slashes, since OpenAI-compatible clients append paths like '/chat/completions' directly to the base url
+func NormalizeModelBaseUrl(baseUrl string) string {
+	return strings.TrimRight(strings.TrimSpace(baseUrl), "/")
+}
+
+// ValidateModelBaseUrl checks that a base url can be used for an OpenAI-compatible api. Self-hosted servers like vLLM and TGI serve the api under '/v1', so that's usually the last part of the url.
+func ValidateModelBaseUrl(baseUrl string) error {
+	if baseUrl == "" {
+		return fmt.Errorf("base url is required")
+	}
+
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return fmt.Errorf("invalid base url: %v", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("base url must start with http:// or https://")
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("base url is missing a host")
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("base url can't have a query string or fragment")
+	}
+
+	return nil
+}
+
+// Validate checks a custom model's config before it's added
+func (m *AvailableModel) Validate() error {
+	if m.Provider == "" {
+		return fmt.Errorf("provider is required")
+	}
+
+	if m.Provider == ModelProviderCustom && (m.CustomProvider == nil || strings.TrimSpace(*m.CustomProvider) == "") {
+		return fmt.Errorf("custom provider name is required")
+	}
+
+	if strings.TrimSpace(m.ModelName) == "" {
+		return fmt.Errorf("model name is required")
+	}
+
+	if strings.ContainsAny(m.ModelName, " \t\n") {
+		return fmt.Errorf("model name can't contain whitespace")
+	}
+
+	err := ValidateModelBaseUrl(m.BaseUrl)
+	if err != nil {
+		return err
+	}
+
+	if !envVarNameRegex.MatchString(m.ApiKeyEnvVar) {
+		return fmt.Errorf("api key environment variable must be a valid variable name")
+	}
+
+	if !m.IsOpenAICompatible {
+		return fmt.Errorf("only OpenAI-compatible models are supported")
+	}
+
+	if m.MaxTokens <= 0 {
+		return fmt.Errorf("max tokens must be greater than 0")
+	}
+
+	if m.DefaultMaxConvoTokens <= 0 || m.DefaultReservedOutputTokens <= 0 {
+		return fmt.Errorf("default max convo tokens and default reserved output tokens must be greater than 0")
+	}
+
+	if m.DefaultMaxConvoTokens+m.DefaultReservedOutputTokens > m.MaxTokens {
+		return fmt.Errorf("default max convo tokens plus default reserved output tokens can't be more than max tokens (%d)", m.MaxTokens)
+	}
+
+	if m.HasStreamingFunctionCalls && (!m.HasStreaming || !m.HasFunctionCalling) {
+		return fmt.Errorf("streaming function calls require both streaming and function calling")
+	}
+
+	return nil
+}
+
+// ModelProbeCheck is one capability that's checked when probing a model endpoint
+type ModelProbeCheck string
+
+const (
+	ModelProbeCheckModels                 ModelProbeCheck = "models"
+	ModelProbeCheckChat                   ModelProbeCheck = "chat"
+	ModelProbeCheckStreaming              ModelProbeCheck = "streaming"
+	ModelProbeCheckJsonMode               ModelProbeCheck = "json-mode"
+	ModelProbeCheckFunctionCalling        ModelProbeCheck = "function-calling"
+	ModelProbeCheckStreamingFunctionCalls ModelProbeCheck = "streaming-function-calls"
+	ModelProbeCheckStructuredOutputs      ModelProbeCheck = "structured-outputs"
+)
+
+var ModelProbeChecks = []ModelProbeCheck{
+	ModelProbeCheckModels,
+	ModelProbeCheckChat,
+	ModelProbeCheckStreaming,
+	ModelProbeCheckJsonMode,
+	ModelProbeCheckFunctionCalling,
+	ModelProbeCheckStreamingFunctionCalls,
+	ModelProbeCheckStructuredOutputs,
+}
+
+var ModelProbeCheckDescriptions = map[ModelProbeCheck]string{
+	ModelProbeCheckModels:                 "Model listing",
+	ModelProbeCheckChat:                   "Chat completions",
+	ModelProbeCheckStreaming:              "Streaming",
+	ModelProbeCheckJsonMode:               "JSON mode",
+	ModelProbeCheckFunctionCalling:        "Function calling",
+	ModelProbeCheckStreamingFunctionCalls: "Streaming function calls",
+	ModelProbeCheckStructuredOutputs:      "Structured outputs",
+}
+
+// ProbeModelEndpointRequest asks the server to check an OpenAI-compatible endpoint, like a self-hosted vLLM or TGI server, before it's added as a custom model. The api key is only used for the probe and isn't stored.
+type ProbeModelEndpointRequest struct {
+	BaseUrl   string `json:"baseUrl"`
+	ModelName string `json:"modelName"`
+	ApiKey    string `json:"apiKey,omitempty"`
+}
+
+func (req *ProbeModelEndpointRequest) Validate() error {
+	if strings.TrimSpace(req.ModelName) == "" {
+		return fmt.Errorf("model name is required")
+	}
+	return ValidateModelBaseUrl(req.BaseUrl)
+}
+
+type ProbeModelEndpointResponse struct {
+	Reachable bool  `json:"reachable"`
+	LatencyMs int64 `json:"latencyMs"`
+
+	// models listed by the endpoint's '/models' route, and whether the requested model is one of them
+	ServedModels []string `json:"servedModels"`
+	ModelFound   bool     `json:"modelFound"`
+
+	// capabilities that worked, for the fields of the same name when adding the model. IsOpenAICompatible is set if a basic chat completion worked.
+	Compatibility ModelCompatibility `json:"compatibility"`
+
+	// why each failed check failed
+	Errors map[ModelProbeCheck]string `json:"errors,omitempty"`
+}
+
+// Passed returns whether a check succeeded
+func (res *ProbeModelEndpointResponse) Passed(check ModelProbeCheck) bool {
+	switch check {
+	case ModelProbeCheckModels:
+		return res.ModelFound
+	case ModelProbeCheckChat:
+		return res.Compatibility.IsOpenAICompatible
+	case ModelProbeCheckStreaming:
+		return res.Compatibility.HasStreaming
+	case ModelProbeCheckJsonMode:
+		return res.Compatibility.HasJsonResponseMode
+	case ModelProbeCheckFunctionCalling:
+		return res.Compatibility.HasFunctionCalling
+	case ModelProbeCheckStreamingFunctionCalls:
+		return res.Compatibility.HasStreamingFunctionCalls
+	case ModelProbeCheckStructuredOutputs:
+		return res.Compatibility.HasStructuredOutputs
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package shared
+
+import "fmt"
+
+type SettingsIssueSeverity string
+
+const (
+	// the settings would make model calls fail, so they can't be saved
+	SettingsIssueError SettingsIssueSeverity = "error"
+	// the settings work, but likely not as intended
+	SettingsIssueWarning SettingsIssueSeverity = "warning"
+)
+
+type SettingsIssueKind string
+
+const (
+	SettingsIssueUnknownModel    SettingsIssueKind = "unknown-model"
+	SettingsIssueInvalid         SettingsIssueKind = "invalid"
+	SettingsIssueContextWindow   SettingsIssueKind = "context-window"
+	SettingsIssueStreaming       SettingsIssueKind = "streaming"
+	SettingsIssueFunctionCalling SettingsIssueKind = "function-calling"
+	SettingsIssueJsonMode        SettingsIssueKind = "json-mode"
+	SettingsIssuePricing         SettingsIssueKind = "pricing"
+)
+
+// SettingsIssue is a problem found when checking proposed settings against what's known about each role's model. Fix says what to change, when there's a clear fix.
+type SettingsIssue struct {
+	Role     ModelRole             `json:"role,omitempty"`
+	Kind     SettingsIssueKind     `json:"kind"`
+	Severity SettingsIssueSeverity `json:"severity"`
+	Msg      string                `json:"msg"`
+	Fix      string                `json:"fix,omitempty"`
+}
+
+func (i *SettingsIssue) String() string {
+	s := i.Msg
+	if i.Role != "" {
+		s = fmt.Sprintf("%s: %s", i.Role, s)
+	}
+	if i.Fix != "" {
+		s += " → " + i.Fix
+	}
+	return s
+}
+
+func SettingsIssuesHaveErrors(issues []*SettingsIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SettingsIssueError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package shared
+
+import "time"
+
+const DefaultStatsDays = 30
+const MaxStatsDays = 365
+
+// BuildDayStats counts the builds started on a day (YYYY-MM-DD, UTC)
+type BuildDayStats struct {
+	Day       string `json:"day"`
+	NumBuilds int    `json:"numBuilds"`
+	NumFailed int    `json:"numFailed"`
+}
+
+type ModelUsageStats struct {
+	Role         ModelRole     `json:"role"`
+	Provider     ModelProvider `json:"provider"`
+	ModelName    string        `json:"modelName"`
+	NumCalls     int           `json:"numCalls"`
+	InputTokens  int           `json:"inputTokens"`
+	OutputTokens int           `json:"outputTokens"`
+	// ReasoningTokens are the output tokens reasoning models spend thinking before they reply. They're included in OutputTokens.
+	ReasoningTokens int     `json:"reasoningTokens"`
+	Cost            float64 `json:"cost"`
+}
+
+type UserUsageStats struct {
+	UserId          string  `json:"userId"`
+	UserName        string  `json:"userName"`
+	UserEmail       string  `json:"userEmail"`
+	NumCalls        int     `json:"numCalls"`
+	InputTokens     int     `json:"inputTokens"`
+	OutputTokens    int     `json:"outputTokens"`
+	ReasoningTokens int     `json:"reasoningTokens"`
+	Cost            float64 `json:"cost"`
+}
+
+// OrgStatsResponse is the activity and model spend for 'plandex stats' over a window of days. Costs are in USD, at the prices when each call was made, and don't include calls to models without a known price. Users who can't manage the org's billing only get their own stats, in which case IsOrgWide is false. FailureRate and AvgBuildDurationMs only count finished builds.
+type OrgStatsResponse struct {
+	Since              time.Time          `json:"since"`
+	Until              time.Time          `json:"until"`
+	IsOrgWide          bool               `json:"isOrgWide"`
+	NumBuilds          int                `json:"numBuilds"`
+	NumFinishedBuilds  int                `json:"numFinishedBuilds"`
+	NumFailedBuilds    int                `json:"numFailedBuilds"`
+	FailureRate        float64            `json:"failureRate"`
+	AvgBuildDurationMs int64              `json:"avgBuildDurationMs"`
+	BuildsPerDay       []*BuildDayStats   `json:"buildsPerDay"`
+	UsageByModel       []*ModelUsageStats `json:"usageByModel"`
+	UsageByUser        []*UserUsageStats  `json:"usageByUser"`
+}
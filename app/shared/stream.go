@@ -6,6 +6,20 @@ type BuildInfo struct {
 	Path      string `json:"path"`
 	NumTokens int    `json:"numTokens"`
 	Finished  bool   `json:"finished"`
+
+	// Error is set when the file failed to build and the plan's build error policy kept the rest of the build going
+	Error string `json:"error,omitempty"`
+
+	// the rate the builder's response is streaming at and an estimate of the seconds left, both 0 until there's enough of the response to estimate from
+	TokensPerSecond float64 `json:"tokensPerSecond,omitempty"`
+	EtaSeconds      int     `json:"etaSeconds,omitempty"`
+}
+
+// BuildPreview is the updated file with the changes that have finished streaming so far applied, sent each time another change is complete
+type BuildPreview struct {
+	Path       string `json:"path"`
+	Content    string `json:"content"`
+	NumChanges int    `json:"numChanges"`
 }
 
 type StreamMessageType string
@@ -17,7 +31,13 @@ const (
 	StreamMessageDescribing        StreamMessageType = "describing"
 	StreamMessageRepliesFinished   StreamMessageType = "repliesFinished"
 	StreamMessageBuildInfo         StreamMessageType = "buildInfo"
+	StreamMessageBuildPreview      StreamMessageType = "buildPreview"
 	StreamMessagePromptMissingFile StreamMessageType = "promptMissingFile"
+	StreamMessagePromptContinue    StreamMessageType = "promptContinue"
+	StreamMessageBuildPaused       StreamMessageType = "buildPaused"
+	StreamMessageBuildResumed      StreamMessageType = "buildResumed"
+	StreamMessageBuildEstimate     StreamMessageType = "buildEstimate"
+	StreamMessageReasoning         StreamMessageType = "reasoning"
 	StreamMessageAborted           StreamMessageType = "aborted"
 	StreamMessageFinished          StreamMessageType = "finished"
 	StreamMessageError             StreamMessageType = "error"
@@ -31,14 +51,29 @@ type StreamMessage struct {
 	ReplyChunk string `json:"replyChunk,omitempty"`
 
 	BuildInfo       *BuildInfo               `json:"buildInfo,omitempty"`
+	BuildPreview    *BuildPreview            `json:"buildPreview,omitempty"`
+	BuildEstimate   *BuildCostEstimate       `json:"buildEstimate,omitempty"`
 	Description     *ConvoMessageDescription `json:"description,omitempty"`
 	Error           *ApiError                `json:"error,omitempty"`
 	MissingFilePath string                   `json:"missingFilePath,omitempty"`
 	ModelStreamId   string                   `json:"modelStreamId,omitempty"`
 
-	InitPrompt    string   `json:"initPrompt,omitempty"`
-	InitReplies   []string `json:"initReplies,omitempty"`
-	InitBuildOnly bool     `json:"initBuildOnly,omitempty"`
+	// MissingFileAutoChoice is set on promptMissingFile messages when the trust policy decides what happens to the file, so the user isn't asked: 'load' when loading it into context is allowed, or 'skip' when it isn't, or when it's a new file the policy doesn't allow creating
+	MissingFileAutoChoice RespondMissingFileChoice `json:"missingFileAutoChoice,omitempty"`
+	// MissingFileOutsideWorkspaces is set when the file is skipped because it's outside the plan's workspaces rather than because of the trust policy
+	MissingFileOutsideWorkspaces bool `json:"missingFileOutsideWorkspaces,omitempty"`
+
+	// PromptingContinue is set on promptContinue messages, and on connectActive messages while the plan is waiting for the user to confirm its next step. ContinueNextTask is the task the planner would continue with, if it's known.
+	PromptingContinue bool   `json:"promptingContinue,omitempty"`
+	ContinueNextTask  string `json:"continueNextTask,omitempty"`
+
+	// ReasoningSeconds is set on reasoning messages, which are sent every few seconds while waiting on a model that can't stream its reply, with how long it's been thinking
+	ReasoningSeconds int `json:"reasoningSeconds,omitempty"`
+
+	InitPrompt      string   `json:"initPrompt,omitempty"`
+	InitReplies     []string `json:"initReplies,omitempty"`
+	InitBuildOnly   bool     `json:"initBuildOnly,omitempty"`
+	InitBuildPaused bool     `json:"initBuildPaused,omitempty"`
 
 	StreamMessages []StreamMessage `json:"streamMessages,omitempty"`
 }
@@ -13,6 +13,9 @@ type StreamedChangeSection struct {
 	StartLineString string `json:"startLineString"`
 	EndLineString   string `json:"endLineString"`
 	EntireFile      bool   `json:"entireFile"`
+	// the lines just before and after the replaced section, which the builder includes to anchor the change
+	ContextBeforeString string `json:"contextBeforeString,omitempty"`
+	ContextAfterString  string `json:"contextAfterString,omitempty"`
 }
 
 type StreamedChangeWithLineNums struct {
@@ -84,6 +87,48 @@ func (streamedChange StreamedChangeWithLineNums) GetLines() (int, int, error) {
 	return startLine, endLine, nil
 }
 
+// AnchorMismatches compares the lines a change says it replaces, along with the context lines around them, to the lines of the file the change was built against. It describes each line that doesn't match, so a change whose line numbers and text disagree isn't applied in the wrong place.
+func (streamedChange StreamedChangeWithLineNums) AnchorMismatches(lines []string) []string {
+	if !streamedChange.HasChange || streamedChange.Old.EntireFile {
+		return nil
+	}
+
+	startLine, endLine, err := streamedChange.GetLines()
+	if err != nil || endLine > len(lines) {
+		// invalid line numbers are reported when the change is applied
+		return nil
+	}
+
+	var mismatches []string
+
+	check := func(key, lineString string, line int) {
+		if lineString == "" || line < 1 || line > len(lines) {
+			return
+		}
+
+		if n, err := extractLineNumber(lineString); err == nil && n != line {
+			mismatches = append(mismatches, fmt.Sprintf("'%s' is %q, but it should be line pdx-%d: %q", key, lineString, line, lines[line-1]))
+			return
+		}
+
+		if normalizeAnchorLine(RemoveLineNums(lineString)) != normalizeAnchorLine(lines[line-1]) {
+			mismatches = append(mismatches, fmt.Sprintf("'%s' is %q, but line pdx-%d is %q", key, lineString, line, lines[line-1]))
+		}
+	}
+
+	check("startLineString", streamedChange.Old.StartLineString, startLine)
+	check("endLineString", streamedChange.Old.EndLineString, endLine)
+	check("contextBeforeString", streamedChange.Old.ContextBeforeString, startLine-1)
+	check("contextAfterString", streamedChange.Old.ContextAfterString, endLine+1)
+
+	return mismatches
+}
+
+// normalizeAnchorLine collapses whitespace, since models often drop trailing spaces or change indentation when they copy a line
+func normalizeAnchorLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
 func extractLineNumber(line string) (int, error) {
 	// Split the line at the first space to isolate the line number
 	parts := strings.SplitN(line, " ", 2)
@@ -0,0 +1,31 @@
+package shared
+
+// SymbolRename is a rename of a symbol across the files in context, which the planner declares instead of writing out every file that references the symbol. Paths are the files in context that referenced it when the reply was written.
+type SymbolRename struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Paths []string `json:"paths"`
+}
+
+// RenamePaths returns the files the description's symbol renames are built into
+func (desc *ConvoMessageDescription) RenamePaths() []string {
+	var res []string
+	seen := map[string]bool{}
+	for _, rename := range desc.Renames {
+		for _, path := range rename.Paths {
+			if !seen[path] {
+				seen[path] = true
+				res = append(res, path)
+			}
+		}
+	}
+	return res
+}
+
+// BuildPaths returns every file the description builds: the files in its reply, then the files its symbol renames are built into, then the files it removes
+func (desc *ConvoMessageDescription) BuildPaths() []string {
+	res := make([]string, 0, len(desc.Files))
+	res = append(res, desc.Files...)
+	res = append(res, desc.RenamePaths()...)
+	return append(res, desc.RemovedFiles...)
+}
@@ -0,0 +1,151 @@
+package shared
+
+import (
+	"path"
+	"strings"
+)
+
+var jsTestExts = map[string]bool{
+	".js":  true,
+	".jsx": true,
+	".ts":  true,
+	".tsx": true,
+	".mjs": true,
+	".cjs": true,
+}
+
+var testDirs = map[string]bool{
+	"test":      true,
+	"tests":     true,
+	"spec":      true,
+	"__tests__": true,
+	"testdata":  true,
+}
+
+// IsTestPath is whether a path follows a test file convention for its language
+func IsTestPath(p string) bool {
+	p = path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	base := path.Base(p)
+	ext := path.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	// files in test directories are test code even when they're helpers or fixtures
+	for _, dir := range strings.Split(path.Dir(p), "/") {
+		if testDirs[dir] {
+			return true
+		}
+	}
+
+	switch {
+	case ext == ".go":
+		return strings.HasSuffix(name, "_test")
+	case jsTestExts[ext]:
+		return strings.HasSuffix(name, ".test") || strings.HasSuffix(name, ".spec")
+	case ext == ".py":
+		return strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test")
+	case ext == ".rb":
+		return strings.HasSuffix(name, "_spec") || strings.HasSuffix(name, "_test")
+	case ext == ".java" || ext == ".kt" || ext == ".php" || ext == ".cs":
+		return strings.HasSuffix(name, "Test") || strings.HasSuffix(name, "Tests")
+	case ext == ".exs":
+		return strings.HasSuffix(name, "_test")
+	}
+
+	return false
+}
+
+// TestPathCandidates returns the paths a file's tests could be at under its language's conventions, with the most common one first. It's empty for tests themselves and for files without a convention, like config files or languages with inline tests.
+func TestPathCandidates(p string) []string {
+	p = path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	if IsTestPath(p) {
+		return nil
+	}
+
+	dir := path.Dir(p)
+	base := path.Base(p)
+	ext := path.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	join := func(elem ...string) string {
+		return path.Join(elem...)
+	}
+
+	switch {
+	case ext == ".go":
+		return []string{join(dir, name+"_test.go")}
+
+	case jsTestExts[ext]:
+		if strings.HasSuffix(name, ".d") {
+			return nil
+		}
+		return []string{
+			join(dir, name+".test"+ext),
+			join(dir, name+".spec"+ext),
+			join(dir, "__tests__", name+".test"+ext),
+		}
+
+	case ext == ".py":
+		if name == "__init__" || name == "conftest" || name == "setup" {
+			return nil
+		}
+		return []string{
+			join(dir, "test_"+name+".py"),
+			join("tests", "test_"+name+".py"),
+			join(dir, name+"_test.py"),
+		}
+
+	case ext == ".rb":
+		// rspec mirrors lib/ and app/ under spec/
+		rel := p
+		if strings.HasPrefix(rel, "lib/") {
+			rel = strings.TrimPrefix(rel, "lib/")
+		} else if strings.HasPrefix(rel, "app/") {
+			rel = strings.TrimPrefix(rel, "app/")
+		}
+		relDir := path.Dir(rel)
+		return []string{
+			join("spec", relDir, name+"_spec.rb"),
+			join("test", relDir, name+"_test.rb"),
+		}
+
+	case ext == ".java" || ext == ".kt":
+		// maven and gradle mirror src/main under src/test
+		if idx := strings.Index(p, "src/main/"); idx >= 0 {
+			testDir := p[:idx] + "src/test/" + path.Dir(p[idx+len("src/main/"):])
+			return []string{join(testDir, name+"Test"+ext)}
+		}
+		return []string{join(dir, name+"Test"+ext)}
+
+	case ext == ".php":
+		rel := strings.TrimPrefix(p, "src/")
+		return []string{join("tests", path.Dir(rel), name+"Test.php")}
+
+	case ext == ".ex":
+		rel := strings.TrimPrefix(p, "lib/")
+		return []string{join("test", path.Dir(rel), name+"_test.exs")}
+	}
+
+	return nil
+}
+
+// PendingTestScaffoldsByPath maps the path of each pending test file written by test scaffolding to the file it tests, from the latest pending result for the path
+func (state *CurrentPlanState) PendingTestScaffoldsByPath() map[string]string {
+	res := map[string]string{}
+	if state.PlanResult == nil {
+		return res
+	}
+
+	for path, results := range state.PlanResult.FileResultsByPath {
+		var latest *PlanFileResult
+		for _, result := range results {
+			if result.IsPending() {
+				latest = result
+			}
+		}
+		if latest != nil && latest.TestSourcePath != "" {
+			res[path] = latest.TestSourcePath
+		}
+	}
+
+	return res
+}
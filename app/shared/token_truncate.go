@@ -0,0 +1,152 @@
+package shared
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// ToBaseTokens converts a count for this tokenizer to the most cl100k tokens that fit in it, the inverse of FromBaseTokens
+func (t Tokenizer) ToBaseTokens(numTokens int) int {
+	ratio, ok := tokenizerRatios[t]
+	if !ok || ratio == 1 {
+		return numTokens
+	}
+	baseTokens := int(math.Floor(float64(numTokens) / ratio))
+	// floating point error can put the result one token over
+	for baseTokens > 0 && t.FromBaseTokens(baseTokens) > numTokens {
+		baseTokens--
+	}
+	return baseTokens
+}
+
+// TruncateKeep is which part of a text is kept when it's truncated to fit a token limit
+type TruncateKeep string
+
+const (
+	// keep the start, for documents and requests, where what comes first usually matters most
+	TruncateKeepStart TruncateKeep = "start"
+	// keep the end, for command output and logs, where the latest lines usually matter most
+	TruncateKeepEnd TruncateKeep = "end"
+)
+
+type TruncateResult struct {
+	Text string
+	// cl100k count of Text, like the counts stored with contexts and conversation messages
+	NumTokens       int
+	Truncated       bool
+	NumLinesRemoved int
+}
+
+// TruncateToTokens trims text so it's at most maxTokens for the tokenizer, keeping its start or its end. It cuts at a line boundary unless a single line is over the limit. The CLI and server both truncate with it, so they always agree on what fits.
+func TruncateToTokens(text string, maxTokens int, tokenizer Tokenizer, keep TruncateKeep) (TruncateResult, error) {
+	tkm, err := getEncoding(TokenizerCl100k)
+	if err != nil {
+		return TruncateResult{}, fmt.Errorf("error getting encoding for model: %v", err)
+	}
+
+	tokens := tkm.Encode(text, nil, nil)
+	budget := tokenizer.ToBaseTokens(maxTokens)
+
+	if len(tokens) <= budget {
+		return TruncateResult{Text: text, NumTokens: len(tokens)}, nil
+	}
+
+	for budget > 0 {
+		var kept string
+		if keep == TruncateKeepEnd {
+			kept = trimInvalidStart(tkm.Decode(tokens[len(tokens)-budget:]))
+			if idx := strings.Index(kept, "\n"); idx >= 0 && idx < len(kept)-1 {
+				kept = kept[idx+1:]
+			}
+		} else {
+			kept = trimInvalidEnd(tkm.Decode(tokens[:budget]))
+			if idx := strings.LastIndex(kept, "\n"); idx > 0 {
+				kept = kept[:idx+1]
+			}
+		}
+
+		// text cut at a different point can tokenize differently, so the kept text is counted again
+		numTokens := len(tkm.Encode(kept, nil, nil))
+		if numTokens <= tokenizer.ToBaseTokens(maxTokens) {
+			return TruncateResult{
+				Text:            kept,
+				NumTokens:       numTokens,
+				Truncated:       true,
+				NumLinesRemoved: strings.Count(text, "\n") - strings.Count(kept, "\n"),
+			}, nil
+		}
+
+		budget -= numTokens - tokenizer.ToBaseTokens(maxTokens)
+	}
+
+	return TruncateResult{
+		Truncated:       true,
+		NumLinesRemoved: strings.Count(text, "\n"),
+	}, nil
+}
+
+// SplitToTokens splits text into consecutive parts that are each at most maxTokens for the tokenizer, cutting at line boundaries unless a single line is over the limit
+func SplitToTokens(text string, maxTokens int, tokenizer Tokenizer) ([]string, error) {
+	if tokenizer.ToBaseTokens(maxTokens) <= 0 {
+		return nil, fmt.Errorf("max tokens must be greater than 0")
+	}
+
+	var parts []string
+	rest := text
+
+	for {
+		res, err := TruncateToTokens(rest, maxTokens, tokenizer, TruncateKeepStart)
+		if err != nil {
+			return nil, err
+		}
+
+		if !res.Truncated {
+			parts = append(parts, rest)
+			return parts, nil
+		}
+
+		if res.Text == "" {
+			return nil, fmt.Errorf("couldn't split text into parts of %d tokens", maxTokens)
+		}
+
+		parts = append(parts, res.Text)
+		rest = rest[len(res.Text):]
+	}
+}
+
+// NumLatestThatFit returns how many of the latest items fit in maxTokens for the tokenizer, given each item's cl100k count in order from oldest to newest. It's used to keep the latest messages of a conversation that's too long.
+func NumLatestThatFit(numTokens []int, maxTokens int, tokenizer Tokenizer) int {
+	total := 0
+	for i := len(numTokens) - 1; i >= 0; i-- {
+		total += numTokens[i]
+		if tokenizer.FromBaseTokens(total) > maxTokens {
+			return len(numTokens) - 1 - i
+		}
+	}
+	return len(numTokens)
+}
+
+// a cut between tokens can split a multi-byte rune, so the partial rune at the cut is dropped. The rest of the text is left as is, so the kept text is still a prefix or suffix of the original.
+func trimInvalidEnd(s string) string {
+	for i := 0; i < utf8.UTFMax && len(s) > 0; i++ {
+		r, size := utf8.DecodeLastRuneInString(s)
+		if r != utf8.RuneError || size > 1 {
+			break
+		}
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+func trimInvalidStart(s string) string {
+	for i := 0; i < utf8.UTFMax && len(s) > 0; i++ {
+		r, size := utf8.DecodeRuneInString(s)
+		if r != utf8.RuneError || size > 1 {
+			break
+		}
+		s = s[1:]
+	}
+	return s
+}
@@ -2,12 +2,144 @@ package shared
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/pkoukk/tiktoken-go"
 )
 
+// Tokenizer identifies the tokenizer a model counts tokens with
+type Tokenizer string
+
+const (
+	// TokenizerCl100k is used by GPT-4 and GPT-3.5. Token counts stored with contexts and conversation messages are cl100k counts. Llama 3's vocabulary extends cl100k, so its counts are close enough to use it too.
+	TokenizerCl100k Tokenizer = "cl100k"
+	// TokenizerO200k is used by GPT-4o. Contexts also store an o200k count, so GPT-4o's context counts are exact.
+	TokenizerO200k Tokenizer = "o200k"
+	// TokenizerClaude is used by Anthropic's Claude 3 models
+	TokenizerClaude Tokenizer = "claude"
+	// TokenizerLlama is the SentencePiece tokenizer used by Llama 2, Code Llama, Mistral, and Mixtral
+	TokenizerLlama Tokenizer = "llama"
+)
+
+// tiktoken encodings for the tokenizers that are counted exactly
+var tokenizerEncodings = map[Tokenizer]string{
+	TokenizerCl100k: tiktoken.MODEL_CL100K_BASE,
+	TokenizerO200k:  tiktoken.MODEL_O200K_BASE,
+}
+
+// tokenizerRatios convert cl100k counts to approximate counts. They're rough averages rather than measurements for any particular text. Claude and Llama 2 vocabularies aren't available, so their counts are always estimates, and are shown with a ~ wherever they're displayed. o200k's ratio is only used for stored counts that don't have an o200k count, like conversation messages. Ratios are on the high side for typical source code and prose, so token limits are reached a little early rather than exceeded.
+var tokenizerRatios = map[Tokenizer]float64{
+	TokenizerCl100k: 1,
+	TokenizerO200k:  0.97,
+	TokenizerClaude: 1.15,
+	TokenizerLlama:  1.25,
+}
+
+var TokenizerLabels = map[Tokenizer]string{
+	TokenizerCl100k: "OpenAI cl100k",
+	TokenizerO200k:  "OpenAI o200k",
+	TokenizerClaude: "Claude",
+	TokenizerLlama:  "Llama",
+}
+
+// IsEstimate is true for tokenizers that can't count text exactly, so their counts are always converted from cl100k counts
+func (t Tokenizer) IsEstimate() bool {
+	_, ok := tokenizerEncodings[t]
+	return !ok
+}
+
+// CountTokens counts the text's tokens for this tokenizer: exactly for cl100k and o200k, and converted from the cl100k count for the others
+func (t Tokenizer) CountTokens(text string) (int, error) {
+	if t.IsEstimate() {
+		numTokens, err := GetNumTokens(text)
+		if err != nil {
+			return 0, err
+		}
+		return t.FromBaseTokens(numTokens), nil
+	}
+
+	tkm, err := getEncoding(t)
+	if err != nil {
+		return 0, fmt.Errorf("error getting %s encoding: %v", t, err)
+	}
+	return len(tkm.Encode(text, nil, nil)), nil
+}
+
+// FormatTokens formats a token count, marking it with a ~ if it's an estimate
+func FormatTokens(numTokens int, estimated bool) string {
+	if estimated {
+		return fmt.Sprintf("~%d", numTokens)
+	}
+	return strconv.Itoa(numTokens)
+}
+
+// FromBaseTokens converts a cl100k count, like the counts stored with contexts and conversation messages, to this tokenizer
+func (t Tokenizer) FromBaseTokens(numTokens int) int {
+	ratio, ok := tokenizerRatios[t]
+	if !ok || ratio == 1 {
+		return numTokens
+	}
+	return int(math.Ceil(float64(numTokens) * ratio))
+}
+
+// GetNumTokensFor returns the context's token count for a tokenizer, and whether it's exact rather than converted from the cl100k count. Image counts don't depend on the tokenizer.
+func (c *Context) GetNumTokensFor(t Tokenizer) (int, bool) {
+	switch {
+	case c.ContextType == ContextImageType || t == TokenizerCl100k || c.NumTokens == 0:
+		return c.NumTokens, true
+	case t == TokenizerO200k && c.NumTokensO200k > 0:
+		return c.NumTokensO200k, true
+	}
+	return t.FromBaseTokens(c.NumTokens), false
+}
+
+// GetTokenizer returns the tokenizer for a model, based on its name since the same model can be served by different providers. Unrecognized models use cl100k.
+func GetTokenizer(modelName string) Tokenizer {
+	name := strings.ToLower(modelName)
+
+	switch {
+	case strings.Contains(name, "gpt-4o"):
+		return TokenizerO200k
+	case strings.Contains(name, "claude"):
+		return TokenizerClaude
+	case strings.Contains(name, "llama-3"), strings.Contains(name, "llama3"):
+		return TokenizerCl100k
+	case strings.Contains(name, "llama"), strings.Contains(name, "mistral"), strings.Contains(name, "mixtral"):
+		return TokenizerLlama
+	}
+
+	return TokenizerCl100k
+}
+
+func (c BaseModelConfig) GetTokenizer() Tokenizer {
+	return GetTokenizer(c.ModelName)
+}
+
+var encodings = map[Tokenizer]*tiktoken.Tiktoken{}
+var encodingsMu sync.Mutex
+
+// getEncoding loads an encoding once it's first needed and keeps it, since loading it parses the whole vocabulary. A failed load is retried on the next call.
+func getEncoding(t Tokenizer) (*tiktoken.Tiktoken, error) {
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+
+	if encodings[t] == nil {
+		tkm, err := tiktoken.GetEncoding(tokenizerEncodings[t])
+		if err != nil {
+			return nil, err
+		}
+		encodings[t] = tkm
+	}
+
+	return encodings[t], nil
+}
+
+// GetNumTokens returns the text's cl100k token count. This is the count stored with contexts and conversation messages. Use a Tokenizer to count for a specific model.
 func GetNumTokens(text string) (int, error) {
-	tkm, err := tiktoken.EncodingForModel("gpt-4")
+	tkm, err := getEncoding(TokenizerCl100k)
 	if err != nil {
 		err = fmt.Errorf("error getting encoding for model: %v", err)
 		return 0, err
@@ -0,0 +1,56 @@
+package shared
+
+import (
+	"fmt"
+	"time"
+)
+
+const DefaultTrialMaxPlans = 5
+const DefaultTrialMaxTokens = 1000000
+
+// OrgTrialPolicy lets people without accounts try Plandex in an org on a self-hosted server. They start a trial with the org's access code and get a guest user that's limited to MaxPlans plans and MaxTokens model tokens until they sign up.
+type OrgTrialPolicy struct {
+	Enabled   bool `json:"enabled"`
+	MaxPlans  int  `json:"maxPlans"`
+	MaxTokens int  `json:"maxTokens"`
+
+	// whether an access code has been generated—the code itself is only returned when it's generated
+	HasAccessCode bool `json:"hasAccessCode"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func DefaultOrgTrialPolicy() *OrgTrialPolicy {
+	return &OrgTrialPolicy{
+		MaxPlans:  DefaultTrialMaxPlans,
+		MaxTokens: DefaultTrialMaxTokens,
+	}
+}
+
+func (p *OrgTrialPolicy) Validate() error {
+	if p.MaxPlans < 1 {
+		return fmt.Errorf("trial users must be allowed at least 1 plan")
+	}
+
+	if p.MaxTokens < 1 {
+		return fmt.Errorf("trial users must be allowed at least 1 token")
+	}
+
+	return nil
+}
+
+type UpdateOrgTrialPolicyRequest struct {
+	Policy *OrgTrialPolicy `json:"policy"`
+
+	// replace the access code so the old one stops working. A code is always generated the first time trials are enabled.
+	RotateAccessCode bool `json:"rotateAccessCode"`
+}
+
+type UpdateOrgTrialPolicyResponse struct {
+	Policy     *OrgTrialPolicy `json:"policy"`
+	AccessCode string          `json:"accessCode,omitempty"`
+}
+
+type StartGuestTrialRequest struct {
+	AccessCode string `json:"accessCode"`
+}
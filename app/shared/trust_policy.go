@@ -0,0 +1,234 @@
+package shared
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// TrustAction is an action Plandex can take on its own while working on a plan
+type TrustAction string
+
+const (
+	// loading a file the model wants to change into context
+	TrustActionLoadContext TrustAction = "load-context"
+	// creating a new file outside the policy's CreateFilesDirs
+	TrustActionCreateFiles TrustAction = "create-files"
+	// deleting a file from the project
+	TrustActionDeleteFiles TrustAction = "delete-files"
+	// running a command in the project, like an apply hook
+	TrustActionRunCommands TrustAction = "run-commands"
+)
+
+var TrustActions = []TrustAction{
+	TrustActionLoadContext,
+	TrustActionCreateFiles,
+	TrustActionDeleteFiles,
+	TrustActionRunCommands,
+}
+
+var TrustActionDescriptions = map[TrustAction]string{
+	TrustActionLoadContext: "Load a file the plan needs to change into context",
+	TrustActionCreateFiles: "Create files outside the listed dirs",
+	TrustActionDeleteFiles: "Delete files from the project",
+	TrustActionRunCommands: "Run commands, like apply hooks",
+}
+
+// TrustLevel is whether an action may happen without confirmation. Levels are ordered from least to most strict.
+type TrustLevel string
+
+const (
+	TrustLevelAllow   TrustLevel = "allow"
+	TrustLevelConfirm TrustLevel = "confirm"
+	TrustLevelDeny    TrustLevel = "deny"
+)
+
+var trustLevelStrictness = map[TrustLevel]int{
+	TrustLevelAllow:   0,
+	TrustLevelConfirm: 1,
+	TrustLevelDeny:    2,
+}
+
+func (l TrustLevel) Validate() error {
+	if _, ok := trustLevelStrictness[l]; !ok {
+		return fmt.Errorf("invalid trust level '%s'—must be allow, confirm, or deny", l)
+	}
+	return nil
+}
+
+// StricterTrustLevel returns whichever of two levels asks for more confirmation
+func StricterTrustLevel(a, b TrustLevel) TrustLevel {
+	if trustLevelStrictness[b] > trustLevelStrictness[a] {
+		return b
+	}
+	return a
+}
+
+// TrustPolicy declares which actions may happen without confirmation. An org has one policy for all its plans, and each project can have its own. Where both apply, the stricter level wins, so a project policy can only add confirmations.
+type TrustPolicy struct {
+	LoadContext TrustLevel `json:"loadContext"`
+	CreateFiles TrustLevel `json:"createFiles"`
+	DeleteFiles TrustLevel `json:"deleteFiles"`
+	RunCommands TrustLevel `json:"runCommands"`
+
+	// new files in these dirs (relative to the project root) are always allowed
+	CreateFilesDirs []string `json:"createFilesDirs"`
+
+	// builds estimated to cost more than this many USD are paused until they're confirmed. 0 means builds never need confirmation.
+	BuildCostConfirmAbove float64 `json:"buildCostConfirmAbove"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DefaultTrustPolicy matches how Plandex behaves without a policy: loading files into context and deleting files need confirmation, and everything else is allowed
+func DefaultTrustPolicy() *TrustPolicy {
+	return &TrustPolicy{
+		LoadContext: TrustLevelConfirm,
+		CreateFiles: TrustLevelAllow,
+		DeleteFiles: TrustLevelConfirm,
+		RunCommands: TrustLevelAllow,
+	}
+}
+
+func (p *TrustPolicy) Level(action TrustAction) TrustLevel {
+	switch action {
+	case TrustActionLoadContext:
+		return p.LoadContext
+	case TrustActionCreateFiles:
+		return p.CreateFiles
+	case TrustActionDeleteFiles:
+		return p.DeleteFiles
+	case TrustActionRunCommands:
+		return p.RunCommands
+	}
+	return TrustLevelDeny
+}
+
+func (p *TrustPolicy) SetLevel(action TrustAction, level TrustLevel) error {
+	switch action {
+	case TrustActionLoadContext:
+		p.LoadContext = level
+	case TrustActionCreateFiles:
+		p.CreateFiles = level
+	case TrustActionDeleteFiles:
+		p.DeleteFiles = level
+	case TrustActionRunCommands:
+		p.RunCommands = level
+	default:
+		return fmt.Errorf("unknown action '%s'", action)
+	}
+	return nil
+}
+
+// CreateFileLevel is the level for creating a new file at a path relative to the project root
+func (p *TrustPolicy) CreateFileLevel(filePath string) TrustLevel {
+	filePath = path.Clean(strings.ReplaceAll(filePath, "\\", "/"))
+
+	for _, dir := range p.CreateFilesDirs {
+		if dir == "." || filePath == dir || strings.HasPrefix(filePath, dir+"/") {
+			return TrustLevelAllow
+		}
+	}
+
+	return p.CreateFiles
+}
+
+func (p *TrustPolicy) Validate() error {
+	for _, action := range TrustActions {
+		err := p.Level(action).Validate()
+		if err != nil {
+			return fmt.Errorf("%s: %v", action, err)
+		}
+	}
+
+	if p.BuildCostConfirmAbove < 0 {
+		return fmt.Errorf("build cost threshold can't be negative")
+	}
+
+	for _, dir := range p.CreateFilesDirs {
+		if dir == "" || path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+			return fmt.Errorf("invalid dir '%s'—dirs must be relative to the project root", dir)
+		}
+	}
+
+	return nil
+}
+
+// AtLeastAsStrictAs reports whether the policy asks for every confirmation the other one does: no action is at a looser level, new files are only allowed without confirmation in dirs the other policy allows them in, and builds need confirmation at or below the other's cost threshold
+func (p *TrustPolicy) AtLeastAsStrictAs(other *TrustPolicy) bool {
+	for _, action := range TrustActions {
+		if StricterTrustLevel(p.Level(action), other.Level(action)) != p.Level(action) {
+			return false
+		}
+	}
+
+	for _, dir := range p.CreateFilesDirs {
+		if other.CreateFileLevel(dir) != TrustLevelAllow {
+			return false
+		}
+	}
+
+	// 0 means builds never need confirmation
+	if other.BuildCostConfirmAbove > 0 && (p.BuildCostConfirmAbove == 0 || p.BuildCostConfirmAbove > other.BuildCostConfirmAbove) {
+		return false
+	}
+
+	return true
+}
+
+// NormalizeDirs cleans up CreateFilesDirs and removes duplicates
+func (p *TrustPolicy) NormalizeDirs() {
+	seen := map[string]bool{}
+	dirs := []string{}
+
+	for _, dir := range p.CreateFilesDirs {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		dir = path.Clean(strings.ReplaceAll(dir, "\\", "/"))
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+
+	p.CreateFilesDirs = dirs
+}
+
+// EffectiveTrustPolicy combines the org's policy with the project's. Project is nil when the project doesn't have its own policy.
+type EffectiveTrustPolicy struct {
+	Org     *TrustPolicy `json:"org"`
+	Project *TrustPolicy `json:"project,omitempty"`
+}
+
+func (e *EffectiveTrustPolicy) Level(action TrustAction) TrustLevel {
+	level := e.Org.Level(action)
+	if e.Project != nil {
+		level = StricterTrustLevel(level, e.Project.Level(action))
+	}
+	return level
+}
+
+func (e *EffectiveTrustPolicy) CreateFileLevel(filePath string) TrustLevel {
+	level := e.Org.CreateFileLevel(filePath)
+	if e.Project != nil {
+		level = StricterTrustLevel(level, e.Project.CreateFileLevel(filePath))
+	}
+	return level
+}
+
+// BuildCostConfirmAbove is the lower of the org and project build cost thresholds that are set, or 0 if neither is
+func (e *EffectiveTrustPolicy) BuildCostConfirmAbove() float64 {
+	threshold := e.Org.BuildCostConfirmAbove
+	if e.Project != nil && e.Project.BuildCostConfirmAbove > 0 && (threshold == 0 || e.Project.BuildCostConfirmAbove < threshold) {
+		threshold = e.Project.BuildCostConfirmAbove
+	}
+	return threshold
+}
+
+type UpdateTrustPolicyRequest struct {
+	Policy *TrustPolicy `json:"policy"`
+}
@@ -0,0 +1,110 @@
+package shared
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Workspaces scope a plan to one or more directories within a repo (e.g. 'apps/api', 'apps/web' in a monorepo). Roots are stored relative to the project root with forward slashes.
+
+func NormalizeWorkspaceRoot(root string) string {
+	root = strings.ReplaceAll(strings.TrimSpace(root), "\\", "/")
+	root = path.Clean(root)
+	root = strings.TrimPrefix(root, "./")
+	root = strings.TrimSuffix(root, "/")
+	if root == "." || root == "/" {
+		return ""
+	}
+	return root
+}
+
+// ValidateWorkspaceRoot checks that a root stays inside the project: it can't be absolute or start with '..' once normalized
+func ValidateWorkspaceRoot(root string) error {
+	normalized := NormalizeWorkspaceRoot(root)
+	if strings.HasPrefix(normalized, "/") || (len(normalized) > 1 && normalized[1] == ':') {
+		return fmt.Errorf("workspace root %q must be relative to the project root", root)
+	}
+	if normalized == ".." || strings.HasPrefix(normalized, "../") {
+		return fmt.Errorf("workspace root %q is outside the project", root)
+	}
+	return nil
+}
+
+func NormalizeWorkspaceRoots(roots []string) []string {
+	seen := map[string]bool{}
+	res := []string{}
+	for _, root := range roots {
+		root = NormalizeWorkspaceRoot(root)
+		if root == "" || seen[root] {
+			continue
+		}
+		seen[root] = true
+		res = append(res, root)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// PathInWorkspaces returns true if the path (relative to the project root) is inside one of the workspace roots, or if there are no workspace roots. With workspace roots, a path outside the project is never inside them.
+func PathInWorkspaces(p string, roots []string) bool {
+	if len(roots) == 0 {
+		return true
+	}
+	p = NormalizeWorkspaceRoot(p)
+	if ValidateWorkspaceRoot(p) != nil {
+		return false
+	}
+	for _, root := range roots {
+		root = NormalizeWorkspaceRoot(root)
+		if root == "" || p == root || strings.HasPrefix(p, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveWorkspacePath resolves a path output by the model to a path relative to the project root.
+// A path that is already known (in context or in the project) or already inside a workspace root is used as-is. Otherwise, if exactly one workspace root contains a known file at root/path, that's used. If there's only a single workspace root, the path is treated as relative to it.
+func ResolveWorkspacePath(p string, roots []string, isKnown func(string) bool) string {
+	if len(roots) == 0 {
+		return p
+	}
+
+	cleaned := NormalizeWorkspaceRoot(p)
+	if cleaned == "" {
+		return p
+	}
+
+	if isKnown != nil && isKnown(cleaned) {
+		return cleaned
+	}
+
+	for _, root := range roots {
+		root = NormalizeWorkspaceRoot(root)
+		if root != "" && strings.HasPrefix(cleaned, root+"/") {
+			return cleaned
+		}
+	}
+
+	var matches []string
+	if isKnown != nil {
+		for _, root := range roots {
+			candidate := path.Join(NormalizeWorkspaceRoot(root), cleaned)
+			if isKnown(candidate) {
+				matches = append(matches, candidate)
+			}
+		}
+	}
+
+	if len(matches) == 1 {
+		return matches[0]
+	}
+
+	if len(matches) == 0 && len(roots) == 1 {
+		return path.Join(NormalizeWorkspaceRoot(roots[0]), cleaned)
+	}
+
+	return p
+}